@@ -24,7 +24,11 @@ import (
 	"github.com/strongdm/comply/internal/config"
 	"github.com/strongdm/comply/internal/gitlab"
 	"github.com/strongdm/comply/internal/jira"
+	"github.com/strongdm/comply/internal/model"
+	"github.com/strongdm/comply/internal/plugin/azuredevops"
+	"github.com/strongdm/comply/internal/plugin/gitea"
 	"github.com/strongdm/comply/internal/plugin/github"
+	"github.com/strongdm/comply/internal/plugin/local"
 	"github.com/urfave/cli"
 )
 
@@ -52,11 +56,14 @@ func newApp() *cli.App {
 		beforeCommand(initCommand, notifyVersion),
 	}
 
+	app.Commands = append(app.Commands, beforeCommand(auditCommand, projectMustExist, notifyVersion))
 	app.Commands = append(app.Commands, beforeCommand(buildCommand, projectMustExist, notifyVersion))
 	app.Commands = append(app.Commands, beforeCommand(procedureCommand, projectMustExist, notifyVersion))
 	app.Commands = append(app.Commands, beforeCommand(schedulerCommand, projectMustExist, notifyVersion))
 	app.Commands = append(app.Commands, beforeCommand(serveCommand, projectMustExist, notifyVersion))
+	app.Commands = append(app.Commands, beforeCommand(statsCommand, projectMustExist, notifyVersion))
 	app.Commands = append(app.Commands, beforeCommand(syncCommand, projectMustExist, notifyVersion))
+	app.Commands = append(app.Commands, beforeCommand(ticketCommand, projectMustExist, notifyVersion))
 	app.Commands = append(app.Commands, beforeCommand(todoCommand, projectMustExist, notifyVersion))
 	app.Commands = append(app.Commands, beforeCommand(translateCommand, projectMustExist, notifyVersion))
 
@@ -64,6 +71,9 @@ func newApp() *cli.App {
 	github.Register()
 	jira.Register()
 	gitlab.Register()
+	gitea.Register()
+	azuredevops.Register()
+	local.Register()
 
 	return app
 }
@@ -102,9 +112,18 @@ func projectMustExist(c *cli.Context) error {
 
 func ticketingMustBeConfigured(c *cli.Context) error {
 	p := config.Config()
-	if p.Tickets == nil || len(p.Tickets) != 1 {
+	systems, err := p.TicketSystems()
+	if err != nil || len(systems) == 0 {
 		return feedbackError("comply.yml must contain a valid ticketing configuration")
 	}
+	if _, err = p.TicketSystem(); err != nil {
+		return feedbackError(err.Error())
+	}
+	for _, ts := range systems {
+		if err = model.ConfigurationError(model.TicketSystem(ts)); err != nil {
+			return feedbackError(err.Error())
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/strongdm/comply/internal/model"
+	"github.com/strongdm/comply/internal/ticket"
+	"github.com/urfave/cli"
+)
+
+var auditCommand = cli.Command{
+	Name:   "audit",
+	Usage:  "track auditor requests (PBC lists) as tickets",
+	Before: beforeAll(projectMustExist, ticketingMustBeConfigured),
+	Subcommands: []cli.Command{
+		{
+			Name:      "import",
+			Usage:     "open a comply-audit ticket for each request in a CSV request list",
+			ArgsUsage: "requests.csv",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dry-run, n",
+					Usage: "show the tickets that would be created, without creating them",
+				},
+				ticketSystemFlag,
+			},
+			Action: auditImportAction,
+		},
+		{
+			Name:   "status",
+			Usage:  "report progress on auditor requests from the local cache",
+			Action: auditStatusAction,
+		},
+	},
+}
+
+func auditImportAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("provide the CSV request list to import", 1)
+	}
+	f, err := os.Open(c.Args().First())
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	defer f.Close()
+
+	requests, err := model.ReadAuditRequests(f)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("%s: %v", c.Args().First(), err), 1)
+	}
+
+	standards, err := model.ReadStandards()
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, std := range standards {
+		for key := range std.Controls {
+			known[key] = true
+		}
+	}
+	for _, req := range requests {
+		for _, key := range req.Controls {
+			if !known[key] {
+				fmt.Fprintf(os.Stderr, "warning: request %s references control %s, which no standard declares\n", req.ID, key)
+			}
+		}
+	}
+
+	imports, err := ticket.ImportAudit(requests, c.String("system"), c.Bool("dry-run"))
+	created := 0
+	for _, i := range imports {
+		switch {
+		case i.Existing:
+			fmt.Printf("request %s: already tracked in ticket %s\n", i.Request.ID, i.Ticket.ID)
+		case c.Bool("dry-run"):
+			fmt.Printf("request %s: would create %q\n", i.Request.ID, i.Ticket.Name)
+			created++
+		default:
+			fmt.Printf("request %s: created ticket %s\n", i.Request.ID, i.Ticket.ID)
+			created++
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if c.Bool("dry-run") {
+		fmt.Printf("%d of %d requests would be imported\n", created, len(requests))
+	} else {
+		fmt.Printf("imported %d of %d requests\n", created, len(requests))
+	}
+	return nil
+}
+
+func auditStatusAction(c *cli.Context) error {
+	tickets, err := model.ReadTickets()
+	if err != nil {
+		return err
+	}
+	var requests []*model.Ticket
+	for _, t := range tickets {
+		if t.Audit() {
+			requests = append(requests, t)
+		}
+	}
+	if len(requests) == 0 {
+		fmt.Println("No audit requests found; import a request list with `comply audit import`, or run `comply sync`.")
+		return nil
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requestLess(requests[i].AuditRequest(), requests[j].AuditRequest())
+	})
+
+	now := time.Now()
+	closed, overdue := 0, 0
+	w := tablewriter.NewWriter(os.Stdout)
+	w.SetHeader([]string{"Request", "Ticket", "State", "Due", "Controls", "Name"})
+	w.SetAutoWrapText(false)
+	for _, t := range requests {
+		state := string(t.State)
+		switch {
+		case t.State == model.Closed:
+			closed++
+		case t.Overdue(now):
+			overdue++
+			state = color.RedString("OVERDUE")
+		}
+		due := ""
+		if t.DueAt != nil {
+			due = t.DueAt.Format("2006-01-02")
+		}
+		w.Append([]string{t.AuditRequest(), t.Key(), state, due, strings.Join(t.AuditControls(), ", "), t.Name})
+	}
+	w.Render()
+	fmt.Printf("%d of %d requests closed (%d%%), %d overdue\n", closed, len(requests), closed*100/len(requests), overdue)
+	return nil
+}
+
+// requestLess orders request numbers numerically where they are numbers.
+func requestLess(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	if (errA == nil) != (errB == nil) {
+		return errA == nil
+	}
+	return a < b
+}
@@ -10,8 +10,14 @@ var buildCommand = cli.Command{
 	Name:      "build",
 	ShortName: "b",
 	Usage:     "generate a static website summarizing the compliance program",
-	Action:    buildAction,
-	Before:    beforeAll(pandocMustExist, cleanContainers),
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "trust-center",
+			Usage: "also generate the public trust-center site (documents marked `public: true`) in trust-center/",
+		},
+	},
+	Action: buildAction,
+	Before: beforeAll(pandocMustExist, cleanContainers),
 }
 
 func buildAction(c *cli.Context) error {
@@ -19,5 +25,12 @@ func buildAction(c *cli.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "build failed")
 	}
+
+	if c.Bool("trust-center") {
+		err = render.BuildTrustCenter("output", "trust-center")
+		if err != nil {
+			return errors.Wrap(err, "trust center build failed")
+		}
+	}
 	return nil
 }
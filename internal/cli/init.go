@@ -100,7 +100,7 @@ func initAction(c *cli.Context) error {
 
 	chooser = promptui.Select{
 		Label: "Ticket System",
-		Items: []string{"GitHub", "Jira", "GitLab", "None"},
+		Items: []string{"GitHub", "Jira", "GitLab", "Gitea / Forgejo", "Azure DevOps", "Local files (tickets/)", "None"},
 	}
 
 	choice, _, err = chooser.Run()
@@ -119,11 +119,20 @@ func initAction(c *cli.Context) error {
 		ticketing = model.Jira
 	case 2:
 		ticketing = model.GitLab
+	case 3:
+		ticketing = model.Gitea
+	case 4:
+		ticketing = model.AzureDevOps
+	case 5:
+		ticketing = model.Local
 	default:
 		ticketing = model.NoTickets
 	}
 
-	if ticketing != model.NoTickets {
+	if ticketing == model.Local {
+		// nothing to configure; tickets are written to tickets/
+		tickets = map[string]interface{}{string(ticketing): map[string]string{}}
+	} else if ticketing != model.NoTickets {
 		chooser = promptui.Select{
 			Label: "Configure ticketing system?",
 			Items: []string{fmt.Sprintf("Configure %s now", string(ticketing)), "Configure later (via comply.yml)"},
@@ -179,7 +188,7 @@ func initAction(c *cli.Context) error {
 
 	success := fmt.Sprintf("%s Compliance initialized successfully!", name)
 	fmt.Printf("%s %s\n\n", promptui.IconGood, success)
-	fmt.Println(whatNow)
+	fmt.Print(whatNow)
 
 	return nil
 }
@@ -3,8 +3,8 @@ package cli
 import (
 	"fmt"
 
-	"github.com/strongdm/comply/internal/config"
 	"github.com/strongdm/comply/internal/model"
+	"github.com/strongdm/comply/internal/ticket"
 	"github.com/urfave/cli"
 )
 
@@ -29,22 +29,13 @@ func procedureAction(c *cli.Context) error {
 
 	procedureID := c.Args().First()
 
-	ts, err := config.Config().TicketSystem()
-	if err != nil {
-		return cli.NewExitError("error in ticket system configuration", 1)
-	}
-
-	tp := model.GetPlugin(model.TicketSystem(ts))
-
 	for _, procedure := range procedures {
 		if procedure.ID == procedureID {
-			err = tp.Create(&model.Ticket{
-				Name: procedure.Name,
-				Body: fmt.Sprintf("%s\n\n\n---\nProcedure-ID: %s", procedure.Body, procedure.ID),
-			}, []string{"comply", "comply-procedure"})
+			t, tp, err := ticket.Create(procedure)
 			if err != nil {
 				return err
 			}
+			fmt.Printf("created %s ticket %s: %s\n", t.Source, t.ID, tp.LinkFor(t))
 			return nil
 		}
 	}
@@ -1,21 +1,139 @@
 package cli
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/strongdm/comply/internal/config"
 	"github.com/strongdm/comply/internal/ticket"
 	"github.com/urfave/cli"
 )
 
 var schedulerCommand = cli.Command{
-	Name:   "scheduler",
-	Usage:  "create tickets based on procedure schedule",
+	Name:  "scheduler",
+	Usage: "create tickets based on procedure schedule",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "dry-run, n",
+			Usage: "show what would be created and why, without creating tickets",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Value: "table",
+			Usage: "dry-run output format: table or json",
+		},
+		cli.BoolFlag{
+			Name:  "daemon, d",
+			Usage: "keep running, creating tickets as procedures come due",
+		},
+		cli.StringFlag{
+			Name:  "listen",
+			Value: ":4040",
+			Usage: "daemon address serving /healthz, /status and ticket system webhooks at /hooks/",
+		},
+		cli.DurationFlag{
+			Name:  "sync-interval",
+			Usage: "how often the daemon re-syncs tickets (default: scheduler.syncInterval, or 15m)",
+		},
+	},
 	Action: schedulerAction,
 	Before: beforeAll(projectMustExist, ticketingMustBeConfigured),
 }
 
 func schedulerAction(c *cli.Context) error {
-	err := syncAction(c)
+	if c.Bool("dry-run") {
+		return schedulerPlanAction(c)
+	}
+	if c.IsSet("output") {
+		return cli.NewExitError("--output is only supported with --dry-run", 1)
+	}
+	if c.Bool("daemon") {
+		return schedulerDaemonAction(c)
+	}
+
+	lock, err := ticket.AcquireLock()
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	defer lock.Release()
+
+	err = syncAction(c)
 	if err != nil {
 		return err
 	}
 	return ticket.TriggerScheduled()
 }
+
+const defaultSyncInterval = 15 * time.Minute
+
+func schedulerDaemonAction(c *cli.Context) error {
+	interval := c.Duration("sync-interval")
+	if interval == 0 {
+		interval = defaultSyncInterval
+		if sc := config.Config().Scheduler; sc != nil && sc.SyncInterval != "" {
+			var err error
+			interval, err = time.ParseDuration(sc.SyncInterval)
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("invalid scheduler.syncInterval %q", sc.SyncInterval), 1)
+			}
+		}
+	}
+	if interval <= 0 {
+		return cli.NewExitError("the sync interval must be positive", 1)
+	}
+
+	err := ticket.RunDaemon(ticket.DaemonOptions{
+		Listen:       c.String("listen"),
+		SyncInterval: interval,
+		Sync: func() error {
+			return syncAction(c)
+		},
+	})
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return nil
+}
+
+func schedulerPlanAction(c *cli.Context) error {
+	output := c.String("output")
+	if output != "table" && output != "json" {
+		return cli.NewExitError(fmt.Sprintf("unknown output format %q; use table or json", output), 1)
+	}
+
+	// plan from current ticket state without writing the cache, which a running scheduler may hold
+	tickets, err := ticket.Fetch()
+	if err != nil {
+		return err
+	}
+	plans, err := ticket.PlanScheduledFor(tickets, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plans)
+	}
+
+	w := tablewriter.NewWriter(os.Stdout)
+	w.SetHeader([]string{"Procedure", "Cron", "Last Ticket", "Next Due", "Action", "Reason"})
+	w.SetAutoWrapText(false)
+	for _, p := range plans {
+		last := ""
+		if p.LastTicket != "" {
+			last = fmt.Sprintf("%s (%s)", p.LastTicket, p.LastState)
+		}
+		next := ""
+		if p.NextDueAt != nil {
+			next = p.NextDueAt.Format("2006-01-02 15:04 MST")
+		}
+		w.Append([]string{p.Procedure, p.Cron, last, next, p.Action, p.Reason})
+	}
+	w.Render()
+	return nil
+}
@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"net/http"
+
 	"github.com/pkg/errors"
 	"github.com/strongdm/comply/internal/render"
+	"github.com/strongdm/comply/internal/ticket"
 	"github.com/urfave/cli"
 )
 
@@ -21,6 +24,9 @@ var serveCommand = cli.Command{
 }
 
 func serveAction(c *cli.Context) error {
+	// ticket systems push changes here; the watcher reloads the dashboard as they are cached
+	http.Handle("/hooks/", ticket.WebhookHandler())
+
 	err := render.Build("output", true)
 	if err != nil {
 		return errors.Wrap(err, "serve failed")
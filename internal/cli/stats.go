@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/strongdm/comply/internal/model"
+	"github.com/urfave/cli"
+)
+
+var statsCommand = cli.Command{
+	Name:  "stats",
+	Usage: "show historical compliance statistics",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "since",
+			Value: "90d",
+			Usage: "earliest snapshot to show, as a date (2006-01-02) or age (e.g. 30d, 12w)",
+		},
+	},
+	Action: statsAction,
+	Before: projectMustExist,
+}
+
+func statsAction(c *cli.Context) error {
+	since, err := parseSince(c.String("since"), time.Now())
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	history, err := model.ReadHistory(since)
+	if err != nil {
+		return err
+	}
+
+	if len(history) == 0 {
+		fmt.Println("No history recorded yet; run `comply build` or `comply sync` to record a snapshot.")
+		return nil
+	}
+
+	w := tablewriter.NewWriter(os.Stdout)
+	w.SetHeader([]string{"Date", "Controls", "Coverage", "Procedures Open", "Oldest (days)", "Audits Open", "Audits Total"})
+	w.SetAutoWrapText(false)
+
+	for _, s := range history {
+		var coverage []string
+		for _, std := range s.Standards {
+			coverage = append(coverage, fmt.Sprintf("%s %d/%d", std.Name, std.ControlsSatisfied, std.ControlsTotal))
+		}
+
+		w.Append([]string{
+			s.Date.Format("2006-01-02"),
+			fmt.Sprintf("%d/%d", s.ControlsSatisfied, s.ControlsTotal),
+			strings.Join(coverage, ", "),
+			strconv.Itoa(s.ProcedureOpen),
+			strconv.Itoa(s.ProcedureOldestDays),
+			strconv.Itoa(s.AuditOpen),
+			strconv.Itoa(s.AuditTotal),
+		})
+	}
+
+	w.Render()
+
+	return nil
+}
+
+var sinceAge = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+// parseSince accepts either an absolute date or an age relative to now.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse("2006-01-02", since); err == nil {
+		return t, nil
+	}
+
+	m := sinceAge.FindStringSubmatch(since)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("unrecognized --since value %q; use a date (2006-01-02) or an age such as 30d", since)
+	}
+
+	n, _ := strconv.Atoi(m[1])
+	switch m[2] {
+	case "d":
+		return now.AddDate(0, 0, -n), nil
+	case "w":
+		return now.AddDate(0, 0, -7*n), nil
+	case "m":
+		return now.AddDate(0, -n, 0), nil
+	default:
+		return now.AddDate(-n, 0, 0), nil
+	}
+}
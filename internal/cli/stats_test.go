@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Time{
+		"2026-07-01": time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		"30d":        time.Date(2026, 9, 18, 0, 0, 0, 0, time.UTC),
+		"2w":         time.Date(2026, 10, 4, 0, 0, 0, 0, time.UTC),
+		"3m":         time.Date(2026, 7, 18, 0, 0, 0, 0, time.UTC),
+		"1y":         time.Date(2025, 10, 18, 0, 0, 0, 0, time.UTC),
+	}
+
+	for in, expected := range cases {
+		actual, err := parseSince(in, now)
+		if err != nil {
+			t.Fatalf("parseSince(%q) returned an error %v", in, err)
+		}
+		if !actual.Equal(expected) {
+			t.Errorf("parseSince(%q) = %s, expected %s", in, actual, expected)
+		}
+	}
+
+	if _, err := parseSince("last quarter", now); err == nil {
+		t.Error("parseSince was expected to fail")
+	}
+}
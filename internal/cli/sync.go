@@ -1,34 +1,28 @@
 package cli
 
 import (
-	"github.com/strongdm/comply/internal/config"
-	"github.com/strongdm/comply/internal/model"
+	"github.com/strongdm/comply/internal/render"
+	"github.com/strongdm/comply/internal/ticket"
 	"github.com/urfave/cli"
 )
 
 var syncCommand = cli.Command{
-	Name:   "sync",
-	Usage:  "sync ticket status to local cache",
+	Name:  "sync",
+	Usage: "sync ticket status to local cache",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "full",
+			Usage: "fetch every ticket and remove deleted ones, rather than only recent changes",
+		},
+	},
 	Action: syncAction,
 	Before: beforeAll(projectMustExist, ticketingMustBeConfigured),
 }
 
 func syncAction(c *cli.Context) error {
-	ts, err := config.Config().TicketSystem()
-	if err != nil {
-		return cli.NewExitError("error in ticket system configuration", 1)
-	}
-
-	tp := model.GetPlugin(model.TicketSystem(ts))
-	tickets, err := tp.FindByTagName("comply")
+	err := ticket.Sync(c.Bool("full"))
 	if err != nil {
 		return err
 	}
-	for _, t := range tickets {
-		err = model.DB().Write("tickets", t.ID, t)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return render.RecordHistory()
 }
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+	"github.com/urfave/cli"
+)
+
+var ticketSystemFlag = cli.StringFlag{
+	Name:  "system, s",
+	Usage: "ticket system to use when several are configured (defaults to tickets.default)",
+}
+
+var ticketCommand = cli.Command{
+	Name:   "ticket",
+	Usage:  "list, inspect and update comply tickets",
+	Before: beforeAll(projectMustExist, ticketingMustBeConfigured),
+	Subcommands: []cli.Command{
+		{
+			Name:  "list",
+			Usage: "list open comply tickets",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "all",
+					Usage: "include closed tickets",
+				},
+				cli.StringFlag{
+					Name:  "system, s",
+					Usage: "only list tickets from this ticket system",
+				},
+			},
+			Action: ticketListAction,
+		},
+		{
+			Name:      "show",
+			Usage:     "show a ticket",
+			ArgsUsage: "ticketID",
+			Flags:     []cli.Flag{ticketSystemFlag},
+			Action:    ticketShowAction,
+		},
+		{
+			Name:      "close",
+			Usage:     "close a ticket, optionally with a closing comment",
+			ArgsUsage: "ticketID",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "message, m",
+					Usage: "comment to add before closing",
+				},
+				cli.StringFlag{
+					Name:  "closed-by",
+					Usage: "record this person as closing the ticket, for ticket systems that record it themselves",
+				},
+				ticketSystemFlag,
+			},
+			Action: ticketCloseAction,
+		},
+		{
+			Name:      "comment",
+			Usage:     "add a comment to a ticket",
+			ArgsUsage: "ticketID comment",
+			Flags:     []cli.Flag{ticketSystemFlag},
+			Action:    ticketCommentAction,
+		},
+	},
+}
+
+// ticketPlugin returns the ticket system selected by --system, or the default one.
+func ticketPlugin(c *cli.Context) (string, model.TicketPlugin, error) {
+	ts, err := config.Config().TicketSystemFor(c.String("system"))
+	if err != nil {
+		return "", nil, cli.NewExitError(err.Error(), 1)
+	}
+	return ts, model.GetPlugin(model.TicketSystem(ts)), nil
+}
+
+// ticketUpdater returns the selected plugin if it supports modifying tickets.
+func ticketUpdater(c *cli.Context) (model.TicketUpdater, error) {
+	ts, tp, err := ticketPlugin(c)
+	if err != nil {
+		return nil, err
+	}
+	tu, ok := tp.(model.TicketUpdater)
+	if !ok {
+		return nil, cli.NewExitError(fmt.Sprintf("the %s ticket system does not support updating tickets from comply; use its own interface instead", ts), 1)
+	}
+	return tu, nil
+}
+
+func ticketListAction(c *cli.Context) error {
+	systems, err := config.Config().TicketSystems()
+	if err != nil {
+		return cli.NewExitError("error in ticket system configuration", 1)
+	}
+	if c.String("system") != "" {
+		ts, err := config.Config().TicketSystemFor(c.String("system"))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		systems = []string{ts}
+	}
+
+	w := tablewriter.NewWriter(os.Stdout)
+	w.SetHeader([]string{"System", "ID", "State", "Created", "Name", "Link"})
+	w.SetAutoWrapText(false)
+
+	found := 0
+	for _, ts := range systems {
+		tp := model.GetPlugin(model.TicketSystem(ts))
+
+		var tickets []*model.Ticket
+		if c.Bool("all") {
+			tickets, err = tp.FindByTagName("comply")
+		} else {
+			tickets, err = tp.FindOpen()
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, t := range tickets {
+			created := ""
+			if t.CreatedAt != nil {
+				created = t.CreatedAt.Format("2006-01-02")
+			}
+			w.Append([]string{ts, t.ID, string(t.State), created, t.Name, tp.LinkFor(t)})
+			found++
+		}
+	}
+
+	if found == 0 {
+		fmt.Println("No tickets found.")
+		return nil
+	}
+	w.Render()
+	return nil
+}
+
+func ticketShowAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("provide a ticket ID", 1)
+	}
+
+	_, tp, err := ticketPlugin(c)
+	if err != nil {
+		return err
+	}
+
+	t, err := tp.Get(c.Args().First())
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return cli.NewExitError(fmt.Sprintf("unknown ticket ID: %s", c.Args().First()), 1)
+	}
+
+	var labels []string
+	for k, v := range t.Attributes {
+		if b, ok := v.(bool); ok && b {
+			labels = append(labels, k)
+		}
+	}
+
+	sort.Strings(labels)
+
+	fmt.Printf("%s: %s\n", t.ID, t.Name)
+	fmt.Printf("State:   %s\n", t.State)
+	if t.CreatedAt != nil {
+		fmt.Printf("Created: %s\n", t.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	if t.ClosedAt != nil {
+		fmt.Printf("Closed:  %s\n", t.ClosedAt.Format("2006-01-02 15:04"))
+	}
+	if len(labels) > 0 {
+		fmt.Printf("Labels:  %s\n", strings.Join(labels, ", "))
+	}
+	fmt.Printf("Link:    %s\n\n", tp.LinkFor(t))
+	fmt.Println(strings.TrimSpace(t.Body))
+	return nil
+}
+
+func ticketCloseAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("provide a ticket ID", 1)
+	}
+	ID := c.Args().First()
+
+	tu, err := ticketUpdater(c)
+	if err != nil {
+		return err
+	}
+
+	if message := c.String("message"); message != "" {
+		err = tu.Comment(ID, message)
+		if err != nil {
+			return err
+		}
+	}
+	if closedBy := c.String("closed-by"); closedBy != "" {
+		ac, ok := tu.(model.AttributedCloser)
+		if !ok {
+			return cli.NewExitError("--closed-by is not supported by this ticket system; it records who closed the ticket itself", 1)
+		}
+		err = ac.CloseAs(ID, closedBy)
+	} else {
+		err = tu.Close(ID)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("closed ticket %s\n", ID)
+	return nil
+}
+
+func ticketCommentAction(c *cli.Context) error {
+	if c.NArg() < 2 {
+		return cli.NewExitError("provide a ticket ID and a comment", 1)
+	}
+	ID := c.Args().First()
+
+	tu, err := ticketUpdater(c)
+	if err != nil {
+		return err
+	}
+
+	err = tu.Comment(ID, strings.Join(c.Args().Tail(), " "))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("commented on ticket %s\n", ID)
+	return nil
+}
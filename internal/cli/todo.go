@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"fmt"
 	"os"
 	"sort"
 
@@ -12,7 +13,7 @@ import (
 
 var todoCommand = cli.Command{
 	Name:   "todo",
-	Usage:  "list declared vs satisfied compliance controls",
+	Usage:  "list declared vs satisfied compliance controls and procedure checklist progress",
 	Action: todoAction,
 	Before: projectMustExist,
 }
@@ -64,5 +65,48 @@ func todoAction(c *cli.Context) error {
 
 	w.Render()
 
+	return checklistTodo(d.Tickets)
+}
+
+// checklistTodo lists checklist progress on open procedure tickets, and
+// procedure tickets that were closed with items left unchecked.
+func checklistTodo(tickets []*model.Ticket) error {
+	var rows []*model.Ticket
+	for _, t := range tickets {
+		if t.ProcedureID() == "" || t.Checklist.Total == 0 {
+			continue
+		}
+		if t.State == model.Open || t.ClosedIncomplete() {
+			rows = append(rows, t)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ProcedureID() != rows[j].ProcedureID() {
+			return rows[i].ProcedureID() < rows[j].ProcedureID()
+		}
+		return rows[i].Key() < rows[j].Key()
+	})
+
+	fmt.Println()
+	w := tablewriter.NewWriter(os.Stdout)
+	w.SetHeader([]string{"Procedure", "Ticket", "State", "Checklist", "Name"})
+	w.SetAutoWrapText(false)
+	for _, t := range rows {
+		state := string(t.State)
+		if t.ClosedIncomplete() {
+			state = color.RedString("CLOSED INCOMPLETE")
+		}
+		w.Append([]string{
+			t.ProcedureID(),
+			t.Key(),
+			state,
+			fmt.Sprintf("%d/%d (%d%%)", t.Checklist.Done, t.Checklist.Total, t.Checklist.Percent()),
+			t.Name,
+		})
+	}
+	w.Render()
 	return nil
 }
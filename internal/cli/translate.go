@@ -1,6 +1,10 @@
 package cli
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
 	"github.com/pkg/errors"
 	"github.com/strongdm/comply/internal/render"
 	"github.com/urfave/cli"
@@ -16,17 +20,52 @@ var translateCommand = cli.Command{
 			Name:  "provider, p",
 			Usage: "LLM provider (openai, anthropic, ollama)",
 		},
+		cli.BoolFlag{
+			Name:  "refresh",
+			Usage: "translate every paragraph again instead of reusing the translation memory",
+		},
+		cli.BoolFlag{
+			Name:  "check",
+			Usage: "list translations whose source changed since they were produced, failing if there are any",
+		},
 	},
 	Action: translateTemplatesAction,
 }
 
 func translateTemplatesAction(c *cli.Context) error {
 	path := c.Args().First()
+	if c.Bool("check") {
+		return checkTranslations(path)
+	}
 	provider := c.String("provider")
 
-	err := render.TranslateTemplates(path, provider)
+	err := render.TranslateTemplates(path, provider, c.Bool("refresh"))
 	if err != nil {
 		return errors.Wrap(err, "template translation failed")
 	}
 	return nil
 }
+
+func checkTranslations(path string) error {
+	statuses, err := render.CheckTranslations(path)
+	if err != nil {
+		return errors.Wrap(err, "unable to check translations")
+	}
+
+	stale := 0
+	w := tablewriter.NewWriter(os.Stdout)
+	w.SetHeader([]string{"Translation", "Source", "Reason"})
+	w.SetAutoWrapText(false)
+	for _, s := range statuses {
+		if s.Stale {
+			stale++
+			w.Append([]string{s.Path, s.Source, s.Reason})
+		}
+	}
+	if stale == 0 {
+		fmt.Printf("All %d translations are up to date\n", len(statuses))
+		return nil
+	}
+	w.Render()
+	return cli.NewExitError(fmt.Sprintf("%d of %d translations are out of date; run comply translate-templates", stale, len(statuses)), 1)
+}
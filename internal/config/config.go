@@ -2,9 +2,11 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"gopkg.in/yaml.v2"
 )
@@ -14,10 +16,13 @@ var projectRoot string
 var dockerAvailable, pandocAvailable bool
 
 const (
-	Jira      = "jira"
-	GitHub    = "github"
-	GitLab    = "gitlab"
-	NoTickets = "none"
+	Jira        = "jira"
+	GitHub      = "github"
+	GitLab      = "gitlab"
+	Gitea       = "gitea"
+	AzureDevOps = "azuredevops"
+	Local       = "local"
+	NoTickets   = "none"
 )
 
 const (
@@ -39,6 +44,9 @@ type Project struct {
 	Tickets        map[string]interface{} `yaml:"tickets"`
 	ApprovedBranch string                 `yaml:"approvedBranch"`
 	Translation    *TranslationConfig     `yaml:"translation,omitempty"`
+	TrustCenter    *TrustCenterConfig     `yaml:"trustCenter,omitempty"`
+	Scheduler      *SchedulerConfig       `yaml:"scheduler,omitempty"`
+	Variables      map[string]interface{} `yaml:"variables,omitempty"`
 }
 
 type TranslationConfig struct {
@@ -49,6 +57,77 @@ type TranslationConfig struct {
 	APIKey    string   `yaml:"apiKey,omitempty"`
 }
 
+// TrustCenterConfig controls the public trust-center site emitted by `comply build --trust-center`.
+type TrustCenterConfig struct {
+	Description      string `yaml:"description,omitempty"`
+	RequestAccessURL string `yaml:"requestAccessURL,omitempty"`
+	ContactEmail     string `yaml:"contactEmail,omitempty"`
+}
+
+// SchedulerConfig controls `comply scheduler`.
+type SchedulerConfig struct {
+	// OpenTickets chooses what happens when a procedure comes due while its previous ticket is still open.
+	OpenTickets string `yaml:"openTickets,omitempty"`
+	// EscalateTo is mentioned in escalation comments, e.g. a team or manager handle.
+	EscalateTo string `yaml:"escalateTo,omitempty"`
+	// SyncInterval is how often `comply scheduler --daemon` re-syncs tickets, e.g. 15m.
+	SyncInterval string `yaml:"syncInterval,omitempty"`
+	// CatchUp chooses which missed occurrences open tickets.
+	CatchUp string `yaml:"catchUp,omitempty"`
+}
+
+// Catch-up policies for occurrences missed while the scheduler wasn't running.
+const (
+	// CatchUpNone only acts on occurrences that came due since the scheduler last ran
+	CatchUpNone = "none"
+	// CatchUpLatest acts on the most recent occurrence only
+	CatchUpLatest = "latest"
+	// CatchUpAll opens a ticket for every missed occurrence
+	CatchUpAll = "all"
+)
+
+// CatchUpPolicy resolves a procedure's `catchUp` override against scheduler.catchUp.
+func (p *Project) CatchUpPolicy(override string) (string, error) {
+	policy := override
+	if policy == "" && p.Scheduler != nil {
+		policy = p.Scheduler.CatchUp
+	}
+	switch policy {
+	case "":
+		return CatchUpLatest, nil
+	case CatchUpNone, CatchUpLatest, CatchUpAll:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown catch-up policy %q; use none, latest or all", policy)
+}
+
+// Open ticket policies for the scheduler.
+const (
+	// OpenTicketComment notes the missed occurrence on the open ticket
+	OpenTicketComment = "comment"
+	// OpenTicketEscalate comments on the open ticket, mentioning scheduler.escalateTo
+	OpenTicketEscalate = "escalate"
+	// OpenTicketCreate opens a new ticket regardless
+	OpenTicketCreate = "create"
+	// OpenTicketSkip leaves the open ticket alone
+	OpenTicketSkip = "skip"
+)
+
+// OpenTicketPolicy resolves a procedure's `openTickets` override against scheduler.openTickets.
+func (p *Project) OpenTicketPolicy(override string) (string, error) {
+	policy := override
+	if policy == "" && p.Scheduler != nil {
+		policy = p.Scheduler.OpenTickets
+	}
+	switch policy {
+	case "":
+		return OpenTicketComment, nil
+	case OpenTicketComment, OpenTicketEscalate, OpenTicketCreate, OpenTicketSkip:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown open ticket policy %q; use comment, escalate, create or skip", policy)
+}
+
 // SetPandoc records pandoc availability during initialization
 func SetPandoc(pandoc bool, docker bool) {
 	pandocAvailable = pandoc
@@ -114,28 +193,60 @@ func ProjectRoot() string {
 	return projectRoot
 }
 
-// TicketSystem indicates the type of the configured ticket system
+// DefaultTicketSystemKey names the ticket system used when a procedure doesn't choose one.
+const DefaultTicketSystemKey = "default"
+
+// TicketSystem indicates the type of the default ticket system
 func (p *Project) TicketSystem() (string, error) {
-	if len(p.Tickets) > 1 {
-		return NoTickets, errors.New("multiple ticket systems configured")
+	systems, err := p.TicketSystems()
+	if err != nil {
+		return NoTickets, err
+	}
+
+	switch len(systems) {
+	case 0:
+		// no ticket block configured
+		return NoTickets, nil
+	case 1:
+		return systems[0], nil
 	}
 
+	def, ok := p.Tickets[DefaultTicketSystemKey].(string)
+	if !ok {
+		return NoTickets, errors.New("multiple ticket systems configured; choose one with `tickets.default`")
+	}
+	for _, ts := range systems {
+		if ts == def {
+			return def, nil
+		}
+	}
+	return NoTickets, fmt.Errorf("default ticket system %q is not configured", def)
+}
+
+// TicketSystems lists every configured ticket system, sorted by name.
+func (p *Project) TicketSystems() ([]string, error) {
+	var systems []string
 	for k := range p.Tickets {
 		switch k {
-		case GitHub:
-			return GitHub, nil
-		case Jira:
-			return Jira, nil
-		case GitLab:
-			return GitLab, nil
-		case NoTickets:
-			return NoTickets, nil
+		case GitHub, Jira, GitLab, Gitea, AzureDevOps, Local:
+			systems = append(systems, k)
+		case NoTickets, DefaultTicketSystemKey:
 		default:
 			// explicit error for this case
-			return "", errors.New("unrecognized ticket system configured")
+			return nil, errors.New("unrecognized ticket system configured")
 		}
 	}
+	sort.Strings(systems)
+	return systems, nil
+}
 
-	// no ticket block configured
-	return NoTickets, nil
+// TicketSystemFor resolves a procedure's `ticketSystem`, falling back to the default ticket system.
+func (p *Project) TicketSystemFor(ticketSystem string) (string, error) {
+	if ticketSystem == "" {
+		return p.TicketSystem()
+	}
+	if _, ok := p.Tickets[ticketSystem]; !ok || ticketSystem == DefaultTicketSystemKey {
+		return NoTickets, fmt.Errorf("ticket system %q is not configured in comply.yml", ticketSystem)
+	}
+	return ticketSystem, nil
 }
@@ -0,0 +1,65 @@
+package config
+
+import "testing"
+
+func TestTicketSystems(t *testing.T) {
+	single := &Project{Tickets: map[string]interface{}{GitHub: nil}}
+	ts, err := single.TicketSystem()
+	if err != nil || ts != GitHub {
+		t.Fatalf("expected github, got %q (%v)", ts, err)
+	}
+
+	multiple := &Project{Tickets: map[string]interface{}{GitHub: nil, Jira: nil}}
+	if _, err = multiple.TicketSystem(); err == nil {
+		t.Fatal("expected an error without tickets.default")
+	}
+
+	multiple.Tickets[DefaultTicketSystemKey] = Jira
+	ts, err = multiple.TicketSystem()
+	if err != nil || ts != Jira {
+		t.Fatalf("expected jira, got %q (%v)", ts, err)
+	}
+
+	systems, err := multiple.TicketSystems()
+	if err != nil || len(systems) != 2 || systems[0] != GitHub || systems[1] != Jira {
+		t.Fatalf("unexpected ticket systems %v (%v)", systems, err)
+	}
+
+	ts, err = multiple.TicketSystemFor(GitHub)
+	if err != nil || ts != GitHub {
+		t.Fatalf("expected github, got %q (%v)", ts, err)
+	}
+	ts, err = multiple.TicketSystemFor("")
+	if err != nil || ts != Jira {
+		t.Fatalf("expected the default, got %q (%v)", ts, err)
+	}
+	if _, err = multiple.TicketSystemFor(GitLab); err == nil {
+		t.Fatal("expected an error for an unconfigured ticket system")
+	}
+
+	multiple.Tickets[DefaultTicketSystemKey] = GitLab
+	if _, err = multiple.TicketSystem(); err == nil {
+		t.Fatal("expected an error for an unconfigured default")
+	}
+}
+
+func TestOpenTicketPolicy(t *testing.T) {
+	p := &Project{}
+	policy, err := p.OpenTicketPolicy("")
+	if err != nil || policy != OpenTicketComment {
+		t.Fatalf("expected comment by default, got %q (%v)", policy, err)
+	}
+
+	p.Scheduler = &SchedulerConfig{OpenTickets: OpenTicketSkip}
+	policy, err = p.OpenTicketPolicy("")
+	if err != nil || policy != OpenTicketSkip {
+		t.Fatalf("expected skip, got %q (%v)", policy, err)
+	}
+	policy, err = p.OpenTicketPolicy(OpenTicketEscalate)
+	if err != nil || policy != OpenTicketEscalate {
+		t.Fatalf("expected the procedure override, got %q (%v)", policy, err)
+	}
+	if _, err = p.OpenTicketPolicy("ignore"); err == nil {
+		t.Fatal("expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// secretKeys are the ticket system settings that hold credentials.
+var secretKeys = map[string]bool{
+	"token":         true,
+	"password":      true,
+	"apiToken":      true,
+	"oauthToken":    true,
+	"privateKey":    true,
+	"webhookSecret": true,
+}
+
+// IsSecretKey reports whether a ticket system setting holds a credential, and
+// so may be given as a secret reference. Other settings are used verbatim.
+func IsSecretKey(k string) bool {
+	return secretKeys[k]
+}
+
+// ResolveSecret expands a secret reference from comply.yml so credentials
+// need not be committed in plaintext:
+//
+//	${GITHUB_TOKEN}          value of an environment variable (may be embedded in a longer string)
+//	file:/run/secrets/jira   contents of a file, relative paths resolved against the project root
+//	exec:op read op://x/y    standard output of a command, e.g. a password manager CLI
+//
+// Any other value is returned unchanged. Surrounding whitespace is trimmed from file and command output.
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "file:"):
+		path := strings.TrimPrefix(value, "file:")
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(ProjectRoot(), path)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to read secret file")
+		}
+		return strings.TrimSpace(string(b)), nil
+
+	case strings.HasPrefix(value, "exec:"):
+		command := strings.TrimSpace(strings.TrimPrefix(value, "exec:"))
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", command)
+		} else {
+			cmd = exec.Command("sh", "-c", command)
+		}
+		cmd.Dir = ProjectRoot()
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", errors.Wrapf(err, "secret command failed: %s", strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	var missing []string
+	resolved := envReference.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envReference.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
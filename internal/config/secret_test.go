@@ -0,0 +1,66 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	SetProjectRoot(dir)
+	defer SetProjectRoot("")
+
+	err = ioutil.WriteFile(filepath.Join(dir, "token.txt"), []byte("from-file\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("COMPLY_TEST_SECRET", "from-env")
+	defer os.Unsetenv("COMPLY_TEST_SECRET")
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"plaintext", "plaintext"},
+		{"${COMPLY_TEST_SECRET}", "from-env"},
+		{"Bearer ${COMPLY_TEST_SECRET}", "Bearer from-env"},
+		{"file:token.txt", "from-file"},
+		{"file:" + filepath.Join(dir, "token.txt"), "from-file"},
+		{"exec:echo from-exec", "from-exec"},
+	}
+	for _, test := range tests {
+		actual, err := ResolveSecret(test.value)
+		if err != nil {
+			t.Errorf("%s: %v", test.value, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.value, test.expected, actual)
+		}
+	}
+
+	for _, value := range []string{"${COMPLY_TEST_UNSET}", "file:missing.txt", "exec:exit 1"} {
+		if _, err := ResolveSecret(value); err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+}
+
+func TestIsSecretKey(t *testing.T) {
+	for _, k := range []string{"token", "password", "privateKey", "webhookSecret"} {
+		if !IsSecretKey(k) {
+			t.Errorf("expected %s to hold a secret", k)
+		}
+	}
+	for _, k := range []string{"repo", "url", "jql", "privateKeyPath"} {
+		if IsSecretKey(k) {
+			t.Errorf("expected %s to be used verbatim", k)
+		}
+	}
+}
@@ -1,11 +1,18 @@
 package gitlab
 
 import (
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
 	"github.com/strongdm/comply/internal/model"
 	"github.com/xanzy/go-gitlab"
 )
@@ -14,6 +21,8 @@ const (
 	cfgDomain = "domain"
 	cfgToken  = "token"
 	cfgRepo   = "repo"
+
+	cfgWebhookSecret = "webhookSecret"
 )
 
 var prompts = map[string]string{
@@ -37,6 +46,8 @@ type gitlabPlugin struct {
 	token    string
 	reponame string
 
+	webhookSecret string
+
 	clientMu sync.Mutex
 	client   *gitlab.Client
 }
@@ -54,7 +65,20 @@ func (g *gitlabPlugin) api() *gitlab.Client {
 }
 
 func (g *gitlabPlugin) Get(ID string) (*model.Ticket, error) {
-	return nil, nil
+	iid, err := strconv.Atoi(ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed GitLab issue IID")
+	}
+
+	issue, resp, err := g.api().Issues.GetIssue(g.reponame, iid)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error during Get")
+	}
+
+	return toTicket(issue), nil
 }
 
 func (g *gitlabPlugin) Configured() bool {
@@ -82,6 +106,12 @@ func (g *gitlabPlugin) Configure(cfg map[string]interface{}) error {
 	if g.reponame, err = getCfg(cfg, cfgRepo); err != nil {
 		return err
 	}
+	g.webhookSecret = ""
+	if _, ok := cfg[cfgWebhookSecret]; ok {
+		if g.webhookSecret, err = getCfg(cfg, cfgWebhookSecret); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -96,6 +126,13 @@ func getCfg(cfg map[string]interface{}, k string) (string, error) {
 	if !ok {
 		return "", errors.New("Malformatted key: " + k)
 	}
+	if !config.IsSecretKey(k) {
+		return vS, nil
+	}
+	vS, err := config.ResolveSecret(vS)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to resolve key: "+k)
+	}
 	return vS, nil
 }
 
@@ -134,7 +171,7 @@ func (g *gitlabPlugin) FindOpen() ([]*model.Ticket, error) {
 }
 
 func (g *gitlabPlugin) FindByTag(name, value string) ([]*model.Ticket, error) {
-	panic("not implemented")
+	return g.FindByTagName(model.TagFor(name, value))
 }
 
 func (g *gitlabPlugin) FindByTagName(name string) ([]*model.Ticket, error) {
@@ -151,19 +188,173 @@ func (g *gitlabPlugin) FindByTagName(name string) ([]*model.Ticket, error) {
 	return toTickets(issues), nil
 }
 
+func (g *gitlabPlugin) FindByTagNameSince(name string, since time.Time) ([]*model.Ticket, error) {
+	options := &gitlab.ListProjectIssuesOptions{
+		State:        gitlab.String("all"),
+		Labels:       []string{name},
+		UpdatedAfter: &since,
+	}
+
+	issues, err := getProjectIssues(g, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "error during FindByTagNameSince")
+	}
+
+	return toTickets(issues), nil
+}
+
 func (g *gitlabPlugin) LinkFor(t *model.Ticket) string {
-	panic("not implemented")
+	if t.Link != "" {
+		return t.Link
+	}
+	return fmt.Sprintf("%s/%s/-/issues/%s", strings.TrimSuffix(g.domain, "/"), g.reponame, t.ID)
 }
 
 func (g *gitlabPlugin) Create(ticket *model.Ticket, labels []string) error {
+	if ticket.Priority != "" {
+		// GitLab CE has no native priority field
+		labels = append(labels, model.TagFor(model.PriorityTag, ticket.Priority))
+	}
 	l := gitlab.Labels(labels)
 	options := &gitlab.CreateIssueOptions{
 		Title:       gitlab.String(ticket.Name),
 		Description: gitlab.String(ticket.Body),
 		Labels:      l,
 	}
-	_, _, err := g.api().Issues.CreateIssue(g.reponame, options)
-	return err
+	if ticket.Assignee != "" {
+		users, _, err := g.api().Users.ListUsers(&gitlab.ListUsersOptions{Username: gitlab.String(ticket.Assignee)})
+		if err != nil {
+			return errors.Wrap(err, "unable to look up assignee")
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("unknown GitLab user %s", ticket.Assignee)
+		}
+		options.AssigneeIDs = []int{users[0].ID}
+	}
+	if ticket.DueAt != nil {
+		due := gitlab.ISOTime(*ticket.DueAt)
+		options.DueDate = &due
+	}
+	issue, _, err := g.api().Issues.CreateIssue(g.reponame, options)
+	if err != nil {
+		return err
+	}
+	*ticket = *toTicket(issue)
+	return nil
+}
+
+// Comment adds a note to an issue.
+func (g *gitlabPlugin) Comment(ID, comment string) error {
+	iid, err := strconv.Atoi(ID)
+	if err != nil {
+		return errors.Wrap(err, "malformed GitLab issue IID")
+	}
+	_, _, err = g.api().Notes.CreateIssueNote(g.reponame, iid, &gitlab.CreateIssueNoteOptions{Body: gitlab.String(comment)})
+	return errors.Wrap(err, "unable to comment on ticket")
+}
+
+// Close closes an issue.
+func (g *gitlabPlugin) Close(ID string) error {
+	iid, err := strconv.Atoi(ID)
+	if err != nil {
+		return errors.Wrap(err, "malformed GitLab issue IID")
+	}
+	_, _, err = g.api().Issues.UpdateIssue(g.reponame, iid, &gitlab.UpdateIssueOptions{StateEvent: gitlab.String("close")})
+	return errors.Wrap(err, "unable to close ticket")
+}
+
+// Evidence fetches an issue's comments and the user who closed it. Uploads
+// are referenced from comment bodies; GitLab has no issue attachment API.
+func (g *gitlabPlugin) Evidence(ID string) (*model.Evidence, error) {
+	iid, err := strconv.Atoi(ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed GitLab issue IID")
+	}
+	issue, _, err := g.api().Issues.GetIssue(g.reponame, iid)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch issue")
+	}
+	ev := &model.Evidence{}
+	if issue.ClosedBy != nil {
+		ev.ClosedBy = issue.ClosedBy.Username
+	}
+
+	options := &gitlab.ListIssueNotesOptions{
+		ListOptions: gitlab.ListOptions{Page: 1},
+		OrderBy:     gitlab.String("created_at"),
+		Sort:        gitlab.String("asc"),
+	}
+	for {
+		notes, resp, err := g.api().Notes.ListIssueNotes(g.reponame, iid, options)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to list comments")
+		}
+		for _, n := range notes {
+			if n.System {
+				// label changes, state changes and the like
+				continue
+			}
+			ev.Comments = append(ev.Comments, &model.Comment{
+				Author:    n.Author.Username,
+				CreatedAt: n.CreatedAt,
+				Body:      n.Body,
+			})
+		}
+		if resp.CurrentPage >= resp.TotalPages {
+			return ev, nil
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+// Download is unsupported; Evidence never reports GitLab attachments.
+func (g *gitlabPlugin) Download(a *model.Attachment) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("GitLab attachments can't be downloaded: %s", a.Name)
+}
+
+// Webhook accepts issue and issue comment events carrying webhookSecret as their token.
+func (g *gitlabPlugin) Webhook(r *http.Request, body []byte) (string, error) {
+	if g.webhookSecret == "" {
+		return "", errors.Wrap(model.ErrWebhookRejected, "no "+cfgWebhookSecret+" is configured for GitLab")
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(g.webhookSecret)) != 1 {
+		return "", model.ErrWebhookRejected
+	}
+
+	var event struct {
+		ObjectKind       string `json:"object_kind"`
+		ObjectAttributes struct {
+			IID          int    `json:"iid"`
+			NoteableType string `json:"noteable_type"`
+		} `json:"object_attributes"`
+		Issue struct {
+			IID int `json:"iid"`
+		} `json:"issue"`
+		Project struct {
+			ID                int    `json:"id"`
+			PathWithNamespace string `json:"path_with_namespace"`
+		} `json:"project"`
+	}
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return "", errors.Wrap(err, "malformed GitLab event")
+	}
+	// repo may name the project by path or by numeric ID
+	if !strings.EqualFold(event.Project.PathWithNamespace, g.reponame) && strconv.Itoa(event.Project.ID) != g.reponame {
+		return "", nil
+	}
+
+	iid := 0
+	switch {
+	case event.ObjectKind == "issue":
+		iid = event.ObjectAttributes.IID
+	case event.ObjectKind == "note" && event.ObjectAttributes.NoteableType == "Issue":
+		iid = event.Issue.IID
+	}
+	if iid == 0 {
+		return "", nil
+	}
+	return strconv.Itoa(iid), nil
 }
 
 func toTickets(issues []*gitlab.Issue) []*model.Ticket {
@@ -176,26 +367,31 @@ func toTickets(issues []*gitlab.Issue) []*model.Ticket {
 
 func toTicket(i *gitlab.Issue) *model.Ticket {
 	t := &model.Ticket{Attributes: make(map[string]interface{})}
-	t.ID = strconv.Itoa(i.ID)
+	// IID is the project-scoped number shown in the UI and used by the API
+	t.ID = strconv.Itoa(i.IID)
 	t.Name = i.Title
 	t.Body = i.Description
+	t.Link = i.WebURL
 	t.CreatedAt = i.CreatedAt
+	t.UpdatedAt = i.UpdatedAt
+	t.ClosedAt = i.ClosedAt
 	t.State = toState(i.State)
+	if i.Assignee != nil {
+		t.Assignee = i.Assignee.Username
+	}
+	if i.DueDate != nil {
+		due := time.Time(*i.DueDate)
+		t.DueAt = &due
+	}
 
 	for _, l := range i.Labels {
-		if l == "audit" {
-			t.SetBool("comply-audit")
-		}
-		if l == "procedure" {
-			t.SetBool("comply-procedure")
-		}
+		t.SetLabel(l)
 
-		// seems redundant, but fixes a bug the other two labels introduce
-		// whereby open comply tickets aren't properly accounted for in the UI
-		if l == "comply-audit" {
+		// legacy label names
+		switch l {
+		case "audit":
 			t.SetBool("comply-audit")
-		}
-		if l == "comply-procedure" {
+		case "procedure":
 			t.SetBool("comply-procedure")
 		}
 	}
@@ -1,9 +1,90 @@
 package gitlab
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/strongdm/comply/internal/model"
 )
 
 func TestGitlab(t *testing.T) {
-	createOne()
+	g, ticket := createOne(t)
+
+	if ticket.ID != "7" {
+		t.Fatalf("expected ticket ID to be the project-scoped IID, got %s", ticket.ID)
+	}
+	if link := g.LinkFor(ticket); link != "https://gitlab.example.com/acme/comply/-/issues/7" {
+		t.Fatalf("unexpected link %s", link)
+	}
+	if !ticket.Bool("comply-procedure") {
+		t.Fatal("expected comply-procedure label")
+	}
+}
+
+func createOne(t *testing.T) (*gitlabPlugin, *model.Ticket) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the client probes the API root for rate limit headers
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v4/" {
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.EscapedPath() != "/api/v4/projects/acme%2Fcomply/issues" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      9001,
+			"iid":     7,
+			"title":   "Patch",
+			"state":   "opened",
+			"labels":  []string{"comply", "comply-procedure"},
+			"web_url": "https://gitlab.example.com/acme/comply/-/issues/7",
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	g := &gitlabPlugin{}
+	err := g.Configure(map[string]interface{}{
+		cfgDomain: server.URL,
+		cfgToken:  "token",
+		cfgRepo:   "acme/comply",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticket := &model.Ticket{Name: "Patch", Body: "Procedure-ID: patch"}
+	err = g.Create(ticket, []string{"comply", "comply-procedure"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g, ticket
+}
+
+func TestWebhook(t *testing.T) {
+	g := &gitlabPlugin{reponame: "acme/comply", webhookSecret: "s3cret"}
+	request := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/hooks/gitlab", nil)
+		r.Header.Set("X-Gitlab-Token", token)
+		return r
+	}
+
+	for _, tc := range []struct {
+		body, want string
+	}{
+		{`{"object_kind":"issue","object_attributes":{"iid":7},"project":{"id":3,"path_with_namespace":"acme/comply"}}`, "7"},
+		{`{"object_kind":"note","object_attributes":{"noteable_type":"Issue"},"issue":{"iid":8},"project":{"id":3,"path_with_namespace":"acme/comply"}}`, "8"},
+		{`{"object_kind":"note","object_attributes":{"noteable_type":"MergeRequest"},"project":{"id":3,"path_with_namespace":"acme/comply"}}`, ""},
+		{`{"object_kind":"issue","object_attributes":{"iid":7},"project":{"id":4,"path_with_namespace":"acme/other"}}`, ""},
+	} {
+		ID, err := g.Webhook(request("s3cret"), []byte(tc.body))
+		if err != nil || ID != tc.want {
+			t.Errorf("%s: expected %q, got %q (%v)", tc.body, tc.want, ID, err)
+		}
+	}
+	if _, err := g.Webhook(request("guess"), []byte(`{}`)); err != model.ErrWebhookRejected {
+		t.Errorf("expected a wrong token to be rejected, got %v", err)
+	}
 }
@@ -1,24 +1,54 @@
 package jira
 
 import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
 	"github.com/strongdm/comply/internal/model"
+	"golang.org/x/oauth2"
 
 	jira "github.com/andygrunwald/go-jira"
 )
 
 const (
-	cfgUsername = "username"
-	cfgPassword = "password"
-	cfgURL      = "url"
-	cfgProject  = "project"
-	cfgTaskType = "taskType"
+	cfgUsername      = "username"
+	cfgPassword      = "password"
+	cfgAPIToken      = "apiToken"
+	cfgToken         = "token"
+	cfgOAuthToken    = "oauthToken"
+	cfgAuth          = "auth"
+	cfgURL           = "url"
+	cfgProject       = "project"
+	cfgTaskType      = "taskType"
+	cfgJQL           = "jql"
+	cfgProjectFilter = "projectFilter"
+	cfgComponents    = "components"
+	cfgCustomFields  = "customFields"
+	cfgProcField     = "procedureField"
+	cfgWebhookSecret = "webhookSecret"
 )
 
+// authentication modes selected by the `auth` key
+const (
+	authBasic = "basic" // username and password
+	authToken = "token" // Jira Cloud: account email and API token
+	authPAT   = "pat"   // Jira Server / Data Center personal access token
+	authOAuth = "oauth" // OAuth 2.0 access token
+)
+
+// searchPageSize is the number of issues requested per search page; Jira Cloud caps it at 100.
+const searchPageSize = 100
+
 var prompts = map[string]string{
 	cfgUsername: "Jira Username",
 	cfgPassword: "Jira Password",
@@ -38,12 +68,22 @@ func Register() {
 }
 
 type jiraPlugin struct {
+	auth     string
 	username string
 	password string
+	token    string
 	url      string
 	project  string
 	taskType string
 
+	jql           string
+	projectFilter bool
+	components    []string
+	customFields  map[string]interface{}
+	procField     string
+
+	webhookSecret string
+
 	clientMu sync.Mutex
 	client   *jira.Client
 }
@@ -53,56 +93,163 @@ func (j *jiraPlugin) api() *jira.Client {
 	defer j.clientMu.Unlock()
 
 	if j.client == nil {
-		tp := jira.BasicAuthTransport{
-			Username: j.username,
-			Password: j.password,
+		var httpClient *http.Client
+		switch j.auth {
+		case authPAT, authOAuth:
+			ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: j.token})
+			httpClient = oauth2.NewClient(context.Background(), ts)
+		default:
+			tp := jira.BasicAuthTransport{
+				Username: j.username,
+				Password: j.password,
+			}
+			httpClient = tp.Client()
 		}
 
-		client, _ := jira.NewClient(tp.Client(), j.url)
+		client, _ := jira.NewClient(httpClient, j.url)
 		j.client = client
 	}
 	return j.client
 }
 
 func (j *jiraPlugin) Get(ID string) (*model.Ticket, error) {
-	return nil, nil
+	issue, resp, err := j.api().Issue.Get(ID, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch Jira issue")
+	}
+	return j.toTicket(issue), nil
 }
 
 func (j *jiraPlugin) Configured() bool {
-	return j.username != "" && j.password != "" && j.url != "" && j.project != "" && j.taskType != ""
+	if j.url == "" || j.project == "" || j.taskType == "" {
+		return false
+	}
+	switch j.auth {
+	case authPAT, authOAuth:
+		return j.token != ""
+	default:
+		return j.username != "" && j.password != ""
+	}
 }
 
 func (j *jiraPlugin) Links() model.TicketLinks {
 	links := model.TicketLinks{}
 	links.ProcedureAll = fmt.Sprintf("%s/issues/?jql=labels+=+comply-procedure", j.url)
 	links.ProcedureOpen = fmt.Sprintf("%s/issues/?jql=labels+=+comply-procedure+AND+resolution+=+Unresolved", j.url)
-	// links.AuditAll = fmt.Sprintf("%s/issues?q=is%3Aissue+is%3Aopen+label%3Acomply+label%3Aaudit", j.url)
-	// links.AuditOpen = fmt.Sprintf("%s/issues?q=is%3Aissue+is%3Aopen+label%3Acomply+label%3Aaudit", j.url)
+	links.AuditAll = fmt.Sprintf("%s/issues/?jql=labels+=+comply-audit", j.url)
+	links.AuditOpen = fmt.Sprintf("%s/issues/?jql=labels+=+comply-audit+AND+resolution+=+Unresolved", j.url)
 	return links
 }
 
 func (j *jiraPlugin) Configure(cfg map[string]interface{}) error {
 	var err error
 
-	if j.username, err = getCfg(cfg, cfgUsername); err != nil {
+	if j.url, err = getCfg(cfg, cfgURL); err != nil {
 		return err
 	}
-	if j.password, err = getCfg(cfg, cfgPassword); err != nil {
+	if j.project, err = getCfg(cfg, cfgProject); err != nil {
 		return err
 	}
-	if j.url, err = getCfg(cfg, cfgURL); err != nil {
+	if j.taskType, err = getCfg(cfg, cfgTaskType); err != nil {
 		return err
 	}
-	if j.project, err = getCfg(cfg, cfgProject); err != nil {
+	if err = j.configureAuth(cfg); err != nil {
 		return err
 	}
-	if j.taskType, err = getCfg(cfg, cfgTaskType); err != nil {
+
+	if j.jql, err = getOptionalCfg(cfg, cfgJQL); err != nil {
+		return err
+	}
+	if v, ok := cfg[cfgProjectFilter]; ok {
+		if j.projectFilter, ok = v.(bool); !ok {
+			return errors.New("Malformatted key: " + cfgProjectFilter)
+		}
+	}
+	if v, ok := cfg[cfgComponents]; ok {
+		list, ok := v.([]interface{})
+		if !ok {
+			return errors.New("Malformatted key: " + cfgComponents)
+		}
+		j.components = nil
+		for _, c := range list {
+			name, ok := c.(string)
+			if !ok {
+				return errors.New("Malformatted key: " + cfgComponents)
+			}
+			j.components = append(j.components, name)
+		}
+	}
+	if v, ok := cfg[cfgCustomFields]; ok {
+		fields, ok := stringKeys(v).(map[string]interface{})
+		if !ok {
+			return errors.New("Malformatted key: " + cfgCustomFields)
+		}
+		j.customFields = fields
+	}
+	if j.webhookSecret, err = getOptionalCfg(cfg, cfgWebhookSecret); err != nil {
+		return err
+	}
+	if j.procField, err = getOptionalCfg(cfg, cfgProcField); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// configureAuth selects the authentication mode, inferring it from the
+// credentials present when `auth` is omitted.
+func (j *jiraPlugin) configureAuth(cfg map[string]interface{}) error {
+	var err error
+
+	if j.auth, err = getOptionalCfg(cfg, cfgAuth); err != nil {
+		return err
+	}
+	if j.auth == "" {
+		switch {
+		case cfg[cfgAPIToken] != nil:
+			j.auth = authToken
+		case cfg[cfgToken] != nil:
+			j.auth = authPAT
+		case cfg[cfgOAuthToken] != nil:
+			j.auth = authOAuth
+		default:
+			j.auth = authBasic
+		}
+	}
+
+	switch j.auth {
+	case authBasic:
+		if j.username, err = getCfg(cfg, cfgUsername); err != nil {
+			return err
+		}
+		if j.password, err = getCfg(cfg, cfgPassword); err != nil {
+			return err
+		}
+	case authToken:
+		// Jira Cloud accepts API tokens in place of the password
+		if j.username, err = getCfg(cfg, cfgUsername); err != nil {
+			return err
+		}
+		if j.password, err = getCfg(cfg, cfgAPIToken); err != nil {
+			return err
+		}
+	case authPAT:
+		if j.token, err = getCfg(cfg, cfgToken); err != nil {
+			return err
+		}
+	case authOAuth:
+		if j.token, err = getCfg(cfg, cfgOAuthToken); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported Jira auth %q (expected basic, token, pat or oauth)", j.auth)
+	}
+	return nil
+}
+
 func getCfg(cfg map[string]interface{}, k string) (string, error) {
 	v, ok := cfg[k]
 	if !ok {
@@ -113,27 +260,105 @@ func getCfg(cfg map[string]interface{}, k string) (string, error) {
 	if !ok {
 		return "", errors.New("Malformatted key: " + k)
 	}
+	if !config.IsSecretKey(k) {
+		return vS, nil
+	}
+	vS, err := config.ResolveSecret(vS)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to resolve key: "+k)
+	}
 	return vS, nil
 }
 
+func getOptionalCfg(cfg map[string]interface{}, k string) (string, error) {
+	if _, ok := cfg[k]; !ok {
+		return "", nil
+	}
+	return getCfg(cfg, k)
+}
+
+// stringKeys converts the map[interface{}]interface{} values produced by the
+// YAML decoder into JSON-encodable map[string]interface{} values.
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = stringKeys(val)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = stringKeys(val)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			l[i] = stringKeys(val)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
 func (j *jiraPlugin) FindOpen() ([]*model.Ticket, error) {
-	panic("not implemented")
+	return j.search("labels = comply AND resolution = Unresolved")
 }
 
 func (j *jiraPlugin) FindByTag(name, value string) ([]*model.Ticket, error) {
-	panic("not implemented")
+	return j.search(fmt.Sprintf("labels = %q", model.TagFor(name, value)))
 }
 
 func (j *jiraPlugin) FindByTagName(name string) ([]*model.Ticket, error) {
-	issues, _, err := j.api().Issue.Search("labels=comply", &jira.SearchOptions{MaxResults: 1000})
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to fetch Jira issues")
+	return j.search(fmt.Sprintf("labels = %q", name))
+}
+
+func (j *jiraPlugin) FindByTagNameSince(name string, since time.Time) ([]*model.Ticket, error) {
+	// absolute JQL dates are read in the user's time zone; a relative offset isn't
+	minutes := int(math.Ceil(time.Since(since).Minutes()))
+	return j.search(fmt.Sprintf(`labels = %q AND updated >= "-%dm"`, name, minutes))
+}
+
+// filter narrows a JQL query by the configured base JQL and project.
+func (j *jiraPlugin) filter(jql string) string {
+	var clauses []string
+	if j.jql != "" {
+		clauses = append(clauses, "("+j.jql+")")
 	}
-	return toTickets(issues), nil
+	if j.projectFilter {
+		clauses = append(clauses, fmt.Sprintf("project = %q", j.project))
+	}
+	clauses = append(clauses, jql)
+	return strings.Join(clauses, " AND ")
+}
+
+func (j *jiraPlugin) search(jql string) ([]*model.Ticket, error) {
+	jql = j.filter(jql)
+	opts := &jira.SearchOptions{MaxResults: searchPageSize}
+
+	var issues []jira.Issue
+	for {
+		page, resp, err := j.api().Issue.Search(jql, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to fetch Jira issues")
+		}
+		issues = append(issues, page...)
+		opts.StartAt += len(page)
+		if len(page) == 0 || resp == nil || opts.StartAt >= resp.Total {
+			break
+		}
+	}
+	return j.toTickets(issues), nil
 }
 
 func (j *jiraPlugin) LinkFor(t *model.Ticket) string {
-	panic("not implemented")
+	if t.Link != "" {
+		return t.Link
+	}
+	return fmt.Sprintf("%s/secure/ViewIssue.jspa?id=%s", strings.TrimSuffix(j.url, "/"), t.ID)
 }
 
 func (j *jiraPlugin) Create(ticket *model.Ticket, labels []string) error {
@@ -150,44 +375,234 @@ func (j *jiraPlugin) Create(ticket *model.Ticket, labels []string) error {
 			Labels:      labels,
 		},
 	}
+	if ticket.Assignee != "" {
+		assignee, err := j.user(ticket.Assignee)
+		if err != nil {
+			return err
+		}
+		i.Fields.Assignee = assignee
+	}
+	if ticket.Priority != "" {
+		i.Fields.Priority = &jira.Priority{Name: ticket.Priority}
+	}
+	if ticket.DueAt != nil {
+		i.Fields.Duedate = jira.Date(*ticket.DueAt)
+	}
+	for _, name := range j.components {
+		i.Fields.Components = append(i.Fields.Components, &jira.Component{Name: name})
+	}
+	if len(j.customFields) > 0 || (j.procField != "" && ticket.Procedure != "") {
+		i.Fields.Unknowns = make(map[string]interface{})
+		for k, v := range j.customFields {
+			i.Fields.Unknowns[k] = v
+		}
+		if j.procField != "" && ticket.Procedure != "" {
+			i.Fields.Unknowns[j.procField] = ticket.Procedure
+		}
+	}
 
-	_, _, err := j.api().Issue.Create(&i)
+	created, _, err := j.api().Issue.Create(&i)
 	if err != nil {
 		return errors.Wrap(err, "unable to create ticket")
 	}
+
+	// the create response carries only identifiers
+	issue, _, err := j.api().Issue.Get(created.ID, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to fetch created ticket")
+	}
+	*ticket = *j.toTicket(issue)
 	return nil
 }
 
-func toTickets(issues []jira.Issue) []*model.Ticket {
+// Comment adds a comment to an issue.
+func (j *jiraPlugin) Comment(ID, comment string) error {
+	_, _, err := j.api().Issue.AddComment(ID, &jira.Comment{Body: comment})
+	return errors.Wrap(err, "unable to comment on ticket")
+}
+
+// Close moves an issue through the first available transition into a done status;
+// workflows vary too much between projects to name the transition.
+func (j *jiraPlugin) Close(ID string) error {
+	transitions, _, err := j.api().Issue.GetTransitions(ID)
+	if err != nil {
+		return errors.Wrap(err, "unable to list transitions")
+	}
+	for _, t := range transitions {
+		if t.To.StatusCategory.Key == "done" {
+			_, err = j.api().Issue.DoTransition(ID, t.ID)
+			return errors.Wrap(err, "unable to close ticket")
+		}
+	}
+	return fmt.Errorf("no transition closes ticket %s", ID)
+}
+
+// user resolves an assignee: Jira Cloud identifies users by account ID, which
+// is looked up from an email address or display name; Server accepts usernames.
+func (j *jiraPlugin) user(assignee string) (*jira.User, error) {
+	if j.auth == authBasic || j.auth == authPAT {
+		return &jira.User{Name: assignee}, nil
+	}
+	users, _, err := j.api().User.Find(assignee)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to look up assignee")
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("unknown Jira user %s", assignee)
+	}
+	return &jira.User{AccountID: users[0].AccountID}, nil
+}
+
+// Webhook accepts issue and comment events for the configured project. Jira
+// Cloud signs events with webhookSecret; Jira Server, which cannot, must pass
+// it as the secret query parameter of the webhook URL instead.
+func (j *jiraPlugin) Webhook(r *http.Request, body []byte) (string, error) {
+	if j.webhookSecret == "" {
+		return "", errors.Wrap(model.ErrWebhookRejected, "no "+cfgWebhookSecret+" is configured for Jira")
+	}
+	if signature := r.Header.Get("X-Hub-Signature"); signature != "" {
+		if !model.ValidSignature(j.webhookSecret, body, signature) {
+			return "", model.ErrWebhookRejected
+		}
+	} else if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("secret")), []byte(j.webhookSecret)) != 1 {
+		return "", model.ErrWebhookRejected
+	}
+
+	var event struct {
+		Issue struct {
+			ID     string `json:"id"`
+			Fields struct {
+				Project struct {
+					Key string `json:"key"`
+				} `json:"project"`
+			} `json:"fields"`
+		} `json:"issue"`
+	}
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return "", errors.Wrap(err, "malformed Jira event")
+	}
+	if project := event.Issue.Fields.Project.Key; project != "" && !strings.EqualFold(project, j.project) {
+		return "", nil
+	}
+	return event.Issue.ID, nil
+}
+
+func (j *jiraPlugin) toTickets(issues []jira.Issue) []*model.Ticket {
 	var tickets []*model.Ticket
-	for _, i := range issues {
-		tickets = append(tickets, toTicket(&i))
+	for i := range issues {
+		tickets = append(tickets, j.toTicket(&issues[i]))
 	}
 	return tickets
 }
 
-func toTicket(i *jira.Issue) *model.Ticket {
+func (j *jiraPlugin) toTicket(i *jira.Issue) *model.Ticket {
 	t := &model.Ticket{Attributes: make(map[string]interface{})}
 	t.ID = i.ID
+	t.Link = fmt.Sprintf("%s/browse/%s", strings.TrimSuffix(j.url, "/"), i.Key)
+	if i.Fields == nil {
+		return t
+	}
 	t.Name = i.Fields.Summary
 	t.Body = i.Fields.Description
 	createdAt := time.Time(i.Fields.Created)
 	t.CreatedAt = &createdAt
+	updatedAt := time.Time(i.Fields.Updated)
+	t.UpdatedAt = &updatedAt
+	if resolvedAt := time.Time(i.Fields.Resolutiondate); !resolvedAt.IsZero() {
+		t.ClosedAt = &resolvedAt
+	}
 	t.State = toState(i.Fields.Resolution)
+	if a := i.Fields.Assignee; a != nil {
+		t.Assignee = userName(a)
+	}
+	if i.Fields.Priority != nil {
+		t.Priority = i.Fields.Priority.Name
+	}
+	if dueAt := time.Time(i.Fields.Duedate); !dueAt.IsZero() {
+		t.DueAt = &dueAt
+	}
 
 	for _, l := range i.Fields.Labels {
-		t.SetBool(l)
+		t.SetLabel(l)
+	}
+	if j.procField != "" {
+		if v, ok := i.Fields.Unknowns[j.procField].(string); ok && v != "" {
+			t.Procedure = v
+		}
 	}
 	return t
 }
 
+// userName identifies a user by the most specific name Jira discloses; Cloud
+// hides usernames and, depending on privacy settings, email addresses.
+func userName(u *jira.User) string {
+	if u.Name != "" {
+		return u.Name
+	}
+	if u.EmailAddress != "" {
+		return u.EmailAddress
+	}
+	return u.DisplayName
+}
+
+// jiraTime is the layout of timestamps Jira returns as plain strings.
+const jiraTime = "2006-01-02T15:04:05.000-0700"
+
+// Evidence fetches an issue's comments, attachments and the user who resolved it.
+func (j *jiraPlugin) Evidence(ID string) (*model.Evidence, error) {
+	i, _, err := j.api().Issue.Get(ID, &jira.GetQueryOptions{Expand: "changelog"})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch issue")
+	}
+	ev := &model.Evidence{}
+	if i.Changelog != nil {
+		// the latest change to set a resolution closed the issue
+		for _, h := range i.Changelog.Histories {
+			for _, item := range h.Items {
+				if item.Field == "resolution" && item.ToString != "" {
+					ev.ClosedBy = userName(&h.Author)
+				}
+			}
+		}
+	}
+	if i.Fields == nil {
+		return ev, nil
+	}
+	if i.Fields.Comments != nil {
+		for _, c := range i.Fields.Comments.Comments {
+			mc := &model.Comment{Author: userName(&c.Author), Body: c.Body}
+			if createdAt, err := time.Parse(jiraTime, c.Created); err == nil {
+				mc.CreatedAt = &createdAt
+			}
+			ev.Comments = append(ev.Comments, mc)
+		}
+	}
+	for _, a := range i.Fields.Attachments {
+		ev.Attachments = append(ev.Attachments, &model.Attachment{
+			ID:   a.ID,
+			Name: a.Filename,
+			URL:  a.Content,
+			Size: int64(a.Size),
+		})
+	}
+	return ev, nil
+}
+
+// Download fetches an attachment's content.
+func (j *jiraPlugin) Download(a *model.Attachment) (io.ReadCloser, error) {
+	resp, err := j.api().Issue.DownloadAttachment(a.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to download attachment")
+	}
+	return resp.Body, nil
+}
+
 func toState(status *jira.Resolution) model.TicketState {
-	if status == nil {
+	// any resolution (Done, Won't Do, Duplicate, ...) closes the ticket,
+	// consistent with the `resolution = Unresolved` JQL used by FindOpen
+	if status == nil || status.Name == "" {
 		return model.Open
 	}
-	switch status.Name {
-	case "Done":
-		return model.Closed
-	}
-	return model.Open
+	return model.Closed
 }
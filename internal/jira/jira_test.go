@@ -1,9 +1,157 @@
 package jira
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/strongdm/comply/internal/model"
 )
 
 func TestJira(t *testing.T) {
-	createOne()
+	j, ticket := createOne(t)
+
+	if ticket.ID != "10001" {
+		t.Fatalf("unexpected ticket ID %s", ticket.ID)
+	}
+	if !strings.HasSuffix(j.LinkFor(ticket), "/browse/COMP-1") {
+		t.Fatalf("unexpected link %s", j.LinkFor(ticket))
+	}
+	if ticket.State != model.Open {
+		t.Fatalf("unexpected state %s", ticket.State)
+	}
+}
+
+func createOne(t *testing.T) (*jiraPlugin, *model.Ticket) {
+	issue := map[string]interface{}{
+		"id":  "10001",
+		"key": "COMP-1",
+		"fields": map[string]interface{}{
+			"summary":     "Patch",
+			"description": "Procedure-ID: patch",
+			"labels":      []string{"comply", "comply-procedure"},
+			"created":     "2026-10-01T10:00:00.000+0000",
+			"updated":     "2026-10-01T10:00:00.000+0000",
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{"id": "10001", "key": "COMP-1"})
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/issue/10001":
+			json.NewEncoder(w).Encode(issue)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	j := &jiraPlugin{}
+	err := j.Configure(map[string]interface{}{
+		cfgUsername: "comply",
+		cfgPassword: "secret",
+		cfgURL:      server.URL,
+		cfgProject:  "COMP",
+		cfgTaskType: "Task",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticket := &model.Ticket{Name: "Patch", Body: "Procedure-ID: patch"}
+	err = j.Create(ticket, []string{"comply", "comply-procedure"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j, ticket
+}
+
+func TestSearchPages(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/search" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		queries = append(queries, r.URL.Query().Get("jql"))
+
+		startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		var issues []map[string]interface{}
+		if startAt == 0 {
+			issues = []map[string]interface{}{{"id": "1", "key": "COMP-1"}, {"id": "2", "key": "COMP-2"}}
+		} else {
+			issues = []map[string]interface{}{{"id": "3", "key": "COMP-3"}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"startAt":    startAt,
+			"maxResults": 2,
+			"total":      3,
+			"issues":     issues,
+		})
+	}))
+	defer server.Close()
+
+	j := &jiraPlugin{}
+	err := j.Configure(map[string]interface{}{
+		cfgUsername:      "comply@example.com",
+		cfgAPIToken:      "token",
+		cfgURL:           server.URL,
+		cfgProject:       "COMP",
+		cfgTaskType:      "Task",
+		cfgJQL:           "component = Security",
+		cfgProjectFilter: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.auth != authToken {
+		t.Fatalf("expected auth %s, got %s", authToken, j.auth)
+	}
+
+	tickets, err := j.FindByTagName("comply-procedure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 3 {
+		t.Fatalf("expected 3 tickets across pages, got %d", len(tickets))
+	}
+	expected := `(component = Security) AND project = "COMP" AND labels = "comply-procedure"`
+	if len(queries) != 2 || queries[0] != expected {
+		t.Fatalf("unexpected queries %q", queries)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	j := &jiraPlugin{project: "OPS", webhookSecret: "s3cret"}
+	body := []byte(`{"webhookEvent":"jira:issue_updated","issue":{"id":"10042","key":"OPS-7","fields":{"project":{"key":"OPS"}}}}`)
+
+	// Jira Cloud signs the body
+	r := httptest.NewRequest(http.MethodPost, "/hooks/jira", nil)
+	r.Header.Set("X-Hub-Signature", "sha256=61d2a7ddf5c5ed7eec5d19a0efb5bc5cfb1a0c2b1bc2bd1ea8fca4b9d0c7cc1b")
+	if _, err := j.Webhook(r, body); err != model.ErrWebhookRejected {
+		t.Errorf("expected a bad signature to be rejected, got %v", err)
+	}
+
+	// Jira Server passes the secret in the URL
+	r = httptest.NewRequest(http.MethodPost, "/hooks/jira?secret=s3cret", nil)
+	ID, err := j.Webhook(r, body)
+	if err != nil || ID != "10042" {
+		t.Fatalf("expected issue 10042, got %q (%v)", ID, err)
+	}
+	r = httptest.NewRequest(http.MethodPost, "/hooks/jira", nil)
+	if _, err = j.Webhook(r, body); err != model.ErrWebhookRejected {
+		t.Errorf("expected a missing secret to be rejected, got %v", err)
+	}
+	j.project = "SEC"
+	r = httptest.NewRequest(http.MethodPost, "/hooks/jira?secret=s3cret", nil)
+	if ID, err = j.Webhook(r, body); err != nil || ID != "" {
+		t.Errorf("expected events for other projects to be ignored, got %q (%v)", ID, err)
+	}
 }
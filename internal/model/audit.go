@@ -1,6 +1,195 @@
 package model
 
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
 type Audit struct {
 	ID   string
 	Name string
 }
+
+// AuditTag labels tickets opened for auditor requests.
+const AuditTag = "comply-audit"
+
+// AuditRequestTag links a ticket to its request number; TagFor(AuditRequestTag, "12").
+const AuditRequestTag = "comply-audit-request"
+
+// AuditRequest is one item of an auditor's provided-by-client (PBC) list.
+type AuditRequest struct {
+	ID          string
+	Title       string
+	Description string
+	Controls    []string
+	Assignee    string
+	DueAt       *time.Time
+}
+
+// auditColumns maps the column headings auditors commonly use to AuditRequest fields.
+var auditColumns = map[string]string{
+	"id":          "id",
+	"#":           "id",
+	"no":          "id",
+	"number":      "id",
+	"request":     "id",
+	"request#":    "id",
+	"requestid":   "id",
+	"requestno":   "id",
+	"title":       "title",
+	"name":        "title",
+	"summary":     "title",
+	"description": "description",
+	"details":     "description",
+	"controls":    "controls",
+	"control":     "controls",
+	"controlkeys": "controls",
+	"due":         "due",
+	"duedate":     "due",
+	"assignee":    "assignee",
+	"owner":       "assignee",
+}
+
+var (
+	headingPunctuation = regexp.MustCompile(`[\s_.-]+`)
+	controlSeparator   = regexp.MustCompile(`[,;\s]+`)
+)
+
+// dueLayouts are the due date formats accepted in PBC lists.
+var dueLayouts = []string{"2006-01-02", "1/2/2006"}
+
+// ReadAuditRequests parses a PBC list exported as CSV. The first row names the
+// columns: a request number, a title or description and, optionally, the
+// related control keys, a due date and an assignee.
+func ReadAuditRequests(r io.Reader) ([]*AuditRequest, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("the request list is empty")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read request list")
+	}
+	columns := make(map[string]int)
+	for i, heading := range header {
+		heading = strings.ToLower(headingPunctuation.ReplaceAllString(strings.TrimSpace(heading), ""))
+		if field, ok := auditColumns[heading]; ok {
+			if _, dup := columns[field]; !dup {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["id"]; !ok {
+		return nil, errors.New("the request list needs a request number column, e.g. `id`")
+	}
+	_, hasTitle := columns["title"]
+	_, hasDescription := columns["description"]
+	if !hasTitle && !hasDescription {
+		return nil, errors.New("the request list needs a `title` or `description` column")
+	}
+
+	var requests []*AuditRequest
+	seen := make(map[string]int)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read request list")
+		}
+		line, _ := cr.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		req := &AuditRequest{
+			ID:          field("id"),
+			Title:       field("title"),
+			Description: field("description"),
+			Assignee:    field("assignee"),
+		}
+		if req.ID == "" && req.Title == "" && req.Description == "" {
+			// blank rows are common in spreadsheet exports
+			continue
+		}
+		if req.ID == "" {
+			return nil, fmt.Errorf("line %d: missing request number", line)
+		}
+		if strings.ContainsAny(req.ID, " \t") {
+			return nil, fmt.Errorf("line %d: request number %q cannot contain spaces", line, req.ID)
+		}
+		if prev, ok := seen[req.ID]; ok {
+			return nil, fmt.Errorf("line %d: request %s is already listed on line %d", line, req.ID, prev)
+		}
+		seen[req.ID] = line
+		if req.Title == "" {
+			req.Title = strings.SplitN(req.Description, "\n", 2)[0]
+		}
+		if req.Title == "" {
+			return nil, fmt.Errorf("line %d: request %s has no title or description", line, req.ID)
+		}
+		for _, c := range controlSeparator.Split(field("controls"), -1) {
+			if c != "" {
+				req.Controls = append(req.Controls, c)
+			}
+		}
+		if due := field("due"); due != "" {
+			req.DueAt, err = parseDue(due)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: request %s: %v", line, req.ID, err)
+			}
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// parseDue reads a due date as the end of that day, local time.
+func parseDue(s string) (*time.Time, error) {
+	for _, layout := range dueLayouts {
+		if d, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			due := d.AddDate(0, 0, 1).Add(-time.Second)
+			return &due, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid due date %q; use YYYY-MM-DD", s)
+}
+
+// Audit reports whether a ticket was opened for an auditor request.
+func (t *Ticket) Audit() bool {
+	return t.Bool(AuditTag)
+}
+
+// AuditRequest returns the number of the auditor request a ticket was opened for.
+func (t *Ticket) AuditRequest() string {
+	for k := range t.Attributes {
+		if strings.HasPrefix(k, AuditRequestTag+":") {
+			return strings.TrimPrefix(k, AuditRequestTag+":")
+		}
+	}
+	return t.metadata()["Audit-Request"]
+}
+
+// AuditControls returns the control keys an auditor request relates to.
+func (t *Ticket) AuditControls() []string {
+	var controls []string
+	for _, c := range controlSeparator.Split(t.metadata()["Controls"], -1) {
+		if c != "" {
+			controls = append(controls, c)
+		}
+	}
+	return controls
+}
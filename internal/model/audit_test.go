@@ -0,0 +1,63 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadAuditRequests(t *testing.T) {
+	list := "Request #,Title,Description,Control Keys,Due Date\n" +
+		"1,Access reviews,\"Evidence of quarterly\naccess reviews\",\"CC6.1, CC6.2\",2026-11-01\n" +
+		",,,,\n" +
+		"PBC-2,,Population of terminated employees,CC6.3,11/15/2026\n"
+
+	requests, err := ReadAuditRequests(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	r := requests[0]
+	if r.ID != "1" || r.Title != "Access reviews" || strings.Join(r.Controls, " ") != "CC6.1 CC6.2" {
+		t.Errorf("unexpected request %+v", r)
+	}
+	if r.DueAt == nil || r.DueAt.Format("2006-01-02 15:04") != "2026-11-01 23:59" {
+		t.Errorf("expected the request to be due at the end of 2026-11-01, got %v", r.DueAt)
+	}
+	r = requests[1]
+	if r.ID != "PBC-2" || r.Title != "Population of terminated employees" || r.DueAt.Format("2006-01-02") != "2026-11-15" {
+		t.Errorf("unexpected request %+v", r)
+	}
+
+	for _, bad := range []string{
+		"Title\nAccess reviews\n",
+		"ID,Controls\n1,CC6.1\n",
+		"ID,Title\n1,Access reviews\n1,Again\n",
+		"ID,Title,Due\n1,Access reviews,next week\n",
+		"ID,Title\nPBC 1,Access reviews\n",
+	} {
+		if _, err := ReadAuditRequests(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected an error reading %q", bad)
+		}
+	}
+}
+
+func TestAuditTicket(t *testing.T) {
+	ticket := &Ticket{
+		Body:       "Evidence\n\n---\nAudit-Request: 7\nControls: CC6.1, CC6.2\n",
+		Attributes: map[string]interface{}{},
+	}
+	ticket.SetLabel(AuditTag)
+	ticket.SetLabel(TagFor(AuditRequestTag, "PBC-7"))
+	if !ticket.Audit() || ticket.AuditRequest() != "PBC-7" {
+		t.Errorf("expected the request number from the label, got %q", ticket.AuditRequest())
+	}
+	delete(ticket.Attributes, TagFor(AuditRequestTag, "PBC-7"))
+	if ticket.AuditRequest() != "7" {
+		t.Errorf("expected the request number from the body, got %q", ticket.AuditRequest())
+	}
+	if c := strings.Join(ticket.AuditControls(), " "); c != "CC6.1 CC6.2" {
+		t.Errorf("unexpected controls %s", c)
+	}
+}
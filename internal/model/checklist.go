@@ -0,0 +1,53 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Checklist counts the markdown task list items (`- [ ]` and `- [x]`) in a ticket body.
+type Checklist struct {
+	Done  int
+	Total int
+}
+
+// task list items may also have been converted to HTML, as for Azure DevOps Server
+var taskItem = regexp.MustCompile(`^\s*(?:(?:[-*+]|\d+[.)])\s+|<li>\s*)\[([ xX])\]`)
+
+// ParseChecklist counts the task list items in markdown, ignoring fenced code blocks.
+func ParseChecklist(body string) Checklist {
+	var c Checklist
+	fenced := false
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		m := taskItem.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		c.Total++
+		if m[1] != " " {
+			c.Done++
+		}
+	}
+	return c
+}
+
+// Percent is the share of items done, rounded down; an empty checklist is complete.
+func (c Checklist) Percent() int {
+	if c.Total == 0 {
+		return 100
+	}
+	return c.Done * 100 / c.Total
+}
+
+// Unchecked is the number of items not yet done.
+func (c Checklist) Unchecked() int {
+	return c.Total - c.Done
+}
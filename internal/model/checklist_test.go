@@ -0,0 +1,42 @@
+package model
+
+import "testing"
+
+func TestParseChecklist(t *testing.T) {
+	body := "# Offboarding\n\n" +
+		"- [x] Disable SSO account\n" +
+		"- [X] Revoke VPN certificate\n" +
+		"  * [ ] Rotate shared secrets\n" +
+		"1. [ ] Collect laptop\n" +
+		"- [] not a task\n" +
+		"```\n- [ ] example inside a code block\n```\n"
+
+	c := ParseChecklist(body)
+	if c.Done != 2 || c.Total != 4 {
+		t.Fatalf("expected 2 of 4 done, got %+v", c)
+	}
+	if c.Percent() != 50 || c.Unchecked() != 2 {
+		t.Fatalf("expected 50%% with 2 unchecked, got %d%% with %d", c.Percent(), c.Unchecked())
+	}
+	if c := ParseChecklist("<ul>\n<li>[x] patch</li>\n<li>[ ] reboot</li>\n</ul>\n"); c.Done != 1 || c.Total != 2 {
+		t.Fatalf("expected 1 of 2 done in a checklist converted to HTML, got %+v", c)
+	}
+	if p := ParseChecklist("no tasks").Percent(); p != 100 {
+		t.Fatalf("expected an empty checklist to be complete, got %d%%", p)
+	}
+}
+
+func TestClosedIncomplete(t *testing.T) {
+	ticket := &Ticket{State: Closed, Checklist: Checklist{Done: 1, Total: 2}}
+	if !ticket.ClosedIncomplete() {
+		t.Fatal("expected a closed ticket with unchecked items to be flagged")
+	}
+	ticket.State = Open
+	if ticket.ClosedIncomplete() {
+		t.Fatal("open tickets are still in progress")
+	}
+	ticket.State, ticket.Checklist.Done = Closed, 2
+	if ticket.ClosedIncomplete() {
+		t.Fatal("a completed checklist must not be flagged")
+	}
+}
@@ -1,36 +1,320 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
-	"github.com/nanobox-io/golang-scribble"
+	"github.com/pkg/errors"
 	"github.com/strongdm/comply/internal/config"
+	bolt "go.etcd.io/bbolt"
 )
 
-var dbSingletonOnce sync.Once
-var dbSingleton *scribble.Driver
+// Store is the local cache in .comply/comply.db: an embedded key-value store
+// holding JSON records by collection, with indexes over cached tickets.
+//
+// The database file is opened for each transaction rather than held open, so
+// a long-running scheduler daemon never locks other comply commands out.
+type Store struct {
+	path string
 
-// DB provides a singleton reference to a local json cache; will panic if storage location is not writeable.
-func DB() *scribble.Driver {
-	dbSingletonOnce.Do(func() {
-		if _, err := os.Stat(filepath.Join(config.ProjectRoot(), ".comply", "cache")); os.IsNotExist(err) {
-			err = os.Mkdir(filepath.Join(config.ProjectRoot(), ".comply"), os.FileMode(0755))
-			if err != nil {
-				panic("could not create directory .comply: " + err.Error())
-			}
-			err = os.Mkdir(filepath.Join(config.ProjectRoot(), ".comply", "cache"), os.FileMode(0755))
+	// mu serializes transactions within the process; the file lock only guards against other processes
+	mu sync.RWMutex
+
+	initMu      sync.Mutex
+	initialized bool
+}
+
+const (
+	ticketsCollection = "tickets"
+
+	// buckets prefixed with an underscore are internal and never collections
+	metaBucket     = "_meta"
+	byProcedure    = "_tickets_by_procedure"
+	byState        = "_tickets_by_state"
+	byCreated      = "_tickets_by_created"
+	schemaVersion  = "schema"
+	indexSeparator = "\x00"
+
+	// createdLayout sorts lexically in time order
+	createdLayout = "20060102T150405.000000000"
+)
+
+// lockTimeout is how long to wait for another comply process to finish with the cache.
+const lockTimeout = 30 * time.Second
+
+var dbMu sync.Mutex
+var dbSingleton *Store
+
+// DB provides a singleton reference to the local cache.
+func DB() *Store {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+
+	// reopen if the project root has moved since, as it does between tests
+	path := filepath.Join(config.ProjectRoot(), ".comply", "comply.db")
+	if dbSingleton == nil || dbSingleton.path != path {
+		dbSingleton = &Store{path: path}
+	}
+	return dbSingleton
+}
+
+// View runs fn in a read-only transaction.
+func (s *Store) View(fn func(tx *Tx) error) error {
+	err := s.init()
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.view(func(tx *bolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+func (s *Store) view(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(s.path, os.FileMode(0644), &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return errors.Wrap(err, "unable to open cache")
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// Update runs fn in a read-write transaction, committing only if fn succeeds.
+func (s *Store) Update(fn func(tx *Tx) error) error {
+	err := s.init()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.update(func(tx *bolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+func (s *Store) update(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(s.path, os.FileMode(0644), &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return errors.Wrap(err, "unable to open cache")
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+// Read decodes the record stored under key into v.
+func (s *Store) Read(collection, key string, v interface{}) error {
+	return s.View(func(tx *Tx) error {
+		return tx.Read(collection, key, v)
+	})
+}
+
+// Write stores v under key, replacing any existing record.
+func (s *Store) Write(collection, key string, v interface{}) error {
+	return s.Update(func(tx *Tx) error {
+		return tx.Write(collection, key, v)
+	})
+}
+
+// Delete removes the record stored under key.
+func (s *Store) Delete(collection, key string) error {
+	return s.Update(func(tx *Tx) error {
+		return tx.Delete(collection, key)
+	})
+}
+
+// ReadAll returns the raw JSON of every record in a collection, ordered by key.
+func (s *Store) ReadAll(collection string) ([]string, error) {
+	var records []string
+	err := s.View(func(tx *Tx) error {
+		var err error
+		records, err = tx.ReadAll(collection)
+		return err
+	})
+	return records, err
+}
+
+// Keys lists the keys of a collection in order.
+func (s *Store) Keys(collection string) ([]string, error) {
+	var keys []string
+	err := s.View(func(tx *Tx) error {
+		var err error
+		keys, err = tx.Keys(collection)
+		return err
+	})
+	return keys, err
+}
+
+// Tx is a cache transaction.
+type Tx struct {
+	tx *bolt.Tx
+}
+
+// notFound satisfies os.IsNotExist, matching a missing file in the cache this store replaced.
+func notFound(collection, key string) error {
+	return &os.PathError{Op: "read", Path: collection + "/" + key, Err: os.ErrNotExist}
+}
+
+// Read decodes the record stored under key into v.
+func (t *Tx) Read(collection, key string, v interface{}) error {
+	b := t.tx.Bucket([]byte(collection))
+	if b == nil {
+		return notFound(collection, key)
+	}
+	data := b.Get([]byte(key))
+	if data == nil {
+		return notFound(collection, key)
+	}
+	return errors.Wrapf(json.Unmarshal(data, v), "malformed record %s/%s", collection, key)
+}
+
+// Write stores v under key, replacing any existing record.
+func (t *Tx) Write(collection, key string, v interface{}) error {
+	if collection == "" || key == "" {
+		return errors.New("cache records need a collection and a key")
+	}
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return errors.Wrapf(err, "unable to encode record %s/%s", collection, key)
+	}
+	b, err := t.tx.CreateBucketIfNotExists([]byte(collection))
+	if err != nil {
+		return err
+	}
+	if collection == ticketsCollection {
+		err = t.reindex(key, b.Get([]byte(key)), data)
+		if err != nil {
+			return err
+		}
+	}
+	return b.Put([]byte(key), data)
+}
+
+// Delete removes the record stored under key.
+func (t *Tx) Delete(collection, key string) error {
+	b := t.tx.Bucket([]byte(collection))
+	if b == nil || b.Get([]byte(key)) == nil {
+		return notFound(collection, key)
+	}
+	if collection == ticketsCollection {
+		err := t.reindex(key, b.Get([]byte(key)), nil)
+		if err != nil {
+			return err
+		}
+	}
+	return b.Delete([]byte(key))
+}
+
+// ReadAll returns the raw JSON of every record in a collection, ordered by key.
+func (t *Tx) ReadAll(collection string) ([]string, error) {
+	var records []string
+	b := t.tx.Bucket([]byte(collection))
+	if b == nil {
+		return records, nil
+	}
+	err := b.ForEach(func(k, v []byte) error {
+		records = append(records, string(v))
+		return nil
+	})
+	return records, err
+}
+
+// Keys lists the keys of a collection in order.
+func (t *Tx) Keys(collection string) ([]string, error) {
+	var keys []string
+	b := t.tx.Bucket([]byte(collection))
+	if b == nil {
+		return keys, nil
+	}
+	err := b.ForEach(func(k, v []byte) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+	return keys, err
+}
+
+// indexEntries lists the index entries of an encoded ticket by index bucket.
+func indexEntries(key string, data []byte) map[string][]byte {
+	entries := make(map[string][]byte)
+	if data == nil {
+		return entries
+	}
+	ticket := &Ticket{}
+	if json.Unmarshal(data, ticket) != nil {
+		// corrupt records are unindexed; ReadTickets reports them
+		return entries
+	}
+	if id := ticket.ProcedureID(); id != "" {
+		entries[byProcedure] = []byte(id + indexSeparator + key)
+	}
+	entries[byState] = []byte(string(ticket.State) + indexSeparator + key)
+	if ticket.CreatedAt != nil {
+		entries[byCreated] = []byte(ticket.CreatedAt.UTC().Format(createdLayout) + indexSeparator + key)
+	}
+	return entries
+}
+
+// reindex replaces the index entries of the ticket stored under key.
+func (t *Tx) reindex(key string, previous, next []byte) error {
+	for bucket, entry := range indexEntries(key, previous) {
+		if b := t.tx.Bucket([]byte(bucket)); b != nil {
+			err := b.Delete(entry)
 			if err != nil {
-				panic("could not create directory .comply/cache: " + err.Error())
+				return err
 			}
 		}
-
-		db, err := scribble.New(filepath.Join(config.ProjectRoot(), ".comply", "cache"), nil)
+	}
+	for bucket, entry := range indexEntries(key, next) {
+		b, err := t.tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		err = b.Put(entry, nil)
 		if err != nil {
-			panic("unable to load comply data: " + err.Error())
+			return err
 		}
-		dbSingleton = db
+	}
+	return nil
+}
+
+// ticketKeys lists the keys of tickets whose entry in an index falls in [from, to).
+func (t *Tx) ticketKeys(index string, from, to []byte) []string {
+	var keys []string
+	b := t.tx.Bucket([]byte(index))
+	if b == nil {
+		return keys
+	}
+	c := b.Cursor()
+	for k, _ := c.Seek(from); k != nil && (to == nil || bytes.Compare(k, to) < 0); k, _ = c.Next() {
+		if i := bytes.LastIndex(k, []byte(indexSeparator)); i >= 0 {
+			keys = append(keys, string(k[i+1:]))
+		}
+	}
+	return keys
+}
+
+// indexedTickets reads the tickets whose entry in an index falls in [from, to).
+func (s *Store) indexedTickets(index string, from, to []byte) ([]*Ticket, error) {
+	var tickets []*Ticket
+	err := s.View(func(tx *Tx) error {
+		for _, key := range tx.ticketKeys(index, from, to) {
+			ticket := &Ticket{}
+			err := tx.Read(ticketsCollection, key, ticket)
+			if err != nil {
+				return err
+			}
+			tickets = append(tickets, ticket)
+		}
+		return nil
 	})
-	return dbSingleton
+	return tickets, err
+}
+
+// prefixRange is the range of index entries for an exact value.
+func prefixRange(value string) ([]byte, []byte) {
+	return []byte(value + indexSeparator), []byte(value + "\x01")
 }
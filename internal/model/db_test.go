@@ -1,9 +1,12 @@
 package model
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/strongdm/comply/internal/config"
 )
@@ -33,3 +36,147 @@ func TestSaveGet(t *testing.T) {
 		t.Error("failed to read ticket")
 	}
 }
+
+func TestMigrateLegacyCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetProjectRoot(dir)
+	defer config.SetProjectRoot("")
+
+	legacy := filepath.Join(dir, ".comply", "cache")
+	for file, content := range map[string]string{
+		"tickets/github-7.json": `{"ID": "7", "Source": "github", "State": "open", "Procedure": "patch"}`,
+		"sync/github.json":      `{"source": "github", "fetched": 1}`,
+	} {
+		err = os.MkdirAll(filepath.Dir(filepath.Join(legacy, file)), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(filepath.Join(legacy, file), []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tickets, err := ReadTicketsForProcedure("patch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 1 || tickets[0].Key() != "github-7" {
+		t.Fatalf("expected the legacy ticket to be imported and indexed, got %+v", tickets)
+	}
+	state, err := ReadSyncState("github")
+	if err != nil || state == nil || state.Fetched != 1 {
+		t.Fatalf("expected the legacy sync state to be imported, got %+v (%v)", state, err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Fatal("expected the legacy cache to be moved aside")
+	}
+	if _, err := os.Stat(legacy + ".migrated"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateCurrentCacheReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetProjectRoot(dir)
+	defer config.SetProjectRoot("")
+
+	err = DB().Write("tickets", "github-1", &Ticket{ID: "1", Source: "github"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, ".comply", "comply.db")
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	err = os.Chtimes(path, past, past)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a fresh process finds the cache current and must not write to it
+	s := &Store{path: path}
+	var keys []string
+	err = s.View(func(tx *Tx) error {
+		keys, err = tx.Keys("tickets")
+		return err
+	})
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("expected the cached ticket, got %v (%v)", keys, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Errorf("expected a current cache to be left untouched, modified at %v", info.ModTime())
+	}
+}
+
+func TestTicketIndexes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetProjectRoot(dir)
+	defer config.SetProjectRoot("")
+
+	day := func(d int) *time.Time {
+		t := time.Date(2026, time.October, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	for _, ticket := range []*Ticket{
+		{ID: "1", Procedure: "patch", State: Closed, CreatedAt: day(1)},
+		{ID: "2", Procedure: "patch", State: Open, CreatedAt: day(2)},
+		{ID: "3", Procedure: "offboarding", State: Open, CreatedAt: day(3)},
+	} {
+		err = DB().Write("tickets", ticket.Key(), ticket)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := func(tickets []*Ticket, err error) string {
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, t := range tickets {
+			keys = append(keys, t.Key())
+		}
+		return strings.Join(keys, ",")
+	}
+	if k := keys(ReadTicketsForProcedure("patch")); k != "1,2" {
+		t.Errorf("by procedure: got %s", k)
+	}
+	if k := keys(ReadTicketsByState(Open)); k != "2,3" {
+		t.Errorf("by state: got %s", k)
+	}
+	if k := keys(ReadTicketsCreatedBetween(*day(2), *day(4))); k != "2,3" {
+		t.Errorf("by creation date: got %s", k)
+	}
+
+	// rewriting and deleting keep the indexes current
+	err = DB().Write("tickets", "2", &Ticket{ID: "2", Procedure: "patch", State: Closed, CreatedAt: day(2)})
+	if err == nil {
+		err = DB().Delete("tickets", "3")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k := keys(ReadTicketsByState(Open)); k != "" {
+		t.Errorf("by state after update: got %s", k)
+	}
+	if k := keys(ReadTicketsByState(Closed)); k != "1,2" {
+		t.Errorf("by state after update: got %s", k)
+	}
+	if k := keys(ReadTicketsCreatedBetween(*day(1), *day(10))); k != "1,2" {
+		t.Errorf("by creation date after delete: got %s", k)
+	}
+}
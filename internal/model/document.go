@@ -5,6 +5,7 @@ import "time"
 type Document struct {
 	Name    string `yaml:"name"`
 	Acronym string `yaml:"acronym"`
+	Public  bool   `yaml:"public"`
 
 	Revisions      []Revision   `yaml:"majorRevisions"`
 	Satisfies      Satisfaction `yaml:"satisfies"`
@@ -13,4 +14,10 @@ type Document struct {
 	ModifiedAt     time.Time
 	Body           string
 	Language       string // Language code (e.g., "en", "pt-BR")
+
+	// recorded by translate-templates in translations
+	SourceHash   string `yaml:"sourceHash"`
+	SourceCommit string `yaml:"sourceCommit"`
+	// Stale marks translations whose source changed since they were produced
+	Stale bool `yaml:"-"`
 }
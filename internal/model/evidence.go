@@ -0,0 +1,133 @@
+package model
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+)
+
+// Evidence records how a ticket was resolved, as reported by its ticket system.
+type Evidence struct {
+	ClosedBy    string
+	Comments    []*Comment
+	Attachments []*Attachment
+}
+
+// Comment is a comment on a ticket.
+type Comment struct {
+	Author    string     `json:"author,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	Body      string     `json:"body"`
+}
+
+// Attachment is a file attached to a ticket.
+type Attachment struct {
+	ID   string // identifies the attachment to the ticket plugin
+	Name string
+	URL  string
+	Size int64
+}
+
+// EvidenceManifest describes an archived ticket in .comply/evidence.
+type EvidenceManifest struct {
+	Procedure   string                `json:"procedure"`
+	TicketID    string                `json:"ticketId"`
+	Source      string                `json:"source,omitempty"`
+	Name        string                `json:"name"`
+	Link        string                `json:"link,omitempty"`
+	CreatedAt   *time.Time            `json:"createdAt,omitempty"`
+	ClosedAt    *time.Time            `json:"closedAt,omitempty"`
+	ClosedBy    string                `json:"closedBy,omitempty"`
+	HarvestedAt time.Time             `json:"harvestedAt"`
+	Comments    []*Comment            `json:"comments"`
+	Attachments []*ArchivedAttachment `json:"attachments"`
+}
+
+// ArchivedAttachment is an attachment copied into an evidence archive.
+type ArchivedAttachment struct {
+	Name   string `json:"name"`
+	File   string `json:"file"` // relative to the archive
+	URL    string `json:"url,omitempty"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// EvidenceManifestFile is the name of the manifest within each archive.
+const EvidenceManifestFile = "manifest.json"
+
+// EvidenceRoot is the directory holding all evidence archives.
+func EvidenceRoot() string {
+	return filepath.Join(config.ProjectRoot(), ".comply", "evidence")
+}
+
+// EvidencePath is the archive directory for a ticket, relative to EvidenceRoot.
+// Procedure IDs may be read from ticket bodies, which anyone able to edit a
+// ticket controls, so a path that would leave EvidenceRoot is an error.
+func EvidencePath(procedureID string, t *Ticket) (string, error) {
+	for _, element := range []string{procedureID, t.Key()} {
+		if element == "" || strings.ContainsAny(element, `/\`) || strings.Contains(element, "..") {
+			return "", errors.Errorf("invalid evidence path for procedure %q, ticket %q", procedureID, t.Key())
+		}
+	}
+	root := EvidenceRoot()
+	dir := filepath.Join(root, procedureID, t.Key())
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
+		return "", errors.Errorf("evidence for procedure %q, ticket %q would be stored outside %s", procedureID, t.Key(), root)
+	}
+	return rel, nil
+}
+
+// HasEvidence reports whether a ticket has already been archived.
+func HasEvidence(procedureID string, t *Ticket) bool {
+	p, err := EvidencePath(procedureID, t)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(EvidenceRoot(), p, EvidenceManifestFile))
+	return err == nil
+}
+
+// SaveEvidenceManifest writes the manifest into an archive directory.
+func SaveEvidenceManifest(dir string, m *EvidenceManifest) error {
+	b, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "unable to encode evidence manifest")
+	}
+	return ioutil.WriteFile(filepath.Join(dir, EvidenceManifestFile), b, os.FileMode(0644))
+}
+
+// ReadEvidence returns every archived manifest keyed by its path relative to EvidenceRoot.
+func ReadEvidence() (map[string]*EvidenceManifest, error) {
+	manifests := make(map[string]*EvidenceManifest)
+	pattern := filepath.Join(EvidenceRoot(), "*", "*", EvidenceManifestFile)
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read %s", f)
+		}
+		m := &EvidenceManifest{}
+		err = json.Unmarshal(b, m)
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed evidence manifest %s", f)
+		}
+		rel, err := filepath.Rel(EvidenceRoot(), filepath.Dir(f))
+		if err != nil {
+			return nil, err
+		}
+		manifests[filepath.ToSlash(rel)] = m
+	}
+	return manifests, nil
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/strongdm/comply/internal/config"
@@ -45,14 +46,64 @@ func ReadData() (*Data, error) {
 	}, nil
 }
 
-// ReadTickets returns all known tickets, or an empty list in the event the ticket cache is empty or unavailable.
+// ReadTickets returns all cached tickets, or an empty list if tickets have never been synced.
 func ReadTickets() ([]*Ticket, error) {
-	rt, err := DB().ReadAll("tickets")
+	rt, err := DB().ReadAll(ticketsCollection)
 	if err != nil {
-		// empty list
-		return []*Ticket{}, nil
+		return nil, errors.Wrap(err, "unable to read ticket cache")
 	}
-	return tickets(rt)
+	tickets, err := tickets(rt)
+	if err != nil {
+		return nil, errors.Wrap(err, "ticket cache is corrupt; run `comply sync --full` to rebuild it")
+	}
+	return tickets, nil
+}
+
+// ReadTicketsForProcedure returns the cached tickets opened for a procedure.
+func ReadTicketsForProcedure(procedureID string) ([]*Ticket, error) {
+	from, to := prefixRange(procedureID)
+	return DB().indexedTickets(byProcedure, from, to)
+}
+
+// ReadTicketsByState returns the cached tickets in a state.
+func ReadTicketsByState(state TicketState) ([]*Ticket, error) {
+	from, to := prefixRange(string(state))
+	return DB().indexedTickets(byState, from, to)
+}
+
+// ReadTicketsCreatedBetween returns the cached tickets created at or after from
+// and before to, oldest first.
+func ReadTicketsCreatedBetween(from, to time.Time) ([]*Ticket, error) {
+	return DB().indexedTickets(byCreated, []byte(from.UTC().Format(createdLayout)), []byte(to.UTC().Format(createdLayout)))
+}
+
+// PruneTickets removes cached tickets from source whose keys are not in keep,
+// along with any unreadable entries, and returns the number removed.
+func PruneTickets(source string, keep map[string]bool) (int, error) {
+	pruned := 0
+	err := DB().Update(func(tx *Tx) error {
+		keys, err := tx.Keys(ticketsCollection)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			t := &Ticket{}
+			err := tx.Read(ticketsCollection, key, t)
+			if err == nil && (t.Source != source || keep[key]) {
+				continue
+			}
+			err = tx.Delete(ticketsCollection, key)
+			if err != nil {
+				return errors.Wrapf(err, "unable to remove cached ticket %s", key)
+			}
+			pruned++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return pruned, nil
 }
 
 func tickets(rawTickets []string) ([]*Ticket, error) {
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+)
+
+// Snapshot is a dated record of compliance program statistics.
+type Snapshot struct {
+	Date time.Time
+
+	ControlsTotal     int
+	ControlsSatisfied int
+
+	ProcedureTotal      int
+	ProcedureOpen       int
+	ProcedureOldestDays int
+
+	AuditOpen   int
+	AuditClosed int
+	AuditTotal  int
+
+	Standards []*StandardCoverage
+}
+
+// StandardCoverage summarizes control satisfaction for a single standard.
+type StandardCoverage struct {
+	Name              string
+	ControlsTotal     int
+	ControlsSatisfied int
+}
+
+const historyDateFormat = "2006-01-02"
+
+func historyDir() string {
+	return filepath.Join(config.ProjectRoot(), ".comply", "history")
+}
+
+// SaveSnapshot persists a snapshot to .comply/history; later snapshots on the same day replace earlier ones.
+func SaveSnapshot(s *Snapshot) error {
+	err := os.MkdirAll(historyDir(), os.FileMode(0755))
+	if err != nil {
+		return errors.Wrap(err, "could not create directory .comply/history")
+	}
+
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "unable to encode snapshot")
+	}
+
+	// write beside the snapshot and rename, so an interrupted build never leaves a truncated file
+	f, err := ioutil.TempFile(historyDir(), ".snapshot-")
+	if err != nil {
+		return errors.Wrap(err, "unable to write snapshot")
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), os.FileMode(0644))
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(historyDir(), s.Date.Format(historyDateFormat)+".json"))
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to write snapshot")
+	}
+	return nil
+}
+
+// ReadHistory returns all snapshots recorded on or after since, oldest first.
+// Malformed snapshots are skipped with a warning rather than failing the build.
+func ReadHistory(since time.Time) ([]*Snapshot, error) {
+	files, err := ioutil.ReadDir(historyDir())
+	if os.IsNotExist(err) {
+		return []*Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read .comply/history")
+	}
+
+	history := []*Snapshot{}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		day, err := time.Parse(historyDateFormat, strings.TrimSuffix(f.Name(), ".json"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: ignoring .comply/history/%s, which is not named for a date\n", f.Name())
+			continue
+		}
+		// allow a day either side for the time zone the snapshot was dated in
+		if day.AddDate(0, 0, 2).Before(since) {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(historyDir(), f.Name()))
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read "+f.Name())
+		}
+		s := &Snapshot{}
+		err = json.Unmarshal(b, s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: skipping malformed snapshot .comply/history/%s: %v\n", f.Name(), err)
+			continue
+		}
+		if s.Date.Before(since) {
+			continue
+		}
+		history = append(history, s)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Date.Before(history[j].Date)
+	})
+	return history, nil
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/strongdm/comply/internal/config"
+)
+
+func TestReadHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetProjectRoot(dir)
+	defer config.SetProjectRoot("")
+
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	for _, s := range []*Snapshot{
+		{Date: now.AddDate(-2, 0, 0), ControlsTotal: 1},
+		{Date: now.AddDate(0, -1, 0), ControlsTotal: 2},
+		{Date: now, ControlsTotal: 3},
+	} {
+		err = SaveSnapshot(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// an interrupted write from an earlier release, and a stray file
+	err = ioutil.WriteFile(filepath.Join(historyDir(), "2026-10-01.json"), []byte(`{"Date": "2026-10-01T`), 0644)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(historyDir(), "notes.json"), []byte(`{}`), 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := ReadHistory(now.AddDate(-1, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].ControlsTotal != 2 || history[1].ControlsTotal != 3 {
+		t.Errorf("expected the snapshots of the last year, oldest first, got %+v", history)
+	}
+
+	files, err := ioutil.ReadDir(historyDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 5 {
+		t.Errorf("expected no temporary files to be left behind, found %d files", len(files))
+	}
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// legacyCache is the directory of JSON files that held the cache before comply.db.
+const legacyCache = "cache"
+
+// migrations upgrade the cache schema: migrations[i] takes version i to i+1.
+// Append new migrations; never edit ones that have shipped.
+var migrations = []func(tx *bolt.Tx, dir string) error{
+	importLegacyCache,
+	indexTickets,
+}
+
+// init migrates the cache once per process, and again should the file be removed.
+func (s *Store) init() error {
+	s.initMu.Lock()
+	defer s.initMu.Unlock()
+
+	if s.initialized {
+		if _, err := os.Stat(s.path); err == nil {
+			return nil
+		}
+	}
+	err := s.migrate()
+	if err != nil {
+		return err
+	}
+	s.initialized = true
+	return nil
+}
+
+// migrate brings the cache up to the current schema version.
+func (s *Store) migrate() error {
+	dir := filepath.Dir(s.path)
+	err := os.MkdirAll(dir, os.FileMode(0755))
+	if err != nil {
+		return errors.Wrap(err, "could not create directory .comply")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// most commands find the cache current; check without taking the write lock
+	from := 0
+	if _, statErr := os.Stat(s.path); statErr == nil {
+		err = s.view(func(tx *bolt.Tx) error {
+			from, err = cachedSchema(tx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err = s.checkSchema(from); err != nil || from == len(migrations) {
+		return err
+	}
+
+	err = s.update(func(tx *bolt.Tx) error {
+		// another process may have migrated the cache since
+		from, err = cachedSchema(tx)
+		if err != nil {
+			return err
+		}
+		if err = s.checkSchema(from); err != nil {
+			return err
+		}
+		for version := from; version < len(migrations); version++ {
+			err = migrations[version](tx, dir)
+			if err != nil {
+				return errors.Wrapf(err, "unable to migrate cache to schema %d", version+1)
+			}
+		}
+		meta, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+		if err != nil {
+			return err
+		}
+		return meta.Put([]byte(schemaVersion), []byte(strconv.Itoa(len(migrations))))
+	})
+	if err != nil {
+		return err
+	}
+
+	// keep the imported files out of the way rather than deleting them
+	legacy := filepath.Join(dir, legacyCache)
+	if _, err := os.Stat(legacy); from == 0 && err == nil {
+		err = os.Rename(legacy, legacy+".migrated")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: unable to move aside the migrated cache in %s: %v\n", legacy, err)
+		}
+	}
+	return nil
+}
+
+// cachedSchema reads the cache's schema version, 0 for a new cache.
+func cachedSchema(tx *bolt.Tx) (int, error) {
+	meta := tx.Bucket([]byte(metaBucket))
+	if meta == nil {
+		return 0, nil
+	}
+	v := meta.Get([]byte(schemaVersion))
+	if v == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(string(v))
+	if err != nil {
+		return 0, errors.Wrap(err, "malformed cache schema version")
+	}
+	return version, nil
+}
+
+// checkSchema refuses a cache written by a newer version of comply.
+func (s *Store) checkSchema(version int) error {
+	if version > len(migrations) {
+		return fmt.Errorf("the cache was written by a newer version of comply (schema %d, this version understands %d); upgrade comply or delete %s", version, len(migrations), s.path)
+	}
+	return nil
+}
+
+// importLegacyCache copies the one-JSON-file-per-record cache, one directory
+// per collection, into buckets.
+func importLegacyCache(tx *bolt.Tx, dir string) error {
+	legacy := filepath.Join(dir, legacyCache)
+	collections, err := ioutil.ReadDir(legacy)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, c := range collections {
+		if !c.IsDir() || strings.HasPrefix(c.Name(), "_") {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(legacy, c.Name()))
+		if err != nil {
+			return err
+		}
+		b, err := tx.CreateBucketIfNotExists([]byte(c.Name()))
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+				continue
+			}
+			data, err := ioutil.ReadFile(filepath.Join(legacy, c.Name(), f.Name()))
+			if err != nil {
+				return err
+			}
+			err = b.Put([]byte(strings.TrimSuffix(f.Name(), ".json")), data)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// indexTickets builds the ticket indexes from scratch.
+func indexTickets(tx *bolt.Tx, dir string) error {
+	for _, index := range []string{byProcedure, byState, byCreated} {
+		if tx.Bucket([]byte(index)) != nil {
+			err := tx.DeleteBucket([]byte(index))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	b := tx.Bucket([]byte(ticketsCollection))
+	if b == nil {
+		return nil
+	}
+	t := &Tx{tx: tx}
+	return b.ForEach(func(k, v []byte) error {
+		return t.reindex(string(k), nil, v)
+	})
+}
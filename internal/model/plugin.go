@@ -2,15 +2,20 @@ package model
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/pkg/errors"
 	"github.com/strongdm/comply/internal/config"
 )
 
 var tsPluginsMu sync.Mutex
 var tsPlugins = make(map[TicketSystem]TicketPlugin)
-var tsConfigureOnce sync.Once
+var tsConfigureOnce = make(map[TicketSystem]*sync.Once)
+var tsConfigureErr = make(map[TicketSystem]error)
 
 // TicketSystem is the type of ticket database.
 type TicketSystem string
@@ -22,6 +27,12 @@ const (
 	GitHub = TicketSystem(config.GitHub)
 	// GitLab from GitLab.
 	GitLab = TicketSystem(config.GitLab)
+	// Gitea from Gitea, also serving Forgejo.
+	Gitea = TicketSystem(config.Gitea)
+	// AzureDevOps Boards from Microsoft.
+	AzureDevOps = TicketSystem(config.AzureDevOps)
+	// Local markdown files in the compliance repository.
+	Local = TicketSystem(config.Local)
 	// NoTickets indicates no ticketing system integration.
 	NoTickets = TicketSystem(config.NoTickets)
 )
@@ -35,18 +46,73 @@ type TicketLinks struct {
 
 // TicketPlugin models support for ticketing systems.
 type TicketPlugin interface {
+	// Get returns a single ticket by ID, or nil if no such ticket exists.
 	Get(ID string) (*Ticket, error)
+	// FindOpen returns all open comply tickets.
 	FindOpen() ([]*Ticket, error)
+	// FindByTag returns all tickets carrying the valued tag "name:value".
 	FindByTag(name, value string) ([]*Ticket, error)
+	// FindByTagName returns all tickets carrying the tag name.
 	FindByTagName(name string) ([]*Ticket, error)
+	// Create opens a new ticket; on success ID, Link and timestamps are populated from the ticket system.
 	Create(ticket *Ticket, labels []string) error
 	Configure(map[string]interface{}) error
 	Prompts() map[string]string
 	Links() TicketLinks
+	// LinkFor returns the URL of a ticket in the ticket system's UI.
 	LinkFor(ticket *Ticket) string
 	Configured() bool
 }
 
+// TicketUpdater is implemented by ticket plugins that can modify existing tickets.
+type TicketUpdater interface {
+	// Comment adds a comment to a ticket.
+	Comment(ID, comment string) error
+	// Close closes a ticket.
+	Close(ID string) error
+}
+
+// EvidenceFetcher is implemented by ticket plugins that can report how a ticket was resolved.
+type EvidenceFetcher interface {
+	// Evidence returns a ticket's comments, attachments and closing user.
+	Evidence(ID string) (*Evidence, error)
+	// Download fetches the content of an attachment returned by Evidence.
+	Download(a *Attachment) (io.ReadCloser, error)
+}
+
+// IncrementalFinder is implemented by ticket plugins that can limit a search to recently updated tickets.
+type IncrementalFinder interface {
+	// FindByTagNameSince returns the tickets carrying the tag name updated at or after since.
+	FindByTagNameSince(name string, since time.Time) ([]*Ticket, error)
+}
+
+// WebhookReceiver is implemented by ticket plugins that accept change notifications pushed by the ticket system.
+type WebhookReceiver interface {
+	// Webhook authenticates a notification, returning ErrWebhookRejected unless
+	// it carries the configured secret, and returns the ID of the ticket it
+	// concerns, or "" if it concerns none.
+	Webhook(r *http.Request, body []byte) (string, error)
+}
+
+// TicketPublisher is implemented by ticket plugins whose tickets can only be
+// linked from the dashboard once copied into the generated site.
+type TicketPublisher interface {
+	// Publish copies tickets into the output directory, at the paths LinkFor returns.
+	Publish(output string) error
+}
+
+// AttributedCloser is implemented by ticket plugins that record who closed a
+// ticket themselves, rather than relying on the ticket system's accounts.
+type AttributedCloser interface {
+	// CloseAs closes a ticket on behalf of closedBy.
+	CloseAs(ID, closedBy string) error
+}
+
+// TagFor formats a valued tag as used by FindByTag.
+func TagFor(name, value string) string {
+	return name + ":" + value
+}
+
 // GetPlugin loads the ticketing database.
 func GetPlugin(ts TicketSystem) TicketPlugin {
 	tsPluginsMu.Lock()
@@ -61,8 +127,14 @@ func GetPlugin(ts TicketSystem) TicketPlugin {
 		panic("Unknown ticket system: " + ts)
 	}
 
+	once, ok := tsConfigureOnce[ts]
+	if !ok {
+		once = &sync.Once{}
+		tsConfigureOnce[ts] = once
+	}
+
 	if config.Exists() {
-		tsConfigureOnce.Do(func() {
+		once.Do(func() {
 			ticketsMap := config.Config().Tickets
 			hasTickets := true
 
@@ -72,6 +144,10 @@ func GetPlugin(ts TicketSystem) TicketPlugin {
 			}
 
 			if hasTickets {
+				if cfg == nil {
+					// an empty block, e.g. `local:`
+					cfg = map[interface{}]interface{}{}
+				}
 				cfgTyped, ok := cfg.(map[interface{}]interface{})
 				if !ok {
 					spew.Dump(cfg)
@@ -89,15 +165,42 @@ func GetPlugin(ts TicketSystem) TicketPlugin {
 				}
 				err := tp.Configure(cfgStringed)
 				if err != nil {
-					panic(fmt.Sprintf("Configuration error `%s` in project YAML", err))
+					// e.g. a secret that can't be resolved; commands that don't
+					// talk to the ticket system must still work
+					tsConfigureErr[ts] = errors.Wrapf(err, "%s ticket system is misconfigured in comply.yml", ts)
 				}
 			}
 		})
 	}
 
+	if err := tsConfigureErr[ts]; err != nil {
+		return &unconfiguredTicketSystem{err: err}
+	}
 	return tp
 }
 
+// ConfigurationError returns the reason a configured ticket system can't be used, if any.
+func ConfigurationError(ts TicketSystem) error {
+	GetPlugin(ts)
+	tsPluginsMu.Lock()
+	defer tsPluginsMu.Unlock()
+	return tsConfigureErr[ts]
+}
+
+// GetPluginFor loads the ticket system a ticket was synced from; tickets cached
+// before multiple ticket systems were supported belong to the default one.
+func GetPluginFor(t *Ticket) (TicketPlugin, error) {
+	ts := t.Source
+	if ts == "" {
+		var err error
+		ts, err = config.Config().TicketSystem()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return GetPlugin(TicketSystem(ts)), nil
+}
+
 // Register ticketing system plugin.
 func Register(ts TicketSystem, plugin TicketPlugin) {
 	tsPluginsMu.Lock()
@@ -142,3 +245,40 @@ func (*noopTicketSystem) LinkFor(ticket *Ticket) string {
 func (*noopTicketSystem) Configured() bool {
 	return false
 }
+
+// unconfiguredTicketSystem stands in for a ticket system whose configuration
+// could not be applied, failing every request with the reason.
+type unconfiguredTicketSystem struct {
+	err error
+}
+
+func (u *unconfiguredTicketSystem) Get(ID string) (*Ticket, error) {
+	return nil, u.err
+}
+func (u *unconfiguredTicketSystem) FindOpen() ([]*Ticket, error) {
+	return nil, u.err
+}
+func (u *unconfiguredTicketSystem) FindByTag(name, value string) ([]*Ticket, error) {
+	return nil, u.err
+}
+func (u *unconfiguredTicketSystem) FindByTagName(name string) ([]*Ticket, error) {
+	return nil, u.err
+}
+func (u *unconfiguredTicketSystem) Create(ticket *Ticket, labels []string) error {
+	return u.err
+}
+func (u *unconfiguredTicketSystem) Configure(map[string]interface{}) error {
+	return u.err
+}
+func (*unconfiguredTicketSystem) Prompts() map[string]string {
+	return make(map[string]string)
+}
+func (*unconfiguredTicketSystem) Links() TicketLinks {
+	return TicketLinks{}
+}
+func (*unconfiguredTicketSystem) LinkFor(ticket *Ticket) string {
+	return ""
+}
+func (*unconfiguredTicketSystem) Configured() bool {
+	return false
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/comply/internal/config"
+)
+
+type secretPlugin struct {
+	noopTicketSystem
+	token string
+}
+
+func (s *secretPlugin) Configure(cfg map[string]interface{}) error {
+	token, err := config.ResolveSecret(cfg["token"].(string))
+	s.token = token
+	return err
+}
+
+func (s *secretPlugin) Configured() bool {
+	return s.token != ""
+}
+
+func TestGetPluginUnresolvedSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetProjectRoot(dir)
+	defer config.SetProjectRoot("")
+
+	err = ioutil.WriteFile(filepath.Join(dir, "comply.yml"), []byte("name: test\ntickets:\n  secret-test:\n    token: ${COMPLY_TEST_UNSET_TOKEN}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// other tests mock the configuration
+	mocked := config.Config
+	defer func() { config.Config = mocked }()
+	config.Config = func() *config.Project {
+		return &config.Project{Tickets: map[string]interface{}{
+			"secret-test": map[interface{}]interface{}{"token": "${COMPLY_TEST_UNSET_TOKEN}"},
+		}}
+	}
+	os.Unsetenv("COMPLY_TEST_UNSET_TOKEN")
+	ts := TicketSystem("secret-test")
+	Register(ts, &secretPlugin{})
+
+	// must not panic: commands that never talk to the ticket system still run
+	tp := GetPlugin(ts)
+	if tp.Configured() {
+		t.Error("expected the ticket system to be unconfigured")
+	}
+	if _, err := tp.FindOpen(); err == nil || !strings.Contains(err.Error(), "COMPLY_TEST_UNSET_TOKEN") {
+		t.Errorf("expected requests to report the missing secret, got %v", err)
+	}
+	if err := ConfigurationError(ts); err == nil {
+		t.Error("expected a configuration error")
+	}
+}
@@ -1,11 +1,28 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
 
 type Procedure struct {
-	Name string `yaml:"name"`
-	ID   string `yaml:"id"`
-	Cron string `yaml:"cron"`
+	Name         string `yaml:"name"`
+	ID           string `yaml:"id"`
+	Cron         string `yaml:"cron"`
+	TicketSystem string `yaml:"ticketSystem,omitempty"` // overrides the default ticket system
+	Timezone     string `yaml:"timezone,omitempty"`     // IANA time zone the cron expression is evaluated in
+	CatchUp      string `yaml:"catchUp,omitempty"`      // none, latest (default) or all missed occurrences
+
+	// ticket fields applied to tickets created for this procedure
+	Assignee string   `yaml:"assignee,omitempty"`
+	Priority string   `yaml:"priority,omitempty"`
+	DueIn    string   `yaml:"dueIn,omitempty"` // e.g. 7d, 2w or 48h
+	Labels   []string `yaml:"labels,omitempty"`
+
+	// OpenTickets overrides scheduler.openTickets for this procedure
+	OpenTickets string `yaml:"openTickets,omitempty"`
 
 	Revisions      []Revision   `yaml:"majorRevisions"`
 	Satisfies      Satisfaction `yaml:"satisfies"`
@@ -15,3 +32,28 @@ type Procedure struct {
 	Body           string
 	Language       string // Language code (e.g., "en", "pt-BR")
 }
+
+var dueInDays = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// DueAt computes the due date of a ticket created at now, or nil when the procedure sets no dueIn.
+func (p *Procedure) DueAt(now time.Time) (*time.Time, error) {
+	if p.DueIn == "" {
+		return nil, nil
+	}
+
+	var due time.Time
+	if m := dueInDays.FindStringSubmatch(p.DueIn); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if m[2] == "w" {
+			n *= 7
+		}
+		due = now.AddDate(0, 0, n)
+	} else {
+		d, err := time.ParseDuration(p.DueIn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dueIn %q for procedure %s; use e.g. 7d, 2w or 48h", p.DueIn, p.ID)
+		}
+		due = now.Add(d)
+	}
+	return &due, nil
+}
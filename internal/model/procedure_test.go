@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDueAt(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for dueIn, want := range map[string]time.Time{
+		"7d":  time.Date(2020, 1, 8, 12, 0, 0, 0, time.UTC),
+		"2w":  time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC),
+		"36h": time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC),
+	} {
+		p := &Procedure{ID: "p", DueIn: dueIn}
+		due, err := p.DueAt(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if due == nil || !due.Equal(want) {
+			t.Errorf("dueIn %s: got %v, want %v", dueIn, due, want)
+		}
+	}
+
+	due, err := (&Procedure{}).DueAt(now)
+	if err != nil || due != nil {
+		t.Errorf("expected no due date without dueIn, got %v, %v", due, err)
+	}
+
+	_, err = (&Procedure{DueIn: "soon"}).DueAt(now)
+	if err == nil {
+		t.Error("expected error for malformed dueIn")
+	}
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	// embed the zone database so procedure time zones resolve in minimal containers
+	_ "time/tzdata"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron"
+)
+
+// descriptors extends the descriptors understood by robfig/cron.
+var descriptors = map[string]string{
+	"@quarterly": "0 0 0 1 1,4,7,10 *",
+}
+
+// Schedule is a procedure's cron schedule, evaluated in the procedure's time zone.
+type Schedule struct {
+	spec cron.Schedule
+	loc  *time.Location
+}
+
+// ParseSchedule accepts 5-field (minute first) and 6-field (second first) cron
+// expressions, and descriptors such as @daily, @monthly, @quarterly and @yearly.
+// timezone is an IANA name such as Australia/Sydney; empty means UTC.
+func ParseSchedule(expr, timezone string) (*Schedule, error) {
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unknown time zone %q", timezone)
+		}
+	}
+
+	expr = strings.TrimSpace(expr)
+	if d, ok := descriptors[expr]; ok {
+		expr = d
+	}
+
+	var spec cron.Schedule
+	var err error
+	switch {
+	case strings.HasPrefix(expr, "@every"):
+		// intervals aren't anchored to the calendar, so occurrences can't be identified
+		return nil, fmt.Errorf("%q is not supported; use a cron expression or a calendar descriptor", expr)
+	case strings.HasPrefix(expr, "@"):
+		spec, err = cron.Parse(expr)
+	default:
+		switch len(strings.Fields(expr)) {
+		case 5:
+			spec, err = cron.ParseStandard(expr)
+		case 6:
+			spec, err = cron.Parse(expr)
+		default:
+			err = fmt.Errorf("expected 5 or 6 fields, found %d", len(strings.Fields(expr)))
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cron expression %q", expr)
+	}
+	return &Schedule{spec: spec, loc: loc}, nil
+}
+
+// Schedule parses the procedure's cron expression in its time zone.
+func (p *Procedure) Schedule() (*Schedule, error) {
+	if p.Cron == "" {
+		return nil, fmt.Errorf("procedure %s has no schedule", p.ID)
+	}
+	s, err := ParseSchedule(p.Cron, p.Timezone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "procedure %s", p.ID)
+	}
+	return s, nil
+}
+
+// Location is the time zone the schedule is evaluated in.
+func (s *Schedule) Location() *time.Location {
+	return s.loc
+}
+
+// Next returns the first activation after t, or the zero time if there is none
+// within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	return s.spec.Next(t.In(s.loc))
+}
+
+// Prev returns the latest activation at or before t, looking back no further than since.
+func (s *Schedule) Prev(t, since time.Time) (time.Time, bool) {
+	// widen the window until it contains an activation...
+	window := time.Minute
+	for {
+		start := t.Add(-window)
+		if start.Before(since) {
+			start = since
+		}
+		if next := s.Next(start); !next.IsZero() && !next.After(t) {
+			break
+		}
+		if !start.After(since) {
+			return time.Time{}, false
+		}
+		window *= 2
+	}
+
+	// ...then find the latest start whose next activation is still at or before t
+	lo, hi := t.Add(-window), t
+	if lo.Before(since) {
+		lo = since
+	}
+	n := sort.Search(int(hi.Sub(lo)/time.Second)+1, func(i int) bool {
+		next := s.Next(lo.Add(time.Duration(i) * time.Second))
+		return next.IsZero() || next.After(t)
+	})
+	return s.Next(lo.Add(time.Duration(n-1) * time.Second)), true
+}
+
+// Between lists the activations after from and at or before to, oldest first,
+// keeping only the latest limit of them.
+func (s *Schedule) Between(from, to time.Time, limit int) []time.Time {
+	var result []time.Time
+	for next := s.Next(from); !next.IsZero() && !next.After(to); next = s.Next(next) {
+		result = append(result, next)
+		if len(result) > limit {
+			result = result[1:]
+		}
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		expr, timezone string
+		want           time.Time
+	}{
+		// 6 fields, seconds first
+		{"0 0 0 1 * *", "", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		// 5 fields, minutes first
+		{"15 10 * * *", "", time.Date(2026, 10, 18, 10, 15, 0, 0, time.UTC)},
+		{"@quarterly", "", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", "", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		// midnight in Sydney is the previous afternoon in UTC
+		{"0 0 1 * *", "Australia/Sydney", time.Date(2026, 10, 31, 13, 0, 0, 0, time.UTC)},
+	} {
+		s, err := ParseSchedule(tc.expr, tc.timezone)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := s.Next(now); !got.Equal(tc.want) {
+			t.Errorf("%s (%s): got %v, want %v", tc.expr, tc.timezone, got.UTC(), tc.want)
+		}
+	}
+
+	for _, expr := range []string{"@every 1h", "0 0 *", "* * * * * * *"} {
+		if _, err := ParseSchedule(expr, ""); err == nil {
+			t.Errorf("expected %q to be rejected", expr)
+		}
+	}
+	if _, err := ParseSchedule("@daily", "Mars/Olympus_Mons"); err == nil {
+		t.Error("expected an unknown time zone to be rejected")
+	}
+}
+
+func TestSchedulePrev(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	since := now.Add(-24 * (365 + 30) * time.Hour)
+
+	for expr, want := range map[string]time.Time{
+		"*/5 * * * *": time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC),
+		"0 0 15 * *":  time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		"@quarterly":  time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 0 1 3 *": time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		"0 12 29 2 *": time.Time{}, // not within the lookback
+		"0 9 * * MON": time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC),
+	} {
+		s, err := ParseSchedule(expr, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := s.Prev(now, since)
+		if ok != !want.IsZero() || !got.Equal(want) {
+			t.Errorf("%s: got %v (%v), want %v", expr, got, ok, want)
+		}
+	}
+
+	s, _ := ParseSchedule("@monthly", "")
+	missed := s.Between(time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC), now, 10)
+	if len(missed) != 4 || missed[0].Month() != time.July || missed[3].Month() != time.October {
+		t.Errorf("unexpected occurrences %v", missed)
+	}
+	missed = s.Between(time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC), now, 2)
+	if len(missed) != 2 || missed[0].Month() != time.September || missed[1].Month() != time.October {
+		t.Errorf("expected the latest occurrences, got %v", missed)
+	}
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SyncState records the most recent sync of a ticket system into the cache.
+type SyncState struct {
+	Source       string    `json:"source"`
+	LastSync     time.Time `json:"lastSync"`
+	LastFullSync time.Time `json:"lastFullSync"`
+	// Full is set when the last sync fetched every ticket rather than recent changes
+	Full    bool `json:"full"`
+	Fetched int  `json:"fetched"`
+	Pruned  int  `json:"pruned"`
+}
+
+const syncCollection = "sync"
+
+// ReadSyncState returns the sync state of a ticket system, or nil if it has never been synced.
+func ReadSyncState(source string) (*SyncState, error) {
+	s := &SyncState{}
+	err := DB().Read(syncCollection, source, s)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read sync state of %s", source)
+	}
+	return s, nil
+}
+
+// SaveSyncState records the sync state of a ticket system.
+func SaveSyncState(s *SyncState) error {
+	return errors.Wrapf(DB().Write(syncCollection, s.Source, s), "unable to record sync state of %s", s.Source)
+}
+
+// ReadSyncStates returns the sync state of every ticket system synced so far, ordered by source.
+func ReadSyncStates() ([]*SyncState, error) {
+	keys, err := DB().Keys(syncCollection)
+	if err != nil {
+		return nil, err
+	}
+	var states []*SyncState
+	for _, key := range keys {
+		s, err := ReadSyncState(key)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			states = append(states, s)
+		}
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Source < states[j].Source
+	})
+	return states, nil
+}
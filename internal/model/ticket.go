@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -14,16 +16,84 @@ const (
 
 type Ticket struct {
 	ID         string
+	Source     string // ticket system the ticket belongs to
 	Name       string
 	State      TicketState
 	Body       string
+	Link       string
+	Procedure  string // ID of the procedure the ticket was opened for, when recorded natively
+	Occurrence string // scheduled occurrence the ticket was opened for, e.g. patch@2026-10, when labelled by earlier releases
+	Assignee   string
+	Priority   string
 	Attributes map[string]interface{}
+	Checklist  Checklist // task list progress in Body, computed during sync
 	ClosedAt   *time.Time
 	CreatedAt  *time.Time
 	UpdatedAt  *time.Time
+	DueAt      *time.Time
 }
 
+// Overdue reports whether an open ticket is past its due date.
+func (t *Ticket) Overdue(now time.Time) bool {
+	return t.State == Open && t.DueAt != nil && t.DueAt.Before(now)
+}
+
+// ClosedIncomplete reports whether a ticket was closed with checklist items left unchecked.
+func (t *Ticket) ClosedIncomplete() bool {
+	return t.State == Closed && t.Checklist.Unchecked() > 0
+}
+
+// Key identifies the ticket in the local cache; IDs are only unique within a ticket system.
+func (t *Ticket) Key() string {
+	if t.Source == "" {
+		return t.ID
+	}
+	return t.Source + "-" + t.ID
+}
+
+// ProcedureTag labels procedure tickets; TagFor(ProcedureTag, id) links a ticket to its procedure.
+const ProcedureTag = "comply-procedure"
+
+// OccurrenceTag names the trailer recording the occurrence a scheduled ticket
+// was opened for. Earlier releases labelled tickets with TagFor(OccurrenceTag, key).
+const OccurrenceTag = "comply-occurrence"
+
+var procedureTrailer = regexp.MustCompile(`<!--\s*comply-procedure:\s*(\S+)\s*-->`)
+var occurrenceTrailer = regexp.MustCompile(`<!--\s*comply-occurrence:\s*(\S+)\s*-->`)
+
+// ProcedureTrailer returns the hidden comment appended to ticket bodies, which
+// survives edits to the visible footer on systems that render markdown.
+func ProcedureTrailer(procedureID string) string {
+	return fmt.Sprintf("<!-- %s: %s -->", ProcedureTag, procedureID)
+}
+
+// OccurrenceTrailer returns the hidden comment recording a scheduled ticket's
+// occurrence, which keeps one label per occurrence out of the ticket system.
+func OccurrenceTrailer(key string) string {
+	return fmt.Sprintf("<!-- %s: %s -->", OccurrenceTag, key)
+}
+
+// OccurrenceID returns the scheduled occurrence a ticket was opened for, if any.
+func (t *Ticket) OccurrenceID() string {
+	if t.Occurrence != "" {
+		return t.Occurrence
+	}
+	if m := occurrenceTrailer.FindStringSubmatch(t.Body); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// ProcedureID returns the procedure a ticket was opened for, preferring the
+// link recorded by the ticket system over the ticket body.
 func (t *Ticket) ProcedureID() string {
+	if t.Procedure != "" {
+		return t.Procedure
+	}
+	if m := procedureTrailer.FindStringSubmatch(t.Body); m != nil {
+		return m[1]
+	}
+	// tickets created before the procedure was recorded natively
 	md := t.metadata()
 	if v, ok := md["Procedure-ID"]; ok {
 		return v
@@ -35,18 +105,33 @@ func (t *Ticket) metadata() map[string]string {
 	md := make(map[string]string)
 	lines := strings.Split(t.Body, "\n")
 	for _, line := range lines {
-		// TODO: transition to RFC822 parsing
-		if strings.Contains(line, ":") {
-			tokens := strings.Split(line, ":")
-			if len(tokens) != 2 {
-				continue
-			}
-			md[strings.TrimSpace(tokens[0])] = strings.TrimSpace(tokens[1])
+		tokens := strings.SplitN(line, ":", 2)
+		if len(tokens) != 2 {
+			continue
 		}
+		md[strings.TrimSpace(tokens[0])] = strings.TrimSpace(tokens[1])
 	}
 	return md
 }
 
+// PriorityTag records priority as a label on ticket systems without a native priority field.
+const PriorityTag = "priority"
+
+// SetLabel records a ticket system label, picking up priority and procedure
+// labels formatted by TagFor.
+func (t *Ticket) SetLabel(label string) {
+	t.SetBool(label)
+	switch {
+	case strings.HasPrefix(label, PriorityTag+":"):
+		t.Priority = strings.TrimPrefix(label, PriorityTag+":")
+	case strings.HasPrefix(label, ProcedureTag+":"):
+		t.Procedure = strings.TrimPrefix(label, ProcedureTag+":")
+	case strings.HasPrefix(label, OccurrenceTag+":"):
+		// tickets opened before the occurrence moved to a trailer
+		t.Occurrence = strings.TrimPrefix(label, OccurrenceTag+":")
+	}
+}
+
 func (t *Ticket) SetBool(name string) {
 	t.Attributes[name] = true
 }
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProcedureID(t *testing.T) {
+	for name, tc := range map[string]struct {
+		ticket *Ticket
+		want   string
+	}{
+		"label": {
+			ticket: func() *Ticket {
+				tk := &Ticket{Attributes: make(map[string]interface{}), Body: "Procedure-ID: stale"}
+				tk.SetLabel("comply")
+				tk.SetLabel(TagFor(ProcedureTag, "onboard"))
+				return tk
+			}(),
+			want: "onboard",
+		},
+		"trailer with edited footer": {
+			ticket: &Ticket{Body: "Steps\n\n---\nProcedure ID was here\n\n" + ProcedureTrailer("offboard")},
+			want:   "offboard",
+		},
+		"legacy footer": {
+			ticket: &Ticket{Body: "Steps\n\n\n---\nProcedure-ID: workstation"},
+			want:   "workstation",
+		},
+		"colons in body": {
+			ticket: &Ticket{Body: "See https://example.com/runbook at 10:30\n\n---\nProcedure-ID: patch"},
+			want:   "patch",
+		},
+		"missing": {
+			ticket: &Ticket{Body: "no procedure here"},
+			want:   "",
+		},
+	} {
+		if got := tc.ticket.ProcedureID(); got != tc.want {
+			t.Errorf("%s: got %q, want %q", name, got, tc.want)
+		}
+	}
+}
+
+func TestOccurrenceID(t *testing.T) {
+	trailer := &Ticket{Body: "Steps\n\n" + ProcedureTrailer("patch") + "\n" + OccurrenceTrailer("patch@2026-10")}
+	if got := trailer.OccurrenceID(); got != "patch@2026-10" {
+		t.Errorf("trailer: got %q", got)
+	}
+	legacy := &Ticket{Attributes: make(map[string]interface{}), Body: "Steps"}
+	legacy.SetLabel(TagFor(OccurrenceTag, "patch@2026-09"))
+	if got := legacy.OccurrenceID(); got != "patch@2026-09" {
+		t.Errorf("label: got %q", got)
+	}
+	if got := (&Ticket{Body: ProcedureTrailer("patch")}).OccurrenceID(); got != "" {
+		t.Errorf("on demand: got %q", got)
+	}
+}
+
+func TestOverdue(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	if !(&Ticket{State: Open, DueAt: &past}).Overdue(now) {
+		t.Error("open ticket past its due date should be overdue")
+	}
+	if (&Ticket{State: Closed, DueAt: &past}).Overdue(now) {
+		t.Error("closed ticket should not be overdue")
+	}
+	if (&Ticket{State: Open, DueAt: &future}).Overdue(now) {
+		t.Error("ticket due in the future should not be overdue")
+	}
+	if (&Ticket{State: Open}).Overdue(now) {
+		t.Error("ticket without a due date should not be overdue")
+	}
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// TranslationStatus compares a translation with the source it was produced from.
+type TranslationStatus struct {
+	Path     string
+	Source   string
+	Language string
+	// SourceCommit is the last commit of the source when the translation was produced, if recorded
+	SourceCommit string
+	Stale        bool
+	Reason       string
+}
+
+// translationSource is recorded in the metadata of generated translations.
+type translationSource struct {
+	SourceHash   string `yaml:"sourceHash"`
+	SourceCommit string `yaml:"sourceCommit"`
+}
+
+// SourceHash identifies the content of a file that is translated.
+func SourceHash(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// SourceCommit returns the last commit that changed path, or "" outside a git repository.
+func SourceCommit(path string) string {
+	out, err := exec.Command("git", "log", "-n", "1", "--pretty=format:%H", "--", path).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// TranslationSource returns the file a translation was produced from, e.g.
+// policies/access.md for policies/access.pt-BR.md, or "" if path is not a translation.
+func TranslationSource(path string) string {
+	lang := extractLanguageFromFilename(filepath.Base(path))
+	if lang == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(strings.TrimSuffix(path, ext), "."+lang) + ext
+}
+
+// TranslationStale reports whether a translated document's source changed
+// since it was produced. Translations that record no source hash can't be
+// vouched for and count as stale.
+func TranslationStale(doc *Document) bool {
+	if doc.Language == "" {
+		return false
+	}
+	current, err := SourceHash(TranslationSource(doc.FullPath))
+	return err != nil || doc.SourceHash != current
+}
+
+// CheckTranslation compares the source hash recorded in a translation with its source's current content.
+func CheckTranslation(path string) (*TranslationStatus, error) {
+	status := &TranslationStatus{
+		Path:     path,
+		Source:   TranslationSource(path),
+		Language: extractLanguageFromFilename(filepath.Base(path)),
+	}
+	if status.Source == "" {
+		return nil, fmt.Errorf("%s is not a translation", path)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var recorded translationSource
+	if meta := strings.SplitN(string(content), "\n---", 2); len(meta) == 2 {
+		err = yaml.Unmarshal([]byte(meta[0]), &recorded)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse "+path)
+		}
+	}
+	status.SourceCommit = recorded.SourceCommit
+
+	current, err := SourceHash(status.Source)
+	switch {
+	case os.IsNotExist(err):
+		status.Stale, status.Reason = true, "source no longer exists"
+	case err != nil:
+		return nil, err
+	case recorded.SourceHash == "":
+		status.Stale, status.Reason = true, "no source hash recorded"
+	case recorded.SourceHash != current && recorded.SourceCommit != "":
+		status.Stale, status.Reason = true, "source changed since commit "+shortCommit(recorded.SourceCommit)
+	case recorded.SourceHash != current:
+		status.Stale, status.Reason = true, "source changed since translation"
+	}
+	return status, nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTranslationSource(t *testing.T) {
+	cases := map[string]string{
+		"policies/access.pt-BR.md": "policies/access.md",
+		"narratives/system.de.md":  "narratives/system.md",
+		"policies/access.md":       "",
+	}
+	for path, expected := range cases {
+		if source := TranslationSource(path); source != expected {
+			t.Errorf("expected %s to be translated from %q, got %q", path, expected, source)
+		}
+	}
+}
+
+func TestCheckTranslation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-translation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "access.md")
+	translation := filepath.Join(dir, "access.pt-BR.md")
+	write := func(path, content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func() *TranslationStatus {
+		status, err := CheckTranslation(translation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	write(source, "name: Access Policy\n---\n# Purpose\n")
+	write(translation, "name: Política de Acesso\n---\n# Propósito\n")
+	if status := check(); !status.Stale || status.Reason != "no source hash recorded" {
+		t.Errorf("expected a translation without a source hash to be stale, got %+v", status)
+	}
+
+	hash, err := SourceHash(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(translation, "name: Política de Acesso\nsourceHash: "+hash+"\nsourceCommit: 0123456789abcdef\n---\n# Propósito\n")
+	if status := check(); status.Stale || status.Language != "pt-BR" || status.Source != source {
+		t.Errorf("expected an up to date pt-BR translation of %s, got %+v", source, status)
+	}
+
+	write(source, "name: Access Policy\n---\n# Purpose\n\nRevised.\n")
+	if status := check(); !status.Stale || status.Reason != "source changed since commit 0123456789ab" {
+		t.Errorf("expected the translation to be stale once its source changed, got %+v", status)
+	}
+
+	doc := &Document{FullPath: translation, Language: "pt-BR", SourceHash: hash}
+	if !TranslationStale(doc) {
+		t.Error("expected the document to be marked stale")
+	}
+
+	os.Remove(source)
+	if status := check(); !status.Stale || status.Reason != "source no longer exists" {
+		t.Errorf("expected an orphaned translation to be stale, got %+v", status)
+	}
+}
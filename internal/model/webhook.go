@@ -0,0 +1,28 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrWebhookRejected is returned by WebhookReceiver for notifications that fail authentication.
+var ErrWebhookRejected = errors.New("webhook signature or secret does not match")
+
+// ValidSignature checks a "sha256=<hex>" HMAC signature of body, as sent by
+// GitHub and Jira webhooks.
+func ValidSignature(secret string, body []byte, signature string) bool {
+	if secret == "" || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,25 @@
+package model
+
+import "testing"
+
+func TestValidSignature(t *testing.T) {
+	// GitHub's documented example: secret "It's a Secret to Everybody", payload "Hello, World!"
+	secret, body := "It's a Secret to Everybody", []byte("Hello, World!")
+	signature := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	if !ValidSignature(secret, body, signature) {
+		t.Fatal("expected the signature to be valid")
+	}
+	for _, tc := range []struct {
+		secret, signature string
+	}{
+		{"wrong", signature},
+		{"", signature},
+		{secret, "sha1=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"},
+		{secret, "sha256=not-hex"},
+	} {
+		if ValidSignature(tc.secret, body, tc.signature) {
+			t.Errorf("expected %q signed with %q to be rejected", tc.signature, tc.secret)
+		}
+	}
+}
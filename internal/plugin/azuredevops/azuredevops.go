@@ -0,0 +1,624 @@
+package azuredevops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/russross/blackfriday/v2"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+const (
+	cfgOrganization = "organization"
+	cfgProject      = "project"
+	cfgToken        = "token"
+	cfgBaseURL      = "baseURL"
+	cfgWorkItemType = "workItemType"
+	cfgAreaPath     = "areaPath"
+	cfgClosedStates = "closedStates"
+	cfgDescription  = "descriptionFormat"
+	cfgDueDateField = "dueDateField"
+)
+
+var prompts = map[string]string{
+	cfgOrganization: "Azure DevOps Organization",
+	cfgProject:      "Azure DevOps Project",
+	cfgToken:        "Azure DevOps Personal Access Token",
+}
+
+const (
+	apiVersion = "6.0"
+	// batchSize is the maximum number of work items fetched per request.
+	batchSize = 200
+
+	defaultBaseURL      = "https://dev.azure.com"
+	defaultWorkItemType = "Task"
+	defaultDueDateField = "Microsoft.VSTS.Scheduling.DueDate"
+
+	// markdownAPIVersion is the first API version accepting markdown descriptions.
+	markdownAPIVersion = "7.1"
+
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+)
+
+// defaultClosedStates covers the terminal states of the Basic, Agile, Scrum and CMMI processes.
+var defaultClosedStates = []string{"Done", "Closed", "Removed", "Resolved"}
+
+// Prompts are human-readable configuration element names
+func (a *azurePlugin) Prompts() map[string]string {
+	return prompts
+}
+
+// Register causes the Azure DevOps plugin to register itself
+func Register() {
+	model.Register(model.AzureDevOps, &azurePlugin{})
+}
+
+type azurePlugin struct {
+	baseURL      string
+	organization string
+	project      string
+	token        string
+	workItemType string
+	areaPath     string
+	closedStates []string
+	// descriptionFormat is how ticket bodies are stored: markdown, or HTML for
+	// Azure DevOps Server releases that render descriptions only as HTML
+	descriptionFormat string
+	dueDateField      string
+	// hasDueDate caches whether the work item type has dueDateField
+	hasDueDate *bool
+
+	client http.Client
+}
+
+type workItem struct {
+	ID        int                    `json:"id"`
+	Fields    map[string]interface{} `json:"fields"`
+	Relations []relation             `json:"relations"`
+	Links     struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"_links"`
+}
+
+type relation struct {
+	Rel        string `json:"rel"`
+	URL        string `json:"url"`
+	Attributes struct {
+		Name         string `json:"name"`
+		ResourceSize int64  `json:"resourceSize"`
+	} `json:"attributes"`
+}
+
+// workItemUpdate is one revision of a work item, holding the fields it changed.
+type workItemUpdate struct {
+	RevisedBy   interface{} `json:"revisedBy"`
+	RevisedDate *time.Time  `json:"revisedDate"`
+	Fields      map[string]struct {
+		NewValue interface{} `json:"newValue"`
+	} `json:"fields"`
+}
+
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// projectURL is the root of the project in both the API and the web UI.
+func (a *azurePlugin) projectURL() string {
+	return fmt.Sprintf("%s/%s/%s", a.baseURL, url.PathEscape(a.organization), url.PathEscape(a.project))
+}
+
+// do issues an API request relative to the project, decoding a JSON response into out.
+func (a *azurePlugin) do(method, path string, query url.Values, contentType string, in, out interface{}) (*http.Response, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	if query.Get("api-version") == "" {
+		query.Set("api-version", apiVersion)
+	}
+	u := a.projectURL() + "/_apis/wit" + path + "?" + query.Encode()
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	// personal access tokens are sent as the password with an empty username
+	req.SetBasicAuth("", a.token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp, fmt.Errorf("%s %s: %s %s", method, u, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		err = json.NewDecoder(resp.Body).Decode(out)
+		if err != nil {
+			return resp, errors.Wrap(err, "malformed response")
+		}
+	}
+	return resp, nil
+}
+
+func (a *azurePlugin) Get(ID string) (*model.Ticket, error) {
+	var wi workItem
+	resp, err := a.do(http.MethodGet, "/workitems/"+url.PathEscape(ID), nil, "", nil, &wi)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error during Get")
+	}
+	return a.toTicket(&wi), nil
+}
+
+func (a *azurePlugin) Configured() bool {
+	return a.organization != "" && a.project != "" && a.token != ""
+}
+
+func (a *azurePlugin) Links() model.TicketLinks {
+	link := func(tag string, open bool) string {
+		return fmt.Sprintf("%s/_queries/query/?wiql=%s", a.projectURL(), url.QueryEscape(a.wiql(tag, open, time.Time{})))
+	}
+
+	links := model.TicketLinks{}
+	links.AuditAll = link("comply-audit", false)
+	links.AuditOpen = link("comply-audit", true)
+	links.ProcedureAll = link("comply-procedure", false)
+	links.ProcedureOpen = link("comply-procedure", true)
+	return links
+}
+
+func (a *azurePlugin) Configure(cfg map[string]interface{}) error {
+	var err error
+
+	if a.organization, err = getCfg(cfg, cfgOrganization); err != nil {
+		return err
+	}
+	if a.project, err = getCfg(cfg, cfgProject); err != nil {
+		return err
+	}
+	if a.token, err = getCfg(cfg, cfgToken); err != nil {
+		return err
+	}
+
+	if a.baseURL, err = getOptionalCfg(cfg, cfgBaseURL, defaultBaseURL); err != nil {
+		return err
+	}
+	a.baseURL = strings.TrimSuffix(a.baseURL, "/")
+	if a.workItemType, err = getOptionalCfg(cfg, cfgWorkItemType, defaultWorkItemType); err != nil {
+		return err
+	}
+	if a.areaPath, err = getOptionalCfg(cfg, cfgAreaPath, ""); err != nil {
+		return err
+	}
+	if a.descriptionFormat, err = getOptionalCfg(cfg, cfgDescription, formatMarkdown); err != nil {
+		return err
+	}
+	a.descriptionFormat = strings.ToLower(a.descriptionFormat)
+	if a.descriptionFormat != formatMarkdown && a.descriptionFormat != formatHTML {
+		return errors.New("Malformatted key: " + cfgDescription + " must be markdown or html")
+	}
+	if a.dueDateField, err = getOptionalCfg(cfg, cfgDueDateField, defaultDueDateField); err != nil {
+		return err
+	}
+	a.hasDueDate = nil
+
+	a.closedStates = defaultClosedStates
+	if v, ok := cfg[cfgClosedStates]; ok {
+		list, ok := v.([]interface{})
+		if !ok {
+			return errors.New("Malformatted key: " + cfgClosedStates)
+		}
+		a.closedStates = nil
+		for _, s := range list {
+			state, ok := s.(string)
+			if !ok {
+				return errors.New("Malformatted key: " + cfgClosedStates)
+			}
+			a.closedStates = append(a.closedStates, state)
+		}
+	}
+
+	return nil
+}
+
+func getCfg(cfg map[string]interface{}, k string) (string, error) {
+	v, ok := cfg[k]
+	if !ok {
+		return "", errors.New("Missing key: " + k)
+	}
+
+	vS, ok := v.(string)
+	if !ok {
+		return "", errors.New("Malformatted key: " + k)
+	}
+	if !config.IsSecretKey(k) {
+		return vS, nil
+	}
+	vS, err := config.ResolveSecret(vS)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to resolve key: "+k)
+	}
+	return vS, nil
+}
+
+func getOptionalCfg(cfg map[string]interface{}, k, defaultValue string) (string, error) {
+	if _, ok := cfg[k]; !ok {
+		return defaultValue, nil
+	}
+	return getCfg(cfg, k)
+}
+
+func (a *azurePlugin) FindOpen() ([]*model.Ticket, error) {
+	tickets, err := a.query(a.wiql("comply", true, time.Time{}))
+	if err != nil {
+		return nil, errors.Wrap(err, "error during FindOpen")
+	}
+	return tickets, nil
+}
+
+func (a *azurePlugin) FindByTag(name, value string) ([]*model.Ticket, error) {
+	return a.FindByTagName(model.TagFor(name, value))
+}
+
+func (a *azurePlugin) FindByTagName(name string) ([]*model.Ticket, error) {
+	tickets, err := a.query(a.wiql(name, false, time.Time{}))
+	if err != nil {
+		return nil, errors.Wrap(err, "error during FindByTagName")
+	}
+	return tickets, nil
+}
+
+func (a *azurePlugin) FindByTagNameSince(name string, since time.Time) ([]*model.Ticket, error) {
+	tickets, err := a.query(a.wiql(name, false, since))
+	if err != nil {
+		return nil, errors.Wrap(err, "error during FindByTagNameSince")
+	}
+	return tickets, nil
+}
+
+// wiql selects work items in the project carrying tag, optionally excluding
+// closed states and those unchanged since a time.
+func (a *azurePlugin) wiql(tag string, open bool, since time.Time) string {
+	q := fmt.Sprintf("SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = @project AND [System.Tags] CONTAINS %s", quote(tag))
+	if open {
+		var states []string
+		for _, s := range a.closedStates {
+			states = append(states, quote(s))
+		}
+		q += fmt.Sprintf(" AND [System.State] NOT IN (%s)", strings.Join(states, ", "))
+	}
+	if !since.IsZero() {
+		q += fmt.Sprintf(" AND [System.ChangedDate] >= %s", quote(since.UTC().Format(time.RFC3339)))
+	}
+	return q + " ORDER BY [System.Id]"
+}
+
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// query runs a WIQL query and fetches the matching work items in batches.
+func (a *azurePlugin) query(wiql string) ([]*model.Ticket, error) {
+	var result struct {
+		WorkItems []struct {
+			ID int `json:"id"`
+		} `json:"workItems"`
+	}
+	// without timePrecision, date comparisons ignore the time of day
+	_, err := a.do(http.MethodPost, "/wiql", url.Values{"timePrecision": {"true"}}, "application/json", map[string]string{"query": wiql}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	var tickets []*model.Ticket
+	for start := 0; start < len(result.WorkItems); start += batchSize {
+		end := start + batchSize
+		if end > len(result.WorkItems) {
+			end = len(result.WorkItems)
+		}
+		var ids []string
+		for _, wi := range result.WorkItems[start:end] {
+			ids = append(ids, strconv.Itoa(wi.ID))
+		}
+
+		var batch struct {
+			Value []workItem `json:"value"`
+		}
+		_, err = a.do(http.MethodGet, "/workitems", url.Values{
+			"ids":     {strings.Join(ids, ",")},
+			"$expand": {"links"},
+		}, "", nil, &batch)
+		if err != nil {
+			return nil, err
+		}
+		for i := range batch.Value {
+			tickets = append(tickets, a.toTicket(&batch.Value[i]))
+		}
+	}
+	return tickets, nil
+}
+
+func (a *azurePlugin) LinkFor(t *model.Ticket) string {
+	if t.Link != "" {
+		return t.Link
+	}
+	return fmt.Sprintf("%s/_workitems/edit/%s", a.projectURL(), t.ID)
+}
+
+func (a *azurePlugin) Create(ticket *model.Ticket, labels []string) error {
+	ops := []patchOperation{
+		{Op: "add", Path: "/fields/System.Title", Value: ticket.Name},
+	}
+	query := url.Values{}
+	if a.descriptionFormat == formatHTML {
+		ops = append(ops, patchOperation{Op: "add", Path: "/fields/System.Description", Value: string(blackfriday.Run([]byte(ticket.Body)))})
+	} else {
+		// markdown descriptions keep checklists editable in the Azure Boards UI
+		ops = append(ops,
+			patchOperation{Op: "add", Path: "/fields/System.Description", Value: ticket.Body},
+			patchOperation{Op: "add", Path: "/multilineFieldsFormat/System.Description", Value: "Markdown"},
+		)
+		query.Set("api-version", markdownAPIVersion)
+	}
+	ops = append(ops, patchOperation{Op: "add", Path: "/fields/System.Tags", Value: strings.Join(labels, "; ")})
+	if a.areaPath != "" {
+		ops = append(ops, patchOperation{Op: "add", Path: "/fields/System.AreaPath", Value: a.areaPath})
+	}
+	if ticket.Assignee != "" {
+		ops = append(ops, patchOperation{Op: "add", Path: "/fields/System.AssignedTo", Value: ticket.Assignee})
+	}
+	if ticket.DueAt != nil && a.dueDateField != "" {
+		has, err := a.workItemTypeHasDueDate()
+		if err != nil {
+			return err
+		}
+		if has {
+			ops = append(ops, patchOperation{Op: "add", Path: "/fields/" + a.dueDateField, Value: ticket.DueAt.UTC().Format(time.RFC3339)})
+		}
+	}
+	if ticket.Priority != "" {
+		priority, err := toPriority(ticket.Priority)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, patchOperation{Op: "add", Path: "/fields/Microsoft.VSTS.Common.Priority", Value: priority})
+	}
+
+	var created workItem
+	_, err := a.do(http.MethodPost, "/workitems/$"+url.PathEscape(a.workItemType), query, "application/json-patch+json", ops, &created)
+	if err != nil {
+		return errors.Wrap(err, "unable to create ticket")
+	}
+	*ticket = *a.toTicket(&created)
+	return nil
+}
+
+// workItemTypeHasDueDate reports whether the configured work item type has
+// the due date field; not every process template's types do, e.g. the Basic
+// process's Issue.
+func (a *azurePlugin) workItemTypeHasDueDate() (bool, error) {
+	if a.hasDueDate != nil {
+		return *a.hasDueDate, nil
+	}
+	var fields struct {
+		Value []struct {
+			ReferenceName string `json:"referenceName"`
+		} `json:"value"`
+	}
+	_, err := a.do(http.MethodGet, "/workitemtypes/"+url.PathEscape(a.workItemType)+"/fields", nil, "", nil, &fields)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to list the fields of work item type %s", a.workItemType)
+	}
+	has := false
+	for _, f := range fields.Value {
+		if strings.EqualFold(f.ReferenceName, a.dueDateField) {
+			has = true
+			break
+		}
+	}
+	a.hasDueDate = &has
+	return has, nil
+}
+
+// Comment adds a comment to a work item's discussion.
+func (a *azurePlugin) Comment(ID, comment string) error {
+	return a.update(ID, patchOperation{Op: "add", Path: "/fields/System.History", Value: comment})
+}
+
+// Close moves a work item into the first closed state its process accepts.
+func (a *azurePlugin) Close(ID string) error {
+	var err error
+	for _, state := range a.closedStates {
+		err = a.update(ID, patchOperation{Op: "add", Path: "/fields/System.State", Value: state})
+		if err == nil {
+			return nil
+		}
+	}
+	return errors.Wrap(err, "unable to close ticket")
+}
+
+func (a *azurePlugin) update(ID string, ops ...patchOperation) error {
+	_, err := a.do(http.MethodPatch, "/workitems/"+url.PathEscape(ID), nil, "application/json-patch+json", ops, nil)
+	return err
+}
+
+// Evidence fetches a work item's discussion, attached files and the user who closed it.
+func (a *azurePlugin) Evidence(ID string) (*model.Evidence, error) {
+	var wi workItem
+	_, err := a.do(http.MethodGet, "/workitems/"+url.PathEscape(ID), url.Values{"$expand": {"all"}}, "", nil, &wi)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch work item")
+	}
+	ev := &model.Evidence{ClosedBy: identity(wi.Fields["Microsoft.VSTS.Common.ClosedBy"])}
+	for _, r := range wi.Relations {
+		if r.Rel != "AttachedFile" {
+			continue
+		}
+		ev.Attachments = append(ev.Attachments, &model.Attachment{
+			ID:   r.URL[strings.LastIndex(r.URL, "/")+1:],
+			Name: r.Attributes.Name,
+			URL:  r.URL,
+			Size: r.Attributes.ResourceSize,
+		})
+	}
+
+	// the discussion is the history of System.History, one comment per revision
+	for skip := 0; ; skip += batchSize {
+		var updates struct {
+			Value []workItemUpdate `json:"value"`
+		}
+		_, err := a.do(http.MethodGet, "/workitems/"+url.PathEscape(ID)+"/updates", url.Values{
+			"$top":  {strconv.Itoa(batchSize)},
+			"$skip": {strconv.Itoa(skip)},
+		}, "", nil, &updates)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to list work item updates")
+		}
+		for _, u := range updates.Value {
+			body, _ := u.Fields["System.History"].NewValue.(string)
+			if body == "" {
+				continue
+			}
+			ev.Comments = append(ev.Comments, &model.Comment{
+				Author:    identity(u.RevisedBy),
+				CreatedAt: u.RevisedDate,
+				Body:      body,
+			})
+		}
+		if len(updates.Value) < batchSize {
+			return ev, nil
+		}
+	}
+}
+
+// Download fetches an attached file's content.
+func (a *azurePlugin) Download(att *model.Attachment) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, att.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth("", a.token)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", att.URL, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func (a *azurePlugin) toTicket(wi *workItem) *model.Ticket {
+	t := &model.Ticket{Attributes: make(map[string]interface{})}
+	t.ID = strconv.Itoa(wi.ID)
+	t.Name = field(wi, "System.Title")
+	t.Body = field(wi, "System.Description")
+	t.Link = wi.Links.HTML.Href
+	if t.Link == "" {
+		t.Link = a.LinkFor(t)
+	}
+	t.CreatedAt = timeField(wi, "System.CreatedDate")
+	t.UpdatedAt = timeField(wi, "System.ChangedDate")
+	t.ClosedAt = timeField(wi, "Microsoft.VSTS.Common.ClosedDate")
+	t.State = a.toState(field(wi, "System.State"))
+	t.DueAt = timeField(wi, a.dueDateField)
+	if p, ok := wi.Fields["Microsoft.VSTS.Common.Priority"].(float64); ok {
+		t.Priority = strconv.Itoa(int(p))
+	}
+	t.Assignee = identity(wi.Fields["System.AssignedTo"])
+
+	// tags are stored as a single "a; b; c" string
+	for _, tag := range strings.Split(field(wi, "System.Tags"), ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			t.SetLabel(tag)
+		}
+	}
+	return t
+}
+
+func (a *azurePlugin) toState(state string) model.TicketState {
+	for _, closed := range a.closedStates {
+		if strings.EqualFold(state, closed) {
+			return model.Closed
+		}
+	}
+	return model.Open
+}
+
+// priorityNames maps common priority names onto the 1 (highest) to 4 (lowest) scale of Azure Boards.
+var priorityNames = map[string]int{
+	"critical": 1, "highest": 1,
+	"high":   2,
+	"medium": 3, "normal": 3,
+	"low": 4, "lowest": 4,
+}
+
+func toPriority(priority string) (int, error) {
+	if p, err := strconv.Atoi(priority); err == nil && p >= 1 && p <= 4 {
+		return p, nil
+	}
+	if p, ok := priorityNames[strings.ToLower(priority)]; ok {
+		return p, nil
+	}
+	return 0, fmt.Errorf("unsupported Azure DevOps priority %q; use 1-4 or critical, high, medium, low", priority)
+}
+
+func field(wi *workItem, name string) string {
+	v, _ := wi.Fields[name].(string)
+	return v
+}
+
+// identity names a user; identity fields are objects in current API versions and strings in older ones.
+func identity(v interface{}) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case map[string]interface{}:
+		name, _ := id["uniqueName"].(string)
+		return name
+	}
+	return ""
+}
+
+func timeField(wi *workItem, name string) *time.Time {
+	t, err := time.Parse(time.RFC3339, field(wi, name))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,116 @@
+package azuredevops
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestAzureDevOps(t *testing.T) {
+	var created []patchOperation
+	var wiql string
+	fieldsListed := 0
+	createdWith := ""
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, token, _ := r.BasicAuth(); token != "pat" {
+			t.Errorf("missing token on %s %s", r.Method, r.URL.Path)
+		}
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/acme/Compliance/_apis/wit/workitems/$Issue":
+			if r.Header.Get("Content-Type") != "application/json-patch+json" {
+				t.Errorf("unexpected content type %s", r.Header.Get("Content-Type"))
+			}
+			createdWith = r.URL.Query().Get("api-version")
+			json.NewDecoder(r.Body).Decode(&created)
+			fmt.Fprint(w, `{"id": 12, "fields": {"System.Title": "Patch", "System.State": "To Do", "System.Tags": "comply; comply-procedure", "System.CreatedDate": "2026-10-01T10:00:00Z"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/acme/Compliance/_apis/wit/workitemtypes/Issue/fields":
+			// the Basic process's Issue has no due date
+			fieldsListed++
+			fmt.Fprint(w, `{"count": 2, "value": [{"referenceName": "System.Title"}, {"referenceName": "System.Description"}]}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/acme/Compliance/_apis/wit/wiql":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			wiql = body["query"]
+			fmt.Fprint(w, `{"workItems": [{"id": 12}, {"id": 13}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/acme/Compliance/_apis/wit/workitems":
+			if r.URL.Query().Get("ids") != "12,13" {
+				t.Errorf("unexpected ids %s", r.URL.Query().Get("ids"))
+			}
+			fmt.Fprint(w, `{"count": 2, "value": [
+				{"id": 12, "fields": {"System.State": "Doing", "System.Tags": "comply; comply-procedure"}},
+				{"id": 13, "fields": {"System.State": "Done", "System.Tags": "comply; comply-procedure"}, "_links": {"html": {"href": "https://example.com/13"}}}
+			]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	a := &azurePlugin{}
+	err := a.Configure(map[string]interface{}{
+		cfgOrganization: "acme",
+		cfgProject:      "Compliance",
+		cfgToken:        "pat",
+		cfgBaseURL:      server.URL,
+		cfgWorkItemType: "Issue",
+		cfgAreaPath:     `Compliance\Security`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	ticket := &model.Ticket{Name: "Patch", Body: "Apply patches", DueAt: &due}
+	err = a.Create(ticket, []string{"comply", "comply-procedure"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket.ID != "12" || ticket.State != model.Open || !ticket.Bool("comply-procedure") || ticket.CreatedAt == nil {
+		t.Fatalf("unexpected ticket %+v", ticket)
+	}
+	if !strings.HasSuffix(ticket.Link, "/acme/Compliance/_workitems/edit/12") {
+		t.Fatalf("unexpected link %s", ticket.Link)
+	}
+	if len(created) != 5 || created[2].Path != "/multilineFieldsFormat/System.Description" || created[4].Path != "/fields/System.AreaPath" {
+		t.Fatalf("unexpected patch %+v", created)
+	}
+	if createdWith != markdownAPIVersion {
+		t.Errorf("expected markdown descriptions to be created with API version %s, got %s", markdownAPIVersion, createdWith)
+	}
+
+	// descriptions are converted for servers without markdown support
+	a.descriptionFormat = formatHTML
+	err = a.Create(&model.Ticket{Name: "Patch", Body: "# Steps\n\n- [ ] patch", DueAt: &due}, []string{"comply"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 4 || createdWith != apiVersion || created[1].Value != "<h1>Steps</h1>\n\n<ul>\n<li>[ ] patch</li>\n</ul>\n" {
+		t.Fatalf("unexpected patch %+v", created)
+	}
+	if fieldsListed != 1 {
+		t.Errorf("expected the work item type's fields to be listed once, got %d", fieldsListed)
+	}
+
+	tickets, err := a.FindOpen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(wiql, "[System.Tags] CONTAINS 'comply'") || !strings.Contains(wiql, "NOT IN ('Done', 'Closed', 'Removed', 'Resolved')") {
+		t.Fatalf("unexpected WIQL %s", wiql)
+	}
+	if len(tickets) != 2 || tickets[0].State != model.Open || tickets[1].State != model.Closed {
+		t.Fatalf("unexpected tickets %+v", tickets)
+	}
+	if tickets[1].Link != "https://example.com/13" {
+		t.Fatalf("unexpected link %s", tickets[1].Link)
+	}
+}
@@ -0,0 +1,537 @@
+package gitea
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+const (
+	cfgBaseURL = "baseURL"
+	cfgToken   = "token"
+	cfgRepo    = "repo"
+)
+
+var prompts = map[string]string{
+	cfgBaseURL: "Gitea / Forgejo URL",
+	cfgToken:   "Gitea / Forgejo Token",
+	cfgRepo:    "Repository (owner/name)",
+}
+
+// pageSize is the number of items requested per page; Gitea's default maximum is 50.
+const pageSize = 50
+
+// labelColor is used for labels comply creates on demand.
+const labelColor = "#0366d6"
+
+// requestTimeout bounds each API request, so an unreachable server can't hang a sync.
+const requestTimeout = 30 * time.Second
+
+// labelsCollection caches label IDs seen during sync, so dashboard links need no API calls.
+const labelsCollection = "gitea-labels"
+
+// Prompts are human-readable configuration element names
+func (g *giteaPlugin) Prompts() map[string]string {
+	return prompts
+}
+
+// Register causes the Gitea plugin to register itself
+func Register() {
+	model.Register(model.Gitea, &giteaPlugin{})
+}
+
+type giteaPlugin struct {
+	baseURL  string
+	token    string
+	reponame string
+
+	labelsMu sync.Mutex
+	labels   map[string]int64
+
+	client http.Client
+}
+
+type issue struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	HTMLURL   string     `json:"html_url"`
+	Labels    []label    `json:"labels"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	DueDate   *time.Time `json:"due_date"`
+	Assignee  *struct {
+		Login string `json:"login"`
+	} `json:"assignee"`
+}
+
+type label struct {
+	ID    int64  `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+type createIssueOption struct {
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Labels    []int64    `json:"labels"`
+	Assignees []string   `json:"assignees,omitempty"`
+	DueDate   *time.Time `json:"due_date,omitempty"`
+}
+
+// do issues a request against the repository API, decoding a JSON response into out.
+func (g *giteaPlugin) do(method, path string, query url.Values, in, out interface{}) (*http.Response, error) {
+	u := fmt.Sprintf("%s/api/v1/repos/%s%s", g.baseURL, g.reponame, path)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "token "+g.token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp, fmt.Errorf("%s %s: %s %s", method, u, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		err = json.NewDecoder(resp.Body).Decode(out)
+		if err != nil {
+			return resp, errors.Wrap(err, "malformed response")
+		}
+	}
+	return resp, nil
+}
+
+func (g *giteaPlugin) Get(ID string) (*model.Ticket, error) {
+	var i issue
+	resp, err := g.do(http.MethodGet, "/issues/"+url.PathEscape(ID), nil, nil, &i)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error during Get")
+	}
+	return toTicket(&i), nil
+}
+
+func (g *giteaPlugin) Configured() bool {
+	return g.baseURL != "" && g.reponame != "" && g.token != ""
+}
+
+func (g *giteaPlugin) Links() model.TicketLinks {
+	// the web UI filters by label ID; fall back to unfiltered lists for labels not synced yet
+	known := g.knownLabels()
+	filter := func(name string) string {
+		id, ok := known[name]
+		if !ok {
+			return ""
+		}
+		return "&labels=" + strconv.FormatInt(id, 10)
+	}
+	issues := fmt.Sprintf("%s/%s/issues?type=all", g.baseURL, g.reponame)
+
+	links := model.TicketLinks{}
+	links.AuditAll = issues + "&state=all" + filter("comply-audit")
+	links.AuditOpen = issues + "&state=open" + filter("comply-audit")
+	links.ProcedureAll = issues + "&state=all" + filter("comply-procedure")
+	links.ProcedureOpen = issues + "&state=open" + filter("comply-procedure")
+	return links
+}
+
+func (g *giteaPlugin) Configure(cfg map[string]interface{}) error {
+	var err error
+
+	if g.baseURL, err = getCfg(cfg, cfgBaseURL); err != nil {
+		return err
+	}
+	g.baseURL = strings.TrimSuffix(g.baseURL, "/")
+	if g.token, err = getCfg(cfg, cfgToken); err != nil {
+		return err
+	}
+	if g.reponame, err = getCfg(cfg, cfgRepo); err != nil {
+		return err
+	}
+	g.reponame = strings.Trim(g.reponame, "/")
+	g.client = http.Client{Timeout: requestTimeout}
+
+	return nil
+}
+
+func getCfg(cfg map[string]interface{}, k string) (string, error) {
+	v, ok := cfg[k]
+	if !ok {
+		return "", errors.New("Missing key: " + k)
+	}
+
+	vS, ok := v.(string)
+	if !ok {
+		return "", errors.New("Malformatted key: " + k)
+	}
+	if !config.IsSecretKey(k) {
+		return vS, nil
+	}
+	vS, err := config.ResolveSecret(vS)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to resolve key: "+k)
+	}
+	return vS, nil
+}
+
+func (g *giteaPlugin) FindOpen() ([]*model.Ticket, error) {
+	issues, err := g.listIssues("open", "comply", time.Time{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error during FindOpen")
+	}
+	return toTickets(issues), nil
+}
+
+func (g *giteaPlugin) FindByTag(name, value string) ([]*model.Ticket, error) {
+	return g.FindByTagName(model.TagFor(name, value))
+}
+
+func (g *giteaPlugin) FindByTagName(name string) ([]*model.Ticket, error) {
+	issues, err := g.listIssues("all", name, time.Time{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error during FindByTagName")
+	}
+	return toTickets(issues), nil
+}
+
+func (g *giteaPlugin) FindByTagNameSince(name string, since time.Time) ([]*model.Ticket, error) {
+	issues, err := g.listIssues("all", name, since)
+	if err != nil {
+		return nil, errors.Wrap(err, "error during FindByTagNameSince")
+	}
+	return toTickets(issues), nil
+}
+
+// listIssues pages through all issues in the given state carrying labelName,
+// limited to those updated since a time unless since is zero.
+func (g *giteaPlugin) listIssues(state, labelName string, since time.Time) ([]issue, error) {
+	var all []issue
+	for page := 1; ; page++ {
+		var issues []issue
+		query := url.Values{
+			"type":   {"issues"},
+			"state":  {state},
+			"labels": {labelName},
+			"page":   {strconv.Itoa(page)},
+			"limit":  {strconv.Itoa(pageSize)},
+		}
+		if !since.IsZero() {
+			query.Set("since", since.UTC().Format(time.RFC3339))
+		}
+		_, err := g.do(http.MethodGet, "/issues", query, nil, &issues)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, issues...)
+		if len(issues) < pageSize {
+			g.rememberLabels(all)
+			return all, nil
+		}
+	}
+}
+
+// knownLabels returns the label IDs seen so far, without calling the API.
+func (g *giteaPlugin) knownLabels() map[string]int64 {
+	g.labelsMu.Lock()
+	defer g.labelsMu.Unlock()
+	return g.cachedLabels()
+}
+
+// cachedLabels reads the label IDs remembered by earlier syncs; callers hold labelsMu.
+func (g *giteaPlugin) cachedLabels() map[string]int64 {
+	known := make(map[string]int64)
+	_ = model.DB().Read(labelsCollection, g.baseURL+"/"+g.reponame, &known)
+	for name, id := range g.labels {
+		known[name] = id
+	}
+	return known
+}
+
+// rememberLabels caches the IDs of the labels on issues for Links. The cache is
+// only a convenience, so failing to update it doesn't fail the sync.
+func (g *giteaPlugin) rememberLabels(issues []issue) {
+	g.labelsMu.Lock()
+	defer g.labelsMu.Unlock()
+
+	stored := make(map[string]int64)
+	_ = model.DB().Read(labelsCollection, g.baseURL+"/"+g.reponame, &stored)
+	known := g.cachedLabels()
+	for _, i := range issues {
+		for _, l := range i.Labels {
+			if l.ID != 0 {
+				known[l.Name] = l.ID
+			}
+		}
+	}
+	if !reflect.DeepEqual(known, stored) {
+		_ = model.DB().Write(labelsCollection, g.baseURL+"/"+g.reponame, known)
+	}
+}
+
+// labelIDs maps label names to repository label IDs, optionally creating missing labels.
+func (g *giteaPlugin) labelIDs(names []string, create bool) ([]int64, error) {
+	g.labelsMu.Lock()
+	defer g.labelsMu.Unlock()
+
+	if g.labels == nil {
+		labels := make(map[string]int64)
+		for page := 1; ; page++ {
+			var batch []label
+			_, err := g.do(http.MethodGet, "/labels", url.Values{
+				"page":  {strconv.Itoa(page)},
+				"limit": {strconv.Itoa(pageSize)},
+			}, nil, &batch)
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to list labels")
+			}
+			for _, l := range batch {
+				labels[l.Name] = l.ID
+			}
+			if len(batch) < pageSize {
+				break
+			}
+		}
+		g.labels = labels
+	}
+
+	var ids []int64
+	for _, name := range names {
+		id, ok := g.labels[name]
+		if !ok {
+			if !create {
+				continue
+			}
+			var created label
+			_, err := g.do(http.MethodPost, "/labels", nil, label{Name: name, Color: labelColor}, &created)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to create label %s", name)
+			}
+			id = created.ID
+			g.labels[name] = id
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (g *giteaPlugin) LinkFor(t *model.Ticket) string {
+	if t.Link != "" {
+		return t.Link
+	}
+	return fmt.Sprintf("%s/%s/issues/%s", g.baseURL, g.reponame, t.ID)
+}
+
+func (g *giteaPlugin) Create(ticket *model.Ticket, labels []string) error {
+	if ticket.Priority != "" {
+		// Gitea has no native priority field
+		labels = append(labels, model.TagFor(model.PriorityTag, ticket.Priority))
+	}
+	ids, err := g.labelIDs(labels, true)
+	if err != nil {
+		return err
+	}
+
+	opts := createIssueOption{
+		Title:   ticket.Name,
+		Body:    ticket.Body,
+		Labels:  ids,
+		DueDate: ticket.DueAt,
+	}
+	if ticket.Assignee != "" {
+		opts.Assignees = []string{ticket.Assignee}
+	}
+
+	var created issue
+	_, err = g.do(http.MethodPost, "/issues", nil, opts, &created)
+	if err != nil {
+		return errors.Wrap(err, "unable to create ticket")
+	}
+	*ticket = *toTicket(&created)
+	return nil
+}
+
+// Comment adds a comment to an issue.
+func (g *giteaPlugin) Comment(ID, comment string) error {
+	_, err := g.do(http.MethodPost, "/issues/"+url.PathEscape(ID)+"/comments", nil, map[string]string{"body": comment}, nil)
+	return errors.Wrap(err, "unable to comment on ticket")
+}
+
+// Close closes an issue.
+func (g *giteaPlugin) Close(ID string) error {
+	_, err := g.do(http.MethodPatch, "/issues/"+url.PathEscape(ID), nil, map[string]string{"state": "closed"}, nil)
+	return errors.Wrap(err, "unable to close ticket")
+}
+
+type user struct {
+	Login string `json:"login"`
+}
+
+type comment struct {
+	Body      string     `json:"body"`
+	CreatedAt *time.Time `json:"created_at"`
+	User      *user      `json:"user"`
+}
+
+type timelineEvent struct {
+	Type string `json:"type"`
+	User *user  `json:"user"`
+}
+
+type attachment struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// Evidence fetches an issue's comments, attachments and the user who closed it.
+func (g *giteaPlugin) Evidence(ID string) (*model.Evidence, error) {
+	issuePath := "/issues/" + url.PathEscape(ID)
+	ev := &model.Evidence{}
+
+	var comments []comment
+	_, err := g.do(http.MethodGet, issuePath+"/comments", nil, nil, &comments)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list comments")
+	}
+	for _, c := range comments {
+		mc := &model.Comment{CreatedAt: c.CreatedAt, Body: c.Body}
+		if c.User != nil {
+			mc.Author = c.User.Login
+		}
+		ev.Comments = append(ev.Comments, mc)
+	}
+
+	var assets []attachment
+	resp, err := g.do(http.MethodGet, issuePath+"/assets", nil, nil, &assets)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return nil, errors.Wrap(err, "unable to list attachments")
+	}
+	for _, a := range assets {
+		ev.Attachments = append(ev.Attachments, &model.Attachment{
+			ID:   strconv.FormatInt(a.ID, 10),
+			Name: a.Name,
+			URL:  a.BrowserDownloadURL,
+			Size: a.Size,
+		})
+	}
+
+	// the timeline arrived in Gitea 1.15; older servers just don't report who closed the issue
+	for page := 1; ; page++ {
+		var events []timelineEvent
+		resp, err := g.do(http.MethodGet, issuePath+"/timeline", url.Values{
+			"page":  {strconv.Itoa(page)},
+			"limit": {strconv.Itoa(pageSize)},
+		}, nil, &events)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read issue timeline")
+		}
+		for _, e := range events {
+			if e.Type == "close" && e.User != nil {
+				ev.ClosedBy = e.User.Login
+			}
+		}
+		if len(events) < pageSize {
+			break
+		}
+	}
+	return ev, nil
+}
+
+// Download fetches an attachment's content.
+func (g *giteaPlugin) Download(a *model.Attachment) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, a.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "token "+g.token)
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", a.URL, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func toTickets(issues []issue) []*model.Ticket {
+	var tickets []*model.Ticket
+	for i := range issues {
+		tickets = append(tickets, toTicket(&issues[i]))
+	}
+	return tickets
+}
+
+func toTicket(i *issue) *model.Ticket {
+	t := &model.Ticket{Attributes: make(map[string]interface{})}
+	t.ID = strconv.Itoa(i.Number)
+	t.Name = i.Title
+	t.Body = i.Body
+	t.Link = i.HTMLURL
+	t.CreatedAt = i.CreatedAt
+	t.UpdatedAt = i.UpdatedAt
+	t.ClosedAt = i.ClosedAt
+	t.State = toState(i.State)
+	t.DueAt = i.DueDate
+	if i.Assignee != nil {
+		t.Assignee = i.Assignee.Login
+	}
+
+	for _, l := range i.Labels {
+		t.SetLabel(l.Name)
+	}
+	return t
+}
+
+func toState(state string) model.TicketState {
+	switch state {
+	case "closed":
+		return model.Closed
+	}
+	return model.Open
+}
@@ -0,0 +1,119 @@
+package gitea
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestGitea(t *testing.T) {
+	labels := []label{{ID: 1, Name: "comply"}}
+	var created createIssueOption
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("missing token on %s %s", r.Method, r.URL.Path)
+		}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repos/acme/compliance/labels":
+			json.NewEncoder(w).Encode(labels)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/repos/acme/compliance/labels":
+			var l label
+			json.NewDecoder(r.Body).Decode(&l)
+			l.ID = int64(len(labels) + 1)
+			labels = append(labels, l)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(l)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/repos/acme/compliance/issues":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"number": 7, "title": %q, "state": "open", "html_url": "https://gitea.example.com/acme/compliance/issues/7", "labels": [{"name": "comply"}, {"name": "comply-procedure"}]}`, created.Title)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repos/acme/compliance/issues":
+			if r.URL.Query().Get("labels") != "comply-procedure" {
+				t.Errorf("unexpected label filter %q", r.URL.Query().Get("labels"))
+			}
+			// two pages: a full one followed by a partial one
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			count := pageSize
+			if page > 1 {
+				count = 3
+			}
+			issues := make([]issue, count)
+			for i := range issues {
+				issues[i] = issue{Number: (page-1)*pageSize + i + 1, State: "closed", Labels: []label{{ID: 2, Name: "comply-procedure"}}}
+			}
+			json.NewEncoder(w).Encode(issues)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repos/acme/compliance/issues/404":
+			http.NotFound(w, r)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "comply-gitea")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetProjectRoot(dir)
+	defer config.SetProjectRoot("")
+
+	cfg := map[string]interface{}{
+		cfgBaseURL: server.URL + "/",
+		cfgToken:   "secret",
+		cfgRepo:    "acme/compliance",
+	}
+	g := &giteaPlugin{}
+	err = g.Configure(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticket := &model.Ticket{Name: "Patch"}
+	err = g.Create(ticket, []string{"comply", "comply-procedure"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket.ID != "7" || !ticket.Bool("comply-procedure") || ticket.State != model.Open {
+		t.Fatalf("unexpected ticket %+v", ticket)
+	}
+	if len(created.Labels) != 2 || created.Labels[0] != 1 || created.Labels[1] != 2 {
+		t.Fatalf("expected existing and newly created label IDs, got %v", created.Labels)
+	}
+
+	tickets, err := g.FindByTagName("comply-procedure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != pageSize+3 {
+		t.Fatalf("expected %d tickets across pages, got %d", pageSize+3, len(tickets))
+	}
+
+	// rendering the dashboard uses the label IDs seen during sync, without the API
+	rendering := &giteaPlugin{}
+	err = rendering.Configure(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	links := rendering.Links()
+	if !strings.HasSuffix(links.ProcedureOpen, "&state=open&labels=2") || strings.Contains(links.AuditAll, "labels=") {
+		t.Errorf("unexpected links %+v", links)
+	}
+
+	missing, err := g.Get("404")
+	if err != nil || missing != nil {
+		t.Fatalf("expected no ticket and no error, got %v, %v", missing, err)
+	}
+}
@@ -0,0 +1,58 @@
+package github
+
+import (
+	"context"
+	"crypto/rsa"
+	"net/http"
+	"strconv"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// appTokenSource exchanges a GitHub App JWT for installation access tokens.
+// Wrap it in oauth2.ReuseTokenSource so tokens are only minted once they expire.
+type appTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	newClient      func(*http.Client) *github.Client
+}
+
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	now := time.Now()
+	claims := jwt.StandardClaims{
+		// backdated to tolerate clock drift; GitHub rejects JWTs valid for more than 10 minutes
+		IssuedAt:  now.Add(-time.Minute).Unix(),
+		ExpiresAt: now.Add(9 * time.Minute).Unix(),
+		Issuer:    strconv.FormatInt(s.appID, 10),
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(s.key)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to sign GitHub App JWT")
+	}
+
+	ctx := context.Background()
+	jwtClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: signed}))
+	token, _, err := s.newClient(jwtClient).Apps.CreateInstallationToken(ctx, s.installationID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create GitHub App installation token")
+	}
+
+	t := &oauth2.Token{AccessToken: ss(token.Token)}
+	if token.ExpiresAt != nil {
+		t.Expiry = *token.ExpiresAt
+	}
+	return t, nil
+}
+
+func parsePrivateKey(pem []byte) (*rsa.PrivateKey, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed GitHub App private key")
+	}
+	return key, nil
+}
@@ -2,22 +2,36 @@ package github
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/go-github/github"
 	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
 	"github.com/strongdm/comply/internal/model"
 	"golang.org/x/oauth2"
 )
 
 const (
-	cfgToken    = "token"
-	cfgUsername = "username"
-	cfgRepo     = "repo"
+	cfgToken          = "token"
+	cfgUsername       = "username"
+	cfgRepo           = "repo"
+	cfgBaseURL        = "baseURL"
+	cfgAppID          = "appID"
+	cfgInstallationID = "installationID"
+	cfgPrivateKey     = "privateKey"
+	cfgPrivateKeyPath = "privateKeyPath"
+	cfgWebhookSecret  = "webhookSecret"
 )
 
 var prompts = map[string]string{
@@ -26,6 +40,18 @@ var prompts = map[string]string{
 	cfgRepo:     "GitHub Repository",
 }
 
+const (
+	// perPage is the page size requested when listing issues; GitHub's maximum is 100.
+	perPage = 100
+	// maxRetries bounds how often a rate-limited request is retried.
+	maxRetries = 3
+	// abuseBackoff is the wait used when GitHub's secondary rate limit omits Retry-After.
+	abuseBackoff = time.Minute
+)
+
+// sleep is replaced in tests.
+var sleep = time.Sleep
+
 // Prompts are human-readable configuration element names
 func (g *githubPlugin) Prompts() map[string]string {
 	return prompts
@@ -40,6 +66,13 @@ type githubPlugin struct {
 	token    string
 	username string
 	reponame string
+	baseURL  string
+
+	appID          int64
+	installationID int64
+	privateKey     []byte
+
+	webhookSecret string
 
 	clientMu sync.Mutex
 	client   *github.Client
@@ -50,40 +83,113 @@ func (g *githubPlugin) api() *github.Client {
 	defer g.clientMu.Unlock()
 
 	if g.client == nil {
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: g.token},
-		)
+		var ts oauth2.TokenSource
+		if g.appID != 0 {
+			// the key was validated by Configure
+			key, _ := parsePrivateKey(g.privateKey)
+			ts = oauth2.ReuseTokenSource(nil, &appTokenSource{
+				appID:          g.appID,
+				installationID: g.installationID,
+				key:            key,
+				newClient:      g.newClient,
+			})
+		} else {
+			ts = oauth2.StaticTokenSource(
+				&oauth2.Token{AccessToken: g.token},
+			)
+		}
 
 		// get go-github client
-		gh := github.NewClient(oauth2.NewClient(context.Background(), ts))
-		g.client = gh
+		g.client = g.newClient(oauth2.NewClient(context.Background(), ts))
 	}
 	return g.client
 }
 
+// newClient returns a go-github client for github.com or the configured GitHub Enterprise Server.
+func (g *githubPlugin) newClient(httpClient *http.Client) *github.Client {
+	if g.baseURL == "" {
+		return github.NewClient(httpClient)
+	}
+	// baseURL was validated by Configure
+	u, _ := url.Parse(g.baseURL)
+	uploadURL := fmt.Sprintf("%s://%s/api/uploads/", u.Scheme, u.Host)
+	gh, _ := github.NewEnterpriseClient(g.baseURL, uploadURL, httpClient)
+	return gh
+}
+
+// webURL is the browser-facing root of the GitHub instance.
+func (g *githubPlugin) webURL() string {
+	if g.baseURL == "" {
+		return "https://github.com"
+	}
+	u, _ := url.Parse(g.baseURL)
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+}
+
+// withBackoff runs call, waiting out primary and secondary rate limits before retrying.
+func withBackoff(call func() (*github.Response, error)) (*github.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := call()
+		if err == nil || attempt == maxRetries {
+			return resp, err
+		}
+
+		switch e := err.(type) {
+		case *github.RateLimitError:
+			sleep(time.Until(e.Rate.Reset.Time) + time.Second)
+		case *github.AbuseRateLimitError:
+			wait := abuseBackoff
+			if e.RetryAfter != nil {
+				wait = *e.RetryAfter
+			}
+			sleep(wait)
+		default:
+			return resp, err
+		}
+	}
+}
+
 func (g *githubPlugin) Get(ID string) (*model.Ticket, error) {
-	return nil, nil
+	number, err := strconv.Atoi(ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed GitHub issue number")
+	}
+
+	var issue *github.Issue
+	resp, err := withBackoff(func() (resp *github.Response, err error) {
+		issue, resp, err = g.api().Issues.Get(context.Background(), g.username, g.reponame, number)
+		return resp, err
+	})
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error during Get")
+	}
+
+	return toTicket(issue), nil
 }
 
 func (g *githubPlugin) Configured() bool {
-	return g.username != "" && g.reponame != "" && g.token != ""
+	if g.username == "" || g.reponame == "" {
+		return false
+	}
+	return g.token != "" || (g.appID != 0 && g.installationID != 0 && len(g.privateKey) > 0)
 }
 
 func (g *githubPlugin) Links() model.TicketLinks {
+	repoURL := fmt.Sprintf("%s/%s/%s", g.webURL(), g.username, g.reponame)
 	links := model.TicketLinks{}
-	links.AuditAll = fmt.Sprintf("https://github.com/%s/%s/issues?q=is%%3Aissue+is%%3Aopen+label%%3Acomply+label%%3Aaudit", g.username, g.reponame)
-	links.AuditOpen = fmt.Sprintf("https://github.com/%s/%s/issues?q=is%%3Aissue+is%%3Aopen+label%%3Acomply+label%%3Aaudit", g.username, g.reponame)
-	links.ProcedureAll = fmt.Sprintf("https://github.com/%s/%s/issues?q=is%%3Aissue+label%%3Acomply+label%%3Acomply-procedure", g.username, g.reponame)
-	links.ProcedureOpen = fmt.Sprintf("https://github.com/%s/%s/issues?q=is%%3Aissue+is%%3Aopen+label%%3Acomply+label%%3Acomply-procedure", g.username, g.reponame)
+	links.AuditAll = fmt.Sprintf("%s/issues?q=is%%3Aissue+label%%3Acomply-audit", repoURL)
+	links.AuditOpen = fmt.Sprintf("%s/issues?q=is%%3Aissue+is%%3Aopen+label%%3Acomply-audit", repoURL)
+	links.ProcedureAll = fmt.Sprintf("%s/issues?q=is%%3Aissue+label%%3Acomply+label%%3Acomply-procedure", repoURL)
+	links.ProcedureOpen = fmt.Sprintf("%s/issues?q=is%%3Aissue+is%%3Aopen+label%%3Acomply+label%%3Acomply-procedure", repoURL)
 	return links
 }
 
 func (g *githubPlugin) Configure(cfg map[string]interface{}) error {
 	var err error
 
-	if g.token, err = getCfg(cfg, cfgToken); err != nil {
-		return err
-	}
 	if g.username, err = getCfg(cfg, cfgUsername); err != nil {
 		return err
 	}
@@ -91,27 +197,112 @@ func (g *githubPlugin) Configure(cfg map[string]interface{}) error {
 		return err
 	}
 
-	return nil
+	if g.baseURL, err = getOptionalCfg(cfg, cfgBaseURL); err != nil {
+		return err
+	}
+	if g.baseURL != "" {
+		u, err := url.Parse(g.baseURL)
+		if err != nil || u.Host == "" {
+			return errors.New("Malformatted key: " + cfgBaseURL)
+		}
+		if !strings.Contains(u.Path, "/api/") {
+			// GitHub Enterprise Server serves the REST API beneath /api/v3
+			u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v3/"
+		}
+		g.baseURL = u.String()
+	}
+
+	if g.webhookSecret, err = getOptionalCfg(cfg, cfgWebhookSecret); err != nil {
+		return err
+	}
+
+	appID, err := getOptionalCfg(cfg, cfgAppID)
+	if err != nil {
+		return err
+	}
+	if appID == "" {
+		g.appID = 0
+		g.token, err = getCfg(cfg, cfgToken)
+		return err
+	}
+	return g.configureApp(cfg, appID)
+}
+
+// configureApp sets up GitHub App authentication in place of a personal token.
+func (g *githubPlugin) configureApp(cfg map[string]interface{}, appID string) error {
+	var err error
+
+	if g.appID, err = strconv.ParseInt(appID, 10, 64); err != nil {
+		return errors.New("Malformatted key: " + cfgAppID)
+	}
+
+	installationID, err := getCfg(cfg, cfgInstallationID)
+	if err != nil {
+		return err
+	}
+	if g.installationID, err = strconv.ParseInt(installationID, 10, 64); err != nil {
+		return errors.New("Malformatted key: " + cfgInstallationID)
+	}
+
+	key, err := getOptionalCfg(cfg, cfgPrivateKey)
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		g.privateKey = []byte(key)
+	} else {
+		path, err := getCfg(cfg, cfgPrivateKeyPath)
+		if err != nil {
+			return errors.New("Missing key: " + cfgPrivateKey + " or " + cfgPrivateKeyPath)
+		}
+		if g.privateKey, err = ioutil.ReadFile(path); err != nil {
+			return errors.Wrap(err, "unable to read GitHub App private key")
+		}
+	}
+
+	_, err = parsePrivateKey(g.privateKey)
+	return err
 }
 
 func getCfg(cfg map[string]interface{}, k string) (string, error) {
 	v, ok := cfg[k]
 	if !ok {
-		v = os.Getenv(fmt.Sprintf("GITHUB_%s", strings.ToUpper(k)))
-		if v == "" {
+		// deprecated: credentials used to be read from GITHUB_<KEY> when absent
+		// from comply.yml; only secrets still are, resolved like ${GITHUB_<KEY>}
+		env := fmt.Sprintf("GITHUB_%s", strings.ToUpper(k))
+		if !config.IsSecretKey(k) || os.Getenv(env) == "" {
 			return "", errors.New("Missing key: " + k)
 		}
+		fmt.Fprintf(os.Stderr, "warning: reading %s from %s is deprecated; set %s: ${%s} in comply.yml\n", k, env, k, env)
+		v = "${" + env + "}"
 	}
 
-	vS, ok := v.(string)
-	if !ok {
-		return "", errors.New("Malformatted key: " + k)
+	switch vT := v.(type) {
+	case string:
+		if !config.IsSecretKey(k) {
+			return vT, nil
+		}
+		vS, err := config.ResolveSecret(vT)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to resolve key: "+k)
+		}
+		return vS, nil
+	case int:
+		// numeric IDs such as appID
+		return strconv.Itoa(vT), nil
+	}
+	return "", errors.New("Malformatted key: " + k)
+}
+
+func getOptionalCfg(cfg map[string]interface{}, k string) (string, error) {
+	if _, ok := cfg[k]; !ok {
+		return "", nil
 	}
-	return vS, nil
+	return getCfg(cfg, k)
 }
 
 func (g *githubPlugin) FindOpen() ([]*model.Ticket, error) {
-	issues, _, err := g.api().Issues.ListByRepo(context.Background(), g.username, g.reponame, &github.IssueListByRepoOptions{
+	issues, err := g.listByRepo(&github.IssueListByRepoOptions{
 		State: "open",
 	})
 
@@ -123,34 +314,250 @@ func (g *githubPlugin) FindOpen() ([]*model.Ticket, error) {
 }
 
 func (g *githubPlugin) FindByTag(name, value string) ([]*model.Ticket, error) {
-	panic("not implemented")
+	return g.FindByTagName(model.TagFor(name, value))
 }
 
 func (g *githubPlugin) FindByTagName(name string) ([]*model.Ticket, error) {
-	issues, _, err := g.api().Issues.ListByRepo(context.Background(), g.username, g.reponame, &github.IssueListByRepoOptions{
+	issues, err := g.listByRepo(&github.IssueListByRepoOptions{
 		State:  "all",
 		Labels: []string{name},
 	})
 
 	if err != nil {
-		return nil, errors.Wrap(err, "error during FindOpen")
+		return nil, errors.Wrap(err, "error during FindByTagName")
 	}
 
 	return toTickets(issues), nil
 }
 
+func (g *githubPlugin) FindByTagNameSince(name string, since time.Time) ([]*model.Ticket, error) {
+	issues, err := g.listByRepo(&github.IssueListByRepoOptions{
+		State:  "all",
+		Labels: []string{name},
+		Since:  since,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error during FindByTagNameSince")
+	}
+	return toTickets(issues), nil
+}
+
+// listByRepo follows pagination until every matching issue has been fetched.
+func (g *githubPlugin) listByRepo(opts *github.IssueListByRepoOptions) ([]*github.Issue, error) {
+	opts.PerPage = perPage
+
+	var all []*github.Issue
+	for {
+		var issues []*github.Issue
+		resp, err := withBackoff(func() (resp *github.Response, err error) {
+			issues, resp, err = g.api().Issues.ListByRepo(context.Background(), g.username, g.reponame, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, issues...)
+
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
 func (g *githubPlugin) LinkFor(t *model.Ticket) string {
-	// return fmt.Sprintf("https://github.com/strongdm/comply/issues/%s", t.ID)
-	panic("not implemented")
+	if t.Link != "" {
+		return t.Link
+	}
+	return fmt.Sprintf("%s/%s/%s/issues/%s", g.webURL(), g.username, g.reponame, t.ID)
 }
 
 func (g *githubPlugin) Create(ticket *model.Ticket, labels []string) error {
-	_, _, err := g.api().Issues.Create(context.Background(), g.username, g.reponame, &github.IssueRequest{
+	if ticket.Priority != "" {
+		// GitHub has no native priority field
+		labels = append(labels, model.TagFor(model.PriorityTag, ticket.Priority))
+	}
+	body := ticket.Body
+	req := &github.IssueRequest{
 		Title:  &ticket.Name,
-		Body:   &ticket.Body,
+		Body:   &body,
 		Labels: &labels,
+	}
+	if ticket.Assignee != "" {
+		req.Assignees = &[]string{ticket.Assignee}
+	}
+	if ticket.DueAt != nil {
+		// issues are grouped into a milestone per month; the exact due date is kept in the body
+		number, err := g.milestoneFor(*ticket.DueAt)
+		if err != nil {
+			return err
+		}
+		req.Milestone = &number
+		body = strings.TrimRight(body, "\n") + "\n\n<!-- comply-due: " + ticket.DueAt.UTC().Format(time.RFC3339) + " -->\n"
+	}
+
+	var issue *github.Issue
+	_, err := withBackoff(func() (resp *github.Response, err error) {
+		issue, resp, err = g.api().Issues.Create(context.Background(), g.username, g.reponame, req)
+		return resp, err
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	*ticket = *toTicket(issue)
+	return nil
+}
+
+// Comment adds a comment to an issue.
+func (g *githubPlugin) Comment(ID, comment string) error {
+	number, err := strconv.Atoi(ID)
+	if err != nil {
+		return errors.Wrap(err, "malformed GitHub issue number")
+	}
+	_, err = withBackoff(func() (*github.Response, error) {
+		_, resp, err := g.api().Issues.CreateComment(context.Background(), g.username, g.reponame, number, &github.IssueComment{Body: github.String(comment)})
+		return resp, err
+	})
+	return errors.Wrap(err, "unable to comment on ticket")
+}
+
+// Close closes an issue.
+func (g *githubPlugin) Close(ID string) error {
+	number, err := strconv.Atoi(ID)
+	if err != nil {
+		return errors.Wrap(err, "malformed GitHub issue number")
+	}
+	_, err = withBackoff(func() (*github.Response, error) {
+		_, resp, err := g.api().Issues.Edit(context.Background(), g.username, g.reponame, number, &github.IssueRequest{State: github.String("closed")})
+		return resp, err
+	})
+	return errors.Wrap(err, "unable to close ticket")
+}
+
+// Evidence fetches an issue's comments and the user who closed it. GitHub's
+// API doesn't expose issue attachments; they're embedded in comment bodies.
+func (g *githubPlugin) Evidence(ID string) (*model.Evidence, error) {
+	number, err := strconv.Atoi(ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed GitHub issue number")
+	}
+
+	var issue *github.Issue
+	_, err = withBackoff(func() (resp *github.Response, err error) {
+		issue, resp, err = g.api().Issues.Get(context.Background(), g.username, g.reponame, number)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch issue")
+	}
+	ev := &model.Evidence{}
+	if issue.ClosedBy != nil {
+		ev.ClosedBy = issue.ClosedBy.GetLogin()
+	}
+
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: perPage}}
+	for {
+		var comments []*github.IssueComment
+		resp, err := withBackoff(func() (resp *github.Response, err error) {
+			comments, resp, err = g.api().Issues.ListComments(context.Background(), g.username, g.reponame, number, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to list comments")
+		}
+		for _, c := range comments {
+			ev.Comments = append(ev.Comments, &model.Comment{
+				Author:    c.GetUser().GetLogin(),
+				CreatedAt: c.CreatedAt,
+				Body:      c.GetBody(),
+			})
+		}
+		if resp.NextPage == 0 {
+			return ev, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Download is unsupported; Evidence never reports GitHub attachments.
+func (g *githubPlugin) Download(a *model.Attachment) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("GitHub attachments can't be downloaded: %s", a.Name)
+}
+
+// Webhook accepts issues and issue_comment events signed with webhookSecret.
+func (g *githubPlugin) Webhook(r *http.Request, body []byte) (string, error) {
+	if g.webhookSecret == "" {
+		return "", errors.Wrap(model.ErrWebhookRejected, "no "+cfgWebhookSecret+" is configured for GitHub")
+	}
+	if !model.ValidSignature(g.webhookSecret, body, r.Header.Get("X-Hub-Signature-256")) {
+		return "", model.ErrWebhookRejected
+	}
+	switch r.Header.Get("X-GitHub-Event") {
+	case "issues", "issue_comment":
+	default:
+		return "", nil
+	}
+
+	var event struct {
+		Issue struct {
+			Number int `json:"number"`
+		} `json:"issue"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return "", errors.Wrap(err, "malformed GitHub event")
+	}
+	if event.Issue.Number == 0 || !strings.EqualFold(event.Repository.FullName, g.username+"/"+g.reponame) {
+		return "", nil
+	}
+	return strconv.Itoa(event.Issue.Number), nil
+}
+
+// milestoneFor finds or creates the milestone for the month a ticket is due,
+// so milestones don't pile up one per due date.
+func (g *githubPlugin) milestoneFor(due time.Time) (int, error) {
+	due = due.UTC()
+	title := "comply: due " + due.Format("2006-01")
+
+	// closed milestones still reserve their title
+	opts := &github.MilestoneListOptions{State: "all", ListOptions: github.ListOptions{PerPage: perPage}}
+	for {
+		var milestones []*github.Milestone
+		resp, err := withBackoff(func() (resp *github.Response, err error) {
+			milestones, resp, err = g.api().Issues.ListMilestones(context.Background(), g.username, g.reponame, opts)
+			return resp, err
+		})
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to list milestones")
+		}
+		for _, m := range milestones {
+			if m.GetTitle() == title {
+				return m.GetNumber(), nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	// due at the end of the month
+	dueOn := time.Date(due.Year(), due.Month()+1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Second)
+	var created *github.Milestone
+	_, err := withBackoff(func() (resp *github.Response, err error) {
+		created, resp, err = g.api().Issues.CreateMilestone(context.Background(), g.username, g.reponame, &github.Milestone{
+			Title: &title,
+			DueOn: &dueOn,
+		})
+		return resp, err
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to create milestone")
+	}
+	return created.GetNumber(), nil
 }
 
 func toTickets(issues []*github.Issue) []*model.Ticket {
@@ -161,17 +568,35 @@ func toTickets(issues []*github.Issue) []*model.Ticket {
 	return tickets
 }
 
+// dueTrailer records a ticket's due date in the issue body.
+var dueTrailer = regexp.MustCompile(`<!--\s*comply-due:\s*(\S+)\s*-->`)
+
 func toTicket(i *github.Issue) *model.Ticket {
 	t := &model.Ticket{Attributes: make(map[string]interface{})}
 	t.ID = strconv.Itoa(*i.Number)
 	t.Name = ss(i.Title)
 	t.Body = ss(i.Body)
+	t.Link = ss(i.HTMLURL)
 	t.CreatedAt = i.CreatedAt
+	t.UpdatedAt = i.UpdatedAt
+	t.ClosedAt = i.ClosedAt
 	t.State = toState(ss(i.State))
+	if i.Assignee != nil {
+		t.Assignee = ss(i.Assignee.Login)
+	}
+	if m := dueTrailer.FindStringSubmatch(t.Body); m != nil {
+		if due, err := time.Parse(time.RFC3339, m[1]); err == nil {
+			t.DueAt = &due
+		}
+	}
+	if t.DueAt == nil && i.Milestone != nil {
+		// issues due before due dates were recorded in the body
+		t.DueAt = i.Milestone.DueOn
+	}
 
 	for _, l := range i.Labels {
 		if l.Name != nil {
-			t.SetBool(*l.Name)
+			t.SetLabel(*l.Name)
 		}
 	}
 	return t
@@ -0,0 +1,253 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestFindByTagNamePages(t *testing.T) {
+	var server *httptest.Server
+	limited := true
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/acme/compliance/issues" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		if limited {
+			// the first request exhausts the primary rate limit
+			limited = false
+			w.Header().Set("X-RateLimit-Limit", "5000")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "API rate limit exceeded for installation ID 1."}`)
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, server.URL, r.URL.Path))
+		}
+		number := page
+		if number == 0 {
+			number = 1
+		}
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"number": number, "title": "Patch", "state": "open"},
+		})
+	}))
+	defer server.Close()
+
+	var slept []time.Duration
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { sleep = time.Sleep }()
+
+	g := &githubPlugin{}
+	err := g.Configure(map[string]interface{}{
+		cfgToken:    "token",
+		cfgUsername: "acme",
+		cfgRepo:     "compliance",
+		cfgBaseURL:  server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tickets, err := g.FindByTagName("comply-procedure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 2 {
+		t.Fatalf("expected 2 tickets across pages, got %d", len(tickets))
+	}
+	if len(slept) != 1 {
+		t.Fatalf("expected one rate-limit backoff, got %d", len(slept))
+	}
+	if link := g.LinkFor(tickets[0]); link != server.URL+"/acme/compliance/issues/1" {
+		t.Fatalf("unexpected link %s", link)
+	}
+}
+
+func TestMilestones(t *testing.T) {
+	var milestonesCreated []string
+	var issue struct {
+		Body      string `json:"body"`
+		Milestone int    `json:"milestone"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/acme/compliance/milestones":
+			if r.URL.Query().Get("state") != "all" {
+				t.Errorf("expected closed milestones to be reused too, got state=%s", r.URL.Query().Get("state"))
+			}
+			fmt.Fprint(w, `[{"number": 3, "title": "comply: due 2026-11", "due_on": "2026-11-30T23:59:59Z"}]`)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/acme/compliance/milestones":
+			var m struct {
+				Title string `json:"title"`
+			}
+			json.NewDecoder(r.Body).Decode(&m)
+			milestonesCreated = append(milestonesCreated, m.Title)
+			fmt.Fprint(w, `{"number": 4}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/acme/compliance/issues":
+			json.NewDecoder(r.Body).Decode(&issue)
+			b, _ := json.Marshal(issue.Body)
+			fmt.Fprintf(w, `{"number": 7, "state": "open", "body": %s, "milestone": {"number": %d, "due_on": "2026-11-30T23:59:59Z"}}`, b, issue.Milestone)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	g := &githubPlugin{}
+	err := g.Configure(map[string]interface{}{
+		cfgToken:    "token",
+		cfgUsername: "acme",
+		cfgRepo:     "compliance",
+		cfgBaseURL:  server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due := time.Date(2026, 11, 5, 12, 0, 0, 0, time.UTC)
+	ticket := &model.Ticket{Name: "Patch", Body: "Apply patches", DueAt: &due}
+	err = g.Create(ticket, []string{"comply"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.Milestone != 3 || len(milestonesCreated) != 0 {
+		t.Errorf("expected the month's milestone to be reused, got milestone %d and created %v", issue.Milestone, milestonesCreated)
+	}
+	if ticket.DueAt == nil || !ticket.DueAt.Equal(due) {
+		t.Errorf("expected the exact due date to be kept, got %v", ticket.DueAt)
+	}
+
+	due = time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	err = g.Create(&model.Ticket{Name: "Patch", DueAt: &due}, []string{"comply"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.Milestone != 4 || len(milestonesCreated) != 1 || milestonesCreated[0] != "comply: due 2026-12" {
+		t.Errorf("expected a milestone for December, got milestone %d and created %v", issue.Milestone, milestonesCreated)
+	}
+}
+
+func TestAppAuthentication(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/installations/7/access_tokens":
+			if !strings.HasPrefix(auth, "Bearer ey") {
+				t.Errorf("expected a JWT, got %q", auth)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "installation-token", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/acme/compliance/issues/3":
+			if auth != "Bearer installation-token" {
+				t.Errorf("expected the installation token, got %q", auth)
+			}
+			fmt.Fprint(w, `{"number": 3, "title": "Patch", "state": "closed"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	g := &githubPlugin{}
+	err = g.Configure(map[string]interface{}{
+		cfgUsername:       "acme",
+		cfgRepo:           "compliance",
+		cfgBaseURL:        server.URL,
+		cfgAppID:          42,
+		cfgInstallationID: 7,
+		cfgPrivateKey:     string(keyPEM),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Configured() {
+		t.Fatal("expected GitHub App configuration to be complete")
+	}
+
+	ticket, err := g.Get("3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket == nil || ticket.ID != "3" {
+		t.Fatalf("unexpected ticket %+v", ticket)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	g := &githubPlugin{username: "acme", reponame: "Comply", webhookSecret: "s3cret"}
+	body := []byte(`{"action":"closed","issue":{"number":42},"repository":{"full_name":"acme/comply"}}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	request := func(event, signature string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/hooks/github", nil)
+		r.Header.Set("X-GitHub-Event", event)
+		r.Header.Set("X-Hub-Signature-256", signature)
+		return r
+	}
+
+	ID, err := g.Webhook(request("issues", signature), body)
+	if err != nil || ID != "42" {
+		t.Fatalf("expected issue 42, got %q (%v)", ID, err)
+	}
+	if ID, err = g.Webhook(request("ping", signature), body); err != nil || ID != "" {
+		t.Errorf("expected ping events to be ignored, got %q (%v)", ID, err)
+	}
+	if _, err = g.Webhook(request("issues", "sha256=00"), body); err != model.ErrWebhookRejected {
+		t.Errorf("expected a bad signature to be rejected, got %v", err)
+	}
+	g.reponame = "other"
+	if ID, err = g.Webhook(request("issues", signature), body); err != nil || ID != "" {
+		t.Errorf("expected events for other repositories to be ignored, got %q (%v)", ID, err)
+	}
+}
+
+func TestGetCfgEnvironment(t *testing.T) {
+	defer os.Setenv("GITHUB_TOKEN", os.Getenv("GITHUB_TOKEN"))
+	defer os.Setenv("GITHUB_REPO", os.Getenv("GITHUB_REPO"))
+	os.Setenv("GITHUB_TOKEN", "from-env")
+	os.Setenv("GITHUB_REPO", "from-env")
+
+	cfg := map[string]interface{}{"username": "acme"}
+	if v, err := getCfg(cfg, cfgToken); err != nil || v != "from-env" {
+		t.Errorf("expected the deprecated GITHUB_TOKEN fallback, got %q (%v)", v, err)
+	}
+	if v, err := getCfg(cfg, cfgRepo); err == nil {
+		t.Errorf("expected only credentials to fall back to the environment, got %q", v)
+	}
+	if v, err := getCfg(cfg, cfgUsername); err != nil || v != "acme" {
+		t.Errorf("expected the configured value, got %q (%v)", v, err)
+	}
+}
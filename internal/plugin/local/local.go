@@ -0,0 +1,466 @@
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	cfgDir = "dir"
+
+	defaultDir = "tickets"
+)
+
+// Prompts are human-readable configuration element names
+func (l *localPlugin) Prompts() map[string]string {
+	return map[string]string{}
+}
+
+// Register causes the local plugin to register itself
+func Register() {
+	model.Register(model.Local, &localPlugin{dir: defaultDir})
+}
+
+// localPlugin stores tickets as markdown files with YAML front matter, so
+// they are reviewed and versioned alongside the rest of the compliance repo.
+type localPlugin struct {
+	dir string
+
+	mu sync.Mutex
+}
+
+type frontMatter struct {
+	ID        string     `yaml:"id"`
+	Name      string     `yaml:"name"`
+	State     string     `yaml:"state"`
+	Labels    []string   `yaml:"labels,flow"`
+	Assignee  string     `yaml:"assignee,omitempty"`
+	Priority  string     `yaml:"priority,omitempty"`
+	DueAt     *time.Time `yaml:"dueAt,omitempty"`
+	CreatedAt *time.Time `yaml:"createdAt,omitempty"`
+	UpdatedAt *time.Time `yaml:"updatedAt,omitempty"`
+	ClosedAt  *time.Time `yaml:"closedAt,omitempty"`
+	ClosedBy  string     `yaml:"closedBy,omitempty"`
+}
+
+type ticketFile struct {
+	frontMatter
+	body string
+}
+
+func (l *localPlugin) path() string {
+	if filepath.IsAbs(l.dir) {
+		return l.dir
+	}
+	return filepath.Join(config.ProjectRoot(), l.dir)
+}
+
+func (l *localPlugin) filename(ID string) string {
+	return filepath.Join(l.path(), ID+".md")
+}
+
+func (l *localPlugin) Get(ID string) (*model.Ticket, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := l.read(ID)
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l.toTicket(f), nil
+}
+
+func (l *localPlugin) Configured() bool {
+	return l.dir != ""
+}
+
+func (l *localPlugin) Links() model.TicketLinks {
+	// there is no UI to link to beyond the files themselves
+	return model.TicketLinks{}
+}
+
+func (l *localPlugin) Configure(cfg map[string]interface{}) error {
+	l.dir = defaultDir
+	if v, ok := cfg[cfgDir]; ok {
+		dir, ok := v.(string)
+		if !ok || dir == "" {
+			return errors.New("Malformatted key: " + cfgDir)
+		}
+		l.dir = dir
+	}
+	return nil
+}
+
+func (l *localPlugin) FindOpen() ([]*model.Ticket, error) {
+	return l.find(func(f *ticketFile) bool {
+		return f.State == string(model.Open) && hasLabel(f, "comply")
+	})
+}
+
+func (l *localPlugin) FindByTag(name, value string) ([]*model.Ticket, error) {
+	return l.FindByTagName(model.TagFor(name, value))
+}
+
+func (l *localPlugin) FindByTagName(name string) ([]*model.Ticket, error) {
+	return l.find(func(f *ticketFile) bool {
+		return hasLabel(f, name)
+	})
+}
+
+func (l *localPlugin) FindByTagNameSince(name string, since time.Time) ([]*model.Ticket, error) {
+	return l.find(func(f *ticketFile) bool {
+		return hasLabel(f, name) && (f.UpdatedAt == nil || !f.UpdatedAt.Before(since))
+	})
+}
+
+func (l *localPlugin) find(match func(*ticketFile) bool) ([]*model.Ticket, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, err := l.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var tickets []*model.Ticket
+	for _, f := range files {
+		if match(f) {
+			tickets = append(tickets, l.toTicket(f))
+		}
+	}
+	return tickets, nil
+}
+
+// LinkFor returns the ticket's path in the generated site, which Publish
+// copies it to.
+func (l *localPlugin) LinkFor(t *model.Ticket) string {
+	return path.Join(l.siteDir(), t.ID+".md")
+}
+
+// siteDir is where tickets are published in the generated site: the ticket
+// directory relative to the project root, or tickets/ if it lies outside.
+func (l *localPlugin) siteDir() string {
+	dir := filepath.ToSlash(filepath.Clean(l.dir))
+	if filepath.IsAbs(l.dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return defaultDir
+	}
+	return dir
+}
+
+// Publish copies the ticket files into the generated site, as the dashboard
+// is served from the output directory rather than the project root.
+func (l *localPlugin) Publish(output string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(l.path(), "*.md"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	dst := filepath.Join(output, filepath.FromSlash(l.siteDir()))
+	err = os.MkdirAll(dst, os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(filepath.Join(dst, filepath.Base(f)), b, os.FileMode(0644))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *localPlugin) Create(ticket *model.Ticket, labels []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := os.MkdirAll(l.path(), os.FileMode(0755))
+	if err != nil {
+		return errors.Wrapf(err, "could not create directory %s", l.dir)
+	}
+
+	files, err := l.readAll()
+	if err != nil {
+		return err
+	}
+	next := 1
+	for _, f := range files {
+		if n, err := strconv.Atoi(f.ID); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	f := &ticketFile{
+		frontMatter: frontMatter{
+			ID:        strconv.Itoa(next),
+			Name:      ticket.Name,
+			State:     string(model.Open),
+			Labels:    labels,
+			Assignee:  ticket.Assignee,
+			Priority:  ticket.Priority,
+			DueAt:     ticket.DueAt,
+			CreatedAt: &now,
+			UpdatedAt: &now,
+		},
+		body: ticket.Body,
+	}
+	err = l.write(f, true)
+	if err != nil {
+		return err
+	}
+	*ticket = *l.toTicket(f)
+	return nil
+}
+
+// Comment appends a dated comment to the ticket body.
+func (l *localPlugin) Comment(ID, comment string) error {
+	return l.update(ID, func(f *ticketFile, now time.Time) {
+		f.body = fmt.Sprintf("%s\n\n### Comment (%s)\n\n%s\n", strings.TrimRight(f.body, "\n"), now.Format(time.RFC3339), strings.TrimSpace(comment))
+	})
+}
+
+// Close marks the ticket closed.
+// Close closes a ticket on behalf of the current user.
+func (l *localPlugin) Close(ID string) error {
+	return l.CloseAs(ID, currentUser())
+}
+
+// CloseAs closes a ticket, recording closedBy as evidence of who resolved it.
+func (l *localPlugin) CloseAs(ID, closedBy string) error {
+	return l.update(ID, func(f *ticketFile, now time.Time) {
+		f.State = string(model.Closed)
+		f.ClosedAt = &now
+		f.ClosedBy = closedBy
+	})
+}
+
+// currentUser names whoever is running comply: the git author configured for
+// the project, or else the login name.
+func currentUser() string {
+	cmd := exec.Command("git", "config", "user.name")
+	cmd.Dir = config.ProjectRoot()
+	if out, err := cmd.Output(); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			return name
+		}
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// commentHeading matches the headings written by Comment.
+var commentHeading = regexp.MustCompile(`(?m)^### Comment \(([^)]*)\)\n`)
+
+// Evidence collects the comments appended to a ticket, the files in its
+// attachment directory (tickets/<ID>/) and the closedBy front matter.
+func (l *localPlugin) Evidence(ID string) (*model.Evidence, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := l.read(ID)
+	if err != nil {
+		return nil, err
+	}
+	ev := &model.Evidence{ClosedBy: f.ClosedBy}
+
+	headings := commentHeading.FindAllStringSubmatchIndex(f.body, -1)
+	for i, h := range headings {
+		end := len(f.body)
+		if i+1 < len(headings) {
+			end = headings[i+1][0]
+		}
+		c := &model.Comment{Body: strings.TrimSpace(f.body[h[1]:end])}
+		if createdAt, err := time.Parse(time.RFC3339, f.body[h[2]:h[3]]); err == nil {
+			c.CreatedAt = &createdAt
+		}
+		ev.Comments = append(ev.Comments, c)
+	}
+
+	dir := filepath.Join(l.path(), ID)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "unable to read attachments of ticket %s", ID)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ev.Attachments = append(ev.Attachments, &model.Attachment{
+			ID:   filepath.Join(dir, e.Name()),
+			Name: e.Name(),
+			URL:  filepath.ToSlash(filepath.Join(l.dir, ID, e.Name())),
+			Size: e.Size(),
+		})
+	}
+	return ev, nil
+}
+
+// Download opens an attachment file.
+func (l *localPlugin) Download(a *model.Attachment) (io.ReadCloser, error) {
+	return os.Open(a.ID)
+}
+
+func (l *localPlugin) update(ID string, fn func(*ticketFile, time.Time)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := l.read(ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	fn(f, now)
+	f.UpdatedAt = &now
+	return l.write(f, false)
+}
+
+func (l *localPlugin) readAll() ([]*ticketFile, error) {
+	entries, err := ioutil.ReadDir(l.path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read %s", l.dir)
+	}
+
+	var files []*ticketFile
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".md" {
+			continue
+		}
+		f, err := l.read(strings.TrimSuffix(e.Name(), ".md"))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		a, _ := strconv.Atoi(files[i].ID)
+		b, _ := strconv.Atoi(files[j].ID)
+		return a < b
+	})
+	return files, nil
+}
+
+func (l *localPlugin) read(ID string) (*ticketFile, error) {
+	b, err := ioutil.ReadFile(l.filename(ID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read ticket %s", ID)
+	}
+
+	content := strings.TrimPrefix(string(b), "---\n")
+	components := strings.SplitN(content, "\n---\n", 2)
+	if len(components) != 2 {
+		return nil, fmt.Errorf("malformed ticket %s, must be of the form: ---\\nYAML\\n---\\nmarkdown content", l.filename(ID))
+	}
+
+	f := &ticketFile{body: strings.TrimPrefix(components[1], "\n")}
+	err = yaml.Unmarshal([]byte(components[0]), &f.frontMatter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "malformed front matter in ticket %s", ID)
+	}
+	if f.ID == "" {
+		f.ID = ID
+	}
+	return f, nil
+}
+
+func (l *localPlugin) write(f *ticketFile, create bool) error {
+	fm, err := yaml.Marshal(&f.frontMatter)
+	if err != nil {
+		return errors.Wrap(err, "unable to encode ticket")
+	}
+
+	var b bytes.Buffer
+	b.WriteString("---\n")
+	b.Write(fm)
+	b.WriteString("---\n\n")
+	b.WriteString(f.body)
+	if !strings.HasSuffix(f.body, "\n") {
+		b.WriteString("\n")
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if create {
+		// never overwrite a ticket created concurrently under the same ID
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	out, err := os.OpenFile(l.filename(f.ID), flags, os.FileMode(0644))
+	if err != nil {
+		return errors.Wrapf(err, "unable to write ticket %s", f.ID)
+	}
+	defer out.Close()
+	_, err = out.Write(b.Bytes())
+	if err != nil {
+		return errors.Wrapf(err, "unable to write ticket %s", f.ID)
+	}
+	return nil
+}
+
+func (l *localPlugin) toTicket(f *ticketFile) *model.Ticket {
+	t := &model.Ticket{Attributes: make(map[string]interface{})}
+	t.ID = f.ID
+	t.Name = f.Name
+	t.Body = f.body
+	t.Link = l.LinkFor(t)
+	t.CreatedAt = f.CreatedAt
+	t.UpdatedAt = f.UpdatedAt
+	t.ClosedAt = f.ClosedAt
+	t.State = toState(f.State)
+	t.Assignee = f.Assignee
+	t.Priority = f.Priority
+	t.DueAt = f.DueAt
+
+	for _, label := range f.Labels {
+		t.SetLabel(label)
+	}
+	return t
+}
+
+func toState(state string) model.TicketState {
+	switch state {
+	case "closed":
+		return model.Closed
+	}
+	return model.Open
+}
+
+func hasLabel(f *ticketFile, name string) bool {
+	for _, label := range f.Labels {
+		if label == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetProjectRoot(dir)
+	defer config.SetProjectRoot("")
+
+	l := &localPlugin{}
+	err = l.Configure(map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Patch", "Access Review"} {
+		ticket := &model.Ticket{Name: name, Body: "Procedure-ID: patch"}
+		err = l.Create(ticket, []string{"comply", "comply-procedure"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "tickets", "2.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "---\nid: \"2\"\nname: Access Review\nstate: open\nlabels: [comply, comply-procedure]\n") {
+		t.Fatalf("unexpected ticket file:\n%s", b)
+	}
+
+	err = l.Comment("1", "Patched all hosts.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// without a git identity, the login name is recorded
+	defer os.Setenv("USER", os.Getenv("USER"))
+	os.Setenv("USER", "auditor")
+	err = l.Close("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticket, err := l.Get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket.State != model.Closed || ticket.ClosedAt == nil || !strings.Contains(ticket.Body, "Patched all hosts.") {
+		t.Fatalf("unexpected ticket %+v", ticket)
+	}
+	if ticket.ProcedureID() != "patch" {
+		t.Fatalf("unexpected procedure ID %q", ticket.ProcedureID())
+	}
+	ev, err := l.Evidence("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.ClosedBy == "" || ev.ClosedBy != currentUser() {
+		t.Errorf("expected the ticket to record who closed it, got %q", ev.ClosedBy)
+	}
+
+	open, err := l.FindOpen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 || open[0].ID != "2" {
+		t.Fatalf("unexpected open tickets %+v", open)
+	}
+
+	err = l.CloseAs("2", "Jane Auditor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err = l.Evidence("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.ClosedBy != "Jane Auditor" {
+		t.Errorf("expected the given closer to be recorded, got %q", ev.ClosedBy)
+	}
+
+	missing, err := l.Get("3")
+	if err != nil || missing != nil {
+		t.Fatalf("expected no ticket and no error, got %v, %v", missing, err)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetProjectRoot(dir)
+	defer config.SetProjectRoot("")
+
+	for _, ticketDir := range []string{"compliance/tickets", filepath.Join(dir, "elsewhere")} {
+		l := &localPlugin{}
+		err = l.Configure(map[string]interface{}{cfgDir: ticketDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ticket := &model.Ticket{Name: "Patch"}
+		err = l.Create(ticket, []string{"comply"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		output := filepath.Join(dir, "output")
+		err = l.Publish(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the dashboard at output/index.html links relative to itself
+		if !strings.HasSuffix(ticket.Link, "tickets/1.md") || strings.HasPrefix(ticket.Link, "/") {
+			t.Errorf("%s: expected a link relative to the site, got %q", ticketDir, ticket.Link)
+		}
+		if _, err := os.Stat(filepath.Join(output, filepath.FromSlash(ticket.Link))); err != nil {
+			t.Errorf("%s: expected %s to be published, got %v", ticketDir, ticket.Link, err)
+		}
+	}
+}
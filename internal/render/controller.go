@@ -22,6 +22,9 @@ type stats struct {
 	ProcedureTotal      int
 	ProcedureOpen       int
 	ProcedureOldestDays int
+	ProcedureOverdue    int
+	// closed procedure tickets with unchecked checklist items
+	ProcedureIncomplete int
 
 	AuditOpen   int
 	AuditClosed int
@@ -42,6 +45,17 @@ type renderData struct {
 	Links             *model.TicketLinks
 	GroupedNarratives []*DocumentGroup
 	GroupedPolicies   []*DocumentGroup
+	History           []*model.Snapshot
+	// most recent ticket by procedure ID
+	LatestTickets map[string]*model.Ticket
+	// open procedure tickets past their due date, most overdue first
+	OverdueTickets []*model.Ticket
+	// procedure tickets closed with unchecked checklist items, most recently closed first
+	IncompleteTickets []*model.Ticket
+	// manifest of the most recent evidence archive by procedure ID
+	LatestEvidence map[string]string
+	// when the least recently synced ticket system was last synced, if ever
+	LastSynced *time.Time
 }
 
 type DocumentGroup struct {
@@ -116,6 +130,29 @@ func load() (*model.Data, *renderData, error) {
 		links := tp.Links()
 		rd.Links = &links
 	}
+	for _, t := range rd.Tickets {
+		if t.Link != "" {
+			continue
+		}
+		if tp, err := model.GetPluginFor(t); err == nil && tp.Configured() {
+			t.Link = tp.LinkFor(t)
+		}
+	}
+	rd.LatestTickets = latestTickets(rd.Tickets)
+	rd.LatestEvidence, err = latestEvidence()
+	if err != nil {
+		return nil, nil, err
+	}
+	rd.LastSynced, err = lastSynced()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// trend charts cover the trailing year
+	rd.History, err = model.ReadHistory(time.Now().AddDate(-1, 0, 0))
+	if err != nil {
+		return nil, nil, err
+	}
 
 	return modelData, rd, nil
 }
@@ -132,6 +169,7 @@ func loadWithStats() (*model.Data, *renderData, error) {
 
 func addStats(modelData *model.Data, renderData *renderData) {
 	stats := &stats{}
+	now := time.Now()
 
 	satisfied := model.ControlsSatisfied(modelData)
 
@@ -145,8 +183,19 @@ func addStats(modelData *model.Data, renderData *renderData) {
 	}
 
 	for _, t := range renderData.Tickets {
-		if t.Bool("audit") {
+		if t.Audit() {
 			stats.AuditTotal++
+			if t.State == model.Closed {
+				stats.AuditClosed++
+			}
+		}
+
+		if t.Bool("comply-procedure") {
+			stats.ProcedureTotal++
+			if t.ClosedIncomplete() {
+				stats.ProcedureIncomplete++
+				renderData.IncompleteTickets = append(renderData.IncompleteTickets, t)
+			}
 		}
 
 		if t.State == model.Open {
@@ -158,16 +207,65 @@ func addStats(modelData *model.Data, renderData *renderData) {
 						stats.ProcedureOldestDays = age
 					}
 				}
+				if t.Overdue(now) {
+					stats.ProcedureOverdue++
+					renderData.OverdueTickets = append(renderData.OverdueTickets, t)
+				}
 			}
-			if t.Bool("audit") {
+			if t.Audit() {
 				stats.AuditOpen++
 			}
 		}
 	}
 
+	sort.Slice(renderData.OverdueTickets, func(i, j int) bool {
+		return renderData.OverdueTickets[i].DueAt.Before(*renderData.OverdueTickets[j].DueAt)
+	})
+	sort.Slice(renderData.IncompleteTickets, func(i, j int) bool {
+		a, b := renderData.IncompleteTickets[i].ClosedAt, renderData.IncompleteTickets[j].ClosedAt
+		return b == nil || (a != nil && a.After(*b))
+	})
 	renderData.Stats = stats
 }
 
+// lastSynced reports the oldest sync among the configured ticket systems, so
+// the dashboard never claims to be fresher than its stalest numbers.
+func lastSynced() (*time.Time, error) {
+	systems, err := config.Config().TicketSystems()
+	if err != nil || len(systems) == 0 {
+		return nil, nil
+	}
+	var oldest *time.Time
+	for _, ts := range systems {
+		state, err := model.ReadSyncState(ts)
+		if err != nil {
+			return nil, err
+		}
+		if state == nil {
+			return nil, nil
+		}
+		if oldest == nil || state.LastSync.Before(*oldest) {
+			oldest = &state.LastSync
+		}
+	}
+	return oldest, nil
+}
+
+func latestTickets(tickets []*model.Ticket) map[string]*model.Ticket {
+	latest := make(map[string]*model.Ticket)
+	for _, t := range tickets {
+		procedureID := t.ProcedureID()
+		if procedureID == "" || t.CreatedAt == nil {
+			continue
+		}
+		previous, ok := latest[procedureID]
+		if !ok || previous.CreatedAt.Before(*t.CreatedAt) {
+			latest[procedureID] = t
+		}
+	}
+	return latest
+}
+
 // groupDocumentsByAcronym groups documents by acronym and creates DocumentGroup structures
 func groupDocumentsByAcronym(docs []*model.Document) []*DocumentGroup {
 	groups := make(map[string][]*model.Document)
@@ -191,6 +289,10 @@ func groupDocumentsByAcronym(docs []*model.Document) []*DocumentGroup {
 			return versions[i].Language < versions[j].Language
 		})
 
+		for _, version := range versions {
+			version.Stale = model.TranslationStale(version)
+		}
+
 		// Use the English version (first one) for the name
 		englishVersion := versions[0]
 
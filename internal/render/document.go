@@ -93,11 +93,14 @@ func preprocessDoc(data *renderData, pol *model.Document, fullPath string) error
 	cfg := config.Config()
 
 	var w bytes.Buffer
-	bodyTemplate, err := template.New("body").Parse(pol.Body)
+	bodyTemplate, err := template.New("body").Funcs(documentFuncs(data, pol)).Parse(pol.Body)
 	if err != nil {
 		w.WriteString(fmt.Sprintf("# Error processing template:\n\n%s\n", err.Error()))
 	} else {
-		bodyTemplate.Execute(&w, data)
+		err = bodyTemplate.Execute(&w, data)
+		if err != nil {
+			w.WriteString(fmt.Sprintf("\n\n# Error processing template:\n\n%s\n", err.Error()))
+		}
 	}
 	body := w.String()
 
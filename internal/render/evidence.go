@@ -0,0 +1,57 @@
+package render
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/model"
+)
+
+// evidenceDir is where archived ticket evidence is published within the output directory.
+const evidenceDir = "evidence"
+
+// latestEvidence links each procedure to the manifest of its most recently closed archived ticket.
+func latestEvidence() (map[string]string, error) {
+	manifests, err := model.ReadEvidence()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]*model.EvidenceManifest)
+	links := make(map[string]string)
+	for rel, m := range manifests {
+		previous, ok := latest[m.Procedure]
+		if ok && previous.ClosedAt != nil && (m.ClosedAt == nil || !m.ClosedAt.After(*previous.ClosedAt)) {
+			continue
+		}
+		latest[m.Procedure] = m
+		links[m.Procedure] = path.Join(evidenceDir, rel, model.EvidenceManifestFile)
+	}
+	return links, nil
+}
+
+// copyEvidence publishes the evidence archives alongside the dashboard that links to them.
+func copyEvidence(output string) error {
+	root := model.EvidenceRoot()
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+
+	err := filepath.Walk(root, func(src string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, src)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(output, evidenceDir, rel)
+		if info.IsDir() {
+			return os.MkdirAll(dst, os.FileMode(0755))
+		}
+		return copyFile(src, dst)
+	})
+	return errors.Wrap(err, "unable to copy evidence")
+}
@@ -0,0 +1,201 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+// maxPartialDepth bounds nested partial inclusion to guard against cycles.
+const maxPartialDepth = 8
+
+// documentFuncs returns the template functions available to document bodies.
+//
+//	{{controls}}                       controls satisfied by this document
+//	{{nextRun "patch"}}                next scheduled time of a procedure
+//	{{openTickets}}                    open procedure tickets, optionally {{openTickets "patch"}}
+//	{{date "2 January 2006" .X}}       date formatted in the document's language
+//	{{partial "signatures"}}           contents of partials/signatures.md
+//	{{var "cisoName"}}                 project variable from comply.yml
+func documentFuncs(data *renderData, doc *model.Document) template.FuncMap {
+	depth := 0
+
+	var funcs template.FuncMap
+	funcs = template.FuncMap{
+		"controls": func() []*control {
+			return controlsFor(data, doc.Satisfies)
+		},
+		"nextRun": func(procedureID string) (time.Time, error) {
+			return nextRun(data, procedureID, time.Now())
+		},
+		"openTickets": func(procedureIDs ...string) int {
+			return openTickets(data, procedureIDs...)
+		},
+		"date": func(layout string, t time.Time) string {
+			return formatDate(t, layout, doc.Language)
+		},
+		"partial": func(name string) (string, error) {
+			if depth >= maxPartialDepth {
+				return "", fmt.Errorf("partials nested more than %d levels deep (cycle in %s?)", maxPartialDepth, name)
+			}
+			depth++
+			defer func() { depth-- }()
+			return renderPartial(data, funcs, name)
+		},
+		"var": func(name string) (interface{}, error) {
+			v, ok := config.Config().Variables[name]
+			if !ok {
+				return nil, fmt.Errorf("undefined variable %q (define it under `variables` in comply.yml)", name)
+			}
+			return v, nil
+		},
+	}
+	return funcs
+}
+
+func controlsFor(data *renderData, satisfies model.Satisfaction) []*control {
+	var result []*control
+	for _, keys := range satisfies {
+		for _, key := range keys {
+			for _, c := range data.Controls {
+				if c.ControlKey == key {
+					result = append(result, c)
+				}
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ControlKey < result[j].ControlKey
+	})
+	return result
+}
+
+func nextRun(data *renderData, procedureID string, now time.Time) (time.Time, error) {
+	for _, p := range data.Procedures {
+		if p.ID != procedureID {
+			continue
+		}
+		schedule, err := p.Schedule()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return schedule.Next(now), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown procedure ID: %s", procedureID)
+}
+
+func openTickets(data *renderData, procedureIDs ...string) int {
+	count := 0
+	for _, t := range data.Tickets {
+		if t.State != model.Open || !t.Bool("comply-procedure") {
+			continue
+		}
+		if len(procedureIDs) > 0 && !contains(procedureIDs, t.ProcedureID()) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+func renderPartial(data *renderData, funcs template.FuncMap, name string) (string, error) {
+	path := filepath.Join("partials", name)
+	if filepath.Ext(path) == "" {
+		path += ".md"
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to read partial %s", name)
+	}
+
+	tpl, err := template.New(name).Funcs(funcs).Parse(string(body))
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to parse partial %s", name)
+	}
+
+	var w bytes.Buffer
+	err = tpl.Execute(&w, data)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to render partial %s", name)
+	}
+	return w.String(), nil
+}
+
+type locale struct {
+	months   [12]string
+	weekdays [7]string
+}
+
+// locales are keyed by the language portion of a document language code ("pt" for "pt-BR").
+var locales = map[string]locale{
+	"de": {
+		months:   [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		weekdays: [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+	},
+	"es": {
+		months:   [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		weekdays: [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+	},
+	"fr": {
+		months:   [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		weekdays: [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+	},
+	"it": {
+		months:   [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		weekdays: [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+	},
+	"pt": {
+		months:   [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		weekdays: [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+	},
+}
+
+// formatDate formats t using a Go time layout, substituting month and weekday
+// names for the given language. Unknown languages fall back to English.
+func formatDate(t time.Time, layout, language string) string {
+	l, ok := locales[strings.ToLower(strings.Split(language, "-")[0])]
+	if !ok {
+		return t.Format(layout)
+	}
+
+	// swap name tokens for placeholders the time package passes through verbatim
+	placeholders := strings.NewReplacer("January", "\x01", "Jan", "\x02", "Monday", "\x03", "Mon", "\x04")
+	formatted := t.Format(placeholders.Replace(layout))
+
+	month := l.months[t.Month()-1]
+	weekday := l.weekdays[t.Weekday()]
+	names := strings.NewReplacer(
+		"\x01", month,
+		"\x02", abbreviate(month),
+		"\x03", weekday,
+		"\x04", abbreviate(weekday),
+	)
+	return names.Replace(formatted)
+}
+
+func abbreviate(name string) string {
+	runes := []rune(name)
+	if len(runes) <= 3 {
+		return name
+	}
+	return string(runes[:3])
+}
+
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
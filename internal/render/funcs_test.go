@@ -0,0 +1,44 @@
+package render
+
+import (
+	"testing"
+	"time"
+
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestFormatDate(t *testing.T) {
+	d := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		layout, language, expected string
+	}{
+		{"2 January 2006", "", "2 March 2026"},
+		{"Monday, 2 January 2006", "pt-BR", "segunda-feira, 2 março 2026"},
+		{"Mon 2 Jan", "es-ES", "lun 2 mar"},
+		{"2006-01-02", "fr", "2026-03-02"},
+		{"January 2006", "xx", "March 2026"},
+	}
+
+	for _, c := range cases {
+		actual := formatDate(d, c.layout, c.language)
+		if actual != c.expected {
+			t.Errorf("formatDate(%q, %q) = %q, expected %q", c.layout, c.language, actual, c.expected)
+		}
+	}
+}
+
+func TestControlsFor(t *testing.T) {
+	data := &renderData{
+		Controls: []*control{
+			{ControlKey: "CC1.1"},
+			{ControlKey: "CC2.1"},
+			{ControlKey: "CC3.1"},
+		},
+	}
+
+	controls := controlsFor(data, model.Satisfaction{"TSC": []string{"CC3.1", "CC1.1"}})
+	if len(controls) != 2 || controls[0].ControlKey != "CC1.1" || controls[1].ControlKey != "CC3.1" {
+		t.Fatalf("unexpected controls %v", controls)
+	}
+}
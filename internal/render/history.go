@@ -0,0 +1,56 @@
+package render
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/model"
+)
+
+// RecordHistory saves a dated snapshot of the current compliance statistics to .comply/history.
+func RecordHistory() error {
+	modelData, data, err := loadWithStats()
+	if err != nil {
+		return errors.Wrap(err, "unable to load data")
+	}
+
+	err = model.SaveSnapshot(snapshot(modelData, data.Stats))
+	if err != nil {
+		return errors.Wrap(err, "unable to record history")
+	}
+	return nil
+}
+
+func snapshot(modelData *model.Data, stats *stats) *model.Snapshot {
+	s := &model.Snapshot{
+		Date:                time.Now().UTC(),
+		ControlsTotal:       stats.ControlsTotal,
+		ControlsSatisfied:   stats.ControlsSatisfied,
+		ProcedureTotal:      stats.ProcedureTotal,
+		ProcedureOpen:       stats.ProcedureOpen,
+		ProcedureOldestDays: stats.ProcedureOldestDays,
+		AuditOpen:           stats.AuditOpen,
+		AuditClosed:         stats.AuditClosed,
+		AuditTotal:          stats.AuditTotal,
+	}
+
+	satisfied := model.ControlsSatisfied(modelData)
+	for _, std := range modelData.Standards {
+		coverage := &model.StandardCoverage{
+			Name:          std.Name,
+			ControlsTotal: len(std.Controls),
+		}
+		for controlKey := range std.Controls {
+			if _, ok := satisfied[controlKey]; ok {
+				coverage.ControlsSatisfied++
+			}
+		}
+		s.Standards = append(s.Standards, coverage)
+	}
+	sort.Slice(s.Standards, func(i, j int) bool {
+		return s.Standards[i].Name < s.Standards[j].Name
+	})
+
+	return s
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/skratchdot/open-golang/open"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
 	"github.com/yosssi/ace"
 )
 
@@ -48,6 +50,17 @@ func html(output string, live bool, errCh chan error, wg *sync.WaitGroup) {
 			return
 		}
 
+		err = copyEvidence(output)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		err = publishTickets(output)
+		if err != nil {
+			errCh <- err
+			return
+		}
+
 		for _, fileInfo := range files {
 			if !strings.HasSuffix(fileInfo.Name(), ".ace") {
 				continue
@@ -95,3 +108,22 @@ func html(output string, live bool, errCh chan error, wg *sync.WaitGroup) {
 		<-subscribe()
 	}
 }
+
+// publishTickets copies tickets kept in the repository into the site, so the
+// dashboard can link to them.
+func publishTickets(output string) error {
+	systems, err := config.Config().TicketSystems()
+	if err != nil {
+		return errors.Wrap(err, "error in ticket system configuration")
+	}
+	for _, ts := range systems {
+		tp := model.GetPlugin(model.TicketSystem(ts))
+		if p, ok := tp.(model.TicketPublisher); ok && tp.Configured() {
+			err = p.Publish(output)
+			if err != nil {
+				return errors.Wrapf(err, "unable to publish %s tickets", ts)
+			}
+		}
+	}
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/translate"
 	"github.com/yosssi/ace"
 )
 
@@ -87,6 +89,11 @@ func Build(output string, live bool) error {
 		errors.Wrap(err, "unable to create output directory")
 	}
 
+	err = RecordHistory()
+	if err != nil {
+		return err
+	}
+
 	var wg sync.WaitGroup
 	errCh := make(chan error, 0)
 	wgCh := make(chan struct{})
@@ -142,17 +149,28 @@ func BuildTranslated(outputDir, targetLang, provider string, live bool) error {
 		return errors.Wrap(err, "unable to create translated output directory")
 	}
 
+	model := ""
+	if t := config.Config().Translation; t != nil {
+		model = t.Model
+	}
+	// shared by the PDF and HTML passes, which translate the same documents
+	memory, err := translate.NewMemory(provider, model)
+	if err != nil {
+		return err
+	}
+	defer func() { fmt.Println(memory.Report()) }()
+
 	var wg sync.WaitGroup
 	errCh := make(chan error, 0)
 	wgCh := make(chan struct{})
 
 	// PDF translation
 	wg.Add(1)
-	go pdfTranslated(langOutputDir, targetLang, provider, live, errCh, &wg)
+	go pdfTranslated(langOutputDir, targetLang, memory, live, errCh, &wg)
 
 	// HTML translation
 	wg.Add(1)
-	go htmlTranslated(langOutputDir, targetLang, provider, live, errCh, &wg)
+	go htmlTranslated(langOutputDir, targetLang, memory, live, errCh, &wg)
 
 	// WG monitor
 	go func() {
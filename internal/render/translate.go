@@ -14,7 +14,7 @@ import (
 )
 
 // pdfTranslated generates translated PDF documents
-func pdfTranslated(outputDir, targetLang, provider string, live bool, errOutputCh chan error, wg *sync.WaitGroup) {
+func pdfTranslated(outputDir, targetLang string, memory *translate.Memory, live bool, errOutputCh chan error, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	fmt.Printf("Generating translated PDF documents (%s)...\n", targetLang)
@@ -28,7 +28,7 @@ func pdfTranslated(outputDir, targetLang, provider string, live bool, errOutputC
 
 	// Process policies
 	for _, pol := range data.Policies {
-		err = renderTranslatedDocument(data, pol, outputDir, targetLang, provider, "pdf")
+		err = renderTranslatedDocument(data, pol, outputDir, targetLang, memory, "pdf")
 		if err != nil {
 			errOutputCh <- errors.Wrapf(err, "unable to render translated policy: %s", pol.Name)
 			return
@@ -47,7 +47,7 @@ func pdfTranslated(outputDir, targetLang, provider string, live bool, errOutputC
 			Satisfies:      proc.Satisfies,
 			Revisions:      proc.Revisions,
 		}
-		err = renderTranslatedDocument(data, doc, outputDir, targetLang, provider, "pdf")
+		err = renderTranslatedDocument(data, doc, outputDir, targetLang, memory, "pdf")
 		if err != nil {
 			errOutputCh <- errors.Wrapf(err, "unable to render translated procedure: %s", proc.Name)
 			return
@@ -56,7 +56,7 @@ func pdfTranslated(outputDir, targetLang, provider string, live bool, errOutputC
 
 	// Process narratives
 	for _, narr := range data.Narratives {
-		err = renderTranslatedDocument(data, narr, outputDir, targetLang, provider, "pdf")
+		err = renderTranslatedDocument(data, narr, outputDir, targetLang, memory, "pdf")
 		if err != nil {
 			errOutputCh <- errors.Wrapf(err, "unable to render translated narrative: %s", narr.Name)
 			return
@@ -67,7 +67,7 @@ func pdfTranslated(outputDir, targetLang, provider string, live bool, errOutputC
 }
 
 // htmlTranslated generates translated HTML documents
-func htmlTranslated(outputDir, targetLang, provider string, live bool, errOutputCh chan error, wg *sync.WaitGroup) {
+func htmlTranslated(outputDir, targetLang string, memory *translate.Memory, live bool, errOutputCh chan error, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	fmt.Printf("Generating translated HTML documents (%s)...\n", targetLang)
@@ -81,7 +81,7 @@ func htmlTranslated(outputDir, targetLang, provider string, live bool, errOutput
 
 	// Process policies
 	for _, pol := range data.Policies {
-		err = renderTranslatedDocument(data, pol, outputDir, targetLang, provider, "html")
+		err = renderTranslatedDocument(data, pol, outputDir, targetLang, memory, "html")
 		if err != nil {
 			errOutputCh <- errors.Wrapf(err, "unable to render translated policy HTML: %s", pol.Name)
 			return
@@ -100,7 +100,7 @@ func htmlTranslated(outputDir, targetLang, provider string, live bool, errOutput
 			Satisfies:      proc.Satisfies,
 			Revisions:      proc.Revisions,
 		}
-		err = renderTranslatedDocument(data, doc, outputDir, targetLang, provider, "html")
+		err = renderTranslatedDocument(data, doc, outputDir, targetLang, memory, "html")
 		if err != nil {
 			errOutputCh <- errors.Wrapf(err, "unable to render translated procedure HTML: %s", proc.Name)
 			return
@@ -109,7 +109,7 @@ func htmlTranslated(outputDir, targetLang, provider string, live bool, errOutput
 
 	// Process narratives
 	for _, narr := range data.Narratives {
-		err = renderTranslatedDocument(data, narr, outputDir, targetLang, provider, "html")
+		err = renderTranslatedDocument(data, narr, outputDir, targetLang, memory, "html")
 		if err != nil {
 			errOutputCh <- errors.Wrapf(err, "unable to render translated narrative HTML: %s", narr.Name)
 			return
@@ -120,7 +120,7 @@ func htmlTranslated(outputDir, targetLang, provider string, live bool, errOutput
 }
 
 // renderTranslatedDocument processes and translates a single document
-func renderTranslatedDocument(data *renderData, doc *model.Document, outputDir, targetLang, provider, format string) error {
+func renderTranslatedDocument(data *renderData, doc *model.Document, outputDir, targetLang string, memory *translate.Memory, format string) error {
 	// Only process newer files
 	if !isNewer(doc.FullPath, doc.ModifiedAt) {
 		return nil
@@ -144,7 +144,7 @@ func renderTranslatedDocument(data *renderData, doc *model.Document, outputDir,
 
 	// Translate the content
 	fmt.Printf("Translating %s to %s...\n", doc.Name, targetLang)
-	translatedContent, err := translate.TranslateDocument(string(content), "en", targetLang, provider, "")
+	translatedContent, err := translate.TranslateDocument(string(content), "en", targetLang, memory)
 	if err != nil {
 		return errors.Wrapf(err, "translation failed for document: %s", doc.Name)
 	}
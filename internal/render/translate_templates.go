@@ -9,11 +9,14 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
 	"github.com/strongdm/comply/internal/translate"
 )
 
-// TranslateTemplates translates template files based on path or config
-func TranslateTemplates(path, provider string) error {
+// TranslateTemplates translates template files based on path or config. Unless
+// refresh is set, segments translated before are reused from the translation
+// memory.
+func TranslateTemplates(path, provider string, refresh bool) error {
 	cfg := config.Config()
 
 	// Get languages from config or default
@@ -64,18 +67,26 @@ func TranslateTemplates(path, provider string) error {
 		return nil
 	}
 
+	memory, err := translate.NewMemory(providerToUse, modelToUse)
+	if err != nil {
+		return err
+	}
+	memory.Refresh = refresh
+
 	fmt.Printf("Translating %d files to languages: %s\n", len(filesToTranslate), strings.Join(languages, ", "))
 
 	// Translate each file to each language
 	for _, file := range filesToTranslate {
 		for _, lang := range languages {
-			err = translateSingleTemplate(file, lang, providerToUse, modelToUse)
+			err = translateSingleTemplate(file, lang, memory)
 			if err != nil {
+				fmt.Println(memory.Report())
 				return errors.Wrapf(err, "failed to translate %s to %s", file, lang)
 			}
 		}
 	}
 
+	fmt.Println(memory.Report())
 	fmt.Println("Template translation completed successfully")
 	return nil
 }
@@ -171,7 +182,7 @@ func isTemplateFile(path string) bool {
 }
 
 // translateSingleTemplate translates a single template file
-func translateSingleTemplate(filePath, targetLang, provider, model string) error {
+func translateSingleTemplate(filePath, targetLang string, memory *translate.Memory) error {
 	// Read original file
 	content, err := ioutil.ReadFile(filePath)
 	if err != nil {
@@ -181,8 +192,8 @@ func translateSingleTemplate(filePath, targetLang, provider, model string) error
 	// Generate translated filename
 	translatedPath := generateTranslatedPath(filePath, targetLang)
 
-	// Check if translation already exists and is newer
-	if shouldSkipTranslation(filePath, translatedPath) {
+	// Check if translation already exists and is up to date
+	if !memory.Refresh && shouldSkipTranslation(translatedPath) {
 		fmt.Printf("Skipping %s (translation is up to date)\n", translatedPath)
 		return nil
 	}
@@ -190,11 +201,17 @@ func translateSingleTemplate(filePath, targetLang, provider, model string) error
 	fmt.Printf("Translating %s to %s...\n", filePath, targetLang)
 
 	// Translate content
-	translatedContent, err := translate.TranslateTemplate(string(content), "en", targetLang, provider, model)
+	translatedContent, err := translate.TranslateTemplate(string(content), "en", targetLang, memory)
 	if err != nil {
 		return errors.Wrapf(err, "translation failed for file: %s", filePath)
 	}
 
+	hash, err := model.SourceHash(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read file: %s", filePath)
+	}
+	translatedContent = recordSource(translatedContent, hash, model.SourceCommit(filePath))
+
 	// Write translated file
 	err = ioutil.WriteFile(translatedPath, []byte(translatedContent), 0644)
 	if err != nil {
@@ -216,21 +233,106 @@ func generateTranslatedPath(originalPath, lang string) string {
 	return filepath.Join(dir, translatedFilename)
 }
 
-// shouldSkipTranslation checks if translation should be skipped
-func shouldSkipTranslation(originalPath, translatedPath string) bool {
-	// Check if translated file exists
-	translatedInfo, err := os.Stat(translatedPath)
-	if err != nil {
+// shouldSkipTranslation reports whether a translation was produced from the
+// current content of its source.
+func shouldSkipTranslation(translatedPath string) bool {
+	if _, err := os.Stat(translatedPath); err != nil {
 		return false // Translation doesn't exist, should translate
 	}
+	status, err := model.CheckTranslation(translatedPath)
+	return err == nil && !status.Stale
+}
+
+// recordSource notes the source a translation was produced from in its
+// metadata, so translate-templates --check can tell when it falls behind.
+// Templates without metadata gain some.
+func recordSource(content, hash, commit string) string {
+	fields := "sourceHash: " + hash + "\n"
+	if commit != "" {
+		fields += "sourceCommit: " + commit + "\n"
+	}
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		// skip a --- line opening the metadata
+		if i > 0 && strings.TrimSpace(line) == "---" {
+			return strings.Join(lines[:i], "") + fields + strings.Join(lines[i:], "")
+		}
+	}
+	return fields + "---\n" + content
+}
+
+// CheckTranslations lists the translations under path, or in the whole
+// project, and whether each is out of date with its source.
+func CheckTranslations(path string) ([]*model.TranslationStatus, error) {
+	var files []string
+	if path == "" {
+		for _, dir := range []string{"policies", "procedures", "narratives"} {
+			dirFiles, err := getTranslationFiles(dir)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, dirFiles...)
+		}
+	} else {
+		var err error
+		files, err = getTranslationFiles(path)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	// Check if original file is newer than translation
-	originalInfo, err := os.Stat(originalPath)
+	var statuses []*model.TranslationStatus
+	for _, f := range files {
+		status, err := model.CheckTranslation(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to check %s", f)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// getTranslationFiles returns the translations in a directory, the
+// translations of a template, or a single translation.
+func getTranslationFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
 	if err != nil {
-		return false // Can't stat original, should translate
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		if model.TranslationSource(path) != "" {
+			return []string{path}, nil
+		}
+		// a template: check each of its translations
+		matches, err := filepath.Glob(strings.TrimSuffix(path, ".md") + ".*.md")
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, m := range matches {
+			if model.TranslationSource(m) == path {
+				files = append(files, m)
+			}
+		}
+		return files, nil
 	}
 
-	// Skip if translation is newer than or equal to original
-	return translatedInfo.ModTime().After(originalInfo.ModTime()) ||
-		translatedInfo.ModTime().Equal(originalInfo.ModTime())
+	var files []string
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(p, ".md") && model.TranslationSource(p) != "" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error walking directory: %s", path)
+	}
+	return files, nil
 }
@@ -0,0 +1,57 @@
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestRecordSource(t *testing.T) {
+	content := "name: Política de Acesso\nacronym: AP\n---\n# Propósito\n\n---\n"
+	expected := "name: Política de Acesso\nacronym: AP\nsourceHash: sha256:abc\nsourceCommit: 0123\n---\n# Propósito\n\n---\n"
+	if recorded := recordSource(content, "sha256:abc", "0123"); recorded != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, recorded)
+	}
+
+	content = "---\nname: Política de Acesso\n---\n# Propósito\n"
+	expected = "---\nname: Política de Acesso\nsourceHash: sha256:abc\n---\n# Propósito\n"
+	if recorded := recordSource(content, "sha256:abc", ""); recorded != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, recorded)
+	}
+
+	content = "# Propósito\n\nSem metadados.\n"
+	expected = "sourceHash: sha256:abc\n---\n# Propósito\n\nSem metadados.\n"
+	if recorded := recordSource(content, "sha256:abc", ""); recorded != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, recorded)
+	}
+}
+
+func TestRecordSourceWithoutMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-translation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "notes.md")
+	err = ioutil.WriteFile(source, []byte("# Purpose\n\nNo metadata.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := model.SourceHash(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	translation := filepath.Join(dir, "notes.pt-BR.md")
+	err = ioutil.WriteFile(translation, []byte(recordSource("# Propósito\n\nSem metadados.\n", hash, "")), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !shouldSkipTranslation(translation) {
+		t.Error("expected a translation of a template without metadata to be up to date")
+	}
+}
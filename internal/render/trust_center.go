@@ -0,0 +1,184 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+	"github.com/strongdm/comply/internal/theme"
+	"github.com/yosssi/ace"
+)
+
+// trustCenterTemplate is resolved relative to the project templates directory,
+// falling back to the copy bundled with the default theme.
+const trustCenterTemplate = "trust-center/index"
+
+type trustCenterData struct {
+	Project          *project
+	Description      string
+	RequestAccessURL string
+	ContactEmail     string
+	Frameworks       []string
+	Standards        []*standardSummary
+	Documents        []*publicDocument
+}
+
+type standardSummary struct {
+	Name              string
+	ControlsTotal     int
+	ControlsSatisfied int
+	Families          []*familySummary
+}
+
+type familySummary struct {
+	Name              string
+	ControlsTotal     int
+	ControlsSatisfied int
+}
+
+type publicDocument struct {
+	Name           string
+	Kind           string
+	OutputFilename string
+}
+
+// BuildTrustCenter generates the public trust-center site in the output directory.
+// Only documents marked `public: true` are published; their PDFs are copied from
+// the source directory populated by Build.
+func BuildTrustCenter(source, output string) error {
+	modelData, data, err := load()
+	if err != nil {
+		return errors.Wrap(err, "unable to load data")
+	}
+
+	err = os.RemoveAll(output)
+	if err != nil {
+		return errors.Wrap(err, "unable to remove files from trust center directory")
+	}
+
+	err = os.MkdirAll(output, os.FileMode(0755))
+	if err != nil {
+		return errors.Wrap(err, "unable to create trust center directory")
+	}
+
+	tc := trustCenterSummary(modelData, data)
+
+	for _, doc := range tc.Documents {
+		err = copyFile(filepath.Join(source, doc.OutputFilename), filepath.Join(output, doc.OutputFilename))
+		if err != nil {
+			return errors.Wrapf(err, "unable to publish %s", doc.OutputFilename)
+		}
+		fmt.Printf("%s -> %s\n", filepath.Join(source, doc.OutputFilename), filepath.Join(output, doc.OutputFilename))
+	}
+
+	tpl, err := loadTrustCenterTemplate()
+	if err != nil {
+		return err
+	}
+
+	outputFilename := filepath.Join(output, "index.html")
+	w, err := os.Create(outputFilename)
+	if err != nil {
+		return errors.Wrap(err, "unable to create HTML file")
+	}
+	defer w.Close()
+
+	err = tpl.Execute(w, tc)
+	if err != nil {
+		return errors.Wrap(err, "unable to render trust center")
+	}
+	fmt.Printf("%s -> %s\n", filepath.Join("templates", trustCenterTemplate+".ace"), outputFilename)
+
+	return nil
+}
+
+func trustCenterSummary(modelData *model.Data, data *renderData) *trustCenterData {
+	tc := &trustCenterData{
+		Project: data.Project,
+	}
+
+	if cfg := config.Config().TrustCenter; cfg != nil {
+		tc.Description = cfg.Description
+		tc.RequestAccessURL = cfg.RequestAccessURL
+		tc.ContactEmail = cfg.ContactEmail
+	}
+
+	satisfied := model.ControlsSatisfied(modelData)
+	for _, std := range modelData.Standards {
+		tc.Frameworks = append(tc.Frameworks, std.Name)
+
+		summary := &standardSummary{Name: std.Name}
+		families := make(map[string]*familySummary)
+		for key, c := range std.Controls {
+			f, ok := families[c.Family]
+			if !ok {
+				f = &familySummary{Name: c.Family}
+				families[c.Family] = f
+				summary.Families = append(summary.Families, f)
+			}
+			f.ControlsTotal++
+			summary.ControlsTotal++
+			if _, ok := satisfied[key]; ok {
+				f.ControlsSatisfied++
+				summary.ControlsSatisfied++
+			}
+		}
+		sort.Slice(summary.Families, func(i, j int) bool {
+			return summary.Families[i].Name < summary.Families[j].Name
+		})
+		tc.Standards = append(tc.Standards, summary)
+	}
+	sort.Strings(tc.Frameworks)
+
+	appendDocuments := func(kind string, docs []*model.Document) {
+		for _, d := range docs {
+			if d.Public {
+				tc.Documents = append(tc.Documents, &publicDocument{Name: d.Name, Kind: kind, OutputFilename: d.OutputFilename})
+			}
+		}
+	}
+	appendDocuments("Narrative", modelData.Narratives)
+	appendDocuments("Policy", modelData.Policies)
+
+	return tc
+}
+
+func loadTrustCenterTemplate() (*template.Template, error) {
+	if _, err := os.Stat(filepath.Join("templates", trustCenterTemplate+".ace")); err == nil {
+		return ace.Load("", filepath.Join("templates", trustCenterTemplate), aceOpts)
+	}
+
+	// project predates the trust center; use the bundled theme template
+	opts := *aceOpts
+	opts.Asset = func(name string) ([]byte, error) {
+		return theme.Asset(filepath.Join("comply-soc2", "templates", name))
+	}
+	tpl, err := ace.Load("", trustCenterTemplate, &opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load trust center template (add templates/trust-center/index.ace to your project)")
+	}
+	return tpl, nil
+}
+
+func copyFile(src, dst string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = io.Copy(w, r)
+	return err
+}
@@ -0,0 +1,84 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestTrustCenter(t *testing.T) {
+	// a project without templates/trust-center falls back to the bundled template
+	dir, err := ioutil.TempDir("", "comply-trust-center")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mocked := config.Config
+	defer func() { config.Config = mocked }()
+	config.Config = func() *config.Project {
+		return &config.Project{TrustCenter: &config.TrustCenterConfig{
+			Description:  "Acme protects customer data.",
+			ContactEmail: "security@example.com",
+		}}
+	}
+
+	modelData := &model.Data{
+		Standards: []*model.Standard{{
+			Name: "TSC",
+			Controls: map[string]model.Control{
+				"CC6.1": {Family: "CC6"},
+				"CC6.2": {Family: "CC6"},
+				"CC7.1": {Family: "CC7"},
+			},
+		}},
+		Policies: []*model.Document{
+			{Name: "Access Policy", OutputFilename: "Acme-AP.pdf", Public: true, Satisfies: model.Satisfaction{"TSC": []string{"CC6.1"}}},
+			{Name: "Incident Response Policy", OutputFilename: "Acme-IRP.pdf", Satisfies: model.Satisfaction{"TSC": []string{"CC7.1"}}},
+		},
+	}
+	tc := trustCenterSummary(modelData, &renderData{Project: &project{Name: "Acme"}})
+
+	if len(tc.Standards) != 1 || tc.Standards[0].ControlsTotal != 3 || tc.Standards[0].ControlsSatisfied != 2 {
+		t.Fatalf("unexpected standards %+v", tc.Standards)
+	}
+	if families := tc.Standards[0].Families; len(families) != 2 || families[0].Name != "CC6" || families[0].ControlsSatisfied != 1 {
+		t.Errorf("unexpected control families %+v", families)
+	}
+	if len(tc.Documents) != 1 || tc.Documents[0].OutputFilename != "Acme-AP.pdf" || tc.Documents[0].Kind != "Policy" {
+		t.Errorf("expected only the public policy to be published, got %+v", tc.Documents)
+	}
+
+	tpl, err := loadTrustCenterTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err = tpl.Execute(&out, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := out.String()
+	for _, expected := range []string{"Acme protects customer data.", "Acme-AP.pdf", "security@example.com"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected the trust center to contain %q", expected)
+		}
+	}
+	if strings.Contains(html, "Acme-IRP.pdf") {
+		t.Error("private documents must not be published")
+	}
+}
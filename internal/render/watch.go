@@ -19,9 +19,8 @@ func watch(errCh chan error) {
 	b.Add("./policies/")
 	b.Add("./procedures/")
 
+	// picks up writes to the ticket cache in .comply/comply.db
 	b.Add("./.comply/")
-	b.Add("./.comply/cache")
-	b.Add("./.comply/cache/tickets")
 
 	go func() {
 		for {
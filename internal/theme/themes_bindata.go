@@ -9,6 +9,7 @@
 // themes/comply-blank/templates/.gitkeep
 // themes/comply-blank/templates/default.latex
 // themes/comply-blank/templates/index.ace
+// themes/comply-blank/templates/trust-center/index.ace
 // themes/comply-soc2/README.md
 // themes/comply-soc2/TODO.md
 // themes/comply-soc2/narratives/README.md
@@ -57,6 +58,7 @@
 // themes/comply-soc2/standards/TSC-2022.yml
 // themes/comply-soc2/templates/default.latex
 // themes/comply-soc2/templates/index.ace
+// themes/comply-soc2/templates/trust-center/index.ace
 // DO NOT EDIT!
 
 package theme
@@ -65,14 +67,13 @@ import (
 	"bytes"
 	"compress/gzip"
 	"fmt"
+	"github.com/elazarl/go-bindata-assetfs"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
-
-	"github.com/elazarl/go-bindata-assetfs"
 )
 
 func bindataRead(data []byte, name string) ([]byte, error) {
@@ -126,7 +127,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _complyBlankReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x54\xc1\x8e\xe3\x36\x0c\xbd\xeb\x2b\x58\xe4\xb2\x0b\x0c\x92\x43\x6f\x73\xdb\xee\xa0\x68\x81\xed\x74\xd0\xcc\xad\x28\x60\x59\x62\x6c\x22\xb2\xe4\x52\x94\x53\x77\xb1\xff\x5e\x50\xb6\xe3\x41\x31\xd8\x9c\x22\x8b\x22\xdf\x7b\xe4\xe3\x01\xbe\x7e\x3d\x3e\xdb\x01\xbf\x7d\x83\xcf\x69\x18\x03\xd9\xe8\x10\x5e\x38\x75\x6c\x07\x63\x5e\x7b\xca\xc0\x38\xa6\x4c\x92\x78\x06\x97\x62\x4e\x81\xbc\x15\xcc\x60\x43\x00\x9f\x5c\x19\x30\x8a\x46\x05\x2b\xe8\x41\x12\x48\x8f\xdf\xcd\x7b\x34\xe6\x00\x67\xe1\xe2\xa4\x30\x1a\xf3\x26\x62\xcf\x67\x19\x21\x71\x67\x23\xfd\x8b\x1e\x6c\x86\x4b\x0a\x21\xdd\xf2\xa3\x31\x4d\xd3\x98\x68\x99\xad\xd0\x84\xf9\x04\xfa\x7b\xbe\x9f\x61\xe4\x34\x91\x47\xb0\x11\xd2\x84\x3c\x11\xde\x20\x5d\x2a\xaa\x35\xa1\x15\x4a\x11\x6c\xf4\xf5\xa3\xdb\xcb\x63\x9c\x88\x53\x54\x04\x47\x33\xa6\x40\x8e\xb6\x02\x00\x2f\xeb\x19\x3a\x4d\x1b\xeb\xdb\x16\x7b\x3b\x51\x62\x2d\x80\xc3\x18\xd2\x8c\xaa\x4c\xf4\x2a\x95\xb0\x75\x92\x38\x1f\xcd\xc8\xc9\xa1\x2f\xbc\x25\x7b\xb9\x9f\x61\x64\xcc\x8e\xa9\x45\xc8\x23\x3a\xba\x90\x83\x2c\x38\x66\x90\xde\x4a\x55\x41\xec\x15\x23\x50\x04\xc6\x3c\xa6\x98\x51\x35\xbe\xe2\x0c\x38\xa9\x52\x47\x93\xc5\x46\x6f\xd9\x6f\x48\xcf\xdb\x79\x4d\x39\xaf\x34\xa3\x70\x0a\x19\xb2\x15\xca\x17\x42\x0f\xed\xfc\x7f\x01\xc6\xad\x43\xa2\x6c\xb4\xcd\x6b\xce\xd7\xed\xbc\xe5\x59\xf4\x2c\x32\x16\x81\x4b\xe2\xc1\xca\x26\xf2\x2f\xaf\xbf\x7d\x81\x27\x9b\xfb\x36\x59\xf6\x55\x8c\x97\xa7\x9f\xc1\xe6\x8c\x8a\x56\xbb\x67\x0e\xf0\x53\xa1\xe0\x29\x76\xc6\x7c\xaa\x17\x95\x6a\x5b\x28\x08\x94\x4c\xb1\x83\x3f\x9b\x8a\x6b\x6e\xfe\xfa\xd0\x8b\x8c\xf9\xf1\x74\x5a\x3e\x1c\xb3\x70\x8a\x9d\x1f\x8e\x2e\x0d\x1f\x1f\xe0\xd6\x93\xeb\xc1\xd9\x08\x2d\x02\xc5\x2c\x36\x04\xf4\x30\x91\x85\xa6\x65\xbc\x6d\xdf\x60\xcd\x07\x1f\x06\xeb\x7e\x3f\x7f\x84\xc4\xd0\x74\x09\x3a\x14\xe8\x48\xfa\xd2\x6a\xc2\xd3\x96\x7d\xad\x56\xc1\xbe\x94\x36\x50\xee\x2b\xdc\xd7\x1e\xa1\x59\x88\x9f\x1a\xf0\xc4\xe8\x36\x6f\x88\xa5\xb8\xf8\xa2\xc3\x88\x5c\xfd\xb0\xd2\x86\x2f\x14\xaf\x59\xbb\x78\x97\xc8\xef\x12\x31\x2e\xfe\xa1\x09\x1f\xaa\x60\xd5\x5b\x38\x62\xf4\x18\xeb\xcc\x69\x08\x45\x17\x8a\x5f\xa9\x2d\x85\xe1\xf3\xd3\x33\x30\x5e\x90\x31\x3a\xcc\x47\x50\x74\x18\x85\xf8\x5d\x90\x0f\x5a\x9d\xf1\x92\x18\x1f\x60\xb0\xb3\x2a\x56\xc6\x90\xac\x5f\x9c\x6b\x23\x9c\x7f\x84\xb6\xb8\x2b\x8a\xca\x63\x63\xd2\x07\x90\xc5\x0a\xb9\x85\x0b\xf4\x29\x0b\xdc\x48\xfa\xa4\xad\x2f\x5c\x23\x86\xe4\x75\x76\xab\xb3\xaa\xbf\xf7\x01\x38\x8b\x95\x92\x8d\xb9\x0f\x3d\xa8\x31\xae\xda\x63\xca\x50\x46\x5f\x75\xba\xf5\x18\x71\x42\x86\xb5\xed\x90\xe7\xe8\x1a\x8d\xa0\x38\xa5\x2b\xfa\x23\xfc\x5a\xff\x80\xad\x57\x30\xb2\xfa\x4e\xd2\xfd\x81\x0e\x8f\x6f\xf4\xcb\x2a\x54\x95\x7a\x50\xb4\xae\x30\x63\x14\x10\xaa\xcc\x72\x45\x54\x61\xee\xa0\xce\xae\x47\x5f\x02\xb2\x31\x9f\xe2\x0c\xcd\x1b\xcf\x36\x8b\x19\xb7\xb4\x16\x1a\xc7\x29\x36\x90\xd7\x27\x70\xa3\x10\xc0\x16\x49\x83\xea\x64\x43\x98\xc1\x31\x56\x5e\x14\x61\x4e\x85\x75\x3c\x2e\xd4\x15\x56\xa1\x2b\x0a\xe5\x9f\xe7\x2c\x38\xbc\xc3\x7d\xc3\x52\x05\xc0\x7f\xd0\x15\x51\x05\xb4\xbb\xf7\xbb\xa5\x6a\x6b\xdd\xf5\x52\xcb\xc7\xb9\xee\x3b\x5f\x70\xad\xb0\x30\x7c\x42\x5d\x4b\xba\xd2\xe0\x0f\x74\x69\x18\x30\xfa\xda\x26\x63\x76\x41\x1d\xd3\x28\x90\x69\xa0\x60\x79\xdb\xe1\xcb\xc6\x55\x9c\x56\x20\xa0\xcd\x02\xa9\x2e\x09\x64\xf0\x76\x5e\x37\xf1\xe1\x87\x53\x4b\xf1\xd4\xda\xdc\x9b\x83\x39\xe8\x42\x63\xfc\xbb\x50\x26\xc1\xfc\x68\x0e\x00\xea\x2e\xb0\xce\x61\xce\xf5\xb8\xf3\xdf\x44\x59\x16\x32\xc5\xd5\xa2\xc7\x79\x08\x35\x72\x99\xcd\x63\xee\x15\xd2\xb8\x98\x70\x1b\x46\xcd\x6f\x0e\xca\x50\x0d\x5c\x77\x93\xc0\xb6\xb3\xab\x89\xf6\x0e\x1a\x45\x30\x96\x10\x34\x7c\x99\xb8\xb7\x5d\x58\x06\xf4\xcd\xdc\x69\x98\x30\x75\x1d\xf2\xd2\x48\x85\x97\x2e\x77\xed\xb7\x1e\xee\x8f\xf6\xe1\x39\x2c\x83\xb8\x22\x32\x6f\x87\x53\x2f\xdf\x61\x01\x17\x4e\xc3\xba\x4b\x4f\xbb\x59\xcd\xce\x7e\xbd\xab\x7a\xff\x17\x00\x00\xff\xff\xc7\x04\x8a\x4e\xb4\x07\x00\x00")
+var _complyBlankReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x55\xc1\x8e\x1c\x37\x0e\xbd\xeb\x2b\xb8\xe8\x8b\x0d\x0c\xba\x0f\x7b\xf3\xcd\xeb\xc1\x62\x17\x70\x9c\x41\x66\x6e\x41\x80\x62\x49\xec\x2a\xa2\x55\x52\x85\xa2\xba\x53\x31\xfc\xef\x01\x55\x55\xdd\x46\x60\x64\x4e\xad\x12\x45\xbe\xf7\xc8\xc7\x39\xc0\xd7\xaf\xc7\x2f\x38\xd1\xb7\x6f\xf0\x29\x4f\x73\x64\x4c\x9e\xe0\x45\xf2\x20\x38\x39\xf7\x36\x72\x01\xa1\x39\x17\xd6\x2c\x0b\xf8\x9c\x4a\x8e\x1c\x50\xa9\x00\xc6\x08\x21\xfb\x3a\x51\x52\x8b\x8a\xa8\x14\x40\x33\xe8\x48\xff\x98\xf7\xe8\xdc\x01\x5e\x55\xaa\xd7\x2a\xe4\xdc\x77\x11\x8f\x7c\x28\x04\x59\x06\x4c\xfc\x27\x05\xc0\x02\xe7\x1c\x63\xbe\x95\x0f\xce\x75\x5d\xe7\x12\x8a\xa0\xf2\x95\xca\x09\xec\xef\xcb\xfd\x0c\xb3\xe4\x2b\x07\x02\x4c\x90\xaf\x24\x57\xa6\x1b\xe4\x73\x43\xb5\x25\x44\xe5\x9c\x00\x53\x68\x1f\xfd\xa3\x3c\xa5\x2b\x4b\x4e\x86\xe0\xe8\xe6\x1c\xd9\xf3\x5e\x00\xe0\x65\x3b\xc3\x60\x69\x53\x7b\xdb\xd3\x88\x57\xce\x62\x05\x68\x9a\x63\x5e\xc8\x94\x49\xc1\xa4\x52\x41\xaf\x59\xca\xd1\xcd\x92\x3d\x85\x2a\x7b\xb2\x97\xfb\x19\x66\xa1\xe2\x85\x7b\x82\x32\x93\xe7\x33\x7b\x28\x4a\x73\x01\x1d\x51\x9b\x0a\x8a\x17\x4a\xc0\x09\x84\xca\x9c\x53\x21\xd3\xf8\x42\x0b\xd0\xd5\x94\x3f\xba\xa2\x98\x02\x4a\xd8\x91\xbe\xee\xe7\x2d\xe5\xb2\xd1\x4c\x2a\x39\x16\x28\xa8\x5c\xce\x4c\x01\xfa\xe5\xef\x02\xcc\x7b\x87\xd4\xd8\xa0\xde\xd9\xbf\xed\xe7\x3d\x4f\x7b\x99\xab\xce\x55\xe1\x9c\x65\x42\xdd\x45\xfe\xdf\xdb\x4f\x9f\xe1\x19\xcb\xd8\x67\x94\xd0\xc4\x78\x79\xfe\x2f\x60\x29\x64\x68\xad\x7b\xee\x00\xff\xa9\x1c\x03\xa7\xc1\xb9\x8f\xed\xa2\x51\xed\x2b\x47\x85\x5a\x38\x0d\xf0\x6b\xd7\x70\x2d\xdd\x6f\xef\x46\xd5\xb9\x7c\x38\x9d\xd6\x0f\xc7\xa2\x92\xd3\x10\xa6\xa3\xcf\xd3\xfb\x27\xb8\x8d\xec\x47\xf0\x98\xa0\x27\xe0\x54\x14\x63\xa4\x00\x57\x46\xe8\x7a\xa1\xdb\xfe\x0d\xb6\x7c\xf0\x6e\x42\xff\xf3\xeb\x7b\xc8\x02\xdd\x90\x61\x20\x85\x81\x75\xac\xbd\x25\x3c\xed\xd9\xb7\x6a\x0d\xec\x4b\xed\x23\x97\xb1\xc1\x7d\x1b\x09\xba\x95\xf8\xa9\x83\xc0\x42\x7e\xf7\x86\x22\xa7\xd5\x17\x03\x25\x92\xe6\x87\x8d\x36\x7c\xe6\x74\x29\xd6\xc5\xbb\x44\xe1\x21\x91\xd0\xea\x1f\xbe\xd2\x53\x13\xcc\x72\x04\x9a\x29\x05\x4a\x36\x83\x2d\x84\x93\x8f\x35\x6c\xd4\xd6\xc2\xf0\xe9\xf9\x0b\x08\x9d\x49\x28\x79\x2a\x47\x30\x74\x94\x94\xe5\x87\x20\x9f\xac\x6b\x42\xe7\x2c\xf4\x04\x13\x2e\xa6\x58\x9d\x63\x46\xcb\xaa\xd9\x2c\xf3\xfa\x6f\xe8\xab\xbf\x90\x9a\x3c\x98\xb2\x3d\x80\xa2\xa8\xec\xd7\x16\xc2\x98\x8b\xc2\x8d\x75\xcc\xd6\xfa\x2a\x2d\x62\xca\xc1\x66\xb7\x39\xab\xf9\xfb\x31\x00\xaf\x8a\x5a\x8b\x73\xf7\xa1\x07\x33\xc6\xc5\x7a\xcc\x05\xea\x6c\xdb\x24\xc0\x6d\xa4\x44\x57\x12\xd8\xda\x0e\x65\x49\xbe\x03\x36\xcd\xae\xf9\x42\xe1\x08\xff\x6f\x3f\x00\xdb\x15\xcc\x62\xbe\xd3\x7c\x7f\x60\xc3\x13\x3a\x33\xc7\x26\x94\x91\x85\xc9\xd0\xfa\x2a\x42\x49\x41\xb9\x31\x33\x3a\xb5\x34\x98\x0f\x50\xaf\x7e\xa4\x50\x23\x89\x73\x1f\xd3\x02\xdd\x77\x9e\xed\x56\x33\xee\x69\x11\x3a\x2f\x39\x75\x50\xb6\x27\x70\xe3\x18\x01\xab\xe6\xc9\x74\xc2\x18\x17\xf0\x42\x8d\x17\x27\x58\x72\x15\xb3\xcd\x99\x87\x2a\x26\x74\x43\x61\xfc\xcb\x52\x94\xa6\x1f\x70\xdf\xb1\x34\x01\xe8\x0f\xf2\x55\x4d\x01\xeb\xee\x5e\x54\xd6\xaa\x3d\xfa\xcb\xd9\x7e\x60\x5a\xda\xbe\x0b\x95\xb6\x0a\x2b\xc3\x67\xb2\xb5\x64\x2b\x0d\x7e\x21\x9f\xa7\x89\x52\x68\x6d\x72\xee\x21\xa8\x17\x9e\x15\x0a\x4f\x1c\x51\xf6\x1d\xbe\x6e\x5c\xc3\x89\x0a\x91\xb0\x28\x64\xdb\x92\x33\x09\x04\x5c\xb6\x4d\x7c\xf8\xd7\xa9\xe7\x74\xea\xb1\x8c\xee\xe0\x0e\xb6\xd0\x84\x7e\xaf\x5c\x58\xa9\x7c\x70\x07\x00\x73\x17\xa0\xf7\x54\x4a\x3b\x3e\xf8\xef\xa2\x34\x3c\x66\x8e\xcd\xe1\xcb\x14\x5b\xe4\x3a\x9b\xc7\x32\x1a\xa4\x79\x35\xe1\x3e\x8c\x96\xdf\x1d\x8c\xa1\x19\xd8\xfe\xf9\x14\x85\x7d\x67\x37\x13\x3d\x3a\xe8\x0c\xc1\x5c\x63\xb4\xf0\x75\xe2\xbe\xef\x42\x1b\x07\xb7\x6b\xbf\x24\x6f\x61\x2a\x3c\x0c\x24\x6b\x23\x0d\x5e\x3e\xdf\xb5\xdf\x7b\xf8\x78\xb4\x37\xc5\x5e\xb6\x41\xdc\x10\xed\x01\xed\x9b\x5d\xfe\x80\x05\x9c\x25\x4f\xb0\xb9\xf5\x61\x56\xf7\x60\xbf\xdd\xb9\xae\xeb\xdc\x5f\x03\x00\xc7\x04\x8a\x4e\xb4\x07\x00\x00")
 
 func complyBlankReadmeMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -141,12 +142,12 @@ func complyBlankReadmeMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-blank/README.md", size: 1972, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-blank/README.md", size: 1972, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complyBlankTodoMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x54\xc1\x6e\xdb\x48\x0c\xbd\xfb\x2b\x08\xe4\x92\x02\x76\x7c\xdf\x5b\xd0\x64\xb1\x3d\x74\x53\x2c\x8a\x5e\x8a\x02\xa6\x67\x68\x89\xcd\x68\xa8\x25\x39\x4e\xd5\xa2\xff\xbe\x18\x49\x56\x15\x24\x0b\xf4\x3a\x7c\x1c\x3e\xf2\x3d\xf2\x0a\x7e\xfc\xb8\xf9\x1b\x3b\xfa\xf9\x13\xde\x4a\xd7\x27\xc6\x1c\x08\x3e\xa8\x34\x8a\xdd\x66\xf3\x17\x37\xed\x2e\xd1\x99\x12\x7c\x7c\xb8\x7b\x80\xa0\x84\x4e\x11\x8e\x03\x7c\x0e\x15\x3f\x7c\xb9\x6e\xdd\x7b\xfb\x63\xbf\x6f\xd8\xdb\x72\xbc\x09\xd2\xed\xcd\x55\x72\x13\xbb\xfd\x84\x79\xb3\xd9\x5c\x5d\xc1\xbb\xcc\xce\x98\xf8\x3b\x3a\x4b\x86\x0f\x2d\x1a\xc1\x75\x2b\x45\xed\xcd\x66\x07\x9f\xe1\x0b\xdc\xc6\x08\xbd\xca\x57\x0a\x0e\x2e\x60\x52\x34\x10\x04\xc9\xae\x92\x66\xcc\x27\x52\x3e\x0d\x70\x98\xbe\x86\x63\xe1\x14\x0f\xd0\x50\x26\x45\x27\x83\x33\x26\x8e\x20\xc5\xfb\xe2\x73\xca\xdb\x91\x35\x38\x87\x47\x72\xce\x4d\x6d\x23\x52\xae\x6c\x6c\x5b\xbf\x3f\x71\x53\x94\xe0\xcc\x78\xf9\xf7\x66\xe8\xd2\xe1\xf5\x8a\x36\xe4\x70\x00\xfa\x46\xa1\xd4\x7a\x4f\xec\xad\x14\x07\x52\x15\xb5\xb1\xd3\xdb\xe2\xad\x68\x2d\x34\x37\xf9\x44\xf4\xb8\x34\xf9\xa9\x12\xac\x7c\xcc\x31\x47\xd4\x68\xfb\x2d\xf4\x5a\x72\x4d\x40\x83\x4c\x81\xcc\x50\x87\x0b\xfb\x62\x2e\x1d\x7f\x27\xc8\xa8\x8a\xce\x67\xb2\xfd\x8b\x58\x2f\x89\x03\xd7\x08\x00\xc0\x14\xbd\x63\x73\xe5\x63\xf1\x65\x86\x06\xd8\x49\x6e\x16\xf4\x0a\x7c\x9f\xad\xce\xe0\x12\x01\x8c\x51\xc9\x0c\x30\xa5\x25\xfb\x65\x55\x95\x40\xb1\xe8\x6f\xd7\x5d\xf0\x2b\xf8\x2c\xcf\x24\xdc\x24\x12\x38\x75\x7d\xaa\x7a\xae\x70\xb7\x66\xdc\x64\xb0\xd0\x52\x2c\x89\xec\x75\x79\x5c\xa2\x1c\x80\x73\xe4\x30\xfa\x61\xcd\x1f\x0c\x9d\xed\xc4\x14\x47\x9d\xee\xa8\x4f\x32\x74\x94\xfd\x55\xa1\xa6\xf0\x2f\xdd\xe7\xba\x7a\x80\x6b\x23\x82\x7f\xee\x6f\xef\xde\xdf\xdf\x74\x11\x4e\xa2\x40\xdf\xb0\xeb\x13\x81\x05\xe5\xde\xff\xe7\x8b\xd9\xac\x93\x39\x47\x87\xb7\xa8\x14\x21\x49\x18\x97\x62\xf3\x62\x7e\x8b\x1c\x2e\xe0\x84\xdd\x8c\xf8\xa8\xc8\x79\x7c\x00\xc9\x50\x8c\x40\x4e\x2b\x7f\xdb\x60\x4e\x5d\xcd\x89\x54\x67\x86\xa3\x12\x97\xf5\xde\x29\x25\x3a\x63\x76\xc0\xe0\x7c\x66\x1f\xc6\x69\x3c\xf4\x75\x83\x56\xae\x25\x27\xcd\x98\x2e\xbd\xbc\x97\xcc\x2e\x0a\xce\x1d\xd5\x39\x4f\x42\x3d\x89\x3e\x9e\x92\x3c\x2d\x1b\xfc\xb5\x98\x03\xe6\x08\x4a\xbb\xbe\x1c\x13\x5b\xbb\x32\xee\x76\x65\xb0\x1c\x57\x7e\x78\xee\xfc\x69\x8b\x22\x3f\x17\x66\x0b\x98\x73\xc1\x54\x2f\xca\x54\xee\x5d\xd7\x8b\x3a\x28\xfd\x5b\xc8\x1c\x12\x9b\xc3\xf5\xc4\xac\x6e\x66\x4a\x70\xa4\xe5\x36\xc4\x4b\xd6\x9f\x25\x9d\x6a\xac\x5a\xe3\x92\x3a\x27\xad\xed\xe6\x8e\xa1\x5d\xf8\x6e\x57\x64\xb7\x23\xf9\x5f\x5d\xbd\xcc\xa2\x33\x47\xca\xe3\xf1\x4a\x89\xc2\x7c\x33\x7b\xa5\x33\x4b\xb1\x34\xec\xe6\xfb\xb1\x1a\xc1\x42\xe1\xbf\x00\x00\x00\xff\xff\x3f\x2b\x38\xdd\x95\x05\x00\x00")
+var _complyBlankTodoMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x54\xc1\x6e\xdb\x48\x0c\xbd\xfb\x2b\x08\xe4\x92\x02\x76\x7c\xdf\x9b\x91\x64\xb1\x3d\x74\x53\x2c\x8a\x5e\x8a\x02\xa6\x67\x68\x89\xcd\x68\xa8\x25\x39\x4e\xd5\xa2\xff\xbe\x18\x4b\x56\x14\x24\x0b\xf4\x3a\xf3\x48\x3e\xf2\x3d\xf2\x0a\x7e\xfe\xbc\xf9\x1b\x3b\xfa\xf5\x0b\x6e\xa5\xeb\x13\x63\x0e\x04\x1f\x55\x1a\xc5\x6e\xb5\xfa\x8b\x9b\x76\x93\xe8\x44\x09\x3e\x3d\xdc\x3d\x40\x50\x42\xa7\x08\x87\x01\xbe\x84\x8a\x1f\xbe\x5e\xb7\xee\xbd\xfd\xb1\xdd\x36\xec\x6d\x39\xdc\x04\xe9\xb6\xe6\x2a\xb9\x89\xdd\x76\xc4\xbc\x5b\xad\xae\xae\xe0\x7d\x66\x67\x4c\xfc\x03\x9d\x25\xc3\xc7\x16\x8d\xe0\xba\x95\xa2\xf6\x6e\xb5\x81\x2f\xf0\x15\x76\x31\x42\xaf\xf2\x8d\x82\x83\x0b\x98\x14\x0d\x04\x41\xb2\xab\xa4\x09\xf3\x99\x94\x8f\x03\xec\xc7\xd4\x70\x28\x9c\xe2\x1e\x1a\xca\xa4\xe8\x64\x70\xc2\xc4\x11\xa4\x78\x5f\x7c\x0a\xb9\x3d\xb3\x06\xe7\xf0\x48\xce\xb9\xa9\x6d\x44\xca\x95\x8d\xad\x6b\xfa\x23\x37\x45\x09\x4e\x8c\x97\xbc\x37\x43\x97\xf6\x6f\x57\xb4\x21\x87\x3d\xd0\x77\x0a\xa5\xd6\x7b\x62\x6f\xa5\x38\x90\xaa\xa8\x9d\x3b\xdd\x15\x6f\x45\x6b\xa1\xa9\xc9\x27\xa2\xc7\xb9\xc9\xcf\x95\x60\xe5\x63\x8e\x39\xa2\x46\xdb\xae\xa1\xd7\x92\x6b\x00\x1a\x64\x0a\x64\x86\x3a\x4c\xf8\xdb\x62\x2e\x1d\xff\x20\xc8\xa8\x8a\xce\x27\xb2\xed\xab\xbf\x5e\x12\x07\xae\x3f\x00\x00\xe3\xef\x1d\x9b\x2b\x1f\x8a\xcf\x33\x34\xc0\x4e\x72\x33\xa3\x17\xe0\xfb\x6c\x45\x9f\xf3\x00\xc6\xa8\x64\x06\x98\xd2\x1c\xfd\xba\xaa\x4a\xa0\x58\xf4\xb7\xeb\xce\xf8\x05\x7c\x92\x67\x14\x6e\x14\x09\x9c\xba\x3e\xa1\xbf\xc0\xed\xcc\xb8\xc9\x60\xa1\xa5\x58\x12\xd9\xdb\xf2\xb8\x44\xd9\x03\xe7\xc8\xa1\xc6\xbf\xe0\x0f\x86\xce\x76\x64\x8a\x67\x9d\xee\xa8\x4f\x32\x74\x94\xfd\x4d\xa1\xc6\xef\x67\xdd\xa7\xba\xba\x87\x6b\x23\x82\x7f\xee\x77\x77\x1f\xee\x6f\xba\x08\x47\x51\xa0\xef\xd8\xf5\x89\xc0\x82\x72\xef\xff\x93\x62\x32\xeb\x68\xce\xb3\xc3\x5b\x54\x8a\x90\x24\x9c\x97\x62\xf5\x6a\x7e\xb3\x1c\x2e\xe0\x84\xdd\x84\xf8\xa4\xc8\xf9\xfc\x00\x92\xa1\x18\x81\x1c\x17\xfe\xb6\xc1\x9c\xba\x5a\x20\x52\x9d\x59\x75\x5b\x98\xd7\x7b\xa3\x94\xe8\x84\xd9\x01\x83\xf3\x89\x7d\x38\x4f\xe3\xa1\xaf\x1b\xb4\x70\x2d\x39\x69\xc6\x74\xe9\xe5\x83\x64\x76\x51\x70\xee\x28\x0d\x17\xa1\x9e\x44\x1f\x8f\x49\x9e\x26\xd0\x2e\x7e\x2b\xe6\x80\x39\x82\xd2\xa6\x2f\x87\xc4\xd6\x2e\x8c\xbb\x5e\x18\x2c\xc7\x85\x1f\x5e\x3a\xbf\xf2\xd9\x95\xc8\x2f\x85\x59\x03\xe6\x5c\x30\xd5\x8b\x32\x96\x7b\xdf\xf5\xa2\x0e\x4a\xff\x16\x32\x87\xc4\xe6\x70\x3d\x32\xab\x9b\x99\x12\x1c\x68\xbe\x0d\xf1\x12\xf5\x67\x49\x47\x4e\xe9\x6c\x8d\x4b\xe8\x14\xb4\xb4\x9b\x3b\x86\x76\xe6\xbb\x5e\x90\xad\x44\xe2\xa2\xab\xd7\x51\x74\xe2\x48\xf5\x94\x06\x49\x89\xc2\x74\x33\x7b\xa5\x13\x4b\xb1\x34\x6c\xa6\xfb\xb1\x18\x01\x38\x87\x47\x72\x5b\xfd\x37\x00\x3f\x2b\x38\xdd\x95\x05\x00\x00")
 
 func complyBlankTodoMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -161,12 +162,12 @@ func complyBlankTodoMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-blank/TODO.md", size: 1429, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-blank/TODO.md", size: 1429, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complyBlankNarrativesGitkeep = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var _complyBlankNarrativesGitkeep = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
 
 func complyBlankNarrativesGitkeepBytes() ([]byte, error) {
 	return bindataRead(
@@ -181,12 +182,12 @@ func complyBlankNarrativesGitkeep() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-blank/narratives/.gitkeep", size: 0, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-blank/narratives/.gitkeep", size: 0, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complyBlankPoliciesGitkeep = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var _complyBlankPoliciesGitkeep = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
 
 func complyBlankPoliciesGitkeepBytes() ([]byte, error) {
 	return bindataRead(
@@ -201,12 +202,12 @@ func complyBlankPoliciesGitkeep() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-blank/policies/.gitkeep", size: 0, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-blank/policies/.gitkeep", size: 0, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complyBlankProceduresGitkeep = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var _complyBlankProceduresGitkeep = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
 
 func complyBlankProceduresGitkeepBytes() ([]byte, error) {
 	return bindataRead(
@@ -221,12 +222,12 @@ func complyBlankProceduresGitkeep() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-blank/procedures/.gitkeep", size: 0, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-blank/procedures/.gitkeep", size: 0, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complyBlankStandardsGitkeep = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var _complyBlankStandardsGitkeep = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
 
 func complyBlankStandardsGitkeepBytes() ([]byte, error) {
 	return bindataRead(
@@ -241,12 +242,12 @@ func complyBlankStandardsGitkeep() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-blank/standards/.gitkeep", size: 0, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-blank/standards/.gitkeep", size: 0, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complyBlankTemplatesGitkeep = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var _complyBlankTemplatesGitkeep = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
 
 func complyBlankTemplatesGitkeepBytes() ([]byte, error) {
 	return bindataRead(
@@ -261,12 +262,12 @@ func complyBlankTemplatesGitkeep() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-blank/templates/.gitkeep", size: 0, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-blank/templates/.gitkeep", size: 0, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complyBlankTemplatesDefaultLatex = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x3b\x6b\x6f\xe4\x36\x92\xdf\xfb\x57\x10\x18\x35\x6e\x7c\xe8\xf6\xd9\x13\x24\x17\x04\xe8\xc5\x4c\x26\x9b\x67\xcf\x6e\xe0\x99\xdc\x05\x67\x39\x38\x4a\x2a\xb5\x18\x53\xa4\x8e\xa4\x6c\x77\x04\xdd\x6f\x5f\x14\x1f\x12\xf5\xf0\x24\xbb\xc8\x17\x37\x59\x2c\x16\x8b\x64\xb1\x9e\xf2\x96\xfc\xbd\x31\x4c\x0a\x4d\x4a\xa9\x48\x43\xf3\x7b\x7a\x02\x4d\xb8\xa4\x05\x14\x04\xb8\x86\xc7\x0a\x14\x6c\xd2\x1f\xa9\xd6\x1e\xf5\x83\xfc\xd1\xe1\x75\xad\x60\xb9\x2c\x20\x29\xa5\x7a\x59\x9d\x1b\x50\x0a\x4a\xe9\x90\x2e\x92\x5d\x32\x03\x25\x09\x88\xa2\x94\x2a\xe9\xbb\x30\xd2\x3f\x43\xb8\x3a\x37\x15\x08\xdd\x77\xad\xe2\xfd\x26\x61\xe5\xcb\x5c\x72\xa9\x38\x13\xf7\xfa\x22\x79\x66\x52\xf1\xc0\x1a\x2d\x68\x0d\x7a\xa7\x1f\x4e\xae\xf1\x74\x7d\x6d\x1b\x7d\xf7\x64\x29\xf4\x1b\x64\x82\x95\x89\x25\x5a\x30\x75\xe1\x5a\x9c\x1a\x78\xda\x17\x4c\xed\x95\xe1\xcf\xae\x70\xf3\xe1\x58\xc8\xbc\xad\x41\x98\xbe\xcb\x58\xc1\x22\x72\x11\xd9\xb7\xdf\xff\x50\x53\x26\x4a\x29\xcc\xb3\xa4\x74\x43\x73\xe8\xbb\x27\x78\xfb\xfd\x0f\x23\x95\xed\x26\x0d\x0b\xe4\x9c\x6a\x7d\x6b\xe9\x21\x21\xcd\x7e\x83\x8b\x64\x43\x48\x12\x7a\xc9\x6e\xb2\x28\xa7\xe2\xe4\x10\x32\x9a\x01\xdf\x63\x7f\x86\xd2\xd0\x06\xd4\x48\x68\xe8\xba\xd6\x14\x37\x03\x5a\x83\xb2\x88\xec\x24\xa4\x02\x21\x45\xa9\x68\x0d\x06\x9e\xcc\xce\xa2\x54\x54\x14\xb2\x35\x16\xc7\xb7\xa7\x34\xa8\x6e\x20\x37\x8a\x1a\x26\x2d\x52\xd4\x3f\x24\x51\x27\x66\x33\xfc\xa2\x48\xd9\x23\x70\xc2\xe3\x38\x8e\x00\x49\xa2\xa1\xf1\x13\x51\xa6\x36\x77\x5d\x32\x39\xba\xa4\x9f\xee\xc3\x76\x68\x7e\x7f\x52\xb2\x15\xc5\x9e\xd5\xf4\x84\xe7\x90\xb6\x1a\x46\xb0\x81\xba\x41\x51\xe8\xb6\x1b\x42\x52\x26\x72\xde\x16\x70\x52\xb4\xa9\x58\xae\x6f\x1f\x59\x61\xaa\x43\x6a\x4f\xcb\xb6\xef\xba\x64\x4e\x32\xe9\xb7\x9b\xf1\x3e\x91\xba\x7f\x54\x5d\x73\x2a\x1b\x7c\x5c\xfd\x26\xd5\x60\x1c\x5f\xc3\x7a\x39\xb5\xbb\xea\x6f\x45\x5b\x67\xa0\xa0\xb8\xfb\x08\x16\xe1\x78\xc3\x04\x49\x2b\x6a\xa4\xea\xbb\x2f\x48\x4c\xd5\x4a\xfa\x80\x8c\xf2\xdf\x77\xe5\xe9\x20\xa4\xaa\x29\x27\x78\x81\x97\xe5\xa9\xdf\xa4\x53\xea\x82\x3e\xb0\x13\xb5\x62\x7a\xae\x33\xc9\x35\x9e\xd8\x08\xbc\x48\x92\xb1\x93\x24\xa8\x1a\x12\xa8\x1b\x73\x9e\x5c\x99\x23\x39\xdc\xd9\xc8\x93\x03\x75\x49\xdc\x4b\xfa\xf1\xfa\xb6\xe4\x47\x05\x0f\x20\x0c\xd1\x9c\x15\x40\x32\x05\xf4\x5e\x13\x26\x88\xa9\x80\xd4\xac\x28\x38\x10\x59\x12\x4a\x70\xd3\xf6\x4a\x36\xe9\x23\x2b\xe4\x63\x03\x82\x72\xc3\x40\x93\x6b\x72\x7d\x75\x75\x75\xb5\x49\x15\x3d\x9d\xa0\xc8\xa4\x31\xb2\xb6\x17\xaf\x21\xc7\x05\xf7\x86\x19\x0e\x7a\xc2\xd8\x70\xb8\x0d\x55\x86\xe0\x0d\xf5\x1d\x5e\x7e\x0e\xc2\x80\x62\xe2\x84\x9d\x0c\x4e\x4c\x74\xd1\xe9\x66\xf2\xa9\xbf\xd5\xd0\x1c\xae\x3f\x6b\xcc\xce\xe1\xde\x39\x12\x76\x8d\x7e\x43\x08\x21\x56\xb8\xec\x24\x7c\xb4\xf1\x70\xca\x84\x06\x65\x10\x92\x36\x54\xe1\x1a\x20\x8a\xf9\x0a\x9b\x55\x59\xf1\x9b\xf9\x17\x78\x7d\xf5\xc7\x79\x0d\x8b\x4c\xd8\xf5\xc0\x7f\x9e\xe3\x36\xfb\x97\x99\xfe\xfc\x9f\xe0\x79\x5c\x66\xca\xf6\x00\xff\x1d\xce\xdf\x98\x2f\x91\x8f\x1f\xa9\x32\x96\x45\xab\xf0\x3a\x9c\x64\x2c\xdf\x11\xce\x7b\x47\xd0\xa2\xb1\x32\x63\x19\x67\xd2\x4a\xe5\xd9\xd2\xe7\x1a\x1c\x87\x9e\x84\x5f\xdf\x51\x41\x30\x8b\x69\x0d\xfc\xc5\xab\x8e\x5c\x87\xb5\xd7\x6c\x8d\xdb\x05\x55\x86\xe5\x3c\xe8\xb3\xa0\x71\x26\x63\x3d\xd9\x12\x01\x50\x68\x62\x24\xc9\x20\x98\xf8\x92\x29\x6d\x56\xf5\x15\xad\x75\x4d\x4d\xb5\xa3\xb5\x46\x85\xd0\x0f\xa6\xa8\xa4\x35\xe3\xe7\xe9\x5a\xb7\xf6\xf5\x8f\xa3\x83\x17\x90\x2c\x60\x5e\x73\x87\x97\x7f\xd7\x45\x28\x56\x23\xa0\x66\x99\x70\xc2\x6b\x59\x80\x12\x53\xdb\xcd\x99\x00\x6d\x14\x98\xbc\x9a\x6d\x5b\x83\x71\xd6\x75\x75\x5b\xac\x34\xf0\xd4\x6f\x52\x56\xfe\xf8\xd5\xd7\x1f\xe0\xe7\x8d\x13\xa3\x61\x1f\x7e\x93\x20\x72\xcf\x3c\x88\xdc\xab\xbb\x0f\xd7\x9e\xe0\x5d\xe7\x07\xfa\xd9\xec\xd6\x94\x9f\xdf\x75\x4c\x34\xed\xca\x68\x87\x9a\x37\x97\x75\x83\x57\xd1\x28\xf9\x80\x7a\x0e\x5a\x25\x09\x15\x05\x91\xa6\x02\x45\xbc\xea\xdd\x58\x09\x22\x5b\xc2\x4a\xc2\x5b\xf4\x4d\x88\x54\xe4\x09\x8d\xaf\xdd\x3c\x5e\x0c\xda\x4f\x6b\x16\x53\x56\xfe\x0c\x6e\x27\xd3\xf5\x02\x56\x3f\x15\xc9\x08\xc3\x3b\x70\x7b\xc4\x0c\x72\xe9\x2f\xe0\x63\x88\xe1\x5c\x09\x49\x0b\x28\x69\xcb\x8d\xbd\x42\xa0\xa6\x55\xa0\xbb\xf7\x39\xe5\x70\x78\x47\x4d\x5e\x1d\xe5\x23\xa8\x9c\x6a\x27\xf5\x2b\xc8\xb7\xa9\xaa\xdd\xdd\xdf\x75\x47\xb4\x2e\x08\x3c\x7c\x80\x9f\x77\x8e\xca\x75\xef\x37\x3c\xf8\x53\x84\xa0\x86\x09\x00\x27\x78\xa1\x37\x8a\xdd\x0c\xb2\x14\xba\x80\x90\x4c\xc5\x4a\x53\xa1\x27\x0b\x05\x80\x5b\x28\xf4\xc6\x85\x66\x90\xe5\x42\x01\x61\xb6\x50\x2d\x85\x9c\xee\xc8\x03\xfc\x8e\x7c\x2f\xda\xd1\x14\xb2\xb2\x23\x8f\x10\x2f\x34\x79\x95\xcc\x9a\x3e\x42\x52\x01\x8f\xe3\x9b\x8b\xde\x1f\x03\x7d\x89\xee\x42\xd2\xdf\x2e\x66\x5e\xae\x3c\xe9\x08\xfc\x91\x57\x8d\x58\x11\x5f\xd6\xdc\x07\x21\xf6\x27\xf0\x3b\x32\x6d\xef\xdb\x21\xbf\xfc\x8a\x9d\x98\xd1\xbb\x23\x35\x4c\xec\xbe\x51\x00\xf7\x17\x41\x06\x1c\x46\x2c\x03\x13\xc8\x9a\x0c\x38\x84\x64\xf6\x42\xa2\xf5\xfe\x24\xda\x93\x77\xf5\x27\x93\xff\xfd\x10\xe4\x23\x3a\xc2\x07\x20\x61\xdb\xd1\x3c\xc7\xda\xdb\xef\x7f\x18\xb9\x1a\x3b\x4b\x86\xa2\x99\xd1\x96\x23\x7d\x6d\xd5\xd8\xaf\xb4\x51\xa0\x61\xdc\xa9\xe3\xed\xd8\xd2\x15\xe6\x1c\x07\xcf\x4c\x4c\x56\xe1\x0b\xbe\x02\xd6\xde\xa1\xad\x71\xb6\x72\x56\x7f\x84\x1f\x1b\x87\x35\x90\x2f\x39\x9a\x8d\x3c\xcf\x53\x40\xec\x23\xb1\x63\xe2\xd7\x3f\xf9\xf4\x6d\x9b\x95\x2f\x7f\x03\x25\xf7\x36\x6e\xd9\x0b\x29\xf6\xbf\x4a\x26\x6c\x64\xb4\xdd\x92\xf7\x6d\xd3\x48\x65\x6c\x12\x60\x44\x23\x23\x1a\xc9\x2b\xaa\x68\x6e\x40\xe9\xcb\x4d\x5a\xd3\x7b\xa0\x86\x83\x31\xa0\x36\xa8\xd5\x53\x9c\x64\xe7\x08\x29\xdc\x0c\x1b\x42\x8d\x5e\x3d\x0f\xba\xdd\x1a\x3b\x5a\xfc\xda\x6a\x43\xee\x41\x09\x26\x4e\x3b\x92\xb5\x86\x68\xc3\x38\x27\xda\x73\xe2\x32\x00\xf8\xc8\x4f\x97\xb8\x1f\x03\x4f\x52\x35\x45\xa9\x0d\xba\x8c\x96\xb8\x83\x1a\x8c\x6a\x4c\xd5\xa5\x42\xda\x98\x21\x2d\x98\xce\x15\xe0\x39\x51\x75\xee\xf6\x7d\xd7\x77\x29\x2e\x74\x79\xf5\x09\xd4\xbd\x9b\x68\x2f\xf9\x01\xdd\x0a\xfb\xea\x87\xb9\x95\xbe\x67\x4d\xfa\xdb\x6b\xfb\x53\xb2\xbe\xb3\xf8\xf6\xa7\x0f\xdb\xb6\x86\x7a\xea\x3f\x7c\x05\x39\xa7\x0a\x7e\x72\x46\xf2\x6d\x38\xaa\xee\xd5\xd5\xd5\xdb\xbe\x5b\x39\x9c\x7e\x13\xb4\x4d\x9a\x53\x83\x93\xfe\xf7\x97\x5f\x7e\xf9\xe5\xd5\xd5\x55\x7e\x48\x69\x6e\xd8\x83\x1d\x6b\x94\x34\x90\x1b\x28\xf0\x8c\x49\xc0\x78\x86\x60\xc9\xf0\x26\xff\x8a\xae\x44\x49\xfe\xe7\xbf\xff\xf6\x7d\x38\xcb\xf5\xb8\x1e\x3b\xa6\x82\x3a\x38\x8d\xb6\xed\xa4\xce\x36\x47\xb9\x8b\xbb\x4b\xc9\xb3\xa3\x33\xdb\x66\x9d\xea\x98\xf8\x08\xe8\x92\xb1\x3d\x9b\x85\xc2\x1b\x4f\x1a\xfa\xce\x96\xac\x4d\x89\xd3\x2c\x93\x19\x1a\x14\x2b\xd1\xcf\x6a\x35\x90\x80\x45\x14\xb5\x4e\x96\xa9\xa8\x20\xc1\x2c\x5b\x99\x77\x31\x27\x4a\xd3\x84\x3c\x13\x02\x26\xfb\x18\x01\x5d\x32\xb6\x67\x4c\xc9\xd6\x4c\x67\x8d\x80\x2e\x19\xdb\x2b\x9a\x7b\x4b\x7e\xd2\x40\xda\xe6\xff\x5a\x69\x00\x7d\x3f\xfa\x40\x19\xa7\x19\x87\x9d\x63\xd3\x28\xca\x4e\x95\x21\x16\xc1\x46\xc7\x0f\xa0\x32\x6a\x58\x4d\x40\x3c\x30\x25\x45\x0d\xc2\xe8\x4d\xfa\x5d\xf9\x35\xe3\xf0\xd7\x27\xa6\x8d\xee\x3c\xc1\x4b\x6d\xce\x7d\x37\xf1\xea\xdc\x40\xdf\x77\xfd\x6c\x4a\xcd\x72\x25\xcd\xb9\xf1\x93\xfc\x39\x06\xe0\x84\xb5\xb9\x03\x6d\x0d\x5a\xc0\x8c\x2c\xda\x0c\xb4\x90\xa4\x01\xc1\xea\xaf\x9f\x34\xbc\x0b\x80\xf7\x60\x6e\xf1\x29\xa8\x56\x33\x29\xee\xba\x8c\x6a\x96\x5b\x4f\x94\x6c\x49\xc1\x34\x72\x41\x46\x04\x7b\x54\x06\x2f\x16\x8f\x02\xf7\xe6\xee\xb2\x00\xe7\x6b\xf8\x00\x63\xaa\xc4\x5e\xb3\xb2\x15\x05\x94\x4c\x40\xd1\xfd\xf0\xf7\x77\x6f\xde\x72\xaa\xf5\xdf\x6c\xee\xc4\xba\xe1\xa8\x0b\x11\x4e\x6c\x76\x09\x59\x9c\x1c\x58\x43\x15\x2a\x0d\x7f\x5c\x0b\x33\xeb\x87\x7b\xab\x4d\xb6\x64\xe2\x6a\x70\x10\x27\x54\x60\x0d\x55\x8e\xc9\xbe\xbb\x6a\x4c\xbf\x36\x6e\x69\x74\x9f\x35\x86\x34\xbc\xd5\xe4\x55\x63\x48\xcd\x44\xab\xc9\x75\x63\xfa\x7e\xe3\x59\x9d\xb2\x89\x3d\x7f\xe6\x81\x8d\x43\x45\x79\xd9\xcf\x34\x5a\x2c\xc2\x41\xaa\xf6\x4c\xec\x85\x34\xf3\xc0\xb2\xa4\x22\x3f\x3f\xa8\x6c\x3d\xc2\x0a\x99\xd6\xe9\x01\x3d\xb5\x8a\x2f\x25\x10\xa1\x28\x7e\x64\x4b\x68\x51\x90\x9f\x6e\x8e\x04\x03\xbb\x21\xfd\x13\xcb\xd9\x94\x5e\x26\xe5\x7d\x4d\xd5\xfd\x92\x66\x18\xe9\xa7\xe0\x21\xe7\xdc\x6f\x52\xdb\xd6\x60\xda\xa6\x73\x8a\x90\x19\x0e\xfb\x1a\x0c\xb5\x1e\x40\x53\x94\x16\x72\xe8\x92\x71\x24\xe9\x67\xd9\xcd\xd6\x54\x52\x4d\x26\x39\xd0\xa1\x4b\xa2\xb1\xf9\xb4\x21\x4f\xdb\x14\x25\xb6\x0f\x5d\x62\x53\xb5\x33\x34\xdd\x66\xbf\x42\x6e\x02\xa6\xef\x1e\xba\xc4\xb7\xe6\xf8\xf7\x70\x7e\x94\xaa\xd0\x61\x42\xe8\x1f\x3a\xfb\x20\xc7\xe1\x24\x34\xfd\x03\x24\x43\x56\x7e\xb7\xd4\xe1\x21\xe1\x4e\xc8\xd8\x3d\x18\xd5\xc2\x6e\x43\xf0\xa2\xee\x2d\xf8\xd0\xf9\x80\xdc\x75\xd1\x13\x0a\x6d\x1f\x39\xbf\xa3\x4a\x4a\xe1\xc9\xf7\x38\xb9\x64\x1c\xa2\xc9\x43\x17\xc3\x8b\xd0\x7e\x76\x72\xce\x4c\x3c\x79\xe8\x5e\x24\xc9\xd0\xf6\x93\xbf\xe4\x2d\xc4\x53\x5b\xc5\xa3\x99\xa1\x77\x91\x24\xa1\xb9\x3a\x6f\xf0\xdd\x2b\x56\x80\x3d\x85\x5d\x14\x04\x37\x45\x99\x2b\x7c\x47\xea\xd0\x1d\xd1\x71\x24\x0f\x8c\x92\x86\x8a\x42\xe6\x28\x6d\xad\xe2\xda\x9c\x39\x74\x1a\xd5\x49\xa4\xb3\x30\x62\xb3\xa9\x8a\x82\x0c\xa6\xe8\xa7\x9b\xa3\x7e\xf6\x0d\xfe\x97\x87\x7d\x2d\xa5\x11\xd6\x04\x6c\x09\xe5\x5c\x3e\x8e\x76\x00\x8d\x18\x1a\x86\x32\xa0\x4c\x6e\xf5\x04\xb2\x06\xa3\xce\x17\x33\x6f\x20\x0d\x03\x4e\x5c\x46\xb4\x24\x34\x67\xfa\x7a\x2d\x51\x73\xfb\x07\xe7\xde\x75\x61\xe0\x63\x39\x2d\xe4\x4a\xc0\x23\x2b\xe7\xf9\xb5\x69\x16\x48\xa3\x8b\xa8\x67\x0a\x2a\x80\x7b\x4b\x22\x97\x75\x4d\x45\x81\x1a\x54\x6b\x53\x29\xd9\x9e\xaa\xfe\xf6\xfa\xae\x7b\x71\xdd\x6f\x52\xae\x8d\x06\xd3\xf9\xdc\x44\xc1\x28\xc7\x87\x76\xfb\xe9\xe5\x27\x77\xc7\x96\x3e\x8f\xf0\xf4\xf9\x67\x35\xd5\xf5\xdd\x1b\xad\xa1\xce\x38\xcc\x6a\x4b\xbc\xb2\x4c\x71\x6d\x04\x3c\x46\xc6\xb9\x43\x6f\xaf\xef\x02\x55\x7c\xfb\x2d\x3d\xc1\xe1\x5b\xaa\xef\x81\xf3\x9d\x35\x6e\x56\x5c\x0e\xa9\xae\x29\xe7\xa9\xf1\x81\xba\x35\xd3\xf1\x12\x15\x3b\x55\x1c\xfd\x01\x26\x4e\xfb\x9a\xe6\x4a\xe2\x92\xc9\x0a\x78\x7a\xbe\x06\x85\x6f\x71\x64\x52\x9c\xec\xc0\x0e\xf5\xa7\xa1\x99\xde\x51\xa5\xe8\xd9\xe7\x5f\x5a\x6e\x98\x92\x8f\xb3\x49\x01\xbc\x6e\x08\x72\xca\x73\x14\x77\x14\x6b\x6c\xb7\xdc\xfa\xf3\x68\xb2\x58\x43\x4f\x40\xac\x0f\xab\x67\x77\x3e\x21\xe0\xaa\x22\x9b\x2d\x79\x47\xef\x81\x84\x92\x86\x1f\x27\x8f\x52\xdd\x93\x47\x66\x2a\x32\x70\xbf\x16\x9c\x94\xa2\xad\x5f\xdb\xd1\x2e\xb5\x3f\x82\xd6\xf0\xff\xa9\xa9\xc0\xf6\x16\x76\xd0\xca\xf5\x96\xbc\x95\x4a\x41\x6e\x88\x54\x05\x28\x74\xaa\xdd\xb9\x11\x5a\x1a\x50\x24\x1d\xea\x10\x44\x2a\x92\xea\x36\x8b\x0a\x13\xd1\x1e\xc0\x48\xc9\x6d\x82\x79\xc6\x59\x43\x4d\x5e\xe5\x75\x91\x0e\xbc\x5b\x13\xdf\x77\x29\x2b\x5f\x0b\x89\x76\x5a\x43\x9e\xd6\x99\x7c\xea\xfa\xb4\x64\x6e\xd0\xba\x6a\x31\xbb\x5b\xf2\xc6\x2a\x80\xe1\xbd\xe3\xe3\x1f\x48\x92\x0a\x68\xf1\x1f\x38\x36\x33\x9f\x01\xdd\x5a\xc2\xa5\x0d\x9d\x0c\xf7\xeb\x63\xc1\x81\xd0\xf4\x01\xb0\x0f\xe2\x61\x94\xa2\xf5\x97\x1d\x4a\x69\xb3\x7b\xf6\xe0\xe5\x19\xe1\xed\xd5\xf4\xc9\xca\x09\x9e\x4b\xc1\xea\xf4\x1b\x26\x5e\x0b\x6a\x5e\x5b\xe0\x5f\x52\x74\x17\x6c\x33\x6a\xd9\x98\x6e\x82\x87\xc1\xdc\x40\xae\x02\x7c\x1d\x73\x7a\x0e\xfa\x17\x1b\x53\xba\x76\xdc\x9c\x90\xf4\x30\x4b\x73\x7a\x17\x36\x69\x49\x6c\x25\xd0\x7a\x2f\x02\x72\xd0\x9a\xaa\xf3\x8e\x68\x89\x81\x87\x21\xa6\x82\x33\x79\xc4\x58\x57\x48\x43\xe4\x03\xa8\x12\xef\xcf\x54\x60\x8b\x23\x9b\x2d\xa9\xa9\x3a\x31\xa1\x49\x76\x26\x5e\xe9\xec\x6c\xd4\xcc\x0c\x61\xda\xc7\xc9\x8d\xd4\x9a\xe1\x05\x1b\x69\x69\x3c\x2a\x66\xc0\x12\xf1\x53\xf4\x06\x9d\x76\x7c\x6e\xf0\xd4\x70\x96\x33\x43\xbc\x17\x88\x02\xb2\x5e\xdb\xdc\x11\xb7\xb3\x1d\xb9\xbc\xbc\xbc\xeb\x5c\x2d\xe7\x1e\xce\xba\xfb\x86\x89\xbe\xf3\xf5\xcf\x70\x21\x3b\x87\x7c\x18\x8f\x74\x77\x0f\xd0\x44\x85\x5d\x7c\xb8\xef\xc1\x04\x96\x48\xc9\x4e\xad\x02\xd2\x70\x9a\x03\xea\x42\x64\xbe\x32\x59\xb3\xfa\x68\x1b\xfd\xda\xe1\x77\x88\xf2\x11\x67\xd5\xda\xe3\x3d\xd5\xd6\x4a\xa2\x68\x79\x6d\x61\xe1\x93\x77\xa1\x0d\x50\x1b\x1e\x57\xd2\xd8\xd1\x2f\x36\x21\x76\xbf\x91\x59\xab\xcd\xdb\x60\x29\x2a\x74\x14\x6f\x5f\xdd\x75\x2f\x5e\xa5\x81\x42\x87\x86\x1c\x6d\x46\x3f\x4b\x19\x1b\xc5\xee\xc1\x55\xc2\x93\xfd\x9e\x50\xae\x25\xc6\x4b\x85\x55\x7c\x18\x55\xa0\xe3\x04\x13\x4b\xe9\x6a\xb1\x50\xdf\x75\x2d\x87\x1a\xcf\xe9\xcd\x83\x64\x05\x46\x31\x19\x87\x5a\x3b\xad\x96\x6a\xd9\x5a\x63\x8e\xaf\x18\x94\x87\x06\x47\x76\x93\x0e\xf9\x90\x02\xca\xaf\x9c\x4f\xe1\x77\xa0\xbb\x54\x41\x6c\xfa\x90\x52\xdf\x45\x9c\xc7\x91\x05\xd4\xa0\x4e\x20\xf2\xb3\x2f\xa8\xf4\xdd\x27\x50\xbb\x2a\x85\xcb\xde\x58\x29\x6d\x39\xb7\xce\xb9\xde\xa4\xbe\x7a\x31\x50\x37\x78\xfb\x68\x74\x5d\xec\x13\xd3\x66\x06\x6a\x0d\x8d\x8b\x69\x56\xc3\x99\x2b\x1b\xbb\xd8\x6a\xb3\x2d\x7e\xfb\xaa\x57\x28\xd3\xe6\xb2\x15\x06\x54\xa7\x21\x17\x6d\x5d\x40\x63\xaa\xbe\xf3\x45\xdd\x00\xb8\x40\x0f\x63\xe8\x79\x17\xee\xd3\xe0\xbf\x0d\xae\xca\x73\xd4\xf6\x28\xc2\x05\xab\x41\xe0\xa6\x15\xd4\xf2\x01\x48\x28\x25\x3a\xa6\x98\x38\x3d\x93\x47\x71\xb4\x2d\x8c\xcb\xfc\x7e\x8f\x77\xe5\x5d\x12\x2f\x88\x91\xa9\xc0\x67\x3c\xb1\x15\xa4\x54\x00\x7b\x6d\xa8\xc0\x49\x9b\x94\x95\x4f\x23\x7a\x3a\x84\xa4\x43\xba\x88\x83\x49\x25\x2f\x46\x94\xd1\xea\x10\x32\xbb\xf3\x61\xc8\x3a\x3b\x93\x69\x28\xc6\xde\xb4\xb8\xd4\x91\x5d\x38\x66\xec\xf9\xb5\x27\x58\x13\xbb\xb7\xe0\x20\x1e\x1d\x98\x88\x81\x0b\x3e\xd6\xac\x86\xfd\x6c\x02\x3d\x22\x94\x88\xa1\xd3\x25\x43\x73\x9e\x7e\xd2\x7c\xaf\xa0\xd4\xde\x83\x0c\x02\x97\x6b\x5e\x51\x71\xf2\x31\xf6\xe4\x01\x4c\x87\xba\xeb\xcb\x4f\xf1\x51\xce\x26\xdb\xaf\x2e\xac\xe6\x5b\x4c\x8e\x86\xec\xd3\x99\x4f\x05\x61\xd4\x19\x3d\x7d\x26\x4e\xad\x60\x06\xc5\xcc\xb0\x1a\x34\xb1\x23\x7b\x3f\x34\x27\xbb\x98\x36\xbe\x1a\xbb\x42\xec\x56\xbe\x7d\x7f\xbc\x81\x12\x14\x88\x1c\x34\xaa\x2e\xb2\x25\x2f\xae\x89\xdb\xd6\x69\xcf\x70\x67\x3b\xf2\xe2\x95\x5b\x91\x84\x15\x49\xb7\x25\x85\x14\xff\x86\x6a\x06\x51\xc6\xcf\x2b\xf4\xe6\xf7\xd2\x13\x5b\x62\x5a\x25\x88\x14\x61\x95\x40\x83\x95\x96\x4c\x4d\xae\xd1\x60\x5d\xbb\x9c\xba\x2c\x0a\xf2\xe2\x7a\x2a\xc3\xa1\x1e\x8f\x0a\xbf\xa1\xaa\x4b\xc7\x5b\x38\x4c\x2f\xc5\x4f\x08\xb9\x6d\x5c\x5c\x83\x99\x6f\x66\x3d\x5f\xf2\xe2\xd5\xea\x61\x6e\x48\xbf\xdd\x10\xb4\x72\x73\x9f\x75\x1a\x35\xbc\x7d\x7f\xfc\x12\x5f\xb6\x0f\x19\xd2\xaa\x64\x9c\xa7\x36\x43\xb1\xc4\x3c\x42\x69\xde\x59\xfb\xed\xe4\xbd\xa1\x2a\x93\x4f\xb7\xe6\x6e\x21\x27\x68\x48\x16\xd3\x6f\x50\x91\x7e\x27\x50\xcd\x2e\xe6\x0f\xfe\x0d\xd9\x93\x15\x62\xcf\x71\xf4\x9d\xbb\x34\x4b\xad\xb2\xd1\xe6\x5c\xde\x31\x0c\x9a\x54\x0c\x9d\xb9\xd9\x7b\x1f\xc1\x05\x15\x53\x50\x32\x2d\xe5\xf9\xa4\x46\x54\x65\xda\x92\xa3\xa4\x05\x69\x24\x3f\x9f\x38\xfa\x2a\x94\x50\x4d\x38\x35\x80\xbf\xc1\x7b\xf9\x02\xed\xa4\x26\x19\x2b\x98\xb3\x6d\x37\x1f\x8e\x04\x89\x59\xff\xe9\x25\x5c\x9e\x2e\xc9\xb7\x90\x29\x78\xdc\x91\x37\x8a\x66\x2c\xbf\x98\x15\xa6\xa3\x05\xfa\xa8\x34\x1c\xc2\x2a\x17\x92\x46\x48\xf6\xbb\xb8\xa8\x96\xf9\xdc\xd0\x32\xd1\xbd\xc0\x74\x95\xd2\xcd\x62\x05\xeb\xa1\x20\x86\x1e\x6a\xbb\x03\x68\x9d\xa7\x71\xc6\x33\x9c\x2d\x11\x3e\xce\x5f\x84\x1f\xb8\x0c\xd7\x35\x68\xf3\x79\xdc\xee\x3e\x1b\x8c\x99\x4f\xe6\xa0\x71\x3d\x3c\xe3\x43\xfc\xa5\xe1\x5d\x67\x3b\xe8\xc9\x9c\xc0\x10\xc5\xac\x9f\x15\xb6\xbc\x47\x9f\x90\x95\x2c\x27\xba\x92\xca\x54\xe8\xa1\x90\x97\x1a\x80\xbc\xf8\xec\xf3\xeb\xff\xbc\xf8\x62\x63\x95\xc2\xd1\xa3\xbf\x47\xa4\x6f\x11\x29\x0d\x14\xc6\x79\xce\x3d\x5c\xc2\x5f\x5c\xfb\x4c\xaf\xe3\x6a\x7c\x05\x3a\xfe\x2c\x32\x02\x27\xd3\x0a\x59\x68\x47\xd5\xbf\xe9\x57\x25\x5c\x48\xce\x4e\x3d\x89\x12\x38\x8c\x73\x38\x51\x3e\x56\xb7\x86\x62\x9b\xfb\xb4\x74\xf9\x20\xac\xa8\xaf\xbc\x04\x6c\x33\x43\x6a\x59\xb0\x92\xa1\x5d\x40\xd1\x0f\x71\xd1\x8c\x15\xf7\xe5\xa9\xb7\xda\x63\x15\xea\x03\xfc\xfc\x33\x7c\xd0\x86\x1a\x38\x5c\xfb\x32\xff\xa0\x08\x6e\x8e\x4e\x03\xd8\x0f\xad\x6e\xc8\x8b\xeb\x14\x44\x71\xd3\xcf\xd1\x8e\x37\x11\xda\xd1\xa3\x1d\x03\x5a\x6c\x6f\x6e\x3e\x1c\xfb\x40\xae\xef\x26\xd4\x62\xb4\xe3\x87\x9b\x80\x76\x74\x68\xc7\x99\xa1\xb7\x5f\x19\x9a\x8c\x65\xf3\x2f\x8a\x1c\x34\xc8\xfb\x5d\xe7\xfa\xfd\x26\x8d\xb3\x41\xde\x15\xc0\x6b\xb7\xd0\xbd\x77\x14\x92\xb8\xeb\x1d\xc2\x86\x53\x26\x04\x35\x91\x5f\x18\x39\x74\x2c\xb3\x1f\x01\xcf\xb9\x58\x50\x76\xb9\x99\x29\xfd\x9d\xa7\xe4\x1e\x92\xa7\x34\x3e\xe5\x19\x64\xf1\x76\xc3\xb8\x57\x26\xf1\xfe\x90\x1d\x5a\x14\x19\xcb\x14\x68\xd9\xaa\x1c\xba\x24\x1e\x9f\x7c\x54\x11\x1f\xa9\xcc\x99\x81\x3d\x2b\x9c\x17\x64\x7b\x2e\xb7\x17\x8f\x24\xcc\x2c\xd2\xc0\x33\x57\xca\x95\x9a\xe6\x89\x19\x0f\x1e\x91\xc7\x34\x3a\xa2\xda\x86\x4f\x9e\x27\xae\xd2\x48\x6d\x26\x39\x75\x8d\x2e\x71\xbf\x49\xbf\x7a\x17\xba\xcd\x02\xa9\x35\x5f\xfb\x8f\xa5\x57\x66\x21\x4a\xa0\x69\xe5\xdb\x55\x1b\x02\x08\xc3\x50\x3b\xdb\xf6\x50\x88\x69\xd3\x18\x99\xd7\x45\x97\xbe\x76\x93\xac\x21\x26\x5d\xca\xa9\x3a\x01\xfa\x55\x36\x1b\xe3\xd2\x31\xcf\x84\xa6\xc3\x8a\x36\x67\xef\x8e\x22\x0a\xbf\x5c\x89\xc0\x5d\x89\x6b\x5f\x24\xbe\x6e\xe0\xae\x84\xa4\x18\x2c\x8c\xd7\x92\x16\x14\xaf\x10\xff\xae\x7d\xdb\x8c\xd1\x2d\x33\xad\xcb\x13\x0f\x1d\x47\x3f\x1a\x4b\x86\xf6\xfa\x2a\x93\x3c\x21\xf2\xec\x75\xd0\x70\xab\xbe\xdf\xcd\x33\x08\xfe\xb6\x7d\x37\x99\xc5\xc8\x5c\x9e\xa4\x4d\x87\xca\x93\x5c\x99\x8a\xe0\xc9\x94\x20\x55\xfe\xc3\xd0\xe1\xab\x7b\xff\xe1\xb9\xde\x97\x4a\x0a\x53\x53\xbc\x6b\xa4\x1b\x75\x97\x5b\x58\x24\xbb\xfd\x17\x96\x76\xd0\x7d\x5c\x19\x17\x05\x9d\x14\x4c\x4a\x3d\x99\x36\x8a\xda\x6a\x8c\x67\x28\x40\xfa\x4d\x12\x9a\x68\x5a\x45\x11\x8f\xcc\x37\xe3\xef\xc2\xee\x7d\x9f\x41\x29\x95\x63\x6d\x02\x41\xbc\xd8\xb5\x32\x32\xbf\x18\x5a\xfb\xe1\x85\xc5\x71\xd6\xbf\xa7\xb9\xfd\x1c\xd1\xd8\xff\x7e\xe8\x92\x01\x71\xae\xe2\x86\x03\x70\x9b\xb0\xc7\xd0\xdf\xda\x02\x82\x6d\xbb\xaa\xdb\xdd\x62\xb5\xf0\x51\x6a\x78\xd6\x2b\xf4\x09\x71\xa9\x55\x59\x06\x5e\x6e\x2b\x56\x00\xe5\x7c\xfc\x8e\x55\xdf\xb9\x23\x72\xeb\x86\x33\xef\x56\xfe\xbf\x23\xaa\xcf\x8d\x55\x26\xcf\x55\x28\xd8\x84\xa6\x57\xee\x0b\x45\x12\x47\xfb\x46\xe6\x21\x71\x80\xcc\xbb\x2c\x41\xbf\x99\xb3\xfc\xcc\x37\xb6\x5c\x96\x56\x76\x99\x36\xb2\x74\x39\x29\x3d\x43\x30\x23\x82\xcb\x12\x7f\xec\x5b\x55\x0d\xc6\xf7\xba\x24\x1a\x9a\xdd\xd6\x8a\x94\xa3\xd7\x35\x17\xf2\x4c\x16\x67\xaf\x7e\x57\x66\x64\x34\xbf\x5f\x79\x16\x83\xc1\x1d\xcd\xdb\x60\x6e\x22\x8b\x17\xbf\x1e\x24\x9e\x57\xb4\x31\xa0\xf4\x5c\x00\xd1\x22\x3b\xd1\x8b\x27\x46\x89\x97\x09\xb2\x82\xf2\x19\xe4\x8f\x95\x82\x3e\x2e\xb2\x0b\x62\x84\x4c\xdc\x04\xa3\x5a\x88\x65\x35\x1e\xeb\x56\x8c\xee\xd4\xc6\x2e\x4a\x5f\x13\xce\x26\x22\x1d\x9e\xfa\xda\x5e\x46\x0f\xe3\x4f\xdf\x9a\xfd\x58\x88\x09\x13\x0f\xdc\xfa\x4c\xd4\x41\x48\x01\xab\x4f\x6f\x39\x67\x79\xf9\xb7\xae\x12\x3e\x65\xe2\x6e\xa9\xdb\xe2\xf8\x31\x68\x34\x5b\x29\x89\x55\x9c\x05\xc4\x1a\xce\x32\x35\x68\xf7\x7f\x04\x00\x00\xff\xff\x6d\xfa\x9b\x61\x8e\x36\x00\x00")
+var _complyBlankTemplatesDefaultLatex = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x7b\x6d\x6f\xe4\x36\x92\xf0\x77\xfd\x0a\x02\xa3\xc6\x33\x7e\xd0\xed\x1b\xcf\x22\xb9\x20\x40\x2f\x66\x32\xd9\xbc\xf6\xec\x06\x9e\xc9\x5d\x70\x96\x83\xa3\xa4\x52\x37\x63\x8a\xd4\x91\x94\xed\x0e\xa1\xfb\xed\x87\xe2\x8b\x44\xa9\xbb\xb3\xd9\x20\x5f\xdc\x64\xb1\x58\x2c\x92\xf5\x4e\x79\x45\xfe\xd1\x19\x26\x85\x26\x8d\x54\xa4\xa3\xd5\x03\xdd\x83\x26\x5c\xd2\x1a\x6a\x02\x5c\xc3\xd3\x01\x14\x64\xc5\x0f\x54\xeb\x80\xfa\x51\xfe\xe0\xf1\x6c\x2f\x58\x25\x6b\xc8\x1b\xa9\x5e\x1e\x8e\x1d\x28\x05\x8d\xf4\x48\x57\xf9\x3a\x5f\x80\xf2\x1c\x44\xdd\x48\x95\x0f\x36\x8e\x0c\x17\x08\x1f\x8e\xdd\x01\x84\x1e\x6c\xaf\xf8\x90\xe5\xac\x79\x59\x49\x2e\x15\x67\xe2\x41\x5f\xe5\x17\x26\xd5\x8f\xac\xd3\x82\xb6\xa0\xd7\xfa\x71\xef\x1b\xcf\x37\x37\xae\x31\xd8\x67\x47\x61\xc8\x90\x09\xd6\xe4\x8e\x68\xcd\xd4\x95\x6f\x71\x6a\xe0\x79\x53\x33\xb5\x51\x86\x5f\x5c\xe1\xf6\xe3\xae\x96\x55\xdf\x82\x30\x83\x2d\x59\xcd\x12\x72\x09\xd9\x77\xdf\x7d\xdf\x52\x26\x1a\x29\xcc\x45\x52\xba\xa3\x15\x0c\xf6\x19\xde\x7d\xf7\xfd\x44\x65\x95\x15\x71\x81\x8a\x53\xad\xef\x1c\x3d\x24\xa4\xd9\xaf\x70\x95\x67\x84\xe4\xb1\x97\xaf\x67\x8b\x72\x2a\xf6\x1e\xa1\xa4\x25\xf0\x0d\xf6\x17\x28\x1d\xed\x40\x4d\x84\xc6\xae\x6f\xcd\x71\x4b\xa0\x2d\xe0\xe9\x10\xc2\xf6\x42\x2a\x10\x52\x34\x8a\xb6\x60\xe0\xd9\xac\x1d\xb9\x03\x15\xb5\xec\x71\x8b\x84\x84\xf6\x9c\x06\xd5\x1d\x54\x46\x51\xc3\xa4\x43\x4a\xfa\xdb\x3c\xe9\xa4\x6c\xc6\x5f\x14\x29\x77\x04\x5e\x78\xdc\xfc\x3c\x01\xe4\xb9\x86\x2e\x4c\x44\x99\xca\xee\x6d\x3e\x3b\xba\x7c\x98\xef\xc3\x75\x68\xf5\xb0\x57\xb2\x17\xf5\x86\xb5\x74\x8f\x07\x5a\xf4\x1a\x26\xb0\x81\xb6\x43\x51\xb0\xab\x8c\x90\x82\x89\x8a\xf7\x35\xec\x15\xed\x0e\xac\xd2\x77\x4f\xac\x36\x87\x6d\xe1\x4e\xcb\xb5\xef\x6d\x3e\xcd\xf5\x24\xf3\x61\x95\x4d\xf7\x89\xd4\x83\x52\xd9\x6e\xdf\x74\xa8\x5c\x43\x56\x68\x30\x9e\xaf\x71\xbd\x8a\xba\x5d\x0d\x77\xa2\x6f\x4b\x50\x50\xdf\xff\x06\x16\xe1\x78\xc3\x04\x49\x2b\x6a\xa4\x1a\xec\xe7\x24\xa5\xea\x24\x7d\x44\x46\xf9\x1f\x6c\xb3\xdf\x0a\xa9\x5a\xca\x09\x5e\xe0\x75\xb3\x1f\xb2\x62\x4e\x5d\xd0\x47\xb6\xc7\xbb\x12\xfa\xd8\x96\x92\x6b\x3c\xb1\x09\x78\x95\xe7\x53\x27\xcf\xd1\x34\xe4\xd0\x76\xe6\x38\xbb\x32\x4f\x72\xbc\xb3\x89\x27\x0f\xb2\x79\xda\xcb\x87\xe9\xfa\x56\xe4\x07\x05\x8f\x20\x0c\xd1\x9c\xd5\x40\x4a\x05\xf4\x41\x13\x26\x88\x39\x00\x69\x59\x5d\x73\x20\xb2\x21\x94\xe0\xa6\xdd\x95\x64\xc5\x13\xab\xe5\x53\x07\x82\x72\xc3\x40\x93\x1b\x72\xf3\xea\xd5\xab\x57\x59\xa1\xe8\x7e\x0f\x75\x29\x8d\x91\xad\x93\x02\x0d\x15\x2e\xb8\x31\xcc\x70\xd0\x57\xf9\xb9\xc3\xed\xa8\x32\x04\x6f\x68\xb0\x78\xf9\x15\x08\x03\x8a\x89\x3d\x76\x4a\xd8\x33\x61\x93\xd3\x2d\xe5\xf3\x70\xa7\xa1\xdb\xde\x7c\xda\x99\xb5\xc7\xbd\xf7\x24\xdc\x1a\x43\x46\x08\x21\x78\xfd\x7e\x12\x2a\x6d\x3a\x5c\x30\xa1\x41\x19\x84\x14\x1d\x55\xb8\x06\x88\x7a\xb9\x42\x76\x56\x56\xc2\x66\xfe\x00\xaf\xaf\x7f\x3f\xaf\x71\x91\x19\xbb\x01\xf8\xaf\x73\xdc\x97\x7f\x98\xe9\xcf\xfe\x05\x9e\xfb\xf2\x3c\xdb\x7d\xf9\xfb\x38\x7f\x6b\xbe\x40\x3e\x7e\xa0\xca\xb8\x73\x75\x06\xcf\xe2\x76\x8d\xe3\x3b\xc1\xf9\xe0\xf7\xe3\xd0\x58\x53\xb2\x92\x33\xe9\xa4\xf2\x88\x10\xd4\x0e\xcf\x61\x20\x11\xd6\xf7\x54\x90\x32\x4b\x69\x8d\xfc\xa5\xab\x4e\x5c\xc7\xb5\x47\x4d\x3b\xb1\xd3\x54\x19\x56\x71\xb8\x9a\x5b\x9c\xd9\xd8\x40\x56\x44\x00\xd4\x9a\x18\x49\x4a\x88\x2e\xbe\x61\x4a\x9b\xb3\xf6\x8a\xb6\xba\xa5\xe6\xb0\xa6\xad\x46\x83\x30\x8c\xae\xa8\xa1\x2d\xe3\xc7\xf9\x5a\x77\x4e\xfb\xa7\xd1\x31\x0a\xc8\x4f\x60\xc1\x72\x47\xcd\xbf\xb7\x09\x8a\xb3\x08\x68\x59\x66\x9c\xf0\x56\xd6\xa0\x44\x72\x00\xe8\xef\x98\x00\x6d\x14\x98\xea\xb0\xd8\xb6\x06\xe3\xbd\xeb\xd9\x6d\xb1\xc6\xc0\xf3\x90\x15\xac\xf9\xe1\xcb\xaf\x3e\xc2\x4f\x19\x21\xc9\xf0\x5d\xdc\x24\x88\x2a\x30\x0f\xa2\x0a\xe6\xee\xe3\x4d\x20\x78\x6f\xc3\xc0\xb0\x98\xdd\x9b\xe6\xb3\x7b\xcb\x44\xd7\x9f\x19\xb5\x68\x79\x2b\xd9\x76\x78\x15\x9d\x92\x8f\x68\xe7\xa0\x57\x92\x50\x51\x13\x69\x0e\xa0\x48\x30\xbd\x59\x81\x0b\x92\x15\x61\x0d\xe1\x3d\xc6\x26\x44\x2a\xf2\x8c\xce\xd7\x5d\x03\x5e\x0c\xfa\x4f\xe7\x16\x0b\xd6\xfc\x04\x7e\x27\xf3\xf5\x22\xd6\x30\x17\xc9\x04\x23\x04\x70\x1b\xc4\x8c\x72\x19\x2e\xe0\xb7\x10\xe3\xb9\x12\x52\xd4\xd0\xd0\x9e\x1b\x3c\x8f\x06\xa8\xe9\x15\x68\xfb\xa1\xa2\x1c\xb6\xef\xa9\xa9\x0e\x3b\xf9\x04\xaa\xa2\x1a\x86\x0b\xc8\x77\x85\x6a\xbd\xc8\xdc\xdb\x1d\xfa\x1f\xa4\xb0\xfd\x08\x3f\xad\x3d\x95\x9b\x21\x6c\x78\x8c\xa7\x08\x41\x0b\x13\x01\x5e\xf0\x62\x6f\x12\xbb\x05\xe4\x54\xe8\x22\x42\x3e\x17\x2b\x4d\x85\x9e\x2d\x14\x01\x7e\xa1\xd8\x9b\x16\x5a\x40\x4e\x17\x8a\x08\x8b\x85\x5a\x29\xe4\x7c\x47\x01\x10\x76\x14\x7a\xd3\x42\x0b\xc8\x99\x1d\x05\x84\x74\xa1\x99\x56\x32\xe7\xfa\x08\x29\x04\x3c\x4d\x3a\x97\xe8\x1f\x03\x7d\x8d\xe1\x42\x3e\x2c\xf4\x19\x07\x26\x4e\xce\x81\x4f\xd9\x99\x61\x25\x7c\xa1\xd2\x8f\x42\x1c\x4e\xe0\x9f\xc8\x34\x9e\x4e\x40\x7e\xf9\x25\xdb\x33\xa3\xd7\x3b\x6a\x98\x58\x7f\xad\x00\x1e\xae\xa2\x0c\x78\x8c\x89\xcf\x05\xe4\xcc\x89\x05\x84\x7c\xa1\x21\xc9\x7a\x7f\x12\xed\x99\x5e\xfd\xc9\xe4\xc7\xcb\xbe\x98\x82\xfc\x86\x8d\x08\x09\x48\xdc\x76\x32\xcf\xef\xfc\xdd\x77\xdf\x4f\x5c\x4d\x9d\x53\x86\x92\x99\xc9\x96\x27\x8e\xbc\x19\xfb\x85\x76\x0a\x34\x4c\x3b\x45\x4c\xd6\xec\x7a\x7a\x86\x39\xcf\xc1\x85\x89\xf9\x59\xf8\x09\x5f\x11\x6b\xe3\xd1\xce\x71\x76\xe6\xac\x7e\x0f\x3f\x78\xb6\x28\xaf\xa7\x1c\x2d\x46\x2e\xf3\x14\x11\xa7\xf3\xc7\x4b\xfb\xe5\x4f\x3e\x7d\xb7\x5f\xd6\xbc\xfc\x15\x94\xdc\xb8\xbc\x65\x23\xa4\xd8\xfc\x22\x99\x70\x99\xd1\x6a\x45\x3e\xf4\x5d\x27\x95\x71\x45\x80\x09\x8d\x4c\x68\xa4\x3a\x50\x45\x2b\x03\x4a\x5f\x67\x45\x4b\x1f\x80\x1a\x0e\xc6\x80\xca\xd0\x05\x14\x38\xc9\x91\x16\x52\xf8\x19\x2e\x85\x9a\xa2\x7a\x1e\x6d\xbb\x73\x76\xb4\xfe\xa5\xd7\x86\x3c\x80\x12\x4c\xec\xd7\xa4\xec\x0d\xd1\x86\x71\x4e\x74\xe0\xc4\x57\x00\x50\xc9\xf7\xd7\xb8\x1f\x03\xcf\x52\x75\x75\xa3\x0d\xc6\xb9\x8e\xb8\x87\x1a\xcc\x6a\xcc\xc1\x16\x42\xba\x9c\xa1\xa8\x99\xae\x14\xe0\x39\x51\x75\xb4\x9b\xc1\x0e\xb6\xc0\x85\xae\x5f\xfd\x05\xda\xc1\x4f\x74\x97\xfc\x88\x61\x85\xd3\xfa\x71\xee\x41\x3f\xb0\xae\xf8\xf5\x8d\xfb\x69\xd8\x60\x1d\xbe\xfb\x19\xe2\xb6\x9d\xa3\x9e\xc7\x0f\x5f\x42\xc5\xa9\x82\x1f\xbd\x37\x7d\x17\x8f\xca\xbe\x7e\xf5\xea\xdd\x60\xcf\x1c\xce\x90\x45\x6b\x53\x54\xd4\xe0\xa4\xff\xfe\xf9\xe7\x9f\x7f\x7e\xfd\xea\x55\xb5\x2d\x68\x65\xd8\x23\x5a\xa2\xa2\x53\xd2\x40\x65\xa0\xc6\x33\x26\x11\xe3\x02\xc1\x86\x65\xab\x15\xf9\x9b\xa8\x89\x6c\xc8\x7f\xfd\xe7\xdf\xbf\x8b\x67\x79\x3e\xaf\x47\xc9\x37\x07\x68\x63\xd0\xe8\xda\x5e\xea\x5c\x73\x92\xbb\xb4\x7b\x2a\x79\x6e\x34\xb5\x42\xb1\x58\x93\x12\x9f\x00\x36\x9f\xda\x8b\x59\x28\xbc\xe9\xa4\xb1\xef\x7d\xc9\xb9\x29\x89\xde\xce\x67\x68\x50\xac\xc1\x38\xab\xd7\x40\x22\x16\x51\x14\xef\x8e\x98\x03\x15\x24\xba\x65\x27\xf3\x3e\xe7\xc4\xf8\x6c\x46\x9e\x09\x01\xb3\x7d\x4c\x00\x9b\x4f\xed\x05\x53\xb2\x37\xf3\x59\x13\xc0\xe6\x53\x3b\x9d\x15\x7e\x57\xe4\x47\x0d\xa4\xef\xfe\xa7\x97\x06\x30\xf6\xa3\x8f\x94\x71\x5a\x72\x58\x7b\x36\x8d\xa2\x6c\x7f\x30\xc4\x21\xb8\xec\xf8\x11\x54\x49\x0d\x6b\x09\x88\x47\xa6\xa4\xc0\xfa\x87\xce\x8a\x6f\x9b\xaf\x18\x87\xbf\x3d\x33\x6d\xb4\x0d\x04\xaf\xb5\x39\x0e\x36\xb1\x67\x71\x60\x18\xec\xb0\x98\xd2\xb2\x4a\x49\x73\xec\xc2\xa4\x70\x8e\x11\x38\x63\x6d\x11\x02\x3b\x09\x1a\xa7\x4f\x52\xb4\x04\x9d\x48\xd2\x88\x80\xd6\xb0\xf8\x51\xc3\xfb\x08\xf8\x00\xe6\x0e\x55\x41\xf5\x9a\x49\x71\x6f\x4b\xaa\x59\x85\x4a\x8f\x17\x5c\x33\x8d\x07\x44\x26\x04\x77\x54\x06\x8d\x19\x1e\x05\xee\x0d\x45\x85\x89\x1a\x9c\xa0\x04\x3f\xbc\x30\x62\x6f\x58\xd3\x8b\x1a\x1a\x26\xa0\xb6\xdf\xff\xe3\xfd\xdb\x77\x58\x6b\xfa\xbb\xab\x9d\xb8\x30\x1c\x6d\x21\xc2\x89\xab\x2e\x21\x8b\xb3\x03\xeb\xa8\x42\xa3\x11\x8e\x6b\xe9\x39\xe2\x30\x6e\x0d\x4f\x73\x16\x6a\x70\x10\x7b\x34\x60\x1d\x55\x9e\xc9\xc1\xbe\xea\xcc\x70\x6e\xdc\xd1\xb0\x9f\x76\x86\x74\xbc\xd7\xe4\x75\x67\x48\xcb\x44\xaf\xc9\x4d\x67\x86\x21\x0b\xac\xce\xd9\xc4\x5e\x38\xf3\xc8\xc6\xf6\x40\x79\x33\x2c\x2c\xda\x28\x8c\xac\x79\x19\xa5\x6a\xc3\xc4\x46\x48\x13\x05\x39\xee\xa6\xa1\xa2\x3a\x3e\xaa\x72\x92\xe0\x74\x34\x56\x5a\xe7\x07\xf4\xdc\x2b\x7e\x2a\x81\x08\x45\xf1\x23\x2b\x42\xeb\x9a\xfc\x78\xbb\x23\x9c\x89\xa9\xfc\x93\xca\xd9\x9c\x5e\x29\xe5\x43\x4b\xd5\xc3\x29\xcd\x38\x32\xcc\xc1\x63\xcd\x79\xc8\x0a\xd7\xd6\x60\xfa\xce\x3a\x95\x75\xe5\x82\x4d\x0b\x86\xba\x88\xa4\xab\x1b\x07\xd9\xda\x7c\x1a\xc9\x87\x45\x75\xb3\x37\x07\xa9\x66\x93\x3c\x68\x6b\xf3\x64\x2c\x1f\x2e\xd4\x69\xbb\xba\xc1\xf6\xd6\xe6\xf8\xb3\x44\xd3\x7d\xf9\x0b\x54\x26\x62\x86\xee\xd6\xe6\xa1\xb5\xc4\x7f\x80\xe3\x93\x54\xb5\x8e\x13\x62\x7f\x6b\x9d\x42\x4e\xc3\x79\x6c\x06\x05\x24\x51\x03\x17\x04\x67\x05\x77\x42\xa6\xee\xd6\xa8\x1e\xd6\x19\xc1\x8b\x7a\x70\xe0\xad\x45\x0e\xc6\xee\x55\x9e\x8f\xed\x90\x39\xbf\xa7\x4a\x4a\x11\xc8\x0f\x38\xb9\x61\x1c\x92\xc9\x63\x17\x93\xee\xd8\xbe\x38\xb9\x62\x26\x9d\x3c\x76\xaf\xf2\x7c\x6c\x87\xc9\x5f\xf0\x1e\xd2\xa9\xbd\xe2\xc9\xcc\xd8\xbb\xca\xf3\xd8\x3c\x3b\x6f\x8c\xdd\x0f\xac\x06\xdc\x9c\x9e\x0e\xcb\x1d\x77\xa5\x50\x8f\xd4\xd6\xee\x30\x70\x24\x8f\x0c\xeb\x94\xa2\x96\x15\x6a\x59\xaf\xb8\x36\x47\x0e\x56\xa3\x39\x49\x6c\x16\xa6\x74\xae\x54\x51\x93\xd1\x15\xfd\x78\xbb\xd3\x17\x75\xf0\x3f\x02\xec\x2b\x29\x0d\xea\xa5\x46\xbd\xe1\x5c\x3e\x4d\x7e\x00\x9d\x18\x3a\x86\x26\xa2\xcc\x6e\x75\x0f\xb2\x05\xa3\x8e\x57\x8b\x68\xa0\x88\x03\x5e\x5c\x26\xb4\x3c\x36\x17\xf6\xfa\x5c\xa1\xe6\xee\x77\xce\xbd\xb7\x71\xe0\xd4\x05\xce\xb9\x12\xf0\xc4\x9a\x65\x7d\x2d\x45\xe5\x4c\x63\x88\xa8\x17\x06\x2a\x82\x07\x47\xa2\x92\x6d\x4b\x45\x8d\x16\x54\x6b\x73\x50\xb2\xdf\x1f\x86\xbb\x9b\x7b\xfb\xe2\x66\xc8\x0a\xae\x8d\x06\x63\x43\x6d\xa2\x66\x94\xa3\xa2\xdd\x7d\x72\xfd\x97\xfb\x5d\x4f\x2f\x23\x3c\x7f\xf6\x69\x4b\x75\x7b\xff\x56\x6b\x68\x4b\x8e\x31\xd8\x8c\xb3\x83\x63\x8a\x6b\x23\xe0\x29\x71\xce\x16\xa3\xbd\xc1\x46\xaa\xa8\xfb\x3d\xdd\xc3\xf6\x1b\xaa\x1f\x80\xf3\xb5\x73\x6e\x4e\x5c\xb6\x85\x6e\x29\xe7\x85\x09\x89\x3a\xda\xc9\xd9\x12\x07\xb6\x3f\x70\x8c\x07\x98\xd8\x6f\x5a\x5a\x29\x89\x4b\xe6\x67\xc0\xf3\xf3\x35\x28\x7c\x27\x47\x26\xc5\xde\x0d\xac\xd1\x7e\x1a\x5a\xea\x35\x55\x8a\x1e\x43\xfd\xa5\xe7\x86\x29\xf9\xb4\x98\x14\xc1\x13\x5f\xe9\x68\x45\x79\x85\x2e\x1a\xc5\x1a\xdb\x3d\x77\xf1\x3c\xba\x2c\x86\x35\x4d\xe2\x62\x58\xbd\xb8\xf3\x19\x01\xe7\xb7\x86\x6c\x45\xde\xd3\x07\x20\xa1\x1f\xdf\x29\xc9\x93\x54\x0f\xe4\x89\x99\x03\x19\xb9\x5f\xfa\x75\x4c\x4e\x1a\xd1\xb7\x6f\xdc\xde\x6c\xe1\x7e\xb0\xc6\xf1\xbf\x85\x39\x80\xeb\x9d\xf8\x41\x27\xd7\x2b\xf2\x4e\x2a\x05\x95\x21\x52\xd5\xa0\x30\xa8\x76\xd8\x9a\xd0\xc6\x80\x22\xc5\xf8\x0e\x81\xa5\xb9\x42\xf7\x65\xf2\x30\x91\xec\x01\x8c\x94\xbc\x94\xcf\xe3\x2a\x91\xb3\x0e\xeb\x63\x55\x5b\x17\x23\xef\x28\xa0\x6a\xb0\x05\x6b\xde\x08\x89\x7e\x5a\x43\x55\xb4\xa5\x7c\xb6\x43\xd1\x30\x3f\x38\xd8\x91\x90\x4f\x44\x56\xe4\xad\x33\x00\xa3\xbe\xa3\xf2\x8f\x24\xc9\x01\x68\xfd\x6f\x38\xb6\x70\x9f\x11\xdd\x79\xc2\x53\x1f\x3a\x1b\x1e\xce\x8f\xc5\x00\x42\xd3\x47\x40\x5c\x10\x8f\x93\x14\x25\x92\x1a\x7f\xd1\xfc\x84\xa7\xb4\xc5\x3d\x07\xf0\xe9\x19\xe1\xed\xb5\xf4\xd9\xc9\x09\x9e\x4b\xcd\xda\xe2\x6b\x26\xde\x08\x6a\xde\x38\xe0\x5f\x0b\x0c\x17\x5c\x33\x69\xa1\xb5\x9e\xe3\x15\x0d\x1b\x46\x72\x07\x40\xa5\x59\xd2\xf3\xd0\xbf\xba\x9c\xd2\xb7\xd3\xe6\x8c\x64\x80\x35\xec\xe4\x2e\x5c\xd1\x92\xb8\x97\x40\x17\xbd\x08\xa8\x40\x6b\xaa\x8e\x6b\xa2\x25\x26\x1e\x06\xdf\xb3\x8e\xe4\x09\x73\x5d\x21\x0d\x91\x8f\xa0\x1a\xbc\x3f\x7c\xe6\x42\xa5\xc8\x56\xa4\xa5\x6a\xcf\x84\x26\xe5\x91\x04\xa3\xb3\x76\x59\x33\x33\x84\xe9\x90\x27\x77\x52\x6b\x86\x17\x6c\xa4\xa3\xf1\xa4\x98\x01\xa4\x1d\xa7\xe8\x0c\x83\x76\x54\x37\x78\xee\x38\xab\x98\x21\x21\x0a\x44\x01\x39\xff\xb6\xb9\x26\x7e\x67\x6b\x72\x7d\x7d\x7d\x8f\x92\xa6\xc1\x3c\xc0\x51\xdb\xaf\x99\x18\x6c\x78\xff\x8c\x17\xb2\xf6\xc8\xdb\xe9\x48\xd7\x0f\x00\x5d\xf2\xb0\x8b\x8a\xfb\x01\x4c\x64\x89\x34\x6c\xdf\x2b\x20\x1d\xa7\x15\x60\xa2\x82\x4f\x11\x07\x53\x76\xe7\xae\xbd\xe9\xf4\x1b\x8f\x6f\x11\x65\x71\xd2\xa3\x70\x85\xc0\x43\x6f\xa8\x76\x5e\x12\xcd\x5a\xb0\x16\x0e\x3e\xf9\x41\xc2\x84\x36\x40\x5d\x7a\x7c\x90\xc6\x8d\x7e\x9e\xc5\xdc\xfd\x56\x96\xbd\x36\xef\xa2\xa7\x38\x28\x68\x86\xbb\xd7\xf7\xf6\xc5\xeb\x22\x52\xb0\x45\xaf\x38\xfa\x8c\x21\x11\x6e\x2c\x19\x1b\xc5\x1e\xc0\xbf\x84\xe7\x9b\x0d\xa1\x5c\x4b\xcc\x97\xd0\xa9\x2b\x82\x59\x85\x42\xd1\x4c\x25\xfe\xce\xbf\xc5\x42\x7b\x6f\x7b\x0e\x2d\x9e\xd3\xdb\x47\xc9\x6a\xcc\x62\x4a\x0e\xad\xf6\x56\xad\xd0\xb2\x37\x78\x5d\xa8\xc5\xa0\x02\x34\x06\xb2\x59\x31\xd6\x43\x6a\x68\xbe\xf4\x31\x45\xd8\x81\xb6\x85\x82\xd4\xf5\x21\xa5\xc1\x26\x9c\xa7\x99\x05\xb4\xa0\xf6\x20\xaa\x63\x78\x50\x19\x2c\x96\x4a\xdc\x2b\x85\xaf\xde\xa0\x84\x35\x3d\xe7\x18\xf3\x81\xce\x8a\xf0\x7a\x31\x52\x37\x78\xfb\xe8\x74\x7d\xee\x93\xd2\x66\x06\x5a\x0d\x9d\xcf\x69\xce\xa6\x33\x38\xe0\xbd\x8c\x7f\xfc\x0e\xaf\x5e\xf1\x99\xb6\x92\x3d\x3e\x14\x5a\x0d\x95\xe8\xdb\x1a\x3a\x73\x18\x6c\x78\xd4\x8d\x80\xab\x3c\x4f\x7a\x21\x84\xfb\x24\x6c\x75\x0a\x55\x2e\x51\xdb\x14\x2d\x7d\xae\x59\x0b\x02\xdd\x95\x82\x56\x3e\x02\x09\x6c\x10\xcf\x14\xbe\x53\xc6\xa3\x9b\xf9\xab\x40\xdb\xc1\xb8\xac\x1e\x36\x78\x57\x21\x24\x09\x82\x98\xb8\x0a\x54\xe3\x99\xaf\x20\x8d\x02\xd8\x68\x43\x45\x8d\x4b\x14\xac\x79\x9e\xd0\x8b\x31\x25\x1d\xcb\x45\x1c\x4c\x21\x79\x3d\xa1\x8c\x2d\xcc\xf5\xe6\x77\x3e\x0e\xb9\x60\x67\x36\x0d\xc5\x38\xb8\x16\x5f\x3a\x72\x0b\xa7\x8c\x5d\x5e\x7b\x86\x95\x76\x4e\x39\x48\x47\x47\x26\x52\xe0\x09\x1f\xe3\x19\x27\x67\x8d\x66\xd1\x45\x44\x28\x11\x63\xc7\xe6\x63\x73\x51\x80\xa9\x34\xdf\x28\x68\x74\x88\x20\xa3\xc0\x55\x9a\x1f\xa8\xd8\x87\x1c\x3b\x4b\x65\x71\x3e\x64\x6f\xae\x3f\x81\x76\x58\x4e\x76\x5f\x5d\x38\x1b\x78\x32\x39\x19\xb2\x7f\x39\x33\x15\x84\x51\x47\x8c\xf4\x99\xd8\xf7\x82\x19\x14\x33\xc3\x5a\xd0\xc4\x8d\x6c\xc2\xd0\x92\xec\xc9\xb4\x49\x6b\xb2\x62\x11\x56\xbe\xfb\xb0\xbb\x85\x06\x14\x88\x0a\x34\x9a\x2e\xb2\x22\x2f\x6e\xf0\xc3\x9c\x3d\x86\x87\x0c\x37\xbd\x26\x2f\x5e\xfb\x15\x49\x5c\x91\xd8\x15\xa9\xa5\xf8\x7f\x68\x66\x10\x65\xfa\xbc\x42\x67\xff\xac\x3c\xb1\x22\xa6\x57\x82\x48\x11\x57\x89\x34\x58\xe3\xc8\xb4\xe4\x06\x1d\xd6\x0d\x12\x62\x8d\xac\x6b\xf2\xe2\x66\x2e\xc3\xf1\x3d\x1e\x0d\x7e\x47\x95\x2d\xa6\x5b\xd8\xce\xef\x2b\x4c\x88\xb5\x6d\x42\x56\x44\x83\x59\x6e\x66\xc9\x30\x06\x51\x03\xda\xf0\x73\x87\x99\x11\xac\xee\xda\x21\x35\xcc\x16\xe3\xd4\x45\xd6\xf0\xee\xc3\xee\x0b\xd4\xec\x90\x32\x14\x87\x86\x71\x5e\xb8\x0a\xc5\x29\xe6\x0e\x1a\xf3\xde\xf9\x6f\x87\x8e\x4c\x94\xf2\xf9\xce\xdc\x9f\xc8\x09\x3a\x92\x93\xe9\xb7\x68\x48\xbf\x15\x68\x66\x4f\xe6\x8f\xf1\x0d\xd9\x90\x33\xc4\x2e\x71\xf4\xad\xbb\x13\x4f\xed\x80\xfb\x87\xa5\xbc\xbf\xb8\x49\xf4\x07\x53\x38\x34\x61\xa0\x36\x21\x46\x40\x3d\xca\x17\xa0\x7c\xfe\x94\x17\x8a\x1a\xc9\x2b\xd3\x8a\xec\x24\xad\x49\x27\xf9\x71\xcf\x31\x56\xa1\x84\x6a\x82\x5f\x83\xe0\x6f\x8c\x5e\x3e\x47\x3f\xa9\x49\xc9\x6a\xe6\x7d\xdb\xed\xc7\x1d\x41\x62\xa8\xd9\xe4\x25\x5c\xef\xaf\xc9\x37\x50\x2a\x78\x5a\x93\xb7\x8a\x96\xac\xba\x5a\xbc\xa9\x27\x0b\x0c\xe1\xfe\xb1\xdc\x1b\xd3\x2a\x9f\x92\x26\x48\xee\xbb\xb8\xe4\x2d\xf3\xd2\xd0\x49\xca\x7a\x8a\x89\x59\x04\x5a\x9e\xe5\x0a\x2e\x2e\x47\x8c\xf0\xbc\x85\xcf\x5d\x11\x74\x9e\xa7\x69\xc6\x05\xce\x4e\x11\x7e\x9b\xbf\x04\x3f\x72\x19\x10\xa7\x87\x87\xe9\x10\x3d\x43\xfe\xb3\xc1\x69\xe6\x55\x9e\x2f\x41\xd3\x7a\x78\xc6\xdb\x30\x8e\xfb\xca\xb1\x2a\x5b\x02\xc7\x48\x66\x0f\x86\x28\xe6\xe2\xac\xb8\xe5\x0d\xc6\x84\xac\x61\x15\xd1\x07\xa9\xb0\xfe\x5e\x6b\xf2\x52\x03\x90\x17\x9f\x7e\x76\xf3\xef\x57\x9f\x67\xce\x28\xec\x02\xfa\x07\x44\xfa\x06\x91\x8a\x48\x61\x9a\xe7\xc3\xc3\x53\xf8\x8b\x9b\x50\xe9\x75\x8c\x6c\x26\x2d\xf0\xd7\x90\x9f\x80\x27\x27\x33\x7b\x2d\x4b\x5e\xff\x52\x41\xd3\xc0\x85\xe4\x6c\x3f\x90\xa4\x80\xc3\x38\x87\x3d\xe5\xd3\xeb\xd6\xf8\xd8\xe6\x3f\x2d\x3d\x55\x08\x27\xea\x67\x34\x01\xb5\x82\x19\xd2\xca\x9a\x35\xf8\x2d\x9b\x13\xfd\x98\x17\x2d\x58\x41\x1a\xa3\xd7\x9e\x5e\xa1\x3e\xc2\x4f\x3f\xc1\x47\x6d\xa8\x81\xad\xb3\xb3\xd3\x5e\x6d\x71\xbb\xf3\x16\xc0\x7d\x1d\x76\x4b\x5e\xdc\xe0\x37\x50\xb7\xc3\x12\x6d\x77\x9b\xa0\xed\x02\xda\x2e\xa2\xa5\xfe\xe6\xf6\xe3\x6e\x88\xe4\x06\x3b\xa3\x96\xa2\xed\x3e\xde\x46\xb4\x9d\x47\xdb\x2d\x1c\xbd\xfb\xca\xd0\x94\xac\x9c\x27\x85\x77\xb9\x87\x46\x79\xbf\xb7\xbe\x8f\xdf\x2d\x26\xd5\xa0\x10\x0a\xe0\xb5\x3b\xe8\x26\x04\x0a\x79\xda\x0d\x01\x61\xc7\x29\x13\x82\x9a\xb0\xf4\x3c\x68\x40\x7c\x6a\xe0\x79\xc9\xc5\x09\x65\xf7\xb3\x9d\xd3\x5f\x07\x4a\x5e\x91\x02\xa5\xc0\x79\xe0\x25\x81\x9c\xe8\x6e\x1c\x0f\xc6\x24\xdd\x1f\xb2\x43\xeb\xba\x64\xa5\x02\x2d\x7b\x55\x81\x0d\x4b\xfb\xf1\x54\xb5\xd3\xed\x08\x89\x95\xd0\x0d\xc3\x92\x6f\x56\xf8\x9e\xaf\xed\xa5\x23\x39\x33\x81\x99\xa9\x0c\x3c\x23\x53\x69\xf7\x74\xb4\x2c\x16\x45\xf0\x84\x3c\x95\xd1\x11\xd5\x55\xcd\x43\xf1\x3c\x77\x23\x07\xea\x3e\xdd\x2e\x50\x57\x1f\x34\x3e\x1b\xe2\x6f\x3e\x84\xf9\xf3\x55\x75\x5f\x46\x52\xe7\x62\xed\xdf\x57\x5e\x59\xa4\x28\x91\xa6\x93\x6f\xff\xda\x10\x41\x98\x86\xba\xd9\x6e\x51\x14\x62\xda\x75\x46\x56\x6d\x6d\x8b\x37\x0e\x36\x38\x47\x4c\x6c\xc1\xa9\xda\x03\xc6\x55\xd8\xc7\x82\x9c\x1d\xb2\x71\xe9\x79\x6a\x5a\x44\xf2\x36\x8f\xad\xe4\x70\x0b\xff\x44\xe0\xaf\xc4\xb7\xaf\xf2\xf0\x6e\xe0\xaf\x84\x14\x98\x2c\x4c\xd7\x52\xd4\xf8\x09\x70\x8e\x7f\xcf\x7d\xdb\x8c\xd9\x2d\x33\xbd\x7f\xab\x19\x3b\x9e\x7e\x32\x96\x8f\xed\xf3\xab\x44\xfe\xc6\xeb\x0c\x36\x68\xbc\xd5\xd0\xb7\xcb\x0a\x82\xcd\xd3\xe1\x7c\x91\x23\x73\xb9\xc7\x6f\xc0\x0b\xfc\x3d\x33\x15\xc1\xb3\x29\xe1\x37\x0b\x1f\x86\xc6\x2f\xbb\x87\xf0\xe1\xb9\xde\x34\x4a\x0a\xfc\x8a\xc6\x38\xc1\x28\x92\xee\xe9\x16\x96\x62\x14\xbf\xb0\x74\x0c\x63\x12\x31\x65\x88\x93\x14\xa4\x54\x68\xa9\x0d\x3e\xe9\xe3\x4a\x9e\xa1\x08\x19\xb2\x3c\x36\xf3\x0c\x8d\x60\x3a\x12\x29\x84\xdf\x2c\xdc\x85\xdb\xfb\xa6\x84\x46\x2a\xbc\xac\x7c\x0e\x41\x55\x0a\xb7\xe1\xb8\x36\xb2\x0a\xfc\x1b\x59\x6d\xe2\x7e\x66\x79\xd6\xff\x2f\x2a\xf7\x39\xa2\x71\xff\xfd\x60\xf3\x11\x71\x71\x9f\xd3\x01\xf8\x4d\xb8\x63\x18\xee\x28\xd6\x0f\x5d\xdb\x45\x90\xfa\xfe\x64\xb5\xf8\x51\x6a\x54\xeb\x33\xf4\xf1\x63\x0c\xac\x3f\xc8\x26\xf2\x72\x87\x6f\x25\x94\xf3\xe9\x3b\x56\x7d\xef\x8f\xc8\xad\x35\x9e\xb9\x3d\x7d\x6e\x4a\xdf\xe7\xc6\xa7\xa4\x6d\xe0\x2a\x3e\xd8\xc4\x66\x30\xee\x81\x91\x89\xa3\x34\xdb\x37\xb2\x8a\x85\x03\x3c\x1c\xd7\xce\x87\x6c\xc9\xf2\x85\x6f\x6c\xb9\x6c\x90\x29\xac\x6f\xc8\xc6\xd7\xa4\xf4\x02\xc1\x4c\x08\x8e\xe6\x62\x7c\xfe\xad\x2a\x7e\x55\xe8\x7b\x36\x4f\x3e\x63\x5d\xdc\xd6\x19\x29\xc7\xa8\x6b\x29\xe4\xa5\xac\x8f\x79\x76\x69\x06\xfe\x7b\xc2\x19\xb5\x18\x1d\xee\xe4\xde\x46\x77\x93\x78\xbc\x78\xff\x91\x78\x75\xa0\x1d\x7e\x02\xb4\x14\x40\xf4\xc8\x5e\xf4\xd2\x89\xc9\xc7\xbc\x33\x64\x05\xcd\x05\xe4\xc0\xe0\x1f\x10\xd9\x13\x62\xf8\x4d\x79\xb2\x2f\x7c\xb3\x1c\x09\x2f\xc6\xec\x19\xa7\x3b\xf7\xb1\x73\x7f\xbd\x30\xbe\x73\x91\x8e\xaa\x1e\x1a\xe3\xef\x3c\xc2\xf8\xd3\xb7\xe6\x3e\x16\x62\xc2\xa4\x03\x77\xa1\x12\xb5\x15\x52\xc0\x59\xd5\x3b\x9d\x73\x7a\xf9\x77\x4e\x08\xc6\x78\xc7\xf5\xf2\xfb\xe5\xfe\xa6\x0d\xa7\x36\xce\xbd\x94\xa4\x26\xce\x01\xf2\x2c\xcd\x46\x44\x6d\x6b\x59\xf5\x2d\x08\x33\xfc\xdf\x00\x6d\xfa\x9b\x61\x8e\x36\x00\x00")
 
 func complyBlankTemplatesDefaultLatexBytes() ([]byte, error) {
 	return bindataRead(
@@ -281,12 +282,12 @@ func complyBlankTemplatesDefaultLatex() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-blank/templates/default.latex", size: 13966, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-blank/templates/default.latex", size: 13966, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complyBlankTemplatesIndexAce = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x59\x6d\x6f\x1b\xb9\xf1\x7f\xef\x4f\x31\xd8\xbc\xb0\x8c\x58\x94\x9d\xfb\xff\xaf\x87\xa4\x7b\x45\x62\xe7\xda\xe0\x7c\x71\x50\x1b\x07\x14\xc1\xa1\xa0\xc8\x91\x96\x36\x97\xdc\x90\xb3\xb2\x75\x8a\xbf\x7b\x41\xee\x83\x56\x2b\x59\x72\x1b\xb9\x40\x81\xd3\x0b\x89\xe2\xc3\xfc\x66\x86\x33\xc3\x19\x32\x05\x69\x05\xcd\x0b\x84\x8c\x72\x7d\x10\xbe\x40\x73\x33\x4d\xd1\x1c\x00\x64\xc8\xe5\x01\x00\x40\x8e\xc4\x41\x64\xdc\x79\xa4\xb4\xa4\xc9\xf0\x87\xd8\x4d\x8a\x34\xc2\x62\xc1\x3e\x39\x7b\x83\x82\xd8\x47\x9e\xe3\xc3\x43\x1c\xd3\xca\xdc\x82\x43\x9d\x26\x9e\xe6\x1a\x7d\x86\x48\x09\x64\x0e\x27\x69\x92\x11\x15\xfe\xf5\x68\x24\xa4\xb9\xf1\x4c\x68\x5b\xca\x89\xe6\x0e\x99\xb0\xf9\x88\xdf\xf0\xfb\x91\x56\x63\x3f\x1a\x97\x3a\xe7\xa3\x13\xf6\x3d\x7b\x35\x12\xbe\xfe\xcf\x72\x65\x98\xf0\x3e\xd9\x2b\x8a\xbf\xe3\x24\xb2\x1a\xcb\x73\x23\x3d\x59\x83\xdd\xb1\x55\x5c\x2f\x9c\x2a\x08\x82\xe6\xd2\x84\xf0\x9e\x46\x37\x7c\xc6\xab\xde\x04\xbc\x13\x4f\x86\xcf\x6d\x8e\x86\xd8\x8d\x1f\xbd\x62\xaf\x5e\xb1\x93\xa6\x23\xc0\xdd\xec\x1d\x4d\x73\x42\x37\x3a\x65\x01\x28\xb6\x9f\x09\xa7\x70\x48\x34\x17\xce\x9a\xd1\x09\x3b\x3d\x65\x27\x9d\x9e\x15\xc8\x68\x59\x86\xe7\x98\x26\x33\x85\x77\x85\x75\x94\x80\xb0\x86\xd0\x50\x9a\xdc\x29\x49\x59\x2a\x71\xa6\x04\x0e\xe3\x9f\x63\x50\x46\x91\xe2\x7a\xe8\x05\xd7\x98\x9e\x56\x64\x52\x10\xde\xd7\xad\x25\xcf\xb1\x03\x82\x89\x97\x51\xa7\x5c\xca\xf7\x33\x34\x74\xa1\x3c\xa1\x41\x37\x48\xce\x2f\x7f\x39\xab\xc0\x2e\x2c\x97\x28\x93\x63\x98\x94\x46\x90\xb2\x66\x80\x61\xea\x11\x2c\x6a\x2a\x1d\x3a\x5f\x4a\x74\xf3\x2b\xd4\x28\xc8\xba\xb7\x5a\x0f\x0e\x59\x10\xec\xf0\x88\x4d\xac\x7b\xcf\x45\x36\x58\x12\xd1\x5d\x0a\x00\xa8\x99\x32\x06\xdd\xdf\xae\x7f\xb9\x80\x14\x2a\xad\x9c\x05\xad\x90\xbd\x22\xa7\xcc\x74\x30\x48\x92\x97\xdd\x69\x47\x8c\x9c\xca\x07\x47\xc7\xe4\x4a\x3c\x82\xd1\x08\xbe\x1f\x4e\x14\x6a\x09\x78\x5f\x38\xf4\x5e\x59\xe3\x5b\x88\x87\xa3\x83\xb6\x51\xb7\x1a\x66\xc0\x67\xf6\x6e\x10\x94\xdd\xe5\x49\x4d\x60\x90\x29\x4f\xd6\xcd\x99\xc3\x42\x73\x81\x57\xc4\x09\x57\xf9\x06\xd8\x34\x67\x60\x4a\xad\x8f\xa1\xfa\x3e\x7c\x71\xf8\x32\x12\x5f\xf2\x72\xd0\x36\x67\xdc\x81\x22\xcc\x3d\xa4\x4b\x3d\x4e\x91\xde\x6b\x0c\x4d\xff\x6e\x7e\xa6\xb9\xf7\x21\x80\x0c\x0e\xc9\x16\x43\xc3\x67\x87\x4b\x4a\x13\xeb\x60\x10\x69\xa4\x27\x6f\x40\xfd\x39\x92\x62\x1a\xcd\x94\xb2\x37\xa0\x5e\xbe\x5c\xe5\xb6\x41\x83\xb4\x02\xfd\xac\x7e\xeb\x8c\x06\x89\x43\x37\x23\x3e\x0d\x80\x90\xa6\x29\x24\x17\x1f\x92\xbe\xc8\xa3\x11\x18\x3e\x53\x53\x1e\xb5\x47\x7c\xec\x57\x86\x5b\x3a\x22\xb0\x1e\x8c\x8a\x05\xcb\xe5\xca\xf8\x4a\xcb\x7d\x7a\x00\xbd\xe9\x5c\xca\xc1\xa1\xf2\x43\x2e\x48\xcd\xb0\x23\x6f\xd4\x1e\xa0\xf6\xb8\x8b\x84\xc3\xdc\xce\x70\x0b\x95\x83\x1d\x14\x47\x23\xf0\x18\xed\xe3\x11\xe9\x94\x8c\x0a\xea\xdb\xcd\x2e\x6e\x32\x25\x25\x9a\xff\x48\xa6\x46\x2d\x9b\x49\x1c\x6c\x6a\x37\xad\xf0\x3b\xb6\x72\x5e\xc5\xb3\x4a\x2e\x96\xa1\xb3\x4c\xf9\x61\xe1\x54\xce\xdd\x3c\x34\x7d\xce\xb5\xae\xd7\xc4\xf1\x61\xbb\x2a\x76\xd5\x1b\x89\xae\x83\x96\x9d\xb2\x6d\x27\x5e\xf5\x29\x98\x2f\xc7\xd5\xb4\x4f\x56\x2b\x31\x3f\x86\x4f\xce\x0a\x94\xa5\xc3\x63\xe0\x46\xc2\xdb\x52\x2a\x82\xe0\x3f\xa5\x5f\xe1\x60\x62\x2d\xb5\x94\x0c\x9f\xb1\x60\x71\x81\xd9\xb1\xbd\x47\x19\x1a\x93\x52\xeb\x18\x06\x3b\x80\x1b\x59\x05\x28\x75\x14\x53\xfd\x8e\xc3\xff\xeb\x69\x5b\x2b\x56\x7b\x18\xb3\x33\x74\x21\xee\xf6\x66\x00\x78\x72\xd6\x4c\xd7\xba\x01\x38\x58\x23\xb4\x12\xb7\x69\xb2\x0c\xb4\xaf\x63\x64\x39\x6c\xa8\x1d\x1e\x25\x70\xb9\x99\x72\x07\xdb\x70\xe7\x78\xb0\x58\xbf\x1f\xf4\x25\xbd\x80\xff\xf1\x31\xea\x1d\x0e\x8a\xb0\x41\x6a\x5f\xf8\x0d\xb5\x80\xfe\x69\x33\xe5\x2e\x76\x63\x14\xfb\x42\x6f\xe9\x45\xfc\xc7\xa8\x77\x38\xf0\xc4\x8d\xe4\x4e\xee\x89\x81\x96\x5c\xc0\xbf\x7a\x84\xf6\xa8\xcb\x00\xce\x94\x44\x23\x70\x6d\xce\x76\xa0\x66\x59\xc0\x79\x5f\xb7\xe1\x57\x5e\xea\xca\x79\x5e\x34\x56\xc8\x1a\xf7\x6f\xf0\x5a\x47\x61\x75\x82\x51\x03\x8f\xb5\x15\xb7\x5f\x4a\x4b\x4b\x4e\xb2\xef\xe0\x3a\x53\x1e\xbc\x22\x0c\xe9\x88\xb7\x5a\x49\x4e\xe8\x81\x6b\xdd\x1e\x60\x3e\xa4\x9e\x9c\x50\x02\x59\xa0\xec\xf1\xc0\x90\x35\xbe\xc9\x84\xd5\x65\x6e\xa2\x57\xcf\x04\x1a\x42\x87\xb2\x1b\x75\xc2\x68\x18\xb4\x06\x87\x94\x29\x27\x3b\xca\x91\x6a\xb6\xa2\xaa\x65\xa8\x09\x2b\xbe\x63\x19\xf7\xc3\x90\xb5\x0d\x1b\xc2\x10\x72\x1b\x67\x35\x5c\x3b\x2e\x6e\x55\x67\x4b\x1b\xa4\xb5\x25\x5b\xe1\x42\x3d\xa0\xcc\x14\xae\x38\x29\x3f\x51\x4b\x00\xdf\x9b\x18\x99\xea\x6d\xeb\x62\xc1\x42\xcc\xf3\xac\x59\xd3\x52\xe9\xc4\xcf\x6f\xe3\xeb\xda\x12\xd7\xdf\xc4\x53\xa4\xd0\xf2\xf3\x5f\xde\xad\xd6\x69\xf7\xbd\x5f\x6f\x63\x62\x00\xd7\x4a\xdc\x22\x3d\x45\x2f\x1c\x88\xbb\x29\x52\xfa\xcf\xb1\xe6\xe6\xb6\x2e\xa8\x16\x0b\x76\xa1\xcc\xad\x67\x2d\xa3\x97\x05\x9a\x87\x87\x64\x2d\x52\xb4\x7a\xed\xcd\xdc\x93\x3c\x97\x5a\xa2\xa7\x5a\x9e\x27\x89\xb3\x81\xa1\x48\xe3\x9c\xcf\xfd\xc3\x03\x48\x3e\xf7\x7b\xda\xf3\xad\x22\xad\x59\x41\x9d\x0c\xec\x79\xbf\x83\xb2\xe1\xef\xf8\xa5\x44\xbf\x8f\xed\x8e\x3c\xee\xdc\xea\xce\xac\xbd\xba\xf3\x9e\xe5\x78\xab\xf5\x6e\x31\xf6\x16\x06\x3a\x83\x74\x67\xab\x41\xbf\x55\x1d\x23\x28\x9c\x9d\x86\xb2\x8e\xb5\x8d\x65\xea\x0a\x33\xae\x4b\x4c\x57\xb9\x3d\xd3\xd6\x87\x28\x0a\x39\xbf\x4f\x1f\x17\xe4\xc5\x32\x41\xfa\xb6\xa3\xb1\x9b\xed\xae\x28\xb2\xca\x1b\x1e\x4b\xbd\xbe\x06\xc9\xc2\x79\x0d\xdc\x40\x73\x48\x83\x9d\xc4\x93\xd3\xba\x29\x37\xea\xf7\xaa\xd2\x0a\x59\x72\xe8\x14\x36\x2f\xb4\xe2\xe1\x7c\x47\x33\x53\xce\x9a\x58\x36\xd6\x54\x89\x8f\x35\xb2\xea\x7b\x3d\xd5\xa5\xf6\xf2\xaa\xfe\xbf\x9a\x1e\x53\x06\xe1\x84\xee\xf7\xbd\x0d\x75\xfc\x3c\xef\x77\x7f\x3a\xff\x09\xce\xed\x9d\xd1\x96\x77\x52\x1a\x5a\x29\x19\x82\x0d\x39\x6e\xa6\x08\xec\xaf\xce\x96\x05\xca\xa5\x1e\x60\xa5\x44\xe8\xb3\x22\x83\xf5\xad\x15\x12\xcd\x40\xcd\xd2\xda\xd8\xda\x51\x56\x83\xff\x8a\x2e\xde\x08\xf4\x16\x54\x73\xd4\x04\xd8\x05\x37\xd3\x92\x4f\xfb\x68\xd5\x87\xb3\x71\x49\x64\x4d\x5b\x23\x85\x86\x32\x13\x5b\x39\xd3\x62\xc1\x2e\x4b\x2a\x4a\xfa\x49\x69\x34\x91\xe5\x9e\xcf\xc5\x7b\xb8\x34\xc9\xb9\x9b\x2a\x33\x74\x6a\x9a\xd1\x6b\xf8\xff\xe2\xfe\xcd\xba\xcf\x55\x2c\x6d\xe3\x67\xb1\x08\xe5\xe2\xd3\x19\x6d\xbc\xe4\x79\x78\xfd\x0a\xef\x3f\x6e\x62\xd1\xc8\x35\x0e\xd7\x7b\xbb\x3d\x2f\x9a\x52\xe1\x79\xfd\x70\x63\x11\xf2\x15\xa6\xc1\xf7\x4c\x74\xb0\x31\x66\x7c\xa6\xac\x0b\x5e\xd8\xda\x20\x60\x5e\x68\x3b\xc7\x90\xec\x1a\x19\x2f\xe3\x1c\x17\x64\x9d\xff\x9f\xf1\xbc\x46\xf2\x3f\xfc\xee\x0f\xbf\x5b\xf1\xbb\xb6\x2c\x7e\x66\xcf\xdb\x5c\x7e\x87\x13\x10\x43\x2d\x3b\x46\xf0\x05\x0a\x35\x51\x02\x3c\x61\xe1\x81\x32\x4e\xc0\x1d\x02\xf1\x5b\x34\xa0\x0c\x38\xf4\x85\x35\x1e\x43\x6d\x79\x8b\x73\x88\xd7\xd1\xcf\xea\x82\x1f\xce\xfb\x3d\x57\x22\x43\x59\x6a\x84\x41\x70\x06\x98\x58\x97\x73\x3a\xda\xed\x86\x4b\xf9\xbf\xc5\x03\x3f\x9c\xf7\xba\x2b\x3f\x3a\x73\xd6\xac\xcd\x8f\x17\xf0\x61\xd1\x86\xd1\x8d\xd6\x4c\x12\x2e\x0d\x48\xcc\xb9\x91\xfd\xd9\xdb\x4c\xa8\xbd\xe6\x78\x5e\x0b\xda\x7c\x81\xf2\xb5\x36\x9b\x79\x9d\x1e\x55\x45\x2b\xf8\xb6\x1e\x1f\xcf\xfb\x89\x53\x4c\x22\x79\xbe\xd5\x6e\x36\x5f\x2d\xee\x36\xa2\xe6\x7a\xe1\x67\x9c\x3f\xc5\xbe\xda\x82\xff\x2f\x8f\x8e\xc0\xbb\xf9\x6e\xeb\x6a\x8a\xf5\x27\xd8\x56\x3d\xf5\x67\x9c\xef\x8a\xe3\xb5\xde\x37\xdb\x23\xc0\xf2\x56\x77\xb1\x60\xe7\x58\x5d\x48\xa9\x0d\xa6\x16\x2c\x74\xd3\xcd\x46\x05\x1a\x75\x5e\x0a\x81\xde\xc3\x3f\x7a\xd1\xe1\x31\x33\xfd\x68\x77\xd8\xe7\xb6\x53\xa9\x65\xe5\x5d\x5f\x03\x21\x94\x37\x16\xf0\xa7\x36\x72\xf7\x63\xf5\xa6\x42\xe9\xdf\x88\xba\xb1\x6b\x62\x2d\xa1\x63\xd5\xcf\xb2\xa6\xea\xdf\x5a\x37\x6e\xb3\xb5\x3c\x2a\xba\x77\x6c\x97\x9d\xa2\xa1\xce\x5d\xce\xac\x99\x28\x89\x86\x14\xd7\xf0\xea\xe4\xf4\x87\x83\x0d\x4f\x82\x6a\x02\x83\x3b\x65\xa4\xbd\x63\xda\x0a\x5e\xbd\x11\x70\x9f\xa5\x69\xd2\x79\x03\xea\xdf\x69\xc7\xee\xde\x03\xc6\x8c\x3b\x08\x2b\xcf\x6c\x5e\x58\x13\xaf\x04\x53\xd8\x44\x9a\xf9\x42\x2b\x1a\x1c\xbe\x68\x5f\x33\xe2\xbb\xdb\xca\xd2\xfa\x3d\xeb\xc7\xd3\xee\x33\x4b\x40\x90\xe8\x49\x99\xaa\x36\x4a\x7b\x78\x9f\x4f\x97\x4f\x5b\x81\xe4\xe7\xa4\xe1\x38\x39\x4e\x96\x15\x5f\x72\x9c\x34\x49\x67\x68\xb6\xf1\x39\x39\x4e\xda\x88\x96\xfc\xc6\x94\x91\x78\x7f\x39\x19\x74\x10\x8f\xe0\xc7\x14\x4e\x56\x5f\x7e\xa2\x6a\xba\x73\xda\xb1\xee\x3b\x0c\xc0\xc3\xbf\x02\x00\x00\xff\xff\x26\x43\xeb\x2c\x60\x20\x00\x00")
+var _complyBlankTemplatesIndexAce = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x5a\xff\x6f\x1b\x37\xb2\xff\xdd\x7f\xc5\x60\xfd\x0a\x49\x2f\xd2\x4a\x76\x92\x36\x4f\x79\xdb\xc2\xb1\xd3\x6b\xd0\x24\x0e\xce\xbe\x1e\x0e\x41\x71\xa0\x76\x47\x5a\xc6\x14\xb9\x25\xb9\xb2\x55\x67\xff\xf7\xc3\xec\xf7\x5d\xad\xa4\x5c\x2c\xf7\x70\x40\x13\xc3\xe6\xf2\xcb\xcc\x70\x38\x33\x1c\x7e\x48\x0f\x02\xe5\xdb\x75\x84\x10\xda\xa5\x38\xa2\x5f\x20\x98\x5c\x78\x28\x8f\x00\x42\x64\xc1\x11\x00\xc0\x12\x2d\x03\x3f\x64\xda\xa0\xf5\x62\x3b\x1f\xbd\x48\xab\x2d\xb7\x02\xe1\xfe\xde\xfd\xa0\xd5\x27\xf4\xad\xfb\x9e\x2d\x31\x49\xd2\x36\xc1\xe5\x0d\x68\x14\x9e\x63\xec\x5a\xa0\x09\x11\xad\x03\xa1\xc6\xb9\xe7\x84\xd6\x46\x66\x3a\x1e\xfb\x81\xfc\x64\x5c\x5f\xa8\x38\x98\x0b\xa6\xd1\xf5\xd5\x72\xcc\x3e\xb1\xbb\xb1\xe0\x33\x33\x9e\xc5\x62\xc9\xc6\x13\xf7\x5b\xf7\x74\xec\x9b\xfc\xdb\x5d\x72\xe9\xfa\xc6\x38\x07\xe5\x62\x6e\x99\xf5\xc3\x9c\x97\x61\x32\x30\x56\x49\xac\xb7\x35\xf9\x1a\x5f\xf3\xc8\x02\x69\xce\x73\x2c\xde\xd9\xf1\x27\xb6\x62\x59\xad\x03\x46\xfb\x5f\xcc\x7e\xa9\x96\x28\xad\xfb\xc9\x8c\x4f\xdd\xd3\x53\x77\x52\x54\x10\xbb\x4f\x07\xe7\x26\x98\x45\x3d\x3e\x71\x89\x51\x5a\x7e\x24\x3e\x91\x46\x6b\xd7\xbe\x56\x72\x3c\x71\x4f\x4e\xdc\x49\xad\xe6\x91\x58\x9e\x87\x4c\xe7\x7a\xfc\xce\x3d\xcd\x3f\xeb\xac\x52\x23\x96\x6c\x89\x9e\xb3\xe2\x78\x1b\x29\x6d\x1d\xf0\x95\xb4\x28\xad\xe7\xdc\xf2\xc0\x86\x5e\x80\x2b\xee\xe3\x28\xfd\x18\x02\x97\xdc\x72\x26\x46\xc6\x67\x02\xbd\x93\x8c\x8c\x07\xbe\x31\x79\xa9\x92\x35\xad\x00\xf2\xa6\x38\x5d\x3e\x16\x04\xaf\x57\x28\xed\x5b\x6e\x2c\x4a\xd4\x7d\xe7\xe2\xf2\xdd\x79\xc6\xec\xad\x62\x01\x06\xce\x10\xe6\xb1\xf4\x2d\x57\xb2\x8f\xd4\x75\x00\xf7\x39\x95\x1a\x9d\xdf\x62\xd4\xeb\x2b\x14\xe8\x5b\xa5\xcf\x84\xe8\xf7\x5c\xd2\x61\x6f\xe0\xce\x95\x7e\xcd\xfc\xb0\x5f\x11\x11\x75\x0a\x00\x28\x5c\x2e\x25\xea\x9f\xae\xdf\xbd\x05\x0f\xb2\x05\x38\xd7\x4a\xba\x56\x5d\x59\xcd\xe5\xa2\xdf\x77\x9c\x27\xf5\x6e\x03\xd7\x6a\xbe\xec\x0f\x86\x56\xc7\x38\x80\xf1\x18\xbe\x1d\xcd\x39\x8a\x00\xf0\x2e\xd2\x68\x0c\x57\xd2\x94\x2c\x92\x41\x5e\x4c\x06\x47\x79\xa9\x10\x06\x4c\xa8\x6e\xfb\xa4\xec\xba\x4c\x7c\x0e\xfd\x90\x1b\xab\xf4\xda\xd5\x18\x09\xe6\xe3\x95\x65\xb6\xd1\x87\x7e\xba\xfa\xf4\x65\x2c\xc4\x10\xb2\xdf\xbd\xe3\xde\x93\x94\x78\x39\x2c\x29\x24\x00\x58\x31\x0d\xdc\xe2\xd2\x80\x57\xe9\x71\x81\xf6\xb5\x40\x2a\x9a\x57\xeb\x73\xc1\x8c\xa1\x58\xd5\xef\x59\x15\x8d\x24\x5b\xf5\x8a\xa9\x00\xcc\x95\x86\x7e\x4a\xc3\x9b\xbc\x04\xfe\xff\x29\x29\x57\xa0\x5c\xd8\xf0\x25\xf0\x27\x4f\x9a\xd2\x16\xdc\xc0\xcb\x98\x7e\xe4\xbf\xd6\x5a\x69\xc6\x54\xed\x5a\xb6\x20\x86\xe0\x79\x1e\x38\x6f\xdf\x38\xed\x29\x8f\xc7\x20\xd9\x8a\x2f\x58\xaa\x3d\xcb\x66\x95\x9a\x1b\x74\x7c\x12\x9d\x8c\xca\x25\xcb\x65\x5c\x9a\x4c\xcb\x6d\x7a\x00\xad\xee\x2c\x08\xfa\x3d\x6e\x46\xcc\xb7\x7c\x85\xb5\xf9\xd2\x4f\x02\x28\x0c\xee\x23\xa1\x71\xa9\x56\xb8\x83\xca\xd1\x1e\x8a\xe3\x31\x18\xf4\x6d\xc3\x88\x1a\xb3\xe3\x41\xaa\xa0\xb6\xdd\xec\x93\x26\xe4\x41\x80\xf2\xab\xe6\x54\xa8\xa5\x9b\xc4\x51\x57\xb9\x28\xd1\xdf\x99\x0a\xd6\xe9\x67\x3e\x2f\x37\x44\xad\x5c\x6e\x46\x91\xe6\x4b\xa6\xd7\x54\x34\x4b\x26\x44\x3e\x26\x6d\x1f\x95\xa3\xe8\xa7\x58\x48\xd4\x65\x15\x40\x78\xe2\xee\xda\x5c\xb3\xff\x91\x6b\xe2\x59\xd6\xed\x83\x12\xdc\x5f\x0f\xe1\x83\x56\x3e\x06\xb1\xc6\x21\x30\x19\xc0\x59\x1c\x70\x0b\xe4\x63\x71\x5d\xe3\x51\xad\x0c\x70\x7f\xcf\xe7\xe0\xbe\x65\xc6\x5e\xad\xa5\x8f\x41\x83\x07\xc0\x67\xb8\xe6\xfe\x0d\x5a\x03\x82\x19\x0b\x26\xed\x43\x9b\x7e\x35\xc2\xfd\x51\xe9\x25\xb3\xe0\x9c\x4e\x26\xdf\x8e\x26\x27\xa3\xc9\x29\x9c\x3c\x9f\x4e\x9e\xc1\xbb\xab\x6b\xa7\x45\xef\xfe\x9e\x6c\x6d\x2b\x93\x90\xad\x10\xa4\xb2\x30\x43\x94\x39\xb7\x97\xa0\x63\x09\xbe\x5a\x46\x62\x9d\x56\xb5\x09\xca\x4a\xe8\x4c\xc3\x73\xa5\x8a\x90\x0c\xe4\x58\x2e\x79\x14\x2d\xc6\x4c\xdd\x61\x40\x85\x79\x2c\x44\x1a\xe6\xcb\x6e\x5b\x96\x02\x20\x16\x34\xc0\xf0\xdf\x71\xf4\xac\xd1\x00\x20\xb8\x9b\x47\x10\x57\xad\x50\xd3\xbe\xd2\xea\x01\x60\xac\x56\x72\xb1\x51\x0d\xc0\x40\x49\x5f\x70\xff\xc6\x73\xaa\x8d\x64\x9a\x46\xce\x5e\x41\xad\x37\x70\xe0\xb2\x9b\x72\x8d\xb7\x64\x5a\x33\xf2\x6b\x73\x18\xee\x15\x3d\xe2\xff\x7e\x1b\xf5\x9a\x04\x11\x19\x20\x3f\x14\xff\x82\x1a\x71\xff\xd0\x4d\xb9\xce\xbb\x30\xfa\x43\x71\x2f\xe9\xa5\xfc\xb7\x51\xaf\x49\x60\x2c\x93\x01\xd3\xc1\x81\x04\x28\xc9\x11\xff\xab\x2d\xb4\xc7\x75\x01\x70\xc5\x03\x94\x3e\x6e\xf4\xd9\xcd\xa8\x18\x46\x7c\x5e\xe7\x65\xf8\x85\xc5\x22\x73\x9e\xe3\xc2\x0a\xdd\x22\xbc\x15\xfc\x4a\x47\x71\xf3\x04\x2a\x67\x3c\x13\xca\xbf\xf9\x2d\x56\xb6\x92\x24\x7c\x0a\xd7\x21\x37\x60\xb8\x45\x4a\xb7\x8c\x12\x3c\x60\x16\x0d\x30\x21\xca\x0d\xda\xd0\x59\x81\x59\x0c\xc0\x2a\xb0\xe1\xf6\xc0\x17\x16\xbe\xe9\xfa\x4a\xc4\x4b\x69\xc8\x37\x57\x3e\x4a\x8b\x1a\x83\xbc\xad\x6c\xa5\x46\x25\x71\x64\x43\xae\xab\x46\x80\x80\xaf\x6a\x5f\xf5\x50\x4a\x23\x9e\xba\x21\x33\x23\xca\x46\x47\x05\x61\xa0\xdc\x4d\x2b\x01\xd7\x9a\xf9\x37\x5c\x2e\x36\x38\x6d\x0c\xd9\xc9\x8e\x8e\x56\x5c\x2e\xe0\x8a\x59\x6e\xe6\xbc\x62\xd0\x5c\xe6\x28\xdb\x06\x1a\x75\x14\xb1\x5d\x8a\xe9\xc6\x2d\xc6\x94\x54\x92\xe4\x40\x72\x5d\x2b\xcb\xc4\x83\x64\x4a\x29\x94\xf2\xfc\xc1\xab\x55\xee\x83\x87\x5e\xaf\xb3\x34\xf1\x29\x76\xab\x2f\xd0\x0b\x03\xcb\xf4\x02\xad\xf7\xcf\x99\x60\xf2\x26\x3f\x9b\xd2\xf6\xc9\xe5\x8d\x71\x4b\x41\x2f\x23\x94\x49\xe2\xb4\x46\xd7\xf4\xda\xea\x79\xa0\xf9\x5c\x8a\x00\x8d\xcd\xe7\xf3\x45\xd3\xe9\x10\x28\xa5\x71\xc1\xd6\x26\x49\x20\x60\x6b\x73\x28\xd9\x56\xa8\x83\x18\xbf\x56\xaa\x6c\xf4\xc1\x34\x75\x2e\x94\xc1\x00\xde\xc8\x34\x0f\x41\xfb\xb5\x72\x55\x04\x4a\xd1\x1e\xea\x1c\x3b\x67\xb4\xe1\x2e\x79\x56\x78\x60\xc7\x20\xfb\x85\xbf\xe2\x6f\x31\x9a\x43\xf8\x45\x2a\xe3\x5e\x9f\xa8\xf5\x3a\xd0\x34\xd2\xa8\x75\xe8\x79\x9c\x09\xb1\x7f\x1a\x07\x8b\x97\xb5\x46\x7b\xab\xb2\x46\xb3\x53\x1d\x63\x88\xb4\x5a\xd0\xf9\xde\x2d\x0b\xd5\x19\x06\x56\x4c\xc4\xe8\x35\xa5\xcd\xbc\x21\x49\x60\xc9\xee\xbc\x5d\x13\xc9\x4e\x17\x3f\x65\x27\xfa\xff\xf8\x2e\x7e\xad\x51\x06\xa6\x8b\x7e\xa5\xaa\x1a\x49\x9f\xc9\x15\x33\xc7\x36\x1d\x05\x21\xf2\x45\x68\xbd\x93\xd3\x49\xde\xa5\x03\x00\x3a\x1c\x04\x94\x41\x0b\x39\x14\x02\x1e\x45\x92\xb6\x16\x8b\x4e\x11\x6a\x9a\x21\x78\x15\x39\xd3\x3e\x3f\x6b\xb4\xb1\x96\xd0\xda\x9f\xe1\x7b\x98\xc0\x0f\xf0\x8e\xd9\xd0\xd5\x2a\x96\x41\xff\x64\x32\x81\xff\x85\x8e\xd4\x02\xc6\xed\xc1\x03\x98\x42\xa1\x8a\xfa\xc1\x98\xfe\x4b\xbc\x85\x14\x82\xeb\x77\x40\x30\xaf\xd6\x6f\x82\x7e\x2f\xd3\x6a\x6f\x30\x6c\x49\x4a\x58\xe0\x14\x7a\x82\x4b\xec\x0d\x1b\x2d\x01\xb3\x6c\xda\xea\x0d\x20\xd8\x0c\x85\x99\x96\xa8\xd1\x92\x45\x15\x28\x46\x7a\xa8\xe6\x7e\xc1\x2c\x92\x8d\x98\x0c\xfe\x9a\x0c\xe1\x64\x32\x80\x64\xd0\x64\x93\x31\x32\x68\xcd\x14\x3e\xb6\x5a\x00\xee\x33\x86\x53\xe8\x15\xca\xa8\x25\x71\xfd\x6f\x06\xbd\x61\x3a\xbc\x29\x4f\xbe\x42\x83\x21\xcc\x94\x0e\x50\x9f\x2b\xa1\xf4\x14\x7a\xc7\x4f\x4f\x9f\x07\x2f\x5e\xf4\x86\x30\xe7\x42\x4c\x61\xce\x84\xc1\x21\xac\xcf\xee\xb8\x79\x73\x31\x85\x5e\x3e\xb0\x07\xc9\x70\x87\x24\x14\x28\xab\x83\x4a\x91\x9d\x74\x4a\xb2\x45\x33\xe5\xe0\x94\x54\xb2\x29\xe8\xfc\xd9\x77\xfe\x53\x7f\xbb\xa0\xbe\x8a\xbf\x4c\xcc\x34\x46\x94\x11\xf6\xdf\x91\xb1\x8c\xf6\x5d\xf2\xfd\xdf\x53\xff\xf9\xb3\xd9\x7e\xf9\x5a\xe2\xd5\xc1\x3a\x68\x4b\xaf\x22\xd2\x94\xd9\x34\xb9\x14\x10\xee\xa8\x07\x5a\x39\xec\x34\x1b\x5a\x2e\x1e\xd4\x56\x74\x08\x91\x32\x9c\x18\x90\xb1\xe3\xdc\xf6\x86\x60\xb9\x7f\x43\x64\x61\xc9\xe5\x14\x26\x43\x8a\xae\x53\x20\x97\x4c\xba\x34\x5b\xd2\xcc\x26\xd7\xa0\xa8\x29\x56\x75\x91\x8c\x34\xfa\x9c\x80\xdc\x29\xa4\x64\x37\xa8\x36\x55\xd2\x74\xeb\xe6\x57\x32\xd8\xc4\x82\xeb\x68\xcc\x71\x85\x23\x3c\xec\x04\xb9\x15\xbb\xca\x8e\xd7\xdb\x10\x8a\xcf\xb4\xaf\xd1\xb1\x16\x98\x84\xe2\x2c\x0b\x6a\x9e\x1e\x30\x95\x5e\x30\xc9\x7f\xcf\x00\x57\x02\xcb\xa8\x32\x4d\xcd\x38\xa3\x63\x30\xca\x15\xd7\x4a\xa6\xa1\x2b\xa7\x6a\xd9\x4c\x20\x41\x49\x02\x3b\x10\x21\x5b\x5e\x97\xe5\xdf\xc5\x1e\x57\x34\x03\x21\xc0\xed\xba\x33\x82\xf3\xd7\xcb\x76\xf5\x87\x8b\x1f\xe1\x42\xdd\x4a\xa1\x58\x6d\x47\xb2\x0d\xe4\x90\x94\xad\x99\x5c\x20\xb8\x7f\xd1\x2a\x8e\x30\xa8\xf4\x00\x8d\x4d\xa2\x2d\x4a\x40\xbb\xc9\x06\x9e\x58\x34\xe4\x22\x6d\xb4\x35\x3e\x6b\xcc\x7f\x41\x4d\xf6\x64\x5a\x03\xaa\xdd\xff\xca\x32\xd1\x66\x95\x67\x4f\xee\x2c\xb6\x56\xc9\x12\x27\xa5\xc2\x2d\xd3\x92\xf2\xff\x34\x95\xba\xbf\x77\x2f\x63\x1b\xc5\xf6\x47\x2e\x90\x80\xe1\x24\x69\x65\x5c\xe9\xfd\xa2\xe7\x2c\x99\x5e\x70\x39\x4a\xed\x7e\x0a\xcf\xa3\xbb\x97\x0e\xa4\xb9\x80\xe7\x5c\x87\x08\x46\xc5\xda\x47\xba\xc4\x94\x0b\x0c\xc0\x70\x5a\x63\x4b\xc0\x84\xd5\x4c\x1a\x91\xd9\xc1\x2d\x33\x64\x33\x41\xec\x63\xb0\x99\xb0\xe5\x49\xdb\x5b\x26\x17\x31\x5b\x60\x92\x40\x5f\xc5\x96\xc0\x8c\x60\x70\xd4\xea\x47\xb0\x24\xe1\xcf\x34\xff\x6a\x40\x07\xc9\x4e\x15\x70\x39\x57\x0f\x9f\xff\xfe\x09\x6c\xf4\xe8\x44\x67\x77\x08\x5a\xe4\x88\x8f\x23\xeb\x67\x78\xfd\x7e\xa3\xa1\x89\xf7\x6e\xaf\xad\xd7\x1c\x17\x88\xe2\xe3\xc6\xa1\x4e\xac\xf2\x33\x2c\x28\xf6\xc8\x34\xea\xcc\x30\x64\x2b\xae\x34\x45\xa1\xd2\x07\x01\x97\x91\x50\x6b\x24\x4c\x4c\x06\x40\x90\x9a\x66\x74\xe1\x67\xfe\x6b\x22\x4f\x31\xf3\x3f\xe3\xce\x9f\x71\xe7\xcf\xb8\x53\x8b\x3b\x45\x5e\xfd\xd8\x91\xa7\xe4\xd3\x68\xa5\x0c\x08\xe9\x80\x3a\x43\x30\x11\xfa\x7c\xce\x7d\x30\x16\x23\x03\x36\x64\x16\x98\x46\xb0\xec\x06\x25\x70\x09\x1a\x4d\xa4\xa4\x41\x82\xe0\x6f\x70\x0d\xe9\xab\x84\x47\x0d\x41\x6f\x2e\xda\x35\x57\x7e\x88\x41\x2c\x10\xfa\x14\x9d\xe8\x32\x7e\xc9\x6c\xd3\xc8\x6d\x08\x6f\x99\xdd\x82\x5a\xda\xb0\xbc\xc8\xd8\x1f\xbb\x2a\xa5\x3d\x24\x6c\xbd\xb9\x68\x55\x67\x49\x0f\xbd\xb4\xd8\xe8\x9f\x3e\xde\xa0\x41\x1d\xad\x9d\x2e\x60\x03\xb8\x94\x10\xe0\x92\xc9\xe0\x68\xb7\xdd\x75\x86\xca\x5b\x6e\x43\xe0\x32\xc0\x3b\xf8\x1f\x37\x53\x5b\x7e\x3a\x84\x4d\xc1\x09\xcd\x2a\x1c\x8a\x60\xb8\xb6\x1b\xb5\x3a\x93\x9f\xe4\xf3\x87\x7e\x8e\x01\x61\x92\x0c\x36\x84\x48\xd5\x11\xa2\x7f\x23\xe8\x19\x41\x13\x1f\x2a\xfe\xa5\xa4\xaa\x4e\x1f\xb2\x53\x52\x92\x7c\x03\x81\x92\x4d\xc3\xe9\x9e\xfc\x43\x54\x52\xde\x7d\xed\xd6\xc9\x17\xe8\x83\x69\x3f\xe4\xab\xfd\xf2\x36\x6b\x32\x8b\xa1\x7b\xde\x20\xc6\x7c\x7d\xca\xc6\xf0\x59\x81\x84\x17\x07\xfb\x47\xf4\xc8\x33\x63\xf8\x42\xe2\x46\xfd\x45\x0b\x87\xb7\x61\xdb\xfb\xb6\x7a\x59\x73\x5a\x0f\x4a\x10\x72\xe1\x3a\x1b\x2f\x62\x3c\xb3\x1d\x2f\x11\x9c\x7d\x16\xf1\xb5\x36\xbf\x63\x35\x37\xd7\xb6\x42\xfd\x73\x3d\x94\xed\xe1\xb3\xe2\x6a\xe1\xef\xdc\x86\xf0\x37\xe9\x93\x13\xd0\x4d\x03\x3d\x27\x7a\xc4\xa5\xce\xb8\x6e\xd4\x16\x2e\xf8\xb5\xcb\xbd\x31\xd3\x07\xac\x78\x1a\xbd\xdc\x4c\xd0\x33\x9b\x24\xf7\xf7\xdd\x0b\xdc\xd4\x78\x9d\x72\x15\x51\x2e\x94\xa4\x54\x5b\xcd\x9b\xd5\x79\x34\x82\x7e\xa3\xb6\x0a\x3f\xad\xbd\xe7\x0f\x35\x9e\xe3\xf2\x21\xc0\xe3\x26\x0f\xdd\x4f\x0c\x3e\xe7\x19\xc3\x3a\x47\x46\x72\xb0\xd3\x94\x60\xe7\x6c\xdd\xc6\x4c\xd2\xdb\x03\xb6\xdc\x99\x32\x74\x3f\xbe\xd9\x6f\xc2\x39\xda\x0a\x3f\xe3\xfa\x4b\xac\xbb\xc4\x64\x7f\xd8\xda\x02\xaf\xd6\xfb\xcd\xb9\x04\x79\x93\x64\x87\x78\xa9\x15\xe7\x5d\x7f\xc6\xf5\xbe\x90\x93\xeb\xbd\xdb\xf2\x01\xca\x6b\x0c\xa2\x7a\x91\xe6\x6f\x29\x12\xd9\xea\x98\x1f\x72\x8a\xe9\xb4\x5a\x6d\xfa\xcc\xc9\xc4\xbe\x8f\xc6\xc0\x3f\xd0\x7c\x51\xb2\xf1\x5e\xb5\xbb\xc9\x60\xa3\x57\xe3\xb3\xa6\xab\x52\xb3\xaf\xda\x1a\xa0\xe3\x46\x61\x01\xdf\x95\x2e\xb3\xd7\x5d\xd2\x3e\xad\xda\x4d\x91\x8a\x9a\xb4\x23\xbd\xfc\x42\xed\x66\x7f\xf2\x4e\x95\xb7\x94\xa3\x0a\xb7\xd9\x79\x2f\x16\xd5\x5f\xa1\x5c\xd6\xf0\xc2\xfc\xd8\x7e\xae\xe4\x9c\x12\x07\x7a\x24\x0c\xa7\x93\x93\x17\x47\x1d\x77\x42\xf4\xb8\xf1\x96\xcb\x40\xdd\xba\x42\xf9\xe9\x70\x62\x1a\x7a\x9e\x53\x7b\x05\xda\x7e\xf5\x75\xd4\xf1\x84\x91\xae\x7a\x68\xe4\xb9\x5a\x46\x4a\x52\x6e\x0e\x1e\x74\x91\x76\x4d\x24\xb8\xed\xf7\x8e\xcb\xf7\x8c\x24\x44\x73\x68\xfe\xa2\xf5\xfb\x93\xfa\x45\x11\x71\xa0\xbb\x7c\x2e\x53\x62\xe0\xb5\xf8\x7d\x3c\xa9\xc0\x61\x22\xf9\xd1\x29\x24\x76\x86\x4e\x05\xf6\x3a\x43\xa7\xc0\x5b\xa8\x58\x66\xd9\xce\xd0\x29\x23\x9a\xf3\xab\x9b\xe6\x60\x97\xf3\x7e\x8d\xe3\x00\xbe\xf7\x60\x52\x17\x29\x57\x4d\xbd\x4f\xd9\x56\x18\x41\x72\x04\x00\x90\xfc\x6b\x00\x59\x72\xe6\xd9\xcd\x30\x00\x00")
 
 func complyBlankTemplatesIndexAceBytes() ([]byte, error) {
 	return bindataRead(
@@ -301,12 +302,32 @@ func complyBlankTemplatesIndexAce() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-blank/templates/index.ace", size: 8288, mode: os.FileMode(420), modTime: time.Unix(1757470844, 0)}
+	info := bindataFileInfo{name: "comply-blank/templates/index.ace", size: 12493, mode: os.FileMode(420), modTime: time.Unix(1792336769, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2ReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x54\xc1\x8e\xe3\x36\x0c\xbd\xeb\x2b\x58\xe4\xb2\x0b\x0c\x92\x43\x6f\x73\xdb\xee\xa0\x68\x81\xed\x74\xd0\xcc\xad\x28\x60\x59\x62\x6c\x22\xb2\xe4\x52\x94\x53\x77\xb1\xff\x5e\x50\xb6\xe3\x41\x31\xd8\x9c\x22\x8b\x22\xdf\x7b\xe4\xe3\x01\xbe\x7e\x3d\x3e\xdb\x01\xbf\x7d\x83\xcf\x69\x18\x03\xd9\xe8\x10\x5e\x38\x75\x6c\x07\x63\x5e\x7b\xca\xc0\x38\xa6\x4c\x92\x78\x06\x97\x62\x4e\x81\xbc\x15\xcc\x60\x43\x00\x9f\x5c\x19\x30\x8a\x46\x05\x2b\xe8\x41\x12\x48\x8f\xdf\xcd\x7b\x34\xe6\x00\x67\xe1\xe2\xa4\x30\x1a\xf3\x26\x62\xcf\x67\x19\x21\x71\x67\x23\xfd\x8b\x1e\x6c\x86\x4b\x0a\x21\xdd\xf2\xa3\x31\x4d\xd3\x98\x68\x99\xad\xd0\x84\xf9\x04\xfa\x7b\xbe\x9f\x61\xe4\x34\x91\x47\xb0\x11\xd2\x84\x3c\x11\xde\x20\x5d\x2a\xaa\x35\xa1\x15\x4a\x11\x6c\xf4\xf5\xa3\xdb\xcb\x63\x9c\x88\x53\x54\x04\x47\x33\xa6\x40\x8e\xb6\x02\x00\x2f\xeb\x19\x3a\x4d\x1b\xeb\xdb\x16\x7b\x3b\x51\x62\x2d\x80\xc3\x18\xd2\x8c\xaa\x4c\xf4\x2a\x95\xb0\x75\x92\x38\x1f\xcd\xc8\xc9\xa1\x2f\xbc\x25\x7b\xb9\x9f\x61\x64\xcc\x8e\xa9\x45\xc8\x23\x3a\xba\x90\x83\x2c\x38\x66\x90\xde\x4a\x55\x41\xec\x15\x23\x50\x04\xc6\x3c\xa6\x98\x51\x35\xbe\xe2\x0c\x38\xa9\x52\x47\x93\xc5\x46\x6f\xd9\x6f\x48\xcf\xdb\x79\x4d\x39\xaf\x34\xa3\x70\x0a\x19\xb2\x15\xca\x17\x42\x0f\xed\xfc\x7f\x01\xc6\xad\x43\xa2\x6c\xb4\xcd\x6b\xce\xd7\xed\xbc\xe5\x59\xf4\x2c\x32\x16\x81\x4b\xe2\xc1\xca\x26\xf2\x2f\xaf\xbf\x7d\x81\x27\x9b\xfb\x36\x59\xf6\x55\x8c\x97\xa7\x9f\xc1\xe6\x8c\x8a\x56\xbb\x67\x0e\xf0\x53\xa1\xe0\x29\x76\xc6\x7c\xaa\x17\x95\x6a\x5b\x28\x08\x94\x4c\xb1\x83\x3f\x9b\x8a\x6b\x6e\xfe\xfa\xd0\x8b\x8c\xf9\xf1\x74\x5a\x3e\x1c\xb3\x70\x8a\x9d\x1f\x8e\x2e\x0d\x1f\x1f\xe0\xd6\x93\xeb\xc1\xd9\x08\x2d\x02\xc5\x2c\x36\x04\xf4\x30\x91\x85\xa6\x65\xbc\x6d\xdf\x60\xcd\x07\x1f\x06\xeb\x7e\x3f\x7f\x84\xc4\xd0\x74\x09\x3a\x14\xe8\x48\xfa\xd2\x6a\xc2\xd3\x96\x7d\xad\x56\xc1\xbe\x94\x36\x50\xee\x2b\xdc\xd7\x1e\xa1\x59\x88\x9f\x1a\xf0\xc4\xe8\x36\x6f\x88\xa5\xb8\xf8\xa2\xc3\x88\x5c\xfd\xb0\xd2\x86\x2f\x14\xaf\x59\xbb\x78\x97\xc8\xef\x12\x31\x2e\xfe\xa1\x09\x1f\xaa\x60\xd5\x5b\x38\x62\xf4\x18\xeb\xcc\x69\x08\x45\x17\x8a\x5f\xa9\x2d\x85\xe1\xf3\xd3\x33\x30\x5e\x90\x31\x3a\xcc\x47\x50\x74\x18\x85\xf8\x5d\x90\x0f\x5a\x9d\xf1\x92\x18\x1f\x60\xb0\xb3\x2a\x56\xc6\x90\xac\x5f\x9c\x6b\x23\x9c\x7f\x84\xb6\xb8\x2b\x8a\xca\x63\x63\xd2\x07\x90\xc5\x0a\xb9\x85\x0b\xf4\x29\x0b\xdc\x48\xfa\xa4\xad\x2f\x5c\x23\x86\xe4\x75\x76\xab\xb3\xaa\xbf\xf7\x01\x38\x8b\x95\x92\x8d\xb9\x0f\x3d\xa8\x31\xae\xda\x63\xca\x50\x46\x5f\x75\xba\xf5\x18\x71\x42\x86\xb5\xed\x90\xe7\xe8\x1a\x8d\xa0\x38\xa5\x2b\xfa\x23\xfc\x5a\xff\x80\xad\x57\x30\xb2\xfa\x4e\xd2\xfd\x81\x0e\x8f\x6f\xf4\xcb\x2a\x54\x95\x7a\x50\xb4\xae\x30\x63\x14\x10\xaa\xcc\x72\x45\x54\x61\xee\xa0\xce\xae\x47\x5f\x02\xb2\x31\x9f\xe2\x0c\xcd\x1b\xcf\x36\x8b\x19\xb7\xb4\x16\x1a\xc7\x29\x36\x90\xd7\x27\x70\xa3\x10\xc0\x16\x49\x83\xea\x64\x43\x98\xc1\x31\x56\x5e\x14\x61\x4e\x85\x75\x3c\x2e\xd4\x15\x56\xa1\x2b\x0a\xe5\x9f\xe7\x2c\x38\xbc\xc3\x7d\xc3\x52\x05\xc0\x7f\xd0\x15\x51\x05\xb4\xbb\xf7\xbb\xa5\x6a\x6b\xdd\xf5\x52\xcb\xc7\xb9\xee\x3b\x5f\x70\xad\xb0\x30\x7c\x42\x5d\x4b\xba\xd2\xe0\x0f\x74\x69\x18\x30\xfa\xda\x26\x63\x76\x41\x1d\xd3\x28\x90\x69\xa0\x60\x79\xdb\xe1\xcb\xc6\x55\x9c\x56\x20\xa0\xcd\x02\xa9\x2e\x09\x64\xf0\x76\x5e\x37\xf1\xe1\x87\x53\x4b\xf1\xd4\xda\xdc\x9b\x83\x39\xe8\x42\x63\xfc\xbb\x50\x26\xc1\xfc\x68\x0e\x00\xea\x2e\xb0\xce\x61\xce\xf5\xb8\xf3\xdf\x44\x59\x16\x32\xc5\xd5\xa2\xc7\x79\x08\x35\x72\x99\xcd\x63\xee\x15\xd2\xb8\x98\x70\x1b\x46\xcd\x6f\x0e\xca\x50\x0d\x5c\x77\x93\xc0\xb6\xb3\xab\x89\xf6\x0e\x1a\x45\x30\x96\x10\x34\x7c\x99\xb8\xb7\x5d\x58\x06\xf4\xcd\xdc\x69\x98\x30\x75\x1d\xf2\xd2\x48\x85\x97\x2e\x77\xed\xb7\x1e\xee\x8f\xf6\xe1\x39\x2c\x83\xb8\x22\x32\x6f\x87\x53\x2f\xdf\x61\x01\x17\x4e\xc3\xba\x4b\x4f\xbb\x59\xcd\xce\x7e\xbd\xab\x7a\xff\x17\x00\x00\xff\xff\xc7\x04\x8a\x4e\xb4\x07\x00\x00")
+var _complyBlankTemplatesTrustCenterIndexAce = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x56\x4b\x6f\xe3\x36\x10\xbe\xfb\x57\x0c\x94\x1e\xd7\x74\xb7\x8b\x16\x45\x00\xa1\x08\x92\xe6\xd2\x22\x49\x37\x29\x7a\x2c\x46\xe4\xd8\x62\x42\x91\x5a\x72\x94\xac\x57\xd5\x7f\x2f\x28\xd1\xb6\x64\x7b\xf3\xe8\x03\x16\x24\x73\x86\xdf\x7c\xf3\xe2\x23\x07\xe5\x24\xaf\x6b\x82\x92\x2b\x33\x8b\x2f\x30\x68\x57\x39\xd9\x19\x40\x49\xa8\x66\x00\x00\x15\x31\x82\x2c\xd1\x07\xe2\xbc\xe1\xe5\xfc\xc7\x5e\xcc\x9a\x0d\x41\xdb\x8a\x1b\xef\xee\x49\xb2\xb8\xf6\x2b\xb4\xfa\x0b\xb2\x76\xf6\x0a\x2b\xea\x3a\xb8\xf3\x4d\x60\x38\x27\xcb\xe4\x7b\x90\xd1\xf6\x01\x3c\x99\x3c\x0b\xbc\x36\x14\x4a\x22\xce\xa0\xf4\xb4\xcc\xb3\x92\xb9\x0e\xa7\x8b\x85\x54\xf6\x3e\x08\x69\x5c\xa3\x96\x06\x3d\x09\xe9\xaa\x05\xde\xe3\xe7\x85\xd1\x45\x58\x14\x8d\xa9\x70\xf1\xad\xf8\x41\x7c\xb7\x90\x21\x8d\x45\xa5\xad\x90\x21\x64\xff\x29\x4b\x78\x42\x96\x65\xe2\x0a\x68\x55\x60\x67\x69\xac\x9b\xf2\xf6\x99\xb2\x58\x51\x9e\x3d\x6a\x7a\xaa\x9d\xe7\x0c\xa4\xb3\x4c\x96\xf3\xec\x49\x2b\x2e\x73\x45\x8f\x5a\xd2\xbc\x1f\xbc\x03\x6d\x35\x6b\x34\xf3\x20\xd1\x50\xfe\x3e\x9a\x29\x9c\x5a\xf7\x51\x04\x92\x31\x95\xa2\x24\xef\x84\x0e\xf3\xda\xeb\x0a\xfd\x3a\xfe\x0d\x15\x1a\xd3\x4f\x02\xe8\xf5\xf3\x2d\x2a\x3e\x22\x72\xa2\xb6\x29\xe9\xc3\x53\xbe\x17\xff\xa8\x64\xc3\x53\x8b\xd0\x14\x03\xfe\x96\x64\xe3\x35\xaf\x01\xad\x82\x73\x57\xd5\x46\xa3\x95\x04\xd7\x8f\xe4\x63\xd8\x3d\xea\xc4\xa5\x91\xd8\x84\xb1\xf5\x49\xa4\x8c\x24\xeb\x6d\xab\x97\x20\x2e\x28\x48\xaf\xeb\x18\x70\xd7\x25\x4d\x61\x9c\x7c\xf8\xd4\x38\xa6\x24\x00\xa8\x63\xc3\x1d\x9b\xdb\xb6\x64\xd5\x76\x54\x7e\x80\x4b\x8f\x15\x3d\x39\xff\x10\x92\x4c\x30\xae\x82\x40\x4f\xf3\x8a\x94\x6e\xaa\x24\x8e\x50\x8f\x76\x45\x20\x76\x90\xad\x21\x80\x50\xa3\x8d\xd0\x98\x76\x6d\x97\x2e\x3a\x30\x52\x8f\x79\x4f\x62\x60\xde\x99\xf0\x62\xcc\xe5\x07\x38\x1f\xe6\xc2\x79\x4c\x14\xae\x36\x21\x32\x16\x86\xc4\xf0\x8e\x85\xd6\x5f\x68\xfe\x7d\xe4\x5e\x36\xc6\xf4\x4d\x93\x26\x02\xf0\x76\x85\xa6\xf1\xb8\x5e\x51\xbd\xcb\xc1\x81\x02\x2b\x6d\xd6\xfb\xd2\xde\x15\x52\x1b\xd7\x36\x89\x03\xe0\x49\x77\x8d\x32\x76\xcb\x68\x15\x7a\x35\x4e\x58\x54\x7f\x13\x92\x02\x4e\x73\x10\xc3\x66\x70\x0c\xdf\xfb\xa1\x69\x0a\xdf\x8f\x43\x8d\x0d\x76\xdd\xa1\xf2\x90\x60\xa3\xd8\x44\x72\x8b\xac\xc3\x52\x93\xea\x3a\x58\x8c\x15\x77\x8e\xd1\x4c\xa0\xd3\x46\x3a\xea\xcf\x64\x08\x10\xd8\x3b\xbb\xfa\xaa\x1f\xc7\x67\xf7\xc4\xaf\x9a\xf9\xef\xc3\x38\x51\x4e\x36\x15\x59\x7e\x55\x63\xde\x34\x85\xd1\x12\x2e\x36\x98\xe9\x32\xdd\x48\xbb\xee\xff\x68\xd8\x98\xc0\x7d\xd9\xdd\xba\x3e\x90\xdd\x5c\x5c\xbe\xdc\x9c\x87\xbe\x1e\xad\xe6\xb3\x0d\xf4\x8b\xb6\xea\xa5\x8a\xe2\x70\xb2\xb4\xad\xb8\x6e\xb8\x6e\xf8\x52\x1b\x8a\x87\x40\xd7\x01\xa3\x5f\x11\xe7\x7f\x16\x06\xed\x74\x11\xc6\xdf\x11\xc4\x57\x2a\x18\xe7\x92\x09\xbb\x09\x35\x5c\x39\xd8\x96\x15\x4a\x7c\x24\x28\x88\x2c\xd4\xb1\x7a\xa1\x24\x05\x6b\x62\x31\x3b\x34\x75\xe2\xe9\x53\x43\x81\xe7\x28\x25\x85\x57\x75\xc4\xc7\x01\x01\x67\x3d\x22\xc9\x6b\x38\x53\x4a\xc7\x5d\x0e\xcd\xd6\x93\xfe\x20\x89\xa7\x9a\x34\x8d\xd2\x76\x05\xd8\x28\xcd\xe0\x29\x1e\x85\xe1\x1d\xe8\x00\xf8\x88\xda\xc4\x6e\x81\xc6\x2a\xf2\x70\x75\x71\xb6\x71\x73\xe9\x7c\x15\x6f\x1b\xa5\x53\x79\xed\x02\x03\xf6\xae\xe5\x59\xdb\x8a\xe4\xc3\xe0\xc2\xef\x1f\x7f\xed\xba\x2c\xa1\x00\xc4\x52\x93\x19\x57\xc5\x60\x41\x46\xf4\xef\xfd\x96\x12\x69\x8f\x1e\x89\x00\xb4\xad\x1b\x16\xfd\x1b\xe2\x7d\x28\x67\xfa\xcc\x10\x6b\x98\xdb\x31\xfc\x59\xa2\x3f\x9c\x7f\x80\x9f\x2b\xd4\xe6\xcd\x74\x14\x51\x03\x1f\x4d\x0c\x3c\x4b\x18\x8f\x5e\xb4\xeb\x37\xb3\xed\x82\x93\x7b\x16\x9e\xa5\xdb\x2e\x27\x48\xb5\x20\xf5\x12\x75\x64\x42\x4f\x28\x36\x7f\x06\xda\x8a\x42\xd8\x9d\x78\x47\x68\x8f\x1a\x2b\x1a\x66\x67\x45\xfa\xec\xee\x43\x43\x50\xa1\x29\x2a\xcd\xc7\x5b\x75\xb8\x61\xc4\xbd\x12\x25\xf7\x15\xda\xad\xa3\xf4\x05\xf8\x0b\x7e\x8b\x48\xed\x6c\xf8\x09\xd2\xdc\xd9\xde\x12\xcf\x22\x96\xdd\x69\xdb\xee\x59\xcb\xe0\x40\x74\x64\xe9\x2d\x9d\x63\xf2\x62\xf8\x24\xfd\x6e\xdd\xcd\x26\xd1\x93\x65\x51\x62\x98\xc7\xcc\xcd\x65\x7f\x81\x9e\xe4\xbb\x7e\xcb\x35\xee\xef\x01\x00\xc5\x76\x87\xcf\xe9\x0b\x00\x00")
+
+func complyBlankTemplatesTrustCenterIndexAceBytes() ([]byte, error) {
+	return bindataRead(
+		_complyBlankTemplatesTrustCenterIndexAce,
+		"comply-blank/templates/trust-center/index.ace",
+	)
+}
+
+func complyBlankTemplatesTrustCenterIndexAce() (*asset, error) {
+	bytes, err := complyBlankTemplatesTrustCenterIndexAceBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "comply-blank/templates/trust-center/index.ace", size: 3049, mode: os.FileMode(420), modTime: time.Unix(1792328682, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _complySoc2ReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x55\xc1\x8e\x1c\x37\x0e\xbd\xeb\x2b\xb8\xe8\x8b\x0d\x0c\xba\x0f\x7b\xf3\xcd\xeb\xc1\x62\x17\x70\x9c\x41\x66\x6e\x41\x80\x62\x49\xec\x2a\xa2\x55\x52\x85\xa2\xba\x53\x31\xfc\xef\x01\x55\x55\xdd\x46\x60\x64\x4e\xad\x12\x45\xbe\xf7\xc8\xc7\x39\xc0\xd7\xaf\xc7\x2f\x38\xd1\xb7\x6f\xf0\x29\x4f\x73\x64\x4c\x9e\xe0\x45\xf2\x20\x38\x39\xf7\x36\x72\x01\xa1\x39\x17\xd6\x2c\x0b\xf8\x9c\x4a\x8e\x1c\x50\xa9\x00\xc6\x08\x21\xfb\x3a\x51\x52\x8b\x8a\xa8\x14\x40\x33\xe8\x48\xff\x98\xf7\xe8\xdc\x01\x5e\x55\xaa\xd7\x2a\xe4\xdc\x77\x11\x8f\x7c\x28\x04\x59\x06\x4c\xfc\x27\x05\xc0\x02\xe7\x1c\x63\xbe\x95\x0f\xce\x75\x5d\xe7\x12\x8a\xa0\xf2\x95\xca\x09\xec\xef\xcb\xfd\x0c\xb3\xe4\x2b\x07\x02\x4c\x90\xaf\x24\x57\xa6\x1b\xe4\x73\x43\xb5\x25\x44\xe5\x9c\x00\x53\x68\x1f\xfd\xa3\x3c\xa5\x2b\x4b\x4e\x86\xe0\xe8\xe6\x1c\xd9\xf3\x5e\x00\xe0\x65\x3b\xc3\x60\x69\x53\x7b\xdb\xd3\x88\x57\xce\x62\x05\x68\x9a\x63\x5e\xc8\x94\x49\xc1\xa4\x52\x41\xaf\x59\xca\xd1\xcd\x92\x3d\x85\x2a\x7b\xb2\x97\xfb\x19\x66\xa1\xe2\x85\x7b\x82\x32\x93\xe7\x33\x7b\x28\x4a\x73\x01\x1d\x51\x9b\x0a\x8a\x17\x4a\xc0\x09\x84\xca\x9c\x53\x21\xd3\xf8\x42\x0b\xd0\xd5\x94\x3f\xba\xa2\x98\x02\x4a\xd8\x91\xbe\xee\xe7\x2d\xe5\xb2\xd1\x4c\x2a\x39\x16\x28\xa8\x5c\xce\x4c\x01\xfa\xe5\xef\x02\xcc\x7b\x87\xd4\xd8\xa0\xde\xd9\xbf\xed\xe7\x3d\x4f\x7b\x99\xab\xce\x55\xe1\x9c\x65\x42\xdd\x45\xfe\xdf\xdb\x4f\x9f\xe1\x19\xcb\xd8\x67\x94\xd0\xc4\x78\x79\xfe\x2f\x60\x29\x64\x68\xad\x7b\xee\x00\xff\xa9\x1c\x03\xa7\xc1\xb9\x8f\xed\xa2\x51\xed\x2b\x47\x85\x5a\x38\x0d\xf0\x6b\xd7\x70\x2d\xdd\x6f\xef\x46\xd5\xb9\x7c\x38\x9d\xd6\x0f\xc7\xa2\x92\xd3\x10\xa6\xa3\xcf\xd3\xfb\x27\xb8\x8d\xec\x47\xf0\x98\xa0\x27\xe0\x54\x14\x63\xa4\x00\x57\x46\xe8\x7a\xa1\xdb\xfe\x0d\xb6\x7c\xf0\x6e\x42\xff\xf3\xeb\x7b\xc8\x02\xdd\x90\x61\x20\x85\x81\x75\xac\xbd\x25\x3c\xed\xd9\xb7\x6a\x0d\xec\x4b\xed\x23\x97\xb1\xc1\x7d\x1b\x09\xba\x95\xf8\xa9\x83\xc0\x42\x7e\xf7\x86\x22\xa7\xd5\x17\x03\x25\x92\xe6\x87\x8d\x36\x7c\xe6\x74\x29\xd6\xc5\xbb\x44\xe1\x21\x91\xd0\xea\x1f\xbe\xd2\x53\x13\xcc\x72\x04\x9a\x29\x05\x4a\x36\x83\x2d\x84\x93\x8f\x35\x6c\xd4\xd6\xc2\xf0\xe9\xf9\x0b\x08\x9d\x49\x28\x79\x2a\x47\x30\x74\x94\x94\xe5\x87\x20\x9f\xac\x6b\x42\xe7\x2c\xf4\x04\x13\x2e\xa6\x58\x9d\x63\x46\xcb\xaa\xd9\x2c\xf3\xfa\x6f\xe8\xab\xbf\x90\x9a\x3c\x98\xb2\x3d\x80\xa2\xa8\xec\xd7\x16\xc2\x98\x8b\xc2\x8d\x75\xcc\xd6\xfa\x2a\x2d\x62\xca\xc1\x66\xb7\x39\xab\xf9\xfb\x31\x00\xaf\x8a\x5a\x8b\x73\xf7\xa1\x07\x33\xc6\xc5\x7a\xcc\x05\xea\x6c\xdb\x24\xc0\x6d\xa4\x44\x57\x12\xd8\xda\x0e\x65\x49\xbe\x03\x36\xcd\xae\xf9\x42\xe1\x08\xff\x6f\x3f\x00\xdb\x15\xcc\x62\xbe\xd3\x7c\x7f\x60\xc3\x13\x3a\x33\xc7\x26\x94\x91\x85\xc9\xd0\xfa\x2a\x42\x49\x41\xb9\x31\x33\x3a\xb5\x34\x98\x0f\x50\xaf\x7e\xa4\x50\x23\x89\x73\x1f\xd3\x02\xdd\x77\x9e\xed\x56\x33\xee\x69\x11\x3a\x2f\x39\x75\x50\xb6\x27\x70\xe3\x18\x01\xab\xe6\xc9\x74\xc2\x18\x17\xf0\x42\x8d\x17\x27\x58\x72\x15\xb3\xcd\x99\x87\x2a\x26\x74\x43\x61\xfc\xcb\x52\x94\xa6\x1f\x70\xdf\xb1\x34\x01\xe8\x0f\xf2\x55\x4d\x01\xeb\xee\x5e\x54\xd6\xaa\x3d\xfa\xcb\xd9\x7e\x60\x5a\xda\xbe\x0b\x95\xb6\x0a\x2b\xc3\x67\xb2\xb5\x64\x2b\x0d\x7e\x21\x9f\xa7\x89\x52\x68\x6d\x72\xee\x21\xa8\x17\x9e\x15\x0a\x4f\x1c\x51\xf6\x1d\xbe\x6e\x5c\xc3\x89\x0a\x91\xb0\x28\x64\xdb\x92\x33\x09\x04\x5c\xb6\x4d\x7c\xf8\xd7\xa9\xe7\x74\xea\xb1\x8c\xee\xe0\x0e\xb6\xd0\x84\x7e\xaf\x5c\x58\xa9\x7c\x70\x07\x00\x73\x17\xa0\xf7\x54\x4a\x3b\x3e\xf8\xef\xa2\x34\x3c\x66\x8e\xcd\xe1\xcb\x14\x5b\xe4\x3a\x9b\xc7\x32\x1a\xa4\x79\x35\xe1\x3e\x8c\x96\xdf\x1d\x8c\xa1\x19\xd8\xfe\xf9\x14\x85\x7d\x67\x37\x13\x3d\x3a\xe8\x0c\xc1\x5c\x63\xb4\xf0\x75\xe2\xbe\xef\x42\x1b\x07\xb7\x6b\xbf\x24\x6f\x61\x2a\x3c\x0c\x24\x6b\x23\x0d\x5e\x3e\xdf\xb5\xdf\x7b\xf8\x78\xb4\x37\xc5\x5e\xb6\x41\xdc\x10\xed\x01\xed\x9b\x5d\xfe\x80\x05\x9c\x25\x4f\xb0\xb9\xf5\x61\x56\xf7\x60\xbf\xdd\xb9\xae\xeb\xdc\x5f\x03\x00\xc7\x04\x8a\x4e\xb4\x07\x00\x00")
 
 func complySoc2ReadmeMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -321,12 +342,12 @@ func complySoc2ReadmeMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/README.md", size: 1972, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/README.md", size: 1972, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2TodoMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\x55\x4d\x6f\xdc\x46\x0c\xbd\xef\xaf\x20\xe0\x8b\x03\xec\x47\xe3\x43\xd1\xf6\x66\xac\x5d\x34\x87\xd4\x41\xeb\xe6\x12\x18\xd8\xd9\x19\xae\xc4\x78\x34\xa3\x92\x1c\xd9\x4a\x90\xff\x5e\xcc\x48\xab\xd5\xd6\xf6\x55\xc3\x47\x3e\x92\x8f\x4f\x17\xf0\xfd\xfb\xfa\x4f\xd3\xe0\x8f\x1f\xb0\x8d\x4d\xeb\xc9\x04\x8b\xf0\x89\x63\xc5\xa6\x59\x2c\xfe\xa0\xaa\x5e\x79\xec\xd0\xc3\xfd\xdd\xcd\x1d\x58\x46\xa3\xe8\x60\xdf\xc3\x17\x9b\xe3\xfb\x87\xcb\x5a\xb5\x95\xdf\x36\x9b\x8a\xb4\x4e\xfb\xb5\x8d\xcd\x46\x94\x63\xa8\x5c\xb3\x19\x62\xde\x2d\x16\x17\x17\xf0\x21\x90\x92\xf1\xf4\xcd\x28\xc5\x00\x9f\x6a\x23\x08\x97\x75\x4c\x2c\xef\x16\x2b\xf8\x02\x0f\x70\xed\x1c\xb4\x1c\xbf\xa2\x55\xd0\x08\x12\x13\x5b\x04\x1b\x83\x72\xf4\x63\xcc\x67\x64\x3a\xf4\xb0\x1b\x52\xc3\x3e\x91\x77\x3b\xa8\x30\x20\x1b\x45\x81\xce\x78\x72\x10\x93\xb6\x49\x47\xc8\xb6\xb0\x06\x25\xfb\x88\x4a\xa1\xca\x6d\x38\x0c\x99\x8d\x2c\x73\xfa\x03\x55\x89\x11\x3a\x32\xc7\xbc\xeb\xbe\xf1\xbb\xd7\x2b\x4a\x1f\xec\x0e\xf0\x19\x6d\xca\xf5\x9e\x48\xeb\x98\x14\x90\x39\xb2\x94\x4e\xaf\x93\xd6\x91\x73\xa1\xb1\xc9\x27\xc4\xc7\xa1\xc9\xe7\x07\xf8\x9c\x09\x66\x3e\xa2\x26\x38\xc3\x4e\x36\x4b\x68\x39\x85\x0c\x30\x02\x01\x2d\x8a\x18\xee\x17\x00\x00\x03\xe6\x9f\xd6\x95\xb9\x6b\x84\xfb\xbf\xb7\xab\xab\x9f\xae\xae\x4a\x61\x60\xec\x48\xd0\x41\x1b\x29\xa8\x40\x3c\xc0\x21\xda\x24\xc7\xc6\x93\x68\x6c\xe8\x1b\x42\x30\xcc\x46\xa9\x43\xd9\x8c\x34\x4e\x6f\x6d\xf4\x64\x29\xbf\x9c\x0a\xde\x86\x3a\x2b\xc1\x41\x87\xc1\x45\x86\xc6\x04\x53\x61\x83\x41\x87\xf0\x1e\x0e\x91\x61\xbb\xfd\x75\x7d\x05\x76\x12\xce\x2c\xc1\x76\x54\x8a\x11\x41\x05\x0a\x1d\x06\x8d\xdc\x9f\xa3\xaf\xd6\xef\x81\xf1\xdf\x44\x5c\x52\x0b\xc0\x6b\x14\x8c\xcd\x03\x39\x47\xfe\x0c\x55\x22\xf7\x46\xcd\xd6\xa8\xad\xdf\xa4\xfc\xcb\xfa\xfd\x2b\x94\xe1\x01\x6e\x48\x94\x69\x9f\x14\x81\xb1\x31\x54\x16\x32\x8a\x4f\xc0\x34\x31\x54\xd3\xac\x66\xb0\xdb\x20\x59\x3c\xc6\xfb\xe9\x15\x8c\x73\x9c\x39\xfb\x2c\x49\x3d\xed\xec\x98\xed\xc5\x7e\x5a\x8e\x16\x5d\xe2\xd9\x16\xce\x19\xfd\x9f\xc7\x14\x3f\x0b\x1f\x75\x3e\x5c\xc0\xa0\x76\x50\x6c\xda\xc2\x62\x16\x77\x2d\x42\x55\x00\xb1\x35\xba\xe4\x51\x5e\xd7\xb9\x46\x17\x77\x40\xc1\x91\x2d\x87\x95\x1b\x9c\x58\x88\x51\x92\x03\xa1\x2b\x82\xbf\xc1\xd6\xc7\xbe\x8c\xfa\x85\xe2\x73\x1b\xe5\xf9\x74\x40\x63\x5d\xde\xc1\xa5\x20\xc2\x5f\xb7\xd7\x37\x1f\x6f\xd7\x8d\x2b\x0b\xc2\x67\xd3\xb4\x1e\x41\x2c\x53\xab\x6f\xa4\x18\xaf\x7e\xb8\xf2\x62\x15\xb5\x61\x74\xe0\xa3\x2d\xee\xb2\x78\x31\xbf\x69\x35\x1a\x41\xd1\x34\x63\xc4\x3d\x1b\x0a\xe5\x03\xc4\x00\x49\x30\x9f\xd0\xc9\x28\xa4\x17\xc5\x26\x63\x1c\xe6\x99\x99\xb2\x89\xa3\x76\x56\x8c\x1e\x3b\x13\x14\x8c\x55\xea\x48\xfb\x32\x8d\xbb\x36\x5b\xd1\xec\xfc\x51\x91\x83\xf1\xc7\x5e\x3e\xc6\x40\x1a\x19\x94\x1a\xcc\x73\x1e\x16\xf5\x14\xf9\xf1\xe0\xe3\xd3\x64\x85\x5f\x93\x28\x98\xe0\x80\x71\xd5\xa6\xbd\x27\xa9\x67\x67\xbc\x9c\x89\x2d\xb8\x99\x1e\xce\x2d\x64\xb0\x23\x47\xe7\x8b\x59\x82\x09\x21\x19\x9f\xad\x79\x28\xf7\xa1\x69\x23\x6b\x39\xc6\x2c\x58\x4f\xa2\x70\x39\x30\xcb\x16\xe7\x3d\xec\x71\x32\x59\x77\x44\xfd\x9e\xfc\x21\xbf\x65\x69\x1c\xa1\x23\x68\x2e\x37\x55\x63\xeb\x89\xef\x72\x46\x76\x59\xc8\x9f\xba\x7a\x89\xc2\x8e\x1c\x86\xf2\x17\xf0\x1e\xed\xf8\xf3\x69\xb3\xef\xc5\x24\xbe\x5f\x8d\x46\x3c\x1b\xc1\x44\xe1\xbf\x00\x00\x00\xff\xff\x4e\x31\xd8\xf6\xde\x06\x00\x00")
+var _complySoc2TodoMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\x55\xc1\x6e\x1b\x47\x0c\xbd\xeb\x2b\x08\xf8\xe2\x00\x96\xd4\xf8\x50\xb4\xbd\x09\xb2\x8b\xe6\x90\x3a\x68\xdd\x5c\x02\x03\x1a\xcd\x50\xbb\x8c\x67\x87\x5b\x92\x23\x7b\x13\xe4\xdf\x8b\xd9\x5d\x49\xab\xda\xbe\xee\xf0\x91\x8f\xe4\xe3\xdb\x0b\xf8\xfe\x7d\xf1\xa7\x6b\xf0\xc7\x0f\x58\x73\xd3\x46\x72\xc9\x23\x7c\x12\xae\xc4\x35\xb3\xd9\x1f\x54\xd5\xf3\x88\x7b\x8c\x70\x7f\x77\x73\x07\x5e\xd0\x19\x06\xd8\x76\xf0\xc5\x97\xf8\xee\xe1\xb2\x36\x6b\xf5\xb7\xe5\xb2\x22\xab\xf3\x76\xe1\xb9\x59\xaa\x09\xa7\x2a\x34\xcb\x21\xe6\xdd\x6c\x76\x71\x01\x1f\x12\x19\xb9\x48\xdf\x9c\x11\x27\xf8\x54\x3b\x45\xb8\xac\x39\x8b\xbe\x9b\xcd\xe1\x0b\x3c\xc0\x2a\x04\x68\x85\xbf\xa2\x37\x30\x06\xe5\x2c\x1e\xc1\x73\x32\xe1\x38\xc6\x7c\x46\xa1\x5d\x07\x9b\x21\x35\x6c\x33\xc5\xb0\x81\x0a\x13\x8a\x33\x54\xd8\xbb\x48\x01\x38\x5b\x9b\x6d\x84\xac\x7b\xd6\x60\xe4\x1f\xd1\x28\x55\xa5\x8d\x80\xa9\xb0\xd1\xab\x92\x7e\x47\x55\x16\x84\x3d\xb9\x43\xde\x45\xd7\xc4\xcd\xeb\x15\xb5\x4b\x7e\x03\xf8\x8c\x3e\x97\x7a\x4f\x64\x35\x67\x03\x14\x61\xd1\xbe\xd3\x55\xb6\x9a\xa5\x14\x1a\x9b\x7c\x42\x7c\x1c\x9a\x7c\x7e\x80\xcf\x85\x60\xe1\xa3\xe6\x52\x70\x12\x74\x79\x05\xad\xe4\x54\x00\x4e\x21\xa1\x47\x55\x27\xdd\x0c\x00\x60\xc0\xfc\xd3\x86\x7e\xee\xc6\x70\xff\xf7\x7a\x7e\xfd\xd3\xf5\x75\x5f\x18\x04\xf7\xa4\x18\xa0\x65\x4a\xa6\xc0\x3b\xd8\xb1\xcf\x7a\x68\x3c\xab\x71\x43\xdf\x10\x92\x13\x71\x46\x7b\xd4\xe5\x48\xe3\xf4\xd6\x72\x24\x4f\xe5\xe5\x54\xf0\x36\xd5\x45\x09\x01\xf6\x98\x02\x0b\x34\x2e\xb9\x0a\x1b\x4c\x36\x84\x77\xb0\x63\x81\xf5\xfa\xd7\xc5\x35\xf8\xa3\x70\x26\x09\xd6\xa3\x52\x9c\x2a\x1a\x50\xda\x63\x32\x96\xee\x1c\x7d\xbd\x78\x0f\x82\xff\x66\x92\x3e\xb5\x02\xbc\x46\xc1\xf9\x32\x90\x73\xe4\xcf\x50\x65\x0a\x6f\xd4\x6c\x9d\xf9\xfa\x4d\xca\xbf\x2c\xde\xbf\x42\x19\x1e\xe0\x86\xd4\x84\xb6\xd9\x10\x04\x1b\x47\xfd\x42\x46\xf1\x29\xb8\x86\x53\x75\x9c\xd5\x04\x76\x9b\xb4\x88\xc7\xc5\x78\x7c\x05\x17\x82\x14\xce\xb1\x48\xd2\x4e\x3b\x3b\x64\x7b\xb1\x9f\x56\xd8\x63\xc8\x32\xd9\xc2\x39\xa3\xff\xf3\x38\xc6\x4f\xc2\x47\x9d\x0f\x17\x30\xa8\x1d\x0c\x9b\xb6\x67\x31\x89\x5b\xa9\x52\x95\x40\x7d\x8d\x21\x47\xd4\xd7\x75\x6e\x1c\x78\x03\x94\x02\xf9\x82\xef\x1b\x3c\xb2\x50\x67\xa4\x3b\xc2\xd0\x0b\xfe\x06\xdb\xc8\x5d\x59\xe1\x4b\xc5\x97\x36\xfa\xe7\xd3\x01\x8d\x75\x65\x03\x97\x8a\x08\x7f\xdd\xae\x6e\x3e\xde\x2e\x9a\xd0\x2f\x08\x9f\x5d\xd3\x46\x04\xf5\x42\xad\xbd\x91\x62\xbc\xfa\xe1\xca\x7b\xab\xa8\x9d\x60\x80\xc8\xbe\x77\x97\xd9\x8b\xf9\x1d\x57\x63\x0c\x86\xae\x19\x23\xee\xc5\x51\xea\x3f\x00\x27\xc8\x8a\xe5\x84\x4e\x46\xa1\x9d\x1a\x36\xa5\x40\xc0\x32\xb3\x72\xb6\x27\xed\xcc\x05\x23\xee\x5d\x32\x70\xde\x68\x4f\xd6\xf5\xd3\xb8\x6b\x8b\x15\x4d\xce\x1f\x0d\x25\xb9\x78\xe8\xe5\x23\x27\x32\x16\x30\x6a\xb0\xcc\xb9\xaf\x06\x4f\x2c\x8f\xbb\xc8\x4f\x63\xd0\x2a\x7c\xcd\x6a\xe0\x52\x00\xc1\x79\x9b\xb7\x91\xb4\x9e\x9c\xf1\xd5\x44\x6c\x29\x4c\xf4\x70\x6e\x21\x85\xcf\x2a\x07\x3a\x5f\xcc\x15\xb8\x94\xb2\x8b\xc5\x9a\x87\x72\x1f\x9a\x96\xc5\xfa\x63\x2c\x82\x8d\xa4\x06\x97\xc3\x1c\x8a\xc5\xc5\x08\x5b\x3c\x9a\x6c\x38\xa0\x7e\xcf\x71\x47\x31\xf6\xd2\x38\x40\x47\xd0\x54\x6e\x66\xce\xd7\x47\xbe\x57\x13\xb2\x85\x48\x98\x74\xf5\x12\x85\x7b\x0a\x58\xfe\x49\x9e\x63\x44\x3f\xfe\x7c\xda\xe2\x7b\x9c\x35\x76\xf3\xd1\x88\x27\x23\x00\x23\xff\x88\xa6\xb3\xff\x06\x00\x4e\x31\xd8\xf6\xde\x06\x00\x00")
 
 func complySoc2TodoMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -341,12 +362,12 @@ func complySoc2TodoMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/TODO.md", size: 1758, mode: os.FileMode(420), modTime: time.Unix(1757470844, 0)}
+	info := bindataFileInfo{name: "comply-soc2/TODO.md", size: 1758, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2NarrativesReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x44\xc8\xc1\x09\x42\x41\x0c\x04\xd0\xfb\xaf\x62\xc0\xbb\xa5\xd8\x43\xd8\x1d\x35\xe0\x4e\x96\x18\x22\x58\xbd\xe0\xe5\xdf\x1e\xef\x82\x9b\x65\x5a\x79\xf3\x7d\x1c\xa7\xb1\x33\xda\x27\x61\x42\x34\xb3\x9d\x1f\xc4\x1d\xf5\x24\x22\x1f\x26\xff\x5a\x79\x08\xa6\xf9\xcf\x11\x6b\xbf\xdc\x34\x08\xaa\x3d\x43\x8b\xaa\xeb\x2f\x00\x00\xff\xff\x77\xd3\x99\x65\x60\x00\x00\x00")
+var _complySoc2NarrativesReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x60\x00\x9f\xff\x23\x20\x4e\x61\x72\x72\x61\x74\x69\x76\x65\x73\x0a\x0a\x4e\x61\x72\x72\x61\x74\x69\x76\x65\x73\x20\x70\x72\x6f\x76\x69\x64\x65\x20\x61\x6e\x20\x6f\x76\x65\x72\x76\x69\x65\x77\x20\x6f\x66\x20\x74\x68\x65\x20\x6f\x72\x67\x61\x6e\x69\x7a\x61\x74\x69\x6f\x6e\x20\x61\x6e\x64\x20\x74\x68\x65\x20\x63\x6f\x6d\x70\x6c\x69\x61\x6e\x63\x65\x20\x65\x6e\x76\x69\x72\x6f\x6e\x6d\x65\x6e\x74\x2e\x03\x00\x77\xd3\x99\x65\x60\x00\x00\x00")
 
 func complySoc2NarrativesReadmeMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -361,12 +382,12 @@ func complySoc2NarrativesReadmeMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/narratives/README.md", size: 96, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/narratives/README.md", size: 96, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2NarrativesControlMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x56\xdf\x6f\x1b\xc9\x0d\x7e\xdf\xbf\x82\x80\x81\x6b\x0b\x58\x6a\x93\xbb\x03\x0a\xbf\xb9\xb2\xdb\xa6\xc8\xc5\x46\x6c\xe4\x1e\x82\x3e\x50\xb3\x5c\x89\xf5\xec\x70\x6f\x38\x23\x67\x7b\xb8\xff\xbd\xe0\xcc\x6a\xb5\x4a\x62\xa4\x2f\xf7\xa4\xd5\xfc\x20\x39\xdf\x47\x7e\x64\xc0\x9e\xae\x60\x23\x21\x45\xf1\x70\x1b\x0e\x1c\x25\xf4\x14\x12\xbc\xc3\x18\x31\xf1\x81\x1a\x74\x51\xc2\xd8\x5f\xc1\xe6\xf6\x5d\xa3\x98\x58\x3b\x26\xbd\x6a\x00\x1e\x1f\x36\xf6\x03\xb0\x82\xcd\xe6\xf5\xfa\xd5\xe2\xfb\xf5\xe2\xfb\xfb\xf9\xfb\x87\xc5\x99\x1f\x16\x67\x7e\x5c\xac\xff\x78\xb6\xfe\x7d\xd3\xe3\x7f\x24\xbe\xa7\x03\x2b\x4b\x28\x6e\x57\xd0\x62\xa2\x2b\xf8\x57\x0e\xf0\x0a\x5e\xff\xe5\xd5\x5f\xcb\x05\x27\xbd\x45\x7e\x05\x6f\x02\x27\x46\x0f\xad\xb8\x6c\x2b\xcd\x6a\xb5\x6a\x9a\x8b\x6f\x3c\xb3\x79\xdc\x13\x74\xe2\xbd\x3c\x73\xd8\xc1\x10\xe5\xc0\x2d\x29\x20\xb4\xa4\x2e\xf2\x90\x58\x02\x48\x07\x69\x4f\xe0\x26\x53\x9a\x62\x76\x29\x47\xb2\x8d\x5f\x7f\x5d\xbf\xc3\x9e\x7e\xfb\x6d\x5d\x8d\x71\x48\xe6\xa2\x5c\x61\x3d\x33\xc3\x0a\x49\x80\x42\xee\x29\x62\xa2\x62\xd3\xcb\x8e\x1d\xfa\x4b\x18\xc4\xb3\x1b\x2f\x01\x43\x6b\x61\x38\x6a\x73\x44\x7f\xf4\xa9\x90\xf6\x98\x40\x29\x1e\xc8\x8c\xf4\x12\x38\x49\x3c\x79\xff\x83\x02\x0e\x83\x67\x87\xc5\x95\x59\x69\x31\x21\x28\xb9\x1c\x39\x8d\x6b\xd8\xec\x31\xec\x48\x21\x07\x27\x07\x8a\xd4\xc2\x76\xb4\x10\x94\x66\x7f\xa4\xc0\xe1\xeb\x61\x9d\x42\xba\x04\x89\xe0\xb2\x26\xe9\x29\x02\x2d\x60\xc5\x48\x80\x6d\x1b\x49\xb5\x5a\x8f\xd4\x53\xcb\x25\x22\x05\x1d\xc8\x71\xc7\xce\xc2\x37\x17\x41\x12\xb5\xe0\x4a\x54\x6b\x63\xea\x6d\xf5\x79\x64\x4c\x9b\x66\x7e\x1d\x50\x3f\x78\x19\x15\x94\x0e\x64\xb0\x4c\xf1\x2d\xe0\x11\x0b\x31\x91\x4b\xb6\xd6\x71\x4b\xa1\xa6\x83\x81\x60\x68\x50\x50\x63\x2c\x48\xec\xd1\x83\x0c\x46\xc1\xc4\x2d\x27\x05\x27\xb1\xe0\xd0\x66\x97\xd6\x4d\xb3\x82\x9f\x30\xb4\x98\x24\x8e\xd5\x04\x05\x17\xc7\x4a\x23\x26\x88\xa4\xa9\x58\xe5\x00\xbd\xd8\xaa\xdd\xc8\x3e\xf1\xaa\x43\x67\xc4\x60\x4e\x7b\x0b\x61\xe2\xa3\xb3\x25\xe7\x48\x4b\xa4\xce\x4b\xb6\xbb\x5d\xc4\x39\x97\x9a\x15\x5c\xbb\xc4\x07\x4e\x63\xb1\x8c\x41\x7a\xf4\xe3\x91\x69\xcb\x4e\x09\xc7\x08\xcd\xa4\x8e\x9a\xa8\xd7\x66\x05\x1f\xb2\x0f\x14\x71\xcb\xde\x2e\xf7\x18\x70\x47\x85\x90\x21\xca\x2e\x62\x6f\xe0\xde\x17\x1e\xff\x2f\x6c\x2b\xe5\xbf\x13\xb4\xf0\x58\x33\xce\x7c\x70\xc9\x37\xe7\x73\x4b\x97\xb0\xcd\x35\x81\x82\x24\xf0\xdc\xb3\x25\x47\x92\xab\xa6\xe0\x52\x80\x3b\x56\x72\x7d\x4a\xb3\x82\xdb\x13\x27\xf3\xda\x5d\xd7\xb1\x23\x78\x98\xd2\xfe\xb4\x71\x8f\xaa\xcf\x12\xdb\xc5\x4a\x7d\xe7\x63\x44\x0e\x06\xef\xbc\xf1\x81\x42\x2b\xf1\xf4\xff\x67\x89\x4f\x9a\x70\xe9\xc8\x10\x3d\x15\xe9\xd7\x50\xdd\xa3\x42\xa9\x74\xc9\x0a\xea\xf6\xd4\x66\x4f\xed\xb2\xd4\x16\x65\x6c\x28\xa6\x1c\xaa\x24\x50\xd7\x91\x65\x02\x05\x7b\xb5\x74\x20\x61\x27\x16\xe0\xb1\x96\x67\x6a\xaa\x5a\x58\x91\x47\xc3\x52\x3a\xa0\x03\x85\xb4\x6a\xa3\xdd\xfe\xcc\x57\x24\x1d\xc4\xfc\xc8\x6c\x68\x15\xc9\xa3\x01\x5d\xae\xa9\x29\xd8\xdd\xcd\xdd\x15\xfc\x9d\x03\x7a\xfe\x2f\x4d\xf2\xe0\x59\x93\x36\xcd\xc5\x05\x3c\xcc\xef\x98\x01\xb6\x08\xae\x73\xcb\x69\x06\x84\xd4\x48\x33\xf1\xa6\xe7\x23\x77\x1f\x7f\xc9\x18\x13\x45\x3f\xfe\xfb\xb4\x37\xdb\x78\x2b\x3b\x85\x8f\xcf\x44\x4f\x67\xfb\x9b\x71\x4b\x11\xde\xb3\x3e\xc1\xb5\x2a\xa9\x96\xa4\xfe\xe3\x49\x40\x07\x51\xe5\xad\x37\x65\xee\x87\x28\x3d\x2b\x81\x3a\x0a\x18\x59\xf4\x4f\x5f\x77\x7a\x63\x59\xbb\xf1\xa8\x6a\x62\x54\x49\x3d\x3f\xf8\x37\x74\x4f\x79\x80\x47\xd2\x64\xa8\x9f\x6f\xde\xb0\xa2\x26\x0b\x8b\x8a\x8c\x8e\xa7\x73\x4a\x3d\xaf\x30\x84\x8c\x7e\xe9\x8f\x0e\xec\x48\xe1\xbb\x65\x1a\xbd\x00\xc8\x77\xb0\xf1\x84\x11\xde\xca\xf3\xea\x3e\xb2\x14\x70\xae\x3d\xc5\x74\x06\xcf\xf5\x30\xf8\x11\xee\x1e\xe0\x1e\x93\xdb\x93\xc2\xc7\x5e\x42\xda\xd7\xcd\x0f\x14\xb9\x1b\xeb\x33\x6f\x58\x07\x51\x2b\xe9\x12\xb0\x35\xa6\x53\x0e\x7f\x16\xc2\x46\x82\x15\xe8\x89\x94\xb9\x2e\x3e\x7e\xf1\xa6\xf9\xcc\x4f\x27\x71\x2a\x79\x60\xa1\xda\x9f\x8d\xe9\xc4\x2e\xc7\xaf\xc1\x7b\x4f\x81\xd2\xb4\x63\xd8\x2d\xed\xff\xbc\xe7\x44\x5b\xf9\x74\xf2\x30\x79\x5c\x9c\x79\xb8\xdb\xbc\x9e\x32\x6e\x5e\xb5\xdc\xbc\x2d\xa9\x7f\x53\x53\xff\x9b\xe9\x79\x17\xb6\x82\xb1\x85\xdb\x22\x7e\x44\x55\x3a\xbe\x58\x7b\x13\x0e\x46\xef\xce\xb2\x6d\xb6\x59\x9e\xf9\xd2\xe6\x9b\xe0\x8a\x42\x9a\x44\xbc\x5f\xf4\xbf\xa5\x36\x64\xb5\x92\xdc\x13\x48\x4e\x4e\xfa\x5a\xba\xf6\x1f\x3b\x89\x45\xba\x59\x82\x35\xc7\xa3\x04\x2f\x87\x82\x5a\xcd\x55\x85\xbb\x11\x74\x2f\xd1\x8c\x70\xd8\xcd\xdd\x9b\x3e\x71\xcd\xc9\xe3\xc4\xb2\xe8\xd2\x6b\xb8\x0b\x8e\x8e\xf7\x99\xda\xcb\xa9\xca\xcf\x0c\x99\x12\x1f\xbb\x77\xed\xe5\xdc\x97\xe1\x28\xec\xbe\xb0\xfe\x79\x78\x55\x95\x5c\x24\x2c\xa7\x02\x3d\xbf\xf8\x10\xd3\x48\xa2\x96\xda\x75\x1d\xd5\xfa\x3e\x87\xa9\x28\xcf\x00\x73\xf3\x0e\x29\x44\xf2\x74\xc0\x90\xac\x81\x5a\xcb\x29\x99\x14\x69\x87\xb1\x35\x7f\x06\x40\x97\x43\x69\x92\xa5\x6b\x4e\xd0\x6e\xe5\x40\xa7\x40\x9e\x39\xed\xcb\xac\x16\x03\xfa\xda\xc5\x3e\x4d\x7f\x06\x8c\xa9\xc8\xa9\x8d\x51\x80\xba\xaa\x21\xc2\x16\x95\xeb\x0b\xd0\x39\x99\x9c\x09\x58\x49\xe7\x32\x28\x44\xfa\x25\x73\xe5\xcf\xb4\xf4\xe2\x02\xde\x4c\x0e\x5e\x7c\xcb\x91\xa0\x63\x22\x5c\x4e\x7d\x9f\x8f\x30\x9e\x8d\x50\xc7\x78\xfd\x08\x59\xe7\xb7\xce\xd3\xab\xcd\x52\x81\xbc\x96\xa6\xf9\xe0\xd1\x3d\x59\x93\xec\x91\x7d\xb3\x82\x7f\x70\xfa\x67\xde\x42\x62\xf7\x44\xc6\x4b\xad\x99\x4f\xdf\x88\x6f\xc6\x7a\x0a\x74\xee\x18\x4b\xec\x6d\xa8\xfd\x1c\xbc\xda\xd7\x4b\xd7\xda\x63\xa4\xbd\xf8\x96\xa2\x5e\xce\x83\xa3\x7d\x9a\xc9\x32\x2d\xe9\xa5\xf1\x97\x3d\xd6\x6f\x7b\xf7\xce\xc4\xb5\x8e\x95\x96\xa8\xc5\xe6\x9c\x2d\xe7\x04\x1c\xed\xe4\x89\xc8\xd9\xd4\xf8\xe7\x13\x37\xb2\xf5\x56\xa8\x2c\x61\xfd\xbf\x00\x00\x00\xff\xff\x47\x74\x67\x02\xff\x0c\x00\x00")
+var _complySoc2NarrativesControlMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x57\x4d\x6f\xe4\xc8\x0d\xbd\xeb\x57\x10\x30\xb0\x49\x00\xab\x93\x99\xdd\x05\x02\xdf\x9c\xb6\x93\x38\x98\x1d\x1b\x63\x63\xf6\x60\xe4\xc0\x2e\x51\xdd\x8c\x4b\x45\x6d\xb1\xaa\x6d\x65\xb1\xff\x3d\x60\x49\xad\x56\x7b\xc6\x98\x5c\xf6\x64\x75\x7d\x90\x8f\xef\xf1\xa3\x1c\xb0\xa3\x0b\x58\x4b\x48\x51\x3c\x5c\x87\x3d\x47\x09\x1d\x85\x04\x1f\x31\x46\x4c\xbc\xa7\x0a\x5d\x94\x30\x74\x17\xb0\xbe\xfe\x58\x29\x26\xd6\x96\x49\x2f\x2a\x80\x87\xfb\xb5\xfd\x01\xa8\x61\xbd\x7e\xbf\x7a\xb7\xf8\x7e\xbf\xf8\xfe\x7e\xfe\xfe\x61\x71\xe6\x87\xc5\x99\x1f\x17\xeb\x3f\x9e\xac\x7f\x5f\x75\xf8\x1f\x89\x9f\x68\xcf\xca\x12\x8a\xdb\x1a\x1a\x4c\x74\x01\xff\xca\x01\xde\xc1\xfb\xbf\xbc\xfb\x6b\xb9\xe0\xa4\x33\xe4\x17\x70\x13\x38\x31\x7a\x68\xc4\x65\x5b\xa9\xea\xba\xae\xaa\xb3\x6f\x84\x59\x3d\xec\x08\x5a\xf1\x5e\x9e\x39\x6c\xa1\x8f\xb2\xe7\x86\x14\x10\x1a\x52\x17\xb9\x4f\x2c\x01\xa4\x85\xb4\x23\x70\x93\x29\x4d\x31\xbb\x94\x23\xd9\xc6\xaf\xbf\xae\x3e\x62\x47\xbf\xfd\xb6\x1a\x8d\x71\x48\xc6\x64\xb9\xc2\x7a\x62\x86\x15\x92\x00\x85\xdc\x51\xc4\x44\xc5\xa6\x97\x2d\x3b\xf4\xe7\xd0\x8b\x67\x37\x9c\x03\x86\xc6\x60\x38\x6a\x72\x44\x7f\xf0\xa9\x90\x76\x98\x40\x29\xee\xc9\x8c\x74\x12\x38\x49\x3c\x7a\xff\x83\x02\xf6\xbd\x67\x87\xc5\x95\x59\x69\x30\x21\x28\xb9\x1c\x39\x0d\x2b\x58\xef\x30\x6c\x49\x21\x07\x27\x7b\x8a\xd4\xc0\x66\x30\x08\x4a\xb3\x3f\x52\xe0\xf0\x75\x58\x47\x48\xe7\x20\x11\x5c\xd6\x24\x1d\x45\xa0\x05\xad\x18\x09\xb0\x69\x22\xa9\x8e\xd6\x23\x75\xd4\x70\x41\xa4\xa0\x3d\x39\x6e\xd9\x19\x7c\x73\x11\x24\x51\x03\xae\xa0\x5a\x99\x52\x1f\x46\x9f\x07\xc5\xb4\xaa\xe6\xe8\x80\xba\xde\xcb\xa0\xa0\xb4\x27\xa3\x65\xc2\xb7\xa0\x47\x2c\x8a\x44\x2e\xd9\x5a\xcb\x0d\x85\x31\x1d\x8c\x04\x63\x83\x82\x9a\x62\x41\x62\x87\x1e\xa4\x37\x09\x26\x6d\x39\x29\x38\x89\x85\x87\x26\xbb\xb4\xaa\xaa\x1a\x7e\xc2\xd0\x60\x92\x38\x8c\x26\x28\xb8\x38\x8c\xd9\x80\x09\x22\x69\x2a\x4a\x71\x80\x4e\x6c\xd5\x6e\x64\x9f\xb8\x6e\xd1\x99\x30\x98\xd3\xce\x20\x4c\x7a\xb4\xb6\xe4\x1c\x69\x41\xea\xbc\xe4\x06\x38\xb4\x11\xe7\x5c\xaa\x6a\xb8\x74\x89\xf7\x9c\x86\x62\x19\x83\x74\xe8\x87\x83\xd2\x96\x9d\x12\x0e\x08\x0d\x86\x0e\x9a\xa8\xd3\xaa\x86\xcf\xd9\x07\x8a\xb8\x61\x6f\x97\x3b\x0c\xb8\xa5\x22\x48\x1f\x65\x1b\xb1\x33\x72\xef\x4a\x7a\xfd\x5f\xdc\x8e\x92\xff\x4e\xd4\xc2\xc3\x98\x71\xe6\x83\x4b\xbe\x39\x9f\x1b\x3a\x87\x4d\x1e\x13\x28\x48\x02\xcf\x1d\x5b\x72\x24\xb9\x30\x29\x2e\x47\xe2\x26\xec\x53\x28\x55\x0d\xd7\x47\x4d\xe6\xb5\xdb\xb6\x65\x47\x70\x3f\xa5\xfd\x71\xe3\x0e\x55\x9f\x25\x36\x8b\x95\xf2\x01\x0f\x11\x39\x18\xbd\xf3\xc6\x67\x0a\x8d\xc4\xe3\xef\x9f\x25\x3e\x69\xc2\xa5\x23\x63\x74\xae\x88\xaf\xb2\xba\x43\x85\x52\xe9\x92\x15\xd4\xed\xa8\xc9\x9e\x8e\xa5\x4d\xba\x2c\x63\x13\x3c\xe5\x30\xb6\x04\x6a\x5b\xb2\x4c\xa0\x60\x51\x4b\x0b\x12\xb6\x62\x00\x0f\xb5\x3c\x4b\x33\x76\x0b\x2b\xf2\x68\x5c\x4a\x0b\xb4\xa7\x90\xea\x26\xda\xed\x57\xbe\x22\x69\x2f\xe6\x47\x66\x43\x75\x24\x8f\x46\x74\xb9\xa6\xd6\xc1\x6e\xaf\x6e\x2f\xe0\xef\x1c\xd0\xf3\x7f\x69\x6a\x0f\x9e\x35\x69\x55\x9d\x9d\xc1\xfd\x1c\xc7\x4c\xb0\x21\xb8\xcc\x0d\xa7\x99\x10\x52\x13\xcd\x9a\x37\x3d\x1f\xb4\x7b\xfc\x25\x63\x4c\x14\xfd\xf0\xef\xe3\xde\x6c\xe3\x83\x6c\x15\x1e\x9f\x89\x9e\x4e\xf6\xd7\xc3\x86\x22\x7c\x62\x7d\x82\x4b\x55\x52\x2d\x49\xfd\xc7\x63\x03\xed\x45\x95\x37\xde\x3a\x73\xd7\x47\xe9\x58\x09\xd4\x51\xc0\xc8\xa2\x7f\xfa\xba\xd3\x2b\xab\xe6\xb5\x47\x55\x6b\x46\xa3\xa8\xa7\x07\xff\x86\xee\x29\xf7\xf0\x40\x9a\x8c\xf5\xd3\xcd\x2b\x56\xd4\x64\xb0\xa8\xb4\xd1\xe1\x78\x4e\xa9\xe3\x1a\x43\xc8\xe8\x97\xfe\x68\xcf\x8e\x14\xbe\x5b\xa6\xd1\x1b\x84\x7c\x07\x6b\x4f\x18\xe1\x83\x3c\xd7\x77\x91\xa5\x90\x73\xe9\x29\xa6\x13\x7a\x2e\xfb\xde\x0f\x70\x7b\x0f\x77\x98\xdc\x8e\x14\x1e\x3b\x09\x69\x37\x9a\xfa\x4c\x91\xdb\x61\x0c\xf3\x8a\xb5\x17\xb5\x92\x2e\x80\x6d\x30\x1d\x73\xf8\x15\x84\xb5\x04\x2b\xd0\xa3\x28\x73\x5d\x3c\x7e\x11\xd3\x7c\xe6\xa7\x63\x73\x2a\x79\x60\x50\xed\xc7\xda\xfa\xc4\x36\x4f\x7d\xe0\xd4\xd1\x1d\x05\x4a\xd3\x8e\x71\xb7\xb4\xff\xf3\x8e\x13\x6d\xe4\xe5\x88\x62\xf2\xb8\x38\x73\x7f\xbb\x7e\x3f\x65\xdc\xbc\x6a\xb9\x79\x6d\x39\x5c\x5f\x8d\xa9\xff\xcd\xf4\xbc\x0d\x1b\xc1\xd8\xc0\x75\x69\x7e\x44\xb6\xd4\xb6\x5f\xac\xdd\x84\xbd\xc9\xbb\xb5\x6c\x9b\x6d\x16\x45\xde\xda\xbc\x09\xae\x0c\x1f\x6b\xba\x9f\x16\xf3\x6f\xd9\x1b\xb2\x5a\xf9\xef\x08\x24\x27\x27\xdd\x58\xba\x56\xfa\xd8\x8a\xcd\xcc\x22\x13\x35\x73\x9d\x9f\x3c\x0a\xec\xaa\x40\xf1\x61\x42\xeb\x4e\xa2\x19\xe1\xb0\x9d\xa7\x37\xbd\xf0\x98\xbb\x93\x81\xe5\x94\x5e\xc1\x6d\x70\x74\xb8\xcf\xd4\x9c\x4f\x55\x7e\x62\xc8\x3a\xf1\x61\x7a\x8f\xb3\x9c\xad\xc2\xf6\x66\xf4\xb5\xf5\xd7\xf0\xc6\xae\xe4\x22\x61\x39\x15\xe8\xf9\xad\x93\x60\x3d\x92\xa8\xa1\xa6\x3c\x00\xd6\xd2\x75\x39\x4c\x45\x79\xd2\x4c\xdd\xbc\x43\x0a\x91\x3c\xed\x31\x24\x1b\xa0\x36\xcd\x8d\x2c\x88\xb4\xc5\xd8\x98\x3f\xe3\xb1\xcd\xa1\x0c\x49\xfb\x7d\xa0\x76\x23\xfb\xf9\x09\xa7\xf0\xcc\x69\x57\xde\x6a\x31\xa0\x2f\x80\xe9\x65\xfa\xd1\x63\x4c\xa5\x9d\x06\xc0\x00\xa8\xf5\x08\x11\x36\xa8\x3c\x46\x80\xce\xc9\xe4\x4c\xc0\x26\x43\x2e\x0f\x85\x48\xbf\x64\x36\xd6\xc6\x5e\x7a\x76\x06\x37\x93\x83\x37\x63\x99\xf0\xcc\x89\x60\xdc\xd9\xdc\xe7\x03\x8d\x27\x4f\xa8\x03\x5e\x3f\x40\xd6\x39\xd6\xf9\xf5\x6a\x6f\xa9\x40\x5e\xcb\xd0\xbc\xf7\xe8\x9e\x6c\x48\x76\xc8\xbe\xaa\xe1\x1f\x9c\xfe\x99\x37\x90\xd8\x3d\x91\xe9\x52\xf0\x5d\xbf\x7c\x03\xdf\xcc\xf5\x04\x74\x9e\x18\x4b\xee\x93\x7c\x49\xde\x38\xd7\x0d\xa3\xee\x30\xd2\x4e\x7c\x43\x51\xcf\xe7\x87\xa3\x7d\x9a\xc9\xf2\x5a\xd2\x73\xd3\x2f\x7b\x7b\x6f\x4d\x71\x6f\xad\xb9\x8e\xff\x94\x58\xa2\x27\x5e\x66\xcb\xa9\x00\x07\x3b\x79\x12\x72\x36\x35\xfc\xf9\xa8\x8d\x6c\x3c\x6f\x31\xb1\x84\xd5\xff\x06\x00\x47\x74\x67\x02\xff\x0c\x00\x00")
 
 func complySoc2NarrativesControlMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -381,12 +402,12 @@ func complySoc2NarrativesControlMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/narratives/control.md", size: 3327, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/narratives/control.md", size: 3327, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2NarrativesOrganizationalMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x55\xdb\x6e\x1b\x47\x0c\x7d\xdf\xaf\x20\x50\xa0\x97\xc0\x16\xe2\xa4\x45\x5a\xbf\xa5\x8a\x83\xba\x40\xa2\xc0\x36\xda\x67\x6a\x86\xbb\x4b\x6b\x76\x66\x4b\xce\x4a\x51\x82\xfc\x7b\xc1\x19\x59\x5a\x3b\xbd\x3c\x69\xb4\xc3\xcb\xe1\xe1\x21\x27\xe2\x40\x97\xb0\x92\x0e\x23\x7f\xc2\xcc\x29\x62\x80\xf7\x28\x82\x99\xb7\xd4\xa0\x93\x14\xf7\xc3\x25\xac\xde\x37\x8a\x99\xb5\x65\xd2\xcb\x06\xe0\xee\x76\x69\x3f\x00\xe7\xb0\x5c\x5e\x2c\x5e\xcc\xce\x2f\x67\xe7\x1f\x67\xe7\x9f\x8e\xe7\x97\x8b\x8b\xd9\xf9\xc5\xec\xfc\xb2\x19\xf0\x3e\xc9\x0d\x6d\x59\x39\xc5\x92\xea\x1c\x3c\x66\xba\x84\xdf\xa7\x08\x17\xf0\xe2\xf9\xc5\xcf\xc5\xc1\xa5\x61\xa0\x98\x2f\xe1\x3a\x72\x66\x0c\xe0\x93\x9b\xec\x4b\x73\x7e\x7e\xde\x34\xdf\xfc\x7b\x51\xcd\x5d\x4f\xd0\xa6\x10\xd2\x8e\x63\x07\xa3\xa4\x2d\x7b\x52\x40\xf0\xa4\x4e\x78\x34\x0f\x48\x2d\xe4\x9e\xc0\x25\x19\x93\x60\x26\x40\x18\x30\x62\x47\x96\x03\x34\xcb\xe4\xf2\x24\x64\x76\x9f\x3f\x2f\xde\xe3\x40\x5f\xbe\x2c\x6a\x6c\x8e\xd9\x6c\x4a\x04\xd6\x47\x51\x59\x21\x27\x20\xcd\xb8\x0e\xac\x3d\xac\x53\xee\x4b\x9e\x40\x1d\x06\xb8\x9f\x84\xd5\xb3\x2b\xb6\x18\xfd\x2c\xbd\x9b\x42\x9e\x04\x03\xc4\x24\x83\x42\xee\x31\x83\x92\x6c\x09\x50\x4b\x84\x36\x4d\xd1\x97\x6a\xa1\x4d\x72\x02\xf5\x9d\x1a\x57\x63\x60\x8c\x8e\xac\xda\x4e\x70\x58\x18\x43\x57\x31\x73\xde\xc3\xdd\x7e\xa4\xa6\x39\xda\x1b\x46\x84\x37\x14\x70\x87\x42\xb0\x3c\x5f\x1e\x30\x58\xe4\x9e\xd0\xff\x35\xa1\x64\x12\xf2\xc0\x11\x6e\x31\xc2\x5b\xc1\xe8\x58\x5d\x3a\x83\x25\x06\x6e\x93\x44\xc6\xc5\x09\x01\xec\x50\x4f\x35\x57\xbf\x8b\x5f\x5e\x3d\x2f\x20\xae\x63\xa6\x4e\x0c\x87\xd5\x7b\x95\x7b\x76\x5a\x69\x7c\xc3\x42\x2e\x27\xd1\x7a\xf3\x91\xdc\x64\xfd\xd3\x47\x94\x03\xea\xc8\x42\xc6\xaa\x59\x79\x1a\x52\xd4\x5c\x18\xd3\x8c\xd1\xa3\xf8\xe2\x40\x25\x70\xb1\xe1\x63\x46\x97\xa2\xb2\x96\x66\xed\x38\xf7\x46\x4e\x4b\xaa\x55\x30\x95\x67\x8e\xf0\x7a\x20\x61\x87\x71\xd6\x0c\x8a\x5b\x96\x14\x4d\x0a\xba\x68\x9a\x65\xcf\xd4\x02\x0e\x29\x76\xd6\x0a\x9d\xe7\x2e\x6c\x9a\x58\x39\x17\xe5\xe4\x04\x7d\x8a\xa4\x79\x6f\xb1\x0d\x8b\x60\xe9\xb7\x56\x0c\x86\xb0\x46\xaa\x72\x13\x3d\x03\xff\xc0\xc4\x19\xd0\x30\x86\xb4\x27\xd2\x33\x43\x9f\xcd\xb7\x7c\x77\x93\xe6\x34\x14\x6b\x8b\x90\x72\x4f\x62\x28\x36\xd4\xa7\xe0\x49\xb4\x90\xfd\x6b\x42\xf1\x70\x1d\x3d\x8d\x14\x3d\x45\x77\x98\x86\xfa\x3d\xb5\x73\xce\xc7\x31\x71\xcc\x95\xb2\xb4\x25\x51\xa2\x2a\xb4\x5a\xed\xb1\x1f\xb0\x6a\x5b\x76\x24\xf0\xfd\xf2\x6a\xf5\xc3\xe2\x1f\x46\xef\xf6\x61\x58\x9e\xa8\xcc\x64\x99\x94\x4a\xe2\x57\x30\x0a\x0f\x28\x7b\xf0\x7c\x1c\xfe\x06\xe0\x19\xdc\x62\x20\x2d\xa7\x77\x28\x1b\xca\x1c\xbb\xc3\xbf\x38\xb5\x68\x71\x1f\xbe\xdc\x90\x12\x8a\xeb\xe1\x5b\x78\x43\x5b\x0a\x69\x2c\xfb\xc0\xae\xae\x63\x9b\x64\xa8\x1a\xbe\x23\xd7\xc7\x14\x52\xb7\x2f\x57\xbf\x4d\x03\x46\xf3\x4d\x93\xb8\x43\xa6\xb7\x1c\xb1\x90\x73\x85\xae\x3f\x22\x32\xcc\x81\x3c\xac\xf7\x80\xf0\x07\x3b\x82\x0f\x42\xca\x9e\x62\x3e\x83\x5d\x9f\xac\xa1\x79\x92\x08\x42\x63\x92\x5c\x46\xbd\x10\x76\xb5\x5a\xc0\xeb\x3a\x85\x94\xe9\x11\x3d\xb0\xec\x51\xb2\x45\x1e\x90\x63\x46\x8e\xe4\xab\x92\x59\xb3\xf0\x7a\xca\x35\xe1\x13\x94\x85\xe6\x77\xa7\x7d\xb4\x5a\xdf\x93\x2b\xd3\xd1\x34\x7f\x26\xd9\x58\xc0\x79\x04\x5b\x3a\x8f\x4a\xd9\x32\xce\x9c\x40\x29\x5b\x16\x43\x2b\xa4\x63\xfd\x7c\xb2\x7e\x5a\x2c\xdb\x30\x84\x80\xeb\x87\xbd\x50\xa4\xfb\x1f\xe2\x28\x78\x6f\x58\x37\x86\x64\x0e\xf6\x24\x08\x25\xda\x14\xca\xaa\xee\x41\xbe\xb2\x86\xdc\x4b\x9a\xba\x27\x73\xfa\x78\x2b\x63\xa6\x8e\xa9\xaa\x36\xdb\x60\x39\x35\xb8\x2e\x4c\x9e\x63\x67\x92\x7a\x06\x37\xdc\x25\x49\x93\x42\xcf\x52\x5f\x80\x62\x68\xbd\x7f\x06\x57\x87\x01\x83\x91\xa4\x68\xc6\xb6\xa6\xd0\x96\x69\x57\xee\x5f\x07\xee\xa2\x79\x59\x3b\x29\xea\xac\xfe\x74\xaa\xab\xa8\xb1\x9b\x02\x4a\x99\xfd\x29\xb2\xc3\x87\x67\xe5\x64\x66\x94\xd3\x91\xaa\x53\x21\x46\xd6\x5b\xc1\xc9\xff\x0f\x65\xe8\x36\x31\xed\x02\xf9\xee\x30\x9a\x63\x52\xe5\x35\x07\x5b\x6e\xe5\x85\x68\x4b\x94\x01\xf7\xc0\xc3\x48\xc2\x61\xb6\xc4\x4e\x38\xe6\xbb\x7a\x8a\x9e\xc4\xf6\x86\xc2\x16\x85\x8d\x26\x63\x67\xcb\xd9\x68\x3d\xb5\xa7\x46\xb6\x26\x7d\xc5\xef\x32\x45\x3f\x39\x1b\x54\x90\x03\x09\x6d\x19\x28\x7b\xa6\x71\xf2\x9c\x2b\x93\xbe\xa7\xc2\x7f\x4e\xb3\xfb\xb2\xd5\x52\xb0\x75\x10\x1d\x8f\xa1\x92\x79\x1d\xb7\xa4\x99\x3b\x2c\x51\x75\xd2\x91\x5d\xc1\x96\x05\xa3\x1e\x16\xa8\x19\x7e\xa8\x5d\x2b\x0d\x12\x1e\xd8\x14\xb2\x46\xb7\xe9\xc4\x9e\x47\x70\x3d\xb9\x8d\x82\xbd\xae\x21\x9c\x96\x69\x53\xf6\xc9\x47\x1e\xf8\x13\xd7\x1d\x0e\x93\x96\xb7\x9d\x67\x8b\x23\x1f\x17\x87\x0d\x40\x25\xc0\x53\xa6\x92\xfd\xef\x00\x00\x00\xff\xff\xe1\x34\x82\x91\x4a\x09\x00\x00")
+var _complySoc2NarrativesOrganizationalMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x55\xdd\x6e\x1b\x47\x0f\xbd\xdf\xa7\x20\x10\xe0\xfb\xda\xc0\x16\xe2\xb8\x45\x5a\xdf\xa5\x8a\x83\xba\x40\xe2\xc0\x36\xda\x6b\x6a\x86\xda\x65\x34\xcb\xd9\x92\xb3\x72\x94\x20\xef\x5e\x70\x56\x96\xd6\x4e\x7f\xae\x34\x9a\xe5\xcf\xe1\x21\x79\x46\xb0\xa7\x0b\xb8\xd6\x16\x85\x3f\x63\xe1\x2c\x98\xe0\x3d\xaa\x62\xe1\x2d\x35\x18\x34\xcb\xae\xbf\x80\xeb\xf7\x8d\x61\x61\x5b\x33\xd9\x45\x03\x70\x77\xbb\xf4\x1f\x80\x53\x58\x2e\xcf\x16\x2f\x67\xe7\xf3\xd9\xf9\x87\xd9\xf9\xc7\xc3\xf9\x7c\x71\x36\x3b\x1f\x7d\xcf\x17\xe7\x4d\x8f\x1f\xb3\xde\xd0\x96\x8d\xb3\xd4\x54\xa7\x10\xb1\xd0\x05\xfc\x36\x0a\x9c\xc1\xcb\x17\x67\x3f\x55\x87\x90\xfb\x9e\xa4\x5c\xc0\x95\x70\x61\x4c\x10\x73\x18\xfd\xa6\x39\x3d\x3d\x6d\x9a\x67\xff\x5c\x54\x73\xd7\x11\xac\x73\x4a\xf9\x9e\xa5\x85\x41\xf3\x96\x23\x19\x20\x44\xb2\xa0\x3c\x38\x0d\x90\xd7\x50\x3a\x82\x90\x75\xc8\x8a\x85\x00\xa1\x47\xc1\x96\x3c\x07\x58\xd1\x31\x94\x51\xc9\xed\xbe\x7c\x59\xbc\xc7\x9e\xbe\x7e\x5d\x4c\xb1\x59\x8a\xdb\xd4\x08\x6c\x8f\xa2\xb2\x41\xc9\x40\x56\x70\x95\xd8\x3a\x58\xe5\xd2\xd5\x3c\x89\x5a\x4c\xf0\x71\x54\xb6\xc8\xa1\xda\xa2\xc4\x59\xfa\x30\xa6\x32\x2a\x26\x90\xac\xbd\x41\xe9\xb0\x80\x91\x6e\x09\xd0\xff\x79\x45\xa3\xc4\x5a\x2d\xac\xb3\x1e\x41\xfd\xdf\x20\xe4\x7e\x48\x8c\x12\xc8\xab\x6d\x15\xfb\x85\x33\x74\x29\x85\xcb\x0e\xee\x76\x03\x35\xcd\xc1\x1e\xd8\xa9\x78\x43\x09\xef\x51\x09\x96\xa7\xcb\x3d\x06\x8f\xdc\x11\xc6\x3f\x47\xd4\x42\x4a\x11\x58\xe0\x16\x05\xde\x2a\x4a\x60\x0b\xf9\x04\x96\x98\x78\x9d\x55\x18\x17\x47\x04\x70\x8f\x76\xac\x79\xf2\x3b\xfb\xf9\xd5\x8b\x0a\xe2\x4a\x0a\xb5\xea\x38\xbc\xde\xcb\xd2\x71\xb0\x89\xc6\x37\xac\x14\x4a\x56\x9b\xbe\x7c\xa2\x30\xfa\x50\xda\x23\xca\x01\x6d\x60\x25\x67\xd5\xad\x22\xf5\x59\xac\x54\xc6\xac\xa0\x44\xd4\x58\x1d\xa8\x06\xae\x91\xf8\x90\x31\x64\x31\xb6\xda\xac\x7b\x2e\x9d\x93\xb3\x26\xf3\xc9\x3b\xf0\xcc\x02\xaf\x7b\x52\x0e\x28\xb3\x66\x90\x6c\x59\xb3\xf8\x28\xd8\xa2\x69\x96\x1d\xd3\x1a\xb0\xcf\xd2\x7a\x2b\x6c\x9e\xbb\xb2\xe9\xc3\xca\xc5\xcd\x1d\x68\x97\x85\xac\xec\x9c\x06\xc7\xa2\x58\xfb\x6d\x13\x06\x47\x38\x45\x9a\xc6\x4d\xed\x04\xe2\x03\x13\x27\x40\xfd\x90\xf2\x8e\xc8\x4e\x20\x64\x29\xee\x5b\xef\xc3\x68\x25\xf7\xe4\x47\x8f\x90\x4b\x47\xea\x28\x36\xd4\xe5\x14\x49\x1d\xe6\x33\xf8\x25\xa3\x46\xb8\x92\x48\x03\x49\x24\x09\xfb\x6d\x98\xee\xf3\x7a\xce\xf9\x30\x64\x96\x32\x91\x9f\xb7\xa4\x46\x34\x0d\xda\x54\xed\xa1\x1f\x70\xbd\x5e\x73\x20\x85\xef\x96\x97\xd7\xdf\x2f\xfe\x66\xf5\x6e\x1f\x96\xe5\xc9\x94\xf9\x58\x66\xa3\x9a\xf8\x15\x0c\xca\x3d\xea\x0e\x22\x1f\x96\xbf\x01\x78\x0e\xb7\x98\xc8\xea\xe9\x1d\xea\x86\x0a\x4b\xbb\xff\x27\xe3\x1a\x7d\x09\x1f\x6e\x6e\xc8\x08\x35\x74\xf0\x3f\x78\x43\x5b\x4a\x79\x70\xc6\xab\xf1\x95\xac\xb3\xf6\x15\x10\xdc\x51\xe8\x24\xa7\xdc\xee\xea\xa7\x5f\xc7\x1e\x05\x6e\xc8\xf2\xa8\x61\x9f\xe9\x2d\x8b\x6f\x4b\xd3\x5c\x62\xe8\x0e\x88\x7c\x33\x12\x45\x58\xed\x00\xe1\x77\x0e\x04\x1f\x94\x8c\x23\x49\x39\x81\xfb\x2e\x7b\x43\xcb\xa8\x02\x4a\x43\xd6\x62\xde\xeb\x4a\xd8\xe5\xf5\x02\x5e\x4f\x5b\x48\x85\x1e\xd1\x03\xcb\x0e\xb5\x78\xe4\x1e\x59\x0a\xb2\x50\xac\x94\x47\xb6\xa2\xbc\x1a\xcb\x94\xf0\x09\xca\x4a\xf3\xbb\xa3\x1e\x5d\xaf\x3e\x52\xf0\x6e\x58\xd3\xfc\x91\x75\xe3\x01\xe7\x11\x5c\x74\x1e\x95\xb2\x65\x9c\x39\x81\x51\xf1\x2c\x8e\x56\xc9\x86\xe9\xfa\x68\xfd\xb4\x58\xf6\x65\x48\x09\x57\x0f\xba\x50\x47\xf7\x5f\x86\xa3\xe2\xbd\x61\xdb\x38\x27\x73\xb0\xc7\x81\x30\xa2\x4d\xa5\x6c\x9a\x7b\xd0\x6f\xac\xa1\x74\x9a\xc7\xf6\xc9\x9e\x3e\x56\x65\x2c\xd4\xb2\x8b\xb9\x44\x28\xbe\x58\xc1\x1c\x6e\x48\x63\x64\x69\x2f\x9a\x06\x9e\xc3\x0d\xb7\x59\xf3\x68\xd0\xb1\x4e\x2f\x40\x35\xf4\xde\x3f\x87\xcb\xfd\x82\xc1\x40\x5a\x67\xc6\x55\x53\x69\xcb\x74\x5f\xbf\xbf\x4e\xdc\x8a\x7b\x79\x3b\x49\x6c\x56\x7f\x3e\x20\x75\xc3\x1b\x6a\xc7\x84\xea\x6d\xef\x47\xe1\x80\x0f\xcf\xca\xd1\xcc\x29\xa7\x03\x55\xc7\x42\x9c\xac\xb7\x8a\x63\xfc\x0f\xca\x30\x6c\x24\xdf\x27\x8a\xed\x7e\x35\x87\x6c\xc6\x2b\x4e\x2e\x6e\xf5\x85\x58\xd7\x28\x3d\xee\x80\xfb\x81\x94\xd3\x4c\xc4\x8e\x38\xe6\x5a\x3d\x4a\x24\x75\xdd\x30\xd8\xa2\xb2\xd3\xe4\xec\x6c\xb9\x30\xcd\xdb\x33\x45\xf6\x26\x7d\xc3\xef\x32\x4b\x1c\x83\x2f\x2a\xe8\x9e\x84\x75\x5d\x28\x7f\xa6\x71\x8c\x5c\x26\x26\x63\x47\x95\xff\x92\x67\xdf\xab\xaa\xe5\xe4\x72\x20\x81\x87\x34\x75\xe5\x4a\xb6\x64\x85\x5b\xac\x51\x6d\xb4\x81\x43\xc5\x56\x14\xc5\x1c\x60\x96\x6a\xf8\x61\xea\x9a\x5b\x05\xe5\x9e\x5d\x7f\x56\x18\x36\xad\xe6\xd1\xdf\xd3\x8e\xc2\xc6\xc0\x5f\xd7\x94\x8e\x62\xea\x9e\xef\xf0\x13\xf7\xfc\xd9\x3d\x7d\x90\x47\xab\x6f\x3b\xcf\x84\xa3\x1c\x84\xc3\x37\x7d\x22\x20\x52\xa1\x50\x38\xcb\x5f\x03\x00\xe1\x34\x82\x91\x4a\x09\x00\x00")
 
 func complySoc2NarrativesOrganizationalMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -401,12 +422,12 @@ func complySoc2NarrativesOrganizationalMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/narratives/organizational.md", size: 2378, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/narratives/organizational.md", size: 2378, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2NarrativesProductsMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x52\xcb\x6e\xdb\x40\x10\xbb\xef\x57\x10\xf0\x35\x0e\x9a\x9e\x0a\xdf\xd2\xf6\xd0\x14\x69\x62\xd8\x5f\xb0\x5e\xd1\xf2\xd4\xd2\xae\x30\xb3\xb2\xa1\x7e\x7d\x21\xd5\x7a\x24\xcd\x51\x1c\x8a\x1c\x72\x27\xfa\x9a\x1b\x6c\x35\x15\x6d\xc8\x06\x1f\x0b\xec\xa9\x17\x09\x34\xbc\x78\x55\x9f\xe5\x42\xe7\x83\xa6\xd8\xd5\x1b\x6c\xf7\x2f\xae\xf6\xbf\x93\xee\x78\x11\x93\x14\x6d\xe3\x80\x35\x0a\x9f\xb9\xc1\xcf\x36\xe2\x01\x9f\x3f\x3d\x7c\x71\x00\x10\x52\x5d\x33\xe6\x0d\x9e\xa2\x64\xf1\x15\x8a\x14\xda\x1e\x71\xeb\xf5\xda\xb9\xd5\x6c\x3b\x3b\xb9\x1f\x54\xe2\x4a\x14\xb4\xa0\x72\x20\xf2\x89\x38\xb3\x43\x33\x92\x6b\xaf\x67\x66\x16\x38\x74\x48\xad\x22\x69\xe9\xa3\xfc\xf1\x59\x52\x5c\xaa\x3a\xb7\x9a\x3e\xf0\xe0\xdc\xeb\xa5\x0f\xc6\x2b\xd2\x71\x14\xeb\xe1\xd5\x6a\x85\x47\x0d\x27\xc9\x0c\xb9\x55\x3a\xf7\x55\x85\x47\xf8\x19\xeb\x57\x17\x0b\xad\xf5\x89\x3f\xf8\x7d\xcf\xd0\xaa\xe4\x0e\xdf\x52\x34\x29\xa8\xc3\x2e\xe6\xdc\xbe\x61\x90\xa3\x04\xd8\xc8\x08\x6f\x18\x38\x26\x9d\xc5\xee\xb1\xe3\x91\x8a\x9c\xd0\xa4\x4a\x82\xd0\xee\xfa\x69\x60\xd1\x2a\x0d\x27\x2a\xef\xfb\x84\x03\x8d\x31\xf0\x5f\xc6\xa9\x3d\x73\xee\x59\x2c\x43\x59\xf1\xe2\x63\x46\x9c\x26\x83\xd0\xc1\x1f\xaa\x0e\x12\x43\xd5\x16\x12\x4b\xf7\xba\x68\xce\x57\x8b\x57\x98\x02\x2d\xa0\xce\x32\xeb\xe5\x4b\xf5\xed\xde\xd6\x7c\xef\xfb\x66\xfd\xf7\xae\x8f\x4d\x53\x49\x18\x4c\xe7\xe6\x06\xa5\xce\x7d\xf7\xd9\x07\xc6\x4c\x1d\x91\xe7\x54\xe2\x97\x8f\xbe\x64\x7f\x39\x23\xba\xf5\x66\xd7\xa4\xc5\xf8\x3d\xe9\x3c\xc5\x20\x45\x4f\xdc\xd1\x9a\x14\x8d\x23\x63\x27\x76\xc6\xa3\x19\xcd\x96\x42\xb7\x1b\xb9\x55\xfc\x5f\x8e\x69\xf2\x51\x12\xf8\x10\x68\x06\x65\x7f\x56\x77\x68\x7c\x0e\x27\x89\xe5\x1d\x7c\x45\xcd\xa8\x53\x94\x9c\x74\x40\xaa\x54\xce\x3c\x46\x64\x5a\x96\x58\xfe\x0d\x00\x00\xff\xff\x5a\x81\xee\x7e\x7f\x03\x00\x00")
+var _complySoc2NarrativesProductsMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x52\xcd\x6e\xdb\x4c\x0c\xbc\xef\x53\x0c\xe0\x6b\x1c\x7c\xf9\x4e\x85\x6f\x69\x7b\x68\x8a\x34\x31\xec\x27\x58\x53\xb4\xcc\x46\xe2\x0a\xe4\xca\x86\xfa\xf4\xc5\x1a\xd6\x4f\xd2\x1c\x77\x38\x9a\xe1\x8c\xa8\xb1\xe5\x0d\xb6\x96\xaa\x9e\xb2\x23\x6a\x85\x3d\xdb\x59\x88\x1d\x2f\xd1\x2c\x66\x39\x73\x88\x64\x49\x87\x76\x83\xed\xfe\x25\xb4\xf1\x77\xb2\x1d\x9f\xc5\x25\xa9\x6f\x02\xb0\x46\x15\x33\x6f\xf0\xb3\x57\x3c\xe0\xff\xff\x1e\xbe\x04\x00\xa0\xd4\xb6\xac\x79\x83\x27\x95\x2c\xb1\x41\x95\xa8\x2f\x48\x58\xaf\xd7\x21\xac\x66\xdb\xd9\x29\xfc\x60\x63\x5c\x18\x15\x3b\x99\x1c\x18\xf9\xc4\x78\xe3\x01\xdd\x48\x6e\xa3\xbd\x71\xe6\x0a\x87\x01\xa9\x37\x24\xab\xa3\xca\x9f\x98\x25\xe9\x52\x35\x84\xd5\xf4\xc0\x43\x08\xaf\xe7\x12\x8c\x2f\x48\xc7\x51\xac\xc0\xab\xd5\x0a\x8f\x46\x27\xc9\x4c\xb9\x37\x0e\xe1\xab\x09\x1f\x11\x67\xac\xac\x2e\x4e\xbd\x97\xc4\x9f\x7c\xbe\x67\xea\x4d\xf2\x80\x6f\x49\x5d\x2a\x2e\xad\x25\xf5\x10\xf6\x1d\x93\x1c\x85\xe0\x23\x83\xde\x31\x70\x4c\x36\x8b\xdd\x63\xc7\x47\x36\xe4\x84\x2e\x35\x42\xc2\x7e\x57\xa6\xc4\x55\x6f\xec\x38\xb1\xf1\x7d\x49\x78\xa5\xb1\x12\x7b\x59\x7f\x6e\xcf\x43\x78\x16\xcf\x30\x6e\xf8\x1c\x35\x43\xa7\xc9\x55\xe8\x10\x0f\xcd\x00\x51\x6a\xfa\x4a\xb4\x0e\xaf\x8b\xe6\x62\xb3\xf8\x0b\x53\xa0\x05\x34\x78\xe6\x76\xc1\x29\xce\xdb\xdb\x9a\x1f\x7d\xdf\xad\xff\xd1\xf5\xb1\xeb\x1a\xa1\xab\x29\x26\xa3\xab\xd2\x10\xbe\xc7\x1c\x89\x35\xb3\x8d\xc8\x73\xaa\xf1\x2b\x6a\xac\xb9\x5c\xce\x88\x6e\xa3\xfb\x25\x59\x35\xbe\x27\x9d\x27\x25\xa9\x0a\x71\xc7\xde\x25\x75\x1e\x19\x3b\xf1\x37\x3c\xba\xb3\xfb\x52\xe8\x76\x23\xb7\x8a\xff\xc9\x31\x4d\x3e\x4b\x82\x48\xc4\xee\x30\x2e\x67\x75\x87\x2e\x66\x3a\x89\xd6\x77\x88\x0d\x5b\x46\x9b\x54\x72\xb2\x2b\xd2\xa4\x7a\xe6\xb1\x22\xb3\x67\xd1\xfa\xef\x00\x5a\x81\xee\x7e\x7f\x03\x00\x00")
 
 func complySoc2NarrativesProductsMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -421,12 +442,12 @@ func complySoc2NarrativesProductsMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/narratives/products.md", size: 895, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/narratives/products.md", size: 895, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2NarrativesSecurityMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x57\x5f\x6f\xe3\xc6\x11\x7f\xe7\xa7\x18\xc0\x40\x0a\x38\x96\xae\x97\x87\xa4\xf0\x9b\xaa\xbb\x22\x6e\x7d\x67\xc3\x32\x72\xe8\xe3\x88\x1c\x89\x5b\x2d\x77\xd8\x99\xa5\x54\x26\xca\x77\x2f\x66\x97\x94\x68\xdd\xf9\x82\xb4\xc8\x9d\x01\x2d\x97\xcb\xf9\xf7\x9b\xf9\xcd\x6c\xc0\x86\x6e\x61\x45\x65\x27\x2e\xf6\xb0\x90\xb2\x76\x91\xca\xd8\x09\xc1\x47\x14\xc1\xe8\xf6\x54\x60\x29\x1c\xfa\xe6\x16\x56\xef\x3f\x16\x8a\xd1\xe9\xc6\x91\xde\x16\x00\xcf\xab\xa5\xfd\x00\xcc\x60\xb9\xfc\x7e\xfe\xfd\x64\xfd\xc3\x69\xfd\xc3\xfc\xed\x64\xfd\x5d\xd1\xe0\xbf\x58\x9e\x68\xef\xd4\x71\x48\x62\x66\x50\x61\xa4\x5b\xf8\x7b\x17\xe0\x2d\x7c\xf7\xe7\xb7\x7f\x49\x1f\x94\xdc\x34\x14\xe2\x2d\xdc\x05\x17\x1d\x7a\xa8\xb8\xec\x6c\xa7\x98\xcd\x66\x45\x71\xf5\x9b\x86\x17\x3f\x92\x10\x1c\x08\x42\xda\x22\x38\xd4\x3d\x70\x27\xc0\xb2\x85\x93\x23\x10\x6b\x82\x92\x43\x14\xf6\xb0\xa3\x5e\xc1\x3b\x8d\x54\x81\x0b\xe9\xd5\x3f\x3f\xdc\xc3\xda\x73\xb9\x33\x95\xbf\xfc\x32\xff\x88\x0d\xfd\xfa\x2b\x3c\x0a\x57\x5d\x19\x5f\xe8\x2e\x8a\x77\xa4\xa5\xb8\x35\x41\x3b\xbc\xc6\xa9\x69\x35\x09\xdd\x00\x35\x6d\x8d\xea\x7e\x76\x61\x0b\x3a\xba\xe0\x9a\xd6\xbb\x12\xa3\xc5\xe4\xa5\xa2\xbb\xb0\x11\xd4\x28\xdd\xa0\xe2\xea\xea\xa4\xfb\xf2\xd5\x67\xda\xdd\x8b\x03\xaf\xa8\x6e\x08\xb5\x13\x32\xb5\x57\x57\xb0\xe8\x62\xcd\xe2\x7e\xa6\x0a\x1e\x49\x94\x43\x20\x5f\x14\x33\xb8\xbe\x5e\x7c\x5a\x81\x30\x47\xc0\xb2\xe4\x2e\xc4\xeb\x6b\x5b\x91\x2a\x38\x85\xad\x60\xb0\xa0\x71\xf0\x3d\x44\x4e\x91\x5b\x3e\x3f\x00\x86\x0a\x96\xef\x1f\x4e\x02\xee\x16\x1f\xbe\xf8\x9d\x7d\xc2\x80\xe0\x5d\xe3\xec\x79\x2b\xdc\xb5\xc0\x1b\xb8\xbe\x7e\x68\x49\x30\xb2\xe8\xf5\x75\x12\x73\x0e\xcd\x6a\xf5\xe3\x6b\xc2\x7e\xa7\xa4\x77\x7f\xfd\x5d\x82\xde\x61\x44\x98\x4a\x33\x54\xee\x9e\x3f\x03\xe4\xac\xa0\xd3\x21\xd3\x36\xec\x3d\x1f\x0c\x80\xd2\x73\x57\x81\x92\xec\x5d\x49\x0a\x1b\x16\x70\x51\xc1\x85\x48\x12\xd0\x5f\x80\x77\x6b\x20\xdc\x3b\x8d\x17\xdf\x15\xc5\x22\x9b\x9d\x83\xae\x74\x29\xd7\xe9\xc9\x03\x03\x4e\x2a\xd3\x3d\x20\x24\xec\xc9\x3c\xb2\xf5\xd9\x56\x6a\x5a\xcf\x3d\x51\x02\xcf\x29\x08\xed\x1d\x1d\xa8\x82\x7f\x77\x28\x91\xc4\xf7\x80\x0a\x07\xf2\xde\x7e\xf7\x0e\x41\x68\xdb\x79\x14\xe0\xb0\x66\x4c\x1a\xde\xf0\x66\x33\xae\x21\xa2\xee\xb2\x83\x81\x0e\x49\x6a\x45\x2d\x4a\xb4\x77\xa3\x32\x9d\xbf\x4c\xfb\x4f\x2c\x3b\x8d\x63\x41\x9c\xf7\x0f\x93\x7d\x40\x2b\x2a\x94\x8a\x82\x79\xb7\x45\x17\x34\x82\xe7\xad\x2b\xd1\x27\x3d\x6d\xdd\x6b\x7e\x88\x11\xcb\x1d\xac\xfb\x0b\x10\xc6\xe4\x4f\xe1\xe5\x84\x68\x2a\x8e\x5e\x23\x35\xd0\x74\x1a\x61\x4d\x70\x70\xb1\x76\x01\x38\x10\x6c\x29\xa4\x43\x1c\x2c\x70\x65\x27\x92\x18\x09\x36\x9d\xf7\xb3\xca\xe9\x0e\x28\x94\xd2\xb7\x76\xc2\x44\xe6\x88\x00\x86\xe8\xf6\x4e\x3a\x7d\x63\xab\x06\xfd\xc1\x6c\x57\xde\x44\x5b\x14\x33\x78\x58\x25\x83\x17\x3f\x01\x76\x91\x1b\x8c\x66\xb6\xef\xa1\x6b\x8d\x1c\xab\xa2\x98\x04\xc4\xa8\xb1\xf5\x0e\x43\x99\x4d\x1b\x80\x1f\x7d\x31\xcc\x68\x8f\xbe\xc3\x5c\x91\x80\x13\xe4\xd6\xa8\x2e\xc5\xfa\x0a\x9e\xa8\xe1\x48\x90\xf3\xa7\x28\x3e\x60\xe8\xbf\x90\x05\x9a\x42\x0e\x92\x0e\xfb\x3e\xcb\x1b\x01\x4f\xd2\x92\xe1\xa5\x31\x45\x19\x2d\xb3\x06\xf6\x31\x43\x53\x06\x8d\x09\x7d\xf7\x3c\xc4\x35\x27\x8d\x01\xa1\xd8\x98\xdd\xb1\xe6\x4a\x2d\x99\x62\xcd\x4a\x13\xd5\x83\x54\x83\x64\x23\xdc\x5c\x24\xea\x09\x5d\xde\x6c\x5c\x49\x37\xe0\xe6\x34\xbf\x81\xca\x89\x59\x32\xe0\x90\xf3\x7e\xa8\x90\x97\xb5\x31\x87\xbb\x68\xc1\x32\xa9\xa3\xce\x3f\x59\xbe\x6b\xcb\x41\xdd\xda\x79\xe3\xc7\xc8\x40\xc1\x02\x0b\xb1\xc6\x98\x19\x0e\xcf\x2c\xd9\x8e\x2c\x69\x35\x3e\x31\x4e\x48\xb9\x13\xab\x40\x0b\xc2\x60\xc2\xf9\xf5\x10\x89\x94\xf5\x43\x05\x3f\xa5\x3a\x9b\x16\xf4\xf9\xf8\x25\x93\xaf\x39\xd6\xe7\xc8\xa6\x54\xcf\x51\xbf\x79\xad\x62\x13\x12\x58\x5a\x67\x34\x4b\x25\x57\x8f\xc1\xba\xa7\x6a\x0e\x8b\x60\x67\xb8\x41\x6f\x3d\x31\xbf\x6a\x59\x46\x6e\x36\xac\xc6\x86\x11\x09\x9b\x54\xcd\x9b\x4e\x62\x4d\x02\x2e\xec\x49\xa3\xdb\xa6\xe4\x9c\xc3\xa7\x9a\xc2\x04\x43\x8d\x28\x11\x58\x86\xa2\xbf\x01\x0c\xaf\x11\x45\x2b\x5c\x52\x65\xa1\x76\x3a\x14\x69\xd6\xdf\x0a\xe7\x81\x61\x90\x73\x7a\xc4\xb6\x15\x6e\xc5\x59\x7b\x1f\xfa\xd2\x10\xeb\x14\xd9\x47\x0a\x14\x87\x72\x7d\x36\x1b\xc3\x76\x4a\x26\x36\x62\x38\xd5\xcc\x25\x01\xe8\x3f\x43\x3c\xdb\xc9\x67\x91\x34\xa6\xa4\xb7\xbf\xd0\xa1\x1f\x4a\x08\x16\xde\xc3\xc6\x05\x33\x3c\x07\xcc\x35\x0d\x55\x66\x89\xef\xcf\x08\x24\xf0\xab\x4a\x48\x75\x12\xca\x34\x7c\x60\x39\xd2\xc8\xd0\x2d\xdf\x2c\xdf\x3f\x5c\xd0\xe0\xe3\x98\xe2\xe3\xb0\x33\x35\xbf\x46\x4d\x9c\x74\xaa\x03\xcf\x79\x84\xb8\xb1\xe1\x65\x85\x01\xfe\x26\x18\x4a\xa7\x25\xdf\xc0\x72\x31\x87\x7f\x50\x0f\x4e\xb5\x4b\xcc\x61\x89\x2f\x58\xee\xa8\x1a\x69\xf1\x21\x95\xd1\xe7\x3a\xe1\x91\xbd\x2b\x7b\xb8\xa7\x6a\x4b\x32\x1f\xcf\xa5\x61\xc9\x1c\xc7\xaa\x72\xa6\x36\x11\x56\x4d\xfe\x24\xd0\x93\x2a\xcb\x8d\xc1\xd7\x92\xd8\xac\x81\x01\xb7\x64\x43\xdc\x4d\x66\x8d\x4e\x23\x57\x36\xdc\x69\xc4\xcd\x66\x0e\xcf\x89\xc9\x4e\x92\x03\xc7\xff\xd5\xc8\x73\x98\xb2\x52\xd1\x91\xb5\x4e\xf0\x4c\xda\x43\x2e\xb9\x56\xdc\xde\x79\xda\xa6\x6e\xf4\x5a\xf1\xa5\x8e\x6a\x81\xa6\x6a\x6c\x0c\x8b\x4f\xab\xa9\xc2\x8a\x49\x93\xe9\x35\xee\xe9\x33\x25\x91\xed\xfc\x85\xd0\x04\xfb\x93\xb5\x8f\x85\x2a\xa9\xa6\x39\x77\x3a\x3f\xa4\x36\xa0\x69\x42\x58\xf6\x6b\x92\xcb\xc3\x5f\x48\x51\x4b\x02\x96\x8a\xc4\x54\xee\x88\x5a\x68\x71\xd2\x31\x80\xf6\xec\xf7\xa9\x3f\xd7\x42\x18\xc1\x63\xa8\xb4\xc4\x96\x12\x0c\x93\x26\x99\x28\x3e\x55\x79\x88\x2c\xbd\x65\x2c\x56\x7b\x12\x45\x71\x03\xfb\x04\x0e\xb3\xe9\x5e\x16\x69\x9c\xae\xa7\xcc\x5f\xa7\x59\xc3\x35\x46\x2b\x29\x03\xa7\x0c\x97\x5b\xd2\x62\x22\xe2\x39\x8b\x28\x8a\x97\xc6\x08\xb5\x42\x4a\x21\x66\xde\x7e\xd5\xaa\xc1\x82\xdb\xa2\x38\x66\x49\xc7\x55\xe2\xe3\xe3\x4f\x54\x46\x96\xe3\x33\xca\x96\xe2\xf1\xde\xed\xc8\xbb\x9a\xb9\x3a\xae\x68\x4f\x96\x49\xc7\xe2\x38\xfb\xca\xbf\x6f\xbf\xf2\xf8\xed\xd7\x4e\x0f\xcb\x63\x71\x84\xc9\xff\xe4\xf7\x47\x0e\xb3\xff\xd3\xf7\x57\x10\x98\xf8\xff\x47\x3a\xfe\x5b\x7e\xa6\xf4\xa6\x0d\x09\x85\x32\x5f\x35\xce\x17\x35\x2d\x8a\xe1\x46\x93\x7b\xe5\x6a\x1c\x5d\xcf\x57\xb9\x55\x9e\xc7\x5e\xbb\xe8\xd9\x9d\xc8\xaa\xdf\x99\xac\xf7\xa7\xf9\x6b\xa0\x84\xe2\x9e\xb7\xf0\xe1\xc4\x3c\xe3\xee\x40\x24\x17\xfc\x51\xbc\x98\x8a\xc6\xcd\xd3\xa1\xbb\x50\xba\xca\x84\x3c\xe5\x21\x81\xc6\x13\xd3\xf1\x6c\xd8\x1a\xae\x6a\xb9\xa3\xd9\xa0\xde\xb6\xbe\xb7\x41\xef\x11\x63\x59\x93\x16\xb9\xe1\xc3\x37\xb0\xf4\x84\x02\xf7\x7c\x98\x3d\x8a\xe3\x7c\xa7\xf5\x24\xf1\x74\x64\x98\xd1\x86\xa7\x77\x94\xe3\xf3\xcd\xcb\x29\xf9\xbf\x01\x00\x00\xff\xff\xa2\xda\x57\x64\xcf\x0f\x00\x00")
+var _complySoc2NarrativesSecurityMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x57\xdf\x6f\x23\xc7\x0d\x7e\xdf\xbf\x82\x80\x80\x14\x70\x2c\x5d\x2f\x0f\x49\xe1\x37\xd5\x77\x45\xdc\xfa\xce\xc6\xc9\xc8\xa1\x8f\xf4\x2c\xa5\x9d\x7a\x76\xb8\x25\x67\xa5\x6e\xa2\xfc\xef\x05\x67\x7f\x68\xa5\x3b\xa7\x48\x8b\xda\x0f\x9a\x9d\x99\x25\x39\xdf\x47\x7e\xc3\x8d\x58\xd3\x0d\x6c\xc8\xb5\xe2\x53\x07\x6b\x71\x95\x4f\xe4\x52\x2b\x04\x1f\x51\x04\x93\xdf\x53\x81\x4e\x38\x76\xf5\x0d\x6c\xde\x7f\x2c\x14\x93\xd7\xad\x27\xbd\x29\x00\x9e\x36\xb7\xf6\x03\xb0\x84\xdb\xdb\xef\x57\xdf\xcf\xc6\x3f\x4c\xe3\x1f\x56\x6f\x67\xe3\xef\x8a\x1a\xff\xc1\xf2\x89\xf6\x5e\x3d\xc7\x6c\x66\x09\x25\x26\xba\x81\xbf\xb6\x11\xde\xc2\x77\x7f\x7c\xfb\xa7\xfc\x82\xe3\xba\xa6\x98\x6e\xe0\x2e\xfa\xe4\x31\x40\xc9\xae\xb5\x99\x62\xb9\x5c\x16\xc5\xe2\x3f\x06\x5e\xfc\x48\x42\x70\x20\x88\x79\x8a\xe0\x50\x75\xc0\xad\x00\xcb\x0e\xa6\x83\x40\xaa\x08\x1c\xc7\x24\x1c\xe0\x85\x3a\x85\xe0\x35\x51\x09\x3e\xe6\xa5\xbf\x7f\xb8\x87\xe7\xc0\xee\xc5\x5c\xfe\xf2\xcb\xea\x23\xd6\xf4\xeb\xaf\xf0\x28\x5c\xb6\x2e\x9d\xf9\x2e\x8a\x77\xa4\x4e\xfc\x33\x41\x33\x2c\xe3\x3c\xb4\x8a\x84\xae\x81\xea\xa6\x42\xf5\x3f\xfb\xb8\x03\x1d\x8f\xe0\xeb\x26\x78\x87\xc9\x30\x39\x77\x74\x17\xb7\x82\x9a\xa4\x1d\x5c\x2c\x16\x93\xef\xcb\xa5\x2f\xbc\xfb\xb3\x0d\xaf\xb8\xae\x09\xb5\x15\xd2\xa2\x58\x2c\x16\xb0\x6e\x53\xc5\xe2\x7f\xa6\x12\x1e\x49\x94\x63\xa4\x50\x14\x4b\xb8\xba\x5a\x7f\xde\x80\x30\x27\x40\xe7\xb8\x8d\xe9\xea\xca\x46\xa4\x0a\x5e\x61\x27\x18\x0d\x34\x8e\xa1\x83\xc4\x19\xb9\xdb\xa7\x07\xc0\x58\xc2\xed\xfb\x87\xc9\xc0\xdd\xfa\xc3\x57\xdf\xb3\x57\x18\x10\x82\xaf\xbd\xd9\xd9\x09\xb7\x0d\xf0\x16\xae\xae\x1e\x1a\x12\x4c\x2c\x7a\x75\x95\xcd\x9c\xa0\xd9\x6c\x7e\x7c\xcd\xd8\xef\xb4\xf4\xee\xcf\xbf\xcb\xd0\x3b\x4c\x08\x73\x6b\xc5\x62\x01\x77\x4f\x5f\x70\x75\x72\xd0\xea\x90\x69\x5b\x0e\x81\x0f\x46\x80\x0b\xdc\x96\xa0\x24\x7b\xef\x48\x61\xcb\x02\x3e\x29\xf8\x98\x48\x22\x86\x0b\xf2\x6e\x8c\x84\x7b\xaf\xe9\xe2\xbd\xa2\x58\xf7\x61\x1b\x82\x15\x29\x5d\xda\xf5\x3a\x9d\xc0\x88\x93\xd2\x7c\x0f\x0c\x09\x07\x32\x90\x8d\xad\x53\xac\x54\x37\x81\x3b\xa2\x4c\x9e\x57\x10\xda\x7b\x3a\x50\x09\xff\x6c\x51\x12\x49\xe8\x00\x15\x0e\x14\x82\xfd\xee\x3d\x82\xd0\xae\x0d\x28\xc0\xf1\x99\x31\x7b\x78\xc3\xdb\xed\x38\x86\x84\xfa\xd2\x1f\x30\xd2\x21\x5b\x2d\xa9\x41\x49\x16\xc9\xe8\x4c\x57\xe7\x69\xff\x99\xe5\x45\xd3\x58\x10\xa7\xf9\xc3\x6c\x1e\x50\x08\x2a\x94\x92\x22\x95\x80\x3b\xf4\x51\x13\x04\xde\x79\x87\x21\xfb\x69\xaa\x4e\xfb\x87\x94\xd0\xbd\xc0\x73\x77\x41\xc2\x98\xfc\x19\x5e\xce\x8c\x5a\x54\xda\x69\xa2\x1a\xea\x56\x13\x3c\x13\x1c\x7c\xaa\x7c\x04\x8e\x04\x3b\x8a\x79\x13\x47\x03\xce\xb5\x22\x59\x91\x60\xdb\x86\xb0\x2c\xbd\xbe\x00\x45\x27\x5d\x63\x01\x9a\xc9\x1e\x11\xc0\x98\xfc\xde\x4b\xab\x6f\x6c\x54\x63\x38\x58\xec\xca\xdb\x64\x83\x62\x09\x0f\x9b\x1c\xf0\xfa\x27\xc0\x36\x71\x8d\xc9\xc2\x0e\x1d\xb4\x8d\x89\x63\x59\x14\x33\x40\xc0\xb1\xc9\x05\x46\xd7\x87\x36\x10\x3f\x9e\xc5\xea\x91\xf6\x18\x5a\x7b\x11\x38\x02\xce\x98\x7b\x46\xf5\x19\xeb\x05\x7c\xa2\x9a\x13\x41\x9f\x3f\x45\xf1\x01\x63\xf7\x95\x2c\xd0\x0c\x39\x48\xde\x1c\xba\xde\xde\x48\x78\xb6\x96\x03\x77\xa6\x14\x2e\x59\xc9\x0c\xea\x63\x81\xda\xca\x94\xd0\x77\x4f\x03\xae\x7d\xd2\x18\x11\x8a\x35\x41\x4d\xa9\xe2\x52\x2d\x99\x52\xc5\x4a\x33\xd7\x83\x55\xa3\x64\x2b\x5c\x5f\x24\xea\xc4\x2e\x6f\xb7\xde\xd1\x35\xf8\x15\xad\xae\xa1\xf4\x42\x2e\x8d\x3c\x58\x66\x4c\x15\x72\x5e\x1b\x2b\xb8\x4b\x06\x56\xaa\x4e\x3e\xff\x60\xf9\xae\x0d\x47\xf5\xcf\x3e\x98\x34\x27\x06\x8a\xa6\x90\x90\x2a\x4c\xbd\xc2\xe1\x49\x25\x9b\x51\x25\xa1\xd5\x79\x70\x42\xca\xad\x58\x05\x62\x9c\x42\x38\x2d\x0f\x48\x18\x13\x03\x03\x60\x97\x22\x1d\xe6\x05\x7d\xda\x7e\xa9\xe4\xcf\x9c\xaa\x13\xb2\xe6\x61\x40\xfd\xfa\xb5\x8a\xcd\x4c\xa0\xb3\x9b\xd1\x22\x95\xbe\x7a\x8c\xd6\x3d\x95\x2b\x58\xc7\x0e\x30\x72\x8d\xc1\xee\xc4\x7e\xa9\x61\x19\x54\xd0\x10\x9a\x2e\x8c\x44\x58\xe7\x6a\xde\xb6\x92\x2a\x12\xf0\x71\x4f\x9a\xfc\x2e\x27\xe7\x0a\x3e\x57\x14\x67\x1c\x6a\x42\x49\xc0\x32\x14\xfd\x35\x60\x7c\x4d\x28\x1a\x61\x47\xa5\x41\xed\x75\x28\xd2\xde\x7f\x23\xdc\x37\x0c\x83\x9d\xe9\x11\x9b\x46\xb8\x11\x8f\x89\xc6\x7b\x69\xc0\x3a\x23\xfb\x48\x91\xd2\x50\xae\x4f\x16\x63\xdc\xcd\xc5\xc4\x5a\x0c\xaf\x66\xc8\x58\x02\xfa\xd7\x80\x67\x33\x7b\x2d\x91\x1a\xe9\xb6\x8e\x31\xb6\x18\xfa\xa4\x5f\xc1\x3a\x04\xd8\xfa\x68\x81\xf7\x80\xf9\xba\xa6\xd2\x22\x09\xdd\x89\x01\xa3\x06\xcb\x52\x48\x75\x06\x65\x6e\x3e\x8c\x0c\x3b\xd1\x76\xbc\x2d\xdf\xdc\xbe\x7f\xb8\x90\xc1\xc7\x31\xc5\xc7\x66\x67\x1e\x7e\x85\x9a\x35\x69\xaa\x83\xc0\x2e\x9f\xf5\xda\x9a\x97\x0d\x46\xf8\x8b\x60\x74\x5e\x1d\x5f\xc3\xed\x7a\x05\x7f\xa3\x0e\xbc\x6a\x9b\x95\xc3\x12\x5f\xd0\xbd\x50\x39\xca\xe2\x43\x2e\xa3\x2f\x7d\xc2\x23\x07\xef\x3a\xb8\xa7\x72\x47\xb2\x1a\xf7\xe5\x66\xc9\x0e\x8e\x65\xe9\xcd\x6d\x16\xac\x8a\xc2\x64\x30\x90\x2a\xcb\xb5\xf5\x23\x0d\x89\xf5\x1a\x18\x71\x47\xd6\xc4\x59\x1a\x94\xe0\x5a\x4d\x5c\x5a\x73\xa7\x09\xb7\xdb\x15\x3c\x65\x25\x9b\x2c\x47\x4e\xff\x6d\x90\x27\x98\x7a\xa7\xa2\xa3\x6a\x4d\xf4\x9c\x80\x1b\xca\xb3\x11\xbf\xf7\x81\x76\xa4\xab\x39\xce\xe7\xc5\x67\x05\x96\x81\xa6\x72\xbc\x18\xd6\x9f\x37\x73\x87\x25\x93\xe6\xd0\x2b\xdc\xd3\x17\x4e\x12\x83\xb5\x52\xe7\x46\x33\xed\x9f\xec\xfa\x58\xab\x92\xaa\x41\x34\x0f\xa1\xbf\x06\x34\x77\x08\xb7\xdd\x33\xc9\xe5\xe6\xaf\xa4\xa8\x25\x01\x4b\x49\x62\x69\xf7\x42\xd4\x40\x83\xb3\x1b\x03\x68\xcf\x61\x6f\xd2\x9a\x2a\x21\x4c\x10\x30\x96\xea\xb0\xa1\x4c\xc3\xec\x92\xf4\xb9\x3a\xac\xca\x63\x62\xe9\x2c\x63\xb1\xdc\x93\x28\x8a\x1f\xd4\x27\x72\x5c\xce\xe7\x7a\x93\xa6\xe9\x3a\x65\xfe\x73\xee\x35\x7c\x6d\xb2\x92\x33\x70\xae\x70\x06\xc0\x02\xd6\x33\x13\x4f\xbd\x89\xa2\x38\x0f\x46\xa8\x11\x52\x8a\xa9\xd7\xed\x57\xa3\x1a\x22\xb8\x29\x8a\x63\x6f\xe9\xb8\xc9\x7a\x7c\xfc\x89\x5c\x62\x39\x3e\xa1\xec\x28\x1d\xef\xfd\x0b\x05\x5f\x31\x97\xc7\x0d\xed\xc9\x32\xe9\x58\x1c\x97\xbf\xf1\xf7\xed\x6f\x3c\x5e\xac\x9d\xcf\x0c\xc3\x63\x71\x84\xd9\x7f\x3e\xf7\x47\x8e\xcb\xff\xf1\xec\xaf\x30\x30\x3b\xff\xff\xf3\xe0\xcb\x6f\xbf\x18\x9e\x9d\x33\xa7\x37\x6d\x49\x28\xe6\xa6\x75\xb1\x38\x7d\xa8\x69\x51\x0c\x5f\x34\x96\x67\x25\x6c\xc6\xd6\xf5\xf4\x29\xb7\xc9\xb7\xe5\xab\x1f\x7a\xf6\x4d\x64\xd5\xef\xcd\xf4\xfb\xa9\xff\xea\x27\xbb\xe2\x9e\x77\xf0\x61\x52\x9e\x71\x76\x10\x92\x0b\xfd\x28\xce\xba\xa2\x71\x72\xda\x74\x17\x9d\x2f\xcd\xc8\xa7\xbe\x49\xa0\x71\xc7\xbc\x3d\x1b\xa6\x8c\xd8\xc7\xf1\x46\xd3\xa2\x58\x37\x4d\xe8\xac\xd1\x7b\xc4\xe4\x2a\xd2\xa2\xbf\xf0\xe1\x1b\xb8\x0d\x84\x02\xf7\x7c\x58\x3e\x8a\xe7\xec\x68\x1d\x48\xd2\xb4\x65\xe8\xd1\x86\xa7\x77\xd4\xe3\xf3\xcd\x79\x97\xfc\xef\x01\x00\xa2\xda\x57\x64\xcf\x0f\x00\x00")
 
 func complySoc2NarrativesSecurityMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -441,12 +462,12 @@ func complySoc2NarrativesSecurityMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/narratives/security.md", size: 4047, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/narratives/security.md", size: 4047, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2NarrativesSystemMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\xcd\x31\x4e\xc3\x40\x10\x85\xe1\x7e\x4e\xf1\x24\x6a\x4b\x84\x0a\x6d\x17\xd1\x00\x82\x14\x98\x86\x72\x59\x0f\xf1\x90\xdd\x19\x34\x3b\x4e\xe4\xdb\x23\xe7\x02\x69\xff\x27\xbd\x4f\x73\xe3\x84\x71\xed\xc1\x0d\x7b\x2f\xb3\x04\x97\x58\x9c\x71\xc8\xee\x39\xe4\xcc\x94\x8b\x9b\xae\x2d\x61\xdc\x1f\xa8\xe5\x5f\xf3\x0f\x3e\x4b\x17\xd3\x9e\x08\x18\x30\xe5\xe0\x84\xd7\x45\xb1\xc3\xc3\xfd\xee\x91\x00\xa0\x58\x6b\xac\x91\xf0\xa2\x12\x92\x2b\x26\x2b\xcb\x56\x68\x18\x06\xa2\xbb\x1b\x28\x3d\xb3\x33\x2e\x0c\xbd\x26\xc6\x65\x5e\x61\x8b\xc3\xfc\x88\x9e\x43\xfa\x8f\x70\x47\xcc\x8c\x62\x1a\x6e\x15\x27\x5e\x3b\xaa\xf4\xe0\x09\xa2\xd7\xe9\xeb\xfd\x0d\xdf\xd5\xca\x69\x03\x3f\xb9\xfd\xd5\xed\xea\xc9\x9a\xe8\x11\xa3\x99\xd2\x7f\x00\x00\x00\xff\xff\xf2\x34\x0d\x1a\x01\x01\x00\x00")
+var _complySoc2NarrativesSystemMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\xcd\xb1\x4e\xc3\x40\x0c\x87\xf1\xfd\x9e\xe2\x2f\x31\x47\xa2\x4c\xe8\xb6\x8a\x05\x10\x74\x20\x2c\x8c\xe6\x62\x1a\xd3\x3b\x1b\xf9\x9c\x56\x79\x7b\xd4\xbc\x40\xd7\xef\x1b\x7e\x4a\x8d\x33\xc6\xb5\x07\x37\xec\xbd\xcc\x12\x5c\x62\x71\xc6\x81\xdc\x29\xe4\xcc\x89\x8a\x9b\xae\x2d\x63\xdc\x1f\x52\xa3\x5f\xf3\x0f\x3e\x4b\x17\xd3\x9e\x13\x30\x60\xa2\xe0\x8c\xd7\x45\xb1\xc3\xc3\xfd\xee\x31\x01\x40\xb1\xd6\x58\x23\xe3\x45\x25\x84\x2a\x26\x2b\xcb\xb5\xa4\x61\x18\x52\xba\xbb\x81\xa6\x67\x76\xc6\x85\xa1\x5b\x62\x5c\xe6\x15\xb6\x38\xcc\x8f\xe8\x14\xd2\x7f\x84\x3b\x62\x66\x14\xd3\x70\xab\x38\xf1\xda\x51\xa5\x07\x4f\x10\xdd\xd6\xd7\xfb\x1b\xbe\xab\x95\xd3\x15\xfc\xe4\xf6\x57\x29\x18\x4f\xd6\x44\x8f\x18\xcd\x34\xfd\x0f\x00\xf2\x34\x0d\x1a\x01\x01\x00\x00")
 
 func complySoc2NarrativesSystemMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -461,12 +482,12 @@ func complySoc2NarrativesSystemMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/narratives/system.md", size: 257, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/narratives/system.md", size: 257, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x34\xc6\x41\x0a\x80\x20\x10\x05\xd0\xbd\xa7\xf8\xd0\xbe\xb3\x74\x05\xb3\x5f\x0a\xe6\x8f\x99\x41\xe8\xf6\xad\xda\xbd\x05\x9b\x7a\x2b\x8d\x9e\xd2\x2f\x5c\x9a\xb4\x81\xa8\xc4\xce\x9a\x67\x93\x41\x27\x78\x3f\x5d\x2f\xe9\xc8\xe3\x40\xd1\x08\xcb\x25\x64\xbe\xa6\x2f\x00\x00\xff\xff\x45\x5c\x41\xeb\x47\x00\x00\x00")
+var _complySoc2PoliciesReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x47\x00\xb8\xff\x23\x20\x50\x6f\x6c\x69\x63\x69\x65\x73\x0a\x0a\x50\x6f\x6c\x69\x63\x69\x65\x73\x20\x67\x6f\x76\x65\x72\x6e\x20\x74\x68\x65\x20\x62\x65\x68\x61\x76\x69\x6f\x72\x20\x6f\x66\x20\x65\x6d\x70\x6c\x6f\x79\x65\x65\x73\x20\x61\x6e\x64\x20\x63\x6f\x6e\x74\x72\x61\x63\x74\x6f\x72\x73\x2e\x0a\x03\x00\x45\x5c\x41\xeb\x47\x00\x00\x00")
 
 func complySoc2PoliciesReadmeMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -481,12 +502,12 @@ func complySoc2PoliciesReadmeMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/README.md", size: 71, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/README.md", size: 71, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesAccessMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x56\xdd\x6e\xdc\xca\x0d\xbe\xdf\xa7\x20\x70\x2e\xda\x06\xf6\xf6\xc4\x05\x8a\xc2\x77\xa7\x4e\x00\xbb\x40\x11\xc3\x59\x20\xd7\xb3\x23\x6a\xc5\x78\x44\xaa\xe4\x68\xb7\xf2\x55\x5f\xa3\xaf\xd7\x27\x29\x38\x23\xed\x8f\x1b\x3b\xbd\x5a\xed\x48\x24\x3f\xf2\xfb\x48\x0e\x87\x1e\x6f\xe1\xb7\x18\xd1\x0c\xbe\xf0\x56\x82\x36\xc4\x3b\x08\xdc\xc0\x06\xb5\x27\x0e\x99\x84\xe1\x51\x12\xc5\x69\x15\xa2\x0a\x4f\xfd\x2d\xfc\xf6\x65\xf3\xb8\xb2\x90\xc9\x5a\x42\xbb\x5d\x01\x6c\xbe\xde\xf9\x0f\xc0\x35\xdc\xdd\xfd\x79\xfd\xf1\xec\xf9\xe6\xec\xf9\x4f\xab\x3e\x7c\x17\x7d\xc2\x3d\x19\x09\x17\xd3\x6b\x68\x42\xc6\x5b\xf8\xdb\xc8\xf0\x11\x6e\x7e\xfd\xf8\x97\x62\x10\xa5\xef\x91\xf3\x2d\x3c\x30\x65\x0a\x09\x1a\x89\xa3\x9f\xac\xae\xaf\xaf\x57\xbf\xc0\xe3\xa8\x83\x18\x16\xac\x5f\xa3\x0c\xb8\x5a\x85\x35\x6c\x3a\x84\x61\x7e\x23\x2d\xe4\x8e\x0c\x86\x82\x1e\xc8\x20\x0b\x34\xd8\x12\x23\x0c\x2a\x11\x9b\x51\xb1\x1c\x4a\x4d\xbd\xf8\x92\xb6\xad\x7f\x46\x43\x2d\x6f\x33\xc6\x8e\x29\x86\x04\xc4\xad\x06\xcb\x3a\xc6\x3c\x2a\x02\x31\x04\xe8\x03\x33\x2a\xe4\x2e\x64\xe8\x89\xa9\xa7\x17\x77\xda\x21\x28\xd9\xb3\x83\x20\x6e\x45\xfb\x5a\xc9\x24\x66\x20\x0a\xf8\xcf\x41\x6c\x54\x5c\xc3\x0c\xfb\x84\x33\x0c\x43\xa2\x8a\x2b\xa4\xf4\x76\xf4\x03\xe5\x8e\xb8\x44\x12\xdd\x05\xa6\x97\x12\xe2\xe7\x1e\xdb\x31\xa5\xeb\x4c\x7d\xad\xdd\x10\x34\xd7\x7f\xd8\x0f\x49\x26\x44\x2b\xe7\x51\x38\x6b\x88\x59\xd4\xdc\xe5\x2f\xf0\xd7\x10\x9f\x77\x2a\x23\x37\x25\xc0\x03\x83\x68\xe3\x99\xcb\x31\xef\xff\x3b\x6d\xf8\x7d\xab\xd2\xc3\x56\x72\x07\xc4\x46\x4d\xc5\x22\x63\x2e\xcf\xaf\x73\xfa\xc3\xd5\xff\x1c\x39\x9d\x8a\x89\x02\x67\x90\x5a\x85\x41\x89\x23\x0d\xa9\x30\x9f\x30\x58\xf6\xa3\x3d\x25\xdc\xe1\xda\x55\x2e\x23\x67\x88\x8a\xd5\x41\x49\xde\x45\x6e\x56\x20\xe2\x1e\x93\x41\x50\x04\x45\xcb\x4a\x31\x63\x53\xc5\x91\xa6\x9a\x18\x9a\x8c\x1a\xbd\x3e\x5b\x93\x34\x66\x4c\x13\x30\x62\x53\xbf\x1b\x50\x3d\x61\xc0\x10\x3b\xff\x63\xc2\xff\xf9\xd7\xbf\x0d\xbe\xcb\x16\x9a\x31\x13\xda\x1a\xbe\x75\xe8\x9a\x71\x69\x95\x77\x2a\xe9\x4d\x22\x21\x76\x81\x77\x68\x9e\x7b\x11\x7a\x4d\xc0\xde\x01\x5e\x2d\x9a\x3f\x2a\xee\xe5\xb9\xa2\x6a\x29\x17\xcf\x8c\x87\x1a\xcd\xad\x1b\xb2\xb0\x4d\xd8\xc0\xc1\xf1\xf8\x6b\x47\xe4\x35\xdb\xcf\xda\xbd\x00\x12\x52\x96\x1d\xe6\x0e\xb5\x2a\x61\x1e\x07\xae\x82\x0f\x9f\x46\xf5\x89\x21\xc7\xe1\x71\xfb\x61\x55\xfa\x97\xd6\x70\x4f\xe5\xdd\xdf\x03\x87\x1d\xea\x2c\x08\x83\xfb\x27\x18\x07\x61\xe8\x48\x0b\x55\xa1\x80\x5b\xd4\xb7\x3e\x99\x3f\x01\xf6\x81\x92\xc1\xc3\xc6\x53\xa9\xf6\x0e\xaf\x3f\x99\x15\x27\x9e\x53\xee\x90\xb4\xa4\xe8\x20\x67\x17\x0f\x9b\xca\xb7\x73\x06\xb1\xc3\xf8\x9c\xc8\x72\xb1\x7e\xbf\x9a\x33\xad\xad\xcc\x9d\xfd\xca\xaf\x8f\x19\x39\x78\xdf\x4b\x5b\x09\x5f\xc4\x01\x8a\x7b\xc2\x43\xf5\x1b\x86\x41\xc5\x4b\x1a\x7e\x24\x3e\xaf\xf3\x2a\x98\x49\xa4\xe0\x5a\x3b\xa1\xb0\xcb\x14\x0e\xa2\xcf\x56\x64\x52\xb9\xf9\x71\xe0\x2c\x60\x98\x61\x1c\x8e\x84\xce\xa3\xe0\xc8\xd1\x3c\xd9\xde\x25\x89\x25\x93\x0f\x75\xaf\x7e\x51\x47\xe0\x23\x35\xd0\x05\x83\x2d\xba\x64\xe6\xe5\x80\xcd\x19\xd2\xfb\x27\x30\xe4\xc6\x4b\x7d\x40\x7c\x4e\x53\xa5\x0f\x14\x07\xd1\xec\xf8\x1e\x36\x60\x63\xdf\x07\xa5\x17\x8f\xb9\x70\x31\x4f\xda\xa3\xcf\x52\x1d\xe2\x3a\xea\x16\xf6\x67\xcd\xce\x3c\x87\xb2\xb3\x2e\xcb\x74\x74\x60\xf3\xeb\xa5\xb3\x5a\xda\x23\x6c\x47\x23\xf6\xc3\x26\x4c\x06\x65\xfc\x28\x46\xa4\xa1\x40\xa8\x69\xc7\xf3\x09\xfa\xe1\xdb\xeb\xf4\xe7\x86\x2c\x62\xb0\xb7\xda\xf6\xed\xd2\x1e\x28\xa5\x45\xc4\xf7\x4f\x55\xc2\xd5\xa5\x6f\x92\xa2\xb0\x62\x79\x56\x4f\x2f\x84\x0b\xc0\x2d\x5b\x49\x49\x0e\x25\x9e\x85\x1e\xc1\x32\x0e\x06\xc1\x7c\x6a\x26\x62\xf4\x92\x55\x34\x97\x8b\xfc\x8c\xf6\xb3\xb5\xb7\x24\xf9\x54\xe4\xfa\x4e\x47\xd8\x59\x42\x9f\x5d\x70\xbd\x70\xee\xae\x1c\x96\x7f\xe8\x32\x71\x74\x55\xf6\x3f\x6b\x2b\xef\xa7\x10\xe3\xa8\x21\x4e\x97\xc9\xce\x97\x90\xa5\x7b\xfa\xd1\x32\xe0\x3e\xa4\x31\x64\x2c\x5b\xab\x4c\xa8\x3c\x0d\x68\x40\x1c\xd3\x58\xf2\x39\xae\xac\xab\xf3\x7d\x75\x05\x7b\xe4\xa6\x3c\x38\x0c\x9b\x2c\x63\x7f\x84\x76\x19\x77\xd3\x2d\x2d\x5b\x8b\x63\x73\xec\x3d\x2a\xb5\x53\x6d\xfe\xb0\x60\xeb\x03\xb1\xd5\xae\x1e\xd4\x9b\x76\x1e\x10\xb5\xdd\x7e\x67\x10\x47\x55\xe4\x7c\x9a\xb4\x8a\x36\x08\x1b\x6d\x29\x51\x59\x01\x97\x49\xf3\x04\xc4\xe7\xfe\x44\x61\x64\x46\x8f\x17\x74\x5a\x22\x17\x44\xdb\x02\xb4\xcc\x75\xea\x7b\x6c\xfc\xfb\x34\xad\x2b\x8d\x9f\xb9\x0b\x1c\xb1\x59\xea\x78\xe7\xd5\x90\xb9\xe2\x37\xbf\xde\xdc\xf8\x0d\x0d\xee\xa4\x1f\x7c\x65\x46\x3c\x23\x75\xb6\x88\x8b\xc5\x12\x6c\xa9\x7e\x03\x7e\xef\x33\x2b\x2c\xbc\xba\x83\x44\xe9\x07\x61\x74\xc2\x4f\xa4\x18\xea\x1e\xbd\xf8\x4d\xc8\x61\x1b\xcc\xd9\x29\x57\x90\xda\x5e\x33\x2b\x8c\xd9\xe7\x1a\x34\xb8\xa7\xf8\xba\x30\x8f\xcb\xde\x6e\x5e\x97\xa0\x6e\xe6\x34\x2d\x78\xcb\x16\xf3\xc9\x18\x9a\x86\xdc\x7d\x48\x10\xc6\xdc\x21\xe7\x39\x1e\x28\xfe\x63\x24\xc5\x1e\x17\x5d\xf6\xc2\x94\xc5\x7b\xf3\x32\xea\xd7\x4b\xa1\x54\xf1\xa0\x3a\xbe\xd3\xe1\x02\x84\x78\x8f\xec\x5e\xb0\xb9\x3a\xde\x4d\xfd\xb9\xd2\xbe\x1b\x53\xd0\x34\xcd\xda\xc2\xe6\x87\x6a\xaf\x23\x31\x8b\x97\xbd\xec\x8b\xc6\x6f\x52\x96\x35\x64\x1f\x59\xc4\x19\xb5\x0d\x7e\xe3\x38\x29\xe0\xfc\x6a\xe2\x89\x8a\xd2\x4b\x5d\x1e\x26\xcc\x98\xca\x7d\xe5\x32\xda\x27\x5c\x52\xf0\xa0\x15\x5f\x94\x3d\xea\x74\x7e\x0f\x5e\x42\x9c\x72\xa9\x5b\x0a\xcd\x1f\x8f\x19\xf9\xe0\x58\xfd\x37\x00\x00\xff\xff\x5c\x9d\xb3\x18\x39\x0c\x00\x00")
+var _complySoc2PoliciesAccessMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x56\xcb\x6e\xdc\xc6\x12\xdd\xcf\x57\x14\xe0\xc5\xbd\xd7\x90\xe6\xda\x0a\x10\x04\xda\x39\xb2\x01\x29\x40\x60\x41\x16\xe0\x75\x4f\x77\x71\x58\x56\xb3\x8a\xa9\x6a\xce\x84\x5a\xe5\x37\xf2\x7b\xf9\x92\xa0\x9a\xe4\x68\x46\x8e\xec\xac\x44\x91\x53\xaf\x73\x4e\x3d\x38\x74\x78\x09\xef\x62\x44\x33\xf8\xc8\x1b\x09\x9a\x88\xb7\x10\x38\xc1\x3d\x6a\x47\x1c\x0a\x09\xc3\xad\x64\x8a\xe3\x2a\x44\x15\x1e\xbb\x4b\x78\xf7\xf1\xfe\x76\x65\xa1\x90\x35\x84\x76\xb9\x02\xb8\xff\x74\xe5\x7f\x00\xce\xe1\xea\xea\xc7\xf5\xdb\xa3\xe7\x8b\xa3\xe7\x1f\x56\x5d\xf8\x22\x7a\x87\x3b\x32\x12\xae\xa6\xe7\x90\x42\xc1\x4b\xf8\x65\x60\x78\x0b\x17\x6f\xde\xfe\x54\x0d\xa2\x74\x1d\x72\xb9\x84\x1b\xa6\x42\x21\x43\x92\x38\xf8\x9b\xd5\xf9\xf9\xf9\xea\x15\xdc\x0e\xda\x8b\x61\xcd\xf5\x53\x94\x1e\x57\xab\xb0\x86\xfb\x16\xa1\x9f\xbf\x48\x03\xa5\x25\x83\xbe\x66\x0f\x64\x50\x04\x12\x36\xc4\x08\xbd\x4a\xc4\x34\x28\xd6\x97\x32\x95\x5e\x7d\x49\xd3\x4c\xff\x0c\x86\x5a\xbf\x16\x8c\x2d\x53\x0c\x19\x88\x1b\x0d\x56\x74\x88\x65\x50\x04\x62\x08\xd0\x05\x66\x54\x28\x6d\x28\xd0\x11\x53\x47\x8f\xee\xb4\x45\x50\xb2\x07\x90\xc6\xad\x44\xbb\x09\xc9\x2c\x66\x20\x0a\xf8\x7b\x2f\x36\x28\xae\x61\x4e\xfb\x29\xcf\xd0\xf7\x99\xdc\x85\x40\xc8\xf9\xe5\xe8\x7b\x2a\x2d\x71\x8d\x24\xba\x0d\x4c\x8f\x35\xc4\xf7\x3d\x36\x43\xce\xe7\x85\xba\x09\xbb\x3e\x68\x99\xfe\xc3\xae\xcf\x32\x22\x5a\x7d\x1f\x85\x8b\x86\x58\x44\xcd\x5d\xbe\x82\x9f\x43\x7c\xd8\xaa\x0c\x9c\x6a\x80\x1b\x06\xd1\xe4\x95\xcb\xa1\xee\x7f\x5d\x36\xfc\xb7\x51\xe9\x60\x23\xa5\x05\x62\xa3\x34\xe5\x22\x43\xa9\xcf\xcf\x6b\xfa\xdf\xd9\x57\x65\x3a\x9d\x8a\x99\x02\x17\x90\x09\x85\x5e\x89\x23\xf5\x19\x1d\xf4\x8c\xc1\x0a\xf4\x4a\x3b\xca\xb8\xc5\xb5\xab\x5c\x06\x2e\x10\x15\x27\x07\x1e\xb0\x77\x91\x9b\x4b\x11\x32\xee\x30\x1b\x04\x45\x50\xb4\xa2\x14\x0b\xa6\x49\x1c\x79\xac\xfe\x15\x4d\x06\x8d\x8e\xcf\xc6\x24\x0f\x05\xf3\x08\x8c\x98\xa6\xdf\xf5\xa8\x8d\x68\x07\x18\x62\x0b\x3d\xaa\x09\xff\xf5\xc7\x9f\x06\x5f\x64\x03\x69\x28\x84\xb6\x86\xcf\x2d\xba\x66\x5c\x5a\xf5\x9b\x4a\x7e\x91\x48\x88\x6d\xe0\x2d\x9a\xd7\x5e\x85\x3e\x15\x60\xdf\x48\x7c\xb2\x48\xff\x57\xdc\xc9\xc3\x94\x55\x43\xa5\x7a\x66\xdc\x4f\xd1\xdc\x3a\x91\x85\x4d\xc6\x04\x7b\xcf\xc7\x3f\x7b\x46\x90\x31\xec\xd0\xbe\x4e\x24\xe4\x22\x5b\x2c\x2d\xea\xa4\x84\x79\x1c\xb8\x0a\x5e\xbf\x1f\xd4\x27\xc6\xdc\x41\xc4\xdb\xcb\xd7\xab\xda\xbf\xb4\x86\x6b\xaa\xdf\x7e\x0d\x1c\xb6\xa8\xb3\x20\x0c\xae\xef\x60\xe8\x85\xa1\x25\xad\x54\x85\x9a\xdc\xa2\xbe\xf5\x93\xf9\x1d\x60\x17\x28\x1b\xdc\xdc\x7b\x29\x93\xbd\xa7\xd7\x3d\x99\x55\x27\x5e\x53\x69\x91\xb4\x96\xb8\x86\x83\x8b\x9b\xfb\x89\x6f\xe7\x0c\x62\x8b\xf1\x21\x93\x95\x6a\xfd\x6d\x34\x67\x5a\x1b\x99\x3b\xfb\x99\x5f\x1f\x33\xb2\xf7\xbe\x97\x66\x22\x7c\x11\x07\x28\xee\x08\xf7\x93\xdf\xd0\xf7\x2a\x0e\x69\xf8\x27\xf1\x39\xee\xab\x60\x26\x91\x42\xc1\xe3\x2c\xec\xb4\x84\xbd\xe8\x83\x55\x99\x40\x79\x39\x70\x11\x30\x2c\x30\xf4\x07\x42\xd7\x70\xca\x51\xd3\x7c\x9f\x24\x96\x42\x3e\xd4\x1d\xfd\xaa\x8e\xc0\x07\x6a\xa0\x0d\x06\x1b\x74\xc9\xcc\xcb\x01\xd3\x51\xa6\xd7\x77\x60\xc8\xc9\xa1\xde\x23\x3e\xe4\x71\xa2\x0f\x14\x7b\xd1\xe2\x0c\xde\xdc\x83\x0d\x5d\x17\x94\x1e\x3d\xe6\xc2\xc5\x3c\x69\x0f\x3e\x2b\x76\xc4\xd3\xa0\x5d\xd8\x9f\x35\x3b\xf3\x1c\xea\xce\x3a\x85\xe9\x90\x54\xc5\xdb\x57\xda\xdc\x59\x0d\xed\x10\x36\x83\x11\xfb\xcb\x14\x46\x83\x3a\x7e\x14\x23\x52\x5f\xe5\x30\x95\x1d\x8f\x27\xe8\xeb\xcf\xcf\xcb\x9f\x1b\xb2\x8a\xcc\x5e\x6a\xdb\x97\xa1\xdd\x53\xce\x8b\x88\xaf\xef\x3c\x6a\x98\x3b\xd6\x37\x89\x3b\x5d\x57\xd2\x0f\xe6\x77\x15\x08\x17\x80\x5b\x36\x92\xb3\xec\x6b\x3c\x0b\x1d\x82\x15\xec\x0d\x82\x81\x0c\x25\x13\x63\x82\x25\x9b\xd3\x45\x7e\x44\xfb\xd1\xda\x5b\x8a\xf4\x55\x8c\xfb\x6f\x74\x84\x1d\x15\xf4\xc1\x95\xde\x09\x97\xf6\xcc\x01\x77\xef\x2e\x13\xcf\x6e\x92\xfd\xf7\xda\xca\xfb\x29\xc4\x38\x68\x88\xe3\x69\xb1\xf3\x11\xb2\x74\x4f\x37\x58\x01\xdc\x85\x3c\x84\x82\x75\x0f\xba\x48\xa0\x8c\x3d\x1a\x10\xc7\x3c\xb8\x8c\x0f\xd4\xd8\xd9\xf1\xbe\x3a\x83\x1d\x72\xaa\x0f\x9e\x86\x8d\x56\xb0\x3b\xa4\x76\x1a\xd7\x3b\x79\xce\xbd\x82\x63\x73\xec\x1d\x2a\x35\x3e\xf7\x43\x59\xd4\xa4\x2e\x67\x36\x5f\xa7\x2a\xbd\x7a\xd3\xd6\x82\x96\x76\xfb\x8f\x41\x1c\x54\x91\xcb\xd3\xa4\x55\xb4\x5e\xd8\x68\x43\x99\xea\x0a\x38\x2d\x9a\x47\x20\x3e\xf6\x27\x0a\x03\x33\x3a\x16\x41\xc7\x25\x72\xcd\x68\x53\x13\xad\x73\x9d\xba\x0e\x93\xc7\xcf\xe3\x7a\xa2\xf1\x03\xb7\x81\x23\xa6\x05\xc7\x2b\x47\x43\x66\xc4\x2f\xde\x5c\x5c\xf8\x85\x06\x57\xd2\xf5\xbe\x32\x23\x1e\x91\x3a\x5b\xc4\xc5\x62\x09\xb6\xa0\x9f\xc0\xef\x3e\xb3\xca\xc2\xb3\x1b\x24\x4a\xd7\x0b\x23\x97\x63\x52\x0c\x75\x87\x0e\x7e\x0a\x25\x6c\x82\xf9\x0a\xab\x47\xcd\xd4\x5e\x33\x2b\x8c\xc5\xe7\x1a\x24\xdc\x51\x7c\x0e\xcc\xed\xb2\xb7\xd3\x73\x08\xa6\xcd\x9c\xc7\x25\xdf\xba\xc5\x7c\x32\x86\x94\xc8\xdd\x87\x0c\x61\x28\x2d\x72\x99\xdb\x19\x14\x7f\x1b\x48\xd1\x6f\xc7\x49\x97\x9d\x30\x15\xf1\x99\x78\x1a\xf5\xd3\xa9\x50\xea\x6f\xbd\x18\x8a\x47\xdb\x77\x49\x84\x78\x87\xec\x5e\x30\x9d\x1d\x6e\x53\x7f\x76\x2b\xc5\xed\x90\x83\xe6\x71\xd6\x16\xa6\xd3\x48\x33\xe6\xd3\x48\x2c\xe2\x44\xb9\x59\x48\x7e\x49\x59\xd1\x50\x7c\x64\x11\x17\xd4\x26\x44\x7c\x8a\x7a\x7a\x9a\x78\xa1\xa2\xf4\x38\x2d\x0f\x13\x66\xcc\xf5\x5e\x39\x8d\xf6\x1e\x97\x12\x5c\x1a\x1e\x48\x31\xca\x0e\x75\x3c\xbe\x83\x97\x10\x4f\xb5\x54\x00\x0a\x5a\xc1\xa3\x8a\xd6\xb0\x5a\xad\xfe\x1e\x00\x5c\x9d\xb3\x18\x39\x0c\x00\x00")
 
 func complySoc2PoliciesAccessMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -501,12 +522,12 @@ func complySoc2PoliciesAccessMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/access.md", size: 3129, mode: os.FileMode(420), modTime: time.Unix(1757470844, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/access.md", size: 3129, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesApplicationMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xc4\x59\xcd\x72\xe4\xc6\x91\xbe\xf3\x29\x32\x62\x2f\xc3\x88\x66\x6b\xa5\xd8\xd8\x50\x8c\x4e\xdc\x19\xad\x45\x87\xa4\x19\x73\xc6\xd6\xb9\xba\x2a\xd1\x48\xb1\x50\x05\xd5\x4f\x37\xa1\x93\x5f\xc3\xaf\xe7\x27\x71\x64\x56\x01\x28\x34\x9b\xb2\x1c\x3e\xf8\xc4\x60\x03\x95\x95\xbf\x5f\x7e\x99\x70\x6a\xc0\xb7\x70\x3f\x8e\x96\xb4\x4a\xe4\x1d\x7c\x42\x9d\x03\xa5\x09\x3e\x7a\x4b\x7a\xba\x51\x3a\x78\x37\x0d\x6f\xe1\xfe\xd3\xc7\x9b\xa8\x12\xc5\x8e\x30\xbe\xbd\x01\xf8\xfc\xe9\x1d\xff\x01\xb8\x83\x77\xef\xfe\x77\xff\xd5\xcd\xa0\x7e\xf6\xe1\x11\x4f\x14\xc9\x3b\x79\xe5\x0e\x8c\x4a\xf8\x16\xfe\x98\x1d\x7c\x09\x5f\xfd\xf7\x97\x5f\xcb\x01\xed\x87\x01\x5d\x7a\x0b\x0f\x8e\x12\x29\x0b\xc6\xeb\xcc\xbf\xdc\xdc\xdd\xdd\xdd\xdc\xfc\x17\x7c\xcc\x61\xf4\x11\x41\x39\x03\x9f\xb4\x1f\xf1\xe6\x46\xed\xe1\x73\x4f\x11\x54\xa3\x6b\x9c\x75\x1d\x45\x57\x30\xd8\x91\xc3\x08\xa9\xc7\xf5\x59\x17\xd4\x80\x67\x1f\x9e\x44\x5a\xc0\x5f\x32\x05\xe4\xcb\x22\x74\x3e\xb4\xf2\xe2\x0e\x9c\x4f\xea\x60\x27\x38\xe3\xe1\xe2\xc9\x99\x52\x4f\x4e\x44\xfb\x70\x54\x8e\x7e\x95\x27\x7f\xff\xeb\xdf\x22\x8c\xc1\x9b\xac\x45\x25\x74\x27\x0a\xde\xb1\xfc\xfd\xaa\xf4\x6c\x1f\x28\x1b\x3d\xbf\x7e\x22\x83\x11\x68\x18\xad\xa8\x42\xee\x08\xda\xbb\x14\xbc\x8d\xa2\x26\xb9\x98\x42\x11\x59\xd4\xbc\x50\x68\x31\x6f\x07\xc9\x03\x39\x6d\xb3\x41\x18\x31\x90\x37\xa4\xe1\x94\xad\xc3\xa0\x0e\x64\xd9\x03\x51\x2b\x57\xc4\xfa\xd4\x63\x80\x34\x8d\x18\xc1\x77\x80\x27\x65\x73\x31\x50\x1e\xab\x18\x31\x46\xf1\x4d\xa3\x7c\x75\xae\xdc\xce\xce\xf5\xa0\xac\xdd\x78\xe7\x37\x9c\xf3\x8a\x6f\x76\xa0\x22\x9c\x91\xe5\x44\x50\x66\x20\x47\x31\x05\x95\x7c\x28\x9a\xe4\x88\x41\x54\x4c\x3d\x72\x1e\x34\x97\x55\xad\xd2\x34\x92\x56\xd6\x4e\xb3\xf5\x11\x70\x18\xad\x9f\x10\x8b\x08\xf1\xa7\xd2\x2c\x72\xcf\x39\xf5\x7f\x4a\x3f\x1d\x83\xcf\xce\x88\x69\x6d\xce\xb7\xee\x12\x13\x17\xd9\x4a\x6b\x9f\x5d\x92\x10\xb0\x75\x56\x85\x23\xc6\x04\x2e\x0f\x07\x0c\xac\x20\xd5\x14\x56\x29\x29\xfd\x04\x27\xd4\xc5\x88\x2e\x61\x80\x41\xd9\xb3\x0a\x08\xe4\x3a\xd4\x55\xfb\xfb\x08\x0a\x02\xc6\x6c\xd3\x0e\x28\x01\x49\x1e\xf8\x90\x94\x4b\x90\x7a\x95\xb6\xae\xe5\xe3\x06\x23\x1d\x1d\x1a\xf1\xf3\x9a\xd9\xe4\x60\x20\x67\x76\x62\xae\x9c\x4c\x3d\x4e\x72\x82\x43\xce\x07\x66\x47\x90\xcb\x3e\x47\x3b\xc1\xe0\x1d\x25\x1f\xd0\x88\x49\x83\xb2\xa4\xc9\xe7\x08\x4a\x27\x3a\xb1\x50\x91\xa3\x7d\xb6\x9c\x84\x86\xd5\x40\x50\x10\xa7\x98\x70\xe0\xc2\x1d\x83\x1f\x28\xe2\x1e\xde\x53\xd4\xfe\x84\x61\x92\x4b\x62\x3e\x44\xfc\x25\x73\x8e\x0f\x94\xe8\x58\x1c\xeb\xbb\x4d\xd2\x5e\xfa\xf9\x4c\xd6\x82\xa5\x81\xd2\xf5\xba\xaa\x3e\x8d\x39\x74\x4a\x63\xb1\x13\x5d\xcc\x81\x43\x0c\x07\x15\xd1\x92\x43\xb0\x78\x42\xcb\x77\x2d\x9e\x61\xd0\x8a\x51\xf2\xb4\x68\x5e\xf3\xf9\xc1\x81\x32\x86\x44\x99\xe4\x8b\x97\xb8\xf6\x8e\x99\x8c\x72\x7c\x45\x6a\x33\x9e\x8b\x75\xc1\x14\xd4\xbd\xe3\xac\xd8\x42\x08\xab\x34\x06\xaf\xd1\x88\x56\x2c\xb5\x68\xf8\x4a\x28\xc7\xe0\x47\x0c\x76\x82\x5e\x05\x83\x1c\x22\x72\x92\x65\x41\x14\xb8\x88\xf0\x81\x73\x6d\xe4\x2c\x26\x8d\x25\x8d\x1f\xb1\xc3\x80\x4e\x63\x14\x8b\xde\xab\xa4\xe0\x9d\x55\x31\x52\x37\xbb\xb9\x22\x37\x3f\xfe\xf0\xd3\xfd\xa7\x8f\xf0\x48\xf1\x09\x1e\x95\xc0\xcc\x0f\x98\x7a\x6f\xbc\xf5\xc7\xf6\x8d\xcf\x18\xe5\xe9\x1f\x32\x19\x6c\x7f\xf7\x23\x7c\x46\x07\x1f\x83\xff\x19\x75\x12\x6c\x5e\xa5\x7f\xbe\x08\x1a\x0c\x39\xa6\xad\xfd\x97\x48\x41\x09\x0c\x87\xcb\x8f\xe2\xbb\x2f\x18\x81\xb5\xf8\xb3\xf8\x47\x4c\x47\x3b\x71\xd6\x76\x74\xcc\xa1\x26\xf1\xa0\x9c\x3a\xa2\xd9\x2f\xf7\x76\xde\x5a\x7f\x66\x9d\x5f\xf1\x56\x51\xe6\x80\x2c\x29\x92\xc1\x2a\x69\x07\xd4\x41\x87\x2a\xd2\xc1\x72\x4a\x09\xaa\x19\x81\x21\x18\x54\x4a\xa5\xaa\x39\x1d\x1b\xbd\x25\x1b\x97\x7b\x4a\x39\xbe\xbd\x91\x5e\x46\x35\x06\xbd\x72\xc6\x6e\x95\x85\xc3\x74\x29\x68\x55\xaa\x86\xec\x7a\x02\xf0\xa9\xd7\x23\x0b\x6f\xc2\x9c\x04\xf0\x46\xdd\xde\xee\x17\x4d\x1e\x5e\x68\x5e\x92\x93\x71\xbd\x78\xd4\x70\xb7\x51\x96\x11\xc9\x87\x41\x5e\xd9\x81\xda\x3e\x0b\xc8\xda\xc0\x81\x21\x24\x2c\x1a\x4b\xe1\x3b\x74\xc9\x4e\x60\x28\x8e\x56\x4d\x8c\x48\x3d\xe9\x1e\x7a\x3a\xf6\x96\x8e\x7d\x2a\xbd\x97\x1b\x0c\x7b\x71\x23\xd5\xb0\x3d\x07\xe4\x88\x29\x2d\x2a\x19\x78\x83\xfb\xe3\x7e\xc7\x7d\x2b\x7a\xc7\x80\x7b\x57\x5e\xef\x48\x1d\x2c\xb6\x4a\xc2\x9b\x8f\x0f\x0f\xb7\x3b\xd6\x22\xa1\x4e\x68\xa0\x47\x65\x53\x7f\xf1\xce\x77\xfc\x0e\x26\xbd\xbf\x5d\x7c\xf2\x09\x5d\xa4\x44\x27\x14\x0d\x76\x80\x71\x44\x4d\x82\xee\xa2\x92\xfc\xdb\x55\xbc\x0f\x18\x53\x20\x91\x7f\x98\xc0\xaa\x33\xf8\x30\xc3\x41\x55\x36\x7a\x3e\xbd\x66\x43\x69\x05\x71\x07\xa3\x8a\xf1\xec\x83\x89\x05\xa8\x74\x40\x43\x09\xb4\x0a\x46\x2e\xba\x85\xd8\x0b\xac\x3a\x2f\xfe\x5c\x7d\x48\x0e\x46\xab\xc8\x25\x7c\x4e\x6b\x2c\xbf\x7d\x0d\x46\x4e\xca\x12\xd3\x2a\x20\x37\xe6\xb4\x22\x4a\xa1\x37\x45\x7f\x3a\xa1\x9d\x76\x5c\x7e\xa5\x46\xbc\xb3\x9c\x8b\xcc\xa9\x96\xee\x5f\x8e\x17\xf9\x01\xe1\xc9\xf9\xb3\xa0\xa2\x94\x4c\x08\xa8\xd3\x1e\xbe\x7d\x56\xcc\x51\xe2\xdc\x64\x77\x70\xc8\xe5\x75\xb6\x42\xa0\x1b\x0d\x1f\x12\xc0\xbd\x8b\x94\xb8\xf7\x04\x1a\x19\x4d\xf8\xe5\xae\xe3\x82\x3a\x61\xe8\xac\x3f\x03\x86\xe0\x43\xf5\x0f\xb9\x9f\x4b\x4f\x84\xce\xaa\x73\xfc\x1d\x86\xe3\x33\xea\x9c\x66\x0c\x2d\xc2\x4a\xdd\x09\x0c\xf8\x72\xa4\xdc\x51\x7a\x0b\x2b\x59\xd9\x16\x18\x4c\x8a\xb8\x42\x6b\x2f\x6b\x13\x87\x39\x8d\x83\xec\xc6\x40\x27\xb2\xc8\xb5\xcb\x0c\x64\x07\x06\xdd\x04\x11\xc3\x89\xb8\x39\xd0\x30\x2a\x0a\x6b\xe8\x07\xd4\xbd\x72\x14\x87\xb8\xe3\x3c\xd1\x41\xc5\x52\xbb\xe5\x8a\xd5\xa4\x9f\x7a\x64\xec\xf7\x71\x46\x9d\x9c\x7a\x1f\xe8\x57\xac\xa5\x20\x0a\xb4\xa6\x1e\x26\x50\x5d\x47\x96\x6a\x85\x0e\x28\x49\xd6\xd3\xc8\x17\x15\xa2\x53\x78\x54\x50\x85\xd3\xf5\xca\xf1\x29\xee\xd9\x27\x32\x59\xd9\x3d\x83\xb6\x58\xae\x1c\x5f\xe8\x07\xc5\xb1\x0a\x78\x22\x3c\x4b\x67\xae\x4a\xd4\x2b\xbd\x13\x5e\x72\xcc\x56\x05\xee\xad\xc4\xa4\x77\xa3\xf7\xef\x09\x91\xd3\x61\x1a\x53\xa9\x2c\x95\x24\x1f\x6b\xb4\x21\x05\xc5\x95\xd8\xa0\xd5\x4c\x7f\x37\x80\x65\xfd\xf1\xc8\xf1\x4c\x5e\x5c\x89\xcf\x89\xdf\xa8\xb0\xce\x66\x3d\x72\x20\xe5\x3d\x49\x63\x6e\x31\x85\x2e\x0a\x85\x2d\xfe\xc4\xd3\x4a\xf0\x13\x58\x54\x31\xc1\x97\xff\x03\x46\x4d\x4d\xa2\xfd\x29\x2b\x5b\x20\x78\x44\x3e\xaf\xbd\x63\xaa\xba\xc6\xb7\xf8\x2a\x16\x28\x33\x08\x45\x1e\xe7\x15\x0a\x30\x70\x1b\x10\xe8\x10\x50\x1c\xbc\x29\xd2\x5a\x8f\x7c\x03\xa3\x0a\x89\x34\x7b\x95\x2b\xb2\xd6\xa0\x38\xae\x63\x52\x28\x46\x6a\x6f\x6d\x29\x86\x1d\xdb\xb2\x9b\x5b\x63\xc5\x88\xab\x58\xba\x87\xf7\xeb\x54\x61\x85\xc0\x71\x04\x92\x7a\x42\x77\xd5\xc7\xc0\xc9\x7a\xc4\xda\x9d\x86\xe2\x55\x69\x0e\x62\x57\x79\x2a\xa9\x88\xcf\x54\xe8\x40\xf4\x5d\x12\x02\xbb\x21\xe0\x2b\xb4\x26\xe5\x0c\xe3\xdb\xdc\xac\x17\xd6\xf7\x5a\xdb\x9b\x07\x21\x34\x7b\x58\xfb\x27\x76\x2a\xdb\xb4\x02\x28\xfb\xc0\xb4\x43\x45\x23\x6a\x07\x31\xeb\x9e\xfb\xb5\x04\x63\x1d\x1d\x18\xe1\xeb\x0c\x05\xa3\x72\x68\x23\x87\x88\x51\xf5\x58\xf5\x92\xd6\xca\xae\x63\xe6\xd8\xf0\x03\x31\xdc\x00\x0d\x03\x1a\x52\x89\xd9\x47\x1e\xbd\x93\x21\x4c\x59\x2b\x87\x57\xa3\xef\xdb\x7c\x17\x19\x95\x10\x0a\x3b\xb6\xf8\xdc\xd8\x71\xa5\xb5\xeb\x1c\x02\xbb\xfe\x35\xd6\xf2\x66\xc9\xd7\xaf\x59\x33\xfe\x9d\x73\x93\x13\x1e\xdd\x31\xf5\x3b\xbe\xe6\x40\x6e\x25\xd8\x76\xec\x95\xcb\x03\x06\xd2\x90\xc7\x11\xc3\x17\xd6\x9f\x31\x68\x15\xb1\x95\x20\x2c\x7d\x1a\x0e\xde\xc6\x86\x31\xbc\xcf\x81\x03\x5d\x39\x59\x49\x14\x9e\x26\x0a\x1f\xdc\x6d\xab\x5b\xac\x65\x4c\xed\xd5\xa9\x85\x2e\x3b\x77\xd7\x42\xcf\x0a\xda\xbd\xa0\xbe\x95\xe7\x19\xe8\x82\x1f\x40\x31\xd5\x0e\x46\xca\x63\x5a\x83\xaa\xe0\x84\xce\xf8\xb0\xb2\xab\x0f\xdc\xba\x36\xc2\x96\x96\x15\xf3\xc8\x83\x53\x69\xd6\x8c\x72\x23\x83\x3d\x9a\x2a\x03\x62\xcf\x95\x51\xb8\x8b\x5e\xa8\x24\x27\xd7\xea\x80\xff\xcf\x3c\x24\x14\x41\xcb\xcc\xb8\x26\x87\x0a\xa1\x64\xc7\xc2\xcb\x36\x03\x4d\xb9\x87\x33\xb1\xcb\x32\xcb\x74\x78\xa7\x27\x6d\x17\xdd\xd6\x8b\x7e\xf4\xa0\x73\x4c\x7e\xa8\x40\xb1\x38\x55\x4d\x72\x51\x25\xa2\x15\xf6\xda\x5b\xf8\x6a\x9f\x53\xa9\xb2\xa5\x65\xd5\x79\x6f\xd6\x41\x2b\x37\x4f\x7a\xc8\x42\xe6\xb6\xf7\x42\x8f\x3f\x8f\x4c\x1e\x84\xaf\x24\xdd\xaf\xc3\x72\x53\xc0\x33\x14\x50\x8c\x79\xa5\xb1\x97\x4e\x5d\xb6\x17\x85\x3e\x47\xcf\x1d\x24\x5e\xe9\x16\xf7\xc5\x39\x4d\xe7\xd9\xa4\x86\x33\x60\x49\xa3\xe3\x9a\x5c\x84\x97\x5a\x12\xd1\x73\x3d\x28\xe7\x32\x93\x34\xd9\x1d\x70\xda\xb0\xa8\xb9\x61\x2d\x48\x55\x31\xbb\x66\xe2\xc5\x1a\x67\x0d\xab\x6c\x3a\xd0\xd4\xf2\x6c\xfa\xcd\x3a\x57\xe8\x40\x09\x03\xa9\x35\x15\x7f\x2c\xb0\x2f\x7b\xae\x4d\x84\x02\xb2\x8a\x33\xa6\x94\xea\x28\x46\xaf\x2b\x15\x18\x03\xf9\x50\x3b\x7d\xf0\x27\x65\x67\xa0\xac\xb8\x3c\x83\xd7\x8c\x91\x45\x74\xed\x04\xf5\x06\xc6\xb3\xa2\xe7\xab\x3b\xa7\xaa\xeb\x67\xae\xae\x3b\xa9\x2e\x58\x87\x2c\x73\xc5\x1d\xff\x4c\x5d\x83\x33\xdb\x68\x90\x7f\x75\xf7\x4b\xcb\x6b\x21\x8e\x81\x64\x79\xb0\x88\x94\xc0\x19\x4c\x18\x78\x90\x58\xf2\xea\xc5\xd8\xdf\x32\xb3\x95\x6b\x49\xd7\x0a\xbb\x3a\x21\x57\xd2\xde\xac\xb0\x96\x78\xc9\x62\xc0\x80\x77\xdb\x3d\x60\xa0\xf8\xc4\xb2\x19\x9c\x5c\x82\xda\x59\xe6\x54\x1f\x94\xc1\x2b\xb5\x27\xfa\x74\xd9\x89\xa3\x95\x6c\xd3\xe6\xa9\x35\xe8\x9e\x78\x14\xc9\xa1\x25\x46\x03\x86\x23\x3a\x3d\x35\x8e\x51\x13\x03\x04\x1e\x7d\xb3\x9f\x58\x17\x6d\xa5\xfc\x54\x08\x75\x56\x8c\x31\x0f\x4c\xd3\x78\x64\xcf\x2e\x91\x05\x35\x13\xde\x26\x38\x5c\xed\x65\xb4\xe5\x24\xe0\x6e\x7a\xed\xe6\x39\xd9\x67\x58\xac\x0e\x7f\xd7\x13\x76\xf0\xd0\x78\xf9\x43\xd7\x91\x66\xa2\x1e\xe6\x5d\x13\x96\x02\xfa\xcb\xe5\x66\x6c\x86\x5e\x53\xb7\x3f\x68\xc0\x94\x16\xd2\xd6\x43\x6b\xdf\xac\x45\x5d\x0b\xb1\x1a\x12\x1f\x69\xb1\xdb\x8a\x13\xa3\x65\x9b\x23\xdc\x93\x27\x4b\xbe\x6b\x13\xcf\x0f\x23\x3a\xa9\xeb\xeb\x4b\xeb\xb2\xa7\x80\x37\xb2\xbe\xb8\x7d\x6d\xf3\xb1\x99\xa0\x0f\xb7\xb7\x6b\x89\x7f\x47\xc7\x1e\xee\x0a\xf2\x45\xd0\x2a\xe1\x51\x88\xba\x40\x1c\x8f\xb9\x45\xc9\xd9\xa8\x8e\x9e\xb7\xcc\x61\x57\x76\xac\x67\x8a\x08\xca\x26\x0c\x8e\x6b\xa0\x59\x89\x09\x57\xc1\x23\x35\xf1\x69\x81\x54\xba\xe9\x40\x09\xf0\x79\xf4\x42\xb2\x0f\xc8\xd9\xd3\x56\xe1\x96\x82\x48\x3d\xac\x9a\x55\xcd\x4b\x7b\x3c\x88\x33\x64\xa6\x13\x88\x63\x6e\x08\xbe\xeb\xee\x64\x6f\x26\xd1\x76\x24\x43\xc1\xbf\x88\x2e\x3f\xa0\xa1\x3c\xbc\xea\xa8\xa1\x3c\xde\xb8\xaa\x00\x73\x31\x71\x01\x81\xb5\x90\x29\xe1\x10\xeb\xf8\xb9\xa4\xca\x1e\xee\x67\x5a\xeb\x57\x37\x35\xee\x5c\x9d\x18\x75\x8f\x26\x5b\x39\xf3\xc2\x3d\xad\x3e\x55\xe3\xda\x76\xff\x5d\x97\x6c\x93\xb3\x59\x0b\xcb\x2d\xdf\xc0\x90\x6d\xa2\xd1\x62\x7b\x2d\x39\x1d\x44\x36\x1f\x11\x9d\xe6\xf9\x93\x29\xd5\x98\x64\xf7\x21\x65\xb0\x87\x87\x8d\xb6\x35\xdd\x5c\xe9\xdd\x6b\xa5\x67\x67\x99\x8a\x1d\x50\xf6\x57\xed\x02\x76\x6c\x57\x8f\x18\x37\x41\xfc\xde\x9f\x5f\x8d\x20\x4f\xeb\xff\x99\xf0\x95\x0d\x5f\xdd\x47\x52\x9c\xd9\xb5\x5c\xbc\xac\x3e\xd8\x11\xcb\x02\xf1\x7a\x75\xc9\x78\x20\xd3\x7b\xc5\x86\xcb\xed\x73\xb3\x82\x53\x71\xce\x68\xb1\xd9\x07\x38\x06\x54\x09\xc3\x6f\xee\x1b\x1b\x3c\x2e\x4b\x95\x1a\x25\x0e\x5d\x90\x05\x20\xf3\x30\x0c\x8c\xb3\xb5\x2b\xbe\xfc\x74\xb5\x0a\x79\xbb\xe5\xa4\x77\x65\xdb\x4e\x92\x5e\x9d\x30\xf2\xb8\x0c\xa0\x65\x59\x73\xf9\x51\xe8\xd2\xc2\x1c\x59\xdf\x83\x4f\x7d\xb3\x00\xa8\x0b\xca\xac\x2c\x24\xef\x6d\xbc\x80\xd7\x97\xeb\xe0\x0d\x58\xea\xdb\xdb\x85\xd0\x35\xf6\x4b\x18\x67\xbb\x67\xf1\x23\x3a\x4c\x95\x52\xd6\x81\xa2\xae\xd1\x7f\xc9\x65\xd0\x5c\xe2\xd9\xb4\x93\x17\x1f\x63\xda\x7c\x13\xbe\xc0\xb7\x30\x9f\x94\x50\x75\x12\x64\x19\xfd\xad\x6d\xe5\x94\xbc\x6e\xa7\x7d\xd2\x4f\xe2\x55\x17\x89\x7d\x29\x47\x1b\xc7\x44\x6e\xaa\xf5\xc7\xc6\xa9\x9d\x0f\x3b\x26\xa1\x0a\x78\xd4\x1c\xf2\xb0\x6b\x1d\x55\xf7\xe3\xaf\x7d\x9e\xab\xac\xa3\xf5\xa0\x69\x17\xb6\x9f\x55\x38\x0a\xcb\xbd\x83\x13\x06\x4e\xc6\x78\xf1\xf1\x2e\x60\xe9\x2d\x2d\x2f\xf7\x41\x36\x10\x1b\x2d\x5b\x8e\xd2\xc8\xf7\x20\x1f\xb0\x30\xac\x60\xe3\x3b\x46\x9a\x79\x03\x35\xaf\x4c\x76\x2f\xb8\x18\x0c\x8a\x5c\x52\xc4\xf0\x51\xb7\xd1\x28\x92\x2e\x3e\x11\xbf\xc8\x8f\x66\xf5\xba\x2c\x52\x2e\xbe\xba\x0a\x33\x9b\x39\x64\x3b\x7c\xf2\x04\x05\x32\x41\xed\xea\xce\x51\x4a\xae\xac\xec\x9a\x17\x77\x85\xd1\x46\x9a\x49\x72\xd1\x16\x9d\x4c\xda\x45\x6a\x47\xf5\xfb\x0a\xdf\xb6\x30\xd6\xf7\xcd\x6d\xdf\x53\x87\x65\x5c\xbb\x62\x8a\xf9\x7d\xa6\x2c\x0b\xc8\xab\xb4\x95\x9c\x96\xad\x4d\xbc\xaa\xd3\xfc\xd6\x43\x7d\x0b\x1e\x31\x8e\xde\xc5\xab\xfa\x60\xab\xcf\x7b\x8a\x2a\x72\x30\x02\x36\x5f\xe1\x0e\x5c\xf1\xdc\x09\xea\x24\xb8\x12\xe7\x42\xe9\x8b\x50\x8a\x97\x8a\x2c\xd2\x1e\x67\x69\x57\xee\xef\xda\xfb\x1f\x2f\xdd\xb0\xb1\xbe\x84\x4b\x9d\x14\xd9\x39\x8f\xcb\x76\xda\x64\x67\x14\x93\xd4\x6b\xde\x28\xa7\xee\xdb\x53\x57\xd4\x38\x8a\x1a\xff\x08\x00\x00\xff\xff\x81\x18\x4c\xd8\xb9\x20\x00\x00")
+var _complySoc2PoliciesApplicationMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xc4\x59\xdd\x8e\xe4\xc6\x6e\xbe\x9f\xa7\x20\xe0\x9b\x1d\xa0\xa7\x1d\x1b\x41\x60\xac\xaf\x36\xbb\x4e\x3c\x81\xed\x5d\xcf\x6c\xe2\x6b\x76\x15\xd5\xa2\xa7\x54\x25\xd7\x4f\xf7\xc8\x57\x79\x8d\xbc\x5e\x9e\xe4\x80\x55\x25\xa9\xd4\xd3\x63\xec\xc1\xb9\x38\x57\x8b\x9d\x96\x58\xe4\x47\xf2\xe3\x57\x94\xc5\x81\xde\xc2\xbb\x71\x34\xac\x30\xb2\xb3\xf0\x48\x2a\x79\x8e\x13\x7c\x72\x86\xd5\x74\x83\xca\x3b\x3b\x0d\x6f\xe1\xdd\xe3\xa7\x9b\x80\x91\x43\xc7\x14\xde\xde\x00\x7c\x7e\x7c\x2f\xff\x00\xdc\xc1\xfb\xf7\xff\xb6\xff\xf6\x66\xc0\xdf\x9d\x7f\xa0\x13\x07\x76\x36\x3f\x72\x07\x1a\x23\xbd\x85\xff\x4a\x16\xbe\x81\x6f\xff\xe5\x9b\xef\xf2\x0b\xca\x0d\x03\xd9\xf8\x16\xee\x2d\x47\x46\x03\xda\xa9\x24\x7f\xb9\xb9\xbb\xbb\xbb\xb9\xf9\x0a\x3e\x25\x3f\xba\x40\x80\x56\xc3\xa3\x72\x23\xdd\xdc\xe0\x1e\x3e\xf7\x1c\x00\x1b\x5f\xc3\xec\xeb\x98\x7d\x05\x4d\x1d\x5b\x0a\x10\x7b\x5a\x7f\xeb\x3c\x0e\x74\x76\xfe\x29\x5b\xf3\xf4\x47\x62\x4f\x72\x58\x80\xce\xf9\xd6\x5e\xd8\x81\x75\x11\x0f\x66\x82\x33\x1d\x2e\x7e\x39\x73\xec\xd9\x66\xd3\xce\x1f\xd1\xf2\x9f\x19\xaf\xff\xff\xdf\xff\x0b\x30\x7a\xa7\x93\x92\xff\x02\xd9\x13\x7b\x67\xc5\xfe\x7e\x75\x7a\x8e\x0f\xd0\x04\x27\x8f\x9f\x58\x53\x00\x1e\x46\x93\x5d\x61\x7b\x04\xe5\x6c\xf4\xce\x84\xec\x26\xdb\x10\x7d\x31\x59\xdc\xbc\x70\x68\x09\x6f\x07\xd1\x01\x5b\x65\x92\x26\x18\xc9\xb3\xd3\xac\xe0\x94\x8c\x25\x8f\x07\x36\x82\x4e\x50\x68\x8b\x59\x17\x7b\xf2\x10\xa7\x91\x02\xb8\x0e\xe8\x84\x26\x65\x7b\xe5\x67\x0c\x81\x42\x10\x87\x42\xe3\x7c\x05\x37\x9f\x2e\xe0\x3a\x40\x63\x36\xe8\xfc\x05\x38\xaf\x60\xb3\x03\x0c\x70\x26\xb1\x13\x00\xf5\xc0\x96\x43\xf4\x18\x9d\x2f\x9e\xa4\x40\x3e\xbb\x18\x7b\x0a\xb4\x39\xac\x7a\x15\xa7\x91\x15\x1a\x33\xcd\xd1\x07\xa0\x61\x34\x6e\x22\x2a\x26\x32\x9e\xa8\xc4\xe4\x5e\x6a\xea\xdf\x51\x3d\x1d\xbd\x4b\x56\xe7\xd0\xda\x9a\x6f\xe1\x62\x6a\x6d\xa3\x52\x2e\xd9\x98\x53\x20\xa9\x37\xe8\x8f\x14\x22\xd8\x34\x1c\xc8\x8b\x83\x5c\x4b\x18\x63\x44\xf5\x04\x27\xca\x27\x02\x76\x91\x3c\x0c\x68\xce\xe8\x09\xd8\x76\xa4\xaa\xf7\xef\x02\x20\x78\x0a\xc9\xc4\x1d\x70\x04\xce\x75\xe0\x7c\x44\x1b\x21\xf6\x18\xb7\xd0\xca\xeb\x9a\x02\x1f\x2d\xe9\x8c\xf3\x5a\xd9\x6c\x61\x60\xab\x77\x39\xdc\xfc\x66\xec\x69\xca\x6f\x48\xca\xe5\x85\x19\x08\xb6\xc9\xa5\x60\x26\x18\x9c\xe5\xe8\x3c\xe9\x1c\xd2\x80\x86\x15\xbb\x14\x00\x55\xe4\x93\x18\xcd\x76\x94\x4b\x46\x03\x5b\x2d\x08\x11\x20\x84\x29\x44\x1a\x40\xb9\x61\xf4\x6e\xe0\x40\x7b\xf8\xc0\x41\xb9\x13\xf9\x29\x1f\x1f\xd2\x21\xd0\x1f\x89\x6c\x84\x81\x23\x1f\x0b\xb0\xae\x03\xfc\x0b\x9c\xcf\x6c\x0c\x18\x1e\x38\x5e\xef\xab\x8a\x69\x48\xbe\x43\x45\x25\x4e\xb2\x21\x79\x49\x31\x1c\x30\x90\x61\x4b\x60\xe8\x44\x46\x72\xb1\x20\x23\xa4\x15\x42\xae\xd3\xe2\x79\xad\xe7\x7b\x0b\xa8\x35\xcb\x01\x52\xc7\x19\x25\xe9\xbd\x63\x62\x8d\x56\x8e\x88\x6d\xc5\x4b\xb3\x2e\x9c\x42\xaa\xb7\x52\x15\x5b\x0a\x11\x97\x46\xef\x14\xe9\xec\x95\x58\x2d\x1e\xbe\x92\xca\xd1\xbb\x91\xbc\x99\xa0\x47\xaf\x49\x52\xc4\x36\x57\x99\xcf\x0e\x5c\x64\xf8\x20\xb5\x36\x7a\x49\x8e\x22\x89\xe1\x2b\x78\xa0\x8e\x3c\x59\x45\x21\x47\xf4\x01\x23\xc2\x7b\x83\x21\x70\x37\xc3\x5c\x99\x5b\x7e\xfe\xf8\xdb\xbb\xc7\x4f\xf0\xc0\xe1\x09\x1e\x30\x4a\xa8\x3f\x53\xec\x9d\x76\xc6\x1d\xdb\x27\x3e\x53\xc8\xbf\xfe\x67\x62\x4d\xed\xdf\xdd\x08\x9f\xc9\xc2\x27\xef\x7e\x27\x15\xc5\x81\xc6\xfa\xe7\x8b\xa4\xc1\x90\x42\xdc\xc6\x7f\xc9\x14\x1c\x41\x4b\xba\xdc\x98\xbb\xf4\x6b\x61\x60\x95\xf1\x2c\xa5\x9e\x43\x27\x33\x09\x1d\x76\x7c\x4c\xbe\x16\xf1\x80\x16\x8f\xa4\x67\x5a\x22\xe8\x9c\x31\xee\x2c\x3e\xbf\x82\x56\x71\xe6\x40\x62\x29\xb0\xa6\x6a\x69\x07\xdc\x41\x47\x18\xf8\x60\xa4\xa4\xc4\x39\xf9\x45\x2a\x6a\xc0\x28\x5d\x5b\x68\xa7\xf5\x3b\x57\xe3\x72\x4e\x69\xc7\xb7\x37\x79\x96\x71\xcd\x41\x8f\x56\x9b\xad\xb3\x70\x98\x2e\x0d\xad\x4e\xd5\x94\x5d\x2f\x00\x79\xeb\xf5\xcc\xc2\x1b\x3f\x17\x01\xbc\xc1\xdb\xdb\xfd\xe2\xc9\xfd\x0b\xcf\x85\x7f\x15\x09\xaf\x0b\x0e\x1d\x6b\x99\x36\x68\x84\x91\x9c\x1f\xf2\x23\x3b\xc0\xed\x6f\x9e\xc4\x1b\x38\x08\x85\xf8\xc5\xe3\xdc\xf8\x96\x6c\x34\x13\x68\x0e\xa3\xc1\x49\x18\xa9\x67\xd5\x43\xcf\xc7\xde\xf0\xb1\x8f\x65\xf6\xca\x80\x91\x7e\xdc\x58\xd5\x12\xcf\x81\x24\x63\xa8\xb2\x4b\x1a\xde\xd0\xfe\xb8\xdf\xc9\xdc\x0a\xce\x0a\xe1\xde\x95\xc7\x3b\xc6\x83\xa1\xd6\x49\x78\xf3\xe9\xfe\xfe\x76\x27\xe1\x44\x52\x91\x34\xf4\x84\x26\xf6\x17\xcf\xfc\x28\xcf\x50\x54\xfb\xdb\x05\x93\x47\xb2\x81\x23\x9f\x48\xb4\x08\xee\x80\xc2\x48\x8a\xe5\xb0\xfc\x07\xc8\xff\xed\x2a\xdf\x7b\x0a\xd1\x73\xb6\x7f\x98\xc0\xe0\x19\x9c\x9f\xe9\xa0\x3a\x1b\x9c\xbc\xbd\x56\x5d\x19\x05\x61\x07\x23\x86\x70\x76\x5e\x87\x42\x54\xca\x93\xe6\x08\x0a\xbd\xce\x07\xdd\x42\xe8\x33\xad\x5a\x97\xf1\x5c\x31\x64\x0b\xa3\x41\xb6\x91\x9e\xe3\x9a\xcb\x1f\x5e\xa3\x91\x13\x1a\x16\x59\x05\x6c\xc7\x14\x57\x46\x91\x43\x67\xff\xf9\x44\x66\xda\x49\xfb\x95\x1e\x71\xd6\x48\x2d\x8a\xa6\x5a\xa6\x7f\x79\xbd\xd8\xf7\x04\x4f\xd6\x9d\xad\xf0\x97\x54\xa7\xf3\x9e\x54\xdc\xc3\x0f\xcf\x28\x1a\x25\xcc\x43\x76\x07\x87\x54\x1e\x97\x28\x32\x75\x93\x96\x97\x32\xe1\xde\x05\x8e\x32\x7b\x3c\x8f\xc2\x26\xf2\x70\xd7\x49\x43\x9d\xc8\x77\xc6\x9d\x81\xbc\x77\xbe\xe2\xc3\x56\x18\x45\x52\xdb\x19\x3c\x87\x2f\x08\x9c\x9e\x49\xa5\x38\x73\x68\x31\x56\xfa\x4e\x42\x0c\xae\x50\x6e\xfe\x73\x9d\x2d\xe2\x64\x55\x5b\xa0\x29\x22\x4b\x87\xd6\x59\xd6\x16\x4e\x74\x80\x16\x92\x1d\x3d\x9f\xd8\xd0\x91\x8a\x02\xd9\x81\x26\x3b\x41\x20\x7f\x62\x19\x0e\x3c\x8c\xc8\x7e\x4d\xfd\x40\xaa\x47\xcb\x61\x08\x3b\xa9\x13\xe5\x31\x94\xde\x2d\x47\xac\x21\xfd\xd6\x93\x70\xbf\x0b\x33\xeb\xa4\xd8\x3b\xcf\x7f\x52\x6d\x05\x41\x70\x13\xea\x61\x02\xec\x3a\x36\x5c\x3b\x74\xa0\x5c\x64\x3d\x8f\x72\x50\x11\x3a\xa2\xd3\x76\xe0\xb1\x68\xba\x1e\xad\xf0\x8d\xcc\xec\x13\xeb\x84\x66\x2f\xa4\x2d\x3a\x53\x42\xc3\x14\xdd\x80\x52\xd6\x9e\x4e\x4c\x67\xe9\xce\xd9\x89\x7a\xa4\xb3\x59\x97\x1c\x93\x41\x2f\xb3\x95\x45\xf4\x6e\xfc\xfe\x92\x14\x59\xe5\xa7\x31\xe6\x82\x07\x8c\xa2\x73\x62\xcd\x36\x44\x8f\xd2\x89\xab\x95\xfb\x59\xfe\xb6\x36\xc0\xb8\xe3\x51\xf2\x19\x25\x9f\x04\xf4\x1c\xc9\x2e\xb4\x2e\x61\x3d\x48\x22\xf3\x73\xb9\x8c\x65\xc8\x4b\xb2\xaa\x84\x2d\x78\xd2\x69\x15\xf8\x11\x0c\x61\x88\xf0\xcd\xbf\x82\xc6\xa9\x29\xb4\x5f\x13\x9a\x42\xc1\x23\xc9\xfb\xca\x59\x91\xaa\x6b\x7e\x0b\x56\xf9\x14\xe5\x34\x41\xb1\x27\x75\x25\x08\x7a\x90\x31\x90\xc9\x39\x93\xe2\xe0\x74\xb1\xd6\x22\xf2\x3d\x8c\xe8\x23\x2b\x41\x55\x3a\xb2\xf6\x60\x06\xae\x13\x51\x98\x83\x54\xce\x98\xd2\x0c\x3b\x29\xbc\xdd\x3c\x1a\x2b\x47\x5c\xe5\xd2\x3d\x7c\x58\x6f\x15\x26\x0b\x38\x69\x92\x88\x4f\x64\xaf\x62\x0c\x52\xac\x47\xaa\xd3\x29\xe3\x5e\x87\x43\x8e\xab\xfc\x9a\xf5\x3d\x3d\x73\x91\x03\xc1\x75\x31\x0b\xd8\x36\xa4\xd5\xf8\x63\x44\xab\x85\xdf\xe6\x61\xbd\xa8\xbe\xd7\xc6\xde\x7c\x11\x22\xbd\x87\xc5\xcc\x07\xea\x30\x99\xb8\x12\xa8\x60\xa0\xdb\x4b\x45\x63\x6a\x07\x21\xa9\x5e\xe6\xb5\x38\xdd\x5c\x1d\x84\xe1\xeb\x1d\x0a\x46\xb4\x64\x82\xa4\x48\x58\xf5\x58\xfd\x12\x83\x19\x3a\x51\x8e\x61\xf1\xa9\x04\xae\x81\x87\x81\x34\x63\x14\xf5\x91\x46\x67\xf3\x25\x0c\x8d\xc9\x2f\xaf\x41\x37\x17\x87\x6a\xa3\x0a\xc2\xac\x8e\x0d\x3d\x37\x71\x5c\x19\xed\x2a\x79\x4f\xb6\x29\xb2\x0b\xd5\xf2\x66\xa9\xd7\xef\x24\x61\xf2\x77\xa9\x4d\xb6\x60\xc8\x1e\x63\xbf\x93\x63\x0e\x6c\x57\x81\x6d\xc6\x1e\x6d\x1a\xc8\xb3\x82\x34\x8e\xe4\xbf\x36\xee\x4c\x5e\x61\xa0\xd6\x82\x74\x47\x98\x86\x83\x33\xa1\x51\x0c\x1f\x92\x97\x44\x57\x4d\x26\xa9\x91\xda\x83\x58\xf4\xe0\x6e\x93\xf8\x12\xad\x70\x6a\x8f\xa7\x96\xba\xcc\x3c\x5d\x8b\x3c\x2b\x6c\xb7\x79\x33\xd7\x90\xca\x30\x69\xe8\xbc\x1b\x00\x21\xf6\xec\x75\x6e\x8f\x69\x4d\x2a\xc2\x89\xac\x76\x7e\x55\x57\x1f\x65\x74\x6d\x8c\x2d\x23\x2b\xa4\x71\x74\xbe\x0e\x6b\x61\xb9\x51\xc8\x9e\x74\xb5\x01\xa1\x97\xce\x28\xda\x45\x2d\x52\x52\x8a\x6b\x05\xe0\x3f\x92\x5c\x12\x8a\xa1\xe5\xce\x58\x43\x3d\x10\xa0\xf7\xa5\x3a\x16\x5d\xd6\xb8\x32\x9f\x23\x95\xd8\x89\x1d\xc3\x1d\xdd\xa9\x49\x99\xc5\xb7\xf5\xa0\x5f\x1c\xa8\x14\xa2\x1b\x2a\x51\xcc\xd1\x0c\x38\x49\x67\xcc\x42\x34\xba\x17\xa7\xc8\xd1\x2e\x65\xef\x3a\x5e\x46\x56\xbd\xef\xcd\x3e\x28\xb4\xf3\x4d\x8f\x20\x2e\x4b\x86\x97\x7e\xfc\xf7\x28\xe2\x21\xeb\x95\xa8\xfa\xf5\xb2\xdc\x34\xf0\x4c\x05\x1c\x42\x5a\x65\xec\x25\xa8\xcb\xf6\x42\x54\x6f\x80\xe0\x64\x82\x84\x2b\xd3\xe2\x5d\x01\xa7\x99\x3c\x6b\x70\xe5\x78\xc3\x8a\xac\xf4\xe4\x62\xbc\xf4\x92\xa4\x76\xe9\x07\xb4\x36\x89\x6c\xcb\xbb\x03\x29\x1b\x31\x35\x0f\xac\x85\xa9\x2a\x67\xd7\x4a\xbc\x58\xe3\xac\x69\x15\x45\x2c\x24\x53\x94\x77\x33\x6f\xd6\x7b\x85\xf2\x1c\xc9\x33\xae\xa5\xf8\x8b\xb8\x2f\xb7\xfa\xdf\xb7\x8b\x23\xf0\x64\x68\xe5\x94\xd2\x1d\x25\xe8\x75\xa5\x02\xa3\x67\xd9\x22\xb8\x5a\xa6\x68\x66\xa2\xac\xbc\x3c\x93\xd7\xcc\x91\x25\xcf\x75\x12\xd4\x13\x80\x6d\xf5\xf3\xfa\x5e\x65\x05\xfd\xb3\x74\xd7\x9d\xc0\x34\xc1\x7a\xc9\xda\x0e\xa7\x2f\x73\x57\xd3\xac\x36\x56\xeb\x8f\x2b\xdc\x2f\x23\xaf\x8d\x38\x7a\x16\x95\xba\x9a\xcc\x89\xd3\x14\xc9\x0f\x6c\xd7\xba\x7a\x71\xed\x6f\x95\xd9\x42\x93\x65\x6a\xf9\x5d\xbd\x21\x57\xd1\xde\xac\xb0\x96\x7c\x89\x78\x21\x0d\xce\x6e\xf7\x80\x9e\xc3\x93\xf0\xa8\x90\x93\x8d\x50\x27\xcb\x5c\xea\x03\x6a\xba\xd2\x7b\xd9\x9f\x2e\xd9\x3c\x5c\x31\x6f\xd3\x6a\x42\xd0\xab\x9e\xe5\x2a\x92\x7c\x53\xea\x3f\x0c\xe4\x8f\x64\xd5\xd4\x00\x83\x93\xcc\x57\x3a\xba\xd5\x97\x15\x93\xda\x7e\xe8\x7d\xbd\x2b\x86\x90\x06\x91\x69\x72\x65\x4f\x36\xb2\x01\x9c\x05\xef\xfa\x56\xee\xf6\xdc\x25\x59\xb1\x90\xbe\x7e\xf2\x5c\xec\x33\x2d\x56\xc0\xdf\xf7\x4c\x1d\xdc\x37\x28\x7f\xec\x3a\x56\x22\xd4\xfd\xbc\x6b\x92\x98\x70\x0f\xff\x73\xb9\x19\x9b\xa9\x57\xd7\xed\x0f\x69\xd0\x65\x84\x34\xa8\x6d\xe2\x9b\xbd\xa8\x6b\x21\xc9\x7b\xce\x4f\x1e\xb1\xdb\x8e\x93\x14\x95\x6d\x4e\xd6\x9e\x2c\x23\xc1\xd3\x36\x9f\x1f\x47\xb2\xf0\x1b\x1d\x5e\x59\x5a\x97\x3d\x05\xbc\xc9\x8b\x8f\xdb\xd7\x36\x1f\x9b\x1b\xf4\xe1\xf6\x76\x6d\xf1\x1f\xf9\xd8\xc3\x5d\x61\xbe\x00\xb2\xfd\x3a\x8a\x46\x16\xa6\x08\xf9\x9a\x5b\x32\x33\x07\xd5\xf1\xf3\x56\x39\xec\xca\x8e\xf5\xcc\xb2\xb5\x34\x91\xbc\xc5\xb8\xc4\x2e\xd0\x64\xad\x42\x47\x6e\xf2\xd3\x12\x69\x74\x75\x21\x46\xcf\xa3\xcb\x22\xfb\x40\x52\x3d\x6d\x17\x6e\x25\x48\xee\x87\xd5\xb3\xea\xb9\xe4\x28\xa4\x43\x06\x23\xdf\xe9\x04\xdf\xac\x0d\xc1\x75\xdd\x5d\xde\x9b\x89\xc0\x24\x2b\x43\xe7\xef\x66\x97\x9f\x49\x73\x1a\x5e\x05\x4a\x84\x54\x1a\xb6\x50\x15\x62\x2e\xb7\xc5\x85\x04\xd6\x46\xe6\x48\x43\xa8\xd7\xcf\xa5\x54\xf6\xf0\x6e\x96\xb5\x6e\x85\xa9\x81\x73\x05\x31\xa8\x9e\x74\x32\xa4\xaf\xc1\xd3\xfa\x53\x3d\xae\x63\xf7\x1f\x85\x64\x5b\x9c\xcd\x5a\x38\x9f\xf2\x3d\x0c\xc9\x44\x1e\x0d\xb5\xc7\xb2\x55\x3e\xdb\x96\x57\x32\x46\xf3\xfd\x53\x24\xd5\x28\x5f\x20\xea\x52\x73\x0f\xf7\x1b\x6f\x6b\xb9\xd9\x32\xbb\x67\x1f\x03\x24\x6b\x44\xc6\x1f\x28\xef\xaf\x56\x78\xc0\x8d\xab\xfe\x1a\x3d\x85\x4d\x12\x7f\x72\xe7\x57\x33\x28\xb7\xf5\x7f\x4e\xfa\x32\xef\xcc\xfb\x48\x0e\xf3\xba\x35\x1f\xbc\xac\x3e\x04\x88\x65\x81\x78\xbd\xbb\x3a\x21\x69\x3b\xad\xa4\x7b\xb9\x7d\x6e\x56\x70\x18\xe6\x8a\xce\x31\x3b\x0f\x47\x4f\x18\xc9\xff\xe5\xbe\xb1\xe1\xe3\xb2\x54\xa9\x59\x92\xd4\xf9\xbc\x00\x14\x1d\x46\x5e\x78\xb6\x4e\xc5\xb9\x2e\xaf\x19\x59\x49\x28\x6b\xd2\x3b\x11\xfa\xa3\x67\xe1\x4a\x11\x0a\x14\xe6\x0b\xad\x31\x75\x59\x73\xf9\x51\xe8\x32\xc2\x14\xc4\xdf\x83\x8b\x7d\xb3\x00\xa8\x0b\xca\x84\x06\xa2\x93\xef\x4d\x5b\x7a\x7d\xb9\x0e\xde\x90\xa5\xba\xbd\x5d\x04\x5d\x13\x7f\x4e\xe3\x1c\xf7\x6c\x7e\x24\x4b\xb1\x4a\xca\x7a\xa1\xa8\x6b\xf4\x3f\xa4\xe2\xda\x7c\x36\xe3\xe4\x32\x88\x4d\xbd\x49\xc7\xc8\x73\xa2\x12\x4b\xaa\xba\x9c\x64\x09\x4a\xfe\xd6\xd8\x29\x7d\xbe\x56\xfb\xaf\x89\xd5\x53\x46\xd5\x06\x16\x2c\x5d\xb7\xdd\x8c\xc8\xd7\x80\xf9\x8f\x0d\xa8\x9d\xf3\x3b\x59\x22\x20\xc8\x55\x73\x48\xc3\xae\x05\xaa\xee\xc7\x5f\xfb\x3c\x57\x55\x47\x8b\xa0\x6e\x17\xb6\x9f\xe5\x7b\x92\x1c\x7e\x07\x27\xf2\xb2\x3e\x08\x9b\x2c\xca\x34\x17\xf2\xda\xea\x72\xe7\xc1\xd2\x79\xeb\x65\xab\x51\x1a\xfb\x0e\xf2\x07\x2c\xf2\x2b\xd9\xb8\x0e\x92\x5d\x36\x50\xf3\xca\x64\xf7\x42\x8b\xc1\x20\x3b\x49\x64\xb9\xbe\xd5\x6d\xb4\xcc\x28\x7f\xf9\x89\xf8\x45\x7d\x2c\xa7\x2f\x0f\xbe\xf8\xea\x2a\x67\x2d\x92\xbd\xbd\x7c\xca\x0d\x0a\xf2\x0d\x6a\x57\x77\x8e\x52\x35\x75\x65\xd7\x3c\xb8\x2b\x9f\x0d\x02\xcf\x22\xb9\x78\x4b\x36\x2f\xd1\x85\xee\xca\x87\x1b\x3d\x0b\xbd\x45\xb1\x7e\x68\x4e\xfb\x89\x3b\xca\x87\x5d\x0b\x45\x7f\x59\x28\xcb\x02\xf2\xaa\x6c\x65\xab\xf2\xd6\x26\x5c\xf5\x69\x7e\xea\xbe\x3e\x05\x0f\x14\x46\x67\xc3\x55\x7f\xa8\xf5\xe7\x03\x07\x0c\x92\x0c\x4f\xcd\x57\xb8\x83\x74\xbc\x4c\x82\x7a\x13\x5c\x85\x73\x0e\xb7\x2e\xb2\x39\x5c\x3a\xb2\x58\x7b\x98\xad\x5d\x39\xbf\x6b\xcf\x7f\xb8\x84\x61\x13\x7d\x49\x17\x9e\x90\xcd\x5c\xc7\x92\x22\x4f\x3a\x59\xd9\x85\x4c\x57\xd1\x28\x6f\xbd\x6b\xdf\xba\xe2\xc6\xf1\xf6\x76\x7f\x73\xf3\xb7\x01\x00\x81\x18\x4c\xd8\xb9\x20\x00\x00")
 
 func complySoc2PoliciesApplicationMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -521,12 +542,12 @@ func complySoc2PoliciesApplicationMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/application.md", size: 8377, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/application.md", size: 8377, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesAssetMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x57\xdb\x6e\x24\xb9\x0d\x7d\xef\xaf\x20\x90\x87\x24\x83\x76\x23\x6b\xe4\x06\xbf\x79\x3d\x1b\xc4\xc0\x4e\x32\xb0\xe7\x07\xd4\x12\xab\x8a\xb1\x4a\xac\x88\x52\x37\x7a\xbe\x3e\xa0\x54\x17\x75\xdb\xc9\x06\xd8\xa7\xe9\x71\x49\x22\x75\x78\xce\x21\x15\xcc\x88\x0f\xf0\x28\x82\x09\x9e\xc3\x09\x43\xe2\x78\x01\x13\x1c\x7c\x31\xc1\xf4\x38\x62\x48\xf0\x95\x3d\xd9\xcb\xce\xd8\xc8\xe1\x32\x3e\xc0\xe3\xf3\x97\xaf\x3b\x31\x89\xa4\x23\x94\x87\x1d\xc0\xb7\xd7\x27\xfd\x07\xe0\x0e\x9e\x9e\xee\x0f\x3f\xac\xbf\xff\xdc\xfc\xfe\xcb\xe1\x87\xdd\x68\xfe\xc5\xf1\x05\x4f\x24\xc4\xa1\x6c\xbd\x03\x67\x12\x3e\xc0\x2b\x4e\xf0\x57\xb8\xff\xc3\xfd\x9f\xca\x06\xcb\xa3\xc6\x7e\x80\xe7\x40\x89\x8c\x07\xc7\x36\x97\x6c\x3a\x8e\xba\xec\x5e\x83\xea\xb2\xc9\x93\x09\x16\x77\x77\x77\x77\xbb\xdd\x6f\xe0\x6b\x8e\x13\x0b\x96\x3b\xbc\x5a\x9e\x70\xb7\x33\x07\xf8\x36\x20\x4c\xf3\x17\xee\x20\x0d\x24\x30\x95\x6b\x01\x09\x24\x06\x94\x64\x8e\x9e\x64\x80\x88\xff\xce\x14\xcb\xcd\xa5\x04\x1b\x0d\x85\x64\x28\x50\xe8\xc1\x04\x30\xd6\xe6\x68\x52\x8d\xa0\xf1\x23\x0e\x18\x84\x4e\x08\xb4\x22\xc8\x1d\x18\xef\x81\x42\xc7\x71\x34\x89\x38\x40\x42\x3b\x04\xf6\xdc\x5f\xc0\x28\xde\x02\x67\x4a\x03\x05\x48\x03\x02\xc7\xde\x04\xfa\x5e\x57\xea\xc1\x18\x24\xc7\x12\x71\x9a\x22\x4f\x91\x34\xa2\xa0\xcd\x91\xd2\x05\x2c\x87\x14\xd9\x0b\x98\x88\xba\xc2\x13\x3a\x48\x43\xe4\xdc\x0f\x9c\x53\x39\xb2\x04\x01\x4f\x1d\xda\x8b\xf5\x78\x98\x71\xd8\x2e\x5e\xf7\x95\xdb\x6b\xae\x83\x89\xee\x6c\x22\xee\x41\xb8\x4b\xf5\x97\x33\xc9\xec\x4b\x3e\x14\xba\x68\x24\xc5\x6c\x53\x8e\x58\xee\xcd\xa1\x40\x94\x06\x93\x40\xf2\x34\x71\x4c\x70\xcc\x42\x01\x45\x80\x27\x8c\xe5\x36\xb2\x6c\x5f\x81\x98\x22\x5b\x14\xa1\xd0\xff\x62\x4e\x38\x4e\x9e\x2f\x88\xb2\xaf\x57\x36\x36\x71\x94\x9a\x51\x1a\x28\x3a\x98\x4c\x4c\xba\xe1\x3c\x30\x8c\x85\xb2\xfb\x39\xb6\xfe\x88\x30\x98\x13\x6a\xc9\x34\xa7\xc4\x57\x38\x1b\x3f\x17\xe2\xa0\xbc\xf9\xd1\xd8\xb7\x3e\x72\x0e\xae\x24\xf5\x53\xd7\xa1\x4d\x5a\xd4\x8a\xe3\xb8\xc9\x81\x04\xba\x1c\x9c\xd1\xff\x18\xaf\xa7\xb6\xb7\x5b\x6b\xa4\x39\xae\x28\x18\x0f\x11\x85\x3c\x61\xb0\x58\xe9\x78\x55\xf2\x31\x4b\x5a\x89\x06\x2a\x8f\x23\x79\x3d\x85\xc2\x0c\xc5\xcc\x99\xc4\x8a\xdf\x84\xd1\x57\x1a\x89\x40\x24\x79\x53\x4c\xa6\xc9\x5f\xde\x53\x64\x0f\x34\x4e\xbe\x66\x6e\x07\x13\x7a\x9c\xf1\x8b\x28\x13\x2b\x8e\xbc\x6d\xa2\x60\xc9\x69\x55\x0f\xab\x6a\x56\xa9\x45\x55\x2d\x3a\x98\x98\xb4\xec\xdc\x41\xc7\x36\x8b\xd6\x68\x30\x42\xdf\xb1\xb0\x8e\x46\xe5\x81\x0a\x52\x57\x5c\xcb\xa3\x22\xb9\x8a\x64\xaf\xe1\x7c\x76\x85\xe5\xab\xa8\xca\x1a\xeb\x8d\x08\x75\x64\x37\x41\x28\x15\xa1\xf3\x7c\x86\xd1\x4c\x93\xee\xd1\xc4\x67\xda\xe1\x5a\xad\x77\xf7\x2f\xc5\x7d\xc1\x0e\xa3\x42\x2f\xe5\x5e\xcf\x4d\xbd\x5e\x97\x0d\xb3\xcb\xe9\xf7\xa7\x82\x13\x3c\xd5\x23\xda\x2f\x2f\x24\x6f\x1f\x58\xa3\xfa\xce\xb6\xe8\xd3\xad\x9f\xbe\x34\x96\xf2\xf0\x69\x57\x0c\x8e\x3e\x60\x81\x0c\x5a\xe9\x95\x06\xe6\xd7\xd9\xcb\x86\xee\x31\x27\x08\xac\x66\x30\x52\x52\xa7\xe0\xea\xd6\xd5\x99\xff\x3e\x0b\x1f\x1c\x9e\xc8\xa2\xc0\xef\x04\xe3\x09\x55\x67\x67\x8e\x6f\x92\xaa\x8e\xf7\x10\x30\xe9\x1f\x40\x6f\x33\xe9\x65\xf6\x30\xf2\x91\xfc\xba\xf3\xf7\xcd\xa9\xaf\xb3\x89\x54\x49\xdb\xc6\x0b\xe4\x22\x09\x47\x69\xd6\x7e\xd6\xda\x46\x9c\x58\x28\x71\x54\x39\x2f\x15\x3f\x1a\xc1\x76\xe5\x93\xe7\xec\x40\xf3\x2b\x99\xbe\x77\xa6\x66\xed\x3f\xe6\x74\x1b\xaf\xaa\x96\x1d\x82\x92\x85\x83\xac\x95\xf8\xc9\xd8\x61\xa5\x67\xe5\xf1\x8a\x75\x91\x66\xc5\x12\x21\xa2\xc7\x93\x09\x09\x4c\x4a\x91\x8e\x39\xa1\x80\xe4\xb2\xb9\x85\xb4\x12\xa0\x48\x89\x3a\xc2\x58\x03\x5f\x91\xba\x59\xfd\xcf\x73\x58\x96\x64\x49\xec\xc8\x84\xb6\xb4\xcd\xca\x9f\xb9\x51\x44\x58\xef\x57\x2f\x74\xa2\x74\x69\x4f\xad\xde\x13\xfa\x19\xf0\x8a\xfd\x52\x15\xad\x6f\x81\xa0\xa9\xd8\xfb\xee\x52\x3b\x4b\xb3\xe6\xc7\xc5\xdf\x6d\xa4\x44\xd6\xf8\xc5\xe9\x8a\x3c\x6f\x6e\xd8\xf2\xfc\x46\xfb\x33\xd5\x8f\x08\x79\xd2\xfe\xaf\x65\x84\x88\xc6\xdf\x25\x1a\x55\x15\x60\x12\x8c\x14\x68\xcc\xe3\xd2\x27\xef\xff\x08\x03\xe7\x58\xac\xc7\x84\x99\xe6\x60\x9c\x23\x8d\xa6\x5c\x74\x6b\xec\xe2\xfd\x11\x47\x3e\x19\x7f\x68\x45\xf9\xf4\xde\x59\x0a\xfb\xfe\xe6\xf9\xdc\x48\xf3\x71\x73\xdc\x52\xff\x23\xae\x97\x43\x07\xca\x4a\x07\x5c\x98\x42\xf1\x0a\x8b\xc4\xff\xb5\x07\x2a\xad\x44\xa5\x5c\x4b\xa5\xd7\x28\xa8\xa5\x01\x2f\x4b\x53\xdc\x83\x24\x8e\xb5\x75\xa5\x68\x82\x8c\x94\x0e\x6b\x56\x9f\x5b\x0f\xdc\x32\x5b\x0c\x03\x1d\xc8\xc0\x67\x2d\xf9\xc0\xe7\x2b\x77\x18\xf9\x84\x02\x47\x4c\x67\xc4\xb0\x28\xb0\x64\xa5\x53\x9d\xc8\x4a\xa6\xa3\x76\x40\xa3\x1a\xdc\xc2\x3e\x7e\xe0\xc9\x5b\x74\xed\x0b\x78\x46\x07\x26\x84\x6c\xbc\xbf\x68\xee\xe7\x01\x0b\x3a\xaa\xff\x88\x20\xd4\x87\xb2\x75\x6b\x42\x8a\xd4\x3a\xa6\xfc\x56\x20\xb2\x2f\x75\x2f\x90\x6c\x23\x02\x98\xca\xec\x9a\xd0\x56\xc7\xc6\x83\x5f\x4a\x23\x9b\x3b\xa6\x0e\xa4\x9f\x3e\xd2\x75\x49\xb7\x4e\x03\xe0\x50\x13\x2a\xb4\xe3\xa2\xbd\xb8\x1c\xe1\xb1\x8c\x7c\xb5\x54\x4d\x23\xaf\x3d\x17\xf2\x32\x3e\xce\x79\xdf\x62\x54\x4e\x9b\xa1\x29\x13\x1c\xfe\x1f\xf3\xdb\xda\x9f\x0b\x86\xae\x2d\xe7\x2f\x8e\x74\x8d\xc2\x9e\xbf\x6d\x0a\x4e\x68\x46\x9d\x53\x6e\xef\xd5\x8e\xb2\x7a\xa0\x45\x1d\xaa\x3c\x7d\xd7\xd0\x37\x0a\xbd\x9e\x42\x4b\x7f\xb6\x97\xab\x1a\xfc\xbc\x24\xd2\x54\xe3\x7f\x6b\x68\x1d\x5d\x2c\x87\x8e\xfa\x1c\xd1\x15\x75\x7f\x80\xcc\x11\x3b\x8e\x08\x47\xd4\xf0\x0e\xcb\x1c\xe8\xea\x38\x34\x45\x76\xb9\x38\xf8\x0d\xfe\x2d\x29\xfb\xec\x8d\x46\x1a\x39\x68\x53\x41\x57\x00\x58\xe3\x9c\xb2\x0f\x18\xcd\xc2\x99\xa5\x33\x94\xa4\xaa\x64\x66\x9e\xbe\x0b\x11\xd1\xd8\x41\x93\xc2\xe0\xee\xb8\xbb\xd3\x72\xac\x71\xcb\xf9\xe8\x2f\xe0\x50\x5f\x30\x24\x6a\xb2\x73\x5d\x1d\x89\xbe\x3f\x9c\x32\x88\xca\x3b\x82\xa3\x2b\x93\x52\x81\xc0\xd5\x26\x98\xb4\x67\x2c\x33\x4f\xd9\x61\x7c\x9d\x8e\x37\x09\x7c\xa9\x77\xaa\x0f\x12\x07\x2f\xa8\x43\x10\x85\xbe\x05\x3f\x27\x1e\x4d\x6d\xf6\x0a\xa7\x0c\x9c\xbd\xd3\x14\x5b\xba\x9d\x8b\x42\x27\x96\x4a\x91\xc4\x1a\xd2\xf2\x69\x6e\x47\x3a\x70\xbf\x2d\x35\xac\x76\xb7\x18\xc5\x2d\xf3\x1b\xde\xcc\x54\x59\x31\x39\x61\xac\xb6\x39\xb3\x19\x26\x8c\xc4\x8e\x2c\x98\xec\x68\xee\xca\x11\x2d\x07\x4b\x9e\xae\xde\x08\x2d\xfc\x9f\x49\x6c\xc4\xc9\x04\x05\x62\xb5\xb2\x25\xdf\x85\xc0\xcb\x4c\xb0\x64\xa3\xe7\x46\xb7\x11\x43\xbf\x48\xa2\xde\x2c\x6a\x8b\x28\xec\x4f\x58\x24\x3e\x4e\xc9\x5f\xb6\x88\x8d\xc7\xc4\x02\x71\x01\xe1\x5d\x27\x4b\x26\xe5\x79\xb2\x59\xd8\x35\xb1\x94\x67\xd2\x12\xb5\xc7\x50\x9e\x24\x4e\xe9\x98\x06\xbf\xe8\xe8\x69\x7d\xbe\x96\xfd\x8f\x8a\xc7\xcd\x80\xf8\x2e\x5c\x6d\xe6\xe5\xe4\x65\xfc\x2d\x38\x5e\x3f\x5c\xeb\xd5\x54\x04\x65\xd7\xf6\x4e\x9e\xaf\x52\x5f\x5f\x4b\x09\xd7\xf7\xd1\x6d\xb4\xab\x1e\xb2\x6a\x4b\x52\x24\x5b\xb9\x05\x26\xa7\x81\x63\x71\x90\x09\xa3\xe8\x2c\xe2\xb7\x06\xb9\xf6\xc2\x80\xe8\x0e\x57\xd6\xd0\xf4\x81\xdb\xa8\xb7\x45\xf3\xdc\xf7\x73\xb9\x94\x92\x68\x66\xb2\x7e\x14\xfb\xf0\x9f\x00\x00\x00\xff\xff\x5d\x16\x7b\x15\xd3\x10\x00\x00")
+var _complySoc2PoliciesAssetMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x57\xdd\x6e\x1b\xcb\x0d\xbe\xd7\x53\x10\x38\x17\x6d\x03\x49\xe8\x31\xfa\x07\xdf\xf9\x38\xa7\xa8\x81\xa4\x0d\xec\xbc\xc0\x68\x86\xab\x65\x3d\x3b\xdc\x92\xb3\x12\x94\xa7\x2f\x38\xfb\x37\x92\xdd\xa4\xc0\x41\x2e\x22\x4b\xc3\x21\xf9\xf1\xfb\x48\x4e\x72\x1d\xde\xc3\x83\x2a\x66\x78\x4a\x27\x4c\x99\xe5\x02\x2e\x05\xf8\xec\x92\x3b\x62\x87\x29\xc3\x17\x8e\xe4\x2f\x1b\xe7\x85\xd3\xa5\xbb\x87\x87\xa7\xcf\x5f\x36\xea\x32\x69\x43\xa8\xf7\x1b\x80\xaf\x2f\x8f\xf6\x1f\xc0\x0e\x1e\x1f\xef\xf6\x3f\x2f\x9f\xff\x52\x7d\xfe\xeb\xfe\xe7\x4d\xe7\xfe\xcd\xf2\x8c\x27\x52\xe2\x54\x4c\x77\x10\x5c\xc6\x7b\x78\xc1\x1e\xfe\x06\x77\x7f\xbc\xfb\x73\x31\xf0\xdc\x99\xef\x7b\x78\x4a\x94\xc9\x45\x08\xec\x07\xfb\x06\x1a\x16\x3b\x76\x67\x4e\xc1\x73\xd7\x47\x72\xc9\xe3\x66\xb7\xdb\x6d\x36\x3f\xc1\x97\x41\x7a\x56\x2c\x39\xbc\x78\xee\x71\xb3\x71\x7b\xf8\xda\x22\xf4\xd3\x2f\xdc\x40\x6e\x49\xa1\x2f\x69\x01\x29\x64\x06\xd4\xec\x0e\x91\xb4\x05\xc1\xff\x0c\x24\x25\x73\x2d\xce\x3a\x47\x29\x3b\x4a\x94\x8e\xe0\x12\x38\xef\x07\x71\x79\xf4\x60\xfe\x05\x5b\x4c\x4a\x27\x04\x5a\x10\xe4\x06\x5c\x8c\x40\xa9\x61\xe9\x5c\x26\x4e\x90\xd1\xb7\x89\x23\x1f\x2f\xe0\x0c\x6f\x85\x33\xe5\x96\x12\xe4\x16\x81\xe5\xe8\x12\x7d\x1b\x4f\xda\xc5\x98\x74\x90\xe2\xb1\xef\x85\x7b\x21\xf3\xa8\xe8\x07\xa1\x7c\x01\xcf\x29\x0b\x47\x05\x27\x08\xae\xef\x23\x61\x80\xdc\x0a\x0f\xc7\x96\x87\x5c\xae\x2c\x4e\x20\x52\x83\xfe\xe2\x23\xee\x27\x1c\xd6\xc4\x47\xbb\x92\xbd\xc5\xda\x3a\x09\x67\x27\xb8\x05\xe5\x26\x8f\x9f\x82\xcb\x6e\x5b\x12\xa5\xd4\x88\xd3\x2c\x83\xcf\x83\x60\xc1\x9d\x53\x81\x28\xb7\x2e\x83\x0e\x7d\xcf\x92\xe1\x30\x28\x25\x54\x05\xee\x51\x4a\x36\x3a\x9b\x2f\x40\xf4\xc2\x1e\x55\x29\x1d\x7f\x18\x13\x76\x7d\xe4\x0b\xa2\x6e\xc7\x94\x9d\xcf\x2c\x3a\x46\x94\x5b\x92\x00\xbd\x93\x6c\x06\xe7\x96\xa1\x2b\x94\xdd\x4e\xbe\x71\x0b\x2c\xd0\xba\x13\x5a\xc9\x2c\xa6\xcc\x57\x38\xbb\x38\x15\x62\x6f\xbc\xf9\xc5\xf9\xd7\xa3\xf0\x90\x42\x09\xea\xd7\xa6\x41\x9f\xad\xa8\xe5\xcc\x74\xb7\x91\xc2\x08\xd3\x0c\x29\x38\xfb\xc3\x45\x83\xaf\xce\x6e\xa9\x91\xc5\xb8\xa0\xe0\x22\x08\x2a\x45\xc2\xe4\xd1\x52\xbe\x29\x79\x37\x68\x5e\x88\x06\x26\x8f\x03\x45\xab\x34\xa5\x09\x8a\x89\x33\x99\xc1\xf8\x80\x12\x47\x1a\xa9\x82\x90\xbe\x1a\x26\x7d\x1f\x2f\x6f\x29\xb2\x05\xea\xfa\x58\xe8\x0c\xbe\x75\xe9\x88\x13\x7e\x82\xda\x73\x0a\x16\xff\x62\x44\xc9\x53\xb0\xaa\xce\x95\xc1\x55\x6a\x62\xaa\xc5\x00\x3d\x93\x95\x9d\x1b\x68\xd8\x0f\x0a\xd8\xf5\xad\x53\xfa\x86\x85\x75\xd4\x19\x0f\x4c\x90\xc0\xcd\x8d\x3c\x2c\xde\xbc\x8a\x64\x0b\x94\x7c\x1c\x42\x61\xf9\x22\xaa\x72\xc6\x47\xa7\x4a\x0d\xf9\x55\x10\x46\x45\x68\x22\x9f\xa1\x73\x7d\x6f\x36\x99\x17\xda\xe1\x52\xad\x37\xf9\x97\xe2\x3e\x63\x83\x62\xd0\x6b\xc9\xeb\xa9\xaa\xd7\xcb\x6c\x30\x75\x39\xfb\xfd\xb1\xe0\x04\x8f\xe3\x15\xf5\x2f\xcf\xa4\xaf\xef\xb4\xc6\xcd\x4f\xcb\x27\xb7\x87\x0f\xb7\xfd\xf4\xb9\x6a\x29\xf7\x1f\x36\xa5\xc1\xd1\x3b\x2c\xd0\xd6\x84\xb8\xd0\xc0\xfd\xb6\xf6\xb2\xa2\x7b\x18\x32\x24\xce\x10\xa9\xa3\x6c\x9d\x82\xad\xf3\x8e\xff\x76\xf0\x8f\x49\xf8\x10\xf0\x44\x1e\x15\x7e\xaf\x28\x27\x34\x9d\x9d\x59\x5e\x35\x17\x07\xba\x85\x84\xd9\xbe\x00\xcb\xa6\xb7\x64\xb6\xd0\xf1\x81\xe2\x62\xf9\x87\xea\xd6\x97\xa9\x89\x14\x5e\x4e\x85\x1c\x7b\x81\x5e\x34\x63\xa7\xd5\xd9\x8f\x56\x5b\xc1\x9e\x95\x32\x8b\xc9\x79\xae\xf8\xc1\x29\xd6\x27\x1f\x23\x0f\x01\x2c\x3e\xf3\xf7\x4e\x67\xaa\xce\xfe\x73\x0a\xb7\xea\x55\x76\xde\x73\x4a\x46\x16\x4e\xba\x54\xe2\x57\xe7\xdb\x89\x7a\x53\x43\x5e\xb1\x2e\xd2\x1c\xb1\x44\x10\x8c\x78\x72\x29\x83\xcb\x59\xe8\x30\x64\x54\xd0\xa1\x18\xd7\x90\x8e\x04\x28\x52\xa2\x86\x50\x4a\xa0\xd7\xa4\xae\x4e\xff\xeb\x9c\xe6\x23\x83\x66\x0e\xe4\x52\x5d\xda\xea\xe4\x27\xae\x14\x31\x97\x63\x4e\xe8\x44\xf9\x52\xdf\x3a\xf6\x9e\x74\x9c\x00\x2f\x36\x73\x6b\x07\xab\xaf\x55\xa4\x32\x78\xb9\x95\xce\xd4\x8d\x43\x75\xe6\x97\xb9\xbf\x7b\xa1\x4c\xde\xc5\xb9\xd3\x59\xb1\x6e\x33\xac\x79\x7e\xa3\xfd\x89\xea\x07\x84\xa1\xb7\xf9\x1f\x80\x12\x08\xba\xb8\xcb\xd4\x99\x2a\xc0\x65\xe8\x28\x51\x37\x74\xf3\x9c\xbc\xfb\x13\xb4\x3c\x88\x16\xf6\xa7\x89\xe6\xe0\x42\x20\xf3\x66\x5c\x0c\x8b\xef\xd2\xfb\x05\x3b\x3e\xb9\xb8\xaf\x45\xf9\x78\x15\x62\x09\xbd\xb0\xef\xef\x91\xcf\x95\x34\x1f\xd6\x8e\x5b\xea\x7f\xc0\x25\x39\x0c\x60\xac\x0c\x60\x33\xbd\x45\x92\x2b\x2c\x32\xff\xcf\x19\x68\xed\x51\x4d\xca\x63\xa9\x2c\x8d\x82\x5a\x6e\xf1\x32\x0f\xc5\x2d\x68\x66\x9b\xbc\x2c\x90\xc5\x25\xed\x28\xef\x97\xa8\x3e\xd6\x3d\x70\x8d\x6c\x6e\x18\x18\x40\x5b\x3e\x9b\xe2\x5b\x3e\x5f\x75\x87\x8e\x4f\xa8\x70\xc0\x7c\x46\x4c\xb3\x02\x4b\x54\xb6\xd5\xa9\x2e\xda\x3e\xd8\x04\x74\xa6\xc1\xd5\xed\xc3\x3b\x3d\x79\xf5\x6e\x73\x01\xcf\x18\xc0\xa5\x34\xb8\x18\x2f\x06\xfd\xb9\xc5\x82\x8e\xad\x27\x82\xa0\x74\x4c\x05\xf4\x75\x08\xd9\xdc\x59\xd6\x94\xdf\x29\x08\xc7\x52\xf7\x02\xc9\xba\x22\x80\xb3\x29\x4c\x36\xe3\xaf\xea\x58\xf5\xe0\xe7\x32\xc8\xa6\x89\x49\x58\x77\xd8\x4a\xd7\x25\xdc\x71\x1b\x80\x80\x16\x50\xa1\x1d\x17\xed\xc9\x7c\x45\xc4\xb2\xf2\xe5\xb6\x9a\x24\x86\xd2\x38\x73\x61\x98\xd7\xc7\x29\xee\x5b\x8c\xca\x6d\x13\x34\x65\x83\xc3\xff\x63\x7f\x5b\xe6\x73\xc1\x30\xd4\xe5\xfc\xe1\x4a\x37\x79\x37\x85\x3d\x7d\x5d\x15\x9c\xd1\x75\xb6\xa7\xdc\xe6\x35\x5f\x6d\x14\xb1\x24\x3c\xa6\x2c\x2e\xd2\x37\x0c\x6f\x14\x7a\xbd\x85\x96\xf9\xec\x2f\x57\x35\xf8\x34\x07\x52\x4d\xc4\xef\x6b\x68\x59\x5d\x3c\xa7\x86\x8e\x83\x60\x28\xea\x7e\x07\x99\x03\x36\x2c\x08\x07\xb4\x58\x03\x96\x3d\xd0\xba\xc4\xb8\x00\x85\xa1\x74\xf0\x1b\xfc\x57\x47\x82\xc7\x21\x3a\x5b\x92\x3a\x4e\x36\x54\x30\x94\xc2\x2e\x7e\x4e\x43\x4c\x28\x6e\xe6\xcc\xb4\xcc\x8f\x41\x15\xcd\xce\x3c\x7d\xe3\x42\xd0\xf9\xd6\x82\xc2\x14\x76\xdc\xec\x6c\xc3\x5e\xfc\x96\xfb\x31\x5e\x20\xa0\xbd\x60\x48\xed\x9d\x33\xd5\x35\x90\xda\xfb\x23\x18\x83\x6c\xba\x7b\xcf\x12\xca\xa6\x54\x20\x28\xc4\x17\xcc\x98\x96\xce\x34\x5a\xb8\x38\x6e\xc7\xab\x04\x3e\x8f\x39\x59\x10\x16\xf7\x33\xda\x12\x44\xe9\x58\x83\x3f\x64\xee\x0a\xc3\x33\x1b\xd1\xb4\xe5\x21\x06\x38\x5c\xd3\xed\xdc\xa2\x20\xf4\xac\x23\x45\x32\x43\x20\xf5\x7c\x9a\xc6\x91\x2d\xdc\xaf\x73\x0d\xc7\x76\x37\x37\x8a\x1b\x58\x6a\xde\x4c\x54\x59\x30\x39\xa1\x50\x53\x3d\x50\xa0\x47\x21\x0e\xe4\xc1\x0d\x81\xa6\xa9\x2c\xe8\x39\x79\x8a\x74\xf5\x46\xa8\xe1\xff\x48\xea\x05\x7b\x97\x3c\x55\xad\x6c\x8e\x77\x26\xf0\xbc\x13\xcc\xd1\xd8\xbd\x12\x56\x62\xd8\x2f\x9a\xe9\x58\xa0\x99\xd6\x60\x8e\x27\xdb\x6b\x85\xbb\x3e\xc7\xcb\xea\xb1\xea\x31\xb6\x9c\x88\x6d\xbd\xe9\x8d\x4e\x6c\x3f\x1a\x46\xb7\x0b\xbb\x7a\xd6\xf2\x4c\x9a\xbd\x1e\xd1\xc8\x66\x2e\x3b\x4e\xb9\x8d\xb3\x8e\x1e\x97\xe7\x6b\xb1\x7f\x30\x3c\xee\x3f\x7c\x7f\x70\x96\xde\x3d\xe6\x33\xaf\xbf\x05\xc7\xeb\x87\xab\x5d\x37\x8a\xa0\x58\xad\xef\x64\x90\x99\x2d\x6b\xa2\x0f\xcb\xfb\xe8\xd6\xdb\xd5\x0c\x99\x92\x11\xd4\x2c\xe4\x2d\x1b\xb3\x18\x72\xcb\x52\x3a\x48\x8f\xa2\x9c\x12\xc6\x75\x40\x2e\xb3\x30\x21\x86\xca\x61\x8c\xf5\x1c\xb8\xf5\x7a\x5b\xb4\xc8\xc7\xe3\x54\x2e\xa3\x24\xba\x89\xac\xef\xf9\xde\xff\x77\x00\x5d\x16\x7b\x15\xd3\x10\x00\x00")
 
 func complySoc2PoliciesAssetMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -541,12 +562,12 @@ func complySoc2PoliciesAssetMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/asset.md", size: 4307, mode: os.FileMode(420), modTime: time.Unix(1757470844, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/asset.md", size: 4307, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesAvailabilityMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x58\x41\x6f\x5b\xb9\x11\xbe\xeb\x57\x0c\xb0\x17\x2b\x6b\xab\x71\x92\x43\x6b\xa0\x07\xaf\x8b\xa2\x2e\xbc\xdb\x20\x76\xb1\xe7\x11\xdf\x48\x8f\x35\x1f\xf9\xc2\x21\xad\xbc\x20\x87\xfc\x8d\x02\xed\x9f\xcb\x2f\x29\x66\xc8\x27\x3d\x59\x72\x92\x5d\x6c\xb1\x7b\x58\x5d\x6c\x51\xe4\x70\xf8\xcd\x37\xdf\x0c\xe9\xb1\xa3\x0b\xb8\x7c\x40\xeb\x70\x69\x9d\x4d\x03\xbc\x0e\xce\x9a\x61\x86\x26\x06\x3f\x74\x17\x70\xf9\x7a\xc6\x98\x2c\xaf\x2c\xf1\xc5\x0c\xe0\xee\xf6\x4a\xfe\x00\x9c\xc1\xe5\xf9\xe2\xbc\xfe\x7b\x75\xf5\xa7\xc5\xf9\xac\xc3\x7f\x85\xf8\x86\x1e\x2c\xdb\xe0\x75\xf6\x19\x34\x98\xe8\x02\xfe\x9e\x3d\x9c\xc3\x8b\xe7\xe7\x7f\xd4\x05\x26\x74\x1d\xf9\x74\x01\xd7\xde\x26\x8b\x0e\x9a\x60\xb2\x8c\xcc\xce\xce\xce\x66\xb3\x6f\xe0\x75\x8e\x7d\x60\x02\xf4\x0d\xdc\x9a\xd0\xd3\x6c\x86\x0b\xb8\x6b\x09\xfa\xfa\x4b\x58\x41\x6a\x2d\x43\xaf\xfe\x82\x65\x48\x01\x1a\x5a\x59\x4f\x10\xe9\x6d\xb6\x91\xc4\x20\xc3\x2a\x44\xe8\x63\xe8\x29\x82\x09\x3e\xc5\xe0\x74\x6a\x1f\x43\x22\x93\x20\xb5\x04\x38\x05\x40\x0d\x13\x84\xb8\x46\x6f\xdf\x63\xb2\xc1\x7f\xfa\xf8\x6f\x06\xeb\x57\x21\x76\xfa\x1d\x78\xe0\x44\x1d\x2f\xaa\x57\x3b\x37\xb0\xef\x9d\x25\xdd\x00\x9d\x83\xcc\x14\x59\x2c\x1e\x59\x0c\x1b\x9b\x5a\xeb\x0f\x36\xab\x06\xd3\xd0\x5b\x83\xce\x0d\x60\xbd\x71\xb9\x21\x06\xea\x7a\x17\x06\x22\x56\x58\xf4\x30\x68\x52\x88\x7c\x0a\xc8\xb0\x21\xe7\xe4\x2f\xfa\x01\xe8\x5d\xa2\xe8\xd1\x41\x8f\x31\xa9\x3f\x2d\x26\x41\x9d\xc0\xfa\x14\x74\x2d\x9a\xa4\x2e\x6c\xfd\x11\xa3\x53\x3f\x2b\x5a\x8e\x1a\x58\x0e\x07\x6e\xc2\x49\x4b\x91\xac\xc7\x55\xa2\x08\x91\x56\x14\x23\x35\x7a\x70\x86\x4f\x1f\xff\xa3\x47\xff\xf4\xf1\xbf\xf3\x7d\x80\xba\xcc\x09\x96\x04\x1d\x36\x12\x27\x6c\xac\x1b\x46\xfc\x1d\xed\xe1\xb6\x10\x22\x7c\x87\xe6\x7e\x1d\x43\xf6\xcd\x96\x01\xd6\x27\xf2\xe9\x38\x01\x3a\xeb\x6d\x67\xdf\x53\x09\x6b\x17\x72\x99\x98\x3d\xbd\xeb\xc9\x24\x6a\x20\x44\xc8\xbe\x77\xe8\x3d\x35\xd0\x84\x8d\x4f\xb6\x23\x38\x41\xc7\x01\xee\x7d\xd8\x78\xf1\x3f\xe4\x84\x6b\xe2\xf9\x53\xa1\xcb\xbe\xa1\x78\x9c\x26\x15\xb5\xfd\x53\xf7\x91\xd8\x44\xbb\x24\x06\xee\xc9\xd8\x95\x35\xd0\x11\x72\x8e\x54\x08\x7a\x80\xae\x06\x6c\x63\x9d\x93\xf0\x47\x42\xa6\xba\x39\x44\x6a\xb2\x6f\xd0\x9b\xe1\x54\xa0\x88\xa1\xc9\x86\x60\x85\xd6\x85\x07\x8a\xd0\x91\x69\xd1\x5b\xee\x84\x15\x12\xd1\xae\x77\x9a\x09\xd0\x05\x6f\x53\x88\xd6\xaf\x81\xb3\x69\xcb\x16\xf5\xa4\x80\x91\xc4\xcb\x07\xf2\x02\x12\x32\x74\x32\x05\xe5\x04\xcc\x76\xe9\x68\x01\x3f\x4a\xc0\xc5\xd3\x01\x0c\x7a\x1f\x34\x8e\xdb\x35\xa7\x5b\x53\xea\xf5\x92\xe0\x6d\xb6\xe6\xde\x0d\xd0\x50\x2a\xd0\x8b\x3b\x92\x95\x8d\xc5\x44\x4d\x49\x9e\x1f\xc7\x24\xd8\x82\x25\x6e\xef\x67\xa4\xe5\x9a\xd8\xea\x18\x82\x69\x51\x88\x4f\xd1\x72\xb2\xe6\x71\x8c\x42\x3c\x1a\x32\xeb\x61\xd3\x5a\xd3\x96\xb3\x3f\x5a\x30\x4e\x32\xe8\xc5\x73\x34\x86\x98\x0b\xef\x31\xa7\x36\x44\xfb\x9e\x1a\x20\x9f\xac\x66\xd3\xa6\x25\x4f\x02\xb6\x27\x6a\xf4\x24\xdf\xc0\x1b\xc9\x00\xf2\x86\x58\xcf\xf5\xc6\xf2\x3d\x5c\x32\x13\xb3\x82\x5f\x65\x55\xa4\xad\xfe\x87\x0b\xb8\x3e\xe2\xe7\x98\x20\x26\x78\xb6\x2c\x4c\x7f\x9c\x20\x26\xf8\x26\x9b\xa4\x70\x72\xee\xfb\x10\x13\x2c\x33\x5b\x4f\xcc\x20\x3a\xa7\x06\xab\x38\x3d\xb9\x45\x8b\x0f\x04\xb8\x03\x76\x0a\xb8\x71\xc8\x2c\x24\xd5\x75\xa7\x45\x26\xb0\x17\x15\x8d\x12\xbb\x9d\x90\x92\x17\xaf\x9a\xaa\x1d\x26\xc4\x3e\x44\x09\x6e\x91\x99\x86\x1e\xc8\x85\x5e\x01\x90\x19\xbd\x92\x55\x7d\xe9\x63\x50\x8c\x19\x96\x28\x48\x87\xca\x81\xfd\xad\xcb\x19\x6e\x0b\xf1\xc5\x82\xa7\xb4\x09\xf1\x5e\xe9\xae\xc9\x33\xc2\x15\x49\x70\x20\xdd\xa3\xeb\x05\xb3\x14\x8e\x27\xa8\x23\x6c\x34\xe7\xa6\xd0\xdc\x91\x69\x7d\x70\x61\x3d\xc0\xc9\xf5\xdd\x5c\x38\xd1\x10\xdb\xb5\xd7\xc3\x5c\xdf\x4d\x70\x85\x0e\x3d\xae\x29\x16\xdf\xfe\xa9\x0a\x3f\x7a\xe1\x43\xb2\x2b\x2b\xc7\x59\x01\x9b\x96\x9a\x2c\xe0\x8c\x89\x71\x42\x8b\xf5\xe2\x74\xcc\xe3\x0e\x55\xc8\xd0\x1b\x9a\x97\x5c\xac\x65\x0b\x7a\x8a\x36\x34\x5a\x37\x46\x81\xaa\x72\x52\xec\x17\x6c\xca\xa6\x45\x4e\x8a\x3c\x4b\xa9\x55\x94\x54\xd2\x6a\x1d\x3b\xdc\xed\x14\xb6\x62\xd8\xe4\x58\x43\x2c\xcb\xd0\x27\x6b\x6c\xaf\x47\xae\xcb\x24\x37\x28\x3e\x58\x23\x08\x73\xee\xfa\x22\x4e\xe2\x91\x1e\xff\x75\xb4\xa2\x5e\x61\x1a\xda\xcc\xb2\x05\x9a\x16\x3c\x6d\xd4\x82\x5d\x7b\x75\x5b\xb9\xdc\x85\xa6\x60\xa4\xb5\x72\x7a\x96\x4a\x49\x13\x44\xb2\xc4\x89\x28\x49\x84\xbb\x24\x52\x94\xb6\xe5\x70\x8f\xb2\x32\x95\x6b\xda\xed\x56\x4c\xd3\x69\x34\x6a\xbd\xe4\x77\x88\x22\xa1\x54\xa8\x2d\x38\x1d\x4f\x58\x38\x89\x63\x5e\xc3\x09\xce\xe7\xe5\xd4\x57\xd8\xa3\x91\x5d\x0b\x15\xb6\xfc\x76\x01\x1b\x58\xa2\x43\x6f\x44\x65\x93\x90\xca\xbe\xcd\x13\x9a\x66\x16\xa5\x44\x11\x34\x11\x42\xf0\x24\x59\x80\x71\x38\x15\x10\x5b\x72\xfd\x7e\x09\x2b\x08\x14\x19\x97\x82\x2d\xbc\x2a\x91\x19\x33\xe0\xe9\x34\x9f\x82\xea\xf7\xd2\xb7\xc1\x84\xb0\x44\x73\x9f\x7b\x90\x52\x58\x80\x25\xaf\xf5\xe8\x62\xa6\x2d\x9a\x5d\xc0\xa5\x73\xc0\xe4\xd9\x26\xfb\x50\x17\x55\x81\x8c\xc4\x29\x48\xb9\xaf\x3d\x0c\x4a\x29\xe7\xe0\x8b\x44\x09\xfb\x0a\x87\x17\x5b\x5b\x7f\xcd\x52\x14\x74\x4b\x25\xb6\x89\x36\x49\x7f\x23\xa6\x42\x8e\x66\x2c\x41\x14\xe5\x20\x45\x10\x30\x49\xb2\x72\x02\x84\x0d\x91\x94\x92\x25\xb2\xe5\x9d\xd1\x6b\x29\x8d\x82\x3e\xee\x6c\x4b\x66\xff\x44\xe3\x0d\xda\x43\xdb\xdf\x55\x7b\x9a\x18\xcc\xc1\x68\xd1\x02\xad\x5e\x6a\x4d\x33\x0a\x55\x3c\x65\x4f\x2c\x81\xcb\x5d\x6d\x4c\x62\x1a\xe0\xe4\xe5\xf3\x39\x34\x38\x70\x2d\x7d\xd3\xe9\xe3\xf6\xc1\x13\x9c\x9c\xcf\x61\x20\x8c\xa7\xa5\x78\x1d\xf0\xd3\xd1\x1a\x5d\xb5\xb1\xce\x0e\x53\x88\xc3\x5e\x93\x7b\xc4\xed\x48\x50\x63\x14\x56\xab\x33\xb6\xa9\xda\xea\xb2\x4b\xb6\x77\x04\x7d\xb0\x92\x20\x61\x35\xe9\x28\x46\x95\xae\x05\x5b\x8a\xca\x1a\xc8\x9b\x38\x94\xc4\x97\x9f\xef\x69\xca\xfb\xdd\xce\x77\xc4\xc5\x5c\x25\x96\xf2\x65\x52\xcd\x44\x1e\x14\x7a\xa3\x91\x80\xb7\x19\x63\xa2\x38\x59\x68\x82\x5f\xd9\x75\xde\xea\xec\xc1\xda\xb4\xb1\x75\xb1\xa0\xf5\x73\x98\xff\xe8\xa8\xdb\xae\x69\x97\x05\x1d\x51\x62\xcd\xbd\x55\x70\x2e\x6c\x04\x01\xe1\x13\x45\x8b\xbb\xd4\xf8\xa1\xd6\x21\xeb\x57\x11\x39\xc5\x6c\x52\xd6\xe6\x08\xd3\x58\x93\xf9\x18\x0d\x77\x8e\x15\x5f\xcf\x9c\x94\xc8\xa9\x5b\x27\x45\xdf\x64\xdb\x65\x4e\xa2\xf8\xe0\x6c\x67\x53\xed\xad\x81\x49\xf0\xc0\x38\x40\x1f\x36\x14\x75\x37\x37\x9c\x6e\x51\xb7\x7c\x7f\x86\x31\xe2\x50\x34\x7d\x37\x5d\x14\x30\x27\xed\xfe\x74\xeb\xf9\x02\xae\x46\x07\x4d\x88\x45\x22\x83\x57\xd5\xfc\xbc\x13\x31\xe4\x44\x72\xeb\xe0\x8d\x4d\xa6\xa5\xda\x69\x86\xd4\x92\x94\x4d\xa9\x16\x0c\xce\xfa\xfb\x32\xfd\xb6\x16\x90\x1b\x3d\xea\xe5\x3a\x52\xbd\x99\x9d\xdc\xde\x5c\xf2\x7c\xfe\x28\x5a\x46\xd4\x46\xea\xc4\x2e\xbf\xb8\xc7\x48\x0b\x90\xe9\x65\xf2\xb6\x52\xa2\xe0\x1c\xa9\x77\x68\x8a\x0c\x8f\x97\xaa\x0d\xf9\x34\x9c\xad\x42\x8e\x70\xf2\xe2\xd5\x1c\xda\x90\xe3\x24\x4d\xc4\x27\x29\xdc\xd3\x80\x7d\x3e\x5e\x63\x88\xd2\x14\x77\x5b\xef\x62\x7e\x4b\x87\x44\x71\x85\x86\xc0\x60\x6c\x78\x94\xd0\xb2\xd7\xaf\x78\xcc\xb1\xab\x2a\x1d\x74\x6b\xd7\xed\x7e\xf5\xd4\xdd\x32\x93\xd2\x07\x3a\x1b\xa3\xde\x13\xbe\xaa\x85\xdc\x4f\xb0\x6d\x23\x2a\x2d\xa2\xf5\x59\xac\xff\xd4\xe4\x7a\x43\x46\x92\x72\x28\x95\x44\xf0\x55\x2d\x71\x81\xb9\xd0\xb0\xc8\xdb\xd8\xba\x5a\x0f\x77\x5a\x78\x5e\x2e\xe0\x09\x1b\x7b\x0f\x02\x47\x0d\x8e\x62\x83\x8d\xdc\x72\x94\xb7\xe5\x72\x3c\x5e\xd7\xc6\x17\x8a\x82\x43\xbd\xb7\xcb\xc9\x76\x68\x5f\x85\xae\x97\xdb\x37\xfa\xe6\x0f\x21\xee\x2e\xe1\x87\xbc\x3a\x15\x11\xe3\xe0\x3d\xb9\xd3\x4a\xa0\xda\x07\x48\x2a\x46\x52\x72\x28\x47\xa6\x42\x38\xfa\x52\x5e\x05\x1a\xb9\x8e\x48\x48\x27\xe1\x1e\x9d\x8d\xc4\xbd\xdc\x1f\x34\xbc\x23\x49\x13\xf2\x7d\xa9\x8f\xe5\x67\x4d\xef\x14\x80\x3a\x8a\x6b\xf2\x66\x9c\xa7\x8d\x5e\x49\xfd\x83\x4b\xc5\xd6\x97\xda\x85\x35\xc7\x91\x10\xda\xd7\xf6\x4e\x02\xf3\xc5\xfa\xb5\x3b\x62\x7d\x6a\x92\x43\x7d\x7b\x76\xf8\x39\x32\xf6\x78\xe8\x6b\x97\xcd\x3e\x3c\x7b\x36\x7d\xf9\x7a\xf6\x0c\x00\x3e\xc0\xc1\xa0\x0c\xdd\x8e\xbd\x7c\xfd\xbe\x65\xd7\x9d\xed\xa8\x8e\xfd\x45\x28\x75\x23\x94\x0a\x51\x86\xc4\xfe\xd5\xde\x6d\x66\xbb\x78\x77\xf0\x3a\xf4\x0f\xbd\x21\xa8\x07\xc7\xa6\xe8\xd8\x75\xe9\x00\x65\x07\x1d\x9a\x7d\xfb\xe7\xc3\xcf\x91\xb1\xc7\x43\x5f\xbb\x6c\xf6\x01\xfe\x26\x42\xb1\xf7\xa9\x63\x29\x4c\x87\x5e\x3e\x97\xde\x27\x27\xe2\xf2\xfd\x5c\x65\x68\x7f\xd9\xf7\xd2\x1c\xa1\x9b\x0c\xcd\x3e\xc0\xe1\xe7\x03\x5c\x15\xdd\x08\x99\xb7\x43\x8f\xa7\x1c\x5b\x76\x38\xf4\xff\xe7\xcf\x57\x3a\xf2\xdb\xf5\xff\x7b\x6a\x6c\xee\xf6\x1d\xb9\x4d\x28\xfd\x42\x33\x19\x7a\x51\xca\xca\xf6\xfb\xab\xbd\xef\x75\x59\xe8\x68\xa2\xaa\x4f\xc7\x77\xef\xb1\xf9\xeb\xf1\xd1\xe7\x3d\x47\xf5\x71\x61\xf5\xb4\xfd\x2f\x0e\x3d\x61\x3f\xa9\xee\xb9\xa4\x2f\x45\x9f\xf1\xff\x67\xda\xd7\xc7\x30\x9a\x24\xc5\x2f\x6c\xbf\xdc\xc8\x4a\x71\xaa\xf6\x7f\xe7\xff\x97\xfc\xbf\x09\x9b\x03\x47\x6e\x6a\x9f\x3b\x19\x7a\xf5\x88\xff\x3f\xd0\xbb\xf4\x78\xd9\x2f\xc9\xff\x6d\xdd\x6d\x70\x18\xc7\x7e\xe7\xff\xe7\xed\xff\x0a\xfc\x9f\xd5\xae\xf3\x02\xf6\xda\x01\xed\x73\x76\xcd\xc0\x4d\xe9\x2f\x67\xff\x0b\x00\x00\xff\xff\x9b\xb5\x75\x45\x6b\x1b\x00\x00")
+var _complySoc2PoliciesAvailabilityMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x58\xcd\x6e\x23\xc7\x11\xbe\xf3\x29\x0a\xf0\x45\x5c\x51\x8c\x65\xfb\x90\x08\xf0\x41\x56\x10\x44\x81\xec\x2c\x56\x0a\x7c\x2e\x76\x17\xc9\x8e\x7a\xba\x67\xab\xba\x45\xcf\x82\x87\x7d\x8d\x00\xc9\xcb\xed\x93\x04\xd5\xd3\xc3\x21\x45\x6a\x05\x07\xb1\x9d\x43\x46\x07\x91\xcd\xea\xea\xaa\xaf\xbe\xfa\xe9\x09\xd8\xd0\x15\x5c\x3f\xa1\xf3\xb8\x70\xde\xa5\x0e\xde\x46\xef\x4c\x37\x41\xc3\x31\x74\xcd\x15\x5c\xbf\x9d\x08\x26\x27\x4b\x47\x72\x35\x01\x78\xb8\xbf\xd1\x7f\x00\x17\x70\x7d\x39\xbf\xac\x1f\x6f\x6e\xfe\x30\xbf\x9c\x34\xf8\xf7\xc8\xef\xe8\xc9\x89\x8b\xa1\x48\x5f\x80\xc5\x44\x57\xf0\x97\x1c\xe0\x12\xbe\xfa\xf2\xf2\xf7\x65\x83\x89\x4d\x43\x21\x5d\xc1\x6d\x70\xc9\xa1\x07\x1b\x4d\xd6\x95\xc9\xc5\xc5\xc5\x64\xf2\x05\xbc\xcd\xdc\x46\x21\xc0\x60\xe1\xde\xc4\x96\x26\x13\x9c\xc3\xc3\x9a\xa0\xad\xbf\xc4\x25\xa4\xb5\x13\x68\x8b\xbd\xe0\x04\x52\x04\x4b\x4b\x17\x08\x98\xde\x67\xc7\xa4\x0a\x05\x96\x91\xa1\xe5\xd8\x12\x83\x89\x21\x71\xf4\x45\xb4\xe5\x98\xc8\x24\x48\x6b\x02\xdc\x07\xa0\x28\x26\x88\xbc\xc2\xe0\x3e\x60\x72\x31\x7c\xfa\xf8\x0f\x01\x17\x96\x91\x9b\xf2\x1d\xa4\x93\x44\x8d\xcc\xab\x55\xa3\x19\xd8\xb6\xde\x51\x39\x00\xbd\x87\x2c\xc4\x02\x71\x79\x6a\x33\x6c\x5c\x5a\xbb\x70\x74\x58\x55\x98\xba\xd6\x19\xf4\xbe\x03\x17\x8c\xcf\x96\x04\xa8\x69\x7d\xec\x88\xa4\xc0\x52\x9c\x41\x93\x22\xcb\x0c\x50\x60\x43\xde\xeb\x7f\x0c\x1d\xd0\x4f\x89\x38\xa0\x87\x16\x39\x15\x7b\xd6\x98\xc0\xc4\x86\xc0\x85\x14\x0b\x10\x68\x52\x31\x61\x67\x8f\x2a\xdd\xb7\xb3\xa2\xe5\xc9\xc2\xa2\x3b\x32\x13\xce\xd6\xc4\xe4\x02\x2e\x13\x31\x30\x2d\x89\x99\x6c\x71\x5c\xe0\xd3\xc7\x7f\x16\xd7\x3f\x7d\xfc\xd7\xf4\x10\xa0\x26\x4b\x82\x05\x41\x83\x56\xe3\x84\xd6\xf9\x6e\xc0\xdf\xd3\x01\x6e\x73\x25\xc2\x77\x68\x1e\x57\x1c\x73\xb0\x3b\x06\xb8\x90\x28\xa4\xd3\x04\x68\x5c\x70\x8d\xfb\x40\xc5\x5c\x6c\x62\xee\x05\x73\xa0\x9f\x5a\x32\x89\x2c\x44\x86\x1c\x5a\x8f\x21\x90\x05\x1b\x37\x21\xb9\x86\xe0\x0c\xbd\x44\x78\x0c\x71\x13\x14\xc3\x98\x13\xae\x48\xa6\x2f\x85\x2e\x07\x4b\x7c\x04\x49\xa1\x49\x45\xed\xd0\xeb\x96\x49\x0c\xbb\x05\x09\x48\x4b\xc6\x2d\x9d\x81\x86\x50\x32\x53\x4f\xd0\x23\x74\x4b\xc0\x36\xce\x7b\x0d\x3f\x13\x0a\xd5\xc3\x81\xc9\xe6\x60\x31\x98\x6e\xa6\xc1\xe4\x68\xb3\x21\x58\xa2\xf3\xf1\x89\x18\x1a\x32\x6b\x0c\x4e\x1a\x65\x85\x46\xb4\x69\x7d\xc9\x04\x68\x62\x70\x29\xb2\x0b\x2b\x90\x6c\xd6\xfd\x11\xd5\x53\x40\x26\x68\x99\x9e\x28\x28\x48\x28\xd0\xa8\x08\x2a\xb1\x45\xdc\xc2\xd3\x1c\x7e\xd4\x80\xab\xd3\x1d\x18\x0c\x21\x96\x38\xee\xf6\xcc\x06\xd0\x7a\xab\x17\x04\xef\xb3\x33\x8f\xbe\x03\x4b\x9a\x68\xaa\x35\x58\xd0\xac\xb4\x0e\x13\xd9\x3e\x79\x7e\x1c\x92\x60\x07\x96\x9a\x7d\x98\x91\x4e\x6a\x62\x17\xc3\x10\xcc\x1a\x19\x4d\x22\x76\x92\x9c\x79\x1e\xa3\xc8\x27\x43\xe6\x02\x6c\xd6\xce\xac\x7b\xdf\x9f\x6d\x18\x84\x0c\x06\x75\x0a\x8d\x21\x91\x9e\xf7\x98\xd3\x3a\xb2\xfb\x40\x16\x28\x24\x57\xb2\x69\xb3\xa6\x40\x0a\x76\x20\xb2\xc5\x93\x2f\xe0\x9d\x66\x00\x05\x43\x52\xfc\x7a\xe7\xe4\x11\xae\x45\x48\xa4\x80\x5f\xcb\xea\xe4\x8b\xdd\x27\x9c\xc3\xed\x09\x3b\x87\x04\x31\x31\x88\x13\x65\xfa\xf3\x04\x31\x31\xd8\x6c\x52\x81\x53\x72\xdb\x46\x4e\xb0\xc8\xe2\x02\x89\x80\xd6\xb9\xe2\x96\xcc\x3f\x7f\xc4\x1a\x9f\x08\x70\x04\x76\x1f\x70\xe3\x51\x44\x49\x5a\x34\xcd\xfa\x32\x81\xad\x56\x51\xd6\xd8\x8d\x85\x94\x82\x5a\xd5\x87\xd6\x05\x13\xb9\x8d\xac\xc1\x55\x66\x6a\x39\x7e\x22\x1f\xdb\x02\x80\x4a\xb4\x85\xac\xaa\x53\x2b\x72\xc1\x58\x60\x81\x8a\x74\xac\x1c\x38\x3c\xba\xf7\xe1\xbe\xd8\x5d\xce\x08\x94\x36\x91\x1f\x0b\xdd\x4b\xf2\x0c\x70\x31\x29\x0e\x54\xce\x68\xda\xe4\x3b\x45\xea\x64\x82\x7a\x42\x5b\x72\x6e\x1f\x9a\x07\x32\xeb\x10\x7d\x5c\x75\x70\x76\xfb\x30\xd5\x2a\x61\x49\xdc\x2a\x14\x67\x6e\x1f\xf6\x70\x85\x06\x03\xae\x88\x7b\xdb\xfe\xa6\x65\x6e\x57\xd5\x42\x4c\x6e\xe9\xd4\x9d\x25\x88\x59\x93\xcd\x0a\xce\x90\x18\x67\x34\x5f\xcd\x67\x35\x0c\xd0\x60\x29\x64\x18\x0c\x4d\xfb\x5c\xac\x6d\x0b\x5a\x62\x17\x6d\xe9\x1b\x43\x81\xaa\xe5\xa4\xd7\xdf\x63\xd3\x1f\xda\x97\x93\xbe\x3c\x6b\xab\x2d\x28\xe9\x8e\xa1\x8f\x1d\x9f\x36\x83\x5d\x31\xb4\x99\x6b\x88\x75\x1b\x86\xe4\x8c\x6b\x8b\xcb\x75\x9b\xe6\x06\xf1\x93\x33\x8a\xb0\xe4\xa6\x55\xe9\xa2\xbf\x77\xff\x2d\xbb\xc8\x0a\xf5\x5e\x68\xb3\xd0\x0c\x08\xcd\x1a\x02\x6d\x14\x49\xc5\xb1\xb0\xa9\x70\xb9\x89\xb6\xc7\xa8\xf4\xca\x7d\x5f\x2a\x25\x4d\xd4\x92\xa5\x46\xb0\x26\x11\x8e\x49\x54\x50\xda\xb5\xc3\x03\xca\xaa\xa8\xd4\xb4\x1b\x77\x8c\x91\x19\x95\xba\x00\x68\x4c\x64\x2d\xa1\xd4\x53\x5b\x71\x3a\x9d\xb0\x70\xc6\x43\x5e\xc3\x19\x4e\xa7\xbd\xd7\x37\xd8\xa2\xd1\x44\xe9\xa9\xb0\xe3\xb7\x8f\x68\x61\x81\x1e\x83\xd1\x2a\x9b\x94\x54\xee\x7d\xde\xa3\x69\x16\xb2\xa5\x5b\x5b\xd2\x42\x08\x81\x34\x0b\x90\xbb\x99\x82\xb8\x26\xdf\x1e\xb6\x30\x75\x6b\x28\xe3\xda\xb0\xe3\x72\x08\xe8\x90\x01\x2f\xa7\xf9\x3e\xa8\xe1\x20\x7d\x2d\x26\x84\x05\x9a\xc7\xdc\x82\xb6\xc2\x9e\x7e\x14\x4a\x3f\xba\x9a\x94\x11\xcd\xcd\xe1\xda\x7b\x10\x0a\xe2\x92\x7b\xaa\x9b\x6a\x81\x64\x92\x14\xb5\xdd\xd7\x19\x06\xb5\x95\x4b\x2c\xc5\xa0\xb0\xa3\x72\x78\xbe\xd3\xf5\xa7\xac\x4d\xa1\x1c\x59\x88\x6d\xd8\x25\x9d\x6f\x94\x56\x31\xb3\x19\x5a\x10\xb1\x3a\xa2\x49\x13\x00\x13\x78\x42\x49\x80\xb0\x21\xd2\x56\xb2\x40\x71\x32\x2a\xbd\xd5\xd6\xa8\xe8\xe3\xa8\x5b\x33\xfb\x67\x2a\xb7\xe8\x8e\x75\x7f\x57\xf5\x29\xf8\x28\x12\x8d\x22\x67\xa1\x74\xaf\xa2\xad\xe4\x2f\x96\xe2\xa9\x67\x62\x1f\xb8\xdc\xd4\xc1\x84\x53\x07\x67\x5f\x7f\x39\x05\x8b\x9d\xd4\xd6\xb7\x2f\x3e\x1c\x1f\x03\xc1\xd9\xe5\x14\x3a\x42\x9e\x69\xb6\x9c\xe0\xa7\xa7\x15\xfa\xaa\x63\x95\x3d\xa6\xc8\xdd\xc1\x90\x7b\xc2\x6c\x26\xa8\x31\x8a\xcb\xe5\x85\xb8\x54\x75\x35\xd9\x27\xd7\x7a\x82\x36\x3a\x4d\x90\xb8\xdc\x9b\x28\x86\x2a\x5d\x1b\xb6\x36\x95\x15\x50\x30\xdc\xf5\x89\xaf\x3f\x3f\xd2\x3e\xef\xc7\x93\x1f\x48\x7a\x75\x95\x58\x85\x2f\x63\xfa\x95\x9e\xa5\xd6\xa8\x57\x3a\x8c\xbf\xcf\xc8\x89\x78\x6f\xa3\x89\x61\xe9\x56\xb5\x24\xc9\x89\xbd\x69\xe3\xea\x66\x45\xeb\x3f\x61\xfe\x33\x57\x77\x53\xd3\x98\x05\x0d\x51\xd2\x79\x99\x60\x19\xbd\x8f\x1b\x45\x40\xf9\x44\xec\x70\x4c\x8d\x1f\x6a\x1f\x72\x61\xc9\x28\x89\xb3\x49\xb9\x0c\x47\x98\x86\x9e\x2c\xa7\x68\x38\x1a\xd6\xdb\x7a\xe1\xe9\x89\xfc\xbe\x59\x67\x7d\x7d\xd3\x63\x17\x39\x81\x8e\x59\xde\x35\x2e\xd5\xd9\x1a\x84\x14\x0f\xe4\x0e\xda\xb8\x21\x2e\xa7\xf9\x6e\xb6\x43\xdd\xc9\xe3\x05\x32\x63\xd7\xd7\xf4\x51\x5c\x2b\x60\x4e\xaa\xb7\x3f\x7a\x3a\x87\x9b\xc1\x40\x13\x59\x71\x6e\xda\x18\xca\x9d\xe9\xf3\x46\x70\xcc\x89\xf4\xd6\x21\x1b\x97\xcc\x9a\xea\xa4\x19\xd3\x9a\xb4\x6d\x6a\xb7\x10\xf0\x2e\x3c\xf6\xe2\xf7\xb5\x81\xdc\x15\x57\xaf\x57\x4c\xf5\x66\x76\x76\x7f\x77\x2d\xd3\xe9\xb3\x68\x19\xad\x36\xbe\xdb\xcf\x2f\x69\x91\x69\x0e\x2a\xde\x0b\xef\x3a\x25\x72\x12\x60\x6a\x3d\x9a\xa2\x74\x77\xa9\xda\x50\x48\xdd\xc5\x32\x66\x86\xb3\xaf\xbe\x99\xc2\x3a\x66\xde\xcb\x6e\xb5\x49\x1b\xf7\x7e\xc0\x3e\x1f\xaf\x21\x44\x69\x1f\x77\x47\x72\x30\x96\x68\x47\xe7\x25\x1a\x02\x83\x6c\x65\x28\xa1\xfd\x59\xbf\xa1\x9b\xc3\x54\xa5\x83\x9e\xc0\xda\xad\xd6\x87\x03\x5f\x31\x2d\x0b\x81\xd5\x7e\xd3\x38\xe6\x72\x4f\x78\x39\xc3\x5e\x4c\xb0\xdd\x20\xaa\x23\xa2\x0b\x59\xb5\xff\xdc\xe4\x7a\x47\x46\x93\xb2\xeb\x3b\x89\xe2\x5b\x6a\x89\x8f\xa2\xb4\x6a\x5c\xea\xcb\xdb\x30\xba\xba\x00\x0f\xa5\xf1\x7c\x3d\x87\x17\x74\x54\x20\x95\x21\x72\x5a\xe1\x50\x6c\xd0\xea\x2d\xa7\xf0\x56\x31\x1e\xaf\x6b\xc3\x1b\x8a\x1e\x87\x7a\x6f\x57\xcf\x46\xb4\x6f\x62\xd3\xea\xed\x1b\x83\xfd\x5d\xe4\xf1\x12\x7e\xcc\xab\x99\xf6\x22\x89\x21\x90\x9f\x55\x02\xd5\x39\x00\x4c\x64\xa6\x42\x8e\xc2\x91\xfd\x42\x38\xd8\xd2\xbf\x15\xb0\x7a\x1d\xd1\x90\xee\x85\x7b\x30\x96\x49\x5a\xbd\x3f\x94\xe1\x68\x20\x69\x42\x79\xec\xfb\x63\xff\x73\x49\xef\x14\x81\x1a\xe2\x15\x05\x33\xc8\x95\x41\x4f\x7f\x3b\x71\xa9\xd8\xd9\x52\xa7\x30\x7b\x1a\x09\xa5\x7d\x1d\xef\x34\x30\xaf\xf6\xaf\xd1\xc5\xfa\xaa\x49\xef\x54\xe7\x17\xc7\xcf\xf9\xeb\x4b\xe7\x17\xaf\xcb\xa8\xd8\x64\xfb\xe6\xcd\xfe\x9b\xaf\x37\x6f\x00\x60\x0b\x47\x8b\xba\x74\x3f\xcc\xf2\xf5\xfb\x8e\x5d\x0f\xae\xa1\xba\xf6\x47\xe5\xe8\x9d\x52\x2a\xb2\x2e\xa9\xfe\x9b\x83\xdb\xcc\x6e\xf3\xe8\x78\x5d\xfa\x6b\xb9\x21\x14\x0b\x4e\x89\x94\xb5\xdb\x7e\x02\xd4\x13\x54\x70\x3b\x39\xff\xf6\xf8\x39\x7f\x7d\xe9\xfc\xdb\xd7\x65\x54\x6c\xb2\x85\x3f\x6b\xa1\x38\x78\xea\x5a\x8a\xc3\x82\x42\xf6\xf5\x97\x3a\xfb\xe4\x44\xd2\x7f\xbf\x2c\xd5\x76\xf8\x5d\xff\xb6\xf0\xbd\x0e\x47\xe8\x87\x05\xb5\x7f\x3b\x7c\xdc\x7b\xb6\x70\xd3\xd7\x8d\x98\x65\xb7\x74\xf8\x9c\xde\x76\xf4\x6c\x7f\x79\xfe\x0c\x47\xbd\x62\xc8\xf0\xe1\x65\x91\xdf\xca\xfe\xef\xc9\xba\xdc\x0c\x27\xea\xdf\x16\xee\x13\xea\xbc\x60\x87\x15\xd8\xc2\x57\x25\x9e\x63\x3c\xbe\x39\xf8\x5e\xb7\xe9\xbb\xc5\xb1\xaa\xbe\x1c\xdf\xfd\xec\xaa\x4b\xcf\x45\x8e\x9f\x6d\xff\x7e\xd7\x53\x7d\xb9\xb0\x7c\x59\xff\xab\x4b\x27\x44\x60\x0b\x4e\xbb\xad\x64\x9f\xf4\x45\xef\xe7\xf8\xf9\xea\xd2\x09\x11\xd8\xf6\x2f\xc3\x88\x87\x85\xff\xba\xfe\xfe\x46\x56\xca\xcc\xaf\xc7\x9f\xe3\x67\xfb\xfa\xd2\x16\x5e\x97\xf9\x95\xec\xbf\x8b\x9b\xe1\xb8\xfa\x6c\xe1\xae\xce\xb9\x00\x2f\xf0\x7d\x0b\x3f\xd0\x4f\xa9\x7e\xf9\x45\xf8\xbf\xeb\xbb\x16\xbb\xff\xf3\xff\x7f\x96\xff\x93\x3a\x75\x5e\x8d\xc3\xe6\xc3\x30\xb0\x8e\xc3\xc0\x9d\x6b\x5c\x12\x98\xfc\x7b\x00\x9b\xb5\x75\x45\x6b\x1b\x00\x00")
 
 func complySoc2PoliciesAvailabilityMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -561,12 +582,12 @@ func complySoc2PoliciesAvailabilityMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/availability.md", size: 7019, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/availability.md", size: 7019, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesChangeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x56\xc1\x8e\xdb\x36\x10\xbd\xfb\x2b\x06\xe8\x25\x59\xc8\x42\xd3\xf6\x10\xb8\xa7\xc4\xc9\xc1\x45\x83\x2c\xe2\x14\x3d\xd3\xd4\x48\x9a\x2e\x45\xaa\x1c\xca\x86\x72\xea\x6f\xf4\xf7\xfa\x25\xc5\x0c\x25\x59\x76\xba\x2d\x7a\xd9\xb5\x65\x72\xf8\xe6\xcd\x7b\x8f\xf2\xa6\xc3\x1d\x1c\x47\x4e\xd8\xc1\xbe\x35\xbe\x41\x78\x0c\x8e\xec\xb8\x31\x36\x06\x3f\x76\x3b\x38\xee\x1f\x37\x6c\x12\x71\x4d\xc8\xbb\x0d\xc0\xe7\xe3\x5e\xfe\x01\x6c\x61\xbf\x7f\x5d\xbe\x5a\x3e\x7f\x5f\xfe\xb0\xe9\xcc\x6f\x21\x7e\xc2\x33\x31\x05\xaf\xcb\xb7\x50\x99\x84\x3b\xf8\x69\xf0\xf0\x0a\xbe\xfb\xf6\xd5\x6b\xdd\x60\x43\xd7\xa1\x4f\x3b\x38\x78\x4a\x64\x1c\x54\xc1\x0e\xf2\x64\xb3\xdd\x6e\x37\x9b\x6f\xe0\x71\x88\x7d\x60\x04\xe3\x2b\x38\xda\xd0\xe3\x66\x63\x4a\xf8\xdc\x12\x03\xf9\x3a\xc4\xce\x24\x0a\x1e\x18\xed\x10\x29\x8d\xd0\x2b\x6e\xa8\xb0\x26\x8f\x0c\x6d\xb8\x80\xd5\x8e\x18\x52\xb8\xdd\xa2\xfd\x32\x98\x88\xd0\x3b\xe3\x3d\x56\x7a\x0a\x75\xbd\x43\x81\x80\x15\x5c\x0f\x9b\xea\x9a\xbe\x77\x94\x6b\xa5\x16\x01\x7d\xa2\x88\xcf\x20\x89\xa1\x89\xa6\x03\x93\x74\x69\x88\x8d\xf1\xf4\x25\x2f\x7a\x41\x25\x96\x52\xc4\x38\x77\xb3\x5b\x00\x08\x27\x83\x27\xab\x4f\x18\x12\xda\xd6\x07\x17\x9a\xb1\x00\xc3\x70\x41\xe7\xe4\x7f\x44\x67\x04\xe2\x4c\x98\xae\x7e\x59\x2a\xe2\x37\xce\x01\x76\xbd\x0b\x23\x22\x17\x60\x83\x4f\xd1\xd8\x14\x22\x17\xd0\x9b\x98\xb6\x89\xba\x4c\x69\xc2\xae\x0f\xd1\xc4\x11\x2e\x21\x3e\xa1\x2c\x60\x8c\x67\xb2\x28\xf8\xcf\x54\xe9\x23\x5d\xd9\xca\x18\xa6\xaa\x15\x9c\x46\x08\xa9\xc5\xa8\x54\xf4\x18\xa5\x05\xad\x01\x75\x88\x5f\x35\x5c\x40\x88\x70\x69\x03\xb4\xe6\x8c\x70\x42\xf4\xd0\x44\xa3\x14\x4f\x4c\xae\x57\xff\xf5\xc7\x9f\xfc\x7f\x58\xe9\x06\x4e\xf2\x7b\xef\x46\xb8\x50\x6a\x21\x5d\x27\x56\x8a\x88\xde\x1a\xfb\xd4\xc4\x30\xf8\xea\xab\x81\xce\x42\xe1\x1e\x2d\xd5\x64\x21\xe2\xef\x03\x45\x15\x80\xf6\x86\x9e\x87\x88\x90\x5a\x93\xd6\x4a\x5a\xd4\xe3\xab\x2c\x8a\x19\x98\xca\x29\x86\x1e\xa3\x1b\x67\x5d\x15\x80\x67\xe3\x06\x99\x57\x01\x11\xcf\x84\x17\xf9\x64\x7a\xe1\x58\x3e\x5d\x9b\x93\x6f\x2b\x05\x16\xcb\x7c\x75\x83\xaf\x56\xdb\x85\x7e\x3c\x8d\x19\x21\xf9\x46\x79\x6c\x22\x9a\x84\x9c\x04\xc3\xc9\x9c\xc8\x89\x14\x43\x0d\x3c\x58\x8b\xcc\x25\xfc\x2a\xbb\x96\x4e\x04\xad\x0f\x69\xfe\xb9\x1e\x5c\x91\xd9\x9b\x8f\x9d\x65\xc0\xd0\xa1\x6c\x22\xee\x58\x47\x6c\x83\xaf\x06\x9b\xe4\xdc\x3e\x70\xda\x2e\xa0\xf3\xc8\x32\x4c\xa9\xdb\x66\xee\xea\x21\x09\x8f\x1d\x71\x32\x4f\x98\x89\xc3\x18\x43\x64\xb0\xc6\xc3\x49\x58\xc3\xb3\x36\xaa\x33\x9b\x02\x48\xe5\xec\xc7\x35\xf5\xd2\xe6\xe2\x32\x13\x6d\x4b\x09\xad\x16\x17\x58\x03\xa7\xd0\x61\x94\xb8\x31\xd0\x1a\x5f\x39\x81\x18\x6a\x30\xd3\xcc\xb2\x5c\x4e\x08\xaa\x2f\xe7\x46\x1d\x39\xb2\x88\x91\x3c\x5c\x22\x69\x53\xcf\xe9\xf2\xb0\xd2\xe5\x71\x46\xf1\xc1\x78\xd3\x60\x84\x17\x87\xe3\x87\x97\xc5\x2c\x0a\x1d\xae\x38\x45\x0a\x1d\x8e\x1f\x26\x1f\x21\xec\x5b\xc2\xfa\xa6\xd2\xc7\xba\x26\x2b\x05\xf6\x87\x8f\x2b\x13\xe7\xae\x67\x80\xbc\x40\xbf\x8a\xe2\xbf\xd7\xf6\x91\x82\xf4\xf4\x25\xf7\x97\x30\x76\x2c\x7c\x9c\xd0\x63\x4d\x89\x0b\x18\x62\x83\xde\x8e\x05\x60\x5d\x87\x98\x66\x0b\x4c\x82\xeb\x43\x92\x98\x33\x4e\x74\x69\x6c\xe2\x67\xa9\x11\xd1\x67\x17\x5c\x51\xad\xd3\x74\x9e\xe1\x8c\x6c\xad\x7a\x29\x1a\xd1\xe1\xd9\xf8\x04\x03\x63\xe4\x32\xa7\xef\x74\x1f\x75\xca\xb0\x2a\xf2\xba\x5d\x15\x28\xb1\x6d\x6d\x88\xd5\x6a\x6a\x75\x70\x2e\x5c\x54\x9b\x31\x58\xac\x86\x88\xf9\xb2\xd2\x3f\x54\xc2\xc3\xa3\x38\x93\x7c\xf3\xb0\x53\x93\xea\xae\x8c\xaf\x00\xf2\xd6\x0d\xd5\xec\xa8\x3b\x61\x57\xc8\xd4\xf8\x02\xd8\xb6\x58\x0d\x22\xae\xe2\xf6\xda\xc8\xcb\x54\x70\x77\x99\x25\x07\x15\x20\xee\x54\x64\xfa\x4d\x2d\x1d\x9c\xdb\x9e\x8c\x7d\xd2\x67\xe5\x2d\xd0\xf7\x39\x39\x28\xf8\x87\xdd\x12\x23\xcf\xc0\x9d\x46\x3d\x82\xc3\x33\x3a\xc1\x90\xbd\x92\x03\x5d\x8f\x22\x7e\xca\x7e\x94\x5f\x24\xa7\x02\x2f\x93\x01\xf2\x29\x86\x6a\xb0\x2b\x9f\xa9\x65\x7e\x84\x0a\x45\x37\xe4\xd7\x27\x43\x1a\x7b\x5c\x34\xbd\x04\x28\x27\xec\xb7\xa7\x71\x2b\xff\x33\xfd\x9c\xef\xde\x99\xa0\x55\x89\xbb\x5e\x3f\x69\x68\x3c\xec\xe6\xf4\x58\x9d\xa5\x43\x32\x5d\xf0\x0d\xe7\x02\xfb\xc3\xc7\x42\x4c\x55\xc0\x7b\xdf\x90\x47\xd4\x08\xfc\x19\x4d\x96\x6d\x01\x54\xcf\xc1\x7c\x72\x58\xc0\xdb\x81\x25\xe8\x19\x7e\xf1\x94\x66\xc3\xde\x9d\xff\x46\x3d\x6b\xdc\xc3\x6e\x3e\x22\x8b\x6d\xf2\xf2\x3d\x9e\x12\x6e\xb7\xef\xd7\xe3\x7e\xd8\xad\xf5\x7d\x43\x5b\xbe\xf6\x55\xe4\xcb\x8c\x94\xe7\x3b\x38\x87\x1b\x49\x09\x28\x09\xf6\x1b\xb1\x3d\xcf\xe5\xbb\xf5\x7b\x81\x52\x2a\x1e\x59\xe3\xd0\x94\xf2\xe3\x3f\xc6\x37\x31\x0f\xc8\x77\x25\x1f\x65\xe1\x12\x33\xd3\xa8\x26\x17\x82\xf9\xb7\x6b\x20\x85\x95\x82\xe4\x9d\x6c\x05\x43\xde\xe4\x34\x58\x66\xc3\xdd\xbe\x38\x20\xc9\x3d\x27\xc5\x29\xd1\x19\xdd\x28\x29\xef\xb1\x31\xf9\x5b\x09\xef\x88\xed\xc0\xf9\x42\x59\x6e\x2d\xe9\xcb\x21\xb3\xd8\xce\xa1\x89\x5e\x93\xf2\xef\x00\x00\x00\xff\xff\xf5\xcb\xe7\x7f\xe9\x0a\x00\x00")
+var _complySoc2PoliciesChangeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x56\xcd\xae\xdb\x46\x0f\xdd\xfb\x29\x08\x7c\x9b\xe4\x42\x36\xbe\xb4\x5d\x04\xee\x2a\x71\xb2\x70\xd1\x8b\x5c\xd4\x29\xba\xa6\x47\x94\xc4\xde\xd1\x8c\x4a\x8e\x6c\x28\xab\xbe\x46\x5f\xaf\x4f\x52\x70\xf4\x73\xe5\x9b\xde\x16\xdd\xd8\xfa\x19\x9e\x39\x3c\x3c\xe4\x28\x60\x4b\x7b\x38\x0d\x9a\xa8\x85\x43\x83\xa1\x26\x78\x88\x9e\xdd\xb0\x41\x27\x31\x0c\xed\x1e\x4e\x87\x87\x8d\x62\x62\xad\x98\x74\xbf\x01\xf8\x7c\x3a\xd8\x1f\xc0\x16\x0e\x87\xb7\xbb\x37\xcb\xf5\xb7\xbb\xef\x36\x2d\xfe\x1a\xe5\x27\xba\xb0\x72\x0c\x79\xf9\x16\x4a\x4c\xb4\x87\x1f\xfa\x00\x6f\xe0\x9b\xff\xbf\x79\x9b\x03\x5c\x6c\x5b\x0a\x69\x0f\xc7\xc0\x89\xd1\x43\x19\x5d\x6f\x4f\x36\xdb\xed\x76\xb3\xf9\x1f\x3c\xf4\xd2\x45\x25\xc0\x50\xc2\xc9\xc5\x8e\x36\x1b\xdc\xc1\xe7\x86\x15\x38\x54\x51\x5a\x4c\x1c\x03\x28\xb9\x5e\x38\x0d\xd0\x65\xde\x50\x52\xc5\x81\x14\x9a\x78\x05\x97\x33\x52\x48\xf1\x36\x24\xe7\xab\x80\x42\xd0\x79\x0c\x81\xca\xbc\x0b\xb7\x9d\x27\xa3\x40\x25\x3c\x6d\x36\xe1\x62\xd7\x79\x1e\xb1\x52\x43\x40\x21\xb1\xd0\x0b\x4c\x24\xd6\x82\x2d\x60\xca\x4b\xa3\xd4\x18\xf8\xcb\x48\xf7\x15\xef\x68\x67\x20\xe8\xfd\x4d\xb4\x11\x30\x4d\xfa\xc0\x2e\x2f\x55\x48\xe4\x9a\x10\x7d\xac\x87\x02\x50\xe1\x4a\xde\xdb\xbf\x90\xc7\x44\xe5\x22\x58\x5e\xfd\x7a\x97\x19\xbf\xf3\x1e\xa8\xed\x7c\x1c\x88\xb4\x00\x17\x43\x12\x74\x29\x8a\x16\xd0\xa1\xa4\x6d\xe2\x76\x94\x34\x51\xdb\x45\x41\x19\xe0\x1a\xe5\x91\x6c\x81\x92\x5c\xd8\x11\x74\x12\x2f\x5c\xe6\x47\x79\x65\x63\x65\x98\x50\x4b\x38\x0f\x10\x53\x43\x92\xa5\xe8\x48\xac\x14\x19\x03\xaa\x28\x5f\x25\x5c\x40\x14\xb8\x36\x11\x1a\xbc\x10\x9c\x89\x02\xd4\x82\x59\xe2\x14\xbf\x5a\xfd\xe7\xef\x7f\xe8\x7f\x51\xa5\xed\x35\x81\x8b\x6d\xe7\x07\xb8\x72\x6a\x20\x3d\x55\x6c\x67\x26\x7a\x8f\xee\xb1\x96\xd8\x87\xf2\xab\x82\xce\x46\xd1\x8e\x1c\x57\xec\x40\xe8\xb7\x9e\x25\x1b\x20\xe7\x46\x41\x7b\x21\x48\x0d\xa6\xb5\x93\x16\xf7\x84\x72\x34\xc5\x4c\x2c\xdb\x49\x62\x47\xe2\x87\xd9\x57\x05\xd0\x05\x7d\x6f\xf5\x2a\x40\xe8\xc2\x74\xb5\x2b\xec\x4c\x63\xbb\x7a\x4a\xce\xee\x56\x0e\x2c\x96\xfa\xda\x0b\xd3\xe1\x29\xdc\xe4\xa7\xf3\x30\x32\xe4\x50\x67\x1d\x6b\x21\x4c\xa4\xc9\xca\x77\xc6\x33\x7b\xb3\x62\xac\x40\x7b\xe7\x48\x75\x07\xbf\x58\xd4\x92\x89\xb1\x0d\x31\xcd\xaf\xab\xde\x1b\x2e\xeb\xb2\xed\x6c\x03\x85\x96\x2c\x88\xb5\xd5\x5c\x62\x17\x43\xd9\xbb\x64\xfb\x76\x51\xd3\x76\x21\x9d\x75\x98\x68\x1a\xae\x95\x03\x13\x54\x7d\x32\x1d\x5b\xd6\x84\x8f\xb6\x73\x28\x81\x44\xa2\x28\x38\x0c\x70\x36\xd5\xe8\x92\x13\xcd\x35\x9b\x06\x90\xd5\xeb\x5d\x18\xd6\xd2\x5b\x9a\x4b\x97\xa1\xb8\x86\x13\xb9\x0c\x6e\xb4\x7a\x4d\xb1\x25\xb1\x71\x83\xd0\x60\x28\xbd\x51\x8c\x15\xe0\x54\xb3\xd1\x2e\x67\xb2\x2c\x5a\xf4\x7e\xc8\x25\x27\x35\x33\x72\x80\xab\x70\x4e\xea\x25\x5f\x1e\x57\xbe\x3c\xcd\x2c\xee\x31\x60\x4d\x02\xaf\x8e\xa7\xfb\xd7\xc5\x6c\x8a\x5c\x5c\xeb\x14\x63\x7c\x3c\xdd\xe7\xe7\x76\x7d\x68\x98\xaa\x1b\xa4\x4f\x55\xc5\xce\x00\x0e\xc7\x4f\xab\x26\x1e\xb3\x9e\x09\xea\x42\xfd\xc9\x14\xff\xbe\xb6\x13\x8e\x96\xd3\x97\x31\xbf\x44\xd2\x2a\xc4\x0a\xce\x14\xa8\xe2\xa4\x05\xf4\x52\x53\x70\x43\x01\x54\x55\x51\xd2\xdc\x02\x93\xe1\xba\x98\x6c\xcc\xa1\x07\x6e\x3b\x74\x49\x5f\x94\xc6\x4c\x9f\xef\xf4\x89\xd5\xca\xcb\x4b\x0d\x67\x66\x6b\xd7\x1b\xa8\x90\xa7\x0b\x86\x04\xbd\x92\xe8\x0e\x32\xc8\x74\x1e\xb5\x59\x61\xcb\x79\x49\x6c\x72\xa0\x8d\x6d\xe7\xa2\x94\xab\xaa\x55\xd1\xfb\x78\xb5\x07\x9d\x44\x47\x65\x2f\x64\x87\x10\x40\xfe\xe1\x1d\xdc\x3d\x58\x67\x72\xa8\xef\xf6\xb9\x49\x73\xd4\xc8\xaf\x00\x0e\xce\xf7\xe5\xdc\x51\xcf\x8c\x5d\x92\x72\x1d\x0a\x50\xd7\x50\xd9\x9b\xb9\x8a\xdb\x63\x23\x2b\x60\x02\xe3\x2a\x41\x13\x25\x6f\x54\x80\x75\xa7\x61\x8f\x77\x16\x2a\xd1\xfb\xed\x19\xdd\x63\x5e\xb1\xbb\x25\xfa\x71\x9c\x1c\x1c\xc3\xdd\x7e\x19\x23\x2f\xd0\x9d\x4a\x3d\x80\xa7\x0b\x79\xe3\x60\xeb\xe6\x81\x9e\xb7\x62\x7d\x1c\xfb\xd1\xde\x74\x12\xed\x70\x2d\x27\x28\xe0\x90\x24\x96\xbd\x5b\xf5\x59\x6e\x99\xef\xa1\x24\xf3\x0d\x87\xf5\xce\x90\x86\x6e\x3a\x45\x1a\x7a\x1a\xa0\x9a\xa8\xdb\x9e\x87\xad\xfd\xdb\xf4\xb0\xb9\x62\x70\x8b\x40\x2b\x88\x67\xb9\xda\x17\x03\x5d\xef\xf6\xf3\xf4\x58\xed\x65\xca\x00\xb6\x31\xd4\x3a\x02\x1c\x8e\x9f\x0a\x38\x9e\xee\x0b\xf8\x18\x6a\x0e\x44\x62\xa2\xfe\x48\x38\xda\xb6\x00\xae\xe6\xc1\x7c\xf6\x54\xc0\xfb\x5e\xed\x8b\x40\xe1\xe7\xc0\x69\x6e\xd8\x67\xfb\xbf\xcb\x03\x19\xfd\xdd\x7e\xde\x62\x34\xdb\xd4\xcb\xcf\xf9\xec\xe0\x36\xfc\xb0\x2e\xf7\xdd\x7e\xed\xef\x75\xe8\x74\xec\x67\x93\x2f\x35\xca\x3a\x3f\xa3\x73\xbc\xb1\x94\x91\xb2\xc1\x7e\x63\xb6\x15\xee\xb3\xe0\x0f\xd3\x88\x98\x63\x85\xac\x47\xd6\x3c\x0c\x08\xc3\xf0\xb7\xe3\x9b\x55\x7b\xd2\x67\x90\x0f\x36\xe7\xa7\x60\x99\x4b\x35\x75\x21\xe0\x3f\x1d\x03\x29\xae\x1c\x64\xdf\x64\x2b\x1a\xf6\x25\x97\x07\xcb\xdc\x70\xeb\xb9\x52\x00\xb1\x9d\x73\x06\xce\x89\x2f\xe4\x07\xfb\x94\x08\x54\xe3\x78\xb7\x83\x0f\xac\xae\xd7\xf1\x40\x99\xe7\x62\xce\xcb\x93\xaa\xb5\x9d\x27\x94\x40\xe5\x6e\xb3\xf9\x6b\x00\xf5\xcb\xe7\x7f\xe9\x0a\x00\x00")
 
 func complySoc2PoliciesChangeMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -581,12 +602,12 @@ func complySoc2PoliciesChangeMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/change.md", size: 2793, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/change.md", size: 2793, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesClassificationMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x5b\xcb\x8e\x1b\x37\x97\xde\x17\xfe\x87\x38\x40\x80\x19\xbb\xa3\xd6\xc4\x33\x9b\x49\x07\x59\x74\x9c\x18\xe9\x41\x3c\x31\xdc\x1d\xcc\x66\x36\x14\xeb\x94\x8a\x69\x16\x59\x21\x59\x92\x2b\xe8\x85\x5f\x63\x80\xe4\xe5\xfc\x24\x83\x73\xc8\xba\x49\x25\xb5\xd4\xb6\xff\x6c\xd2\x1b\xc3\xc5\xdb\xb9\x7c\xe7\x4a\xca\x88\x0a\xaf\xe0\x7b\x11\x04\xbc\xd4\xc2\x7b\x55\x28\x29\x82\xb2\x06\xde\x58\xad\x64\x9b\x09\xe9\xac\x69\xab\x2b\xf8\xfe\xe5\x9b\xcc\x8b\xa0\x7c\xa1\xd0\x5f\x65\x00\x77\xb7\x2f\xe9\x1f\x80\x4b\x78\xf9\xf2\xeb\xe5\xd7\x59\x25\x7e\xb5\xee\x2d\x6e\x94\x57\xd6\xf0\x94\x4b\xc8\x45\xc0\x2b\xf8\xaf\xc6\xc0\x0b\xf8\xf7\xaf\x5e\xfc\x27\x2f\x90\xb6\xaa\xd0\x84\x2b\xb8\x31\x2a\x28\xa1\x21\xb7\xb2\xa1\x2f\xd9\xe5\xe5\x65\xf6\x05\x5c\xd7\x35\x9a\x5c\x49\xf4\x90\x65\xe9\x3f\xef\xe0\xfa\x0a\x7e\x14\x26\xd7\xca\xac\xc1\x16\x3d\xb9\x98\xc3\x8d\x29\xac\xab\x98\xec\xd1\xfc\xef\xae\xe0\x95\x75\x15\x91\x67\x4d\xa1\x72\x34\x74\x96\x0a\x2d\xdc\x06\x11\x90\xcf\xcb\xbe\x80\x37\x8d\xab\xad\x47\x10\x26\x87\x5b\x69\x6b\xcc\x32\xb1\x84\xbb\x52\x79\x22\x5e\x80\x9c\x8a\xa5\x66\xb1\x40\x8e\x85\x32\xe8\x21\x94\x08\x0e\x7f\x6b\x94\xe3\x0d\x3d\x04\x0b\x68\x7c\xe3\x10\x42\x29\x02\xa8\x81\x34\xd8\xaa\x50\x2a\xc3\x4b\xac\x5b\x0b\xa3\x7e\x8f\xdf\x95\x87\xda\xd9\x80\x32\x60\x0e\x22\x80\x30\x20\xea\xda\xd9\xda\x29\x11\x10\x34\x6e\x50\x2f\x47\x54\x25\x61\xd1\x24\xad\x90\x8f\xa4\x3d\x89\x3f\x87\xe0\x89\x07\x12\xd0\xee\x39\x1f\xde\xff\x9f\x9f\xd0\xe3\x51\x36\x8e\xe4\x51\x3b\xbb\x76\xa2\x5a\xc2\x0d\x11\x2c\x75\x93\xa3\x07\xa1\x35\x84\xb6\x46\x4f\x7b\x8d\x96\x2d\xc0\xe1\x5a\xb8\x5c\xa3\x8f\x43\xc1\x03\x0d\x2e\xc0\x37\xb2\x04\xe1\xa1\x16\x35\x3a\xb0\x0e\x50\xa3\x0c\xce\x1a\x25\x7b\xa2\xfd\x22\x92\x1d\xa5\xe9\x59\xea\x24\xe6\x95\xf0\x48\x63\x26\x87\x7b\x63\xb7\x1a\xf3\x35\x51\x3f\xa1\x37\x0a\xd4\x83\xb1\x01\xb6\x4e\x85\x80\x66\x24\x96\xa4\x98\x91\x50\x88\x03\x65\x72\xb5\x51\x79\x23\x74\x3c\xcb\xb7\x3e\x60\xe5\xe3\x5e\xa5\xd8\x20\x08\x29\x89\x93\x60\x27\x67\xdd\x63\x1d\x60\xd5\xee\x09\x71\x49\x90\xf9\x4e\xc8\xfb\xb5\xb3\x8d\xc9\xf7\x8e\x1f\xe3\xa2\x54\xeb\x32\x6a\x0f\xec\xea\x57\x94\x41\x6d\x30\x52\xa1\xaa\x5a\x33\x5e\x12\x00\x8c\x0f\xae\x91\x51\x22\x85\x75\xf3\xaa\x9b\x43\xa3\x97\x25\x56\x98\x48\xe8\x55\x37\x37\x93\xe9\x20\x09\x7b\xd8\xa2\xd6\xac\x27\x67\x25\xe6\x8d\xc3\xe1\xd0\xe9\xa2\x05\x68\xb1\x42\x36\x38\xa2\xba\x1c\x59\x1f\x1f\x71\x00\xd0\xcb\x3d\x83\xa3\xd5\xc6\x9a\xcb\x5c\x79\xa9\x2d\x5b\x87\x58\x3b\x4c\x26\x53\x09\x65\x82\x50\x06\xf3\x39\x89\x43\xd5\xf8\x00\x0e\x0b\x74\x68\x24\x99\x55\x2f\x6d\x56\xc6\xdb\x6e\xc4\xb3\x32\xde\x2a\x7f\x0f\xd7\xde\xa3\xf7\x6c\x24\xc9\x8d\xd1\xd0\x6d\x87\xf7\x1b\x23\x99\x3a\x78\x2d\x8c\x58\xe3\x64\xde\x17\xe3\x15\x37\x45\x2f\x11\xcc\x27\xf8\x50\x1e\x1c\x4a\x54\x1b\xcc\xa1\x70\xb6\x02\xdb\x04\xaf\x72\xdc\x23\x7f\xc1\x5f\x6a\x74\x9e\x3c\x40\x69\xbb\x65\x11\x22\xe3\x2d\x99\xcf\x74\x5c\x0b\x8a\x4c\x91\xc0\x69\x5d\x2e\x88\x6f\x12\x76\x74\x37\x8d\x46\x52\x1e\x7a\xe9\xd4\x8a\xe9\x9a\x08\x05\xee\x86\x03\x43\x89\x0e\x57\x2d\x6c\x95\xd6\xb0\x42\x69\xab\x44\xe1\xd6\x90\x89\x16\xbb\x44\x2c\x3f\x15\xdb\x03\x60\xc8\xa7\x91\x4f\x70\x81\xce\x5b\x35\x9e\xec\xc3\x83\xad\xd1\x75\x3e\x80\x2c\x43\x05\xb2\xda\x67\xb8\x5c\x2f\x17\x20\x1b\x1f\x6c\x85\x2e\xc2\x8c\xbc\xae\xb3\x1b\x95\x63\x0e\x52\xdb\x26\x07\x8f\x6e\x43\xe1\xe1\xf9\x62\x4f\x8a\xbb\x08\x1e\xe1\x7d\xc2\x38\x3b\xab\x89\x5b\x63\xf9\xaf\x10\x2a\x91\xe3\x21\xd9\xfb\x1a\x65\xbf\xb9\xef\x04\xe8\x90\xbe\x93\x79\x0f\x94\x27\x12\x07\x9c\xb3\x44\x2c\x29\x04\x34\xae\x85\x9e\x84\x8d\x28\xf7\xff\x29\xb1\x67\xa0\x25\x43\x9b\xd0\x47\x27\x25\x6f\x52\x80\xdc\xb1\x30\x8a\x0b\x18\xd0\x55\xc9\x8c\xae\x32\x8e\xb4\x2a\xa2\x61\x23\x74\x83\x33\xea\x5e\x00\xf9\xdd\x9c\x24\xac\xaa\x5a\xc8\xe0\x21\x6e\xca\x8a\xcf\x1b\x47\x44\x30\x87\x64\x55\x62\xb0\x2a\x76\x1d\xde\x2f\xe1\xb5\x75\x53\xf9\x5b\xb3\x3b\xd9\x47\xe2\x0a\xa6\x2c\xb9\x8b\x79\x2b\x85\x67\x83\x99\x3f\x13\xcf\x9f\x2f\x7b\x26\x6e\xd1\x78\xc5\x28\x89\xce\x44\x3a\x15\x94\x8c\xac\x1f\x65\xab\xf3\xc3\x48\x3e\x84\x0e\x95\x42\xcb\x46\x0b\x0a\xb5\xe4\xf4\x50\x4c\x61\x00\x2a\x60\x75\x84\xf3\x81\xa4\x9f\x48\x89\x1c\x0c\x69\x3b\xeb\x12\x61\xd6\x04\x27\x64\x68\x04\x39\x7d\xad\xd6\x11\x29\x4b\xc8\xb2\xec\xcb\xcb\xfd\xbf\x99\x6f\x73\xd3\x4e\x1a\xcb\x1e\x2e\x2e\x76\x3c\xef\xc5\xc5\x03\x5c\x5c\xfc\x44\x5e\xfc\xe2\x02\xd2\xdf\x03\xc0\xc5\xc5\x34\xdb\xeb\x07\x79\xec\x9a\x43\xe2\xb0\xa0\x1b\xcb\x1e\xf8\xdf\x8b\x8b\x9f\x08\x86\x69\xfc\x01\xf6\xfe\x1e\x78\xd2\x4b\xa7\x02\x3a\x25\xa6\xfb\x10\x3d\x6f\xd1\x07\xa7\x62\xbc\x1b\x8f\x3e\x64\x5f\x7e\xbb\xff\x37\xf3\x6d\x6e\xda\x29\x63\xdf\x7e\x99\x3d\xc0\x9b\x66\xa5\x95\x9c\xd2\xf4\xca\xba\xe9\xf7\x07\x78\x2d\xee\x3b\x0c\x8c\xf1\xc1\x63\x37\x53\x97\x28\x36\x42\x69\xb1\xd2\x18\x59\xcf\x66\x44\x02\x0f\xf0\x16\x35\x0a\x8f\xe3\x4f\x75\x3c\x92\xbd\x33\x65\x35\xa5\x70\x55\x37\x96\xb2\xba\x38\x65\x39\xa7\x87\x7d\xa1\xef\x7d\xda\xcf\x35\xcd\xe1\xe9\xfb\x5b\x9d\x7e\x90\x30\x2d\x6c\x45\xbb\x3c\x69\xfa\x30\xf6\x4f\xb0\x89\x53\x39\x38\x42\xe5\x5f\xcd\xc1\x8d\x09\xe8\x8c\xd0\xf0\x4b\x07\x9f\x99\x6f\x0f\xf0\x8b\x11\x4d\x28\xad\x53\xbf\x53\xcc\x8d\x69\xed\x30\xfd\x20\x62\x4f\x17\x51\x25\x5a\x90\xa2\xf1\x08\x95\x32\x96\xa2\x73\x25\xd6\x08\x1d\x5c\x29\xdf\xc6\xaa\xd6\xb6\xc5\x94\xe7\xf6\x5b\x9d\x03\xa4\xfc\xdf\x38\xef\x97\xd6\x6c\xd0\x28\x0e\x06\xdd\xd8\xc0\x5e\x28\x95\xcb\x39\xab\x50\xe8\x97\x4f\x41\x6c\xb2\xaf\x69\xe2\x9a\xc6\xfe\x86\xe5\x29\x1c\x74\x6e\x1c\xf3\x81\xa4\xbd\x6f\xf3\xb0\x0c\x16\x3e\x1d\x2c\x27\x49\x74\x0f\xd1\x5e\xcb\xa2\xcf\xda\x80\x8a\xb6\x9a\x12\x86\xf3\xbc\xa8\xb4\x86\x92\x5c\xc7\x94\x25\xd0\x77\x1c\x4c\x01\x3f\xe2\xf4\x4c\x2f\xdd\x27\xc6\xc9\x00\x86\xb1\x09\xd0\x27\x3a\x7e\x72\x18\xf8\x57\x7f\x64\xfa\x53\x0e\x70\x58\x37\x61\x6c\x43\xc7\xa6\xcf\x8e\xfd\x6d\x72\xa7\x70\x30\x4e\xef\xd2\xb1\x33\x9f\x9e\x6a\x72\x63\x75\x7c\x12\xeb\xb3\x46\xb7\x74\x70\x6f\x80\xb1\x1d\x73\x79\x9e\xf5\x89\x20\x7c\x70\xb6\x2e\x95\xdc\xb5\xbe\xd8\xd8\x49\x45\xc5\x8e\x3a\x4e\x3e\x60\xd7\xf4\x86\xbd\x1e\xe6\xc2\xc3\xf9\x07\xec\x98\xde\xc8\x58\xfe\x62\x40\x65\x77\xac\xf9\xff\xb8\x9a\xa0\x62\xb7\x7b\xc3\xd9\xbe\xcf\xb2\x7f\x31\x2b\x5f\x7f\x93\x65\xf9\x72\x32\xbd\xab\x9b\x47\x2d\x83\xbe\xfc\xda\x2d\x53\x63\x17\x8a\x8a\xf1\x51\x41\x98\x88\x58\x66\x19\x4e\x77\xe6\x56\xd9\xb8\x61\xc9\xcd\xbb\x58\xc3\x7b\xf0\xa5\x6d\x74\x0e\xc1\x31\xc4\x08\x74\x5c\x21\xdb\x2d\xd5\x7a\xb3\x07\xc7\xae\x5f\x6c\xcf\x7a\x6e\xb3\xe6\xf8\x5b\xd3\xf7\x58\x29\x30\xa4\x46\x6c\x57\x70\x73\xf3\x44\x6c\xac\xca\xa9\x16\x68\x8c\x41\xb2\x25\xe1\xb8\x67\x9a\xc7\x96\x1d\x48\xeb\xb9\x82\x2f\xa6\xd4\x8f\xe4\x21\x3c\x7c\x78\xff\xc7\x10\x1b\x3f\xbc\xff\x13\xac\xa3\x6f\x63\x59\xd3\xd7\x4e\x98\x42\x4a\x5b\xd5\xc2\xa8\xd8\x14\x13\xa0\x95\xe7\x06\xca\xc8\xb0\x63\x8f\x87\xe1\xbf\x2d\x95\x2c\xf7\x6a\x95\xd8\xec\x48\xc6\x97\x3a\x4e\x46\x54\xe8\xe9\xf0\x5f\xed\x0a\x8a\xc6\xc8\xbe\x93\xd1\x6d\xb7\x2d\x6d\xec\x8c\xc6\xf2\x77\x5d\x06\x8e\xa2\xc9\x8b\xec\x34\xb5\x97\x59\xb6\x7e\x84\xed\x71\xa6\xfa\x54\x16\xd9\x91\xa8\x62\xd2\xce\x3d\xd8\x7a\xe2\x72\x6a\xa7\xb7\x4b\x93\xba\x1e\xf4\x32\xcb\xca\x73\x80\x96\x1a\x90\x1b\x85\xdb\xd8\x24\x9d\xc5\x56\x6c\x43\xa8\x69\xcb\x5a\x78\x8f\xc1\x03\x6e\xd0\xb5\x50\xa8\x0d\x42\x8b\xc2\xa5\x5c\x81\x9b\x0a\xb0\x2d\x91\x9b\x42\x87\x77\x4e\x40\x27\x13\x2b\x85\x59\x63\xce\x9d\x22\x47\x9b\x42\x6d\xbd\x57\x2b\x8d\x8b\x43\xa6\x36\x2c\x26\xcb\x70\x98\xc7\x56\x13\x15\xbc\xdc\x4b\xbb\x8c\xb6\xea\x6d\x11\xb6\xc2\x61\xdf\x5a\x1b\x5a\x6e\xc1\xf6\x5d\x2d\xbf\xd8\x11\x4d\x63\xf2\x9e\x74\xd2\x65\xcb\x9d\x6a\x6e\x82\x58\x1d\x05\x27\xb4\xb7\x4f\x94\x5e\xd7\xac\x17\x45\x98\xed\xa8\x45\x71\x30\x9a\xe3\x14\xd1\x53\xca\x74\xf0\x4e\x5c\x59\xf7\x8d\x8e\x24\x3a\xaa\x55\xec\x36\x02\x6f\x6f\x5f\x7f\xae\x30\xe7\xbc\x21\x77\xce\x63\xf4\xb5\x8e\xbd\x47\x42\x61\x61\xe9\x68\x65\xd6\x43\x8b\xee\x0d\x5f\x98\xf4\x57\x24\x57\x47\xe4\xc4\x6d\xfe\x9c\x98\x19\xfa\x5b\xc1\xd6\x0c\xa8\x95\x0d\xc1\x56\x24\x4a\xee\x6a\xf5\xf7\x44\xb5\x58\xe3\x37\xdc\x50\xf6\x51\x1b\x7b\x3b\x14\xce\x9a\xd0\x35\xd2\xa4\xdd\xa4\xeb\x1b\xb3\x41\x6d\x6b\x04\x29\x9c\xe3\x7e\x64\xbc\xe1\x19\xdd\x40\x0d\xcd\xd5\x6e\x27\xc5\x37\x04\x85\xd5\x04\x8c\x89\x67\xea\x57\x29\x0f\x3e\x58\x92\x1e\xdc\x14\xe3\xed\xd2\xdd\x4e\x12\xdd\x82\xaf\x96\x72\x2c\x44\xa3\xc3\xee\x75\x86\xf2\x93\x0a\x78\x68\xcb\xfd\x30\x73\xe7\xf4\x39\x04\xfa\x39\x68\xbf\xd9\xc7\xfe\x51\xd2\xcd\x91\xbb\xb4\x1e\x88\x7b\xdc\x25\x0b\x4e\xbe\xd1\x4b\x87\x38\xed\x93\x77\xf3\x78\x84\xfc\x93\x81\x5c\xf9\x5a\x8b\x01\x03\x93\x08\x40\xd4\xcf\x88\xbf\x12\x4a\x9f\x2c\x79\xd5\xc1\xc7\xf9\x00\x5a\x99\xbe\x5b\x8d\xb4\x0d\xac\x6c\xde\xb2\xc4\xd5\xb9\x92\xde\x0f\x40\x07\x09\x26\x50\x52\x72\x59\x61\xae\x04\x3c\xcb\x95\xbf\xf7\x0b\xa8\xb0\xb2\x8e\xd2\x5a\x97\xfb\x05\x60\x90\xcb\xe7\xc7\xb8\x3a\x28\x76\x02\x95\x6f\x5c\x21\x64\xcf\x1b\x1f\xf4\x79\xd8\x1a\x43\x29\x38\x61\x7c\xa5\x02\x13\xe3\x84\xd6\xed\x31\x06\x06\x1f\x47\xb8\x56\x96\xef\x13\xb0\xb0\x0e\x09\x05\xb2\xf1\x7e\xe7\x66\xa2\xeb\x96\x13\x67\x97\xc1\x5e\x32\x87\xd2\x56\x55\x63\xfa\x5b\x98\x55\x0b\x01\x35\xd6\xa5\x35\xb8\x60\x7f\x6d\xda\x74\x19\x52\xa1\x88\xf9\x07\x91\x36\x5d\x97\x58\x12\x4b\xb8\xd6\xba\xcf\x05\x22\x70\xe9\xc4\x03\x97\x54\xac\x82\xe8\x66\x99\xcf\x75\xa3\x72\xd4\x7c\x2d\x4b\x29\x46\x44\xdb\xf0\xac\x60\x41\xc2\x7c\xfc\x65\xc1\xf2\xc3\xfb\x3f\xb3\xa7\xd1\x42\xb1\x51\x63\x88\x6f\x0c\x7c\xb3\xaa\x54\x00\x71\xf8\x55\x42\x8a\x14\x14\x0c\x2b\x86\x48\x40\xf0\x4d\x4d\x61\xca\x5b\xf6\xcc\x29\xd8\x42\x6d\x95\x09\x97\xb6\xb8\xa4\x88\x2b\x64\x58\xc2\x35\x78\x41\x87\x1d\xd9\x9d\xed\x6e\xe0\xff\xbb\x2e\x86\xc5\x3b\x51\x2a\x4f\xe2\xf5\x48\x8a\x57\xaa\xaa\x9d\xa5\xf8\x34\xbe\xfe\x3d\xc6\xed\x0a\xa9\xc2\xb1\x2e\x09\x7a\xee\x1a\xed\x84\x9b\xd8\xc9\x5d\xd0\xea\xf9\xf3\x88\x85\x7f\x64\xff\x4b\xce\x77\xe5\x50\xdc\x43\x36\x3c\x15\x39\xf9\x75\xc8\xe3\x59\xdf\xe0\x39\xfb\xeb\xcb\x83\x21\x7c\x04\xad\x8b\x2b\x16\x63\x0c\xe5\xdf\x77\x91\x67\xc7\x26\x07\x83\x8d\x3e\x9f\xfe\x5e\x2c\xe1\x67\xca\x6e\x67\xb2\x5e\x2a\xa7\x47\x79\xec\xb2\x5f\x33\x5e\x7c\x53\x80\x27\xb1\x1d\xbf\x80\xee\xe3\x54\xc7\x9c\x4f\xf1\xdb\xe1\x9a\x6c\xc2\x61\xce\x0e\x7b\x39\x21\xac\xe7\x83\x49\xe1\x24\x7c\x85\xf1\x6d\x84\x32\xe0\xac\xad\x3c\x2b\xd5\x36\xa1\xbb\xcb\xe8\x48\x3d\xb0\x4d\x0f\x90\xca\x6e\x30\x07\x7c\x57\x63\xae\x82\xb2\x8d\xd7\x6d\xbc\x51\xae\x9d\x22\x39\xc5\x70\x56\x88\x77\x50\x09\x59\x92\x84\x87\x38\x39\x14\x52\x93\x63\xee\x66\xf9\xe4\x4c\x83\x81\x08\xda\xca\x7b\xcc\x41\x8a\x95\x32\x18\x8e\xb1\xba\xc2\xe8\x35\x0b\x74\xb4\x3a\x3d\x74\xe0\x1b\xdc\x43\x55\x47\x2c\x51\xe8\x18\xa9\x2d\xa5\xd4\x5d\xfa\x34\x3d\xe7\x89\xea\x22\xdd\x24\x42\x40\x80\xc3\xd0\x38\x13\xaf\xe2\xeb\xd0\x65\xb0\x47\x85\xde\xbb\x92\xa8\xc3\x4e\x01\x27\x89\x7c\x0c\xd3\x09\x75\xb1\xc0\xe4\xae\x8f\xad\xdb\xdd\x22\xeb\xc4\xa5\x39\x69\xd3\xce\xae\x56\xd3\xa7\x24\xd9\x3e\xfa\x1f\xd5\xb9\x17\xc5\x8e\x60\xa6\x4b\x3e\x42\xd9\x5c\xb4\x06\xd7\xf8\xb0\xb5\x2e\x94\x6d\xf7\xe8\x62\x0c\x02\xf6\xf8\x28\xf4\x41\x3c\xbc\x12\xef\xba\x6b\xc6\xdd\x5c\xb2\x46\x17\xa3\xf6\xe3\xf4\x47\x0d\xe6\x7d\x9d\x4c\xdb\xed\x39\x94\x98\x78\x0b\xc3\xae\xcc\xe0\xbb\xd0\xdf\x31\x46\xfd\xc7\x40\x30\x4a\x88\x06\x37\xf6\xe9\x5c\xd8\x78\x11\xbf\x77\xe8\xb3\x74\xa0\xf2\x13\xdf\xa5\x1a\x17\x36\x4a\x40\x63\xd0\x48\xd7\xd6\x7c\x5b\xaf\x34\x82\x2f\x05\xe7\x1a\x7d\x95\xda\xbd\x39\x7b\x75\xf7\x86\x2d\xa7\xed\x41\x50\x0b\xef\xb7\xd6\xe5\xc3\xdb\xba\xe9\xe1\xd7\x93\xde\xc0\x4c\xf5\xbf\xe5\x42\x71\xbe\x72\x19\x4e\xe9\x1f\xee\x31\x1a\x08\xc9\x66\xdd\x9f\xbd\xaf\xb8\x94\x54\xdb\x43\x85\x51\x4a\xb4\x47\x27\x88\x26\x58\x22\x4c\x52\xe2\xd6\xb9\xb0\x58\xee\xbe\xf8\x0a\x2a\x65\x9a\xd0\xbd\xd1\x4b\x0f\x69\xda\x47\x3d\x25\x6b\xab\xef\xf7\xb0\x4f\x49\xaa\x8b\x12\x88\xcf\xc1\xc6\x6f\x0d\x49\x8f\x7d\x0b\x08\xfb\xe7\x3c\x8f\x88\x6e\xbc\xc3\x50\xf5\x9d\x87\x81\x79\xbd\xdb\x82\x53\xc8\xd0\xd6\xb8\xa3\xf7\x1e\x31\xa7\x7b\x20\x74\xc2\xe3\x29\xfe\xe7\x0c\xcf\x33\x02\xae\x75\xd5\xe3\x16\x9c\x56\xb2\x01\xf3\xdb\x4c\xb7\x21\x8f\x1c\x51\x42\x52\x49\x4d\x15\x3d\xff\x4a\xee\x91\x03\xba\xd0\x4d\x72\x3c\x2a\x57\xee\xef\xb1\x12\x7a\xfa\x3b\x13\xfb\xf1\xee\xee\xcd\x6d\x7c\x26\x7b\xfb\xe3\x12\x5e\x35\x8e\xf2\xf7\x03\xf1\x6a\x58\x4e\x0b\x06\x2b\x49\x01\x8c\x50\x39\xb2\x93\x58\x64\x8e\x9c\xf0\x7e\x5f\xe5\x36\x26\x67\x9f\xc9\x13\x3d\xea\x0c\x3e\xd2\xde\x3f\xde\x98\x47\x7b\xce\x91\x17\x41\xc4\x6a\xd6\xb6\x2f\xa9\x87\x0c\x6d\x0c\x9f\xba\x6c\x3d\x51\x30\x95\xc4\x61\x6f\xf1\x8b\xef\xbb\xa0\xda\xae\x29\x28\x1e\x31\xfd\x18\x7c\x92\xa8\x03\x56\xb5\x75\xc2\x29\x02\xb5\xe3\x80\x26\x0c\x9a\x40\x54\x62\x11\x78\x8f\xad\x75\xf7\xb5\x16\x7b\xe9\x8b\x08\x62\x40\x93\x4b\x17\x0a\xba\x4d\x08\x32\x20\xf4\xda\x3a\x15\xca\x6a\xda\xd5\xe7\x47\xcd\x08\x39\x6a\x1c\xd9\xc5\x31\x43\x3e\x59\xf7\x23\x11\x86\xd2\xd9\x66\x5d\x42\xd5\xe8\xa0\xa8\xe2\x0d\x54\xd3\x36\xa1\xa4\xfd\x53\x95\xfe\xec\xf5\xab\xeb\xe7\x27\x6b\xaf\xb3\x50\x65\x7c\x10\x7c\xc6\xc8\x0b\x9c\x6b\xfb\x8f\x9c\x71\x2e\x42\x62\xa5\xc4\xb2\x0b\x6d\xdf\xdd\xe5\x2b\x03\x4b\xb5\xe6\x50\x05\xf3\x95\x81\xb5\xd5\x5e\x2a\xf1\x5a\x28\xfd\x99\x6c\x97\xcd\x0c\xb9\x11\x1d\xab\x6d\xe1\xb0\x68\xc8\xbe\x64\x89\xf2\x3e\xbd\xd0\x94\xaa\x56\x7b\x69\x29\x95\xf2\xf1\x39\xad\x0f\x2c\x91\xd8\xcf\xfe\xf0\xfe\x8f\x99\xba\xf0\xc3\xfb\x3f\xb9\xdf\xf6\x78\x78\xfd\x81\x7b\x56\xfb\xae\x50\x3d\x92\xfb\x9f\x00\xd6\x03\x5b\xef\xc9\xfb\x36\xf5\xb2\x5e\x53\xd2\xff\x99\x04\xcf\x7b\x93\x5d\x53\x28\xf1\x27\x27\x5e\xa7\x55\x40\x54\xad\x34\xd5\x79\xe5\xea\xdd\x68\xdd\xd1\x7a\xf5\x63\xbc\x61\xe4\x9a\x2b\xa5\x09\xdb\x07\xf2\x8e\x38\xfd\xdc\x6a\xf4\x49\x32\x3a\xbf\x46\xec\x93\xa2\xb4\xd3\x6e\x4a\x64\xdd\xa4\x3a\x8b\xb3\x4e\x40\xe9\x61\xa6\xf7\xcb\xb1\x34\xf7\x33\xd4\x61\xb1\xbf\xf3\x58\x2d\xb6\x93\x67\xdc\x8d\x9a\xa5\x3f\x73\xb3\xf4\x93\x59\xcf\xce\x6f\x4c\xa6\x62\x98\x3c\xd9\xe8\x37\x20\x09\x52\x29\xc8\x99\x07\x32\x24\x52\xa7\x9c\xc0\x9c\xd2\xa6\x1d\x87\xaf\xfc\xa8\x8b\xda\xc3\xf1\x78\xdb\x84\x77\xeb\xf5\x65\x1b\x93\x5f\xd6\xce\xda\x62\xdf\xbe\xf8\x59\x9e\xf3\xa3\x7e\x5f\xa2\xcf\xa1\xb4\x2e\x1f\x9f\x77\x10\x1e\x2f\xc7\x7b\x48\x6b\xf2\x86\x33\xaa\x2e\xa8\x8e\x7e\x36\x14\x9b\xc3\x8f\x98\xd8\x7f\xdb\x88\x1b\xe9\x08\xe8\xfd\x2d\xd6\x98\x4e\xd1\x3b\x82\x65\x96\xdd\x98\x69\x69\xb2\xe8\xdc\x41\x2c\x3f\xfa\x1f\xe7\x90\x71\x36\x55\xa3\x45\x50\x1b\xd4\xed\x82\xa9\x89\x51\x4e\x84\x61\x4d\x91\xda\xd9\x07\x6e\x39\xaa\x3a\xd9\xd8\xce\x8f\x7e\xe2\x23\xfe\xb8\x45\xf7\x18\x82\xb6\xe2\x9b\xc5\x43\x17\x90\x97\x1c\x43\xba\x17\x76\x13\xb2\x47\x3f\x08\x61\x8a\xe2\xb3\xf7\x03\x3b\xb1\xff\x48\xe6\xc3\xf9\xfd\x21\x09\x2c\xb3\x7f\x64\x59\x96\xfd\x7f\x00\x00\x00\xff\xff\xdd\x87\x8c\xb9\x27\x38\x00\x00")
+var _complySoc2PoliciesClassificationMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x5b\xcd\x8e\x1c\xb7\x73\xbf\x37\xfe\x0f\x51\x80\x80\x44\x5a\xcd\x76\xac\xe4\x12\xaf\xe1\xc3\x5a\xb6\xe0\x0d\xac\x58\xd0\xae\x90\x4b\x2e\x1c\x76\xf5\x34\xbd\x6c\xb2\x4d\xb2\x67\xd4\xc6\x1c\xf4\x1a\x01\xec\x97\xd3\x93\x04\x55\x64\x7f\xcd\xd7\xce\xca\x92\xff\x17\x8f\x0f\x2b\x0f\xc9\x62\xb1\xf8\xab\x62\xd5\x8f\x1c\x23\x6a\xbc\x82\xef\x45\x10\xf0\x52\x0b\xef\x55\xa9\xa4\x08\xca\x1a\x78\x63\xb5\x92\x5d\x26\xa4\xb3\xa6\xab\xaf\xe0\xfb\x97\x6f\x32\x2f\x82\xf2\xa5\x42\x7f\x95\x01\xdc\xdd\xbe\xa4\x3f\x00\x97\xf0\xf2\xe5\xd7\xf9\xd7\x59\x2d\x7e\xb1\xee\x2d\xae\x95\x57\xd6\x70\x97\x4b\x28\x44\xc0\x2b\xf8\xaf\xd6\xc0\x0b\xf8\xf7\xaf\x5e\xfc\x27\x0f\x90\xb6\xae\xd1\x84\x2b\xb8\x31\x2a\x28\xa1\xa1\xb0\xb2\xa5\x6f\xb2\xcb\xcb\xcb\xec\x09\x5c\x37\x0d\x9a\x42\x49\xf4\x90\x65\xe9\x7f\xde\xc3\xf5\x15\xfc\x28\x4c\xa1\x95\x59\x81\x2d\x07\x75\xb1\x80\x1b\x53\x5a\x57\xb3\xda\x93\xfe\xdf\x5d\xc1\x2b\xeb\x6a\x52\xcf\x9a\x52\x15\x68\x68\x2e\x15\x3a\xb8\x0d\x22\x20\xcf\x97\x3d\x81\x37\xad\x6b\xac\x47\x10\xa6\x80\x5b\x69\x1b\xcc\x32\x91\xc3\x5d\xa5\x3c\x29\x2f\x40\xce\xcd\xd2\xb0\x59\xa0\xc0\x52\x19\xf4\x10\x2a\x04\x87\xbf\xb6\xca\xb1\x40\x0f\xc1\x02\x1a\xdf\x3a\x84\x50\x89\x00\x6a\x54\x0d\x36\x2a\x54\xca\xf0\x10\xeb\x56\xc2\xa8\xdf\xa2\x48\xe5\xa1\x71\x36\xa0\x0c\x58\x80\x08\x20\x0c\x88\xa6\x71\xb6\x71\x4a\x04\x04\x8d\x6b\xd4\xf9\x44\xab\x64\x2c\xea\xa4\x15\xe9\x60\x59\x26\xad\xcf\x21\x78\x5a\x03\x19\x68\x77\x9e\x8f\x1f\xfe\xcf\xcf\xf4\xf1\x28\x5b\x47\xf6\x68\x9c\x5d\x39\x51\xe7\x70\x43\x0a\x4b\xdd\x16\xe8\x41\x68\x0d\xa1\x6b\xd0\x93\xac\xc9\xb0\x05\x38\x5c\x09\x57\x68\xf4\xb1\x29\x78\xa0\xc6\x05\xf8\x56\x56\x20\x3c\x34\xa2\x41\x07\xd6\x01\x6a\x94\xc1\x59\xa3\xe4\xa0\xb4\x5f\x44\xb5\x23\xc8\x3c\x5b\x9d\xcc\xbc\x14\x1e\xa9\xcd\x14\x70\x6f\xec\x46\x63\xb1\x22\xed\x67\xfa\x46\x83\x7a\x30\x36\xc0\xc6\xa9\x10\xd0\x4c\xcc\x92\x36\x66\x62\x14\x5a\x81\x32\x85\x5a\xab\xa2\x15\x3a\xce\xe5\x3b\x1f\xb0\xa6\x7d\x13\x01\x2a\xb1\x46\x10\x52\xd2\x4a\x82\x9d\xcd\x75\x8f\x4d\x80\x65\xb7\x67\xc4\x3c\xcb\x9e\xc0\x77\x42\xde\xaf\x9c\x6d\x4d\xb1\x37\xfd\x14\x17\x95\x5a\x55\x71\xf7\xc0\x2e\x7f\x41\x19\xd4\x9a\xec\x6a\x0a\x50\x75\xa3\x19\x2f\x09\x00\xc6\x07\xd7\x4a\xfa\x37\xdb\xf2\xf0\xd6\x1d\x42\xa3\x97\x15\xd6\x98\x54\x18\xb6\xee\x50\x4f\xd6\x83\x2c\xec\x61\x83\x5a\xd3\xdf\xc6\x59\x89\x45\xeb\x70\x9c\x74\x3e\x68\x01\x5a\x2c\x91\x1d\x8e\x6c\x57\x4d\xbc\x8f\xa7\x38\x02\xe8\x7c\xcf\xe1\x68\xb4\xb1\xe6\xb2\x50\x5e\x6a\xcb\xde\x21\x56\x0e\x93\xcb\xd4\x42\x99\x20\x94\xc1\xe2\x90\xc5\xa1\x6e\x7d\x00\x87\x25\x3a\x34\x92\xdc\x6a\xb0\x36\x6f\xc6\xdb\xbe\xc5\xf3\x66\xbc\x55\xfe\x1e\xae\xbd\x47\xef\x49\x7a\x1f\xc6\xa8\xe9\xb6\xc7\xfb\x8d\x91\x1c\x0e\xe0\xb5\x30\x62\x85\xb3\x7e\x4f\xa6\x23\x6e\xca\xc1\x22\x58\xcc\xf0\xa1\x3c\x38\x94\xa8\xd6\x58\x40\xe9\x6c\x0d\xb6\x0d\x5e\x15\xb8\xa7\xfe\x82\xbf\x69\xd0\x79\x8a\x00\x95\xed\x87\x45\x88\x4c\x45\xf2\x3a\xd3\x74\x1d\x28\x72\x45\x02\xa7\x75\x85\xa0\x75\x93\xb1\x79\x8c\x6b\x35\xd2\xe6\xa1\x97\x4e\x2d\x59\xaf\x99\x51\xe0\x6e\x9c\x30\x54\xe8\x70\xd9\xc1\x46\x69\x0d\x4b\x94\xb6\x4e\x1a\x6e\x0c\xb9\x68\xb9\xab\x44\xfe\xb9\x96\x3d\x02\x86\x62\x1a\xc5\x04\x17\x68\xbe\x65\xeb\x29\x6e\x7a\xb0\x0d\xba\x3e\x06\x90\x67\xa8\x40\x5e\xfb\x14\xf3\x55\xbe\x00\xd9\xfa\x60\x6b\x74\x31\x02\x5b\x43\xf1\x71\xad\x0a\x2c\x40\x6a\xdb\x16\xe0\xd1\xad\xe9\x78\x78\xb6\xd8\xb3\xe2\x2e\x82\x27\x78\x9f\x2d\x9c\x83\xd5\x64\xe0\x22\xe2\x6c\x89\x50\x8b\x02\x8f\xd9\xde\x37\x28\x07\xe1\xbe\x37\xa0\x43\xfa\x9e\xdc\x7b\xd4\x3c\xa9\x38\xe2\x9c\x2d\x62\x69\x43\x40\xe3\x4a\xe8\xd9\xb1\x11\xed\xfe\x3f\x15\x0e\x0b\xe8\xc8\xd1\x66\xfa\xd1\x4c\x29\x9a\x94\x20\x77\x3c\x8c\x4e\x2b\x0c\xe8\xea\xe4\x46\x57\x19\x9f\xb4\x8a\x42\x03\xc2\x5a\xe8\x76\x38\x13\x66\x42\x29\xee\x16\x40\x80\xae\x1b\x21\x83\x07\x76\x8c\xb8\xf1\x45\xeb\x48\x09\x9a\xd7\x91\x57\x89\xd1\xab\x38\x74\x78\x9f\xc3\x6b\xeb\xe6\xf6\xb7\x66\xb7\xb3\xa7\x98\x1d\xe3\x62\xc2\x2a\x1e\xf1\x52\x78\x3a\xba\xf9\x53\xf1\xec\x59\x3e\x2c\xe2\x16\x8d\x57\x8c\x92\x8e\xed\x28\x9d\x0a\x4a\xc6\xd3\xfc\xe4\xb2\xfa\x38\x8c\x14\x43\x68\x52\x29\xb4\x6c\xb5\xa0\xa3\x96\x82\x1e\x8a\x39\x0c\x40\x05\xac\x4f\xac\x7c\x54\xe9\x27\xda\x44\x3e\x0c\x49\x9c\x75\x49\x31\x6b\x82\x13\x32\xb4\x42\x83\x5d\x6a\xb5\x62\xab\xf8\x1c\xb2\x2c\x7b\x7e\xb9\xff\x79\x7e\xd6\x57\xe7\xb5\x65\xdb\x8b\x8b\x9d\xc8\x7b\x71\xb1\x85\x8b\x8b\x9f\x28\x8a\x5f\x5c\x40\xfa\x6c\x01\x2e\x2e\xe6\xd9\xde\xd0\xc8\x6d\xd7\x7c\x24\x8e\x03\xd2\x67\x9b\x6d\xf9\xef\xc5\xc5\x4f\x04\xc3\xd4\xbe\x85\x9d\x6e\x3c\x01\xcd\xe0\x54\x40\xa7\xc4\x5c\x0e\xe9\xf3\x16\x7d\x70\x2a\x9e\x77\xd3\xd6\x6d\xf6\xfc\xdb\xfd\xcf\xf3\xb3\xbe\x3a\xab\xed\xdb\xe7\xd9\x16\xde\xb4\x4b\xad\x64\x3f\x27\xfd\xb7\xa5\x44\x71\xfe\xfd\x16\x5e\x8b\xfb\x1e\x03\x53\x7c\x70\xf7\x49\xc2\x49\xe0\x16\x6b\xa1\xb4\x58\x6a\xa4\x36\x80\x6c\x3b\x15\x9e\x3e\x5b\x78\x8b\x1a\x85\xc7\xfe\x0b\xea\xdb\xc4\x29\x39\x3a\x53\x56\x53\x09\x57\xf7\x6d\x29\xab\x8b\x5d\xf2\x43\xfb\x30\xff\x1c\xf8\x6e\xbb\x1f\x96\x95\x19\xda\x8e\x7f\x86\xb6\xf3\x27\x12\xa6\x83\x8d\xe8\xf2\x43\x6d\xfd\xbf\xf6\x3f\xdb\xbf\xc0\x27\xfa\xb9\x4e\xab\x74\x52\xcb\xfe\x1f\xff\xac\x15\xdc\x98\x80\xce\x08\x0d\xef\x7a\xf8\x1c\xf8\x6e\x0b\xef\x8c\x68\x43\x65\x9d\xfa\x8d\xce\x5c\xf6\xe1\x5e\xcb\x53\x88\x3d\xdf\x44\xb5\xe8\x40\x8a\xd6\x23\xd4\xca\x58\x3a\x9d\x6b\xb1\xc2\x01\xae\x94\x6f\x63\xdd\x68\xdb\x61\xca\x73\x07\x51\xe7\xcf\x21\x4c\xf1\x6f\x9c\xf7\x4b\x6b\xd6\x68\x14\x65\x76\x43\xdb\xb8\xbc\x50\x29\x57\x70\x56\xa1\xd0\xe7\xa3\xa8\xf3\x27\x0a\x76\xcf\x3b\x12\x7a\xb7\xf0\x37\x2c\xcf\x81\x65\x1f\xc6\x31\x6d\x34\xc0\x81\xef\x0e\xc3\x32\xd8\xcf\x08\xcb\x69\x78\x1e\x21\x3a\xc2\x72\xc8\xda\x80\x8a\xb6\x86\xf2\xa0\xc7\x45\x51\x69\x0d\x25\xb9\x8e\x35\x4b\xa0\xef\x57\x30\x07\xfc\x64\xa5\x8f\x90\x4f\x51\x7a\x48\x8c\x93\x03\x8c\x6d\x33\xa0\x7f\x9e\x63\xe0\x5f\xfd\xd8\x76\xfc\xf3\x88\x09\x1c\x36\x6d\x98\xfa\xd0\xa9\xee\x07\xdb\xfe\x76\xb9\x73\x5c\x6e\x9a\xde\xa5\x69\x0f\x7c\xf5\xa9\x2e\xd7\x2f\xe5\x51\x71\xf4\x94\xf7\x59\xa3\x3b\x9a\x78\x70\xc0\x48\xc7\x5c\x3e\x0a\x5b\x52\x04\xe1\x83\xb3\x4d\xa5\xe4\xae\xf7\x45\x62\x27\x15\x15\x3b\xdb\x71\xf6\x04\xbb\xae\x37\xca\xda\x1e\x3a\x1e\x1e\x3f\xc1\x8e\xeb\x4d\x9c\xe5\x90\x88\xbf\x10\x50\xd9\x1d\x87\xb4\xff\xb8\x9a\xa1\x62\x8a\x28\x2a\xb0\x38\xdb\xf7\x59\xf6\x2f\x66\xe9\x9b\x6f\xb2\xac\xc8\x67\xdd\xfb\xba\xb9\xaf\xbc\xb1\x18\xcb\xaf\xdd\x32\x95\xeb\x57\x0f\x62\x56\x10\x26\x25\xf2\x2c\xc3\xb9\x64\x8a\xa8\x53\x78\x45\xf2\x2e\xd6\xf0\x1e\x7c\x65\x5b\x5d\x40\x70\x0c\x31\x02\x1d\x6d\x9c\xb6\x1b\xaa\xf5\x0e\x4e\x1c\x59\xbf\x48\xcf\x52\xbc\x06\x51\xe0\xaf\xed\xc0\xb1\xd2\xc1\x90\x88\xd8\xbe\xe0\x66\xf2\x44\xac\xad\x2a\xa8\x16\x68\x8d\x41\xf2\x25\xe1\x98\x33\x2d\x22\x65\x07\xd2\xfa\xe0\xf3\x2c\x2b\xe7\xda\x4f\xec\x21\x3c\x7c\xfc\xf0\xfb\x78\x36\x7e\xfc\xf0\x07\x91\x9b\x1f\x3f\xfc\x3e\xb5\x35\x7d\xdb\x1b\x93\x88\x9f\xba\x11\x86\xea\x70\x52\x01\xb4\xf2\x4c\xa0\x4c\x1c\x3b\x92\x4a\x0c\xff\x4d\xa5\x64\xb5\x57\xab\xb0\xa1\x7a\xe7\x4b\x8c\x13\x11\xfe\x9e\x26\xff\xc5\x2e\xa1\x6c\x4d\x62\x1d\x6d\x39\x88\xdb\x54\x36\x32\xa3\x24\xce\xa9\x55\x15\xc8\xbe\x7d\x14\xd9\x21\xb5\xf3\x2c\x5b\x3d\xb0\xec\x69\xa6\xfa\xa9\x4b\xe4\x40\xa2\xca\x19\x9d\x7b\x94\x7a\xe2\x72\x6a\x87\xdb\xa5\x4e\x3d\x07\x9d\x67\x59\xf5\x18\xa0\x25\x02\x72\xad\x70\xc3\x26\x3c\x8c\xad\x48\x43\xa8\x39\x65\x4d\x2c\x48\xf0\x80\x6b\x74\x1d\x94\x44\x10\x75\x28\x5c\xca\x15\x98\x54\x80\x4d\x85\x4c\x0a\x1d\x97\x9c\x80\xbe\x44\x90\x95\x30\x2b\x2c\x98\x29\x72\x24\x14\x1a\xeb\xbd\x5a\x6a\x5c\x1c\x1e\x3b\x78\xc9\x32\x7a\x86\xc3\x22\x52\x4d\x54\xf0\x32\x97\x76\x19\xa9\x12\x6f\xcb\xb0\x11\x0e\x07\x6a\x6d\xa4\xdc\x82\x1d\x58\x2d\xbf\xd8\x31\x4d\x6b\x8a\x41\x75\xda\xcb\x8e\x2f\x19\x98\x04\xb1\x3a\x32\x6a\x42\x7b\xfb\x89\xd6\xeb\xc9\x7a\x51\x86\x83\x8c\x5a\x34\x07\xa3\x39\x76\x11\x83\xa6\xac\x07\x4b\xd2\x74\x5f\x30\x10\x1d\xc9\x74\x74\xbb\x61\x37\xd1\xb7\xf6\xe4\xfa\xc7\x1a\x73\x0a\xa5\x1e\xdd\xcc\x9c\x93\x0f\x30\x8b\x48\xd1\x23\xa1\xb0\xb4\x34\xb5\x32\xab\x91\xa2\x7b\xc3\x17\x26\xc3\x15\xc9\xd5\x09\x3b\x31\xcd\x5f\xd0\x62\x46\x7e\x2b\xd8\x86\x01\xb5\xb4\x21\x10\x25\x5b\x46\x56\xab\x97\x07\x8d\x58\xe1\x37\x4c\x28\xd3\xad\x8e\xb7\xfb\x12\x4a\x67\x4d\x48\x7b\x00\xd2\xae\xd3\xf5\x8d\x59\xa3\xa6\xcb\x24\x29\x9c\x63\x3e\x92\x49\x53\x31\x38\xd2\x94\x5c\xed\x25\x29\xbe\x2e\x28\xad\x26\x60\xcc\x22\xd3\x30\x4a\x79\xf0\xc1\x12\x14\xe1\xa6\x9c\x8a\x4b\x77\x3b\xc9\x74\x0b\xbe\x5a\x2a\xb0\x14\xad\x1e\x88\xf1\xb4\x8d\x94\xb9\x4c\xe3\xca\x48\xcb\xfd\x70\xe0\xce\xe9\x4b\x18\xf4\x4b\xe8\x7e\xb3\x8f\xfd\x93\xaa\x9b\x13\x77\x69\x03\x10\xf7\x56\x97\x3c\x38\xc5\x46\x2f\x1d\xe2\x9c\x27\xef\xfb\x71\x0b\xc5\x27\x03\x85\xf2\x8d\x16\x23\x06\x26\x5e\x9a\xb3\xf6\x07\xcc\x5f\x0b\xa5\xcf\xb6\x7c\x4a\xe1\x4a\xe5\x7c\x00\xad\xcc\xc0\x56\x23\x89\x81\xa5\x2d\x3a\xb6\xb8\x7a\xac\xa5\xf7\x0f\xa0\xa3\x0a\x13\x28\x29\xb9\xac\xb1\x50\x02\x9e\x16\xca\xdf\xfb\x05\xd4\x58\x13\xb9\x2b\x85\x2b\xfc\x02\x30\xc8\xfc\xd9\xa9\x55\x1d\x35\x3b\x81\xca\xb7\xae\x14\x72\x58\x1b\x4f\xf4\x65\x96\x35\x85\x52\x70\xc2\xf8\x9a\xee\x4b\x0b\xb0\x4e\x68\xdd\x9d\x5a\xc0\x18\xe3\xc8\x77\x94\xa5\x24\x6d\x89\x25\xf1\xfc\x74\x7d\xd7\x7a\xbf\x73\x33\xd1\xb3\xe5\xb4\xb2\xcb\x60\x2f\xe9\x2f\x1d\x07\x75\x6b\x92\xc2\x0b\x0a\xb3\x01\x35\x36\x95\x35\xb8\xe0\x78\x6d\xba\x74\x19\x52\xa3\x88\xf9\x07\xa9\x36\x1f\x97\x96\x24\x72\xb8\xd6\x7a\xc8\x05\x22\x70\x69\xc6\x23\x97\x54\xbc\x05\x31\xcc\xf2\x3a\x57\xad\x2a\xe8\x16\x13\x3d\xa7\x18\xdc\xbb\x7f\x63\xf0\x1e\xae\x17\x94\x9b\x3d\xfc\xb2\x20\xff\xf8\xe1\x8f\xec\xd3\x74\xa1\x3c\x47\x63\x40\xf6\x50\xdf\x2e\x6b\x15\x40\x1c\x7f\x95\x90\x4e\x0a\x4a\x25\x6a\x86\x48\x40\xf0\x6d\x43\xc7\x94\xb7\x1c\x99\xd3\x61\x0b\x8d\x55\x26\x5c\xda\xf2\x92\x4e\x5c\x21\x43\x0e\xd7\xe0\x05\x4d\x76\x42\xba\xf2\xb3\xf5\x7f\xd7\x9f\x61\xf1\x4e\x94\xee\x35\xe3\xf5\x48\x3a\xaf\x54\x4d\x4f\x11\xd0\xcd\xae\x7f\x4f\xad\x76\x49\x17\x62\x8d\x75\xc9\xad\x0f\x5d\xa3\x9d\x71\x13\x3b\xbb\x0b\x5a\x3e\x7b\x16\xb1\xf0\x8f\xec\x7f\x29\xf8\x2e\x1d\x8a\x7b\xc8\xc6\xa7\x22\x67\xbf\x0e\x79\x38\xeb\x1b\x23\xe7\x70\x7d\x79\xf4\x08\x9f\x40\xeb\xe2\x8a\xcd\x18\x8f\xf2\xef\xd3\xa9\xe0\x77\x7c\x72\x74\xd8\x78\x5e\xd1\x7f\x2f\x72\xf8\x99\xb2\xdb\x03\x59\x2f\x95\xd3\x93\x3c\x36\x1f\xc6\x4c\x07\xdf\x94\xe0\xc9\x82\xc7\xd2\xe1\xc5\xfc\xf0\xed\x17\xe7\xd3\xf9\xed\x70\x45\x3e\xe1\xb0\x00\x8a\xb4\x79\x36\x95\x3d\xac\x83\x55\xe1\x24\x7c\x89\xf1\x6d\x84\x32\xe0\xac\xad\x3d\x6f\xaa\x6d\x43\x7f\x97\xd1\xab\x7a\x44\x4c\x9a\xdd\x61\x6d\xe9\x0e\x19\xdf\x37\x58\xa8\xa0\x6c\xeb\x75\x17\x2f\xd2\x1b\xa7\x28\xb0\xc5\x1c\xb9\x14\xef\xa1\x16\xb2\x22\x0b\x27\x99\x2a\x9f\x90\x8c\xb3\x69\xee\x0e\xae\x93\x33\x0d\x42\xbc\x00\x6d\xe5\x3d\xdd\x21\x8b\xa5\x32\x18\x8e\xea\x28\x3a\x1a\xc8\x51\xb3\x44\x47\xa3\xd3\x43\x07\xd2\xe8\x68\xd5\xc1\xd6\xa1\x4e\x74\x47\x4d\x29\x75\x9f\x3e\xe5\xd9\x67\xd8\x2e\xda\x9b\xa4\x08\x08\x70\x18\x5a\x67\xe2\x55\x7c\x13\xfa\x0c\xf6\xe8\x82\x48\xc6\x10\x4a\x74\x37\xdd\x80\xb3\x4c\x9e\x24\x32\x4c\x67\xda\x71\x15\xc0\xd8\x90\xb6\x99\xb7\x9d\x3f\xb4\xa0\xdd\xb4\x07\x47\xab\xf9\x53\x92\x24\xf1\x51\x7b\xee\x45\x89\xf9\x09\x98\xfc\x89\xcd\xe6\xa2\x35\xb8\xd6\x87\x8d\x75\xa1\xea\x92\xdf\xce\x40\x40\x16\xf5\x28\xf4\x51\x3c\xbc\x12\xef\xfb\x6b\xc6\x41\xa9\x94\x0a\x34\x74\x9d\x4f\xa7\xf6\xc3\xfa\x47\xa7\x29\x86\x3a\x99\xc4\xed\x05\x14\xca\x1b\x7c\x10\x86\x43\x99\xc1\xf7\xfd\x41\xd3\xef\x7f\x3c\x08\x26\x09\xd1\x00\xa1\xec\xf3\x85\xb0\xe9\x20\x7e\xef\xd0\x2f\xc5\x03\x95\x9f\xf8\x3e\xd5\xb8\xb0\x56\x02\x5a\x83\x46\xba\xae\xa1\xa5\x95\x4a\x23\xf8\x4a\xf0\x9b\x84\x84\x78\x3f\xbc\x39\x7b\x75\xf7\x86\x3d\xa7\x1b\x40\xd0\x08\xef\x37\xd6\x15\xe3\xdb\xba\xf9\xe4\xd7\x33\x6e\x60\xff\x1c\xa0\xc4\xd7\xe1\xde\xc6\x24\x6c\x0d\xb3\x0c\x0f\xf7\x18\x0d\x84\x64\xb3\x1a\xe6\x9e\xcf\x78\x37\x26\xd5\xf6\x58\x61\x94\x12\xed\xc9\x0c\xa2\x0d\x96\x8e\x57\x49\x89\x5b\x1f\xc2\x62\xb9\xfb\xe2\x2b\xba\x88\x6b\x43\xff\x46\x2f\x3d\xa4\xe9\x1e\x8c\x94\xbc\x5b\xfd\x16\x11\xde\x87\xad\x8b\x16\x28\x2d\x15\xf5\xd3\xb7\x86\xb4\x8f\x3d\x9b\x52\xe1\xf0\x9c\xe7\x01\xd3\x29\x7f\xc0\x76\x8f\xc4\xc0\xe1\x7d\x27\x9a\xc8\x74\xfc\x40\x71\x67\xdf\x07\xc4\x9c\x1f\x81\xd0\x09\x8f\xb3\xc6\x23\xf1\xe7\xb8\x1b\xee\x45\x9e\x09\x70\xad\xab\xf3\x13\x43\x45\x37\x19\xc9\x0e\xcc\x6f\x33\xdd\x9a\x22\x72\x24\xf6\xc8\x33\x12\xa9\xa2\x0f\xbf\x92\x7b\x60\x82\xfe\xe8\x26\xff\x39\x69\x57\x66\x48\x85\x9b\x98\x71\x70\xb1\x1f\xef\xee\xde\xdc\x72\x58\xbc\xbd\xfd\x31\x87\x57\xad\xa3\xfc\xfd\xc8\x79\x35\x0e\x17\x66\xe2\x82\xfd\x01\x46\xa8\x9c\xf8\x09\x01\x26\xd5\x29\xf1\xc4\xdd\xe7\x55\x6e\xb9\x6a\xfd\x52\x91\xe8\xc1\x60\xd0\xaf\xeb\x13\xfd\xfd\xcf\x3b\xf3\x44\xe6\x21\xf5\x22\x88\x78\x9b\xb5\x1d\x4a\xea\x31\x43\x9b\xc2\xa7\xa9\x3a\x4f\x8f\xa7\xe6\x96\x38\x1e\x2d\xde\xf9\x81\x05\xd5\x76\x45\x87\xe2\x09\xd7\x57\xdc\x92\x4c\x1d\xb0\x6e\xac\x13\x4e\x11\xa8\x1d\x1d\x43\xb5\x30\x68\x02\x69\x89\x65\xa0\x9e\xb0\xb1\xee\xbe\xd1\x62\x2f\x7d\xa1\x97\x7f\xbd\xd9\xd8\x41\x93\x73\xf0\x6a\x88\xbb\xd7\x2b\xeb\x54\xa8\xea\x39\xab\xcf\x8f\x9a\x11\x0a\xd4\x38\xf1\x8b\x53\x8e\x7c\xf6\xde\x4f\x4c\x18\x2a\x67\xdb\x55\x05\x75\xab\x83\xa2\x8a\x37\x50\x4d\xdb\x86\x8a\x02\x45\xaa\xd2\x9f\xbe\x7e\x75\xfd\x2c\x9f\x4d\x75\x62\xf7\x7a\x0f\x55\xc6\x07\xc1\x73\x4c\xa2\xc0\x63\x7d\xff\x81\x39\x1e\x8b\x10\x76\xfa\x18\x04\x43\xd7\xf7\x23\x0c\x34\x68\xa9\xd6\x1c\xab\x60\x52\x8d\x40\xb7\x97\x4a\xbc\x16\x4a\x7f\x21\xdf\xa5\x05\x7b\x64\x22\x9a\xf1\x22\x85\xc3\xb2\x25\xff\x92\x15\xca\xfb\xf4\x42\x53\xaa\x46\x8d\x81\x3d\x0d\x25\x5a\x21\x3e\xa7\xf5\x54\x1e\x17\x89\xcf\xfe\xf8\xe1\xf7\x7d\x0b\x7a\xba\xbb\x20\xbe\xed\xe1\xe3\xf5\x07\xe6\xac\xf6\x43\xa1\x7a\x20\xf7\x3f\x03\xac\x47\x44\xef\xd9\xfb\x36\x71\x59\xaf\x89\x3f\xf8\x42\x86\x67\xd9\xe4\xd7\x74\x44\xfb\xb3\x13\xaf\xf3\x2a\x20\xaa\x56\xda\xfa\x71\xe5\xea\xdd\x64\xdc\xc9\x7a\xf5\x04\xd6\x1f\xdc\xdc\xb8\x6a\xf2\x88\xf9\xb2\xa7\x7b\xb1\xd7\x7d\x3f\x3f\x38\x5d\x8d\x7e\x92\x8d\x1e\x5f\x23\x0e\x49\x51\x92\xb4\x9b\x12\x59\x37\xab\xce\x62\xaf\x33\x50\x7a\x7c\xd1\xfb\xe5\x58\xea\xfb\x05\xea\x30\xda\xa3\x87\x6b\xb1\x9d\x3c\xe3\x6e\x42\x96\xfe\xcc\x64\xe9\x67\xf3\x9e\x9d\xdf\x98\xcc\xcd\xf0\xce\x1c\x12\x40\x3b\x4b\x64\x37\xd5\x33\x0e\xd9\x09\x12\x53\x4e\x51\x36\xa5\x4d\x3b\x01\x5f\xf9\x09\x8b\x8a\xc5\x83\x80\xbe\xeb\xa5\x0d\xfb\x45\xbf\x6c\xb9\x6c\x9c\xb5\xe5\x5c\x47\xea\xc9\xcf\xf2\x9c\x9f\xf0\x7d\x49\x3f\x87\xc4\x92\x61\x71\x06\x3c\x5e\x4e\x65\x48\x6b\xe8\x22\x7d\x72\xa8\x4e\x7e\x36\x14\xc9\xe1\x07\x5c\xec\xbf\x6d\x4c\x1d\xa5\x53\xcd\x90\x9a\xcc\xf5\x14\x43\x20\xc8\xb3\xec\xc6\xcc\x4b\x93\x45\x1f\x0e\x62\x09\x3a\xfc\x38\x87\x9c\xb3\xad\xe9\x75\xb7\x5a\xa3\xee\xe8\x2a\x40\x98\x78\xca\x89\x30\x8e\x29\x13\x9d\x7d\x98\x4e\x27\x69\x5d\x4f\xa4\x4e\x7f\xf4\x63\xcb\x51\x44\xff\x18\x82\x44\xf1\x35\xed\x61\x6e\xde\xc3\x25\x15\xf7\xfd\x2e\x8e\xe3\x49\xed\xc9\x0f\x42\x48\x8c\x88\xcf\xde\x8f\x48\xe2\xf8\x91\xdc\x87\xf3\xfb\x63\x16\xc8\xb3\x7f\x64\x59\x96\xfd\xff\x00\xdd\x87\x8c\xb9\x27\x38\x00\x00")
 
 func complySoc2PoliciesClassificationMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -601,12 +622,12 @@ func complySoc2PoliciesClassificationMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/classification.md", size: 14375, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/classification.md", size: 14375, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesConductMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x58\xcf\x8e\xdb\x46\xf2\xbe\xeb\x29\x0a\x08\xf0\x3b\x18\xb2\x7e\xeb\x3d\x2d\xe6\xe6\x68\x03\xac\x83\x05\xe2\xb5\x07\xc8\x65\x2f\xc5\x66\x51\xac\x4c\xb3\x8b\xa9\xee\xa6\x4c\x9f\xf2\x1a\x79\xbd\x3c\xc9\xa2\xba\x49\x8a\x9a\x19\xdb\x07\xcf\x0c\xc5\xae\xbf\xdf\xf7\x55\xb5\x02\x0e\xf4\x00\x67\x69\x09\xa4\x83\xb3\x84\x36\xbb\x04\x1f\xc5\xb3\x9b\x0f\xe8\x54\xc2\x3c\x3c\xc0\xf9\x97\xf3\xc7\x43\xc4\xc4\xb1\x63\x8a\x0f\x07\x80\xc7\xcf\x67\xfb\x01\xf0\x16\xce\xe7\x77\xa7\x77\x87\x01\x7f\x13\xfd\x44\x13\x47\x96\x50\x5e\x79\x0b\x2d\x26\x7a\x80\x9f\x73\x80\x77\xf0\xf7\xbf\xbd\xfb\x47\x39\xe0\x64\x18\x28\xa4\x07\xf8\x10\x38\x31\x7a\x68\xc5\x65\x7b\x72\x78\xfb\xf6\xed\xe1\xf0\x03\x7c\xcc\x3a\x4a\x24\xc0\xd0\xc2\x67\x27\x23\x1d\x0e\x00\x78\x82\xc7\x9e\x60\x5c\x3e\x93\x0e\x52\xcf\x11\xc6\x12\x28\x70\x84\x24\xd0\x52\xc7\x81\x80\xbe\x8c\xe4\x12\xb5\xd0\x50\x8f\x13\x8b\x42\xa7\x32\x00\x0d\xa3\x97\x99\xc8\x5e\xbd\xa2\xb6\x11\x52\x4f\xac\xe0\xc4\x7b\xc2\x4b\xa6\x78\x84\x98\x47\xd2\x89\xa3\x68\x3c\x16\xff\xa9\x27\x90\x89\x14\xbd\x07\xd1\x0b\x06\xfe\x8a\x89\x25\x9c\x60\x09\xea\xd7\xd5\x1f\xd8\x2b\x7b\x1f\xd0\x89\xf7\x72\x05\xc9\xfa\xbc\xbe\x27\xf8\xa5\xeb\x28\x44\x9e\x68\x0b\xf2\x08\x2d\x47\xcd\x63\xba\x7f\x68\x41\x8c\xa8\x89\x1d\x8f\xc5\x33\x70\x80\x48\xca\x92\xa3\x9d\x18\x73\xa2\x08\xb1\x97\xec\x2d\x61\xc0\x49\xb8\xa5\xf6\x04\x3f\x6d\xa1\xa0\xee\x6a\x52\xe2\x8a\x89\x14\x10\x94\xa2\x3d\xed\xb2\x2f\x6e\xac\x10\xd8\x88\x62\x09\x81\xc2\xc4\x2a\xc1\x1a\xb3\x25\xfb\xb8\x2b\x39\x8e\xa3\xe7\x9a\xe8\x7d\xe6\xd5\x54\x48\x8a\x2e\x89\xc6\xd2\xb7\xb9\x04\xd1\x48\x0e\x2d\x34\xf3\x52\xf8\x1a\xa1\x39\x28\xe5\x50\xf8\x37\x25\x0b\x4c\x14\x3e\x84\x96\x46\x0a\xad\x7d\x76\xde\x6c\xc1\xfb\x8b\x12\x95\x03\xb7\xf2\x5a\x87\x5e\x87\x2f\x5c\x7b\xf6\x04\x23\x69\x27\x3a\x70\xb8\x2c\x7e\xdb\x9c\x98\x6a\x5c\x2f\x4e\x72\x04\xc9\xc9\x73\x28\xf0\xf1\x72\x7d\x80\xc3\xe1\x87\x85\x0e\xb5\x08\x6f\xce\x32\x8c\x9e\x31\x38\x82\x2b\xa7\x1e\x3c\x5e\xdf\x14\x64\x03\x2c\x3f\x78\x5f\xfe\xa5\x37\x3d\x4e\x06\x69\xc8\xa1\x25\x8d\x09\x43\x6b\x11\x49\xb7\xd4\x6b\x18\xfd\x5c\xcd\x95\x6a\xde\x8a\x8f\xfe\x08\x11\x3b\x4a\x73\x05\x43\x87\x96\x02\xa1\xb7\xe3\x1e\xaf\xf1\x04\xbf\xf6\x14\x5e\xa6\xf9\x9b\x34\x96\xea\x5c\x4e\xad\x07\x8a\x07\x2b\x99\x79\xc4\x30\xff\xf5\xc7\x9f\x11\x46\x15\x4b\x3e\x1e\xa1\xe3\x60\x79\xc5\x23\x38\xe5\xc4\x0e\x3d\x70\x30\xb3\x05\x7a\x47\xf8\x3f\x18\x73\xe3\xd9\x01\x0f\x78\xa1\xe3\xbe\xeb\xcf\x40\xd6\x10\x50\xea\x8b\x05\xf3\x6f\x50\x93\x10\xb9\xf1\x74\x82\x0f\x96\xf4\x76\xd6\x6a\x9e\x43\xcc\x5a\x1a\x71\xed\x29\xf5\x05\x9d\x86\x21\x7b\x07\xcd\x22\xba\x8a\xfd\x68\x79\x0e\x9c\x0a\xbd\x67\xcb\xdf\xe0\x72\xae\xc9\x2c\xd0\x3c\x5a\x82\xf3\x5a\xf7\x48\xf4\x04\xd8\x4e\xec\xa8\xea\x80\x65\xaf\x14\x25\xab\x23\x18\x30\xe0\x85\xf4\x04\x2b\xc4\xdf\x7c\xaa\xa4\x30\x9a\xd9\x9b\x57\xd1\xa7\xd1\xa3\xa3\x37\x87\xef\x34\x77\x61\xd2\x0b\x45\x39\xc1\x3f\x39\x3a\xe5\x81\x03\x26\xd1\x79\x47\xec\x1e\x15\x63\xb4\x0e\x1f\x2d\x85\x89\x5d\xe2\x61\x51\x17\xb8\xb2\xf7\x10\x24\x59\x19\x93\x78\x52\x2b\xc2\x46\xc3\x37\x1f\x55\x12\xd5\x8a\x48\xb7\xb6\xd2\xda\x38\x92\xa6\x79\x1f\xe9\xf9\xd9\x67\x47\x68\x24\xf5\x30\x60\x22\x35\xed\x15\x05\x0e\x09\xc3\xc5\x3a\x73\xdc\xe9\x48\x52\x2a\x85\x2f\x80\x59\xd3\x2b\x50\x45\xa5\x3b\x75\xb9\xa7\xfb\x03\xdc\x53\x01\xe0\xdd\x09\x3e\x57\xb3\x96\xd0\xc0\x31\xc7\x0d\x7e\x40\xbf\x67\x1e\x0b\xa3\xbf\x7d\x6c\xf3\xee\xfd\x2e\xd8\x5d\x4a\x1c\x9c\xcf\x85\x4e\x49\xb1\xa5\x01\xf5\xc9\x10\x2c\xe3\xac\x7c\xe9\xd3\xf1\x1e\xc3\x4a\xa3\x68\x5a\xf4\x5d\x0a\xd8\x56\x53\x45\x13\x22\x6d\xe5\xd9\x0b\x6b\x8e\xd4\x82\x04\x3f\x1b\xba\x0b\x5f\x29\xd1\x4a\xb2\xa2\x27\x87\x57\x62\x1f\x6b\xa7\xb6\x7c\x3b\x74\xec\xd9\xde\xdf\xb9\xdf\xba\xb1\xc6\x51\x81\xda\xa2\x91\xac\xbc\x37\x61\x68\xd1\x73\x1c\x8e\x46\x90\x40\x93\x05\x2d\x71\xe1\xd3\xe1\xf0\xdf\x11\x2f\xd4\x28\xe1\xd3\x0d\x23\xa4\x51\x82\xd1\x6f\x1c\x09\xd5\x68\xbd\x07\x46\xd1\x8c\xe7\x18\xdf\x13\x7a\xc8\x31\xc1\xa8\x14\x8b\xdc\xf6\x34\x44\xf2\x13\x45\x3b\x83\xc1\x8c\xaa\x8c\xca\x98\xc8\x44\x41\xa5\xa3\x68\x83\x1f\xbd\x51\x2a\x18\xa3\x1e\x77\x24\xc4\x86\x5b\x5a\x94\x7f\xab\x45\xab\x14\x23\x38\x69\xe9\x86\xec\xb3\x68\x99\x81\x12\x5e\xe7\x9b\xc9\x4c\xcb\xd1\x49\x56\xbc\x50\x5b\x0b\x85\xce\xd1\x98\xac\xff\x17\xee\x52\xac\x0f\x9d\x67\x0a\x29\x1a\xc2\x6d\x7e\x06\xb2\x51\xf4\xa3\x72\x43\xca\x8b\xa1\x51\xa5\xe7\x86\x0d\xe6\x9d\x68\x89\xad\xa1\x40\x1d\xa7\xaa\xcb\x33\xd0\x97\x44\x1a\x36\x9e\xd4\xdf\xcd\xdc\x7c\x0b\xf9\xe7\x0d\x02\xa5\x55\x98\x53\x2f\xca\xf7\x34\x7c\x21\x18\x5d\xf6\x9d\x71\xfc\x4e\xaa\xcd\x44\xe1\x9b\xb9\xba\x98\x8d\x4d\x3b\x8b\xb8\x2c\x6b\x4b\xe5\x41\xcb\x13\xb7\xd9\x30\xca\x61\x12\x3f\xad\xfa\xb0\x78\xfc\x7c\xdb\x64\x8a\x91\x45\xe8\x22\x0c\x38\x17\x22\x1a\x0b\xb1\xb1\xff\x6b\x0c\x5b\xdc\x55\x34\x28\x51\x70\xd5\xbf\xc1\xc3\x0b\xb6\x7b\x75\xc0\xa7\x02\x1f\x1b\xfe\xce\x49\x0e\xa9\xe0\x12\x5a\xf2\x74\xc1\xd2\x8a\x25\x9f\x24\x90\x08\x07\x18\x68\x68\x4a\x0b\x76\x31\x3e\xee\x3e\x78\x65\x4b\x59\xc7\x3b\x2b\x78\x42\x9b\x9a\x7f\xfd\xf1\x27\x70\x88\x49\x73\x11\xbf\x78\x9b\x9d\xc6\xc5\xfd\x74\x5f\x07\x9d\xe4\x4b\x6f\x0b\x4e\x30\xa8\xd9\xdb\x06\x5f\x48\x3c\x90\x9f\x37\xa4\xae\x9d\x7c\xdf\x18\xd8\x89\x38\x0e\x75\xf9\x43\x6d\xd9\x4e\x7e\xb7\x95\x8d\x6d\xa5\xc1\xa5\x8c\xbe\x16\xc1\x49\x9d\xc2\x52\x8c\x78\xc2\xc9\xfe\x2c\xa0\xb4\x52\xd6\x29\x7e\x5b\x5e\xa2\xeb\xa9\xcd\x9e\xa0\xa5\x64\xb3\x2d\xd0\x6e\x47\xea\x59\xed\xf0\x36\xa6\x7e\xfa\x52\xa0\x6e\xb9\x3b\x0c\xe6\x7b\xc0\x96\x0a\x7c\xc5\x39\x2c\xbb\x37\xa4\x1e\x0b\x75\x27\xa3\xee\x8d\xd1\x25\x82\xea\xd8\x6c\x96\x1d\x04\xb5\xf6\xd7\x1e\xf4\x92\xb5\xf0\xa5\xc5\x39\x1e\x97\x65\xc4\x78\x3e\xa1\xdf\x46\xe7\x2b\x31\xdd\xb8\x1b\x3a\xcf\xae\x90\xa7\x90\x85\x62\xfa\x6e\xe5\xca\xae\x5a\x78\x36\x2e\x62\xb5\x6e\x20\x6c\xbf\x5a\x4e\x45\x1f\x57\x63\x4b\x66\x83\x89\x7a\x69\xbb\x75\x7d\xdd\x68\xee\x68\xb4\x0f\x6a\x5b\x6a\xbf\xa5\x29\xb7\x3e\x76\xca\x14\xda\x75\x11\x7b\x3e\xcc\xab\x62\x8f\x14\xea\x04\xd8\xd9\xbd\x17\x3b\x63\xd7\xb2\xcb\xdf\xcb\x6b\xd1\xa2\x45\x51\xa5\x89\x09\x9d\x5f\x18\xf2\xcc\x93\xe1\xdc\x4e\xed\xd3\x18\x86\x1c\xd8\xbd\x48\xe3\xfc\xad\xdb\x8b\x09\xda\x9e\x5f\x45\xcf\x1b\xda\x25\xb0\xb3\x08\x38\x48\xb8\xc4\x04\x84\xae\xaf\x55\xbf\xf9\xfe\xb1\x6a\xe2\x1d\x0b\x6e\xd7\x9e\xbb\x2b\x8f\xa5\xbe\x17\x15\xba\xad\xf8\x8b\xb2\xc6\xe5\x16\x61\xe8\x55\xb2\xad\xdf\xf2\xe7\xc1\x96\xbf\xce\x26\x75\xcc\x65\x56\x1d\x77\xa3\xd2\x32\x6b\x6c\x8d\xaa\xc8\xdf\x21\x63\x35\x5a\x2e\x59\xeb\x5c\x11\xbb\x4c\xc4\x13\x7c\x5a\xcd\xff\x2b\x0f\x18\xe0\xd3\xb2\xf3\xc5\xc2\x96\x41\x94\xf6\x7b\x01\x48\xd8\x85\xb8\x0d\x51\x5b\x28\x99\xee\x52\x7f\x7f\x77\xdb\x29\x65\xdd\xaf\xef\x6e\xbf\x8b\x16\x28\xfe\x27\x53\xac\x94\xbd\x21\xad\x65\xdd\x94\xee\x35\x56\x15\xb0\xfd\xbf\xe8\xf3\xd8\x6f\xa1\xd9\x62\xc9\xa3\xe7\x80\x3a\x2f\xeb\xf1\x5d\x98\x9f\x68\xac\x1b\xdc\x32\xba\x42\xaa\xe3\x79\x62\xf1\xb8\xae\x8e\xdf\xbb\x3c\xd9\x78\x32\x7d\xa1\x54\x13\x6b\x5f\x3a\x34\xf8\x85\x48\xbf\x67\xb2\x4b\x43\x3d\x32\xd9\xc7\xf5\xf6\x56\x2e\x38\x75\xc5\xd8\xbc\x1e\xa1\xc9\xa9\x74\xbf\x6e\x6d\xf4\x00\xf7\x8b\x53\x4b\x83\xd8\x8b\xfb\x67\x4a\xa3\xf2\x80\xa1\xdd\x3f\x8c\x39\x8e\x76\x87\xb6\x4c\x14\xa0\x6a\x67\xcd\xec\xde\x5e\x59\x4d\x97\x84\x37\xc0\x18\x06\xb0\x7e\x63\xc0\x89\x6a\x91\xb6\xbf\x0c\x8f\x5b\x29\x8d\x64\x18\x29\xd6\x55\x7b\x5d\x4f\xca\x25\xa3\x4b\xb6\x31\x35\xf4\xf5\xab\xa7\x6d\x93\xaf\xc8\xcc\xc1\xe3\xd5\x6e\xd6\xdb\x97\x10\x36\x78\x9d\xcd\x6e\x73\x6d\x63\xd2\x6f\x55\x3c\xd4\x7f\xff\x0b\x00\x00\xff\xff\xc5\x67\x4f\xa3\x8c\x11\x00\x00")
+var _complySoc2PoliciesConductMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x58\xcd\x8e\xdb\x46\x12\xbe\xf3\x29\x0a\x08\xb0\x07\x43\xd6\xae\xf7\xb4\x98\x9b\xa3\x0d\xb0\x0e\x16\x88\xd7\x36\x90\xcb\x5e\x8a\xdd\x45\xb1\x32\xcd\x2e\xa6\xba\x5b\x32\x7d\xca\x6b\xe4\xf5\xf2\x24\x41\x35\x7f\x44\xcd\x4c\xe6\x62\x59\x14\xbb\x7e\xbf\xef\xab\xea\x89\x38\xd0\x03\x9c\xc4\x13\x48\x07\x27\x89\xbe\xb8\x0c\x1f\x25\xb0\x9b\x1a\x74\x2a\x71\x1a\x1e\xe0\xf4\xd3\xe9\x63\x93\x30\x73\xea\x98\xd2\x43\x03\xf0\xe5\xf3\xc9\x3e\x00\xde\xc2\xe9\xf4\xee\xf8\xae\x19\xf0\x17\xd1\x4f\x74\xe1\xc4\x12\xeb\x2b\x6f\xc1\x63\xa6\x07\xf8\xb1\x44\x78\x07\xff\xfc\xc7\xbb\x7f\xd5\x03\x4e\x86\x81\x62\x7e\x80\x0f\x91\x33\x63\x00\x2f\xae\xd8\x93\xe6\xed\xdb\xb7\x4d\xf3\x1d\x7c\x2c\x3a\x4a\x22\xc0\xe8\xe1\xb3\x93\x91\x9a\x06\x00\x8f\xf0\xa5\x27\x18\x97\xdf\xa4\x83\xdc\x73\x82\xb1\x06\x0a\x9c\x20\x0b\x78\xea\x38\x12\xd0\xd7\x91\x5c\x26\x0f\x2d\xf5\x78\x61\x51\xe8\x54\x06\xa0\x61\x0c\x32\x11\xd9\xab\x57\x54\x9f\x20\xf7\xc4\x0a\x4e\x42\x20\x3c\x17\x4a\x07\x48\x65\x24\xbd\x70\x12\x4d\x87\xea\x3f\xf7\x04\x72\x21\xc5\x10\x40\xf4\x8c\x91\xbf\x61\x66\x89\x47\x58\x82\xfa\x79\xf5\x07\xf6\xca\xde\x07\x74\x12\x82\x5c\x41\x8a\x3e\xad\xef\x11\x7e\xea\x3a\x8a\x89\x2f\xb4\x05\x79\x00\xcf\x49\xcb\x98\xef\x1f\x5a\x10\x23\x6a\x66\xc7\x63\xf5\x0c\x1c\x21\x91\xb2\x94\x64\x27\xc6\x92\x29\x41\xea\xa5\x04\x4b\x18\xf0\x22\xec\xc9\x1f\xe1\x87\x2d\x14\xd4\x5d\x4d\x6a\x5c\x29\x93\x02\x82\x52\xb2\xa7\x5d\x09\x35\x57\x2b\x04\xb6\xa2\x58\x43\xa0\x78\x61\x95\x68\x8d\xd9\x92\xfd\xb2\x2b\x39\x8e\x63\xe0\x5a\xcc\x27\x99\xcf\xa6\x62\x56\x74\x59\x34\xd5\xbe\x4d\x35\x88\x56\x4a\xf4\xd0\x4e\x4b\xe1\xe7\x08\xcd\x41\x2d\x87\xc2\x7f\x29\x5b\x60\xa2\xf0\x21\x7a\x1a\x29\x7a\xfb\xed\xb4\xd9\x82\xf7\x67\x25\xaa\x07\x6e\xe5\xb5\x0e\xbd\x0c\x5f\xb8\xf6\x1c\x08\x46\xd2\x4e\x74\xe0\x78\x5e\xfc\xfa\x92\x99\xe6\xb8\x9e\x9d\xe4\x04\x52\x72\xe0\x58\xe1\x13\xe4\xfa\x00\x4d\xf3\xdd\x42\x87\xb9\xe3\x6f\x4e\x32\x8c\x81\x31\x3a\x82\x2b\xe7\x1e\x02\x5e\xdf\x54\x64\x03\x2c\x1f\xbc\x2f\xff\xd2\x9b\x1e\x2f\x06\x69\x28\xd1\x93\xa6\x8c\xd1\x5b\x44\xd2\x2d\xa5\x1f\xc6\x30\xcd\xe6\x6a\x35\x6f\xc5\xc7\x70\x80\x84\x1d\xe5\x69\x06\x43\x87\x96\x02\x61\xb0\xe3\x01\xaf\xe9\x08\x3f\xf7\x14\x9f\xa7\xf9\x8b\xb4\xe0\x4b\x9e\xea\xa9\xf5\x40\xf5\x60\x25\x73\x32\x8c\x18\xa7\x3f\x7e\xfb\x3d\xc1\xa8\x62\xc9\xa7\x03\x74\x1c\x2d\xaf\x74\x00\xa7\x9c\xd9\x61\x00\x8e\x66\xb6\x42\xef\x00\x7f\x83\xb1\xb4\x81\x1d\xf0\x80\x67\x3a\x00\xfd\x25\xc8\x5a\x02\xca\x7d\xb5\x60\xfe\x0d\x6a\x12\x13\xb7\x81\x8e\xf0\xc1\x92\xde\xce\x1a\x79\x4b\x4c\x45\x6b\x23\xae\x3d\xe5\xbe\xa2\xd3\x30\x64\xef\xa0\x59\x44\x67\x01\xd8\xab\x23\xe9\xc0\xd9\x9e\xb5\x93\x95\x1e\xc4\xe8\x55\x93\x59\xa0\x79\xb0\x3e\x4f\x6b\xdd\x13\xd1\x23\xa0\xbf\xb0\xa3\x59\x07\x2c\x7b\xa5\x24\x45\x1d\xc1\x80\x11\xcf\xa4\x47\x58\x21\xfe\xe6\xd3\x4c\x0a\xa3\x99\xbd\x79\x15\x7d\x1c\x03\x3a\x7a\xd3\xbc\xd2\xdc\x85\x49\xcf\x14\xe5\x08\xff\xe6\xe4\x94\x07\x8e\x98\x45\xa7\x1d\xb1\x7b\x54\x4c\xc9\x3a\x7c\xb0\x14\x2e\xec\x32\x0f\x8b\xba\xc0\x95\x43\x80\x28\xd9\x18\x9d\x25\x90\x5a\x11\x36\x1a\xbe\xf9\xa8\x92\x69\xae\x88\x74\x6b\x2b\xad\x8d\x23\x69\x9e\xf6\x91\x9e\x9e\xfc\x76\x80\x56\x72\x0f\x03\x66\x52\xd3\x5e\x51\xe0\x98\x31\x9e\xad\x33\x87\x35\x1d\xf3\xaa\x64\x3e\x67\x48\xae\xe9\x59\x27\x1d\x2a\xed\x2b\xf0\x84\xee\x0f\x2b\x07\x96\x0f\x80\x77\x47\xf8\x3c\x9b\xb5\x84\x06\x4e\x25\x6d\xf0\x03\xfa\xb5\xf0\x68\x45\x78\xe5\xd8\xe6\x3d\x84\x5d\xb0\xbb\x94\x38\xba\x50\x2a\x9d\xb2\xa2\xa7\x01\xf5\xd1\x10\x2c\xe3\xa4\x7c\xee\xf3\xe1\x1e\xc3\x4a\xa3\x68\x5e\xf4\x5d\x2a\xd8\x56\x53\x55\x13\x12\x6d\xe5\xd9\xfa\xdb\x12\x94\x44\x1e\x24\x86\xc9\x14\xcf\xc2\x0f\x94\x69\x25\x59\xd5\x93\xe6\x85\xd8\xc7\xb9\x53\x5b\xbe\x1d\x3a\x0e\x6c\xfa\xb3\x73\xbf\x75\x63\x8d\x63\x06\xaa\x47\x23\x59\x7d\xef\x82\xd1\x63\xe0\x34\x1c\xe0\xda\x53\xa4\x8b\x05\x2d\x69\xe1\x53\xd3\xfc\x7f\xc4\x33\xb5\x4a\xf8\x78\xc3\x08\x69\x92\x68\xf4\x1b\x47\x42\xc5\x78\x0f\xe1\xaa\x19\x4f\x31\xbe\x27\xf4\x50\x52\x86\x51\x29\x59\x73\x72\x4f\x43\xa2\x70\xa1\x64\xbc\xc0\x68\x46\x55\x46\x65\xcc\x64\xa2\xa0\xd2\x51\xb2\xc1\x8f\xc1\x28\x15\x8d\x51\x5f\x76\x24\xc4\x96\x3d\x2d\xca\xbf\xd5\xc2\x2b\xa5\x04\x4e\x3c\xdd\x90\x7d\x12\xad\x33\x50\xe2\xcb\x7c\xb3\x31\xe2\x39\x39\x29\x8a\x67\xf2\x73\xa1\xd0\x39\x1a\xb3\xf5\xff\xcc\x5d\x4e\xf3\x43\x17\x98\x62\x4e\xc6\x2d\x9b\x9f\x91\x6c\x14\x7d\xaf\xdc\x92\xf2\x62\x68\x54\xe9\xb9\x65\x83\x79\x27\x5a\x4b\xd1\x52\xa4\x8e\xb3\x29\x51\x85\xe7\xd7\x4c\x1a\x37\x9e\xcc\xff\x37\x73\xd3\x2d\xe4\x1f\x37\x08\xd4\x56\x61\xc9\xbd\x28\xe7\xe9\xe5\x04\x96\x82\x74\x25\x74\xc6\xf1\x3b\xa9\x36\x13\x95\x6f\x1c\x33\x9d\xcd\xc6\xa6\x9d\x86\xff\x75\x6d\xb1\x01\xc1\xd1\xf3\x85\x7d\x31\x8c\x72\xbc\x48\xb8\xac\xfa\xb0\x78\xfc\x7c\xdb\x64\xaa\x91\x45\xe8\x12\x0c\x38\x55\x65\x31\x16\x62\x6b\xff\xce\x31\x6c\x71\xcf\xa2\x41\x99\xa2\x4d\xf9\xe8\x2b\x3c\x82\xa0\x5f\x63\x37\x75\xc0\xc7\x0a\x9f\x2c\x80\xce\x49\x89\xb9\xe2\x12\x3c\x05\x3a\x63\x6d\xc5\x92\x4f\x16\xc8\x84\x03\x0c\x34\xb4\xb5\x05\xbb\x18\xbf\xec\x7e\x78\x36\x40\x6e\xe3\x9d\x15\x02\xa1\x27\x4d\x7f\xfc\xf6\x3b\x70\x4c\x59\x4b\x15\xbf\x55\x7c\x16\x2e\xee\xa7\xfb\x3a\xe8\xa4\x9c\xfb\xdc\x95\x10\x0d\x6a\xf6\xb6\xc1\x17\x32\x0f\x14\xa6\x0d\xa9\x6b\x27\xdf\xb7\x06\x76\x22\x4e\x43\xb5\x9c\x51\x3d\xdb\xc9\x57\x5b\xd9\xda\x56\x1a\x5d\x2e\x18\xe6\x22\x38\x99\x97\x0d\xa9\x46\x02\xe1\xc5\xbe\x56\x50\x5a\x29\xeb\xd3\xdd\xf2\x92\x5c\x4f\xbe\x04\x02\x4f\xd9\x66\x5b\xa4\xdd\x8e\xd4\xb3\xda\xe1\x6d\x4c\xfd\xf0\xb5\x42\x5d\x62\x02\x87\xd1\xc6\xc3\x80\x9e\x2a\x7c\xc5\x39\x34\x0a\xda\x5e\x8b\x95\xba\x17\xa3\xee\x8d\xd1\x35\x82\xd9\xb1\xd9\xac\x3b\x08\xea\xdc\x5f\x7b\xd0\x4b\xd1\xca\x17\x8f\x53\x3a\x2c\xcb\x88\xf1\xfc\x82\x61\x1b\x9d\x2f\xc4\xb4\x96\xef\x24\xb1\x0b\xec\x2a\x79\x0c\xc1\x4a\x29\xbf\x5a\xb9\xba\xab\x56\x9e\x8d\x8b\x58\xad\x1b\x08\xdb\xd6\x63\x39\x55\x7d\x5c\x8d\x2d\x99\x0d\x26\xea\x55\x45\x4c\x81\xd7\x8d\xe6\x8e\x46\xfb\xa0\xb6\xa5\x56\xe2\xab\xd1\xb4\xb6\x1f\x30\x45\xbf\x2e\x62\x4f\x87\x79\x6d\x9c\x8c\x14\xad\xb5\xbb\x65\x59\xe2\xbd\xd8\x19\xbb\x96\x5d\xfe\x5e\x5e\xad\xb6\xab\xa2\x4a\x9b\x32\xba\xb0\x30\xe4\x89\x27\xc3\xb9\x75\x65\x9f\xc6\x30\x94\xc8\xee\x59\x1a\xa7\xbf\xba\xbd\x88\xde\x11\x6f\xd6\xf3\x96\x76\x09\xec\x2c\x02\x0e\x12\xcf\x29\x03\xa1\xeb\xe7\xaa\xdf\x7c\x7f\x3f\x6b\x62\xda\xbb\xbd\x5d\x7b\x6e\xf8\xca\x52\x85\x65\x2f\x2a\x74\x5b\xf1\x17\x65\x4d\xcb\x2d\xc2\xd0\xab\xd4\x91\x5a\x2c\xc6\x47\x90\xae\xb3\x49\x9d\x4a\x9d\x55\x87\xdd\xa8\xb4\xcc\x5a\x5b\xa3\x66\xe4\xef\x90\xb1\x1a\xad\x97\xac\x75\xae\x88\x5d\x26\xd2\x11\x3e\xad\xe6\xff\x53\x06\x8c\xf0\x69\xd9\xf9\x52\x65\xcb\x20\x4a\xfb\xbd\x00\x24\xee\x42\x5c\x53\xaf\x8b\x3f\xd3\x5d\xea\xef\xef\x6e\x3b\xb5\xac\xfb\xf5\x7d\x8d\x62\x5c\x8e\x1e\xe1\x7f\x85\x92\xb9\xd8\x23\xcd\xb3\x6e\x4a\xf7\x12\xab\xaa\x58\xfd\x5d\xf4\x69\xec\xb7\xd0\x6c\xb1\xe4\x31\x70\x44\x9d\x96\xf5\xf8\x2e\xcc\x4f\x34\xce\x1b\xdc\x32\xba\xa2\x85\x80\x01\x2e\x2c\x61\xc9\xb8\x7b\xf5\xf2\x64\xe3\xc9\xf4\x85\xf2\x9c\x98\x7f\xee\xd0\x46\x45\x4c\xf4\x6b\x21\xbb\x34\xcc\x47\x2e\x16\xcf\x7c\x7b\x33\x95\x90\x79\xc5\xd8\xbc\x1e\xa0\x2d\xb9\x6a\xd7\xbc\xb5\xd1\x03\x6c\x63\xab\x2e\x4e\x9e\x06\xb1\xf0\xf6\xcf\x94\x46\xe5\x01\xa3\xdf\x3f\x4c\x25\x8d\x76\x87\xb6\xde\x29\xc0\xac\x9d\xd5\xc7\xdd\x12\xe9\xa9\xde\x1e\x97\x84\x37\xc0\x18\x06\x70\xfe\x8b\x01\x67\x32\x0b\x1c\xb7\x6f\x86\xc7\xad\x94\x46\x32\x4c\x94\xac\x46\x6e\x5b\x4f\xea\x25\xa3\xcb\xb6\x31\xb5\xf4\xed\x5b\xa0\x6d\x93\xaf\xec\x81\x12\x03\x5e\xed\x66\xbd\x6e\xfc\x75\xf0\x3a\x9b\xdd\xe6\xda\xc6\x64\xd8\xaa\xd8\x34\x4d\xd3\x34\xcd\x9f\x03\x00\xc5\x67\x4f\xa3\x8c\x11\x00\x00")
 
 func complySoc2PoliciesConductMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -621,12 +642,12 @@ func complySoc2PoliciesConductMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/conduct.md", size: 4492, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/conduct.md", size: 4492, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesConfidentialityMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x57\xcd\x6e\xe4\xc6\x11\x3e\x67\x9e\xa2\x80\x00\x8e\xb5\x90\x66\x23\x9f\x02\xdd\x12\x79\x81\x6c\x10\x23\x0b\xaf\x9d\x7b\xb1\xbb\x38\xac\xa8\x59\x45\xf7\xcf\x8c\x98\x93\x1f\x24\x79\x39\x3f\x49\x50\x4d\x0e\xc9\x91\x76\xb4\xd1\x45\xc3\x61\x77\xfd\x7d\x5f\x7d\x55\x23\xd8\xd3\x03\x3c\xaa\xb4\xec\x49\x32\x63\xe0\x3c\xc2\x27\x0d\xec\xc6\x1d\xba\xa8\x32\xf6\x0f\xf0\xf8\x69\x97\x30\x73\x6a\x99\xd2\xc3\x0e\xe0\xa7\xcf\x8f\xf6\x0f\xe0\x0e\x1e\xef\xf7\xf7\xeb\xc7\xef\x76\x3d\xfe\x4b\xe3\x8f\x74\xe4\xc4\x2a\xf5\xf0\x1d\x78\xcc\xf4\x00\x7f\x2b\x02\xf7\xf0\xdd\x1f\xef\xff\x54\xcf\x3b\xed\x7b\x92\xfc\x00\x1f\x85\xcd\x2f\x78\x75\xc5\xbe\xd9\xdd\xdd\xdd\xed\x76\xbf\x87\x4f\x25\x0e\x9a\x08\x50\x3c\x7c\x76\x3a\xd0\x6e\x87\x7b\xf8\xa9\xe3\x04\x43\x8d\x0f\xb4\xe4\xc0\x42\x09\xe8\x79\x20\x97\xc9\x43\x43\x1d\x1e\x59\x23\x68\x0b\xd4\x0f\x41\x47\xa2\x04\x59\xe1\x89\x68\x00\xb7\x49\x13\x58\x5a\x8d\x3d\x66\x56\x01\x6c\xb4\x64\x70\x81\x49\x72\xba\x85\x01\x63\x16\x8a\xe9\xb6\xfa\xd6\x12\x2d\xd8\x01\x65\x84\x44\xae\x44\xda\xc3\xab\x50\x70\x18\x02\x4f\xae\x30\x84\xd5\xf7\x2d\x34\x8a\xd1\x43\x4f\x7d\x53\x2d\xb2\x1c\x29\x65\x3d\x1b\x77\x2a\x39\xa2\x9b\xbe\x38\x75\x0a\x3d\x8e\xd0\xe1\x91\x00\x9d\xa3\x54\x0d\x5e\x0b\xfb\x32\x82\xbe\xa4\x0c\x0d\x41\x8f\x9e\x20\x12\x7a\x0e\x23\xe0\x11\x39\x60\x13\xe8\x1c\xd8\xa9\xd3\x1e\x38\xef\xd6\x78\xf7\x56\xeb\xbf\xa0\x7b\x3a\x44\x2d\xe2\xe7\xcc\xe8\x9c\xf2\x1f\xd2\xf5\xb2\x9d\x5d\x0e\x51\xf3\x54\xff\x56\x23\xe4\x93\x9a\xff\x54\xe1\xaf\x50\xf3\x1e\x3e\xe6\x9a\x59\x43\x10\xe8\x80\x21\x8c\xd0\xb0\x78\x96\x03\x7c\xcb\x7b\xda\x43\x22\x49\x9c\xf9\x48\xe0\x4a\xca\xda\x53\x34\xd6\xe0\xcd\x17\x0c\xb4\x45\x3c\x1a\x51\x30\x58\x56\x06\x4f\x53\x92\xf1\x20\xcd\xc6\x96\xc7\x21\xaa\x15\x91\xd2\xcd\x84\xd8\xa3\xf6\xbd\x0a\xd0\x33\xf6\x43\xa0\x64\x2c\xb9\x9a\x1c\xcb\x05\xf2\x2c\x2e\x14\x5f\x01\x2d\x19\x38\x81\x68\x86\xc0\x3d\x5b\xda\x59\x1f\x60\x89\xf4\x67\x19\x4a\x13\x38\x75\x56\x0f\x16\x14\xf7\xc2\xf4\x72\xf2\x71\x4e\xf5\xfd\x4c\xb8\xf7\x47\x12\xaf\xf1\x3d\x3d\x67\x8a\x82\xa1\x12\x71\xac\x85\x58\xee\x7c\xc2\x3c\x91\xd4\xcc\x95\x80\xe9\x16\x84\x4e\x90\xc9\x75\xa2\x41\x0f\x4c\x67\xda\xe6\x8e\x22\xb0\x64\x0a\x81\x5c\x2e\x66\x2e\xea\x40\x31\x8f\x8b\xb1\x0f\xcf\x9c\xb2\x81\x60\x17\x86\xa8\xc9\xda\xe8\x02\x84\xc0\x29\xa7\x4d\x6a\x9e\x93\x0b\x9a\xac\xd5\xce\x45\x4e\x39\x62\x26\x73\x3c\x17\xc9\x0c\x0e\x91\x9d\xfd\xff\x06\x7a\x8c\x4f\x64\x4e\x16\x2b\x3f\x60\xa6\xc8\x18\x12\x7c\xb3\x22\x64\x3d\x1c\xd8\x71\x0e\xe3\x74\xc5\x03\x26\xf8\xed\xd7\xff\x6c\x01\xfa\xed\xd7\xff\xee\x7e\x67\x40\x7e\x58\x7a\xfb\xc4\x21\x4c\x0d\x73\xc4\x38\x9a\xcb\x40\x47\x0a\x15\x5b\x2c\xb9\xd3\xc8\xff\x36\x5b\xff\x47\x37\x55\xd1\x99\x94\xcf\x7c\xbc\x3b\x3b\x99\x82\xf4\x25\x52\xe5\x77\x87\xe2\x83\x39\xba\x66\xe9\xdd\x92\xe9\xdf\xd5\x3d\xd5\xda\x4e\xba\xf1\x86\x00\xe5\xda\x9d\x99\x7b\x5a\xab\xfd\x19\x5b\x0a\x23\x78\x4e\x55\x07\xe7\x46\xe9\x22\xf9\x9b\x45\x2c\x13\x9c\x3a\x12\x10\x85\xa0\x72\xa0\x08\x42\xe4\xc9\x2f\x36\xfe\xc9\x74\xba\xee\x56\x25\x8c\xa0\x72\x0e\xcf\xd3\x91\xdd\x26\x80\xef\x67\xb0\x5f\xdf\xa9\x4e\xb7\xe5\x15\x0f\x42\x56\x62\x8c\x2b\xbd\xbe\xd7\xda\x25\x25\xbd\x91\xb9\x55\x74\xa0\x98\xd4\xf8\x7e\x40\x96\x5b\x68\x48\xa8\xe5\x7c\x0b\xf6\x2a\x6a\xcb\xf9\xa5\xc5\x33\x0b\xaf\x9b\x35\xb9\x93\x51\x85\x6c\x48\x24\xf6\x04\x79\xd5\x34\x33\xbc\x1e\x38\x71\xee\x58\x2e\xde\x9b\x10\x7b\xa5\xa9\xc9\x27\x35\x1e\xac\x79\x22\x63\x36\x3a\xf0\x91\x03\x1d\x28\xc1\xcb\xc0\x4c\xd9\xdf\x88\x4a\x23\x44\x32\x9a\x63\xfe\xba\xfa\x14\x99\x60\xf1\xd0\xa3\xd8\x38\x9a\x19\xa0\xdb\x57\x33\x64\x37\xaf\x22\x89\xd4\xeb\xf1\x45\x28\x2b\x69\xda\xa8\xfd\x46\xe1\x87\x48\x3d\x5b\x13\x16\x09\xd6\x26\xd8\x24\x0d\x25\x1b\xfb\x16\x54\xad\x62\xd5\xe4\xd4\x1c\xff\x68\xdb\x3a\xd9\xac\x11\x7a\xc2\x54\x22\xa5\x95\xf8\x36\x40\xfe\xca\xd1\x5e\xfe\x80\x82\x46\xcc\xd4\x69\x09\x7e\x0a\x28\xf6\xb5\xdc\xda\xb6\x77\x8b\x91\xb5\xc9\x3a\x4c\xd0\x10\x49\x0d\x30\x50\x9d\xeb\x63\x95\xd2\x50\x57\x89\x75\xb8\x5b\x32\xf3\x30\x7e\xf7\x72\x87\xf9\x72\x50\x0b\xc2\x26\x1b\x19\x9f\x26\x5e\xb4\x1a\x82\x9e\xb6\xa9\x58\xb6\x24\xf6\xf1\x3c\xdd\x2a\x7e\xd7\x11\x3b\x0f\x00\xfb\xbb\xdf\xc3\xe7\x4a\x04\x6b\x8b\x60\x1a\x30\xe0\x60\x13\xed\x5c\xff\x8b\xa3\x1f\xc4\xc5\x71\xc8\x40\x26\xd3\x51\x85\xdd\xa5\x34\x88\x07\xb6\x3a\xd8\xcd\x0b\x1e\x56\xcd\x67\x87\xe1\x22\xea\x84\x2d\x1d\x8a\xed\x1c\x36\x37\x1a\x4c\x74\xe9\xee\x47\xfa\xa5\x70\xa4\xcb\xf5\x28\xf1\xc1\x44\x44\xee\xe6\xce\x2a\x91\xde\xdb\xa3\x95\x8b\x32\x01\x1e\x22\xd1\xeb\xd0\x1f\x55\x52\x09\xb9\xb6\x90\x8d\x70\xdb\xbc\xfa\x8a\x77\x0d\xb6\xa1\xd6\xaa\x70\x88\x28\x75\xca\xac\x2e\x37\x72\x4c\x31\x23\xcb\xf5\x4e\x98\xb5\xf8\xd9\xd1\x60\xcf\x1b\x3c\x7f\x16\x4f\x71\x31\x10\xe8\xc0\x99\x7b\x2b\x8c\x53\xf1\x5c\x0f\xdf\xbe\xb1\xbc\xe0\x58\xc5\xd2\x82\x68\x68\x91\x14\x6f\x53\x71\x5e\x0f\xe6\x99\xff\x02\xda\x8f\x2d\x20\x44\x3a\x94\x80\x59\xe3\x08\x78\x20\x71\x23\x44\xfa\xa5\x50\xca\xe9\x12\xbc\x54\x67\x78\x1d\x46\x26\x98\x9e\xb3\x89\xc0\xb4\x04\xf2\x61\xb3\x0e\xac\xc6\xb7\x42\x14\x27\xb0\xd2\x39\x3c\xab\xe2\xd6\xfe\xb7\xb3\x78\xd5\xa5\x0a\x1a\x5b\xe0\xd2\xcd\x85\x57\x38\x92\x64\xa3\xb1\x89\xe9\xbc\xd7\x76\x3c\xac\x7b\x95\x40\x2a\xae\x03\x67\x44\xb9\x5d\x20\x9a\x76\xbb\x39\xa7\xca\xc1\x48\x8e\x6c\x35\x18\xa2\xa1\x7c\x8a\x9c\xf3\x66\x06\xcc\x62\x6e\xb2\x92\x3b\xe2\x08\xdd\xd4\xfd\xfd\xdc\xfd\x33\x15\x36\x69\x7c\x45\xba\x21\x77\x1c\x7d\x0d\x99\x29\xed\x27\x16\xd8\x40\xe2\x21\xb0\x98\x1e\x39\x95\x64\xf1\x89\xa3\xf4\x6e\xd5\xbf\xcd\x6a\xd0\x29\x1c\x59\x43\xed\x95\xee\x52\x0b\x4d\x21\xe6\xc5\xb9\x4a\x41\x8b\x6e\x8a\x6e\x31\x5f\x57\x22\x4d\x89\x6d\x7b\x9e\xea\x8b\xee\xbc\x28\xcc\xbe\xfe\x0c\xa9\xa4\xf3\x4f\x8f\x48\xe8\x3a\xab\x79\xde\x6c\xe5\x93\xce\x44\x3e\x58\x11\x50\x2e\x91\xb7\xea\xdb\x36\xc7\x75\xf8\x4d\xa1\x1a\x6b\xa7\x5b\xb6\xcb\xd3\xdc\x5b\x99\x62\xcf\xb2\x0a\x42\xa4\x81\xd0\xbc\x16\xe1\x2f\x9b\x30\x7a\x63\x48\x3a\x65\xb6\xb9\xbf\x09\x7f\xfb\xf3\x81\xd3\xb2\x91\xd3\xd1\x80\x6d\x33\xc5\x5a\xb6\xad\xef\x57\xca\xbb\x33\x63\xff\x0b\x00\x00\xff\xff\x99\xc5\x05\x3b\x45\x0e\x00\x00")
+var _complySoc2PoliciesConfidentialityMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x57\xcd\x6e\xe4\xc8\x0d\x3e\xa7\x9f\x82\x40\x80\xdd\xb5\x61\xf7\xc4\x7b\x0a\x7c\x4b\x3c\x06\xe2\x20\x8b\x0c\xd6\x33\xb9\x53\x25\x4a\x62\x5c\x22\xb5\xf5\xd3\x6d\xe5\xb4\x0f\x92\xbc\xdc\x3e\x49\xc0\x92\x5a\x52\xdb\xd3\x93\xc0\x87\xb6\x5a\x55\xfc\xfb\x3e\x7e\x64\x0b\xf6\x74\x0f\x0f\x2a\x0d\xd7\x24\x89\xd1\x73\x1a\xe1\x93\x7a\x76\xe3\x0e\x5d\x50\x19\xfb\x7b\x78\xf8\xb4\x8b\x98\x38\x36\x4c\xf1\x7e\x07\xf0\xf9\xf9\xc1\x3e\x00\x6e\xe1\xe1\x6e\x7f\xb7\xfe\xfb\xe3\xae\xc7\x7f\x6a\xf8\x99\x0e\x1c\x59\xa5\x1c\xbe\x85\x1a\x13\xdd\xc3\x5f\xb3\xc0\x1d\xfc\xf8\x87\xbb\x3f\x96\xf3\x4e\xfb\x9e\x24\xdd\xc3\x93\xb0\xf9\x85\x5a\x5d\xb6\x6f\x76\xb7\xb7\xb7\xbb\xdd\xef\xe1\x53\x0e\x83\x46\x02\x94\x1a\x9e\x9d\x0e\xb4\xdb\xe1\x1e\x3e\x77\x1c\x61\x28\xf1\x81\xe6\xe4\x59\x28\x02\xbd\x0e\xe4\x12\xd5\x50\x51\x87\x07\xd6\x00\xda\x00\xf5\x83\xd7\x91\x28\x42\x52\x78\x21\x1a\xc0\x6d\xd2\x04\x96\x46\x43\x8f\x89\x55\x00\x2b\xcd\x09\x9c\x67\x92\x14\x6f\x60\xc0\x90\x84\x42\xbc\x29\xbe\x35\x07\x70\xda\x0f\x28\x23\x44\x72\x39\xd0\x1e\xde\x85\x82\xc3\xe0\x79\x72\x85\xde\xaf\xbe\x6f\xa0\x52\x0c\x35\xf4\xd4\x57\xc5\x22\xcb\x81\x62\xd2\x93\x71\xa7\x92\x02\xba\xe9\x8b\x63\xa7\xd0\xe3\x08\x1d\x1e\x08\xd0\x39\x8a\xc5\xe0\xa5\xb0\xcf\x23\xe8\x73\x4c\x50\x11\xf4\x58\x13\x04\xc2\x9a\xfd\x08\x78\x40\xf6\x58\x79\x3a\x05\x76\xec\xb4\x07\x4e\xbb\x35\xde\xbd\xd5\xfa\xcf\xe8\x5e\xda\xa0\x59\xea\x39\x33\x3a\xa5\xfc\x7d\xbc\x5c\xb6\x93\xcb\x21\x68\x9a\xea\xdf\x68\x80\x74\x54\xf3\x1f\x0b\xfc\x05\x6a\xde\xc3\x53\x2a\x99\x55\x04\x9e\x5a\xf4\x7e\x84\x8a\xa5\x66\x69\xe1\x07\xde\xd3\x1e\x22\x49\xe4\xc4\x07\x02\x97\x63\xd2\x9e\x82\xb1\x06\xaf\xbe\x62\xa0\xc9\x52\xa3\x11\x05\xbd\x65\x65\xf0\x54\x39\x1a\x0f\xe2\x6c\x6c\x79\x1c\x82\x5a\x11\x29\x5e\x4d\x88\x3d\x68\xdf\xab\x00\xbd\x62\x3f\x78\x8a\xc6\x92\x8b\xc9\xb1\x9c\x21\xcf\xe2\x7c\xae\x0b\xa0\x39\x01\x47\x10\x4d\xe0\xb9\x67\x4b\x3b\xe9\x3d\x2c\x91\x7e\x91\x21\x57\x9e\x63\x47\x35\x34\x2c\x28\xee\x8d\xe9\xe5\xe4\xc3\x9c\xea\x87\x99\x70\x1f\x0e\x24\xb5\x86\x0f\xf4\x9a\x28\x08\xfa\x42\xc4\xb1\x14\x62\xb9\xf3\x09\xd3\x44\x52\x33\x97\x3d\xc6\x1b\x10\x3a\x42\x22\xd7\x89\x7a\x6d\x99\x4e\xb4\x4d\x1d\x05\x60\x49\xe4\x3d\xb9\x94\xcd\x5c\xd0\x81\x42\x1a\x17\x63\x8f\xaf\x1c\x93\x81\x60\x17\x86\xa0\xd1\xda\xe8\x0c\x04\xcf\x31\xc5\xe5\xfc\x17\xa9\x39\x3a\xaf\x91\xea\xb5\xc8\x31\x05\x4c\x64\x8e\xe7\x22\x99\xc1\x21\xb0\xb3\xcf\xef\xa0\xc7\xf0\x42\xe6\x64\xb1\xf2\x13\x26\x0a\x8c\x3e\xc2\x77\x2b\x42\xd6\xc3\x9e\x1d\x27\x3f\x4e\x57\x6a\xc0\x08\xbf\xfd\xfa\xef\x2d\x40\xbf\xfd\xfa\x9f\xdd\xef\x0c\xc8\xc7\xa5\xb7\x8f\xec\xfd\xd4\x30\x07\x0c\xa3\xb9\xf4\x74\x20\x5f\xb0\xc5\x9c\x3a\x0d\xfc\x2f\xaa\xff\xaf\x6e\xb2\x46\x98\x95\xcf\x7c\x5c\x9f\x9c\x4c\x41\xd6\x39\x50\xe1\x77\x87\x52\x7b\x73\x74\xc9\xd2\xf5\x92\xe9\xdf\xd4\xbd\x14\x0d\x99\x74\xe3\xe2\x0d\xc0\x54\xba\x33\x71\x4f\x6b\xb5\x9f\xb1\x21\x3f\x42\xcd\xb1\xe8\xe0\xdc\x28\x5d\xa0\xfa\x6a\x11\xcb\x08\xc7\x8e\x04\x44\xc1\xab\xb4\x14\x40\x88\x6a\xaa\x17\x1b\xff\x60\x3a\x5e\x76\xab\xe2\x47\x50\x99\x65\x0d\x6a\x3a\xb0\xdb\x04\xf0\x71\x06\xfb\xfd\x9d\xe2\x74\x5b\x5e\xa9\x41\xc8\x90\xc4\xb0\xd2\xeb\xa3\x96\x2e\xc9\xf1\x1b\x99\x5b\x45\x07\x0a\x51\x05\x3d\xb4\xc8\x72\x03\x15\x09\x35\x9c\x6e\x40\x83\x95\xbe\xe1\xf4\xd6\xe2\x89\x85\x97\xcd\x9a\xdc\xc9\xa8\x42\x36\x24\x22\xd7\x04\x69\xd5\x34\x33\xbc\x1e\x38\x72\xea\x58\xce\xde\x9b\x10\xd7\x4a\x53\x93\x17\x72\xe1\x30\x04\x1d\x02\x63\x32\xb9\xe3\x03\x7b\x6a\x29\xc2\xdb\xc0\x4c\xd9\xbf\x11\x95\x06\x08\x34\x78\x76\x98\xfe\xb7\xfa\x64\x99\x60\xa9\xa1\x47\xb1\x71\x34\xab\x9b\x6e\x5f\xcd\x90\x5d\xbd\x8b\x24\x50\xaf\x87\x37\xa1\xac\xa4\x69\x82\xf6\xa7\x6c\xbf\x8f\x30\x04\xea\x39\x52\x84\x2c\xde\xda\x04\xab\xa8\x3e\x27\x63\xdf\x82\xaa\x69\x6d\x31\x59\x94\xf4\xfa\xef\x4d\x53\x26\x9b\x35\x42\x4f\x18\x73\xa0\xb8\x12\xff\x73\x47\xf0\x17\x0e\xf6\xf2\x27\x14\x34\x62\xc6\x4e\xb3\x2f\x03\xaf\xe1\xd0\x97\x72\x6b\xd3\xdc\x2e\x46\xd6\x26\xeb\x30\x42\x45\x24\x25\x40\x4f\x26\xb0\xd5\x58\xa4\xd4\x9b\x16\xd2\x3a\xdc\x2d\x99\x79\x18\x5f\xbf\xdd\x61\xbe\x1e\xd4\x82\xb0\xc9\x46\xc2\x97\x89\x17\x8d\x7a\xaf\xc7\x6d\x2a\x96\x2d\x89\x65\x75\x9a\x6e\xc6\xaa\x6f\x20\x76\x1a\x00\xf6\x77\xb7\x87\xe7\x42\x04\x6b\x7d\x6f\x1a\x30\xe0\x40\x61\xad\xff\xd9\xd1\x47\x71\x61\x1c\x12\x90\xc9\x74\x50\x61\x77\xc6\x04\xb3\xc1\x36\xae\x2c\xd9\x33\x1e\x16\xcd\x67\x87\xfe\x2c\xea\x88\x0d\xb5\xd9\x76\x0e\x9b\x1b\x15\x46\x3a\x77\xf7\x33\xfd\x92\x39\xd0\xba\xa2\x58\xaa\x91\x5b\x13\x11\xb9\x9d\x3b\x2b\x07\xfa\x60\x8f\x56\x2e\x4a\x04\xd8\x06\xa2\xf7\xa1\x3f\xa8\xc4\xec\x53\x69\x21\x1b\xe1\xac\xc1\xc8\x8a\x6d\x39\x0b\x15\x35\x56\x85\x36\xa0\xd8\x00\xd8\xb8\xdc\xc8\x31\x85\x84\x2c\x17\xeb\x3a\xa3\xfb\xf8\xea\x68\xb0\xe7\x0d\x9e\x5f\xa4\xa6\xb0\x18\xf0\xd4\x72\xe2\xde\x0a\xe3\x54\x6a\x2e\x87\x6f\x2e\xdb\xb5\x75\xc4\xc4\xd2\x82\xa8\x68\x91\x94\x7a\x0f\x8f\xa7\xf5\x60\x9e\xf9\x6f\xa0\x7d\x6a\x00\x21\x50\x9b\x3d\x26\x0d\x23\x60\x4b\xe2\x46\x08\xf4\x4b\xa6\x98\xe2\x99\x13\x8c\x65\x86\x1b\x63\x51\x00\x73\xcd\xc9\xc4\x67\x5a\x02\xb9\xdd\xac\x03\xab\xf1\xad\x10\x99\x51\x36\x60\xe7\xf0\xac\x8a\x5b\xfb\x3f\xcc\xe2\x55\x96\x2a\xa8\x6c\x81\x8b\x57\x67\x5e\xe1\x40\x92\x8c\xc6\x1a\x96\xbd\xb6\xe3\x61\x29\xe2\x93\x40\xcc\xae\x03\x67\x44\xb9\x59\x20\x9a\x76\xbb\x39\xa7\x22\xef\x81\x1c\xd9\x6a\x30\x04\x43\xf9\x18\x38\xa5\xcd\x0c\x98\xc5\xdc\x64\x25\x75\xc4\x01\xba\xa9\xfb\xfb\xb9\xfb\x67\x2a\x6c\xd2\xb8\x08\xcc\xa4\xcc\x90\x3a\x0e\x75\x09\x99\x29\xee\x27\x16\x7c\xe4\xe8\x78\xf0\x2c\xa6\x47\x4e\x25\x5a\x7c\xe2\x28\x5e\xcf\x10\xf1\xd9\x6a\xd0\x29\x1c\x58\xbd\x51\x62\x2a\xea\xea\xd1\x14\x62\x5e\x9c\xcb\x06\xd1\xa0\x9b\x38\xb0\x98\xb7\x9c\x07\x8d\x91\x6d\x7b\x9e\xea\x8b\xee\xb4\x28\xcc\xbe\xfe\x04\x31\xc7\xd3\x4f\x8f\x40\xe8\x3a\xab\x79\xda\x6c\xe5\xc5\x78\x0a\xdc\x5a\x11\x50\xce\x91\xdf\xc3\x93\xd8\x36\xc7\x65\xf8\x4d\xa1\x1a\x6b\xa7\x5b\xb6\xcb\xd3\xdc\x5b\x89\x42\xcf\xb2\x0a\x42\xa0\x81\xd0\xbc\x66\xe1\xaf\x9b\x30\x7a\xa3\x8f\x3a\x65\xb6\xb9\xbf\x09\x7f\xfb\xf3\x81\xe3\xb2\x91\xd3\xc1\x80\x6d\x12\x85\x22\x8e\x5b\xdf\xef\x94\x77\xb7\xdb\x01\xfc\x77\x00\x99\xc5\x05\x3b\x45\x0e\x00\x00")
 
 func complySoc2PoliciesConfidentialityMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -641,12 +662,12 @@ func complySoc2PoliciesConfidentialityMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/confidentiality.md", size: 3653, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/confidentiality.md", size: 3653, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesContinuityMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x58\xcd\x72\xdc\xb8\x11\xbe\xcf\x53\x74\xd5\x5e\xbc\xae\xd1\x6c\x9c\x53\xa2\x9b\x2d\xe7\xb0\xc9\x61\x55\xb2\xaa\xf6\xdc\x03\x34\x87\xbd\x06\x01\xa6\x01\xcc\x84\x3e\xe5\x35\xf2\x7a\x79\x92\x54\x03\x20\x87\x1c\x49\xeb\xac\x2f\x92\x45\xb0\x7f\xbf\xef\xeb\x06\x3d\x0e\x74\x0f\x9f\x72\x64\x4f\x31\xc2\x43\xf0\x89\x7d\xe6\x34\xc1\x63\x70\x6c\xa6\x1d\x1a\x09\x7e\x1a\xee\xe1\xd3\xc3\xe3\x2e\x62\xe2\xd8\x31\xc5\xfb\x1d\xc0\xf3\x97\x07\xfd\x01\x70\x07\x0f\x0f\x7f\x3d\x7c\xd8\x0d\xf8\x5b\x90\x27\x3a\x73\xe4\xe0\xcb\x91\x3b\xb0\x98\xe8\x1e\xfe\x9e\x3d\x7c\x80\x3f\xff\xe9\xc3\x5f\xca\x0b\x26\x0c\x03\xf9\x74\x0f\x3f\x7b\x4e\x8c\x0e\x6c\x30\x59\xff\xb2\xbb\xbb\xbb\xdb\xed\x76\x3f\xc0\x63\x96\x31\x44\x02\xf4\x16\xbe\x98\x30\xd2\x6e\x87\x07\x80\xe7\x9e\x60\x6c\x8f\x42\x07\xa9\xe7\x08\x63\x89\x13\x38\x42\x0a\x40\x3e\x66\x21\x48\x3d\x26\x48\x3d\x41\x90\x13\x7a\xfe\x86\x89\x83\x07\x8a\x09\x8f\x8e\x63\x4f\x11\xc2\xf1\x37\x32\x89\xcf\x14\xf7\x30\x3a\xf4\x51\x5d\xed\x61\x94\x60\xc8\x66\xa1\x08\x31\x9b\xbe\x1a\x42\x28\x99\x81\xe5\x28\x79\x2c\xa6\x52\x78\x61\xfe\xbf\xff\xfe\x4f\x84\xaf\x34\xc1\x71\x2e\x26\xaa\x03\x4e\x4c\x51\xa3\x1b\xd8\xf3\xc0\xdf\xc8\x1e\x00\x4a\x36\xcf\xab\xe8\x71\x1c\x9d\x9e\x4b\x01\xd0\x39\x60\xdf\x09\xc6\x24\xd9\x24\x4d\x47\xab\x60\x31\x21\x5c\x38\xf5\xec\x5f\x77\xcd\xbe\x0b\x32\xd4\x4c\x23\x99\x2c\xda\xc4\x51\xc2\x49\x70\x38\x7c\xcf\xe1\x80\x1e\x4f\xa4\x2d\xd8\x03\x0d\xa3\x0b\x13\x69\x65\xd4\x71\xcc\xe5\xa8\xc4\x56\x0c\x21\x60\x7f\x0e\xee\x4c\x16\xd8\x83\x25\x53\xfb\x5d\x0e\x97\xfa\xc5\x48\x11\xb0\xeb\xb4\xc0\xfe\xf4\x7a\xb4\x4b\x91\xcc\x82\xb8\x6d\x80\x43\x8e\x09\x8e\x04\x03\x5a\x02\x21\xb4\xec\x26\xc0\x33\xb2\xc3\xa3\xa3\x39\xee\x4b\x1f\x06\xe0\xb4\x4a\xe7\xa0\xf0\xf9\x84\xe6\xeb\x49\x42\xf6\xf6\x8a\x9b\x98\x8d\x86\x56\x71\x73\x83\x0c\x8e\x20\xe4\x18\x7d\x82\x3c\x86\x5a\xe0\x51\x28\x92\x9c\xeb\x81\xd0\x81\x11\x4e\x6c\xd0\x5d\x43\x0f\x23\x49\x79\x5c\x73\xd7\xbc\x7d\x81\x73\x97\xbd\xa9\x7f\xcf\x91\xac\xc6\x6a\xc9\xf1\x99\xa4\xe0\x63\x94\x60\xb3\x49\xf5\x25\x75\xc1\x86\xe2\xe1\xfb\xd8\xb6\xd4\xb1\xa7\x12\x9b\xc6\x42\xc2\x08\x5d\x90\xa5\x82\xfe\xf4\x6a\x68\x7a\xe4\x65\xc2\x35\x47\x3a\x93\x4f\xea\x0d\x57\xe0\x3e\xc0\x97\x91\x0c\x77\x9a\xac\x9b\xf6\x35\x92\x99\xa1\x2d\x8a\x78\xbf\x2b\x4c\xe6\x1a\xf7\x16\xaa\x98\x53\x1f\x0a\xfc\xae\x8c\x5c\x22\x13\x8a\xec\x98\xbc\x29\x59\xb6\x7a\xcc\x98\xd1\x82\x4c\x31\xd1\x10\x0f\x1b\xfb\x42\xff\xcc\x2c\x05\x9f\x35\x21\xea\xba\x20\xa9\x94\xa5\x42\x17\x52\x2f\x21\x9f\xfa\x9a\x09\xc6\x44\x02\x41\x20\xa4\x9e\xae\xc4\x3d\xcf\x19\x5f\x7a\xaa\x05\xf0\x44\x16\x50\x38\x6a\x07\x36\x2e\x97\x12\x6b\x0e\x5d\xc7\x86\xc9\x27\x85\xa0\x76\xba\x62\xfb\x4c\x6e\xd2\x7c\xf2\x40\xe0\x95\x7b\x6f\x60\xa3\xd3\x60\x36\x15\xae\xa8\xfc\x75\x66\xf3\xaa\xbe\xfb\x12\x56\x17\x9c\x0b\x17\xed\x68\x29\x37\x37\x43\xe3\xe8\xa6\xfb\x6b\x98\xef\x17\xd9\xe6\x61\x44\x93\x00\x3d\xba\x29\x72\xfc\x09\xb5\x9c\x51\xed\xbd\x87\x3b\x40\x0f\xf4\x2f\x12\xc3\xb1\x29\xa3\xa5\x44\x32\xe8\xab\xc5\x5b\x7b\x3b\x74\xe0\x42\x9c\x19\xab\xb4\x0f\x52\xc1\xe1\x4b\x9a\x21\x8b\xa9\xc4\xf3\x40\x3e\x91\x8c\x5a\xb7\xfd\x46\x56\x0b\xa8\xa2\x41\xb7\xd0\xa6\x38\x74\x41\x4b\xa2\x04\x48\x3c\xd0\x1e\xd8\x2a\x53\x3a\x6e\x6f\x14\x69\xcc\xc3\xe2\x24\x96\xbe\x54\xe6\x08\xa5\x2c\x45\x70\x71\x2e\xb2\xa3\x33\x39\xb5\xbd\x14\x79\xdf\xc4\x87\x15\x76\xfc\x8d\x14\x66\x46\xfd\x4d\x7a\x6c\x0b\xb0\x55\x72\x9a\x6b\xc5\xdb\xaa\xf9\xef\x3f\xcf\xf8\x59\x6c\xe8\x80\x28\x85\x84\x48\xa5\x22\x7d\x1e\xd0\xef\x61\xec\xa7\xa8\x2c\xd9\x43\x22\xd3\xfb\xfa\xeb\x22\x83\x36\x0b\xba\x55\x4e\xff\x7f\x32\x4d\xe6\xb1\x91\xcd\x96\xaa\x15\xc3\x26\xc4\xb4\xaf\xf0\x45\x3f\x0f\x98\x22\x10\xac\x1d\x51\x7c\x91\x85\xe3\x54\x7a\x34\x90\x9c\xc8\x9b\x09\xea\xec\x2a\x49\x1d\xa0\xe4\xb9\x24\xfb\x34\xe7\x58\x5c\x2c\x53\x51\xb3\xd5\x42\xe1\x10\x72\x55\x88\x1a\x82\xc2\x92\xec\xa2\x29\xeb\x2a\xe3\x15\xfc\xb3\xfe\xa9\xe3\x0d\x70\xb6\x49\xaf\x88\xb1\x10\x76\x35\x63\x83\x31\x59\xe2\x9b\x01\x8f\x81\x35\xb2\x75\xc4\x0b\xae\x2d\x1c\x51\x95\xb7\x49\x39\x1a\x43\x63\x2a\x73\xa3\x8c\xd1\x02\xc7\x26\x81\x06\xab\xde\xae\x1d\xaf\x9a\x51\x64\xe1\x87\x79\x19\x2a\xb4\xbd\xd2\xee\x89\xe3\x57\xf8\xb8\x70\xad\x74\x68\x79\xf8\x73\x65\xd5\xc7\xc6\xc9\xf7\x0b\xc0\xfe\x86\xa6\x6f\xaa\x25\x75\xf2\x14\x71\x2b\x68\x1f\x49\x74\x8a\xaf\x8b\x29\xea\x04\xb7\x4e\x8e\x6f\x10\xbf\x6a\xa3\xda\xdf\x2c\x22\x15\xe3\xab\xed\x81\x45\xe7\x38\x6a\xa6\x42\x71\x0c\x3e\xf2\x91\x5d\x99\xc1\x1b\x11\x7c\x33\x88\x32\x9d\x1b\x8b\xab\x26\xde\x4c\x27\xe5\x82\x22\xb3\x69\xfc\x4d\x28\x57\x26\x0a\x8d\x21\x72\x0a\x52\xb5\x00\x53\x19\x67\xd8\x9a\x23\xe4\xe8\x8c\x2d\x69\x4f\xca\x62\x94\xa9\x36\x71\xc6\xe0\x2b\xe6\xff\x50\x16\x2d\xf0\x92\xc3\x3c\xe4\xd4\xee\x22\x03\x0b\xe2\x1e\x1d\x7a\x78\xf7\xf9\xe9\xf1\xc7\xd9\xf9\xef\xd4\xb1\xb6\xf9\xf3\xd3\x23\xc4\xbe\xae\x74\xc6\x65\x4b\xf7\x57\x34\xeb\xbf\x0f\x07\xf8\xc7\x3a\xfe\x45\xaa\x0e\xcb\x91\xf5\xd9\x8f\xba\xe6\x98\x02\xe4\x92\x8b\x72\xaa\xae\x44\xd5\xeb\x6e\x7d\xf8\x71\x96\xc3\x45\x8b\x67\xba\x6e\xcf\x2d\xf9\x3d\x2b\xc5\x7f\x59\x16\x63\x78\xf7\xf4\xfc\x4b\xfc\xf1\x8d\xc3\x8f\x85\x7e\x9b\xd3\x8f\xf5\x74\xe1\xc8\xeb\xd5\xbb\x21\xc1\x6b\x20\x2d\x3d\xe9\xf1\x4c\x2a\x0c\xad\x1f\x64\x4b\x21\x95\x1f\x12\xce\x6c\x09\x4e\x99\x2d\xea\x0e\x51\xa4\xb0\x47\xb1\x17\x14\xda\x43\x0c\x5d\xaa\xbf\x05\x01\x4f\xe9\x12\xe4\x6b\x84\x23\x99\x30\x5c\x71\xe9\x26\xb0\x53\x9c\x45\x0a\x9d\x9e\x35\xa4\x3a\x90\xc2\x55\xbb\xde\xc5\x5e\xe7\x9f\xe2\xc2\x05\x1d\x8a\x24\x03\x84\x9c\xf0\x44\x4b\x51\xe6\x44\x34\xba\x4a\x89\xda\xe5\x3a\x72\x75\x6c\xac\xe6\xa0\xee\xb2\x27\xcf\x49\x9f\x87\x6e\xb3\xaf\x07\x99\xd3\xcf\x7e\xdd\xd0\x97\x7b\x9a\x6f\x21\x2c\x04\x6a\x88\xa9\xdc\xb9\x84\xec\xac\x6e\xcd\x3c\x8c\x8e\x5a\xe9\x52\x98\x57\x44\x7a\x75\x3f\xb1\x59\x96\x35\xbd\xd8\x3e\xc0\xaf\x3d\x09\x41\x47\x18\xf9\xe8\xa8\xee\x24\x4b\x8e\x46\x61\x6e\xa9\xb2\x2f\x57\xf5\x44\x97\x48\x34\xd9\xb3\x96\xbe\xeb\xee\x22\x27\xd2\x7b\xde\x98\x8b\xb0\x5f\xbd\xbd\x33\xc1\xd9\x3d\x5c\x50\x86\x3d\xf4\x21\x81\x9e\x7c\x51\x50\x2d\xdd\x72\x05\x10\x3a\x33\xe9\xd8\xc1\x13\xb2\x8f\x2f\x6f\x76\xe5\x46\x11\x93\x60\xa2\xd3\xb4\xdf\xdc\xed\x4c\x76\xba\x97\xd6\xb1\x4c\xa9\x67\xa3\xd7\x9a\x08\x17\x72\x4e\x7f\xd6\x0d\x7b\x0f\x8e\x4e\x3a\xbc\x63\xc2\x94\x53\x90\xaa\x69\x42\xa7\xec\xb0\xfc\x77\xbd\x81\x7e\xa7\xf9\xf7\x1b\xc2\x7c\x5c\x0f\xe2\x21\x68\xef\xaf\xc5\x28\x69\xde\xec\xf0\xab\xc7\xb7\xdd\x4f\x34\x8c\x41\x54\x01\x7f\x1f\xf1\x33\x4c\xb7\xd4\xfd\xb8\x5d\x68\x8a\xdf\xba\x90\x6c\xae\x0e\xd7\xcb\xcb\xfa\x7e\xa2\x28\x6a\xc3\x3b\xf8\xda\xdd\xed\x8c\xdc\x08\xcf\x72\x89\x56\x1f\x23\x09\x07\xcb\x06\x12\x45\x5d\xb9\xf6\xad\xa1\xfb\x56\xe3\xf6\xbd\x60\x26\x89\x96\x53\x5f\x53\xd1\xac\x97\x48\xb2\x2f\xe6\x47\xe9\x21\x82\xde\xf1\x46\x35\xf3\x53\x10\x60\x6f\xf9\xcc\x36\x2b\xc1\x17\x19\xd2\x49\xa1\xb7\xc1\x7a\x0c\x9e\x28\xa6\x50\xa3\x2e\x82\x74\x3b\x96\x1b\x0b\xc3\xc5\x93\xb4\xa6\xce\x4c\x52\x3d\x52\x6b\xc7\xab\x35\x59\x59\xd3\x92\xae\xe6\x4e\xb1\xf6\xbd\xf3\xdb\x31\xba\xc5\x8d\x0e\xae\xf2\xbe\xc9\x31\x05\xcb\xad\x61\x65\x7b\xad\x43\x6e\xf7\xe2\x7c\x73\x15\x4d\x4f\x36\xbb\x42\x4c\xba\x66\x75\xf3\x42\xe5\x78\x7b\x65\x20\xcb\xd8\xee\x99\x47\xbd\xcf\x05\x5d\x48\x2a\x00\x4c\x16\xb2\x1b\xd2\xf4\xe1\x52\x76\xaa\x58\x3f\x6c\x20\x97\x89\x4d\xf6\x70\xe3\x40\x2f\x67\x4a\x9d\x21\x9c\x6f\x3c\x15\xf5\x12\xf4\xb1\xd3\x1d\x28\xa9\xdb\x52\x9a\x13\xdd\x60\x76\x1c\x25\x8c\xc2\x98\x68\x5b\xbd\x2b\xc4\xca\x5a\x5d\x02\x7e\x75\x9e\xd4\x5d\x41\xc2\xb0\x0d\xa0\x7d\xc0\x99\x2b\x03\xb7\xa5\x5c\x3b\x6b\xb8\xad\x2d\xd3\xc8\x3b\x55\x83\xba\x59\x77\xcb\xd3\x2a\xb3\x1d\xcb\x50\x49\x3b\xdf\x11\xfd\xea\xa3\xc3\x1a\x24\x2b\x67\x03\xa5\x3e\xd8\x46\x48\xf5\xab\xf6\xc8\x1b\x99\xea\x3a\xbf\x0a\xfc\x00\xbb\xff\x05\x00\x00\xff\xff\x73\xec\xc5\x55\xb3\x13\x00\x00")
+var _complySoc2PoliciesContinuityMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x58\xcf\x92\xdb\xc8\xed\xbe\xeb\x29\x50\xf5\xbb\x78\x5d\x1a\xed\xcf\x39\x25\x73\xf3\x9f\x1c\x36\x39\xec\xd4\x78\xaa\x7c\x86\xba\x41\x11\xeb\x66\x83\x41\x77\x4b\x2b\x9f\xf2\x1a\x79\xbd\x3c\x49\x0a\xdd\x24\x45\xce\x8c\xd7\x49\x95\xab\x2c\x0d\x9b\x0d\xe0\xc3\xf7\xe1\x8f\x22\x0e\x74\x0f\x1f\x4a\xe2\x48\x29\xc1\x47\x89\x99\x63\xe1\x7c\x85\x07\x09\xec\xae\x3b\x74\x2a\xf1\x3a\xdc\xc3\x87\x8f\x0f\xbb\x84\x99\x53\xc7\x94\xee\x77\x00\x4f\x9f\x3f\xda\x7f\x00\x77\xf0\xf1\xe3\x5f\x0e\xef\x76\x03\xfe\x26\xfa\x48\x67\x4e\x2c\xb1\x1e\xb9\x03\x8f\x99\xee\xe1\x6f\x25\xc2\x3b\xf8\xd3\xff\xbf\xfb\x73\x7d\xc1\xc9\x30\x50\xcc\xf7\xf0\x4b\xe4\xcc\x18\xc0\x8b\x2b\xf6\x97\xdd\xdd\xdd\xdd\x6e\xb7\xfb\x3f\x78\x28\x3a\x4a\x22\xc0\xe8\xe1\xb3\x93\x91\x76\x3b\x3c\x00\x3c\xf5\x04\xe3\xf4\x48\x3a\xc8\x3d\x27\x18\xab\x9f\xc0\x09\xb2\x00\xc5\x54\x94\x20\xf7\x98\x21\xf7\x04\xa2\x27\x8c\xfc\x0d\x33\x4b\x04\x4a\x19\x8f\x81\x53\x4f\x09\xe4\xf8\x1b\xb9\xcc\x67\x4a\x7b\x18\x03\xc6\x64\xa6\xf6\x30\xaa\x38\xf2\x45\x29\x41\x2a\xae\x6f\x17\x21\xd4\xc8\xc0\x73\xd2\x32\xd6\xab\xb2\xbc\xb8\xfe\xdf\xff\xfc\x57\x82\xaf\x74\x85\xe3\x0c\x26\x9a\x01\xce\x4c\xc9\xbc\x1b\x38\xf2\xc0\xdf\xc8\x1f\x00\x6a\x34\x4f\x2b\xef\x71\x1c\x83\x9d\xcb\x02\x18\x02\x70\xec\x14\x53\xd6\xe2\xb2\x85\x63\x28\x78\xcc\x08\x17\xce\x3d\xc7\xd7\x4d\x73\xec\x44\x87\xfa\x1d\x12\xb9\xa2\x96\xc4\x51\xe5\xa4\x38\x1c\x7e\x64\x70\xc0\x88\x27\xb2\x14\xec\x81\x86\x31\xc8\x95\x0c\x19\x33\x9c\x4a\x3d\xaa\x69\x02\x43\x09\x38\x9e\x25\x9c\xc9\x03\x47\xf0\xe4\x5a\xbe\x0d\xc0\x86\x5f\x4a\x94\x00\xbb\xce\x00\x8e\xa7\xd7\xbd\x5d\x40\x72\x0b\xe3\xb6\x0e\x0e\x25\x65\x38\x12\x0c\xe8\x09\x94\xd0\x73\xb8\x02\x9e\x91\x03\x1e\x03\xcd\x7e\x5f\x7a\x19\x80\xf3\x2a\x9c\x83\xd1\xe7\x03\xba\xaf\x27\x95\x12\xfd\x8d\x37\xa9\x38\x73\x0d\xa4\x7b\xe1\x91\xa5\x47\x29\x30\xc6\x0c\x65\xb4\xf4\x1a\xcf\x94\x12\xe9\xb9\x1d\x90\x0e\x9c\x72\x66\x87\xe1\xe6\xba\x8c\xa4\xf5\x71\x25\x0f\x50\x4a\x14\x2b\x9d\xbb\x12\x5d\xfb\x7b\x49\xe4\x21\x0b\x78\x0a\x7c\x26\xad\xfc\x18\x55\x7c\x71\xb9\xbd\x64\x26\xd8\x51\x3a\xfc\x98\xdb\x9e\x3a\x8e\xc6\x6d\xaa\xbe\x90\x32\x42\x27\xba\x20\x18\x4f\xaf\xba\x66\x47\x5e\x06\xdc\x62\xa4\x33\xc5\x6c\x88\xe0\x8a\xdc\x07\xf8\x3c\x92\xe3\xce\x82\x0d\xd7\x7d\xf3\x64\x56\xe8\xe4\x45\xba\xdf\x55\x25\x73\xf3\x7b\x4b\x55\x2c\xb9\x97\x4a\xbf\x9b\x22\x17\xcf\x94\x12\x07\xa6\xe8\x6a\x94\x13\x1e\x33\x67\x8c\x6e\xd7\x94\x69\x48\x87\xcd\xfd\x4a\xff\x28\xac\x95\x9f\xa9\xc6\x4c\x5d\x27\x9a\x2b\x2c\x8d\xba\x90\x7b\x95\x72\xea\x5b\x24\x98\x32\x29\x88\x82\xe4\x9e\x74\x89\xed\x3c\x47\x7c\xe9\xa9\x01\x10\x89\x3c\xa0\x72\xb2\x0c\x6c\x4c\x2e\x10\x5b\x0c\x5d\xc7\x8e\x29\x66\xa3\x60\xf4\xf6\xdd\xb8\x7d\xa6\x70\x05\xa5\x54\x06\x82\x68\xda\xfb\x0e\x37\x3a\x73\x66\x83\x70\x63\xe5\x97\x59\xcd\x2b\x7c\x0d\x6e\x82\x4e\x42\x90\x0b\xc7\x53\x83\x9b\xa7\x8b\xc6\x31\x5c\xef\x6f\x6e\xbe\x5d\xca\x36\x0f\x23\xba\x0c\x18\x31\x5c\x13\xa7\x9f\xd1\xe0\x4c\x06\xd7\x5b\xb8\x03\x8c\x40\xbf\x93\x3a\x4e\x53\x65\xf4\x94\x49\x07\x63\x4a\xb5\x36\xbd\x2d\x1d\x04\x49\xb3\x62\x4d\xf6\xa2\x8d\x1c\xb1\x86\x29\x45\x5d\x13\x5e\x04\x8a\x99\x74\x34\xdc\xf6\x9b\xb2\x6a\x6f\x52\x72\x18\x16\xd9\x54\x83\x41\x0c\x12\x13\x40\xe6\x81\xf6\xc0\xde\x94\x62\x8d\xa4\x3a\x50\x4b\x63\x19\x16\x23\xa9\xe6\xa5\x29\x47\x29\x17\x8d\xf6\x09\x67\x90\x03\x9d\x29\x98\x63\x0b\xc8\xfb\xa9\xf8\xb0\xd1\x8e\xbf\x91\xc9\xd9\xc9\x99\xf4\x6a\xc7\xb6\x04\x5b\x05\x67\xb1\x36\xbe\xad\x92\xff\xf6\xd3\xcc\x9f\xe5\x0e\x6b\x10\x15\x48\x48\x54\x11\xe9\xcb\x80\x71\x0f\x63\x7f\x4d\xa6\x92\x3d\x64\x72\x7d\x6c\x1f\x97\x32\xe8\x8b\x62\x58\xc5\xf4\xdf\x07\x33\x95\x79\x9c\xc4\xe6\x2b\x6a\x35\x44\x27\x29\xef\x1b\x7d\x31\xce\x0d\xa6\x16\x08\xb6\x8c\x18\xcb\xc9\xc3\xd1\x68\x0a\x34\x90\x9e\x28\xba\xab\x09\x61\x16\xc5\x01\x2a\x7b\x96\x60\x1f\xe7\x18\xab\x89\xa5\x2b\x5a\xb4\x06\x14\x0e\x52\x5a\x85\xa8\xcf\xd1\x68\x49\x7e\xa9\x29\x6b\x94\xf1\x46\xfe\xb9\xfe\x99\xe1\x39\xfe\x57\x82\x5e\x09\x63\x11\xec\x4d\x24\x20\xce\x15\x4d\xdf\x75\x78\x14\x8e\x79\xeb\xf1\xc2\x6b\x0f\x47\xb4\xca\x3b\x95\x72\x74\x8e\x46\xeb\xfd\x64\xf3\x08\x1a\xcd\xd3\x5c\x02\x1d\xb6\x59\x62\x6d\x78\x95\x0c\xb3\x6f\xf3\x48\x6d\x4a\x4d\xb6\x37\xd9\x3d\x72\xfa\x0a\xef\x17\xad\xd5\x0c\x2d\x0f\x7f\x69\xaa\x7a\x3f\x69\xf2\xed\x42\xb0\xbf\xa2\xeb\xa7\x86\xab\x96\xb9\xa9\xb8\x55\xb6\x8f\xa4\xd6\xc5\xd7\x60\xaa\x19\xb9\x09\xba\x1a\x39\x7e\x47\xf8\x35\x31\x64\xf7\x6f\x06\x91\xc6\xf1\xd5\xf4\xc0\x0a\xa8\x84\x16\xa9\x52\x1a\x25\x26\x3e\x72\xa8\x3d\x78\x71\xd3\xea\xfa\x77\x9d\xa8\xdd\x79\x52\xb1\x91\xcd\x3f\xef\x4e\xa6\x05\x63\xa6\x74\xaf\xb9\x72\x53\xa2\xd2\x28\x89\xb3\x68\xab\x05\x98\x6b\x3b\xc3\x29\x39\x4a\x81\xce\x38\x05\x1d\xc9\xda\x04\xea\xb5\x25\x71\xe6\xe0\x2b\xd7\xff\x4f\x51\x4c\x8e\x9b\x85\xa5\xc9\xd9\xbd\x4b\x19\x58\x18\xf7\x10\x30\xc2\x9b\x4f\x8f\x0f\x3f\xcd\xc6\xff\x00\xc7\x96\xe6\x4f\x8f\x0f\x90\x7a\x9b\x54\x38\xba\x50\x3c\xdd\xdf\xd8\x6c\xff\xde\x1d\xe0\xef\x6b\xff\x97\x52\x75\x58\x8e\xac\xcf\xbe\xb7\x31\xc7\x55\x22\xd7\x58\x4c\x53\x6d\x24\x6a\x56\x77\xeb\xc3\x0f\x73\x39\x5c\x6a\xf1\x2c\xd7\xed\xb9\x25\xbe\x27\x93\xf8\xaf\xb3\xa0\x12\xbc\x79\x7c\xfa\x35\xfd\xf4\x9d\xc3\x0f\x55\x7e\x9b\xd3\x0f\xed\x74\xd5\xc8\xeb\xe8\x3d\x13\xc1\x2b\xa9\x6b\x39\xe9\xf1\x4c\x80\x4b\x3e\xc8\x57\x20\x4d\x1f\x2a\x67\xf6\x04\xa7\xc2\x1e\x6d\x86\xa8\xa5\xb0\x47\xf5\x17\x54\xda\x43\x92\x2e\xb7\x4f\xa2\x10\x29\x5f\x44\xbf\x26\x38\x92\x93\xe1\xc6\xcb\x70\x05\x7f\x4d\x73\x91\xc2\x60\x65\xca\x91\xd5\x81\x2c\xb7\xda\xf5\x26\xf5\xd6\xff\x8c\x17\x41\xac\x29\x92\x0e\x20\x25\xe3\x89\x16\x50\xe6\x40\xcc\xbb\xea\xf8\x94\xe5\x5a\x7e\x7f\xb7\xb6\xb1\xea\x83\x36\xcb\x9e\x22\x67\x7b\x2e\xdd\x66\x5e\x17\x9d\xc3\x2f\x71\x9d\xd0\x97\x73\x5a\x9c\x5c\x58\x04\x34\x31\xa6\x35\xf6\x8b\x94\xe0\x6d\x6a\xe6\x61\x0c\x34\x41\x97\x65\x1e\x11\x57\x52\xb8\x15\x38\xf0\x45\x97\x31\xbd\xde\x7d\x80\x2f\x3d\x29\x41\x47\x98\xf8\x18\xa8\xcd\x24\x4b\x8c\xce\xca\x85\xb7\x5e\xde\x13\x94\x56\x3d\x31\x64\xd2\x88\xc6\x9b\x3d\x48\xd7\xdd\x25\xce\x04\x4e\x86\xb1\xd4\xc2\x7e\xb3\xf6\xc6\x49\xf0\x7b\xb8\xa0\x0e\x7b\xe8\x25\x83\x9d\x7c\x01\xa8\x41\xb7\xac\x00\x4a\x67\xa6\x8b\x0d\x6a\x27\xe4\x98\x5e\x6e\x76\x75\xf5\x4a\x59\x31\xd3\xe9\xba\xdf\xec\x76\xae\x04\x5b\xa1\x5a\x5b\xa6\xdc\xb3\xb3\xb5\x26\xc1\x85\x42\xb0\xff\xdb\x84\xbd\x87\x40\x27\x6b\xde\x29\x63\x2e\x59\xb4\xd5\x34\xa5\x53\x09\x58\xbf\xae\x27\xd0\x1f\x24\xff\x7e\x23\x98\xf7\xeb\x46\x3c\x88\xa7\x35\x18\x35\xcc\x67\x33\xfc\xea\xf1\xf3\xec\x67\x1a\x46\x51\xab\x80\x7f\xcc\xf8\x29\x8f\xe9\xb0\xf5\x64\x3b\xd0\xd4\x1a\xd6\x06\x92\xcd\xea\x30\x0b\x60\xbb\x9f\x58\x73\x9a\x9a\xb7\xc4\x96\xdd\x9b\xa3\x07\xd8\x18\x7a\xb8\x2d\xd1\x66\x63\x24\x65\xf1\xec\x20\x53\xb2\x85\x70\x3f\x25\x74\x3f\x61\x3c\xfd\x5e\x30\x8b\xc4\x78\x66\xaf\x59\xd1\x6c\x4b\x24\xf9\x17\xfd\xa3\xe6\x10\xc1\x76\xbc\xd1\xae\xf9\x59\x14\x38\x7a\x3e\xb3\x2f\x26\xf0\xa5\x0c\x59\xa7\xb0\x6d\xb0\x1d\x83\x47\x4a\x59\x1a\xfa\xb5\x20\xa5\x67\x15\x69\x52\xa1\x5c\x22\xe9\x94\xd4\x59\x49\x80\xad\xef\x1c\x6f\xb7\xe9\xea\x36\x83\x74\xd5\x77\xea\x6d\x3f\x3a\xbf\x6d\xa3\x5b\xde\x58\xfb\xad\xef\xbb\x92\xb2\x78\x9e\x12\x66\x08\x4d\x4d\x6e\xf7\xe2\xfc\x64\x2a\xb9\x9e\x7c\x09\x55\x98\x74\x8b\xea\xd9\x0b\x4d\xe3\xd3\x2b\x03\x79\xc6\x69\xcf\x3c\xda\x3e\x27\x6a\x7a\xb3\x7d\xcc\x7e\x4a\x20\xbf\x11\x4d\x2f\x97\x3a\x53\xd9\x00\x92\x60\x40\x8e\x19\x6d\xea\xda\xf2\xed\x4b\x2f\x30\xa0\xad\x0e\x83\x9c\x9f\x59\x32\x34\xb2\x62\x4c\x1d\xa9\xed\xef\x59\xaa\x4d\x2b\x3e\x9b\x3b\xde\x8f\xa3\xca\xa8\x8c\x99\xb6\xe8\xdd\x28\x56\xc7\x6a\x7b\xf9\xd5\x51\x60\x9a\x15\x54\x86\xad\x03\x59\x36\x50\x6e\x3a\xf2\x53\xbf\x35\x36\xf1\xb6\xfe\x4e\x54\x31\xe9\xac\x1a\xb4\xc9\xba\x5b\x9e\xb6\x32\xdb\xb1\x0e\xf5\xe6\x65\x47\x8c\xab\x1f\x1d\xd6\x24\x59\x19\x1b\x28\xf7\xe2\x27\x41\x9a\x5d\xbb\x8f\xa2\xd3\x6b\x1b\xe7\x57\x8e\x1f\x60\xf7\x9f\x01\x00\x73\xec\xc5\x55\xb3\x13\x00\x00")
 
 func complySoc2PoliciesContinuityMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -661,12 +682,12 @@ func complySoc2PoliciesContinuityMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/continuity.md", size: 5043, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/continuity.md", size: 5043, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesCyberMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x58\xcd\x6e\x23\xb9\x11\xbe\xeb\x29\x0a\xc8\x65\x07\x90\xb5\x33\x93\x1c\x12\xdf\xbc\x4e\x16\xd8\x5c\x32\xf0\x1a\xc8\x61\x30\x07\x8a\xac\x56\x57\xcc\x66\xf5\x14\x49\x69\x94\x53\x5e\x23\xaf\x97\x27\x09\x8a\x64\xff\x48\x96\x07\x49\x0e\x39\x19\x6e\x92\xc5\xfa\xf9\xea\xfb\x8a\x0a\x66\xc0\x7b\x78\x3c\xef\x51\xe0\x89\xe2\x0b\x3c\xc4\x88\x31\x0e\x18\x12\x7c\x62\x4f\xf6\xbc\x31\x56\x38\x9c\x87\x7b\x78\x7c\xfa\xb4\x89\x26\x51\xec\x08\xe3\xfd\x06\xe0\xf9\xd7\x47\xfd\x03\x70\x07\x8f\x8f\x7f\xd8\x7d\xd8\x0c\xe6\x6f\x2c\x4f\x78\xa4\x48\x1c\xca\x96\x3b\x70\x26\xe1\x3d\xfc\x39\x07\xf8\x00\x1f\xdf\x7f\xf8\x7d\x39\x60\x79\xd0\x2b\xee\xe1\x97\x40\x89\x8c\x07\xc7\x36\xeb\x97\xcd\xdd\xdd\xdd\x66\xf3\x1b\xf8\x94\x65\xe4\x88\x60\x82\x83\x5f\x2d\x8f\xb8\xd9\x98\x1d\x3c\xf7\x08\x63\x5b\xe1\x0e\x52\x4f\x11\xc6\xe2\x25\x50\x84\xc4\xe0\xb0\xa3\x80\x90\x74\x9f\xb0\x45\x97\x05\xcb\x82\x29\x61\x15\x73\x49\xd0\x24\xa0\xd0\xb1\x0c\x26\x11\x07\x88\x68\xb3\x50\x3a\x83\x50\x7c\x89\x70\xa2\xd4\x53\x28\x46\x58\x0e\x26\xd0\xdf\xcb\xb6\x6d\x3d\x7d\x71\x89\xb1\x16\xc7\x64\xf6\x1e\xc1\xe3\x11\xbd\x7a\xa5\x46\x80\x8f\x28\xc6\xfb\x5d\x71\xbb\x24\xd6\x2c\x89\x55\x3b\x65\x57\x71\xa5\x7e\x12\x04\x33\x8e\x9e\xb0\x5c\xa1\xb6\x31\x24\x12\x84\xa8\xd1\xd7\x68\x2f\xfd\xf9\xd7\x3f\xfe\x19\x6f\x87\x31\x0a\x1f\xc4\x0c\xb3\xc3\xc6\xfb\xcb\x8d\xe7\x98\x70\x88\x70\xea\xc9\xf6\xe5\xea\x1c\xd1\xbd\x15\x37\xb0\xb4\x9d\x96\xb3\x77\xd0\x9b\xa3\xd6\x05\x68\x18\x8d\x4d\xc0\xaf\x4f\xbc\xe9\xd9\xae\x55\x71\x29\x5b\x8d\x39\x4e\x5e\x0e\x26\x98\x03\xce\x49\xc2\x61\xf4\x7c\x46\x5d\xef\x4d\x82\x64\x5e\x10\x46\x23\x5a\xbd\xdb\x97\xca\x65\xa6\xe3\xe5\x65\x43\x8e\x09\xf6\x08\x83\x71\x08\x82\xc6\x91\x3f\x83\x39\x1a\xf2\xa5\x82\xcd\x87\x53\xcf\x03\x50\x5a\xb9\xb6\x53\x44\xfe\x64\xec\xcb\x41\x38\x07\xf7\x2a\x88\x0a\x88\x08\x06\x62\xc2\xf1\x6e\x7f\xbe\xd3\xbf\x15\x81\x31\x42\xc7\x02\x96\x83\xcb\x36\x51\x38\xbc\xf2\x71\x0b\x26\xc2\x09\xbd\xd7\xbf\x5a\xfb\x8a\x4f\xa7\xf5\xef\x14\x0f\x15\x95\x9d\xf0\x50\xd2\x7e\xb3\xe2\x28\x71\x44\x9b\xe8\x88\x57\xf9\xf5\x51\x01\x1b\xad\xd0\x1e\x23\xf4\x7c\xd2\x2b\x46\xc1\x51\xcb\xae\x39\xbc\xee\xfa\x27\x1c\x59\x12\x08\x7e\xcd\x24\xe8\xd4\xa9\x92\xf2\x86\xc0\x2b\xef\xa7\x20\x6b\x65\xff\xda\x63\x58\x87\x6a\xae\xb7\x6f\x5f\xa3\xab\x14\xa5\x45\x7b\x2e\x05\x58\x2f\x9b\xdb\xc8\xdd\xc1\x2f\xa9\x9e\x4c\x7a\xe5\xc5\xf1\xd4\x6b\x02\x6b\xaf\x1f\xb3\x0f\x28\x66\x4f\x9e\x92\xd6\xb2\x37\x47\xf5\x4b\x9b\x98\x0b\xde\x21\x66\xdb\xcf\x66\x6b\xc7\x88\x49\x35\x35\x11\x8f\x58\xd2\xcb\x5d\xdd\xf7\x3d\xd3\xc6\x5a\x16\xd7\xac\x1b\x4d\x71\x85\x45\xb1\xa7\x9f\xa3\x35\x1e\x77\x25\xd5\x2b\x0a\xe0\x53\x40\x89\x33\x34\xa7\x33\x8a\x19\x34\xb6\xaf\x9b\x28\xe1\x50\x33\xfc\x97\x60\xf1\x66\x1d\x28\x2a\xa9\x8e\x1e\x13\xba\x1b\x59\x8e\xbd\xa6\xc6\x61\x42\x19\x94\xbc\x1a\x10\x6a\xbf\x4d\xbc\xd7\x63\xc3\x44\x63\xaf\x66\xbf\x41\x70\x8a\x83\x22\x38\xc4\xa1\x42\x23\x31\x43\x4f\x87\xbe\xa1\x6e\xd5\xbf\x14\xe1\x25\xf0\x29\xe8\xae\x4b\xb2\x6b\x84\xb8\x90\x1f\x8f\xea\xa3\x52\x86\xf5\xd9\x21\xec\x73\x25\xc4\xc0\x09\x3c\x0d\x94\x2a\x29\x6a\x43\x9e\x4b\x26\x27\xd8\x5b\x0e\x49\xd8\xc7\x2d\x70\x4e\x91\xb3\xd8\xa9\xc1\xb6\x8d\x9a\x97\xff\x59\xc0\x51\xd4\x13\x14\x72\x29\x53\xe1\xef\x44\x47\xb5\x64\x62\x64\x4b\x26\x35\x1a\x9c\x53\x5c\xb3\xfe\x00\x23\x06\x4c\x52\x73\x99\x30\xa6\xb9\x60\x23\x8a\xc2\x13\x1d\xec\xcf\x60\x54\x92\xc4\x95\x86\x39\xab\xcb\x8a\x9f\xee\x3c\x49\x45\x16\x63\xcf\x6f\x35\x52\xe1\xbb\xae\xab\x4d\x1c\x94\x37\xb8\x03\x87\x85\x00\xaf\xe5\x22\x16\x46\xfa\x34\x29\x1c\x3c\x33\xfc\xe9\x1b\xda\x9c\xde\x6a\xe7\x12\xc5\x23\x87\x8e\x64\x98\xc8\x74\x91\x98\x5b\xae\x5c\xe9\xd3\xc4\x65\xbd\x89\xb0\x47\xed\x72\x23\xa2\xe4\xc4\x5a\xab\x35\xf4\x6f\x71\x4a\x9d\x24\x76\xb7\x35\xfc\xf5\xfd\xa7\x4a\x84\x17\x5d\x7d\x8b\x05\xf4\x30\xc9\x75\x27\x36\xd9\x6b\xad\x5a\x62\xad\xc2\x85\xdf\x46\xcf\x94\xae\xf7\x17\x97\x62\x11\x16\x33\x68\x83\x28\x1b\x0b\x42\x97\x25\xf5\x28\x80\x47\xe3\x73\x41\x06\xa9\x16\x3a\x14\x75\x0e\xa3\xca\x3e\xc5\x0a\x15\x2b\x94\xc8\x1a\xdf\xc8\x82\x82\xa3\x23\xb9\x6c\x7c\x6d\xad\xef\x47\xbe\x64\xb9\x05\xbe\x1a\x30\x6a\xa8\x26\x91\x85\x01\x4d\x28\xa0\x10\x74\xb9\x00\xbd\xea\x8a\x76\x80\x9f\x00\x5d\x3b\x79\x25\x1e\x4d\x29\xaf\x52\xdc\x60\xed\xfd\xcd\xda\xaf\xc6\x92\xda\x1f\xca\x6f\x25\x27\x33\xc5\xac\x87\x85\xff\x7d\x42\x59\xf2\x72\x0b\x02\xa4\x77\xe9\x7f\x4b\x18\x23\x0a\xb1\xab\x5a\xf8\xd9\x99\xf3\x8f\x03\x87\xd4\xff\x78\x46\x23\x5f\x34\x73\xd7\xdf\x56\xc6\x2f\xb3\xbc\xb6\xfd\x5f\x58\xfb\x99\x54\x91\xa4\xf4\x54\x4b\x49\xd3\x52\x07\x2e\x8b\x56\xe1\xb3\x0a\xb1\xa2\xb6\xfa\xfa\xe5\xed\x18\xbf\xd3\x66\xea\x62\xe5\xd2\xc2\x2b\x9f\x55\xdf\x39\x80\x60\x1c\x39\x44\xd2\x69\x45\x15\xa2\x6c\x59\x97\xfe\x86\x32\x7f\xa9\x84\x86\xdf\x46\x94\xa4\xeb\x14\x93\x51\x15\x19\x85\x8f\xe4\x2e\x2f\x28\x88\x1a\x46\x13\xce\xaf\xee\x5a\x4e\xb6\x90\xfe\x58\xe3\xbd\x71\xf5\xf6\xa2\xe8\x1a\x8b\x65\xef\xd1\x16\x1e\xef\x85\xf3\xa1\x87\xaf\x19\xa3\x2e\x07\x43\x3a\xa2\x6b\x2e\x28\x24\x94\x23\xe1\xa9\x8e\xe0\x17\x1e\x54\xff\xe2\x16\xf4\xc1\xe2\x1b\xa3\x16\x25\x6d\x02\xaa\xaf\x14\xe5\xcc\xcb\xd9\x21\x07\x4a\xeb\xee\x5b\xa5\xb7\x8d\x29\x55\xc6\x3a\xf6\x9e\x4f\xf1\x7e\x53\x5e\x27\x54\x5b\xe3\x16\xe5\x54\x6a\x51\xd6\x69\xf7\x16\x0c\x2c\xfd\xb6\x9b\x2d\x3c\x37\x02\xba\xda\xb0\x28\x7b\x71\xbf\x92\x95\x65\xa9\xc1\x16\x12\xbd\x9e\x2c\xde\xb4\x50\x39\x6e\x37\xdd\xf8\xb4\x9a\x27\xde\x3c\xd3\x44\xad\x1d\x79\xe4\x10\xf1\x6b\xc6\x60\x31\x4e\x1d\xec\xb9\x6a\x8f\x55\xb9\x28\x16\x0a\xaf\x6d\x4b\x7d\x0e\xa5\x7d\xd5\xe7\x36\x37\x53\xe1\xbc\x72\xdd\x42\x94\x39\xd6\xe9\x2f\x5a\x56\x22\xd5\xfe\x7a\xaf\x0d\xf5\x71\x5b\x2b\xfb\x1e\xf6\x38\x61\xc7\xf3\x49\xf5\xb4\x0d\x17\x6a\xf9\xe3\x6a\x55\x67\x8b\x65\x79\x9d\x5d\x04\x4f\x2f\xe8\xa9\x67\x76\xcb\xb3\x4b\x45\x56\x34\x1c\xf8\x81\x76\xb8\x5b\xa4\xae\xe6\x0a\x4e\xe4\xfd\x2c\x04\xfa\x7d\x9d\xec\xf3\xbb\x82\x8c\xff\x7b\x1c\x17\x2f\xc7\x82\x4d\xe3\x6d\xf6\xc5\x05\x9d\x2a\x5c\x81\x45\x1e\xab\xd4\x2c\x25\x2b\xb7\x2c\x59\x58\xac\x3e\xcd\xef\xd8\xd9\x79\xee\xe0\xb7\xda\xde\xbf\xab\xb5\x9a\x27\xc1\x32\x59\xed\x11\x72\x58\xbd\x65\x27\xd1\x6a\xe6\x7e\x9e\xc0\xf3\x6a\xd3\x76\x1a\xf1\xaf\x87\xb9\xa9\xc5\x22\x39\x14\x9d\x48\x0b\x64\xc6\x51\x78\x14\x1d\xb3\x6e\xcb\xc2\x34\xcf\x55\x1f\x23\x56\xce\x58\x1c\x79\xe8\x12\xca\xd5\xae\xf6\x6a\xde\x2a\x5d\xac\x24\xb7\x2d\xb6\x01\x76\xa1\x80\xda\xe9\x9a\xcf\xe9\x27\x87\xb6\xb5\x3c\x81\x59\xe0\x80\x8a\x87\xb4\x10\xba\x26\x7d\x96\x8b\xf6\x22\xee\xfe\x03\x32\xaf\x3f\x8d\x5c\x34\xe7\x6a\x24\x7a\x2e\x39\xfc\xe1\x61\x1c\x31\x38\xfa\x06\x0f\xef\xee\x97\x26\xb5\x3c\xec\x29\xcc\x77\x2d\xcf\x93\x57\x8f\x8e\x65\xd2\xd9\xd6\x9f\x43\x6a\x6d\x62\xcf\xa7\x58\xf1\x6d\x7c\xc6\xf9\x0d\xfa\x06\x72\x1a\x0b\x4d\xa8\x8b\xeb\xf1\x77\xed\xff\xf3\x5c\xe5\x6b\xf7\x7f\x7a\x77\x3f\x3f\x83\xcb\x18\xd0\x46\x7a\xbd\xf7\x32\x2d\xdb\x56\xd7\x16\xdb\x5c\xcc\xee\x7b\x20\xab\xac\xbb\xea\x94\x75\xad\x77\xff\x0e\x00\x00\xff\xff\xf5\x1c\xb7\x64\xc5\x12\x00\x00")
+var _complySoc2PoliciesCyberMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x58\xcd\x6e\x24\xb9\x0d\xbe\xf7\x53\x10\xc8\x65\x07\x68\xf7\xce\x4c\x72\x48\x7c\xf3\x3a\x59\x60\x73\xc9\xc0\x6b\x20\x87\xc1\x1c\xd4\x12\xab\x8b\xb1\x4a\xac\x11\x55\xdd\x53\x39\xe5\x35\xf2\x7a\x79\x92\x80\x92\xea\xa7\xcb\xed\x41\x92\x43\x4e\x86\x4b\x12\x45\x7e\xfc\xf8\x91\xea\x60\x3a\xbc\x87\xc7\xf1\x88\x11\x9e\x48\x5e\xe0\x41\x04\x45\x3a\x0c\x09\x3e\xb1\x27\x3b\xee\x8c\x8d\x1c\xc6\xee\x1e\x1e\x9f\x3e\xed\xc4\x24\x92\x86\x50\xee\x77\x00\xcf\xbf\x3e\xea\x1f\x80\x3b\x78\x7c\xfc\xc3\xe1\xc3\xae\x33\x7f\xe3\xf8\x84\x67\x12\xe2\x90\xb7\xdc\x81\x33\x09\xef\xe1\xcf\x43\x80\x0f\xf0\xf1\xfd\x87\xdf\xe7\x03\x96\x3b\xbd\xe2\x1e\x7e\x09\x94\xc8\x78\x70\x6c\x07\xfd\xb2\xbb\xbb\xbb\xdb\xed\x7e\x03\x9f\x86\xd8\xb3\x20\x98\xe0\xe0\x57\xcb\x3d\xee\x76\xe6\x00\xcf\x2d\x42\x5f\x57\xb8\x81\xd4\x92\x40\x9f\xbd\x04\x12\x48\x0c\x0e\x1b\x0a\x08\x49\xf7\x45\xb6\xe8\x86\x88\x79\xc1\xe4\xb0\xb2\xb9\x14\xd1\x24\xa0\xd0\x70\xec\x4c\x22\x0e\x20\x68\x87\x48\x69\x84\x48\xf2\x22\x70\xa1\xd4\x52\xc8\x46\x38\x9e\x4c\xa0\xbf\xe7\x6d\xfb\x72\xfa\xea\x12\x63\x2d\xf6\xc9\x1c\x3d\x82\xc7\x33\x7a\xe0\x26\x1b\x01\x3e\x63\x34\xde\x1f\xb2\xdb\x19\x58\xb3\x00\xab\x76\xf2\xae\xec\x4a\xf9\x14\x11\x4c\xdf\x7b\xc2\x7c\x85\xde\x8d\x21\x51\x44\x10\x8d\x5e\xed\x6e\xfd\xf9\xd7\x3f\xfe\x29\xb7\xc3\xe8\x23\x9f\xa2\xe9\x66\x87\x8d\xf7\xd7\x1b\x47\x49\xd8\x09\x5c\x5a\xb2\x6d\xbe\x7a\x10\x74\x6f\xc5\x0d\x1c\xeb\x4e\xcb\x83\x77\xd0\x9a\xb3\xe6\x05\xa8\xeb\x8d\x4d\xc0\xe1\x3f\xf7\xec\x50\xb3\xb8\xa4\xad\xc4\x2c\x93\x97\x9d\x09\xe6\x84\x33\x48\xd8\xf5\x9e\x47\xd4\x14\xb6\x26\x41\x32\x2f\x08\xbd\x89\x09\x6e\xb8\x99\x2f\x8d\xd7\x48\xcb\xf5\x65\xdd\x20\x09\x8e\x08\x9d\x71\x08\x11\x8d\x23\x3f\x82\x39\x1b\xf2\x39\x83\xd5\x87\x4b\xcb\x1d\x50\x5a\xb9\x76\x50\x46\xfe\x64\xec\xcb\x29\xf2\x10\xdc\xab\x20\x0a\x21\x04\x0c\x48\xc2\xfe\xee\x38\xde\xe9\xdf\xc2\x40\x11\x68\x38\x82\xe5\xe0\x06\x9b\x28\x9c\x5e\xf9\xb8\x07\x23\x70\x41\xef\xf5\xaf\xe6\x5e\x49\x01\xe4\x34\xff\x8d\xf2\x41\x0f\x08\x34\x91\xbb\x0c\xfb\x0d\x5c\xa1\xc7\x28\x3d\xda\x44\x67\xbc\x76\xcd\x78\x51\xc2\x8a\x8d\x74\x44\x81\x96\x2f\x0a\x75\x1f\xb1\xd7\xb4\x6b\xe2\xb6\x55\xff\x84\x3d\xc7\x04\x11\xbf\x0e\x14\xd1\xa9\x53\x19\xf2\xca\xc0\x8d\xf7\x53\x90\x25\xb3\x7f\x6d\x31\xac\x43\x35\xdb\x60\xf7\xaf\xd2\x56\x92\x52\xa3\x55\x7f\xfd\xd5\xb2\xb9\xcd\xdc\x03\xfc\x92\xca\xc9\xa4\x57\x5e\x1d\x4f\xad\x02\x28\x99\x40\xe7\xc1\x07\x8c\xe6\x48\x9e\x92\xd2\xac\x35\x67\xf5\x4b\x95\x82\x33\xdf\x41\x06\xdb\xce\x66\x4b\xc5\x44\x93\x0a\x34\x82\x67\xcc\xba\xc0\x4d\xd9\xf7\x3d\xd3\xc6\x5a\x8e\xae\x5a\x37\xd0\x47\x2c\xb4\xc8\xf6\xf4\xb3\x58\xe3\xf1\x90\xa1\x5e\x49\x00\x5f\x02\x46\x99\xa9\x39\x9d\x51\xce\xa0\xb1\x6d\x01\x90\x12\x76\x05\xe1\xbf\x04\x8b\x37\xf3\x40\x02\x96\xbb\xde\x63\x42\x77\x03\x65\x69\x15\x59\x87\x09\x63\xa7\x0a\x59\x89\x50\xea\x6d\xd2\xbd\x16\x2b\x27\xaa\x7a\x55\xfb\x95\x82\x53\x1c\x24\xe0\x10\xbb\x42\x8d\xc4\x0c\x2d\x9d\xda\xca\xba\x55\xfd\x92\xc0\x4b\xe0\x4b\xd0\x5d\xd7\x62\x57\x05\x71\x11\x3f\xee\xd5\x47\x15\x33\xeb\x07\x87\x70\x1c\x52\x56\xa5\xc0\x09\x3c\x75\x94\x8a\x28\x6a\x41\x8e\xea\xc1\x4c\x7b\xcb\x21\x45\xf6\xb2\x07\x1e\x92\xf0\x10\xed\x54\x60\xfb\x2a\xcd\xcb\xff\x1c\xc1\x91\xe8\x09\x0a\x83\x7e\x56\x8c\x8c\x96\x8c\x66\xd8\x88\xb0\x25\x93\xaa\x0c\xce\x10\x17\xd4\x1f\xa0\xc7\x80\x49\x01\x50\xb9\x43\xa9\xe4\x3b\xa2\x56\x9e\xd2\x13\x1d\x1c\x47\x30\xda\x92\xa2\xcb\x1a\x35\xaa\xcb\xca\x9f\x66\x9c\x5a\xc5\x10\x8d\x1d\x27\x29\xdf\x26\x50\x19\x81\x4d\x53\x8a\x38\x68\xb3\xe2\x06\x1c\x66\x01\x74\x1b\x04\x25\x2b\xd2\xa7\xa9\xc3\xc1\x33\xc3\x9f\xbe\xa1\x1d\xd2\x5b\xe5\x9c\xa3\x78\xe4\xd0\x50\xec\x26\x31\x5d\x5a\xcc\x2d\x57\xae\x2f\x9c\xb5\xac\x35\x02\x47\xd4\x2a\x37\x31\xaa\x38\xf1\x90\xae\xa9\x7f\x4b\x53\xca\x24\x71\xb8\xdd\xc3\x5f\xdf\x7f\x29\x42\x78\x55\xd5\xb7\x54\x40\x0f\x53\xdc\x56\x62\x6d\x7b\xb5\x54\x73\xac\xa5\x71\xe1\xb7\xde\x33\xa5\xed\xfe\xec\x92\xe4\xc6\x62\x3a\x2d\x10\x55\xe3\x88\xd0\x0c\x31\xb5\x18\x01\xcf\xc6\x0f\x99\x19\x14\x80\xa3\xc3\xa8\xce\xa1\x68\xdb\x27\x51\x51\x40\xb0\x91\x12\x59\xe3\xab\x58\x50\x70\x74\x26\x37\x18\x9f\x63\x93\xef\x47\xbe\xa0\x7c\x31\xdb\x29\xa6\x84\x6a\x12\x59\xe8\xd0\x84\x4c\x8a\x88\x6e\xc8\x44\x2f\x7d\x45\x2b\xc0\x4f\x84\x56\x83\x32\x21\xa7\xf9\xa1\x70\x0b\xe2\x4a\x6b\xef\x5f\x61\x3f\x4f\x48\xba\x6d\xaa\x0f\xd5\xb7\x8c\xc9\x2c\x31\xeb\x61\xe1\x7f\x9f\x50\x16\x5c\xb6\x6e\x28\x12\xa4\x77\xa9\x1b\x4b\x18\x3d\x46\x62\x57\x7a\xe1\x67\x67\xc6\x1f\x3b\x0e\xa9\xfd\x71\x44\x13\xbf\x68\x56\xb6\xdf\x56\xc6\xaf\x51\x5e\xdb\xfe\x2f\xac\xfd\x4c\x41\x73\x9a\x5b\x64\x85\xa4\xf6\x52\x07\x6e\x88\x9a\x85\xcf\xda\x88\xa9\x19\xab\xaf\x5f\xde\x8e\xf1\x3b\x65\xa6\x2e\x16\x2d\xcd\xba\xf2\x59\xfb\x3b\x07\x88\x28\x3d\x07\x21\x9d\x56\xb4\x43\xe4\x2d\xeb\xd4\xaf\xcd\x57\x5b\x5f\x72\xaa\x00\xbf\xf5\x18\x93\xae\x93\x24\xa3\x5d\xa4\x8f\x7c\x26\x77\x7d\x41\x66\x54\xd7\x9b\x30\xbe\xba\x6b\x39\x59\x43\xfa\xe3\x10\xdf\xb8\x7a\x7f\x95\x74\x8d\xc5\xb2\xf7\x68\x15\xec\xd4\x46\x1e\x4e\x2d\x7c\x1d\x50\x74\x39\x18\xd2\x11\x5d\xb1\xa0\x90\x30\x9e\x09\x2f\x65\x04\xbf\xf2\xa0\x00\x20\x7b\xd0\x07\x8b\xaf\x8a\x9a\x3b\x69\x6d\xa0\xfa\x4a\x11\xb9\xa2\x9f\xf1\x30\x04\x4a\xeb\xea\x5b\xc1\x5b\xc7\x94\xd2\xc6\x1a\xf6\x9e\x2f\x72\xbf\xcb\xaf\x13\x2a\xa5\x71\x4b\x72\xd4\xcf\xa2\x3a\xf5\xde\xcc\x81\xa5\xde\x0e\xb3\x85\xe7\x2a\x40\x9b\x0d\x4b\x67\xd7\x54\xa5\x22\x56\x96\x63\x09\x36\x8b\xe8\x46\x9f\xde\xb6\x50\xc6\x91\xc3\x74\xe3\xd3\x6a\x9e\x78\xf3\x4c\x6d\x6a\xf5\xc8\x23\x07\xc1\xaf\x03\x06\x8b\x32\x55\xb0\xcf\x38\x36\x2a\x2d\x4d\xb9\x35\xeb\x9a\x26\x35\xe1\x29\x97\xaf\xfa\x5c\xe7\x66\x75\x72\x2c\x2e\x2e\x42\x39\x88\xc6\x61\xf4\xed\xa2\x42\xaa\xf5\xf5\x5e\xcb\xf3\xe3\xbe\x64\xf6\x3d\x1c\x71\xe2\x8e\xe7\x8b\xf6\xd3\x3a\x5c\xa8\xe5\x8f\xab\x55\x9d\x2d\x96\xe5\x35\xba\x08\x9e\x5e\xd0\x53\xcb\xec\x96\x67\x97\x36\xd9\xa8\xe1\xc0\x0f\x74\xc0\xc3\xd2\xea\x0a\x56\x70\x21\xef\xe7\x46\xa0\xdf\xd7\x60\x8f\xef\x32\x33\xfe\xef\x71\x5c\xbd\x1c\xd5\x03\x6b\xbc\x1d\x7c\xee\x39\xc7\x11\x8c\xcb\xb4\x18\xfa\x8c\x88\x5d\x52\x96\xb9\xb3\xa0\xb0\x58\x7d\x9a\xdf\xb1\xb3\xf3\xdc\xc0\x6f\xf5\x25\xf7\xbb\x92\xab\x79\x12\xcc\x93\xd5\x11\x61\x08\xab\xb7\xec\xd4\xb4\xaa\xb9\x9f\x27\xf2\xbc\xda\xb4\x07\xb3\x95\xb0\x32\xcc\x4d\x25\x26\xe4\x30\xea\x44\xaa\xae\x9a\xbe\x8f\xdc\x47\x1d\xb3\x6e\xb7\x85\x69\x9e\x2b\x3e\x0a\x16\xcd\x58\x1c\x79\x68\x12\xc6\xcd\xae\xfa\x6a\xde\xab\x5c\xac\x5a\x6e\x5d\xac\x03\xec\x22\x01\xa5\xd2\x15\xcf\xe9\x27\x87\x6a\x27\x3f\x81\x39\xc2\x09\x75\x38\x48\x8b\xa0\x2b\xe8\x73\xbb\xa8\x2f\xe2\x66\x2b\x79\x37\xc4\x5c\x7f\xfe\x00\x98\x5c\xdf\x8e\x44\xcf\x19\xc3\x1f\x1e\xfa\x1e\x83\xa3\x6f\xf0\xf0\xee\x7e\x29\x52\xcb\xdd\x91\xc2\x7c\xd7\x24\x3e\xfb\xed\xe8\xb2\x9e\x74\x74\xe8\xd7\x1f\x41\xb2\x5d\x69\xf9\xa2\x73\x1e\x82\x72\x19\xe7\x37\xe8\x1b\xcc\xa9\x2a\x34\xb1\x4e\x66\x51\x5f\xa0\xcf\xfe\x3f\xcf\x59\xde\xba\xff\xd3\xbb\xfb\x3a\xb6\x94\xc3\xd3\x48\xaf\xf7\x5e\xc3\xb2\xaf\x79\xad\xb1\xcd\xc9\x6c\xbe\x47\xb2\x1c\xe6\xba\x52\xd6\xb9\x3e\xfc\x7b\x00\xf5\x1c\xb7\x64\xc5\x12\x00\x00")
 
 func complySoc2PoliciesCyberMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -681,12 +702,12 @@ func complySoc2PoliciesCyberMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/cyber.md", size: 4805, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/cyber.md", size: 4805, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesDatacenterMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x56\xc1\x6e\xdc\x46\x0f\xbe\xef\x53\x10\xc8\xd5\x5e\xfc\xf9\x51\x14\x85\x6f\xa9\xd3\x43\x8a\x02\x35\xea\xa4\x97\xa2\x07\x6a\x86\x92\x18\x8f\x86\x0a\x67\xb4\x8e\x72\xea\x6b\xf4\xf5\xfa\x24\x05\x67\xa4\xd5\xae\xbd\x0e\x72\xe8\xc9\x5e\x2d\x45\xf2\xfb\xf8\xf1\xe3\x46\x1c\xe8\x06\xde\x62\x46\x47\x31\x93\xc2\x9d\x04\x76\xf3\x0e\x9d\x4a\x9c\x87\x1b\x78\x7b\xb7\x4b\x98\x39\xb5\x4c\xe9\x66\x07\xf0\xfe\xfe\xd6\xfe\x00\x5c\xc3\xed\xed\xf7\xfb\xef\x76\x03\x7e\x14\xfd\x8d\x0e\x9c\x58\x62\x09\xb9\x06\x8f\x99\x6e\xe0\xe7\x29\xc2\x6b\xf8\xff\xff\x5e\xff\x50\x5e\x70\x32\x0c\x14\xf3\x0d\xbc\x8b\x9c\x19\x03\x78\x71\x93\x3d\xd9\x5d\x5f\x5f\xef\x76\xaf\xe0\x6e\xd2\x51\x12\x01\x46\x0f\xf7\x4e\x46\xda\xed\x70\x0f\xef\x7b\x82\x71\xf9\x46\x5a\xc8\x3d\x27\x18\x4b\x93\xc0\x09\xb2\x80\xa7\x96\x23\x41\x22\x37\x29\xe7\x19\x46\x15\x47\x7e\x52\x4a\xf0\xc8\xb9\xe7\x08\xb9\x27\x10\xed\x30\xf2\x17\xcc\x2c\xf1\x9f\xbf\xfe\x4e\xd6\x23\x42\x05\x9d\x4a\xc9\x92\x80\x80\x3e\x4d\x3c\x5a\x5b\x80\x4a\x98\xf6\x4b\x13\x5b\x55\x1c\xc7\xc0\x54\x4a\x63\x9c\xc1\x05\x99\x3c\xf4\x92\x32\x79\xab\x7d\x60\xbf\x66\x6c\xd1\x71\xe0\xcc\x2f\x77\x02\xb9\xc7\x52\x08\x02\x36\x14\xc8\x03\x26\x20\xce\x3d\x29\xe0\x69\x8b\x20\xf6\xe0\x62\x8b\x7b\xb8\x9f\x5c\x7f\x5a\xcc\xf2\xd1\xe7\x31\xb0\xe3\x1c\x66\x70\x18\x2c\xb3\x4c\x79\x6b\x83\xd3\x91\xfe\xaf\x23\x0c\x01\x06\x8c\xd8\x91\x85\x5e\x01\x0d\x63\x90\x99\x68\xa1\x6c\x2a\x91\x9a\x2a\x0e\x27\xd1\x4f\x2e\x43\x33\x25\x8e\x94\x12\xc8\x48\x5a\x70\x1e\x09\xd8\xd8\x32\x44\xdf\x3e\x83\x57\xf0\x23\xba\x87\x4e\x65\x8a\xfe\x59\xbf\x55\x01\xa9\xb0\x5b\x1e\xf1\xd2\x9f\x4e\x81\x12\x74\x72\x20\x8d\x1c\xbb\x6f\xad\x07\xad\xca\x00\x8d\xe4\x1e\x10\xc6\x7e\x4e\xec\x30\x94\xf8\x20\x5d\xf9\x7f\x13\x1b\x69\x1a\xc9\x65\x3e\x50\x95\xea\xca\x2a\x04\x4e\x39\x15\xfe\xbe\xb5\x2a\x47\x98\x12\x41\x33\x3f\x93\xc9\x15\x8c\x4a\xc9\x29\x37\x94\xa0\x97\x47\x40\xe7\x8c\x5f\x4e\xc6\x79\x56\x29\x03\xb6\xd4\x14\x5b\x51\x47\xfe\xaa\x7e\x4a\x19\x9b\xc0\xa9\xa7\x74\xba\x16\xad\x89\x29\xce\x60\x3b\x9b\xa5\x68\x2b\xf7\xac\x1e\x46\xd4\x3c\x2f\xc9\x9f\x48\x22\x24\x39\xf2\x3c\xaa\xf4\xdc\xb0\x09\x1e\x0d\xfa\xa2\x3a\x23\xdc\x30\x69\xd1\x4a\x2d\x33\x92\xb2\x78\x76\x90\xb0\x25\xcb\xbd\xc2\x37\xf2\x5c\x4f\xee\xa1\x4e\x77\xb1\x9d\x75\xe1\x5b\x09\x41\x1e\x6d\x64\x41\xdc\xa2\x20\x53\xb5\x0b\x98\x12\xb7\x4c\xfe\x12\x4d\xb6\x3c\x0b\xb7\x95\x51\xab\x66\xaf\x55\x05\xac\x2f\x1d\x51\xdd\xec\x8a\x31\xf1\x1e\xfe\xb0\x69\x3d\x1d\xd6\x69\xed\x6d\x6a\x35\xf3\x14\x3d\xe9\x65\x63\x59\x26\xf2\x67\x01\xf3\x13\xba\xfe\x2c\xe7\x93\x4c\x30\x4c\x29\x43\x8f\x07\x02\x5c\x16\x4d\xc1\x30\x76\x91\x7c\xe5\x62\x79\x5a\x72\x9b\x5d\xd7\x57\x9a\x4d\x6a\xe4\xe1\x25\x93\x53\x72\xa2\x3e\xed\xe1\x5d\x0d\x70\x58\x5d\xd4\xa6\x2f\xf1\x7a\x54\x1a\xce\xe4\x79\x55\xa2\x2e\x57\x2c\x12\x68\x6c\xc3\xd2\x52\xd2\xb0\x44\xc2\xca\xc3\x09\xa8\xfd\x06\xfd\x19\xd4\xc6\x86\x48\xa8\x61\x86\x01\xf5\xc1\x40\xbe\xa9\x62\xce\xf2\x34\xcf\xf6\xc6\x26\xf2\x66\x06\xcc\x10\x08\xad\x23\x9b\xd0\x03\x79\xf0\x22\xba\x87\x37\x47\x41\x2f\xeb\x11\xa4\x7b\xa1\x68\xdd\x0e\x4c\x1c\x56\xb9\x73\x13\x08\x3e\x5a\x30\xc7\xc4\x9e\x4a\x2f\x25\x6f\x01\x73\x47\x9a\x8a\x8d\xf5\x52\x14\x15\x25\xaf\x5e\xf8\x82\x14\xb5\xb4\x6d\xa9\x94\xbc\x81\x6b\x68\xed\x2f\xed\xe1\xf7\xe5\xbf\xb5\x7a\xec\xce\xf5\x95\x7a\x0c\x61\xd3\xe7\xaf\x4d\x46\x23\xfc\xc8\xd4\x59\x70\xfd\x46\xd4\x63\x74\x54\x9c\x16\x94\x5a\x52\xb2\x8f\x36\x8d\x35\x4b\x0c\x33\x6c\x32\x54\x1a\x2c\x29\xc7\xf3\x6c\x8f\x3d\x45\xa0\xe4\x44\xf3\xc2\x37\x78\x32\x41\xa2\x7d\x5e\x0f\x40\xd5\xe6\xfa\xa9\xd2\x9c\x32\xce\xb5\xbc\xd1\xb1\x0e\xc3\x4f\x6a\xf0\x72\x4f\xac\x56\x9d\x95\x6a\xe4\x09\xd1\xe7\xe2\x59\xda\xfd\x45\xba\x6a\xe9\x4a\x8e\x13\x41\xe6\xa1\x88\x97\x62\xd6\xea\x24\xf4\x99\xf3\xaa\xfd\xe7\xb3\xdf\x5f\xb0\x93\x53\xbf\x52\x3a\x75\xb2\xa5\x9b\x53\x2a\x36\xfe\xef\x7a\xc9\xd2\x29\x8e\xfd\x7c\x65\x76\x69\x47\x5e\xca\x4d\x9e\x3c\xcb\xb2\x66\x1c\xbb\xb4\x2e\xd7\x03\x47\xbf\x21\xb9\x95\x18\xed\x48\x48\x5c\xbf\xa7\x40\x2e\x6b\xb9\x25\x9e\x0e\xec\xa8\x1c\x5b\x18\xe5\x91\xb4\x9e\xd5\xf9\x0a\xa6\x18\x0c\x8a\x1d\x18\x6e\x2d\xd6\xd4\x3a\xe5\x5e\x94\xbf\x6c\xb2\x53\x4a\xa3\x09\xcd\x04\x3c\x16\x99\x6e\x85\x3f\xc4\x93\xf8\x29\x61\x57\x08\x34\xbf\xc7\xc1\xac\x39\x76\x75\x5e\xd6\x12\xc7\x94\xeb\x2f\x85\xe3\x61\xfa\x1a\x82\xad\x6d\x6b\x22\x52\x7e\x14\x7d\xf8\x2f\x7a\xbe\xcf\xa2\xa5\x51\x05\x54\xd7\xf3\x01\x83\xd5\x0c\xa8\x1d\x01\x0e\x32\xd9\x6d\x91\x16\x46\x1c\x49\x61\xc0\x4c\xca\x18\xd2\x85\x04\x2d\xb4\x01\x87\x01\xad\xca\x31\xce\xf2\x5e\x00\xf8\xa1\x1a\xe3\x28\x9a\x4b\x7c\x4f\x98\xcb\x4f\x86\x02\xf3\x34\xfb\x20\x0f\x1c\xbb\x2b\xa8\x01\x45\x0e\x5e\x39\xda\xc3\xaa\xb8\xfb\xd3\x6d\x3a\xfa\x8f\xdd\x3a\xf2\xe5\x28\x3a\x19\xc6\xc0\xdb\xb2\x1e\x0f\x62\x39\x0e\xf5\x50\x9e\x5d\x52\xf3\x93\xcd\xf4\x3e\x4d\xa8\x99\xcc\xcc\x1a\x4c\x9c\xf6\xbb\x7f\x03\x00\x00\xff\xff\xb1\x0b\x9e\x72\xc5\x0b\x00\x00")
+var _complySoc2PoliciesDatacenterMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x56\xcf\x6e\xdc\xc6\x0f\xbe\xef\x53\x10\xc8\xd5\x5e\xfc\xf2\x43\x51\x14\xbe\xa5\x4e\x0f\x29\x0a\xd4\xa8\x93\x5e\x8a\x1e\xa8\x19\xae\xc4\x78\x34\x54\xc8\x91\x37\xca\xa9\xaf\xd1\xd7\xeb\x93\x14\x1c\x69\x57\xf2\xbf\x20\x87\x9e\xbc\x92\x66\xc8\xef\xfb\xf8\x91\x74\xc6\x9e\xae\xe0\x2d\x16\x0c\x94\x0b\x29\xdc\x48\xe2\x30\xed\x30\xa8\xe4\xa9\xbf\x82\xb7\x37\x3b\xc3\xc2\x76\x60\xb2\xab\x1d\xc0\xfb\xdb\x6b\xff\x03\x70\x09\xd7\xd7\xdf\xef\xbf\xdb\xf5\xf8\x51\xf4\x37\xba\x67\x63\xc9\xf5\xc8\x25\x44\x2c\x74\x05\x3f\x8f\x19\x5e\xc3\xff\xff\xf7\xfa\x87\x7a\x21\x48\xdf\x53\x2e\x57\xf0\x2e\x73\x61\x4c\x10\x25\x8c\xfe\x66\x77\x79\x79\xb9\xdb\xbd\x82\x9b\x51\x07\x31\x02\xcc\x11\x6e\x83\x0c\xb4\xdb\xe1\x1e\xde\x77\x04\xc3\xf2\x45\x0e\x50\x3a\x36\x18\x2a\x48\x60\x83\x22\x10\xe9\xc0\x99\xc0\x28\x8c\xca\x65\x82\x41\x25\x50\x1c\x95\x0c\x8e\x5c\x3a\xce\x50\x3a\x02\xd1\x16\x33\x7f\xc1\xc2\x92\xff\xf9\xeb\x6f\x73\x8c\x08\x33\x69\xab\x29\x6b\x00\x02\xfa\x34\xf2\xe0\xb0\x00\x95\xd0\xf6\x0b\x88\x35\x2b\x0e\x43\x62\xaa\xa9\x31\x4f\x10\x92\x8c\x11\x3a\xb1\x42\xd1\x73\xdf\x73\x3c\x45\x3c\x60\xe0\xc4\x85\x5f\x46\x02\xa5\xc3\x9a\x08\x12\x36\x94\x28\x02\x1a\x10\x97\x8e\x14\x70\x0b\x11\xc4\x5f\x3c\x0b\x71\x0f\xb7\x63\xe8\xb6\xc9\x3c\x1e\x7d\x1e\x12\x07\x2e\x69\x82\x80\xc9\x23\xcb\x58\x56\x18\x6c\x67\xf9\xbf\xce\x30\x25\xe8\x31\x63\x4b\x9e\xef\x02\xa8\x1f\x92\x4c\x44\x8b\x64\x63\xd5\x42\x6d\xe6\x11\x24\xc7\x31\x14\x68\x46\xe3\x4c\x66\x20\x03\x69\xe5\x79\x16\x60\x55\xcb\x19\x7d\x7b\x0d\x5e\xc1\x8f\x18\xee\x5a\x95\x31\xc7\x27\x78\x67\x07\x38\x08\x9a\x29\xf0\x82\x4f\xc7\x44\x06\xad\xdc\x93\x66\xce\xed\xb7\xe6\x83\x83\x4a\x0f\x8d\x94\x0e\x10\x86\x6e\x32\x0e\x98\x2a\xbe\x24\x6d\xfd\xbd\x9a\x8d\xd4\x06\x0a\x85\xef\xc9\x35\xa4\xb3\xaa\x90\xd8\x8a\x55\xfd\xbe\x35\x2b\x67\x18\x8d\xa0\x99\x9e\xd8\xe4\x02\x06\x25\x0b\xca\x0d\x19\x74\x72\x04\x0c\xc1\xf5\x65\x83\x20\xb9\xa8\xd4\x02\x3b\x40\xca\x07\xd1\x40\xf1\xa2\x26\x22\x2b\xd8\x24\xb6\x8e\x6c\xdb\x16\x07\x37\x53\x9e\xc0\x7b\xb6\x88\x7a\x25\x4a\xc7\x1a\x61\x40\x2d\xd3\x12\xfc\x91\x25\x92\x9d\x3a\xad\x86\xea\xb8\x61\x37\x3c\x3a\xf5\xc5\x75\x39\x82\x3a\x27\xad\x5e\x99\xd3\x0c\xa4\x2c\x91\x03\x18\x1e\xa8\x4c\x2b\x7d\xef\xd4\xd0\x51\xb8\x9b\xab\xbb\x8c\x9d\x53\xc3\x1f\x24\x25\x39\x7a\xc9\x92\x84\xc5\x41\xee\xea\x90\xd0\x8c\x0f\x4c\xf1\x39\x99\xbc\x79\x16\x6d\x67\x45\x5d\x03\xbf\x36\x3b\xe0\x74\xe9\xcc\xea\x6a\x57\x07\x13\xef\xe1\x0f\xaf\xd6\xe3\x62\x6d\x73\xaf\x55\x9b\x23\x8f\x39\x92\x3e\x01\x50\x07\xcb\x52\x91\x3f\xab\x4d\x7f\xc2\xd0\x3d\x88\xf9\x28\x12\xf4\xa3\x15\xe8\xf0\x9e\x00\x97\x46\x53\x70\x8e\x6d\xa6\x38\x6b\xb1\xbc\xad\xb1\x7d\x5c\xcf\x57\x9a\xd5\x6a\x14\xe1\xa5\x21\xa7\x14\x44\xa3\xed\xe1\xdd\x7c\x20\xe0\x3c\x45\x7d\x70\x49\xbe\x1c\x94\xfa\x2d\x3a\xbb\x80\xf2\x62\xc6\x6a\x81\xc6\x3b\xcc\x96\x94\xce\x25\x13\xce\x3a\x6c\x48\xed\x57\xea\x4f\xa8\x36\x5e\x44\x42\x4d\x13\xf4\xa8\x77\x4e\xf2\x4d\xf5\x9b\xcf\xd3\x47\x71\xd6\x1b\xab\xc9\x9b\x09\xb0\x40\x22\x74\x44\x5e\xa1\x3b\x8a\x10\x45\x74\x0f\x6f\xce\x86\x5e\xda\x23\x49\xfb\x42\xd2\x5a\x50\x42\xe3\x74\xb2\x3b\x37\x89\xe0\xa3\x1f\xe6\x6c\x1c\xa9\x72\xaa\x71\x2b\x99\x1b\x52\x93\x6c\x70\xec\xc4\xa1\x41\x96\x72\x9a\x85\x2f\x58\xd1\xdd\x2a\x35\x94\x52\x74\x72\x0d\x9d\xf0\xd9\x1e\x7e\x5f\x7e\x9d\xb2\xe7\x76\x4b\xdc\xc0\x3a\x4c\x69\xf5\xe7\xaf\x4d\x41\xce\xcb\x61\x0f\xf6\xe0\xf0\xfc\x45\x34\x62\x0e\x54\x27\x2d\x28\x1d\x48\xc9\x1f\x71\xbf\x46\xc9\x69\x82\xd5\x86\x4a\xbd\x07\xe5\xfc\x30\xda\xb1\xa3\x0c\x64\x41\xb4\x2c\x7a\x43\x24\x37\x24\xfa\xf3\x69\x01\xcc\xde\x3c\x3d\xcd\x32\x5b\xc1\x69\x4e\x5f\xba\x33\x59\x88\xa3\x7a\x23\x97\x8e\x58\x3d\x3b\x2b\xcd\x27\x37\x42\x6f\x00\xac\x70\x7f\x91\x7a\xcb\x67\x5f\x60\x23\x28\xdc\x57\xf3\x52\x2e\x3a\x4f\x12\xfa\xcc\xe5\xe4\xfd\xa7\xb5\xdf\x3f\x33\x4e\xb6\xf3\x4a\x69\x3b\xc9\x16\x34\x5b\x29\x56\xfd\x6f\x3a\x29\xd2\x2a\x0e\xdd\x74\xe1\xe3\xd2\x97\xbc\xf8\x0f\x1c\x23\xcb\xd2\x66\x9c\x5b\x3b\x35\xd7\x1d\xe7\xb8\x32\xb9\x96\x9c\x7d\x49\x48\x3e\x7d\xa7\x44\xa1\x68\xdd\x25\x91\xee\x39\x90\x17\x15\x61\x90\x23\xe9\xbc\x56\xa7\x0b\x18\x73\xf2\x72\xfb\x82\xe1\x83\x9f\x75\xb7\x8e\xa5\x13\xe5\x2f\xab\xed\x94\x6c\x70\xa3\xb9\x81\x87\x6a\xd3\x35\xf1\x87\xbc\x39\x3f\x1a\xb6\x55\x40\x9f\xf7\xd8\xfb\x68\xce\xed\x5c\x2f\x87\xc4\xd9\xca\xfc\x9f\xc2\x79\x31\x7d\x8d\xc1\x0a\xdb\x41\x64\x2a\x47\xd1\xbb\xff\x02\xf3\x6d\x11\xad\x40\x15\x50\x43\xc7\xf7\x98\x3c\x67\x42\x6d\x09\xb0\x97\x31\x97\x2a\xf3\x80\x03\x29\xf4\x58\x48\x19\x93\x3d\x13\xe0\x00\x87\x84\x7d\x8f\x9e\xe5\x7c\xce\xab\xf6\x0c\xc1\x0f\xf3\x60\x1c\x44\x7d\x65\x12\x74\x84\xc5\x0d\x33\xd3\xdc\x46\xef\xe5\x8e\x73\x7b\x01\xf3\x81\x6a\x87\xa8\x9c\xfd\xe5\xec\xb8\xdb\x8d\x85\xd6\xf9\xe3\xbb\x8e\x62\x5d\x8a\x41\xfa\x21\xf1\xda\xac\xe7\x85\xe8\x9e\x5e\x16\xe5\x83\x4d\x2a\x79\x3b\xf4\x3e\x8d\xa8\x85\x34\x4d\xd0\xa0\xb1\xed\x77\xff\x0e\x00\xb1\x0b\x9e\x72\xc5\x0b\x00\x00")
 
 func complySoc2PoliciesDatacenterMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -701,12 +722,12 @@ func complySoc2PoliciesDatacenterMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/datacenter.md", size: 3013, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/datacenter.md", size: 3013, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesDevelopmentMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x5a\xcf\x92\xdc\xb6\xf1\xbe\xef\x53\x74\xd5\xef\xb2\x5b\x35\x33\x6b\xfd\x4e\x8e\x72\x92\x77\x9d\x8a\x52\x76\x65\x4b\x92\x93\x33\x06\x68\x92\xf0\x82\x00\x0d\x80\x33\xa6\x4e\x7a\x8d\x54\xc5\x2f\xa7\x27\x49\x75\x03\x20\x41\xce\xac\x2d\x57\x7c\x88\x2f\x9a\x9d\x01\x81\x46\xf7\xf7\x7d\xfd\x87\xb6\xa2\xc7\xd7\xf0\xde\x35\xf1\x2c\x3c\xc2\x23\x9e\xd0\xb8\xa1\x47\x1b\xe1\x3b\xdd\xa0\x9c\xa4\x41\x78\x72\x46\xcb\xe9\x46\x48\xef\xec\xd4\xbf\x86\xf7\x8f\xdf\x3d\x3c\xdd\x04\x11\x75\x68\x34\x86\xd7\x37\x00\x1f\xde\x3f\xd0\x3f\x00\x7b\x78\x78\xf8\xfa\xf0\xea\xa6\x17\x3f\x3a\xff\x0e\x4f\x3a\x68\x67\x79\xc9\x1e\x94\x88\xf8\x1a\xfe\x36\x5a\x78\x05\xff\xff\xd5\xab\xaf\xf9\x01\xe9\x7a\x3a\xee\x35\xbc\xb5\x3a\x6a\x61\x40\x39\x39\xd2\x37\x37\xfb\xfd\xfe\xe6\xe6\xff\xe0\x69\xf4\x83\x0b\x08\xc2\x2a\x78\x2f\xdd\x80\x37\x37\xe2\x00\x1f\x3a\x84\x21\xff\xe2\x1a\x88\x9d\x0e\x30\xb0\x9d\xa0\x03\x44\x07\x0a\x1b\x6d\x11\x3c\xfe\x34\x6a\x8f\xb4\x61\x80\xc6\x79\xc0\x10\xc5\xd1\xe8\xd0\x69\xdb\xf2\x9e\xbd\xd0\x36\x0a\x6d\xe9\xef\xa3\x08\x68\xe8\xb1\xc1\xbb\x88\x32\x6a\x67\x21\x44\x61\x95\xf0\x2a\x3d\x2e\x5d\x3f\x08\x3b\x41\xc8\x2e\xdb\x81\xc5\x78\x76\xfe\x19\x14\x9e\xb4\xc4\xb0\x83\x80\xfe\x84\x3e\xec\x78\x77\x85\xe1\x39\xba\x21\x1c\xb2\xd5\x8b\x99\x62\x18\x8c\x46\xb6\x55\x18\x03\x63\x40\x1f\x60\x40\xdf\x38\xdf\x93\x2d\xe5\x04\xda\xb8\x04\x65\x07\x61\x0a\x11\x7b\x10\xaa\xd7\x56\x87\xe8\x05\xd9\xb8\xcb\x17\xb1\xa2\xe5\x9b\x26\x8f\x20\x39\x4d\x46\x7d\xd2\x91\xce\x39\xeb\xd8\x69\x4b\xdf\x83\xf3\xad\xb0\xfa\x23\x3f\x9b\x8d\x8a\xd3\xa0\xa5\x30\x66\x02\x6d\xa5\x19\x15\x06\xc0\x7e\x30\x6e\x42\x0c\xbc\xbb\x74\x36\x7a\x21\xa3\xe3\x9b\x05\x38\xa3\x31\xf4\x2f\x39\xc3\xa3\xc1\x93\xb0\x11\xf0\xe7\x88\xde\x0a\x03\x83\xf0\x7c\xa8\xb6\x27\x67\x4e\xa8\x20\x9d\xbc\xb6\xe8\xb6\x43\x8f\xda\x8a\x26\xa2\x07\x8f\x0d\x7a\x8f\x8a\xfd\x11\xe0\xf3\xa7\x7f\xb3\x47\x3e\x7f\xfa\xe5\x6e\xed\xb7\x7e\x0c\x11\x8e\x08\xbd\x50\x14\x5e\xa1\xb4\x99\x40\x9c\x84\x36\xe2\x68\x70\xe5\xce\x2b\x4e\x37\xc1\xd5\x9e\x47\x1b\xd1\x0f\x5e\x07\xdc\x9f\xb5\xc2\xec\xde\x74\x63\x5e\x26\xd9\x49\xa1\x04\x01\x15\x1c\xa7\x0b\x1f\x82\xf3\xe0\x2c\x1c\xb1\x13\xa6\xc9\xce\x5f\x2f\x20\xe8\x0c\xde\xa9\x31\x61\x4a\xf7\x83\xe1\x48\xa5\x10\x10\xcc\xbf\x11\xf2\xb9\xf5\x6e\xb4\x6a\xc6\xb7\xb6\x71\x0e\xe6\x16\xde\x68\xc3\xe8\x11\x04\xc7\x61\x9f\xc0\xae\x08\x7b\x92\xbf\x4e\x01\x0b\x3a\xf0\x0e\x83\x77\x12\x43\x02\x30\xa3\x84\xf0\x45\x36\xa2\x8d\xda\x23\x98\x99\xea\xae\x59\x70\x47\x9b\x68\x4b\x70\x4c\x77\xc8\xbe\xd9\x41\xe3\x5d\x0f\x3a\xd3\x75\x45\x30\x61\x85\x99\x82\x0e\x30\xda\xa8\x4d\x01\xab\x42\x22\xb9\x0e\x21\xc3\x0d\xcb\x55\x92\xd9\x81\x4d\x61\x1b\xd5\x48\x8c\xf2\xce\x60\xa6\x8f\xc7\x30\xd0\x3d\x8e\xda\x30\x66\x76\x89\xc3\x42\x76\xc4\xcb\x16\x8b\xb3\xaf\x91\x65\xb9\x56\xc2\xc1\x3f\x0b\x01\x66\x5f\xee\xbe\xe0\xd9\xe2\xc7\x40\x47\x5d\xbd\xec\x0e\x84\x97\x9d\x26\xbd\x28\xbe\x57\x18\x74\x6b\x77\x6b\xea\x46\x0c\x51\xdb\x96\xbe\x25\x62\xd1\x97\xf7\x6b\x20\xec\xc0\x0d\x98\x28\x1d\xee\x59\x97\xd0\x0a\x2b\xb1\x48\xc9\xc6\x8d\x01\x4b\x64\x31\x40\x2f\x26\x62\x45\xe3\x8c\x71\xe7\x44\x38\xa2\x26\x85\xef\xcf\xfc\x07\x9c\x45\x44\xdf\x10\x39\x7a\xa7\xd0\xec\x40\xc7\xf2\x94\x18\x06\xef\x06\xaf\x45\x64\x02\xa5\x4d\x96\xb0\x84\x00\xa4\x8a\xc2\x9b\x69\x07\xe7\x4e\x1b\x4c\xdb\x83\x68\xe9\x73\xed\xb7\xbc\x75\xfd\xa8\x14\x44\x0d\xf0\x38\xa0\x88\xc5\x32\xd0\x91\x6f\x7a\x42\x68\x44\xe8\x0a\x0b\xde\x91\x0a\xa0\x95\x18\x38\x64\xef\x74\x78\x86\x37\x74\xbf\xc0\xbb\xe7\x4c\x44\x59\x21\x7f\xca\x54\xa9\xc9\xf6\xf9\xd3\xbf\xc2\xcb\x29\x0d\x1e\x38\xaa\xb7\x94\xc4\xee\x16\xb1\x23\x83\xd3\xb5\x89\x1b\x43\x27\x02\x25\x36\xce\x50\xfa\x00\xef\xea\xb8\xbf\xc9\x71\x9f\x7f\x7d\xb3\x0d\xff\x23\x87\x7f\xfe\xfd\x43\x0a\xfc\xfc\xf7\xe3\x12\xff\xb7\xab\xf8\xcf\x2b\xfe\xbe\xc0\xe0\xfb\x05\x06\xd5\x06\x0b\x12\xd8\x05\x8f\xa3\xe7\x74\x66\x4c\x36\xbd\xf0\x82\x6e\x09\x67\xd2\x59\x10\x85\x8e\x3a\x80\x75\x91\xc2\xb0\x28\x52\x66\x42\x5a\xc0\x0a\x4b\x4b\x3a\x71\x22\x71\x38\x21\xa5\x6e\x01\x01\x23\x39\x4a\x44\x4e\x06\x42\xdb\x95\x3c\x68\x45\x6a\xd2\x4c\x6c\x88\x8c\x23\x65\x01\x74\x03\x89\x0a\xa5\x4d\x3f\x38\x4f\xf0\xa2\x45\x89\xc9\x79\x51\xa3\xe9\x72\x9c\xf9\xf9\x90\x51\x76\x9c\x57\xa4\x74\xa3\x8d\x60\xc7\xfe\xc8\xd9\x94\xa5\x4d\xc7\x09\xa4\x53\xf4\xb8\x77\x23\x79\xb5\xb6\x61\x47\x47\x11\xea\x5d\xec\xd0\x2f\x3b\x53\x56\xab\xac\xa3\x73\x0e\xf0\xb6\xb2\x9d\x2f\x75\x76\xa3\x51\x84\x54\x66\xbc\x42\x4a\x46\x01\x6d\xd0\x8c\xd2\xe4\x14\x3c\xa1\xa7\x25\x63\x40\x05\x6e\x8c\xb4\x8e\x7c\x5d\x69\x3b\xda\x93\xf6\xce\x32\x56\x0e\x33\x40\x17\x6c\x55\x09\xb0\xa4\x32\x2a\x2a\x0c\x12\x37\x84\x55\xf7\xec\xae\xd9\x02\x95\x42\x4b\xe1\xb9\x2e\xb5\x1c\xf0\x05\xaa\x8c\xce\x8f\x08\xc7\x31\x90\xb6\x86\xd5\x53\x87\x79\xd9\x53\x2a\x34\x40\x80\x27\x8e\x89\x99\x63\x07\xf8\xde\x79\x5c\x45\xd6\xd9\xed\xa2\x40\x20\x52\x3a\xc8\x31\x84\x39\xb9\xbf\xc0\x56\xb8\xf5\x85\xd4\x70\x2b\xee\xee\x16\x1b\x1e\xd3\x06\x20\xc2\x80\x32\x09\xec\x1c\xe4\x5b\x3c\xb4\x87\x1d\x39\xa2\x49\xa1\x13\x46\xc7\x69\xc7\x39\xb1\xf5\xfc\x31\xe7\x7c\xca\x0c\xd3\x1d\xf3\xae\x4b\xa2\x35\x41\xaf\xdb\x2e\x72\x02\x9f\x48\xcf\x58\xf2\x2b\x3f\x1c\x2a\x62\x9f\x34\x9e\xc1\x63\x3b\x1a\x11\x9d\x9f\xb6\x4e\x56\x17\xb9\x9c\xe5\x85\xf3\x07\xa3\x78\x2e\x0f\x77\x4b\x0a\x4b\x0f\xb6\xa3\x56\x5c\x4d\x86\x8b\xf3\x9a\x91\xb5\x62\x8e\x51\xeb\x84\xb9\x5c\x25\x47\xef\xc9\x8f\xf3\xb2\x6d\x46\x8e\x28\x3b\xeb\x8c\x6b\xa7\x2a\x71\x2c\xdb\xbc\xb5\x0b\xed\x06\xef\x5a\x2f\xfa\xba\x48\xd4\x29\x9b\x27\x09\xd4\xb6\xcd\x18\xa2\xff\x5e\x1d\x66\x95\xa3\xa8\x14\x43\xb2\x3e\x70\x6d\x75\x2f\xc7\x10\x5d\x9f\xaa\xac\xea\xb9\x1f\xac\x42\xcf\x4e\x81\xb2\x62\x4f\xd5\xa0\x45\xcf\xc1\xf3\x8d\x90\x1b\x28\xe7\x58\x97\x6b\xee\x0d\x49\xf6\x5c\x58\xdf\xad\xf7\x2f\xa0\x19\xbc\xfb\x11\x65\x84\xa8\x7b\x6c\xbc\xe8\xb1\xc2\x55\x92\x7c\x76\xd7\xe0\xb5\xf3\x3a\xea\x8f\xb8\x60\x2b\x38\x33\xb2\xff\xae\x53\x23\x21\x18\xa4\x0b\x91\xb7\x38\x8e\xaa\x45\xd6\x3c\xaa\xb7\x75\x69\x24\x2e\xb6\xab\x7d\xb9\xca\x89\xb4\xc9\xb5\xf8\xbc\xa1\xac\x7b\xaa\x0c\xbb\xc0\x5e\x3a\x7a\x79\xe2\x7b\xf1\x8c\x54\x1a\x1f\xc7\x09\x4e\xe1\x00\xc7\x51\x1b\xf5\xf9\xd3\x2f\x54\x1b\xa4\x4e\x6b\x63\x1a\x7a\x6e\x4a\xb8\xb1\x51\xe0\x12\x4f\x6b\x08\x89\xa3\x3b\xe1\x35\x99\xba\xd0\xa6\xfb\xeb\xa2\xf4\x42\xed\xb3\x95\xa5\x6f\xd5\x28\x09\x87\xb5\x5f\x22\x8a\x3e\x90\x51\xcc\x5b\x07\xd2\x53\x81\x40\x89\x2a\x55\xb1\x09\x6e\x57\xe3\x4a\x22\xe9\x53\x87\xa7\x6d\xe3\x45\x88\x7e\x4c\x26\xcc\x77\xaf\x0d\x5b\xf6\xf8\x4e\x87\x98\x78\x43\xed\x0e\x1b\x6c\x9d\xdd\x2f\xdf\x54\x69\xc6\x46\xef\xcc\x35\xcd\xac\xaf\x7c\x16\xe6\x39\x76\xde\x8d\x6d\xb7\xac\x7c\x98\x6f\xc2\x57\x48\x88\x5e\xb6\x4e\x2e\xba\x58\xde\xe9\xb6\xcb\x4b\xd7\x36\x25\xde\x93\xf0\x71\x01\x75\xc5\xd8\xb4\xe3\x55\x7d\x27\x1c\xdf\x1f\xd1\x62\xa3\xe3\x92\x35\x08\x26\x39\x52\x14\x61\x67\xd7\x1c\x78\xcc\x4d\x38\x87\x58\x61\x14\xda\xfc\xda\xc1\xd7\xce\xcd\xbb\x7b\xd6\x32\x2e\x1b\x65\x97\x50\x95\x8b\xae\x1d\x88\x08\x02\xa8\x91\xed\xc7\x7e\x57\xed\x9e\x9e\x61\xe9\xa9\x9a\xb0\x22\x7f\x55\xb4\xd7\x9d\x68\x7e\x8e\x1e\xab\x5c\x39\x97\xc8\x35\x90\x82\xf4\xfa\x58\xdd\x6c\xbe\xcf\x52\x50\x27\xf5\x28\x8a\x5e\xeb\x24\x04\x6c\x3d\xb6\x39\x39\x36\xa0\x46\xce\xe6\xf4\xc0\xf6\x97\x2b\xf5\x7f\xd9\xf8\x6a\xbd\x00\xb5\x8d\xe4\xbe\xd2\x66\xa1\x55\x7b\xd2\x5d\x60\x09\x2a\xdb\x08\x2a\x71\x39\x35\x64\x7d\x5f\x5f\x92\x36\x10\xd0\xa2\x45\x5f\xc7\x8c\x0c\x81\xc1\x88\x2a\x6c\x3f\x0c\x8a\x6b\xff\x2f\xce\x75\x17\xde\x69\xea\x26\xe2\x42\x4c\x59\x18\x0a\xa6\x32\xdd\x7b\x1d\x75\x4b\xa7\x7a\x0c\x5a\x51\x29\xf8\xeb\xc1\x86\xd3\x68\xe8\x26\xa5\x17\xbc\xbc\xaa\x55\xcb\x78\x07\x02\x0e\xc2\x6f\x25\x87\x13\x3a\x5d\xff\xb7\xaa\xb4\xdf\x59\x9a\xd5\x67\x6c\x94\xef\x3d\x46\x18\x87\x45\xd5\xea\xa5\x95\x15\x25\x0b\xce\xd3\xa3\x10\x9d\x17\x2d\x56\xf5\xd2\x07\x9f\x6b\xee\xda\x29\xb3\x88\x6a\x1b\xa2\x30\x66\xf1\x1e\xd7\x4e\xed\xe8\x67\x34\x66\xf7\x1e\x0d\x56\xe3\x2b\x3d\x77\xb5\xea\x52\x69\xa9\xd4\x66\xa9\xa8\x22\xb3\x15\xb3\x6b\xf2\xf1\x36\xd9\xb2\x9b\x6d\xc0\xb5\x86\xb1\xcf\x38\x0e\xeb\xdb\x1c\xb6\x5e\x2b\xa7\xec\x3d\x1a\xd6\xbe\x1a\x03\x13\x91\x41\x3e\x73\xc3\x76\x8d\xe4\x39\x5b\x5c\x61\xf9\xcc\x80\x34\xd3\xcb\xe5\x0d\x99\x98\x2b\x33\x0a\x01\xa7\xd3\x5b\x7d\xc0\xc3\x8e\x0a\x44\x8f\xdc\x6f\x15\x1a\x57\x71\x79\x70\x96\xc8\x0c\xa3\xd5\x71\xc5\xf2\x72\xdf\xea\xb1\x3f\x00\x6b\xe5\x84\x0d\xce\x6a\xc5\x57\x78\x05\x01\x59\x1d\x73\xb2\x82\xa3\x8b\x3c\x44\x89\x5a\x26\x7a\x4e\x56\xf4\xfc\xb9\x2a\xfc\x68\xa7\xe8\xe6\xee\xe9\x82\x82\xb0\x6a\x72\x37\x07\x2e\xfa\x70\x99\x1f\x72\x29\x49\x77\x09\x97\x9e\xcc\x63\x50\x6a\x7a\xd9\x36\xe3\x44\xe2\x6d\x1a\x63\x5e\x26\x69\x40\x4b\xb8\x56\x97\x07\x8d\x61\x06\x4b\xf6\xdb\x26\xab\x5c\x4b\xf8\x5b\x7b\xb4\x55\x38\xa0\x25\x2f\x6c\x10\x58\xf7\x44\x65\x16\xc5\x57\xab\x8b\xc1\x5c\x74\xd5\xb4\xdd\x0c\x18\xff\x10\x09\x2a\x53\x85\x2d\x32\x66\xaf\x6a\xe3\x62\xbd\x2e\x1b\x7c\x91\x53\x37\x32\x9c\xba\xe9\x79\xc8\x7b\x8d\xf0\xe5\x88\xe8\x05\x37\xcc\x3c\x0d\x8d\x67\x44\x9b\x4f\x4d\xdc\x32\x66\x1f\xa4\x30\xb5\xb1\x97\x11\x9b\x5b\x3c\x90\x1d\x26\x7a\xcf\xd3\x47\x68\xb4\xc1\xd5\x00\x74\x8c\x1d\x61\x53\xea\x38\x1d\x36\x13\x96\xdf\xca\x95\xdc\x1a\xb2\xa6\xd6\xe3\xf4\x13\x12\xfc\x82\xb3\x16\x53\xe5\x95\x26\xf3\xb9\xc7\x4d\x66\x70\x5e\x9c\x81\xd3\x8c\x56\x6e\x4a\xfb\x3c\x30\x4a\x73\x70\xa9\x87\x45\x83\x23\x18\x14\x54\x83\x9e\xdd\x3c\x54\x4e\xdb\x3b\xaf\x28\xbf\x3b\x8a\x2f\x3b\xf3\xaa\xbf\x52\x3f\x8b\x2f\x54\x10\x7f\x00\x8e\xae\x8f\x20\x2f\xb3\xda\x69\x53\x54\x88\xf0\x9c\xe7\xe6\x97\x03\x8e\x34\xab\x41\x33\x15\x66\xa7\x39\x7f\x3a\x2a\x93\x21\x87\x80\x5c\x70\x95\x44\xc7\x31\x0d\xa5\x8c\xee\x35\x57\xc1\x6e\xd3\xb0\xce\x1b\xd0\xea\x14\xb4\x64\x0e\x65\x86\x75\x13\xf9\x61\x64\x58\x54\x32\xb3\xfe\xfd\x69\x79\x0d\x73\x14\xf2\x79\x1c\xd2\x3c\xca\x27\x36\xbb\x75\xa3\x96\x87\xef\x05\x21\xef\x13\x50\xdf\x54\xd3\x89\x32\x09\x79\xe9\x8c\x32\x77\xab\xdc\x9d\xf3\x4e\x9d\x4d\x78\x9c\xc1\xcb\x8b\xcf\x47\xae\xda\x72\xa9\x2a\xa2\xec\x30\x6c\xba\xe5\xcc\x4b\x36\xfb\x2a\x19\x5e\x5c\x3e\xa0\xd7\x4e\x69\x59\x8c\x7b\x51\xf6\x5e\xdc\x41\x58\x4b\x45\xdd\x76\x74\x74\xb8\x9c\x72\x0a\x33\xbf\x78\x21\xc4\xac\xe3\xfa\x0d\xe6\xcc\x95\x30\x93\x79\xcf\x13\x3b\x06\xca\x56\xbe\x77\x29\xb3\x89\x31\xba\x5e\x64\xb4\x43\x2f\xc8\x96\x1d\x08\x8f\x33\x5d\xc9\x46\xe9\xbc\x47\x19\xcd\x94\x8a\xc7\xa6\x41\x02\x2f\x9a\x69\x7d\xab\xbf\xf2\x20\xd4\xb5\x79\xfc\x49\xbb\x14\xff\xf0\x74\x71\x65\xe0\x49\x98\xb1\xd4\x38\xb3\x75\xab\xdc\xc0\x87\x9d\x84\xd1\x5c\x74\x8b\x51\xe9\xb8\x6d\x37\xf3\xc1\xf3\x7c\x18\x9c\x6d\x1d\xb3\xd9\x59\x1d\x9d\xcf\x89\xac\x7e\x5f\x95\x20\xbf\xb8\x48\x61\x79\x85\x59\x0f\xd6\x4e\xda\x99\xfc\x4e\x8b\x1f\xb2\xa4\xa0\xce\xeb\x8f\xa8\x40\x76\xc2\xb6\xb8\x31\xe1\x1f\xc5\x4e\x7e\x61\x54\x3c\xc4\x42\x5a\xf2\x47\x31\xb2\x2e\xb1\xe6\xcc\x5c\xea\x8d\x45\x29\x66\x20\xce\x43\xb9\xf9\xad\xda\x53\x29\x19\xe0\x2f\xce\xc3\xb7\x3f\xa3\x4c\x43\xde\xb7\x56\x72\x0d\x02\xef\xd2\x0b\x21\xbc\x12\x1f\x51\xbd\xba\x5a\x67\x30\x12\xbb\x4e\x78\xc5\xbf\x31\x51\xaa\x9a\x31\x85\x54\x87\x4a\x9a\xd2\x38\xd0\xf3\xcb\xd0\x45\x96\x91\x94\xc7\x92\x27\xe6\x73\x8e\x63\x9b\xdb\xbe\xa5\x7d\x3c\x1a\x0a\xc8\x11\xd3\x6c\x3a\xb2\x4c\xb3\x3a\x64\xe1\xbe\xe8\xaf\xaa\x29\x5e\xad\xe4\xf0\x94\x18\x9d\xc2\x94\x99\x5e\x04\x35\xbd\xc2\x54\xe5\xa5\xae\xd5\x16\xe3\x04\xb7\x7f\xfa\xea\x0e\x94\x98\xf2\xbb\x2a\x4a\x34\x5c\xbe\x0d\xde\x9d\x74\xa9\xe4\x15\xfe\x94\xe0\xb9\x6e\x47\x07\xb1\xb4\xad\xf3\xfd\xf2\xb1\x07\xf8\xb6\x47\xdf\xa2\x95\xd3\x0e\xa4\xd7\x91\x60\xbf\x83\xa3\x47\xf1\xbc\x6f\xf4\xcf\xa9\x1d\xfc\x88\xde\xed\x95\x98\x36\x52\xc1\xfe\x2e\xd6\x5c\xde\x40\x04\xf8\x69\xd4\xf2\x99\x28\x48\xbd\x66\x08\xfa\x68\xbe\x6c\x22\xf5\x5b\xf5\xd0\xf2\x92\xe4\xa5\x8a\xe8\x4b\x2a\xf6\x32\x35\x2b\xd4\xe5\xb7\xd5\x73\x0d\x04\x1e\x7b\x77\x12\xe6\xb2\x14\x72\x95\xba\x55\x65\x51\xf9\x9f\x08\x56\x4f\xdf\x7b\x1c\x8c\x90\x9b\xe1\xf4\x23\x46\xa4\x04\x91\x5f\xbf\x78\x8c\x54\xe9\x6c\xe6\x96\xdb\xf6\x6c\xfd\xfe\xb6\xe8\x03\xbf\xf2\xf8\xef\xa7\x3b\xbf\x73\x4c\xf0\x3f\x32\x25\xf8\x4f\x00\x00\x00\xff\xff\xbe\x37\xed\x94\xe5\x22\x00\x00")
+var _complySoc2PoliciesDevelopmentMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x59\xcb\xae\x1c\xb7\xd1\xde\x9f\xa7\x28\xc0\x1b\x1d\x60\x66\x64\xfd\x2b\xff\xca\x4a\xd6\x71\x10\x05\x36\x22\x48\x72\xb2\xe6\x90\xd5\xdd\xf4\x61\x93\x6d\x5e\x66\xdc\x5a\xe9\x35\x02\xc4\x2f\xa7\x27\x09\xaa\x48\x76\xb3\x67\xe6\xf8\x02\x68\x11\xc0\x80\x75\xa6\xc9\x62\x5d\xbf\xaf\x58\xb4\x62\xc4\x97\xf0\xde\x75\xf1\x2c\x3c\xc2\x03\x9e\xd0\xb8\x69\x44\x1b\xe1\x7b\xdd\xa1\x9c\xa5\x41\x78\xeb\x8c\x96\xf3\x9d\x90\xde\xd9\x79\x7c\x09\xef\x1f\xbe\x7f\xfd\xf6\x2e\x88\xa8\x43\xa7\x31\xbc\xbc\x03\xf8\xf0\xfe\x35\xfd\x0f\x60\x0f\xaf\x5f\x7f\x73\x78\x71\x37\x8a\x9f\x9c\x7f\x87\x27\x1d\xb4\xb3\xbc\x64\x0f\x4a\x44\x7c\x09\x7f\x4f\x16\x5e\xc0\xff\x7d\xfd\xe2\x1b\xde\x20\xdd\x48\xc7\xbd\x84\x37\x56\x47\x2d\x0c\x28\x27\x13\xfd\x72\xb7\xdf\xef\xef\xee\xbe\x82\xb7\xc9\x4f\x2e\x20\x08\xab\xe0\xbd\x74\x13\xde\xdd\x89\x03\x7c\x18\x10\xa6\xf2\xc5\x75\x10\x07\x1d\x60\x62\x3d\x41\x07\x88\x0e\x14\x76\xda\x22\x78\xfc\x39\x69\x8f\x24\x30\x40\xe7\x3c\x60\x88\xe2\x68\x74\x18\xb4\xed\x59\xe6\x28\xb4\x8d\x42\x5b\xfa\xfb\x28\x02\x1a\xda\x36\x79\x17\x51\x46\xed\x2c\x84\x28\xac\x12\x5e\xe5\xed\xd2\x8d\x93\xb0\x33\x84\xe2\xb2\x1d\x58\x8c\x67\xe7\x1f\x41\xe1\x49\x4b\x0c\x3b\x08\xe8\x4f\xe8\xc3\x8e\xa5\x2b\x0c\x8f\xd1\x4d\xe1\x50\xb4\x5e\xd5\x14\xd3\x64\x34\xb2\xae\xc2\x18\x48\x01\x7d\x80\x09\x7d\xe7\xfc\x48\xba\xd4\x13\x40\xad\x41\xd9\x41\x98\x43\xc4\x11\x84\x1a\xb5\xd5\x21\x7a\x41\x3a\xee\x8a\x21\x56\xf4\x6c\x69\xf6\x08\x92\xd3\x64\xd4\x27\x1d\xe9\x9c\xb3\x8e\x83\xb6\xf4\x3b\x38\xdf\x0b\xab\x3f\xf2\xde\xa2\x54\x9c\x27\x2d\x85\x31\x33\x68\x2b\x4d\x52\x18\x00\xc7\xc9\xb8\x19\x31\xb0\x74\xe9\x6c\xf4\x42\x46\xc7\x96\x05\x38\xa3\x31\x20\xe8\xdb\x0c\x1e\x0d\x9e\x84\x8d\x80\xbf\x44\xf4\x56\x18\x98\x84\xe7\x43\xb5\x3d\x39\x73\x42\x05\xda\x5e\x6b\xf4\x6c\x40\x8f\xda\x8a\x2e\xa2\x07\x8f\x1d\x7a\x8f\x8a\xfd\x11\xe0\xf3\xa7\xff\xb0\x47\x3e\x7f\xfa\xf5\x7e\xeb\xb7\x31\x85\x08\x47\x84\x51\x28\x0a\xaf\x50\xda\xcc\x20\x4e\x42\x1b\x71\x34\xb8\x71\xe7\x0d\xa7\x9b\xe0\x5a\xcf\xa3\x8d\xe8\x27\xaf\x03\xee\xcf\x5a\x61\x71\x2f\x59\xa5\xf2\x32\xc9\x4e\x0a\x35\x08\xa8\xe0\x38\x5f\xf9\x10\x9c\x07\x67\xe1\x88\x83\x30\x5d\x71\xfe\x76\x01\xa5\xce\xe4\x9d\x4a\x39\xa7\xf4\x38\x19\x8e\x14\x7f\x3d\x50\x9a\x7f\x2b\xe4\x63\xef\x5d\xb2\xaa\x28\x8d\xa0\x6d\x5c\x82\xb9\x9a\xa0\x8b\xe2\x21\x79\x04\xc1\x71\xd8\xe7\x64\x57\x94\x7b\x92\x7f\xce\x01\x0b\x3a\xb0\x84\xc9\x3b\x89\x21\x27\x30\x67\x09\xe5\x17\xe9\x88\x36\x6a\x8f\x60\x96\x52\x77\xdd\x9a\x77\xe4\x03\x6d\x29\x1d\x59\xcb\xea\x9b\x1d\x74\xde\x8d\xa0\x4b\xb9\x6e\x0a\x4c\x58\x61\xe6\xa0\x03\x24\x1b\xb5\x29\x3b\x40\x21\x15\xb9\x0e\xa1\xa4\x1b\x56\x53\xb2\xda\x81\xfd\xc9\x3a\xaa\xe4\x71\x07\xde\x19\x2c\xe5\xe3\x31\x4c\x64\xc7\x51\x1b\xce\x99\x1d\xdb\x80\x42\x0e\x54\x97\x7d\xa9\x7d\xbc\x59\x2c\xab\x59\x39\x0f\xfe\x55\x0b\x60\xf1\xe5\xee\x0f\xec\xad\x7e\x0c\x74\xd4\x4d\x63\x77\x20\xbc\x1c\x34\xe1\x45\xf5\xbd\xc2\xa0\x7b\xbb\x6b\x25\xee\x20\x62\x88\xda\xf6\xf4\x2b\x15\x16\xfd\xf8\x7c\x9b\x08\x3b\x70\x13\xe6\x92\x0e\xcf\x19\x97\xd0\x0a\x2b\xb1\x42\xc9\x85\x1b\x43\xf1\x5a\x08\x18\x60\x14\x33\x55\x45\xe7\x8c\x71\xe7\x5c\x70\x54\x9a\x14\xbe\xbf\xf0\x1f\x70\x16\x11\x7d\x47\xc5\x31\x3a\x85\x66\x07\x3a\xd6\x5d\x62\x9a\xbc\x9b\xbc\x16\x91\x0b\x28\x0b\x59\xc3\x12\x02\x10\x2a\x0a\x6f\xe6\x1d\x9c\x07\x6d\x28\x37\x41\x58\x10\xbd\x36\x5b\xbf\x15\xd1\xed\x56\x29\xa8\x34\xc0\xe3\x84\x22\x56\xcd\x40\x47\xb6\xf4\x84\xd0\x89\x30\xd4\x2a\x78\x47\x28\x80\x56\x62\xe0\x90\xbd\xd3\xe1\x11\x5e\x91\x7d\x81\xdc\x55\x99\xe8\xee\xab\xe5\x5f\xa5\x54\xda\x62\xfb\xfc\xe9\xdf\xe1\x69\x4a\x83\xd7\x94\x11\xf0\x8c\x48\xec\x7e\x05\x3b\x52\x38\x9b\x4d\xb5\x31\x0d\x22\x10\xb1\x31\x43\xe9\x03\xbc\x6b\xe3\xfe\xaa\xc4\x7d\xf9\xfa\xea\x32\xfc\x0f\x1c\xfe\xe5\xfb\x87\x1c\xf8\xe5\xef\x87\x35\xfe\x6f\x36\xf1\x5f\x56\xfc\x63\x4d\x83\x1f\xd6\x34\x68\x04\xac\x99\xc0\x7e\x7a\x48\x9e\xd4\xa6\xd8\x66\xd5\x6b\x5d\x90\x95\x70\x26\x9c\x05\x51\xcb\x51\x07\xb0\x2e\x52\x18\x56\x44\x2a\x95\x90\x17\x30\xc2\xd2\x92\x41\x9c\x08\x1c\x4e\x48\xd4\x2d\x20\x60\x24\x47\x89\x48\x35\x41\x94\xb9\x81\x07\xad\x08\x4d\xba\x99\x15\x91\x31\x11\x0b\xa0\x9b\x08\x54\x88\x36\xfd\xe4\x3c\xa5\x17\x2d\xca\x95\x5c\x16\x75\x9a\x72\x9c\x99\x9f\x0f\x49\x72\x60\x5e\x91\xd2\x25\x1b\xc1\xa6\xf1\xc8\x6c\xca\xd0\xa6\xe3\x0c\xd2\x29\xda\xee\x5d\x22\xaf\xb6\x3a\xec\xe8\x28\xca\x7a\x17\x07\xf4\xab\x64\x33\x6f\xb4\x23\x63\x0e\xf0\xa6\xd1\x9d\x8d\x3a\xbb\x64\x14\x65\x2a\x57\xbc\x42\x22\xa3\x80\x36\xe8\xa8\x4f\x58\x9c\x82\x27\xf4\xb4\x24\x05\x54\xe0\x52\x0c\x44\x1a\xae\x6b\xb1\x1d\xed\x49\x7b\x67\x29\xa8\x0b\xeb\xb7\xb9\xd5\x10\x60\xa5\x32\x6a\x2a\x0c\x52\x6d\x08\xab\x9e\x3b\xdf\x6a\xa0\x72\x68\x29\x3c\xb7\xa1\x96\x03\xbe\xa6\x2a\x67\xe7\x47\x84\x63\x0a\xda\x52\xf9\xb5\xbb\x0e\xcb\xb2\xb7\xb9\xd1\x00\x01\x9e\x6a\x4c\x2c\x35\x76\x80\x1f\x9c\xc7\x4d\x64\x9d\xbd\x5c\x14\xa8\xc9\x52\x3a\xc8\x14\xc2\x42\xee\x4f\x54\x2b\x3c\xf3\xb5\xa8\xe1\x99\xb8\xbf\x5f\x75\x78\xc8\x02\x40\x84\x09\x65\x06\xd8\x25\xc8\xcf\xf0\xd0\x1f\x76\xe4\x88\x2e\x27\x96\x30\x3a\xce\x3b\xe6\xc4\x9e\x56\xec\x2a\xe7\x13\x33\xcc\xf7\x8c\x90\x43\x06\xad\x19\x46\xdd\x0f\x91\x09\x7c\x26\x3c\x63\xc8\x6f\xfc\xb0\xaa\x40\x1d\x2a\x9e\xc1\x63\x9f\x8c\x88\xce\xcf\x97\x4e\x56\x57\x5c\xce\xf0\xc2\x04\xc6\x59\xbc\xb4\x87\xbb\x95\xc2\x72\xff\xd0\x27\xad\xb8\x9b\x0c\x57\xe7\x75\x29\x26\xdf\xc4\xa8\x77\xc2\x5c\xaf\x92\xc9\x7b\xf2\xe3\xb2\xec\x92\x91\x23\xca\xc1\x3a\xe3\xfa\xb9\x21\x8e\x55\xcc\x1b\xbb\x96\xdd\xe4\x5d\xef\xc5\xd8\x36\x89\x3a\xb3\x79\x86\x40\x6d\xfb\x92\x43\xf4\xdf\x8b\x92\x47\x94\x5f\xae\x5b\x14\x29\x00\xc2\xbd\xd5\x73\x99\x42\x74\x63\xee\xb2\x9a\x7d\x3f\x5a\x85\x9e\x9d\x02\x75\xc5\x9e\xba\x41\x8b\x9e\x83\xe7\x3b\x21\x2f\x52\xb9\xc4\xba\x9a\xb9\x37\xc4\x9a\x4b\x63\x7d\xbf\x95\x5f\x93\x66\xf2\xee\x27\x94\x11\xa2\x1e\xb1\xf3\x62\xc4\xb2\x8c\xe1\x91\x21\x9f\x83\x30\x79\xed\xbc\x8e\xfa\x23\xae\xb9\x15\x9c\x49\xe4\xaa\x8d\x16\xeb\xf6\xcc\x37\x20\x5d\x88\x2c\xe2\x98\x54\x8f\x8c\x79\xd4\x6f\xeb\x7a\x91\xb8\x12\xd7\xfa\x72\xc3\x89\x24\xe4\x56\x7c\x5e\x11\xeb\x9e\x1a\xc5\x36\x5e\x59\x8f\x5e\x77\xfc\x20\x1e\x91\x5a\xe3\x63\x9a\xe1\x14\x0e\x70\x4c\xda\xa8\xcf\x9f\x7e\xa5\x16\x2b\xdf\xb4\x2e\x54\x43\xcf\x97\x12\xbe\xd8\x28\xea\x51\xe3\xb0\xad\x6d\x71\x74\x27\xbc\x05\x53\x57\xd8\xf4\xfc\x36\x28\x3d\xd1\xfb\x5c\xc2\xd2\x77\x2a\x49\x11\xb7\xbd\x42\x44\x31\x06\x52\x8a\xeb\xd6\x81\xf4\xd4\x20\x10\x51\x91\xfe\x95\x8e\x6e\xc6\x95\x40\xd2\x73\xf7\x48\x48\xe5\x45\x88\x3e\x65\xfe\x5d\x6c\x6f\x15\x5b\x65\x7c\xaf\x03\x1d\x2c\x07\x4b\xd7\x1d\x56\xd8\x3a\xbb\x5f\x7f\x59\xf6\x13\xc7\x79\x67\x6e\x61\x66\x6b\xf2\x59\x98\xc7\x38\x78\x97\xfa\x61\x5d\xf9\x7a\xb1\x84\x2b\x26\x67\xf4\x2a\x3a\xbb\xe8\x6a\xf9\xa0\xfb\xa1\x2c\xdd\xea\x44\x5a\x52\xed\xf4\xc4\xa0\x0a\x6e\x28\x9b\x25\xde\xd2\x95\xf3\xf8\xf9\x11\x2d\x76\x3a\xae\xac\x41\x69\x52\x22\x45\x11\x76\x76\x5b\x03\x0f\xe5\x12\xce\xe8\xa7\x30\x0a\x6d\x7e\xeb\xe0\x5b\xe7\x16\xe9\x9e\xb1\x8c\xdb\x46\x39\x64\x16\x2d\x4d\xd7\x0e\x44\x04\x01\x74\x91\x1d\xd3\xb8\x6b\xa4\xe7\x3d\x4c\x08\xcd\x25\xac\x5e\x48\x9a\x68\x6f\x6f\xa2\x65\x1f\x6d\x6b\x5c\x59\xda\x50\x6c\xad\xc3\x20\xbd\x3e\x36\x96\x2d\xf6\x2c\xab\x0b\x7a\x54\x44\x6f\x71\x12\x02\xf6\x1e\xfb\x42\x8e\x1d\xa8\xc4\x6c\x4e\x1b\x2e\xbf\xdc\xe8\xff\xab\xe0\x9b\xfd\x02\xb4\x3a\x52\x19\xd5\x6b\x16\x5a\xb5\x27\xdc\x05\x86\xa0\x2a\x46\xd0\x80\x80\x30\xb3\xe2\xfb\xd6\x48\x12\x20\xa0\x47\x8b\xbe\x8d\x19\x29\x02\x93\x11\x4d\xd8\x7e\x9c\x68\x38\xf3\x27\xb8\xee\xca\x3b\x5d\x7b\x89\xb8\x02\x53\x5a\x5e\x07\x3b\xb5\xdc\x47\x1d\x75\x4f\xa7\x7a\x0c\x5a\x51\x2b\xf8\xdb\xc1\x86\x53\x32\x64\x49\xbd\x0b\x5e\x9b\x6a\xd5\x3a\xde\x81\x80\x93\xf0\x97\x90\x43\x52\xd9\xfc\xdf\xeb\xd2\xfe\x64\x6b\xd6\x9e\x71\x81\x7c\xef\x31\x42\x9a\x56\x54\x6b\x97\x36\x5a\x54\x16\x5c\xa6\x47\x21\x3a\x2f\x7a\x6c\xfa\xa5\x0f\xbe\xf4\xdc\xad\x53\x16\x10\xd5\x36\x44\x61\xcc\xea\x3d\xee\x9d\xfa\xe4\x97\x6c\x2c\xee\x3d\x9a\xf5\xce\xcb\x81\x2b\xb4\xa3\x5a\x87\xb2\x8e\xdc\x6a\x33\xa3\x34\x91\x29\x65\xb5\x64\xd3\x2d\xf8\x78\x93\x75\x29\xfd\x5b\x5f\xa9\x61\xc1\x30\xf6\x19\xc7\x61\x6b\xcd\xe1\xd2\x6b\xf5\x94\xbd\x47\xc3\xd8\xd7\xe6\xc0\x4c\xc5\x20\x1f\x29\x5c\x37\x8b\xbc\xb0\xc5\x8d\x2a\x5f\x2a\x80\x8d\xab\xed\x0d\xa9\x58\x3a\x33\x1a\xe0\x31\x9d\x3e\xd3\x07\x3c\xec\xa8\x41\xf4\xc8\x03\x8c\x5a\xc6\x4d\x5c\x5e\x3b\x4b\xc5\x0c\xc9\xea\xb8\xa9\xf2\x6a\x6f\xb3\xed\x0b\xe4\x5a\x3d\xe1\x22\xcf\x5a\xc4\x57\x78\x23\x03\x0a\x3a\x16\xb2\x82\xa3\x8b\x3c\x44\x89\x5a\xf2\x62\x35\x5b\x31\x6a\xb9\x52\x04\x35\x7e\x14\xff\xe8\x96\xdb\xd3\x55\x09\xae\x78\x45\x97\xdc\x8b\x03\x57\x7c\x38\x5c\x69\x59\x5a\x49\xb2\x25\x5c\x7b\xb2\x8c\x41\xe9\xd2\xcb\xba\x19\x27\x72\xdd\xe6\x31\xe6\x35\x49\x03\x5a\x9a\xfe\xa9\xeb\x83\x52\x58\x92\xa5\xf8\xed\x82\x55\xae\x64\x1d\xee\xae\xf4\xd1\x56\xe1\x84\x96\xbc\xb0\x71\xc1\xbc\xb9\x13\xd5\x59\x14\x9b\xd6\x36\x83\xa5\xe9\x6a\xcb\xf6\x62\xc0\xf8\x45\x20\xa8\x4e\x15\x2e\x33\x63\xf1\xaa\x36\x2e\xb6\xeb\x5c\x77\x43\xb5\xdd\xc6\x3b\xa4\x67\xbe\x4d\x2f\x43\xde\x5b\x05\x5f\x8f\x88\x5e\xf0\x85\x99\xa7\xa1\xf1\x8c\x68\xcb\xa9\x24\xa7\x4b\xc6\xec\x83\x14\xa6\x55\xf6\x3a\x62\xcb\x15\x0f\xe4\x80\xb9\xbc\x97\xe9\x23\x74\xda\xd4\xc9\x6d\x60\x3f\xa6\x38\xd0\xf5\x50\xea\x38\xaf\xa2\xf2\x84\xe5\xf7\xb8\x92\xc4\xf0\x92\xcd\x38\xfd\x84\x34\x85\x0f\xce\x5a\xcc\x9d\x17\xd1\x6e\xa8\x77\xdc\x9c\xb7\xcc\x8b\x4b\xe2\x74\xc9\xca\x8b\xd6\xbe\x0c\x8c\xf2\x1c\x5c\xea\x69\xc5\xe0\x08\x06\x05\xf5\xa0\x67\x57\xa9\xa0\x88\x77\x5e\x11\xbf\x3b\x8a\x2f\x67\xdd\x4d\x7f\xd1\x82\x32\x5d\xbb\xd1\x41\x7c\x81\x3c\xba\x3d\x82\xbc\xcc\xa9\xf7\x78\xba\x68\x2a\x44\x78\x2c\x73\xf3\xeb\x01\x47\x9e\xd5\xa0\x99\xeb\x03\x07\x35\x93\xae\x5c\x8a\x4a\x31\x94\x10\xa0\x6f\xfc\xdc\x16\xd1\x31\x45\x9e\x5b\x19\x3d\x6a\x32\x22\xba\x97\x9b\x8b\xe1\xab\x45\x00\xad\xce\x41\x63\xc1\x92\x06\x98\x87\xcd\xda\x0f\x89\x9f\x7b\x1a\x98\xd9\x7e\x2f\xa9\x48\x6b\x8e\x42\x3e\xa6\x29\xcf\xa3\x3c\x5f\xed\xa2\xdb\x5e\x5f\xcb\xf0\xbd\x66\xc8\x7b\xd6\x1c\x5e\x35\xd3\x89\x3a\x09\x79\xea\x8c\x92\xdb\xad\xbb\x0b\xef\x54\xac\xaf\x70\xc1\xf3\xab\xc5\xe7\x89\xbb\xb6\x6c\xe6\x24\xa2\x1c\x30\xdc\x1f\x36\x87\x94\xba\xa4\x5d\xb7\x8b\xe1\xc9\xe5\x13\x7a\xed\x94\x96\x55\xb9\x27\x61\xef\x49\x09\xc2\x5a\x6a\xea\x2e\x47\x47\x87\xeb\x29\xa7\xa8\x4f\x05\x39\x63\xb6\x71\xfd\x16\x0b\x73\xa1\x6a\xea\x9e\x27\x76\xe4\xee\x2b\xf8\xde\x65\x66\x13\x29\xba\x51\x94\x6c\xa7\xb1\x47\x12\x86\x26\xf5\xb8\x94\x2b\x39\x45\x3a\xef\x51\x46\x7a\x41\xa2\xe6\xb1\xeb\xe8\xd1\xef\x84\x66\x3e\x6c\x74\xf8\x1b\x0f\x42\x5d\x5f\xc6\x9f\x24\xa5\xfa\x87\xa7\x8b\x1b\x05\x4f\xc2\xa4\xda\xe3\x2c\xda\x6d\xb8\x81\x0f\x3b\x09\xa3\x29\x7c\x20\x92\xd2\xb1\x65\x9f\xe6\xe0\x65\x3e\x0c\xce\xf6\x8e\x34\x1e\x9d\xd5\xd1\xf9\x42\x64\xd5\x6d\x2b\x4e\xad\x2e\x52\x58\x9f\x30\xdb\xc1\xda\x49\x3b\x53\xde\xb4\x78\x93\x15\x29\x0e\xce\xeb\x8f\xa8\x40\x0e\xc2\xf6\x78\xa1\xc2\x3f\xab\x9e\x64\xcf\xe2\x21\xca\x9d\x85\x3f\xaa\x92\xa8\xae\xc3\xb1\xf4\x1b\xcb\x97\x35\x11\x97\xa1\xdc\xf2\xaa\xf6\xb6\xb6\x0c\xf0\x57\xe7\xe1\xbb\x5f\x50\x26\xce\xa5\x37\x56\x72\x0f\x02\xef\xf2\x83\x10\x6e\x75\xe4\xf8\x88\xf5\x25\xe7\x82\xc1\x68\x5a\x30\x08\xaf\x28\xe9\x73\xa1\x34\x3d\x63\x0e\xa9\x5e\xde\x5e\x51\x95\x71\xa0\x37\xf3\x06\x96\x91\x90\xc7\x8a\xb8\x76\xcf\x70\x4c\xb9\x34\x17\xdb\x26\xef\x8e\x86\x02\x72\x44\x3a\x42\x93\x0f\x54\x92\x3c\x23\x8d\xee\xf6\xfd\xaa\x99\xe2\xb5\x48\x0e\x6f\x49\xd1\x72\xaf\xac\x95\x5e\x01\x95\x0d\x44\x55\x1f\x75\xad\xb6\x48\x43\xd3\xff\xff\xfa\x1e\x94\x98\xcb\x5b\x15\x11\x0d\xb7\x6f\x34\x66\xd2\xb5\x93\x57\xf8\x73\x4e\xcf\x06\x5a\xe8\xe2\x26\xd6\x6b\xeb\x62\x5f\x39\xf6\x00\xdf\x8d\xe8\x7b\xb4\x72\xde\x81\xa4\x71\x9a\xa4\x62\x3a\x7a\x14\x8f\xfb\x4e\xff\x92\xdf\xa7\x3e\xa2\x77\x7b\x25\xe6\x0b\xa8\x60\x7f\x57\x6d\xae\x2d\x10\x01\x7e\x4e\x5a\x3e\x52\x09\xd2\x5d\x33\x04\x7d\x34\xf8\x05\x78\xac\x7d\x2e\x7b\xaa\x23\xfa\x23\x1d\x7b\x9d\x9a\xd5\xd2\xe5\xd7\xea\xa5\x07\x02\x8f\xa3\x3b\x09\x73\xb8\x92\xbd\xf0\xa8\x30\x6d\x5b\xd4\xb9\x1b\xbb\x9f\x7b\x9c\x8c\x90\x17\xc3\xe9\x07\x8c\x48\x04\x51\x9e\x5f\x3c\xd2\x63\xee\xe5\xdc\xf2\xf2\x7a\xb6\x06\x8f\x62\x52\xf1\x81\x04\x7c\x81\xe9\xce\x9f\x1c\x13\xfc\x8f\x4c\x09\xfe\x3b\x00\xbe\x37\xed\x94\xe5\x22\x00\x00")
 
 func complySoc2PoliciesDevelopmentMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -721,12 +742,12 @@ func complySoc2PoliciesDevelopmentMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/development.md", size: 8933, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/development.md", size: 8933, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesDisasterMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x5a\xcd\x6e\x1c\xb9\x11\xbe\xcf\x53\x14\x10\x20\x90\xbc\xa3\x51\xbc\xb9\x04\x0a\x72\x90\xed\x45\xa2\xcd\xc2\x16\x24\x01\x7b\x50\x74\xa8\x26\xab\xa7\x69\xb1\xc9\x0e\xc9\x9e\xf1\x18\x3e\xe4\x35\xf2\x7a\x79\x92\xa0\xc8\x6e\x76\xf7\xfc\x58\x72\x8c\x31\xb0\x81\xe7\xe2\x11\x87\xcd\xfa\xfb\xea\xab\x2a\xb6\x0d\xd6\x74\x01\x6f\x94\x47\x1f\xc8\xc1\x0d\x09\xbb\x22\xb7\x81\x6b\xab\x95\xd8\xcc\x50\x38\x6b\x36\xf5\x05\xbc\xb9\xb9\x9e\x79\x0c\xca\x97\x8a\xfc\xc5\x0c\xe0\xee\xf6\x35\xff\x03\x70\x06\x97\x2f\x17\x3f\x0e\x5f\xff\x38\xab\xf1\xbd\x75\x37\xb4\x52\x5e\x59\x13\x37\x9f\x81\xc4\x40\x17\xf0\x73\x6b\xe0\x25\xfc\xf8\x87\x97\x7f\x8a\xfb\x85\xad\x6b\x32\xe1\x02\xae\x8c\x0a\x0a\x35\x48\x2b\x5a\x5e\x99\x9d\x9d\x9d\xcd\x66\xbf\x83\xeb\xd6\x35\xd6\x13\xa0\x91\x70\x2b\x6c\x43\xb3\x19\x2e\xe0\xae\x22\x68\xba\x5f\x6c\x09\xa1\x52\x1e\x9a\xa8\x2f\x28\x0f\xc1\x82\xa4\x52\x19\x82\x50\x11\x58\xb7\x44\xa3\x3e\x62\x50\xd6\xfc\xe7\x5f\xff\xf6\xd0\x38\x2b\x48\xb6\x8e\xe2\x4e\x97\xec\x85\x2b\x53\x5a\x57\xc7\x5d\x70\x47\xa2\x32\x56\xdb\xe5\x06\x4e\xae\xee\x4e\x41\x99\xd2\xa1\x0f\xae\x15\xa1\x75\x49\x97\xab\x3b\xf0\xe4\x56\x4a\x90\x87\xb5\x0a\x95\x32\xe0\x29\x80\x24\x94\x5a\x19\xf2\xa0\x4c\x94\x2e\x30\xa9\x88\x20\x7b\x0f\x5b\x07\x36\x54\xe4\x78\xc5\xb5\x4d\x50\x2b\x02\x65\x84\x92\x64\x42\x32\xcd\x16\xef\x49\xc4\xf5\x6c\x9c\x46\xd3\x99\x26\x6c\xdd\x68\x0a\xc9\x38\xd7\x47\xcb\x96\xac\xd2\xf3\x14\xc5\xa8\x6a\x0e\xf4\x9d\xaa\x09\xde\x65\x91\x27\x37\x77\xef\x4e\x17\x9d\x9b\x47\x7e\x35\x42\xb7\x92\x3c\xa0\xd6\xe0\xc8\xdb\xd6\xf1\x91\x2c\x22\x3a\xd4\x7b\xf2\x60\x88\xbf\xa0\xdb\x40\x69\x5d\x2f\x37\xee\x91\x18\x30\x6b\x3b\x8f\x4b\xf1\x7b\x3a\x4f\x8d\x9c\xef\x49\xb4\x4e\x85\x0d\xa0\x6f\x48\x04\xcf\xa6\x15\xad\x67\xa7\x7a\x10\xd6\x04\x65\x5a\xfe\xb9\x46\x83\x4b\x62\xac\xec\x2a\x8b\x4d\xa3\x55\x8a\x2f\x1f\x3f\x6c\x9d\x03\xd5\x8d\xb6\x1b\xea\x54\xf7\x6d\xdc\xe9\x3c\x84\x0a\x03\xa0\xe3\x50\xac\xac\x5e\x91\xec\x23\xf8\xb4\x8b\xb7\xfd\xbb\x8d\xba\xa9\x6e\x75\xeb\x03\x14\x04\x35\x4a\x3e\x1c\xa5\xd2\x1b\xc0\x15\x2a\x8d\x85\xa6\x5e\xe5\x75\x65\x6b\x50\x61\x64\xc9\x82\xd3\xe1\x15\x8a\xc7\xa5\xb3\xad\x91\x3b\x36\x27\xc8\xfb\x24\x7d\x45\x8e\x4f\xc9\x98\xcb\x46\xf8\xe0\x30\xd0\x32\x05\x68\x9f\xa2\x34\x6c\x91\xe4\x85\x53\x45\x7f\xe6\x76\x1e\x7d\x16\x40\x73\x58\x57\x4a\x54\x8c\xd9\xa4\x98\x04\x4c\xe7\xc8\xd6\xa5\x40\x33\xb6\xf9\xc9\x91\x0b\x41\xd3\x8a\x74\xd4\x4d\x38\x15\x94\x40\x3d\xc4\x7e\x80\x59\xb0\xec\x40\x47\x3e\x58\xc7\x07\x97\x6c\xe2\x13\x19\x46\xab\x18\x7e\xf4\xb0\x26\xad\x7b\x65\x06\x2e\x98\xf3\x79\x8d\x35\x5e\x15\x4a\x47\xf8\x19\x09\x81\x89\x20\x6a\xb1\x6c\x95\x44\x23\x58\xea\x3f\x5b\xc5\x52\x83\x85\x9a\x28\xc4\x63\x6e\xee\xde\x6d\x01\x50\x7b\x0b\x5a\xf9\x90\xc4\x30\x6c\x51\x84\x09\xce\xd9\xc8\x86\x9c\xb7\xc6\x90\x9e\x38\xa1\x71\x76\xa5\x64\x06\x65\x8d\x1b\x36\xd7\x10\x49\x92\xec\x3e\x65\x96\x63\x6b\x73\x6c\xa3\x89\x8b\x4c\x90\xa5\xd5\xda\xae\x79\xb3\xb0\x46\x2a\x16\xea\x07\xf4\x51\xe8\x20\xd0\xb3\x4b\x72\xea\x4a\x31\x0a\x2f\x66\x91\x9d\xd5\x02\x2e\xb5\x06\xb6\xb8\x49\xd9\xe3\x6d\x19\xd6\xe8\x46\x39\x7d\x92\x70\xa4\x1c\x14\x28\x1e\xdb\xc6\x9f\x97\xa8\x74\x4c\xed\xd3\x98\x4f\x03\xb4\x99\x23\x6d\xcd\xc8\x37\x86\xdc\x22\xcb\xb8\x2a\x81\xd9\xad\xa3\x40\x08\xf8\x48\x51\x27\xa6\x8e\x70\x80\xc3\xab\x8d\x8f\x71\xd1\x56\xc4\xd5\xf9\x16\x2f\x8d\xb3\x78\x70\x50\x59\x5a\x17\x7c\x52\xab\x4b\xb6\x82\x20\x38\x34\xbe\x24\xd7\x45\x15\x0d\xa0\x0e\xe4\x0c\x06\x82\xb5\x75\x8f\xe0\x55\x20\x38\xf1\xad\xa8\x3a\xdc\x28\x07\x15\x5b\x62\xcb\x52\x09\x3a\xdd\xa6\x65\xe5\x40\xb6\x41\x91\x1f\x6c\xe4\x80\x8c\x4b\xcc\x6d\xcf\x72\xef\xe2\x11\x8e\x13\x25\x03\x50\x53\x4a\x01\x6b\x9d\x54\x06\x43\x0c\x78\x64\x4c\x23\x5b\x91\xfe\x84\x42\x9d\xa1\x31\x2d\x6a\x38\xc1\x00\x9a\xd0\x87\x53\x70\x54\x11\x3a\x8f\x3a\x57\x8e\x11\x63\x72\x98\x17\x30\x22\x0e\x0e\xbb\xb4\xcc\xdb\x36\x24\x3a\x8e\xde\x1e\x80\x13\x36\x0d\x45\x02\xee\x83\xe3\x07\x64\x5c\xf5\x4b\x1d\x75\x96\x25\x89\x00\xa2\xf5\xc1\xd6\x8c\x5d\x86\x37\xba\x60\xf8\x7b\xd1\x06\xa8\x70\x45\x60\x2c\x07\x81\x37\xda\x5d\x9e\x8c\x91\xf5\x1b\x1f\xa8\xf6\x7f\x4e\xf4\xcb\x06\xa0\xa7\x79\xca\xa2\xee\xec\x04\xe2\x44\xe4\x9d\xbb\xed\xda\x4c\x2c\x1d\x53\x45\x8d\x8f\x04\x9e\xc9\x3a\x2a\x1a\x2a\xda\x80\xc0\xbc\x3f\xa2\x40\x99\x40\x8e\x33\x94\x29\x7c\x47\xb1\xe8\x7c\x15\xb2\x72\x8b\xa7\x9c\xa0\x6d\x2b\xb7\x2b\xc5\x50\x6a\x3a\x50\x0b\x1b\xab\xcd\x64\x53\xe4\xbf\x79\x57\x6c\x39\x02\xec\x39\x8e\x8e\x56\xb5\x0a\x09\x9f\x7f\xb5\x76\xa9\x69\x0e\x7f\x23\x67\x1f\xdb\x54\x4b\x2f\x6b\xfc\x68\x0d\xfc\x4a\x05\xdc\x76\xd5\xa8\x6b\x25\xc6\x66\x48\x6a\xc8\x48\xcf\xbe\x8f\x60\x1e\x55\x3f\xfb\x3c\x7f\xc6\x22\xd4\x75\x86\x8c\xa3\x17\x37\xd4\x67\xe0\x8b\x71\x3e\x1f\x68\xbc\x54\xcd\xbd\xc1\x90\x53\xca\x43\x6b\x32\x41\xcc\x0f\xa5\x9e\xaf\x38\xb9\x0b\x82\xd6\x93\x84\x22\x56\x26\xb5\xe4\x4d\x72\xa0\xd0\x5d\x7b\xa3\xd0\x7d\xe7\x29\x9f\x78\x83\x6b\x47\x80\x7b\xe6\xe9\xa8\x31\x4a\xe9\xb8\xd2\xd8\x64\xc0\xbe\x47\x7b\x08\x4d\x1d\xbb\x56\x5a\xb3\x72\x0f\xd3\x84\x1f\xe8\x7d\x5c\x35\x1c\x35\xd6\x05\xfe\x76\x48\xc8\x2e\xc7\xe7\xee\x6b\x50\x76\x38\x3c\xa8\xa0\x69\x97\x3e\x92\x9c\x98\xc6\x07\x45\x3d\xa4\x72\xf1\xe2\x75\x5f\x6d\x7b\xf4\xcc\xe1\xef\xb4\x81\x3b\xf4\x8f\xb1\x51\x9a\xf7\x3f\xc0\x2f\xb1\x42\x5f\x2e\x1d\xc5\x86\xca\xc3\xc9\xed\x2f\x97\xfe\xf4\xc5\xc4\xf0\x81\x41\x72\x6f\x94\xcb\x29\xf7\xd5\xdc\xd1\x30\x09\x0b\xd6\x57\x52\xe7\x98\x82\x86\xaa\xcf\x06\xe4\xca\x6f\x1b\x4a\x3d\x83\x4f\x68\x8f\xf9\xaf\xea\x9a\xa4\xc2\x40\x7a\x33\xe9\x06\x4e\x94\x61\xa4\xd9\xc8\xae\xd6\x49\x72\xa7\x3c\x80\x44\xf5\xf8\xf3\x72\x31\x72\x62\x96\x77\x58\xcf\x54\x83\x59\xa2\xa3\x1a\xb9\x9c\xb4\xc6\xb0\x69\x3b\x51\x7a\x38\xe0\x83\x47\xda\x40\xe8\x3d\x09\xec\xae\x5c\x86\x47\x0e\x78\xaa\xb0\x33\x2b\x00\x0a\x61\x5d\x6a\x44\xf6\x32\x55\x84\x7c\x1e\x1f\xd8\x5d\x39\x50\xc9\x75\x9a\x96\xa8\xe7\x31\xb7\x99\xef\xb8\x7c\x44\xa8\x2c\x5b\x8d\xc1\x72\x97\x5b\x68\xb5\x4c\xce\xee\xb8\x7e\xe2\x34\x5b\x8e\xcc\x39\x4f\xc6\x0c\x1e\x2a\x08\x1e\xa9\x09\x43\xc0\x58\x56\x54\x94\xc1\xd9\xf5\x87\x79\x46\x7a\xc8\xad\xca\x97\xf5\x96\x9c\xbf\xf7\xbe\x6b\xbd\x6a\xfc\xa0\xea\xb6\x06\xac\x6d\x6b\x42\x6e\x2a\x0b\x2a\x99\x5e\x73\x7c\x87\x8a\xd0\x2b\xda\x03\x66\x9e\xf8\x3f\xe6\x17\xb8\x4c\x67\xd1\x5b\x4b\x0a\x31\x81\x76\x60\x72\x3e\x81\x08\x77\x3e\x60\x0d\x1b\xf5\xb0\x80\x9b\xe9\x19\x49\x4e\x6e\x79\x9f\x81\xb8\x01\x1c\xa9\xa5\x90\xc3\x60\xc1\x23\x20\x9b\xd7\x90\x53\x56\x76\xc9\xfb\xd6\x06\x55\x2a\x91\x45\x5c\x73\x5d\x4f\x03\xf5\x94\x98\xa7\xb0\x1c\x08\xa4\x17\x68\xe2\x41\x2c\xaf\x22\x33\x1d\x3c\x55\x3a\x8e\x64\x06\x45\x86\x04\x73\xf3\x70\xd6\xc9\x50\xbb\x12\x2f\x9d\x4e\xf1\x91\x65\xd8\xb2\x3b\x3c\x2b\x0a\x43\x02\xdd\xa7\x03\x77\x38\xcd\x8c\x4c\xf5\xe3\x3a\x19\x65\x3d\xec\xeb\xa2\xe2\x23\x9b\xb8\x67\xc2\x9a\xac\x3b\x17\x80\xc2\xae\xa8\x73\x64\x74\xdc\x1b\x42\xc6\xda\x8e\xeb\x7a\x67\x44\x43\xac\x49\xac\x23\xfb\xcd\xa9\x2e\xf5\x6a\xe7\xd6\x3e\xcd\x84\x80\x6d\xa8\x12\x21\xc5\xbb\x89\xfe\x99\xa4\x91\x46\x33\xb6\xe4\xbd\x2d\x3a\x6b\xc6\x6d\x46\x62\xb2\xbd\x2d\xfb\x48\x87\xbe\x0b\x1e\x00\xc8\xbf\xa8\xa0\x3a\x98\x6d\x83\x0f\xce\x07\xe4\xf5\x49\xcd\x2d\xae\xa4\x80\x4a\xf7\xde\xc9\xb1\x2b\x5b\xad\x37\x23\x58\xa2\x91\xe7\x91\x3d\x52\x26\xed\xad\xfc\x1c\x12\x1f\xb8\x3e\x76\xa4\x60\x96\x91\xc8\x0c\xa8\xba\xc1\x58\x13\xbd\x20\x83\x4e\xd9\x79\xf6\x47\x9c\x79\xd2\x53\x3d\xa5\x60\x22\x80\xae\xfa\x4a\xe5\x85\xa3\x1e\xf0\xc7\x72\xfb\x57\xe4\xcb\x28\x28\xfb\x68\xf4\x2b\x73\x66\x40\xe8\x7e\x0e\xed\x3a\x64\x23\x09\x57\x8c\x18\xdb\xc7\x28\x76\xb1\x86\xa7\x10\xdd\xcd\xda\xe3\xdb\x95\xa1\xce\x32\x08\xbc\x65\x00\xf1\x48\xeb\x63\x36\xa5\x24\xb9\x84\xbe\x0e\x38\xd2\xb4\x42\x13\xa0\xb1\xca\xa4\x7b\x9a\x7e\xce\x2d\x6c\xa8\x52\x4f\x6d\x30\xcd\xb6\xf4\xa1\xfb\xa3\xeb\x45\xb6\x21\x42\x46\x68\xeb\xd3\xbc\x76\xd9\x70\x9b\xaa\x3e\xc0\x65\x12\xf9\xa6\xaf\x8c\xc2\x29\xaf\x38\xed\x03\x3f\xb2\x52\xa1\x6b\x14\x04\xb9\x80\xe3\x41\x2f\x21\x39\xa5\x47\x43\x8e\xc7\x2e\x92\xe0\xd4\xb2\x0a\x80\x6b\xdc\x6c\x87\x96\xfd\x19\xad\x76\x3c\x7d\xd3\x59\x07\x9c\x8f\xc3\x2d\x50\x2c\xc1\x5b\x17\x79\xd3\xfa\x3c\x4c\x46\xf7\x03\x96\x52\x20\x78\xa2\x8d\x21\xf7\x81\x1a\x0f\x5c\x09\x53\x3c\x7b\x4e\xdf\x3d\xb2\xa3\xc6\x2e\xed\x47\xf7\x94\x7b\x2b\xfe\xf8\x5e\x61\x94\xd0\x9f\xb9\xab\x5a\x44\x65\xf7\x69\xac\x3c\x8c\xcc\xef\xe7\xa7\xd6\x2d\xd9\x01\x4d\xeb\x44\x85\x3e\x0d\x85\xf9\x5e\x60\x7a\x0b\xd6\x36\xfc\xd4\x7d\x2a\xc8\x0f\x5f\x20\x48\xd8\xba\x6e\x0d\xb3\x7b\xd7\xdd\x08\xad\xb8\x73\xf9\x9a\x23\x62\xaa\xb7\x85\x56\xe2\x6b\x4e\x49\x27\x64\x3e\x61\x3a\xed\x6f\x03\x7c\xe0\x5d\xb1\xb5\xb2\xf1\xa2\x88\xa3\x64\x52\x6b\x1c\x57\x71\x0d\xc4\xe1\x11\xdd\x5d\xe5\x17\x68\x91\xf2\xb1\xd3\xe1\xde\x60\xbc\x71\xc8\x53\xdb\xb9\x6d\x43\xbc\xee\x88\xe4\x94\x46\xed\x6d\x77\xa3\x94\xe7\xb5\x95\xaa\xdc\x9c\x3b\xaa\x99\xcb\x7b\x19\x5d\x9e\xf7\x08\xf4\x24\x52\xc1\x18\x5d\xe2\x26\x6e\xb9\x4d\x68\x15\xe3\x3b\x44\x46\x31\x27\xde\xe4\xde\x36\xcd\xc6\x9f\xbd\x20\x4d\xf9\x15\x6f\xb7\xa6\x99\xfe\x6a\x31\x9b\xfd\xa3\xc1\x25\x15\x8e\xf0\x11\x78\xca\x1c\x58\xe0\x82\x5b\xaa\x44\x35\xd7\x99\x6a\x5e\x27\xaa\x99\xcd\xae\x7a\x9a\xe9\x56\xfc\x6c\xf6\xc3\xd9\xce\x67\xcf\xd2\xbe\xb5\x67\x2d\xfd\x30\xfb\xd4\xf1\xf9\x5b\x0e\x49\xfa\xa4\xa5\x9f\x6d\x01\x77\x1c\xcf\xbc\x76\x5d\x59\x43\xf0\xb6\xad\x0b\x72\x69\xe9\xa7\x1a\x95\x86\xcb\x6e\xd6\x04\xf8\x74\x99\xe7\xb2\xce\x84\x4f\xb3\x1f\xfe\xb2\xf3\xd9\xb3\xb4\x6f\xed\x59\x4b\x83\x05\xa3\xcf\x9e\xa5\x7d\x6b\xcf\x5a\xfa\x74\x7c\x01\xdf\x2c\xc8\x47\xb3\xe0\xb7\xef\xa2\xd9\x4f\x1f\xbe\x27\xdf\xf7\xe4\x3b\x62\x90\x8f\x66\xc1\x6f\xdf\x45\xa3\x82\x39\xa9\x97\xaf\x2e\x86\xeb\x93\xdb\x5c\xaa\x27\x2f\x1f\xba\x52\x7d\x35\x2d\xd5\xbf\xcf\xb7\x7f\xb3\xd9\x6e\xd5\x1f\xbd\x36\xe7\x32\xde\xbf\x1c\x8c\x75\x3c\xcd\x8b\x50\x90\xb6\xeb\x8b\xbd\x14\xf0\x79\x27\x1c\x5e\xde\x36\x3a\x79\x7a\xf8\x4f\x0a\xbd\x52\x7d\x18\xae\xd3\x24\x78\x33\xba\x04\x18\x96\x4f\xfc\x29\xbc\xed\x67\xa9\x5f\x79\x66\x7b\xdd\xbf\xb5\x81\xbd\x69\xff\x79\x02\x38\xbc\xbc\x9b\xf0\xc9\xe5\xf9\xcd\x65\x54\x9f\xe4\xc5\x21\xd8\x1d\x5e\x7e\x62\xd3\x3e\x5c\x3f\x79\xe6\xff\x26\x0a\x8e\x1b\x67\x9e\xe1\xe0\xe5\xc5\x11\xcc\x38\xa2\x8f\x8e\xe8\x92\xb1\x9c\xde\x3d\x3f\x1e\xc3\x3d\xdf\x0e\x43\xc7\x05\xd0\xf7\x94\xfb\x52\x8f\x1d\x2d\xe5\x8e\xec\xa2\xff\x8b\xac\xfb\x66\x38\x3a\xa2\xb7\xfe\x1b\x00\x00\xff\xff\xf3\xab\x76\xcb\x4c\x28\x00\x00")
+var _complySoc2PoliciesDisasterMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x59\xdd\x6e\x5c\xb7\x11\xbe\xdf\xa7\x18\x20\x40\x21\x25\xab\x55\x9d\xde\x14\x2a\x7c\xa1\xd8\x41\xab\x34\xb0\x05\x49\x40\x2e\x5c\x5f\xf0\x90\x73\x76\x27\xe2\xe1\x9c\x90\x3c\xbb\x5e\xc3\x17\x7d\x8d\xbe\x5e\x9f\xa4\x18\x92\xe7\x67\x7f\x2c\x2b\x75\x95\x16\x85\x17\x01\xb2\xa6\x28\x72\xe6\x9b\x6f\xbe\x99\xa1\x9c\x6a\xf0\x02\x5e\x52\x50\x21\xa2\x87\x1b\xd4\xbc\x46\xbf\x85\x6b\xb6\xa4\xb7\x33\xa5\x3d\xbb\x6d\x73\x01\x2f\x6f\xae\x67\x41\x45\x0a\x35\x61\xb8\x98\x01\xdc\xdd\xbe\x90\xff\x01\x9c\xc1\xe5\xb3\xc5\xb7\xe3\xd7\x3f\xcc\x1a\xf5\x33\xfb\x1b\x5c\x53\x20\x76\x69\xf3\x19\x18\x15\xf1\x02\x7e\xe8\x1c\x3c\x83\x6f\x7f\xff\xec\x8f\x69\xbf\xe6\xa6\x41\x17\x2f\xe0\xca\x51\x24\x65\xc1\xb0\xee\x64\x65\x76\x76\x76\x36\x9b\x7d\x05\xd7\x9d\x6f\x39\x20\x28\x67\xe0\x56\x73\x8b\xb3\x99\x5a\xc0\xdd\x0a\xa1\x2d\x3f\xe1\x1a\xe2\x8a\x02\xb4\xc9\x5e\xa0\x00\x91\xc1\x60\x4d\x0e\x21\xae\x10\xd8\x2f\x95\xa3\xf7\x2a\x12\xbb\x7f\xfe\xfd\x1f\x01\x5a\xcf\x1a\x4d\xe7\x31\xed\xf4\xd9\x5f\xb8\x72\x35\xfb\x26\xed\x82\x3b\xd4\x2b\xc7\x96\x97\x5b\x38\xb9\xba\x3b\x05\x72\xb5\x57\x21\xfa\x4e\xc7\xce\x67\x5b\xae\xee\x20\xa0\x5f\x93\xc6\x00\x1b\x8a\x2b\x72\x10\x30\x82\x41\x65\x2c\x39\x0c\x40\x2e\xdd\xae\x55\x36\x51\x81\xe9\x11\x66\x0f\x1c\x57\xe8\x65\xc5\x77\x6d\xa4\x35\x02\x39\x4d\x06\x5d\xcc\xae\x71\xf5\x33\xea\xb4\x3e\x38\x67\x95\x2b\xae\x69\x6e\x5a\x8b\x31\x3b\x57\xac\xdf\xca\x15\x57\x77\x8f\x34\x54\x25\x53\x87\x40\xdf\x51\x83\xf0\x7a\xb8\xf2\xe4\xe6\xee\xf5\xe9\xa2\xc0\x3c\xc1\xd5\x69\xdb\x19\x0c\xa0\xac\x05\x8f\x81\x3b\x2f\x47\xca\x15\x09\xd0\x10\x30\x80\x43\x8d\x21\x28\xbf\x85\x9a\x7d\x7f\x6f\xc2\xcb\xa8\xa8\x06\x6b\xe7\x69\x29\x5d\x9f\xcf\xa3\x09\xf8\x01\x75\xe7\x29\x6e\x41\x85\x16\x75\x0c\xe2\x5a\xd5\x05\x01\x35\x80\x66\x17\xc9\x75\xf2\xe3\x46\x39\xb5\x44\xe1\xca\xa1\xb1\xaa\x6d\x2d\xe5\xf8\xca\xf1\xe3\xd6\x39\x60\xd3\x5a\xde\x62\x31\x3d\x74\x69\xa7\x0f\x10\x57\x2a\x82\xf2\x12\x8a\x35\xdb\x35\x9a\x3e\x82\x9f\x86\x78\x1f\xdf\x7d\xd6\xed\xda\xd6\x74\x21\x42\x85\xd0\x28\x23\x87\x2b\x43\x76\x0b\x6a\xad\xc8\xaa\xca\x62\x6f\xf2\x66\xc5\x0d\x50\x9c\x78\xb2\x90\x74\xf8\x4e\xe9\xfb\xa5\xe7\xce\x99\x03\x9f\x33\xe5\xc5\x11\x04\x41\x56\x4e\x19\x38\x37\x38\x11\xa2\x57\x11\x97\x39\x40\xc7\x0c\xc5\x71\x8b\xc1\xa0\x3d\x55\x18\x0e\x36\xa6\x3c\x7a\x90\x40\x73\xd8\xac\x48\xaf\x84\xb3\xd9\x30\x03\x2a\x9f\x63\x3a\x9f\xce\x10\x3c\x23\x35\x3b\x10\x82\xc5\x35\xda\x64\x9b\xf6\x14\x49\x2b\x3b\xc6\x7e\xa4\x59\x64\x01\xd0\x63\x88\xec\xd1\x80\xaa\x25\xad\x3e\x91\x61\xb8\x4e\xe1\x57\x01\x36\x68\x6d\x6f\xcc\xa8\x05\x73\x21\x75\xcb\x2e\x50\x45\x56\xf8\x25\x66\x45\x11\x82\x64\xc5\xb2\x23\xa3\x9c\x96\x5b\x7f\xe9\x48\x6e\x8d\x0c\x0d\x62\x4c\x3e\xdd\xdc\xbd\xde\x0d\x86\xb2\x81\xc1\x52\x88\xf9\x1a\xa1\xad\xd2\x71\x87\xe7\xe2\x64\x8b\x3e\xb0\x73\x68\x77\x40\x68\x3d\xaf\xc9\x0c\xa4\x6c\xd4\x56\xdc\x75\x88\x06\x0d\x98\xce\x93\x5b\x82\x3a\x12\x5b\x5c\x4f\x92\x01\xa1\x66\x6b\x79\x23\x9b\x35\x3b\x43\x72\x69\x18\xd9\x87\xb1\x50\xa0\x57\x97\x0c\xea\x9a\x84\x85\x17\xb3\xa4\xce\xb4\x80\x4b\x6b\x01\x7f\xe9\xa8\xcd\xd9\x13\xb8\x8e\x1b\x55\x78\x9f\x72\xfa\x24\x1d\x82\xe4\xa1\x52\xfa\xbe\x6b\xc3\x79\xad\xc8\x0a\x01\xc3\x69\xca\xa7\x91\xda\xa2\x91\xdc\x08\xf3\x9d\x43\xbf\x18\xee\xb8\xaa\x41\xb9\x41\x02\x21\xaa\x7b\x4c\x36\x89\x74\xc4\xe3\xdc\x6b\x57\xdb\x90\xe2\x62\x59\x27\x36\xcd\xf7\x74\x69\x9a\xc5\x23\x40\x75\xcd\x3e\x86\x6c\x56\x49\xb6\x0a\x21\x7a\xe5\x42\x8d\xbe\x44\x55\x39\x50\x36\xa2\x77\x2a\x22\x6c\xd8\xdf\x43\xa0\x88\x70\x12\x3a\xbd\x2a\xbc\x21\x0f\x2b\xf1\x84\xeb\x9a\x34\x9e\xee\xcb\x32\x79\x30\x5d\x24\x0c\xa3\x8f\x92\x58\xd3\x12\x73\xdb\xab\xdc\xeb\x74\x84\x97\x44\x19\x08\x68\x25\x78\x1e\x34\xb3\x37\xe4\x54\x94\x18\x0a\xe0\x12\xc7\x4e\xe7\x7f\x42\x45\x67\xca\xb9\x4e\x59\x38\x51\x11\x2c\xaa\x10\x4f\xc1\xe3\x0a\x95\x0f\xca\x0e\x95\x63\xa2\x98\xad\x55\x6e\x01\x13\xe1\x90\xb0\x1b\x16\xdd\xe6\x08\x09\x23\x88\x3b\xc4\x89\xdb\x16\x83\x1c\xd5\x07\x27\x8c\xcc\xb8\xea\x97\x8a\x74\xd6\x35\xea\x08\xba\x0b\x91\x1b\xe1\xae\xd0\x5b\xf9\xe8\xe4\x7b\xd5\x45\x58\xa9\x35\x82\x63\x09\x82\x6c\xe4\x43\x9d\x4c\x91\x0d\xdb\x10\xb1\x09\x7f\x92\xc0\x65\x07\x54\xc0\x79\xda\xdb\x9f\x9d\x49\x9c\x85\xbc\xc0\xcd\x1b\x37\xad\x0d\x3b\x52\xd1\xa8\x7b\x84\x20\x62\x9d\x0c\x8d\x2b\xdc\x82\x56\xc3\xfe\xc4\x02\x72\x11\xbd\x64\xa8\x94\xc8\x03\xc3\x52\x76\x52\x1c\x8c\x5b\x7c\x0a\x04\xcb\x9d\xd9\xaf\x14\x63\xa9\x29\xa4\xd6\xec\x71\x7f\x53\xd2\xbf\x79\x29\xb6\x12\x67\x41\xce\x71\x04\x4b\x0d\xc5\xcc\xcf\x3f\x33\x2f\x2d\xce\xe1\x2f\xe8\xf9\xbe\xcb\xb5\xf4\xb2\x51\xef\xd9\xc1\x4f\x58\xc1\x6d\xa9\x46\xa5\x95\x98\xba\x61\xb0\x45\x67\x02\x48\x95\x15\x32\x4f\xaa\x1f\x3f\x0e\xcf\x54\x84\x4a\x67\x28\x3c\xfa\xfa\x06\xfb\x0c\xfc\x7a\x04\xa5\x3e\x1e\xda\xd6\x53\x23\xbd\xc1\x98\x53\x14\xa0\x73\x83\x40\xcc\x3f\x96\x7a\x61\x25\xc9\x5d\x21\x74\x01\x0d\x54\x5b\x30\x18\x68\x29\x9b\xcc\x28\xa1\x87\xfe\xa6\x4b\x8f\x9d\x47\x01\x92\xd5\x52\x3b\x22\xbc\x11\x9d\x4e\x16\x2b\x63\xbc\x54\x9a\x94\x3b\x78\xd4\x94\x9e\x42\x3b\x17\xc1\x86\xac\x15\xe3\xde\xee\x26\xfc\x28\xef\xd3\xaa\xe1\xb1\x65\x1f\xe5\xdb\xc7\x2e\x39\xd4\xf8\xa1\xfb\x1a\x8d\x1d\x0f\x8f\x14\x2d\x1e\xca\x47\xbe\x47\x48\xf4\xf1\xab\xc4\x60\x09\xe3\x8b\xbe\xda\xf6\xec\x99\xc3\x5f\x71\x0b\x77\x2a\xdc\xa7\x46\x69\xde\xff\x00\x7e\x14\x86\xc2\xe5\xd2\x63\xea\xbd\x02\x9c\xdc\xfe\x78\x19\x4e\xc7\xe8\xef\x96\x9e\x52\xd3\xc2\x58\x4e\xa5\xaf\x96\xde\x4c\x44\x58\x8b\xbd\x06\x0b\x30\x15\x8e\x55\x5f\x1c\x18\x2a\x3f\xb7\x98\x7b\x86\x20\x14\x31\x39\xff\xa9\x69\xd0\x90\x8a\x68\xb7\x3b\xdd\xc0\x09\x39\x68\x3d\x71\xea\x21\xd9\x1b\xf4\xa7\x32\x80\x24\xf3\xe4\xbf\x67\x8b\x49\xc4\x87\xfb\x3e\x6e\x67\x8a\x78\xba\xd1\x63\xa3\xc8\x81\xef\x9c\x13\xd7\x0e\xa2\xf4\xf6\x23\x18\xdc\xe3\x16\x62\x8f\x24\x08\x5c\x43\x19\x9e\x00\xf0\xa9\xc2\x2e\xaa\x00\x4a\x6b\xf6\xb9\x11\x39\xaa\x54\x89\xf2\xc3\xf8\x20\x70\x0d\x81\xca\xd0\x59\x5c\x2a\x3b\x4f\xda\x27\x7a\x27\xe5\x83\xe5\xa6\x65\x67\x55\x64\xbf\x05\xae\x2c\x2d\xd3\x59\xbd\xd6\xef\x80\xc6\xf5\xc4\x9d\xf3\xec\xcc\x88\x50\x85\x70\x8f\x6d\x1c\x03\x26\x77\x25\x43\x85\x9c\xa5\x3f\x1c\x66\xa4\xb7\x43\xab\xf2\xeb\x7a\x4b\xa9\x95\x6f\x42\x69\xbd\x1a\xf5\x8e\x9a\xae\x01\xd5\x70\xe7\xe2\xd0\x54\x56\x58\xb3\x9f\xc4\x77\xac\x08\xbd\xa1\x3d\x61\xe6\x92\x89\x25\xbf\xc0\x0f\x72\x96\xd0\x5a\x62\x4c\xd5\xf6\x80\x26\xe7\x3b\x14\x91\xce\x07\xd8\x89\x53\x6f\x17\x30\x4a\x62\x3a\x23\xdf\x33\xb4\xbc\x8f\x60\x5c\x6f\x61\xdf\x52\x98\x71\xb0\x90\x11\x50\x7a\xe6\x16\x3d\xb1\x29\xc9\xfb\x8a\x23\xd5\x54\xae\xe4\x1a\xae\xa5\xae\xe7\x81\x7a\x57\x98\x77\x69\x39\x0a\x48\x7f\xa1\x4b\x07\xa1\x81\xcd\x0a\xcb\x6d\xfd\xe0\x49\xf9\x38\x34\x03\x29\x06\x4a\x28\x6b\x27\x67\x9d\x8c\xb5\x2b\xeb\xd2\xe9\x2e\x3f\x86\x3b\xb8\x2e\x53\xed\x60\x28\x8c\x09\xf4\x26\x1f\x78\xa0\x69\x6e\xe2\x6a\x98\xd6\xc9\x74\xd7\xdb\x63\x5d\x54\xfa\x95\x6d\xda\xb3\xa3\x9a\x62\xbb\x14\x80\x8a\xd7\x58\x80\x4c\xc0\xbd\x44\x25\x3c\x3d\x80\xae\x07\x23\x39\xc2\x2e\xab\x8e\xe9\x37\xe7\xba\xd4\x9b\x3d\xb4\xf6\x79\xe6\x06\xd5\xc5\x55\x16\xa4\xc8\x93\xdf\x49\xe9\x2b\x20\x4c\x3d\xf9\x99\xab\xe2\xcd\xb4\xcd\xc8\x4a\x76\xb4\x65\x9f\xd8\xd0\x77\xc1\x23\x01\xe5\x26\x92\x56\x34\x71\x71\x9f\x7c\x70\x3e\x32\xaf\x4f\x6a\x69\x71\x0d\x46\x45\xb6\x47\x67\x88\x5d\xdd\x59\xbb\x9d\xd0\x52\x39\x73\x9e\xd4\x43\x18\x8e\xe6\x68\xe5\x97\x90\x84\x28\xf5\xb1\x88\x82\x5b\x26\x21\x73\x40\x4d\xab\x52\x4d\x0c\x1a\x9d\xf2\xc4\xf3\x01\x8f\x34\xf3\xe4\xdf\xea\x25\x25\xf9\x38\x0c\x04\x86\x82\xf6\xd8\x13\xfe\xa9\x60\xff\x8c\x7c\x99\x04\xe5\x98\x8c\x7e\x66\xce\x94\xb3\x89\xdd\x71\x0d\x2d\x1d\xb2\x33\xa8\xd6\xc2\x18\xee\x63\x94\xba\x58\x27\x33\xa8\x2d\xb3\xf6\xf4\x75\x65\x90\xed\x20\x24\x08\x2c\x04\x92\xf7\x85\x90\xb2\x29\x27\xc9\x25\xf4\x75\xc0\xa3\xc5\xb5\x72\x11\x5a\x26\xe9\x80\xb9\x1e\xe6\xdc\x8a\xe3\x2a\xf7\xd4\x4e\xe5\xd9\x16\xdf\x95\x7f\x44\x3e\x4a\x11\x74\xda\xb2\x74\x77\xe4\xe0\xb2\x95\x36\x95\xde\xc1\x65\xbe\xf2\x65\x5f\x19\xb5\xa7\x40\x92\xf6\x51\x58\xb5\xa6\x58\x1a\x05\x8d\x3e\xaa\xe9\xa0\x97\x99\x9c\x5b\x8a\x16\xbd\x8c\x5d\x68\xc0\xd3\x72\x15\x41\x6d\xd4\x76\x3f\xb4\x82\xa7\x4c\xc7\xd2\x93\xb4\x1e\xcf\x0a\x71\xde\x8f\xaf\x40\x69\xb6\xde\x7b\xc8\xdb\xad\xcf\x25\xcc\xb4\x80\x37\x23\x97\x72\x20\x64\xa2\x4d\x99\x19\x22\xb6\x01\xa4\x12\x66\x9d\xed\x35\xfd\xf0\xc8\x22\x8d\x25\xed\x27\xef\x94\xfb\xe0\xa5\x72\x39\x7d\x57\x98\x24\xf4\xde\x70\x31\x79\x63\x08\x8b\x64\xec\x31\x8b\x29\xf4\x79\xf3\xbe\xbc\x72\x88\xf5\x9d\x5f\x0a\x00\x6d\xe7\xf5\x4a\x49\x19\xe5\x7a\x7c\x17\x98\xbe\x5e\x04\xe8\x5a\x21\xdc\x9b\x5c\x90\xdf\xfe\x8a\x8b\xe4\x31\xb8\x73\x52\xc8\x4a\x77\xa3\x2d\x49\x8b\xf9\x39\x47\x08\x5c\x6d\x57\x59\xd2\x9f\x73\x4a\x3e\xa1\xdf\x95\xe4\xb4\x7f\x0d\x08\x51\xcc\x15\x00\x44\x74\x2d\x2c\x25\x4a\x4e\x50\xc9\x95\xdd\xaa\x0d\xa0\x84\x47\x97\xb7\xca\x5f\x61\x45\xce\xc7\x62\xc3\x1b\x79\xa8\x17\x06\xf6\x53\xdb\x39\x77\x31\x3d\xc3\x0a\x83\xcb\xa8\xbd\x0f\xb7\x32\xe6\xbc\x61\x43\xf5\xf6\xdc\x63\xc3\x6b\x1c\xee\x28\x79\xde\x33\x30\x48\x83\x95\xf3\x7d\x78\xc4\xcd\xda\x72\x9b\xd9\xaa\x47\x6e\x66\x16\x4b\x29\x9a\xf2\xae\xcc\xc6\x0f\x91\xae\xe4\x57\x7a\xdd\xda\xcd\xf4\xef\x16\xb3\xd9\xdf\x5a\xb5\xc4\xca\xa3\xba\x87\xd9\xec\xab\x89\x0a\x5c\x48\x4b\x95\xa5\xe6\x7a\x90\x9a\x17\x59\x6a\x66\xb3\xab\x5e\x66\xca\x4a\x98\xcd\xbe\x39\x3b\xf8\x1c\x59\x3a\x7b\xdc\xb6\xc3\xa5\x6f\x66\x1f\x8a\x9e\xbf\x92\x90\xa4\x6f\x90\x97\x7e\xe0\x0a\xee\x24\x9e\xc3\xda\xf5\x8a\x1d\xc2\xab\xae\xa9\xd0\xe7\xa5\xef\x1b\x45\x16\x2e\xcb\xac\x09\xf0\xe1\x72\x98\xcb\x8a\x0b\x1f\x66\xdf\x3c\x3f\xf8\x1c\x59\x7a\xfe\xb8\x6d\x87\x4b\xa3\x07\x93\xcf\x91\x25\xf8\xf0\x6f\x2e\x7d\x78\xfa\x0b\x1e\x15\xaa\xb3\xb3\xc7\x6d\x7b\x28\xc8\x0f\xda\x01\xf0\xb8\x6d\xff\x9f\x10\xcd\xbe\x7f\xf7\x25\xf9\xbe\x24\xdf\x97\xe4\xfb\xaf\x24\xdf\x58\x30\x77\xea\xe5\x77\x17\xe3\xf3\xc9\xed\x50\xaa\x77\xfe\xf8\x50\x4a\xf5\xd5\x6e\xa9\xfe\xdd\xf0\xfa\x37\x9b\x1d\x56\xfd\xc9\x9f\xcd\xa5\x8c\xf7\x7f\x1c\x4c\x75\x3c\xcf\x8b\x50\xa1\xe5\xcd\xc5\x51\x09\x78\xc8\x95\x87\x96\x8f\xf3\x62\xf0\xef\xba\x37\xaa\x0f\xc3\x75\x9a\xff\xe0\x66\xf2\x08\x30\x2e\x9f\x84\x53\x78\xd5\xcf\x52\x3f\xc9\xcc\xf6\xa2\xcc\xb2\x29\x60\x7d\x82\x3e\x7f\xfe\x98\x34\x7e\x68\x79\xf7\x23\x56\x67\xc8\xcb\x48\x52\x30\x45\x73\x71\x9c\x3c\x1f\xe1\xd4\x63\x36\x1d\xe3\xf5\x03\xdb\x1f\x5c\xfe\xd4\xa6\xa7\x8d\xb3\xcc\x70\xf0\xec\x62\xef\xce\xff\x84\x1b\x4f\x88\xd1\x13\x42\xb2\x17\xe5\x04\xcf\xb7\x4f\x01\xcf\x6f\xc7\xa1\xa7\x25\xd0\x97\x94\xfb\x9f\x49\xb9\x27\x86\xe8\x09\x51\xd9\x0b\xf4\x13\x66\xdd\x6f\xc6\xa3\x27\x44\xeb\x5f\x03\x00\xf3\xab\x76\xcb\x4c\x28\x00\x00")
 
 func complySoc2PoliciesDisasterMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -741,12 +762,12 @@ func complySoc2PoliciesDisasterMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/disaster.md", size: 10316, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/disaster.md", size: 10316, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesEncryptionMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x58\xcd\x8e\x1c\xb7\xf1\xbf\xf7\x53\x14\x60\xe0\x8f\x7f\x56\x33\xa3\x48\x81\x8d\x78\x83\x1c\x94\xcd\x1e\x14\x07\xca\xc2\xbb\x91\x2f\xb9\x54\x93\x35\xdd\xe5\x65\x93\x1d\x92\x3d\xe3\x16\x74\xf0\x6b\xf8\xf5\xfc\x24\x41\x15\xbb\xe7\x7b\x01\x59\xf2\x61\xfb\x32\x33\x35\x64\xb1\x3e\x7e\x55\xac\x5f\x7b\xec\xe8\x1a\x6e\xbd\x89\x63\x9f\x39\x78\xb8\x0b\x8e\xcd\x58\xa1\x89\xc1\x8f\xdd\x35\xdc\xde\x55\x09\x33\xa7\x35\x53\xba\xae\x00\x1e\xee\x6f\xe4\x03\x60\x09\x37\x37\xdf\xae\xbe\xad\x3a\xfc\x31\xc4\xef\x69\xc3\x89\x83\xd7\x25\x4b\xb0\x98\xe9\x1a\xfe\x31\x78\x78\x05\xaf\xff\xf8\xea\xcf\xba\xc1\x84\xae\x23\x9f\xaf\xe1\xad\xe7\xcc\xe8\xc0\x06\x33\x88\xa4\x5a\x2e\x97\xd5\x57\x70\x37\xc4\x3e\x24\x02\xf4\x16\xee\x4d\xe8\xa9\xaa\x70\x05\x0f\x2d\x27\xe8\xd5\x28\xb0\xb4\x66\x4f\x09\x42\x6c\xd0\xf3\x07\x14\x83\xd1\x41\xa4\xff\x0e\x1c\x49\x54\x25\x58\x87\x08\xb9\x25\x18\x12\x41\x58\x83\xfa\x15\x9a\x88\x7d\xcb\x06\x4c\xf0\x39\x06\x97\x16\x80\x09\xb6\xe4\x9c\x7c\xca\xea\x33\x15\xc7\xfb\x1e\x69\x4c\x0b\x60\x0f\x21\x5a\x8a\x90\x03\xf4\x31\x64\x32\x59\x37\x9b\xe0\xd7\x6c\xc9\x8b\x53\x9c\x47\x59\x98\xa9\x89\xfa\x15\x87\xdc\xca\x3f\x86\xf3\xa8\x9e\xf9\xe0\x23\xf5\x83\x65\xb5\x5e\x4c\x64\xbf\x0e\xb1\xd3\x9f\xab\x33\x97\xb1\xef\x1d\x53\x92\x23\xd1\x39\x48\x63\xca\xd4\xa5\x05\x88\xbd\xbd\x98\xbb\x80\x35\x1a\x76\x9c\x65\x95\x1c\x70\xa0\x0e\xb6\x9c\x5b\xf6\x6a\x64\x92\x88\xca\x71\xf2\xe3\x30\x80\xbf\xfe\xfc\x4b\x3a\xda\x94\xc8\x0c\x62\xbc\xf8\xd8\x44\xec\x8a\x51\x6f\x9c\x03\xea\x7a\x17\x46\xa2\xb4\x28\x91\x44\x93\x43\x4c\x0b\xe8\x31\xe6\x65\xe6\xae\xe4\x2e\x53\xd7\x87\x88\x71\x84\x6d\x88\x8f\x24\x0b\x12\xc5\x0d\x1b\x12\x8d\x1b\xb6\x2a\xd2\x95\xad\xe4\x7b\xd2\x6a\xa1\x1e\x21\xe4\x96\xa2\x7a\xdb\x53\x14\x93\x54\x07\x04\x0f\x35\xb5\xe8\xd6\x97\x1c\x80\x16\x37\xec\x1b\xd9\x64\x83\xba\x7c\x92\xbd\x5d\xd0\xd0\x35\x21\x72\x6e\xe5\x7b\x88\x92\x55\xd9\xd7\x61\xa6\x28\x78\xc4\x48\x90\x86\xfa\x47\xcd\x6b\x80\x7c\x98\x06\x6f\xa1\x1b\x52\x16\x10\xf7\x6e\x2c\xa7\x70\x5e\x55\xd5\x57\xf0\x37\x34\x8f\x4d\x0c\x83\xb7\x4f\x02\x56\x4c\x6e\xb9\x69\xc1\xd1\x86\x1c\x04\x3d\x83\x37\x73\xc6\xba\xde\x29\xf4\x8a\x3b\xec\x53\x8e\x83\x91\xef\x7b\x38\x9f\x65\xec\x22\xbe\xf7\x0e\xaa\x62\x81\xed\x0a\xde\x66\xe0\x04\x1b\xce\xe8\x20\xb7\x98\xcf\x03\x88\x36\xf4\x19\x10\x52\x46\x6f\x31\x5a\x41\x5d\x0c\x68\x5a\x09\xc3\xe5\x0a\x02\x69\x10\x29\x29\x28\x35\x45\x86\x7c\x96\xd4\x1d\x16\x09\xf9\x34\x44\x02\xf2\x76\x99\xc3\x92\xbc\xdd\x61\x6b\x01\xdb\x96\x1d\x01\xba\xa4\xb5\xd4\x85\x2c\xa9\x90\xc2\x89\xa1\xa7\x88\xb5\x40\x7a\x9c\xa2\x39\x37\x8a\xa3\x78\xa6\x9e\x0c\xaf\x4f\x9c\x16\xbb\x37\x64\x35\x6c\x43\xa2\xc5\x79\x61\x3f\xd2\x08\x1d\x7a\x6c\x54\xa6\x61\x9a\x6a\x99\x83\x2f\xb0\x3c\xdb\x33\x24\x31\xee\x20\x10\xa3\xb8\x69\x5c\x18\x2c\x90\xdf\x70\x0c\x5e\x17\x2b\x1c\xa6\x06\x5a\xa0\x70\x12\x68\x85\xd0\xdc\x3a\xd8\x5b\xde\xb0\x1d\x70\x57\xd6\x02\xca\xc3\x4a\xac\x47\xe8\x08\x7d\x7a\xba\x91\x49\xff\x2a\x41\x91\xc2\x87\x07\xac\x1d\xc1\x9f\xae\xa1\xaa\xfe\xd3\x63\x43\x75\x24\x7c\x84\xaa\x7a\xb1\xbc\xf4\x5c\x92\x9e\xc9\x4e\x04\x2f\xaa\x8f\x70\x75\xf5\x0e\x3b\x45\xdf\xbd\xda\xfd\xf2\xea\x0a\x44\x7a\x73\x68\xe2\x24\xdb\xdf\x2c\x93\xe0\x3b\x1a\xe1\x9e\x3f\x90\xfc\x54\x5d\x0f\xa3\xf6\xa5\xab\x2b\x98\x1f\x95\x86\xe0\x0e\x44\x2a\x7b\x33\x27\x5a\xfe\xf8\x08\x47\x4f\xd1\xf5\x76\x1f\xbc\x69\xf3\xc9\xb2\xcb\xb2\x33\x5d\x2f\xfe\x7a\xe9\xb9\x24\x3d\x93\x9d\x08\x24\x5e\x77\x43\xed\xd8\x80\x78\x7e\x78\xe4\xbf\x7a\xf2\xf7\xf7\xff\x3c\x94\xbd\xb9\xbd\x5f\xbe\xfe\xfa\x9b\xbd\xe0\xf5\xd7\xdf\x2c\x6b\xce\x8a\x5a\xf5\xf1\xad\x5f\x47\x2c\xfd\x41\x4a\x4b\xd0\xf9\x99\x3e\x7e\x84\x37\xf3\xdd\x54\xd0\xf6\x25\xf1\xfa\x5d\xf1\x75\xe9\x79\x0e\x76\xfd\x1d\x33\x1e\x8e\x4a\xf0\x05\x79\xfc\x8e\xc6\xf4\x1c\x7d\x7c\xae\xb1\x7f\xcf\x31\x4b\xa7\xbc\x8b\xbc\xc1\x4c\xa7\xb1\x97\xb6\xfd\xc9\xb1\x7f\x47\x59\xaf\xac\xff\x7f\x7f\xf7\xee\x0f\x7b\xf3\x45\xd7\xfb\xbb\x77\xbf\xc5\xc7\x8f\x7a\xc5\x3e\xc7\x78\x5d\x7a\x9e\x83\x5d\x3f\x50\x9d\x38\x13\x1c\x16\xcc\x3e\x8f\x0b\xb8\xb9\xfd\xfe\x01\x3e\x31\x8f\x37\x14\xb3\x4c\x00\x33\x1e\x3e\xdb\x47\xf8\x5d\x9d\xac\x76\xb7\xf0\xd1\x85\x08\x37\xf3\x9d\x5d\x55\xff\xe7\xeb\xd4\xff\xa5\xaa\xea\x15\xdc\xfe\x64\xa8\xcf\xb0\x6d\x29\x52\x19\x7f\xb7\x9c\x48\x46\xb1\x4c\x76\x51\xf0\xa5\x63\x43\x4d\xd3\xdc\xa2\x83\x72\x6e\x89\x23\x84\xad\xa7\x28\x63\x87\x59\x9d\x1c\x76\xb4\x6f\x9a\x38\xc8\x02\x36\x28\xe3\x25\xb8\x90\x64\x88\x6f\xd1\x37\x32\xa2\x80\xa5\xdd\xcc\x29\xda\x85\x75\xe8\x6c\xac\x03\x55\x1f\x59\x42\x8c\xc6\x50\x4a\xf3\xe8\x01\x1d\xc9\x76\x4e\x5d\x19\xd7\x23\x6d\x64\x9e\x1a\xbc\x30\x9e\x10\xf9\x03\x59\x9d\x51\xa5\x32\x6b\x34\x8f\xa2\x6d\xe8\x8b\x5d\x32\x72\x42\xa4\x66\x70\x18\xa1\xc6\xc4\xe2\x82\x5d\xc1\x0f\x2d\xf9\x79\xfa\xb2\x0b\x30\x43\xca\xa1\x93\xa1\xf2\x29\xda\xa2\x23\xd8\xb2\xc6\x44\x16\x52\x58\xe7\xad\x8c\xf0\x21\x42\xef\x30\x2b\x7b\x08\xeb\x35\x45\x1d\xf2\xa7\x50\x68\x6a\x72\x80\x50\x67\x64\x7f\x34\x6d\x45\x6a\x30\x5a\xf6\xcd\x75\xa5\x7c\x95\xcb\x00\x77\x3c\x78\xe5\x20\x19\x1c\xe4\xc0\x63\x16\xc8\xf1\x84\xc8\x4d\x2a\xde\xf8\x11\x0c\xf6\x65\xa0\x55\x26\x27\x23\xb8\xd8\x89\x1b\x64\x37\xdb\x83\xce\x85\xed\x34\x52\xce\x6c\x69\xef\xbf\x2c\x90\x94\xec\xd3\x7e\x4a\x71\x82\x1b\x94\x30\xac\x8e\x6c\x2f\xac\x34\x8f\x73\x00\x4d\x18\x7c\x8e\x62\x45\xc1\x5b\x7e\xc2\x3f\x31\x6f\xf6\x31\xe5\x50\x82\x9a\x23\xfa\xb4\xa6\xf8\x94\x95\xbf\xfe\xfc\x8b\x10\x7f\x5c\xed\x66\xdf\x89\xa3\x1c\xd3\x75\x37\x2e\x77\x63\x3a\xed\x2f\xd3\x39\x41\x4d\xd8\x50\x9c\xc6\x59\x34\x26\x44\x8b\xde\x50\x21\x5c\x62\xae\xc3\x6d\x3a\x76\x67\x94\x29\xbf\xd1\xe9\x3d\xc4\x52\x42\x13\xb8\x94\x53\x4c\x11\x60\x2f\x94\xc3\xb4\x62\x54\x4c\x3b\x7a\x59\x02\x2a\xd7\xc1\xea\xf0\x6a\x57\x6b\x7c\x50\x8b\xe6\x40\x6c\x38\x38\xad\x03\x3f\x4e\x56\xec\xcf\x11\xaa\xc6\xde\xb8\xc1\x2a\x8d\x11\x02\x9c\x5f\xd2\x4f\xf2\x01\x51\x6a\x8b\x0b\x9f\x2b\x71\x39\x70\x5b\x95\x97\x7a\x86\x9b\xd0\xf5\xa2\xdc\x04\x05\x92\xa6\x5d\x19\x91\x9f\xdf\x74\xcc\x04\xad\xd0\xbb\x7f\xaf\xee\x57\x67\x67\xed\xa9\xcb\xcc\xb1\x87\x04\x06\xfd\xce\x93\x89\xb8\x1d\x2b\xae\x85\xbe\xa2\x22\x43\x46\xca\x5a\x18\x8f\x14\x7b\x5f\xde\xc9\xa4\xfd\x3b\x80\x13\xfe\xb4\x6b\x31\x25\x9e\x5a\xf4\x12\x82\x5d\x35\x16\xb8\x0f\x39\x48\x61\x18\x49\xff\xb4\x3d\x9d\x34\x94\x45\x21\x87\xa4\x80\xc3\x86\x16\xda\x36\x86\xbe\x50\xb2\x90\x77\xef\x4b\x0a\xab\xbd\x9f\xe8\x9f\x68\xd4\x82\x9d\x20\x7f\x62\xdf\x8c\xd2\x99\x79\x6d\xd8\x96\x35\xd3\xe1\x02\xf0\x03\x4d\x4b\x4b\x89\x1b\x2f\xbd\xb7\x00\x65\xb1\x47\xde\xc4\x49\x0b\xb1\xd5\x04\xbe\xb4\xa4\x9f\x47\x6d\x44\xcc\x6d\xc8\x53\x14\xac\x48\x3d\x1c\xa6\x5b\x6d\xff\x1c\x6b\x6d\xc7\x9e\x53\x8e\xd3\x19\x5f\x62\xbc\x89\x84\x99\x16\x90\x4c\x4b\x76\x70\x04\x96\x1c\x89\x80\xbc\x74\xa3\x97\x96\x93\x76\xa5\x39\xe6\x05\x47\x89\x32\x0c\x09\x1b\x2a\xef\x36\x66\xa4\xfc\x16\x87\x4a\x27\x99\x2f\x84\xf9\x32\x98\xdf\x70\x48\x9d\x46\xda\xf5\x2a\x69\x73\x3d\xc5\x19\xa0\x8e\xd7\x94\xb9\xa3\x4f\x3d\x4b\x8d\xa7\x72\x02\x66\x70\x84\x29\x43\x90\x4e\x42\x1b\x8a\x23\xbc\x7a\x0d\x5d\xf0\xb9\x15\xe3\xab\xff\x05\x00\x00\xff\xff\x69\xcf\x17\x8d\x05\x15\x00\x00")
+var _complySoc2PoliciesEncryptionMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x58\xcd\x6e\x23\xc7\x11\xbe\xcf\x53\x14\x60\x20\x48\x24\x92\x9b\xdd\xc0\x46\xac\x20\x07\x45\xd1\x61\xe3\x40\x11\x4c\x65\x7d\xc9\xa5\xa6\xbb\x38\xd3\x56\x4f\xd7\xa4\xba\x87\xf4\x2c\x74\xf0\x6b\xf8\xf5\xfc\x24\x41\xf5\xfc\xf0\x47\x94\x21\x7b\x63\xe4\x12\x5e\x48\xf6\x74\x57\x7d\xf5\xdb\x5f\x4d\xc0\x86\xae\xe0\x36\x18\xe9\xdb\xe4\x38\xc0\x3d\x7b\x67\xfa\x02\x8d\x70\xe8\x9b\x2b\xb8\xbd\x2f\x22\x26\x17\x37\x8e\xe2\x55\x01\xf0\xb0\xbe\xd1\x2f\x80\x25\xdc\xdc\x7c\xb9\xfa\xb2\x68\xf0\x5b\x96\xaf\x69\xeb\xa2\xe3\x90\xb7\x2c\xc1\x62\xa2\x2b\xf8\x5b\x17\xe0\x2d\xbc\xfb\xfd\xdb\x3f\xe6\x03\x86\x9b\x86\x42\xba\x82\xf7\xc1\x25\x87\x1e\x2c\x9b\x4e\x57\x8a\xe5\x72\x59\x7c\x06\xf7\x9d\xb4\x1c\x09\x30\x58\x58\x1b\x6e\xa9\x28\x70\x05\x0f\xb5\x8b\xd0\x66\x50\x60\x69\xe3\x02\x45\x60\xa9\x30\xb8\x8f\xa8\x80\xd1\x83\xd0\xbf\x3b\x27\xa4\xa2\x22\x6c\x58\x20\xd5\x04\x5d\x24\xe0\x0d\x64\xbb\xb8\x12\x6c\x6b\x67\xc0\x70\x48\xc2\x3e\x2e\x00\x23\xec\xc8\x7b\xfd\xd6\xdd\xcf\x44\x1c\x9f\x7b\xa4\x3e\x2e\xc0\x05\x60\xb1\x24\x90\x18\x5a\xe1\x44\x26\x65\x55\x86\xc3\xc6\x59\x0a\x6a\x94\x4b\xbd\x6e\x4c\x54\x49\xfe\x89\x5d\xaa\xf5\x89\x71\xa9\xcf\x96\x05\x0e\x42\x6d\x67\x5d\x46\xaf\x10\x5d\xd8\xb0\x34\xf9\xef\xea\x99\xc9\xd8\xb6\xde\x51\x54\x95\xe8\x3d\xc4\x3e\x26\x6a\xe2\x02\x14\x6f\xab\x16\x2f\x60\x83\xc6\x79\x97\x74\x97\x2a\x38\x10\x07\x3b\x97\x6a\x17\x32\xc8\xa8\x1e\x55\x75\xfa\xe7\xd0\x81\x3f\x7e\xff\x43\x3c\x3a\x14\xc9\x74\x0a\x5e\x6d\xac\x04\x9b\x01\xd4\xb5\xf7\x40\x4d\xeb\xb9\x27\x8a\x8b\xc1\x93\x68\x12\x4b\x5c\x40\x8b\x92\x96\xc9\x35\x43\xec\x12\x35\x2d\x0b\x4a\x0f\x3b\x96\x47\xd2\x0d\x91\x64\xeb\x0c\xa9\xc4\xad\xb3\x79\x29\xef\xac\x35\xde\xa3\x54\x0b\x65\x0f\x9c\x6a\x92\x6c\x6d\x4b\xa2\x76\x64\x19\xc0\x01\x4a\xaa\xd1\x6f\xce\x19\x00\x35\x6e\x5d\xa8\xf4\x90\xe5\x6c\xf2\x49\xd4\x67\xa7\xa1\xaf\x58\x5c\xaa\xd5\x81\x2c\xf0\x48\xbd\x9e\x6b\x30\x91\x68\x3e\xa2\x10\xc4\xae\xfc\x36\xc7\x95\x21\x1d\x86\x21\x58\x68\xba\x98\xc0\x70\xd3\xfa\x7e\xd0\xe2\xd2\xaa\x28\x3e\x83\xbf\xa0\x79\xac\x84\xbb\x60\x5f\x4c\x58\x85\x5c\xbb\xaa\x06\x4f\x5b\xf2\xc0\x59\x87\xdb\x4e\x11\x6b\x5a\x9f\x53\x6f\x08\x9a\x0b\x31\x49\x67\xf4\xf7\x3e\x9d\x9f\x45\xec\x6c\x7e\xef\x0d\xcc\x82\x35\x6d\x57\xf0\x3e\x81\x8b\xb0\x75\x09\x3d\xa4\x1a\xd3\x73\x07\xa2\xe5\x36\x01\x42\x4c\x18\x2c\x8a\x05\x6c\x5b\x61\x34\xb5\xfa\xf4\x7c\x05\x81\x36\x88\x18\x73\x52\xe6\x10\x19\x0a\x49\x43\x77\x58\x24\x14\x62\x27\x04\x14\xec\x32\xf1\x92\x82\x9d\x73\x6b\x01\xbb\xda\x79\x02\xf4\x31\xd7\x52\xc3\x49\x43\xa1\x85\x23\xdc\x92\x60\xa9\x29\xdd\x8f\xde\x9c\x1a\xc5\x91\x3f\x63\x4b\xc6\x6d\x4e\x8c\x56\xdc\x5b\xb2\xd9\x6d\x5d\xa4\xc5\xf3\xc2\x7e\xa4\x1e\x1a\x0c\x58\x65\x8f\x67\x37\x8d\xb5\xec\x38\x2c\xf2\xff\x67\x67\xba\xa8\xe0\x0e\x1c\xd1\x6b\x2f\x30\x9e\x3b\x0b\x14\xb6\x4e\x38\xa8\xb0\x98\xd3\x61\x6c\xa0\x43\x2a\x9c\x38\x3a\xa7\xd0\xd4\x3a\x5c\xb0\x6e\xeb\x6c\x87\x73\x59\x03\xcb\x51\x25\x96\x3d\x34\x84\x21\xbe\xdc\xc8\xb4\x7f\x0d\x4e\xd1\xc2\x87\x07\x2c\x3d\xc1\x1f\xae\xa0\x28\xfe\xd5\x62\x45\xa5\x10\x3e\x42\x51\x5c\x2e\xcf\x7d\x2e\x5f\xb3\x76\xb2\x70\x59\x3c\xc1\xc5\xc5\x1d\x36\x39\xfb\xd6\xb9\xb2\xde\x5c\x5c\x80\xae\xde\x1c\x42\x1c\xd7\xf6\x37\xcb\xb8\xf0\x15\xf5\xb0\x76\x1f\x49\xff\x66\x59\x0f\x7d\xee\x4b\x17\x17\x30\x7d\xf2\x2a\xb3\x3f\x58\xca\x47\xaf\xa7\x40\xeb\x83\xa7\xe9\xc9\xf8\x3c\xcb\x7a\xbf\x77\xde\x78\xf8\x64\xdb\xf9\xb5\x93\x85\xa7\xe2\xf2\xcf\xe7\x3e\x97\xaf\x59\x3b\x59\x50\x7f\xdd\x77\xa5\x77\x06\xd4\xf2\xf9\xf3\x04\xf0\x8f\x96\xc2\x7a\xfd\xf7\x69\x45\x61\x5c\xdf\xae\x97\xef\x3e\xff\x62\xbf\xf0\xee\xf3\x2f\x96\xa5\x4b\xda\xaa\x06\x1b\xdf\x87\x8d\xe0\xd0\x1f\xb4\xb4\x34\xa3\x7f\xa1\x8d\x4f\x70\x3d\xdd\x4d\x43\xdf\x39\x7f\xee\x55\xb2\x9e\x65\xcd\x27\xe5\xd7\x24\xf7\x27\x54\x9e\x5f\xfb\x95\x71\xfd\x15\x13\x1e\x52\xa5\x4f\x89\xe3\x57\xd4\xc7\xe9\xf1\x0b\xf0\xff\x27\x36\x4e\x72\x7f\x42\xe5\xf9\xb5\x5f\x19\xd7\x07\x27\x49\x3b\xe5\xbd\xb8\x2d\x26\x3a\xf5\xbd\xb6\xed\x57\xfb\xfe\x8e\x52\xbe\xb2\x7e\xfb\xe1\xfe\xee\x77\xf3\xb6\x1c\xc7\x0f\xf7\x77\x2f\x9a\x74\xc6\xc6\x27\x78\xfc\x7f\x1c\x7f\x56\x1c\xbf\xa1\x32\xba\x44\x70\x58\x30\xfb\x38\x2e\xe0\xe6\xf6\xeb\x87\xd7\xc6\xf1\x86\x24\x29\x03\x98\xf2\xe1\x0c\xfc\xd7\xd9\x08\xff\x55\x23\x8b\xf9\x16\x3e\xba\x10\xe1\x66\xba\xb3\x8b\xe2\x37\xa1\x8c\xed\x9f\x8a\xa2\x5c\xc1\xed\x77\x86\xda\x04\xbb\x9a\x84\x06\xfa\xbb\x73\x91\x94\x8a\x25\xb2\x0b\x35\x36\x0e\xcc\xb3\xa4\x91\xb7\x64\xa2\x9c\x6a\x72\x02\xbc\x0b\x24\x4a\x3b\xcc\xea\x44\xd9\xd1\xb9\x91\x71\x90\x05\xac\x50\xe9\x25\x78\x8e\x4a\xe2\x6b\x0c\x95\x52\x14\xb0\x34\x73\x4e\x95\xae\x53\x47\xe6\xc6\x99\x08\xb6\xe2\xd4\xc5\x68\x0c\xc5\x38\x51\x0f\x68\xc8\xd4\x18\x5c\x6c\x06\xba\x2e\xb4\x55\x3e\xd5\x05\x9d\x78\x58\xdc\x47\xb2\x79\x06\xd3\xca\x2c\xd1\x3c\xaa\xb4\xae\x1d\xec\x51\xca\x09\x42\x55\xe7\x51\xa0\xc4\xe8\xd4\x04\xbb\x82\x6f\x6a\x0a\x13\x63\xb3\x0b\x30\x5d\x4c\xdc\x28\xa9\x7c\x69\x6c\xc9\x14\x6c\x59\x62\x24\x0b\x91\x37\x69\xa7\x14\x9e\x05\x5a\x8f\x49\x99\x00\xf0\x66\x43\xa2\xaa\x27\x57\xe4\xd0\x24\x06\x2e\x13\xba\x70\xc4\xb6\x84\x2a\x14\xeb\x42\x75\x55\xe4\x79\xd5\x0d\x04\xee\x98\x78\x25\xd6\x08\x76\xaa\xf0\x78\x0a\x74\x47\xd4\x6d\x35\x8b\xb8\x0e\x3d\x18\x6c\x07\x42\xab\x33\x5a\xa6\xe0\x8a\x13\xb7\xe8\xfc\x84\x07\xbd\xe7\xdd\x48\x29\xa7\x69\x69\x6f\xbf\x4e\x7f\x1a\x92\x51\x11\xef\xc2\x09\xac\xc8\xbe\xd3\x4b\x3c\xee\x15\x2b\xf6\x61\x2a\x4d\xfd\xe4\x40\xc3\x5d\x48\xa2\x28\x86\x7c\x4b\x2f\xd8\xa7\xf0\x26\x1b\x63\xe2\xc1\xa9\x49\x30\xc4\x0d\xc9\x4b\x28\x7f\xfc\xfe\x07\x1d\xfc\x71\x1a\x62\xe7\x19\xfc\x30\x6c\xe8\x7d\xbf\x9c\x69\x3a\xed\x2f\xd3\x29\x40\x15\x6f\x49\x46\x3a\x8b\xc6\xb0\x58\x0c\x86\x86\x81\x4b\xe1\x7a\xdc\xcd\xf9\x30\x98\xd3\x2b\xcb\xaf\x32\x7b\x67\x19\x4a\x68\x4c\xae\x3c\x53\x8c\x1e\x70\x41\x47\x0e\x53\x2b\x28\x89\xf3\x78\x39\x38\x54\xaf\x83\xd5\xe1\xd5\x9e\xd1\x04\xce\xd5\x33\x39\x62\xeb\xd8\xe7\x3a\x08\xfd\x88\x62\xaf\x47\x47\x35\x17\x8c\xef\x34\x7f\x74\xa0\x63\x49\x6f\xe8\xbb\x96\x25\x81\x68\x6d\xb9\x5c\x5b\x71\xf0\xcb\x81\xd9\x59\xf8\x50\xcf\x70\xc3\x4d\x8b\x9a\x2e\x9c\x69\x6c\x0e\x7b\x9e\x88\xc2\xe8\xba\x79\x40\x1b\xe6\xc6\x7f\xae\xd6\xab\x67\xba\xf6\xa3\xcb\x34\x63\x77\x11\x0c\x86\xd9\x92\x71\x70\x3b\x16\x5c\xea\xf8\x8a\x39\x33\x94\x52\x96\x3a\xf1\x68\xb1\xb7\xc3\x3b\x99\xb8\x7f\x07\x70\x32\x3f\x4d\x61\x1b\xfd\x99\x8b\x5e\x5d\x30\x57\xe3\x90\xee\x5d\x62\xa5\xe5\x46\xc3\x3f\x1e\x8f\x27\x0d\x65\x31\x0c\x87\xda\xfa\x58\xb0\xa2\x45\x6e\x1b\x5d\x9b\xad\x10\x4e\xf3\xfb\x12\x6d\x21\x2b\x58\x8f\xe3\x9f\x4a\xcc\x05\x3b\xa6\xfc\x09\xbe\x29\x4b\xa7\xc9\x4b\x5f\x3f\x68\x13\x9a\x94\x27\x9e\x07\x49\x95\xb4\xb4\x14\x5d\x15\x30\x65\x43\xf2\x8b\x8a\x39\xf3\xc6\x99\x54\xa3\x32\x06\xf0\x8d\xa5\xfc\x7d\xd4\x46\x14\x6e\x45\x81\x44\x73\x45\xeb\xe1\x30\xdc\x19\xfb\x2f\x41\x6b\x1b\x17\x5c\x4c\x32\xea\xf8\x14\xf0\x46\x08\x13\x2d\x20\x9a\x9a\x6c\xe7\x09\x2c\x79\xd2\x05\x0a\xda\x8d\xde\x58\x17\xf5\x7b\xf6\xf9\x90\x47\x91\x12\x74\x11\x2b\x1a\x5e\xc6\x4d\x99\xf2\x73\x0c\xd2\xc0\xee\x2f\x84\xe9\x32\x98\xde\x70\x68\x9d\x0a\xcd\xbd\x4a\xdb\x9c\xbe\x08\x18\x13\xd4\xbb\x0d\x25\xd7\xd0\x6b\x75\x65\xf0\x79\x4b\x04\x4c\xe0\x09\x63\x02\xd6\x4e\x42\x5b\x92\x1e\xde\xbe\x83\x86\x43\xaa\xe3\xaa\x28\x8a\xff\x0c\x00\x69\xcf\x17\x8d\x05\x15\x00\x00")
 
 func complySoc2PoliciesEncryptionMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -761,12 +782,12 @@ func complySoc2PoliciesEncryptionMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/encryption.md", size: 5381, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/encryption.md", size: 5381, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesIncidentMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x59\xdd\x6e\xe4\xc6\xb1\xbe\xd7\x53\x14\x70\x6e\x24\x83\x9a\x73\xec\xe3\x20\x86\x1c\x04\x58\x6b\xd7\x59\x25\x5e\x78\x21\x39\xf6\x75\x4d\xb3\x38\x2c\xab\xd9\x4d\x77\x37\x67\x4c\x5f\xf9\x35\x02\x24\x2f\xe7\x27\x09\xaa\xba\xf9\x33\x3f\xda\x8d\x81\x5c\x69\x34\x43\x36\xeb\xe7\xab\xaf\xbe\x2a\x3a\xec\xe8\x0e\x9e\xc8\x0c\x81\xd3\x08\x0f\xce\x70\x4d\x2e\xc1\x23\xc5\xde\xbb\x48\xf0\xde\x5b\x36\xe3\x15\x9a\xe0\xdd\xd8\xdd\xc1\xd3\xc3\xe3\xfb\xab\x88\x89\x63\xc3\x14\xef\xae\x00\xbe\x7b\xba\x97\x3f\x00\xb7\x70\x7f\xff\xc7\xcd\xff\xaf\x3e\x7f\xbe\xfa\xfc\x87\xab\x0e\x7f\xf4\xe1\x91\xf6\x1c\xd9\x3b\xbd\xf5\x16\x6a\x4c\x74\x07\x7f\x1d\x1c\x7c\x0a\x9f\xfd\xdf\xa7\x5f\xe8\x0d\xc6\x77\x1d\xb9\x74\x07\x0f\x8e\x13\xa3\x85\xda\x9b\x41\xbe\xb9\xba\xbd\xbd\xbd\xba\xfa\x1f\x78\x3f\x84\xde\x47\x02\x74\x35\x3c\x19\xdf\xd3\xd5\x15\x6e\xe0\xbb\x96\x23\xc4\xc9\x15\x9e\x5c\x09\x93\x2b\xbd\xba\x02\x1c\x81\x5d\x22\x57\x53\x0d\xc9\x03\xc5\x84\x5b\xcb\xb1\x05\xe3\x5d\x0a\xde\x46\xfd\xd6\xc5\x21\x10\xd4\x94\xc8\x24\xf6\x0e\x7c\xb3\x1c\xbd\x1f\xac\xa3\x80\x5b\xb6\x9c\x98\xa2\x9a\x31\x3d\x2e\x56\x80\x11\x0e\x64\xad\xfc\xfd\x69\x60\xf3\x0c\x81\x30\x1f\x22\x17\xce\xe6\x24\xbf\x9c\xb8\x95\x4b\x5a\x8a\x9b\xc5\x91\xc9\x67\x40\x1b\x3d\xf4\xc1\xef\xb9\xa6\x08\xdc\xf5\x96\xe4\x7b\x76\x3b\x60\x17\x53\x18\xf4\xec\x08\x8d\x0f\x1f\xf0\xbe\x92\xe7\xb1\x33\x76\xa8\xc5\xad\x86\x25\xb4\xde\xc5\x4a\x8e\x36\x54\x0f\x81\x62\x35\x5d\xcd\x93\x6b\x95\x9a\xdc\x53\x68\x7c\xe8\xd0\x19\x82\x8e\x50\x22\x13\xe1\xba\xa3\x14\xd8\xc4\xe2\x54\xef\x83\x9a\xd4\x91\x69\xd1\x71\xec\xe2\xcd\xca\x99\x12\x7a\xec\x7b\x2b\x01\x4b\x1e\xd0\x5a\x18\x22\x85\x28\x91\x65\xa7\xe7\x6b\x8c\xe2\x18\x13\x75\x11\x0e\x9c\x5a\x76\x90\x5a\x02\x1f\x76\xe8\xf8\x17\xfd\xbd\x1c\x98\xc6\x9e\x0d\x5a\x3b\x4e\x3e\x45\xa0\xae\xb7\x7e\xa4\x92\x0f\x4d\x26\x9a\xe4\xc3\x71\x46\xd0\x8d\x40\x3f\x27\x0a\x0e\x2d\xf4\x18\x34\x81\xa9\xc5\x24\xa8\x23\x41\x86\xd7\x7b\xd1\x24\x35\x61\xb6\x27\x27\x79\xb1\xb3\xa0\xc5\x52\x0d\xdb\xf1\xcc\x4c\xb8\x6e\x29\x10\x3b\x6c\x12\x05\x08\xd4\x50\x08\x19\x6f\x18\xe1\xb7\x5f\xff\xa9\xae\xff\xf6\xeb\xbf\x6e\x8e\x03\xd4\x0d\x31\xc1\x96\xa0\xc3\x9a\x04\x36\x35\xdb\x11\x70\x8f\x6c\x71\x6b\xe9\x28\x6e\x1b\x29\x84\xaf\xd0\x3c\xef\x82\x1f\x5c\xad\xb1\x7e\x05\xcf\x34\x82\xdf\xfe\x28\xa0\xdd\x93\x84\xf6\xd4\xb0\xdf\x7e\xfd\x47\x84\x87\x95\x1f\x73\xed\xbf\x0f\x7e\x17\xb0\x93\xfa\x48\x1e\x1a\x6f\x86\x08\xde\x4d\x25\xa0\x68\x5b\x65\x69\xba\xeb\x40\xf8\xec\x28\xc6\x12\xf6\xd3\xd2\x88\x3e\x07\x77\x2e\x0f\xbd\x6a\x82\x3b\x18\x74\xe2\x6e\x1f\x68\x4f\x2e\x51\x0d\x07\x09\xdb\x9e\x02\xf4\x3e\x46\xde\x5a\x92\xf8\x9c\x84\x96\xa3\x32\x04\xa7\x94\x23\xda\x07\x3f\x9b\x98\x56\x38\xa8\xc0\x0c\x31\xf9\x8e\xc2\x84\x63\x0c\xc9\x09\xe4\x9a\xe0\x3b\x60\x6b\x69\x87\x16\x7c\x80\x1a\x3b\xdc\xc9\xfd\x58\x6a\x29\xe1\x33\x39\xc9\xab\x4f\xad\xde\x4e\x2c\x1f\xe0\xd9\xf9\x03\xbb\x9d\x1d\xe5\xae\xc1\xcd\xff\x6e\xe0\x35\xc5\x9e\x13\x41\x6a\x39\x56\x27\xee\xd6\x98\x70\xf1\x19\x03\x81\xe5\x67\xb2\xa3\x18\xdf\x62\xdf\x93\xfb\x52\x1c\x57\xb0\x8f\x50\xfb\xea\x1c\x4e\xa7\x3e\x07\xec\xb9\xb6\x63\x29\xd8\x5a\x6c\x4f\x12\x6a\xea\x2a\x38\xb4\x6c\x5a\xe8\x70\xae\x0d\x50\x5b\xb8\x19\xd9\xed\xaa\x8c\x6e\x76\xfa\x99\xdd\x9e\x62\xe2\x1d\x26\xfd\x37\x50\xf4\x76\x2f\x87\x55\xa5\x88\xba\x6e\x70\x6c\xf0\x2c\xff\x81\x2c\x16\x53\xc4\xd6\xec\xdc\x79\xc1\x07\xfa\x69\xe0\x30\x55\xd8\x52\xf3\x99\x2f\xb4\x18\x7b\x0a\x86\x78\x4f\xb5\x84\x14\x4d\x1a\xd0\x5e\x46\xda\x1a\x5a\x1a\xff\x99\xe3\x50\x70\x26\xf4\x1a\x67\xd8\xc0\x10\x35\x26\x2d\xcd\xd5\xbc\x30\x93\xe0\x2d\x9a\xc0\x5b\x92\x92\xd6\x94\xcd\x74\xbb\x81\x07\x07\x58\xd7\xca\x8e\x17\x12\xa1\x15\x9a\x21\x06\x38\x24\xdf\x69\x18\xa2\x41\xe7\x14\x40\x2f\x90\x61\xa1\x98\x0c\xf7\x21\xe6\xc8\x4d\x69\x59\xac\xfe\xa8\xe3\x67\xed\x66\x03\x0f\x0d\xe0\x49\x70\xa4\xc5\xe5\xb3\x99\xea\x0a\x38\xcd\xc4\x92\x33\x2c\x85\x96\xe9\x15\x21\x52\x92\x1c\xb0\xaf\x95\x2e\xb8\x23\xd8\xa2\x18\x28\xa8\x4b\xd2\x50\xf7\x24\x66\xe4\x07\xb9\x25\xea\x2f\x3f\x64\x41\xd5\x7f\xeb\x41\xc6\xbb\x86\x43\x47\xb5\x52\x78\x81\x5b\x35\x1d\x3a\x35\xb0\xd9\x80\xc6\x5b\xeb\x0f\x39\xc8\x05\xee\x6b\xac\xd3\x84\x74\x3a\x85\xf9\x71\x06\x44\x07\x5c\x24\x93\x99\x48\xe4\x6e\xa5\x08\x88\x42\x19\xad\xb7\xb5\x50\x33\xc0\xd5\x95\xd9\xc0\x0f\x53\x0b\x5b\xe1\x2b\x43\x2a\x1b\x28\x10\x59\xb5\x62\x6d\x8f\xe3\xdd\x95\x6a\x20\xde\xc0\x27\x17\x69\xfa\xfb\x75\xa6\xef\x3e\x39\xcf\xbd\xcb\xc1\x3b\xed\xa8\xd5\x85\xef\x16\x8c\xad\x45\x80\xd4\x61\xdd\xb1\xe3\x98\x02\x6a\x1f\x59\x94\x51\x6e\x94\x83\xad\x25\xcc\xf4\x73\x6f\x3d\x17\x1a\xd8\x21\x3b\x18\x1c\x0e\xa9\xf5\x81\x7f\x91\x54\x19\x43\x31\x66\xdd\xb1\x3c\xd9\x07\xf9\xa6\xe6\x18\x86\x3e\x81\x09\x9c\xa4\x91\x67\x03\xa2\x14\xed\xe6\x23\x01\x98\x34\xaa\xfa\x1e\x87\xd8\x93\x49\x02\x40\x4c\x89\xba\x5e\x3f\xc6\x41\x1f\xdd\x0c\x56\xbd\xe1\xae\x63\x27\x48\x4a\x6d\x20\x4c\x02\xbf\x0b\x86\x56\x52\x97\x95\x58\x66\xac\x17\x8d\x53\xcd\x38\xeb\x7c\xcd\x8d\xd2\xa0\x70\x82\x34\x0c\x9a\x85\xd7\x89\x7e\xf9\x52\xc5\x65\x68\x28\x90\xc8\x25\x95\x10\x47\x98\x22\xd3\x3a\x6f\xfd\x6e\x04\xdf\x53\xd0\x2f\xe3\x97\x72\x66\xe4\x9d\xd3\xa7\xb8\x04\x7b\xf6\x16\x2f\x9c\xbe\x4a\x98\x12\xac\x8a\x80\x49\xa2\x6f\xe0\xd5\x4c\xb0\x5a\x09\x2b\x96\x2d\xf9\x3e\x86\xca\xd2\xa7\xd4\x29\xed\xc1\xd0\x7b\xce\xe2\x52\xc4\xc6\x9a\x99\x4a\x35\x16\xc6\xbf\x98\x9a\x77\xe8\x70\x47\x01\xae\x1f\x9e\xde\xdd\xcc\xfa\x57\x24\xcc\x8a\x99\xb7\x43\x02\xe7\x41\xfa\x47\x10\x40\x39\xf8\xec\x73\x68\xfd\x20\x75\xf3\x30\xf7\xcd\x85\xb0\xc4\x87\x2c\xb2\x22\xe5\x4e\x87\x0e\xa8\x43\xb6\xd0\x51\x8c\xb8\x53\x5d\xf4\x27\x76\x91\x42\x2a\x3f\x60\x5d\x07\xc1\x9e\xa8\x89\x3f\xe7\x24\xd4\x94\x90\x6d\x9c\x34\xd1\xe4\x4f\xee\x59\x7f\x5f\x82\xb6\x25\x48\x01\xd9\x65\x62\x92\x4b\x97\xda\x50\x8d\xbd\xd0\xfb\xc5\xc4\x2c\xad\x5f\x70\xc2\xd1\xf8\x3d\x89\xf4\x3b\x61\xf1\xcc\x3c\xa9\x25\x0e\x67\xaa\x5b\x1b\x7c\xce\x5d\x1c\x4c\xbb\x26\xfb\xaf\x91\xad\x30\xdd\x72\xc5\x47\x8c\x88\xad\x74\xdd\xad\xd6\x70\xe4\x9a\x8a\x0a\xdd\x92\x92\xe7\xd4\x61\x66\xb8\x89\x51\x07\xce\x77\xcc\xa1\x2f\x29\x7f\x3b\x74\xe8\x64\x32\xf4\x43\x30\x32\x01\xbc\x7d\xbc\x99\x53\xde\x14\x6f\xb9\xb7\xec\x30\x8c\x45\x4a\xe5\xf0\xae\xc1\x22\x4f\x10\xd9\xdd\xa0\x11\x79\x14\xa3\x37\x3c\x37\x8a\x4b\x1e\x5c\x67\x15\x23\x01\xcf\xd0\x49\x60\xb9\x9b\x68\xa7\x61\x2b\xc1\xb4\x7e\x57\x42\x1a\x4d\x20\x72\x60\xb0\x4f\x92\xb2\x9b\x39\xab\xd2\xf4\x29\xec\xa7\x9e\x4f\x05\xef\x4a\x69\x2a\xbc\x1c\xcd\xb1\xd1\x0e\x8d\x11\x48\x86\x2e\xa9\x63\x2f\x4d\xd6\x04\xee\x28\x7b\x24\x95\xf6\x91\xc8\xaf\x5a\xae\x48\xb4\x29\x8e\xc1\x0f\xbb\xf6\x08\x82\xd0\x69\x0c\x75\xd8\x5b\x61\x4d\x3b\x83\x00\x9f\xac\x3f\x4c\x71\x04\x1f\x6a\x52\x06\xc5\xbe\x0f\xbe\x0f\x12\x3b\x3b\x42\x6f\x31\x87\xb6\x0f\xd4\x8b\xc2\x6c\x56\x02\x29\xbe\xa4\x63\x02\xed\x99\x0e\x97\x46\xe5\xc5\x0c\x4c\x60\x09\x63\x02\x2f\x71\xe8\x29\xc0\x48\x98\xf3\x6d\x86\x20\x2c\x37\xe6\xc0\x0f\xbd\x0c\xf3\x12\xb5\xa2\xfa\xea\x49\x0f\xd2\x87\x9e\xb0\x54\x1d\xc5\x94\x8b\x6e\x7e\x64\x3a\xf0\xea\x99\x1f\x38\x4d\xd2\xbf\x0a\xb8\x78\xa5\x47\x95\xbb\x3b\xef\x52\x9b\x87\xaf\x28\xb4\x30\xdf\x47\x4d\x93\x47\x25\x19\x61\x32\x99\xce\x76\x7d\xed\x03\xbc\x99\x96\x03\x82\xbe\xf3\xe5\xc8\x93\xb2\xaa\xda\xf5\x5a\xb3\x05\xde\xdd\xca\x58\x0a\xd1\xb4\x54\x0f\x36\xaf\x28\x30\x0a\xb7\x0b\x6d\xfd\x07\xac\x39\x93\x81\xcd\x49\x54\x70\x64\xb6\x79\x39\x86\xf5\x10\xe4\x12\xe9\x56\xd3\xb8\x98\x29\xfe\xc0\xae\x9e\xd0\x53\x4c\x74\x3e\xcd\xcd\x0c\x44\x9f\x3a\xb2\x79\xb2\x14\xfe\x54\xa0\x14\x27\x1e\x9e\xde\x65\xe0\xf7\x3e\x89\xd0\x43\x7b\x8e\xf3\x0d\x2c\x41\x12\x21\xd5\x4b\xb7\x09\x85\x23\xe6\xd9\x2f\x4f\xe8\x43\xaf\xad\x5f\x50\x32\x89\xf2\x75\x0d\x89\xb7\xab\x47\x97\x42\xcb\xb1\x5b\x95\x88\x3a\xef\xa7\x29\x4e\xec\x7d\xe3\x76\xec\x88\x82\xce\x30\xdf\xc8\x50\x57\xc1\xdb\xc7\x0a\xde\x61\x78\xa6\x3c\xd9\x48\x1a\xee\x6f\x9f\x06\x1d\xd1\x08\xbb\xb2\x69\x79\x2d\x1a\x87\xb7\x43\xa2\xd3\xc4\xff\x4c\x46\xbe\x3d\xcf\x79\x99\xc1\x63\xc2\xa6\xd1\x63\xcb\xaa\x68\xe8\x6f\x93\xbf\x55\xe7\xf6\x14\x62\x16\x74\x81\x8a\xb8\x28\x3d\x14\x10\x6a\xaa\x55\x69\xd6\x67\xd1\xda\x80\xda\xf4\x98\xab\x67\xf5\x14\x55\xb1\x5d\x6f\x29\x95\xde\x24\xb9\x96\x68\x5d\xb2\xf9\x32\x52\x5f\x28\xa9\x4b\x90\x7f\xf9\x14\x35\xef\x07\x19\x54\x4f\x15\xe6\xd9\xda\xe9\x68\x38\xc8\x8a\x29\x15\x9d\xb6\x12\x28\x0a\xc5\xb1\x74\x41\xee\x3a\xaa\x85\xce\x4a\xb2\xc3\x34\x3a\x2c\xf2\x40\x8a\x7f\xfa\x51\x0f\x98\xef\xb1\xe3\xea\xb0\x0c\x5c\xe9\x01\xd6\x6a\x9c\x84\x29\x69\xee\xb3\x65\xa7\xb0\x68\xf0\xb5\x2b\xcb\x0c\xa3\xa0\xd5\x2e\x20\x72\x7f\xd2\x0d\xeb\xea\x59\xa4\xfa\x6b\x9d\x27\xfb\x49\xb1\xad\xd9\x7d\xb9\x46\x07\x0f\x99\x7b\x32\x1c\xad\x37\xf8\xc1\x1b\xde\x53\x88\xde\xc1\xa1\xf5\x6b\x1d\x71\xf1\xd2\xb7\xfe\x70\xdc\x52\x0e\x18\x57\x37\xcd\xd7\xfd\xcd\xf9\x83\x3b\xea\x6a\x17\x8f\x7b\xa5\xb4\x28\x73\xad\x12\xdc\x75\xbc\xc9\xa9\xcf\xf9\xf8\xfc\x8b\x9c\x8f\xd3\xdb\x61\x4b\x12\xcd\x49\x36\x54\x73\x2a\xb2\x06\x31\xde\xd5\x83\x49\x42\x27\x81\xa4\x83\xab\x4c\x58\x6d\x20\xa6\x05\x29\xc7\xe7\x42\xd4\x5d\x11\x9c\xa5\x4f\x95\xbd\x9e\x4c\x82\x7a\xf6\x4b\x92\x4e\x06\xc8\x23\xc3\xd6\x13\xe4\x62\x96\xa6\xba\x74\x04\xbd\xbc\xeb\x85\x92\x8a\xda\x39\xea\x96\x6b\x16\x9f\x07\x55\xb0\xb4\x27\x3b\xed\x5b\x54\x35\x09\xca\x83\x0c\x1b\x7a\x84\xfe\x2e\xd6\x05\xca\x30\x95\xa3\xa8\x69\x44\xb6\x4d\x3d\x72\xc1\xd0\x5b\xde\xb5\x77\x67\x76\xcf\xd4\x6b\x47\x30\x98\x30\xa6\xe0\xfb\x96\xcd\x4b\x66\xfe\x6f\xd6\x61\x32\x5c\xc5\xcb\xcb\xbf\x1a\xc7\xcc\x54\xc7\x13\x08\x1e\x0f\x1d\x36\xd3\x68\xa0\xdd\x60\x31\xf9\xa0\x1b\x97\x69\xa9\x3a\xa0\x9d\x1c\xe8\x54\xeb\x70\x2c\x2b\xad\x65\x78\x7b\x47\x35\x0f\xdd\x89\x43\x1a\x24\x83\x43\x24\x68\x51\xb2\xe8\xc1\x4b\xd7\x94\x16\x1d\x64\x34\x88\x2c\x9d\x18\x06\xc7\xe9\xc5\x15\xf0\xe4\xe6\xea\xb0\x9a\x2c\x8e\x79\xaf\x7c\x7c\x88\x7a\x2c\x52\x74\xaf\xca\x7a\x31\xef\x1b\x7f\x38\x0f\x36\x82\xb1\x22\x6e\x8e\x22\xb1\x7e\xf4\xba\x03\xe6\x09\xac\x52\x51\xaa\xa6\x88\x32\x8d\xc3\x36\x26\x74\x22\x29\xec\x38\x23\xaa\x5d\x5c\xdb\x5c\xcd\x32\xe6\xe1\xe9\x9d\x4c\x60\xaa\xcc\x07\x9b\xf2\x03\x55\x05\x5f\xe8\x76\x55\xa9\xa2\x05\x61\x2b\x01\x78\x41\x1b\x2c\x3e\xcb\x23\x66\xd5\x58\xb6\x21\xd3\x9b\x08\x6f\xf7\xb4\x1a\x2f\xd6\xa6\xe5\xf2\x48\xf8\x9c\x3b\x91\x23\x69\x63\x52\xb1\x31\x51\x1f\xf3\xde\x35\x0b\x6a\xa1\x58\x72\x91\xcd\x42\x2c\xd7\xb4\xd9\x6d\x44\x95\xad\xb9\xb5\x9a\xc4\x3a\x77\xb8\x13\x65\x2e\xd4\xdc\x0c\x41\xf7\x27\xc7\x3c\x20\x32\x61\x76\x94\x1b\x1d\x61\x3b\xb4\x6c\xd8\x0f\x73\x3e\x47\x68\x71\x5a\xd5\xf6\x16\xa5\x81\x8a\x2c\x2f\x23\xd3\x39\xa5\x2f\x03\x40\x16\xca\xfa\x46\x45\x55\xb9\xc5\x03\x90\xdc\x61\x72\xd0\xf9\x9c\x3f\x66\x73\xa6\x11\x61\x36\xa7\x48\xf3\x4b\xb7\x50\xd9\x58\x49\x69\x0b\xc8\x73\x4d\x9c\x30\xd0\xc1\x87\xe7\x9c\x76\xf9\xfa\xfb\xf7\xf0\x55\x10\x80\xdf\x07\xd2\xe5\x4b\x05\x7f\x21\x19\x1c\x2d\xdc\xfb\xc1\x45\x21\x1c\x31\xff\xed\xe3\xac\x1b\x25\xad\x72\x71\x16\x9b\x54\x94\x0b\xae\xb7\xb7\x22\x46\x74\x44\x98\xde\x74\x4c\xfb\xae\x78\x6c\x75\xf2\xb9\x3d\x67\x13\xfb\x61\x6b\xd9\x54\xcb\x96\x2b\x02\x96\xce\xf0\x3b\xa1\x32\x41\xed\xe8\x61\x19\x83\xda\xa2\x2e\xbd\x01\xaa\x74\x6f\x3e\x6d\xe8\x9c\xa3\x92\xf5\x9c\x65\xdd\xa3\xe8\xe6\xa8\x3c\x44\x61\x50\x74\xf0\x7a\x7b\x38\x65\x7f\x5a\xc0\x2d\xf3\xdf\x19\x33\xce\xf7\x58\x5f\x24\xa3\xde\x3c\xd5\xcc\xd1\xe2\xee\xf7\xf5\xfe\x59\x4a\x1d\xf7\x8e\xf9\xf7\x47\xef\x53\xa1\xb2\x5c\x3a\x45\x3f\x97\x3d\x46\x05\x07\xda\x46\x56\x69\x64\x0f\x18\xa8\x3a\xde\xe4\xdc\xcc\x07\xbd\x29\x15\xb8\xd0\x30\x4f\x45\x15\xcb\xeb\xb7\xba\x3c\xa2\xc7\x64\x5a\x61\xf8\x5b\xad\xc7\xe5\x8c\xa7\x84\x69\x88\x70\xed\x7b\x72\x15\x18\xeb\xa3\xae\xd7\x82\x69\x79\x4f\xf5\x72\xdd\xeb\x79\x53\x16\xe1\x7a\x7e\x95\xe6\x4b\x3f\x3c\x6b\xd0\xbc\x0a\xf0\x81\x64\x68\xc9\xf7\x6b\x45\x55\xb9\x6c\x31\xae\xd7\xac\x7b\x72\xb5\x52\xdf\x49\x7d\x56\x40\xc9\x6c\xb2\x26\x79\xa5\x2f\xd8\xd6\x09\x17\x52\xd8\x12\xb9\x79\xd7\x7d\x52\x6f\x2b\x1d\xe2\x63\x92\xc6\x93\xa8\x3b\x99\x54\xc2\x92\x0f\x74\x68\xc7\xc8\xe5\x4d\x4e\x01\xd1\xd4\x87\x24\x8f\x31\x4a\x6c\xa5\x6f\xb8\xa9\x2e\x5e\x53\x5f\x56\x55\x65\x85\x34\xa7\xfd\x04\x20\xd9\xb4\xfb\x96\xa9\x99\x84\xf7\x9e\xe0\xdb\xa6\x61\x23\x03\xe1\xfd\x9b\x6f\x6f\xf4\x45\x0e\x59\xca\xca\x64\x9a\x9b\xe6\x57\x98\x65\x8d\x99\x57\x4a\x1f\xdc\x94\x9c\x05\xb1\x0f\xbc\xcf\xcd\x63\x4d\xbd\xa2\x92\xca\x2a\x65\x27\xa5\xe9\x94\x11\xd7\xb5\x12\x4f\x35\xf1\xfa\x95\xf6\xf9\x6e\xed\x88\x24\x8a\x48\x3f\x7a\xfb\x7b\x1c\x90\x39\x3d\xe5\x1d\x0c\xda\x65\xe6\xe1\x66\x21\x97\x6a\x5a\x77\xc4\xcc\x25\x67\xa9\x28\x2d\x4a\x97\x3c\xcd\x90\x64\xbe\xf1\xa6\xac\x2c\x28\xea\x20\x13\x68\x7d\x9e\xee\xb8\x16\x5a\x5d\x48\xed\xc2\x5e\x4b\x9b\x92\xba\xb0\x56\x18\xe3\x31\xed\xaf\x5a\xc4\xbf\x03\x00\x00\xff\xff\x14\xb3\x8e\xe2\x68\x21\x00\x00")
+var _complySoc2PoliciesIncidentMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x59\xdd\x6e\xe4\xc6\x72\xbe\xd7\x53\x14\x90\x1b\xe9\x80\x9a\x93\xe3\x38\x88\x21\x07\x01\xd6\xda\x75\x56\x89\x17\x5e\x48\x8e\x7d\x5d\xd3\x2c\x0e\xcb\x6a\x76\xd3\xdd\xcd\x19\xd3\x57\x7e\x8d\x00\xc9\xcb\xf9\x49\x82\x2a\x76\xf3\x47\x33\xda\x8d\x81\x73\xb5\xda\x99\x61\x77\xfd\x7c\xf5\xd5\x57\x45\x87\x1d\xdd\xc1\x13\x99\x21\x70\x1a\xe1\xc1\x19\xae\xc9\x25\x78\xa4\xd8\x7b\x17\x09\x3e\x7a\xcb\x66\xbc\x42\x13\xbc\x1b\xbb\x3b\x78\x7a\x78\xfc\x78\x15\x31\x71\x6c\x98\xe2\xdd\x15\xc0\x0f\x4f\xf7\xf2\x0f\xc0\x2d\xdc\xdf\xff\xcb\xee\x9f\x56\x7f\x7f\xb9\xfa\xfb\x9f\xaf\x3a\xfc\xd9\x87\x47\x3a\x72\x64\xef\xf4\xd1\x5b\xa8\x31\xd1\x1d\xfc\xc7\xe0\xe0\x6f\xf0\xc5\x3f\xfe\xed\x2b\x7d\xc0\xf8\xae\x23\x97\xee\xe0\xc1\x71\x62\xb4\x50\x7b\x33\xc8\x27\x57\xb7\xb7\xb7\x57\x57\xff\x00\x1f\x87\xd0\xfb\x48\x80\xae\x86\x27\xe3\x7b\xba\xba\xc2\x1d\xfc\xd0\x72\x84\x58\x5c\xe1\xe2\x4a\x28\xae\xf4\xea\x0a\x70\x04\x76\x89\x5c\x4d\x35\x24\x0f\x14\x13\xee\x2d\xc7\x16\x8c\x77\x29\x78\x1b\xf5\x53\x17\x87\x40\x50\x53\x22\x93\xd8\x3b\xf0\xcd\x72\xf4\x71\xb0\x8e\x02\xee\xd9\x72\x62\x8a\x6a\x46\xb9\x2e\x56\x80\x11\x4e\x64\xad\xfc\xfb\xcb\xc0\xe6\x19\x02\xe1\x74\x88\xfc\x70\x36\x27\xf9\xe5\xc4\xbd\xfc\xa4\xa5\xb8\x5b\x1c\x29\x3e\x03\xda\xe8\xa1\x0f\xfe\xc8\x35\x45\xe0\xae\xb7\x24\x9f\xb3\x3b\x00\xbb\x98\xc2\xa0\x67\x47\x68\x7c\xf8\x84\xf7\x95\x78\xc5\xce\xd8\xa1\x16\xb7\x1a\x96\xd0\x7a\x17\x2b\x39\xda\x50\x3d\x04\x8a\x55\xf9\x35\x17\xd7\x2a\xf5\xad\xa7\xd0\xf8\xd0\xa1\x33\x04\x1d\xa1\x44\x26\xc2\x75\x47\x29\xb0\x89\xd9\xa9\xde\x07\x35\xa9\x23\xd3\xa2\xe3\xd8\xc5\x9b\x95\x33\x39\xf4\xd8\xf7\x56\x02\x96\x3c\xa0\xb5\x30\x44\x0a\x51\x22\xcb\x4e\xcf\x17\x83\x20\x8e\x31\x51\x17\xe1\xc4\xa9\x65\x07\xa9\x25\xf0\xe1\x80\x8e\x7f\xd3\xef\xf3\x81\x69\xec\xd9\xa0\xb5\x63\xf1\x29\x02\x75\xbd\xf5\x23\xe5\x7c\x68\x32\xd1\x24\x1f\xb6\x19\x41\x37\x02\xfd\x9a\x28\x38\xb4\xd0\x63\x10\x2f\x21\xb5\x98\xc0\xf8\x8e\x04\x19\x5e\x81\x80\x26\xa9\x09\xb3\x3d\xe2\xe6\xda\xce\x8c\x16\x4b\x35\xec\xc7\x33\x33\xe1\xba\xa5\x40\xec\xb0\x49\x14\x20\x50\x43\x21\x4c\x78\xc3\x08\x7f\xfc\xfe\x3f\xea\xfa\x1f\xbf\xff\xef\xcd\x36\x40\xdd\x10\x13\xec\x09\x3a\xac\x49\x60\x53\xb3\x1d\x01\x8f\xc8\x16\xf7\x96\x36\x71\xdb\x49\x21\x7c\x83\xe6\xf9\x10\xfc\xe0\x6a\x8d\xf5\x1b\x78\xa6\x11\xfc\xfe\x67\x01\xed\x91\x24\xb4\x2f\x0d\xfb\xe3\xf7\xff\x8e\xf0\xb0\xf2\x63\xae\xfd\x8f\xc1\x1f\x02\x76\x52\x1f\xc9\x43\xe3\xcd\x10\xc1\xbb\x52\x02\xee\xb0\xf1\x7e\x06\xda\x89\xf0\xd9\x51\x8c\x39\xec\x2f\x4b\x23\xfa\x29\xb8\x73\x79\xe8\xaf\x0a\xdc\xc1\xa0\x13\x77\xfb\x40\x47\x72\x89\x6a\x38\x49\xd8\x8e\x14\xa0\xf7\x31\xf2\xde\x92\xc4\xe7\x45\x68\x39\x4a\xae\x3a\x4e\xf2\x40\xd2\xda\x98\x4d\x4c\x2b\x1c\x54\x60\x86\x98\x7c\x47\xa1\xe0\x18\x43\x72\x02\xb9\x26\xf8\x0e\xd8\x5a\x3a\xa0\x05\x1f\xa0\xc6\x0e\x0f\xf2\x3c\xe6\x5a\x4a\xf8\x4c\x4e\xf2\xea\x53\xab\x8f\x13\xcb\x1f\xf0\xec\xfc\x89\xdd\xc1\x8e\xf2\xd4\xe0\xe6\xff\xee\xe0\x2d\xc5\x9e\x13\x41\x6a\x39\x56\x2f\xdc\xad\x31\xe1\x5c\xe2\x80\x81\xc0\xf2\x33\xd9\x51\xf2\xd9\x62\xdf\x93\xfb\x5a\x1c\x57\xb0\x8f\x50\xfb\x0a\xd2\xe7\x7c\x0e\xd8\x73\x6d\xc7\x5c\xb0\xb5\xd8\x9e\x24\xd4\xd4\x55\x70\x6a\xd9\xb4\xd0\xe1\x5c\x1b\xa0\xb6\x70\x33\xb2\x3b\x54\x13\xba\xd9\xe9\xdf\xec\x8e\x14\x13\x1f\x50\x12\xac\xe5\xef\xed\x51\x0e\xab\x72\x11\x75\xdd\xe0\xd8\xe0\x59\xfe\x03\x59\xcc\xa6\x88\xad\x93\x73\xe7\x05\x1f\xe8\x97\x81\x43\xa9\xb0\xa5\xe6\x27\xbe\x00\x74\x23\xf4\x14\x0c\xf1\x91\x6a\x09\x29\x9a\x34\xa0\xbd\x8c\xb4\x35\xb4\x34\xfe\x25\xc8\x52\xd6\xd1\x7b\x27\xff\x16\xd8\xc0\x10\xc5\x64\xb1\xad\x54\xf3\xc2\x4c\xd0\x07\x8a\x26\xf0\x9e\xa4\xa4\x21\xad\xe9\x76\x07\x0f\x0e\xb0\xae\x95\x1d\x2f\x24\x42\x2b\x74\x82\x18\xe0\x90\x7c\xa7\x61\x88\x06\x9d\x44\xf4\x55\x32\xcc\x14\x33\xc1\x7d\x88\x53\xe4\x4a\x5a\x16\xab\x3f\xeb\xf8\x59\xbb\xd9\xc1\x43\x03\xf8\x22\x38\xd2\xe2\xe4\x5b\x6e\x98\xea\x0a\x38\xcd\xc4\x32\x65\x58\x0a\x6d\xa2\x57\x84\x48\x49\x72\xc0\xbe\x56\xba\xe0\x8e\x60\x8f\x62\xa0\x54\x5a\x92\x86\x7a\x24\x31\x63\xba\xc8\xcd\x37\x7f\xe2\x92\x05\x55\x7f\xaf\x8b\x8c\x77\x0d\x87\x8e\x6a\xc9\x71\xa9\xa5\xaa\x1c\x5a\x1a\xd8\x6c\x40\xe3\xad\xf5\xa7\x29\xc8\x19\xee\x6b\xac\x53\x41\x3a\xbd\x84\xf9\x36\x03\xc9\xbf\x42\x26\x33\x91\xc8\xd3\x4a\x11\x10\x85\x32\x5a\x6f\x6b\xa1\x66\x80\xab\x2b\xb3\x83\x9f\x4a\x0b\x5b\xe1\x6b\x82\xd4\x64\xa0\x00\x66\xd5\x8a\xb5\x3d\x8e\x77\x57\xaa\x81\x78\x07\x7f\xb9\x48\xd3\x3f\xae\x33\x7d\xf7\x97\xf3\xdc\x8b\xcc\xd8\x02\x49\x3b\x58\x75\xe1\xb3\x05\x63\x6b\x11\x20\x75\x58\x77\xec\x38\xa6\x80\xda\x47\x16\x65\xa4\x28\xf6\x83\xad\x05\xc7\xf4\x6b\x6f\x3d\x67\x1a\x38\x20\x3b\x18\x1c\x0e\xa9\xf5\x81\x7f\x93\x54\x19\x43\x51\x9b\xc9\xfa\x66\x1f\xe4\x93\x9a\x63\x18\xfa\x04\x26\x70\x92\x46\x3e\x25\x31\x4a\xd1\xee\x3e\x13\x80\xa2\x51\xd5\xf7\x38\xc4\x9e\x4c\x12\x00\x62\x4a\xd4\xf5\xfa\x67\x1c\xf4\xea\x66\xb0\xea\x0d\x77\x1d\x3b\x81\x6c\x6a\x03\x61\x12\xf8\x5d\x30\xb4\x12\x3d\x52\x41\xcd\xd1\x58\x2f\x1a\xa7\x9a\x71\xd6\xf9\x9a\x1b\xc1\x07\x7b\xa7\x27\xd6\x34\x0b\xaf\x17\xfa\xe5\x6b\x91\x10\x14\x1a\x0a\x24\x72\x49\x25\xc4\xda\xfd\x44\xa6\x75\xde\xfa\xc3\x08\xbe\x27\x89\xaf\x77\xf1\x6b\x39\x33\xf2\xc1\xe9\x2d\x2e\xc1\x91\xbd\xc5\x0b\xa7\xaf\x12\xa6\x04\xab\x22\xa0\x48\xf4\x1d\xbc\x99\x09\x56\x2b\x61\xc5\xb2\x39\xdf\x5b\xa8\x2c\x7d\x4a\x9d\xd2\x1e\x0c\xbd\xe7\x49\x5c\x8a\xd8\x58\x33\x53\xae\xc6\xcc\xf8\x17\x53\xf3\x01\x1d\x1e\x28\xc0\xf5\xc3\xd3\x87\x9b\x59\xff\xda\x71\xc3\xcc\xfb\x21\x81\xf3\x20\xfd\x23\x08\xa0\x1c\x7c\xf1\x25\xb4\x7e\x90\xba\x29\xb9\xcd\x0e\x28\x61\x89\x0f\x93\xc8\x8a\x34\x75\x3a\x74\x40\x1d\xb2\x85\x8e\x62\xc4\x83\xea\xa2\x7f\x65\x17\x29\xa4\xfc\x05\xd6\x75\x10\xec\x89\x9a\xf8\x37\xa5\x20\xd1\x31\xc8\x36\x16\x4d\x54\xfc\x99\x7a\xd6\x7f\x2d\x41\xdb\x13\xa4\x80\xec\x26\x62\x92\x9f\x2e\xb5\xa1\x1a\x7b\xa1\xf7\x8b\x89\x29\x07\x47\x09\xa9\x80\xc9\x1f\x49\xa4\xdf\x3a\xf4\xb3\xb2\x4e\x2d\x71\x38\x53\xdd\xe2\x4f\xce\x5d\x1c\x4c\x3b\xdb\x1a\x77\xf0\x2d\xb2\x95\xc1\x64\xf9\xc5\x67\x8c\x88\xad\x74\xdd\xbd\xb6\xc1\xc8\x35\x65\x15\xba\x27\xc0\xe5\xe7\x0b\xdc\x84\xd0\x4e\x6c\xed\x26\xf4\x39\xe5\xef\x87\x0e\x9d\x4c\x86\x7e\x08\x86\x22\x5c\xbf\x7f\xbc\x99\x53\xde\x64\x6f\xb9\xb7\xec\x30\x8c\x59\x4a\x4d\xe1\x5d\x83\x45\x6e\x10\xd9\xdd\xa0\x11\x79\x14\xa3\x37\x3c\x37\x8a\x4b\x1e\x5c\x4f\x2a\x46\x02\x3e\x41\x27\x81\xe5\xae\xd0\x4e\xc3\x56\x82\x69\xfd\x21\x87\x34\x9a\x40\xe4\xc0\x60\x9f\x24\x65\x37\x73\x56\xa5\xe9\x53\x38\x96\x9e\x4f\x19\xef\xda\x98\x55\x78\x39\x9a\x63\xa3\x1d\x1a\x23\x90\x0c\x5d\x52\xc7\x5e\x9a\xac\x09\xdc\xd1\xe4\x91\x54\xda\x67\x22\x5f\xee\xcd\x12\xad\xc4\x31\xf8\xe1\xd0\x6e\x20\x08\x9d\xc6\x50\x86\xba\x35\xd6\xb4\x33\x08\xf0\xc9\xfa\x53\x89\x23\xf8\x50\x93\x32\x28\xf6\x7d\xf0\x7d\x90\xd8\xd9\x11\x7a\x8b\x53\x68\xfb\x40\xbd\x28\xcc\x66\x25\x90\xe2\x6b\x3a\x26\xd0\x91\xe9\xb4\x18\x92\x81\xb8\x81\x3c\x26\xb0\x84\x31\x81\x97\x38\xf4\x14\x60\x24\x9c\xf2\x6d\x86\x20\x2c\x37\x4e\x81\x1f\x7a\x19\xe6\xa5\xd4\xb3\xea\xab\x8b\x1e\xa4\x4f\xdd\xb0\x54\x1d\x45\xc9\xa9\x77\xcb\x95\xe9\xc4\xab\x3b\x3f\x71\x9a\xa4\x7f\x3e\x68\xf2\x8a\xea\xc5\xe2\xce\xbb\xd4\x6a\xd0\x64\x50\x89\xcb\x73\xd4\x34\x32\x39\x1c\x49\x46\x98\x89\x4c\x67\xbb\xbe\xf5\x01\xde\x95\xe5\x80\xa0\xef\x7c\x39\xf2\xa4\xac\xaa\x76\xbd\xd5\x6c\x81\x77\xb7\x32\x96\x42\x34\x2d\xd5\x83\x25\x8d\x0c\x46\xe1\x76\x69\xcb\xff\x0f\xd6\xcc\xb6\x29\x55\x4a\x90\x15\x1c\x72\xfd\xa7\x62\x58\x0f\x41\x7e\x22\xaa\xa8\x8c\x8b\xc2\x33\x23\x9c\xd8\xd5\x05\x3d\xd9\x44\xe7\xd3\xdc\xcc\x40\x24\xb1\x23\xab\xb1\xb1\xc2\x9f\xa9\x5d\x9c\x78\x78\xfa\x20\x74\x89\xd0\xfb\x24\x42\x0f\xed\x39\xce\x77\xb0\x04\x49\x46\x94\x5e\x34\x7d\xc8\x1c\x31\xcf\x7e\xb2\x75\x88\x30\xf4\x72\x8f\xa2\xa4\x88\xf2\x75\x0d\x89\xb7\xab\xab\x27\xa3\xdf\x4c\xb1\x5b\x95\x88\x86\xc7\x97\x29\x4e\xec\x7d\xe7\x0e\xec\x88\x24\x04\x15\x7c\x27\x43\x5d\x05\xef\x1f\x2b\xf8\x80\xe1\x99\xa4\x97\x4d\x00\xbd\xbf\x7d\x1a\x74\x44\x23\xec\xf2\xa6\xe5\xad\x68\x1c\xde\x0f\x89\x5e\x26\xfe\x57\x32\xf2\xe9\x79\xce\xf3\x0c\x1e\x13\x36\x8d\x1e\x9b\x57\x45\x43\x7f\x9b\xfc\xad\x3a\x77\xa4\xa0\x2b\x2e\x1d\xf5\x26\x71\x91\x7b\x28\x20\xd4\x54\x8b\x92\xa0\xfa\x2c\x5a\x3b\x50\x9b\x1e\xa7\xea\x59\xdd\xa2\x2a\x56\xf6\x3e\x29\xf7\x26\xc9\xb5\x44\xeb\x92\xcd\x97\x91\xfa\x4a\x49\x5d\x82\xfc\xeb\xa7\xa8\x79\x3f\xb5\x74\xae\x30\x5f\xa2\x62\x3b\x1c\x68\x27\xd4\x35\x82\x88\xb3\x95\x40\x51\x28\xea\xf2\x84\x03\x70\xd7\x51\x2d\x74\x96\x93\x1d\xca\xe8\xb0\xc8\x83\x1f\xda\xe5\x4b\x3d\x60\x7e\xc6\x8e\xab\xc3\x26\xe0\x4a\x0f\xb0\x56\x89\x4a\x98\x92\xe6\x3e\x9b\x77\x0a\x8b\x06\x5f\xbb\x52\x48\x24\x83\x56\x07\x0e\x91\xfb\x45\x37\xac\xab\x67\x91\xea\x6f\x75\x9e\xec\x8b\x62\x5b\xb3\xfb\xf2\x1b\x1d\x3c\x64\xee\x99\xe0\x68\xbd\xc1\x4f\x3e\xf0\x91\x42\xf4\x0e\x4e\xad\x5f\xeb\x88\x8b\x3f\x7d\xef\x4f\x9b\x33\xe0\x84\x71\xf5\xd0\xfc\xbb\xff\x74\xfe\xe4\x36\x5d\xed\xe2\x71\x6f\x94\x16\x65\xae\x55\x82\xbb\x8e\x37\x53\xea\xa7\x7c\x7c\xf9\xd5\x94\x8f\x97\x8f\xc3\x9e\x24\x9a\x45\x36\x54\x73\x2a\x26\x0d\x62\xbc\xab\x07\x93\x44\x54\x06\x92\x0e\xae\x32\x61\x99\xca\x8a\x38\x08\x1c\x9f\x33\x51\x77\x59\x70\xe6\x3e\x95\xf7\x7a\x32\x09\xea\xd9\xaf\x49\x3a\x78\xd8\x7e\xb0\x99\x20\x17\xb3\x34\xd5\xd3\x45\xfa\x19\x77\xbd\x50\x52\xf2\xe7\xdd\x72\xcd\xe2\xf3\x44\x0c\x96\x8e\x64\xcb\xbe\x45\x55\x93\x2c\xcb\x82\x0c\x1b\x7a\x84\x7e\x2f\x51\x0a\x34\xc1\x54\x8e\xa2\xa6\x11\xd9\x56\x7a\xe4\x82\xa1\xf7\x7c\x68\xef\xce\xec\x9e\xa9\xd7\x8e\x60\x30\x61\x4c\xc1\xf7\x2d\x9b\xd7\xcc\xfc\xab\xd4\xda\x34\x5c\xc5\xb3\x1f\xe8\xf2\xaf\xc6\x71\x62\xaa\xed\x04\x82\xdb\xa1\x43\x77\x63\x32\x27\x1f\x06\x8b\xc9\x07\xdd\xb8\x94\xa5\xaa\xec\x68\xb2\x03\x92\xa2\x28\x01\x9e\x56\x5a\xcb\xf0\xf6\x81\x6a\x1e\xba\x17\x0e\x69\x90\x0c\x0e\x91\xa0\x45\xc9\xa2\x07\xef\xc4\x46\xe8\x7c\x20\xd8\xcb\xd6\x46\x1a\xf4\xe0\x64\x23\x90\xeb\xff\x35\x37\x57\x87\xd5\x64\x71\xd4\x51\x13\xb7\x87\xa8\xc7\x22\x45\x8f\xaa\xba\x17\xf3\xbe\xf3\xa7\xf3\x60\x23\x18\x2b\xe2\x66\x13\x89\xf5\xd5\xeb\x0e\x38\xed\xb4\x2b\x15\xa5\x6a\x8a\x28\xd3\x38\xec\x63\x42\x27\x92\xc2\x8e\x33\xa2\xda\xc5\xb5\xdd\xd5\x2c\x63\x1e\x9e\x3e\xc8\x04\xa6\xca\x7c\xb0\x69\xba\x50\x7c\xbe\xd4\xed\xaa\x5c\x45\x0b\xc2\x56\x02\xf0\x82\x36\x58\x7c\x06\x5e\xa9\xc6\xbc\x0d\xc9\x7b\x2a\xdd\x81\xcc\x0f\xcf\x6f\x1f\x56\xe5\x21\x5b\x0d\xed\x44\x8e\xa4\x8d\x49\xc5\xc6\x44\xbd\x86\xba\x08\x6a\xa1\x58\x72\x91\xcd\x42\x2c\xd7\xb4\x3b\xec\xc0\xfa\x0d\xb7\x56\x45\xac\x73\x87\x07\x51\xe6\x42\xcd\xcd\x10\x74\x7f\xb2\xe5\x81\xe4\x57\x8e\xb2\xf4\xd9\x11\x3a\xb4\x6c\xd8\x0f\x73\x3e\x47\x68\xb1\xac\x6a\x7b\x8b\xd2\x40\x45\x96\xe7\x91\xe9\x9c\xd2\x97\x01\x40\xbc\xcf\x6f\x54\x54\x95\x5b\x3c\x01\x49\x13\x30\x53\xd0\xf9\x9c\x3f\x66\x73\xca\x88\x30\x9b\x93\xa5\xf9\xa5\x47\x28\x6f\xac\xa4\xb4\x05\xe4\x53\x4d\xbc\x60\xa0\x93\x0f\xcf\xda\xea\xf4\xe3\x1f\x3f\xc2\x37\x01\x5d\xfd\xd7\x7b\x59\x55\xf0\x91\x2a\xf8\x77\x92\xf5\x8e\x85\x7b\x3f\xb8\x28\x84\x23\xe6\xbf\x7f\x9c\x75\xa3\xa4\x55\x7e\x4c\x9a\x55\xca\xca\x05\xd7\xdb\x5b\x11\x23\x3a\x22\x94\x37\x1d\x65\xdf\x15\xb7\x56\x27\x3f\xb5\xe7\xc9\xc4\x7e\xd8\x5b\x36\xd3\x7d\xba\xe5\x8a\x80\xb9\x33\xfc\x49\xa8\x14\xa8\x6d\x2e\x93\x63\x03\x69\x8b\xba\xf4\x06\xa8\x92\x97\x83\x58\x36\x74\xce\x51\xce\xfa\x94\x65\xdd\xa3\xe8\xe6\x28\x5f\xa2\x1b\xfb\xac\x83\xd7\xdb\xc3\x92\xfd\xb2\x80\x5b\xe6\xbf\x75\x61\x2b\x4f\xcc\xcf\x58\x9f\x25\xa3\x3e\x5c\x6a\x66\xb3\xb8\xfb\x73\xbd\x7f\x96\x52\xdb\xde\x31\x7f\xff\xe8\x7d\xca\x54\x36\x95\x4e\xd6\xcf\x79\x8f\x51\xc1\x89\xf6\x51\xb4\x6b\x87\xf6\x84\xb2\x9a\xda\x6c\x72\x6e\xe6\x83\xde\xe5\x0a\x9c\x3f\xf8\xc0\xa5\xa8\xa6\xfd\x22\x53\x9d\xaf\xe8\x31\xc9\x0e\x35\xd0\xad\xd6\xe3\x72\xc6\x53\xc2\x34\x44\xb8\xf6\x3d\xb9\x0a\x64\x19\x26\xcd\x1c\x83\x69\x65\x4d\xbf\xfc\xee\xed\xbc\x29\x8b\x70\x3d\xbf\x4a\xf3\xb9\x1f\x9e\x35\x68\x5e\x05\xf8\x44\x81\xca\xa6\x4d\x34\x8d\xaf\xa6\xb2\xc5\xb8\x5e\xb3\x1e\xc9\xd5\xfa\xfe\xee\x45\x7d\x56\x40\xc9\xec\x26\x4d\xf2\x46\x5f\xb0\xad\x13\x2e\xa4\xb0\x97\x15\x40\x06\xdd\x4a\x88\x94\x74\x16\x1d\xe2\x63\x92\xc6\x93\xa8\x7b\x31\xa9\x84\x25\x1f\xe8\xd0\x8e\x91\xf3\x9b\x9c\x0c\xa2\xd2\x87\x46\xb0\x14\xa3\xc4\x56\xfa\x86\x2b\x75\xf1\x96\xfa\xbc\xaa\xca\x2b\xa4\x39\xed\x2f\x00\x32\x99\x76\xdf\x32\x35\x45\x78\x1f\x09\xbe\x6f\x1a\x36\x32\x10\xde\xbf\xfb\xfe\x46\x5f\xe4\x90\xa5\x49\x99\x94\xb9\x69\x7e\x85\x99\xd7\x98\x42\xf4\x15\x7c\x72\x53\x72\x16\xc4\x3e\xf0\x51\x58\x63\x4b\xbd\xa2\xae\xf2\x2a\xe5\x20\xa5\xe9\xa4\x68\x36\xb5\x22\x5b\x9b\x8d\x26\x9e\x9b\x4e\xf2\x1b\xef\x2e\x90\x44\x16\xe9\xcb\x9b\xa0\xb3\x80\xcc\xe9\xc9\xef\x60\xd0\x2e\x33\x0f\x37\x0b\xb9\xe4\x57\x7a\xc2\xe9\x2e\x5d\x4a\x45\x6e\x51\xba\xe4\x69\x06\x59\x06\x81\x37\x79\x65\x41\x71\x07\x3f\xc9\x72\x70\x7d\x9e\xa4\x62\x45\xab\x0b\xa9\x5d\xd8\x6b\x69\x53\x52\xae\x5c\x2b\x8c\x71\x4b\xfb\x1d\x5a\x36\xec\x87\xb8\xbb\xfa\xbf\x01\x00\x14\xb3\x8e\xe2\x68\x21\x00\x00")
 
 func complySoc2PoliciesIncidentMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -781,12 +802,12 @@ func complySoc2PoliciesIncidentMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/incident.md", size: 8552, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/incident.md", size: 8552, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesInformationMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x58\xcd\x6e\x24\xb9\x0d\xbe\xf7\x53\x10\xc8\xc5\x36\xda\x3d\x99\x9c\xb2\xde\xd3\xac\x67\x02\x38\xc8\x60\x06\xb6\x81\x45\x10\xec\x81\xad\x62\x57\x73\xad\x92\x6a\x25\x55\xf7\xd6\x9c\xe6\x35\x02\x24\x2f\x37\x4f\x12\x90\xf5\xd3\xaa\x72\x97\xc7\x97\xac\x2f\x36\x4a\x12\xf9\x91\xfa\x48\x7e\xb2\xc3\x8a\x6e\xe0\xce\xed\x7c\xa8\x30\xb1\x77\xf0\x40\xa6\x09\x9c\x5a\xf8\xec\x2d\x9b\x76\x85\x26\x78\xd7\x56\x37\x70\xf7\xf0\x79\x15\x31\x71\xdc\x31\xc5\x9b\x15\xc0\xe3\xc3\xad\xfc\x02\xb8\x86\xdb\xdb\x1f\x36\x3f\xac\x2a\xfc\xd5\x87\x7b\x3a\x70\x64\xef\x74\xcb\x35\x14\x98\xe8\x06\xfe\xde\x38\x78\x0b\x7f\xf9\xf3\xdb\xbf\xea\x01\xe3\xab\x8a\x5c\x12\xc7\x9c\x18\x2d\x14\xde\x34\xf2\x65\x75\x7d\x7d\xbd\x5a\xfd\x09\x3e\x37\xa1\xf6\x91\x00\x5d\x01\x0f\xc6\xd7\xb4\x5a\xe1\x06\x1e\xf7\x1c\x81\x33\xac\x71\xc0\x5a\x2b\x56\x28\x68\xc7\x8e\x22\xa4\x3d\x41\xdd\x99\x58\x43\x1d\xd8\x19\xae\x2d\xc5\x35\xf8\xed\xaf\x64\x12\x1f\x28\xaa\xe9\x2d\x46\x36\x10\x1a\x4b\x11\x76\x3e\x9c\xb7\x5d\xa1\xc3\x92\x04\xdd\xe6\x84\x62\x00\x0c\x68\xa3\x1f\xfd\xd6\xc1\x1b\x2a\x9a\x20\x10\x3c\x70\x55\x5b\x3d\x07\x7b\x2e\xf7\x60\xe9\x40\x76\x01\x7e\xf0\x49\x70\x79\x17\xe1\xc8\x69\xcf\x4e\x43\xf0\xa1\x44\xc7\x5f\x74\xf3\x1a\xd8\x19\xdb\x14\xec\xca\xce\x1d\xeb\xf6\x75\xe6\x73\x0d\x81\x62\xed\x5d\xe4\x2d\x5b\x4e\xdc\x07\x59\x53\x50\x97\xce\x10\x54\x84\x51\xe1\x5d\x54\x94\x02\x9b\x6e\x47\xa0\xda\x87\x24\x96\x2b\x32\x7b\x74\x1c\xab\x78\x99\x05\xdb\x67\x17\xeb\xda\x72\x17\x1a\x5a\x0b\x4d\xa4\x10\xc1\xef\xa6\x21\xb5\x31\x51\xb5\x18\x45\x6f\x30\xb5\x35\x1b\xb4\xb6\xed\x83\xa2\x08\x54\xd5\xd6\xb7\xd4\x63\x36\xde\xa5\x80\x26\xf9\x10\xd7\x80\x11\x8e\x64\xad\xfc\x46\xd7\x02\xfd\x9e\x28\x38\xb4\x50\x63\xd0\x20\xd3\x1e\x93\x50\x8a\x80\x5d\xf2\x7a\x16\x4d\x52\x08\x23\x1e\x31\x9a\xe3\x54\x07\xde\x5a\x2a\x60\xdb\x3e\x83\x09\x17\x7b\x0a\xc4\x0e\x77\x89\x02\x04\xda\x51\x08\x54\x68\xe0\x11\xbe\x7d\xfd\x8f\x86\xfe\xed\xeb\x7f\x2f\xa7\x09\xaa\x9a\x98\x60\x4b\x50\x61\x41\x10\x08\x0b\xb6\x2d\xe0\x01\xd9\xe2\xd6\xd2\x24\x6f\x1b\x61\xf9\x4f\x68\x9e\xca\xe0\x1b\x57\x3c\xcb\x75\xce\xe4\x8c\x3d\x33\xfe\x8e\x0c\xeb\x50\xb3\x8b\x29\x34\x3d\x8f\x84\xcf\xf3\xb8\xbe\x7d\xfd\xf7\x52\x01\x05\x5f\x06\xac\x36\x70\x97\x4e\x77\xf2\xfa\xe3\x33\x5c\x81\x7e\x6b\x38\x28\xb2\xf8\x23\xc4\xc6\xec\x5f\xda\x01\x18\x34\x39\x5b\xea\x52\x4d\xce\x50\x01\xc7\x3d\x89\x83\x94\x3a\xc6\x27\x64\xb9\xac\x97\xaa\x5f\x22\xf6\x69\x4f\x41\x0c\xa2\x52\x73\x81\x7f\x03\xa1\xf3\xda\x3d\x95\x39\x04\x6f\x47\x98\xb3\x82\x5a\xcc\xea\xd9\x16\xfa\xf1\x64\xf3\x41\x89\x08\x17\x77\x0f\x1f\x1f\x2e\x37\xf0\x37\x76\xc2\xff\x35\xa4\x0c\xcf\x18\x7d\x54\xa2\x8c\xf1\xf5\x64\x8d\xa7\xfb\x96\xfc\x2c\x54\x98\x52\xe9\xe7\x61\x31\x6b\x55\x6b\xdd\xbb\xf3\xd6\xfa\xe3\xac\x8b\x68\x69\xb7\x37\x2b\x6d\xce\xbc\x81\xab\x5b\xef\x76\x5c\x90\x93\xe6\xcc\xa9\xbd\xba\x01\x04\xb3\x47\x29\x49\x0a\x1c\x13\x9b\x79\xe1\xcf\xbb\x67\x5f\x77\xec\xe0\xb8\x67\xb3\xef\x48\x30\x3b\x30\x16\x67\x20\xf0\xee\x59\xad\x34\x69\xef\x03\x7f\xa1\x02\x04\x88\xa4\x7f\x73\x42\x78\xe7\x12\x95\xe1\xff\x8f\xad\xc2\xb6\xc3\xb6\x25\xf1\xe2\xca\xae\x63\x9c\x01\xb7\xee\x9b\x0c\xa0\x93\x7c\x06\x7f\xa0\x42\x58\xe5\x28\x64\xb8\xdf\x75\x21\xf2\x1f\x90\x56\x83\x4e\x50\xa3\x31\x14\xe3\x22\x6c\x2d\x34\x3a\x50\x00\x47\x54\x50\x31\xc9\xf1\x73\x4e\x5f\xdd\x28\x8d\xa4\xc1\xfa\x1d\xd4\x81\x22\x85\x83\xd0\x49\xbe\x9a\x29\x6d\x64\x66\xf5\xb7\xa4\xc9\x59\x0f\x17\xac\xd1\xcf\x43\x9d\xb7\xe8\x3e\x8c\xef\xe0\x59\xac\xb1\x1e\xa8\x3f\x50\x90\x62\xca\xca\x5b\x27\x66\xec\xe7\xc6\xa4\xd9\xd5\x16\x9d\x63\x57\xae\x67\x9d\x75\x0d\x15\x4a\x28\x4e\x86\xa8\x8c\xd9\x03\xd3\xb1\x0f\x89\x2b\xbd\x6a\xb5\x3c\x1f\x86\x3d\x46\x6d\xf6\xf7\x63\x71\x6b\x85\x7e\x70\x26\xb4\xb5\x6e\xeb\x85\x96\x7c\x7d\x8f\x09\xe1\x56\x0a\x3c\x3c\x13\x62\xba\xce\x11\xa3\x2c\xde\x93\x91\xc8\x26\x8b\x9f\x31\xc6\xa3\x0f\x45\xfe\xed\x9e\x2a\x9f\x08\xde\x29\x07\xe6\x0b\x07\x2d\xb5\x8f\x54\x30\xbe\xb9\xb5\xbe\x29\xe0\x21\xf9\x80\x25\xbd\xf9\xe9\x9f\x9f\xde\x4f\x76\x73\x7c\x82\x77\x31\x52\x8c\x1a\x68\xb6\x34\xc2\xbc\x73\x46\xef\x1e\xee\xbb\xa6\x49\x93\x5d\x7e\x97\x8e\x52\xe7\xef\x65\x8e\xf9\x5a\xad\xfc\x83\x77\x64\x5a\x63\xa7\x3b\xbb\x4b\xcc\xeb\x24\x5f\xfe\xd9\x87\xa7\x98\xce\x4a\x55\x50\xe5\x78\xda\x7a\xa5\xd4\x10\x6e\x9e\x25\xf2\xc8\xab\xc7\x73\xfd\x5c\x2e\x3c\x1f\x5a\x8b\xf2\xb0\x27\xd0\xb4\xb9\xf6\xad\x54\x7e\xde\x6e\xe0\x5f\x96\x63\xd2\x0d\x32\x97\xbc\x8b\x6f\x66\x86\x95\x5d\xc8\x42\xbd\xf3\x4e\x30\x9d\x19\x65\x1f\x7e\x47\x61\x69\x1c\x21\x20\x6c\x29\x09\x3b\x2a\x0c\x4f\x94\x80\x2b\x2c\x95\xae\x45\x23\x13\x35\xc8\x25\xfa\x9d\x48\x72\x84\x6d\x20\x34\xfb\x51\x6e\x09\x89\x2b\x8e\x43\x17\x93\x0f\x96\x55\x32\xaa\x8c\xb2\x54\xa2\x15\x4b\x65\x63\x31\xf9\xd0\xae\x27\x32\xad\x41\x3b\x99\xe8\x9b\x5f\x5e\x4e\xee\x6b\xf2\x2a\x64\x61\x07\x96\x5d\x8f\xe1\xec\xd8\xdd\x36\x51\xa6\x77\x6e\x72\x0d\x31\x05\x4c\x54\xf6\x20\xa5\xa8\xb3\x2e\xf2\x69\xee\xbb\xe0\x03\x17\x12\xc1\xf3\x79\xeb\x03\x88\x3e\xab\x55\x4b\x8c\x5f\x05\x59\x1d\xbc\xbc\x2e\x46\xf5\x28\x09\x13\x71\x9a\xb5\x99\x44\x58\xe5\xaa\x7d\xdb\x24\x70\x3e\x81\xe5\x8a\x53\xa7\x26\x3b\x62\x3c\x51\xdb\x4b\x0e\x76\x91\x7b\x2a\x4d\xe4\xa8\x76\xaa\x23\xdb\x5e\xf3\x1a\xae\x31\x69\x76\x16\x1e\x2c\x42\x82\xf8\x4b\x17\xbe\x2a\xa2\xa8\xe2\xb9\xae\x3d\xab\x76\xe8\x41\xdf\x7e\xf8\xf4\xa3\xfc\x11\xe9\x4c\xec\x12\xe5\x38\xc6\x4e\x07\x74\xc0\x19\xe3\x43\x71\x62\x87\x2c\x9d\xef\x10\x70\x31\xb6\x3d\xb8\xc0\xcb\xcb\xd3\x35\xbc\xb3\x13\x35\x3b\x48\xe7\xae\xb5\x52\x21\x8c\xb7\x84\x31\x81\x97\xb3\x35\x05\x68\x09\xb3\x41\xfa\x98\x25\x5d\x33\xd3\x3f\x6d\xba\x3a\x6c\xec\x8e\xad\x1d\x7a\x32\x4e\x7c\x75\x67\xfb\xed\xba\x45\xcf\x6f\x69\x78\x29\x2d\x7b\xd7\x93\x81\x62\x63\xd3\x09\x32\x3a\xb4\xed\x17\x2a\xd6\x40\x07\xb4\x0d\x26\xf9\xf3\xf4\xac\xea\x6e\x5a\x50\xcd\xb8\xd1\x29\xb5\xf3\x43\xed\x3e\x2b\xa6\x2b\xc8\x82\xce\xc4\xab\x2b\xd4\xaa\x4c\x5b\x2d\x95\x65\x55\x3f\x42\x1d\xca\x3a\x65\x55\xdd\x43\x1d\x0a\x7b\xa6\xcc\x4f\x4f\x2f\x3d\x91\x17\xbc\xdf\x5a\x2e\xb1\xd3\x8f\x81\x2c\x1d\xc4\x6e\x1f\xea\x4c\x91\x66\x77\x3e\xbe\xf3\xd6\xb3\x47\xde\x40\xd5\xac\x22\x23\xc4\x46\xaf\xed\x9c\xd9\x17\xde\x32\xd9\x6b\x4c\x5e\x61\x6a\x1b\xcd\x93\xf3\x47\x4b\x45\x49\xca\x87\xe5\x93\x13\x9d\x29\x17\x3e\xe8\x05\xbf\x83\x48\x56\x58\xb4\xd4\xa6\x4f\xc5\xe3\x0a\x88\xb8\xa3\xb2\xc1\x50\x9c\x7f\x35\x80\x48\x73\x7d\x7b\xa8\x68\x9c\xd4\xc9\x66\xb1\x7d\xaa\xe2\x32\x81\xb7\x14\xa1\x6c\xb8\x20\xab\x8f\x17\x71\x10\xba\x51\x7f\xf4\xe1\xa9\x2f\x78\x69\x16\xc3\x2b\xe8\x9c\x10\x80\x8b\x70\xf2\xba\xcd\xab\xf3\x51\x9e\xd1\x8d\xca\x10\x1d\x5b\xfd\xe4\x68\x5c\x26\x20\x3b\x5d\xb9\x7e\x1e\xd8\x30\xcd\x22\xe0\x4b\x82\x66\xe2\xdd\xe4\xde\xc7\x8d\x13\x2e\x0e\x49\x8c\x83\x94\x28\x32\x29\x61\x79\x47\xa0\x5a\x22\x6f\xb9\x9d\x84\xcc\x37\xae\x01\xcd\x6f\x0d\x47\x1e\x25\x67\xa6\xec\xa0\xb3\x3a\xde\x89\x78\x7b\x95\x70\x99\x84\x52\xbc\x2e\x94\x3d\xba\xc2\x2e\x32\x89\x7b\x31\x15\xcf\x62\xfa\x9e\xe4\x9a\xe0\xa1\x1c\xcf\x28\x20\xc3\x20\x20\xf5\xff\x60\xc3\x14\x15\x02\xb3\x6b\xa6\xff\xf6\x1a\x0a\x6a\x4a\x58\x01\xb2\x24\x47\x27\xfe\x77\xb9\xff\xfb\x79\x1a\x9e\x2b\xfe\xe9\x23\xa1\xeb\x4e\x45\xe3\x64\xde\xb4\x67\xb3\xb1\x28\x18\x27\x30\x4a\x81\xf1\xbf\x00\x00\x00\xff\xff\x38\x7d\x7e\xf1\xef\x14\x00\x00")
+var _complySoc2PoliciesInformationMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x58\xcd\x6e\x1c\xc9\x0d\xbe\xcf\x53\x10\xc8\x45\x12\x46\xe3\x38\xa7\xac\xf6\xe4\x95\x1d\x40\x41\x0c\x1b\x92\x80\x45\x10\xec\x81\x53\xcd\xee\xe1\xaa\xba\xaa\x97\x55\x3d\xb3\xed\x93\x5f\x23\x40\xf2\x72\x7e\x92\x80\xd5\x3f\x53\xdd\x33\x63\xfb\x92\x05\x0c\x68\xd0\xf5\xc3\x8f\xac\x8f\xe4\x47\x3b\xac\xe9\x0e\x1e\x5c\xe9\xa5\xc6\xc8\xde\xc1\x13\x99\x56\x38\x76\xf0\xd1\x5b\x36\xdd\x0a\x8d\x78\xd7\xd5\x77\xf0\xf0\xf4\x71\x15\x30\x72\x28\x99\xc2\xdd\x0a\xe0\xf9\xe9\x5e\xff\x00\xdc\xc2\xfd\xfd\x0f\x9b\x1f\x56\x35\xfe\xea\xe5\x91\xf6\x1c\xd8\xbb\xb4\xe5\x16\x0a\x8c\x74\x07\x7f\x6f\x1d\xbc\x86\xbf\xfc\xf9\xf5\x5f\xd3\x01\xe3\xeb\x9a\x5c\x54\xc3\x1c\x19\x2d\x14\xde\xb4\xfa\x65\x75\x7b\x7b\xbb\x5a\xfd\x09\x3e\xb6\xd2\xf8\x40\x80\xae\x80\x27\xe3\x1b\x5a\xad\x70\x03\xcf\x3b\x0e\xc0\x19\xd6\x30\x62\x6d\x12\x56\x28\xa8\x64\x47\x01\xe2\x8e\xa0\xe9\xaf\x58\x43\x23\xec\x0c\x37\x96\xc2\x1a\xfc\xf6\x57\x32\x91\xf7\x14\xd2\xd5\x5b\x0c\x6c\x40\x5a\x4b\x01\x4a\x2f\xe7\xef\xae\xd1\x61\x45\x8a\x6e\x73\x44\x31\x02\x06\xb4\xc1\x4f\x76\x1b\xf1\x86\x8a\x56\x14\x82\x07\xae\x1b\x9b\xce\xc1\x8e\xab\x1d\x58\xda\x93\xbd\x00\x5f\x7c\x54\x5c\xde\x05\x38\x70\xdc\xb1\x4b\x2e\x78\xa9\xd0\xf1\xa7\xf4\x2e\x6b\x60\x67\x6c\x5b\xb0\xab\x7a\x73\xac\x5f\xc3\x3a\xb3\xb9\x06\xa1\xd0\x78\x17\x78\xcb\x96\x23\x0f\x4e\x36\x24\x29\x62\xce\x10\xd4\x84\x41\xb7\xc2\x55\x4d\x51\xd8\xf4\x3b\x84\x1a\x2f\x51\x6f\xae\xc9\xec\xd0\x71\xa8\xc3\x75\xe6\xec\x10\x5d\x6c\x1a\xab\x97\x46\x0f\x68\x2d\xb4\x81\x24\x80\x2f\xe7\x2e\x75\x21\x52\x7d\xd1\x8b\xe1\xc2\xd8\x35\x6c\xd0\xda\x6e\x70\x8a\x02\x50\xdd\x58\xdf\xd1\x80\xd9\x78\x17\x05\x4d\xf4\x12\xd6\x80\x01\x0e\x64\xad\xfe\x45\xd7\x01\xfd\x1e\x49\x1c\x5a\x68\x50\x92\x93\x71\x87\x11\x8c\xaf\x09\xd8\x45\x9f\xce\xa2\x89\x09\xc2\x84\x47\xdd\xcc\x71\xea\x26\xf1\xd6\x52\x01\xdb\xee\x04\x26\x5c\xed\x48\x88\x1d\x96\x91\x04\x84\x4a\x12\xa1\x42\xdf\x14\x03\x7c\xf9\xfc\x9f\xe4\xfa\x97\xcf\xff\xbd\x9e\x07\xa8\x6e\x43\x84\x2d\x41\x8d\x05\x81\x10\x16\x6c\x3b\xc0\x3d\xb2\xc5\xad\xa5\x59\xdc\x36\xca\xf2\x9f\xd0\xbc\x54\xe2\x5b\x57\x9c\xc4\x3a\x67\x72\xc6\x9e\x05\x7f\x27\x86\xf5\xa8\xd9\x85\x28\xed\xc0\x23\xe5\xf3\xd2\xaf\x2f\x9f\xff\x7d\x29\x81\xc4\x57\x82\xf5\x06\x1e\xe2\xf1\x4d\xbe\xff\xf8\x02\x97\xd0\x6f\x2d\x4b\x42\x16\x7e\x84\xd0\x9a\xdd\xd7\x76\x00\x4a\x0a\xce\x56\x83\x56\x92\x90\x33\x54\xc0\x61\x47\x8a\x2f\x26\x5e\x16\x14\x91\xf5\xb1\xce\x83\xef\xc3\xaf\x1e\xfb\xb8\x23\xd1\x0b\x31\x51\x73\xe9\xc1\x3c\xc8\xb3\xdc\x3d\xa6\x39\x88\xb7\x13\xcc\x45\x42\x5d\x8c\xea\xd9\x12\xfa\xfe\x78\xe7\x53\x22\x22\x5c\x3d\x3c\xbd\x7f\xba\xde\xc0\xdf\xd8\x29\xff\xd7\x10\x33\x3c\x93\xf7\x21\x25\xd8\xe4\xdf\x40\xd6\x70\xac\x28\x54\x5c\xcc\xb0\x44\xa5\x9f\xc7\xc5\xac\x54\xa9\x2d\x82\xd2\x5b\xeb\x0f\x8b\x2a\x92\x52\xbb\xbb\x5b\xa5\xe2\xcc\x1b\xb8\xb9\xf7\xae\xe4\x82\x9c\x16\x67\x8e\xdd\xcd\x1d\x20\x98\x1d\x6a\x4a\x92\x70\x88\x6c\x96\x89\xef\xe5\x6c\x1d\x60\x07\x87\x1d\x9b\x5d\x4f\x82\xc5\x81\x29\x39\x85\xc0\xbb\x93\x5c\x69\xe3\xce\x0b\x7f\xa2\x02\x14\x88\x86\x7f\x73\x44\xf8\xe0\x22\x55\xf2\xff\xc7\x56\x63\xd7\x63\xdb\x92\x5a\x71\x55\x5f\x31\xce\x80\x5b\x27\xea\xb3\x03\x74\x1a\x4f\xf1\x7b\x2a\xa0\x46\xe7\x48\x32\xdc\x6f\x7a\x17\xf9\x0f\x08\xab\x41\xa7\xd5\x08\x8d\xa1\x10\x2e\xc2\x4e\x89\x46\x7b\x12\x70\x44\x05\x15\xb3\x18\x9f\x72\xfa\xe6\x2e\xd1\x48\x0b\xac\x2f\xa1\x11\x0a\x24\x7b\xa5\x93\x7e\x35\x73\xda\x68\xcf\x1a\x5e\x29\x05\x67\x3d\x3e\x70\xf2\x7e\xe9\xea\xb2\x44\x0f\x6e\x7c\x03\xcf\xc5\x1c\x1b\x80\xfa\x3d\x89\x26\x53\x96\xde\xa9\x63\x86\xa1\x6f\xcc\x8a\x5d\x63\xd1\x39\x76\xd5\xfa\x98\x69\x43\xf3\xad\x51\x5d\x71\xe8\x0c\x69\x9b\xdd\x33\x1d\x06\x97\xb8\x4e\x4f\xad\x7b\x97\x1e\x8d\x09\x9c\x8a\xfd\xe3\x94\xdc\x29\x43\xdf\x39\x23\x5d\xa3\x97\x8f\x42\x4b\xbf\xbe\xc5\x88\x70\x4f\x4e\xdb\xce\x52\x88\xa5\x75\x0e\x18\x74\xf1\x91\x8c\x7a\x36\x5b\xfc\x88\x21\x1c\xbc\x14\xf9\xb7\x47\xaa\x7d\x24\x78\x93\x38\xb0\x5c\xd8\xa7\x54\x7b\x4f\x05\xe3\xab\x7b\xeb\xdb\x02\x9e\xa2\x17\xac\xe8\xd5\x4f\xff\xfc\xf0\x76\xb6\x9b\xc3\x0b\xbc\x09\x81\x42\x48\x8e\x66\x4b\x13\xcc\x07\x67\x52\xc9\x80\xc7\x5e\x85\x50\x7e\xc1\x93\x2f\xe3\x41\xeb\xfc\x5b\xed\x63\xbe\x49\xb7\xfc\x83\x4b\x32\x9d\xb1\xf3\x9d\xfd\x23\xe6\x79\x92\x2f\xff\xec\xe5\x25\xc4\x05\x07\xfa\x75\xd0\x30\x67\x5b\x6f\x12\x35\x94\x9b\x67\x89\x3c\xf1\xea\x79\x51\x42\x53\x3d\xd7\x07\xcf\x9b\xd6\x45\x79\x38\x10\x68\x5e\x5c\x87\x52\xaa\xff\x5e\x6f\xe0\x5f\x96\x43\x4c\x1b\xb4\x2f\x79\x17\x5e\x2d\x2e\x56\x63\x11\x59\xa9\x77\xde\x08\xc6\xd3\x42\x0f\xef\x7e\x47\x65\x69\x18\x1b\x36\x20\x6c\x29\x2a\x3b\x6a\x94\x17\x8a\xc0\x35\x56\x89\xae\x45\xab\x1d\x55\xf4\x11\x7d\xa9\x92\x1c\x61\x2b\x84\x66\x37\xb4\x39\xe3\x95\xc4\x35\x87\xb1\x8a\xe9\x07\xcb\xca\xf6\xd4\x67\xc0\x52\x85\x56\x6f\xaa\x5a\x8b\xd1\x4b\x37\x6e\xeb\x65\x5a\x8b\x76\xd6\xd1\x37\xbf\x7c\x3d\xb8\xdf\x13\x57\x25\x0b\x3b\xb0\xec\x06\x0c\xcb\x00\xa4\x67\xda\xb6\x41\xbb\x77\x7e\xe5\x1a\x42\x14\x8c\x54\x0d\x20\x35\xa9\xb3\xce\xf1\x61\x69\xbb\xe0\x3d\x17\xea\xc1\x69\xbf\xf5\x02\xaa\xcf\x9a\xa4\x25\xa6\xaf\x8a\xac\x11\xaf\xd3\xc5\xa4\x1e\x35\x60\x2a\x4e\xb3\x32\x13\x09\xeb\x5c\xb5\x6f\xdb\x08\xce\x47\xb0\x5c\x73\xec\xd5\x64\x4f\x8c\x17\xea\x06\xc9\xc1\x2e\x70\x41\x27\x9e\xf6\x95\xea\xc0\x76\xd0\xbc\x86\x1b\x8c\x29\x3a\x67\x23\x57\xa3\x92\x20\xfc\xd2\xbb\x9f\x14\x51\x48\xe2\xb9\x69\xbc\x56\xb1\x09\xf4\xfd\xbb\x0f\x3f\xea\x8f\x40\x67\x7c\x57\x2f\xa7\x36\x76\x3c\xa0\x56\xd1\x18\x2f\xc5\x91\x1d\xfa\x32\xe7\x2b\x04\x5c\x4d\x65\x0f\xae\xf0\xfa\xfa\xf8\x0c\x6f\xec\x4c\xcd\x8e\xd2\xb9\x2f\xad\x54\x00\x46\xb0\x84\x21\x82\xd7\xb3\x0d\x09\x74\x84\x59\x23\x7d\xce\x82\x9e\x22\x33\x8c\x36\x09\x67\xd9\xda\x92\xad\x1d\x6b\x32\xce\x6c\xa9\x06\x9c\x26\xa1\xb4\x25\x9d\xdf\x26\x2b\x1a\xce\xcb\xd6\xd3\x49\xa1\xd0\xda\x78\x84\x8c\x0e\x6d\xf7\x89\x8a\x35\xd0\x1e\x6d\x8b\x51\x7f\x1e\xc7\xaa\xfe\xa5\x15\xd5\x82\x1b\xfd\x80\x75\xbe\xa9\x3d\x66\xc9\x74\x03\x99\xd3\x99\x78\x75\x45\xf2\x55\xe5\x87\xd0\x79\x26\x0c\xaa\x7e\x82\x3a\xa6\xf5\x30\x1c\xa5\xac\x1e\xa0\x8e\x89\xbd\x50\xe6\xc7\xd1\x2b\x9d\xc8\x13\xde\x6f\x2d\x57\x89\x7a\x01\x84\x2c\xed\xf5\xde\xe8\x4f\xd8\x3b\x7f\xf3\x69\xce\x5b\x4f\xd5\xa3\x1f\xf2\x46\xaa\x66\x19\x19\x20\xb4\x29\x5b\xcf\x5d\xfb\x95\x59\x26\x9b\xc6\x74\x0a\x4b\x77\xa3\x79\x71\xfe\x60\xa9\xa8\x28\x89\xeb\xcb\x27\x67\x3a\x53\x1f\x7c\xd4\x0b\xbe\x84\x40\x56\x8b\xc7\xa5\x32\x7d\x4c\x1e\x57\x40\xc0\x92\xaa\x16\xa5\x38\x3f\x35\x80\x4a\xf3\x34\x7b\xa8\xec\x81\x59\x9e\xcc\xed\xcf\x4e\xa9\xe2\x32\xc2\x5b\x0a\x50\xb5\x5c\x90\xd6\xc7\xde\x80\xf4\xad\xfe\xe0\xe5\x65\x48\x78\x2d\x16\xe3\x14\x74\x4e\x08\xc0\xd5\x34\x71\xc0\xd5\x36\xcf\xce\x67\x1d\xa3\xdb\x24\x43\xf4\xf0\xd8\x39\x5a\x97\x09\xc8\x5e\x57\xae\x4f\x1d\x1b\xbb\x59\x00\xfc\x9a\xa0\x99\x59\x37\xb9\xf5\x69\xe3\x8c\x8b\x63\x10\xc3\x28\x25\x8a\x4c\x4a\x58\x2e\x09\x92\x96\xc8\x4b\x6e\x2f\x21\xf3\x8d\x6b\x40\xf3\x5b\xcb\x81\x27\xc9\x99\x29\x3b\x9d\x1b\xf3\x37\x51\x6b\xdf\x25\x5c\x66\xae\x14\xdf\xe7\xca\x0e\x5d\x61\x2f\x32\x89\x07\x31\x15\xce\x62\xfa\x96\xe4\x9a\xe1\xa1\x1c\xcf\x24\x20\x65\x14\x90\x1a\x83\xa9\x8b\x1a\xef\x22\xbb\x76\xfe\xdf\x5e\x63\x42\xcd\x09\xab\xc1\xb9\x24\x47\x67\xf6\xcb\xdc\x7e\x5e\xd8\x4e\x7b\x7f\xff\x5c\xb3\x21\x41\xe1\xa9\x80\x71\xda\x6f\xba\xb3\xd1\xb8\x28\x18\x67\x30\xaa\xeb\xeb\xcd\xea\x7f\x03\x00\x38\x7d\x7e\xf1\xef\x14\x00\x00")
 
 func complySoc2PoliciesInformationMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -801,12 +822,12 @@ func complySoc2PoliciesInformationMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/information.md", size: 5359, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/information.md", size: 5359, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesLogMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x57\xcd\x6e\x24\xb9\x0d\xbe\xf7\x53\x10\xc8\xc5\x06\xca\xbd\xd9\xbd\x24\x70\x4e\x93\x19\x20\x71\x30\x3b\x68\xc4\x1e\xe4\xcc\x96\x58\x5d\x5c\xab\xa4\x0a\x25\x75\xa7\xe7\x94\xd7\xc8\xeb\xe5\x49\x16\x94\x54\x3f\x3d\xe3\xc5\x60\x4f\x76\x57\x49\xfc\xf9\xf8\xf1\x23\xcb\xe3\x48\x8f\xf0\x31\x9c\xe0\x67\xf4\x78\xa2\x91\x7c\x82\x43\x70\x6c\xae\x3b\x34\x12\xfc\x75\x7c\x84\x8f\x3f\x1f\x76\x11\x13\xc7\x9e\x29\x3e\xee\x00\x5e\x9e\xdf\xeb\x1f\x80\x07\x78\xff\xfe\x4f\xfb\x9f\x76\x23\xfe\x12\xe4\x9f\x74\xe6\xc8\xc1\x97\x23\x0f\x60\x31\xd1\x23\xfc\x23\x7b\xf8\x11\x7e\xfa\xe3\x8f\x7f\x2e\x17\x4c\x18\xd5\xc5\x23\x3c\x79\x4e\x8c\x0e\x6c\x30\x59\x9f\xec\x1e\x1e\x1e\x76\xbb\x3f\xc0\x21\xcb\x14\x22\x01\x7a\x0b\xcf\x26\x4c\xb4\xdb\xe1\x1e\x5e\x06\x8e\xe0\xc2\x09\xc6\x35\x4a\x3d\x21\x74\x66\xba\xc0\x54\x02\x06\x4b\x3d\x7b\x8a\x10\x27\x32\xdc\xb3\x01\xa1\x7f\x67\x96\x72\x3c\x42\x1f\x04\xd8\xf7\x41\x46\x4c\x1c\x3c\xc4\x6b\x4c\x34\x46\x48\x01\x4e\xe4\x49\x30\x51\x07\x31\x05\xa1\x0e\x26\x09\x86\x62\xec\x8a\x13\x3c\x9d\x84\x4e\x98\x08\x70\x9a\x24\x4c\xc2\xe5\xff\x6c\x39\x69\x4c\x11\x14\xa8\x18\x21\x0d\x04\x41\x4e\xe8\xf9\x4b\xf1\xf0\xff\xff\xfe\x2f\x02\xf9\xc4\x42\x40\xfe\xcc\x12\x7c\x09\x9c\x3d\x04\xb1\x24\xea\x79\x92\x70\x66\x4b\xf0\x4a\xd7\x9b\xe0\xd4\xaf\xa5\x44\x46\x8f\x5b\x36\x98\x82\x44\x08\x3d\x4c\x21\xa9\x49\x74\x0a\xe5\x24\x61\xe4\x48\xfb\x15\xa3\x86\x04\x4e\x93\x63\x2a\xb9\xa1\x73\x6f\xa6\x7d\xe1\x34\xb0\x7f\x3b\xe8\x49\x82\xcd\xa6\x1c\xf7\x94\x2e\x41\x5e\xbf\xeb\x82\xc6\xc9\x85\x2b\x51\xec\xc0\x04\x9f\x04\x8d\x46\x5c\x01\x9c\x50\x92\xa7\x1a\xff\xd7\xfe\x20\x0d\x98\x00\xed\xc8\x9e\x63\x22\x81\x20\x0b\x28\x23\xb2\x4f\xe4\xd1\x1b\x82\xf0\xfd\x50\x5b\x66\x1a\xa6\x84\x7c\x1a\x42\x4e\x90\xd6\x88\x3b\x35\x10\xa9\xe0\x79\x66\x9b\xd1\x45\x40\x21\x10\xea\x49\x84\x6c\xc9\x24\x36\x2b\x4b\x44\x52\x90\xdf\x2b\x35\xff\x8a\xe6\xf5\x24\x21\x7b\x5b\xc0\x78\xda\x94\x71\x24\x8c\x59\x94\xb5\x6f\x60\x5d\xe2\x74\x74\x26\xa7\x00\x44\x32\x59\x38\x5d\x21\xd5\x28\x15\xae\x9e\x6d\x2d\x2a\xa7\x6b\x07\x9a\xf4\x49\xca\xbf\x85\x7e\x67\x64\x87\x47\xae\x2f\x15\x84\x16\xe2\x98\x63\x02\x13\x9c\x53\x96\x54\x3a\x5a\x4c\x58\x11\x6d\x18\xc6\xc6\xac\xc8\xa7\x21\x45\x35\x1d\xe6\xeb\x13\x49\x89\x54\xd1\x2d\x7e\x4c\xe2\x33\x27\xa6\xd8\x0a\xbd\x31\xc9\x71\x76\x44\x16\x1a\x6b\xf4\x72\x49\xa8\x9a\xd3\x4e\xd8\xab\x92\x9c\xd8\x9f\xa0\x97\x30\x82\x11\x4e\x6c\xd0\xcd\x95\xe9\x2a\x69\x4c\x01\xa7\x71\x23\x92\x9c\xd9\x50\x5c\x03\xde\x02\x58\x52\x31\xe8\xcb\x31\xd2\xf2\x20\xc4\x84\x92\xd4\xc7\x14\xd8\xa7\xd2\xd6\x23\x25\x61\x13\x8b\x41\xf6\xa6\xa0\x09\xec\xcf\x14\x13\x9f\xaa\xb7\x5b\xf2\x2e\xce\xde\x96\x8a\x6a\x27\x26\xa9\xcc\xaa\xda\x31\x84\x4b\x29\x75\x51\x20\x88\xd9\x0c\x35\xe7\xa2\x5a\x55\x31\x95\x16\xef\x9c\x7b\x83\x94\xbf\xd5\x6e\x10\x07\xed\x1e\x21\x13\xc4\x36\x49\x4b\xc8\xbe\x82\xff\xe0\x1a\x9c\xdf\x60\xc2\xde\xb8\xac\x09\xd4\x4a\x38\x17\x2e\x5f\x9d\x7b\xdc\x15\xc1\xe5\x3d\xbc\x5b\x0a\x3b\xd7\x9c\xec\xdc\x4f\x35\xbc\xfd\x72\xf6\x65\x20\xc8\xb1\xb6\xa1\x52\x32\x5d\xe1\x8e\xf7\xb4\x5f\xda\xc2\x98\x90\x7d\xba\x6f\x24\x5b\xcc\xa9\xad\x46\xa0\xc2\x60\x8d\x4e\x03\xda\xd0\xb5\xdc\xd8\x9e\x83\x0b\x6e\x23\x52\xca\xec\x4b\x1c\xdb\x60\x7a\x76\x74\xc3\x9b\x4e\x43\x0b\x69\xd0\x18\x8f\xbf\x28\xf5\xbf\x67\x38\xf8\x6f\xcd\x26\x1e\xe9\x8d\x8b\xc1\x98\xac\x5a\x70\x0b\x48\x0a\xc1\x7d\xd7\x8b\x16\xf8\xf6\x5a\xc8\xc9\x84\x91\xe0\x8e\xf6\xa7\x7d\xa7\x8c\xd1\x99\xa2\xe1\xf7\xc8\x2e\x0b\xdd\xcf\x82\x38\x9b\xac\x2a\xfb\x3c\x73\x72\xed\x48\x25\xde\x91\x94\x6f\x27\xb2\xb5\xf1\x1b\x03\x3a\x50\xf9\x04\x15\xab\x31\x8f\x6b\xd1\x9f\x36\x8c\xb9\x5b\xeb\x81\x39\x0d\x5a\xd7\x8a\xe5\xad\x58\x29\xa3\x31\x96\xf2\xeb\x5a\x50\x22\x9d\x30\xc6\x4b\x10\x1b\xef\x8b\x06\x08\x61\x22\xdb\x81\x10\xda\x0e\xf2\x64\xeb\xcf\x20\x60\xc9\x51\x22\xbb\x87\x0d\xed\x0c\x4d\xaa\x17\x65\xee\xea\xb4\xd7\x1f\x6d\x9e\xa8\xea\x79\x32\xad\x35\x97\x3b\x9f\x95\x7a\x5f\xc5\x58\xb4\x29\xa7\x21\xc8\x32\x32\xc2\xd2\x56\xfa\xb2\x99\x8c\x2b\xfa\x7f\x13\xf4\x2a\x11\x1d\x8c\xc1\x2a\x94\x2b\x73\x84\xce\xa1\x19\x0e\xbd\xd2\x59\x4b\x22\x45\x1d\xb7\xbc\x45\x5b\xff\x80\xa7\xcb\xd2\x10\x2a\xfe\xd3\x5f\xc0\x0c\xe8\x4b\x57\x96\xe7\x93\xf0\x99\x1d\x9d\x74\xf2\x29\x59\x95\x12\x23\xc7\x58\x25\x4e\xd5\xf3\x88\x91\x66\xae\xde\xbc\xec\x59\xe8\x52\xda\x3f\x3b\x5a\x66\x65\x03\x7c\xcd\xe6\xb9\xe4\xda\xcd\x79\x96\x34\x16\xd9\x2c\xd3\xe3\x94\xa5\xa6\x54\x42\xa3\x9b\x4c\x62\xe8\xd3\x45\xe7\x9c\x0a\x1a\x3a\xd7\xa0\x98\x30\x99\x41\x4f\xd6\x22\xc6\x4d\x5b\xb5\x83\x64\xd7\xbb\xcd\xae\xf6\xd1\x12\x95\xca\x70\x9e\x3a\x88\x43\x4e\x36\x5c\x66\x78\x8b\x3c\x17\x6c\xfd\xb6\x6d\xf7\xb0\xb9\xfb\x6e\x7d\x3e\xaf\x5a\x80\xc7\x20\xa9\x9b\x5b\xa3\x18\xc3\xa3\x57\x76\x3a\x20\x6f\x3b\xa0\xa2\xd5\xe8\xdc\x15\x6c\xd6\xa6\x54\x67\x21\x8b\x21\xa0\xff\x0c\x98\x63\x2d\xaa\xc6\x80\x66\xa8\xd5\x5b\x4e\x38\x1e\x39\xe9\xcb\x34\x08\xc5\x21\x38\x0b\x77\x33\xe1\xdf\x1f\x3e\x77\x30\xd2\x18\xe4\xda\xbd\xc5\xcf\xf5\xe1\x11\xbd\xbd\xb0\x4d\x43\x07\x96\xe3\x2b\xc4\x09\x0d\x6d\x90\x9b\xdd\xc5\xfb\x3a\xa8\x5b\x32\x8a\xc6\x6c\x62\x29\xdc\xec\xfd\xc3\xdf\xdf\x1f\xd4\xc2\x87\x4f\xcf\xc5\xd2\x80\x62\x0b\xe6\x3d\x66\x97\x56\x16\x7c\x28\xdb\x60\xe3\x6d\xcc\x71\x62\xc3\x21\x97\x0e\xf8\x41\x47\x20\xba\xf9\xc1\xac\x4f\x65\x06\xe3\xba\x70\x34\x1d\x9e\x1d\xa3\x87\x27\x9f\x24\x2b\x19\xb7\xd6\x05\x0e\x42\x67\x6d\xc0\xe0\x1b\xf7\xe0\xee\xe9\xc3\xf3\x0f\x4f\x87\xe7\x7b\x25\x69\xe2\x87\x33\x4b\x9e\x57\xa5\x5a\x2a\x7d\x1a\xa7\x6b\x89\x7c\x19\x2a\xb8\x87\xcf\xde\x69\x75\x13\x99\xc1\xeb\x2e\xe0\xae\xc0\xe3\xa4\xeb\x61\xd9\x0c\xea\x4e\x4e\x18\xf9\x58\x74\xde\xb9\xba\x52\x17\x89\x3b\xd2\xba\x7b\x97\xb5\x03\xc1\x90\xee\x96\x6e\xc9\x25\xb4\xfd\x71\x55\xca\xb6\x8c\x5b\xee\x7b\x12\xdd\x03\x66\xa9\xd0\x35\xe2\x48\x60\x82\x08\xb9\xaa\x5b\xe8\xd1\x5d\xbf\xd4\xff\x2c\xe8\xd2\xfa\xaa\x83\x48\xfb\x8b\x47\x76\x28\xc5\x64\x07\x49\xc8\xdb\xd6\xa0\x06\xa3\xc1\xd2\x59\xd4\xf7\x64\x52\x5d\x7a\x96\x40\xb7\x33\xbf\x24\x31\xa0\x2e\x2e\x39\x05\x95\x59\x53\x1d\xf1\x48\xee\x5a\xbe\x67\x58\xdb\x2a\x75\x50\x00\xaf\x52\xe7\xc3\x88\xee\x0a\x09\xeb\xe8\x2f\xcf\x1c\x49\xd5\xb3\xf2\xab\xee\x82\x75\xf3\x41\x9f\xd1\xb5\xef\xa0\x8a\xf8\xc7\x2d\x7e\xf5\xbd\xbb\xb6\x13\x75\xf0\x6b\x5f\x9c\xb2\x43\x81\x23\x46\x2e\x9f\x6b\xdb\xb9\xb5\x01\x33\xf4\x45\xe4\x34\xf5\x9b\x7d\xb8\xae\x6e\xb5\x06\x61\xa2\xf6\x70\x76\x7a\xe3\x2b\x81\x23\x8c\x65\x46\x05\x9f\x06\x77\xad\x4e\x67\x62\x73\x0b\xb8\xd5\x44\xdb\xfa\xf0\xf4\xf4\xbb\x4c\xdd\x7c\x50\xd5\x4d\xba\xbf\x42\xf6\x39\x2a\x34\x47\x1a\xf0\xcc\x41\x2a\x38\xff\x1a\xc8\x43\x8e\x15\x58\x9d\xd0\xb5\x61\x2d\x18\x17\xb2\xdd\x7e\xa6\x75\xb7\x44\x7c\xa5\x29\x69\x10\xdf\x9c\x9b\x67\x88\x22\x92\x23\x75\xab\xe6\xa0\x73\x61\x33\xc3\x72\x62\xd7\x26\x58\x63\x52\x15\xd5\x96\xf2\x57\x85\x2b\xfe\xb4\xc2\xda\x12\x6f\x80\xbf\xa2\xde\x56\x90\x92\xd2\x5a\x39\x7e\x23\xd4\xb8\x5f\x36\xd4\xdf\xf1\x45\x58\x43\x8a\x57\x6f\x06\x09\x9e\xbf\xe8\xde\x44\x2c\x6a\xde\xbc\x46\x38\x96\x86\x76\x65\x73\xd6\x18\x3e\x35\xa1\x7b\xd1\x0d\xeb\x20\x21\x05\x13\x1c\xdc\x7d\x7a\x39\xdc\x17\xa1\x98\x5b\x0b\x0c\x4e\x8d\xc9\xbf\x1d\xd2\x37\xae\x35\xc6\xba\x54\xe2\x48\x3a\x7b\x47\x94\x6b\xdd\xe6\x2a\xea\xfb\xdd\xaf\x01\x00\x00\xff\xff\xb6\x2c\xb2\xa4\xd3\x10\x00\x00")
+var _complySoc2PoliciesLogMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x57\xcd\x6e\x24\xb9\x0d\xbe\xf7\x53\x10\xc8\xc5\x06\xca\xbd\xd9\xbd\x24\x70\x4e\x93\x19\x20\x71\xb0\x3b\x68\xc4\x1e\xe4\xcc\x96\x58\x55\x5c\xab\xa4\x8a\x28\x75\xa7\xe7\x94\xd7\xc8\xeb\xe5\x49\x02\xb2\x54\xfd\x33\xe3\x85\x91\xd3\x8c\xd5\x12\x7f\x3e\x7e\xfc\xc8\x8a\x38\xd1\x23\xfc\x9c\x06\xf8\x05\x23\x0e\x34\x51\x2c\xb0\x4b\x81\xdd\x69\x83\x2e\xa7\x78\x9a\x1e\xe1\xe7\x5f\x76\x1b\xc1\xc2\xd2\x33\xc9\xe3\x06\xe0\xe5\xf9\xa3\xfe\x03\xf0\x00\x1f\x3f\xfe\x61\xfb\xd3\x66\xc2\x5f\x53\xfe\x3b\x1d\x58\x38\x45\xbb\xf2\x00\x1e\x0b\x3d\xc2\xdf\x6a\x84\x1f\xe1\xa7\xdf\xff\xf8\x47\x7b\xe0\xd2\xa4\x2e\x1e\xe1\x29\x72\x61\x0c\xe0\x93\xab\x7a\xb2\x79\x78\x78\xd8\x6c\x7e\x07\xbb\x9a\xe7\x24\x04\x18\x3d\x3c\xbb\x34\xd3\x66\x83\x5b\x78\x19\x59\x20\xa4\x01\xa6\x4b\x94\x7a\x23\xd3\x81\xe9\x08\xb3\x05\x0c\x9e\x7a\x8e\x24\x20\x33\x39\xee\xd9\x41\xa6\x7f\x56\xce\x76\x5d\xa0\x4f\x19\x38\xf6\x29\x4f\x58\x38\x45\x90\x93\x14\x9a\x04\x4a\x82\x81\x22\x65\x2c\xd4\x81\x94\x94\xa9\x83\x39\x27\x47\x22\x9d\x85\x81\xc3\x90\x69\xc0\x42\x80\xf3\x9c\xd3\x9c\xd9\xfe\x5f\x3d\x17\x8d\x49\x40\x81\x12\x81\x32\x12\xa4\x3c\x60\xe4\xaf\xe6\xe1\xbf\xff\xfe\x8f\x00\xc5\xc2\x99\x80\xe2\x81\x73\x8a\x1a\x09\x70\x84\x94\x3d\x65\xf5\x3c\xe7\x74\x60\x4f\xf0\x4a\xa7\x9b\xe0\xd4\xaf\xa7\x42\xae\x00\x47\xcf\x0e\x4b\xca\x02\xa9\x87\x39\x15\x35\x89\x01\x5c\x9a\xe6\x9c\x26\x16\xda\x5e\x30\x6a\x48\xe0\x3c\x07\x26\xcb\x0d\x43\x78\x33\xed\x23\x97\x91\xe3\xdb\x41\xcf\x39\xf9\xea\xec\x7a\xa4\x72\x4c\xf9\xf5\x5d\x17\x34\xcd\x21\x9d\x88\xa4\x03\x97\x62\xc9\xe8\x34\xe2\x05\xc0\x19\x73\x89\xb4\xc4\xff\xad\x3f\x28\x23\x16\x40\x3f\x71\x64\x29\x94\x21\xe5\x33\x28\x13\x72\x2c\x14\x31\x3a\x82\xf4\x7e\xa8\x2d\x33\x0d\x33\xa7\x3a\x8c\xa9\x16\x28\x97\x88\x3b\x35\x20\x64\x78\x1e\xd8\x57\x0c\x02\x98\x09\x32\xf5\x94\x33\x79\x03\x4b\x1a\x3e\xe7\x88\xb2\x21\xbf\x55\x6a\xfe\x19\xdd\xeb\x90\x53\x8d\xde\xc0\x78\xba\x2a\xe3\x44\x28\x35\x2b\x6b\xdf\xc0\xda\x78\x10\xe8\x40\x41\x01\x10\x72\x35\x73\x39\x41\x59\xa2\x54\xb8\x7a\xf6\x4b\x51\xb9\x9c\x3a\xd0\xa4\x07\xbd\xd2\xe8\x77\x40\x0e\xb8\xe7\x60\x27\x8a\x5f\x0b\x71\xaa\x52\xc0\xa5\x10\x94\x25\x0b\x1d\x3d\x16\x5c\x10\x6d\x18\x4a\x63\x96\xf0\x30\x16\x51\xd3\x69\x7d\x3e\x53\xb6\x66\x50\x74\xcd\x8f\x2b\x7c\xe0\xc2\x24\xad\xd0\x57\x26\x59\x56\x47\xe4\xa1\xb1\x46\x1f\x5b\x42\x8b\x39\xed\x84\xad\x2a\xc9\xc0\x71\x80\x3e\xa7\x09\x5c\xe6\xc2\x0e\x43\xbb\xa2\xed\xa4\xbc\x74\x56\xbd\xc6\x0d\xa1\x7c\x60\x47\x72\x09\xf8\x1a\x40\x4b\xc5\x61\xb4\x6b\x04\x28\x80\x20\x05\x73\x51\x1f\x73\xe2\x58\xac\xad\x27\x2a\x99\x9d\x98\x41\x8e\xce\xd0\x04\x8e\x07\x92\xc2\x83\x59\x92\x5b\xf2\x9e\x9d\xbd\x2d\x15\x8b\x1d\x29\x79\x61\xd6\xa2\x1d\x63\x3a\x2a\x45\x16\x05\x02\xa9\x6e\xb4\xee\x37\x6a\x34\xc5\x54\x5a\x7c\x08\xe1\x0d\x52\xfe\x56\xbb\x81\x8c\xda\xa0\x99\x5c\xca\xbe\x49\x5a\x41\x8e\x0b\xf8\x0f\xa1\xc1\xf9\x1d\x26\x1c\x5d\xa8\x5e\x3b\xdc\x2a\x11\x42\x3a\x7e\x73\xef\x71\x63\x82\xcb\x5b\xf8\x70\x2e\xec\x5a\x73\xf2\x6b\x3f\x2d\xe1\x6d\xcf\x77\x5f\x46\x82\x2a\x4b\x1b\x2a\x25\xcb\x09\xee\x78\x4b\xdb\x76\x11\xd0\xb9\x54\x63\xb9\x6f\x24\x3b\x9b\x53\x5b\xb8\xf8\x31\x06\x6b\x74\x1a\xd0\x15\x5d\xed\xc5\xf5\x3d\x38\xa2\x5c\x99\x50\xca\x6c\x2d\x8e\xeb\x60\x7a\x0e\x74\xc3\x9b\x4e\x43\x4b\x65\xd4\x18\xf7\xbf\x2a\xf5\xdf\x33\x9c\xe2\xf7\x66\x0b\x4f\xf4\xc6\xc3\xe4\x5c\x55\x2d\xb8\x05\xa4\xa4\x14\xde\xf5\xa2\x05\xbe\x7d\x96\x6a\x71\x69\x22\xb8\xa3\xed\xb0\xed\x94\x31\x3a\x53\x34\xfc\x1e\x39\xd4\x4c\xf7\xab\x20\xae\x26\xb7\x26\x2c\xcf\x2b\x27\xdb\x71\x53\xd9\x3d\x29\xdf\x06\xf2\x4b\xe3\x37\x06\x74\xa0\xf2\x09\x2a\x9f\x53\x9d\x2e\x45\x7f\xba\x62\xcc\xdd\xa5\x1e\x58\xcb\xa8\x75\x5d\x7a\xf0\x56\xac\x94\xd1\x28\x56\x7e\x5d\x0b\x2c\xd2\x19\x45\x8e\x29\x7b\xb9\x37\x0d\xc8\x84\x85\x7c\x07\x99\xd0\x77\x50\x67\x9d\xf1\xde\x2a\xe2\x29\x50\x21\xbf\x85\x73\x04\x1f\x9c\xa3\xb9\x28\xd5\x74\xee\xea\xb4\xd7\x3f\xda\x3c\x51\xd5\x8b\xe4\x5a\x6b\x9e\xdf\x7c\x51\xea\x7d\x13\xa3\x36\x85\x1e\xa5\xbc\xf6\xcc\x59\xc3\x96\x4e\x6d\x26\xe5\x82\xfe\x5f\x32\x46\x95\x88\x0e\xa6\xe4\xb9\xbf\x66\x4e\xa6\x43\x6a\x86\x53\xaf\x74\xd6\x92\x64\x53\xc7\x6b\xde\xa2\x5f\xfe\x81\x48\xc7\x73\x43\xa8\xf8\xcf\x7f\x02\x37\x62\xb4\xae\xb4\xf3\x39\xf3\x81\x03\x0d\x3a\xf9\x94\xac\x4a\x89\x89\xc5\x16\xa1\x4e\x97\x20\xdc\xa3\xd0\xca\xd5\x9b\x1f\x7b\xce\x74\xb4\xf6\xaf\x81\xce\xb3\xb2\x01\x7e\xc9\xe6\xd9\x72\xed\xd6\x3c\x0d\xee\xb3\x6c\xda\xf4\x18\x6a\x5e\x52\xb2\xd0\xe8\x26\x13\x49\x7d\x39\xea\x9c\x53\x41\xc3\x10\x1a\x14\x33\x16\x37\xea\xcd\xa5\x88\x72\xd5\x56\xed\x22\xf9\xcb\xdb\x66\x57\xfb\xe8\x1c\x95\xca\x70\x9d\x3b\x90\xb1\x16\x9f\x8e\x2b\xbc\x26\xcf\x4a\x6c\x8c\xd7\x6d\xbb\x85\xab\xb7\x1f\x2e\xe7\xeb\xaa\x05\xb8\x4f\xb9\x74\x6b\x6b\x98\x31\xdc\x47\x65\x67\x00\x8a\xbe\x03\x32\xad\xc6\x10\x4e\xe0\xab\x36\x25\x64\x92\x54\xb3\x23\xa0\x7f\x8d\x58\xc5\xcc\x59\x0c\xe8\x46\x4d\x1d\x2f\x37\x02\x4f\x5c\xd4\x66\x19\x33\xc9\x98\x82\x87\xbb\x95\xf0\x1f\x77\x5f\x3a\x98\x68\x4a\xf9\xd4\xbd\xc5\xcf\xcb\xe1\x1e\xa3\x3f\xb2\x2f\x63\x07\x9e\xe5\x15\x64\x46\x47\x57\xc8\xad\xee\xe4\xde\x96\x8d\x35\x19\x45\x63\x35\x71\x2e\xdc\xea\xfd\xd3\x5f\x3f\xee\xd4\xc2\xa7\xcf\xcf\x66\x69\xc4\xec\xad\x5e\x3d\xd6\x50\x2e\x2c\xf8\x64\xdb\x60\xe3\xad\x54\x99\xd9\x71\xaa\xd6\x01\x3f\xe8\x08\xc4\xb0\x1e\xac\xfa\xa4\x82\x0a\x78\x59\x38\x9a\x0e\xaf\x8e\x31\xc2\x53\x2c\xb9\x2a\x19\xaf\xad\x67\xd8\x65\x3a\x68\x03\xa6\xd8\xb8\x07\x77\x4f\x9f\x9e\x7f\x78\xda\x3d\xdf\x2b\x49\x0b\x3f\x1c\x38\xd7\x75\x55\xb2\xa8\xed\x54\xe6\x93\x45\x7e\x1e\x2a\xb8\x85\x2f\x31\x68\x87\x15\x72\x63\xd4\x5d\x20\x9c\x80\xa7\x59\xd7\x43\xfd\x4b\x9f\x72\xec\x09\x85\xf7\xa6\xf3\x21\xa8\xc8\xc9\x22\x71\x7b\xba\xec\xde\x3a\x90\x01\xc1\x91\xee\x96\xeb\x46\x01\x92\xda\xfe\x78\x51\xca\xb6\x8c\x7b\xee\x7b\xca\xba\x07\xac\x52\xa1\x6b\xc4\x9e\xc0\xa5\x9c\x29\xa8\x45\xcd\x05\xc3\xe9\xeb\xf2\x3f\x0f\xba\xb4\xbe\xea\x20\xd2\xfe\xe2\x89\x03\xea\xfe\xa2\x3d\x52\x32\x45\xdf\x1a\xd4\xa1\x38\x34\x71\xa0\xbe\x27\x57\x96\xa5\xe7\x1c\xe8\xf5\xcc\xb7\x24\x46\x3c\xe8\xc7\x42\x49\x2a\xb3\xce\x4c\xe8\xf0\x09\x27\x4d\x14\x38\x0e\x24\xa5\x03\x03\xdc\x7e\xc4\x98\x26\x0c\x27\x28\xb8\x8c\x7e\x3b\x0b\x64\x2b\x4f\x5b\x08\x97\x5d\xd0\xe2\x9c\x30\x56\x0c\xed\x3b\x68\x19\x1f\x3f\x5f\xe3\xb7\xfc\x1e\x4e\xed\x86\xaa\x71\xb4\xbe\x18\x6a\xc0\x0c\x7b\x14\x96\xc7\xab\xce\x7c\xb9\x4c\x23\x05\x33\xf5\x26\x7e\xd2\x7d\xb3\x0f\x5b\x1c\xad\x06\x69\xa6\x76\xb8\x3a\xbd\xf1\x55\x20\x10\x8a\xcd\xa8\x14\xcb\x18\x4e\x8b\xd3\x95\xd8\xdc\x02\x6e\x35\xd1\xb6\xde\x3d\x3d\xfd\x5f\xa6\x6e\x3e\xa8\x6c\xf7\xe3\xfe\x04\x35\x56\x51\x68\xf6\x34\xe2\x81\x53\x5e\xc0\xf9\xc7\x48\x11\xaa\x2c\xc0\x42\xaa\x65\x69\x58\x0f\x2e\xa4\xea\xaf\x3f\xd3\xba\x5b\x22\xbe\xd2\x5c\x34\x9f\xef\xee\xad\x33\x44\x11\xa9\x42\xdd\x45\x73\x30\x84\x75\xd4\xd8\x8f\x85\x43\x9b\x60\x8d\x49\x8b\xa8\xb6\x94\xbf\x29\x9c\xf9\xd3\x0a\xeb\x7c\x78\x03\xfc\x0b\xea\x6d\x05\xb1\x94\x2e\x95\xe3\x37\x42\x95\xed\x79\x43\xbd\x19\xfc\xef\xac\xa8\x06\x81\x9c\xa2\x1b\x73\x8a\xfc\x55\xf7\x26\xe2\xac\xe6\xdd\xab\xc0\xde\x1a\x3a\xd8\x47\xb6\xc6\xf0\xb9\x09\xdd\x8b\x6e\x58\xbb\x9c\x4a\x72\x29\xc0\xdd\xe7\x97\xdd\xbd\x76\x3b\xae\xad\x05\x0e\xe7\xf6\x55\xf3\xdb\x21\x7d\xe7\x5a\xb7\x2c\xf5\x0f\x82\x13\xc1\x9c\x79\xc2\x7c\xb2\x86\x02\x49\x35\x3b\xda\x6e\xfe\x37\x00\xb6\x2c\xb2\xa4\xd3\x10\x00\x00")
 
 func complySoc2PoliciesLogMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -821,12 +842,12 @@ func complySoc2PoliciesLogMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/log.md", size: 4307, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/log.md", size: 4307, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesMediaMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x5a\xcd\x6e\x1c\x47\x92\xbe\xf3\x29\x02\xf0\x85\x32\x9a\xdc\xf5\x1e\x76\x17\xba\x59\xa4\x06\xe8\x81\x69\x09\x24\x01\xc3\xc7\xe8\xac\xa8\xae\x30\xb3\x32\xcb\x99\x59\xdd\x6a\x9f\xfc\x1a\x03\xcc\xbc\x9c\x9f\x64\x10\x91\x59\x7f\xfd\x43\x49\x83\x19\xc0\x3a\x48\x54\x77\x65\xc6\xff\x17\x5f\x44\xd1\x61\x4b\x6f\xe1\x91\x5a\xbf\xc3\x8d\x25\x78\xa0\x8a\x11\xd0\x55\x70\x67\x7d\x5f\xc1\x53\xf2\x01\xb7\x04\x1f\xbd\x65\x73\xb8\x42\x13\xbc\x3b\xb4\x6f\xe1\xe1\xee\xe3\x55\xc4\xc4\xb1\x66\x8a\x6f\xaf\x00\x9e\x9f\xee\xe4\x1f\x80\x1b\xb8\xbb\xfb\xdf\xdb\xff\xbb\x6a\xf1\x17\x1f\x1e\x69\xc7\x91\xbd\xd3\x47\x6e\xa0\xc2\x44\x6f\xe1\xaf\xbd\x83\xef\xe0\x7f\xfe\xfb\xbb\xff\xd7\x03\xc6\xb7\x2d\xb9\xf4\x16\xd6\x8e\x13\xa3\x85\xca\x9b\x5e\x3e\xb9\xba\xb9\xb9\xb9\xba\xfa\x06\x3e\xf6\xa1\xf3\x91\x54\xab\x27\xe3\x3b\xba\xba\xc2\x5b\x78\x6e\x38\x42\x18\x15\x6f\x45\xf1\x15\x18\xd5\x3a\x16\xad\xe5\xc4\xbb\xc0\x6e\x0b\x3f\xfb\x3e\xc0\x87\xbd\x83\x7b\xda\xb1\x21\xb8\x7e\xf7\xf3\x87\xfb\x37\xd0\xa9\x59\x50\x51\xcd\x8e\x22\xa4\x86\xc0\x6f\x7e\x21\x93\x78\x47\x71\x05\x81\x7e\xed\x39\x90\x28\x13\xf5\x32\x6e\x3b\xab\xff\x95\x3b\xd9\xc5\x14\x7a\x93\xc4\x3e\xa8\x7d\x50\xb1\xf2\x45\x85\x09\xc1\xbb\x53\xed\xd8\x15\x05\xc9\xed\x38\x78\xa7\x17\xaf\xf4\x66\xef\xa0\xa3\x10\xbd\x43\x6b\x0f\x37\x7e\xef\xa8\x82\x4a\x75\x55\x3d\xb6\x18\x2a\x4b\x31\x82\xaf\xf3\xf5\xc6\x62\x8c\x5c\xb3\x41\x91\x0f\x96\x76\x64\x6f\x27\xc7\x14\xc3\xb0\xeb\x2c\x8b\x61\x1e\xd0\x5a\x60\x57\xfb\xd0\xe6\x13\x22\x54\x6f\xda\x73\x6a\xd8\x65\xdb\xc3\x16\x1d\xff\xa6\x0f\xfc\xf1\xfb\xdf\xe2\xe2\x40\x24\xd3\x07\x4e\x07\xe8\x82\xdf\x06\x6c\x57\x80\x11\xf6\x64\xad\xfc\x2b\xb7\x5f\x0a\xc6\x21\x26\x6a\xb3\xff\x2e\x99\x08\xc4\xa9\xa1\x00\xf9\x43\x1f\xc0\x78\x97\x82\xb7\x96\x2a\xd8\x1c\x4e\x74\xfb\xac\xa1\x7d\xa4\xa0\xbe\x5a\x18\x50\x14\xb9\x60\x70\xb9\x30\x1d\x3a\x36\xa2\x21\xb0\x33\xb6\xaf\x44\xb9\xb6\xb3\xfe\x40\x94\x6d\x50\xd5\xd0\x24\x1f\xe2\xd2\x05\xee\x00\xf4\x29\x51\x70\x68\xa1\xc3\x90\x54\x9f\x06\x93\x64\x38\x01\xbb\xe4\xf5\x2c\x9a\xa4\x2a\x2c\x1c\x33\xd7\xf3\x75\xdb\xe1\xba\xa1\x40\xec\xb0\x4e\x14\x20\x50\x4d\x21\x50\xa5\x86\x47\xf8\xe3\xf7\xbf\xab\xe9\x7f\xfc\xfe\x8f\x37\x4b\x07\xb5\x7d\x4c\xb0\x21\x68\xb1\x22\x08\x84\x15\xdb\x03\xe0\x0e\xd9\x6a\xc4\xe6\x7e\xbb\x95\xa2\x7b\x87\xe6\x65\x1b\x7c\xef\xaa\x13\x5f\xcf\xab\xa5\x0b\xde\x50\xd5\x07\x2a\x15\x80\x35\xd9\x03\xf4\x51\xca\xe0\x4b\xaa\xf3\x62\x42\x24\x0f\x96\x5b\x4e\x39\x47\xad\x97\xd4\x0f\x40\x9f\x3a\x1f\xfb\x40\xb7\x00\x4f\xbd\x69\x44\x66\xab\x71\x1e\xee\x1c\xcc\x8c\x29\xb0\x49\xf6\xb0\x70\x26\x19\xec\x23\xc9\xe3\xa2\x7a\x24\x17\x59\x0a\x3d\x8b\xc8\x91\x42\x97\x4f\x7b\xf1\xa9\xd7\x24\x69\x6f\xe1\x5d\x39\x49\x68\x9a\x72\x3c\xd2\x28\x33\x1d\x3a\x49\x8d\x20\x31\x96\xd8\x38\x91\x4b\x5d\x43\x2d\x05\xb4\xa2\x76\xe7\x43\x52\x3f\xb0\x03\x87\xa9\x0f\xb4\x02\x4e\xa0\x2e\x8d\x91\xe5\x1b\xf1\xde\x49\xa8\xc5\x09\x82\x7c\xf2\x05\x6e\xd8\x4a\xf5\x25\x0f\x7e\x47\x21\x12\xcd\xea\x44\x9f\x58\xe4\xfa\xc2\x02\xe0\xba\x78\x64\xaa\xe2\x98\xd0\x55\x18\xaa\xac\xb9\xf3\x09\x6a\x6f\xad\xdf\x53\x35\xab\xae\x01\x8a\x45\x4e\xe4\x98\x62\xb6\x3e\x90\xb8\xaf\x20\x5f\x47\x21\x21\x3b\x89\x78\xf2\x9f\x89\xf9\xea\xd5\xa0\xdf\xc2\x7b\x71\x70\xb9\x39\x17\x0b\xbb\xf8\xb5\x30\x2c\xbe\x08\xb4\xe5\x98\x82\x3a\x63\x05\x2d\x3a\xdc\xea\x01\xf9\x99\x5d\x22\x87\xce\x14\x6d\x2a\x8e\x9d\x97\x4c\xf0\x4e\xac\xd3\x18\x4b\x48\x67\x69\x95\x3d\xf2\xd3\x80\x1b\x63\x29\xac\x54\x56\xa2\xd0\xce\xb2\x69\x1e\x06\x2d\x4f\x4d\xe6\xf9\xa7\x9a\x6a\x1c\x47\x0c\xa7\x4a\x6a\xf7\xf1\xfd\xd3\xf3\xe3\xfa\xee\xf9\xfd\xbd\x04\xf6\xee\xc3\x8f\x7f\x59\xdf\xbf\xff\xf1\x79\xfd\xfd\x0f\x92\x36\x68\x8c\x0f\x95\x68\x9d\xc1\x43\x04\xdf\x4b\xde\xde\x2d\x1b\x41\x6e\xd4\x70\xad\x92\x49\x1e\xbf\xc6\x37\x6f\xb4\xa4\x1f\x87\x8f\xa2\x9a\x73\xf9\xb4\x7e\xfd\x7d\x8c\x94\x60\xed\x76\xe4\x92\x0f\xf9\xb3\xa7\x21\x79\xd6\xce\x70\x25\x79\xf1\x48\xb1\xf3\x2e\xd2\xfc\xe4\x7b\x67\xc2\xa1\x5b\xdc\xf7\xcd\xfc\xfb\x6f\x8f\xe8\xc6\xb7\x57\xca\x03\xf8\x16\xbe\x3f\xed\x22\xd9\x76\x75\x6c\x5f\x38\x40\xc9\x8b\xb1\xd3\x4a\x02\xb2\x13\x6d\x92\x3f\xad\xa1\x01\x11\x72\x46\x90\x54\xc5\xa5\x6e\x77\x64\xf2\xc2\x89\x1b\x75\xe2\x2b\x7a\x5a\xb9\x5d\xef\x5e\x9c\x9a\xc9\xbf\xe1\x72\x33\xe7\xca\x44\xf8\xb5\xc7\x90\x28\xd8\x03\x6c\x30\xb2\xe6\x09\x39\xc1\xb7\x92\x22\x9a\x25\x31\xb1\xb5\xf3\xa6\x35\xd4\x7c\xc1\xb1\xa3\xc6\x08\xe5\xcf\x77\xb7\xf0\xec\xe7\x52\x0f\x80\x0e\x38\x51\x9b\xb3\x56\x2a\x2f\x0c\x77\x1c\x1b\xa3\x4a\x9b\x86\xcc\xcb\xe0\x2c\x39\x38\x65\xde\x89\xeb\xd6\xb3\xf4\x1e\xb3\xe4\x41\xcb\x2e\xc0\xf5\xfa\xe9\xe1\xcd\x91\x6a\x0d\xc1\xfa\xe9\x21\x0b\x4a\x81\x30\x69\xff\x3c\xd6\x63\x00\x65\x81\xa7\x0d\x81\xf5\x06\x53\xae\x16\xeb\x63\xca\xf5\x8b\x13\xa6\xf1\x90\x96\x81\x04\x72\x47\xdf\x5b\xbf\xdd\xe6\xd8\x9c\x2b\xa3\xcf\x66\xf5\x22\x11\xcc\x3c\x11\x9e\xbf\xcc\x91\xde\x55\xbd\x49\xda\x61\xb1\xeb\x82\xef\x02\x63\xa2\x39\x14\x15\xa4\xce\x6e\x96\xd0\xbb\x44\x61\x87\x36\x2e\x0e\x94\xfc\x1e\xd0\xa9\x20\x6c\x94\x4e\x88\x02\x27\x84\x52\x15\x2b\xc8\xb1\x48\xfa\xb3\xc5\x0d\x59\xfd\x89\x92\xf9\x72\xcd\x57\xb0\x97\x5e\x06\x9d\x50\x1d\x21\x44\xab\x6c\xcb\x3c\x3f\xd1\x01\x5a\x25\x3c\x49\x9b\xd1\x06\xcd\x4b\xdf\x81\xf1\xdd\x61\xb8\x75\x0e\x7b\x43\xf4\x8a\xa9\x19\xf4\x81\x3e\x49\x5f\x99\x14\x7b\x3c\xe7\xc1\xa9\x27\x4b\x0c\x95\x66\x40\x67\xd1\x14\x45\x1a\xa1\x5f\x10\xa8\xea\x0d\x55\x10\x38\xbe\x88\xfc\x9a\x83\xaa\x55\x5b\xef\xab\x8c\x16\xad\x80\x39\x8c\xb2\xd6\x85\x09\x94\x46\xae\xce\x1f\xbb\xce\x59\x44\x5f\x9d\x8d\xf0\xdb\x45\x6e\xbf\x5b\x2a\x6b\xbd\x79\xa1\x0a\x0c\x6e\xd8\x51\x12\x85\xaa\x80\x7b\x0a\xcb\x82\x90\x41\x4b\xb2\x40\xa8\x43\xc6\x4f\xaa\x66\xc4\x44\x70\x40\xd2\x94\xf2\xf7\xdc\x35\x03\x92\x9d\xcb\xe8\x13\x04\x5e\x64\x70\x75\x0c\x65\x17\xe6\x95\x91\x8b\x0d\x21\xa0\x80\x91\xaa\x15\x94\x1e\x5b\x02\x38\x7c\x5b\x51\x4c\xc1\x1f\xe4\x81\x0d\xd5\x62\x4d\xc6\xaf\x40\x7d\xcc\xa4\x3e\x77\x5a\xf9\xb9\x9e\x14\xf8\xa9\xa1\xcb\x92\x85\x9a\x0c\xa7\x56\x73\xa1\x39\x75\x55\x74\x8e\x8e\x7e\x29\x88\x12\xfd\x08\x9e\x85\xcd\xe9\xe5\x54\x41\x1d\x7c\xbb\x04\xe7\xdc\xd7\xbf\x5d\x8c\xba\xcb\x6e\xb4\x64\xac\x03\x63\xff\xcf\xf4\xa4\xa5\x6a\xf0\x38\xa3\x30\xd0\xe2\x41\x0e\x48\xb0\xdb\xce\x72\x6c\xf2\x70\xd0\xa2\xeb\x33\xc5\xc4\x3e\xf9\x56\xcb\xab\x25\x74\x52\x4f\x9f\x37\xe3\xcf\xd1\xb2\xfe\x64\x6d\x0a\x1e\xe7\xba\xfc\x0b\x7e\xbf\x04\xb0\xe7\x62\xf0\xd5\xfd\xa1\x1c\x43\x69\x71\xdb\xde\x62\x98\x75\x0a\x65\x99\x3a\xb2\x0e\x8f\x19\xef\x6a\xde\xf6\x03\x0d\x46\x63\x28\xc6\x21\x18\x2b\x49\x52\x75\x87\xdc\xdf\x7a\xc7\x79\x7d\x71\xd4\x28\xee\x0b\x38\x9c\x4f\xa1\x29\x55\x3a\xcb\x19\xde\x65\x94\x4c\x60\x09\x63\x02\xef\x08\xbc\x4e\xf6\x5d\x73\x88\xd2\x46\x72\x17\xd0\xb8\xdf\x53\x47\x4e\x81\xb9\x18\xb7\x14\xd1\x05\xbf\xe3\x8a\xc2\x2a\x33\xee\x41\xc2\xbc\x1a\xb2\xef\xcb\xb8\x3e\x18\xab\x03\xcc\x3c\x08\x27\x95\xa7\x28\x2b\x95\x7b\x5e\x60\x9c\x66\xc1\x8a\x5a\xef\xb4\x08\x69\x35\xec\x28\x52\x13\x7c\xbf\x6d\x26\xd6\x81\xc6\x04\xaa\x38\x15\x2f\x4f\x67\xf4\xbf\xc9\xf7\x41\x21\x33\xfb\x41\xb3\x24\x0b\x48\x0d\x71\x80\x1a\x8d\x0c\x76\x5c\xd0\x2e\xa3\xbc\x60\xa8\x4f\xcd\xe8\x35\x99\xd5\x5d\x05\x64\xc9\xa4\xe0\x5d\xfe\x68\x55\xe6\xec\x0d\xc5\x34\xf4\x69\x9a\x65\xe0\xfa\x95\xbc\xfb\x5c\x97\xcb\xc5\x3c\xab\x96\xb1\x07\x8c\xcd\xe9\x4c\xef\x59\x34\x99\xa3\x14\x5a\x36\x91\x0c\xc6\xfa\xd7\xf9\xc4\xca\xa6\x21\x24\x32\x8d\xf3\xd6\x6f\xb3\x07\x74\xdf\x10\xe3\xd8\x14\xb5\x5c\x76\xd3\xa6\x64\xfd\xf4\x30\xeb\xef\xda\x5b\xca\xc8\x8f\xe7\x1d\x21\x43\x2e\x47\x71\x07\xbb\x7e\x68\x32\xe5\xbb\x7f\x6b\x93\xf9\x78\x3c\xf4\xe6\x4d\x64\x9c\xfa\xcd\x87\x59\xa2\xa2\x3d\x6a\xfe\x4a\x28\x56\x90\x02\xba\x58\x56\x3e\x3e\x0c\xee\xa0\x57\xf7\x87\xa2\x9b\xc6\xba\x77\x15\x9d\x6e\x19\x14\x18\xd5\xd4\xd8\x70\x97\x39\xf5\x49\xc7\x0a\x94\xd3\x45\x51\x98\xb7\x8d\xf6\xb5\x01\xd5\x95\x80\xaa\xba\xb4\x23\x29\x65\xad\x8f\xdc\x0a\x84\xb9\x9f\xe0\x78\x56\x6c\x59\xa6\xe2\x56\x2d\x73\xa5\xde\xe3\x4e\xa4\x60\xa3\xa2\xc4\x8e\x82\x40\xe4\x34\xcd\xcb\x7d\x97\xb7\xa6\x13\x1e\x2e\xf9\xd9\xda\xc5\x84\xd6\x4e\x6b\xd0\x05\xce\xd6\xf0\xe0\x37\x6c\x69\xd8\x14\x3f\x4c\xd2\xae\x1f\xee\x1f\xde\x40\xf4\x75\xda\xe3\xd0\xf0\x24\xfc\x54\xd7\x79\x65\x6c\x0f\x45\xb9\xd5\xe8\x1b\xb9\x7f\xcf\x5d\x59\x32\xbd\x12\x81\xe1\x80\x26\xd0\xc5\x0d\xc9\xc2\x90\x07\xd1\x1b\x33\xc9\xe4\xa8\xde\xf8\xc5\x6f\x20\x71\xb2\x79\x57\xf9\x5f\x92\x22\x7a\x55\x14\xb0\x6c\x7c\xe0\xdf\x32\x42\x4b\x51\x5c\xcc\x97\x73\xbb\x28\xd5\x71\x23\x55\x49\x71\xb9\xf7\xd4\xe5\x54\x37\x82\x73\x1c\x37\xcb\x1b\x8c\xc3\x06\x74\x6a\xa3\x39\x5b\xd5\xc8\x9c\x36\x5f\x68\xd8\x42\x42\x17\x7c\xc3\x1b\x4e\xc3\x4d\x5a\xe2\xaf\x2d\xd0\x15\x3c\x85\xa6\xe8\x96\x4a\x34\x2a\x3c\x32\x2f\x8a\x25\x9a\xa8\x31\x1d\x97\x7b\x81\xf2\xc2\x4c\xe4\x4d\x89\x3a\x2b\x62\x9d\x14\xb9\xe6\xb2\xd7\x9b\x90\xf2\xfa\xe3\x7a\xfd\x46\x2d\x2e\x53\xeb\x50\xbb\xb3\x62\x0d\x93\x2b\xca\xe4\x9b\xb8\xa5\xcc\xb0\x2e\x58\xb1\xac\x96\xbc\xab\x53\x88\x34\x86\xba\xbc\x5d\xec\x55\xef\xd9\xae\x6c\xc0\x5c\xbf\x89\x14\x0a\x4a\x8e\xab\xf1\x4b\xde\x5a\x16\x8b\xb0\x47\x7f\x06\x98\x86\xab\x65\xe6\xa3\x0a\xfa\xee\x2c\x27\x39\x0a\xeb\xfd\x43\x89\x44\xd5\x79\x76\xd2\xb4\x7c\x2a\xcb\xbe\xb1\xaa\x86\x8b\x39\x57\xe9\xc9\xbc\x88\x99\x27\x89\xbf\x8e\xae\x7f\x3a\xbb\x82\x5b\x6c\x42\x8f\x67\x96\xaf\xee\x66\xb3\xf5\xc5\xd1\x55\xa5\x71\x8f\x8d\xab\xc3\x18\xf7\x3e\x48\xdc\xd9\xad\xa0\x77\x32\x07\x42\x87\x49\x06\xe6\x15\xd4\xec\xb6\x14\xba\x20\x6e\xf0\x01\x24\x6a\x3b\xb4\x82\x32\x23\xaf\x68\xc9\x34\xe8\x38\xb6\xaf\xcb\x56\x36\x63\xbc\x73\x64\x14\x95\xb3\x22\xc5\xd1\x83\x79\x7b\x0e\xa4\xef\x8f\x1c\xa5\xbd\x0f\x2f\x47\xae\x2b\x8d\x32\x57\xc7\x34\x64\xf5\x29\x72\x45\xe7\x58\xbb\xc2\x41\x17\xa8\xe5\x28\xf5\xc5\x65\xd7\x32\xec\x69\xa8\x4e\xd0\x3b\xb1\xd5\x55\x92\xfa\xba\xb4\xad\xc7\x6d\xf7\x6a\x4e\x6c\x8a\xe3\xbe\x44\x21\x76\xd0\xf5\x1b\xcb\x46\x2a\x16\xe3\x7c\x42\xc8\x8b\x24\x7c\x91\xd1\x00\xa3\xbe\x90\x38\x1a\x4d\xf4\x1e\x7d\x75\x36\xee\x93\x02\xe1\xb2\x18\xa5\x3e\xdc\x0c\x29\x0b\x78\x2e\x75\xfb\x88\xc9\x34\xe5\x65\x50\xdf\x55\x98\x66\x23\xf2\x94\xb5\xa5\x16\xec\x61\x79\xf8\x6e\xda\xf9\x9e\xa3\x57\xa5\x22\x3e\x9b\x90\x78\x39\x21\x17\xac\x45\xb5\x19\x69\x4b\x99\xcb\xe7\x1e\x8d\xf3\xa9\x7c\x05\xd1\xdb\x3c\xe0\x17\x32\x9c\xe9\xbc\x30\xf0\x50\xe9\x4b\xad\x43\x7e\xcf\x43\x61\xc7\x86\xdd\x76\xa9\xc5\x3a\xbf\xd3\x98\xd0\x39\xf9\x19\x9a\xc8\x1f\x54\x44\xf1\xfb\xb1\x9a\xf3\x48\x22\x77\xa2\x3b\xc8\xb8\x40\x9f\x04\xcf\x80\x53\x21\x25\xb7\xe3\xf9\xe3\x8b\xf2\xca\x84\xad\xa5\x2d\x0a\x2b\x48\x14\x58\xe6\xa0\x45\xa9\xdf\x9e\xc8\x2f\xed\x5f\x2a\x92\x0d\x39\xb1\x7c\x00\x9f\xd3\x87\x7f\xf0\x9a\xa4\x37\x8a\x22\x63\x4d\xc7\xd3\x07\x9f\x0b\x31\x3b\x0b\x96\xc9\x17\xf6\x3f\x74\xd8\x7d\xc3\xa6\x81\x06\x77\x54\x4a\x86\xdc\x09\x95\x3d\xb3\xd7\xbd\x38\xd1\x04\x52\x80\x5f\xd2\xb3\x1d\xd3\x7e\x05\x32\x99\xac\x06\x32\x50\x91\xa5\x44\xda\x70\x8e\xf4\x3c\xf7\x7e\xe2\x6c\xe3\x5a\x30\xd0\x33\x9e\xfe\x3a\x1d\xcb\x08\x0a\x75\x6f\x6d\xd6\xae\xbc\x86\x91\x08\xf9\x5a\xd3\x60\x58\x4f\xcd\xd3\xb6\x56\xea\x2d\x44\x71\x1a\xd8\x1c\x89\x96\x18\x0e\x23\x81\x99\xf5\x17\x5f\x83\x8c\xf0\xe8\x0e\x37\x81\x2c\x0e\xdb\xb5\x95\x56\x96\xef\xd3\xb0\xa6\x88\x85\x56\x1e\xef\x9a\x5e\xb1\x6e\xcf\xd6\x6a\x18\x3b\xcc\x91\x9b\xde\x17\x5f\x8f\x00\xa5\x2d\x37\xff\xd2\x81\x78\xbf\xa6\x4c\x6f\x4f\x20\x4e\x3e\x14\x80\x86\x2e\xff\xd6\xc3\xf1\x46\xe1\x44\xb0\x08\x2d\xe5\x03\x8e\xf6\x47\xf4\xd4\x11\x55\x8a\x84\x0b\x6c\xca\xc4\x5a\xbd\x21\x7c\x60\xb9\x0d\x1c\xfb\xcf\x26\x10\x2a\xd0\x0d\x0e\xcb\xf1\x3a\xcf\x19\x67\x8b\x00\x1f\x14\xbf\x5a\xdd\xd1\x24\x21\xc5\x65\x07\x96\x87\xb3\x7f\x06\x00\x00\xff\xff\xae\x5e\x22\x54\x73\x22\x00\x00")
+var _complySoc2PoliciesMediaMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x5a\xdd\x6e\xe3\x46\xb2\xbe\xf7\x53\x14\x90\x1b\x4f\x20\xeb\x9c\x9c\x8b\xb3\x8b\xb9\xcb\xd8\xb3\x80\x16\x51\x66\x60\x1b\x08\x72\x59\x6a\x16\xc5\x8a\x9b\xdd\x4c\x77\x53\x1a\xe5\x2a\xaf\xb1\xc0\xee\xcb\xe5\x49\x16\x55\xdd\xa4\x48\xfd\x78\x26\xc1\x2e\x10\x60\x30\x96\x45\x76\xfd\xd7\x57\x3f\x6d\x87\x2d\xbd\x85\x47\x6a\xfd\x0e\x37\x96\x60\x4d\x15\x23\xa0\xab\xe0\xde\xfa\xbe\x82\xa7\xe4\x03\x6e\x09\x3e\x7a\xcb\xe6\x70\x83\x26\x78\x77\x68\xdf\xc2\xfa\xfe\xe3\x4d\xc4\xc4\xb1\x66\x8a\x6f\x6f\x00\x9e\x9f\xee\xe5\x07\xc0\x1d\xdc\xdf\xff\xff\xf2\x2f\x37\x2d\xfe\xe4\xc3\x23\xed\x38\xb2\x77\xfa\xca\x1d\x54\x98\xe8\x2d\xfc\xbd\x77\xf0\x0d\xfc\xdf\xff\x7e\xf3\x57\x3d\x60\x7c\xdb\x92\x4b\x6f\x61\xe5\x38\x31\x5a\xa8\xbc\xe9\xe5\x9b\x9b\xbb\xbb\xbb\x9b\x9b\xaf\xe0\x63\x1f\x3a\x1f\x49\xa5\x7a\x32\xbe\xa3\x9b\x1b\x5c\xc2\x73\xc3\x11\xc2\x28\x78\x2b\x82\x2f\xc0\xa8\xd4\xb1\x48\x2d\x27\xde\x05\x76\x5b\xf8\xd1\xf7\x01\x3e\xec\x1d\x3c\xd0\x8e\x0d\xc1\xed\xbb\x1f\x3f\x3c\xbc\x81\x4e\xd5\x82\x8a\x6a\x76\x14\x21\x35\x04\x7e\xf3\x13\x99\xc4\x3b\x8a\x0b\x08\xf4\x73\xcf\x81\x44\x98\xa8\xec\xb9\xed\xac\xfe\x2a\x34\xd9\xc5\x14\x7a\x93\x44\x3f\xa8\x7d\x50\xb6\xf2\xa0\xc2\x84\xe0\xdd\xb9\x74\xec\x8a\x80\xe4\x76\x1c\xbc\x13\x4a\x71\xa1\x94\xbd\x83\x8e\x42\xf4\x0e\xad\x3d\xdc\xf9\xbd\xa3\x0a\x2a\x95\x55\xe5\xd8\x62\xa8\x2c\xc5\x08\xbe\x16\x2b\x22\x18\x8b\x31\x72\xcd\x06\x85\x3f\x58\xda\x91\x5d\x1e\x0d\x53\x14\xc3\xae\xb3\x2c\x8a\x79\x40\x6b\x81\x5d\xed\x43\x9b\x4f\x08\x53\xa5\xb4\xe7\xd4\xb0\xcb\xba\x87\x2d\x3a\xfe\x45\x5f\xf8\xed\xd7\x7f\xc4\xd9\x81\x48\xa6\x0f\x9c\x0e\xd0\x05\xbf\x0d\xd8\x2e\x00\x23\xec\xc9\x5a\xf9\x29\xd4\xaf\x39\xe3\x10\x13\xb5\xd9\x7e\xd7\x54\x04\xe2\xd4\x50\x80\xac\xb7\x0f\x60\xbc\x4b\xc1\x5b\x4b\x15\x6c\x0e\x67\xb2\x7d\x56\xd1\x3e\x52\x50\x5b\xcd\x14\x28\x82\x5c\x51\xb8\x10\x4c\x87\x8e\x8d\x48\x08\xec\x8c\xed\x2b\x11\xae\xed\xac\x3f\x10\x65\x1d\x54\x34\x34\xc9\x87\x38\x37\x81\x3b\x00\x7d\x4a\x14\x1c\x5a\xe8\x30\x24\x95\xa7\xc1\x04\xc6\xb7\x04\xec\x92\x57\xb5\xd0\x24\x15\x61\x66\x98\xa9\x9c\xaf\xeb\x0e\xb7\x0d\x05\x62\x87\x75\xa2\x00\x81\x6a\x0a\x81\x2a\xf5\x70\x84\xdf\x7e\xfd\xa7\xaa\xfe\xdb\xaf\xff\x7a\x33\x37\x50\xdb\xc7\x04\x1b\x82\x16\x2b\x82\x40\x58\xb1\x3d\x00\xee\x90\xad\x06\xe8\xd4\x6e\x4b\x49\xba\x77\x68\x5e\xb6\xc1\xf7\xae\x3a\xb3\xf5\x34\x5b\xba\xe0\x0d\x55\x7d\xa0\x92\x01\x58\x93\x3d\x40\x1f\x25\x0d\xbe\x24\x3b\xaf\x06\x44\xf2\x60\xb9\xe5\x94\xa3\xdd\x7a\x09\xfd\x00\xf4\xa9\xf3\xb1\x0f\xb4\x04\x78\xea\x4d\x23\x3c\x5b\xf5\xf3\x40\x73\x50\x33\xa6\xc0\x26\xd9\xc3\xcc\x98\x64\xb0\x8f\x24\xaf\x8b\xe8\x91\x5c\x64\x49\xf4\xcc\x22\x7b\x0a\x5d\x3e\xed\xc5\xa6\x5e\xb3\xa2\x5d\xc2\xbb\x72\x92\xd0\x34\xe5\x78\xa4\x51\x8f\x74\xe8\x24\x34\x82\xf8\x58\x7c\xe3\x84\x2f\x75\x0d\xb5\x14\xd0\x8a\xd8\x9d\x0f\x49\xed\xc0\x0e\x1c\xa6\x3e\xd0\x02\x38\x81\x7a\x27\x46\x96\x27\x62\xbd\x33\x57\x8b\x11\x04\xf9\xe4\x01\x6e\xd8\x4a\xf6\x25\x0f\x7e\x47\x21\x12\x4d\xf2\x44\xe4\x9c\x27\xeb\x4c\x03\xe0\xba\x58\xe4\x98\xc5\x31\xa1\xab\x30\x54\x59\x72\xe7\x13\xd4\xde\x5a\xbf\xa7\x6a\x92\x5d\x03\x14\x0b\x9f\xc8\x31\xa9\xad\x53\x13\x48\xcc\x57\x90\xaf\xa3\x90\x90\x9d\x78\x3c\xf9\xcf\xf8\x7c\xf1\xaa\xd3\x97\xf0\x5e\x0c\x5c\x28\x0b\x4f\x21\x1c\x7f\x2f\x0c\x8b\x2d\x02\x6d\x39\xa6\xa0\x09\xb5\x80\x16\x1d\x6e\xf5\xbc\x7c\x66\x97\xc8\xa1\x33\x45\x9a\x8a\x63\xe7\x25\x12\xbc\x13\xed\xd4\xc7\xe2\xd2\x49\x58\x65\x8b\xfc\x30\xe0\xc6\x98\x0a\x0b\xb1\x2e\x24\x0a\xed\x24\x9a\xa6\x6e\xd0\xf4\x54\x54\x9a\x7e\xab\xa1\xc6\x71\xc4\x70\xaa\x04\x3f\x1e\xdf\x3f\x3d\x3f\xae\xee\x9f\xdf\x3f\x88\x63\xef\x3f\x7c\xff\xb7\xd5\xc3\xfb\xef\x9f\x57\xdf\x7e\x07\xec\x00\x8d\xf1\xa1\x12\xa9\x33\x78\x08\xe3\x07\x89\xdb\xfb\x79\x21\xc8\x85\x1a\x6e\x95\x33\xc9\xeb\xb7\xf8\xe6\x8d\xa6\xf4\xe3\xf0\x55\x54\x75\xae\x9f\xd6\xc7\xdf\xc6\x48\x09\x56\x6e\x47\x2e\xf9\x90\xbf\x7b\x1a\x82\x67\xe5\x0c\x57\xe4\x12\x3c\x52\xec\xbc\x8b\x63\x83\x20\x6f\xbd\x77\x26\x1c\xba\x19\xbd\xaf\xa6\xcf\xbf\x3e\x69\x37\xbe\xbe\xd1\x3e\x80\x97\xf0\xed\x79\x15\xc9\xba\xab\x61\xfb\xd2\x03\x94\xb8\x18\x2b\xad\x04\x20\x3b\x91\x26\xf9\xf3\x1c\x1a\x10\x21\x47\x04\x49\x56\x5c\xab\x76\x27\x2a\xcf\x8c\xb8\x51\x23\xbe\x22\xa7\xe5\x98\x32\xed\xd9\xa9\x09\xff\x3b\x2e\x94\x39\x63\x0b\xc2\xcf\x3d\x86\x44\xc1\x1e\x60\x83\x51\x6a\x8f\x07\x72\x82\x6f\x25\x44\x14\x22\x62\x62\x6b\xa7\x45\x6b\xc8\x79\x5f\x9f\xa9\x51\x44\x94\x7f\xdf\x2c\xe1\xd9\x4f\xb9\x1e\x00\x1d\x70\xa2\x36\x47\xad\x94\xda\x30\xd0\x38\x55\x46\x85\x36\x0d\x99\x97\xc1\x58\x72\xf0\x18\x79\x67\xa6\x5b\x4d\xc2\x7b\x8c\x92\xb5\xa6\x5d\x80\xdb\xd5\xd3\xfa\xcd\x89\x68\x0d\xc1\xea\x69\x9d\xad\x93\x02\x61\x02\x74\x87\x33\x39\x06\x50\x16\x78\xda\x10\x58\x6f\x50\x8c\x8c\x51\x70\x31\xe5\xfc\xc5\x23\xa6\xf1\x10\x96\x81\x04\x72\x47\xdb\x5b\xbf\xdd\x52\x75\x2d\x8d\x3e\x1b\xd5\xb3\x40\x30\xd3\x40\x78\xfe\x32\x43\x7a\x57\xf5\x26\x69\x85\xc5\xae\x0b\xbe\x0b\x8c\x89\xa6\x50\x54\x90\x5a\xfd\x03\x82\x0e\x2e\x51\xd8\xa1\x8d\xb3\x03\x25\xbe\x07\x74\x2a\x08\x1b\xa5\x12\xa2\xc0\x09\xa1\xc0\xf0\x42\x50\xb0\xc5\x94\xf4\xb3\xc5\x0d\x59\xfd\x44\xc9\x7c\xb9\xe4\x0b\xd8\x4b\x2d\x83\x2e\x48\xf6\x19\xb4\x8b\x6c\xcd\x69\x7c\xa2\x03\xb4\xda\xf0\x24\x09\x09\xd8\xa0\x79\xe9\x3b\x30\xbe\x3b\x0c\x54\xa7\xb0\x37\x78\xaf\xa8\x9a\xbb\x5b\xa0\x4f\x52\x57\x8e\x82\x3d\x5e\xb2\xe0\xb1\x26\x0b\x1c\x40\xc4\x9a\xa0\xb3\x68\x8a\x20\x8d\x74\xa0\x10\xa8\xea\x0d\x55\x10\x38\xbe\x08\xff\x9a\x83\x8a\x55\x5b\xef\xab\x8c\x16\xad\x80\x39\x8c\xbc\x56\xa5\x13\x28\x85\x5c\x8d\x3f\x56\x9d\x8b\x88\xbe\xb8\xe8\xe1\xb7\xb3\xd8\x7e\x37\x17\xd6\x7a\xf3\x42\x15\x18\xdc\xb0\xa3\x24\x02\x55\x01\xf7\x14\x96\xb3\x43\x32\x68\x11\x78\x27\xad\x43\xc6\x4f\x2a\xfd\xf9\x50\x2d\x34\xca\xa5\xbb\x22\x67\xb8\x6b\x28\x5c\x8f\xe8\x33\x04\x9e\x45\x70\x75\x0a\x65\x57\xe6\x95\xb1\x17\x1b\x5c\x40\x01\x23\x55\x0b\xf0\x61\xea\xc0\xe1\x69\x45\x31\x05\x7f\x90\x17\x36\x54\x8b\x36\xb9\xc5\x09\xd4\x47\x01\xbc\x50\x2a\xad\x7c\xae\x8f\x02\xfc\xd0\xd0\x75\xce\xd2\x9a\x0c\xa7\x16\x53\xa6\x39\x74\x95\x75\xf6\x8e\x3e\x14\x44\x89\x7e\x04\xcf\xd2\xcd\x29\x71\xaa\xa0\x0e\xbe\x9d\x83\x73\xae\xeb\x5f\xcf\x46\xdd\x79\x35\x9a\x75\x2f\x63\xc7\xfe\xdf\xa9\x49\x73\xd1\xe0\x71\xd2\xc2\x40\x8b\x07\x39\x20\xf0\xd5\x76\x96\x63\x93\x87\x83\x16\x5d\x9f\x5b\x4c\xec\x93\x6f\x15\x1c\x5b\x42\x17\x97\x37\x5f\xa0\xc6\x9f\xa3\x64\xfd\xc9\xca\x14\x3c\x4e\x65\xf9\x03\x76\xbf\x06\xb0\x97\x7c\xf0\xbb\xeb\x43\x39\x86\x52\xe2\xb6\xbd\xc5\x30\xa9\x14\xda\x65\xea\xc8\x3a\x50\x37\xde\xd5\xbc\xed\x87\x36\x18\x8d\x91\x15\x42\x71\xc6\x42\x16\x0e\x6a\x0e\xa1\xdf\x7a\xc7\xe2\xe7\xb3\x42\xf1\x50\xc0\xe1\x72\x08\x1d\x43\xa5\xb3\xd2\x44\x96\x49\x34\x81\x25\x8c\x09\xbc\x23\xf0\x3a\xd9\x77\xcd\x21\xca\x5c\x9d\x6b\xb8\xfa\xfd\x81\x3a\x72\x0a\xcc\xde\x5d\xb0\x50\x17\xfc\x8e\x2b\x0a\x12\x07\xba\xea\xc9\x1c\xa6\xd9\x90\x6d\x5f\xc6\xf5\x41\x59\xaa\x8e\xc2\x3f\x9f\x04\x41\xb6\xb7\xa2\x6c\x1f\xaf\x31\x1c\xa6\x76\x74\x50\x51\xeb\x65\xcb\x83\x89\x16\xc3\x8e\x22\x35\xc1\xf7\xdb\xe6\xd8\x75\xa0\x31\x81\x2a\x4e\xc5\xca\xc7\x33\xfa\x6b\xf2\x7d\x50\xc8\xcc\x76\xd0\xec\xcc\x0c\x52\x43\x1c\xa0\x46\x23\x83\x1d\x17\xb4\x53\xaa\x02\x76\x1b\x9f\x9a\xd1\x6a\x32\xab\xbb\x0a\xc8\x92\x49\xc1\xbb\xfc\xd5\xa2\xcc\xd9\x1b\x8a\x69\xa8\xd3\x14\x97\xa7\xd5\x6d\xae\xe4\x31\x32\x5e\xad\x72\x2a\xe0\xe4\x9b\xd1\xd1\xc7\xe2\x74\xa1\xf6\x4c\x60\xa4\x9a\xd4\x18\x0d\xa1\x91\x80\x16\x91\x0c\xc6\xfa\xdf\x25\xf9\x62\x51\x0d\x21\x91\x69\x9c\xb7\x7e\x9b\x2d\xa0\xfb\x86\x58\x3c\xc4\xa5\x3b\xda\x1d\x37\x25\xab\xa7\xf5\xa4\xbe\x6b\x6d\x29\x23\x3f\x5e\x36\x84\x0c\xb9\x1c\x25\x25\xd8\xf5\x43\x91\x29\xcf\xfe\xa3\x45\xe6\xe3\xe9\xd0\x9b\x37\x91\xf1\x58\x6f\x3e\x4c\x02\x15\xed\x49\xf1\x97\xf6\x40\xa4\x0b\xe8\x62\x59\xf9\xc8\x62\x21\x9b\x83\x5e\xdd\x1f\x0a\x6c\xaa\xaf\x7b\x57\xd1\xf9\x96\x41\xfb\x77\x11\x29\xc4\x86\xbb\xdc\x53\x9f\xbe\x03\x81\x72\xb8\xc8\x83\xc0\xdb\x46\xeb\xda\x80\xea\xda\x80\xaa\xb8\xb4\x23\x49\x65\xcd\x8f\x5c\xfd\xa5\x73\x3f\xc3\xf1\x5c\xc4\x8f\x01\x32\x4c\x03\x9a\xe6\xda\x7a\x8f\x3b\x91\x82\x8d\x8a\x12\x3b\xd9\xa7\xd8\xc9\x34\x2f\xd0\x7a\x4d\xeb\xc5\x04\x0f\xe7\xfd\xd9\xca\xc5\x84\xd6\x1e\xd7\xa0\x33\x9c\xad\x61\xed\x37\x6c\x69\xd8\x14\xaf\x8f\xdc\x6e\xd7\x0f\xeb\x37\x10\x7d\x9d\xf6\x38\x14\x3c\xe9\x31\xa8\xae\x65\x65\xb1\x93\xe6\x2c\x0b\xb7\x18\x2b\x9e\xd0\xdf\x73\x57\x96\x4c\xaf\x78\x60\x38\xa0\x01\x74\x4d\xa9\xe5\x4c\x91\xb5\xc8\x8d\x92\x87\x5a\xca\xc5\x1a\x3f\xf9\x0d\x24\x4e\x56\xd0\xc4\x55\xff\x23\x21\xa2\xa4\xa2\x80\x65\xe3\x03\xff\x92\x11\x5a\x92\xe2\x1a\x93\x8b\xbb\x28\x95\x71\x23\x59\x49\x71\xbe\xf7\x14\x6d\x74\xfd\x9a\x57\xd1\x71\xdc\x2c\x6f\x30\x0e\x1b\xd0\x63\x19\xa5\x38\x66\x7f\x0e\x9b\x2f\x54\x6c\xc6\xa1\x0b\xbe\xe1\x0d\xa7\x81\x92\x68\xf3\xea\x02\x5d\x44\xd2\xc9\x45\xe2\x48\xfd\x96\x1f\xe5\x85\xa7\x6e\xc0\x50\x7d\x3a\x2e\xf7\x82\x74\xb3\x9c\xb7\x4a\x45\x38\x5e\xc2\x24\x89\x75\x52\xe4\x9a\xcb\x5e\xef\x88\x94\xb7\x1f\x57\xab\x37\xda\xb0\x95\xa9\x75\xc8\xdd\x49\xb2\x86\xa3\x29\xca\xe4\x9b\xb8\x55\x1d\xf0\x9a\x16\x47\x21\x24\x5b\xf2\xae\x4e\xb4\x11\x42\x5d\xde\x2e\x8a\x19\x66\xbb\xb2\x01\x73\xfd\x26\x52\x28\x28\x39\xae\xc6\xaf\x59\x6b\x9e\x2c\xd2\x04\xfb\x0b\xc0\x34\x90\x96\x99\x8f\x2a\xe8\xbb\x8b\x3d\xc9\x72\x46\x6b\xfd\xb0\xd6\xe0\x20\x57\x75\x9e\x9d\x14\x2d\x9f\xca\xb2\x6f\xcc\xaa\x81\xb0\xac\xf5\xd0\xda\xb3\x79\x51\xec\x65\xad\xda\xeb\x24\x6a\x9e\x2e\x95\xb2\x61\x14\x9b\x13\xf9\xc3\xd5\xac\xb0\x7a\x3e\x27\x55\x0a\xf7\x58\xb8\x3a\x8c\x71\xef\x43\xb5\x80\x8e\xdd\x02\x7a\x27\x73\x20\x74\x98\x64\x60\x5e\x40\xcd\x6e\x4b\xa1\x0b\x62\x06\x1f\x40\xbc\xb6\x43\x2b\x98\x36\xf6\x15\x2d\x99\x06\x1d\xc7\xf6\x75\xde\xda\xcd\x18\xef\x1c\x19\x45\x65\x3d\x4f\xc5\xd0\x83\x7a\x7b\x0e\xa4\xf7\x47\x8e\xd2\xde\x87\x97\x13\xd3\x95\x42\x29\xf1\x34\xb1\x92\xef\x53\xe4\x6a\x5c\x98\x9f\xc1\x41\x17\xa8\xe5\x28\x50\xcb\x65\xd7\x32\xec\x69\xa8\x4e\xd0\x3b\xd1\xd5\x55\x12\xfa\x22\x0c\xd7\xe3\xb6\x7b\x31\x6d\x6c\x8a\xe1\xbe\x44\x20\x76\xd0\xf5\x1b\xcb\x46\x32\x16\xe3\x74\x42\x50\xf6\x09\x5f\x64\x82\xc4\xa8\x17\x12\x27\xa3\x89\xbc\x2b\xb0\x34\xd9\x27\xc9\x3d\xc8\x2c\x19\x25\x3f\xdc\x04\x29\x0b\x78\xce\x65\xfb\x88\xc9\x34\xe5\x32\xa8\xef\xe4\x4e\x33\x5e\x88\xda\x92\x0b\xf6\x30\x3f\x3c\x2c\x5c\x69\x7e\xe7\x33\x9c\x2f\x19\xf1\xd9\x80\xc4\xeb\x01\x39\xeb\x5a\x54\x9a\xb1\x6d\x29\x73\xf9\xd4\xa2\x71\x3a\x95\x2f\x20\x7a\x9b\x07\xfc\xd2\x0c\x6b\xb1\x90\x1d\x04\x87\x4a\x2f\xb5\x0e\x5a\x1d\x04\x4e\xd8\xb0\xdb\xce\xa5\x58\x25\xe0\x19\x3a\x27\x3f\x41\x13\xf9\x27\x8b\x65\x41\xae\x81\x7f\x19\x49\x84\x26\xba\x83\x8c\x0b\xf4\x49\xf0\x0c\x38\x95\xa6\x64\x39\x9e\x3f\x25\x24\x03\x3b\x01\x5b\x4b\x5b\x94\xae\x20\x51\x60\xd9\x98\xcd\x52\x7d\x79\x73\x7a\xac\x94\x7f\xc9\x48\x36\xe4\x04\x83\x07\xf0\x39\x7f\xf9\x3b\xaf\x41\x7a\xa7\x28\x32\xe6\x74\x3c\x7f\xf1\xb9\x34\x66\x17\xc1\x52\xee\x6c\xb4\xfb\x2f\x10\x0b\xfb\x86\x4d\x03\x0d\xee\xf2\xcd\xcb\x86\xc8\x9d\xb5\xb2\x53\x42\x73\xf0\x9f\x3e\xc9\x91\x17\x48\x3c\x42\xf3\xf6\x6c\xc7\xb4\x5f\x80\x4c\x26\x8b\xa1\x19\xa8\xc8\x52\x12\x6c\x38\x9c\xca\x39\x0d\xc6\xd3\xa6\x73\x56\xb8\x66\x1d\xe8\x05\x4b\xff\x3e\x19\xcb\x08\x0a\x75\x6f\x6d\x96\xae\x5c\xc3\x88\x87\xe4\xfe\x36\xc5\x71\x3d\x75\x64\x25\xf7\x59\x5c\x2e\xa5\x8e\x03\x9b\x23\x89\x25\x0c\x87\xb1\x81\x99\xd4\x17\x5f\xcb\x35\x6c\x87\xee\x70\x17\xc8\xe2\xb0\x5d\x5b\x68\x66\xf9\x3e\x0d\x6b\x8a\x58\xda\xca\x09\xb3\x12\x86\x57\xb5\xdb\xcb\x7e\x5e\xdc\xd8\x61\x1e\x42\x8e\xf7\xc5\xb7\x23\x40\x69\xc9\xcd\x7f\x74\x20\xd6\xaf\x49\x6a\x78\x38\x87\x38\xf9\x52\x00\x1a\xba\xfc\x57\x0f\xf1\x73\x8c\x85\x69\x49\x1f\x70\xb4\x3f\x69\x4f\x1d\x51\xa5\x4b\x9a\x19\x36\xc9\xfb\xc5\x1a\xd2\x01\x1d\x59\x48\xb5\x1f\xeb\xcf\x26\xc8\x5d\x98\xce\x0f\x76\x98\xec\xaf\x35\x0d\x23\x00\xe6\xcd\xbb\xe0\x57\xab\xab\xbc\x24\x4d\x71\xd9\x81\xad\x9e\xd6\x4b\xb8\xf9\xf7\x00\xae\x5e\x22\x54\x73\x22\x00\x00")
 
 func complySoc2PoliciesMediaMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -841,12 +862,12 @@ func complySoc2PoliciesMediaMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/media.md", size: 8819, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/media.md", size: 8819, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesOfficeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x57\xdd\x8e\xdd\xb8\x0d\xbe\xae\x9f\x82\x40\x81\x20\x19\x9c\x39\xbb\x29\x8a\xa2\x9d\xbb\x41\xba\x40\xd3\x1f\x6c\xb0\xb3\xe8\x55\x6f\x68\x89\xb6\x99\x91\x25\x57\x94\xcf\xa9\x7b\xd5\xd7\xe8\xeb\xf5\x49\x0a\x4a\xfe\x3b\x73\xdc\x2e\x36\x37\x49\x66\x24\x8a\xfc\xf8\xf1\xe3\x67\x8f\x3d\x3d\xc1\xf7\x4d\xc3\x86\xe0\x85\xcc\x18\x39\x4d\xf0\x25\x38\x36\x53\x85\x26\x06\x3f\xf5\x4f\xf0\xfd\xcb\x97\x4a\x30\xb1\x34\x4c\xf2\x54\x01\xfc\xf8\xf2\x49\xff\x02\x78\x84\x4f\x9f\x7e\x73\xfe\x75\xd5\xe3\xd7\x10\x7f\xa0\x0b\x0b\x07\x9f\x8f\x3c\x82\xc5\x44\x4f\xf0\xc7\xd1\xc3\x47\xf8\xd5\xb7\x1f\x7f\x9b\x2f\x98\xd0\xf7\xe4\xd3\x13\x7c\xf6\x9c\x18\x1d\xd8\x60\x46\xfd\x49\xf5\xf8\xf8\x58\x55\xbf\x84\x2f\x63\x1c\x82\x10\xa0\xb7\xf0\x62\xc2\x40\x55\x85\x67\xf8\xb1\x63\x81\x21\xa7\x05\x24\x09\x6b\xc7\xd2\x91\x40\xea\x08\xe2\xe8\x48\xa0\x0d\x17\x8a\x9e\x7d\x0b\x26\xf8\x14\x83\x93\x13\xf4\xc1\x73\x0a\x91\x7d\x7b\xca\xf1\x22\xf5\xe1\x82\x0e\x42\x03\x43\x37\x09\x1b\x74\x80\xc6\x90\x08\xa4\xa0\xa9\x0d\xe8\xa7\xff\xfc\xeb\xdf\x02\x0d\x1a\x76\x9c\x98\xe4\x7c\xf7\x3e\x0e\x83\x63\xca\x57\xd0\x39\x90\x84\x4d\x73\x2a\xaf\xa2\x49\x21\xca\x09\x42\x84\xd4\x71\xb4\x30\x60\xd4\x20\x70\xed\x02\x44\xfa\xfb\xc8\x91\x76\x2f\xa2\x9f\xb6\x44\x5c\x30\x98\x38\x78\x08\x57\x4f\xf6\x04\x61\xa0\x88\x29\xff\x2b\x42\x48\x1d\xc5\x2b\x0b\x41\x30\x66\x1c\x98\x2c\xd4\x53\xae\x7e\xce\xfa\x0c\xcf\x20\x34\xa0\x5e\x59\x81\xfa\x07\x4b\x12\x68\x42\xdc\xa1\xb3\xbd\xbe\xbb\xad\xbd\x42\x30\xe4\x13\xc5\x33\xe4\x36\x14\x0a\x68\xed\x0f\x7f\x41\x8f\x2d\x69\x93\x20\x92\x0c\xc1\x0b\xd7\x33\x3a\x0f\x55\xee\x2a\x9f\x61\x77\x48\x3a\xc5\x85\xbc\x8c\x91\x9e\xca\x01\xfd\xf3\xf1\xac\xd0\xc5\x30\x44\xd6\x24\xc9\xa7\x38\xad\xcd\x02\x8c\x04\xec\x61\x70\x68\x28\xa7\x2c\xca\x46\xd2\x9f\xa3\xdc\x04\x91\x85\xa6\x03\x45\x09\xde\x93\x3b\x01\x5b\xf2\x89\x1b\x9e\x21\xac\xd1\xb6\x54\xfa\x40\x8e\x4c\x8a\xc1\xb3\x81\x57\x9a\xc0\x60\xb4\x02\xd2\x85\xd1\x59\xa8\x09\x46\x21\xab\x60\x5c\xd0\xb1\xcd\x69\xf5\x83\x0b\x13\xed\xdb\xb4\x91\xe1\x26\x0f\x13\x7c\xc3\xb1\x07\x25\x7d\x0a\x11\xde\x41\x3b\x92\xa4\xe5\xe2\x10\x83\x21\xab\x25\x74\x28\x50\x13\x79\x68\x82\x73\xe1\x5a\x7a\xd7\x05\x49\x85\x3b\xb0\x06\xdd\x47\xef\x37\x3c\x07\x8a\x1c\xac\x92\xc4\x4d\x10\xe9\xc2\x74\x15\x70\x2c\x49\x89\xcc\xde\xf2\x85\xed\x88\x4e\xe0\xca\xa9\x3b\x62\xf6\x56\xc0\xf1\x5b\x0a\xca\x72\x3c\x92\x09\x0a\x91\xce\xcb\x52\x99\x0b\x6d\x69\xd0\x2b\x0d\x29\x37\x07\xa1\x67\xcf\xfd\xd8\x6b\x0a\xbf\xfb\x16\x2c\x4e\xe5\x8a\x9e\x3a\xc8\x97\x6c\xbe\x36\xfa\x51\xc6\x9c\x5a\xe2\x8b\xb6\xb0\x10\xec\x4f\x34\x2d\xcf\xbf\x2b\xc9\xc8\x24\x89\x7a\x79\xa8\x76\xc9\xb2\x8e\x21\xcd\x20\x2a\x95\x33\xcd\xb5\x28\x7d\xb4\x4c\xa5\x5d\xa6\x72\x2e\x79\x8d\x9b\xfb\xfe\xcd\x2b\x4d\x52\x84\x6b\x5f\xfe\xf3\xdb\x23\x33\x83\x7d\x48\x4a\x11\xe9\x30\x92\x85\x8c\x03\xfa\xf2\x44\x9e\x47\xa9\xee\xa1\xfc\x7f\xb1\x3a\xbc\xd0\xc2\xd4\x49\x0b\x60\xdf\x84\xd8\xcf\x53\xaf\x11\x21\x75\xe8\x01\x21\x52\x1a\xa3\x87\x1e\xd9\x01\x5a\x1b\x49\x7e\xce\x5b\x35\xcd\x01\x4a\xae\x7f\x18\x7b\xf4\xf0\x03\x49\x18\xa3\xc9\x5a\x44\x5e\x87\x7f\xca\xb8\xf9\x00\x2e\xf8\x96\x22\x78\x22\x4b\xf6\xe0\x9d\x3f\x2b\x55\x75\x22\x53\x70\xe4\xef\x11\xdd\x3f\x3b\x84\x98\xc8\x02\xf7\x3d\x59\x1d\x73\x37\xc1\x41\xc4\xcf\x0d\xa0\xdf\x46\xcd\x74\xe8\xdb\x59\x51\x21\x06\x47\x8a\x43\xda\xa5\x36\x4b\xa7\xdc\x91\x5b\x9b\x42\x78\x99\x37\xc1\xac\x66\x27\xfd\x0f\xc7\x83\x44\xaf\x5c\xf2\x94\x51\x06\xf2\x96\xec\x51\x72\x77\x78\xe9\xa5\x48\xed\xe8\x30\xae\x84\xde\x32\xd9\xe4\x28\xf2\x85\x1d\x69\x21\x65\xd9\xe4\x73\x73\x12\x79\x84\xaa\xea\x6f\x03\xb6\x54\x47\xc2\xd7\x99\xfa\x2f\x59\x01\xde\xed\xd6\xc7\x9d\x7e\x3c\xc0\x2a\xb2\xcf\xeb\x48\xdf\xad\x0d\x01\x16\x68\x23\xfa\x54\xba\xbe\x80\x5b\x92\xd9\x6d\x27\xa8\x51\x35\x2f\xf8\x9d\x74\xc0\xd7\x50\x43\x33\x7a\x93\xa9\xa8\x17\x16\xa4\x96\x88\xf5\xf4\x16\x97\xf3\x2e\x2d\x3f\xed\x83\x2d\x77\xf0\x3e\x5b\x19\x70\x85\xb4\x26\x60\x91\x51\x0f\x6e\x07\x54\xa3\x37\x0c\x16\xc5\x3e\x43\x96\x08\x2d\x44\xd5\x41\xaf\xa1\x37\x7a\x1f\xb4\xac\xd7\xc3\x04\x6f\xea\x38\xd4\xa4\x5d\x09\x9f\x7d\x61\x10\xea\x8a\x6d\x20\x51\xec\xd9\x67\x60\x4f\x77\x71\xe7\xed\x51\x16\xdc\x46\x75\x0d\xbb\xee\xf0\x66\x29\xa1\x7a\xcf\x67\xd2\x65\xe1\x74\x11\xcd\xbf\x53\x32\x9e\x6e\xf8\x59\x0c\x8a\xae\xe2\x22\x04\x28\x42\x69\xdd\x4e\x79\x31\xef\x64\xfc\x03\xa4\x2e\x86\xb1\xed\x72\xd2\xa1\x69\xea\x80\xd1\x66\x4d\x5c\x58\x73\x9e\x09\xf6\xd7\x9f\xd8\x4e\x0f\xd5\x2f\x7e\x9e\xba\xbe\x59\xb5\xef\x00\xc7\xd4\x85\xc8\xff\x5c\x2b\x9f\xd7\x46\x69\x40\x7e\x55\x6e\x6d\xc0\xb3\x73\xdb\xa1\x7e\x94\x94\x07\x3c\x67\x97\x07\xc7\x10\x5f\x08\xae\x91\x53\x22\x0f\xea\x37\x12\xbd\x79\xa6\x89\xa1\x07\x9c\x17\x68\x4f\x7d\x5d\x8c\xcb\xee\x91\xa5\xf0\xb9\xe0\x55\xa3\x16\xc2\xe4\x65\x89\x20\xdc\xea\x24\x7c\x13\xc6\xa4\x43\x5a\x50\x70\xa1\x9d\x2f\xe8\xe0\x20\xfb\xa7\x39\xdd\x6c\x0e\xd5\x34\x9f\x20\xef\xfe\x48\x43\x24\xd1\xf6\xd8\x13\x0c\xb3\x75\x5d\x20\x28\x3d\x9d\xd3\x5f\xcd\xca\x5a\xc8\xe6\xc6\x6e\xf2\xbe\x7d\xbf\x68\x39\xb2\x9f\x37\xe8\xd1\xe2\x3d\x2a\x7b\x57\x70\xcb\x17\x55\xed\x62\x8a\x56\x33\xf9\xb6\x8f\x59\x71\x35\xed\xda\x4d\x60\x59\x12\xfb\x76\x2c\x26\x7b\x6d\x55\x06\xfd\xae\x9e\x43\xd4\x8b\x03\xdb\x92\x90\x31\x46\x55\x5c\xdd\xa4\x35\x35\x21\x52\x16\x6e\xc5\x40\x29\xbc\xac\xeb\xc2\xd9\xe7\xd1\x72\xda\xac\xe1\xbb\x9d\x19\xda\x0c\xe7\xef\xe7\x0f\x07\xb2\x1b\x99\x0b\xe5\xe8\xa2\xb5\x99\xdc\x88\x41\x55\x4f\x3f\x6e\x36\xcb\x77\xe3\x32\xd3\x66\xec\x8b\xbc\x0e\xfa\x71\x41\x3b\x5f\xea\x6f\xe2\xb3\x37\x6e\xb4\x6f\x6c\xed\xde\x27\xdd\xfa\xdd\x79\xc2\x4b\xb0\x5d\x9c\x43\x1b\x56\x1c\x3f\x07\x5f\x74\xf0\x71\x6f\xcb\x66\x43\x74\x78\x4f\xcb\x0d\x8a\xf0\x85\xd8\xb9\x2c\x8c\xf3\x71\x78\x9f\x59\x1e\x29\x45\xa6\x4b\x2e\x4c\x9d\xfe\x87\xc3\x30\x8e\x6c\x4b\x51\x80\x9b\x2c\xb0\x59\x03\x76\xb2\x2c\xb3\xa0\x7c\xa7\xa6\xc5\xbc\x69\xc6\x77\xcb\xb6\xf9\xa9\x0f\xa1\x26\x8c\xde\x6a\x0b\x2e\x1c\xdc\xaa\x18\xbb\x2e\xc0\xfb\x6b\x47\x85\xa0\x3e\x29\x43\x33\x1c\x65\x8e\x33\x65\xd1\x7d\x80\x1e\xa7\x42\xaa\xfa\x2b\x99\xa4\xaa\x64\x59\x0c\x0f\x8e\x3d\xc6\x29\xdb\x4b\x15\xee\xd2\x2c\xf6\xed\xd3\xc1\x9a\xd7\xd1\xe5\x1e\xbd\x3d\x06\x23\xa8\xbb\x68\xf6\x3b\x2c\x52\xcf\xf2\x3f\x5a\xb7\xdb\x17\x55\x55\x55\xff\x0d\x00\x00\xff\xff\x6c\x56\xf9\x32\x57\x0f\x00\x00")
+var _complySoc2PoliciesOfficeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x57\xdb\x8e\xdc\xb8\x11\x7d\x8e\xbe\xa2\x80\x00\x86\x3d\xe8\xe9\x5d\x07\x41\x90\xcc\x9b\xe1\x2c\x10\xe7\x82\x35\x76\x8c\x3c\xe5\xa5\x9a\x2c\x49\xe5\xa1\x58\x0a\x8b\xea\x8e\xf2\x94\xdf\xc8\xef\xe5\x4b\x82\xa2\xae\x3d\xdd\xbb\x8b\x85\x1f\x6c\xcf\x90\xc5\xba\x9c\x3a\xe7\x28\x62\x47\x4f\xf0\x7d\x5d\xb3\x23\x78\x26\x37\x24\xce\x23\x7c\x96\xc0\x6e\xac\xd0\x25\x89\x63\xf7\x04\xdf\x3f\x7f\xae\x14\x33\x6b\xcd\xa4\x4f\x15\xc0\x97\xe7\x8f\xf6\x17\xc0\x23\x7c\xfc\xf8\xbb\xe3\x6f\xab\x0e\xbf\x4a\xfa\x81\xce\xac\x2c\xb1\x1c\x79\x04\x8f\x99\x9e\xe0\xcf\x43\x84\xf7\xf0\x9b\x6f\xdf\xff\xbe\x5c\x70\xd2\x75\x14\xf3\x13\x7c\x8a\x9c\x19\x03\x78\x71\x83\xfd\xa4\x7a\x7c\x7c\xac\xaa\x5f\xc3\xe7\x21\xf5\xa2\x04\x18\x3d\x3c\x3b\xe9\xa9\xaa\xf0\x08\x5f\x5a\x56\xe8\x4b\x5a\x40\x9a\xf1\x14\x58\x5b\x52\xc8\x2d\x41\x1a\x02\x29\x34\x72\xa6\x14\x39\x36\xe0\x24\xe6\x24\x41\x0f\xd0\x49\xe4\x2c\x89\x63\x73\x28\xf1\x12\x75\x72\xc6\x00\x52\x43\xdf\x8e\xca\x0e\x03\xa0\x73\xa4\x0a\x59\xc0\x49\xd7\x63\x1c\xff\xf7\x9f\xff\x2a\xd4\xe8\x38\x70\x66\xd2\xe3\xcd\xfb\xd8\xf7\x81\xed\x6d\x01\x0c\x01\x34\x63\x5d\x1f\xa6\x57\xd1\x65\x49\x7a\x00\x49\x90\x5b\x4e\x1e\x7a\x4c\x16\x04\x2e\xad\x40\xa2\x7f\x0e\x9c\x68\xf7\x22\xc6\x71\x4b\x24\x88\xc3\xcc\x12\x41\x2e\x91\xfc\x01\xa4\xa7\x84\xb9\xfc\x2b\x81\xe4\x96\xd2\x85\x95\x40\x9c\x1b\x7a\x26\x0f\xa7\xb1\x54\x3f\x67\x7d\x84\x0f\xa0\xd4\xa3\x5d\x59\x1b\xf5\x2f\xd6\xac\x50\x4b\xda\x75\x67\x7b\x7d\x77\xdb\x66\x85\xe0\x28\x66\x4a\x47\x28\x63\x28\x21\x4a\xed\x0f\x7f\xc3\x88\x0d\xd9\x90\x20\x91\xf6\x12\x95\x4f\x73\x77\x1e\xaa\x32\x55\x3e\xc2\xee\x90\xb6\xd6\x17\x8a\x3a\x24\x7a\x9a\x0e\xd8\x9f\xf7\x47\xc0\xbe\x4f\xd2\x27\xb6\x24\x29\xe6\x34\xae\xc3\x02\x4c\x04\x1c\xa1\x0f\xe8\xa8\xa4\xac\x86\x46\xb2\x9f\xa3\x5e\x05\xd1\x05\xa6\x3d\x25\x95\x18\x29\x1c\x80\x3d\xc5\xcc\x35\xcf\x2d\x3c\xa1\x6f\x68\x9a\x03\x05\x72\x39\x49\x64\x07\x2f\x34\x82\xc3\xe4\x15\xb4\x95\x21\x78\x38\x11\x0c\x4a\xde\x9a\x71\xc6\xc0\x06\x58\xa0\xae\x0f\x32\xd2\x7e\x4c\x1b\x18\xae\xf2\x70\x12\x6b\x4e\x1d\x18\xe8\xb3\x24\x78\x03\xcd\x40\x9a\x97\x8b\x7d\x12\x47\xde\x4a\x68\x51\xe1\x44\x14\xa1\x96\x10\xe4\x32\xcd\xae\x15\xcd\x13\x76\x60\x0d\xba\x8f\xde\x6d\xfd\xec\x29\xb1\x78\x43\x6b\x18\x21\xd1\x99\xe9\xa2\x10\x58\xb3\x01\x99\xa3\xe7\x33\xfb\x01\x83\xc2\x85\x73\x7b\x0f\xd9\x5b\x01\xf7\xdf\xb2\xa6\x2c\xc7\x13\x39\xb1\x16\xd9\xbe\x2c\x95\x05\x69\xd4\x06\x01\x2f\xd4\xe7\x32\x1c\x84\x8e\x23\x77\x43\x67\x29\xfc\xe1\x5b\xf0\x38\x4e\x57\xec\xd4\x9d\x7c\xc9\x97\x6b\x43\x1c\x74\x28\x4b\x97\xf9\x6c\x4c\x33\x01\xec\x2f\x34\x2e\xcf\xbf\x29\x13\x02\x1d\x35\x53\xa7\x0f\x73\x96\x0b\xc8\xbe\xb4\x34\x37\xd1\x16\xbd\xc0\xdc\xb6\xcb\x1e\x9d\xb6\xd2\x2f\x5b\x39\x97\xbc\xc6\xb5\xa8\xfa\xcd\x0b\x8d\x85\x9c\xae\xcb\xff\xf0\xfa\xc8\x8c\xe0\x28\xd9\x20\xa2\x2d\x26\xf2\x86\xa5\x20\x18\x27\xb8\x94\x7d\xd4\xea\xb6\x95\x3f\x15\xab\xc5\x33\x2d\x48\x1d\xad\x00\x8e\xb5\xa4\x6e\xde\x7a\x8b\x08\xb9\xc5\x08\x08\x89\xf2\x90\x22\x74\xc8\x01\xd0\xfb\x44\xfa\x4b\xde\x3a\xd1\x1c\x60\xca\xf5\x4f\x43\x87\x11\x7e\x20\x95\x21\xb9\xc2\x45\x14\x8d\x3a\xc6\x32\xd2\x28\x10\x24\x36\x94\x20\x12\x79\xf2\x77\xde\xf9\xab\x41\xd5\x36\x32\x4b\xa0\x08\xf8\x53\xcf\xf6\x92\x32\x79\xe0\xae\x23\x6f\x6b\x1e\x46\xb8\x13\xf1\x53\x0d\x18\xb7\x55\x73\x2d\xc6\x66\x66\x54\x48\x12\xc8\xfa\x90\x77\xa9\xcd\xd4\xa9\x37\xe0\xb6\xa1\x10\x9e\x49\xf7\x6c\x76\xb0\xff\x70\xba\x93\xe8\x85\xa7\x3c\x75\xd0\x9e\xa2\x27\x7f\x2f\xb9\x9b\x7e\xd9\xa5\x44\xcd\x10\x30\xad\x80\xde\x32\xd9\xe8\x28\xf1\x99\x03\x59\x21\xb6\x09\xf3\xb9\x39\x89\xb2\x42\x55\xf5\x8f\x1e\x1b\x3a\x25\xc2\x97\x19\xfa\xcf\x85\x01\xde\xec\xe4\xe3\x86\x3f\x1e\x60\x25\xd9\x79\xe2\x59\x6e\x65\x43\x81\x15\x9a\x84\xd1\xda\x9f\x65\x6d\xee\x94\xcc\x4e\x9d\xe0\x84\xc6\x79\x12\x77\xd4\x01\x5f\xe5\x04\xf5\x10\x9d\x45\x2a\x17\x96\x4e\x2d\x11\x4f\xe3\xeb\xbe\x1c\x77\x69\xc5\x71\x1f\x6c\xb9\x83\xb7\xd9\x6a\x8f\x6b\x4b\x4f\x04\xac\x3a\x90\x07\xdc\x0e\x18\x47\x6f\x3d\x58\x18\xfb\x08\x85\x22\xa2\x2f\x60\x2f\xd7\x30\x3a\xbb\x0f\xd6\xb5\x97\xbb\x09\x5e\xd5\x71\x97\x93\x76\x25\x7c\x2a\x2b\x01\x0e\x4d\x62\x6b\xc8\x94\x3a\x8e\x65\x33\x0f\x37\x71\x67\xf5\x98\x04\x6e\x83\xba\x85\x5d\x35\xbc\x5e\x4a\xa8\xde\xf2\x91\x8e\xe0\x24\x98\x10\xcd\xbf\x33\x30\x1e\x96\x13\x56\x92\x4e\x06\xc5\xa4\xb8\x50\x0b\xa0\x2a\xe5\x55\x9d\x8a\x30\xef\x68\xfc\x1d\xe4\x36\xc9\xd0\xb4\x25\x69\xa9\xeb\x93\x60\xf2\x46\x29\x2b\x6a\x8e\x33\xc0\xfe\xfe\x33\xea\xf4\x50\xfd\xea\x97\xb1\xeb\x2b\xa9\x7d\x03\x38\xe4\x56\x12\xff\x7b\xad\x7c\x96\x8d\x09\x79\x45\x13\xf5\xda\x06\x7c\x08\x61\xd1\x16\x85\x6e\xd0\x5c\xbc\x51\xd1\xce\xb2\x38\x8e\xf8\x4c\x70\x49\x9c\x33\x45\x30\xbf\x91\xe9\xd5\x33\x75\x92\x0e\x70\x16\xd0\x8e\xba\xd3\x64\x5c\x76\x8f\x2c\x85\xcf\x05\xaf\x1c\xb5\x00\xa6\x88\x25\x82\x72\x63\x9b\xf0\x8d\x0c\x19\x82\x34\x53\x17\x82\x34\x33\xa9\xd9\xe2\x20\xc7\xa7\x39\xdd\x62\x0e\xcd\x34\x1f\xa0\x68\x7f\xa2\x3e\x91\xda\x78\xfc\x01\xfa\xd9\xba\x2e\x2d\x98\x66\x3a\xa7\xbf\x9a\x95\xb5\x90\xcd\x8d\x5d\xe5\x7d\xfd\xfe\xc4\xe5\xc8\x91\xfc\x8f\x0a\xef\xbd\xb2\x77\x05\x37\x7c\x36\xd6\x9e\x4c\xd1\x6a\x26\x5f\xcf\xb1\x30\xae\x55\x79\x0a\x23\x78\xd6\xcc\xb1\x19\x26\x93\xbd\x8e\xaa\x34\xfd\xa6\x9e\x7b\xcf\x4f\x8f\xed\x92\xd0\x21\x25\x63\x5c\x53\xd2\x13\xd5\x92\xa8\x10\xb7\xf5\xc0\x20\xbc\xc8\xf5\x84\xd9\x0f\x83\xe7\xbc\x59\xc3\x37\x3b\x33\xb4\x19\xce\x3f\xce\x1f\x0e\xe4\x37\x30\x4f\x90\xa3\xb3\xd5\xe6\xca\x22\xf7\xc6\x7a\xf6\x71\xb3\x59\xbe\x2b\x97\x99\x37\x63\x3f\x09\x6a\x6f\x1f\x17\xb4\xf3\xa5\xf1\x2a\x3e\x47\x17\x06\xff\xca\xd6\xce\x0d\x32\xfc\x5c\x0f\x63\xc6\xde\x1c\x6c\x17\x67\x3d\xb4\x3f\x3d\x39\x7e\x96\x38\xf1\xe0\xe3\xde\x96\xcd\x86\xe8\xee\x3d\x2b\x57\x40\x87\x74\x26\x0e\xa1\x10\xe3\x7c\x1c\xde\x16\x94\x27\xca\x89\xe9\x5c\x0a\x33\xa7\xff\xee\x6e\x98\x40\xbe\xa1\xa4\xc0\x75\x21\x58\x1b\xcd\x9e\x96\x75\x26\x94\xef\xcc\xb4\xb8\x57\xc3\xf8\x6e\x51\x9b\x9f\xfb\x10\xaa\x65\x88\xde\x8c\xfe\x99\x25\xac\x8c\xb1\x9b\x02\xbc\xbd\xb4\x54\x28\x90\x63\x36\xa6\x29\xed\x98\x24\xc1\xc6\x9a\x31\xbc\x83\x0e\x47\x9b\xa4\x0e\xa7\xaf\xe4\xb2\xb1\x92\x67\x75\xdc\x07\x8e\x98\xc6\x62\x2f\x25\x1e\xe6\x61\x71\x6c\x9e\xaa\xdb\x72\x6d\x75\xb9\xc3\xe8\xef\x37\x43\x54\x77\x2c\x6e\x8a\x9b\xa8\x63\xfd\x91\xd1\xed\xf4\xa2\xaa\xaa\xea\xff\x03\x00\x6c\x56\xf9\x32\x57\x0f\x00\x00")
 
 func complySoc2PoliciesOfficeMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -861,12 +882,12 @@ func complySoc2PoliciesOfficeMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/office.md", size: 3927, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/office.md", size: 3927, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesPasswordMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x54\xb1\x72\xe4\x36\x0c\xed\xf5\x15\x98\x49\x77\xb3\xeb\xe4\x52\xe5\xdc\x65\x9c\x2b\x9c\xca\x63\x7b\x26\x35\x44\x42\x2b\x5c\x48\x42\x06\xa8\xdd\xa8\xbb\x0f\x49\x7e\xee\xbe\x24\x03\x4a\xde\xdd\xb3\x9b\xab\x34\x43\x91\xef\x01\x0f\xef\xa1\x60\xa6\x5b\x78\x40\xb3\x93\x68\x84\x07\x49\x1c\x96\x0e\x83\x4a\x59\xf2\x2d\x3c\xfc\xf5\xd0\x19\x56\xb6\x81\xc9\x6e\x3b\x80\xe7\xa7\x3b\xff\x00\xec\xe1\xee\xee\xd3\xcd\xa7\x2e\xe3\x17\xd1\x47\x3a\xb2\xb1\x94\x76\x65\x0f\x11\x2b\xdd\xc2\x9f\x73\x81\x8f\xf0\xeb\x2f\x1f\x7f\x6b\x0f\x82\xe4\x4c\xa5\xde\xc2\x7d\xe1\xca\x98\x20\x4a\x98\xfd\xa4\xdb\xef\xf7\x5d\xf7\x13\x3c\xcc\x3a\x89\x11\x60\x89\xf0\x14\x64\x22\xe8\x3a\xbc\x81\xe7\x91\xde\xd6\x07\x91\x2c\x28\xf7\x64\x50\x47\x82\x49\x25\x50\x9c\x95\xa0\x0a\x18\x25\x0a\xb5\x81\x18\x85\x59\x29\x2d\x90\xb1\xe0\x81\x60\xda\x50\xec\xe6\x15\x99\x0d\xa6\x15\x11\xa7\x29\xb1\xe3\x09\x60\x4a\x40\x79\x4a\xb2\x10\xd9\x0e\x82\x94\xaa\x18\xaa\xa8\xed\x1a\x2c\x96\x05\xa4\x8e\xa4\x30\x91\x9a\x94\x42\x09\x4e\xa3\xc0\x88\x47\x2f\x1e\x30\x04\x99\x4b\x05\x29\xed\xaa\x2d\x56\x29\x43\x1d\xb1\x82\x92\x71\x24\x03\xac\xed\x57\x90\x3c\xf9\x77\xc0\xc0\x89\xeb\x02\xa2\x30\xa2\x39\x02\x59\xab\xc5\xdb\x7b\xbd\x55\xa8\x9e\x44\xff\xbe\x01\x68\x6a\xad\x93\xf2\x3e\x3e\x3c\x4a\xc5\xca\x52\x40\xe9\x65\x66\x25\x57\xd5\x3e\x40\xd7\x74\xe7\x1b\xf8\x3d\xa5\xad\x8c\x7d\xa2\x23\xa5\x8b\x12\x60\xa3\xcc\x29\x42\x4f\xa0\x0e\x42\xb1\x95\x5d\x21\x11\x5a\x05\x84\x97\x19\xb5\x92\xa6\x05\x7a\x34\xb6\x15\x6b\x36\xd2\x1f\x40\x3a\xc3\xd0\x91\x74\x01\xe3\x7f\x20\x4b\xa9\x63\xd3\x7f\x2b\xed\x7e\x00\x84\xa0\x14\xa9\x34\x53\xb0\x81\xcd\x36\x51\x68\xa5\x0c\xd0\x13\x97\x43\x93\x40\x25\xb3\x51\xdc\xad\x23\x7f\x35\x04\x17\x78\x99\xc9\x5a\xf7\xef\x2b\xe0\x9c\x29\x32\x56\x37\x81\x8f\xce\x9f\x7e\x2e\x07\x2e\x44\xca\xe5\xf0\xf3\x93\x3b\xc4\x85\xaf\x84\xf9\xea\x7d\x91\xca\x03\x53\xdc\x8c\x72\xb6\xdf\xa4\x52\x29\x38\xd9\x77\xda\x5e\x44\x40\x37\xa1\xd2\xda\xbe\xb9\x77\x06\x3e\xb7\x56\x06\xd1\xbc\x0e\xaa\xd9\x73\xa5\x2b\x52\x9d\xd2\x46\x54\x8a\x70\xe2\x3a\xba\x37\xa4\x50\x13\x67\x91\x19\x94\x02\xb1\x7b\xab\xcd\x97\xac\x36\x9f\xfb\x7d\xc0\x33\xf7\x0e\x22\x95\xa5\x75\xf8\x7a\xcb\x49\xdc\xbd\x18\x6a\x3b\xdf\x9c\x28\xa7\x42\x0a\x83\x28\xa0\x19\x5b\xc5\x12\xc8\x75\x9c\x54\xd6\x14\xbb\xe2\x58\x80\x4b\xe4\x23\xc7\x19\xd7\x81\xbf\xfa\xfa\x6a\x78\x7f\x48\xab\xfe\xa4\x5c\x09\xa2\x9c\xca\x45\x89\x1d\x58\x15\x17\x63\xa4\xec\xe0\x94\x91\x93\xed\xa0\xa5\x53\xa5\x70\xf0\xa7\x9e\x2f\x51\xc8\xd2\x73\x22\x88\x74\xe4\xb0\x1d\xad\xed\xb5\xd7\x72\x24\x5d\x87\x3e\x5e\xab\x92\x67\xab\x1b\xc9\x45\xff\x0b\x3c\xa6\xb4\xec\x20\x0a\x98\x6c\xa2\x5e\x4c\xb3\xee\x03\x5d\x33\xe9\x81\xeb\x89\x8a\xaf\x00\x95\x23\x45\xe8\x17\xb8\x7f\x3e\xf3\x54\x9d\x7d\x85\x34\xb6\xf7\x9a\x37\xfc\x3a\xaa\xcc\x07\xa7\x88\x64\x7c\x28\x6d\xfc\xef\xd8\x44\xe1\xa0\x58\xea\x55\xbc\xb1\xac\x8b\x27\xac\xae\xb8\xe0\x18\x97\x43\x22\x70\x30\x0f\x64\x1b\x4d\x24\x7d\xaf\xfd\x6c\x4d\x25\xf8\xf6\xf5\xdf\x47\xca\x94\x7b\xd2\xb3\x5d\xbf\x7d\xfd\x0f\x06\xc2\xea\x9b\x51\x86\x6b\x26\x6b\xde\x38\x51\x0f\xbd\xca\xc9\x48\xdf\x44\xd2\xfb\xde\x62\x78\xad\xdb\x59\xaa\xef\xf2\xb8\x86\xed\x4d\x2c\xdf\x04\xaf\x05\x6a\x01\xba\xca\x9e\x6d\xd9\xdb\x32\xf6\xd9\xd3\x11\xda\xea\xba\x64\xab\x9c\x17\xb1\xab\x77\xd9\xc4\x30\xc8\xec\x71\xde\x96\xee\x91\x25\x35\xcd\xeb\xd5\x46\xcf\xb8\xb4\x5c\xcd\xfd\x17\x6f\xa3\x0a\x44\xb6\xc0\x53\xe2\x82\xba\x00\xb6\x18\x3b\x79\xf7\x7f\x00\x00\x00\xff\xff\x7c\x0d\xe0\x7e\x04\x07\x00\x00")
+var _complySoc2PoliciesPasswordMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x54\x31\x6e\x23\x47\x10\xcc\xf7\x15\x05\x38\x3b\x90\xb4\xcf\x91\x4f\xd9\x41\xbe\x40\x8e\x04\x49\x80\xe3\xe1\x4c\x93\xdb\xe7\xd9\xe9\x55\xf7\x2c\xe9\xcd\xee\x21\xf6\xe7\xee\x25\x46\x0f\x57\x2b\x9e\x94\x38\x5a\x60\x31\x53\x5d\x5d\x53\x55\x25\x0c\x74\x83\xfb\x60\x76\x16\x4d\xb8\x97\xcc\x71\xee\x42\x54\x29\xf3\x70\x83\xfb\x3f\xef\x3b\x0b\x95\xed\xc0\x64\x37\x1d\xf0\xf4\x78\xeb\x1f\x60\x8b\xdb\xdb\x4f\xbb\x4f\xdd\x10\xbe\x8a\x3e\xd0\x89\x8d\xa5\xb4\x23\x5b\xa4\x50\xe9\x06\x7f\x4c\x05\x1f\xf1\xeb\x2f\x1f\x7f\x6b\x17\xa2\x0c\x03\x95\x7a\x83\xbb\xc2\x95\x43\x46\x92\x38\xf9\x9f\x6e\xbb\xdd\x76\xdd\x4f\xb8\x9f\x74\x14\x23\x84\x92\xf0\x18\x65\x24\x74\x5d\xd8\xe1\xa9\xa7\xb7\xfc\x90\xc8\xa2\xf2\x9e\x0c\xb5\x27\x8c\x2a\x91\xd2\xa4\x84\x2a\x30\xca\x14\x6b\x03\x31\x8a\x93\x52\x9e\x31\x84\x12\x8e\x84\x71\x41\xb1\xdd\x0b\x32\x1b\xc6\xb6\x31\xc2\x38\x66\x76\x3c\x41\xc8\x19\x34\x8c\x59\x66\x22\xdb\x20\x4a\xa9\x1a\x62\x15\xb5\x4d\x83\x0d\x65\x86\xd4\x9e\x14\x23\xa9\x49\x29\x94\x71\xee\x05\x7d\x38\x39\x79\x84\x18\x65\x2a\x15\x52\xda\x51\x9b\xad\xd2\x80\xda\x87\x0a\x25\xe3\x44\x86\xe0\x04\x67\x44\x19\x46\xff\x1e\x42\xe4\xcc\x75\x86\x28\xfa\x60\x8e\x40\xd6\xb8\xf8\x7a\x2f\xa7\x0a\xd5\xb3\xe8\x5f\x3b\xa0\xa9\xd5\x78\xb7\x3d\x3e\x3c\x48\x0d\x95\xa5\x40\xe9\x79\x62\x25\x57\xd5\x3e\xa0\x6b\xba\xf3\x0e\x9f\x73\x5e\x68\x6c\x33\x9d\x28\xbf\x2a\x01\xeb\x65\xca\x09\x7b\x82\x3a\x08\xa5\x46\xbb\x22\x53\xb0\x8a\x80\xe7\x29\x68\x25\xcd\x33\xf6\xc1\xd8\x2e\x58\x93\x91\xfe\x0f\xa4\x15\x86\x4e\xa4\x33\x8c\xff\xc6\x20\xa5\xf6\xb6\x7b\xa5\x76\x77\x40\x40\x54\x4a\x54\x9a\x29\xd8\x60\x93\x8d\x14\x1b\x95\x03\xf6\xc4\xe5\xd8\x84\x52\x19\xd8\x28\x6d\x2e\x4f\xbe\x8c\x05\x17\x3c\x4f\x64\x6d\xfb\xf7\x0c\x78\x18\x28\x71\xa8\x6e\x02\x7f\x3a\xbf\xfa\xa5\x1c\xb9\x10\x29\x97\xe3\xcf\x8f\xee\x10\x17\xbe\x52\x18\xae\x36\x28\x52\xf9\xc0\x94\x16\xa3\xac\xf6\x1b\x55\x2a\x45\x1f\xf6\x83\xb6\xaf\x22\x04\x37\xa1\xd2\x65\x7d\x73\xef\x1c\x78\x5d\xad\x1c\x44\x87\xcb\x43\x39\x99\x65\x5c\x91\xea\x94\xad\x0f\x4a\x09\x67\xae\xbd\x7b\x43\x0a\x35\x71\x66\x99\xa0\x14\x89\xdd\x5b\xed\x7d\xc9\xaa\x3b\xa3\x9d\x47\x58\x95\xd8\x20\x51\x99\x9b\x38\x2f\xa7\x7c\x88\xbb\x37\xc4\xda\xfe\x2f\x4e\x94\x73\x21\xc5\x41\x14\xc1\x8c\xad\x86\x12\xc9\x75\x1c\x55\x2e\x29\x76\xc5\x43\x01\x97\xc4\x27\x4e\x53\xb8\x3c\xf8\x8b\xaf\xaf\x1e\xef\x77\x69\xec\xcf\xca\x95\x90\xe4\x5c\x56\x36\xb6\x81\x55\x71\x31\x7a\x1a\x1c\x9c\x86\xc0\xd9\x36\x68\xe9\x54\x29\x1c\xfd\xaa\xe7\x4b\x14\x83\xec\x39\x13\x12\x9d\x38\x2e\xbf\x2e\xeb\xb5\xdb\x72\x22\x75\x1c\x8c\xfd\xb5\x2a\xc3\x64\x75\x19\xb2\x4e\xbd\x82\x0f\x39\xcf\x1b\x24\x81\xc9\x22\xea\x4a\x6e\xe9\x03\x07\x0d\xb5\x05\x6e\x4f\x54\xbc\x02\x54\x4e\x94\xb0\x9f\x71\xf7\xb4\xce\xa9\x3a\x79\x85\xb4\x69\xef\x35\x6f\xf8\xb5\x57\x99\x8e\x3e\x22\x91\xf1\xb1\xb4\xe7\x7f\x37\x4d\x14\x47\x0d\xa5\x5e\xc5\xdb\xdb\xc2\x8b\x27\x5e\x5c\xf1\x8a\x63\x5c\x8e\x99\xe0\x60\x1e\xc8\xf6\x34\x89\xf4\xbd\xf6\x93\x35\x95\xf0\xfd\xdb\x3f\x0f\x34\xd0\xb0\x27\x5d\xdb\xf2\xfb\xb7\x7f\x71\xa0\x50\xbd\x19\xe5\x70\x3d\xc9\x5a\x91\x9d\x69\x8f\xbd\xca\xd9\x48\xdf\x44\xd2\xf5\x5d\x62\x78\xad\xdb\x2a\xd5\x0f\x79\xbc\x84\xed\x4d\x2c\xdf\x04\xaf\x05\x6a\x06\x5d\x65\xcf\x96\xec\x2d\x19\xfb\xe2\xe9\x88\xad\xba\x56\x26\x9f\xcb\x5a\xc4\x5e\x8d\xaf\x4d\x8c\x83\x4c\x1e\xe7\xa5\x74\x4f\x2c\xb9\x69\x5e\xaf\x1a\x7d\x08\x73\xcb\xd5\xb4\xff\xea\x6b\x54\x41\x62\x8b\x3c\x66\x2e\x41\x67\x84\x58\x59\xca\x0e\x5d\xd7\xfd\x37\x00\x7c\x0d\xe0\x7e\x04\x07\x00\x00")
 
 func complySoc2PoliciesPasswordMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -881,12 +902,12 @@ func complySoc2PoliciesPasswordMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/password.md", size: 1796, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/password.md", size: 1796, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesPatchMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x58\x4d\x6f\x24\xb9\xcd\xbe\xf7\xaf\x20\xb0\x87\x17\x58\xd8\xc6\xee\xbc\x09\x76\xe1\xdb\xac\x3d\x01\x0c\xc4\x81\x61\x4f\x72\x67\x4b\xac\x2a\xc6\x2a\x49\x91\x54\xe5\xe9\xf9\xf5\x01\xf5\x51\xa5\xb6\x7b\x33\x27\x37\xdc\x12\x3f\x1e\x3e\x7c\x48\xb5\xc5\x99\x6e\xe1\x09\x93\x9a\xe0\x11\x2d\x8e\x34\x93\x4d\xf0\xe4\x0c\xab\xd3\x01\x55\x70\xf6\x34\xdf\xc2\xd3\xe3\xd3\x21\x62\xe2\x38\x30\xc5\xdb\x03\xc0\xd7\x97\x3b\xf9\x03\x70\x0d\x77\x77\xbf\xdf\xfc\xba\x7d\xfe\xed\xe6\xd7\xc3\x8c\xff\x76\xe1\x99\x56\x8e\xec\x6c\x3e\x7e\x0d\x1a\x13\xdd\xc2\x0b\x79\xf8\x1d\x3e\xfd\xf2\xe9\xaf\xf9\x82\x72\xb3\xb8\xbb\x85\x07\xcb\x89\xd1\x80\x76\x6a\xc9\x01\x0c\x2e\xc8\xb1\x4f\xe2\x48\x8e\x79\xc3\x68\x15\x1d\xae\xaf\xaf\x0f\x87\x9f\xe0\x69\x09\xde\x45\x02\xb4\x1a\x5e\x94\xf3\x74\x38\xe0\x0d\x7c\x9d\x08\x7c\xfd\xc6\x0d\x90\x26\x8e\xe0\x73\x26\xc0\x11\x92\x03\x8a\x09\x8f\x86\xe3\x04\x81\xfe\xb3\x70\xc8\xc9\xc6\xec\x8c\x35\xd9\xc4\xc3\x89\xed\x78\x05\xb4\xa2\x59\x30\xe5\xcf\x89\x62\xf9\x20\xbe\x34\x79\xe3\xe4\x0c\x44\x37\xa4\x37\x0c\x04\x5e\xb0\xa3\x98\xbf\x8e\xa4\x96\xc0\xe9\x04\x8b\x97\x7c\xb3\xcf\x19\xd9\x26\x64\x0b\xf1\x14\x13\xcd\xfb\x19\xb9\x10\x28\xb2\x61\xb2\x8a\x6e\x6a\x06\x7b\xc8\xe8\xbd\xe1\x62\x03\x8d\x01\xb6\x83\x0b\x33\x26\x76\x16\x12\xa9\xc9\x3a\xe3\xc6\x53\xb5\x1a\xaf\x80\xad\x32\x8b\x96\xd0\x9c\xa7\x90\x83\xdf\xbf\xcc\xb6\x54\xbe\x1c\xaf\x60\xe0\x30\x4b\xec\x57\xe7\x41\x27\xe7\x4c\x84\x37\x4e\x13\x5b\x48\x13\x81\x0b\x23\x5a\xfe\x9e\xaf\xfd\x5f\x94\x08\x02\xc6\x14\x16\x95\x96\xf0\xe3\x80\x69\x16\xac\xa8\x42\xa3\x9c\x4d\x01\x55\x72\x21\x4a\xd6\xde\xd9\xc8\x47\x43\x19\xfb\x8a\x0c\xea\x99\x2d\xc7\x14\xb2\xc3\xab\x2d\xb0\x12\xa6\x9a\xd0\x8e\x04\xf3\x46\xd2\x1b\x21\xc2\x1f\xa8\x5e\xc7\xe0\x16\xab\x73\x38\x5f\x86\x81\x54\xe2\xb5\x96\xa5\x3b\x2d\x0c\x50\x81\x13\x2b\x34\x7d\x59\x76\x98\xce\x2b\xe3\x03\xad\xc2\x08\x3b\x02\x7d\xf3\xc6\x71\x2a\xd0\xbb\x01\x5e\xad\x7b\xb3\xb0\x2e\xc6\x52\xc0\x23\x1b\x4e\x4c\xb1\xb0\x6f\xa3\x6c\x10\xf6\x93\x06\xef\x58\x18\xe6\x06\x18\x9c\x5a\xa2\x80\x32\x61\xe4\xef\x94\x01\xe6\xd9\xbb\x90\x84\xd8\x72\xe2\x9c\x52\x8d\x91\xb5\x6c\xa5\x54\x25\xd0\x8d\x35\x27\xc0\x08\x1e\x43\x92\xeb\x15\xa0\x0c\xb4\x33\xe0\x83\x53\x14\x23\xc5\x52\xa8\x7f\xda\x42\xd4\x66\x44\xaa\xe0\x03\x45\x81\x26\xf2\x68\xb3\x23\xf9\xdc\x40\x08\x1c\x5f\x6b\xe9\xd0\x82\x21\xd4\x02\x9b\xc6\x84\x70\x0c\x84\xc2\x79\x29\x51\x58\x59\x11\x68\x8e\x61\xf1\x95\x5e\xa5\xdc\xad\x65\x61\x65\x67\x0a\xf3\x72\xc5\x9e\x69\xa0\x20\x8c\x8f\x39\xae\xbb\x12\xf5\x5d\x8d\xba\x0a\x8f\x7c\xf3\xcc\xf1\xf5\x82\x26\xc9\x57\x2f\x2d\xc8\x07\xab\x32\x4c\xf0\x5c\x28\x45\xfd\xa9\xcf\x31\x52\x82\x07\x2b\x75\x74\xa1\x94\xf5\x82\xbd\x9f\xfa\x3b\x3f\x17\x29\x7c\xf8\x88\xfd\xa3\xb3\x9c\x5c\x60\x3b\xde\xfe\x7c\xc8\xea\xc5\xa5\xe6\x7d\x97\x40\x9c\x72\xaf\xce\xde\x14\x27\xb8\x24\x37\x63\x22\x5d\xbb\x2b\xb9\x4d\x69\x00\x57\x64\x83\xd2\x04\x4d\x43\xa4\x19\xe4\x7e\x2b\x51\xed\x43\xcc\x89\x70\x4b\xe4\x66\x73\xbf\xe1\xd0\x8b\xd0\xc6\xf2\x26\x42\xf3\x12\x13\x1c\x69\x23\x14\xe9\x7c\xae\x8a\x1c\xe9\xd6\xf2\x9f\xfe\x02\x93\x5b\x42\x66\x6b\x20\x43\x18\x69\x77\xf5\xd9\x98\xcd\x4b\x33\xa8\x30\xa1\x71\x63\xb5\x70\xa6\x50\x9b\x1a\x95\x29\x51\xa6\xc3\xe7\xdc\x9d\x1d\x05\x33\xa7\x1b\xeb\x57\x0a\x79\x5e\x74\x17\x5e\x68\xa5\x9c\x9f\xa1\x95\xcc\xb9\x5a\xf1\xec\x51\xa5\xee\xf0\xbf\xc8\x6a\x17\x20\x50\x99\x2a\xba\x70\x2e\x5f\x62\x1b\x13\x1a\xd3\x42\x2b\x1a\xf6\xce\xd7\x3d\x79\xb2\x9a\xac\xe2\x8a\xa3\x0f\x94\xa7\x44\xe4\x54\xb9\x5a\xc9\xf1\xa5\x4e\x87\x4a\x8c\xcc\x53\xe1\x5a\x8c\x79\x98\xed\xec\xf8\x82\x6a\x6a\x42\x54\x21\xdb\x41\xcf\xb5\x2e\xb2\x9c\x35\xe4\x74\xf5\x3e\xb7\xd2\x49\xde\x25\x29\x1b\x1a\x38\x2e\x91\x2d\xc5\xd8\x75\xdb\x5e\x9f\xbb\x56\xf5\xf8\x81\x13\x5a\x07\x8a\x51\x94\x0c\x8b\x36\xa6\x29\x10\x8a\x2a\x05\x98\x78\x9c\xae\xa5\xd5\xdf\xeb\xd9\x16\xb1\x0f\xec\xc4\xf6\xf7\x1a\x33\xcf\x33\x69\xc6\x44\x75\x18\x56\x29\xee\x1a\x22\x9b\x73\x03\x58\x97\x72\x86\x79\x5e\xe2\x3b\x20\xde\x88\x47\x51\x23\x1c\x51\x0a\x92\x79\xde\x2e\x6e\x97\x38\x5d\x89\x9e\x45\xd6\x14\x7a\xa1\x4e\x15\xb2\x0c\xd0\x06\x4b\x9d\x7b\x45\x68\xf6\x72\x7d\x2d\x03\x1c\x9e\xbb\x91\xdf\x15\xe9\x12\xb1\x65\xe6\x93\xd0\x06\x10\xac\xb3\xd7\x3e\x38\x5d\x18\x03\x64\x57\x0e\xce\xe6\xe6\x3e\xd2\xe0\x42\x8f\x83\xb4\x77\x77\xb6\xd2\xbc\x43\xa7\x86\x92\xfd\x08\xb5\x87\x13\xa4\x09\xd3\x16\x80\x76\x19\x35\x4b\x23\x4a\xa5\x4c\xa3\x42\xcb\x7c\x58\x6c\x36\x8d\x85\x30\x9e\x42\x6e\x39\xab\xe8\x4a\xaa\xb9\xd5\xbe\x0e\x81\xce\xf5\x97\x99\xc2\x98\x47\xc6\x05\x5e\xa8\x0f\xe4\xf9\xc0\x06\x3c\xc1\xf1\xe4\x31\x46\xa0\x6f\x89\x6c\xcc\x3c\xaa\xe9\xe4\xee\x47\xef\x83\xf3\x21\x33\xa3\x1b\xbb\xf9\xdf\x2b\x96\xe6\x25\x3b\x49\xac\x1a\xe6\x4d\x4c\xcf\xc1\x91\xd1\xb6\x98\xb4\x57\xa2\x2d\x84\x55\xb3\x8a\x35\xd2\x0d\xfa\x0e\xec\x33\x36\xee\xd5\xbf\xdf\x8b\x53\x96\xc5\x89\xf4\x62\xce\x55\xfc\xcf\x7b\x67\x0b\x23\x5b\xd9\xa5\xf2\xb7\x4f\xbb\x54\xc6\x45\xc9\xa0\x1d\x16\xd3\x00\xd9\x73\xfa\x87\xb3\xd7\x1b\xb8\x3f\x32\xfa\xff\xbf\x80\xc6\x53\x2f\xbf\x52\x53\xbd\x64\xea\x4b\x7f\x58\xfa\x96\x20\x96\x0c\x04\x44\x59\x61\xc8\xe6\x21\xfb\xc6\x56\xbb\xb7\xdd\x71\x49\xbe\x63\x66\x76\x3a\x38\x63\xdc\xdb\xbe\x06\x93\xbe\xb8\x35\xe8\x25\x50\xbc\x2c\xe1\x75\x5c\x8b\x30\x4a\xb9\x5a\x75\xbb\x13\x0f\x6d\xf6\x15\x85\xf4\x06\xad\x2c\x59\xdd\x89\x67\x67\xcc\x11\xd5\x6b\xe7\xac\xf7\xe0\xe6\x79\xb1\x6d\xf2\x26\x27\xdd\xfe\x4a\x93\x33\x9a\x42\xfc\x1f\x74\xce\x0c\xed\x40\x75\x4b\x12\xdd\x28\x42\x14\x66\x34\x2d\xd5\x02\x55\xfc\xc8\xda\x96\x4c\x91\x7f\xeb\xf6\x05\xa0\x09\xca\x4b\xe9\xc2\xe7\x6e\xff\x92\x01\x40\xca\xad\x14\x4e\x1d\xa3\xfe\x28\xf4\xdc\x84\xac\x06\x79\xd5\xfa\x58\xd2\x5f\xfc\x4e\x85\xac\x05\x6d\x30\x87\x6a\xaf\x2f\x46\x51\xa4\xbd\xbe\x17\x30\xec\x14\x9b\x3c\x86\x6a\xac\x4a\x59\xdb\x2c\xfc\x3b\x5e\x74\x0a\xf1\x52\x87\x72\x33\x53\x7b\x94\x74\xa3\x60\x6e\xc0\x21\x51\xf8\x60\x25\xbf\xad\x6c\x5c\x4a\x47\x7a\x0a\xbb\x16\xef\xf6\xff\x86\x2c\xac\x7d\xdf\x05\xc1\x19\xf9\x77\x4e\x66\x9b\x2d\xa6\x2e\xfd\x1c\xe3\xd2\x6f\x30\x76\x95\x0e\x1b\x31\x6d\x50\x45\x67\xd6\x0c\xcc\xde\xf2\xdd\x8e\x27\x69\xdf\xf3\x90\x37\xce\x54\x33\x84\xaf\x27\x4f\xf1\x52\xfb\x9f\x3f\x70\xea\x12\xf0\x5e\xcd\xdb\xd3\x51\xf4\x93\x8b\x16\x9f\x8b\xdc\xae\x6d\x0d\xb8\xf7\x70\xed\x98\xdc\xcb\x5e\xe3\xfc\x26\x4f\x4d\x51\x9b\x2f\x21\x75\x6d\x5a\x54\x8a\x8c\x80\xda\x30\xdc\x94\x20\x6f\x95\x2b\x1a\x96\x75\x6f\xc3\xf7\x07\x0a\x59\x03\xf8\x3b\x8d\xa8\x4e\xef\x72\xcb\x11\x78\x52\x8c\x26\x8f\xfd\x09\xad\x36\xdb\xdc\x9a\x70\x3d\xd3\xe5\x12\x4c\xc7\xc3\xb6\xff\x93\x8d\xe5\x19\xda\x4d\x23\xa9\xd2\x7d\xbd\xdb\xad\x50\x24\xef\xa0\x73\x51\xde\xe6\xf2\xd9\x34\x91\x05\xe6\x7c\x3f\x31\x6e\x1c\x2b\x1b\xba\xa0\x2e\x8a\x57\xa1\x07\x9f\x3d\x08\x62\xc2\xb4\xf4\xfa\x93\xd7\x39\xdc\xd6\xb9\xd8\xcd\x1d\x34\xa0\x49\x95\x5f\x35\x00\xba\x3b\x6d\xae\xb7\xe9\xb5\xff\x54\x90\xa3\x96\x86\x0e\xba\x77\xf2\xd9\x9e\x1a\xb7\xc9\x2a\xb7\xd8\x44\xa1\xa7\xf4\x52\x18\x97\xc8\xef\x92\xf7\xe8\x6c\x9a\xcc\x49\xde\x6d\x2e\x74\x23\x72\x24\x5b\x69\x11\x27\xf7\xb6\xf3\xad\x7b\x82\x95\x24\x01\x55\x70\x31\xf6\x2f\x8c\x9d\x08\x8f\x94\x02\xab\xae\xfb\xeb\xfb\x58\x94\xd4\x5e\x68\x78\x9e\xc9\xc8\xda\xd5\xbf\x4b\xeb\x83\x26\xaf\x66\xb5\xd6\x77\x7b\x10\x72\xf0\xf3\xa2\xb9\xdf\x90\x4b\x45\x6a\x78\x9b\x22\xd0\xb8\x18\x0c\xe6\x04\x28\xc7\xf3\xdb\xa9\xc9\x4b\x97\x54\xd6\xef\xee\x37\x9e\x8f\x4a\x96\x57\x2b\x85\x56\x56\xaa\x63\xeb\x0c\x91\x33\x12\x8b\xf9\x47\x94\xdc\xf7\x2e\xec\x4b\xa4\xac\x9b\x29\x60\x7e\xb5\x5f\xa4\xfb\x9f\x31\xfb\x2c\xa3\x8e\xb2\xdb\x3b\xbc\xcb\x6f\x65\x7a\xcb\xe5\xb6\x0b\x1a\x53\x88\x58\x5e\x6b\x5a\x5e\xf4\x96\x48\x93\xbe\xf9\x6f\x00\x00\x00\xff\xff\x67\x1c\xb0\x34\x9c\x13\x00\x00")
+var _complySoc2PoliciesPatchMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x58\x41\x6f\xe5\xb8\x0d\xbe\xbf\x5f\x41\x60\x0f\x05\x06\x49\xb0\x3b\x6d\xb1\x8b\xdc\xb2\xc9\x14\x08\xd0\x14\x41\x32\xed\x9d\x4f\xa2\x6d\x36\xb2\xa4\x8a\xf2\xcb\x78\x7e\x7d\x41\x59\xb6\xf5\x92\xec\x0e\xe6\x30\xc1\xb3\x44\x91\x1f\x3f\x7e\xa4\xe4\x71\xa4\x6b\x78\xc4\x6c\x06\x78\x40\x8f\x3d\x8d\xe4\x33\x3c\x06\xc7\x66\x3e\xa0\x49\xc1\xcf\xe3\x35\x3c\x3e\x3c\x1e\x04\x33\x4b\xc7\x24\xd7\x07\x80\xaf\xcf\xb7\xfa\x1f\xc0\x25\xdc\xde\xfe\x76\xf5\xcb\xf6\xf7\xaf\x57\xbf\x1c\x46\xfc\x6f\x48\x4f\x74\x62\xe1\xe0\xcb\xf2\x4b\xb0\x98\xe9\x1a\x9e\x29\xc2\x6f\xf0\xf9\xe7\xcf\x7f\x2f\x1b\x4c\x18\xf5\xb8\x6b\xb8\xf7\x9c\x19\x1d\xd8\x60\x26\xfd\x05\xba\x90\x74\xd9\x67\x3d\x08\x4c\x18\xa3\x63\xf4\x86\x0e\x97\x97\x97\x87\xc3\x4f\xf0\x38\xa5\x18\x84\x00\xbd\x85\x67\x13\x22\x1d\x0e\x78\x05\x5f\x07\x82\x58\xbf\x84\x0e\xf2\xc0\x02\xb1\x44\x02\x2c\x90\x03\x90\x64\x3c\x3a\x96\x01\x12\xfd\x6f\xe2\x54\x82\x95\x72\x18\x5b\xf2\x99\xbb\x99\x7d\x7f\x01\x74\x42\x37\x61\x2e\x7f\x67\x92\xe5\x0f\x3d\xcb\x52\x74\x41\xd7\x80\x84\x2e\xbf\x62\x22\x88\x8a\x1d\x49\x71\x45\xc8\x4c\x89\xf3\x0c\x53\xd4\x78\xcb\x99\x23\xb2\xcf\xc8\x1e\x64\x96\x4c\xe3\xbe\x46\x37\x24\x12\x76\x4c\xde\xd0\x55\x8d\x60\x77\x19\x63\x74\xbc\xd8\x40\xe7\x80\x7d\x17\xd2\x88\x99\x83\x87\x4c\x66\xf0\xc1\x85\x7e\xae\x56\xe5\x02\xd8\x1b\x37\x59\x75\x2d\x44\x4a\xc5\xf9\xfd\x63\xb1\x65\xca\x66\xb9\x80\x8e\xd3\xa8\xbe\x5f\x9c\x3b\x9d\x43\x70\x02\xaf\x9c\x07\xf6\x90\x07\x82\x90\x7a\xf4\xfc\xbd\x6c\xfb\x8b\xa8\x07\x09\x25\xa7\xc9\xe4\x29\xfd\xd8\x61\x1a\x15\x2b\xaa\xd0\x98\xe0\x73\x42\x93\x43\x12\x48\x24\x31\x78\xe1\xa3\xa3\x82\x7d\x45\x06\xed\xc8\x9e\x25\xab\xf3\xc1\x5f\x6c\x8e\x2d\x6e\x9a\x01\x7d\x4f\x30\x6e\x24\xbd\x52\x22\xfc\x8e\xe6\xa5\x4f\x61\xf2\xb6\xb8\xf3\xa5\xeb\xc8\x64\x3e\xd5\xb4\x34\xab\x95\x01\x26\x71\x66\x83\xae\x4d\xcb\x0e\xd3\x0e\x84\x1e\x17\x13\x9d\x94\x11\xbe\x07\xfa\x16\x5d\xe0\x5c\xbc\x82\xd0\xc1\x8b\x0f\xaf\x1e\x4e\x93\xf3\x94\xf0\xc8\x8e\x33\x93\x2c\xec\xdb\x28\x9b\x94\xfd\x64\x21\x06\xf6\x59\x74\x57\x17\xcc\x24\x40\x63\x1c\x50\xf8\x3b\x15\x80\x79\x8c\x21\x65\x25\xb6\xae\x38\xa7\xd4\xca\xc8\x9a\xb6\x82\x41\x75\x74\x63\xcd\x0c\x28\x10\x31\x65\xdd\x5e\x01\x2a\x40\x07\x07\x31\x05\x43\x22\x24\x4b\xa2\xfe\xed\x0b\x22\xb4\x1a\xd1\x2c\xc4\x44\xa2\xd0\x08\xf7\x9e\x3b\x36\xe8\xf3\x0e\x42\x62\x79\xa9\xa9\x43\x0f\x8e\xd0\x2a\x6c\x16\x33\xc2\x31\x11\x2a\xe7\x35\x45\xe9\xc4\x86\xc0\xb2\xa4\x29\x56\x7a\x95\x3d\x5b\xc9\xc2\x89\x83\x2b\x21\xa8\x27\x3f\xc1\x13\x75\x94\x94\xf1\x52\xfc\xba\x5d\xbc\xbe\xad\x5e\x57\xe1\xd1\x2f\x4f\x2c\x2f\x1f\x68\x92\x7e\x7a\x5e\x9d\xbc\xf7\xa6\xc0\x04\x4f\x0b\xa5\xa8\x5d\x75\x23\x42\x19\xee\xbd\xe6\x31\xa4\x25\xad\x1f\xd8\xfb\xa9\xdd\xf3\x69\x91\xc2\xfb\xf7\xd8\x3f\x04\xcf\x39\x24\xf6\xfd\xf5\xa7\x43\x51\x2f\x5e\x72\xde\x56\x09\xc8\xa0\xd4\xe7\x31\xba\xc5\x69\x9c\x72\x18\x31\x93\x82\xa7\xd5\x95\xc3\x9a\xd7\x19\xf0\x84\xec\xf0\xe8\x76\x0d\xd1\x62\xd0\xfd\x6b\x8a\x6a\x1d\x62\x09\x84\xd7\x40\xae\xb6\xe3\x37\x1c\x5a\x11\xda\x58\xbe\x8a\xd0\x38\x49\x86\x23\x6d\x84\x22\x5b\xa0\xa8\x22\x47\x76\x2d\xf9\xcf\x7f\x83\x21\x4c\xa9\xb0\x35\x91\x23\x14\xda\x8f\xba\x71\x6e\x73\x73\x35\x68\x30\xa3\x0b\x7d\xb5\x70\xa6\x50\x9b\x1a\xa9\xfa\x2f\xff\x2e\xe1\xa6\x54\x67\x43\x41\x75\x63\x63\xfd\x89\x52\xe9\x17\xcd\x86\x67\x3a\x51\x89\xcf\xd1\x89\xdc\xb9\x5a\xf1\x18\xd1\xe4\x66\xf1\x7f\xc8\xdb\x90\x20\xd1\xd2\x55\x6c\x49\xdd\x02\x09\x7b\xc9\xe8\xdc\xea\xda\xa2\x61\x6f\xce\xba\xa3\x48\xde\x92\x37\x5c\x71\x8c\x89\x4a\x97\x10\xce\x24\x2d\x39\xbe\xd4\xee\x50\x89\x51\x78\xaa\x5c\x13\xd1\x94\x37\xec\xf8\x82\x66\x58\x85\xa8\x42\xb6\x83\x5e\x72\xad\x8a\x69\x16\x0d\x99\x2f\xde\xc6\xb6\xe8\x5e\x0c\x59\xb9\x88\x0e\x8e\x93\xb0\x27\x91\xa6\xda\xf6\xfc\xdc\xae\x59\x97\x77\x9c\xb0\x36\x91\x88\x0a\x1d\x2e\xda\x98\x87\x44\xa8\xaa\x94\x60\xe0\x7e\xb8\xd4\x52\x7f\xab\x67\x9b\xc7\x31\x71\x50\xdb\xdf\xab\xcf\x3c\x8e\x64\x19\x33\xd5\x66\x58\xa5\xb8\xba\xa1\x05\x51\xcc\x85\x0e\x7c\xc8\xa5\x27\x94\x7e\x89\x6f\x80\x78\x25\xee\x07\x65\x62\x8f\x9a\x1d\xc8\xcd\xc6\x6d\x13\xe7\x0b\x30\xda\x29\x2c\xa5\x56\xa8\x73\x85\xac\x00\xb4\xc1\x52\xfb\x5e\xf0\x72\xd5\xa6\xeb\xeb\xd2\xc0\xe1\xa9\x69\xf9\xd7\x9f\xfe\x94\xd8\xda\xf3\x49\x69\x03\x08\x3e\xf8\xcb\x98\x82\x5d\x18\x03\xe4\x4f\x9c\x82\x57\x2b\x70\xa4\x2e\xa4\x16\x07\x55\xc8\x66\x6d\xa5\x79\x83\x4e\x75\xa5\x60\xab\xd4\xee\x66\xc8\x03\xe6\xcd\x01\x1b\x0a\x6a\x9e\x7a\xd4\x4c\xb9\x95\x0a\x6b\xe4\xdd\xe4\x8b\x1b\xb8\x10\x26\x52\x2a\x43\x81\x37\x74\xa1\xd9\xdc\x72\x5f\x9b\x40\x73\xf4\x97\x91\x52\x5f\x5a\xc6\x07\xbc\x30\xef\xc8\xf3\x8e\x0d\x38\xc3\x71\x8e\x28\x02\xf4\x2d\x93\x97\xc2\xa3\x1a\x8e\xea\x87\x66\x3a\x85\x98\x0a\x33\x9a\xb6\x5b\x7e\x3e\xe1\x52\xbc\xe4\x07\x6d\x76\x16\xc6\x4d\x4c\xcf\xc1\xd1\xd1\x60\x72\x79\xcf\xc4\x3a\x10\x56\xcd\x5a\xac\x91\x5d\xa1\x6f\xc0\x3e\x63\xe3\x9e\xfd\xbb\x3d\x39\xea\xc1\xb3\x19\xc8\x4e\xee\x5c\xc5\xff\xb8\x76\x36\x37\x8a\x95\x5d\x2a\x7f\xfd\xbc\x4b\xa5\x4c\x46\x1b\x6d\x37\xb9\x15\x90\x3d\xa6\x7f\x05\x7f\xb9\x81\xfb\x23\xa3\x7f\xfd\x19\x2c\xce\xad\xfc\x6a\x4e\xed\x54\xa8\xaf\xf5\xe1\xe9\x5b\x06\x59\x22\x20\xbb\x8c\x30\xe4\x15\x51\x78\x65\x6f\xc3\xeb\x7e\xf0\x12\x7c\xc3\xcc\x72\x68\x17\x9c\x0b\xaf\xfb\x18\x4c\xf6\xc3\xa9\xc1\x4e\x89\xe4\x63\x09\xaf\xed\x5a\x85\x91\x64\xcf\x6e\xb3\xe2\x7e\xed\x7d\xa5\x14\x21\x3a\xf4\x9e\x7d\xdf\xac\x78\x0a\xce\x1d\xd1\xbc\x34\x87\x35\x5f\x6f\xc3\x38\x4e\x7e\xed\xbc\x39\x80\x64\x7c\xa1\x21\x38\x4b\x49\xfe\x84\xce\x23\xce\x67\xa0\x86\x29\xab\x6e\x28\x9a\x5e\x6b\xc4\xad\xa1\x2e\x50\xc9\x7b\xd6\xae\xc1\x2c\xf2\xef\xc3\x3e\x00\xac\x82\xf2\xbc\x54\xe1\x53\x33\x7f\x69\x03\x20\x13\x4e\x94\xe6\x86\x51\xbf\x2f\xf4\xdc\x84\xac\x3a\x79\xb1\xd6\xb1\x86\x3f\xc5\x9d\x0a\x45\x0b\xb8\x92\x3c\x55\x7b\x6d\x32\x94\x59\x64\xf7\xfc\x7e\x80\xe1\x66\x2c\x26\x8a\x98\xaa\xb1\x2a\x65\xeb\x64\x11\xdf\xf0\xa2\x51\x88\xe7\xda\x94\x57\x33\xb5\x46\xc9\xae\x14\x54\x54\xb0\xcb\x94\xde\x59\x51\xdd\x23\x2f\x93\xce\xae\x49\x65\x78\xbd\x83\xb4\x1d\xea\x1f\xc8\x8e\xec\x9e\xaf\x7a\x4c\x0a\x4e\x7f\x2e\xc1\x6c\xbd\xc5\xcd\x4b\xf3\x63\x91\xa9\x59\xab\x23\x90\x64\xee\x71\xd5\x83\x44\x12\xdc\x89\xec\x59\xc9\x37\x33\x9e\x86\x7d\xc7\x5d\x99\x38\x33\xd4\xf4\x7d\x9d\x23\xc9\x47\xe5\x7f\x7e\xc1\xa9\x43\xc0\x5b\x35\x5f\xaf\x8e\xda\x57\x59\x3f\xa0\xdb\x98\x53\xb6\xec\xda\xb6\x02\xf7\x16\xae\x1d\x93\x3b\x9d\x6b\x42\xdc\xe4\xa9\x0a\xc8\x76\x96\x92\xba\x16\x2d\x1a\x43\x4e\x1b\xdc\x8a\xe1\xa6\x04\x65\xaa\x3c\xa1\x63\x1d\xf7\x36\x7c\x7f\xa0\x90\xd5\x81\x7f\x52\x8f\x66\x7e\x13\x5b\xf1\x20\x92\x61\x74\xa5\xed\x0f\xe8\xad\xdb\xfa\xd6\x80\xa7\x33\x5d\x2e\x07\xb6\x3c\x5c\xe7\x7f\xf2\xfa\x64\xe0\xfb\xb6\x1b\x69\x15\xdd\xd5\xbd\xcd\x08\x45\x7a\x0f\x3a\x17\xe5\xad\x2f\xb7\x97\xb8\x32\xc0\x9c\xcf\x27\x2e\xf4\x7d\x65\x43\xe3\xd4\x87\xe2\xb5\x88\xe2\x36\x47\x17\xc4\x25\x63\x9e\xda\x39\xb0\x8c\x73\xb8\x8d\x73\xd2\xf4\x1d\x74\x60\xc9\x2c\xaf\x1a\x00\xcd\x9e\x75\xc4\x58\xbb\xd7\xfe\x54\xa0\x26\xca\x48\x9a\x6c\x7b\xc8\x8d\x9f\x57\x6e\x93\x37\x61\xf2\x99\xd6\x82\x2d\x94\x9e\x0a\x34\x92\x29\xee\x92\xf7\x10\x7c\x1e\xdc\xac\xf7\xb6\x90\x9a\x16\xd9\x93\xaf\xb4\x90\x21\xbc\xee\x7c\xdb\x5f\x4d\x54\x42\xf3\x24\xa0\xcf\x39\x22\xed\x0d\x63\x27\xc2\x03\xe5\xc4\x66\xb7\xba\xde\x8f\x55\x49\x7d\xb5\xd8\x84\x94\x79\x24\xa7\xd3\x68\x7b\x2f\xad\x17\x1a\x9d\x19\xe6\x9a\xeb\xdb\xdd\x09\x5d\x78\x33\x59\x6e\x27\xe4\x25\x23\xd5\xbd\xf5\xe8\x44\xfd\xe4\x30\xb9\x19\x50\x97\x93\x6d\xe4\xa5\x09\xaa\xe8\x77\xf3\xc6\xf3\x5e\xc9\xca\x68\x65\xd0\xeb\x48\x75\x5c\x2b\x43\xe5\x8c\xd4\x62\x79\x44\x29\x8d\x39\xa4\x7d\xb6\xd6\x71\x33\x27\x0d\x5e\x3e\xa6\xfb\x1f\x31\xfb\x2c\xa2\x86\xb2\xdb\x3d\x7c\x83\x56\xdf\x06\xe8\xb5\xa4\xdb\x4f\xe8\x34\x4e\x6f\xeb\x93\x91\x05\x14\xf0\x44\x96\xec\xd5\xff\x07\x00\x67\x1c\xb0\x34\x9c\x13\x00\x00")
 
 func complySoc2PoliciesPatchMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -901,12 +922,12 @@ func complySoc2PoliciesPatchMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/patch.md", size: 5020, mode: os.FileMode(420), modTime: time.Unix(1757470844, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/patch.md", size: 5020, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesPolicyMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x93\xc1\x6e\xdb\x30\x10\x44\xef\xfa\x8a\x01\x72\x8d\xdd\xa6\xa7\xc6\x37\xc3\x28\xd0\x14\x69\x6b\x34\xee\x07\xd0\xe2\x48\xda\x96\xe2\xaa\x4b\xca\x86\xff\xbe\x20\x1d\x38\x09\x9c\x8b\x0d\x2e\xb9\x6f\x67\x06\xab\xe8\x46\xae\xb0\xd5\x20\xed\x09\x3b\x73\x12\x25\xf6\xcf\xe7\xc6\xb5\xa6\xf1\x34\xae\xb0\xdd\x6d\x9b\xe4\xb2\xa4\x4e\x98\x56\x0d\xb0\x7b\xda\x94\x3f\x60\x81\xcd\xe6\x7e\x79\xdf\x8c\xee\x8f\xda\x2f\x1e\x24\x89\xc6\xfa\x64\x01\xef\x32\x57\xf8\x36\x47\xdc\xe1\xd3\xc7\xbb\xcf\xb5\xa1\xd5\x71\x64\xcc\x2b\x3c\x44\xc9\xe2\x02\xbc\xb6\x73\xa9\x34\x8b\xc5\xa2\xb9\xc1\x76\xb6\x49\x13\xe1\xa2\xc7\x53\xab\x13\x9b\xc6\x2d\xb1\x1b\x24\x61\x3a\xab\x74\xde\x1b\x53\xe2\xa5\x40\x3f\xb7\x2e\x8b\x46\x18\xff\xcd\x62\x2c\xb8\x84\x4e\x0d\x1c\xa7\xa0\x27\x32\x55\x5e\xab\x31\x9b\x6b\xb3\x5a\x5a\xe2\x9a\x3b\x4d\x41\x98\x90\x15\x2e\x04\x74\x73\x08\x8b\x2c\x23\x5f\x20\xb7\x98\x9c\xe5\xab\xe2\x15\x7a\xed\x07\x1a\x63\xcb\xca\x4a\x49\xfa\x48\x7f\x1e\x53\x06\x48\xc2\x5e\xa2\x2f\x41\xcf\xd1\xd3\x90\x07\x8a\xe1\x4b\x25\x16\xed\xf8\xd9\x75\x34\x3c\x32\x67\x5a\xc1\x7f\x50\xc3\x43\xf4\x9c\x18\x7d\xb9\xdf\x5c\xa6\x61\xdd\x1b\xab\xe1\xe2\xe8\x06\xeb\x62\xa2\x75\x7b\x09\x92\x4f\xd5\xe2\xef\x49\x23\x06\x31\x42\x3b\x38\x44\x1e\x2f\xda\xa1\xf6\x4a\xf8\x6d\xd1\x81\xaf\x62\x45\xd8\x77\x17\x5d\x4f\xc3\x51\x42\x80\x67\xa6\x8d\x12\x89\xe3\x20\xed\x80\x34\xef\x13\x73\x2a\xc0\x8b\xa9\xfa\xb0\x44\x78\x2a\xa6\xf3\xe0\x32\x8a\xc7\x83\xf8\xd9\x85\x12\x34\x5f\x9d\xcf\xaf\x07\x77\x20\x3a\x39\x10\x47\xb5\xbf\x65\xaa\x77\xa7\x9a\xbf\xd1\xf9\xaa\xe6\x2a\xbc\x33\xa9\xd3\x10\xf4\x58\x3a\x2a\x68\x4f\x04\xed\x7b\x7a\x48\xac\x6d\xef\xef\x33\x1e\xe9\x7b\xda\x79\x71\xeb\x8f\x2c\xb1\xae\x13\x6a\xea\x65\x5f\xdf\xdc\x6d\x74\x9c\x02\xeb\x66\x5d\xdd\x3d\x7f\x22\xd7\x28\xf2\xbd\xa2\xbd\x29\xfe\xd0\xcc\xd4\xfc\x0f\x00\x00\xff\xff\xc1\x46\x0a\x9e\x7c\x03\x00\x00")
+var _complySoc2PoliciesPolicyMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x93\xc1\x6e\xdb\x3c\x10\x84\xef\x7a\x8a\x01\x72\x8d\xfd\xff\xe9\xa9\xf1\xcd\x30\x0a\x34\x45\xda\x1a\x8d\xfb\x00\xb4\x38\x92\xb6\xa5\xb8\xea\x92\xb2\xe1\xb7\x2f\x48\x07\xae\x03\xe7\x22\x81\x43\xf2\xdb\x99\xd5\x2a\xba\x91\x2b\x6c\x35\x48\x7b\xc2\xce\x9c\x44\x89\xfd\xeb\xba\x71\xad\x69\x3c\x8d\x2b\x6c\x77\xdb\x26\xb9\x2c\xa9\x13\xa6\x55\x03\xec\x5e\x36\xe5\x05\x2c\xb0\xd9\x3c\x2e\x1f\x9b\xd1\xfd\x52\xfb\xc1\x83\x24\xd1\x58\x8f\x2c\xe0\x5d\xe6\x0a\x5f\xe6\x88\x07\x7c\xf8\xff\xe1\x63\xbd\xd0\xea\x38\x32\xe6\x15\x9e\xa2\x64\x71\x01\x5e\xdb\xb9\x28\xcd\x62\xb1\x68\xee\xb0\x9d\x6d\xd2\x44\xb8\xe8\xf1\xd2\xea\xc4\xa6\x71\x4b\xec\x06\x49\x98\xaa\x2b\x38\xef\x8d\x29\xf1\x22\xd0\xcf\xad\xcb\xa2\x11\xc6\x3f\xb3\x18\x0b\x2e\xa1\x53\x03\xc7\x29\xe8\x89\x4c\x95\xd7\x6a\xcc\xe6\xda\xac\x96\x96\xb8\xe5\x4e\x53\x10\x26\x64\x85\x0b\x01\xdd\x1c\xc2\x22\xcb\xc8\x7f\x90\x7b\x4c\xce\xf2\x8d\x78\x83\x5e\xfb\x81\xc6\xd8\xb2\xb2\x52\x92\x3e\xd2\x9f\xdd\x96\x02\x92\xb0\x97\xe8\x4b\xa3\xe7\xe8\x69\xc8\x03\xc5\xf0\xa9\x96\x29\xde\xf1\xbd\xeb\x68\x78\x66\xce\xb4\xe2\xfc\x3f\x35\x3c\x45\xcf\x89\xd1\x97\xfd\xcd\xa5\x1a\xd6\xbd\xb1\x06\x2e\x89\xee\xb0\x2e\x21\x5a\xb7\x97\x20\xf9\x54\x23\xfe\x9c\x34\x62\x10\x23\xb4\x83\x43\xe4\xf1\xe2\x1d\x6a\x57\xc6\xef\x8b\x0f\x7c\x16\x2b\xc6\xbe\xba\xe8\x7a\x1a\x8e\x12\x02\x3c\x33\x6d\x94\x48\x1c\x07\x69\x07\xa4\x79\x9f\x98\x53\x01\x5e\x42\xd5\x83\x6e\x9a\xc2\xa9\x84\xce\x83\xcb\x28\x19\x0f\xe2\x67\x17\xca\x07\xe4\xd5\xfa\x8c\x1d\xdc\x81\xe8\xe4\x40\x1c\xd5\x7e\x97\xaa\xde\x9d\x6a\xff\x8d\xce\x57\x37\x37\xcd\x3b\x93\x3a\x0d\x41\x8f\xe5\x46\x05\xed\x89\xa0\x7d\x4f\x0f\x89\xf5\xda\xfb\xf3\x8c\x67\xfa\x9e\x56\x86\x13\xa8\x0f\x59\x62\x5d\x2b\x94\x06\xd6\x79\x7d\xb3\xb7\xd1\x71\x0a\xac\x93\x75\xb3\xf7\xfa\x8b\x5c\x4b\x67\x14\xf9\x9e\x68\x6f\xc4\x6f\x9a\x99\x9a\xbf\x03\x00\xc1\x46\x0a\x9e\x7c\x03\x00\x00")
 
 func complySoc2PoliciesPolicyMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -921,12 +942,12 @@ func complySoc2PoliciesPolicyMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/policy.md", size: 892, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/policy.md", size: 892, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesPrivacyMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\xd0\xcd\x6a\xeb\x30\x10\xc5\xf1\xbd\x9e\xe2\xc0\x5d\x2b\x5c\xf9\x2b\x41\xdb\xac\x5a\x08\x88\xa6\x2f\x30\x28\x6a\x98\x12\xcd\x80\xed\x04\xfc\xf6\xc5\x01\x33\x5e\xe9\xc7\x9f\x83\x40\x12\xaa\x25\x22\x8d\xfc\xa2\xbc\xe0\x42\x42\xf7\x52\x8b\xcc\x48\xfa\xe0\xbc\x38\xca\xa3\xca\x52\x23\xd2\x25\xb9\x89\x66\x9e\x7e\xb8\x4c\xd1\x01\xdf\xd7\xf3\x7a\x00\x1e\x29\x1c\xc2\xc6\xc6\xd8\xee\xd9\x6c\xec\xac\x76\xfb\xda\x6e\xec\x6d\xd0\xdb\x60\xb0\x3a\xec\x6b\x6b\xec\x8c\xbd\x71\x30\x1e\x37\x1e\xed\xb2\xd3\x21\xb8\x4a\xbf\x3a\x7e\x95\x17\x4f\xac\xf2\x7e\x9c\xc7\x8d\xe6\x12\xf1\xf9\x14\x04\x34\xff\xc3\xe9\xbd\xcf\x5a\xd7\xcf\x89\xf8\x10\x9e\x99\x1e\xb8\x69\x7e\xae\xc5\x79\xef\x9d\xfb\x87\xb3\x56\x96\x3b\xae\xaa\xf2\x17\x00\x00\xff\xff\x4b\x76\x27\x2c\x5a\x01\x00\x00")
+var _complySoc2PoliciesPrivacyMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\xd0\x41\x6a\xc3\x30\x10\x85\xe1\xbd\x4e\xf1\xa0\x6b\x85\xca\xb1\x9d\xa0\x6d\x56\x2d\x04\x44\xd3\x0b\x0c\x8a\x1a\xa6\x44\x33\x60\x3b\x01\xdf\xbe\x38\xe0\x8e\x57\xfe\xf8\x79\x16\x48\x42\xb5\x44\xa4\x81\x9f\x94\x67\x9c\x49\xe8\x56\x6a\x91\x09\x49\xef\x9c\x67\x47\x79\x50\x99\x6b\x44\x3a\x27\x37\xd2\xc4\xe3\x0f\x97\x31\x3a\xe0\xfb\x72\x5a\x3e\x80\x47\x0a\xbb\xb0\xb2\x31\xee\xb7\x6c\x56\xb6\x56\xdb\x6d\xdd\xaf\xec\x6c\xd0\xd9\xa0\xb7\xda\x6f\xeb\xff\x6f\xfd\xae\x35\x76\xc6\xde\x78\x58\x79\xb0\xc3\x8e\xbb\xe0\x2a\xfd\xea\xf0\x55\x9e\x3c\xb2\xca\xeb\x72\x1e\x57\x9a\x4a\xc4\xe7\x43\x10\xd0\xbc\x87\xe3\x6b\x9f\xb5\x2e\x8f\x13\xf1\x21\x3c\x31\xdd\x71\xd5\xfc\x58\x8a\xf3\xde\x3b\xf7\x86\x93\x56\x96\x1b\x2e\xaa\xf2\x37\x00\x4b\x76\x27\x2c\x5a\x01\x00\x00")
 
 func complySoc2PoliciesPrivacyMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -941,12 +962,12 @@ func complySoc2PoliciesPrivacyMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/privacy.md", size: 346, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/privacy.md", size: 346, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesProcessingMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\xce\xb1\x8a\xc3\x30\x10\x04\xd0\x5e\x5f\x31\x70\xb5\x8e\x93\x2f\x81\xa0\xd6\x95\x52\x89\x38\x3f\x20\x64\xc5\x6c\xb0\x76\x41\x92\x03\xfe\xfb\x60\x57\xaa\xf6\xb1\x30\xc3\x70\xc8\xc9\xc2\x17\x89\xa9\x56\xe2\x05\x8e\x5b\x5a\x0a\xb5\x1d\x5e\x56\x8a\xbb\x0a\xb1\x08\xef\xd9\xc2\x3b\xaf\x6a\x68\x54\x5f\x94\xaa\x55\xc0\x73\x1a\x8f\x03\x68\x78\x67\x7e\x4d\xe7\xa1\xf3\x7f\xe7\x4b\xe7\xab\xca\xe1\x2d\xe5\x91\x3e\x54\x49\xf8\xac\xd4\x98\x43\x4b\x16\xf7\x8d\x61\x30\xfc\x99\xdb\x19\x88\x92\x73\xe2\x66\xe1\x98\x1a\x85\x15\xb3\xc4\xed\xf8\x28\xad\xb5\x52\x3f\x18\x25\x1f\xe3\x27\x11\xfe\x06\x00\x00\xff\xff\x52\xb2\xb4\x8a\xd2\x00\x00\x00")
+var _complySoc2PoliciesProcessingMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\xcc\xc1\x8a\xc3\x20\x18\x04\xe0\xbb\x4f\x31\xb0\x67\x97\x35\xdb\x42\xf1\x9a\x93\x3d\x49\xd3\x17\x10\x63\xc3\x5f\xe2\xff\x83\x9a\x42\xde\xbe\x24\x27\x4f\xf3\x31\x30\xc3\x21\x27\x0b\x5f\x24\xa6\x5a\x89\x17\x38\x6e\x69\x29\xd4\x76\x78\x59\x29\xee\x2a\xc4\x22\xbc\x67\x0b\xef\xbc\xaa\xa1\x51\x7d\x51\xaa\x56\x01\xcf\x69\x3c\x02\xd0\xf0\xce\xfc\x9a\xce\x43\xe7\xff\xce\x97\xce\x57\x95\xc3\x5b\xca\x23\x7d\xa8\x92\xf0\x79\xa9\x31\x87\x96\x2c\xee\x1b\xc3\x60\xf8\x33\xb7\x73\x10\x25\xe7\xc4\xcd\xc2\x31\x35\x0a\x2b\x66\x89\xdb\xd1\x28\xad\xb5\x52\x3f\x18\x25\x13\x2f\x98\x44\xf8\x3b\x00\x52\xb2\xb4\x8a\xd2\x00\x00\x00")
 
 func complySoc2PoliciesProcessingMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -961,12 +982,12 @@ func complySoc2PoliciesProcessingMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/processing.md", size: 210, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/processing.md", size: 210, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesRemoteMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x57\xcd\x72\xdc\xc8\x0d\xbe\xcf\x53\xa0\x2a\x17\x5b\x35\x9a\x8d\xf7\x90\xa4\xb4\x27\x47\x71\x55\x9c\xaa\x64\x55\xb2\x37\x7b\xee\x69\x82\x43\x44\xcd\x06\x83\x06\x35\xa6\x4f\x7e\x8d\x54\x25\x2f\xe7\x27\x49\x01\x4d\x72\x46\x96\x64\x25\xa7\xf9\x61\xff\x7c\xc0\xf7\xe1\x03\x98\x43\x8f\x57\x70\x8b\x3d\x2b\xc2\xdb\x18\xb1\x14\xb8\xe1\x44\x71\xda\x84\x28\x9c\xa7\xfe\x0a\x6e\xdf\xbd\xbd\xd9\x94\xa0\x54\x5a\xc2\x72\xb5\x01\xf8\xf8\xe1\xda\x3e\x00\x2e\xe1\xfa\xfa\x77\xbb\x37\x67\xdf\x7f\x3c\xfb\xfe\xfb\x4d\x1f\xfe\xc1\x72\x8b\xf7\x54\x88\xb3\x6f\xbd\x84\x26\x28\x5e\xc1\x5f\xc6\x0c\x6f\xe0\xc7\xdf\xbe\xf9\x83\x6f\x88\xdc\xf7\x98\xf5\x0a\xde\x67\x52\x0a\x09\x1a\x8e\xa3\xfd\xb3\xb9\xbc\xbc\xdc\x6c\x7e\x03\x37\xa3\x0c\x5c\x10\x42\x6e\xe0\x43\xe4\x01\x37\x9b\xb0\x83\x8f\x1d\xc2\x30\x3f\xe1\x16\xb4\xa3\x02\x83\xc3\x07\x2a\xa0\x0c\x0d\xb6\x94\x11\x04\xff\x39\x92\xa0\x1d\x58\xa0\x65\x81\xc8\x39\x63\x54\xca\x07\x5b\xa5\x1d\x02\xcb\x21\x64\xfa\x1c\x94\x38\x7f\xfd\xf2\xaf\x02\x65\x2a\x8a\x7d\xf1\x1b\x33\xea\x91\xe5\xae\x40\x2b\xdc\x83\xd4\x74\x75\x5c\xb4\x6c\x81\x72\x4c\x63\x63\x27\x0d\x28\x85\x73\x48\x69\xba\xe4\x63\xc6\x06\x1a\xbc\xa7\x88\xbe\x06\x58\x1a\x14\xbb\xac\xa7\x4c\x3d\x7d\x46\x4b\x44\x80\xc4\xa5\xfc\x80\x9f\x06\x2e\xa3\xe0\x6e\x0e\xea\x14\x45\x18\x86\x44\xe8\xa1\x84\x94\x60\x2c\x28\xc5\x22\xa5\xdc\xb2\xf4\x0e\x76\x05\x7a\x24\xed\x28\x3f\x0a\x66\x3e\x50\xa7\x81\xa2\x61\x9b\x01\x63\x01\xec\x87\xc4\x13\x62\x8d\x31\x72\x56\x09\x51\x59\xca\x16\x42\x81\x23\xa6\x64\x9f\x21\x4f\x80\x9f\x14\x25\x87\x04\x43\x10\x75\x3c\x5d\x50\x23\x0d\x81\xb2\xb2\xef\x0d\x51\x1d\xc2\x83\xc4\x9d\xe3\xf4\x0b\x38\x25\x6c\x60\x3f\x3d\x82\x09\xaf\x3a\x14\xa4\x1c\x5a\x45\x01\xc1\x16\x45\xb0\xf1\xc0\x0b\x7c\xfd\xf2\x6f\x0f\xfd\xeb\x97\xff\xbc\x7e\x98\xa0\x7e\x2c\x0a\x7b\x84\x3e\x34\x46\x73\x68\x28\x4d\x10\x5c\xc8\xb4\x4f\xf8\x20\x71\x3b\x13\xd2\x1f\x43\xbc\x3b\x08\x8f\xb9\x59\x15\x44\x59\x31\xeb\xd3\x02\x5a\xd9\x7a\x52\x23\x0b\x71\x2e\xb5\xd0\x87\x03\x16\x38\x76\x14\x3b\xe8\xc3\x04\x82\x65\x4c\x5a\x35\x63\xdb\xc7\x1c\x46\xed\x58\xe8\x33\x36\x8b\x88\xc6\xaa\x5c\xc1\xc2\xa3\xcc\x5a\x59\xf4\xb4\x1f\x15\x32\x2b\x24\xea\x49\x3d\x17\x57\x7e\x8e\x69\xc6\x36\x15\xcc\x85\x94\xee\x71\x6b\x54\x0c\x46\x54\xe4\xdc\x52\x83\xb9\xd6\x90\x09\xac\xb2\xa0\x98\x12\x46\x1d\x8d\x42\xe1\x01\x45\xa7\x9f\x66\xc4\xcf\x16\xc0\x30\xee\x13\x45\x20\x5b\xf4\xe2\x62\xbb\xc2\x15\x32\xb3\xff\x93\x5f\x6c\xc5\xe7\x42\xf8\x81\x05\x58\x3b\x14\xfb\x2b\xe4\x68\xf0\x12\x85\x3d\x25\x72\x3d\x51\x8e\xa3\xf3\x6d\x82\x5b\x12\x67\x21\x8e\xb1\xf3\x78\xb1\xd4\xe2\xf8\x75\x11\xf9\x4a\xd4\xd6\x01\xb5\x9c\x12\x1f\x2d\x6b\x5e\xf2\x64\xc0\x8a\x97\xcf\x74\xb5\x71\x8b\xa1\x1d\x5c\xfc\x95\xf7\x94\xd0\xb3\x35\x7a\xf1\x9b\x2f\x0c\xee\x3c\x17\xa7\xba\x18\x58\x34\xec\xd7\x75\x68\x05\xd1\xd7\x9d\x43\xc7\xd9\x48\x2a\x7d\x10\x5d\x7f\xf5\xd8\xb3\x4c\x10\x83\x34\x55\xf6\x35\xd4\x79\xcf\x7a\x87\x91\xdd\xb8\xfd\x14\x65\x09\x07\xdc\x1a\x19\x2e\xd5\x7c\xf0\x7d\x2a\x21\x97\x16\xc5\x42\x37\xf6\x76\x27\xe8\xb7\x27\xd3\x31\xac\x65\xb6\xb6\x9a\xb1\xfc\x84\x21\x6c\x21\x73\x63\x4c\x2d\xee\x55\x8b\x96\x8a\x6b\x6a\xcc\x66\x47\x0d\x09\x46\x5d\x2a\xb3\x56\xc0\x37\xee\x71\x42\xf0\x11\x13\xda\x39\x57\x17\xbe\xca\xea\x9d\x5b\x18\x1d\x7c\xff\x6c\x62\x3d\xae\x73\xc7\x34\x09\x0d\x28\x06\x17\x1c\x16\x8f\x5a\xa8\x79\xa6\xc2\x86\x6e\x2a\xe6\x5b\x30\x08\xf6\x64\x32\x80\x05\x87\x93\xcd\x58\xe3\x99\xc9\xab\x75\x56\x6b\xea\x01\x65\x5e\xfb\x73\x67\x33\x1d\x5d\x7c\xc0\x38\x0a\xe9\x04\xb7\xdf\xf6\x86\x39\xd3\x7f\x76\xb0\x86\x7e\x56\xcd\xf5\x1a\xdc\xbb\x25\xb8\x8b\x35\x3b\xd7\x61\xf4\xdc\x2f\x7e\x84\x9f\x50\x22\x19\xdf\xc7\x0e\xf3\xf7\xf2\x63\x42\x4e\x21\x62\x63\x54\xb9\x42\x28\xc3\x98\xcf\xdc\xb2\x0c\x21\x62\xa9\xb5\x10\x0a\xdc\x63\x47\x31\x99\xee\xe6\x0a\xad\xcf\xb7\xd0\xb1\x62\x02\x61\xee\x5d\x93\xe8\x17\xf9\xd9\x65\xeb\xce\x80\x82\x39\x22\x44\xcc\x55\xd4\x27\xa9\x8e\x79\x10\x56\x8c\x66\x33\x41\x30\x94\x17\x58\x59\xc8\x58\xe3\xff\xd5\xa2\xac\x5a\x78\x40\xb6\x5d\xf1\x7c\xf0\xdb\xb9\xa3\x79\xd6\x34\xdc\xa1\xd5\x10\xce\x42\x3d\x53\x34\xd7\xb6\x56\xfb\x29\xbc\xc2\xdd\x61\x07\x0d\x95\x21\x85\xc9\xf2\x56\x9f\x96\x28\x88\xf9\x35\xc4\x90\x4d\x11\xfb\xda\x0f\xac\xd7\x3c\x30\xde\xda\xa5\x0b\x50\x7b\x7e\x26\x15\xd8\xa3\x61\x73\x06\x6a\x4b\xb3\x09\xe1\xc5\xf1\x80\xa5\xaa\xd8\x5b\xdf\x93\x2b\x1f\x16\xf2\xed\x79\x76\x16\xb5\x8c\x83\x4d\x45\x8d\x67\x6b\x08\x1a\xbb\xd9\x27\xdc\xed\x83\x62\x51\x28\x8b\x5e\xeb\xda\x62\x51\x07\x85\x84\xa1\x28\x04\xe8\x39\x6b\x97\x26\xd8\x87\x42\xe5\x7b\xb7\x75\xe1\x1e\x01\x73\x33\x30\x65\x85\x99\x75\x77\x0d\x6e\xf5\x68\xd9\xaf\xe9\xed\x43\xf2\x5f\xc5\xd2\x89\xf2\x1a\x28\x17\x0d\xae\x47\x43\xb9\x22\x56\xef\xaf\x4a\xfd\xb9\x1a\x6e\xe6\x1c\xbf\x68\x0e\xdc\xb6\x97\x8b\x98\x4c\x78\x66\xfd\x03\xe7\xda\xbb\x2d\x03\x82\x87\x31\x05\x81\x7d\x88\x77\xe3\xe0\x6d\xef\x3c\xc1\x4b\x97\x73\xc5\x08\x9a\x62\xcb\xa2\x87\x13\xbb\x27\x60\xf3\x88\xfb\x12\xa9\x0b\x2f\x0d\x67\x3b\x47\x78\x3c\x74\xe6\xb2\x98\xa3\x4c\xc3\xc2\x94\x89\xca\x5a\x6d\xf4\x4c\xfc\xfd\xe6\x6f\xeb\x58\xc9\xb9\xea\xa1\x1f\x93\xd2\x65\xeb\x53\xd5\xf9\x72\x5b\x80\xd9\x7a\x4c\xb3\x83\xb7\xeb\x60\x57\xc7\xd4\xb3\x1a\xaa\x83\xcc\x0a\x67\x10\xae\xa3\xb4\xd9\x8a\x9d\xef\x77\x0a\xce\x0d\xbf\x9c\x46\x8d\x27\xfa\xf3\xa9\x9a\x14\x63\x97\x39\xf1\x61\x02\xc5\xd0\xef\xfc\x98\x3b\x9c\x4e\x17\x09\xeb\xc2\x6e\x15\x98\x1e\xad\x48\x06\x14\x98\x30\x88\xe9\xea\x9e\xe7\x38\xb8\x7d\xbc\x7f\x76\x63\xb7\x32\x83\xf3\x73\xdb\xee\x39\x88\x4f\x35\xd5\x84\x77\xb0\x72\xf2\xfe\xbc\x75\x29\x4b\xad\xe8\xef\x88\x66\xf5\xd8\x95\x8d\xaa\xb3\x2e\x48\x03\x8d\xd0\x3d\x42\x3b\xa6\x64\x0e\x71\xb7\x2c\xaa\xad\xec\xfb\xc6\xbf\x34\x96\x93\xab\xbf\x5b\x27\xe4\xe5\xce\x32\x60\xa4\x76\x9e\xa4\xcf\x5c\xc5\xab\x75\xde\x6f\x51\x1f\x85\x1c\xf5\xc2\x08\x09\x74\x95\xda\x3e\xe4\x70\x40\x81\x93\x28\x7f\xce\x69\x9a\x95\xea\x5c\x85\x52\xe8\x60\x1c\xdb\x5b\x84\x78\x83\x40\xe9\x49\xeb\x0c\xe8\xed\x6d\xd6\x87\x75\xf8\x97\x3c\x76\x07\xbf\x9c\xdb\x5f\x55\xda\xab\xa5\x99\xb8\xfd\x17\x48\x74\x6f\x9b\x66\x33\xf3\x71\x44\xe7\x59\x73\xa6\xf9\x68\xe3\xf9\x59\x88\x65\x69\xe2\xd8\xbc\xf6\xca\x35\xcf\x7d\x84\xd3\xaf\x99\x5f\x83\x4e\x01\xff\xa9\xfe\xb1\x26\xf5\x01\x3c\xbb\x3c\xa2\xa8\x67\x59\xb1\xac\xbb\x7e\x71\xe4\xf3\xad\xfe\xe6\xb6\x80\x79\xca\x38\x7c\x44\x19\x6c\xd8\x15\x0a\x8a\x75\x32\x3e\x8c\xb2\x8a\x76\x89\x2e\xad\xf3\xd1\x3a\x9e\x3d\x7e\x3b\x7c\xef\xe3\x2d\xea\x9c\x16\x3a\x23\x7b\xc9\xd0\xee\x7f\x00\xea\x01\xcf\x9e\xfb\xfc\x1c\xfd\x68\x54\x07\xa1\x43\x67\xef\x9a\x48\x75\x86\xb6\x11\x72\xb1\xeb\x75\xb2\xee\x83\xa2\xb8\x0d\xb8\x1b\xda\xb3\x41\xb0\xb8\xcd\xe6\xff\x4b\x31\x9b\xff\x06\x00\x00\xff\xff\xfd\x98\xef\x1a\x17\x10\x00\x00")
+var _complySoc2PoliciesRemoteMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x57\xcd\x8e\xdc\xc8\x0d\xbe\xf7\x53\x10\xc8\xc5\x1e\xf4\xf4\xc6\x7b\x48\x82\xde\x93\x33\x31\x10\x07\x48\x76\x30\xf6\x66\xcf\xd5\x25\xaa\xc5\x4c\xa9\x58\x21\x4b\xd3\x96\x4f\x7e\x8d\x00\xc9\xcb\xf9\x49\x02\x96\x4a\x6a\xb5\x67\xc6\x93\x9c\xfa\x47\xf5\xf3\x91\xdf\xc7\x8f\x54\x74\x3d\xee\xe1\x0e\x7b\xce\x08\x6f\xbd\x47\x55\xb8\xe5\x40\x7e\xdc\x38\x2f\x1c\xc7\x7e\x0f\x77\xef\xde\xde\x6e\xd4\x65\xd2\x96\x50\xf7\x1b\x80\x8f\x1f\x6e\xec\x03\xe0\x1a\x6e\x6e\x7e\xb7\x7b\xb3\xfa\xfe\xe3\xea\xfb\xef\x37\xbd\xfb\x07\xcb\x1d\x3e\x90\x12\xc7\xb2\xf5\x1a\x1a\x97\x71\x0f\x7f\x19\x22\xbc\x81\x1f\x7f\xfb\xe6\x0f\x65\x83\xe7\xbe\xc7\x98\xf7\xf0\x3e\x52\x26\x17\xa0\x61\x3f\xd8\x3f\x9b\xeb\xeb\xeb\xcd\xe6\x37\x70\x3b\x48\x62\x45\x70\xb1\x81\x0f\x9e\x13\x6e\x36\x6e\x07\x1f\x3b\x84\x54\x9f\x70\x0b\xb9\x23\x85\x54\xe0\x03\x29\x64\x86\x06\x5b\x8a\x08\x82\xff\x1c\x48\xd0\x0e\x54\x68\x59\xc0\x73\x8c\xe8\x33\xc5\xa3\xad\xca\x1d\x02\xcb\xd1\x45\xfa\xec\x32\x71\xfc\xfa\xe5\x5f\x0a\x3a\x6a\xc6\x5e\xcb\x8d\x11\xf3\x89\xe5\x5e\xa1\x15\xee\x41\xa6\x74\x75\xac\x59\xb7\x40\xd1\x87\xa1\xb1\x93\x12\x8a\x72\x74\x21\x8c\xd7\x7c\x8a\xd8\x40\x83\x0f\xe4\xb1\xac\x01\x96\x06\xc5\x2e\xeb\x29\x52\x4f\x9f\xd1\x12\xe1\x20\xb0\xea\x0f\xf8\x29\xb1\x0e\x82\xbb\x1a\xd4\x39\x0a\x97\x52\x20\x2c\xa1\xb8\x10\x60\x50\x14\x05\x6e\x81\x62\xcb\xd2\x17\xb0\x0b\xd0\x13\xe5\x8e\xe2\xa3\x60\xea\x81\x79\x4c\xe4\x0d\x5b\x05\x8c\x0a\xd8\xa7\xc0\x23\xe2\x14\xa3\xe7\x98\xc5\xf9\xcc\xa2\x5b\x70\x0a\x27\x0c\xc1\x3e\x5d\x1c\x01\x3f\x65\x94\xe8\x02\x24\x27\xb9\xe0\xe9\x5c\x06\xcf\x3d\x02\xc5\xcc\x96\xce\xec\x7c\x2e\x10\x2e\x12\xb7\xc6\x69\x8b\x84\x43\xc0\x06\x0e\xe3\x23\x98\xf0\xaa\x43\x41\x8a\xae\xcd\x28\x20\xd8\xa2\x08\x36\x25\x70\x85\xaf\x5f\xfe\x5d\x42\xff\xfa\xe5\x3f\xaf\x2f\x13\xd4\x0f\x9a\xe1\x80\xd0\xbb\xc6\x68\x76\x0d\x85\x11\x5c\x11\x32\x1d\x02\x5e\x24\x6e\x67\x42\xfa\xa3\xf3\xf7\x47\xe1\x21\x36\x8b\x82\x28\x66\x8c\xf9\x69\x01\x2d\x6c\x7d\x8b\xb7\x68\x64\x26\xce\x56\x36\xae\x77\x47\x54\x38\x75\xe4\x3b\xe8\xdd\x08\x82\x3a\x84\x3c\x69\xc6\xb6\x0f\xd1\x0d\xb9\x63\xa1\xcf\xd8\xcc\x22\x1a\x26\xe5\x0a\x2a\x0f\xe2\xf1\x42\x4f\x87\x21\x43\xe4\x0c\x81\x7a\xca\x25\x17\xfb\x92\x36\xd3\x8c\x6d\x52\x8c\x4a\x99\x1e\x70\x6b\x54\x24\x23\xca\x73\x6c\xa9\xc1\x38\xd5\x90\x09\xcc\xa8\xb5\x00\x43\x40\x9f\x07\xa3\x50\x38\xa1\xe4\xf1\xa7\x8a\xf8\xd9\x02\x48\xc3\x21\x90\x07\xb2\xb0\x5e\x5c\x6c\x57\x14\x85\x54\xf6\x7f\x2a\xf4\x5b\xf1\x99\x82\x9a\x1f\x58\x80\x73\x87\x02\x2d\x45\x17\xbd\xc1\x0b\xe4\x0e\x14\xa8\xe8\x89\xa2\x1f\x0a\xdf\x26\xb8\x39\x71\x16\xe2\xe0\xbb\x52\x23\xa8\x53\x71\xfc\x3a\x8b\x7c\x21\x6a\x5b\x72\xd2\x72\x08\x7c\xb2\x2a\x2c\x25\x4f\x06\x4c\x4b\xf9\x8c\xfb\x4d\xb1\x18\xda\xc1\xd5\x5f\xf9\x40\x01\x4b\xb6\x86\x52\xfc\xe6\x0b\xc9\x6c\x61\x7f\x75\xae\x8b\xc4\x92\xdd\x61\x59\x87\x56\x10\xfd\xb4\x33\x75\x1c\x8d\x24\xed\x9d\xe4\xe5\x57\x8f\x3d\xcb\x08\xde\x49\x53\xa2\xad\xa1\xd6\x3d\xcb\x1d\x56\xbe\x4d\xb1\x1f\xcd\x2c\xee\x88\x5b\x23\xa3\x48\x35\x1e\xcb\xbe\x2c\x2e\x6a\x8b\x62\xec\x9a\x3d\xec\xce\xd0\xab\x47\x9b\xe9\x18\x56\xad\xd6\xd6\x4c\x25\xfa\x84\x21\x6c\x21\x72\x63\x4c\xcd\xee\x35\x15\x2d\x69\xd1\xd4\x10\xcd\x8e\x1a\x12\xf4\x79\xae\x4c\xbb\xf5\x5b\x72\x57\x08\x3e\x62\x40\x73\xc1\xfd\x55\xc9\xb8\xd5\x3b\xb7\x30\xa8\xe5\xb1\x7f\x36\xb1\x25\xae\xb5\x63\x9a\x84\x12\x8a\xf9\x02\xd8\x71\xc0\x43\x56\x6a\x9e\xa9\xb0\xd4\x8d\x6a\xbe\x05\x49\xb0\x27\x45\xdd\xc1\x8c\xc3\xee\x6d\x18\xa7\x78\x2a\x79\xe5\x90\x5a\x53\x15\xd3\x44\x52\xa9\xfd\xda\xd9\x4c\x47\x57\x1f\xd0\x0f\x42\x79\x84\xbb\x6f\x7b\x43\xcd\xf4\x9f\x0b\x58\x43\x5f\x55\x73\xb3\x04\xf7\x6e\x0e\xee\x6a\xc9\xce\x8d\x1b\x0c\xf3\xe2\x47\xf8\x09\xc5\x93\xf1\x7d\xea\x30\x7e\x2f\x3f\x26\xe4\xe0\x3c\x36\x46\x55\x51\x08\x45\x18\xe2\xca\x2d\x35\x39\x8f\x3a\xd5\x82\x53\x78\xc0\x8e\x7c\x30\x15\xd6\x0a\x9d\x9e\x6f\xa1\xe3\x8c\x01\x84\xb9\x2f\x9a\xc4\x82\xb5\x9c\xad\xe6\x10\xb1\x45\xc1\xe8\x11\x3c\x5a\xb1\xea\x76\x25\xd5\x21\x26\xe1\x8c\xde\x6c\xc6\x09\x3a\x7d\x81\x95\x99\x8c\x25\xfe\x5f\x2d\xca\x49\x0b\x17\x64\x5b\xfe\x9e\x0f\x7e\x5b\x3b\x5a\xc9\x5a\x76\xf7\x68\x35\x84\x55\xa8\x2b\x45\x73\x2c\x38\xa6\x7e\x0a\xaf\x70\x77\xdc\x41\x43\x9a\x82\x1b\x2d\x6f\xd3\x53\xf5\x82\x18\x5f\x83\x77\xd1\x14\x71\x98\xfa\x81\xf5\x9a\x0b\xe3\x9d\xba\xb4\x02\xb5\xeb\x33\x49\xe1\x80\x86\xbe\x30\x30\xb5\x34\x9b\x10\x9e\x35\xbc\xb9\xcb\xb1\x4c\x2a\x2e\xad\xef\xc9\x95\x97\x85\xbc\xaa\xe3\x1a\xf7\x01\x61\x48\x36\x15\x35\x85\x90\xe4\xb2\xef\xaa\x4f\xd8\x79\xc1\x65\xd4\x0c\x3a\xeb\x75\x5a\xab\x16\xb5\xcb\x10\xd0\x69\x06\x07\x3d\xc7\xdc\x85\x11\x0e\x4e\x49\xbf\x77\x5b\xe7\x1e\x10\x30\x36\x89\x29\x66\xa8\xac\x9b\x72\x95\xdb\x7c\xb2\xec\x4f\xe9\xed\x5d\x28\xbf\xd4\xd2\x89\xf2\x1a\x28\x6a\x76\x45\x8f\x86\x72\x41\x9c\x4b\x7f\xcd\xd4\xaf\xd5\x70\x5b\x73\xfc\xa2\x39\x70\xdb\x5e\xcf\x95\x0d\x76\x9d\xa0\x26\x8e\x53\xef\xb6\x62\x14\x3c\x0e\xc1\x09\x1c\x9c\xbf\x1f\x52\x99\x7d\xd6\x09\x9e\xbb\x5c\x51\x8c\xa0\x29\x56\x67\x3d\x9c\xd9\x3d\x03\xab\x23\xee\x4b\xa4\xce\xbc\x34\x1c\x4d\x8d\xc2\xc3\xb1\x03\x17\x01\xa3\x97\x31\xcd\x4c\x99\xa8\xac\xd5\xfa\xc2\xdd\xdf\x6f\xff\xb6\x8c\x95\x1c\xa7\x51\xa8\x1f\x42\xa6\xeb\xb6\x4c\x55\xeb\xe5\x96\x6f\x8c\xd6\x63\x9a\x1d\xbc\x9d\xe7\x93\x3a\xa6\xae\x6a\x68\x1a\x64\x16\x38\x49\x78\x1a\xa5\xcd\x56\xec\xfc\x72\xa7\x60\x6d\xf8\x75\x3c\x7d\x32\xb2\x75\x35\x65\xf4\x5d\xe4\xc0\xc7\x11\x32\xba\x7e\x57\x8e\xb9\xc7\xf1\x7c\x91\x70\x9e\xd9\x9d\x04\x96\x4f\x56\x78\x09\x05\x46\x74\x62\x2a\x7e\xe0\x1a\x07\xb7\x8f\xf7\x57\x37\x6e\xa0\xce\xa3\x3f\xb7\xed\x81\x9d\x94\xa9\x66\x32\xe1\x1d\x2c\x9c\xbc\x5f\x41\xb3\xc6\x38\x55\xf4\x77\x44\x33\xdf\x72\x66\x63\xd2\x59\xe7\xa4\x81\x46\xe8\x01\xa1\x1d\x42\x30\x87\xb8\x9f\x17\xd9\x20\xfc\x92\xf1\xcf\x8d\xe5\xec\xea\xef\x96\x09\x79\xbe\x53\x13\x7a\x6a\xeb\x24\xbd\x72\x15\xdb\x9f\xeb\x7e\x8b\xfa\x24\x54\x50\xcf\x8c\x90\x40\x47\x62\xff\xf4\x2e\xba\x23\x0a\x9c\x45\xf9\x73\x0c\x63\x55\x6a\xe1\xca\xa9\xd2\xd1\xde\x1f\xec\x2d\x42\xac\xd7\x27\x94\x9e\xb2\x45\x9a\xd9\xb4\x32\xeb\xc3\x3a\xfc\x4b\x1e\xbb\x83\x5f\xd6\xf6\x37\x19\xee\xab\xb9\x99\x94\x49\x45\x21\xd0\x83\x61\xab\x66\x66\x5f\x5d\xae\xb3\x66\xa5\xf9\x64\xe3\xf9\x2a\x44\x9d\x9b\x38\x36\xaf\x4b\xe5\x9a\xe7\x3e\xc2\x59\xae\xa9\xaf\x41\xe7\x80\xff\x34\xfd\xb1\xc8\xe5\x02\x9e\x5d\xee\x51\x72\xc9\x72\x46\x5d\x76\xfd\x52\x90\xd7\x5b\x6d\xd5\x02\xe6\x29\xe3\x30\xf0\x2e\x25\xe1\x24\xe4\xb2\xb5\xde\xd8\xd2\x71\x90\x45\xb4\x73\x74\x61\x99\x8f\x96\xf1\xec\xf1\xdb\xe1\x7b\xeb\x98\x11\x73\x4d\x0b\xad\xc8\x9e\x33\xb4\xfb\x1f\x80\x96\x80\xab\xe7\x3e\xed\x40\x4f\x8e\xea\x20\x74\xec\xec\x5d\x13\xc9\xe8\x2a\x18\x17\xbb\x5e\x26\xeb\xde\x65\x94\x62\x03\xc5\x0d\x2d\x29\x49\x50\x6d\xc6\xe0\xf8\x7f\x29\x66\xf3\xdf\x01\x00\xfd\x98\xef\x1a\x17\x10\x00\x00")
 
 func complySoc2PoliciesRemoteMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -981,12 +1002,12 @@ func complySoc2PoliciesRemoteMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/remote.md", size: 4119, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/remote.md", size: 4119, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesRetentionMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x58\xcb\xae\xdc\xb8\xd1\xde\x37\xfe\x87\x28\xe0\xdf\xd8\x33\x3a\x3d\xf1\x20\x37\x9c\x20\x0b\x8f\x8f\x11\x38\x48\xe0\x03\xdb\x48\x36\xd9\x54\x93\xd5\xad\xb2\x29\x52\x21\xa9\xee\xa3\x81\x17\x7e\x8d\x00\x33\x2f\xe7\x27\x09\xaa\x48\xa9\xd5\x17\x3b\x9e\x04\x13\x20\xbd\xf0\x91\x29\x91\xac\xcb\x57\xdf\x57\xa4\xc7\x8e\x6e\xe1\x0e\x33\xc2\x2b\xca\xe4\x33\x07\x0f\xf7\xc1\xb1\x19\x57\x68\x62\xf0\x63\x77\x0b\xaf\xee\x57\x09\x33\xa7\x2d\x53\xba\x5d\x01\xbc\x79\xfd\x4c\xfe\x00\xdc\xc0\xb3\x67\x4f\xd6\xdf\xce\xcf\xbf\x5e\xff\xaa\x3e\xdf\xff\x72\xfd\xed\xaa\xc3\xb7\x21\xbe\xa2\x3d\x27\x0e\x5e\x67\xde\x80\xc5\x4c\xb7\xf0\xc7\xc1\xc3\x13\xf8\xf6\x17\x4f\x7e\xab\xdf\x9b\xd0\x75\xe4\xf3\x2d\xbc\xf0\x9c\x19\x1d\xd8\x60\x06\x19\x59\xdd\xdc\xdc\xac\x56\xff\x0f\x4f\xfb\x9e\xbc\x65\x43\x69\x55\x1f\x1f\xe0\xe9\xed\xd2\x64\x8a\x1c\x6c\x92\x6f\xef\x87\xd8\x87\x44\x80\xde\xc2\x6b\x13\x7a\x5a\xad\x70\x0d\x6f\x5a\x4e\xb2\x39\x42\x9c\x27\xf5\xea\x27\x58\xda\xb2\xa7\x04\xb9\x25\x08\x9b\xb7\x64\x32\xef\x29\xe9\xfc\x48\x7f\x1f\x38\x92\x98\x92\x60\x1b\xe2\xf9\x0a\x07\xce\x2d\xfb\x32\x33\xee\xd0\xf3\xf7\x28\xe3\xeb\xe3\x96\x75\x0f\x13\xf6\x14\x13\xa0\x73\x65\x89\x4f\x4c\xfc\xf8\xe1\x1f\x09\xcc\x90\x72\xb0\x23\x84\x08\x26\xf8\x1c\x83\x6b\x20\xd2\x0e\xa3\x75\x94\x12\x84\xad\x4e\xeb\xc8\xf2\xd0\xe9\xa3\xae\xc8\x09\x52\x0e\x91\x2c\xb0\x87\x47\xe4\xc8\xe4\x18\x3c\x1b\x31\xbb\x6b\xa0\xc7\x9e\x62\x7d\xa6\x6c\xd6\x8f\xe1\xaf\x93\x09\xb3\x95\xcd\x72\xe1\x43\xcb\xa6\x85\x36\x38\x9b\xe6\x0d\x22\x6d\x29\xca\x16\x39\x00\x26\x60\x2f\xeb\xa9\xe1\x0d\xf8\x00\x1d\xe6\x4c\x11\x0e\x2d\x66\xdd\x09\x38\xcb\x2c\xbe\x12\x0f\xec\x7b\xc7\x12\xf3\xa0\x31\x19\x92\x44\x27\x6c\x97\x4b\x42\x1a\x53\xa6\x2e\x7d\x32\xc8\x65\xc1\x3c\xf6\x6c\xd0\xb9\x11\xd8\x1b\x37\x58\x4a\x40\x5d\xef\xc2\x48\x35\x87\x1a\x43\x34\x39\xc4\xd4\x88\xd5\x07\x72\x4e\xfe\xa2\x1f\x81\x1e\x32\x45\x8f\x0e\x7a\x8c\x59\xed\x11\xdb\x4d\xe8\x08\xd8\xe7\xa0\x73\xd1\x64\x35\x61\xb6\x47\x16\x5d\xda\x79\x6e\x18\x84\x83\x4f\x8b\xec\x25\x78\xd4\x52\x24\xf6\xb8\x95\xf0\x9c\x05\xf1\xe3\x87\x1f\xd4\xfb\x8f\x1f\x7e\x7c\x7c\x1a\xa3\x6e\x48\x19\x36\x04\x1d\x5a\x82\x48\x68\xd9\x8d\x80\x7b\x64\x87\x1b\x47\x27\xa1\x5b\x0b\xf0\xbf\x43\xf3\x6e\x17\xc3\xe0\x6d\x0d\xf7\x99\x59\x9c\x60\x23\x6f\x61\x23\x6b\xbb\xcc\xbd\x23\x70\xb4\xc3\x82\xaf\xc1\x61\x0e\x71\x3c\x89\xd9\x80\x0e\xc2\xc6\xf1\x4e\x57\x28\xa9\xa8\x58\x94\xfd\x8f\xe0\xcb\x52\x14\xc8\x3e\xe9\xbe\x89\x4e\x66\xa5\xcc\xbd\xac\x4e\xd0\x86\x03\xb8\xe0\x77\x65\x96\x41\x2f\xfe\x95\x99\x64\x1b\xdd\x5a\x3e\xd1\xb7\x93\xfb\x96\x52\x8e\x61\x24\xbb\x86\xe7\x0f\xd8\xf5\x8e\x14\x29\x3f\xcd\xf0\x8a\x0d\x70\x78\x98\x2a\x5b\xe7\xd5\x97\xea\x89\x0b\x06\x1d\xbc\x1d\x22\x27\xcb\xa6\xd4\xb7\x24\xee\x32\xc1\x26\x78\x3b\x98\x9c\x60\x33\x24\xa1\x8e\xd4\x9c\x6c\x9e\x4a\xca\x34\x58\x33\x16\x9b\x52\xd7\x1d\x09\x0c\x13\xc5\x3d\x1b\x82\x3e\x86\x3d\x5b\x1d\x12\x04\x7a\xe5\x08\x6f\x21\xe4\xb6\x64\xf5\x5a\x1e\x3b\x1c\x01\x5d\x0a\x12\x1c\xf6\xfb\xe0\xf6\xa5\xe8\x69\xaf\x34\x95\x06\xd3\x0a\xb0\x1c\xe7\xea\xbe\x60\xd1\x72\xc2\x54\xe0\xa7\x5c\x34\x42\x32\xe4\x31\x72\xa8\xa0\xaf\x54\x27\xa9\xcc\x01\x5a\xdc\x13\xa0\x31\x42\x38\x39\x40\x88\xbc\x63\xa9\x93\x25\xee\x59\x16\xb6\x14\xe5\x83\x3e\x86\x4c\x26\x5f\x27\x34\xf6\x99\x22\xa5\xac\x45\x91\x5b\x21\x67\x29\xf5\x9c\xfe\xb3\xe8\xc0\xd3\x04\x42\xc6\x69\x70\xb9\xb9\x4c\x92\x84\xc9\x53\xad\xb3\x68\x5a\xde\x17\x4d\x50\x96\x3c\x71\x44\x88\x5d\x50\x29\xae\x48\x28\x38\xeb\xe4\x0d\xe9\x7c\xb2\x95\xf9\xc7\x9b\x1c\x6e\x2c\x8e\x10\x7a\x8a\x05\x3a\xeb\x15\xac\xe0\x6f\x3d\xee\x68\x13\x09\xdf\x81\xea\x4f\x51\x4f\x49\xdd\x57\x2f\x16\xbb\xcc\x62\xf5\xd5\x4a\x45\x8f\xd7\x0b\xfd\x12\x71\x52\x19\xb2\x92\x3a\xa5\x61\x94\xb0\x79\xf4\x86\xce\x89\x91\x3d\xa0\x04\x47\x30\x58\xd3\xeb\xc4\x3b\xf2\x7b\x8e\xc1\x8b\x5a\x55\xfa\xae\x15\x56\x12\x49\x5a\xf9\xe8\x01\x87\xdc\x86\xc8\xdf\x93\x55\xf6\x80\x99\x5c\x2d\x7b\x8c\x22\x56\x43\x2c\x39\x9a\xf0\xbd\x86\xd9\xe6\xa5\x4b\x43\x2a\xc8\x53\x1e\xa0\x3d\xb9\xd0\xcb\xe6\x0d\xa4\x8c\x3b\xf6\xbb\x52\x16\x99\x52\x66\xbf\x93\xf5\x26\x0a\x4d\xad\x50\x97\x0f\x79\x59\xff\xb0\xa1\x31\xc8\xf7\x2d\x71\x04\x54\x19\x96\x2d\xa0\x57\x71\x07\xaf\x94\xda\xb3\xee\x59\x30\xf7\x99\x08\x48\xf5\x54\x93\xbf\x53\x8d\xc7\x19\x26\x8b\x0e\xa0\xac\x7c\x2e\x3c\x6a\x9d\xc4\xcd\x5f\x31\x23\x6c\x81\x1e\xd0\x64\x37\x42\x3e\x04\x18\x09\x63\x82\x6d\x0c\x9d\x22\xda\x44\xaa\xb1\xf1\xaa\xd6\xe8\x81\x1e\x0c\xf5\x53\x96\xc3\xa6\x3a\xdb\x53\xec\x38\x6b\x60\x70\x42\x5f\x88\x90\xda\x10\x4b\x99\x9e\xda\x58\x78\x60\xca\x07\x0c\x5e\x29\x37\xf5\xc1\x27\x16\x35\xd8\x6a\x69\x9d\xe2\x5a\x19\x54\xea\x9a\x52\xa9\xcc\xd9\x92\x63\x6c\x9e\xaa\x26\xc9\xcb\xab\x9e\x9e\xc0\x2e\x81\x30\x87\xc2\xcf\x98\x10\xad\x62\x53\x59\x2e\x2f\xa5\xdd\x5b\x91\xf7\x18\x84\x96\xe6\x1d\x53\x73\x69\x9a\x44\xb8\x14\x66\x29\x31\x9c\x6b\x60\xf2\xf9\xa5\xec\x50\xd0\x55\x8b\xa3\x16\x72\xb5\xb0\x9a\xd4\x5c\x77\xfd\x44\x3c\x66\x8f\x9f\xa3\x69\xcf\x02\xa9\xad\xcd\xe7\x63\xc9\xb9\xa4\x56\xa8\x6a\x48\xf2\xaf\xf2\x88\x50\x53\x0c\x5a\x5d\x85\x9b\xea\x86\xa2\x08\x1a\x23\xc9\x6f\x55\xca\x93\x66\x52\xbb\xb8\x39\x68\x25\xbb\x4b\x1b\x2e\x0c\x34\xf2\xc6\x52\x6d\x1c\x36\x74\x61\x61\x38\x78\x8a\x47\x37\xcf\x65\x43\x99\x58\x45\x53\xea\xdb\x27\x72\x4a\x72\x9c\xd2\x40\x82\xc0\xa3\x5a\x48\xcf\x27\x7b\x1c\x91\x23\x9c\xb8\xd8\x29\x92\x28\x67\x71\xac\x0f\x8a\x53\x91\x5b\xad\x59\x11\xde\xe3\x5a\x0d\x60\xdc\x70\x8e\xb3\x0c\x29\x75\x83\x71\xc8\x5d\x6a\xc0\x52\x87\xde\xca\x03\xa7\x7e\xc8\xa4\x02\xb1\x54\xf4\x52\xdd\x4b\x8e\xb8\x02\x3e\xf6\x29\xc7\x42\x04\xb5\x10\x55\xcd\x97\xae\x9e\x65\x7f\x92\x9d\xb3\xfe\x10\x70\xbb\x65\xc7\x98\xc9\x4e\xb0\x26\x69\x06\x7b\x69\x15\x6b\x31\xe5\x21\xfa\x73\x32\x2e\x84\xd5\x07\x01\x41\xaa\x8e\xd6\xde\x6f\xca\xfd\x89\x32\x0d\xbd\x90\x0c\xf5\x58\xe3\x82\xde\x7e\xa3\x8e\xe7\x0a\x8f\xf5\x55\xb6\xd5\x04\xa7\x62\x08\xc9\x78\xad\x8d\x99\x2a\x9a\x5a\x1e\xca\x29\x13\x16\x27\x86\xdc\x9e\xb6\xeb\xc5\xf3\xae\x1b\x3c\x1b\xe9\xcc\xb2\x76\x6d\xa5\xac\x52\xc9\xbe\xa3\x3d\xfa\x3c\xb5\xc6\xeb\x4b\x45\x7b\x3a\xed\x76\x54\xb4\x79\xe8\x4c\xd1\x12\x99\x41\xa0\x2b\x45\x83\xbb\x0b\x45\xd3\x9e\x45\x71\x76\x51\x77\x52\x9a\xbe\xe0\x9e\x7d\x11\xb2\x52\x20\xe3\xb9\x8e\xa5\x2f\x10\x32\xd8\x0c\x79\xd1\x77\x46\xa6\xfd\x51\x16\x6d\xc7\x9e\x93\xa0\x55\xf4\x9e\x12\xef\xbc\x62\x61\x33\x1e\x51\x80\x1e\x77\xcb\x1c\xc9\xef\xc9\x1a\xee\xdb\x31\xc9\x31\x04\x1e\xd1\x7a\xb7\xae\xc7\xad\xc7\xda\x6c\x45\x9b\x2e\xe9\x8e\xfd\x45\x48\x1e\x49\x81\x67\xd2\x2a\xd9\x6e\xe5\xf1\x71\xc9\x92\x23\x8c\x6e\x04\x87\x1b\x72\xb5\x02\x2a\x0b\x6e\xc2\x03\x25\xf0\xd8\x69\x31\x7e\x86\x0f\xaa\x95\xcf\x8f\xa7\xc2\x4f\x5a\x56\x8e\x3a\x39\xb2\xc9\x53\x03\x38\x9f\x63\x12\x65\x09\xc6\xd5\x9d\x8e\xbc\xdf\x47\x56\x44\x85\xea\x62\x2d\x23\xbf\x65\x5b\xa8\x82\xf3\xd8\x68\x53\xb8\x8b\x9c\xab\x60\xd4\xc4\xb2\xbc\x9c\x8e\xb8\x8b\x3d\x4e\x79\xad\xaa\xf9\x02\xee\xff\x42\xc8\x7f\x53\x65\xfa\x28\xca\x97\x0a\x55\x15\x39\x5d\x95\xe3\x49\x90\x9e\xcf\x7a\x36\x47\xae\xd2\x64\xc1\xc9\x17\x24\x41\xbb\xd6\xdd\xc0\x96\x1c\x7b\x6a\xd4\x98\x2b\x7e\x74\x41\x23\x87\x7e\x36\xbe\xf6\xa4\xbb\x88\x3e\x57\xc5\x5c\x6e\xa5\x89\x43\xd8\x73\x46\x07\x2d\x0b\xac\x14\x91\x7d\xbd\x0f\x99\xce\x05\x26\xc4\x3e\x44\xc9\x50\xc5\x40\x73\x3c\xb8\x4c\x0d\x9b\x69\x85\x16\xdc\x37\x39\xca\x41\x26\x91\x89\x34\x37\x54\x3f\xcd\x0d\x8d\xf7\xbf\xe1\x86\xe3\x8e\x35\xa6\x53\xe1\x9e\xbb\x41\x1d\xb2\x6b\x20\x47\xdc\x93\x03\xce\xec\x29\x62\xe4\xa2\xc8\x74\x93\x23\xf7\x80\x76\xcf\x29\x94\xc1\xa0\xe7\x14\xa9\x63\x37\x56\x90\xf7\x64\x78\xcb\x66\x3a\x4a\x2e\xcf\x8e\x33\x25\xcf\x5a\x74\xa2\xde\xb5\xca\xef\x9f\xbd\x80\xbb\xd7\xaf\x1b\xf8\xc3\xdd\xfd\xab\x7a\xaf\x72\x95\xba\x2f\xda\x11\x78\x34\x91\xe3\x86\x5c\x38\x3c\x86\x4a\x7d\xe4\xed\x84\x7e\x72\xd8\xa7\xb9\xe5\x39\x46\x75\x0d\x70\x49\xc5\x77\x47\xaa\x3f\x92\xf1\x62\xf0\xca\x01\xa3\x9f\x28\x4b\x22\x33\x65\xfc\x44\x33\xd2\xb0\xdd\xb2\x61\x39\x4e\xa8\x20\x78\x4b\x97\x8d\x91\x5e\x91\x4c\xd2\xac\xad\x5a\xbd\xbb\x03\x8e\x95\x61\xb1\xf2\xf5\x82\x97\xbb\x8e\xa2\x61\x74\x6e\xbc\x39\xde\x68\x74\x84\x3e\x7d\xba\x83\x29\x51\xd4\x73\x11\xb2\xaa\xe6\x2c\x83\xd2\x39\x66\x64\xa1\x47\xc7\x29\x4b\x08\xe7\x02\x5f\x7a\xd4\x51\x6e\x45\xdf\x96\x3c\x25\x00\x24\xe9\x0a\xf2\xd8\x5f\x08\xd3\x44\x8b\x0d\x1c\x5a\xd2\xde\x85\xfd\x31\x72\x13\x75\x77\x64\x19\x67\x64\x3e\xbb\xbb\x79\xf5\xf2\xcf\xa9\x81\xbb\xbf\xdc\xa5\x06\x36\x68\xde\x0d\x3d\x64\xec\x05\x86\x2d\x46\x0b\x36\xf2\x5e\xfe\xd3\x85\x0d\x3b\x3d\x3a\xb1\x51\xe0\x86\x98\x35\x12\xe5\x03\xd0\xd2\xd0\xdb\x90\x0d\xa6\xb9\x5e\x65\xb8\x2e\xba\x65\x47\x69\xa1\x3e\x67\x8d\xc9\xf1\xd2\x6f\x46\x60\x6a\x23\x59\xab\x8a\x59\xda\x84\xa5\x8a\x96\x97\x32\x5e\x4c\xfa\x1d\x1c\x30\x65\x9a\x27\x17\x00\xd6\xeb\xb6\x48\x5d\x98\xc4\x73\x8a\x75\x4f\x31\x05\xef\xb5\xe1\xc2\xe5\xf9\x56\x39\x5e\x63\x29\x8c\x54\xbb\x8c\xf3\xd2\x2f\x8d\x66\x3d\x43\x49\xcc\xa7\xd6\xda\x36\xd3\xdd\xa6\xac\x33\xa4\x23\xd7\x9e\x5f\x70\x4d\x18\x67\x0f\xc7\x8b\xe2\x06\x3e\x7e\xf8\xe1\xe2\xae\x78\xfd\xf1\xc3\x8f\xb0\xfa\xbf\xd5\xd9\xf1\xfd\xb3\xf7\xcb\xb0\x5a\x7d\x7d\x73\xf1\xbb\x32\x74\x6d\xec\x8b\x86\xbe\x5e\xbd\x3f\x61\x8e\x37\x02\xca\xd3\xa1\x97\x2a\xb7\xef\xe1\x75\x45\xdf\x9f\x82\x29\x2f\xde\x5f\x18\x0c\xef\x6b\x47\x86\x6e\x1e\x59\x7d\xfd\xfb\x8b\xdf\x95\xa1\x6b\x63\x5f\x34\x24\x1e\x5c\xfc\xae\x0c\x5d\x1b\xfb\xa2\xa1\xf7\x3f\xff\x06\xff\x85\x24\xff\xcc\x1e\xfc\xcf\x87\xe8\x9f\x01\x00\x00\xff\xff\x8c\xe2\x45\x23\x9a\x1a\x00\x00")
+var _complySoc2PoliciesRetentionMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x58\xcf\x8e\xe4\xb6\xf1\xbe\x37\x7e\x0f\x51\xc0\xef\xb2\x6b\xab\xdb\x59\x23\xff\xb0\x81\x0f\xeb\x9d\x45\xe0\x20\x81\x07\xbb\x46\x72\xc9\xa5\x9a\xac\x6e\x95\x4d\x91\x0a\x8b\xea\x1e\x19\x7b\xd8\xd7\x08\x60\xbf\xdc\x3e\x49\x50\x14\xa5\x96\x5a\x3d\xf6\xc4\x41\x6e\xe9\x39\x8c\x40\x89\x64\xfd\xf9\xea\xfb\x8a\xf4\xd8\xd0\x4b\xb8\xc3\x84\xf0\x96\x12\xf9\xc4\xc1\xc3\x7d\x70\x6c\xfa\x0d\x9a\x18\x7c\xdf\xbc\x84\xb7\xf7\x1b\xc1\xc4\x72\x60\x92\x97\x1b\x80\x6f\xde\xbd\xd6\x7f\x00\x5b\x78\xfd\xfa\xc5\xee\xf3\xe9\xf9\xb7\xbb\xdf\x94\xe7\xfb\x5f\xef\x3e\xdf\x34\xf8\x6d\x88\x6f\xe9\xc4\xc2\xc1\xe7\x99\x5b\xb0\x98\xe8\x25\xfc\xa9\xf3\xf0\x02\x3e\xff\xd5\x8b\xdf\xe7\xef\x4d\x68\x1a\xf2\xe9\x25\x7c\xe5\x39\x31\x3a\xb0\xc1\x74\x3a\xb2\xd9\x6e\xb7\x9b\xcd\xff\xc3\xab\xb6\x25\x6f\xd9\x90\x6c\xca\xe3\x03\xbc\x7a\x39\x37\x99\x22\x07\x2b\xfa\xed\x7d\x17\xdb\x20\x04\xe8\x2d\xbc\x33\xa1\xa5\xcd\x06\x77\xf0\x4d\xcd\xa2\x9b\x23\xc4\x69\x52\x9b\xfd\x04\x4b\x07\xf6\x24\x90\x6a\x82\xb0\xff\x96\x4c\xe2\x13\x49\x9e\x1f\xe9\x1f\x1d\x47\x52\x53\x04\x0e\x21\x5e\xaf\x70\xe6\x54\xb3\x1f\x66\xc6\x23\x7a\xfe\x1e\x75\xe5\xdd\x65\xcb\xb2\x87\x09\x27\x8a\x02\xe8\xdc\xb0\xc4\x23\x13\x3f\x7e\xf8\xa7\x80\xe9\x24\x05\xdb\x43\x88\x60\x82\x4f\x31\xb8\x0a\x22\x1d\x31\x5a\x47\x22\x10\x0e\x79\x5a\x43\x96\xbb\x26\x3f\xe6\x15\x59\x40\x52\x88\x64\x81\x3d\x3c\x23\x47\x26\xc5\xe0\xd9\xa8\xd9\x4d\x05\x2d\xb6\x14\xcb\x33\x25\xb3\x7b\x0e\x7f\x1b\x4d\x98\xac\xac\xe6\x0b\x9f\x6b\x36\x35\xd4\xc1\xd9\x12\x37\x16\x88\x74\xa0\xa8\x5b\xa4\x00\x28\xc0\x5e\xd7\xcb\x86\x57\xe0\x03\x34\x98\x12\x45\x38\xd7\x98\xf2\x4e\xc0\x09\x58\x3f\x5b\xc7\x03\xdb\xd6\xb1\xc6\x3c\xe4\x98\x74\xa2\xd1\x09\x87\xf9\x92\x20\xbd\x24\x6a\xe4\xd1\x20\x0f\x01\x4e\x7d\xcb\x06\x9d\xeb\x81\xbd\x71\x9d\x25\x01\x6a\x5a\x17\x7a\x2a\x39\xcc\x31\x44\x93\x42\x94\x4a\xad\x3e\x93\x73\xfa\x1f\x7d\x0f\xf4\x90\x28\x7a\x74\xd0\x62\x4c\xd9\x1e\xb5\xdd\x84\x86\x80\x7d\x0a\x39\xfe\x68\x52\x36\x61\xb2\x47\x81\x31\xb7\xf3\xda\x30\x08\x67\x2f\xb3\xec\x09\x3c\xab\x29\x12\x7b\x3c\x68\x78\xae\x82\xf8\xf1\xc3\x0f\xd9\xfb\x8f\x1f\x7e\x7c\xbe\x8c\x51\xd3\x49\x82\x3d\x41\x83\x96\x20\x12\x5a\x76\x3d\xe0\x09\xd9\xe1\xde\xd1\x22\x74\x3b\x05\xfe\x97\x68\xbe\x3b\xc6\xd0\x79\x5b\xc2\x7d\x65\x16\x0b\xec\xf5\x2d\xec\x75\x6d\x97\xb8\x75\x04\x8e\x8e\x38\xe0\xab\x73\x98\x42\xec\x17\x31\xeb\xd0\x41\xd8\x3b\x3e\x66\x4f\x87\x54\x14\x2c\xea\xfe\x17\xf0\x25\x2d\x2b\x64\x2f\xea\x02\x09\x2d\x66\x49\xe2\x56\x57\x27\xa8\xc3\x19\x5c\xf0\xc7\x01\x51\x06\xbd\xfa\x37\xcc\x24\x5b\xe5\xad\xf5\x93\xfc\x76\x74\xdf\x92\xa4\x18\x7a\xb2\x3b\x78\xf3\x80\x4d\xeb\x28\x23\xe5\xdf\x33\xbc\x60\x03\x1c\x9e\xc7\xca\x3e\x76\x6e\x7a\x99\x81\xef\x82\x41\x07\xdf\x76\x91\xc5\xb2\xd1\x79\x70\xd6\xc4\xad\x13\x6c\x82\xb7\x9d\x49\x02\xfb\x4e\x94\x3a\xa4\x5a\x6c\x2e\x43\xca\x72\xb0\x26\x2c\x56\x43\x5d\x37\xa4\x30\x14\x8a\x27\x36\x04\x6d\x0c\x27\xb6\x79\x48\x11\xe8\xb5\x0a\xd4\xba\x90\xea\x21\xab\xb7\xf2\xd8\x60\x0f\xe8\x24\x68\x70\xd8\x9f\x82\x3b\x0d\x45\x4f\xa7\x4c\x53\xd2\x99\x5a\xf1\xed\x38\x15\xf7\x15\x8b\x96\x05\x65\x80\x5f\xe6\xa2\x1e\xc4\x90\xc7\xc8\xa1\x80\xbe\x50\x9d\xa6\x32\x05\xa8\xf1\x44\x80\xc6\x28\xe1\xa4\x00\x21\xf2\x91\xb5\x4e\xe6\xb8\x67\x0f\x21\x5a\x8a\x8a\x84\x36\x86\x44\x26\xad\x42\x95\x09\x8d\x7d\xa2\x48\x92\x72\x51\xa4\x5a\xc9\x59\x4b\x3d\xc9\x7f\x16\x1d\x78\x25\xa0\x64\x2c\x9d\x4b\xd5\x3a\x49\x1a\x26\x4f\xa5\xce\xa2\xa9\x59\x3d\xf2\x76\x60\xc9\x85\x23\x4a\xec\x8a\x4a\x75\x45\xeb\x9f\x53\x9e\xbc\xa7\x3c\x9f\x6c\x61\xfe\x7e\x9b\xc2\xd6\x62\x0f\xa1\xa5\x98\xbd\x93\xdd\x06\x36\xf0\xf7\x16\x8f\xb4\x8f\x84\xdf\x81\x96\x61\x51\x4f\x2d\xc1\x4f\xbe\x9a\xed\x32\x89\xd5\x27\x9b\x2c\x7a\xbc\x9b\xe9\x97\x8a\x53\x96\x21\xab\xa9\x53\x5f\x1a\xd4\xb0\x79\xf4\x86\xae\x89\x91\x3d\xa0\x42\x47\x31\x58\xd2\xeb\xd4\x3b\xf2\x27\x8e\xc1\xab\x5a\x15\xfa\x2e\x15\x36\x24\x92\x72\xe5\xa3\x07\xec\x52\x1d\x22\x7f\x4f\x36\xb3\xc7\x88\xff\x10\x2d\x7b\x8c\x3d\x98\xd0\xc5\x21\x47\x23\xbe\x77\x30\xd9\x3c\x77\xa9\x93\x01\x79\x3a\xdb\xd2\x89\x5c\x68\x75\xf3\x0a\x24\xe1\x91\xfd\x71\x28\x8b\x44\x92\xd8\x1f\x75\xbd\x91\x42\xa5\x56\xea\xf2\x21\xcd\xeb\x1f\xf6\xd4\x07\x6f\x35\x93\x1c\x01\xb3\x0c\xab\x81\xd0\x66\x71\x07\x9f\x29\xb5\xe5\xbc\x67\x0a\x3f\x13\x01\xd9\x4d\x26\x7f\xd9\x6b\x70\x71\x82\xc9\xac\x03\x18\x56\xbe\x16\x9e\x6c\x9d\xc6\xcd\xdf\x30\x23\x1c\x80\x1e\xd0\x24\xd7\x43\x3a\x07\xe8\x09\xa3\xc0\x21\x86\x26\x23\xda\x44\x2a\xb1\xf1\x59\xad\xd1\x03\x3d\x18\x6a\xc7\x2c\x87\x7d\x71\xb6\xa5\xd8\x70\xca\x81\xc1\x11\x7d\x21\x82\xd4\x21\x0e\x65\xba\xb4\x71\xe0\x81\x31\x1f\xd0\xf9\x4c\xb9\xd2\x06\x2f\xac\x6a\xa0\x10\xd5\x34\xcc\xfd\xc8\x0c\xaa\x75\x4d\x32\x54\xe6\x64\xc9\x25\x36\xaf\xb2\x26\xe9\xcb\x9b\x9e\xce\x97\x63\x01\x65\x0e\x4d\x38\x1a\x13\xa2\xcd\xd8\xcc\x2c\x37\x6b\x22\x72\xca\xb1\xd5\xda\x25\x7b\xd9\x51\xaa\xb5\x69\x1a\xe1\xa1\x30\x87\x12\xc3\xa9\x06\x46\x9f\xbf\xd6\x1d\xd4\xb8\xa9\x38\x4a\x21\x17\x0b\x8b\x49\xd5\x6d\xd7\x17\xe2\x31\x79\xfc\x06\x4d\x7d\x15\x48\x96\x9f\x8d\x25\xa7\x21\xb5\x24\x95\x86\x48\x39\x5c\x79\x44\xa9\x29\x86\x5c\x5d\x03\x37\x95\x0d\x55\x11\x72\x8c\x34\xbf\x45\x29\x17\xcd\x64\x38\xcc\x83\x36\x64\x77\x6e\xc3\xca\x40\xa3\x6f\x2c\x95\xc6\x61\x4f\x2b\x0b\xc3\xd9\x53\xbc\x24\xf6\x5a\x36\x32\x13\x67\xd1\xd4\xfa\xf6\x42\x2e\x93\x1c\x8b\x74\x04\x38\x57\x0b\xed\xf9\x94\x34\x2f\xc8\xc1\xb4\xd8\x29\x92\xc3\x54\x1c\x6b\x43\xc6\xa9\xf6\x09\x11\x8a\xf0\x5e\xd6\xaa\x00\xe3\x9e\x53\x9c\x64\x28\x53\x37\x18\x87\xdc\x48\x05\x96\x1a\xf4\x56\x1f\x58\xda\x2e\xa9\xb0\xc7\x85\xa2\x0f\xd5\x3d\xe7\x88\x1b\xe0\x63\x2f\x29\x0e\x54\x58\x0a\x51\x43\xb3\x70\xf5\x12\x96\x9c\xfd\x51\x76\x16\xaa\x1d\x22\xe0\xe1\xc0\x8e\x31\x91\x1d\x61\x4d\x60\x42\xd3\x6a\xab\x58\x8a\x29\x75\xd1\x2f\x82\x31\x90\x27\x47\x68\x83\x82\x40\x8a\xa3\xa5\xf7\x1b\x73\xbf\x50\xa6\xae\x55\x92\xa1\x16\x4b\x5c\xd0\xdb\xcf\xb2\xe3\xa9\xc0\x63\x77\x93\x6d\x73\x82\x65\x28\x1d\xd2\x74\x18\x5a\xd2\x59\x55\xca\x43\x53\x33\x61\x71\x64\xc8\x45\x2d\x8f\xfd\x4a\xd3\x74\x9e\x8d\x76\x66\xa9\xa6\xa9\xf0\xb3\xe4\x47\x72\x74\x42\x9f\xc6\xd6\x78\xb7\x56\xb4\x57\xe3\x6e\x17\x45\x9b\x86\xae\x14\x4d\xc8\x74\x0a\x5d\x15\x5f\x3c\xae\x14\x2d\xf7\x2c\x19\x67\xab\xba\xd3\xd2\xf4\x03\xee\xd9\x0f\x42\x96\x8b\x74\xdf\x5f\xeb\x98\x3c\x41\xc8\x60\xdf\xa5\x59\xdf\x19\x99\x4e\x17\x59\xb4\x0d\x7b\x16\x45\xab\xea\x3d\x09\x1f\x7d\xc6\xc2\xbe\xbf\xa0\x00\x3d\x1e\xe7\x39\xd2\xbf\x17\x3b\xb8\xaf\x7b\xd1\x63\x08\x3c\xa3\xdd\x71\x57\x8e\x5b\xcf\x73\xb3\x15\xad\xac\xe9\x8e\xfd\x2a\x24\xcf\xb4\xc0\x93\x42\x05\xc2\xe1\xa0\x8f\xcf\x87\x2c\x39\xc2\xe8\x7a\x70\xb8\x27\x57\x2a\xa0\xb0\xe0\x3e\x3c\x90\x80\xc7\x46\x03\xfe\x53\x7c\x50\xac\x7c\x73\x39\x15\x3e\x6a\x59\x06\xbe\xa4\xc8\x26\x8d\x0d\xe0\x74\x8e\x11\x4a\xda\x42\xdc\xdc\xe9\xc2\xfb\x6d\xd4\x12\x52\x0c\x0d\x2e\x96\x32\xf2\x07\xb6\x8a\x53\x74\x9c\x7a\x55\x83\x44\xc7\xc8\x49\x3b\x12\x5b\x76\xe2\x3d\xeb\xcb\xf1\x88\x3b\xdb\x63\xc9\x6b\x45\xcd\x8b\xd1\xea\x7c\xd1\x83\xc7\x84\xfc\x77\x45\xa6\x2f\xa2\xbc\x56\x28\x55\xbd\x46\x25\xfc\x96\x1c\x8f\x82\xf4\x66\xd2\xb3\x29\x72\x85\x26\xc9\x3e\x1a\x9a\x65\x12\x72\xd7\x7a\xec\xd8\x92\x63\x4f\x5a\x89\x37\xfd\x68\x42\x8e\x1c\xfa\xc9\xf8\xd2\x93\x1e\x23\xfa\x54\x9a\xd2\xf9\x56\x39\x71\x08\x27\x4e\xe8\xa0\x66\x85\x55\x46\x64\x5b\xee\x43\xc6\x73\x81\x09\xb1\x0d\x51\x33\x54\x30\x50\x5d\x0e\x2e\x63\xc3\x66\x6a\xa5\x05\xf7\x59\x8a\x7a\x90\x11\x32\x91\x92\xfc\x22\x37\x72\xbc\x7f\x81\x1b\x8e\x1b\xd6\xd7\x53\xe1\x5e\xbb\x41\x0d\xb2\xab\x20\x45\x3c\x91\x03\x4e\xec\x29\x62\x64\xd5\xe7\x36\xd2\x36\x45\x6e\x01\xed\x89\x25\x0c\x83\xaa\xe9\x7a\xa0\x6f\x5a\xd7\x97\xf3\x7c\x4b\x86\x0f\x6c\xc6\x33\xf0\x18\x05\x95\xb0\x89\x92\x27\x2d\x5a\xa8\x77\xa9\xf2\xfb\xd7\x5f\xc1\xdd\xbb\x77\x15\xfc\xf1\xee\xfe\x6d\xb9\x57\xb9\x49\xdd\xab\x76\x04\x9e\x8d\xe4\xb8\x27\x17\xce\xcf\xa1\x50\x1f\xe9\xe1\x6f\x40\x3f\x39\x6c\x85\xec\x2a\xaa\x3b\x80\x35\x15\xdf\x5d\xa8\xfe\x42\xc6\xb3\xc1\x2b\x3a\xd6\xf5\xdb\x91\xb2\x42\xbc\x64\x7c\xa1\x19\xd2\x1d\x0e\x6c\x58\x8f\x13\x59\x10\x94\x86\x57\x08\xd7\xa8\x4d\xd2\xac\x2f\xc7\xbb\x3b\xe0\x58\x18\x16\x0b\x5f\xcf\x78\xb9\x69\x28\x1a\xd6\x6b\x9b\xed\xe5\x46\xa3\x21\xf4\xf2\x78\x07\x33\x54\x5c\x3e\x17\xa1\xb6\x01\xde\x4e\x32\xa8\x9d\x63\x42\x56\x7a\x74\x2c\x49\x43\x38\x15\xf8\xdc\xa3\x86\x52\xad\xfa\x36\xe7\xa9\x43\x88\x40\xda\x15\xa4\xbe\x5d\x09\xd3\x48\x8b\x15\x9c\x6b\xca\xbd\x0b\xfb\x4b\xe4\x46\xea\xd6\xbb\x38\x9c\x90\xf9\xfa\x6e\xfb\xf6\xeb\xbf\x48\x05\x77\x7f\xbd\x93\x0a\xf6\x68\xbe\xeb\x5a\x48\xd8\x2a\x0c\x6b\xbd\x3a\xb1\x51\x6f\x18\x2b\x68\xc2\x9e\x9d\xa2\x42\x2f\x03\x14\xb8\x21\xa6\x1c\xac\xe1\x03\x25\x20\xf6\xf9\xae\x64\x8f\x32\xd5\xab\x0e\x97\x45\x0f\xec\x48\x66\xea\x33\x37\x9d\xfd\xec\xd2\x6f\x62\x2b\xa9\x23\x59\x9b\x4f\x7e\x0a\xa9\xe5\x69\x70\x78\xa9\xe3\x83\x49\x7f\x80\xb3\x5e\x1a\x4c\x93\x07\x00\x96\xeb\xb6\x48\x4d\x18\xc5\x73\x8c\x75\x4b\x51\x82\xf7\xe4\x86\x6e\xe1\x72\xbe\x55\x5a\x19\x62\x89\x6e\xea\x32\xae\x4b\x3f\x37\x00\x5c\xce\x50\x64\x2f\xad\xb5\x2d\xdd\xb6\xcd\x49\xef\xca\x49\x76\xd5\x57\xcd\x30\xce\x1e\x2e\x17\xc5\x15\x7c\xfc\xf0\xc3\xea\xae\x78\xf7\xf1\xc3\x8f\xb0\xf9\xbf\xcd\xd5\xf1\xfd\x27\xef\x97\x61\xb3\xf9\x74\xbb\xfa\xdd\x18\xda\x3e\xed\xb3\xf5\xd0\xa7\x9b\xf7\x0b\xe6\xf8\x46\x41\xb9\x1c\xfa\x5a\xfb\x40\x78\x0f\xef\x0a\xfa\xfe\x1c\xcc\xf0\xe2\xfd\xca\x60\x78\x5f\x3a\x32\x74\xd3\xc8\xe6\xd3\x2f\x56\xbf\x1b\x43\x5f\x3c\xed\xb3\xf5\x90\x7a\xb0\xfa\xdd\x18\x82\xa7\x7d\xb6\x1e\x7a\xff\xdf\xdf\xe0\x49\xa9\xda\x6e\x9f\xf6\xd9\x7a\xe8\x7f\x21\xfa\xf9\x10\xfd\x6b\x00\x8c\xe2\x45\x23\x9a\x1a\x00\x00")
 
 func complySoc2PoliciesRetentionMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -1001,12 +1022,12 @@ func complySoc2PoliciesRetentionMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/retention.md", size: 6810, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/retention.md", size: 6810, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesRiskMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x5a\xcd\x92\x1b\xb7\x11\xbe\xf3\x29\xba\x2a\x55\x89\xbc\xa6\xc6\x96\xed\x43\xb2\xaa\x1c\x36\x8a\x53\x51\x4a\xb1\x5d\xab\xcd\x03\x80\x98\x1e\x0e\x2c\x0c\x30\x46\x63\xc8\xa5\x4b\x87\xbc\x46\x5e\x2f\x4f\x92\xea\x06\xe6\x97\xdc\x15\xe9\xad\x52\x2e\xcb\x8b\xad\x59\x00\xfd\xf7\xe1\xeb\x06\x1a\x4e\x35\x78\x0d\xb7\x86\x3e\xc0\x0d\x11\x12\x35\xe8\x22\xfc\xe4\xad\xd1\x87\x95\xd2\xc1\xbb\x43\x73\x0d\xb7\x6f\x6f\x7e\x5a\x91\x8a\x86\x2a\x83\x74\xbd\x02\xb8\x7b\xff\x86\xff\x03\xf0\x12\xde\xbc\xf9\x53\xf1\x6a\xd5\xa8\x9f\x7d\xb8\xc5\x9d\x21\xe3\x9d\x0c\x79\x09\xa5\x8a\x78\x0d\xff\xe8\x1c\xbc\x82\x6f\xbe\x7e\xf5\x47\x99\xa0\x7d\xc3\x32\xae\xe1\xad\x33\xd1\x28\x0b\xa5\xd7\x1d\x7f\x59\xbd\x7c\xf9\x72\xb5\xfa\x1d\xfc\xd4\x85\xd6\x13\x82\x72\x25\xbc\xd7\xbe\xc5\xd5\x4a\x15\x70\x57\x23\xb4\xf9\x2f\xbe\x82\x58\x1b\x82\x56\xd4\x04\x43\x10\x3d\x94\x58\x19\x87\x10\x6b\x84\x06\x63\xed\x4b\x6f\xfd\xf6\x00\x95\x0f\xf2\x4d\x8d\xd6\xf1\xc2\x31\xa0\x8a\xf2\x2f\x5f\x81\x71\x95\x0f\x8d\x8a\xc6\x3b\x20\xd4\x5d\x30\xf1\x00\xc1\xd0\x07\x82\xbd\x89\xb5\x71\xb2\x82\x0f\x5b\xe5\xcc\xaf\x32\x6c\x9d\x16\x99\x49\x55\x5a\x63\x1b\xd5\xc6\x22\x58\xdc\xa1\xe5\x95\x79\x11\x50\x04\x84\x11\x36\x87\xa3\x65\xfe\xfb\xef\xff\x10\x58\x54\x25\x06\xaa\x4d\x5b\x88\xa5\xb7\x69\xce\x4c\x5d\x59\x67\xd4\x59\x05\x04\xd5\xb6\xd6\xa0\x28\xc1\xcb\xa2\x8b\x26\x20\x10\x3b\x2c\x39\xe8\x84\xa8\x93\x86\xb6\xc1\x6f\x83\x6a\x06\x93\x94\xb5\x22\x3e\x12\xec\x6b\xa3\x6b\x91\xd6\x11\x96\x0f\x39\x03\x7c\xc8\x23\xb5\xef\x6c\x09\xb5\xda\x71\xf4\xc0\x34\xad\xd2\x11\xbc\x3b\x2d\x37\xaf\x66\x62\x91\x03\x3c\x46\x34\xd9\x46\xbd\x36\xd8\xb4\xd6\x1f\x10\xe9\x94\x61\xb0\xaf\x3d\x44\xf5\x01\xa1\x55\x21\x82\x71\xbd\xd3\x1f\x71\x60\xc1\x38\xfb\x8b\xd2\x1f\xb6\xc1\x77\xae\x14\xf9\x37\xf0\x01\x0f\x80\x16\x7b\x54\x5c\xec\x41\x06\xa2\x82\xda\x5b\x43\xd1\x68\x11\x4b\x07\x8a\xc8\xc3\x35\xdb\x14\xbc\xd2\x35\x1b\x25\xda\x34\xca\xa9\xad\x48\x9b\x1b\x9f\x20\x45\x22\x3f\xe0\x2f\x9d\x09\x32\x88\x64\xc1\x36\x78\x8d\x6c\xda\x00\xec\x99\x2f\xa2\x07\x53\x32\x14\xaa\xc3\x23\xa8\xce\xbb\x29\x2d\x05\xda\x3b\x32\x14\xc5\xb9\x95\xef\x82\xf8\x91\xae\xfb\x85\x8c\xce\x41\x7e\xc0\x23\x09\x2a\x6b\x46\xf9\x1e\x05\x3a\x32\x2e\xd6\xec\xec\xa4\xf4\xae\xb3\x0e\x83\xda\x18\x6b\xa2\x84\xb5\x56\x51\x62\x7c\x78\x3d\x8d\x53\x96\x60\xcd\x07\xb4\xa6\xf6\xbe\x94\xc9\xac\x1e\xfe\xd2\xa1\xd3\x08\x2f\x58\xfd\x2f\xfa\x81\x8f\x89\xd8\xa0\x71\x5b\x08\xa8\xac\xf9\x15\xcb\xf5\x29\x63\x86\xdd\xc4\x9e\x44\x8e\x4c\xe7\x26\x7b\x98\x45\xa5\x3d\x81\x3b\x65\xbb\x99\x13\x02\x92\x29\x3b\x65\x33\xd4\xaa\x88\x61\x01\xae\x5b\xac\x30\xb0\xce\x34\xee\xe9\x09\xc1\xde\x62\xeb\x43\x84\x3b\x46\xb6\x8a\x28\xb4\x97\x38\x97\x47\x5f\x2d\x86\x5f\xad\x84\x39\x4d\x8a\xdb\x12\xde\x7d\x1c\x8d\xd3\xb6\x2b\x33\x70\x4e\x05\xef\x61\x6f\xd5\x6a\xc7\xee\x62\x3e\xf3\xb2\x2f\x99\xa5\x5b\xe5\x0e\x39\xba\xc5\x4c\x81\xca\x04\x8a\x40\x11\x5b\xc8\x6c\xb0\x54\x29\x31\xf2\x80\xc4\x29\xa3\x8c\x14\x32\xa3\xaa\xc7\xf6\xd5\x6b\x16\xe3\x63\x8d\x01\xf6\x3e\x94\x8c\xb5\x25\x45\x35\xea\x00\xaa\xaa\x50\x47\x59\x4d\x7b\x57\x25\xe9\xca\x9a\x78\x58\x83\x71\x11\xb7\x41\xfe\x57\xb9\xf2\x2b\x1f\x40\xed\x94\xb1\xc9\x03\x87\x65\x0e\x38\xc1\x71\x85\x84\x23\x92\x48\xca\x9e\x1e\x32\x17\xfb\x1e\x5a\xd5\x62\x60\x2a\x44\x8b\x3a\x06\xef\x8c\x66\x64\x31\xaf\x32\x9b\xa5\x40\xd0\x9a\x93\xa2\xda\x28\x42\x5a\xcf\x64\x46\xd4\xb5\x4b\x29\x8b\xf7\x7c\xcb\xeb\xca\x88\xa0\x28\x86\x4e\xc7\x2e\x60\x86\xe3\x7d\xc4\xe0\x94\xfd\xca\x77\x91\x7c\x17\x34\x96\x40\x18\x76\x46\xe3\x82\x23\x0a\xf8\x5b\x0f\x6d\xf1\x16\xcf\x07\xbf\x77\x18\xa0\xe9\x28\xc2\x66\xc4\x09\x96\xf3\x18\x3b\xbc\xef\x43\x7c\x1c\xcb\xc7\xa0\xa4\x88\xbc\x36\x2a\xe6\x74\x31\x91\xce\x0b\x3f\x3c\xaf\x57\x88\xc9\x13\x4b\x76\xa8\x3a\x82\x95\x6c\xcc\x02\xbe\x1f\x96\x94\x68\x6c\xf0\x48\x68\xd3\xd9\x68\x5a\x3b\x50\x44\x76\x9c\xb0\xaf\x7c\xf9\xe4\xc4\x85\x7a\x9c\x1e\x48\x35\xad\x3d\xc6\x7a\x62\x0b\xa6\xef\xe0\x77\xa6\xc4\x92\x19\x50\x52\x51\x86\xf6\x27\xf6\x3e\xbc\x08\x3d\x57\xc0\x0b\xf5\xc5\x17\x63\x1c\x86\xe0\xf5\x54\xf4\x70\xec\x46\x62\x48\x43\x24\x95\xe7\xaa\x27\xf6\xd3\x92\xc9\xb2\xf5\x54\xc3\x5b\xae\x34\x3b\xa1\xb1\x51\xe4\x8f\xac\xc5\xb8\x0e\x49\xee\x1f\xe5\xac\x79\xf6\x21\x29\x90\x5c\x70\x9d\xa6\xf2\xef\x55\x01\x6f\x46\xae\xa6\x91\x56\xb5\x6f\x36\xc6\x9d\x45\x44\x3c\x87\x2b\x87\x41\xb5\x6c\x81\xa9\x80\x3a\x8e\x7a\x9f\x3a\x23\x06\x23\xdc\x4e\x05\xcc\x54\x78\x37\x66\x0f\x5f\x81\xd7\xba\x0b\xc9\xb7\x7e\xbe\xc4\x0b\x53\x60\x21\xce\x68\x83\xdf\xf4\x44\x90\x32\x53\x0f\x92\xbd\xe1\xe2\xe3\xbe\xb5\xde\x24\x5e\x99\xaa\x7b\x98\x24\x83\x16\x75\x34\xbb\xec\xee\x3e\x82\xbd\x4f\x82\x11\x65\xc5\xb6\x12\x23\x86\xc6\x38\x66\xdb\x69\x62\x63\x5f\x4c\xf3\x5e\xc0\x5c\x06\xc8\x4e\xb8\x63\x88\x11\x7c\x2b\xc3\xbe\x2b\x64\xf5\xa3\x8c\x90\x6a\x4e\x43\xa0\x95\xd5\x9d\x15\x4c\x6f\x0e\xa0\xca\x52\xa8\x3d\xb1\xe2\x20\x90\xb4\x0f\x38\xc0\x64\x22\x5a\xfe\x50\xac\x56\x5f\xbe\x5c\xfe\xce\xf9\x72\xd1\xef\xcb\xd5\x47\xb8\xba\x9a\x60\xe6\xea\x0a\x4e\x7f\xf9\x2b\x92\x0e\xa6\x65\x04\x5d\x5d\xc1\x45\xbf\x8f\x20\x52\xde\xb1\x77\x86\xb9\xfc\xe5\x3d\x1b\x3a\xf9\xf2\xa4\xdf\xc7\xd5\x97\x7f\x5e\xfe\xce\xf9\x72\xd1\x8f\xfd\xf5\xce\xef\x17\xe6\x7d\x7d\x64\xf0\x3b\x4f\x52\xcc\x3d\x96\x05\x97\x19\x50\xa0\xee\x7c\xcc\x8e\x58\x1d\xfb\xe3\xd4\x97\x49\xca\x9d\x26\xcb\x3f\x30\x08\xa9\x86\xca\xfa\xfd\x1a\x2c\x6e\x95\x15\x89\xda\xbb\x18\x94\x8e\xbc\xaf\x1f\x70\xf9\x99\x82\xfd\xc6\x9a\x6d\x9f\x51\x7d\x80\x80\x6d\x17\x73\xa2\x3e\x27\x58\x9f\x07\xdc\xe7\x58\xf2\xa4\xdf\x47\x80\xcf\x65\xcb\x3f\x7d\x89\x81\xd3\xd5\x20\xfa\xd5\x91\x32\xbf\x05\x78\xb9\x9e\xea\xc2\x45\xc0\xab\x8c\x53\x4e\x1b\x65\x41\x7b\x4a\x27\xbc\x5a\x11\x58\xbf\x67\x01\x4d\xaf\xeb\x78\x02\x65\x88\x3e\xea\xf4\x73\x81\x37\x87\xb9\x60\x7b\x09\xed\x09\x38\xfb\x6a\x33\x4f\x3f\x53\xc8\x45\x68\x3e\x9a\xfe\x8c\xee\xcb\x6d\xf9\xbb\xd9\xd6\x73\xd1\xdf\x1c\x29\xf3\x9b\x69\xb5\x56\x3b\xbc\x08\xdd\xa6\x69\xb0\xe4\xaa\x54\x80\xed\xc3\x57\x72\x44\x67\x4c\x4b\xa9\x39\x03\xf5\x02\x90\x4f\x43\xf7\x84\xb5\x7d\xcb\x5b\x28\x11\x6c\x42\x79\x3e\x8c\x9f\x82\xf9\x9a\x61\x7e\x2e\xba\x2f\xa5\xeb\xf9\xf4\x67\x74\x5f\x6a\xcb\x4a\xca\x47\xf8\xf6\x1a\x26\x65\x14\x03\x79\x52\x67\x81\xd4\x47\xa9\x26\xef\x0b\xd6\xcf\x58\x03\x8e\x45\x3b\x17\x64\xa7\xbf\x3c\xd7\x80\x97\xd4\x80\xdf\x1b\xb9\x2f\xc1\x7b\x43\x91\xeb\xff\xe1\x4e\x45\x76\xb0\xb7\xe9\x64\x47\x31\x78\xb7\xcd\xe9\x73\x87\x40\x1e\x2a\x15\x2e\xab\x01\xc7\x63\xaf\x03\x55\xe2\x2f\x1d\xf3\xd6\x70\x01\xde\x06\x1f\xf9\x70\xe4\x9d\x90\xe3\xf2\xbc\xf5\xa4\x54\x5c\x8d\x77\x4f\xf3\xeb\x3e\x3e\x05\xe1\x7d\x0c\xd8\xa0\x3d\x70\x51\x50\xc0\x0f\x1e\x1c\xee\xb9\xd2\x10\xea\xa6\x73\xc9\x8a\xbd\x84\xf7\x7c\xbe\x4b\x47\x31\x96\x58\x75\xb1\x0b\xf8\x5c\x68\xfe\xff\x0a\xcd\x4f\xa3\x5b\xe0\xdc\x70\x6d\x38\xc5\xe7\xc4\x15\xe7\xc6\xff\x11\x44\x9f\x02\xf4\x14\x94\x4f\x42\xf7\x31\xa2\xfb\xaa\xb6\x80\xf7\xbe\x41\x68\x8c\xf3\x61\x04\xb4\x14\xd2\x62\xf5\x05\x85\x66\xbe\x1e\x29\x0b\xf8\x61\xba\x39\x04\xf5\xad\x27\x32\x1b\x8b\x6b\xd8\x74\x51\x8e\x86\xb5\xd9\xd6\xbc\x9f\x98\x9a\x0f\xc5\x33\xbc\x7f\x93\x2d\xe7\x54\x9a\xe7\x91\x37\x87\xc4\x38\x68\xad\xd2\x5c\x06\x0e\xd3\xcf\x2d\x32\x1d\xca\xe9\xdd\xec\xf0\x35\x63\x36\x60\xee\x5f\xb1\x82\x0f\x41\x3a\xa1\xf2\x69\xc8\xee\x31\x5d\xc0\xdb\x01\x70\xa9\x65\x98\x64\xdb\x13\x37\x78\x2c\x35\xb1\xef\xb9\xe6\x9d\x4d\xd2\xd3\x59\xcf\x80\xbe\xd4\x96\x5c\x5c\x7e\x77\x54\x5c\x4e\xee\x61\x4f\xd6\x96\xbf\x77\x1b\x6a\x5f\xaf\x56\x9b\xa1\xdd\x95\x5a\x6f\x5c\x8c\xf6\xa3\xc6\xbe\x97\x8c\xd8\x29\xdb\x21\xc1\xd7\x10\xeb\xe0\xbb\x6d\x0d\xdf\xc8\x3e\xe8\x0f\x47\xa9\x17\xbe\x99\x35\xe2\x53\xbb\xf3\xe4\x32\xf9\x36\xf5\xf4\x12\x47\x9d\x40\x2a\xe0\x5f\x47\xdf\x86\x9b\x78\xe9\xfd\x49\x0b\x45\xf7\xe6\xdc\xf5\xed\xc0\x85\x11\x63\xdb\xd1\x10\x1f\xe5\x52\xbf\x99\x05\x67\xa3\x86\x96\xc1\xb0\x42\xba\xff\x2d\xe0\xc6\xda\x2c\xb7\x0a\xbe\x39\xd9\x5b\x48\xd1\xe8\xd5\xd2\xbe\x9d\xbc\x10\x38\xb5\x68\xba\x94\x36\xd4\x7a\x32\xf9\x5d\x83\xe5\x8a\x50\x9a\x21\x93\x37\x12\xed\x70\x9b\x31\xef\x7d\x4e\xfa\x22\x27\x97\x9f\xb6\x45\x8c\x83\x59\xa7\x63\x0c\xcb\xcd\xb4\x61\x62\x68\xf9\xda\x21\xf7\xb3\xa4\xf9\x00\x6f\xbe\xff\xb1\xbf\x54\x49\x2d\xc1\xbe\x53\x99\xda\xe9\x81\x80\x6a\x65\xed\x70\xd3\x8e\xe0\x37\x3f\x27\x9a\x4b\xed\x85\xc6\x44\x39\xb6\xba\xad\xe4\x6f\x96\x22\x99\x36\x05\x99\x7d\x7c\x1c\xfc\xa3\x40\x4b\x6f\x86\xd9\xd8\xb8\xce\x77\x12\xc8\xe0\x77\xe9\xe9\x40\x7e\xa5\x42\xeb\x63\xd5\xa4\x4d\x69\xc9\xb3\x43\x25\x2c\xd2\x66\x48\x76\xe4\xc8\xfa\xb0\xe8\xbd\xae\x01\x77\xe8\xc0\x08\x0b\x9b\x34\x2e\x5f\xdf\x1b\x82\x12\xb1\x39\x01\xfc\x49\x33\xef\x28\x88\x2c\x22\x09\xeb\xfb\x98\x52\xd4\x7a\x6b\xfd\x5e\xbc\x92\xc6\xcd\x3b\x3b\xef\xa5\xab\x99\x8b\x9e\x92\x37\xb4\x6f\xfb\xa6\xfd\x32\x3d\xbd\xa0\x45\x0b\xe4\x2e\x28\x47\x15\x26\x1a\xef\x73\x49\x7a\xdd\xc1\x01\x0f\xa5\x84\xff\xf0\x3a\xb5\x8c\xee\x05\x4f\x6b\xd8\x1c\xd8\x95\xba\x56\x64\xe4\x8c\x02\xc6\x51\x17\x84\x20\xf2\x4b\x09\x1f\x80\xcc\x56\x9a\x29\x6a\xb8\x9a\x48\xd8\xa5\x4e\x9e\x90\x04\xe2\x41\xbc\xba\xc3\x40\x73\xad\x6e\x76\xde\x94\x53\x8d\x58\x62\x69\x28\x87\xb5\xff\xcb\xa6\x23\xe3\x90\x08\x14\x83\x68\x68\x12\x69\xd5\x11\x52\x6a\x29\xc9\x4e\x98\xaf\x2d\x91\x98\x2e\xfe\x3a\x41\x3b\x39\x57\xf6\x05\xc3\x33\xf2\xce\xf7\xce\x1e\x72\x7c\x81\x46\x3f\x57\x13\x60\x9c\xd8\x8b\x7b\x79\x6b\x23\xd7\x9f\x0d\x83\x21\xd6\x2a\x1d\x4f\x5a\x1f\xd3\xe5\xd4\x70\x53\xf4\xe0\x41\x69\xb2\x05\xe5\x0d\x43\x96\x2e\xfe\x5c\x4a\x5c\x8f\x8b\xa4\x7e\x22\xd5\xa2\x01\x52\x34\x0d\x57\xc8\x51\xda\xc6\xfb\xc7\xfa\x5a\x99\x7a\xf3\x83\x09\xd6\xd5\x2a\xe7\xb0\x9c\x17\x36\x13\x56\x2c\xf2\x7b\x08\xdc\x76\x56\x05\xb8\xc5\x9d\xc1\xbd\xdc\xc2\x2d\x99\x8f\x25\x3d\x44\xbc\x77\x0f\x32\xe5\xf1\xac\x05\x85\x76\x6d\x99\xba\xc3\x35\x3a\xb6\x8e\x23\x35\xb4\x43\x33\x8e\xe7\x3d\xd2\x02\x6e\x22\x28\x2e\xcf\x4d\xd3\x35\xeb\x14\xf6\xb4\x4c\xe6\x4f\xb6\x21\xb3\x94\x90\xb4\x2b\x3b\x9d\x70\xc0\xd0\xc6\x00\x07\x54\xa1\x80\xb7\x92\x22\x72\xd1\x1d\x30\xbd\x9b\x2b\x25\x53\xa8\x78\x92\xfd\x1f\xb4\x66\x69\x88\xec\x9a\xca\x68\xe5\x22\xe8\x5a\xb9\x2d\x52\x2a\xb4\xfa\x5c\x31\x7f\xeb\x36\xbe\x4d\x58\x8f\xdb\x61\xa4\x55\x39\xd9\x0f\xdf\xd1\xed\x4c\xf0\x2e\xbf\x84\x49\xd1\x6b\x7d\x60\x54\x2d\xde\xb1\x20\x75\x36\x3d\x3b\xfa\xf4\x8b\xad\xd4\xc1\x67\x97\x51\xb7\x49\xf0\x8a\xd9\x95\xb4\x1e\x9d\xd9\xbf\xc8\xe8\x5f\x10\x9c\xee\xbd\x17\xab\xd5\xff\x02\x00\x00\xff\xff\x5b\x0c\x99\x9b\xf8\x28\x00\x00")
+var _complySoc2PoliciesRiskMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x5a\xcf\x72\xdc\xb8\xd1\xbf\xcf\x53\x74\xd5\x57\xf5\xc5\xd6\x8e\xb8\xb6\x77\x0f\x89\x5c\x39\x28\x8a\x53\x71\xca\xd9\x75\xc9\xca\x03\x60\xc0\xe6\x10\x2b\x10\xa0\xd1\xe0\x8c\xb8\xa5\x43\x5e\x23\xaf\x97\x27\x49\x75\x13\xfc\x33\x9c\x19\x69\x14\x65\x7d\x12\x7d\xb0\x8a\x03\xa2\x1b\xdd\x3f\xfc\xba\xd1\x0d\xa7\x2a\xbc\x80\x6b\x43\xb7\x70\x49\x84\x44\x15\xba\x08\x9f\xbd\x35\xba\x5d\x28\x1d\xbc\x6b\xab\x0b\xb8\xfe\x78\xf9\x79\x41\x2a\x1a\x2a\x0c\xd2\xc5\x02\xe0\xe6\xcb\x15\xff\x07\x70\x0e\x57\x57\x7f\xc8\xde\x2e\x2a\xf5\x8b\x0f\xd7\xb8\x31\x64\xbc\x93\x21\xe7\x90\xab\x88\x17\xf0\xb7\xc6\xc1\x5b\x78\xf7\xe6\xed\xef\xe5\x03\xed\x2b\x96\x71\x01\x1f\x9d\x89\x46\x59\xc8\xbd\x6e\xf8\xcd\xe2\xfc\xfc\x7c\xb1\xf8\x3f\xf8\xdc\x84\xda\x13\x82\x72\x39\x7c\xd1\xbe\xc6\xc5\x42\x65\x70\x53\x22\xd4\xe9\x17\x5f\x40\x2c\x0d\x41\x2d\x6a\x82\x21\x88\x1e\x72\x2c\x8c\x43\x88\x25\x42\x85\xb1\xf4\xb9\xb7\x7e\xdd\x42\xe1\x83\xbc\x53\xe3\xea\x78\xe2\x18\x50\x45\x96\x0a\xbe\x00\xe3\x0a\x1f\x2a\x15\x8d\x77\x40\xa8\x9b\x60\x62\x0b\xc1\xd0\x2d\xc1\xd6\xc4\xd2\x38\x99\xc1\x87\xb5\x72\xe6\x57\x19\xb6\x14\xed\x76\xa5\x2a\xad\xb1\x8e\x6a\x65\x11\x2c\x6e\xd0\xf2\xcc\x3c\x09\x28\x02\xc2\x08\xab\x76\x6f\x9a\x7f\xff\xf3\x5f\x04\x16\x55\x8e\x81\x4a\x53\x67\xb2\x52\x71\xc6\x4c\x5d\x99\x67\xd4\x59\x05\x04\x55\xd7\xd6\x60\xce\x4b\xe7\x69\xd1\x45\x13\x10\x88\x0d\xc6\x92\x0f\x8a\x3a\xb8\xd0\x3a\xf8\x75\x50\xd5\xb0\x24\x65\xad\x88\x8f\x04\xdb\xd2\xe8\x52\xa4\x35\x84\xf9\x31\x63\x80\x0f\x69\xa4\xf6\x8d\xcd\xa1\x54\x1b\xf6\x1e\x98\xaa\x56\x3a\x82\x77\x87\x0d\x9c\x66\x33\x31\x4b\x0e\x1e\x3d\xda\xad\x8d\x7a\x6d\xb0\xaa\xad\x6f\x11\xe9\xd0\xc2\x60\x5b\x7a\x88\xea\x16\xa1\x56\x21\x82\x71\x10\x1e\x35\x60\xc6\x38\xfb\x93\xd2\xb7\xeb\xe0\x1b\x97\x8b\xfc\x4b\xb8\xc5\x16\xd0\x62\x8f\x8a\x27\x5b\x90\x81\xa8\xa0\xf4\xd6\x50\x34\x5a\xc4\x52\x4b\x11\x79\xb8\x66\x7f\x05\xaf\x74\xc9\x8b\x12\x0d\x2b\xe5\xd4\x5a\xa4\xed\x2e\xbe\x03\x32\x09\x58\x02\x7e\x6d\x4c\x90\x41\x24\x13\xd6\xc1\x6b\x64\x6c\x0c\xc0\xde\xb1\x45\xf4\x60\x72\x86\x42\xd1\x1e\x56\x95\x25\x53\xda\x4d\xdd\x54\xa0\xbd\x23\x43\x51\x8c\x5b\xf8\x26\x88\x1d\xe9\xa2\x9f\xc8\xe8\xe4\xe4\x23\x16\x61\x43\x47\x5a\x32\xca\xb7\x28\xd0\x91\x71\xb1\x64\xb4\x76\x4a\x6f\x1a\xeb\x30\xa8\x95\xb1\x26\x8a\x5b\x4b\x15\xd9\x1e\xb6\x7d\x3f\xf5\x53\xb2\xb9\x35\xb7\x68\x4d\xe9\x7d\x2e\x1f\xb3\x7a\xf8\xb5\x41\xa7\x11\x5e\xb1\xfa\xaf\xfb\x81\x0f\x89\x58\xa1\x71\x6b\x08\xa8\xac\xf9\x15\xf3\xe5\xa1\xc5\x0c\xbb\x89\x2d\x89\xec\x99\xc6\x4d\xf6\x30\x8b\xea\xf6\x04\x6e\x94\x6d\x76\x8c\x10\x90\x4c\xde\x28\x9b\xa0\x56\x44\x0c\x33\x70\x5d\x63\x81\x81\x75\xa6\x71\x4f\x4f\x08\xf6\x1a\x6b\x1f\x22\xdc\x30\xb2\x55\x44\x86\x63\xe2\x5c\x1e\x7d\x36\x1b\x7e\xb6\x10\xe6\x34\x9d\xdf\xe6\xf0\x4e\x90\x00\xe3\xb4\x6d\xf2\x04\x9c\x43\xce\x3b\x6e\xad\x52\x6d\xd8\x5c\xcc\x67\x5e\x76\x39\x68\x5f\xd5\xca\xb5\x89\x08\xb2\x1d\x05\x0a\x13\x28\x02\x45\xac\x21\xb1\xc1\x5c\xa5\x8e\x91\x93\x0e\xed\x0e\xa3\x8c\x14\xb2\x43\x55\x0f\xed\xab\xf7\x2c\xc6\xc7\x12\x03\x6c\x7d\xc8\x69\xb9\x33\xa1\x10\x4f\xa5\x5a\x50\x45\x81\x3a\xca\x6c\xda\xbb\xa2\x93\xae\xac\x89\xed\x12\x8c\x8b\xb8\xe6\x39\xc5\xa3\xdf\xfb\x00\x6a\xa3\x8c\xed\x2c\xd0\xce\x63\x80\x71\x7b\x50\xcf\xc4\x1d\x91\x44\x52\xb2\xf4\x10\xb9\x88\x15\xac\x55\x8d\x81\xa9\x10\x2d\xea\x18\xbc\x33\x9a\xf7\x28\xf3\x2a\xb3\x59\xe7\x08\x5a\x72\x50\x54\x2b\x45\x48\xcb\x1d\x99\x11\x75\xe9\xba\x90\xc5\x7b\xbe\x66\x98\xc8\x88\xa0\x28\x86\x46\xc7\x26\x60\x82\xe3\x5d\xc4\xe0\x94\xfd\xde\x37\x91\x7c\x13\x34\xe6\x40\x18\x36\x46\xe3\x8c\x23\x32\xf8\x4b\x0f\x6d\xf1\x23\x7f\x0f\x7e\xeb\x30\x40\xd5\x50\x84\xd5\x88\x13\xcc\x77\x7d\xec\xf0\xae\x77\xf1\xbe\x2f\x1f\x82\x92\x22\xf2\xda\xa8\x98\xc2\xc5\x44\x3a\x4f\x7c\xfc\xbb\x5e\x21\x26\x4f\xcc\xd9\xa0\x6a\x0f\x56\x12\x5c\x33\xf8\x30\x4c\x29\xde\x58\xe1\x9e\xd0\xaa\xb1\xd1\xd4\x76\xa0\x88\x64\x38\xfe\xae\x7b\xf3\xe8\x87\x33\xf5\x32\xb8\x04\x52\x55\x6d\xf1\xb0\x52\x6c\xa5\x3a\xf8\x8d\xc9\x31\x67\x06\x64\x0a\xed\xa1\xfd\xc8\xde\x87\x57\xa1\xe7\x0a\x78\xa5\x5e\xbf\x1e\xfd\x30\x38\xaf\xa7\xa2\xe3\xbe\x1b\x89\xa1\x1b\xc2\xcb\xed\xb3\x9e\xd8\x7f\xd6\x2d\x99\x5f\x93\xaa\x10\x8c\xcb\xcd\x46\x68\x6c\x14\xf9\xb3\xd3\xd3\x79\x48\x62\xff\x28\x67\xc9\xfb\xa2\xed\x14\xe0\xa9\x89\x2e\xba\x4f\xf9\xdf\xdb\x0c\xae\x46\xae\xa6\x91\x56\xb5\xaf\x56\xc6\x9d\x44\x44\xfc\x8d\x72\x13\xd5\xd2\x0a\x4c\x01\xd4\xb0\xd7\xfb\xd0\x19\x31\x18\xe1\x76\xca\x60\x47\x85\x4f\x63\xf4\xf0\x05\x78\xad\x9b\x20\x3c\x0c\x7e\x77\x8a\x57\x26\xc3\x8c\x97\xc3\x2c\xb3\xea\x89\xa0\x8b\x4c\x3d\x48\xb6\x86\x93\x8f\xbb\xda\x7a\xd3\xf1\xca\x54\xdd\xb6\xf7\x6f\x40\xaa\x51\x47\xb3\x49\xe6\x7e\x9d\xed\x28\x74\x15\x8c\x28\x2b\xf6\xc8\x31\x62\xa8\x8c\x63\xb6\x9d\x06\x36\xf6\xd7\x34\xee\x05\x4c\x69\x80\xec\x84\x1b\xc6\x3d\xc1\x0f\x62\xb2\x1f\x33\x59\xee\x5e\x44\xe8\x72\x4e\x43\xa0\x95\xd5\x8d\x15\x4c\xaf\x5a\x50\x79\xce\xc2\x12\x2b\x0e\x02\x49\xfb\x80\x03\x4c\x26\xa2\xe5\x87\x6c\xb1\xf8\xee\x7c\xfe\x9c\xf2\xe6\x49\xcf\x77\x8b\x7b\x38\x3b\x9b\x60\xe6\xec\x0c\x0e\xbf\xf9\x33\x92\x0e\xa6\x66\x04\x9d\x9d\xf5\xb6\x3d\xed\xb9\x07\x91\xf2\x89\xad\x33\x7c\xcb\x6f\xbe\xf0\x42\x27\x6f\xd2\x1f\xff\xdd\xbf\xfb\xc5\x77\x7f\x9c\x3f\xa7\xbc\x79\xd2\xc3\xf6\xfa\xe4\xb7\xbd\xcc\xa4\xf7\x9b\xfe\xcf\xf4\xf0\x18\x22\x06\xe7\x43\x51\x70\x1e\x01\x05\xea\xce\xc7\x64\x88\xc5\xbe\x3d\x0e\xbd\x99\x84\xdc\x69\xb0\xfc\x1d\x83\x90\x4a\x28\xac\xdf\x2e\xc1\xe2\x5a\x59\x91\xa8\xbd\x8b\x41\xe9\xc8\xfb\xfa\x88\xc9\x4f\x14\xec\x57\xd6\xac\xfb\x88\xea\x03\x04\xac\x9b\x98\x02\x75\x3f\xea\xe8\x73\xff\xad\xc0\x7d\xca\x4a\x9e\xf5\xdc\x03\x7c\xab\xb5\xfc\xdd\xe7\x18\x38\x5c\x0d\xa2\xdf\xf6\x7f\x3e\x0b\x78\x29\x9f\x6a\xc2\x93\x80\x57\x18\xa7\x9c\xe6\xea\x81\xf6\xd4\x9d\xe8\x4b\x45\x60\xfd\x96\x05\x54\xbd\xae\xe3\x09\x94\xf9\xef\xf8\x84\x4f\x00\xde\x2e\xcc\x05\xdb\x73\x68\x4f\xc0\xd9\x67\x9b\xe9\xf3\x13\x85\x3c\x09\xcd\x2f\xe8\xfe\x1f\xa0\xfb\xaf\x66\x5d\xf6\x62\xf9\xdf\x3d\xbc\xeb\xff\x7c\x16\xba\x85\x56\xb9\x1e\xf2\x24\x74\x9b\xaa\xc2\x9c\xd3\x59\x86\x0f\xf8\xf0\x3d\x47\x6e\xc3\x98\x5e\xd9\x39\xa8\x67\xbc\x7b\xc4\xe8\x27\x0a\x9e\xb0\xb6\xaf\x79\x0b\x75\x04\xdb\xa1\x3c\x1d\xc6\x0f\xc1\x7c\xc9\x30\xbf\x3f\x51\xc8\x53\xe9\xfa\x05\xdd\xcf\x43\xf7\x42\xd2\x47\xf8\xe1\x02\x26\x69\x14\xe7\x07\x93\x3c\x0b\x24\x3f\xea\x4e\x66\x7d\xc2\xfa\x0d\x73\xc0\x31\x69\xe7\x84\xec\xf0\x9b\x97\x1c\xf0\x29\x39\xe0\x07\x23\xf5\x12\xbc\xe3\x5a\xa4\x5b\x8f\x35\x15\xd9\xc1\xde\x76\x27\x3b\xe2\x3a\xc5\x3a\x85\xcf\x0d\x02\x79\x28\x54\x48\x86\x38\x71\x13\x8c\xc7\x5e\x07\x2a\xc7\xaf\x8d\x8a\x93\x02\x78\x1d\x7c\xe4\xc3\x91\x77\x92\xa7\xcd\xcf\x5b\xcf\x21\xab\xfe\xf8\xc5\xa7\xb9\xdd\x72\x1f\x9f\xc6\xf1\x2e\x72\xdd\xd4\xb6\x9c\x14\x64\xf0\x93\x07\x87\x5b\xae\x91\x49\x46\x4c\xa7\x92\x15\x5b\x09\xef\xf8\x7c\xc7\xd3\x76\x94\x5b\x34\x5c\x8c\xc9\x5e\xc8\xea\x37\x20\xab\xd3\x12\xcd\xc7\xd1\xcd\x31\x17\x2a\xce\x0d\xa7\xf8\x4c\x9f\x3f\x01\x64\x0f\x21\xfa\x10\xa0\xa7\xa0\x7c\x16\xba\xf7\x11\xdd\x67\xb5\x19\x7c\xf1\x15\x42\x65\x9c\x0f\x23\xa0\xa5\xbc\x23\xab\x7e\x42\xa2\x99\xca\x23\x79\x06\x3f\x4d\x37\x87\x70\x43\xed\x89\xcc\xca\xe2\x12\x56\x4d\x94\xa3\x61\x69\xd6\x25\xef\x27\x26\xeb\x36\x7b\xc9\x34\x7f\xb3\x4c\xf3\x34\xf2\x66\x97\x70\xdd\xd9\x2a\xcd\x69\xe0\xf0\xf9\x89\x06\x33\x0e\xe5\xf4\x6e\x36\xf8\x9e\x31\xcb\x85\x3e\xe9\x5f\xb1\x82\xc7\x20\xdd\xa1\xf2\x88\xbd\x4f\x14\xdc\x63\x3a\x83\x8f\x03\xe0\x04\xb8\x49\xb6\x3d\x50\xc1\x63\xa9\x5d\x55\xfe\x54\x68\x9f\x4c\xd2\x2f\x7c\xfd\x1c\xbe\x4e\xc9\xe5\x8f\x7b\xc9\xe5\x98\xc0\x1d\xce\x2d\xff\xdf\xad\xa8\x7e\xbf\x58\xac\x86\x76\x57\xd7\x7a\xe3\x64\xb4\x1f\x35\xf6\xbd\xa4\x86\xce\x6d\x38\x24\x78\xc3\xe5\x59\xdf\xac\x4b\x78\x27\x44\xd5\x1f\x8e\xba\x5e\xf8\x6a\xa7\x11\xcf\x95\x51\xca\x0e\x4e\x93\xaa\xa9\x87\xa7\xd8\xeb\x04\x52\x06\xff\xd8\x7b\x37\x54\xe2\xa5\xf7\x27\x2d\x14\xdd\x2f\xe7\xa6\x6f\x07\xce\x16\x31\xb6\x1d\x0d\x71\x87\xbc\xeb\x37\x63\x3e\x2c\x6a\x68\x19\x0c\x33\x80\xd8\x38\x83\x4b\x6b\x93\xdc\x22\xf8\xea\x60\x6f\x41\x46\x0e\x6a\x69\x5f\x4f\x6e\x08\x1c\x9a\xb4\x2b\x4a\x1b\xaa\x3d\x99\x74\xaf\xc1\x72\x46\x28\x5d\x94\x51\x55\x5f\x0f\xd5\x8c\xdd\xde\xe7\xa4\x2f\x72\x70\xfa\x69\x5b\x84\x3b\xf3\xd3\x4e\xc7\xe8\x96\xcb\x69\xc3\xc4\xd0\xac\x59\xdf\xf7\xb3\xa4\xf9\x00\x57\x1f\x7e\xee\x8b\x2a\x5d\x4b\xb0\xef\x54\x76\xed\xf4\x40\x40\x25\xf7\xa8\xfa\x4a\x3b\x82\x5f\xfd\xd2\xd1\x5c\xd7\x92\xa8\x4c\x94\x63\xab\x5b\x33\x69\x8a\x14\x66\x97\xe4\x64\xb6\x71\xf3\xb8\xa3\xa5\xb1\xc6\xa9\xb4\x71\x8d\x6f\xc4\x91\xc1\x6f\xc4\x95\xfd\x2d\x15\x5a\xee\xab\xc6\x31\x5a\x59\xf2\xe0\xeb\xc8\x60\x15\xe9\x69\x1d\xc9\xb3\x7e\x5c\x51\xdf\x59\xc7\x0d\x3a\x30\x6c\x1b\x34\x41\x14\xe2\x6b\x1e\x1d\x5f\xe7\x88\xd5\x01\xe0\x8f\xb6\xbd\xd9\x73\x22\x1b\x81\x27\xa1\xa1\x8f\xc9\x76\x2d\xbc\xb5\x7e\xcb\x96\x48\xe3\x76\x3b\x3b\x5f\xd0\x8e\x49\x4f\xce\x1b\xda\xd7\x7d\xd3\x7e\x1e\x9e\x5e\xd1\xac\x05\x72\x13\x94\xa3\x02\x3b\x1a\xef\x63\x89\xb4\x15\x15\x5f\xe4\x09\xb9\xb8\xbf\x7d\x2f\xfe\xc1\x3b\xe9\xb3\x2d\xf9\xbe\x4c\xdd\x04\x5d\x2a\xe2\xcf\xa4\x2b\x44\x4d\x10\x82\x48\x37\x25\x7c\x00\x32\x6b\x69\xa6\xa8\xa1\x34\xd1\x35\xf2\xa8\xe1\xa6\x2b\xdb\xdc\x77\x17\x1a\x1c\x06\xda\xd5\xea\x72\xe3\xcd\xd0\x1b\x61\x8d\x58\x62\x6e\x28\xb9\xb5\xff\x65\xd5\x90\x71\xdc\x5f\x57\x0c\xa2\xa1\x49\xa4\x55\xc3\xb7\x30\xa4\xa5\xc4\x1f\xcf\xe6\x16\x4f\x4c\x27\xe7\x18\x6b\x28\x19\x97\x3d\x57\x73\x23\x28\x72\x97\xc6\x3b\xdb\x26\xff\x02\x8d\x76\x2e\x26\xc0\x80\xb8\xe7\xc6\xad\xdc\xb5\x91\xf2\x67\xc5\xbd\x9c\x58\x2a\x09\x90\x50\xfb\xd8\xd5\xfc\x87\x4a\xd1\x5e\x00\x1f\x42\xf0\xb8\x05\xe5\x0e\x43\x92\x2e\xf6\x9c\x4b\x5c\x8e\x93\x74\xfd\x44\x2a\x45\x03\xa4\x68\xb8\x1f\x27\x3f\xf3\x19\xeb\x81\xbe\x56\xa2\x5e\x25\xc2\x78\x7c\x6d\x95\x73\x98\xef\x26\x36\x13\x56\xcc\xe4\xf6\xc4\xd9\x35\xae\x1b\xab\x02\xf0\xfd\x32\xdc\x4a\x15\x6e\xce\x7c\x2c\xe9\x18\xf1\xde\x1c\x65\xca\xfd\xaf\x66\x14\xda\xd4\x7c\x89\x2d\x87\x6d\x89\x8e\x4f\x90\xb6\x9d\xb4\x43\x13\x8e\x77\x7b\xa4\x19\x5c\x72\x0b\x91\x9b\x7c\x55\x53\xb1\xd5\x0c\xa5\x69\x12\x7f\xf2\x1a\x12\x4b\x09\x49\xbb\xbc\x91\xe3\xa5\x67\x8b\xf1\x25\x82\x16\x55\xc8\xe0\xa3\x84\x88\x94\x74\x07\xec\xee\xcd\x31\x91\x0a\xfe\x0e\xb1\xff\xd1\xd5\xcc\x17\x22\xbb\x86\x2f\x87\xb8\x08\xba\x54\x6e\xcd\x57\xab\xf8\x2c\xd0\xc7\x8a\x69\x55\x71\x39\xb9\x9b\xb0\x1c\xb7\xc3\x48\xab\x72\xb2\x1f\xde\xa3\xdb\x98\xe0\x5d\xba\x09\xd3\x79\x8f\x1b\xde\xc6\xad\x77\x3d\x12\x90\x1a\xdb\x5d\x3b\x7a\xfc\xc6\x16\xb7\xbf\x73\xb9\xf7\x41\xcd\xaa\xeb\x62\xc6\x64\x4a\x5a\x8e\xc6\xec\x6f\x64\xf4\x37\x08\xe6\x16\xba\xc6\xda\x87\x98\x2d\x16\xff\x19\x00\x5b\x0c\x99\x9b\xf8\x28\x00\x00")
 
 func complySoc2PoliciesRiskMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -1021,12 +1042,12 @@ func complySoc2PoliciesRiskMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/risk.md", size: 10488, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/risk.md", size: 10488, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesVendorMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x57\xcd\x72\x1b\x37\x0c\xbe\xeb\x29\x30\x93\x43\xad\x19\x59\xd3\xe4\xd4\xfa\xe6\xf8\xa4\x4e\x32\xf5\xc4\x9e\xdc\x29\x12\xda\x45\xcd\x25\xb7\x00\x57\x9e\xed\xa9\xaf\xd1\xd7\xeb\x93\x74\x40\xee\x9f\x64\xc9\xe9\x25\x76\x76\x97\xc4\x87\x0f\x1f\x3e\xc0\xc1\x34\x78\x07\xdf\x31\xb8\xc8\xf0\xd5\x04\x53\x61\x83\x21\xc1\x63\xf4\x64\xfb\x95\xb1\x1c\x43\xdf\xdc\xc1\xf7\xaf\x8f\x2b\x31\x89\xe4\x40\x28\x77\x2b\x80\xe7\xa7\x07\xfd\x01\x70\x0b\x0f\x0f\xbf\x6e\x3f\xad\x1a\xf3\x47\xe4\x6f\x78\x24\xa1\x18\xf2\x27\xb7\xe0\x4c\xc2\x3b\xf8\xad\x0b\xf0\x11\x3e\xfd\xfc\xf1\x97\x7c\xc0\xc6\x46\x43\xdc\xc1\x2e\x50\x22\xe3\xc1\x45\xdb\xe9\x93\xd5\xed\xed\xed\x6a\xf5\x01\x1e\x3b\x6e\xa3\x20\x98\xe0\xe0\xc9\xc6\x16\x57\x2b\xb3\x85\xe7\x9a\x04\xda\x0c\x0b\x1c\x1e\x28\xa0\x40\xaa\x11\xb8\xf3\x28\x70\x88\x0c\x8c\xde\x24\x0d\x5e\x53\x2b\xf0\x4a\xa9\xce\x1f\x44\xae\x4c\xa0\xbf\xf2\xab\x7f\xff\xfe\x47\x60\x17\x0e\x91\x9b\xfc\x7f\x78\x46\x5b\x87\xe8\x63\xd5\xc3\xcd\xee\x79\x0d\xc7\xcc\x84\xe4\xd8\xad\xe1\x14\x90\x65\x0b\x6f\x00\x98\xb6\xf5\xa4\x00\x22\x18\xef\x61\xf7\x7c\xf1\x20\xbc\xd6\x11\x6a\x73\xc4\x8c\xc3\xec\xc9\x53\xea\xf5\x0c\x35\xad\xb1\x29\x3f\xb5\x31\x1c\xc8\x61\x50\x26\x28\xf5\x1b\xa0\x90\xb0\xe2\xfc\xab\xde\x65\x8e\x86\xfc\x78\x34\x1e\x2e\x67\x94\xe6\x2c\xf4\x8c\x60\x10\x4a\x74\x44\xa0\x39\xd5\x0d\x44\xce\x80\x0c\x63\x26\x87\x42\xbe\x4c\x94\xe1\xab\x37\x2f\x2e\x00\x41\xdb\x29\x30\x68\x39\x56\x6c\x9a\xed\x8f\x68\xc1\xa6\xf5\xb1\x47\x2c\xac\xd8\x18\x12\x1b\x9b\x94\xa5\x54\x9b\x94\x81\x30\x4a\x1b\x83\xd0\xde\x63\xae\xa1\x82\x68\x66\x19\xea\xb9\x78\x44\x16\xaa\xea\xa4\x20\xaf\x31\x7d\x01\xbf\x96\xed\x03\x7c\x36\xf6\xa5\xe2\xd8\x05\x37\xa0\xc5\x7c\xa1\xc2\x9b\xf2\xb9\x70\x18\x48\xa0\xa6\xaa\xf6\x2a\xb6\x16\x83\x56\x08\xe2\xc0\xd8\xe2\x1c\x25\x99\x12\xeb\x8c\xbf\xa4\x41\xfd\x64\xf7\x0c\xd2\x65\x72\xde\x68\xeb\x92\xae\x81\xf1\xcf\x8e\x38\x73\x50\xb4\x8d\x87\x03\xda\x5c\xd2\xf7\xd8\x91\xce\xd6\x57\x02\xc1\x81\x63\x03\x26\x5c\xa9\x28\xb2\xb4\x25\x40\xe1\x68\x00\xd4\x32\x8a\x65\xda\xa3\x40\x43\x81\x9a\xae\x01\x49\x26\x38\xc3\x4e\xc0\x0c\xa5\x80\xa6\x93\x04\x0d\x62\x7a\x3f\x48\x3e\xd9\x46\x0a\x49\x55\x6e\x7d\xe7\x28\x54\xf3\x6b\xeb\x4d\x27\x28\x1b\x60\x92\x17\x30\x22\x28\x92\x09\xd8\x80\x20\x1f\xc9\x22\x78\x3c\xa2\x07\x53\x31\xe2\xf0\x46\x53\xa4\x60\x73\x07\x2d\xa8\xd9\x6a\xe9\xbf\xe1\x01\x19\x83\x45\xc9\xa5\x5f\xb6\xfd\xd3\x18\x74\xf0\x39\x7d\x3f\x3d\xdb\x8d\xf7\x7d\x2b\xe2\xc4\xe9\xab\x0f\xcb\xef\x97\x52\x64\xd4\xa6\xa8\x69\x4f\x09\xdd\xc0\x82\xb5\x28\xa2\x19\xfe\xff\xc6\xd2\xa4\x93\x40\x17\x12\x79\x00\x33\x29\x2b\xff\x62\x28\x9c\xf2\xa5\x2f\xa3\x17\x95\x6a\xa6\xc4\xe5\xbe\xd3\xfe\xa7\x2a\xa0\x83\x7d\x5f\x7c\xa7\x6d\x39\xb6\x4c\x26\x61\x56\x03\xa1\x94\xce\xbd\x3f\x75\xae\x5c\x45\x1b\x9b\xd6\xf7\xb3\x79\xce\x0a\xd1\xc4\xb5\xb7\x8b\x46\x5d\x0e\xe4\x90\xe9\x38\x26\xac\x9f\xbf\xc3\x31\xdc\xf0\x58\x0f\xb8\x31\xeb\xf5\xe0\xaa\xf7\xcb\x46\x1c\x2b\x29\x8a\x7d\x01\x2d\xf2\xac\xe3\x0c\x73\x8f\xd3\xd4\x40\x15\x80\xb2\x1d\xd9\x19\xbd\xfc\xba\xf1\xff\xb0\xc2\x27\x18\xf7\xeb\x75\x91\x57\xd3\xa0\x53\xf6\x7c\xaf\xad\xf8\x6a\xd8\x15\xaa\xaf\x26\x5c\xe6\x28\xc3\xcd\xee\xe9\xeb\x7a\x9a\x1e\x67\x06\x93\xf3\x30\xae\x46\xc6\xf1\xb2\x84\xdc\x64\x2b\x53\x77\x7a\x1a\x34\xff\x25\x6b\xfe\x7e\xd2\x3c\xdc\x3c\x7d\xb9\x97\x35\x68\xea\x9c\x93\x4f\xb1\xe4\x3c\x13\xb6\x85\x7b\x19\x6e\x53\x69\x76\xad\x0e\x62\x37\x0c\x14\x81\x80\xaf\x10\xd5\x67\xf4\xe5\xf2\x9e\xcd\x5b\x1f\xcc\x30\xa9\x69\xfd\x68\x39\x3d\xd8\xda\x84\x0a\x73\x55\x26\x0d\x06\xc4\x81\x15\x0c\xd2\x31\x02\x25\x60\x6c\x0c\x05\x95\x7a\x91\x15\x69\x79\x06\x3a\x3e\xe3\x21\x8e\xc1\x55\xd5\x39\x8b\x85\xe0\x15\x69\x35\x28\xbe\x74\xd2\x48\x52\x6b\x38\x7b\xf1\x02\xe4\x4f\x67\xed\xd4\x4b\xc2\x46\x36\x6f\xc4\xdd\x05\x87\x5c\x95\x38\x4d\xcb\x58\xeb\x9c\x3c\xe2\xb9\xe1\x28\x44\x5d\x54\x68\xe1\x09\xfa\x89\x14\x77\x2f\x69\x5e\x1b\x44\xa3\x3c\xcb\x4c\x3f\x10\x3a\x70\x1d\x8f\x36\x70\x16\x49\x4d\x43\x73\xdb\x42\x0e\x38\x46\x7d\xbe\xf0\x65\xa9\x43\xb9\xf4\x02\x9c\x65\xfe\x65\xd8\x36\x4d\x17\xc8\x96\x27\xf3\x7e\xb0\xd1\xfa\xbf\xa2\xf7\xfa\xf3\x7a\x52\x65\x88\xe4\x52\x53\x90\x4d\x89\xa0\x8e\x8d\x20\xdd\xfe\x76\x1a\x31\xdb\x13\xdc\xdf\x3b\x1f\x90\xc7\x65\x65\xe1\xe0\x13\x2b\x36\x06\xd7\xd9\x21\x1a\x1e\x8d\xef\xb2\x2b\xc5\x54\x16\x20\x38\x2e\x6e\xa0\x2c\x50\xca\x16\x9a\x0d\x66\x80\x76\x3a\x62\x35\xd9\x7d\x27\x3a\x36\x65\xaa\x82\xbe\xd9\xbe\xa1\xf4\x9c\xcd\x29\xbe\x56\xc6\xd3\x0b\x7a\xaa\x63\x2c\xce\x36\x43\x1a\x76\x35\x9d\xf5\x73\x49\x33\x73\xcb\x29\x66\x75\x89\x71\xc8\x85\xef\xbc\x50\x30\x9a\x04\x12\x3b\xb6\xc3\x02\x54\x74\x79\x9e\xe3\x19\x85\x4b\xbd\xb6\x1c\x8f\xe4\x10\x50\xff\x55\x4f\x2a\x8b\x0a\xf1\xdb\x21\xb0\x01\x8b\xac\xe0\x4a\xc5\x05\x6e\xf2\x2e\x60\x04\x9e\x7e\x7f\x80\x4f\xeb\xcd\xa8\x8a\xa1\x0d\x8b\x63\x30\x7a\x3c\x9a\x90\xe6\xfb\x0e\x6c\x1a\x7c\x8d\xfc\x72\x86\xeb\x81\x29\x91\xd5\x0a\x5d\x6a\xa8\x16\x99\xa2\x23\x0b\x8c\x0b\x92\x67\x33\x88\xa1\x8a\x85\xa5\xd3\xf8\x53\xd8\xe5\xbe\x33\x38\xc4\xbb\x0d\x36\xdc\x9b\xd7\xc8\xa5\x13\xe4\x0d\x4c\x27\xc1\x34\x93\x13\xda\x6c\x7d\x62\x0e\x58\x75\xd9\xbb\x0b\x17\x8e\x44\xff\xca\x70\x79\x6f\x52\x1c\xe8\xfb\x0b\x36\x2d\x89\xc9\x26\xdf\x0f\x56\x3d\xed\xb6\x79\xd5\xb5\x46\xf7\x56\x8f\x95\xf1\x1b\x60\xac\x3a\x6f\x52\xe4\xfe\x64\xdd\x2d\x5b\xe1\x62\x9d\x63\xac\x0c\xbb\xd1\x11\x6c\xf4\x5e\x17\x2f\x5d\xd0\x07\x37\xa0\x50\x15\x88\x89\x4d\x90\x86\x44\x06\x4d\xcd\x7b\xbd\x33\xc9\xc0\x58\xe1\x47\x64\xd1\xd4\x7d\x7f\xbb\x1b\x24\x9a\x71\x2d\x67\xd3\xcd\xe3\x6e\x77\x7d\x14\x19\xed\xf4\xa8\x7f\x73\x69\x76\x9d\xa3\x74\x95\xfe\xb9\xa8\xe7\xc5\x5c\x30\xf2\x66\x6b\x38\xb1\x9d\x1f\xf3\x15\xf7\x9e\xaa\x22\xe4\xed\xea\xbf\x00\x00\x00\xff\xff\x72\xfd\x70\xb8\xa3\x0e\x00\x00")
+var _complySoc2PoliciesVendorMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x57\x4d\x6e\x23\xcd\x0d\xdd\xeb\x14\x04\x66\x11\x1b\x90\x85\x7c\xb3\x4a\xbc\xf3\xe7\x95\x82\x6f\x10\x63\x2c\xcc\x9e\xaa\xa2\xba\x99\xa9\xae\xea\x90\xd5\x32\x3a\xab\x5c\x23\xd7\xcb\x49\x02\x56\xff\x4a\x96\x3d\xd9\x8c\x35\xea\xae\xe2\xe3\xe3\xe3\x23\x15\xb1\xa1\x47\xf8\x41\xd1\x27\x81\x6f\x18\xb1\xa2\x86\x62\x86\x97\x14\xd8\xf5\x1b\x74\x92\x62\xdf\x3c\xc2\x8f\x6f\x2f\x1b\xc5\xcc\x7a\x62\xd2\xc7\x0d\xc0\xe1\xf5\xd9\xfe\x00\x3c\xc0\xf3\xf3\x5f\x77\x5f\x37\x0d\xfe\x23\xc9\x77\x3a\xb3\x72\x8a\xe5\x95\x07\xf0\x98\xe9\x11\xfe\xd6\x45\xf8\x0d\xbe\xfe\xf9\xb7\xbf\x94\x03\x2e\x35\x16\xe2\x11\xf6\x91\x33\x63\x00\x9f\x5c\x67\xdf\x6c\x1e\x1e\x1e\x36\x9b\x2f\xf0\xd2\x49\x9b\x94\x00\xa3\x87\x57\x97\x5a\xda\x6c\x70\x07\x87\x9a\x15\xda\x02\x0b\x3c\x9d\x38\x92\x42\xae\x09\xa4\x0b\xa4\x70\x4a\x02\x42\x01\xb3\x05\xaf\xb9\x55\x78\xe3\x5c\x97\x17\x92\x54\x18\xf9\x5f\xe5\xd1\x7f\xff\xfd\x1f\x85\x7d\x3c\x25\x69\xca\xff\xe1\x40\xae\x8e\x29\xa4\xaa\x87\xbb\xfd\xe1\x1e\xce\x85\x09\x2d\xb1\x5b\x94\x1c\x49\x74\x07\xef\x00\x60\xdb\x06\x36\x00\x09\x30\x04\xd8\x1f\x6e\x1e\x84\xb7\x3a\x41\x8d\x67\x2a\x38\xf0\xc8\x81\x73\x6f\x67\xb8\x69\xd1\xe5\xf2\xad\x4b\xf1\xc4\x9e\xa2\x31\xc1\xb9\xdf\x02\xc7\x4c\x95\x94\x8f\x06\x02\xcf\xc8\x61\x3a\x9a\x4e\xb7\x33\xca\x4b\x16\x76\x46\x29\x2a\x67\x3e\x13\xf0\x92\xea\x16\x92\x14\x40\x28\x54\xc8\xe1\x58\x2e\x53\x63\xf8\xc3\x9b\x57\x17\x80\x92\xeb\x0c\x18\xb4\x92\x2a\xc1\x66\xf7\x2b\x5a\xa8\x69\x43\xea\x89\x06\x3a\x5d\x8a\x59\xd0\xe5\x24\x56\x38\xcc\x05\x88\x90\xb6\x29\x2a\x1f\x03\x95\x1a\x1a\x88\x66\x91\xa1\x9d\x4b\x67\x12\xe5\xaa\xce\x06\xf2\x23\xa6\x6f\xe0\xb7\xb2\x7d\x81\xdf\xd1\xfd\xac\x24\x75\xd1\x8f\x68\xa9\x5c\x68\xf0\xe6\x7c\x6e\x1c\x06\x56\xa8\xb9\xaa\x43\x0f\x9e\x5a\x8a\x56\x21\x48\x23\x63\xab\x73\x9c\x75\x4e\xac\xc3\x70\x4b\x83\xf6\xca\xfe\x00\xda\x15\x72\xae\x80\xdf\xd6\x35\x08\xfd\xb3\x63\x29\x1c\x0c\xda\xa6\xd3\x89\x5c\x29\xe9\x67\xec\x68\xe7\xea\x0f\x02\xc1\x49\x52\x03\x18\xd7\x92\x58\x32\x69\x49\xb4\x1d\x02\x18\x22\x9a\x00\xb5\x42\xea\x84\x8f\xa4\xd0\x70\xe4\xa6\x6b\x40\x33\x46\x8f\xe2\x15\x70\x2c\x05\x34\x9d\x66\x68\x88\xf2\xe7\x41\xca\xc9\x36\x71\xcc\xa6\x72\x17\x3a\xcf\xb1\x5a\x1e\xbb\x80\x9d\x92\x6e\x41\x58\x7f\x02\xaa\x92\xaa\xa5\xa9\x5b\x50\x92\x33\x3b\x82\x40\x67\x0a\x80\x95\x10\x8d\x4f\x4c\x20\x1c\x5d\xe9\xa0\x95\x70\x76\x56\xfa\xef\x74\x22\xa1\xe8\x48\x4b\xe9\xd7\x6d\xff\x3a\x05\x1d\x7d\xce\x9e\xcf\xdf\xed\xa7\xfb\xbe\x0f\xe2\xa4\xf9\xad\x2f\xeb\xf7\xd7\x52\x14\xb2\xa6\xa8\xf9\xc8\x99\xfc\xc8\x82\x73\xa4\x6a\x19\xfe\xff\x8d\x65\x49\x67\x85\x2e\x66\x0e\x00\x38\x2b\xab\x7c\x40\x8e\x97\x7c\xd9\xc3\x14\xd4\xa4\x5a\x28\xf1\xa5\xef\xac\xff\xb9\x8a\xe4\xe1\xd8\x17\xb9\x62\xdb\x4a\x6a\x85\x31\x53\x51\x03\x93\x0e\x9d\xfb\x74\xe9\x5c\xa5\x8a\x2e\x35\x6d\xe8\x17\xf3\x9c\xa3\x15\x41\x98\xe5\x0d\x1a\xf5\x45\x5c\x9e\x84\xcf\x53\xc2\xf6\xfa\x27\x1c\xc3\x9d\x4c\xf5\x80\x3b\xbc\xbf\x1f\x5d\xf5\x69\xdd\x88\x53\x25\xd5\xb0\xaf\xa0\x25\x59\x74\x5c\x60\x1e\x69\x9e\x1a\x64\x02\x00\x74\x2e\x89\xc7\xe8\xe8\x13\xe3\xff\x65\x85\x2f\x30\x1e\xef\xef\x4b\x92\xdc\x34\xe4\x8d\xbd\xd0\x5b\x2b\xbe\xa1\xf8\x81\xea\x0f\x13\x1e\xe6\xa8\xc0\xdd\xfe\xf5\xdb\x94\xe7\xe1\x0a\xd0\xd0\x34\xe8\x6b\x12\x9a\x2e\xcb\x24\x4d\xb1\x32\x73\xa7\xd7\x51\xf3\x7f\x14\xcd\x3f\xcd\x9a\x87\xbb\xd7\x3f\x9e\xf4\x1e\x2c\x75\x29\xc9\xe7\x34\xe4\xbc\x10\xb6\x83\x27\x1d\x6f\x33\x93\xed\x5a\x1b\xc4\x7e\x1c\x28\x0a\x91\xde\x20\x99\xcf\xd8\xc3\xf5\x3d\xdb\x77\xbc\x0d\x30\xb9\x69\xc3\x64\x39\x3d\xb8\x1a\x63\x45\x6a\xf3\x64\xd6\x60\x24\x1a\x59\xa1\xa8\x9d\x10\x70\x06\xa1\x06\x39\xaa\x95\xa7\xc8\x8a\xad\x3c\x23\x1d\xbf\xd3\x29\x4d\xc1\x4d\xd5\x16\x7d\x2d\x78\x43\x5a\x8d\x8a\x1f\x3a\x69\x22\xa9\x45\x31\x20\x6b\x90\x7f\xba\x6a\xa7\x5e\x33\x35\xba\x5d\x2b\xa8\xa4\xd1\x45\x4f\x52\x0d\x71\x9a\x56\xa8\xb6\x39\x79\xa6\x6b\xc3\x31\x88\xb6\xa8\xf0\xca\x13\xec\x15\x1d\xdc\x7d\x48\x73\x75\xf7\x85\xcd\x4e\xf2\x2c\xfa\xe2\x13\x93\x07\xdf\xc9\x64\x03\x57\x91\xcc\x34\x2c\xb7\x1d\x94\xcd\x68\x8a\x7a\xb8\xf1\x66\xb9\x77\xbc\xf4\x06\x9c\x75\xfe\x86\xc7\x96\xac\x2e\xb2\x1b\xbe\x59\xf6\x83\x2d\xa0\xc2\x1b\x85\x60\x7f\x3f\x4e\x6a\x18\x22\xa5\xd4\x1c\x75\x6b\x09\x0f\x8e\x4d\xa0\xdd\xf1\x61\x1e\x31\xbb\x0b\xdc\x3f\xba\x10\x49\xa6\x65\x65\x01\xbf\xb0\xe2\x52\xf4\x9d\x1b\xa3\xd1\x19\x43\x57\x5c\x29\x65\xcb\x0b\x03\x9c\x57\x37\x98\xd9\xa0\x70\xb1\xd0\xe2\xa8\x23\xb4\xcb\x11\x6b\xc9\x1e\x3b\xb5\x75\x50\xe7\x2a\xd8\xf0\xbd\x84\x66\x94\x5e\xb3\x39\xc7\xb7\xca\x04\xfe\x49\x81\xeb\x94\x7c\xe9\x92\x05\xd2\xb8\xab\xa5\xd3\xba\xa4\x85\xb9\xf5\x14\x73\xb6\xc4\x78\x92\x81\xef\xb2\x50\x08\x61\x06\x4d\x9d\xb8\x71\x01\x1a\x74\x79\x9d\xe3\x15\x85\x6b\xbd\xb6\x92\xce\xec\x09\xc8\xfe\x35\x4f\x2a\xf7\x12\xcb\xfb\x21\xb0\x05\x47\x92\xf9\x34\x56\x5c\xe1\xae\xec\x02\xa8\xf0\xfa\xf7\x67\xf8\x7a\xbf\x9d\x54\x31\xb6\xe1\xe0\x18\x42\x81\xce\x18\xf3\x72\xdf\x49\xb0\xa1\xb7\x24\x3f\xaf\x70\x3d\x0b\x67\x76\x18\x66\xd1\x5f\x34\x54\x4b\xc2\xc9\xb3\x03\xa1\x15\xc9\x8b\x19\xa4\x58\xa5\x81\xa5\xc9\x06\x86\xf8\x73\xd8\xf5\xbe\x33\x3a\xc4\xa7\x0d\x36\xde\x5b\xd6\xc8\xb5\x13\x94\x0d\xcc\x26\xc1\x3c\x93\x33\x99\xdc\xb6\xa0\x78\xa2\xaa\x2b\xde\x3d\x70\xe1\x59\xed\x57\x86\x37\xbf\x2d\x38\x28\xf4\x37\x6c\x5a\xb3\xb0\xcb\xa1\x1f\xad\x7a\xde\x6d\xcb\x2f\x00\x87\xb6\xb7\x06\xaa\x30\x6c\x41\xa8\xea\x02\xe6\x24\xfd\xc8\xf5\x7a\x2b\x5c\xad\x73\x42\x15\x8a\x9f\x1c\xc1\xa5\x10\x6c\xf1\xb2\x05\x7d\x74\x03\x8e\xd5\x00\x31\x0b\x46\x6d\x58\x75\xd4\xd4\xb2\xd7\x7b\xcc\x08\x53\x85\x5f\x48\xd4\x52\x0f\xfd\xc3\x7e\x94\x68\xc1\xb5\x9e\x4d\x77\x2f\xfb\xfd\xc7\xa3\x08\xad\xd3\x93\xfd\xe6\xb2\xec\x3a\xcf\xf9\x43\x7f\x5b\x8a\x7a\x5d\xcc\x15\x23\xef\xb6\x86\x0b\xdb\xf9\x35\x5f\xe9\x18\xb8\xc2\xcc\x29\xea\x6e\xf3\xbf\x01\x00\x72\xfd\x70\xb8\xa3\x0e\x00\x00")
 
 func complySoc2PoliciesVendorMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -1041,12 +1062,12 @@ func complySoc2PoliciesVendorMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/vendor.md", size: 3747, mode: os.FileMode(420), modTime: time.Unix(1757470844, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/vendor.md", size: 3747, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2PoliciesWorkstationMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xc4\x54\xcd\x8a\x23\x37\x10\x3e\xa7\x9f\xa2\x60\x21\x87\x61\xec\x64\x73\x08\x8b\x6f\x89\x37\x84\x0d\x59\x66\x58\x0f\x0c\x39\xca\x52\xd9\x5d\x6b\x49\xd5\xa8\x4a\xee\x74\x9e\x3e\x48\xea\xf6\xd8\x33\xb0\xd7\xbd\xd8\xd0\x92\xbe\xbf\xfa\xa4\x68\x02\x6e\xe0\x99\xd3\x49\xd4\x28\x71\x84\x47\xf6\x64\xa7\xce\xd8\xc4\x71\x0a\x1b\x78\x7e\xec\xc4\x28\xc9\x81\x50\x36\x1d\xc0\xd3\x6e\x5b\xfe\x00\x56\xb0\xdd\xfe\xba\xfe\xd0\x05\xf3\x95\xd3\x17\x3c\x93\x10\xc7\xba\x65\x05\xce\x28\x6e\xe0\xaf\x1c\xe1\x3d\xfc\xf2\xf3\xfb\x0f\xf5\x80\xe5\x10\x30\xea\x06\x3e\x45\x52\x32\x1e\x1c\xdb\x5c\xbe\x74\xab\xd5\xaa\xeb\xde\xc1\x63\x4e\x03\x0b\x82\x89\x0e\x76\x96\x07\x84\xae\x33\x6b\x78\xea\x49\x60\xa8\xb2\xc0\xe1\x81\x22\x0a\xec\x51\x14\x86\x64\xac\x92\x45\x01\x65\x48\xe8\xb2\x45\xd0\x1e\x21\x91\x9c\x80\x0f\x45\x85\x01\xcf\x22\x3f\xe1\xbf\x03\x4b\x4e\x65\x39\x71\x3e\xf6\x30\xbe\x38\x96\xf5\x5b\x1a\x33\x0c\x9e\x1a\xae\xf1\x1e\x30\x0c\x9e\x27\x44\xa9\xd2\x2c\x47\x2d\xcc\x9c\x64\x7d\x13\x1d\xc9\xac\xcf\x81\x91\xaa\xc4\xb2\xf7\x68\xeb\x22\x1f\x2a\x94\xe5\x30\x98\x38\xad\x78\xac\xdb\xa2\x83\x01\x93\x70\x2c\x71\xe0\xb9\x9a\x29\xf8\x86\x22\xc5\xe3\xb2\xbb\x3a\x29\x32\xdf\xcd\xe3\x69\x8a\xaf\xb9\x97\xc3\x21\x8b\x42\x40\xd4\xca\x7f\x60\xef\x79\xac\x48\x89\x14\x13\x99\x36\xbb\xfa\x43\x6b\x78\x18\x30\x19\x2d\xeb\x32\x89\x62\x68\xc7\xf7\x08\x91\x21\x70\xcd\xcb\x44\xe0\x88\x70\xc4\x58\xb7\x16\x27\xde\x61\x6a\x2b\x36\xa7\x54\x06\x78\x8d\xf9\xb1\x2a\xb9\x20\x61\xb4\x69\x1a\xb4\x98\x55\x48\x28\xdf\xdc\xec\xd9\x9e\xd0\xc1\xd8\x63\x84\xc8\x0a\x14\x21\x0b\x02\xa7\xf6\x69\x99\x03\x78\x34\x67\x6c\x11\x5f\x4d\x12\x6e\xa0\xaf\xd2\x91\x0b\x41\x16\x74\x70\xe0\x04\x26\x6b\xcf\x89\xfe\x43\x07\xfb\x2c\xa5\x53\x02\x43\xeb\x9f\x00\x47\x3f\xdd\x82\xfd\xcd\x22\x45\x86\x43\xd1\x94\x2f\x13\x5d\x52\x97\x9e\xb3\x77\x85\x20\xe1\xc0\xa9\xb8\xa5\x10\xd0\x91\x51\x7c\x03\x65\x06\xe5\xa1\x55\xc9\xa1\x9c\x94\x87\xd7\x38\x29\xc7\xea\xcd\x1b\x2d\x3d\x3f\x63\x92\xa5\x42\x51\xe9\x4c\x29\x0b\x08\x1f\x74\x34\x6d\x44\x0a\xbd\x29\x77\x02\x63\x69\x6e\xe2\x73\x71\x35\xc1\xa7\xa7\xd6\x93\xbb\x8f\x33\xcd\x8f\xe0\x2b\xf9\xc2\x77\xd7\x2d\xa2\xfe\xb8\x34\x7c\x24\xef\x8b\x11\x12\xc9\x65\x68\x8b\xc6\xfb\xf9\xec\x7d\x89\x61\xcf\xda\x17\x86\xd6\xf1\xda\xd1\x7b\xd8\x9b\x12\x2e\x57\xe9\x94\xe0\x2b\xef\xc1\x65\x25\x94\x35\x6c\x5f\xae\x4c\x23\x28\x22\xc9\xe1\xbc\x95\xc7\x38\xa3\xd7\xcb\xf8\xc3\x5c\x8d\xca\xdb\x82\x9a\x57\xdb\x1c\xb9\xb6\x16\x0b\x55\x30\xf6\x61\x57\x14\x3d\x53\x74\x3c\xca\xba\x19\xfe\xcc\x7b\xf2\xf8\xd6\xe6\xed\xf7\x4b\x2b\x66\xc0\x7a\x6d\x97\x1b\x4c\x6d\x04\x5f\x30\xf0\xd9\xec\x3d\xc2\xe7\x32\xce\xf9\xfe\xdd\xc3\xd6\x73\x76\xb0\x53\x4e\xe6\x88\xf7\x55\xe3\xef\xa9\x5c\xa4\x7f\x38\x27\x78\x18\xe3\x52\xed\x76\xa0\xd8\xfa\x86\x86\x2b\x47\xd4\xfc\xfc\x16\x5d\x62\x72\xeb\xcb\xb1\xed\xd5\x53\x00\xc1\x4c\xad\xa4\x7b\x04\x63\x2d\xca\x9c\x7c\xb8\xc5\x1e\x49\x7b\xd8\x79\x63\x4f\x55\xe0\x9f\xc1\x90\x9f\x5f\xbb\xbb\x17\x63\xb5\xa7\x2f\x19\xbd\x5a\xf8\x3e\x21\xbd\x16\x51\x9e\x66\x4c\x81\x54\x9b\xd1\x4b\xcd\x17\xab\x46\xc0\x73\x3c\x96\x7f\x52\x70\x8c\x52\x1f\x10\xcb\xf1\xe0\xc9\x6a\x8b\x82\xb5\xc7\xd4\xde\xf8\x5a\xcb\xee\xff\x00\x00\x00\xff\xff\x2d\x5e\x5a\x8c\xff\x06\x00\x00")
+var _complySoc2PoliciesWorkstationMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xc4\x54\xc1\x6e\xe3\x36\x10\x3d\x57\x5f\xf1\x80\x05\x7a\x08\x62\xb5\xdb\x43\xb1\xc8\xad\xf5\x16\xc5\x16\x5d\x24\xd8\x04\x08\x7a\xa4\xc8\xb1\x35\x6b\x8a\x43\x70\x28\xab\xee\xd7\x17\xa4\x24\xc7\x4e\x80\xbd\xee\xc5\x06\x44\xce\x9b\xf7\xde\xbc\x61\x30\x03\xdd\xe1\x59\xd2\x41\xb3\xc9\x2c\x01\x0f\xe2\xd9\x9e\x1a\x63\x93\x84\xd3\x70\x87\xe7\x87\x46\x4d\x66\xdd\x31\xe9\x5d\x03\x3c\x3d\x6e\xcb\x1f\xb0\xc1\x76\xfb\x6b\xfb\xa1\x19\xcc\x57\x49\x5f\xe8\xc8\xca\x12\xea\x95\x0d\x9c\xc9\x74\x87\xbf\xc6\x80\xf7\xf8\xe5\xe7\xf7\x1f\x6a\x81\x95\x61\xa0\x90\xef\xf0\x29\x70\x66\xe3\xe1\xc4\x8e\xe5\x4b\xb3\xd9\x6c\x9a\xe6\x1d\x1e\xc6\x14\x45\x09\x26\x38\x3c\x5a\x89\x84\xa6\x31\x2d\x9e\x7a\x56\xc4\x4a\x0b\x8e\x76\x1c\x48\xd1\x91\x66\xc4\x64\x6c\x66\x4b\x8a\x2c\x48\xe4\x46\x4b\xc8\x3d\x21\xb1\x1e\x20\xbb\xc2\xc2\xc0\x8b\xea\x4f\xf4\x6f\x14\x1d\x53\x39\x4e\x32\xee\x7b\x4c\x2f\x8a\xb5\x7d\xdb\xc6\xc4\xe8\x79\xc6\x35\xde\x83\x86\xe8\xe5\x44\xa4\x95\x9a\x95\x90\x4b\x67\x49\xda\x5e\x59\xc7\xba\xf0\x73\x30\x5a\x99\x58\xf1\x9e\x6c\x3d\x94\x5d\x85\xb2\x32\x44\x13\x4e\x1b\x99\x02\xb9\x0a\x17\x29\xa9\x84\x62\x07\x1d\xab\x98\x82\x6f\x38\x70\xd8\xaf\xb7\xab\x92\x42\xf3\xdd\x32\x9e\x99\xf1\x65\xef\xb5\x78\x18\x35\x63\x20\xca\xb5\xff\x4e\xbc\x97\xa9\x22\x25\xce\x94\xd8\x94\xf9\x00\xf5\x87\x5b\xdc\x47\x4a\x26\x97\x73\x3d\x69\xa6\x61\x2e\xef\x08\x41\x30\x48\xf5\xcb\x04\x48\x20\xec\x29\xd4\xab\x45\x89\x77\x94\xe6\x13\x3b\xa6\x54\x06\x78\x89\xf9\xb1\x32\x39\x23\x51\xb0\xe9\x14\x73\x11\x9b\x91\x48\xbf\x79\xd9\x8b\x3d\x90\xc3\xd4\x53\x40\x90\x0c\x0e\x18\x95\x20\x69\xfe\xb4\xce\x01\x9e\xcc\xb1\x8c\xa7\xa7\xcb\x49\xe2\x0a\xfa\xc2\x9d\xc5\x96\x8e\x0a\x9a\xc3\x4e\x12\xcc\x98\x7b\x49\xfc\x1f\x39\x74\xa3\x96\x4c\x29\xe2\x9c\x3f\x85\x04\x7f\xba\x06\xfb\x5b\x54\x0b\x0d\x47\x9a\xd3\x78\x9e\xe8\xea\xba\xf6\x32\x7a\x87\x8e\x90\x28\x4a\x2a\x6a\x79\x18\xc8\xb1\xc9\xf4\x06\xca\xc4\x2c\x71\x8e\x92\x23\x3d\x64\x89\xaf\x71\xd2\x18\xaa\x36\x6f\x72\xc9\xf9\x91\x92\xae\x11\x0a\x99\x8f\x9c\x46\x85\xca\x2e\x4f\x66\x1e\x51\x46\x6f\xca\x4e\x50\x80\x89\x31\xc9\xb1\xa8\x3a\xe1\xd3\xd3\x9c\x93\x9b\x8f\x4b\x9b\x1f\xe1\x4d\xbc\xe8\x77\xd3\xac\xa4\xfe\x38\x27\x7c\x62\xef\x8b\x10\x56\x1d\xcb\xd0\x56\x8e\xb7\x4b\xed\x6d\xb1\xa1\x93\xdc\x97\x0e\x85\xe4\x92\xd1\x5b\x74\xa6\x98\x2b\x95\x3a\x27\x7c\x95\x0e\x6e\xcc\x4c\xda\x62\xfb\xb2\x32\x73\x83\x42\x92\x1d\x2d\x57\x65\x0a\x0b\x7a\x5d\xc6\x1f\x96\x68\x54\xd2\xb3\x51\xcb\xe9\x3c\x47\xa9\xa9\x25\x48\xc0\x60\xec\xfd\x63\x61\xf4\xcc\xc1\xc9\xa4\xed\x2c\xf8\xb3\x74\xec\xe9\xad\xcc\xeb\xef\xe7\x54\x2c\x80\x75\x6d\xd7\x0d\xe6\xaa\x03\x5f\x68\x90\xa3\xe9\x3c\xe1\x73\x19\xe7\xb2\x7f\xb7\xd8\x7a\x19\x1d\x1e\xb3\x24\xb3\xa7\xdb\xca\xf1\xf7\x54\x16\xe9\x1f\x19\x13\xee\xa7\xb0\x46\x7b\x2e\x68\xf1\x4d\x0e\x17\x8a\x78\xd6\xf3\x5b\x70\x49\xd8\xb5\xe7\xb2\xed\xc5\x53\x80\xc1\x9c\xe6\x90\x76\x04\x63\x2d\xe9\xe2\xfc\x70\x8d\x3d\x71\xee\xf1\xe8\x8d\x3d\x54\x82\x7f\x0e\x86\xfd\xf2\xda\xdd\xbc\x08\xab\x39\x7d\xf1\xe8\xd5\xc1\xf7\x31\xe9\x35\x89\xf2\x34\x53\x1a\x38\x17\x02\x72\x11\xf3\x55\xaa\x51\x78\x09\xfb\x32\x41\xce\x70\x42\x5a\x1f\x10\x2b\x61\xe7\xd9\xe6\xd9\x0a\xc9\x3d\xa5\xf9\x8d\x67\xd2\x16\xcd\xff\x03\x00\x2d\x5e\x5a\x8c\xff\x06\x00\x00")
 
 func complySoc2PoliciesWorkstationMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -1061,12 +1082,12 @@ func complySoc2PoliciesWorkstationMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/policies/workstation.md", size: 1791, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/policies/workstation.md", size: 1791, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2ProceduresReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x44\xc6\xc1\x09\x02\x41\x0c\x05\xd0\xfb\x54\xf1\xc1\xbb\xb5\xd8\xc2\x18\xbf\x18\x06\x32\x21\x3f\x2e\x6c\xf7\x7b\xdc\xdb\x7b\xe0\x55\xdb\xf8\xf9\x17\x35\xc6\x6d\x64\x51\x56\xfe\x26\x94\x34\xff\xba\x41\xcd\x14\xfa\x37\x1b\xb3\x88\x9e\x8b\x01\x0f\x14\x95\x3b\x44\xf4\xc6\xe2\x09\x1e\x8c\xd6\x73\x5c\x01\x00\x00\xff\xff\x7b\xb0\x4d\xeb\x5c\x00\x00\x00")
+var _complySoc2ProceduresReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x5c\x00\xa3\xff\x23\x20\x50\x72\x6f\x63\x65\x64\x75\x72\x65\x73\x0a\x0a\x50\x72\x6f\x63\x65\x64\x75\x72\x65\x73\x20\x70\x72\x65\x73\x63\x72\x69\x62\x65\x20\x73\x70\x65\x63\x69\x66\x69\x63\x20\x73\x74\x65\x70\x73\x20\x74\x68\x61\x74\x20\x61\x72\x65\x20\x74\x61\x6b\x65\x6e\x20\x69\x6e\x20\x72\x65\x73\x70\x6f\x6e\x73\x65\x20\x74\x6f\x20\x6b\x65\x79\x20\x65\x76\x65\x6e\x74\x73\x2e\x0a\x03\x00\x7b\xb0\x4d\xeb\x5c\x00\x00\x00")
 
 func complySoc2ProceduresReadmeMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -1081,12 +1102,12 @@ func complySoc2ProceduresReadmeMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/procedures/README.md", size: 92, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/procedures/README.md", size: 92, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2ProceduresOffboardingMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\x8e\xb1\x6e\x2a\x31\x10\x45\xfb\xfd\x8a\xab\xed\xf7\x07\xe8\x5e\xf7\x22\x45\x42\x02\x25\x4d\x94\x62\xf0\xce\x86\x11\xb6\xc7\x99\x19\x93\xf0\xf7\x11\xb0\x05\x94\xf6\x9c\x73\x75\x64\xde\x60\xd4\x65\x39\x28\xd9\x3c\x0e\x95\x0a\x6f\x30\x6e\xd7\x0f\xbc\x39\xdb\x38\x4c\xd3\x34\x0c\x3b\x76\xcd\x67\x46\x1c\xc5\x11\x92\x4e\x1c\x38\x5c\xc0\xbf\x9c\x7a\x48\xfd\x42\x1c\x19\x8b\xe6\xac\x3f\xd7\x97\x07\x37\xdf\x0c\xc3\x84\x0f\x7c\xe2\xa5\x14\x9e\x85\x82\xf3\x05\xde\xbd\x71\x9d\xd1\x9d\x0d\x52\xb1\xdf\x6f\x57\xea\x5f\xbb\x1d\xfe\xef\x10\x6c\x45\x2a\x85\x68\x85\xf1\x77\x67\x0f\xf0\x54\x48\x32\x42\x1f\x13\x56\xf3\x55\xf5\x84\xde\x50\xa8\x76\xca\xf9\x32\x35\xd3\xb3\xb8\x68\xe5\x19\xd4\x5a\x96\x74\x1b\x73\x2c\x6a\x77\xdf\x34\x33\xd4\x6e\x19\xeb\xca\x3b\x65\x99\x29\x18\x94\x12\xbb\xc3\xf8\xac\x77\xef\xda\xc9\x94\x8e\xcf\xa1\x49\xeb\x22\x56\xee\x84\xda\x23\xff\x5c\xf9\x17\x00\x00\xff\xff\xdb\xfb\x69\xca\x66\x01\x00\x00")
+var _complySoc2ProceduresOffboardingMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\x8e\xb1\x6e\x2a\x31\x10\x45\xfb\xfd\x8a\xab\xed\xf7\x07\xe8\x5e\xf7\x22\x45\x42\x02\x25\x4d\x94\x62\xf0\xce\x86\x11\xb6\xc7\x99\x19\x93\xf0\xf7\x11\xb0\x05\x94\xf6\x9c\x73\x75\x64\xde\x60\xd4\x65\x39\x28\xd9\x3c\x0e\x95\x0a\x6f\x30\x6e\xd7\x0f\xbc\x39\xdb\x38\x4c\xd3\x34\x0c\x3b\x76\xcd\x67\x46\x1c\xc5\x11\x92\x4e\x1c\x38\x5c\xc0\xbf\x9c\x7a\x48\xfd\x42\x1c\x19\x8b\xe6\xac\x3f\xd7\x97\x07\x37\xdf\x0c\xc3\x84\x0f\x7c\xe2\xa5\x14\x9e\x85\x82\xf3\x05\xde\xbd\x71\x9d\xd1\x9d\x0d\x52\xb1\xdf\x6f\x57\xea\x5f\xbb\x1d\xfe\xef\x10\x6c\x45\x2a\x85\x68\x85\xf1\x77\x67\x0f\xf0\x54\x48\x32\x42\x1f\x13\x56\xf3\x55\xf5\x84\xde\x50\xa8\x76\xca\xf9\x32\x35\xd3\xb3\xb8\x68\xe5\x19\xd4\x5a\x96\x74\x1b\x73\x2c\x6a\x77\xdf\x34\x33\xd4\x6e\x19\xeb\xca\x3b\x65\x99\x29\x18\x94\x12\xbb\xc3\xf8\xac\x77\xef\xda\xc9\x94\x8e\xcf\xa1\x49\xeb\x22\x56\xee\x84\xda\x23\xff\x5c\xf9\x37\x00\xdb\xfb\x69\xca\x66\x01\x00\x00")
 
 func complySoc2ProceduresOffboardingMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -1101,12 +1122,12 @@ func complySoc2ProceduresOffboardingMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/procedures/offboarding.md", size: 358, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/procedures/offboarding.md", size: 358, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2ProceduresOnboardingMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x90\xb1\x6e\xeb\x30\x0c\x45\x77\x7f\xc5\x85\xa7\xf7\x06\xff\x40\xb6\x6e\x9d\xda\x22\x40\xa7\xa2\x03\x23\xd1\x36\x51\x59\x52\x45\xda\xae\xff\xbe\x90\x93\xb4\xc9\xd4\x91\x82\x0e\xcf\xe5\x15\x7f\x40\x9b\xe2\x29\x51\xf1\x6d\x13\x69\xe2\x03\xda\xe7\xf3\x8c\x27\x5e\xf1\xaa\x5c\xda\xa6\xeb\xba\xa6\x39\xb2\xa6\xb0\x30\x6c\x14\x85\x89\xfb\x60\xc3\x69\x03\x7f\xb1\x9b\x4d\xe2\x00\x1b\x19\x7d\x0a\x21\xad\x75\x52\xe3\xac\x87\xa6\xe9\xf0\x86\x77\x3c\xe4\xcc\xd1\xe3\xf1\x08\xf2\x1e\x85\x3f\x67\x56\x03\x77\x13\x49\x80\xa5\xdb\xa5\x17\xe2\xa5\x24\x72\x26\x0b\x87\x0d\x0b\x05\xf1\x64\x8c\x92\x02\x83\x54\x65\x88\x13\x47\xc3\x2a\x36\x62\xa2\x48\x03\x17\xfc\x53\xe6\x6a\xb8\xdf\xfe\xff\x9a\xc0\x7b\xcc\xca\xa5\xda\x3c\xf7\x34\x07\xc3\x50\xd2\x9c\xd1\xa7\xb2\x67\xd7\xcc\x4e\x7a\x61\xbf\x6b\x7e\x63\x2c\xa2\x92\x22\x28\x6e\x55\x35\x53\x08\x5b\x97\xaf\xcf\xec\x41\x39\x07\x71\x64\x92\xa2\xd6\x46\x76\x1a\x00\xee\x4e\x3f\xa3\xf8\x01\x6b\x47\x2e\xc5\x5e\xca\xb4\xa3\x7f\xb7\x70\xf9\x7d\x3e\x3a\xf2\x7a\x39\x67\x24\xab\xf1\x37\x38\x8a\x20\xe7\x58\x15\x14\x6e\x4c\xec\xa1\x9b\x1a\x4f\xfa\x1d\x00\x00\xff\xff\x15\x19\xab\x13\xef\x01\x00\x00")
+var _complySoc2ProceduresOnboardingMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x90\xb1\x6e\xeb\x30\x0c\x45\x77\x7f\xc5\x85\xa7\xf7\x06\xff\x40\xb6\x6e\x9d\xda\x22\x40\xa7\xa2\x03\x23\xd1\x36\x51\x59\x52\x45\xda\xae\xff\xbe\x90\x93\xb4\xc9\xd4\x91\x82\x0e\xcf\xe5\x15\x7f\x40\x9b\xe2\x29\x51\xf1\x6d\x13\x69\xe2\x03\xda\xe7\xf3\x8c\x27\x5e\xf1\xaa\x5c\xda\xa6\xeb\xba\xa6\x39\xb2\xa6\xb0\x30\x6c\x14\x85\x89\xfb\x60\xc3\x69\x03\x7f\xb1\x9b\x4d\xe2\x00\x1b\x19\x7d\x0a\x21\xad\x75\x52\xe3\xac\x87\xa6\xe9\xf0\x86\x77\x3c\xe4\xcc\xd1\xe3\xf1\x08\xf2\x1e\x85\x3f\x67\x56\x03\x77\x13\x49\x80\xa5\xdb\xa5\x17\xe2\xa5\x24\x72\x26\x0b\x87\x0d\x0b\x05\xf1\x64\x8c\x92\x02\x83\x54\x65\x88\x13\x47\xc3\x2a\x36\x62\xa2\x48\x03\x17\xfc\x53\xe6\x6a\xb8\xdf\xfe\xff\x9a\xc0\x7b\xcc\xca\xa5\xda\x3c\xf7\x34\x07\xc3\x50\xd2\x9c\xd1\xa7\xb2\x67\xd7\xcc\x4e\x7a\x61\xbf\x6b\x7e\x63\x2c\xa2\x92\x22\x28\x6e\x55\x35\x53\x08\x5b\x97\xaf\xcf\xec\x41\x39\x07\x71\x64\x92\xa2\xd6\x46\x76\x1a\x00\xee\x4e\x3f\xa3\xf8\x01\x6b\x47\x2e\xc5\x5e\xca\xb4\xa3\x7f\xb7\x70\xf9\x7d\x3e\x3a\xf2\x7a\x39\x67\x24\xab\xf1\x37\x38\x8a\x20\xe7\x58\x15\x14\x6e\x4c\xec\xa1\x9b\x1a\x4f\xfa\x3d\x00\x15\x19\xab\x13\xef\x01\x00\x00")
 
 func complySoc2ProceduresOnboardingMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -1121,12 +1142,12 @@ func complySoc2ProceduresOnboardingMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/procedures/onboarding.md", size: 495, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/procedures/onboarding.md", size: 495, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2ProceduresPatchMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x90\x31\x4b\x03\x41\x10\x85\xfb\xfd\x15\x8f\xb3\x0b\x9c\x68\x61\x73\x20\x92\x22\x45\x1a\x13\x14\x6c\x44\xc8\xba\x37\xb9\x5b\xdc\xec\x2c\x33\xb3\xd1\xfc\x7b\xb9\xf3\x0a\x8b\x30\xd5\x3c\xbe\x79\x8f\x37\xb1\xef\xd0\x14\x6f\x61\x6c\x5c\xf6\x27\xea\xd0\xac\x4b\x49\x17\xec\x5e\x31\xcb\xa4\x8d\x0b\xc2\xb9\x43\x73\x87\x69\xee\x1f\xb0\xc2\xaa\x71\x6d\xdb\x3a\x77\x33\x71\xfb\x89\xc3\x5e\x38\x50\x5f\x85\x9c\x7b\x21\xe5\x74\x26\xd8\x18\x15\x16\xc3\x17\x19\x3e\x2f\xa0\x1f\x0a\xd5\x62\x1e\x60\x23\xe1\xc8\x29\xf1\xf7\xb4\xa9\x51\xd1\xce\xb9\x16\xef\xf8\xc0\xbe\xa6\x34\x03\xc9\x1b\xa9\x41\x83\xc4\x62\x8a\xa3\xf0\x69\xd6\x77\x45\x21\x54\x58\xa3\xb1\x5c\x96\xab\xcd\xec\x4d\x38\x6c\x9e\xdf\x1e\xd5\xfc\x30\x19\xcf\x05\x5a\x9f\xd2\xad\x8e\x87\x05\xdc\x66\x2d\x14\x0c\x5c\xad\x54\x73\x00\xb0\x38\x88\xb0\xe8\x13\xb6\xf9\x4c\x6a\x71\xf0\x46\xf0\xb9\x87\xfc\x95\xb9\x96\x53\x84\xfb\x1a\x2c\x72\xbe\x1a\xb5\x36\xf3\x61\x44\xe2\x61\x49\x83\xf1\xff\x9f\xfc\x06\x00\x00\xff\xff\xb4\x3f\x07\x6a\x7c\x01\x00\x00")
+var _complySoc2ProceduresPatchMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x90\x31\x4b\x03\x41\x10\x85\xfb\xfd\x15\x8f\xb3\x0b\x9c\x68\x61\x73\x20\x92\x22\x45\x1a\x13\x14\x6c\x44\xc8\xba\x37\xb9\x5b\xdc\xec\x2c\x33\xb3\xd1\xfc\x7b\xb9\xf3\x0a\x8b\x30\xd5\x3c\xbe\x79\x8f\x37\xb1\xef\xd0\x14\x6f\x61\x6c\x5c\xf6\x27\xea\xd0\xac\x4b\x49\x17\xec\x5e\x31\xcb\xa4\x8d\x0b\xc2\xb9\x43\x73\x87\x69\xee\x1f\xb0\xc2\xaa\x71\x6d\xdb\x3a\x77\x33\x71\xfb\x89\xc3\x5e\x38\x50\x5f\x85\x9c\x7b\x21\xe5\x74\x26\xd8\x18\x15\x16\xc3\x17\x19\x3e\x2f\xa0\x1f\x0a\xd5\x62\x1e\x60\x23\xe1\xc8\x29\xf1\xf7\xb4\xa9\x51\xd1\xce\xb9\x16\xef\xf8\xc0\xbe\xa6\x34\x03\xc9\x1b\xa9\x41\x83\xc4\x62\x8a\xa3\xf0\x69\xd6\x77\x45\x21\x54\x58\xa3\xb1\x5c\x96\xab\xcd\xec\x4d\x38\x6c\x9e\xdf\x1e\xd5\xfc\x30\x19\xcf\x05\x5a\x9f\xd2\xad\x8e\x87\x05\xdc\x66\x2d\x14\x0c\x5c\xad\x54\x73\x00\xb0\x38\x88\xb0\xe8\x13\xb6\xf9\x4c\x6a\x71\xf0\x46\xf0\xb9\x87\xfc\x95\xb9\x96\x53\x84\xfb\x1a\x2c\x72\xbe\x1a\xb5\x36\xf3\x61\x44\xe2\x61\x49\x83\xf1\xff\x9f\xfc\x0e\x00\xb4\x3f\x07\x6a\x7c\x01\x00\x00")
 
 func complySoc2ProceduresPatchMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -1141,12 +1162,12 @@ func complySoc2ProceduresPatchMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/procedures/patch.md", size: 380, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/procedures/patch.md", size: 380, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2ProceduresWorkstationMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x92\x41\x4f\x1b\x31\x10\x85\xef\xfe\x15\x4f\x39\x22\x82\x5a\xa9\xbd\xec\x0d\x05\x10\x51\xa9\x40\x64\x5b\x0e\x08\x09\xc7\x3b\x9b\xb8\xf1\xce\x6c\x3d\xe3\xa4\xf9\xf7\xd5\x86\x10\xd2\xaa\x95\x7a\xad\xf6\xb0\x1a\x7b\xec\xf7\xfc\xbd\x89\x4d\x85\xd1\x46\xf2\x4a\xcd\x5b\x14\x1e\x39\xf6\x1d\x55\x18\x4d\x24\x25\x0a\x86\x87\xb7\x3d\x5c\x90\xf9\x98\x74\xe4\x42\x16\xae\x30\x7a\x87\xe1\x7b\xff\x11\x1f\x70\x32\x72\xe3\xf1\xd8\xb9\x7b\x52\x49\x6b\x82\x2d\xa3\xc2\x62\x58\x91\x61\xbe\x05\xfd\xa0\x50\x2c\xf2\x02\xb6\x24\xb4\x92\x92\x6c\x86\x4a\x8d\x7a\xad\x9c\x1b\xe3\x11\x4f\x98\x11\x37\xbb\x86\x20\x5d\x57\x38\x86\x9d\xac\x62\x4e\x49\x36\xfb\x9e\x2b\xc9\xf0\xbc\x05\x75\x3e\x26\x64\xea\x53\x24\x3d\x85\x37\xf3\x61\x89\x20\x6c\xc4\x06\x93\x63\x07\xfb\xa3\x5f\x7d\x8a\x8d\x37\x42\x26\xed\x85\x95\x14\x3e\x0f\x55\xa0\xb8\xa6\x06\x6d\x96\x0e\xe4\xc3\xd2\x39\xf7\xfc\xfc\xec\x6a\xa9\x70\x41\xba\x32\xe9\xa1\xa5\xef\x25\x9b\x9b\x95\xf9\x37\x0a\x56\xe1\x9c\xb9\xf8\x84\x23\x74\x88\xbc\x26\x36\xc9\x5b\xe7\xee\x12\x79\xa5\x57\x57\xbb\x27\x95\x9c\x07\x67\x7f\x3c\xf0\xe2\xf7\x18\xcc\x8b\xf1\x0a\x8f\xf7\x97\x77\x37\xe7\x93\x4b\x3c\x4c\xeb\x6b\x7c\xb9\xbf\x41\x7d\x8b\xfa\x7a\x3a\x43\x3d\x9d\x7c\xba\xac\x9f\x9c\xab\x97\xf4\x97\x5b\x75\xe9\x53\x42\xe4\x90\x4a\x43\xbf\x09\xb4\x91\x52\xa3\x95\x03\x80\x13\xcc\x28\x47\x9f\xc0\xa5\x9b\x53\xde\xaf\x4d\x8a\x9a\x34\xd1\xf3\xbe\xbe\x2a\x29\xa1\x89\xba\x02\x71\xc8\xdb\x7e\x27\x36\x88\x16\xdd\x77\x7c\xf6\x69\x33\x10\xed\xb3\x18\x85\xe3\xfd\x01\xe7\x1b\xd4\xdb\x62\x2a\x25\x07\x6a\x30\x19\x1c\x4e\x88\x8d\x32\xa6\xf5\xbf\xe2\x3d\x57\xf4\x3e\x1b\xa4\x85\x94\x0c\xe1\x85\x0c\x6f\x0a\xd2\xf5\x29\x7a\x0e\x04\x6a\x5b\xc9\xa6\xf0\xdc\xa0\xa7\xbc\x6b\x53\xca\xeb\x18\x86\xd4\x17\x99\xa8\x23\xb6\x53\x6c\x86\xfc\xbf\x97\x98\x09\xfe\x90\xd1\x1b\x41\x69\x8f\x2d\x28\x22\xa3\x28\x0d\xbf\x81\xe6\xfe\xc2\x57\x1b\x3e\x04\x29\x6c\x67\x87\xf8\x5f\xe6\xac\x39\x4c\x63\x47\xaa\x7e\x41\xd8\x44\xfb\x75\x28\x0e\x82\x67\xff\x4d\x9c\x3f\x03\x00\x00\xff\xff\x89\xd2\xee\x65\x39\x04\x00\x00")
+var _complySoc2ProceduresWorkstationMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x92\x41\x4f\x1b\x41\x0c\x85\xef\xf3\x2b\x9e\x72\x44\x04\xb5\x52\x7b\xd9\x1b\x0a\x20\xa2\x52\x81\xc8\xb6\x1c\x10\x12\x93\x59\x6f\x32\xcd\xac\xbd\x1d\x7b\x92\xe6\xdf\x57\x1b\x42\x48\xab\x56\xea\xb5\xda\xc3\xca\x33\x1e\xfb\xf3\x7b\x8e\x4d\x85\xd1\x46\xf2\x4a\xcd\x5b\x14\x1e\x39\xf6\x1d\x55\x18\x4d\x24\x25\x0a\x86\x87\xb7\x3b\x5c\x90\xf9\x98\x74\xe4\x42\x16\xae\x30\x7a\x87\xe1\x7b\xff\x11\x1f\x70\x32\x72\xe3\xf1\xd8\xb9\x7b\x52\x49\x6b\x82\x2d\xa3\xc2\x62\x58\x91\x61\xbe\x05\xfd\xa0\x50\x2c\xf2\x02\xb6\x24\xb4\x92\x92\x6c\x86\x48\x8d\x7a\xad\x9c\x1b\xe3\x11\x4f\x98\x11\x37\xbb\x84\x20\x5d\x57\x38\x86\x1d\x92\x62\x4e\x49\x36\xfb\x9c\x2b\xc9\xf0\xbc\x05\x75\x3e\x26\x64\xea\x53\x24\x3d\x85\x37\xf3\x61\x89\x20\x6c\xc4\x06\x93\x63\x82\xfd\xd3\xaf\x3e\xc5\xc6\x1b\x21\x93\xf6\xc2\x4a\x0a\x9f\x87\x28\x50\x5c\x53\x83\x36\x4b\x07\xf2\x61\xe9\x9c\x7b\x7e\x7e\x76\xb5\x54\xb8\x20\x5d\x99\xf4\xd0\xd2\xf7\x92\xcd\xcd\xca\xfc\x1b\x05\xab\x70\xce\x5c\x7c\xc2\x91\x74\x88\xbc\x26\x36\xc9\x5b\xe7\xee\x12\x79\xa5\x57\xaa\xdd\x48\x25\xe7\x81\xec\x8f\x0f\x5e\x78\x8f\x85\x79\x01\xaf\xf0\x78\x7f\x79\x77\x73\x3e\xb9\xc4\xc3\xb4\xbe\xc6\x97\xfb\x1b\xd4\xb7\xa8\xaf\xa7\x33\xd4\xd3\xc9\xa7\xcb\xfa\xc9\xb9\x7a\x49\x7f\xa9\xaa\x4b\x9f\x12\x22\x87\x54\x1a\xfa\xad\x41\x1b\x29\x35\x5a\x39\x00\x38\xc1\x8c\x72\xf4\x09\x5c\xba\x39\xe5\xfd\xd9\xa4\xa8\x49\x13\x3d\xef\xe3\xab\x92\x12\x9a\xa8\x2b\x10\x87\xbc\xed\x77\xcd\x86\x51\x8a\xee\x33\x3e\xfb\xb4\x19\x14\xed\xb3\x18\x85\xe3\xfb\x41\xce\x37\x51\x6f\x8b\xa9\x94\x1c\xa8\xc1\x64\x20\x9c\x10\x1b\x65\x4c\xeb\x7f\x95\xf7\x5c\xd1\xfb\x6c\x90\x16\x52\x32\x84\x17\x32\xcc\x14\xa4\xeb\x53\xf4\x1c\x08\xd4\xb6\x92\x4d\xe1\xb9\x41\x4f\x79\x97\xa6\x94\xd7\x31\x0c\xae\x2f\x32\x51\x47\x6c\xa7\xd8\x0c\xfe\x7f\x2f\x31\x13\xfc\xc1\xa3\x37\x05\xa5\x3d\x46\x50\x44\x46\x51\x1a\x7e\x83\x9a\xfb\x82\xaf\x18\x3e\x04\x29\x6c\x67\x07\xfb\x5f\xf6\xac\x39\x6c\x63\x47\xaa\x7e\x41\xd8\x44\xfb\x75\x29\x0e\x0d\xcf\xfe\x1b\x3b\x7f\x0e\x00\x89\xd2\xee\x65\x39\x04\x00\x00")
 
 func complySoc2ProceduresWorkstationMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -1161,12 +1182,12 @@ func complySoc2ProceduresWorkstationMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/procedures/workstation.md", size: 1081, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/procedures/workstation.md", size: 1081, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2StandardsReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x92\xb1\x6e\xdb\x40\x0c\x86\x77\x3d\x05\x81\x0c\x6d\x8d\xd8\x6a\xb3\x14\xc8\x66\x18\x28\x90\xa9\x46\x9c\x07\x08\x7d\x47\x49\x4c\xee\x8e\x02\x49\x39\xd0\xdb\x17\x27\x5b\x41\xa6\x6e\x94\xf0\x93\xfc\xee\xe7\x7f\x07\x27\xc7\x12\x51\xa3\x35\xcd\x3e\x25\x78\x9d\x31\xa7\x57\xe8\x38\x91\x01\x17\xf0\x81\x0d\x22\x2b\x05\x17\x9d\x01\x95\x00\xcd\xa6\x4c\x11\x5c\x20\x48\xe9\x44\x73\x2d\x07\xf7\xd1\x1e\xdb\xb6\x67\x1f\xa6\xf3\x2e\x48\x6e\x65\xa4\x12\xa4\xb8\x4a\x6a\x2d\x0c\x94\xd1\x5a\x57\xa2\x36\xa3\x39\x69\xfb\xfe\x81\x89\xbb\xb9\xb5\x1b\x42\xd3\xdc\xdd\xc1\xfe\x82\x9c\xf0\x9c\xe8\x2b\xd9\x16\x36\x9b\x97\xd3\x61\xfb\xf0\xf3\xd7\xef\xdd\x9c\xd3\x66\xf3\x08\x7f\x95\x7b\x2e\x98\xa0\xfe\x84\x17\x9d\xcc\xe1\x44\x7a\xe1\x40\x06\x07\x65\x27\x65\x84\x4e\x14\x4e\x14\x26\x65\x9f\xef\xd7\xe1\x9c\x96\xaf\xa3\x4a\x20\x33\x2e\x3d\x3c\x15\xa7\xfe\xaa\x39\x48\xe9\x38\x52\x71\xc6\xab\x0c\x4b\x84\xa3\xf2\x05\xc3\xfc\x05\xe4\xe1\x61\x05\xf9\xef\xfe\x0f\xf6\x01\x9e\xe9\xc2\x46\x11\x8e\xc2\xc5\x0d\xa4\x83\x3f\x12\x26\x83\xef\x75\xcc\x0f\xd8\xc2\xe6\x99\x82\xe4\x4c\x25\x52\x5c\x90\x0b\x7d\x00\xe7\x31\x51\xa6\xe2\xe8\x2c\xc5\x36\x4d\xf3\x32\x10\xac\xdb\x97\x13\x01\x97\x90\xa6\x48\x06\x3e\x10\x18\x66\x82\x20\x4a\x10\xd6\xf5\x68\xb0\xfa\x06\xe7\xc9\xab\x5e\x74\x14\x45\xbf\xf5\xec\x9f\x0e\xc7\xfd\x37\x83\x65\xa4\xde\x38\xc7\x4f\xce\x6e\xe1\xf4\x01\x1d\x30\x46\x25\x33\xc8\x12\x49\x0b\x84\xf9\x4c\x6a\x37\x67\xc1\x07\x25\x74\xbb\x07\x2a\x03\x96\x40\x11\x7a\xb9\x90\x96\x5a\x43\x3f\x71\xac\xc5\xd5\xca\x69\x8c\xe8\x14\x41\xd9\xde\x6b\x96\xc8\xac\x3e\x12\x70\x1c\x55\x30\x0c\x64\xbb\xa6\xd9\xc7\xb7\xea\x67\x25\x74\xd4\x9e\x1c\xd6\x90\x2c\xf6\x2c\xa9\x1c\x55\xde\x28\x38\x9c\xe7\xca\x56\xaf\x28\x0a\x4a\x59\x2e\xb5\x4e\x5c\x68\xcb\x4e\xd9\x96\x1b\x70\x01\xc2\x30\x2c\xae\xdd\x57\xe1\xb5\xa5\xfd\xd4\xe3\x97\x0d\xd5\xd9\x1a\x00\xa5\x34\xef\xfe\x05\x00\x00\xff\xff\xf3\xf1\xd9\x72\x23\x03\x00\x00")
+var _complySoc2StandardsReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x92\xc1\x6a\xeb\x4c\x0c\x85\xf7\x7e\x0a\x41\x17\xff\x7f\x43\x13\xb7\xd9\x14\xba\x0b\x81\x0b\x5d\xdd\xd0\xf4\x01\xaa\xcc\xc8\xb6\xda\x99\x91\x91\xe4\x14\xbf\xfd\x65\xdc\xa4\x64\x75\x77\xb2\x91\xe6\x7c\x3a\x47\x77\x70\x74\x2c\x11\x35\x5a\xd3\xec\x52\x82\xf7\x19\x73\x7a\x87\x8e\x13\x19\x70\x01\x1f\xd8\x20\xb2\x52\x70\xd1\x19\x50\x09\xd0\x6c\xca\x14\xc1\x05\x82\x94\x4e\x34\xd7\x72\x70\x1f\xed\xb9\x6d\x7b\xf6\x61\x3a\x6d\x82\xe4\x56\x46\x2a\x41\x8a\xab\xa4\xd6\xc2\x40\x19\xad\x75\x25\x6a\x33\x9a\x93\xb6\x9f\x5f\x98\xb8\x9b\x5b\xbb\x20\x34\xcd\xdd\x1d\xec\xce\xc8\x09\x4f\x89\x6e\xc9\xd6\xb0\x5a\xbd\x1d\xf7\xeb\xed\xc3\xe3\xd3\x66\xce\x69\xb5\x7a\x86\x3f\xca\x3d\x17\x4c\x50\x7f\xc2\x9b\x4e\xe6\x70\x24\x3d\x73\x20\x83\xbd\xb2\x93\x32\x42\x27\x0a\x47\x0a\x93\xb2\xcf\xf7\xd7\xc7\x39\x2d\x5f\x07\x95\x40\x66\x5c\x7a\x78\x29\x4e\xfd\x77\xcf\x5e\x4a\xc7\x91\x8a\x33\x7e\xb7\x61\x89\x70\x50\x3e\x63\x98\x6f\x40\xb6\xdb\x2b\xc8\x3f\xf5\xbf\xd8\x07\x78\xa5\x33\x1b\x45\x38\x08\x17\x37\x90\x0e\x7e\x4b\x98\x0c\xfe\xdf\x3e\x6c\xb7\xbf\x60\x0d\xab\x57\x0a\x92\x33\x95\x48\x71\x41\x2e\xf4\x05\x9c\xc7\x44\x99\x8a\xa3\xb3\x14\x5b\x35\xcd\xdb\x40\x70\x55\x5f\x22\x02\x2e\x21\x4d\x91\x0c\x7c\x20\x30\xcc\x04\x41\x94\x20\x5c\xe5\xd1\x2e\x03\x8f\x4f\x70\x9a\xbc\xf6\x8b\x8e\xa2\xe8\x97\x99\xdd\xcb\xfe\xb0\xfb\xcf\x60\x79\x52\x2f\x9c\xe3\x0f\x67\xb7\x70\xfa\x80\x0e\x18\xa3\x92\x19\x64\x89\xa4\x05\xc2\x7c\x22\xb5\x8b\xb3\xe0\x83\x12\xba\xdd\x03\x95\x01\x4b\xa0\x08\xbd\x9c\x49\x4b\xad\xa1\x9f\x38\xd6\xe2\xdb\xca\x69\x8c\xe8\x14\x41\xd9\x3e\xeb\x2d\x91\x59\x5d\x12\x70\x1c\x55\x30\x0c\x64\x9b\xa6\xd9\xc5\x8f\x9a\x67\xdd\xca\x51\x7b\x72\xb8\x1e\xc9\x62\xcf\x72\x95\xa3\xca\x07\x05\x87\xd3\x5c\xd9\x6a\x8a\xa2\xa0\x94\xe5\x5c\xeb\xc4\x85\xd6\xec\x94\x6d\xc9\x80\x0b\x10\x86\x61\x71\xed\x1e\x44\x2f\x23\xed\x4f\x3f\xde\x28\x54\x67\xa9\x38\x2b\xa5\x79\xf3\x77\x00\xf3\xf1\xd9\x72\x23\x03\x00\x00")
 
 func complySoc2StandardsReadmeMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -1181,12 +1202,12 @@ func complySoc2StandardsReadmeMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/standards/README.md", size: 803, mode: os.FileMode(420), modTime: time.Unix(1757470844, 0)}
+	info := bindataFileInfo{name: "comply-soc2/standards/README.md", size: 803, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2StandardsTsc2017Yml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x5b\xcd\x72\xdc\x38\x92\xbe\xeb\x29\x70\xb4\x23\x68\x6f\xb4\x24\xdb\x3d\xbd\x27\x75\xb5\x3b\x56\x11\xeb\x9d\x0a\x5b\x33\x77\x08\xc8\xaa\xc2\x1a\x04\xd8\xf8\x29\xb9\xe6\x34\xaf\x31\xaf\x37\x4f\x32\x91\x09\x80\x04\x59\x64\xa9\x4a\xf6\xcc\xc9\x92\x4c\x02\x99\x5f\xfe\xff\xd0\xf0\x16\x7e\x61\x0f\x5f\x56\x57\xab\xd5\x4f\x6f\x7f\xfa\xe5\x8a\xb1\x0d\x6f\x95\x3e\xfc\xc2\x56\xab\x9f\xae\x18\x4b\x0f\xdc\x9b\x00\x5b\xa7\xc2\x81\x71\x23\xd9\xc7\xb0\x53\xc2\x5f\x31\x26\xc1\x0b\xa7\xba\xa0\xac\xf9\x85\x3d\xec\x80\x81\x09\xf8\x90\x84\xd6\x1a\x1f\x1c\x0f\xe0\x19\x67\xc2\xb6\xad\x0a\x2d\x98\xc0\x82\x65\x6a\x74\x16\xe0\x59\x5c\xb3\x3d\xd7\x11\x3c\x51\x71\xbd\x44\xc5\xaf\x96\x3b\xc9\xee\x8d\x84\x0e\x8c\x04\x23\x60\x8e\x86\x47\x7a\xca\x6e\x98\x54\x0e\x44\xb0\xce\x8f\xe9\x51\xd5\xfb\x6c\xe3\x6c\xcb\x5a\x6e\xf8\x16\x88\x3e\x22\xe9\x1b\x38\xa1\x3c\x78\x66\xf7\xe0\xbc\xda\xee\x02\x1e\x17\x76\xc0\x24\xec\x41\xdb\xae\x7f\xb4\x03\xb7\xb1\xae\xe5\x78\x94\xdd\x10\x6b\xce\x70\xcd\x84\x35\xc1\x59\x4d\xec\xdc\x2c\xb1\xf3\x67\xb7\xe5\x46\xfd\x8d\x23\xe9\x5c\xb3\x2f\xc1\x45\x11\xa2\x3b\x62\xea\xd3\x40\x1e\xf8\xc0\x1f\xb5\xf2\x3b\xf0\x0d\x7b\x52\x61\x57\xb8\x2d\x84\x36\xcc\x97\x63\x7c\xc3\x1c\x74\xd6\x05\x65\xb6\x4c\x2b\x83\x7f\x40\x9a\x79\xd7\x39\xdb\x39\xc5\x03\x30\x1e\xc3\xce\x3a\x15\x14\xca\xc9\x48\xe6\xc0\x77\xd6\x78\xf5\xa8\x74\xfa\xa3\x32\xc4\x77\x17\x9d\x8f\x8a\x60\xb0\x8f\xff\x0f\x22\xa8\x7d\x16\xd6\xed\x12\x77\xff\xa3\x9c\x32\xdb\x86\x3d\x38\xae\x0c\x92\x80\xe7\x7f\x86\x80\x2a\x62\xcd\x4b\x95\x87\x87\xe0\xb8\x08\x4d\x91\x44\x93\xc9\x0e\x5c\x19\x7c\xb4\xa3\x0b\x50\xc6\x6a\xaf\x64\xe4\x9a\x58\xe0\x5a\x6d\x0d\x1d\x41\x98\x4d\x58\x78\xb7\xac\xf5\xe5\x14\x76\x27\x84\x8d\x26\x70\x02\xe6\x70\x82\xfa\x9d\xd5\xd2\x8f\xee\xe7\xe5\x55\x0d\x6c\x63\x1d\xe2\xa9\xdc\x91\xaa\x5c\x06\xfd\xdb\xab\xd5\xea\x7a\x6a\xae\xd7\x3d\xe1\x7f\xf1\xa4\x8e\xf7\x86\x94\x13\x69\x64\x5f\x0e\x3e\x40\x7b\xca\x68\xed\x23\x82\xe8\x99\x75\x6c\x0b\x06\x32\xfe\x46\xb2\x88\xa6\xe0\x40\xc3\x9e\x9b\xd0\xb0\x3f\x22\x47\x0c\x98\xaa\x4e\x0f\x96\xf9\xd8\xa1\xb2\x11\xcd\x9b\x68\x04\xfe\x1d\xc5\x3e\x6f\x16\xd7\x53\x2b\x3f\x22\x7e\x65\xdb\x36\x1a\x25\x46\xe4\x37\xe4\x89\xf0\xac\x13\x8c\x94\xeb\xf4\x81\x94\x27\x1d\x42\x80\xf6\x04\x37\x4c\x19\xa1\xa3\x24\x02\x7b\x54\xe7\x4d\x00\x65\x36\xe5\xa0\x61\x06\x04\x78\xcf\xdd\xe1\x25\xbc\xdf\xbc\x8c\xf7\x8f\xdf\x9e\xe5\x7d\xc4\x30\x29\x3b\xe4\x97\x58\xc7\x1d\xf1\xe3\x60\xcb\x1d\x71\xde\xf2\x10\xc0\x79\xc6\x37\x1b\x44\xc0\x6c\xcf\x64\xe0\x66\xaa\x79\x37\x83\x4f\x1b\x4c\x6b\x99\x48\xdf\x81\x50\x1b\xa4\xa5\xc2\x9e\x88\xf5\x71\xb3\x51\x42\xa1\xa5\x0a\xcd\x29\x48\x04\xcb\xc0\x90\xf1\x20\x71\x4a\xe2\x19\x9b\x02\x0d\xf9\x33\xef\xc1\x7b\xb2\x6e\xbb\x61\x4e\xf9\xaf\xa4\xad\x3c\x31\x64\xc7\xd6\x7e\x33\xd5\xbb\x81\xf4\xcf\xca\x7f\xc5\xe7\xcf\x62\xa1\xd0\x81\x78\xd2\x8d\xc1\x12\x7d\x5c\xec\x14\xec\xa1\x50\xa3\xc2\x88\x47\x2e\x9c\xf5\x9e\x1e\xcc\xe7\x10\x03\x86\xeb\xc3\xdf\xfa\x93\x38\x7a\xbd\x47\xee\x55\x52\x3d\x09\x01\x5c\x9b\x5c\xe8\xce\x3e\xd1\xcb\xe9\x41\xbf\xb3\x51\x4b\xf6\x08\x39\x80\x49\xe2\xef\x66\x89\xbf\xdf\x1d\x8f\xf2\x32\x2e\x05\x1a\x82\x44\x15\x21\x4f\x64\xc9\x79\x73\x4d\x74\x6d\xe8\x38\x74\xae\x84\x3f\x92\x77\x0a\x89\x89\x14\x6e\x97\xa8\xbc\x6f\x3b\x2e\xe8\x8d\xd5\x8e\x9b\xed\xb9\x42\x18\x14\x01\x3c\x13\xe9\x4d\x16\x76\x3c\x30\x41\x20\x79\xb5\x35\xa4\x36\x26\xe8\x03\x53\xe9\x12\x24\xd3\x93\x71\x2d\x28\xfa\xed\x54\xd1\x6f\x7b\x3a\x3f\x59\xa3\x82\xc5\x10\x77\x4a\xd1\x41\x83\x08\xbe\x8f\x56\x39\x02\xe7\xac\xc1\x33\x6b\xb6\x36\x87\xc6\xff\xb2\x8e\x79\xe8\x38\xba\x5d\x06\x98\x0d\x91\x86\x13\x9c\xdc\x0b\x70\x14\xe1\x9e\x76\x10\x76\x40\x31\x84\xa2\x9d\x35\x60\x50\xc5\x8e\xa9\x67\xdc\x01\xeb\x1c\xf8\x92\xaa\x54\x66\x4d\x9c\x5d\x2f\x71\xf6\x19\x5a\x90\x8a\x3f\x13\xa5\x33\x89\x19\xfa\x89\x9f\x9d\x90\x22\x21\x19\xb5\xc8\x61\x8d\xb3\xa0\x5a\xd0\x07\xd4\x5b\x83\xdc\xa0\xc6\x58\x0f\x95\x8b\xca\x3e\xb8\x84\x4c\xfe\x15\x61\x12\xd6\xb9\xa4\x44\x8c\x8b\xa9\x1b\xf7\x60\x94\x75\xd3\x5c\x2e\xcc\x26\x84\x0d\x19\xd9\x90\x07\x5d\xad\x56\xef\xa6\xa2\x7e\x77\xec\xd3\x92\xf1\x7c\x52\x41\x6d\x9f\x83\x27\x4b\x9e\x68\x28\xc2\x1f\x44\x83\xa7\xa5\xe0\x92\x55\xd4\x04\xa7\x1e\x63\x80\x62\x3c\x6d\x7f\xc7\xe0\xd3\x9e\xb3\x2b\xd2\x14\x21\xa0\x4b\xa9\x86\xc6\x5b\x3d\x71\x76\xbd\xc4\xd9\x03\x88\x9d\xb1\xda\x6e\x0f\x6c\x95\x68\x3b\x65\x6e\x5c\x7b\x3b\xcf\x58\xca\x17\xf4\x1c\x83\x98\x99\xb2\x30\xdc\x33\x89\x96\xa7\xbc\xc4\xbb\xa9\x2f\x1b\x08\xff\xd8\xe7\xc1\x92\xad\xad\x46\xed\x3a\x5d\x8f\x74\xda\x1e\x16\x04\xe0\x6c\xdc\xee\x58\x97\x4f\x49\x12\xe9\xf3\x6c\xf6\x84\xbf\x2a\xcf\xe0\x5b\x07\x22\x80\x24\xc6\x95\x61\x9d\xb3\x02\x24\xa6\xd9\xe9\x8d\x2e\x86\xe1\x0c\x65\x48\x16\xa4\x23\xab\xd5\xfb\xa9\x6a\xbd\xef\xf9\xf8\x5f\xbb\xa5\xea\xe7\x4e\x60\x36\x71\xca\xd5\xb5\x9d\x26\x94\x3c\xd3\xf9\x1d\x4e\xef\x30\x0f\x22\x52\xa0\xf4\x76\x13\x9e\xb8\x03\x34\x8a\x8d\xe3\x7d\x1d\x90\xb3\x7e\x27\x76\x2a\x40\xaa\x0c\x92\x54\x3a\x67\x43\xe2\xa9\x4e\xe5\xd0\x8b\x06\x52\xa6\xfc\xff\x28\xa7\x36\x15\x4a\xfd\x5d\xb0\x27\x52\x82\x65\x2d\x40\xa8\x02\xda\x3f\xff\xfe\x0f\x3f\x96\xe2\xfb\xa9\xfa\xbd\xaf\xb3\x1d\xb7\xc0\xfa\xda\xa1\x31\x63\xb5\xe8\x7d\x24\xf3\x4e\x7e\x5a\x38\x90\x29\x08\x25\x15\xdc\x3a\x6e\x42\xf5\xff\x09\x94\xa6\x0e\xb1\x0e\xb6\xca\xa7\x3c\x07\x71\x48\x15\x0f\x86\x5b\x03\x4f\x83\xaf\x4a\x85\x5f\xfe\x25\x7a\x7c\xfc\x89\x7c\x52\x86\x59\x79\xc6\x25\x86\x61\x3c\x0a\x24\x7b\x3c\x54\x77\xbc\x65\xbf\x53\x62\x8f\xcf\x5f\xfa\x6e\x43\x6f\xcc\xf2\xe7\x80\x39\x68\xed\x1e\x24\x3a\x7e\x93\x1e\x1c\xce\x34\x96\x69\x6b\xb6\xf8\xb7\xc2\x94\x24\xc0\x6f\x96\x00\xff\x6c\x35\xbc\xf9\x95\x7b\x90\xcf\x6b\xdc\x00\x54\xc3\x5a\x2b\x29\xcc\x36\x58\x1c\x24\x92\x7c\x21\x24\x58\x26\x79\xe0\x4d\xa5\x7f\x25\xd4\xe4\x70\x67\x29\x64\x9d\xd4\xb6\x47\xa2\xc9\x1a\xe6\xac\x4e\x85\xeb\x38\x09\xa7\x8b\xab\x70\x2d\x01\x03\x7a\x0a\x3d\x29\xd8\x37\x6c\xab\xf6\x29\x48\xa4\x9c\xa5\x2f\x4c\x52\xac\x34\xe8\x18\x29\x52\x6a\xe0\x3e\xb0\xce\xa9\xbd\xd2\xb0\x05\x3a\xc4\xc3\x16\xd3\xe2\xe2\x70\x65\x4c\x97\x9e\xa7\xdf\xb7\x4b\x70\xaf\x77\x07\x7f\x9e\x79\x3b\xf0\xc1\x29\xf4\xab\x5d\x79\x67\x80\x77\xc3\x45\xa9\x45\x28\x7b\x38\x05\xe4\x2b\x0c\x97\xf0\x8d\xa3\xbb\x68\x48\x30\x4c\x00\xea\x78\x75\x4a\xc3\x1e\xb9\xf8\xfa\x26\x76\x8c\x02\x3d\xf3\xc1\x3a\xbe\x85\x5a\x58\x1e\x8c\x57\x14\xf2\xb4\x4d\xa9\xb6\x7f\x4d\xf1\xa5\x57\x34\x4c\x62\xbc\x35\x06\xf4\x99\x28\xbd\x5b\x42\xe9\x37\x24\xf2\x37\xe5\x3b\xeb\x4f\x96\x36\x52\x79\xf4\xdf\xca\x44\xa8\x9c\x20\x02\x52\x20\xcb\xc8\x50\xda\x94\x3c\x5c\x0f\x66\x42\xc7\x1a\x7d\x60\x7c\x13\x72\x06\x95\xab\x79\x64\xc0\x01\x97\x49\xb9\x05\xbd\x49\xc8\x91\x66\x64\xad\x4e\x0e\x30\x99\x78\x3e\x6d\xc7\x3d\x7b\x04\x30\x4c\x2a\x32\xee\x5d\x89\x0e\xb5\x69\x3a\xf8\x23\x2a\x34\xfa\xf3\x50\x7a\xbf\x84\x52\xa9\xfd\xd8\xc3\xce\x01\x0f\xdf\x19\x2b\x5a\xe0\x3e\xc5\xae\xc1\xcd\xf3\x2d\x96\xff\x48\x20\x5d\x90\x3d\xbe\x8d\x4e\x60\xc0\x88\x01\xad\x8a\x0a\x9a\x6c\x83\x8f\x36\x1a\xc9\x9d\xca\x94\x7f\x38\x29\xdf\x55\xf4\xc1\xca\x54\xef\x3c\x38\x6e\x7c\xab\xbc\x3f\x9d\x42\x0d\x36\x81\x90\x85\xea\x25\x74\x48\x29\x67\x28\xed\x9f\xd6\xee\xb9\x4e\x69\xf0\xa8\x29\x51\xe9\xeb\x29\x4f\x9f\xcd\x4a\x50\xf9\xd0\xd4\x56\xe6\x99\x0a\x4c\x46\x47\xb5\xe4\x02\x09\xc5\x27\xf2\x73\x4d\xe1\xe7\x25\xa8\x3e\x71\x4d\xba\xf6\x1b\x64\x3d\x3e\x4f\xca\x39\xad\xc9\xd2\xa4\xd8\xcc\x72\xe5\x28\x52\x1a\x8c\xd5\x4e\xec\x6c\xea\x28\x29\x7c\x5a\x46\x51\xbc\x5d\x34\x15\x4c\x94\x42\x63\x1a\x63\xa3\x1f\x94\xff\x1c\xbe\x3e\x4c\xd3\x9c\x0f\x3d\x5f\x7f\x8d\x1a\x13\xc4\x6c\x6e\xcb\xdc\xe5\x5b\xc6\x55\xf3\x28\x98\x53\x2f\x4a\x96\x03\x88\xb7\xb6\xaf\xc4\x46\x49\x99\x2d\xe5\xe1\x81\xbd\xfa\xe9\xf5\x50\x13\x5a\x84\x6b\xa3\xb6\xd1\x95\x02\x0b\xd3\x37\x07\x3e\xea\x50\x7a\x6e\x53\x84\x30\x53\xd8\x57\x3c\xa8\xa2\x25\xaf\xae\x5f\x33\x1f\x3d\xc6\x96\xa1\x61\x14\x2c\xbe\xa0\xb3\xc7\xda\x53\xc8\x9f\xbc\x4d\x70\x5d\x2f\xc1\x75\x67\x6c\xcb\xf5\x29\xa0\x06\x40\x32\xf7\xbd\x55\x56\x55\x61\xa9\x7f\x6c\x57\xa2\x21\x75\xb1\xd1\x83\x55\x4f\x61\xc0\xe0\x74\x61\x9f\xfd\xa2\xc4\x95\x91\xd4\x64\xd9\x53\x43\x6a\x50\x09\x4e\xf5\xac\xe1\x21\x62\xc2\x2f\x95\xe7\x94\x5b\x25\x38\xc0\x39\x7b\xd4\x4f\xaa\xf4\xa5\x72\xb8\x33\x82\xfe\xef\x8a\x0e\x24\x21\xf7\x45\xc8\x75\x96\x2e\x08\xd4\x25\x30\xfa\x88\x52\xe2\x4e\x52\x53\x02\xf8\x66\x09\xe0\x2f\xe5\xe1\x7b\x23\x48\x4b\xd8\xc7\xbe\xe2\x3e\xab\xe6\x9d\x49\x84\x17\x28\x4c\xed\x07\xeb\xd8\x8e\xef\x21\xab\x19\xa4\xbe\x09\xdb\x70\xa5\xa3\x83\x32\x5c\xc8\xb7\xcc\x83\xc3\x5e\xf5\x77\xaa\x4c\xb4\x7f\x8d\xa0\x37\x4c\x6d\x98\xb7\x0d\x16\xc9\x94\x95\xf5\x6d\x83\xca\x15\x70\x29\x1d\x05\x80\x28\x76\xe5\xda\x84\xd1\xed\xf9\x18\x7d\x4e\x49\x19\xb0\xb5\xe6\xcf\x38\xee\xce\x1a\x59\xdb\xa0\x02\xc9\x8e\xe9\xc7\x44\x18\xbe\x81\x88\xa4\x2b\x9c\xfa\x04\x86\xc0\xc9\x37\xba\x72\x63\xe7\xec\xd6\xf1\x16\x4f\x8c\x46\x82\xf3\x81\x38\x47\xc7\xc7\x95\xc1\x7c\x31\xf5\x2c\x72\x12\x53\xb5\x23\x66\xae\x9d\xa9\xfe\x3f\x4c\xd3\x93\xb3\x70\xf8\x98\x68\x3f\xed\xa6\xfb\x1e\xd5\xb4\x0b\x54\x39\xf0\xba\x1e\xb5\x7d\x0e\x42\x01\xf8\x24\x80\x57\xab\xd5\xcf\x53\xaf\xfb\x73\x4f\x79\x6a\xa0\x95\xca\xfe\xcc\x54\x3f\x65\xd6\x15\xb1\xa4\x3e\x82\x92\x18\xdf\xf4\xbe\x93\x1e\xb5\x22\xb6\x09\xd0\x00\x9e\x70\x45\x50\xf7\x70\xcc\x60\xe5\x7d\xa7\xc5\xe9\xb4\x78\xe8\x63\x71\xef\xc7\x67\xac\xe1\x6a\xb5\xfa\xd3\x94\xf1\x3f\x0d\x19\x87\xf2\x2e\x12\x9b\x17\x74\x6c\x6a\x41\xf5\x7d\xbb\x51\x97\xc3\xe1\x59\x55\x67\xa6\x92\x1a\x3a\xd1\xdc\xbd\x75\x8a\x7a\xa1\x24\xbc\xa1\x63\xfa\x18\xbd\x32\x68\x81\xb2\xa7\x2d\x31\x71\xbd\xc4\xc4\x5f\xc1\x48\xeb\x32\x03\x7d\x53\xeb\x94\x14\x4b\xfb\x93\x82\x22\xbd\x31\xb4\x94\xbd\x15\xa8\xea\x32\xb5\xd9\xf7\x74\x76\x7a\xb2\xa7\xac\xe3\x2e\x18\x70\xfe\xea\x6e\x32\x08\xbe\x1b\x26\x62\x2b\xde\x71\x81\x97\xa1\x0b\x30\xa7\x9b\x9f\x2d\x57\x64\x9d\x54\x3f\xa6\x20\x95\xa3\x44\xef\x46\x45\x74\x0e\x2d\x2a\xe7\x5e\x54\xbe\x95\x1b\xf2\xe4\x09\x9d\xe3\x71\x68\x7b\x35\xaf\x45\x75\xc2\x4e\xf5\x4a\x82\x61\x38\x54\x42\x9b\x3a\xee\x72\x3a\x57\x28\xba\xda\x07\x4a\x2e\xa5\xca\x93\xd9\xfe\xf5\x60\xd9\x0e\x74\x37\xab\x91\x77\x93\xc1\x75\x85\xda\xaf\x5c\x7c\x8d\x5d\x1e\x80\x92\x6d\x9f\x9a\x20\x5e\x60\x8d\x83\x85\x35\x39\xca\xc3\xc8\x0a\x2b\x11\x54\x99\x92\x67\x60\xf6\xca\x59\x9a\x88\x8e\xcb\xa6\xda\x10\xa9\x04\x2a\x95\xe2\x24\x3b\xce\x2e\xea\x30\xb1\xe6\x25\x6b\xbd\x9b\x4c\xc1\x2b\x6c\x0a\x20\xec\x01\x7c\x38\xad\x50\xe4\x64\x86\xab\x3b\xcd\x47\x6d\xb8\xdc\x56\xac\x9a\x41\xfd\xa3\x4b\x4e\x64\xba\xf1\x50\x29\x3a\xfa\xb9\xdc\x89\x19\xcd\x51\xef\x47\x03\xa8\xf3\x87\x13\xbd\x28\x92\x0b\x2d\x47\x4f\xea\x95\xd3\x49\x76\x1a\x6b\xa5\x84\xa8\x3e\x45\x85\xc3\xd5\xd1\xde\xc4\x19\xbc\x9c\x57\x74\x77\x96\x56\x21\x36\xff\x1e\xc2\xdf\x5e\xad\xef\x27\x62\x58\xdf\x0f\xb4\xaf\x07\xcf\x30\xec\xa0\x9c\x35\x7b\x99\x1b\x67\x53\xaf\xcd\x1f\xe5\x08\xcf\x4d\xb7\x87\x89\x29\xa5\xd3\xb3\x6c\x0d\x2e\xac\x1e\x4a\x50\x46\xa3\x72\x47\x62\x93\x4c\xaa\x98\x92\x2c\xa1\x0e\x0b\x8d\xdc\x85\x72\x7b\x25\x20\xcd\x47\x59\xee\xbb\x34\xd3\x96\x79\x76\x8a\xf9\x45\x5f\xbf\xe9\x09\xcc\xeb\x8b\xc0\xbc\x13\x22\x3a\x2e\x4e\x79\xa4\x2a\x90\xf7\x2d\xee\x49\x98\xa6\x84\x25\x9b\x9d\x32\x5d\x44\x8f\x34\xc0\xd0\x17\xa8\xf4\x18\xfa\x71\x0d\x01\x28\xea\xa4\xea\x34\x91\xd0\xa4\xec\xa7\x54\x62\x85\xc3\xa6\x67\xaf\x38\x9f\x62\xe7\xcf\x97\xa5\x88\xc7\xcd\x45\x78\xfc\xb9\x54\x4b\x67\xb6\x58\xce\x41\xa4\x8a\x6f\xff\x16\x0e\x6f\x2f\xe3\x30\x06\x14\xd0\xf7\xb1\x47\xc1\xf5\x2b\x30\xbe\xe7\x4a\x53\x18\xa5\x8e\x83\x56\xc8\xb6\xa5\x1b\x7a\x41\xeb\x43\x93\x65\x9c\x7f\xc6\xf0\x9b\x26\x80\x58\x07\x09\x61\x9d\xa4\x15\xaa\xb4\x03\x90\xb6\x03\xc4\x30\xff\x3c\x03\x81\x77\x17\x21\x90\x02\xf2\xf7\x23\xe0\x83\xa5\x52\x39\xeb\x7b\x80\xd6\xf7\xb3\xa1\xec\x0b\xa8\xa7\x9a\x00\x7f\x29\x1e\x49\x87\x2e\x87\x65\xea\x56\x6b\x50\xd4\x9e\x8b\x03\xfb\x3f\x7b\x56\x28\xc3\x74\x42\x49\xf0\xcc\xd8\x80\xfe\x29\xb7\xfc\x99\x8f\x74\x1b\xd6\xf5\x36\xa6\xe8\xda\xe5\x93\x3b\x87\xd9\xb1\x80\x33\x08\x1d\x7b\x51\x7a\xfd\x2d\xdd\x9e\x6f\x53\x9e\xc5\x4e\xf2\x32\x7b\xab\xfc\xb6\x3c\xa6\x64\x66\xba\x8c\xb9\x79\x55\x7f\x4c\xe8\x38\x22\x78\xe4\xb7\xf2\x6b\xb9\x2d\x54\x3c\x2f\x75\xbe\xc7\x31\xf0\x8c\x59\xc1\x0c\x9f\x57\xeb\xc9\x12\xd7\xfa\xfa\x48\x46\x2b\x2c\x35\xf3\x34\x7b\xb5\xb3\x6a\x7e\xe9\x71\x6e\xfd\x47\xd0\xd3\xbe\x32\xd1\x71\x20\x13\x56\x6b\xc8\x73\xf4\xe8\x01\xab\xe8\xbc\x9d\xd7\x50\xeb\x4a\x5b\xdf\x8f\x0f\x65\x4e\x15\x96\xf8\x2f\xc8\x4e\xf4\x22\x77\xa0\x04\xf2\xf0\x47\x04\x93\xf0\xdd\x30\x6e\x0e\x0d\x1e\x05\x5c\xec\x32\x9d\x6f\xd9\xc7\x6f\x1d\x5a\x5a\x48\x8f\x9b\x50\xb6\xe5\x7e\x2c\xa1\xca\xe7\xd4\x00\x64\x2a\xd2\xc6\x24\x5b\x97\x47\x20\x47\xa3\x8e\x44\x79\xe9\xe7\xbf\x65\x5f\x22\xd2\x9e\x49\xad\x4f\xa5\x29\x43\xa1\x5d\x99\x00\x46\xe2\x21\xd1\x61\x26\x55\x9a\x3d\x2f\xce\x9f\x46\x26\x52\x3d\x3d\xbf\x31\x84\x7e\xec\x3f\x82\x69\x69\x06\x80\xbc\x5a\x4f\xf6\xc3\xd6\xc3\x76\xcf\xba\xbc\x5d\xa7\xa1\xab\x9e\x94\xa3\x29\xf0\xc2\x65\x99\x78\x90\x69\xda\xe7\x43\xbf\xda\x79\x99\xf5\x4d\xb6\xc1\x2a\x42\x7b\x5d\xcc\xe6\x37\xa5\xec\x77\x5a\x0a\xac\x53\x44\x54\x0b\x44\x1c\x26\x5a\x3c\x6a\x5f\x8f\xec\x13\xff\x6e\x00\xd5\xd0\xba\xd4\x99\xeb\x5f\xe1\x9e\x3d\x81\xd6\xf8\xef\xd4\x7e\xa8\x36\xed\x5b\x87\x29\xf3\x44\xef\x7c\x24\x61\x24\x09\x7c\xfa\x7d\xde\x65\x51\x6c\xca\x69\x72\x7f\x0d\x95\xe3\x79\xf8\x3e\x56\x94\x1f\xa5\xba\x57\xeb\xc9\x5e\xd5\xfa\xb6\x0e\xd7\x1d\xb8\xb2\x07\x39\xa7\x2e\x27\xbc\x9f\x56\xad\xca\xe3\xa2\x13\xae\xba\x30\x96\x0d\xd2\xd7\x2d\xb6\xec\xe8\x2f\xe1\xe4\x7a\x89\x93\x39\x55\x3f\x67\xff\x39\x2d\x33\xfb\x79\xda\xbf\x4f\xdf\x6f\x27\xb9\xf0\x33\xd4\x9e\x51\x1f\x52\x4f\x12\xf4\xb8\x50\x5c\x42\xfd\x05\x9a\x32\x59\xcb\x5a\xbf\x1b\x8f\x15\xbf\x24\xb7\xfd\xfc\x80\x9d\xf6\x44\x46\xa2\x2e\xcb\x20\xf8\x87\x94\x4c\x8c\x03\xc1\x64\x40\x5c\xc6\xf1\xb4\xbc\x4d\x79\x9f\x9c\xe7\x93\x5a\x81\xb0\x57\xf0\x94\xba\xf3\x34\x76\xcb\xb6\xd8\x0c\xa9\x54\x3f\x9a\xb6\x8e\x51\xb7\xd1\x59\xa3\x04\x13\xb6\x53\x09\x44\x9a\xc4\x4c\x00\x9c\x50\xf8\xa2\xb0\x71\xbf\xa9\x56\x48\x24\x18\x05\xb2\x99\x86\x6d\x57\x8c\x1c\xe4\xf0\xe5\x83\x51\x79\x78\xea\x80\xfb\xcc\x28\x79\xad\xf4\x5f\xe4\xb4\x4a\x74\x7c\x69\x3a\x34\xd9\x55\x5b\x14\x77\x0b\x46\x3e\xd3\x0e\xcd\xcb\x82\x58\x51\xe1\xd3\x69\x85\x84\x77\x1d\xfe\x3c\x2f\xb9\x7e\x09\xa5\xfe\x63\x16\x18\x2d\xed\x1c\x27\x37\x23\x7f\x4e\x60\x1c\x39\x1a\xe5\x64\xd9\x6b\x24\x88\xd2\xf7\x0c\x01\xf2\xba\xc1\xf7\xe0\x95\x84\x39\x72\xf4\x65\x45\x32\x07\xe5\x1f\x27\xde\x17\x0a\x74\xb2\xfb\xb6\x7e\x5f\x77\xa5\xfa\x70\xf5\x40\x28\xad\xb9\x0b\x07\x74\x3a\x39\xf9\x78\x66\x17\x44\x93\xbb\x59\xf6\xf0\x15\xf0\x95\xaf\x9c\xe6\x98\xa5\x2b\x53\xd0\xc9\x4d\xe4\xa5\xdc\xae\x0f\x8d\x43\x8e\xf4\x62\x64\xae\x17\x90\xb9\x1b\x32\xcf\x01\x8c\x93\x6b\xda\x0e\xfa\x4d\xdc\x12\x3d\x78\x5f\x6d\x0e\xb5\xe6\xa8\xd2\x74\x80\x65\x26\x65\x13\xc3\x7d\x03\x57\x3f\xda\x91\x4f\xb6\xd2\x2a\x76\xff\x52\xaf\x1f\xfc\x47\x18\x4e\xfb\x03\xc5\x02\x3b\xeb\xf0\xe7\xd1\x16\x44\x8d\xc3\xab\xa1\x1e\x7c\x74\x58\xb0\x80\x7f\xfd\xc3\xc1\xb9\x5d\xd2\x85\xea\x63\xad\x8b\xad\xa4\xe4\x76\xa5\xc6\x1d\x3e\xa5\xca\xdb\x3d\xf5\x1c\xc8\xe6\x99\xf5\xc8\x6c\x76\x36\xcd\xad\x87\x65\xb4\x1f\xc6\xf5\xdb\xd9\xd9\xd5\x68\x0f\xfc\x9f\x7f\xff\x47\x12\xaa\x4a\x1f\xd8\x61\x6d\xdf\x81\x53\x56\x2a\x91\x37\xfe\xdf\x60\x02\x8d\xde\x99\xaa\x1f\x92\x36\x8d\xc1\xe7\x56\xc5\x37\xc3\x47\x3b\x08\xf9\xbb\x73\xf4\xf1\xc5\x98\x5f\x8e\x35\xba\xa8\xe7\x71\x36\x96\x56\x5a\x42\xfd\xb9\x53\xfa\x6d\x9f\xdd\x19\x17\x21\xa6\xbc\xc2\x47\x9f\x97\x96\x17\x95\x7b\x41\x8f\x73\x75\x6b\xaa\xfe\x50\xd9\x47\xcd\xe6\x82\x29\x51\xa5\x9b\xc3\x36\x60\x5e\x37\x2a\x91\xf4\xa8\x9d\x05\xd5\xea\xf6\xec\xa0\x7f\x3a\xf9\xbd\xd4\x94\xde\x2f\xc8\xb5\x6e\x76\x21\xdf\xdf\x27\xe7\x51\x4f\xac\x3e\xb6\xf8\x88\xbc\x28\x5e\x56\x1d\x10\x2f\x5a\x8b\x99\x26\x9a\x58\x79\x6f\xa3\xe6\xc3\x7c\x94\xb4\xe3\xe5\xfc\x7f\x58\x72\x25\xe9\x3b\xc4\xfc\x59\xd7\x85\xa5\x55\xcf\xee\x38\x95\x48\x4a\x6b\xca\x37\x8e\xf9\x6c\xaa\xae\xe6\xf4\x77\x07\x5a\x96\x8e\x42\x89\x9e\x25\x01\xa9\xcf\x45\xc3\x9f\x2f\x59\xfb\x25\xb6\xa3\xe7\xfb\x14\xfb\x65\xb8\x4d\xb6\xd7\xd6\x1f\x6a\xdc\x68\x3a\xf1\x02\xd4\x72\xed\x3c\x9d\x00\x0e\xe1\x29\x76\x6f\x82\x7d\x23\xe9\xe7\x2a\x7c\x51\x58\x4b\x13\xa9\x1f\x19\x66\x26\xbb\x22\xeb\x9f\x9f\x2f\xff\x62\x00\xf6\x19\xbc\xd5\xcf\xee\xb9\x54\xdb\x2c\xa5\x01\x9e\x6b\x21\x01\xb4\x99\xdd\x94\x2d\x24\xfa\xd9\xe1\xa1\xfb\xbe\x45\x3e\x24\xd2\xa5\x3b\xe9\xfa\x5b\xd3\x5e\x27\xf5\x57\xd2\x0a\x4a\xdb\x69\xc4\xd2\x0f\xdd\xa9\x88\xd9\xc0\x4c\x37\xaf\x32\xa7\xfc\xe1\x15\x05\x0f\xfa\x36\xb4\x9f\x86\x57\x21\xe6\x65\x81\x6c\xd5\x27\xdd\xb5\x7b\x1f\xbe\x10\x2d\x3b\x59\xd5\xbb\x55\x21\x3a\xfa\x3a\x0a\x7d\x6c\xcb\x25\x4d\x53\x30\x92\x99\x99\x8e\xf6\xbf\x02\x00\x00\xff\xff\x9c\x48\x63\x9e\xb4\x3f\x00\x00")
+var _complySoc2StandardsTsc2017Yml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x5b\xdd\x92\xdb\x36\xb2\xbe\x9f\xa7\xe8\x4b\xbb\x8a\x9e\x53\xd1\x78\xec\x24\xe7\x6a\xa2\x78\xeb\x4c\xd5\xc9\x89\xca\x76\xf6\x1e\x03\xb4\x24\x1c\x93\x00\x03\x80\x1a\x6b\xaf\xf6\x35\xf6\xf5\xf6\x49\xb6\xba\x09\x80\x20\x25\x6a\x24\xd9\xbb\x17\xa9\x72\x34\x24\xd0\xfd\xf5\xff\x0f\x8d\x68\xf0\x67\xf8\xfc\x69\x79\xb3\x5c\xfe\x70\xfb\xc3\xcf\x37\x00\x6b\xd1\xe8\x7a\xff\x33\x2c\x97\x3f\xdc\x00\xf4\x0f\x3c\x9a\x80\x1b\xa7\xc3\x1e\x84\x51\xf0\x21\x6c\xb5\xf4\x37\x00\x0a\xbd\x74\xba\x0d\xda\x9a\x9f\xe1\xf3\x16\x01\x4d\xa0\x87\x14\x36\xd6\xf8\xe0\x44\x40\x0f\x02\xa4\x6d\x1a\x1d\x1a\x34\x01\x82\x05\x3d\x3a\x0b\xe9\x2c\x51\xc3\x4e\xd4\x1d\x7a\xa6\x62\x31\x47\xc5\x2f\x56\x38\x05\x8f\x46\x61\x8b\x46\xa1\x91\x78\x8c\x86\x27\x7e\xca\xae\x41\x69\x87\x32\x58\xe7\xc7\xf4\xe8\xe2\x7d\x58\x3b\xdb\x40\x23\x8c\xd8\x20\xd3\x47\xec\xe1\x57\x74\x52\x7b\xf4\x60\x77\xe8\xbc\xde\x6c\x03\xd8\x35\x84\x2d\x82\xc2\x1d\xd6\xb6\xcd\x8f\xb6\xe8\xd6\xd6\x35\x82\x8e\xb2\x6b\x66\xcd\x19\x51\x83\xb4\x26\x38\x5b\x33\x3b\x77\x73\xec\xfc\xee\x36\xc2\xe8\xbf\x09\x22\x5d\xd4\xf0\x29\xb8\x4e\x86\xce\x1d\x30\xf5\xdb\x40\x1e\xfa\x20\x9e\x6a\xed\xb7\xe8\x2b\x78\xd6\x61\x9b\xb8\x4d\x84\x56\xe0\xd3\x31\xbe\x02\x87\xad\x75\x41\x9b\x0d\xd4\xda\xd0\x2b\xc4\x9e\x68\x5b\x67\x5b\xa7\x45\x40\x10\x5d\xd8\x5a\xa7\x83\x26\x39\x19\x05\x0e\x7d\x6b\x8d\xd7\x4f\xba\xee\x7f\xd4\x86\xf9\x6e\x3b\xe7\x3b\xcd\x30\xd8\xa7\xff\x47\x19\xf4\x2e\x0a\xeb\xed\x1c\x77\xff\xa3\x9d\x36\x9b\x0a\x3e\x3b\xa1\x0d\x91\x40\x77\x7f\xc4\x40\x2a\x62\xcd\xb5\xca\x23\x42\x70\x42\x86\x2a\x49\xa2\x8a\x64\x07\xa1\x0d\xe9\x59\xcb\x17\x80\x36\x4a\xef\xb4\xea\x44\xcd\x2c\x88\x5a\x6f\x0c\x1f\xc1\x98\x4d\x58\xb8\x9f\x63\xe1\x31\x9f\x02\x0f\x52\xda\xce\x04\xc1\xc0\xec\x4f\x50\xbf\xb5\xb5\xf2\xa3\xfb\x45\x7a\xb5\x46\x58\x5b\x47\x78\x6a\x77\xa0\x2a\x97\x41\x7f\x7b\xb3\x5c\x2e\xa6\xe6\xba\xc8\x84\xff\xe1\x59\x1d\x1f\x0d\x2b\x27\xd1\x08\x9f\xf6\x3e\x60\x73\xca\x68\xed\x13\x81\xe8\xc1\x3a\xd8\xa0\xc1\x88\xbf\x51\xd0\x91\x29\x38\xac\x71\x27\x4c\xa8\xe0\xcf\x4e\x10\x06\xa0\x8b\xd3\x83\x05\xdf\xb5\xa4\x6c\x4c\xf3\xba\x33\x92\x7e\x27\xb1\x1f\x37\x8b\xc5\xed\xe2\x05\xe2\x97\xb6\x69\x3a\xa3\xe5\x88\xfc\x0a\x1e\xe3\x59\x27\x18\x49\xd7\xd5\x7b\x90\xf9\x10\xf4\x25\xc1\x15\x68\x23\xeb\x4e\x31\x81\x19\xd5\xe3\x26\x40\x32\x9b\x72\x50\x81\x41\x89\xde\x0b\xb7\xbf\x86\xf7\xbb\xeb\x78\xff\xf0\xf5\x45\xde\x47\x0c\xb3\xb2\x63\x7c\x09\x5a\xe1\xd8\xa4\x1d\x6e\x84\x63\xce\x1b\x11\x02\x3a\x0f\x62\xbd\x26\x04\xcc\xe6\x4c\x06\xee\xa6\x9a\x77\x97\x19\xf8\x3d\x83\x79\x82\x48\xdf\xa2\xd4\x6b\xa2\x65\xd0\xe8\xde\x9b\xf9\x6e\xbd\xd6\x52\x93\xa5\xca\x5a\x70\x90\x08\x16\xd0\xb0\xf1\x10\x71\x5a\xd1\x19\xeb\x04\x0d\x09\x4c\x78\x8f\xde\xb3\x75\xdb\x35\x38\xed\xbf\xb0\xb6\x8a\x9e\x21\x5b\xdc\xc1\xa4\x2f\xe6\x48\xff\xa8\xfd\x17\x7a\xfe\x2c\x16\x12\x1d\x84\x27\xdf\x18\x2c\x83\x27\xe4\x56\xe3\x0e\x13\x35\x3a\x8c\x78\x14\xd2\x59\xef\x21\x0c\xe7\x30\x03\x46\xd4\xfb\xbf\xe5\x93\x04\x79\xbd\x27\xe1\x75\xaf\x7a\x0a\x03\xba\xa6\x77\xa1\x5b\xfb\xcc\x2f\xf7\x4c\xfa\xad\xed\x6a\x05\x4f\x18\x03\x98\x62\xfe\xee\xe6\xf8\xfb\x8b\x13\x9d\xba\x8c\x4b\x49\xb1\x40\x91\x8a\xd0\xad\xad\x25\xdf\xaa\x45\xcd\x74\xad\xf9\x38\x72\xae\x8c\x3f\x91\x77\x0a\x89\x89\x14\xde\xce\x51\xf9\xd8\xb4\x42\x32\x76\xcb\xad\x30\x9b\x73\x85\x30\x28\x02\x7a\x90\xfd\x9b\x10\xb6\x22\x80\x64\x90\xbc\xde\x18\x56\x1b\x13\xea\x3d\xe8\xfe\x12\x62\xca\xb3\x5f\x9c\x51\xf4\xb7\x53\x45\x7f\x9b\xe9\xfc\xcd\x1a\x1d\x2c\x85\xb8\x13\x04\x7a\xac\x51\x06\x9f\xa3\x95\xaf\xca\xac\xc1\x83\x35\x1b\x1b\x43\xe3\x7f\x59\x07\x1e\x5b\x41\x6e\x17\x90\xb2\x21\xd6\x70\x86\x53\x78\x89\x8e\x9c\x33\x3c\x6f\x31\x6c\x91\x63\x08\xf9\xb6\xd6\x1a\x34\xc1\x1f\xa3\x1e\x84\x43\x68\x1d\xfa\x94\xaa\x14\x66\xcd\x9c\x2d\xe6\x38\xfb\x88\x0d\x2a\x2d\x5e\x88\xd2\x91\xc4\x08\xfd\xc4\xcf\x4e\x48\x51\xd8\x1b\xb5\x8c\x61\x4d\x40\xd0\x0d\xd6\x7b\xd2\x5b\x43\xdc\x90\xed\x58\x8f\x85\x8b\x8a\x3e\x38\x85\x4c\xf1\x85\x60\x92\xd6\x51\x46\xa7\x77\x08\x42\x4e\xdd\xb8\x47\xa3\xad\x9b\xe6\x72\xe1\x68\x42\x58\x81\xf0\x65\x1e\x74\xb3\x5c\xde\x4f\x45\x7d\x9f\x01\xc9\xa6\xd2\x1b\xcf\x6f\x3a\xe8\xcd\x4b\xf0\x44\xc9\x33\x38\x49\xf8\x83\x68\xe8\xb4\x3e\xb8\x44\x15\x35\xc1\xe9\xa7\x2e\x60\x32\x9e\x26\xdf\x01\x76\x7d\xa6\x5d\xd1\xbb\x42\x4a\x6c\x29\x45\x44\xa8\xe9\x56\xca\xd1\xee\x6f\x17\x73\x9c\x7d\x46\xb9\x35\xb6\xb6\x9b\x3d\x2c\x7b\xda\x4e\x99\x9b\xa8\xbd\x3d\xce\x58\x9f\x2f\x14\xba\x37\x30\x48\x29\x34\x84\xe1\x9e\x49\xb4\x3c\xe5\x25\xee\x6f\xef\xe6\x08\xff\x90\xf3\x60\x05\x2b\x5b\x6b\xd2\xac\x13\x94\x2b\x6c\x6b\xbb\x9f\x11\x80\xb3\xdd\x66\x0b\x6d\x3c\xa5\x77\x1a\x39\xcf\x86\x67\xfa\x5f\xed\x01\xbf\xb6\x28\x03\x92\xb7\x56\x94\x52\xb6\xce\x4a\x54\x9d\x4b\x6f\xb4\x5d\x18\xce\xd0\x86\x65\x41\x74\xdc\x2c\x97\xef\xa6\xaa\xf5\x2e\xf3\xf1\xbf\x76\xc3\xd5\xcf\x83\xa4\x6c\xe2\x04\x07\xba\x69\x6b\x46\xc9\x43\x1d\xdf\x11\xfc\x0e\x78\x94\x1d\x07\x4a\x6f\xd7\xe1\x59\x38\xa4\xdc\x66\xed\x44\xae\x03\x62\xd6\xef\xe4\x56\x07\xe4\x5f\xa2\x54\x5a\x67\xe9\x07\x54\xa3\x54\x8e\xbc\x68\x60\x6d\x8b\x7f\x27\x39\x35\x7d\xa1\x94\xef\xc2\x1d\x93\x12\x2c\x34\x88\xa1\x08\x68\xff\xfc\xfb\x3f\xca\x88\xc7\xdc\x2f\xe6\xb8\xff\xc3\xa3\x9b\x61\x7d\xe5\xc8\x98\xa9\x5a\xf4\xbe\x23\xdb\x8f\x7e\x5a\x3a\x64\xa7\xcf\x89\xb5\x51\xb0\x71\xc2\x84\xe2\xef\x3d\x28\x55\x19\x62\x1d\x6e\xb4\xef\xf3\x1c\x0a\x12\x7d\xc5\x43\xe1\xd6\xe0\xf3\xe0\xab\x08\xa3\x9c\x2a\x75\x9e\x1e\x7f\x66\x9f\x14\x61\xd6\x1e\x84\xa2\x30\x4c\x47\xa1\x82\xa7\x7d\x71\xc7\x2d\xfc\x85\xa8\xe5\xe7\x2f\x7d\xb7\xa2\xfc\xda\x1d\xe5\xcf\x21\x38\x6c\xec\x0e\x15\x39\x7e\xd3\x3f\x38\x9c\x69\x2c\xd4\xd6\x6c\xd0\x0d\x4c\x51\x0a\xf0\xee\xf6\x6e\x0e\xf0\x8f\xb6\xc6\x37\xbf\x08\x8f\xea\x65\x8d\x1b\x80\xaa\xa0\xb1\x8a\xc3\x6c\x45\xc5\x41\x4f\x92\x4f\x84\x04\x0b\x4a\x04\x51\x15\xfa\x97\x42\x4d\x0c\x77\x36\x6c\x5f\xd2\xb6\x27\xa6\xc9\x1a\x70\xb6\xa6\x0b\xa7\x49\x38\x5f\x5c\x84\x6b\x85\x14\xd0\xf9\xf4\x18\xec\x2b\xd8\xe8\x1d\x29\x42\xca\x59\x72\x61\x42\xaf\x49\x6b\xc8\x31\x7a\xf2\x98\x35\x0a\x1f\xa0\x75\x7a\xa7\x6b\xdc\x20\x1f\xe2\x71\xe3\x70\x70\xb8\xaa\xeb\x2f\x3d\x4f\xbf\xdf\xce\xc1\xbd\xda\xee\xfd\x79\xe6\xed\xd0\x07\xa7\x29\x60\xb4\xe9\x9d\x01\xde\xb5\x90\xa9\x16\x21\x5a\x4f\x02\xf9\x8a\x52\x33\xfc\x2a\xc8\x5d\x54\x2c\x18\x90\x48\x3a\x5e\x9c\x52\xc1\x93\x90\x5f\xde\x74\x2d\x70\xa0\x07\x1f\xac\x13\x1b\x2c\x85\xe5\xd1\x78\x4d\x1c\x42\x6d\xfb\x54\xdb\xbf\x26\x52\x06\x45\xa3\x24\xc6\x5b\x63\xb0\x3e\x13\xa5\xfb\x39\x94\x7e\x25\x22\x7f\xd5\xbe\xb5\xfe\x64\x69\xa3\xb4\x27\xff\xad\x4d\x87\x85\x13\x34\x6a\x80\x2c\x22\xc3\x69\x53\xef\xe1\x32\x98\xbd\x9a\x59\x53\xef\x41\xac\x43\xcc\xa0\x62\x35\x4f\x0c\x38\x14\x8a\x74\xcc\xa1\xe4\x37\x19\x39\xc2\x23\x69\x75\xef\x00\xfb\x34\x25\x62\xbd\x15\x1e\x9e\x10\x0d\x28\xcd\xc6\xbd\x4d\xd1\xa1\x34\x4d\x87\x7f\x76\xda\xa1\x3a\x13\xa5\x77\x73\x28\xa5\xda\x0f\x3e\x6f\x1d\x8a\xf0\x8d\xb1\xa2\x41\xe1\x39\x10\x14\x6e\x5e\x6c\xa8\xfc\x27\x65\xe7\x0b\xa2\xc7\xb7\x9d\x93\x54\xa8\x75\x81\x2a\x01\x2e\x68\xa2\x0d\x3e\xd9\xce\x28\xe1\x74\xa4\xfc\xfd\x49\xf9\x2e\x3b\x1f\xac\xea\xeb\x9d\xcf\x4e\x18\xdf\x68\xef\x4f\xa7\x50\x83\x4d\x90\xac\x42\xf1\x12\x39\xa4\x3e\x03\x4a\xed\x9f\xc6\xee\x44\x4d\x96\x5b\x9a\xc4\x58\x5f\x4f\x79\xfa\x68\x56\x64\x70\xa9\x4b\x16\x51\xf1\xa0\x03\xa8\x8e\xf2\xfc\x59\x12\x92\x4f\x14\xe7\x9a\xc2\x8f\x73\x50\xfd\x26\x6a\xd6\xb5\x5f\x31\xea\xf1\x79\x52\x8e\x69\x4d\x94\x26\xc7\x66\x88\x95\x23\xc9\x95\xa2\x9e\x0c\xd0\xb5\xd4\xa8\xa1\x1a\x9a\x9e\x56\x9d\x4c\xde\xae\x33\x05\x4c\x9c\x42\x53\x2a\x64\x3b\x3f\x28\xff\x39\x7c\xbd\x9f\xa6\x39\xef\x33\x5f\x7f\xed\x6a\x4a\x10\xa3\xb9\xcd\x73\x17\x6f\x19\x57\xcd\xa3\x60\xce\xbd\x28\x95\x0e\x60\xde\x9a\x5c\x89\x8d\x92\x32\x9b\xca\xc3\x3d\xbc\xfa\xe1\xf5\x50\x13\x5a\xca\x02\xd7\x7a\xd3\xb9\x54\x60\x51\xfa\xe6\xd0\x77\x75\x00\x7d\x1c\x21\xca\x14\x76\x05\x0f\x3a\x69\xc9\xab\xc5\x6b\xf0\x9d\xa7\xd8\x32\x34\x8c\x82\x05\x83\xcf\x75\xf4\x58\x3b\x0e\xf9\x93\xb7\x19\xae\xc5\x1c\x5c\x0f\xc6\x36\xa2\x3e\x05\xd4\x00\x48\xe4\x3e\x5b\x65\x51\x15\xa6\xfa\xc7\xb6\x29\x1a\x72\x17\xdb\xfa\x51\xed\x48\x01\x43\xf0\x85\x39\xfb\x25\x89\x53\x07\x93\x3c\xff\x8e\x3b\x89\x83\x4a\x08\xae\x67\x8d\x08\x1d\x25\xfc\x4a\x7b\x41\x49\x4d\x84\x03\x9d\xb3\x07\xfd\xa4\x42\x5f\x0a\x87\x7b\x44\xd0\xff\x5d\xd0\x41\x24\xc4\xbe\x08\xbb\xce\xd4\x05\xc1\xb2\x04\x26\x1f\x91\x4a\xdc\x49\x6a\xca\x00\xdf\xcd\x01\xfc\x29\x3d\xfc\x68\x24\x6b\x09\x7c\xc8\x15\xf7\x59\x35\xef\xe4\xb6\x13\x14\xf6\xed\x07\xeb\x60\x2b\x76\x18\xd5\x8c\x73\x20\x10\xb0\x16\xba\xee\x1c\xa6\xe1\x42\xbc\xe5\x38\x38\xf0\x2a\xdf\xa9\x23\xd1\xfe\x35\x81\x5e\x81\x5e\x83\xb7\x15\x15\xc9\x04\x5c\x8c\x7f\x63\x57\x20\x94\x72\x1c\x00\x3a\xb9\x4d\xd7\xf6\x36\xfb\xf6\x7c\x8c\x3e\xf6\x49\x19\xc2\xaa\x16\xa7\x54\xb2\x4f\xde\x54\x69\x83\x1a\x15\x1c\xd2\x4f\x89\x30\x7e\x45\xd9\x71\x06\x2f\xb8\x4f\x60\x18\x9c\x78\x63\x4c\x03\xa9\x91\x61\x37\x4e\x34\x74\x62\x67\xa8\x21\x15\x98\x73\x72\x7c\x42\x1b\xca\x17\x39\x95\x09\x31\x89\x29\xda\x11\x47\xae\x3d\x52\xfd\xbf\xbf\xbd\xbf\x02\x87\x0f\x3d\xed\x27\x75\x26\x03\x70\xd0\x05\x2a\x1c\x78\x59\x8f\xda\x9c\x83\x70\x00\x3e\x09\xe0\xcd\x72\xf9\xe3\xd4\xeb\xfe\x98\x29\xef\x1b\x68\xa9\xb2\x3f\x41\x63\x76\xff\x4c\x23\x65\xd6\x05\xb1\xac\x3e\x92\x93\x18\x5f\x65\xdf\x49\x0e\x50\x59\xd9\x71\x00\xaa\x20\xa0\x27\xb7\xc0\xa3\xa5\x1d\x1e\x32\x58\x78\xdf\x69\x71\x3a\x2d\x1e\x72\x2c\xce\x7e\xfc\x88\x35\xdc\x2c\x97\x3f\x4d\x19\xff\x29\x33\xfe\xab\xf6\xae\x63\xbd\xbc\xa0\x63\x53\x0a\x2a\x36\x39\xaa\x71\x97\x83\xda\x30\x65\x67\xa6\x90\x1a\x39\xd1\xd8\xbd\x75\xda\x93\x36\xb3\xf0\x86\x8e\xe9\x53\xe7\x69\x02\xe7\x41\x65\xda\xc8\xfe\x7e\xba\x5d\xcc\x31\xf1\x57\x34\xca\xba\xc8\x40\x6e\x6a\x9d\x92\x62\x6a\x7f\x12\xd1\x7d\x1b\x6c\x68\x29\x7b\x2b\xb5\xa0\x9a\x81\xdb\xec\x3b\x3e\xbb\x7f\x32\x53\x46\xcd\x37\x83\xce\xdf\x3c\x4c\x06\xc1\x0f\xc3\x44\x6c\x29\x5a\x21\x49\x05\xc9\x05\x98\xd3\xcd\xcf\x46\x68\xb6\x4e\x5f\xe5\x20\x15\xa3\x44\x76\xa3\xb2\x73\x8e\xbc\x6f\xcc\xbd\x08\x37\x99\x6e\xa0\x27\xbb\x7e\x1c\x72\x18\xda\x5e\x1d\xd7\xa2\x32\x61\xe7\x7a\xa5\x87\x61\x38\x54\x61\x43\xcf\xd0\x7f\x93\xb9\x42\xd2\xd5\x1c\x28\x85\x52\x3a\x4e\x66\xf3\xeb\xc1\xc2\x16\xeb\xf6\x98\x7f\x26\xd4\x16\x33\xa8\xfd\x22\xe4\x97\xae\x8d\x03\x50\xb6\xed\xfd\xf7\xb1\xc6\xc1\xc2\xaa\x18\xe5\x71\x64\x85\x85\x08\x8a\x4c\xc9\x03\x9a\x9d\x76\x96\x27\xa2\xe3\xb2\xa9\x34\x44\x82\x34\x57\x8a\x93\xec\x38\xba\xa8\xfd\xa4\xd5\x34\x67\xad\x0f\x93\x29\x78\x81\x4d\x02\x04\x3e\xa3\x0f\xa7\x15\x8a\x9d\x4c\xf2\x8e\x7b\x68\x6b\x31\x6a\xc3\xc5\xb6\x62\xd1\x0c\xca\x8f\xce\x39\x91\xe9\xc6\xc3\x40\xd6\x92\xfc\x5c\xec\xc4\x8c\xe6\xa8\x8f\xd1\x4d\xc8\xf3\xfd\x49\x34\xc8\x28\x0a\x90\xe5\xd1\x93\x7a\xe5\x74\x92\xdd\x8f\xb5\xfa\x5a\xb2\x3c\x45\x87\xfd\xcd\xc1\xde\xc4\x19\xbc\x9c\x57\x74\xb7\x96\x9c\x8a\x5d\xff\x7b\x08\xbf\xbd\x59\x3d\x4e\xc4\xb0\x7a\x1c\x68\x5f\x0d\x9e\x61\xd8\x41\x39\x6b\xf6\x72\x6c\x9c\xcd\xbd\x36\x7f\x90\x23\xbc\x34\xdd\x1e\x26\xa6\xc4\xe1\x71\xb6\x06\x17\x56\x0e\x25\x38\xa3\x61\x37\xc2\x08\xb2\x49\x25\x53\x52\x29\xd4\x51\x29\x16\xbb\x50\x6e\xa7\x25\xf6\xf3\x51\x88\x7d\x97\x6a\xda\x32\x8f\x4e\x31\xbe\xe8\xcb\x37\x3d\x83\xb9\xb8\x08\xcc\x07\x29\x3b\x27\xe4\x29\x8f\x54\x04\xf2\xdc\xe2\x9e\x84\x69\x32\xc9\x64\x76\xda\xb4\x5d\xf0\x25\x0c\xb9\x40\xe5\xc7\xa8\xf8\xa8\x69\x4f\x83\xe2\x21\x9d\x23\x22\x09\xcc\xea\x50\x89\x25\x0e\xab\xcc\x5e\x72\x3e\xc9\xce\x5f\x54\x3c\xc6\xe3\xee\x22\x3c\x7e\x4f\xd5\x92\xff\x7e\x88\x0c\xca\xf1\xef\xe1\xf0\xed\x65\x1c\x76\x81\x04\xf4\x6d\xec\x11\x61\xe2\x0b\x82\xd8\x09\x5d\xf3\xc0\x89\x3b\x0e\xb5\x26\xb6\x2d\xdf\x90\x05\x5d\xef\xab\x28\xe3\xf8\x6f\x0a\xbf\xfd\x04\x90\xa6\x81\x52\x5a\xa7\x78\x85\x8a\x93\x93\xb8\x1d\x10\xd5\xff\x4c\x04\xee\x2f\x42\xa0\x0f\xc8\xdf\x8e\x00\x75\x4e\x71\xd0\x77\x5a\xb0\x89\x62\x8d\x77\xc6\x9e\x2a\xc3\xe1\xaf\xc5\xa3\xb7\xaa\xcb\x61\x99\xba\xd5\x12\x14\xbd\x13\x72\x0f\xff\x67\xcf\x0a\x65\x94\x4e\x68\x45\x13\x13\x1b\xc8\x3f\xc5\x96\x3f\xf8\x8e\xd5\xd0\x83\x78\xb2\x5d\x1f\x5d\xdb\x78\x72\x4b\x5b\x5a\xa4\xd0\x97\x05\x87\xf8\xfa\x2d\x8b\x22\xde\xa6\x3d\x74\xad\x12\x69\xf6\x56\xf8\x6d\x75\x48\xc9\x91\xe9\xf2\xda\xba\xb2\xfb\x33\xa1\xe3\x80\xe0\x91\xdf\x8a\xaf\x69\x33\xf2\xbc\xdc\xf9\x1e\xc7\xc0\xea\x2a\x3e\x6f\x56\x93\x25\xae\xd5\xe2\x40\x46\x4b\x6b\xf2\x0c\x7f\xb9\xb5\x5a\xe2\x09\x49\x8d\x82\x9a\xe4\xa7\x7d\x61\xa2\xe3\x40\x26\x6d\x4d\x65\x0e\x53\xdf\x79\xa4\x2a\x3a\x6e\xe7\x55\x14\xf7\x65\x6d\x7d\x1e\x1f\xaa\x98\x2a\xcc\xf1\x9f\x90\x9d\xe8\x45\xec\x40\xd1\x4c\x06\xff\xec\x68\x63\x93\xe2\xc2\x1a\x84\xd9\x57\x74\x14\x0a\xb9\x8d\x74\xde\xc2\x87\xaf\x2d\xf9\x1a\x5a\xd9\xe8\x59\x8e\xdb\x72\xdf\x97\x50\xed\xe3\xa6\x1b\xaa\xbe\xc5\x3d\x26\xd9\xba\x38\x02\x39\x18\x75\xf4\x94\xa7\x7e\xfe\x2d\x7c\xa2\x5e\x4a\x22\xb5\x3c\x95\xa7\x0c\x89\x76\x6a\x3b\x1b\x45\x87\x74\xae\xb5\x3e\x37\x7b\x26\xd8\x5d\x69\x22\xc5\xd3\xc7\x37\x86\x74\xf3\x1f\xc2\x34\x35\x03\x50\xdd\xac\x26\xfb\x61\xab\x61\x3d\x6c\x95\xde\x2e\xd3\xd0\x65\x26\x65\xaa\xd9\xab\x99\xcb\x22\xf1\xa8\x98\x2d\x9a\xee\xa6\xd5\xce\xcb\xac\x6f\xb2\x0d\x56\x10\x9a\x75\x31\x9a\xdf\x94\x32\x9a\xf7\x96\x44\xf5\x6a\x41\x88\xe3\x44\x8b\x47\xed\xeb\x91\x7d\xd2\xef\x06\x49\x0d\x69\xf7\xa7\xd0\x26\x6e\x50\x3d\x63\x4d\x73\xab\x03\xfb\x21\xb3\x19\x5a\x87\xcc\x0d\x7b\xe7\x03\x09\x13\x49\xe8\x7b\x2b\x3a\x26\xb5\x18\x9b\x62\x9a\x9c\xaf\xe1\x72\x3c\x0e\xdf\xc7\x8a\xf2\xbd\x54\xf7\x66\x35\xd9\xab\x5a\x0d\xcb\x47\x2b\x47\x75\x6c\xda\x83\x3c\xa6\x2e\x27\xbc\x5f\xad\x1b\x1d\xfc\x4b\xae\x3a\x31\x16\x0d\xd2\x97\x3d\x4a\x6d\x2e\xe6\x64\x31\xc7\xc9\x11\xda\xcf\xda\x7f\xee\x97\x99\xfd\x71\xda\xbf\x4d\xdf\xdf\xde\xde\x5d\x42\xed\x19\xf5\x21\x77\x57\xb1\x1e\x17\x8a\x73\xa8\x5f\xa1\x29\x93\xb5\xac\xd5\xb0\x02\xc4\x63\xc5\x4f\xbd\xdb\x7e\x79\xc0\xce\x7b\x22\x23\x51\xa7\x65\x10\xfa\x81\x8a\x40\x35\x09\x04\x93\x01\x71\x1a\xc7\xf3\xf2\x36\xe7\x7d\xea\x38\x9f\xdc\x0a\xc4\x9d\xc6\x67\xb2\xaf\xaa\x1f\xbb\x45\x5b\xac\x92\xb1\x16\x73\x7e\x9a\xd7\x93\xfb\x73\xd6\x68\x09\xd2\xb6\x94\x6a\xb2\xa5\x89\x30\x3a\xf8\x20\xd7\xb9\x06\xd1\x5b\x78\x5c\xa7\x69\x30\x79\x6d\x34\x1a\x55\x35\x39\x98\x86\x2f\xbd\xd2\xa1\x1a\xbe\x7c\x30\x54\xf6\x13\x6a\x0e\x85\x8f\x8c\xb2\xd7\xea\xff\xc4\x4e\x2b\x45\xc7\x6b\xd3\xa1\xc9\xae\xda\xac\xb8\x1b\x34\xea\x85\x76\x68\x5c\x16\xa4\x6a\x9f\x9e\xee\x57\x48\x44\x4b\x5f\x8e\xcc\xd8\x56\x5e\x42\x29\x7f\x8c\x02\xe3\x85\x9f\x09\x4a\xd3\x26\x02\x83\x51\xbe\xcb\x8e\x46\x3b\x95\xf6\x1a\x19\x22\x0a\x01\x3a\x10\xe3\xd6\x7d\x23\x5e\xbd\x30\x93\x72\xb1\x40\x22\xd7\x29\x28\x7f\x3f\xf1\x5e\x29\xd0\xc9\xee\xdb\x6a\x98\x75\xa7\xbc\x96\xa8\xfe\xcc\x28\xad\x84\xa3\xe9\x70\x4e\x3e\x4e\x08\x37\x66\x28\x38\x23\xc9\x29\xf0\x85\xaf\x9c\x44\xe7\xdc\x95\x49\xe8\xc4\x26\xf2\x5c\x6e\x97\x43\xe3\x90\x23\x5d\x8d\xcc\x62\x06\x99\x87\x21\xf3\x1c\xc0\x38\xe5\xdc\x24\x2d\x6c\xc4\x02\x35\x45\x0f\x91\xab\xcd\xa1\xd6\x1c\x55\x9a\xd4\x1c\xa5\x2f\x87\xd6\xe5\x92\xc4\xc0\xd5\xf7\x76\xe4\x93\xad\xb4\x82\xdd\x3f\x8c\xf8\x0f\x33\x4c\x09\xb2\xcc\x16\x48\xad\x16\x54\xd0\x99\x19\x1c\x5e\x0d\xf5\xe0\x93\xa3\x82\x05\xfd\xeb\xef\x0e\xce\xdb\x19\x70\x1e\x86\x31\xe5\xe5\x56\x92\x5a\xa0\xf1\x9e\xe2\x53\x2a\xdf\x97\x3e\xe5\x1c\xc8\xc6\x99\xf5\xc8\x6c\xb6\xb6\x9f\x5b\xa7\xe8\x67\xbf\x1f\xd7\xb7\x47\x67\x57\xa3\x3d\xf0\x7f\xfe\xfd\x1f\x44\x73\x5b\x6b\xee\x86\xd0\xba\x20\xdd\xaf\xad\xd2\x32\x6e\xfc\xbf\xa1\x04\x9a\x56\x2a\xb9\xfa\xa1\xdf\xfa\x31\xf8\xb1\x55\xf1\xf5\xf0\xd1\x0e\xe9\xe3\xfd\x0c\xe4\x23\x7d\xbc\x1a\xf3\xcb\xb1\x26\x17\xf5\x32\xce\xd4\x14\x59\x97\xfb\xa3\x39\x67\xdd\x45\x77\x26\x64\xa0\x2f\xd6\xd8\x7d\xfb\xb8\xb4\xdc\x99\xcb\x8c\x3c\x56\xb7\xa6\xe8\x0f\xa5\x7d\xd4\x68\x2e\xc1\x96\x23\xf4\x78\x08\x6d\x03\xc6\x75\xa3\x14\x49\x0f\xda\x59\xc3\x27\x8c\x33\x83\xfe\xb2\xc3\x78\x8d\x29\xbd\x9b\x31\xa5\xb2\xd9\x45\x7c\x7f\x9b\x9c\x73\x22\x57\x62\x44\xc7\x26\x1f\x11\x17\xc5\xe3\xa4\x9e\x44\xd7\xaf\xc5\x4c\x13\x4d\xaa\xbc\x37\x5d\x2d\x86\xf9\x28\x5b\xe2\xf5\xfc\xbf\x9f\xe1\x3f\x7e\xc2\x18\x3f\xeb\xba\xb0\xb4\xca\xec\x8e\x68\x8f\x4a\x6b\x40\x8c\xce\x26\x8a\x8f\xea\xef\x16\x6b\x95\x3a\x0a\x11\xe2\x9c\x80\x94\xe7\x92\xe1\x1f\x3b\x20\x66\xd3\x47\x9f\x8f\x59\xd0\xb5\x99\xe7\x64\x7b\x6d\x35\x2c\x80\xa4\x01\xc9\x15\xa8\xc5\xda\x79\x3a\x01\x1c\xc2\x53\xd7\xbe\x09\xf6\x0d\x35\x37\xab\x32\x7c\x71\x1c\xef\x27\x52\xc7\x81\xbc\x8e\xc7\xc9\xae\xc8\x6a\x58\x15\x39\xc6\x17\xd9\x41\x4b\xdf\xa4\x7c\x44\x6f\xeb\x17\xf7\x5c\x86\x86\x79\x1e\x71\x71\x0e\xe9\x50\x22\x6f\x66\x57\x69\x0b\x89\xff\xed\xe8\xd0\xf8\xf3\x28\x91\x4e\xdd\x49\x97\x6f\x25\xe0\xb5\xa1\xc2\x82\xf7\xee\x18\x27\x1a\x6c\x47\x73\xa1\xca\xb3\xa3\x6c\xe0\x48\x37\xaf\x30\xa7\xf8\xe1\x15\x07\x0f\xfe\x36\x34\x4f\xc3\x8b\x10\x73\x0d\xae\xb7\xb0\xcc\x49\x77\xe9\xde\x73\xb0\xc9\x3b\x59\xc5\xbb\x45\x21\x3a\xfa\x3a\x8a\x7c\x6c\x23\x14\x52\x6a\x42\x91\xcc\x1c\xf9\x5e\xea\x5f\x03\x00\x9c\x48\x63\x9e\xb4\x3f\x00\x00")
 
 func complySoc2StandardsTsc2017YmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1201,12 +1222,12 @@ func complySoc2StandardsTsc2017Yml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/standards/TSC-2017.yml", size: 16308, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/standards/TSC-2017.yml", size: 16308, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2StandardsTsc2022Yml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x5b\xdd\x72\xdc\xb6\x92\xbe\xd7\x53\xa0\x2a\x17\xb1\xab\x68\xef\xb1\x2c\xdb\x39\xd9\x2b\x65\x22\x9f\x9d\xaa\xd8\x9e\x92\x94\x6c\xed\x25\x04\xf4\xcc\x60\x0d\x02\x0c\x00\x8e\x3c\xe7\x6a\xdf\x61\xdf\x70\x9f\x64\xab\x1b\x00\x09\x72\xc8\xd1\x48\x56\xce\x95\x25\x99\x04\xba\x3f\xf4\xcf\xd7\x8d\xa6\xe1\x35\xfc\xcc\x6e\x6f\x16\xaf\xce\xff\x76\x7e\x7e\xf6\x03\x3b\xff\xdb\x9b\x0f\xec\xd6\xb5\x3e\xb0\x1b\x70\x3b\x25\xc0\xb3\x85\x53\x01\x9c\xe2\x6c\x6d\x1d\xbb\x01\xd1\x3a\x15\xf6\x15\xbb\xdc\x71\xa5\xf9\x9d\xd2\xf4\xdb\xca\x59\x01\xde\x2b\xb3\x61\x4b\x13\x60\x13\x9f\x39\xfb\x81\x2d\xac\x59\x2b\x09\x26\x28\x1e\x9f\xe4\x46\xb2\x95\x53\x3b\x2e\xf6\xec\xc5\x7f\xaa\xb0\x65\xd7\xb0\x53\x1e\x24\x5b\x59\x65\x82\x67\x76\xcd\x3e\x5a\xd1\x7a\xf6\x7f\xff\xf3\xbf\x0c\xe5\x7a\x79\xf6\x03\xfb\x6c\x03\x4a\xba\x05\x26\xac\x03\x26\xb2\x4c\x0e\x6a\xae\x0c\x6b\x8d\xd8\x72\xb3\x01\xc9\xd6\xce\xd6\xa4\x46\xc5\xee\xda\xc0\x9a\x6e\xcd\x35\xad\x79\x0f\x0e\x98\x4b\x1b\x2a\x43\xeb\x9f\xa1\x98\x5f\x3e\x7d\xfa\xf2\x99\x2d\xae\x97\xb7\x57\xd7\xcb\x4b\xf6\x22\x2b\xfa\x92\xbd\x62\xd7\xf0\x67\xab\x1c\x2e\x6e\x1d\xe3\x5a\xb3\x9b\x2f\x0b\x76\xce\x78\x2b\x55\xf0\x67\x8b\xc5\x9b\xd7\x6f\x7e\x3e\x63\x6c\xcd\x6b\xa5\xf7\x3f\xb3\xc5\xe2\xcd\x19\x63\x11\xdb\x0e\x0c\xd2\xfb\x2a\x6c\x95\xf0\x67\x8c\x49\xf0\xc2\xa9\x26\x28\x6b\xa2\x56\x08\x50\xd8\x33\x09\xb5\x35\x3e\x38\x1e\xc0\x33\xce\x84\xad\x6b\x15\x6a\x30\x81\x05\xcb\xd4\x60\x2d\xc0\xb5\xb8\x66\x3b\xae\x5b\xc0\x35\x8d\x0d\xe0\x7f\x26\x8d\xa2\x86\xca\x1a\xc6\xa5\xf4\x4c\x68\x4e\xaf\x59\xc3\x36\x76\x07\xce\x70\x23\x80\x16\x71\xd0\x58\x17\xf0\xd4\x7c\x70\xad\x08\xad\x83\xa8\xd0\xf9\x9c\x42\xbf\x58\xee\x24\x5b\x1a\x09\x0d\x18\x09\x46\xc0\x94\x3a\x77\xf4\x94\x5d\x33\xa9\x1c\x88\x60\x9d\x1f\xaa\xa6\x8a\xf7\xe3\x91\xd5\xdc\xf0\x0d\x90\xaa\xa4\xdd\x37\x70\x42\x79\xf0\x0c\x05\xf6\x6a\xb3\x0d\xb8\x5c\xd8\x02\x93\xb0\x03\x6d\x9b\xee\xd1\x06\xdc\xda\xba\x9a\x74\xb2\x6b\x42\xc9\x19\xae\x99\xb0\x26\x38\xab\x49\x9d\xb7\x73\xea\x7c\x71\x1b\x6e\xd4\x3f\x39\x8a\xce\x35\xbb\xc9\x28\x8c\x95\xfa\xd4\x8b\x07\x3e\xf0\x3b\xad\xfc\x16\x7c\xc5\xee\xd1\x80\x93\xb6\x59\xd0\xaa\x00\xb3\x2a\x20\xd6\xca\xe0\x1f\x50\x66\xde\x34\xce\x36\x4e\xf1\x00\x8c\xb7\x61\x6b\x9d\x0a\x0a\x8f\x9c\xce\xc4\x37\xd6\x78\x45\xae\xa5\x08\x2c\xd2\xbb\x69\x9d\x6f\x15\xc1\x60\xef\xfe\x1b\x44\x50\xbb\xf9\x73\x07\xb3\x45\x40\x24\xdb\xb4\x4a\x46\x68\xcc\x58\x14\xda\x4d\x2a\x2f\x54\xa3\x95\xe1\x6e\xcf\xb8\x40\x6d\xa3\x05\x5c\xcc\x41\xf6\x1f\xca\x29\xb3\xa9\xd8\xad\xe3\xca\xe0\x62\xb8\xcc\x35\x04\x34\x61\x6b\x9e\x6a\xdc\x3c\x04\xc7\x45\xa8\xf2\xf1\x56\x09\x8b\x80\xee\x2d\x6c\xdd\xd0\x06\x68\x38\x6a\xa7\x64\xcb\x35\xe1\xc2\xb5\xda\x18\x5a\x82\x0e\xa2\xc0\x05\x55\x78\x37\xef\x95\x79\x15\x76\x29\x84\x6d\x4d\x48\x81\xec\x88\xf4\x5b\xab\xa5\x1f\xec\xcf\xf3\xab\x1a\x28\x32\x84\x2d\x28\x77\x60\x7f\xcf\x73\x9e\x5c\x4a\x07\x1e\xfd\xa1\x49\xb1\x53\x58\x23\xc0\x19\x4f\x5b\x4f\x9e\xe2\xd9\x62\x71\x3e\x8e\x4c\xe7\x1d\x06\xbf\x7b\x72\x97\xa5\x21\xe7\xc1\x37\xd8\xcd\xde\x07\xa8\x8f\xc5\x27\x7b\x87\xe7\xe1\x99\x75\x6c\x03\x06\xd2\x51\x1a\xc9\x5a\x14\xcd\x81\x86\x1d\x37\xa1\x62\x7f\xb6\x14\xed\x99\x2a\x56\x0f\x96\xf9\xb6\x41\x0b\x24\xf5\xd7\xad\x21\x39\xd1\x82\xa6\xdc\xf6\x31\x86\xcd\xbd\x07\xb4\x8d\x1d\x98\x60\x5d\x8c\x8f\xe5\xd6\x42\x73\xef\xd5\x5a\x09\xfa\x95\x70\x39\x7f\x00\x97\x85\xad\xeb\xd6\xa4\x37\x32\x32\x15\xc5\x73\x14\xf3\x08\x46\x59\x13\xbd\x27\x13\x8f\x8b\xd0\xb1\x77\x02\x55\x4c\x19\xa1\x5b\x49\xba\x77\x67\x3f\xed\xfd\x78\xbc\x63\x70\x2a\x66\x00\xd3\x2d\x9e\xf6\x5f\x08\xab\xe4\x81\xb3\xb5\xb6\xf7\x09\x50\x41\x89\xbc\xd0\x2a\x83\xf9\xf6\x69\x60\x5e\x7d\x7b\x10\xcc\x01\x82\xe4\xe3\x90\x5e\x62\x0d\x77\x04\x90\x83\x0d\x77\x04\x65\xcd\x43\x00\xe7\x19\x5f\xaf\x11\x52\xb3\x79\x76\x44\xba\xcd\x07\x18\xb0\xc6\xd9\x60\x85\xd5\xe4\x73\x6f\xc7\x3e\xf7\xb6\xcf\x36\xa5\x9f\xcf\xa9\xec\x1b\x10\x6a\x8d\x9a\x15\xa6\x41\xaa\xfb\x76\xbd\x56\x42\xd1\x19\xa4\x94\x1e\x2c\x03\x43\x11\x08\x55\x8d\x44\x2b\xdb\x79\xcc\x34\x18\x34\x3c\x85\x48\xbb\x66\x4e\xf9\xaf\xe4\xa7\x3c\xc2\x63\x87\x21\xf3\xed\xd8\x2d\x7a\xd1\xaf\x95\xff\x8a\xcf\x9f\xa4\x42\x96\x03\x4f\x87\x76\x0c\x96\xe4\xe3\x62\xab\x60\x07\x59\x1a\x15\x06\x3a\x72\xe1\xac\xf7\xf4\x60\x5a\x87\x14\x30\x5c\xef\xff\xd9\xad\xc4\x31\x75\xdc\x71\xaf\x52\xe0\x83\x00\xae\x8e\x79\x68\x6b\xef\xe9\xe5\xf8\xa0\xdf\xda\x56\x4b\x76\x07\x89\x5a\xc8\xb9\x83\x6e\x9c\xdd\x29\x09\x9e\xd5\xc8\x2c\x25\x66\x1c\x0d\x29\x45\x73\xb1\x25\x88\x91\x64\x25\xc8\xb6\x0e\x78\x88\xbe\xba\x6b\x35\xc6\xc0\xec\xaa\x04\xe0\xdb\x39\x00\x3f\x3a\xde\xca\xc7\xc1\x28\x30\x10\x48\xb4\x68\xca\x17\x36\x44\x1a\x4d\x8a\xaf\x69\x39\x65\xd2\x01\xa3\x68\xc7\xa0\x1e\x1d\xf3\xc5\x9c\x94\xcb\xba\xe1\x82\xde\x58\x10\xa1\x3e\xf1\x94\x7b\x4b\x03\xcf\x22\x15\x47\xa1\x39\x06\x0b\x3c\x05\xaf\x36\x86\xec\xd2\x04\xbd\x67\x2a\x6e\x82\x62\x7a\x8a\x05\x8f\xf2\xcb\xba\xd9\x72\xaf\xd0\x22\xb4\xfa\x0a\x5a\x6d\xad\x95\x31\x40\xc5\x65\x7b\x8b\x47\x6f\xbc\x18\x7b\xe3\x45\xa7\xeb\x27\x6b\x54\xb0\x48\x66\x8e\x79\x23\x68\x10\xc1\x77\xbc\x24\x11\xb8\x44\x3a\x3d\xb3\x66\x63\x13\x09\xfa\x37\xeb\x98\x87\x86\x63\x56\xec\x4c\xc6\x1a\x3a\x12\xee\x05\x38\xe2\x32\xf7\x5b\x08\x5b\x20\xb6\x40\xbc\xc6\x1a\x48\x15\xca\x01\x73\xe0\x0e\x58\xe3\xc0\x67\xa6\x5b\x44\x32\xd2\xec\x7c\x4e\xb3\x6b\xa8\x41\x2a\xfe\x00\x1f\x4b\x22\xa6\xe3\x1b\xe5\xaa\x91\x28\x12\x62\xe4\x11\x89\xc0\x70\x16\x54\x0d\x7a\x8f\xce\x65\x50\x1b\xb4\x3a\xeb\xa1\x88\xca\x29\x8f\x65\x72\xc4\xbf\x22\x4c\xc2\x3a\x17\x0d\x31\xd1\x94\x32\x15\x7a\x30\xca\xba\x71\x29\x10\x26\xeb\x89\x8a\x22\x41\x4f\xa3\xf1\xac\xdf\x8d\xcf\xfa\xdd\x61\xe4\x8d\x1e\xf8\x49\x05\xb5\x79\x08\x9f\x74\xf4\x91\x26\xa7\xd3\xef\xcf\x06\x57\x8b\x19\x3a\xd9\xb9\x09\x4e\xdd\xb5\x01\xb2\x07\xd6\xdd\x1e\x7d\xe4\x7d\xc8\x39\xc9\x54\x84\x80\x26\xb2\x4a\x8d\xbb\x7a\xd2\xec\x7c\x4e\xb3\x5b\x10\x5b\x63\xb5\xdd\xec\xb1\xd6\x46\xd9\x8e\xf9\x2c\xd7\xde\x4e\x2b\x16\xf9\x9c\x9e\x52\x10\x2b\x1b\x16\xfa\x7d\x46\x94\xe3\x58\xa8\x79\x37\x0e\x88\xbd\xe0\x57\x5d\x1d\x85\xa5\xbf\x46\xf3\x3a\x5e\x1a\x37\xda\xee\x67\x0e\xc0\xd9\x76\xb3\x65\x4d\x5a\x25\x9e\x48\x57\xa7\xb1\x7b\xfc\x55\x79\x06\xdf\x1a\x10\x01\x52\xb4\xa0\xc8\x2f\x40\x62\x99\x16\xdf\x68\xa8\x61\x90\xd6\x50\x86\xce\x82\x6c\xe4\x6c\xb1\x78\x3f\xb6\xad\xf7\x9d\x22\xbf\xd9\x0d\x55\xe2\x97\x02\x39\xd9\xb1\x80\x59\x37\x9a\x60\xf2\x4c\xa7\x77\x38\xbd\xc3\x7c\xea\x36\x30\x6f\xd7\xe1\x9e\x3b\x40\xb7\x58\x3b\xde\x15\x92\xa9\x6c\x74\x62\xab\x02\xc4\xd2\x32\x1e\x0b\x52\x8f\xa8\x54\x49\x78\x89\x10\x93\x35\xa5\xff\xc7\x83\xaa\x63\xa5\xdd\xed\x05\x3b\x12\x25\x58\x56\x03\x84\x22\xef\xfe\xe8\x4f\xa9\x30\xbf\x35\xdc\x48\x90\x45\xa4\xc3\xd3\x4f\x0a\xa5\x53\xea\x92\xfa\x50\x1b\xc2\xf3\x7c\x0e\xcf\xdf\x3d\xb8\x19\x30\x57\x0e\x03\x44\xb0\x4c\x79\xdf\x52\xc8\x88\xf9\x43\x38\x48\x3d\xa6\x68\xd5\x1b\xc7\x4d\x28\xfe\x3f\x4a\x55\x95\xdc\xc2\xc1\x46\xf9\x48\x17\x11\xd9\x58\x84\x63\x56\x31\x70\xdf\xc7\xbf\xd8\x8b\x48\xbf\xb4\x1e\x1f\xbf\xa7\x38\x97\xf4\x54\x9e\x71\x89\xfc\x03\x97\x02\xc9\xee\xf6\xc5\x1e\xaf\xd9\x47\x2a\x0b\xf1\xf9\xc7\xbe\x5b\xd1\x1b\x93\xfa\x51\x07\xab\xb6\x3b\x90\x98\x4c\x4c\x7c\xb0\x5f\xd3\x58\xa6\xad\xd9\xe0\xdf\xb2\x52\xb3\xdc\x27\xc6\x5e\xf0\x43\xda\xaf\xd6\x6b\x70\xe8\xcd\xb4\x72\xd8\x37\xe4\xca\xef\xc7\xae\xdc\x9f\xd8\xb5\xd5\xf0\xea\x17\xee\x41\x3e\xec\x04\x3d\xd2\x15\xab\xad\x24\xfe\x50\x61\x41\x19\x75\xf2\x59\x93\x60\xa9\xfe\xa8\x0a\x97\xc8\xf9\x2f\xe5\x60\x4b\x79\xf4\xa8\x03\xdc\x91\x4c\xd6\x30\x67\x75\x6c\xc6\x0c\xab\x2b\xda\xb8\xe0\x21\x12\x90\xa9\xc4\x7c\x18\x59\x4c\xc5\x36\x6a\x17\x33\x57\x24\x63\x5d\x31\x1b\x13\xb8\xc1\x60\x4d\xe9\x5b\x03\xf7\x81\xca\x73\xa5\x61\x13\xfb\x6b\x1e\x36\x58\x9e\x64\xe7\x90\x6d\xdc\xf4\x21\x97\x43\xb0\x2f\xe6\xc0\x5e\x6d\xf7\xfe\xb4\x78\xe3\xc0\x07\xa7\x30\xd2\x37\xf9\x9d\x1e\xdc\x35\x17\xb9\xc4\x24\x42\x73\x0c\xc6\x17\x98\xc1\xe1\x1b\xc7\xf8\x55\xc5\xb2\x50\x00\xba\x48\xb1\x4a\xc5\xee\xb8\xf8\xfa\xaa\x6d\x18\x71\x0f\xe6\x83\x75\x7c\x03\xe5\x51\x79\x30\x5e\x51\x12\xd6\x36\x96\x28\xfe\x25\x65\xbc\xce\x4e\x91\x57\x79\x6b\x0c\xe8\x93\x30\x7a\x37\x87\xd1\xaf\x28\xe2\xaf\xca\x37\xd6\x1f\x2d\x2f\xa5\xf2\x18\xa9\x94\x69\xa1\x88\xc9\x08\x47\x06\x2c\xe1\x42\x3c\x2e\x06\xdc\x0e\xca\x88\x8d\x35\x7a\xcf\xf8\x3a\x24\x4a\x97\x1a\x49\x28\xbe\x03\x2e\xa3\x61\x0b\x7a\x93\x70\x23\xab\x48\x16\x1d\xe3\x71\x8c\x0f\x69\xb5\x2d\xf7\xec\x0e\x00\x9d\x90\x22\xc3\x36\x67\xab\xd2\xaf\x5d\xee\x47\x3f\x31\x74\x4f\x7b\x3d\xec\x94\x80\x2c\xec\xbe\x48\x8e\x04\xf5\xfb\x39\xa8\x73\x11\xcf\x6e\x63\x65\xf4\x7d\xf9\xaf\x06\xee\x63\x42\xee\x53\x17\xdf\x70\x65\x7c\xe8\x4a\xaf\x98\xc5\x6c\xeb\x04\x26\xc1\x36\xa0\x5b\x52\x2d\x99\x9c\xf8\xce\xb6\x46\x72\xa7\x92\xe4\x1f\x8e\x1a\xc9\xa2\xf5\xc1\xca\x58\x6a\xde\x3a\x6e\x7c\xad\xbc\x3f\xce\x0b\x7b\xb7\x42\xdc\x43\xf1\x12\x46\xb4\x48\x84\x72\xfb\xb2\xb6\x3b\xae\x23\xb9\x1f\x74\xc2\x0a\x93\x3f\x96\x6b\x92\x67\x0a\x2a\xac\xaa\xd2\x51\x3d\x53\x81\xc9\xd6\x51\x4d\x3a\x23\x42\x0e\xaa\xfc\x34\x6f\xfa\x69\x0e\xa8\x4f\x5c\x93\xb9\xfe\x0a\xc9\x15\x4e\x3b\xe3\x8e\x03\xd0\x59\x12\xdb\x60\xa9\x64\x17\x91\xda\x63\xb9\xd6\x36\x36\xf6\x43\x15\x3e\x2d\x5b\x91\x83\x65\x6b\x0a\x90\xa8\x2c\x40\x66\x66\x5b\xdf\xfb\xcf\x53\xa9\xcb\x54\x6b\x67\x79\xcb\x82\xb5\x89\x3f\xf4\xb4\xc1\xb6\x26\x50\x5b\xe7\xc3\x98\x00\x7e\xe8\xf0\xf9\xa3\xe8\x03\xec\x8f\xa0\x94\xa4\x1d\xb6\x3d\x06\xa4\x84\xda\xa8\x32\x2f\x40\xa2\xd4\x5d\x95\x3a\xe0\xab\x36\x97\xdf\x7b\xf6\xe2\xcd\xcb\xbe\xe6\xb6\x08\xfb\x5a\x6d\x5a\x97\x8b\x4f\x64\xb6\x0e\x7c\xab\x43\xee\x3c\x8f\x91\x46\xc6\x33\xea\x65\x44\x5b\x7b\x71\xfe\x92\xf9\xd6\x63\x8a\xeb\x1b\x92\xc1\xe2\x0b\x3a\x05\xcf\x1d\x51\x97\xc3\x4e\xc8\x87\x31\xbf\xeb\xe1\xba\x34\xb6\xe6\xfa\x18\x50\x3d\x20\x49\xfb\xce\xb7\x8b\x8a\x39\xd7\x86\xb6\xc9\x49\x99\x2e\x88\x30\x98\x16\x4f\xd1\x95\x1d\x6d\xd8\x15\x06\x68\x39\xca\x48\xea\x92\xed\xa8\x3f\xd9\x9b\x16\xa7\x5a\xdf\xf0\xd0\x62\x2d\x24\x95\xe7\xc4\x11\x23\x1c\xe0\x9c\x3d\x68\x2f\x76\x76\x57\x44\xfe\x89\x63\xfe\xf7\x42\x0a\x14\x20\xb5\xb5\x28\x86\xe7\x26\x16\x94\xcd\x01\x8c\x33\xb9\xf8\x1f\x51\x76\x82\xf7\xed\x1c\xbc\xf9\xe6\x92\x2d\x73\xc3\xf6\xaa\x63\xe8\x27\x75\x03\x26\x0a\x84\x19\x09\x63\x73\xc7\x3a\xb6\xe5\x3b\x48\x46\x16\xef\x55\x39\x5b\x73\xa5\x5b\x07\xf9\xd6\x2e\xed\x32\x0d\x0e\x7b\xd1\xed\x99\xbb\xcc\xfe\x25\x42\x5e\x31\xb5\x66\xde\x56\x2c\xf0\xaf\x44\x0d\xbb\x86\x4a\x11\x50\xd2\xdd\x08\xf3\xad\xd8\xe6\x6d\x23\x46\x17\xa7\x63\x74\x1d\x99\x21\xb0\x95\xe6\x0f\x04\xff\xc6\x1a\x59\x7a\xa0\x02\xc9\x0e\xe5\x47\x3a\x0f\xdf\x40\xb4\x64\x29\x9c\x3a\x28\x06\x8a\x36\xba\xcb\x3b\x36\xce\x6e\x1c\xaf\x71\xc5\xd6\x48\x70\x3e\x90\xe6\x18\x3e\xb9\x32\x48\x5a\x63\x37\x27\x71\xa9\xa2\x51\x33\xb1\xed\x41\x5f\x04\x71\x78\xf7\x04\x1c\xae\xa2\xec\xc7\x83\x7d\xd7\x01\x1c\xf7\xc7\x8a\x34\x50\x16\xea\xb6\x23\x43\x94\xc4\x8f\x02\x88\x41\xf7\xa7\x71\xd0\xfd\xa9\x13\x3d\xf6\x27\x73\xcf\xe3\xc4\x82\x23\xf2\xfb\x42\x5a\xb2\x1f\x41\x74\xca\x57\x5d\xe8\xa4\x47\xad\x68\xeb\x88\x68\x00\x4f\xc0\x22\xaa\x3b\x38\xd4\xb0\x08\xbe\xe3\xaa\x7d\x5c\xc2\x74\x09\xbd\x0b\xe3\x13\xee\x70\xe4\x12\x90\x86\x10\x44\x8b\xb4\xb3\x4f\x83\x0d\x0f\x62\x3b\xd5\xfc\x4f\x41\xd3\x81\x57\x5a\x81\x11\x7b\xc4\xf4\xef\x63\x4c\xff\xde\x33\x22\xe5\x5d\x4b\x08\x3e\xa2\x4d\x56\x1a\x41\xd7\x2d\x1d\xb4\x96\x1c\xae\x55\xb4\xc3\x0a\x8b\xc0\xf0\x9c\x1a\xfb\x4e\x51\x17\x9b\x0c\xa3\xef\x75\xdf\xb5\x5e\x19\xf4\x6e\xd9\xc9\xe6\x49\x89\xf3\x39\x25\xfe\x00\x23\xad\x4b\x0a\x74\xad\xc4\x63\x06\x92\x1b\xd7\x94\x6e\xe9\x8d\xfe\xb6\xc1\x5b\x81\x6e\x24\xe3\x0d\xcc\x8e\xd6\x8e\x4f\x76\x92\x35\xdc\x05\x03\x6e\xf6\xd8\xba\x0b\x06\x2e\xa5\x4a\x83\x06\x25\xf3\x88\x8b\x0e\x72\xe8\x7e\xd0\xc5\xfe\x81\x5d\xfe\x71\xb9\xfc\xed\xf2\x97\xe5\x6f\xcb\xdb\xff\x2a\x86\x54\xbe\x34\x71\xb9\x97\x67\x97\xa3\x19\x94\xcb\xfe\xb2\x7b\xc1\x1b\x2e\x70\x49\x8c\x6c\xe6\x78\xb7\xbb\xe6\x8a\x82\x0e\xd5\xe6\x31\xf3\xa6\xd4\xd7\x65\x07\xd1\x3a\xea\x0d\x34\xfd\xc4\x8f\xc8\x3b\xa4\x9b\x60\x8c\xf9\x87\xf9\xfa\xc5\xb4\x6f\x94\x05\x11\x55\x83\xf1\x04\xfa\x45\x25\xd4\xf1\x1e\x48\x8e\x6f\xbb\xb2\x07\xf6\xcd\xa7\x1e\xe0\xee\xf5\x60\xd9\x16\x74\x33\xe9\x67\x97\xa3\x41\x97\x02\xb5\x5f\xb8\xf8\xda\x36\x69\xb6\x21\x96\x44\xcf\x13\x63\xfa\xb8\x51\x25\xea\x02\x83\xd8\x52\x1c\x41\x41\xff\x3c\x03\xb3\x53\xce\xd2\xb0\xc3\xb0\x2c\x2d\xc3\x0b\x95\x98\xb9\x0e\x1f\x15\x0e\x5d\x65\x37\x3c\x87\xb9\x18\x74\x39\x9a\x9a\x29\xb0\xc9\x80\xb0\x5b\xf0\xe1\xb8\x41\x51\xe8\x2c\x8a\x4a\xcd\x07\x6d\xd7\xd4\x46\x2e\x3a\x75\xdd\xa3\x33\x62\xd1\xac\xd6\xe7\x8f\xcb\x5f\xaf\x3e\xdf\x2e\x2f\x67\xfd\xe1\x60\x26\xab\xf0\x87\x62\x22\x6d\x30\xfe\xb0\x1c\x04\xd0\xd3\x2f\xbe\xba\x13\x8b\xf9\x23\x2f\x3d\xaa\xf8\x8e\x15\x2a\xf1\x46\x36\x92\x41\x31\x1c\x98\x3b\x3b\x18\xc6\x3a\x41\x93\xd3\x3a\x1f\x8d\xa5\xf9\xaa\xf5\x5f\x22\xf6\xd9\x0f\x6c\x75\xfd\x65\x71\x75\x73\xb3\xfc\xfc\x0f\xb6\xfc\x7c\x7b\xf5\x8f\xeb\xb9\xc3\x5a\x2d\x47\xa7\xb5\x5a\xf6\x4a\x4e\x4d\x16\x9e\x76\x75\x37\x35\xac\x42\x6d\x55\x7f\x40\xa4\x1e\x9a\x5d\xe9\x67\x0c\xa8\xe2\x98\x44\xa0\x0f\x88\xe5\x9d\x16\xd1\x3e\x95\xfa\x47\xeb\xe8\xa0\xd9\x31\x65\xa6\x03\x58\x8b\xa5\x7e\x21\x0d\x5d\xe6\x19\x80\xd4\x24\xab\xc6\x37\x2e\x29\xc6\xa6\x37\x7d\xf9\xaa\x27\x34\xcf\x1f\x85\xe6\xa5\x10\xad\xe3\xe2\x58\x80\x2b\xd8\x4e\x77\x43\x32\xe2\x32\x44\xeb\x92\x17\x2b\xd3\xb4\x18\xe0\x7a\x1c\xba\x66\x00\x3d\x86\x69\x41\x43\x00\xca\x9f\xb1\x13\x10\x45\xa8\x22\x47\xcc\xd5\x6a\xd6\xb0\xea\xd4\xab\x46\x73\x8b\x0f\xb5\x00\x10\x8d\xb7\x8f\x42\xe3\x4b\xae\x27\x4f\x6c\x65\x9d\x82\x47\x91\x2c\xff\x02\xfd\x2e\x1e\xa7\x5f\x1b\xf0\x70\xbe\x4f\x39\xca\xd3\x5f\x81\xf1\x38\x05\xac\x21\x76\x76\xb4\x42\xa5\x2d\xed\xd0\x1d\xb2\xde\x57\xe9\x7c\xd3\xcf\x98\xc9\xe3\xed\x31\x56\x8a\x42\x58\x17\xb9\x50\x1c\x72\x19\x98\xfe\x49\xfa\xbf\x7b\x94\xfe\x31\xb3\x7f\xbf\xfe\x3e\x58\x6a\x24\x24\x4b\x0f\x50\xfb\xee\x52\x31\x85\x01\x6a\x7d\x47\xb8\x9f\x8a\x46\xb4\x9f\x47\x82\x42\xc1\x77\xf9\xc7\xe5\x62\x26\xde\x8e\xc3\x6d\x89\x58\x9c\x2e\xfc\x6c\x4f\xca\x84\x1d\xb5\x35\x36\x60\xdc\x4a\x97\x36\xcc\xb7\x24\x8c\x67\xfc\xce\xb6\x31\x87\xe7\xb9\xc5\xc6\x21\xfd\x17\xf0\xa0\x16\xc3\xd8\x4a\x2f\xbf\xa6\xbd\xd3\x5e\xca\xb3\xb6\x91\x3c\xdf\xe7\x16\xd1\x5c\x1e\xca\x31\x31\xb2\x80\xa5\x47\x51\xb9\x0d\xa4\x38\x10\x76\x10\xca\xd2\x4b\xa9\x9b\x96\x83\x31\xdd\x5c\x0c\x13\xe8\x83\x37\x3d\x13\x3a\x9e\xad\x46\x43\x9b\xab\xf3\x83\xd3\x59\x60\x85\x9e\xc6\x23\x16\x5b\xab\xa6\x87\xb0\xa7\x46\xe8\x04\x3d\xed\x0b\xbf\x1d\xa6\x36\x61\xb5\x86\x34\x98\xd1\x7a\xa8\x98\xcb\x83\xbd\x15\xf5\xfb\xb4\xf5\xdd\x6d\xb4\x4c\x2c\x63\x4e\xfb\x8c\xea\xc8\x22\x52\xdb\x4e\xa0\x0e\x7f\xb6\x60\x22\xba\x6b\xc6\xcd\xbe\xc2\xa5\x80\x8b\x6d\x92\xf3\x35\xbb\xfa\xd6\xa0\x03\x86\xf8\xb8\x09\x79\xd0\xf6\x79\x05\x55\x3e\x91\x85\xfc\x01\xc1\x50\x64\xeb\xd2\x05\xd6\xc1\x45\x55\x94\x3c\xdf\xc7\xbc\x66\x37\x2d\xca\x9e\x44\x2d\x57\xa5\x5b\xa2\x2c\xbb\x32\x01\xe8\xea\xbc\x69\x1d\x92\xb0\xdc\x23\x7b\x22\xf5\x1a\x38\x47\xf7\xec\xf4\x8c\x1c\x86\xb6\x7f\x09\x9e\xb9\x7d\x02\xf2\x6c\x35\x9a\x88\x5c\xf5\xe3\x66\xab\xfc\x76\xc9\x5e\x17\x9d\x28\x07\xd7\xff\x33\x9b\x25\xe1\x41\xc6\x5b\x5a\x1f\xba\x89\xf0\xc7\xf8\xdd\x68\xfa\xb1\x10\xb3\xb3\xc2\xe4\x78\x63\xb9\x3e\xd2\x8c\x6e\x49\x17\xd1\x20\x10\x6f\x18\xd9\xef\xa0\xdb\x3f\xf0\x4c\xfc\xbb\x81\xf4\x91\x89\x2f\xec\x88\x3a\x7a\xf7\xa0\x35\xfe\x3b\xf6\x1c\xaa\x7a\xbb\x5e\x6b\x64\xa1\x18\x91\x0f\xce\x17\x45\x02\x1f\x7f\x9f\x0e\x55\x94\xac\x12\x65\xee\xb6\xa1\x42\x3f\xcd\x5c\x0c\xcd\xe4\x79\x8c\xf6\x6c\x35\x1a\xd0\x5b\x5d\x94\xd9\xbb\x01\x97\x67\x88\xa7\x4c\xe5\x48\xd4\xd3\xaa\x56\xe9\x86\xee\x48\x80\xce\x6a\x25\x47\xf4\x65\x47\x32\x85\xf7\xd3\xf5\x38\x9f\xd3\x63\xca\xc8\x4f\xf9\x60\x22\x7e\xfd\xe0\xa7\x25\xff\x1e\x4b\xbf\x18\x51\xe2\x07\x64\x3d\xa1\x9c\xa4\xf6\x2d\xe8\x61\x5d\x39\x87\xf8\xa3\x6d\x64\x34\xd8\xb7\x7a\x37\xbc\xc3\xbd\x89\x81\xfa\xe1\x81\x08\x1a\x0b\x1a\x1c\x72\x9e\xfd\xc1\x3f\x44\xea\x30\x0c\xfd\xa3\x2b\xfd\x3c\x3e\x41\x5f\x7a\x10\x01\x94\xd3\x5a\x52\x5f\x13\x76\x0a\xee\xe3\x35\x06\xdd\x72\x26\x1f\xac\x7a\xda\xd4\x0d\x13\x58\xc7\xa8\x75\xea\xac\x51\x82\x09\xdb\xa8\x08\x21\x5d\x58\x8d\xe0\x1b\x49\xf8\x84\x44\xb1\x5c\x17\xf3\x42\x12\x8c\x02\x59\x8d\xd3\xb4\xcb\xae\x0d\xb2\xff\xf2\xca\xa8\x74\x4f\xed\x80\xfb\xa4\x26\xc5\xaa\xf8\x5f\x14\xaa\x72\x36\x7c\x1a\xf9\x19\x4d\x3a\xce\x1e\x75\x0d\x46\x3e\xd0\xd7\x4d\xb3\xa6\x58\x52\xe1\xd3\x71\xd8\x87\x37\x0d\xfe\x3c\x7d\x6a\xdd\xb8\x50\xf9\xc7\x74\x58\x34\x9f\x75\x48\x65\x06\x31\x9c\xa0\x38\x08\x2f\xca\xc9\x3c\x16\x4b\x00\xc5\x0f\x9f\x02\xa4\xe1\x90\xa7\xa3\x15\x0f\x72\x10\xda\xf3\x7c\x6d\x4a\xc2\xcf\x77\xb4\x4f\x3a\xcc\xd1\xd0\xe4\xea\x7d\xd9\xba\xea\xd2\xd3\x2d\x21\xb4\xe2\x2e\xec\x31\xd4\x24\xaa\xf1\xc0\xd4\x8e\xa6\x20\x33\x1f\xd3\x0b\xd0\x8b\xf8\x38\x66\x93\xb9\x23\x93\xb1\x49\xed\xe8\x39\x16\xd7\xa5\xc2\x9e\x11\x3d\x11\x97\xf3\x19\x5c\x2e\x7b\x86\xd9\x43\x71\xf4\x1b\x01\x07\xdd\x08\x77\xce\x17\xbc\x2b\x36\xfb\x52\x73\x50\x68\x3a\xc0\x2a\x93\xb8\x43\xbf\x5f\xaf\xd3\xf3\x06\xef\xd1\xdc\x60\xa1\xec\xef\xe5\x84\xc7\xbf\x44\xdd\x38\x5a\x91\x3d\xaf\xb1\x0e\x7f\x1e\x0c\x9a\x94\x28\xbc\xe8\x6b\xbe\x3b\x87\x65\x09\xf8\x97\xcf\x0c\xcd\xc5\x9c\x1d\x14\x1f\x88\x3e\xda\x3f\x32\x8b\xeb\x3f\x15\xcc\x5f\x5a\xa6\xe1\xa9\xf2\x1a\xcb\xa6\xeb\xfc\x81\xc3\x6c\x6d\xbc\xd2\xef\xc7\x05\x9f\x49\xe7\xd7\x93\x17\x6f\x83\x4f\x07\x7e\x8c\xc7\xa9\xba\x8f\xfb\x70\x6f\x65\xa5\x12\x31\x5b\xfb\x57\x48\x93\x31\x1e\x53\x85\x43\xe7\x4c\xd3\x01\x53\xdf\x16\xac\xfb\x2f\xe5\x10\xee\x77\xa7\x58\xe2\x93\xf1\x7e\x3c\xce\x18\x98\x1e\xc6\xd8\x58\x9a\xf3\x09\xe5\x37\x86\xf1\xb7\x5d\x0a\x62\x5c\x84\x36\xb2\x08\xdf\xfa\x34\xe4\x3e\x6b\xd6\x33\x16\x9c\xaa\x57\x53\x74\x7e\xf2\xb0\x71\x72\x14\x24\x40\x85\x5d\xf6\xb3\x9a\x69\x96\x2b\xe7\xce\x83\x2e\x16\x14\xa3\xfe\x93\xf3\x0f\xe3\xfb\xf0\xc7\x39\xd1\xfb\x99\x53\x2d\x9b\x58\xa8\xf5\xf7\x9d\xf2\xa0\xd7\x55\x2e\x9b\x63\x03\x2b\xbf\x92\x8c\x9f\x72\xd0\xa4\xd0\x98\x54\x62\x6d\xbd\x69\x35\xef\x6f\x57\xc9\x36\x9e\xaa\xfd\x87\xb9\x10\x12\x87\xd7\xd2\x67\x8f\x8f\x2c\x9f\x3a\x65\x87\xd4\x21\x1a\xac\xc9\x83\x71\x69\x6d\xaa\xa0\xa6\x6c\x77\x0b\x5a\xe6\x8e\x41\xce\x97\x99\x70\x94\xeb\xfe\x38\x53\x92\x76\xb3\x81\xa3\xa7\x3b\x22\xfd\x14\xc4\x46\x83\x7c\xab\x0f\x25\x62\x74\x09\xf1\x04\xbc\x52\x5d\x3c\xbe\x10\xec\xd3\x51\xdb\xbc\x0a\xf6\x95\xa4\x9f\x8b\x74\x45\x69\x2c\xde\x3c\x3d\x5f\x5a\x19\x4d\xcd\xac\x7e\x7a\xb8\xbc\x6b\x03\xb0\x6b\xf0\x56\x3f\x38\xf2\x53\x0c\xf6\xe4\x5e\x77\xaa\x76\x04\xd0\xa4\x7c\x95\x07\xb2\xe8\x67\x87\x8b\xee\xba\x6e\x78\xf1\xdd\x6c\xea\x38\xba\x6e\xd7\x38\x26\x4b\x9d\x93\x38\x8c\x53\x37\x1a\x91\xf4\x7d\xd7\xa9\xc5\xdc\x3f\xd1\xa1\x2b\x9c\x28\x7d\x9d\x47\x09\x83\x3e\xc2\xee\x6e\xd0\x8b\xb4\xf2\x94\xc4\xb5\xe8\xa8\x75\x19\xd0\xfb\x0f\xb1\xf3\x70\x5a\xf1\x6e\x51\x68\x0e\x3e\xa0\xc3\xa8\x5a\x73\x49\x97\x26\x98\xbb\xcc\x44\x7f\xfa\xff\x03\x00\x00\xff\xff\xf3\x14\xd5\x73\x9c\x45\x00\x00")
+var _complySoc2StandardsTsc2022Yml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x5b\xdb\x92\xdb\x36\x93\xbe\x9f\xa7\xe8\xaa\x5c\xc4\xae\xa2\x67\xff\x8c\x4f\x49\xf6\x6a\x22\x3b\xff\xaa\x2a\xb6\x55\xe3\x49\xb6\xf6\x12\x03\xb4\x24\xac\x29\x80\x01\x40\xd9\xca\xd5\xbe\xc3\xbe\xe1\x3e\xc9\x56\x37\x0e\x04\x39\xa2\xe6\x60\xe7\xbf\x48\x95\xa3\x21\x81\xee\xaf\xcf\x07\x1a\xb1\xc3\x9f\xe1\xfa\xe3\xe2\xd9\xc5\x3f\x2e\x2e\xce\xbe\x83\x8b\x7f\xfc\xf0\x1a\xae\x5d\xef\x03\x7c\x44\xb7\xd7\x12\x3d\x2c\x9c\x0e\xe8\xb4\x80\xb5\x75\xf0\x11\x65\xef\x74\x38\x34\x70\xb9\x17\xba\x15\x37\xba\xe5\xff\x5b\x39\x2b\xd1\x7b\x6d\x36\xb0\x34\x01\x37\xf1\x99\xb3\xef\x60\x61\xcd\x5a\x2b\x34\x41\x8b\xf8\xa4\x30\x0a\x56\x4e\xef\x85\x3c\xc0\x93\xff\xd4\x61\x0b\x57\xb8\xd7\x1e\x15\xac\xac\x36\xc1\x83\x5d\xc3\xaf\x56\xf6\x1e\xfe\xef\x7f\xfe\x17\x88\xae\xa7\x67\xdf\xc1\x7b\x1b\x88\xd2\x2d\x82\xb4\x0e\x41\x66\x9a\x1c\xee\x84\x36\xd0\x1b\xb9\x15\x66\x83\x0a\xd6\xce\xee\x98\x8d\x06\x6e\xfa\x00\x5d\x39\x73\xcd\x67\x7e\x46\x87\xe0\xd2\x85\xda\xf0\xf9\x67\x44\xe6\x87\x77\xef\x3e\xbc\x87\xc5\xd5\xf2\xfa\xed\xd5\xf2\x12\x9e\x64\x46\x9f\xc2\x33\xb8\xc2\x3f\x7b\xed\xe8\x70\xeb\x40\xb4\x2d\x7c\xfc\xb0\x80\x0b\x10\xbd\xd2\xc1\x9f\x2d\x16\x3f\x9c\xff\xf0\xf3\x19\xc0\x5a\xec\x74\x7b\xf8\x19\x16\x8b\x1f\xce\x00\x22\xb6\x05\x0c\xe6\xfb\x6d\xd8\x6a\xe9\xcf\x00\x14\x7a\xe9\x74\x17\xb4\x35\x91\x2b\x02\x28\x1c\x40\xe1\xce\x1a\x1f\x9c\x08\xe8\x41\x80\xb4\xbb\x9d\x0e\x3b\x34\x01\x82\x05\x3d\x3a\x0b\xe9\x2c\xd1\xc2\x5e\xb4\x3d\xd2\x99\xc6\x06\xf4\x3f\x33\x47\x91\x43\x6d\x0d\x08\xa5\x3c\xc8\x56\xf0\x6b\xd6\xc0\xc6\xee\xd1\x19\x61\x24\xf2\x21\x0e\x3b\xeb\x02\x49\xcd\x07\xd7\xcb\xd0\x3b\x8c\x0c\x5d\xcc\x31\xf4\x8b\x15\x4e\xc1\xd2\x28\xec\xd0\x28\x34\x12\x8f\xb1\x73\xc3\x4f\xd9\x35\x28\xed\x50\x06\xeb\xfc\x98\x35\x5d\xbd\x1f\x45\xb6\x13\x46\x6c\x90\x59\x25\xc2\xf0\x0b\x3a\xa9\x3d\x7a\x20\x82\xbd\xde\x6c\x03\x29\x46\xd8\x22\x28\xdc\x63\x6b\xbb\xf2\x68\x87\x6e\x6d\xdd\x8e\x79\xb2\x6b\x46\xc9\x19\xd1\x82\xb4\x26\x38\xdb\x32\x3b\xcf\xe7\xd8\xf9\xe0\x36\xc2\xe8\xbf\x04\x91\x2e\x5a\xf8\x98\x51\x98\x32\xf5\x6e\x20\x0f\x7d\x10\x37\xad\xf6\x5b\xf4\x0d\x7c\x26\x05\x4e\xdc\x66\x42\x9b\x0a\xcc\xa6\x82\xb8\xd5\x86\x5e\x21\xf6\x44\xd7\x39\xdb\x39\x2d\x02\x82\xe8\xc3\xd6\x3a\x1d\x34\x89\x9c\x65\xe2\x3b\x6b\xbc\x66\xd3\xa2\x1f\xb5\x61\xbe\xbb\xde\xf9\x5e\x33\x0c\xf6\xe6\xbf\x51\x06\xbd\x9f\x97\x3b\x9a\x2d\x01\xa2\x60\xd3\x6b\x45\xff\x02\x6b\xa6\xa4\xf0\x6d\x4a\x7b\xa9\xbb\x56\x1b\xe1\x0e\x20\x24\x71\x1b\x35\xe0\xc5\x1c\x64\xff\xa1\x9d\x36\x9b\x06\xae\x9d\xd0\x86\xf8\xa2\x63\xae\x30\x90\x0a\x5b\xf3\x58\xe5\x16\x21\x38\x21\x43\x93\xc5\xdb\x24\x2c\x02\x99\xb7\xb4\xbb\x8e\x2f\x00\x6d\x94\xde\x6b\xd5\x8b\x96\x71\x11\xad\xde\x18\x3e\x82\x05\x51\xe1\x42\x2c\xbc\x9c\x63\x61\x59\x4e\x81\x4b\x29\x6d\x6f\x42\x72\x64\x27\xa8\xdf\xda\x56\xf9\xd1\xfd\x22\xbf\xda\x22\x7b\x86\xb0\x45\xed\x6e\xe9\xdf\xb7\x91\xa7\x50\xca\xa1\x27\x7b\xe8\x92\xef\x94\xd6\x48\x74\xc6\xf3\xd5\x47\xa5\x78\xb6\x58\x5c\x4c\x3d\xd3\x45\xc1\xe0\x77\x8f\x74\xf5\xd2\xb0\xf1\x10\xbb\xf0\xf1\xe0\x03\xee\x4e\xf9\x27\x7b\x43\xf2\xf0\x60\x1d\x6c\xd0\x60\x12\xa5\x51\xd0\x13\x69\x0e\x5b\xdc\x0b\x13\x1a\xf8\xb3\x67\x6f\x0f\xba\x3a\x3d\x58\xf0\x7d\x47\xc6\xc0\xec\xaf\x7b\xc3\x74\x92\x06\x1d\x33\xdb\x87\x28\xb6\xf0\x1e\x03\x68\xb3\x47\x13\x2c\x21\x60\xd4\xe8\x6a\xd9\x0a\xef\xf5\x5a\x4b\xb6\x73\xc6\xe5\xe2\x0e\x5c\x16\x76\xb7\xeb\x8d\x96\x23\x64\x1a\x0e\x6e\xe4\x5d\x4e\x60\x94\x39\x69\x0f\xec\xbf\xe3\x21\xe8\x6b\x82\x1a\xd0\x46\xb6\xbd\x62\xde\x8b\xec\x8f\x5b\x3f\x89\x77\x0a\x4e\x03\x06\x29\xdc\x92\xcd\xfe\x8d\xb0\x2a\x11\x04\xac\x5b\xfb\x39\x01\x2a\x39\x90\x57\x5c\x65\x30\x9f\x3f\x0e\xcc\xb7\x5f\xee\x04\x73\x84\x20\xdb\x38\xa6\x97\xa0\x13\x8e\x7d\xa6\xc3\x8d\x70\x0c\xe5\x4e\x84\x80\xce\x83\x58\xaf\x09\x52\xb3\xf9\xe6\x88\x94\xcb\x47\x18\x40\xe7\x6c\xb0\xd2\xb6\xfe\xec\x6c\xb1\x78\x3e\xb5\xb9\xe7\x05\x8e\x0f\xb5\x9d\xcf\xb1\xec\x3b\x94\x7a\x4d\x9c\x0d\x6e\x21\xc6\x19\xdf\xaf\xd7\x5a\x6a\x96\x41\x0a\xe9\xc1\x02\x1a\xf6\x40\xc4\x2a\xcb\xa7\xe8\x39\x4b\x8d\x4c\xc3\x7b\x76\x91\x76\x0d\x4e\xfb\x4f\x6c\xa7\x22\xc2\x63\xab\x3b\x98\xf4\x8b\x39\xd2\xaf\xb4\xff\x44\xcf\xdf\x8b\x85\x4c\x07\x49\x87\x6f\x0c\x96\x45\x21\xe4\x56\xe3\x1e\x33\x35\x3a\x8c\x78\x14\xd2\x59\xef\x21\x0c\xe7\x30\x03\x46\xb4\x87\xbf\xca\x49\x82\x42\xc7\x8d\xf0\x3a\x39\x3e\x0c\xe8\x76\x31\x0e\x6d\xed\x67\x7e\x39\x32\xe9\xb7\xb6\x6f\x15\xdc\x60\x4a\x2d\xd4\x9c\xa0\x3b\x67\xf7\x5a\xa1\x87\x1d\x65\x96\x0a\x83\xd0\x2d\xa6\x10\x2d\xe4\x96\x98\x46\x4a\xb2\x12\x64\x5b\x87\x22\x44\xa7\xb7\xef\x5b\xf2\x81\xd9\x54\x19\xc0\xe7\x73\x00\xfe\xea\x44\xaf\x1e\x06\xa3\xa4\x34\x40\x91\x46\x13\x5b\x9d\xa5\x08\xa8\x45\xcb\x8c\xaf\xf9\x38\x0a\x81\x2c\x60\x22\xed\x14\xd4\x13\x31\xbf\x98\xa3\x72\xb9\xeb\x84\x64\xe1\x2c\x38\xa1\xbe\xa7\x94\x07\x4d\x43\x0f\x31\x15\x27\xa2\x45\x00\xc9\x52\xf0\x7a\x63\x58\x2f\x4d\x68\x0f\xa0\xe3\x25\xc4\x94\xe7\x90\xf3\x20\xbb\xdc\x75\x5b\xe1\xf5\x5f\xe8\xa1\xd5\x9f\xb0\xd5\x5b\x6b\x49\x4b\x54\x3e\x76\xd0\x78\xb2\xc6\x17\x53\x6b\x7c\x51\x78\x7d\x67\x8d\x0e\x96\x92\x99\x13\x4c\x7a\x6c\x51\x06\x5f\xf2\x12\xdf\xd4\x49\xa7\x07\x6b\x36\x36\x25\x41\xff\x66\x1d\x78\xec\x04\x45\xc5\xa2\x32\xd6\xb0\x48\x84\x97\xe8\x28\x76\xc2\xe7\x2d\x86\x2d\x3a\x16\x12\xe5\x35\xd6\x60\xaa\x50\xa6\x08\x80\x70\x08\x9d\x43\x9f\x33\xdd\xca\x93\x31\x67\x17\x73\x9c\x5d\xe1\x0e\x95\x16\x77\xe4\x63\x89\xc4\x24\xbe\x91\xa7\xbd\x45\x8a\xc2\xe8\x79\x64\x4a\x60\x04\x04\xbd\xc3\xf6\x40\xc6\x65\x88\x1b\x32\x70\xeb\xb1\xf2\xca\x29\x8e\xe5\xe4\x48\x7c\x22\x98\xa4\x75\x54\x10\xe8\x3d\xa6\x34\xa5\x0e\x85\x1e\x8d\xb6\x6e\x5a\x0a\x84\xa3\xf5\x44\x03\xc2\xd7\x69\x34\xc9\xfa\xe5\x54\xd6\x2f\x0b\x22\xc5\xde\xa2\x05\xbe\xd3\x41\x6f\xee\xc2\x27\x89\x9e\xd1\xc9\xd2\x1f\x64\x43\xa7\xc5\x08\x9d\xf4\xdc\x04\xa7\x6f\xfa\x80\xd9\x02\x77\xe5\x0e\xb0\xeb\x7b\x1a\x27\xbd\x2b\xa4\xc4\x8e\x4a\x0c\x84\x96\x6e\xa5\x74\xfc\xe5\xf9\xc5\x1c\x67\xd7\x28\xb7\xc6\xb6\x76\x73\xa0\x5a\x9b\x68\x3b\x65\xb3\xa2\xf5\xf6\x38\x63\x31\x9f\xab\x94\x6f\x60\x90\x4a\x30\x08\xc3\x3d\x93\x94\xe3\x94\xab\x79\x79\xfe\x7c\x8e\xf0\xb7\xa5\x8e\xa2\xd2\xbf\xd5\xa4\x5a\x27\x28\x57\xd8\xb5\xf6\x30\x23\x00\x67\xfb\xcd\x16\xba\x74\x4a\xf4\x3c\xa5\x4e\x83\xcf\xf4\xbf\xda\x03\x7e\xe9\x50\x06\x4c\xde\x82\x3d\xbf\x44\x45\x35\x6f\x7c\xa3\xeb\xc3\x70\x86\x36\x2c\x0b\xa2\x83\x74\xeb\xd5\x54\xb7\x5e\x15\x46\x7e\xb3\x1b\xae\xc4\x2f\x25\xe5\x64\x27\x58\xd0\xbb\xae\x65\x98\x3c\xb4\xe9\x1d\xc1\xef\x80\x4f\xdd\x06\xf0\x76\x1d\x3e\x0b\x87\x64\x16\x6b\x27\x4a\x21\x99\xca\x46\x27\xb7\x3a\x20\xff\x92\xc4\x42\xa9\x47\x64\xaa\x4e\x78\xc9\x07\x06\x56\xb7\xf4\x77\x12\xd4\x2e\x56\xda\xe5\x2e\xa4\x74\x99\x1f\xda\x21\x86\x2a\xee\x7e\x5f\x87\xe5\x59\x3f\xfc\xa5\x13\x46\xa1\xaa\x3c\x1d\xe9\x72\x62\x28\x49\xa9\x04\xf5\x31\x37\x8c\xe7\xc5\x1c\x9e\xbf\x7b\x74\x33\x60\xae\x1c\x39\x08\xea\x85\x78\xdf\xb3\xcb\x88\xf1\x43\x3a\x4c\x3d\xa6\xe8\xcc\x36\x4e\x98\x50\xfd\x3d\x52\xd5\xd4\xb9\x85\xc3\x8d\xf6\x31\x5d\xa4\xe0\x15\x8b\xf0\xbf\xd0\x83\xc1\xcf\x43\x30\xa2\xc3\x4a\xd2\xd7\x7b\x7a\xfc\x33\xfb\xb9\xc4\xa7\xf6\x20\x14\xe5\x1f\x74\x14\x2a\xb8\x39\x54\x77\x9c\xc3\xaf\x44\x2d\x3f\xff\xd0\x77\x1b\x2a\xa9\xdc\x51\xfe\xb8\x83\xb5\xb3\x7b\x54\x14\x4c\x4c\x7c\x70\x38\xd3\x58\x68\xad\xd9\xa0\x1b\x98\x9a\xcd\x7d\xa2\xef\x45\x3f\x4e\xfb\xf5\x7a\x8d\x8e\xac\x99\x4f\x0e\x87\x8e\xb3\x86\x57\xe7\xcf\xe7\x24\x76\x65\x5b\x7c\xf6\x8b\xa0\x26\xde\x9d\x46\x30\x20\xdd\xc0\xce\x2a\xce\x74\x1b\x2a\x28\x23\x4f\x3e\x73\x12\x2c\xd7\x1f\x4d\x65\x12\x39\xfe\xa5\x18\x6c\xc3\xf6\x2e\x03\xb8\x61\x9a\xa8\xf5\x61\x5b\xba\x70\x5a\x5d\xf1\xc5\x55\x1e\xa2\x90\x32\x15\x3e\x3d\x65\x31\x0d\x6c\xf4\x9e\x34\x29\x27\x63\xa5\x98\xa5\xd7\xb8\x08\xef\x62\xf8\x6e\x51\xf8\x00\x9d\xd3\x7b\xdd\xe2\x26\xf6\xd7\x3c\x6e\x1c\x0e\x41\x40\xf5\xf1\xd2\xbb\x4c\x8e\xcc\xe3\xc5\x1c\xd8\xab\xed\xc1\xdf\xcf\xdf\x38\xf4\xc1\x69\x0a\x61\x5d\x7e\x67\x00\x77\x2d\x64\x02\x81\x29\x3d\x09\xe3\x13\xca\x38\xf1\x8b\x20\xff\xd5\xb0\x58\x40\x22\x99\x48\x75\x4a\x03\x37\x42\x7e\x7a\xd6\x77\xc0\xb9\x07\xf8\x60\x9d\xd8\x60\x2d\x2a\x8f\xc6\x6b\xe2\x10\x5a\x1b\xab\x47\xff\x94\x48\x19\xf4\x94\xf2\x2a\x6f\x8d\xc1\xf6\x5e\x18\xbd\x9c\xc3\xe8\x0d\x91\xf8\x46\xfb\xce\xfa\x93\xe5\x25\xf5\x4d\x2c\x79\x8a\x1e\x2b\x9f\x6c\xd4\x00\x58\xc2\x85\x1a\x2a\xc9\xe1\x16\x28\xa3\x8a\x59\xd3\x1e\x40\xac\x43\x4a\xe9\x52\x23\x89\xc8\x77\x28\x14\xe9\x97\x43\xc9\x6f\x32\x6e\x84\x46\xd6\xe8\xe8\x8f\x63\xde\x94\x90\xde\x0a\x0f\x37\x88\x06\x94\x66\xcf\xb0\xcd\xd1\xaa\xb6\x6b\x97\xfb\xd1\x8f\x74\xdd\xc7\xad\x1e\xa9\xdd\x9f\x89\x3d\x54\xc1\x91\xa1\x7e\x35\x07\x75\x2e\xe2\xe1\x3a\x56\x46\x5f\x17\xff\x76\x28\x3c\x07\xb7\x2a\x74\x89\x0d\xf5\x9c\x42\x29\xbd\x18\x35\x6f\x7b\x47\xd3\x09\xdb\x07\xaa\x91\xb8\x96\x4c\x46\x7c\x63\x7b\xa3\x84\xd3\x89\xf2\xd7\x27\x95\x64\xd1\xfb\x60\x55\x2c\x35\xaf\x9d\x30\x7e\xa7\xbd\x3f\x9d\x17\x0e\x66\x45\xb8\x87\xea\x25\xf2\x68\x31\xad\xcb\xed\xcb\x9d\xdd\x8b\x96\x4c\xbf\xb6\xaa\xb1\xca\x9f\x8a\x35\xc9\x32\x09\xa3\xdc\x3a\x4e\xa8\x78\xd0\x01\x54\x4f\xd5\xcb\x2c\x09\xd9\xa9\x8a\xfb\x59\xd3\x8f\x73\x40\xbd\x13\x2d\xab\xeb\x1b\x4c\xa6\x70\x3f\x19\x97\x1c\x80\x65\xc9\xd9\x06\xa4\x92\x9d\xa4\x4a\x51\x57\x06\xe8\x3b\xea\x0d\x52\xf3\x82\x9e\x56\xbd\xcc\xce\xb2\x37\x15\x48\x5c\x16\x50\x76\x67\x7b\x3f\xd8\xcf\x23\xf5\xff\x68\x6b\x67\x79\x0d\xc1\xda\x36\x62\x9e\x34\x29\x75\x78\xb9\xad\xf3\x7a\x9a\x00\xbe\x2e\xf8\xfc\x51\xf5\x01\x0e\x27\x50\x4a\xd4\x8e\xdb\x1e\xa3\xa4\x84\xdb\xa8\x2a\x1f\xc0\xa4\xec\x4a\x95\x3a\xca\x57\x6d\x2e\xbf\x0f\xf0\xe4\x87\xa7\x43\xcd\x6d\x29\x41\x5e\xeb\x4d\xef\x72\xf1\x49\x99\xad\x43\xdf\xb7\x01\xf4\x71\xa4\x29\xe3\x99\xf4\x32\xa2\xae\x3d\xb9\x78\x0a\xbe\xf7\x54\x8f\x0c\x0d\xc9\x60\x29\x45\x6a\x93\xf3\xdc\x73\xea\x72\xbb\x13\xf2\xfa\xfc\x62\x0e\xae\x4b\x63\x77\xa2\x3d\x05\xd4\x00\x48\xe2\xbe\xd8\x76\x55\x31\xe7\xda\xd0\x76\x39\x28\xf3\x80\xc8\xfa\x51\x5d\x4d\x91\x4b\xf0\x85\xa5\x30\x20\xcd\xa1\x3e\x3e\x85\xa0\x3d\x37\xc1\x07\xd5\x12\x5c\xeb\x1b\x11\x7a\xaa\x85\x94\xf6\x82\x92\xb3\x64\x7a\xe8\x9c\xbd\xd5\x5e\x2c\x7a\x57\x79\xfe\x23\x62\xfe\xf7\x8a\x0a\x22\x20\xb5\xb5\xd8\x87\xe7\x26\x16\xd6\xcd\x01\x0a\xdf\xb9\xf8\x9f\xa4\xec\xac\x8d\xcf\xe7\xe0\xcd\x93\x4b\x58\xe6\x86\xed\xdb\x92\xa1\xdf\xab\x1b\x30\xb9\xed\x04\x85\xb1\xb9\x63\x1d\x6c\xc5\x1e\x93\x92\x71\x22\x06\x02\xd6\x42\xb7\xbd\xc3\x3c\xb5\x4b\xb7\x1c\x07\x07\x9e\x94\x3b\x73\x97\xd9\x3f\x25\xc8\x1b\xd0\x6b\xf0\xb6\xa1\xf6\x01\x01\x97\x02\xf1\xd8\xa1\xa4\xd9\x08\xf8\x5e\x6e\xf3\xb5\x51\x05\x5f\xdc\x1f\xa3\xab\x98\x19\x22\xac\x5a\x71\x4a\x21\x63\x06\xa9\x6a\x0b\xd4\xa8\xe0\x36\xfd\x94\xce\xe3\x17\x94\x3d\xd7\x21\x82\x3b\x28\x06\xab\x36\x7a\xca\x45\xa9\xc5\x63\x37\x4e\xec\xe8\xc4\xde\x50\xbb\x2f\x30\xe7\xe4\x3e\x85\x36\x94\xb4\x72\x46\x15\x52\x2e\x55\x35\x6a\x8e\x5c\x7b\xab\x2f\x42\x38\xbc\x7c\x04\x0e\x6f\x23\xed\x27\x75\xa6\x00\x70\xab\x3f\x56\x85\x81\xba\x50\xb7\x25\x19\xe2\x20\x7e\x12\x40\x72\xba\x3f\x4e\x9d\xee\x8f\x85\xf4\xd8\x9f\xcc\x3d\x8f\x13\x44\x96\x28\xc2\x44\x52\x7e\x5f\x51\xcb\xfa\x23\x39\x9d\xf2\x4d\x71\x9d\xe4\x95\x95\x95\x3d\xc7\xb1\x06\x02\x7a\xf2\x0a\xdc\x11\xde\xe3\x6d\x0e\x2b\xe7\x3b\xad\xda\xa7\x25\x4c\x09\xe8\xc5\x8d\x1f\x31\x87\xb9\xb8\x25\x14\xd5\xdb\x71\x91\xc1\x9a\x21\x0c\x76\x22\xc8\xed\xb1\xe6\x7f\x72\x9a\x0e\xbd\x6e\x35\x1a\x79\x20\x4c\x7f\x9a\x62\xfa\x53\xc1\xf4\x8d\xf6\xae\x67\x9d\x7f\x40\x9b\xac\x08\xd1\x37\xb9\xb3\xd4\x8c\x5b\x4b\xd4\xfb\xaa\xdb\x61\x95\x46\x90\x7b\x4e\x8d\x7d\xa7\x79\x7d\x84\x15\x63\xe8\x75\xdf\xf4\x9e\x66\xd5\x1e\x54\xa1\x8d\x6c\xfb\xa7\xf3\x8b\x39\x26\xfe\x40\xa3\xac\x4b\x0c\x94\x56\xe2\x29\x05\xc9\x8d\x6b\x22\x3a\x36\x1f\x87\x69\x83\xb7\x52\x0b\x2a\x8b\x78\x02\xb3\xe7\xb3\xe3\x93\x85\x32\x6a\x79\x1a\x74\xfe\xce\x01\x83\x50\x4a\xa7\x45\x83\x3a\xf3\x88\x87\x8e\x22\x70\x26\x8a\x14\x90\xd6\x53\x2e\xff\xb8\x5c\xfe\x76\xf9\xcb\xf2\xb7\xe5\xf5\x7f\x55\x4b\x2a\x1f\x18\x0f\xd1\x3e\x3d\xbb\x9c\xec\xa0\x5c\x0e\xc3\xee\x85\xe8\x84\x24\xcb\x22\xcf\x66\x4e\x77\xbb\x69\x99\x86\xba\xd4\xbe\x29\x91\x37\x85\xbe\x12\x1d\x64\xef\xb8\x37\x90\xd2\x52\x12\x99\xcc\x37\xd0\x93\x7d\x1c\xf9\xdd\x8e\xd7\x4f\x8e\xdb\x46\x5d\x10\x71\x35\x18\x25\x30\x1c\xaa\x70\x47\xcf\xd0\x7f\x93\x69\x57\xb6\xc0\x12\xfd\x2b\x80\xcb\xeb\xc1\xc2\x16\xdb\xee\x58\xd8\x21\xd4\x2e\x66\x50\xfb\x45\xc8\x4f\x7d\x97\x76\x1b\xd8\x65\x1d\xbe\x8d\x8f\x19\xfc\x46\x93\x52\x17\x1c\xf9\x96\x4a\x04\x55\xfa\xe7\x01\xcd\x5e\x3b\xcb\xcb\x0e\xe3\xb2\xb4\x76\x2f\x04\x69\xa9\xc3\x27\x85\x43\xa9\xec\xc6\x72\x98\xf3\x41\x97\x93\xad\x99\x0a\x9b\x0c\x08\x5c\xa3\x0f\xa7\x15\x8a\x5d\x67\x55\x54\xb6\x62\xd4\x76\x4d\x6d\xe4\xaa\x53\x57\x1e\x9d\x21\x8b\x77\xb5\xde\xff\xba\x7c\xf3\xf6\xfd\xf5\xf2\x72\xd6\x1e\x6e\xed\x64\x0d\xd4\xd7\x1b\x69\xa3\xf5\x87\xe5\xc8\x81\xde\xcb\xe3\x25\x97\x91\x24\x06\xb2\x3e\x7a\x52\xf1\x9d\x2a\x54\xe2\x44\x16\x55\x49\xdf\xd3\x19\xb4\x91\x72\x6b\x19\xeb\x1e\x9c\xdc\xaf\xf3\xd1\x59\x72\x7a\x76\xfd\xb7\x90\x7d\xf6\x1d\xac\xae\x3e\x2c\xde\x7e\xfc\xb8\x7c\xff\x4f\x58\xbe\xbf\x7e\xfb\xcf\xab\x39\x61\xad\x96\x13\x69\xad\x96\x03\x93\xc7\x36\x0b\xef\x37\xba\x3b\xb6\xac\xc2\x6d\x55\x7f\x2b\x91\xba\x6b\x77\x65\xd8\x31\x20\x28\x8e\x23\x30\x38\xc4\x7a\xa6\xc5\x69\x1f\x7b\x7d\x86\x9a\x0d\x34\x1b\xa6\xca\xe9\x00\xd5\x62\xa9\x5f\xc8\x4b\x97\x79\x07\x20\x35\xc9\x9a\xe9\xc4\x25\xf9\xd8\xf4\x66\x2a\x59\xd3\xbe\xe6\xd9\x6a\x39\xd1\x98\x3b\xd1\xbc\x94\xb2\x77\x42\x9e\x72\x70\x55\xb6\x53\x26\x24\x93\x5c\x86\x2c\x3c\x5b\xb1\x36\x5d\x1f\x7c\x8d\x43\x69\x06\xf0\x63\x54\xa0\xb5\xb4\xd1\x45\x91\x9d\xce\x11\x89\x04\x66\x75\xa8\x56\x33\x87\x4d\x61\x2f\xfb\xb2\xec\x36\xee\x50\x51\x46\xe3\xf9\x83\xd0\xf8\x90\xeb\x49\xff\xed\xf0\x18\x74\xe3\xef\xe0\xef\xc5\xc3\xf8\xeb\x03\x09\xe7\xeb\x98\x23\xb2\xc4\x27\x04\x11\xb7\x80\x5b\xa4\x20\xa7\xb0\xd5\xc4\xb4\xe5\x1b\x8a\x90\xdb\x43\x93\xe4\x9b\xfe\x4d\x91\x3c\x4e\x8f\x69\x92\x2c\xa5\x75\xb1\x0b\xc3\x29\xd6\x58\xf5\xef\xc5\xff\xcb\x07\xf1\x1f\x23\xfb\xd7\xf3\x4f\x0d\x6e\x1c\x34\x9d\x36\xe7\x92\x48\xd3\x9d\xa9\xf5\xcd\x60\xf8\xc7\xa2\x11\xed\xe9\x81\xa0\xb0\xf3\x5d\xfe\x71\xb9\x98\xf1\xb7\x53\x77\x5b\x23\x16\xb7\x0b\xdf\xdb\x7b\x45\xc2\x92\xda\x1a\x1b\xc8\x6f\xa5\xa1\x0d\xf8\x9e\x35\xd4\x83\xb8\xb1\x7d\x8c\xe1\x79\x6f\xb1\xa3\x35\x4f\xd2\xf4\x87\x44\x97\xf4\xf2\x39\x4b\x29\xdd\xa5\x3d\xf4\x9d\x12\x79\x9e\x5b\x79\x73\x75\x9b\x8e\x23\x2b\x0b\x6b\xeb\xea\xb6\xd9\x88\x8a\x5b\xc4\x8e\x5c\x59\x7a\x49\x9b\x91\x33\xe6\xc9\xc5\x38\x80\x36\x8f\xe0\xf1\x6c\x35\x59\xda\x5c\x5d\xdc\x92\xce\xc2\x9a\xb2\x14\xb2\xd8\x5a\x2d\xf1\x84\x8c\x46\x61\x4e\xf2\xd3\xbe\xb2\xdb\x71\x68\x93\xb6\xa5\xa5\x07\xa6\xbd\xf7\x48\xcd\x87\xb4\xd8\xdb\x50\xca\x20\x5b\xeb\xcb\x34\x5a\xa5\x2c\x63\x8e\xfb\x8c\xea\x44\x23\x52\xdb\x8e\xe6\x69\xf8\x67\x4f\x1b\xe4\x14\x28\xd6\x20\xcc\xa1\xa1\xa3\x90\x96\xae\x22\x9d\xe7\xf0\xf6\x4b\x47\x0e\x88\xf6\x88\x22\xcb\x69\xd1\xf6\xdb\x12\xaa\x7d\xda\x6c\xcd\x1f\x10\x8c\x49\xb6\x2e\x0d\xb0\x6e\x0d\xaa\x22\xe5\x79\x1e\x73\x0e\x1f\xa9\x05\x95\x49\xad\x4f\xe5\x29\x51\xa6\x9d\x3a\xfe\x86\x4a\xf9\xae\x77\x9d\xf5\xa5\x47\x36\xc1\xee\x51\xc6\x51\x9e\x3d\xbe\x23\xa7\x77\xff\x22\x3c\x73\xfb\x04\xd5\xd9\x6a\xb2\x11\xb9\x1a\x16\x22\x57\xf9\xed\x3a\x7b\x5d\x14\x52\xa6\x5a\xbd\x9a\xb9\x2c\x11\x8f\x8a\xd9\xa2\xb1\x7e\xde\x08\x7f\x88\xdd\x4d\xb6\x1f\x2b\x32\x8b\x16\x26\xc3\x9b\xd2\x45\x63\xfe\x9a\xa4\xa8\x10\x84\x37\x4e\xf4\x77\xd4\xed\x1f\x59\x26\xfd\x6e\x30\x7d\x64\xe2\x2b\x3d\xe2\x8e\xde\x67\x6c\x69\xe2\x78\xcb\x72\xc8\x60\x86\x5e\x2b\x73\xc3\x1e\xf9\x96\x7c\x89\x24\xf4\xd1\x7e\x8e\xc9\x2c\x05\xab\x94\x32\x97\x6b\xb8\xd0\x4f\x3b\x17\x63\x35\xf9\x36\x4a\x7b\xb6\x9a\x2c\xe8\xad\x86\x2d\xb6\x95\xa3\xfa\x38\xef\x10\x1f\x53\x95\x13\x5e\xaf\xd5\x3b\x1d\xfc\x5d\x0e\x3a\xb3\x95\x0c\xd1\x0f\xa5\x9d\xca\xc3\x92\xfb\xf3\x71\x31\xc7\xc7\x11\xca\xef\xf5\xc1\x44\xfc\xfa\xc1\x1f\xa7\xfc\x6b\x34\xfd\xc5\xf9\xf3\x87\xd0\x7a\x8f\x72\x92\x1b\xd1\xd8\x8e\xeb\xca\x39\xc4\x1f\xac\x23\x93\xc5\xbe\xd5\xb0\x44\xc6\x33\xdc\x8f\xd1\x51\xdf\xbd\x10\xc1\x6b\x41\x23\x21\xe7\xdd\x1f\xfa\x81\x0a\x41\x35\x71\xfd\x93\x91\x7e\x5e\x9f\xe0\x2f\x3d\x38\x01\x54\xc7\xb9\xe4\xbe\x26\xee\x35\xf2\xee\x7c\x13\xa7\x9c\xc9\x06\x9b\xa1\x23\x58\xd6\x0c\x68\xbf\x82\x9c\x9e\xb3\x46\x4b\x90\xb6\xa3\x9c\x93\x2d\x4c\x84\xd1\xc1\xb7\x32\x9b\x87\xe3\x79\x0e\xcb\xb2\xa7\x45\x9e\x1a\x8d\x46\xd5\x4c\x8e\xa5\xbe\x72\x54\x37\x54\xc3\x97\x57\x86\x3a\x04\x84\x99\x43\xe1\x13\x9b\xec\xab\xe2\x9f\xd8\x55\xe5\x68\xf8\xb8\xe4\x67\xb2\xe9\x38\x2b\xea\x1d\x1a\x75\x47\x5f\x37\xed\x9a\x52\xb5\x4f\x4f\xc7\x65\x1f\xd1\xd1\x77\x6b\x33\x36\x55\xd6\x85\xea\x1f\x93\xb0\x78\xb7\x6b\x82\xd1\xb4\x89\xc0\x50\xd4\xef\xb2\x7b\xd1\x4e\xe5\xb5\x58\x06\x88\xdc\xbe\x0e\xc4\xb8\x75\x5f\x85\x56\x14\x64\x56\x2b\x16\x46\xe2\x39\x07\xe1\x6f\x27\xda\x47\x09\x73\xb2\x34\xb9\x1a\x56\x0a\x72\x06\x4b\x34\x5f\x33\x42\x2b\xe1\xc2\x01\xde\x94\x54\xe3\x84\x60\x53\x3e\x82\x33\x52\x9c\x82\x5e\xf9\xc7\x49\x34\x2e\x1d\x99\x8c\x4d\x6a\x47\xcf\x65\x71\x25\x14\x0e\x19\xd1\x23\x71\xb9\x98\xc1\xe5\x72\xc8\x30\x07\x28\x4e\xb9\x34\x49\x3b\x31\xa9\x3e\xcd\xf1\x42\x94\x62\x73\x28\x35\x47\x85\x26\x35\x59\xe3\xf7\x99\x55\x46\x3b\xf0\xf4\x6d\x9d\xf7\x64\x6f\xb0\x62\xf6\x77\x23\xfe\xc5\xec\x52\x2a\x2c\x8b\xe5\x51\x8f\x05\x15\xf4\x66\x06\x85\x27\x43\xcd\x77\xe3\xa8\x2c\x41\xff\xf4\x1b\x43\xf3\x62\x06\x9a\xcb\x61\x82\xfb\x70\xfb\xc8\x8d\xcf\x74\x4f\xf5\xa5\x65\x5a\x9e\xaa\xc7\x58\x36\x8d\xf3\x47\x06\xb3\xb5\x71\xa4\x9f\xe3\x9d\xfd\x56\x3c\x9f\x1f\x1d\xbc\x8d\x3e\x1d\xf8\x9e\xe8\xed\x5a\x9d\xc7\x63\x82\xee\xd6\x56\x69\x99\x3e\x68\x7a\x46\x69\x32\xed\xcb\x72\x85\x43\xbf\xc5\xed\x80\x63\xdf\x16\xac\x87\x2f\xe5\x48\x13\x5f\xce\xc0\x3d\xd2\xc4\x47\xe3\xfd\x70\x9c\xc9\x31\xdd\x8d\x31\x35\x3c\xd6\xf5\x72\x70\xc9\x4d\xf7\xc9\x89\x09\x19\xe8\x63\x56\x76\xd9\x3e\x2d\xb9\xf7\xe6\x61\xc6\x9d\xaa\x57\x53\x75\x7e\xf2\xb2\x71\x32\x94\x60\xeb\xcd\x82\x74\x08\xed\x6a\xa6\x5d\xae\x1c\x3b\x6f\x75\xb1\x86\x4f\xa6\x67\xf6\x1f\xea\xb6\xe2\xc3\x8d\xe8\xd5\x8c\x11\xd5\x4d\x2c\xe2\xfa\xeb\xa4\x5c\x92\xb6\x1a\x21\x3a\x36\xfb\x86\xd1\x57\x92\xcc\x4b\xdc\x14\x9a\x26\x95\x54\x5b\x6f\xfa\x56\x0c\xd3\x55\xb6\xc1\xc7\x72\xff\x7a\x86\xfb\xf4\x65\x73\xfa\xec\xf1\x81\xe5\x53\x61\x76\x44\x79\x52\x58\x03\x62\x74\x36\xd1\x7b\x54\x77\xb7\xd8\xaa\xdc\x31\x48\x00\x97\x84\xa3\x3e\xf7\xfb\xa3\xaf\xa7\xac\xf9\xc8\xd3\x29\xe3\x79\x5c\x86\x39\x59\xe4\x5b\x0d\xdb\x30\x79\x0e\xf2\x08\xbc\x52\x5d\x3c\x1d\x08\x0e\xe1\xa8\xef\x9e\x05\xfb\x8c\xda\x95\x4d\x1d\xae\x38\x6a\xc7\xc9\xd3\x71\x08\x1f\xc3\xe1\x64\x6b\x66\x35\x2c\xcd\x1c\xe3\x8a\xb4\xbf\xa3\xef\x96\xae\xd0\xdb\xf6\xce\x95\x9f\xa1\x37\x5e\x06\x59\x9c\x2b\x3a\x94\xc8\x9b\xf2\x4d\x5e\xc8\xe2\x7f\x3b\x3a\x34\xfd\x3c\x4a\x97\x73\xc7\xd1\x95\x5b\x09\x76\x6d\xa8\x78\xe0\x05\x44\x46\x89\x86\xe1\xc9\x48\xa8\xb2\xec\x29\xf6\x1f\xe9\xd0\x55\x46\x94\xbe\xce\xe3\x80\xc1\x1f\x61\x97\x09\x7a\x15\x56\x1e\x8e\xea\x39\x2c\x4a\x6a\x5d\x3b\xf4\x12\x5e\xca\x72\x5a\xf5\x6e\x55\x68\x8e\x3e\xa0\x23\xaf\xba\x13\x8a\x87\x26\x14\xbb\xcc\x91\x4f\xea\xfe\x7f\x00\xf3\x14\xd5\x73\x9c\x45\x00\x00")
 
 func complySoc2StandardsTsc2022YmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1221,12 +1242,12 @@ func complySoc2StandardsTsc2022Yml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/standards/TSC-2022.yml", size: 17820, mode: os.FileMode(420), modTime: time.Unix(1757470844, 0)}
+	info := bindataFileInfo{name: "comply-soc2/standards/TSC-2022.yml", size: 17820, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2TemplatesDefaultLatex = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x3b\x6b\x6f\xe4\x36\x92\xdf\xfb\x57\x10\x18\x35\x6e\x7c\xe8\xf6\xd9\x13\x24\x17\x04\xe8\xc5\x4c\x26\x9b\x67\xcf\x6e\xe0\x99\xdc\x05\x67\x39\x38\x4a\x2a\xb5\x18\x53\xa4\x8e\xa4\x6c\x77\x04\xdd\x6f\x5f\x14\x1f\x12\xf5\xf0\x24\xbb\xc8\x17\x37\x59\x2c\x16\x8b\x64\xb1\x9e\xf2\x96\xfc\xbd\x31\x4c\x0a\x4d\x4a\xa9\x48\x43\xf3\x7b\x7a\x02\x4d\xb8\xa4\x05\x14\x04\xb8\x86\xc7\x0a\x14\x6c\xd2\x1f\xa9\xd6\x1e\xf5\x83\xfc\xd1\xe1\x75\xad\x60\xb9\x2c\x20\x29\xa5\x7a\x59\x9d\x1b\x50\x0a\x4a\xe9\x90\x2e\x92\x5d\x32\x03\x25\x09\x88\xa2\x94\x2a\xe9\xbb\x30\xd2\x3f\x43\xb8\x3a\x37\x15\x08\xdd\x77\xad\xe2\xfd\x26\x61\xe5\xcb\x5c\x72\xa9\x38\x13\xf7\xfa\x22\x79\x66\x52\xf1\xc0\x1a\x2d\x68\x0d\x7a\xa7\x1f\x4e\xae\xf1\x74\x7d\x6d\x1b\x7d\xf7\x64\x29\xf4\x1b\x64\x82\x95\x89\x25\x5a\x30\x75\xe1\x5a\x9c\x1a\x78\xda\x17\x4c\xed\x95\xe1\xcf\xae\x70\xf3\xe1\x58\xc8\xbc\xad\x41\x98\xbe\xcb\x58\xc1\x22\x72\x11\xd9\xb7\xdf\xff\x50\x53\x26\x4a\x29\xcc\xb3\xa4\x74\x43\x73\xe8\xbb\x27\x78\xfb\xfd\x0f\x23\x95\xed\x26\x0d\x0b\xe4\x9c\x6a\x7d\x6b\xe9\x21\x21\xcd\x7e\x83\x8b\x64\x43\x48\x12\x7a\xc9\x6e\xb2\x28\xa7\xe2\xe4\x10\x32\x9a\x01\xdf\x63\x7f\x86\xd2\xd0\x06\xd4\x48\x68\xe8\xba\xd6\x14\x37\x03\x5a\x83\xb2\x88\xec\x24\xa4\x02\x21\x45\xa9\x68\x0d\x06\x9e\xcc\xce\xa2\x54\x54\x14\xb2\x35\x16\xc7\xb7\xa7\x34\xa8\x6e\x20\x37\x8a\x1a\x26\x2d\x52\xd4\x3f\x24\x51\x27\x66\x33\xfc\xa2\x48\xd9\x23\x70\xc2\xe3\x38\x8e\x00\x49\xa2\xa1\xf1\x13\x51\xa6\x36\x77\x5d\x32\x39\xba\xa4\x9f\xee\xc3\x76\x68\x7e\x7f\x52\xb2\x15\xc5\x9e\xd5\xf4\x84\xe7\x90\xb6\x1a\x46\xb0\x81\xba\x41\x51\xe8\xb6\x1b\x42\x52\x26\x72\xde\x16\x70\x52\xb4\xa9\x58\xae\x6f\x1f\x59\x61\xaa\x43\x6a\x4f\xcb\xb6\xef\xba\x64\x4e\x32\xe9\xb7\x9b\xf1\x3e\x91\xba\x7f\x54\x5d\x73\x2a\x1b\x7c\x5c\xfd\x26\xd5\x60\x1c\x5f\xc3\x7a\x39\xb5\xbb\xea\x6f\x45\x5b\x67\xa0\xa0\xb8\xfb\x08\x16\xe1\x78\xc3\x04\x49\x2b\x6a\xa4\xea\xbb\x2f\x48\x4c\xd5\x4a\xfa\x80\x8c\xf2\xdf\x77\xe5\xe9\x20\xa4\xaa\x29\x27\x78\x81\x97\xe5\xa9\xdf\xa4\x53\xea\x82\x3e\xb0\x13\xb5\x62\x7a\xae\x33\xc9\x35\x9e\xd8\x08\xbc\x48\x92\xb1\x93\x24\xa8\x1a\x12\xa8\x1b\x73\x9e\x5c\x99\x23\x39\xdc\xd9\xc8\x93\x03\x75\x49\xdc\x4b\xfa\xf1\xfa\xb6\xe4\x47\x05\x0f\x20\x0c\xd1\x9c\x15\x40\x32\x05\xf4\x5e\x13\x26\x88\xa9\x80\xd4\xac\x28\x38\x10\x59\x12\x4a\x70\xd3\xf6\x4a\x36\xe9\x23\x2b\xe4\x63\x03\x82\x72\xc3\x40\x93\x6b\x72\x7d\x75\x75\x75\xb5\x49\x15\x3d\x9d\xa0\xc8\xa4\x31\xb2\xb6\x17\xaf\x21\xc7\x05\xf7\x86\x19\x0e\x7a\xc2\xd8\x70\xb8\x0d\x55\x86\xe0\x0d\xf5\x1d\x5e\x7e\x0e\xc2\x80\x62\xe2\x84\x9d\x0c\x4e\x4c\x74\xd1\xe9\x66\xf2\xa9\xbf\xd5\xd0\x1c\xae\x3f\x6b\xcc\xce\xe1\xde\x39\x12\x76\x8d\x7e\x43\x08\x21\x56\xb8\xec\x24\x7c\xb4\xf1\x70\xca\x84\x06\x65\x10\x92\x36\x54\xe1\x1a\x20\x8a\xf9\x0a\x9b\x55\x59\xf1\x9b\xf9\x17\x78\x7d\xf5\xc7\x79\x0d\x8b\x4c\xd8\xf5\xc0\x7f\x9e\xe3\x36\xfb\x97\x99\xfe\xfc\x9f\xe0\x79\x5c\x66\xca\xf6\x00\xff\x1d\xce\xdf\x98\x2f\x91\x8f\x1f\xa9\x32\x96\x45\xab\xf0\x3a\x9c\x64\x2c\xdf\x11\xce\x7b\x47\xd0\xa2\xb1\x32\x63\x19\x67\xd2\x4a\xe5\xd9\xd2\xe7\x1a\x1c\x87\x9e\x84\x5f\xdf\x51\x41\x30\x8b\x69\x0d\xfc\xc5\xab\x8e\x5c\x87\xb5\xd7\x6c\x8d\xdb\x05\x55\x86\xe5\x3c\xe8\xb3\xa0\x71\x26\x63\x3d\xd9\x12\x01\x50\x68\x62\x24\xc9\x20\x98\xf8\x92\x29\x6d\x56\xf5\x15\xad\x75\x4d\x4d\xb5\xa3\xb5\x46\x85\xd0\x0f\xa6\xa8\xa4\x35\xe3\xe7\xe9\x5a\xb7\xf6\xf5\x8f\xa3\x83\x17\x90\x2c\x60\x5e\x73\x87\x97\x7f\xd7\x45\x28\x56\x23\xa0\x66\x99\x70\xc2\x6b\x59\x80\x12\x53\xdb\xcd\x99\x00\x6d\x14\x98\xbc\x9a\x6d\x5b\x83\x71\xd6\x75\x75\x5b\xac\x34\xf0\xd4\x6f\x52\x56\xfe\xf8\xd5\xd7\x1f\xe0\xe7\x8d\x13\xa3\x61\x1f\x7e\x93\x20\x72\xcf\x3c\x88\xdc\xab\xbb\x0f\xd7\x9e\xe0\x5d\xe7\x07\xfa\xd9\xec\xd6\x94\x9f\xdf\x75\x4c\x34\xed\xca\x68\x87\x9a\x37\x97\x75\x83\x57\xd1\x28\xf9\x80\x7a\x0e\x5a\x25\x09\x15\x05\x91\xa6\x02\x45\xbc\xea\xdd\x58\x09\x22\x5b\xc2\x4a\xc2\x5b\xf4\x4d\x88\x54\xe4\x09\x8d\xaf\xdd\x3c\x5e\x0c\xda\x4f\x6b\x16\x53\x56\xfe\x0c\x6e\x27\xd3\xf5\x02\x56\x3f\x15\xc9\x08\xc3\x3b\x70\x7b\xc4\x0c\x72\xe9\x2f\xe0\x63\x88\xe1\x5c\x09\x49\x0b\x28\x69\xcb\x8d\xbd\x42\xa0\xa6\x55\xa0\xbb\xf7\x39\xe5\x70\x78\x47\x4d\x5e\x1d\xe5\x23\xa8\x9c\x6a\x27\xf5\x2b\xc8\xb7\xa9\xaa\xdd\xdd\xdf\x75\x47\xb4\x2e\x08\x3c\x7c\x80\x9f\x77\x8e\xca\x75\xef\x37\x3c\xf8\x53\x84\xa0\x86\x09\x00\x27\x78\xa1\x37\x8a\xdd\x0c\xb2\x14\xba\x80\x90\x4c\xc5\x4a\x53\xa1\x27\x0b\x05\x80\x5b\x28\xf4\xc6\x85\x66\x90\xe5\x42\x01\x61\xb6\x50\x2d\x85\x9c\xee\xc8\x03\xfc\x8e\x7c\x2f\xda\xd1\x14\xb2\xb2\x23\x8f\x10\x2f\x34\x79\x95\xcc\x9a\x3e\x42\x52\x01\x8f\xe3\x9b\x8b\xde\x1f\x03\x7d\x89\xee\x42\xd2\xdf\x2e\x66\x5e\xae\x3c\xe9\x08\xfc\x91\x57\x8d\x58\x11\x5f\xd6\xdc\x07\x21\xf6\x27\xf0\x3b\x32\x6d\xef\xdb\x21\xbf\xfc\x8a\x9d\x98\xd1\xbb\x23\x35\x4c\xec\xbe\x51\x00\xf7\x17\x41\x06\x1c\x46\x2c\x03\x13\xc8\x9a\x0c\x38\x84\x64\xf6\x42\xa2\xf5\xfe\x24\xda\x93\x77\xf5\x27\x93\xff\xfd\x10\xe4\x23\x3a\xc2\x07\x20\x61\xdb\xd1\x3c\xc7\xda\xdb\xef\x7f\x18\xb9\x1a\x3b\x4b\x86\xa2\x99\xd1\x96\x23\x7d\x6d\xd5\xd8\xaf\xb4\x51\xa0\x61\xdc\xa9\xe3\xed\xd8\xd2\x15\xe6\x1c\x07\xcf\x4c\x4c\x56\xe1\x0b\xbe\x02\xd6\xde\xa1\xad\x71\xb6\x72\x56\x7f\x84\x1f\x1b\x87\x35\x90\x2f\x39\x9a\x8d\x3c\xcf\x53\x40\xec\x23\xb1\x63\xe2\xd7\x3f\xf9\xf4\x6d\x9b\x95\x2f\x7f\x03\x25\xf7\x36\x6e\xd9\x0b\x29\xf6\xbf\x4a\x26\x6c\x64\xb4\xdd\x92\xf7\x6d\xd3\x48\x65\x6c\x12\x60\x44\x23\x23\x1a\xc9\x2b\xaa\x68\x6e\x40\xe9\xcb\x4d\x5a\xd3\x7b\xa0\x86\x83\x31\xa0\x36\xa8\xd5\x53\x9c\x64\xe7\x08\x29\xdc\x0c\x1b\x42\x8d\x5e\x3d\x0f\xba\xdd\x1a\x3b\x5a\xfc\xda\x6a\x43\xee\x41\x09\x26\x4e\x3b\x92\xb5\x86\x68\xc3\x38\x27\xda\x73\xe2\x32\x00\xf8\xc8\x4f\x97\xb8\x1f\x03\x4f\x52\x35\x45\xa9\x0d\xba\x8c\x96\xb8\x83\x1a\x8c\x6a\x4c\xd5\xa5\x42\xda\x98\x21\x2d\x98\xce\x15\xe0\x39\x51\x75\xee\xf6\x7d\xd7\x77\x29\x2e\x74\x79\xf5\x09\xd4\xbd\x9b\x68\x2f\xf9\x01\xdd\x0a\xfb\xea\x87\xb9\x95\xbe\x67\x4d\xfa\xdb\x6b\xfb\x53\xb2\xbe\xb3\xf8\xf6\xa7\x0f\xdb\xb6\x86\x7a\xea\x3f\x7c\x05\x39\xa7\x0a\x7e\x72\x46\xf2\x6d\x38\xaa\xee\xd5\xd5\xd5\xdb\xbe\x5b\x39\x9c\x7e\x13\xb4\x4d\x9a\x53\x83\x93\xfe\xf7\x97\x5f\x7e\xf9\xe5\xd5\xd5\x55\x7e\x48\x69\x6e\xd8\x83\x1d\x6b\x94\x34\x90\x1b\x28\xf0\x8c\x49\xc0\x78\x86\x60\xc9\xf0\x26\xff\x8a\xae\x44\x49\xfe\xe7\xbf\xff\xf6\x7d\x38\xcb\xf5\xb8\x1e\x3b\xa6\x82\x3a\x38\x8d\xb6\xed\xa4\xce\x36\x47\xb9\x8b\xbb\x4b\xc9\xb3\xa3\x33\xdb\x66\x9d\xea\x98\xf8\x08\xe8\x92\xb1\x3d\x9b\x85\xc2\x1b\x4f\x1a\xfa\xce\x96\xac\x4d\x89\xd3\x2c\x93\x19\x1a\x14\x2b\xd1\xcf\x6a\x35\x90\x80\x45\x14\xb5\x4e\x96\xa9\xa8\x20\xc1\x2c\x5b\x99\x77\x31\x27\x4a\xd3\x84\x3c\x13\x02\x26\xfb\x18\x01\x5d\x32\xb6\x67\x4c\xc9\xd6\x4c\x67\x8d\x80\x2e\x19\xdb\x2b\x9a\x7b\x4b\x7e\xd2\x40\xda\xe6\xff\x5a\x69\x00\x7d\x3f\xfa\x40\x19\xa7\x19\x87\x9d\x63\xd3\x28\xca\x4e\x95\x21\x16\xc1\x46\xc7\x0f\xa0\x32\x6a\x58\x4d\x40\x3c\x30\x25\x45\x0d\xc2\xe8\x4d\xfa\x5d\xf9\x35\xe3\xf0\xd7\x27\xa6\x8d\xee\x3c\xc1\x4b\x6d\xce\x7d\x37\xf1\xea\xdc\x40\xdf\x77\xfd\x6c\x4a\xcd\x72\x25\xcd\xb9\xf1\x93\xfc\x39\x06\xe0\x84\xb5\xb9\x03\x6d\x0d\x5a\xc0\x8c\x2c\xda\x0c\xb4\x90\xa4\x01\xc1\xea\xaf\x9f\x34\xbc\x0b\x80\xf7\x60\x6e\xf1\x29\xa8\x56\x33\x29\xee\xba\x8c\x6a\x96\x5b\x4f\x94\x6c\x49\xc1\x34\x72\x41\x46\x04\x7b\x54\x06\x2f\x16\x8f\x02\xf7\xe6\xee\xb2\x00\xe7\x6b\xf8\x00\x63\xaa\xc4\x5e\xb3\xb2\x15\x05\x94\x4c\x40\xd1\xfd\xf0\xf7\x77\x6f\xde\x72\xaa\xf5\xdf\x6c\xee\xc4\xba\xe1\xa8\x0b\x11\x4e\x6c\x76\x09\x59\x9c\x1c\x58\x43\x15\x2a\x0d\x7f\x5c\x0b\x33\xeb\x87\x7b\xab\x4d\xb6\x64\xe2\x6a\x70\x10\x27\x54\x60\x0d\x55\x8e\xc9\xbe\xbb\x6a\x4c\xbf\x36\x6e\x69\x74\x9f\x35\x86\x34\xbc\xd5\xe4\x55\x63\x48\xcd\x44\xab\xc9\x75\x63\xfa\x7e\xe3\x59\x9d\xb2\x89\x3d\x7f\xe6\x81\x8d\x43\x45\x79\xd9\xcf\x34\x5a\x2c\xc2\x41\xaa\xf6\x4c\xec\x85\x34\xf3\xc0\xb2\xa4\x22\x3f\x3f\xa8\x6c\x3d\xc2\x0a\x99\xd6\xe9\x01\x3d\xb5\x8a\x2f\x25\x10\xa1\x28\x7e\x64\x4b\x68\x51\x90\x9f\x6e\x8e\x04\x03\xbb\x21\xfd\x13\xcb\xd9\x94\x5e\x26\xe5\x7d\x4d\xd5\xfd\x92\x66\x18\xe9\xa7\xe0\x21\xe7\xdc\x6f\x52\xdb\xd6\x60\xda\xa6\x73\x8a\x90\x19\x0e\xfb\x1a\x0c\xb5\x1e\x40\x53\x94\x16\x72\xe8\x92\x71\x24\xe9\x67\xd9\xcd\xd6\x54\x52\x4d\x26\x39\xd0\xa1\x4b\xa2\xb1\xf9\xb4\x21\x4f\xdb\x14\x25\xb6\x0f\x5d\x62\x53\xb5\x33\x34\xdd\x66\xbf\x42\x6e\x02\xa6\xef\x1e\xba\xc4\xb7\xe6\xf8\xf7\x70\x7e\x94\xaa\xd0\x61\x42\xe8\x1f\x3a\xfb\x20\xc7\xe1\x24\x34\xfd\x03\x24\x43\x56\x7e\xb7\xd4\xe1\x21\xe1\x4e\xc8\xd8\x3d\x18\xd5\xc2\x6e\x43\xf0\xa2\xee\x2d\xf8\xd0\xf9\x80\xdc\x75\xd1\x13\x0a\x6d\x1f\x39\xbf\xa3\x4a\x4a\xe1\xc9\xf7\x38\xb9\x64\x1c\xa2\xc9\x43\x17\xc3\x8b\xd0\x7e\x76\x72\xce\x4c\x3c\x79\xe8\x5e\x24\xc9\xd0\xf6\x93\xbf\xe4\x2d\xc4\x53\x5b\xc5\xa3\x99\xa1\x77\x91\x24\xa1\xb9\x3a\x6f\xf0\xdd\x2b\x56\x80\x3d\x85\x5d\x14\x04\x37\x45\x99\x2b\x7c\x47\xea\xd0\x1d\xd1\x71\x24\x0f\x8c\x92\x86\x8a\x42\xe6\x28\x6d\xad\xe2\xda\x9c\x39\x74\x1a\xd5\x49\xa4\xb3\x30\x62\xb3\xa9\x8a\x82\x0c\xa6\xe8\xa7\x9b\xa3\x7e\xf6\x0d\xfe\x97\x87\x7d\x2d\xa5\x11\xd6\x04\x6c\x09\xe5\x5c\x3e\x8e\x76\x00\x8d\x18\x1a\x86\x32\xa0\x4c\x6e\xf5\x04\xb2\x06\xa3\xce\x17\x33\x6f\x20\x0d\x03\x4e\x5c\x46\xb4\x24\x34\x67\xfa\x7a\x2d\x51\x73\xfb\x07\xe7\xde\x75\x61\xe0\x63\x39\x2d\xe4\x4a\xc0\x23\x2b\xe7\xf9\xb5\x69\x16\x48\xa3\x8b\xa8\x67\x0a\x2a\x80\x7b\x4b\x22\x97\x75\x4d\x45\x81\x1a\x54\x6b\x53\x29\xd9\x9e\xaa\xfe\xf6\xfa\xae\x7b\x71\xdd\x6f\x52\xae\x8d\x06\xd3\xf9\xdc\x44\xc1\x28\xc7\x87\x76\xfb\xe9\xe5\x27\x77\xc7\x96\x3e\x8f\xf0\xf4\xf9\x67\x35\xd5\xf5\xdd\x1b\xad\xa1\xce\x38\xcc\x6a\x4b\xbc\xb2\x4c\x71\x6d\x04\x3c\x46\xc6\xb9\x43\x6f\xaf\xef\x02\x55\x7c\xfb\x2d\x3d\xc1\xe1\x5b\xaa\xef\x81\xf3\x9d\x35\x6e\x56\x5c\x0e\xa9\xae\x29\xe7\xa9\xf1\x81\xba\x35\xd3\xf1\x12\x15\x3b\x55\x1c\xfd\x01\x26\x4e\xfb\x9a\xe6\x4a\xe2\x92\xc9\x0a\x78\x7a\xbe\x06\x85\x6f\x71\x64\x52\x9c\xec\xc0\x0e\xf5\xa7\xa1\x99\xde\x51\xa5\xe8\xd9\xe7\x5f\x5a\x6e\x98\x92\x8f\xb3\x49\x01\xbc\x6e\x08\x72\xca\x73\x14\x77\x14\x6b\x6c\xb7\xdc\xfa\xf3\x68\xb2\x58\x43\x4f\x40\xac\x0f\xab\x67\x77\x3e\x21\xe0\xaa\x22\x9b\x2d\x79\x47\xef\x81\x84\x92\x86\x1f\x27\x8f\x52\xdd\x93\x47\x66\x2a\x32\x70\xbf\x16\x9c\x94\xa2\xad\x5f\xdb\xd1\x2e\xb5\x3f\x82\xd6\xf0\xff\xa9\xa9\xc0\xf6\x16\x76\xd0\xca\xf5\x96\xbc\x95\x4a\x41\x6e\x88\x54\x05\x28\x74\xaa\xdd\xb9\x11\x5a\x1a\x50\x24\x1d\xea\x10\x44\x2a\x92\xea\x36\x8b\x0a\x13\xd1\x1e\xc0\x48\xc9\x6d\x82\x79\xc6\x59\x43\x4d\x5e\xe5\x75\x91\x0e\xbc\x5b\x13\xdf\x77\x29\x2b\x5f\x0b\x89\x76\x5a\x43\x9e\xd6\x99\x7c\xea\xfa\xb4\x64\x6e\xd0\xba\x6a\x31\xbb\x5b\xf2\xc6\x2a\x80\xe1\xbd\xe3\xe3\x1f\x48\x92\x0a\x68\xf1\x1f\x38\x36\x33\x9f\x01\xdd\x5a\xc2\xa5\x0d\x9d\x0c\xf7\xeb\x63\xc1\x81\xd0\xf4\x01\xb0\x0f\xe2\x61\x94\xa2\xf5\x97\x1d\x4a\x69\xb3\x7b\xf6\xe0\xe5\x19\xe1\xed\xd5\xf4\xc9\xca\x09\x9e\x4b\xc1\xea\xf4\x1b\x26\x5e\x0b\x6a\x5e\x5b\xe0\x5f\x52\x74\x17\x6c\x33\x6a\xd9\x98\x6e\x82\x87\xc1\xdc\x40\xae\x02\x7c\x1d\x73\x7a\x0e\xfa\x17\x1b\x53\xba\x76\xdc\x9c\x90\xf4\x30\x4b\x73\x7a\x17\x36\x69\x49\x6c\x25\xd0\x7a\x2f\x02\x72\xd0\x9a\xaa\xf3\x8e\x68\x89\x81\x87\x21\xa6\x82\x33\x79\xc4\x58\x57\x48\x43\xe4\x03\xa8\x12\xef\xcf\x54\x60\x8b\x23\x9b\x2d\xa9\xa9\x3a\x31\xa1\x49\x76\x26\x5e\xe9\xec\x6c\xd4\xcc\x0c\x61\xda\xc7\xc9\x8d\xd4\x9a\xe1\x05\x1b\x69\x69\x3c\x2a\x66\xc0\x12\xf1\x53\xf4\x06\x9d\x76\x7c\x6e\xf0\xd4\x70\x96\x33\x43\xbc\x17\x88\x02\xb2\x5e\xdb\xdc\x11\xb7\xb3\x1d\xb9\xbc\xbc\xbc\xeb\x5c\x2d\xe7\x1e\xce\xba\xfb\x86\x89\xbe\xf3\xf5\xcf\x70\x21\x3b\x87\x7c\x18\x8f\x74\x77\x0f\xd0\x44\x85\x5d\x7c\xb8\xef\xc1\x04\x96\x48\xc9\x4e\xad\x02\xd2\x70\x9a\x03\xea\x42\x64\xbe\x32\x59\xb3\xfa\x68\x1b\xfd\xda\xe1\x77\x88\xf2\x11\x67\xd5\xda\xe3\x3d\xd5\xd6\x4a\xa2\x68\x79\x6d\x61\xe1\x93\x77\xa1\x0d\x50\x1b\x1e\x57\xd2\xd8\xd1\x2f\x36\x21\x76\xbf\x91\x59\xab\xcd\xdb\x60\x29\x2a\x74\x14\x6f\x5f\xdd\x75\x2f\x5e\xa5\x81\x42\x87\x86\x1c\x6d\x46\x3f\x4b\x19\x1b\xc5\xee\xc1\x55\xc2\x93\xfd\x9e\x50\xae\x25\xc6\x4b\x85\x55\x7c\x18\x55\xa0\xe3\x04\x13\x4b\xe9\x6a\xb1\x50\xdf\x75\x2d\x87\x1a\xcf\xe9\xcd\x83\x64\x05\x46\x31\x19\x87\x5a\x3b\xad\x96\x6a\xd9\x5a\x63\x8e\xaf\x18\x94\x87\x06\x47\x76\x93\x0e\xf9\x90\x02\xca\xaf\x9c\x4f\xe1\x77\xa0\xbb\x54\x41\x6c\xfa\x90\x52\xdf\x45\x9c\xc7\x91\x05\xd4\xa0\x4e\x20\xf2\xb3\x2f\xa8\xf4\xdd\x27\x50\xbb\x2a\x85\xcb\xde\x58\x29\x6d\x39\xb7\xce\xb9\xde\xa4\xbe\x7a\x31\x50\x37\x78\xfb\x68\x74\x5d\xec\x13\xd3\x66\x06\x6a\x0d\x8d\x8b\x69\x56\xc3\x99\x2b\x1b\xbb\xd8\x6a\xb3\x2d\x7e\xfb\xaa\x57\x28\xd3\xe6\xb2\x15\x06\x54\xa7\x21\x17\x6d\x5d\x40\x63\xaa\xbe\xf3\x45\xdd\x00\xb8\x40\x0f\x63\xe8\x79\x17\xee\xd3\xe0\xbf\x0d\xae\xca\x73\xd4\xf6\x28\xc2\x05\xab\x41\xe0\xa6\x15\xd4\xf2\x01\x48\x28\x25\x3a\xa6\x98\x38\x3d\x93\x47\x71\xb4\x2d\x8c\xcb\xfc\x7e\x8f\x77\xe5\x5d\x12\x2f\x88\x91\xa9\xc0\x67\x3c\xb1\x15\xa4\x54\x00\x7b\x6d\xa8\xc0\x49\x9b\x94\x95\x4f\x23\x7a\x3a\x84\xa4\x43\xba\x88\x83\x49\x25\x2f\x46\x94\xd1\xea\x10\x32\xbb\xf3\x61\xc8\x3a\x3b\x93\x69\x28\xc6\xde\xb4\xb8\xd4\x91\x5d\x38\x66\xec\xf9\xb5\x27\x58\x13\xbb\xb7\xe0\x20\x1e\x1d\x98\x88\x81\x0b\x3e\xd6\xac\x86\xfd\x6c\x02\x3d\x22\x94\x88\xa1\xd3\x25\x43\x73\x9e\x7e\xd2\x7c\xaf\xa0\xd4\xde\x83\x0c\x02\x97\x6b\x5e\x51\x71\xf2\x31\xf6\xe4\x01\x4c\x87\xba\xeb\xcb\x4f\xf1\x51\xce\x26\xdb\xaf\x2e\xac\xe6\x5b\x4c\x8e\x86\xec\xd3\x99\x4f\x05\x61\xd4\x19\x3d\x7d\x26\x4e\xad\x60\x06\xc5\xcc\xb0\x1a\x34\xb1\x23\x7b\x3f\x34\x27\xbb\x98\x36\xbe\x1a\xbb\x42\xec\x56\xbe\x7d\x7f\xbc\x81\x12\x14\x88\x1c\x34\xaa\x2e\xb2\x25\x2f\xae\x89\xdb\xd6\x69\xcf\x70\x67\x3b\xf2\xe2\x95\x5b\x91\x84\x15\x49\xb7\x25\x85\x14\xff\x86\x6a\x06\x51\xc6\xcf\x2b\xf4\xe6\xf7\xd2\x13\x5b\x62\x5a\x25\x88\x14\x61\x95\x40\x83\x95\x96\x4c\x4d\xae\xd1\x60\x5d\xbb\x9c\xba\x2c\x0a\xf2\xe2\x7a\x2a\xc3\xa1\x1e\x8f\x0a\xbf\xa1\xaa\x4b\xc7\x5b\x38\x4c\x2f\xc5\x4f\x08\xb9\x6d\x5c\x5c\x83\x99\x6f\x66\x3d\x5f\xf2\xe2\xd5\xea\x61\x6e\x48\xbf\xdd\x10\xb4\x72\x73\x9f\x75\x1a\x35\xbc\x7d\x7f\xfc\x12\x5f\xb6\x0f\x19\xd2\xaa\x64\x9c\xa7\x36\x43\xb1\xc4\x3c\x42\x69\xde\x59\xfb\xed\xe4\xbd\xa1\x2a\x93\x4f\xb7\xe6\x6e\x21\x27\x68\x48\x16\xd3\x6f\x50\x91\x7e\x27\x50\xcd\x2e\xe6\x0f\xfe\x0d\xd9\x93\x15\x62\xcf\x71\xf4\x9d\xbb\x34\x4b\xad\xb2\xd1\xe6\x5c\xde\x31\x0c\x9a\x54\x0c\x9d\xb9\xd9\x7b\x1f\xc1\x05\x15\x53\x50\x32\x2d\xe5\xf9\xa4\x46\x54\x65\xda\x92\xa3\xa4\x05\x69\x24\x3f\x9f\x38\xfa\x2a\x94\x50\x4d\x38\x35\x80\xbf\xc1\x7b\xf9\x02\xed\xa4\x26\x19\x2b\x98\xb3\x6d\x37\x1f\x8e\x04\x89\x59\xff\xe9\x25\x5c\x9e\x2e\xc9\xb7\x90\x29\x78\xdc\x91\x37\x8a\x66\x2c\xbf\x98\x15\xa6\xa3\x05\xfa\xa8\x34\x1c\xc2\x2a\x17\x92\x46\x48\xf6\xbb\xb8\xa8\x96\xf9\xdc\xd0\x32\xd1\xbd\xc0\x74\x95\xd2\xcd\x62\x05\xeb\xa1\x20\x86\x1e\x6a\xbb\x03\x68\x9d\xa7\x71\xc6\x33\x9c\x2d\x11\x3e\xce\x5f\x84\x1f\xb8\x0c\xd7\x35\x68\xf3\x79\xdc\xee\x3e\x1b\x8c\x99\x4f\xe6\xa0\x71\x3d\x3c\xe3\x43\xfc\xa5\xe1\x5d\x67\x3b\xe8\xc9\x9c\xc0\x10\xc5\xac\x9f\x15\xb6\xbc\x47\x9f\x90\x95\x2c\x27\xba\x92\xca\x54\xe8\xa1\x90\x97\x1a\x80\xbc\xf8\xec\xf3\xeb\xff\xbc\xf8\x62\x63\x95\xc2\xd1\xa3\xbf\x47\xa4\x6f\x11\x29\x0d\x14\xc6\x79\xce\x3d\x5c\xc2\x5f\x5c\xfb\x4c\xaf\xe3\x6a\x7c\x05\x3a\xfe\x2c\x32\x02\x27\xd3\x0a\x59\x68\x47\xd5\xbf\xe9\x57\x25\x5c\x48\xce\x4e\x3d\x89\x12\x38\x8c\x73\x38\x51\x3e\x56\xb7\x86\x62\x9b\xfb\xb4\x74\xf9\x20\xac\xa8\xaf\xbc\x04\x6c\x33\x43\x6a\x59\xb0\x92\xa1\x5d\x40\xd1\x0f\x71\xd1\x8c\x15\xf7\xe5\xa9\xb7\xda\x63\x15\xea\x03\xfc\xfc\x33\x7c\xd0\x86\x1a\x38\x5c\xfb\x32\xff\xa0\x08\x6e\x8e\x4e\x03\xd8\x0f\xad\x6e\xc8\x8b\xeb\x14\x44\x71\xd3\xcf\xd1\x8e\x37\x11\xda\xd1\xa3\x1d\x03\x5a\x6c\x6f\x6e\x3e\x1c\xfb\x40\xae\xef\x26\xd4\x62\xb4\xe3\x87\x9b\x80\x76\x74\x68\xc7\x99\xa1\xb7\x5f\x19\x9a\x8c\x65\xf3\x2f\x8a\x1c\x34\xc8\xfb\x5d\xe7\xfa\xfd\x26\x8d\xb3\x41\xde\x15\xc0\x6b\xb7\xd0\xbd\x77\x14\x92\xb8\xeb\x1d\xc2\x86\x53\x26\x04\x35\x91\x5f\x18\x39\x74\x2c\xb3\x1f\x01\xcf\xb9\x58\x50\x76\xb9\x99\x29\xfd\x9d\xa7\xe4\x1e\x92\xa7\x34\x3e\xe5\x19\x64\xf1\x76\xc3\xb8\x57\x26\xf1\xfe\x90\x1d\x5a\x14\x19\xcb\x14\x68\xd9\xaa\x1c\xba\x24\x1e\x9f\x7c\x54\x11\x1f\xa9\xcc\x99\x81\x3d\x2b\x9c\x17\x64\x7b\x2e\xb7\x17\x8f\x24\xcc\x2c\xd2\xc0\x33\x57\xca\x95\x9a\xe6\x89\x19\x0f\x1e\x91\xc7\x34\x3a\xa2\xda\x86\x4f\x9e\x27\xae\xd2\x48\x6d\x26\x39\x75\x8d\x2e\x71\xbf\x49\xbf\x7a\x17\xba\xcd\x02\xa9\x35\x5f\xfb\x8f\xa5\x57\x66\x21\x4a\xa0\x69\xe5\xdb\x55\x1b\x02\x08\xc3\x50\x3b\xdb\xf6\x50\x88\x69\xd3\x18\x99\xd7\x45\x97\xbe\x76\x93\xac\x21\x26\x5d\xca\xa9\x3a\x01\xfa\x55\x36\x1b\xe3\xd2\x31\xcf\x84\xa6\xc3\x8a\x36\x67\xef\x8e\x22\x0a\xbf\x5c\x89\xc0\x5d\x89\x6b\x5f\x24\xbe\x6e\xe0\xae\x84\xa4\x18\x2c\x8c\xd7\x92\x16\x14\xaf\x10\xff\xae\x7d\xdb\x8c\xd1\x2d\x33\xad\xcb\x13\x0f\x1d\x47\x3f\x1a\x4b\x86\xf6\xfa\x2a\x93\x3c\x21\xf2\xec\x75\xd0\x70\xab\xbe\xdf\xcd\x33\x08\xfe\xb6\x7d\x37\x99\xc5\xc8\x5c\x9e\xa4\x4d\x87\xca\x93\x5c\x99\x8a\xe0\xc9\x94\x20\x55\xfe\xc3\xd0\xe1\xab\x7b\xff\xe1\xb9\xde\x97\x4a\x0a\x53\x53\xbc\x6b\xa4\x1b\x75\x97\x5b\x58\x24\xbb\xfd\x17\x96\x76\xd0\x7d\x5c\x19\x17\x05\x9d\x14\x4c\x4a\x3d\x99\x36\x8a\xda\x6a\x8c\x67\x28\x40\xfa\x4d\x12\x9a\x68\x5a\x45\x11\x8f\xcc\x37\xe3\xef\xc2\xee\x7d\x9f\x41\x29\x95\x63\x6d\x02\x41\xbc\xd8\xb5\x32\x32\xbf\x18\x5a\xfb\xe1\x85\xc5\x71\xd6\xbf\xa7\xb9\xfd\x1c\xd1\xd8\xff\x7e\xe8\x92\x01\x71\xae\xe2\x86\x03\x70\x9b\xb0\xc7\xd0\xdf\xda\x02\x82\x6d\xbb\xaa\xdb\xdd\x62\xb5\xf0\x51\x6a\x78\xd6\x2b\xf4\x09\x71\xa9\x55\x59\x06\x5e\x6e\x2b\x56\x00\xe5\x7c\xfc\x8e\x55\xdf\xb9\x23\x72\xeb\x86\x33\xef\x56\xfe\xbf\x23\xaa\xcf\x8d\x55\x26\xcf\x55\x28\xd8\x84\xa6\x57\xee\x0b\x45\x12\x47\xfb\x46\xe6\x21\x71\x80\xcc\xbb\x2c\x41\xbf\x99\xb3\xfc\xcc\x37\xb6\x5c\x96\x56\x76\x99\x36\xb2\x74\x39\x29\x3d\x43\x30\x23\x82\xcb\x12\x7f\xec\x5b\x55\x0d\xc6\xf7\xba\x24\x1a\x9a\xdd\xd6\x8a\x94\xa3\xd7\x35\x17\xf2\x4c\x16\x67\xaf\x7e\x57\x66\x64\x34\xbf\x5f\x79\x16\x83\xc1\x1d\xcd\xdb\x60\x6e\x22\x8b\x17\xbf\x1e\x24\x9e\x57\xb4\x31\xa0\xf4\x5c\x00\xd1\x22\x3b\xd1\x8b\x27\x46\x89\x97\x09\xb2\x82\xf2\x19\xe4\x8f\x95\x82\x3e\x2e\xb2\x0b\x62\x84\x4c\xdc\x04\xa3\x5a\x88\x65\x35\x1e\xeb\x56\x8c\xee\xd4\xc6\x2e\x4a\x5f\x13\xce\x26\x22\x1d\x9e\xfa\xda\x5e\x46\x0f\xe3\x4f\xdf\x9a\xfd\x58\x88\x09\x13\x0f\xdc\xfa\x4c\xd4\x41\x48\x01\xab\x4f\x6f\x39\x67\x79\xf9\xb7\xae\x12\x3e\x65\xe2\x6e\xa9\xdb\xe2\xf8\x31\x68\x34\x5b\x29\x89\x55\x9c\x05\xc4\x1a\xce\x32\x35\x68\xf7\x7f\x04\x00\x00\xff\xff\x6d\xfa\x9b\x61\x8e\x36\x00\x00")
+var _complySoc2TemplatesDefaultLatex = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x7b\x6d\x6f\xe4\x36\x92\xf0\x77\xfd\x0a\x02\xa3\xc6\x33\x7e\xd0\xed\x1b\xcf\x22\xb9\x20\x40\x2f\x66\x32\xd9\xbc\xf6\xec\x06\x9e\xc9\x5d\x70\x96\x83\xa3\xa4\x52\x37\x63\x8a\xd4\x91\x94\xed\x0e\xa1\xfb\xed\x87\xe2\x8b\x44\xa9\xbb\xb3\xd9\x20\x5f\xdc\x64\xb1\x58\x2c\x92\xf5\x4e\x79\x45\xfe\xd1\x19\x26\x85\x26\x8d\x54\xa4\xa3\xd5\x03\xdd\x83\x26\x5c\xd2\x1a\x6a\x02\x5c\xc3\xd3\x01\x14\x64\xc5\x0f\x54\xeb\x80\xfa\x51\xfe\xe0\xf1\x6c\x2f\x58\x25\x6b\xc8\x1b\xa9\x5e\x1e\x8e\x1d\x28\x05\x8d\xf4\x48\x57\xf9\x3a\x5f\x80\xf2\x1c\x44\xdd\x48\x95\x0f\x36\x8e\x0c\x17\x08\x1f\x8e\xdd\x01\x84\x1e\x6c\xaf\xf8\x90\xe5\xac\x79\x59\x49\x2e\x15\x67\xe2\x41\x5f\xe5\x17\x26\xd5\x8f\xac\xd3\x82\xb6\xa0\xd7\xfa\x71\xef\x1b\xcf\x37\x37\xae\x31\xd8\x67\x47\x61\xc8\x90\x09\xd6\xe4\x8e\x68\xcd\xd4\x95\x6f\x71\x6a\xe0\x79\x53\x33\xb5\x51\x86\x5f\x5c\xe1\xf6\xe3\xae\x96\x55\xdf\x82\x30\x83\x2d\x59\xcd\x12\x72\x09\xd9\x77\xdf\x7d\xdf\x52\x26\x1a\x29\xcc\x45\x52\xba\xa3\x15\x0c\xf6\x19\xde\x7d\xf7\xfd\x44\x65\x95\x15\x71\x81\x8a\x53\xad\xef\x1c\x3d\x24\xa4\xd9\xaf\x70\x95\x67\x84\xe4\xb1\x97\xaf\x67\x8b\x72\x2a\xf6\x1e\xa1\xa4\x25\xf0\x0d\xf6\x17\x28\x1d\xed\x40\x4d\x84\xc6\xae\x6f\xcd\x71\x4b\xa0\x2d\xe0\xe9\x10\xc2\xf6\x42\x2a\x10\x52\x34\x8a\xb6\x60\xe0\xd9\xac\x1d\xb9\x03\x15\xb5\xec\x71\x8b\x84\x84\xf6\x9c\x06\xd5\x1d\x54\x46\x51\xc3\xa4\x43\x4a\xfa\xdb\x3c\xe9\xa4\x6c\xc6\x5f\x14\x29\x77\x04\x5e\x78\xdc\xfc\x3c\x01\xe4\xb9\x86\x2e\x4c\x44\x99\xca\xee\x6d\x3e\x3b\xba\x7c\x98\xef\xc3\x75\x68\xf5\xb0\x57\xb2\x17\xf5\x86\xb5\x74\x8f\x07\x5a\xf4\x1a\x26\xb0\x81\xb6\x43\x51\xb0\xab\x8c\x90\x82\x89\x8a\xf7\x35\xec\x15\xed\x0e\xac\xd2\x77\x4f\xac\x36\x87\x6d\xe1\x4e\xcb\xb5\xef\x6d\x3e\xcd\xf5\x24\xf3\x61\x95\x4d\xf7\x89\xd4\x83\x52\xd9\x6e\xdf\x74\xa8\x5c\x43\x56\x68\x30\x9e\xaf\x71\xbd\x8a\xba\x5d\x0d\x77\xa2\x6f\x4b\x50\x50\xdf\xff\x06\x16\xe1\x78\xc3\x04\x49\x2b\x6a\xa4\x1a\xec\xe7\x24\xa5\xea\x24\x7d\x44\x46\xf9\x1f\x6c\xb3\xdf\x0a\xa9\x5a\xca\x09\x5e\xe0\x75\xb3\x1f\xb2\x62\x4e\x5d\xd0\x47\xb6\xc7\xbb\x12\xfa\xd8\x96\x92\x6b\x3c\xb1\x09\x78\x95\xe7\x53\x27\xcf\xd1\x34\xe4\xd0\x76\xe6\x38\xbb\x32\x4f\x72\xbc\xb3\x89\x27\x0f\xb2\x79\xda\xcb\x87\xe9\xfa\x56\xe4\x07\x05\x8f\x20\x0c\xd1\x9c\xd5\x40\x4a\x05\xf4\x41\x13\x26\x88\x39\x00\x69\x59\x5d\x73\x20\xb2\x21\x94\xe0\xa6\xdd\x95\x64\xc5\x13\xab\xe5\x53\x07\x82\x72\xc3\x40\x93\x1b\x72\xf3\xea\xd5\xab\x57\x59\xa1\xe8\x7e\x0f\x75\x29\x8d\x91\xad\x93\x02\x0d\x15\x2e\xb8\x31\xcc\x70\xd0\x57\xf9\xb9\xc3\xed\xa8\x32\x04\x6f\x68\xb0\x78\xf9\x15\x08\x03\x8a\x89\x3d\x76\x4a\xd8\x33\x61\x93\xd3\x2d\xe5\xf3\x70\xa7\xa1\xdb\xde\x7c\xda\x99\xb5\xc7\xbd\xf7\x24\xdc\x1a\x43\x46\x08\x21\x78\xfd\x7e\x12\x2a\x6d\x3a\x5c\x30\xa1\x41\x19\x84\x14\x1d\x55\xb8\x06\x88\x7a\xb9\x42\x76\x56\x56\xc2\x66\xfe\x00\xaf\xaf\x7f\x3f\xaf\x71\x91\x19\xbb\x01\xf8\xaf\x73\xdc\x97\x7f\x98\xe9\xcf\xfe\x05\x9e\xfb\xf2\x3c\xdb\x7d\xf9\xfb\x38\x7f\x6b\xbe\x40\x3e\x7e\xa0\xca\xb8\x73\x75\x06\xcf\xe2\x76\x8d\xe3\x3b\xc1\xf9\xe0\xf7\xe3\xd0\x58\x53\xb2\x92\x33\xe9\xa4\xf2\x88\x10\xd4\x0e\xcf\x61\x20\x11\xd6\xf7\x54\x90\x32\x4b\x69\x8d\xfc\xa5\xab\x4e\x5c\xc7\xb5\x47\x4d\x3b\xb1\xd3\x54\x19\x56\x71\xb8\x9a\x5b\x9c\xd9\xd8\x40\x56\x44\x00\xd4\x9a\x18\x49\x4a\x88\x2e\xbe\x61\x4a\x9b\xb3\xf6\x8a\xb6\xba\xa5\xe6\xb0\xa6\xad\x46\x83\x30\x8c\xae\xa8\xa1\x2d\xe3\xc7\xf9\x5a\x77\x4e\xfb\xa7\xd1\x31\x0a\xc8\x4f\x60\xc1\x72\x47\xcd\xbf\xb7\x09\x8a\xb3\x08\x68\x59\x66\x9c\xf0\x56\xd6\xa0\x44\x72\x00\xe8\xef\x98\x00\x6d\x14\x98\xea\xb0\xd8\xb6\x06\xe3\xbd\xeb\xd9\x6d\xb1\xc6\xc0\xf3\x90\x15\xac\xf9\xe1\xcb\xaf\x3e\xc2\x4f\x19\x21\xc9\xf0\x5d\xdc\x24\x88\x2a\x30\x0f\xa2\x0a\xe6\xee\xe3\x4d\x20\x78\x6f\xc3\xc0\xb0\x98\xdd\x9b\xe6\xb3\x7b\xcb\x44\xd7\x9f\x19\xb5\x68\x79\x2b\xd9\x76\x78\x15\x9d\x92\x8f\x68\xe7\xa0\x57\x92\x50\x51\x13\x69\x0e\xa0\x48\x30\xbd\x59\x81\x0b\x92\x15\x61\x0d\xe1\x3d\xc6\x26\x44\x2a\xf2\x8c\xce\xd7\x5d\x03\x5e\x0c\xfa\x4f\xe7\x16\x0b\xd6\xfc\x04\x7e\x27\xf3\xf5\x22\xd6\x30\x17\xc9\x04\x23\x04\x70\x1b\xc4\x8c\x72\x19\x2e\xe0\xb7\x10\xe3\xb9\x12\x52\xd4\xd0\xd0\x9e\x1b\x3c\x8f\x06\xa8\xe9\x15\x68\xfb\xa1\xa2\x1c\xb6\xef\xa9\xa9\x0e\x3b\xf9\x04\xaa\xa2\x1a\x86\x0b\xc8\x77\x85\x6a\xbd\xc8\xdc\xdb\x1d\xfa\x1f\xa4\xb0\xfd\x08\x3f\xad\x3d\x95\x9b\x21\x6c\x78\x8c\xa7\x08\x41\x0b\x13\x01\x5e\xf0\x62\x6f\x12\xbb\x05\xe4\x54\xe8\x22\x42\x3e\x17\x2b\x4d\x85\x9e\x2d\x14\x01\x7e\xa1\xd8\x9b\x16\x5a\x40\x4e\x17\x8a\x08\x8b\x85\x5a\x29\xe4\x7c\x47\x01\x10\x76\x14\x7a\xd3\x42\x0b\xc8\x99\x1d\x05\x84\x74\xa1\x99\x56\x32\xe7\xfa\x08\x29\x04\x3c\x4d\x3a\x97\xe8\x1f\x03\x7d\x8d\xe1\x42\x3e\x2c\xf4\x19\x07\x26\x4e\xce\x81\x4f\xd9\x99\x61\x25\x7c\xa1\xd2\x8f\x42\x1c\x4e\xe0\x9f\xc8\x34\x9e\x4e\x40\x7e\xf9\x25\xdb\x33\xa3\xd7\x3b\x6a\x98\x58\x7f\xad\x00\x1e\xae\xa2\x0c\x78\x8c\x89\xcf\x05\xe4\xcc\x89\x05\x84\x7c\xa1\x21\xc9\x7a\x7f\x12\xed\x99\x5e\xfd\xc9\xe4\xc7\xcb\xbe\x98\x82\xfc\x86\x8d\x08\x09\x48\xdc\x76\x32\xcf\xef\xfc\xdd\x77\xdf\x4f\x5c\x4d\x9d\x53\x86\x92\x99\xc9\x96\x27\x8e\xbc\x19\xfb\x85\x76\x0a\x34\x4c\x3b\x45\x4c\xd6\xec\x7a\x7a\x86\x39\xcf\xc1\x85\x89\xf9\x59\xf8\x09\x5f\x11\x6b\xe3\xd1\xce\x71\x76\xe6\xac\x7e\x0f\x3f\x78\xb6\x28\xaf\xa7\x1c\x2d\x46\x2e\xf3\x14\x11\xa7\xf3\xc7\x4b\xfb\xe5\x4f\x3e\x7d\xb7\x5f\xd6\xbc\xfc\x15\x94\xdc\xb8\xbc\x65\x23\xa4\xd8\xfc\x22\x99\x70\x99\xd1\x6a\x45\x3e\xf4\x5d\x27\x95\x71\x45\x80\x09\x8d\x4c\x68\xa4\x3a\x50\x45\x2b\x03\x4a\x5f\x67\x45\x4b\x1f\x80\x1a\x0e\xc6\x80\xca\xd0\x05\x14\x38\xc9\x91\x16\x52\xf8\x19\x2e\x85\x9a\xa2\x7a\x1e\x6d\xbb\x73\x76\xb4\xfe\xa5\xd7\x86\x3c\x80\x12\x4c\xec\xd7\xa4\xec\x0d\xd1\x86\x71\x4e\x74\xe0\xc4\x57\x00\x50\xc9\xf7\xd7\xb8\x1f\x03\xcf\x52\x75\x75\xa3\x0d\xc6\xb9\x8e\xb8\x87\x1a\xcc\x6a\xcc\xc1\x16\x42\xba\x9c\xa1\xa8\x99\xae\x14\xe0\x39\x51\x75\xb4\x9b\xc1\x0e\xb6\xc0\x85\xae\x5f\xfd\x05\xda\xc1\x4f\x74\x97\xfc\x88\x61\x85\xd3\xfa\x71\xee\x41\x3f\xb0\xae\xf8\xf5\x8d\xfb\x69\xd8\x60\x1d\xbe\xfb\x19\xe2\xb6\x9d\xa3\x9e\xc7\x0f\x5f\x42\xc5\xa9\x82\x1f\xbd\x37\x7d\x17\x8f\xca\xbe\x7e\xf5\xea\xdd\x60\xcf\x1c\xce\x90\x45\x6b\x53\x54\xd4\xe0\xa4\xff\xfe\xf9\xe7\x9f\x7f\x7e\xfd\xea\x55\xb5\x2d\x68\x65\xd8\x23\x5a\xa2\xa2\x53\xd2\x40\x65\xa0\xc6\x33\x26\x11\xe3\x02\xc1\x86\x65\xab\x15\xf9\x9b\xa8\x89\x6c\xc8\x7f\xfd\xe7\xdf\xbf\x8b\x67\x79\x3e\xaf\x47\xc9\x37\x07\x68\x63\xd0\xe8\xda\x5e\xea\x5c\x73\x92\xbb\xb4\x7b\x2a\x79\x6e\x34\xb5\x42\xb1\x58\x93\x12\x9f\x00\x36\x9f\xda\x8b\x59\x28\xbc\xe9\xa4\xb1\xef\x7d\xc9\xb9\x29\x89\xde\xce\x67\x68\x50\xac\xc1\x38\xab\xd7\x40\x22\x16\x51\x14\xef\x8e\x98\x03\x15\x24\xba\x65\x27\xf3\x3e\xe7\xc4\xf8\x6c\x46\x9e\x09\x01\xb3\x7d\x4c\x00\x9b\x4f\xed\x05\x53\xb2\x37\xf3\x59\x13\xc0\xe6\x53\x3b\x9d\x15\x7e\x57\xe4\x47\x0d\xa4\xef\xfe\xa7\x97\x06\x30\xf6\xa3\x8f\x94\x71\x5a\x72\x58\x7b\x36\x8d\xa2\x6c\x7f\x30\xc4\x21\xb8\xec\xf8\x11\x54\x49\x0d\x6b\x09\x88\x47\xa6\xa4\xc0\xfa\x87\xce\x8a\x6f\x9b\xaf\x18\x87\xbf\x3d\x33\x6d\xb4\x0d\x04\xaf\xb5\x39\x0e\x36\xb1\x67\x71\x60\x18\xec\xb0\x98\xd2\xb2\x4a\x49\x73\xec\xc2\xa4\x70\x8e\x11\x38\x63\x6d\x11\x02\x3b\x09\x1a\xa7\x4f\x52\xb4\x04\x9d\x48\xd2\x88\x80\xd6\xb0\xf8\x51\xc3\xfb\x08\xf8\x00\xe6\x0e\x55\x41\xf5\x9a\x49\x71\x6f\x4b\xaa\x59\x85\x4a\x8f\x17\x5c\x33\x8d\x07\x44\x26\x04\x77\x54\x06\x8d\x19\x1e\x05\xee\x0d\x45\x85\x89\x1a\x9c\xa0\x04\x3f\xbc\x30\x62\x6f\x58\xd3\x8b\x1a\x1a\x26\xa0\xb6\xdf\xff\xe3\xfd\xdb\x77\x58\x6b\xfa\xbb\xab\x9d\xb8\x30\x1c\x6d\x21\xc2\x89\xab\x2e\x21\x8b\xb3\x03\xeb\xa8\x42\xa3\x11\x8e\x6b\xe9\x39\xe2\x30\x6e\x0d\x4f\x73\x16\x6a\x70\x10\x7b\x34\x60\x1d\x55\x9e\xc9\xc1\xbe\xea\xcc\x70\x6e\xdc\xd1\xb0\x9f\x76\x86\x74\xbc\xd7\xe4\x75\x67\x48\xcb\x44\xaf\xc9\x4d\x67\x86\x21\x0b\xac\xce\xd9\xc4\x5e\x38\xf3\xc8\xc6\xf6\x40\x79\x33\x2c\x2c\xda\x28\x8c\xac\x79\x19\xa5\x6a\xc3\xc4\x46\x48\x13\x05\x39\xee\xa6\xa1\xa2\x3a\x3e\xaa\x72\x92\xe0\x74\x34\x56\x5a\xe7\x07\xf4\xdc\x2b\x7e\x2a\x81\x08\x45\xf1\x23\x2b\x42\xeb\x9a\xfc\x78\xbb\x23\x9c\x89\xa9\xfc\x93\xca\xd9\x9c\x5e\x29\xe5\x43\x4b\xd5\xc3\x29\xcd\x38\x32\xcc\xc1\x63\xcd\x79\xc8\x0a\xd7\xd6\x60\xfa\xce\x3a\x95\x75\xe5\x82\x4d\x0b\x86\xba\x88\xa4\xab\x1b\x07\xd9\xda\x7c\x1a\xc9\x87\x45\x75\xb3\x37\x07\xa9\x66\x93\x3c\x68\x6b\xf3\x64\x2c\x1f\x2e\xd4\x69\xbb\xba\xc1\xf6\xd6\xe6\xf8\xb3\x44\xd3\x7d\xf9\x0b\x54\x26\x62\x86\xee\xd6\xe6\xa1\xb5\xc4\x7f\x80\xe3\x93\x54\xb5\x8e\x13\x62\x7f\x6b\x9d\x42\x4e\xc3\x79\x6c\x06\x05\x24\x51\x03\x17\x04\x67\x05\x77\x42\xa6\xee\xd6\xa8\x1e\xd6\x19\xc1\x8b\x7a\x70\xe0\xad\x45\x0e\xc6\xee\x55\x9e\x8f\xed\x90\x39\xbf\xa7\x4a\x4a\x11\xc8\x0f\x38\xb9\x61\x1c\x92\xc9\x63\x17\x93\xee\xd8\xbe\x38\xb9\x62\x26\x9d\x3c\x76\xaf\xf2\x7c\x6c\x87\xc9\x5f\xf0\x1e\xd2\xa9\xbd\xe2\xc9\xcc\xd8\xbb\xca\xf3\xd8\x3c\x3b\x6f\x8c\xdd\x0f\xac\x06\xdc\x9c\x9e\x0e\xcb\x1d\x77\xa5\x50\x8f\xd4\xd6\xee\x30\x70\x24\x8f\x0c\xeb\x94\xa2\x96\x15\x6a\x59\xaf\xb8\x36\x47\x0e\x56\xa3\x39\x49\x6c\x16\xa6\x74\xae\x54\x51\x93\xd1\x15\xfd\x78\xbb\xd3\x17\x75\xf0\x3f\x02\xec\x2b\x29\x0d\xea\xa5\x46\xbd\xe1\x5c\x3e\x4d\x7e\x00\x9d\x18\x3a\x86\x26\xa2\xcc\x6e\x75\x0f\xb2\x05\xa3\x8e\x57\x8b\x68\xa0\x88\x03\x5e\x5c\x26\xb4\x3c\x36\x17\xf6\xfa\x5c\xa1\xe6\xee\x77\xce\xbd\xb7\x71\xe0\xd4\x05\xce\xb9\x12\xf0\xc4\x9a\x65\x7d\x2d\x45\xe5\x4c\x63\x88\xa8\x17\x06\x2a\x82\x07\x47\xa2\x92\x6d\x4b\x45\x8d\x16\x54\x6b\x73\x50\xb2\xdf\x1f\x86\xbb\x9b\x7b\xfb\xe2\x66\xc8\x0a\xae\x8d\x06\x63\x43\x6d\xa2\x66\x94\xa3\xa2\xdd\x7d\x72\xfd\x97\xfb\x5d\x4f\x2f\x23\x3c\x7f\xf6\x69\x4b\x75\x7b\xff\x56\x6b\x68\x4b\x8e\x31\xd8\x8c\xb3\x83\x63\x8a\x6b\x23\xe0\x29\x71\xce\x16\xa3\xbd\xc1\x46\xaa\xa8\xfb\x3d\xdd\xc3\xf6\x1b\xaa\x1f\x80\xf3\xb5\x73\x6e\x4e\x5c\xb6\x85\x6e\x29\xe7\x85\x09\x89\x3a\xda\xc9\xd9\x12\x07\xb6\x3f\x70\x8c\x07\x98\xd8\x6f\x5a\x5a\x29\x89\x4b\xe6\x67\xc0\xf3\xf3\x35\x28\x7c\x27\x47\x26\xc5\xde\x0d\xac\xd1\x7e\x1a\x5a\xea\x35\x55\x8a\x1e\x43\xfd\xa5\xe7\x86\x29\xf9\xb4\x98\x14\xc1\x13\x5f\xe9\x68\x45\x79\x85\x2e\x1a\xc5\x1a\xdb\x3d\x77\xf1\x3c\xba\x2c\x86\x35\x4d\xe2\x62\x58\xbd\xb8\xf3\x19\x01\xe7\xb7\x86\x6c\x45\xde\xd3\x07\x20\xa1\x1f\xdf\x29\xc9\x93\x54\x0f\xe4\x89\x99\x03\x19\xb9\x5f\xfa\x75\x4c\x4e\x1a\xd1\xb7\x6f\xdc\xde\x6c\xe1\x7e\xb0\xc6\xf1\xbf\x85\x39\x80\xeb\x9d\xf8\x41\x27\xd7\x2b\xf2\x4e\x2a\x05\x95\x21\x52\xd5\xa0\x30\xa8\x76\xd8\x9a\xd0\xc6\x80\x22\xc5\xf8\x0e\x81\xa5\xb9\x42\xf7\x65\xf2\x30\x91\xec\x01\x8c\x94\xbc\x94\xcf\xe3\x2a\x91\xb3\x0e\xeb\x63\x55\x5b\x17\x23\xef\x28\xa0\x6a\xb0\x05\x6b\xde\x08\x89\x7e\x5a\x43\x55\xb4\xa5\x7c\xb6\x43\xd1\x30\x3f\x38\xd8\x91\x90\x4f\x44\x56\xe4\xad\x33\x00\xa3\xbe\xa3\xf2\x8f\x24\xc9\x01\x68\xfd\x6f\x38\xb6\x70\x9f\x11\xdd\x79\xc2\x53\x1f\x3a\x1b\x1e\xce\x8f\xc5\x00\x42\xd3\x47\x40\x5c\x10\x8f\x93\x14\x25\x92\x1a\x7f\xd1\xfc\x84\xa7\xb4\xc5\x3d\x07\xf0\xe9\x19\xe1\xed\xb5\xf4\xd9\xc9\x09\x9e\x4b\xcd\xda\xe2\x6b\x26\xde\x08\x6a\xde\x38\xe0\x5f\x0b\x0c\x17\x5c\x33\x69\xa1\xb5\x9e\xe3\x15\x0d\x1b\x46\x72\x07\x40\xa5\x59\xd2\xf3\xd0\xbf\xba\x9c\xd2\xb7\xd3\xe6\x8c\x64\x80\x35\xec\xe4\x2e\x5c\xd1\x92\xb8\x97\x40\x17\xbd\x08\xa8\x40\x6b\xaa\x8e\x6b\xa2\x25\x26\x1e\x06\xdf\xb3\x8e\xe4\x09\x73\x5d\x21\x0d\x91\x8f\xa0\x1a\xbc\x3f\x7c\xe6\x42\xa5\xc8\x56\xa4\xa5\x6a\xcf\x84\x26\xe5\x91\x04\xa3\xb3\x76\x59\x33\x33\x84\xe9\x90\x27\x77\x52\x6b\x86\x17\x6c\xa4\xa3\xf1\xa4\x98\x01\xa4\x1d\xa7\xe8\x0c\x83\x76\x54\x37\x78\xee\x38\xab\x98\x21\x21\x0a\x44\x01\x39\xff\xb6\xb9\x26\x7e\x67\x6b\x72\x7d\x7d\x7d\x8f\x92\xa6\xc1\x3c\xc0\x51\xdb\xaf\x99\x18\x6c\x78\xff\x8c\x17\xb2\xf6\xc8\xdb\xe9\x48\xd7\x0f\x00\x5d\xf2\xb0\x8b\x8a\xfb\x01\x4c\x64\x89\x34\x6c\xdf\x2b\x20\x1d\xa7\x15\x60\xa2\x82\x4f\x11\x07\x53\x76\xe7\xae\xbd\xe9\xf4\x1b\x8f\x6f\x11\x65\x71\xd2\xa3\x70\x85\xc0\x43\x6f\xa8\x76\x5e\x12\xcd\x5a\xb0\x16\x0e\x3e\xf9\x41\xc2\x84\x36\x40\x5d\x7a\x7c\x90\xc6\x8d\x7e\x9e\xc5\xdc\xfd\x56\x96\xbd\x36\xef\xa2\xa7\x38\x28\x68\x86\xbb\xd7\xf7\xf6\xc5\xeb\x22\x52\xb0\x45\xaf\x38\xfa\x8c\x21\x11\x6e\x2c\x19\x1b\xc5\x1e\xc0\xbf\x84\xe7\x9b\x0d\xa1\x5c\x4b\xcc\x97\xd0\xa9\x2b\x82\x59\x85\x42\xd1\x4c\x25\xfe\xce\xbf\xc5\x42\x7b\x6f\x7b\x0e\x2d\x9e\xd3\xdb\x47\xc9\x6a\xcc\x62\x4a\x0e\xad\xf6\x56\xad\xd0\xb2\x37\x78\x5d\xa8\xc5\xa0\x02\x34\x06\xb2\x59\x31\xd6\x43\x6a\x68\xbe\xf4\x31\x45\xd8\x81\xb6\x85\x82\xd4\xf5\x21\xa5\xc1\x26\x9c\xa7\x99\x05\xb4\xa0\xf6\x20\xaa\x63\x78\x50\x19\x2c\x96\x4a\xdc\x2b\x85\xaf\xde\xa0\x84\x35\x3d\xe7\x18\xf3\x81\xce\x8a\xf0\x7a\x31\x52\x37\x78\xfb\xe8\x74\x7d\xee\x93\xd2\x66\x06\x5a\x0d\x9d\xcf\x69\xce\xa6\x33\x38\xe0\xbd\x8c\x7f\xfc\x0e\xaf\x5e\xf1\x99\xb6\x92\x3d\x3e\x14\x5a\x0d\x95\xe8\xdb\x1a\x3a\x73\x18\x6c\x78\xd4\x8d\x80\xab\x3c\x4f\x7a\x21\x84\xfb\x24\x6c\x75\x0a\x55\x2e\x51\xdb\x14\x2d\x7d\xae\x59\x0b\x02\xdd\x95\x82\x56\x3e\x02\x09\x6c\x10\xcf\x14\xbe\x53\xc6\xa3\x9b\xf9\xab\x40\xdb\xc1\xb8\xac\x1e\x36\x78\x57\x21\x24\x09\x82\x98\xb8\x0a\x54\xe3\x99\xaf\x20\x8d\x02\xd8\x68\x43\x45\x8d\x4b\x14\xac\x79\x9e\xd0\x8b\x31\x25\x1d\xcb\x45\x1c\x4c\x21\x79\x3d\xa1\x8c\x2d\xcc\xf5\xe6\x77\x3e\x0e\xb9\x60\x67\x36\x0d\xc5\x38\xb8\x16\x5f\x3a\x72\x0b\xa7\x8c\x5d\x5e\x7b\x86\x95\x76\x4e\x39\x48\x47\x47\x26\x52\xe0\x09\x1f\xe3\x19\x27\x67\x8d\x66\xd1\x45\x44\x28\x11\x63\xc7\xe6\x63\x73\x51\x80\xa9\x34\xdf\x28\x68\x74\x88\x20\xa3\xc0\x55\x9a\x1f\xa8\xd8\x87\x1c\x3b\x4b\x65\x71\x3e\x64\x6f\xae\x3f\x81\x76\x58\x4e\x76\x5f\x5d\x38\x1b\x78\x32\x39\x19\xb2\x7f\x39\x33\x15\x84\x51\x47\x8c\xf4\x99\xd8\xf7\x82\x19\x14\x33\xc3\x5a\xd0\xc4\x8d\x6c\xc2\xd0\x92\xec\xc9\xb4\x49\x6b\xb2\x62\x11\x56\xbe\xfb\xb0\xbb\x85\x06\x14\x88\x0a\x34\x9a\x2e\xb2\x22\x2f\x6e\xf0\xc3\x9c\x3d\x86\x87\x0c\x37\xbd\x26\x2f\x5e\xfb\x15\x49\x5c\x91\xd8\x15\xa9\xa5\xf8\x7f\x68\x66\x10\x65\xfa\xbc\x42\x67\xff\xac\x3c\xb1\x22\xa6\x57\x82\x48\x11\x57\x89\x34\x58\xe3\xc8\xb4\xe4\x06\x1d\xd6\x0d\x12\x62\x8d\xac\x6b\xf2\xe2\x66\x2e\xc3\xf1\x3d\x1e\x0d\x7e\x47\x95\x2d\xa6\x5b\xd8\xce\xef\x2b\x4c\x88\xb5\x6d\x42\x56\x44\x83\x59\x6e\x66\xc9\x30\x06\x51\x03\xda\xf0\x73\x87\x99\x11\xac\xee\xda\x21\x35\xcc\x16\xe3\xd4\x45\xd6\xf0\xee\xc3\xee\x0b\xd4\xec\x90\x32\x14\x87\x86\x71\x5e\xb8\x0a\xc5\x29\xe6\x0e\x1a\xf3\xde\xf9\x6f\x87\x8e\x4c\x94\xf2\xf9\xce\xdc\x9f\xc8\x09\x3a\x92\x93\xe9\xb7\x68\x48\xbf\x15\x68\x66\x4f\xe6\x8f\xf1\x0d\xd9\x90\x33\xc4\x2e\x71\xf4\xad\xbb\x13\x4f\xed\x80\xfb\x87\xa5\xbc\xbf\xb8\x49\xf4\x07\x53\x38\x34\x61\xa0\x36\x21\x46\x40\x3d\xca\x17\xa0\x7c\xfe\x94\x17\x8a\x1a\xc9\x2b\xd3\x8a\xec\x24\xad\x49\x27\xf9\x71\xcf\x31\x56\xa1\x84\x6a\x82\x5f\x83\xe0\x6f\x8c\x5e\x3e\x47\x3f\xa9\x49\xc9\x6a\xe6\x7d\xdb\xed\xc7\x1d\x41\x62\xa8\xd9\xe4\x25\x5c\xef\xaf\xc9\x37\x50\x2a\x78\x5a\x93\xb7\x8a\x96\xac\xba\x5a\xbc\xa9\x27\x0b\x0c\xe1\xfe\xb1\xdc\x1b\xd3\x2a\x9f\x92\x26\x48\xee\xbb\xb8\xe4\x2d\xf3\xd2\xd0\x49\xca\x7a\x8a\x89\x59\x04\x5a\x9e\xe5\x0a\x2e\x2e\x47\x8c\xf0\xbc\x85\xcf\x5d\x11\x74\x9e\xa7\x69\xc6\x05\xce\x4e\x11\x7e\x9b\xbf\x04\x3f\x72\x19\x10\xa7\x87\x87\xe9\x10\x3d\x43\xfe\xb3\xc1\x69\xe6\x55\x9e\x2f\x41\xd3\x7a\x78\xc6\xdb\x30\x8e\xfb\xca\xb1\x2a\x5b\x02\xc7\x48\x66\x0f\x86\x28\xe6\xe2\xac\xb8\xe5\x0d\xc6\x84\xac\x61\x15\xd1\x07\xa9\xb0\xfe\x5e\x6b\xf2\x52\x03\x90\x17\x9f\x7e\x76\xf3\xef\x57\x9f\x67\xce\x28\xec\x02\xfa\x07\x44\xfa\x06\x91\x8a\x48\x61\x9a\xe7\xc3\xc3\x53\xf8\x8b\x9b\x50\xe9\x75\x8c\x6c\x26\x2d\xf0\xd7\x90\x9f\x80\x27\x27\x33\x7b\x2d\x4b\x5e\xff\x52\x41\xd3\xc0\x85\xe4\x6c\x3f\x90\xa4\x80\xc3\x38\x87\x3d\xe5\xd3\xeb\xd6\xf8\xd8\xe6\x3f\x2d\x3d\x55\x08\x27\xea\x67\x34\x01\xb5\x82\x19\xd2\xca\x9a\x35\xf8\x2d\x9b\x13\xfd\x98\x17\x2d\x58\x41\x1a\xa3\xd7\x9e\x5e\xa1\x3e\xc2\x4f\x3f\xc1\x47\x6d\xa8\x81\xad\xb3\xb3\xd3\x5e\x6d\x71\xbb\xf3\x16\xc0\x7d\x1d\x76\x4b\x5e\xdc\xe0\x37\x50\xb7\xc3\x12\x6d\x77\x9b\xa0\xed\x02\xda\x2e\xa2\xa5\xfe\xe6\xf6\xe3\x6e\x88\xe4\x06\x3b\xa3\x96\xa2\xed\x3e\xde\x46\xb4\x9d\x47\xdb\x2d\x1c\xbd\xfb\xca\xd0\x94\xac\x9c\x27\x85\x77\xb9\x87\x46\x79\xbf\xb7\xbe\x8f\xdf\x2d\x26\xd5\xa0\x10\x0a\xe0\xb5\x3b\xe8\x26\x04\x0a\x79\xda\x0d\x01\x61\xc7\x29\x13\x82\x9a\xb0\xf4\x3c\x68\x40\x7c\x6a\xe0\x79\xc9\xc5\x09\x65\xf7\xb3\x9d\xd3\x5f\x07\x4a\x5e\x91\x02\xa5\xc0\x79\xe0\x25\x81\x9c\xe8\x6e\x1c\x0f\xc6\x24\xdd\x1f\xb2\x43\xeb\xba\x64\xa5\x02\x2d\x7b\x55\x81\x0d\x4b\xfb\xf1\x54\xb5\xd3\xed\x08\x89\x95\xd0\x0d\xc3\x92\x6f\x56\xf8\x9e\xaf\xed\xa5\x23\x39\x33\x81\x99\xa9\x0c\x3c\x23\x53\x69\xf7\x74\xb4\x2c\x16\x45\xf0\x84\x3c\x95\xd1\x11\xd5\x55\xcd\x43\xf1\x3c\x77\x23\x07\xea\x3e\xdd\x2e\x50\x57\x1f\x34\x3e\x1b\xe2\x6f\x3e\x84\xf9\xf3\x55\x75\x5f\x46\x52\xe7\x62\xed\xdf\x57\x5e\x59\xa4\x28\x91\xa6\x93\x6f\xff\xda\x10\x41\x98\x86\xba\xd9\x6e\x51\x14\x62\xda\x75\x46\x56\x6d\x6d\x8b\x37\x0e\x36\x38\x47\x4c\x6c\xc1\xa9\xda\x03\xc6\x55\xd8\xc7\x82\x9c\x1d\xb2\x71\xe9\x79\x6a\x5a\x44\xf2\x36\x8f\xad\xe4\x70\x0b\xff\x44\xe0\xaf\xc4\xb7\xaf\xf2\xf0\x6e\xe0\xaf\x84\x14\x98\x2c\x4c\xd7\x52\xd4\xf8\x09\x70\x8e\x7f\xcf\x7d\xdb\x8c\xd9\x2d\x33\xbd\x7f\xab\x19\x3b\x9e\x7e\x32\x96\x8f\xed\xf3\xab\x44\xfe\xc6\xeb\x0c\x36\x68\xbc\xd5\xd0\xb7\xcb\x0a\x82\xcd\xd3\xe1\x7c\x91\x23\x73\xb9\xc7\x6f\xc0\x0b\xfc\x3d\x33\x15\xc1\xb3\x29\xe1\x37\x0b\x1f\x86\xc6\x2f\xbb\x87\xf0\xe1\xb9\xde\x34\x4a\x0a\xfc\x8a\xc6\x38\xc1\x28\x92\xee\xe9\x16\x96\x62\x14\xbf\xb0\x74\x0c\x63\x12\x31\x65\x88\x93\x14\xa4\x54\x68\xa9\x0d\x3e\xe9\xe3\x4a\x9e\xa1\x08\x19\xb2\x3c\x36\xf3\x0c\x8d\x60\x3a\x12\x29\x84\xdf\x2c\xdc\x85\xdb\xfb\xa6\x84\x46\x2a\xbc\xac\x7c\x0e\x41\x55\x0a\xb7\xe1\xb8\x36\xb2\x0a\xfc\x1b\x59\x6d\xe2\x7e\x66\x79\xd6\xff\x2f\x2a\xf7\x39\xa2\x71\xff\xfd\x60\xf3\x11\x71\x71\x9f\xd3\x01\xf8\x4d\xb8\x63\x18\xee\x28\xd6\x0f\x5d\xdb\x45\x90\xfa\xfe\x64\xb5\xf8\x51\x6a\x54\xeb\x33\xf4\xf1\x63\x0c\xac\x3f\xc8\x26\xf2\x72\x87\x6f\x25\x94\xf3\xe9\x3b\x56\x7d\xef\x8f\xc8\xad\x35\x9e\xb9\x3d\x7d\x6e\x4a\xdf\xe7\xc6\xa7\xa4\x6d\xe0\x2a\x3e\xd8\xc4\x66\x30\xee\x81\x91\x89\xa3\x34\xdb\x37\xb2\x8a\x85\x03\x3c\x1c\xd7\xce\x87\x6c\xc9\xf2\x85\x6f\x6c\xb9\x6c\x90\x29\xac\x6f\xc8\xc6\xd7\xa4\xf4\x02\xc1\x4c\x08\x8e\xe6\x62\x7c\xfe\xad\x2a\x7e\x55\xe8\x7b\x36\x4f\x3e\x63\x5d\xdc\xd6\x19\x29\xc7\xa8\x6b\x29\xe4\xa5\xac\x8f\x79\x76\x69\x06\xfe\x7b\xc2\x19\xb5\x18\x1d\xee\xe4\xde\x46\x77\x93\x78\xbc\x78\xff\x91\x78\x75\xa0\x1d\x7e\x02\xb4\x14\x40\xf4\xc8\x5e\xf4\xd2\x89\xc9\xc7\xbc\x33\x64\x05\xcd\x05\xe4\xc0\xe0\x1f\x10\xd9\x13\x62\xf8\x4d\x79\xb2\x2f\x7c\xb3\x1c\x09\x2f\xc6\xec\x19\xa7\x3b\xf7\xb1\x73\x7f\xbd\x30\xbe\x73\x91\x8e\xaa\x1e\x1a\xe3\xef\x3c\xc2\xf8\xd3\xb7\xe6\x3e\x16\x62\xc2\xa4\x03\x77\xa1\x12\xb5\x15\x52\xc0\x59\xd5\x3b\x9d\x73\x7a\xf9\x77\x4e\x08\xc6\x78\xc7\xf5\xf2\xfb\xe5\xfe\xa6\x0d\xa7\x36\xce\xbd\x94\xa4\x26\xce\x01\xf2\x2c\xcd\x46\x44\x6d\x6b\x59\xf5\x2d\x08\x33\xfc\xdf\x00\x6d\xfa\x9b\x61\x8e\x36\x00\x00")
 
 func complySoc2TemplatesDefaultLatexBytes() ([]byte, error) {
 	return bindataRead(
@@ -1241,12 +1262,12 @@ func complySoc2TemplatesDefaultLatex() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/templates/default.latex", size: 13966, mode: os.FileMode(420), modTime: time.Unix(1755266841, 0)}
+	info := bindataFileInfo{name: "comply-soc2/templates/default.latex", size: 13966, mode: os.FileMode(436), modTime: time.Unix(1757471022, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _complySoc2TemplatesIndexAce = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x59\x6d\x6f\x1b\xb9\xf1\x7f\xef\x4f\x31\xd8\xbc\xb0\x8c\x58\x94\x9d\xfb\xff\xaf\x87\xa4\x7b\x45\x62\xe7\xda\xe0\x7c\x71\x50\x1b\x07\x14\xc1\xa1\xa0\xc8\x91\x96\x36\x97\xdc\x90\xb3\xb2\x75\x8a\xbf\x7b\x41\xee\x83\x56\x2b\x59\x72\x1b\xb9\x40\x81\xd3\x0b\x89\xe2\xc3\xfc\x66\x86\x33\xc3\x19\x32\x05\x69\x05\xcd\x0b\x84\x8c\x72\x7d\x10\xbe\x40\x73\x33\x4d\xd1\x1c\x00\x64\xc8\xe5\x01\x00\x40\x8e\xc4\x41\x64\xdc\x79\xa4\xb4\xa4\xc9\xf0\x87\xd8\x4d\x8a\x34\xc2\x62\xc1\x3e\x39\x7b\x83\x82\xd8\x47\x9e\xe3\xc3\x43\x1c\xd3\xca\xdc\x82\x43\x9d\x26\x9e\xe6\x1a\x7d\x86\x48\x09\x64\x0e\x27\x69\x92\x11\x15\xfe\xf5\x68\x24\xa4\xb9\xf1\x4c\x68\x5b\xca\x89\xe6\x0e\x99\xb0\xf9\x88\xdf\xf0\xfb\x91\x56\x63\x3f\x1a\x97\x3a\xe7\xa3\x13\xf6\x3d\x7b\x35\x12\xbe\xfe\xcf\x72\x65\x98\xf0\x3e\xd9\x2b\x8a\xbf\xe3\x24\xb2\x1a\xcb\x73\x23\x3d\x59\x83\xdd\xb1\x55\x5c\x2f\x9c\x2a\x08\x82\xe6\xd2\x84\xf0\x9e\x46\x37\x7c\xc6\xab\xde\x04\xbc\x13\x4f\x86\xcf\x6d\x8e\x86\xd8\x8d\x1f\xbd\x62\xaf\x5e\xb1\x93\xa6\x23\xc0\xdd\xec\x1d\x4d\x73\x42\x37\x3a\x65\x01\x28\xb6\x9f\x09\xa7\x70\x48\x34\x17\xce\x9a\xd1\x09\x3b\x3d\x65\x27\x9d\x9e\x15\xc8\x68\x59\x86\xe7\x98\x26\x33\x85\x77\x85\x75\x94\x80\xb0\x86\xd0\x50\x9a\xdc\x29\x49\x59\x2a\x71\xa6\x04\x0e\xe3\x9f\x63\x50\x46\x91\xe2\x7a\xe8\x05\xd7\x98\x9e\x56\x64\x52\x10\xde\xd7\xad\x25\xcf\xb1\x03\x82\x89\x97\x51\xa7\x5c\xca\xf7\x33\x34\x74\xa1\x3c\xa1\x41\x37\x48\xce\x2f\x7f\x39\xab\xc0\x2e\x2c\x97\x28\x93\x63\x98\x94\x46\x90\xb2\x66\x80\x61\xea\x11\x2c\x6a\x2a\x1d\x3a\x5f\x4a\x74\xf3\x2b\xd4\x28\xc8\xba\xb7\x5a\x0f\x0e\x59\x10\xec\xf0\x88\x4d\xac\x7b\xcf\x45\x36\x58\x12\xd1\x5d\x0a\x00\xa8\x99\x32\x06\xdd\xdf\xae\x7f\xb9\x80\x14\x2a\xad\x9c\x05\xad\x90\xbd\x22\xa7\xcc\x74\x30\x48\x92\x97\xdd\x69\x47\x8c\x9c\xca\x07\x47\xc7\xe4\x4a\x3c\x82\xd1\x08\xbe\x1f\x4e\x14\x6a\x09\x78\x5f\x38\xf4\x5e\x59\xe3\x5b\x88\x87\xa3\x83\xb6\x51\xb7\x1a\x66\xc0\x67\xf6\x6e\x10\x94\xdd\xe5\x49\x4d\x60\x90\x29\x4f\xd6\xcd\x99\xc3\x42\x73\x81\x57\xc4\x09\x57\xf9\x06\xd8\x34\x67\x60\x4a\xad\x8f\xa1\xfa\x3e\x7c\x71\xf8\x32\x12\x5f\xf2\x72\xd0\x36\x67\xdc\x81\x22\xcc\x3d\xa4\x4b\x3d\x4e\x91\xde\x6b\x0c\x4d\xff\x6e\x7e\xa6\xb9\xf7\x21\x80\x0c\x0e\xc9\x16\x43\xc3\x67\x87\x4b\x4a\x13\xeb\x60\x10\x69\xa4\x27\x6f\x40\xfd\x39\x92\x62\x1a\xcd\x94\xb2\x37\xa0\x5e\xbe\x5c\xe5\xb6\x41\x83\xb4\x02\xfd\xac\x7e\xeb\x8c\x06\x89\x43\x37\x23\x3e\x0d\x80\x90\xa6\x29\x24\x17\x1f\x92\xbe\xc8\xa3\x11\x18\x3e\x53\x53\x1e\xb5\x47\x7c\xec\x57\x86\x5b\x3a\x22\xb0\x1e\x8c\x8a\x05\xcb\xe5\xca\xf8\x4a\xcb\x7d\x7a\x00\xbd\xe9\x5c\xca\xc1\xa1\xf2\x43\x2e\x48\xcd\xb0\x23\x6f\xd4\x1e\xa0\xf6\xb8\x8b\x84\xc3\xdc\xce\x70\x0b\x95\x83\x1d\x14\x47\x23\xf0\x18\xed\xe3\x11\xe9\x94\x8c\x0a\xea\xdb\xcd\x2e\x6e\x32\x25\x25\x9a\xff\x48\xa6\x46\x2d\x9b\x49\x1c\x6c\x6a\x37\xad\xf0\x3b\xb6\x72\x5e\xc5\xb3\x4a\x2e\x96\xa1\xb3\x4c\xf9\x61\xe1\x54\xce\xdd\x3c\x34\x7d\xce\xb5\xae\xd7\xc4\xf1\x61\xbb\x2a\x76\xd5\x1b\x89\xae\x83\x96\x9d\xb2\x6d\x27\x5e\xf5\x29\x98\x2f\xc7\xd5\xb4\x4f\x56\x2b\x31\x3f\x86\x4f\xce\x0a\x94\xa5\xc3\x63\xe0\x46\xc2\xdb\x52\x2a\x82\xe0\x3f\xa5\x5f\xe1\x60\x62\x2d\xb5\x94\x0c\x9f\xb1\x60\x71\x81\xd9\xb1\xbd\x47\x19\x1a\x93\x52\xeb\x18\x06\x3b\x80\x1b\x59\x05\x28\x75\x14\x53\xfd\x8e\xc3\xff\xeb\x69\x5b\x2b\x56\x7b\x18\xb3\x33\x74\x21\xee\xf6\x66\x00\x78\x72\xd6\x4c\xd7\xba\x01\x38\x58\x23\xb4\x12\xb7\x69\xb2\x0c\xb4\xaf\x63\x64\x39\x6c\xa8\x1d\x1e\x25\x70\xb9\x99\x72\x07\xdb\x70\xe7\x78\xb0\x58\xbf\x1f\xf4\x25\xbd\x80\xff\xf1\x31\xea\x1d\x0e\x8a\xb0\x41\x6a\x5f\xf8\x0d\xb5\x80\xfe\x69\x33\xe5\x2e\x76\x63\x14\xfb\x42\x6f\xe9\x45\xfc\xc7\xa8\x77\x38\xf0\xc4\x8d\xe4\x4e\xee\x89\x81\x96\x5c\xc0\xbf\x7a\x84\xf6\xa8\xcb\x00\xce\x94\x44\x23\x70\x6d\xce\x76\xa0\x66\x59\xc0\x79\x5f\xb7\xe1\x57\x5e\xea\xca\x79\x5e\x34\x56\xc8\x1a\xf7\x6f\xf0\x5a\x47\x61\x75\x82\x51\x03\x8f\xb5\x15\xb7\x5f\x4a\x4b\x4b\x4e\xb2\xef\xe0\x3a\x53\x1e\xbc\x22\x0c\xe9\x88\xb7\x5a\x49\x4e\xe8\x81\x6b\xdd\x1e\x60\x3e\xa4\x9e\x9c\x50\x02\x59\xa0\xec\xf1\xc0\x90\x35\xbe\xc9\x84\xd5\x65\x6e\xa2\x57\xcf\x04\x1a\x42\x87\xb2\x1b\x75\xc2\x68\x18\xb4\x06\x87\x94\x29\x27\x3b\xca\x91\x6a\xb6\xa2\xaa\x65\xa8\x09\x2b\xbe\x63\x19\xf7\xc3\x90\xb5\x0d\x1b\xc2\x10\x72\x1b\x67\x35\x5c\x3b\x2e\x6e\x55\x67\x4b\x1b\xa4\xb5\x25\x5b\xe1\x42\x3d\xa0\xcc\x14\xae\x38\x29\x3f\x51\x4b\x00\xdf\x9b\x18\x99\xea\x6d\xeb\x62\xc1\x42\xcc\xf3\xac\x59\xd3\x52\xe9\xc4\xcf\x6f\xe3\xeb\xda\x12\xd7\xdf\xc4\x53\xa4\xd0\xf2\xf3\x5f\xde\xad\xd6\x69\xf7\xbd\x5f\x6f\x63\x62\x00\xd7\x4a\xdc\x22\x3d\x45\x2f\x1c\x88\xbb\x29\x52\xfa\xcf\xb1\xe6\xe6\xb6\x2e\xa8\x16\x0b\x76\xa1\xcc\xad\x67\x2d\xa3\x97\x05\x9a\x87\x87\x64\x2d\x52\xb4\x7a\xed\xcd\xdc\x93\x3c\x97\x5a\xa2\xa7\x5a\x9e\x27\x89\xb3\x81\xa1\x48\xe3\x9c\xcf\xfd\xc3\x03\x48\x3e\xf7\x7b\xda\xf3\xad\x22\xad\x59\x41\x9d\x0c\xec\x79\xbf\x83\xb2\xe1\xef\xf8\xa5\x44\xbf\x8f\xed\x8e\x3c\xee\xdc\xea\xce\xac\xbd\xba\xf3\x9e\xe5\x78\xab\xf5\x6e\x31\xf6\x16\x06\x3a\x83\x74\x67\xab\x41\xbf\x55\x1d\x23\x28\x9c\x9d\x86\xb2\x8e\xb5\x8d\x65\xea\x0a\x33\xae\x4b\x4c\x57\xb9\x3d\xd3\xd6\x87\x28\x0a\x39\xbf\x4f\x1f\x17\xe4\xc5\x32\x41\xfa\xb6\xa3\xb1\x9b\xed\xae\x28\xb2\xca\x1b\x1e\x4b\xbd\xbe\x06\xc9\xc2\x79\x0d\xdc\x40\x73\x48\x83\x9d\xc4\x93\xd3\xba\x29\x37\xea\xf7\xaa\xd2\x0a\x59\x72\xe8\x14\x36\x2f\xb4\xe2\xe1\x7c\x47\x33\x53\xce\x9a\x58\x36\xd6\x54\x89\x8f\x35\xb2\xea\x7b\x3d\xd5\xa5\xf6\xf2\xaa\xfe\xbf\x9a\x1e\x53\x06\xe1\x84\xee\xf7\xbd\x0d\x75\xfc\x3c\xef\x77\x7f\x3a\xff\x09\xce\xed\x9d\xd1\x96\x77\x52\x1a\x5a\x29\x19\x82\x0d\x39\x6e\xa6\x08\xec\xaf\xce\x96\x05\xca\xa5\x1e\x60\xa5\x44\xe8\xb3\x22\x83\xf5\xad\x15\x12\xcd\x40\xcd\xd2\xda\xd8\xda\x51\x56\x83\xff\x8a\x2e\xde\x08\xf4\x16\x54\x73\xd4\x04\xd8\x05\x37\xd3\x92\x4f\xfb\x68\xd5\x87\xb3\x71\x49\x64\x4d\x5b\x23\x85\x86\x32\x13\x5b\x39\xd3\x62\xc1\x2e\x4b\x2a\x4a\xfa\x49\x69\x34\x91\xe5\x9e\xcf\xc5\x7b\xb8\x34\xc9\xb9\x9b\x2a\x33\x74\x6a\x9a\xd1\x6b\xf8\xff\xe2\xfe\xcd\xba\xcf\x55\x2c\x6d\xe3\x67\xb1\x08\xe5\xe2\xd3\x19\x6d\xbc\xe4\x79\x78\xfd\x0a\xef\x3f\x6e\x62\xd1\xc8\x35\x0e\xd7\x7b\xbb\x3d\x2f\x9a\x52\xe1\x79\xfd\x70\x63\x11\xf2\x15\xa6\xc1\xf7\x4c\x74\xb0\x31\x66\x7c\xa6\xac\x0b\x5e\xd8\xda\x20\x60\x5e\x68\x3b\xc7\x90\xec\x1a\x19\x2f\xe3\x1c\x17\x64\x9d\xff\x9f\xf1\xbc\x46\xf2\x3f\xfc\xee\x0f\xbf\x5b\xf1\xbb\xb6\x2c\x7e\x66\xcf\xdb\x5c\x7e\x87\x13\x10\x43\x2d\x3b\x46\xf0\x05\x0a\x35\x51\x02\x3c\x61\xe1\x81\x32\x4e\xc0\x1d\x02\xf1\x5b\x34\xa0\x0c\x38\xf4\x85\x35\x1e\x43\x6d\x79\x8b\x73\x88\xd7\xd1\xcf\xea\x82\x1f\xce\xfb\x3d\x57\x22\x43\x59\x6a\x84\x41\x70\x06\x98\x58\x97\x73\x3a\xda\xed\x86\x4b\xf9\xbf\xc5\x03\x3f\x9c\xf7\xba\x2b\x3f\x3a\x73\xd6\xac\xcd\x8f\x17\xf0\x61\xd1\x86\xd1\x8d\xd6\x4c\x12\x2e\x0d\x48\xcc\xb9\x91\xfd\xd9\xdb\x4c\xa8\xbd\xe6\x78\x5e\x0b\xda\x7c\x81\xf2\xb5\x36\x9b\x79\x9d\x1e\x55\x45\x2b\xf8\xb6\x1e\x1f\xcf\xfb\x89\x53\x4c\x22\x79\xbe\xd5\x6e\x36\x5f\x2d\xee\x36\xa2\xe6\x7a\xe1\x67\x9c\x3f\xc5\xbe\xda\x82\xff\x2f\x8f\x8e\xc0\xbb\xf9\x6e\xeb\x6a\x8a\xf5\x27\xd8\x56\x3d\xf5\x67\x9c\xef\x8a\xe3\xb5\xde\x37\xdb\x23\xc0\xf2\x56\x77\xb1\x60\xe7\x58\x5d\x48\xa9\x0d\xa6\x16\x2c\x74\xd3\xcd\x46\x05\x1a\x75\x5e\x0a\x81\xde\xc3\x3f\x7a\xd1\xe1\x31\x33\xfd\x68\x77\xd8\xe7\xb6\x53\xa9\x65\xe5\x5d\x5f\x03\x21\x94\x37\x16\xf0\xa7\x36\x72\xf7\x63\xf5\xa6\x42\xe9\xdf\x88\xba\xb1\x6b\x62\x2d\xa1\x63\xd5\xcf\xb2\xa6\xea\xdf\x5a\x37\x6e\xb3\xb5\x3c\x2a\xba\x77\x6c\x97\x9d\xa2\xa1\xce\x5d\xce\xac\x99\x28\x89\x86\x14\xd7\xf0\xea\xe4\xf4\x87\x83\x0d\x4f\x82\x6a\x02\x83\x3b\x65\xa4\xbd\x63\xda\x0a\x5e\xbd\x11\x70\x9f\xa5\x69\xd2\x79\x03\xea\xdf\x69\xc7\xee\xde\x03\xc6\x8c\x3b\x08\x2b\xcf\x6c\x5e\x58\x13\xaf\x04\x53\xd8\x44\x9a\xf9\x42\x2b\x1a\x1c\xbe\x68\x5f\x33\xe2\xbb\xdb\xca\xd2\xfa\x3d\xeb\xc7\xd3\xee\x33\x4b\x40\x90\xe8\x49\x99\xaa\x36\x4a\x7b\x78\x9f\x4f\x97\x4f\x5b\x81\xe4\xe7\xa4\xe1\x38\x39\x4e\x96\x15\x5f\x72\x9c\x34\x49\x67\x68\xb6\xf1\x39\x39\x4e\xda\x88\x96\xfc\xc6\x94\x91\x78\x7f\x39\x19\x74\x10\x8f\xe0\xc7\x14\x4e\x56\x5f\x7e\xa2\x6a\xba\x73\xda\xb1\xee\x3b\x0c\xc0\xc3\xbf\x02\x00\x00\xff\xff\x26\x43\xeb\x2c\x60\x20\x00\x00")
+var _complySoc2TemplatesIndexAce = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x5a\xff\x6f\x1b\x37\xb2\xff\xdd\x7f\xc5\x60\xfd\x0a\x49\x2f\xd2\x4a\x76\x92\x36\x4f\x79\xdb\xc2\xb1\xd3\x6b\xd0\x24\x0e\xce\xbe\x1e\x0e\x41\x71\xa0\x76\x47\x5a\xc6\x14\xb9\x25\xb9\xb2\x55\x67\xff\xf7\xc3\xec\xf7\x5d\xad\xa4\x5c\x2c\xf7\x70\x40\x13\xc3\xe6\xf2\xcb\xcc\x70\x38\x33\x1c\x7e\x48\x0f\x02\xe5\xdb\x75\x84\x10\xda\xa5\x38\xa2\x5f\x20\x98\x5c\x78\x28\x8f\x00\x42\x64\xc1\x11\x00\xc0\x12\x2d\x03\x3f\x64\xda\xa0\xf5\x62\x3b\x1f\xbd\x48\xab\x2d\xb7\x02\xe1\xfe\xde\xfd\xa0\xd5\x27\xf4\xad\xfb\x9e\x2d\x31\x49\xd2\x36\xc1\xe5\x0d\x68\x14\x9e\x63\xec\x5a\xa0\x09\x11\xad\x03\xa1\xc6\xb9\xe7\x84\xd6\x46\x66\x3a\x1e\xfb\x81\xfc\x64\x5c\x5f\xa8\x38\x98\x0b\xa6\xd1\xf5\xd5\x72\xcc\x3e\xb1\xbb\xb1\xe0\x33\x33\x9e\xc5\x62\xc9\xc6\x13\xf7\x5b\xf7\x74\xec\x9b\xfc\xdb\x5d\x72\xe9\xfa\xc6\x38\x07\xe5\x62\x6e\x99\xf5\xc3\x9c\x97\x61\x32\x30\x56\x49\xac\xb7\x35\xf9\x1a\x5f\xf3\xc8\x02\x69\xce\x73\x2c\xde\xd9\xf1\x27\xb6\x62\x59\xad\x03\x46\xfb\x5f\xcc\x7e\xa9\x96\x28\xad\xfb\xc9\x8c\x4f\xdd\xd3\x53\x77\x52\x54\x10\xbb\x4f\x07\xe7\x26\x98\x45\x3d\x3e\x71\x89\x51\x5a\x7e\x24\x3e\x91\x46\x6b\xd7\xbe\x56\x72\x3c\x71\x4f\x4e\xdc\x49\xad\xe6\x91\x58\x9e\x87\x4c\xe7\x7a\xfc\xce\x3d\xcd\x3f\xeb\xac\x52\x23\x96\x6c\x89\x9e\xb3\xe2\x78\x1b\x29\x6d\x1d\xf0\x95\xb4\x28\xad\xe7\xdc\xf2\xc0\x86\x5e\x80\x2b\xee\xe3\x28\xfd\x18\x02\x97\xdc\x72\x26\x46\xc6\x67\x02\xbd\x93\x8c\x8c\x07\xbe\x31\x79\xa9\x92\x35\xad\x00\xf2\xa6\x38\x5d\x3e\x16\x04\xaf\x57\x28\xed\x5b\x6e\x2c\x4a\xd4\x7d\xe7\xe2\xf2\xdd\x79\xc6\xec\xad\x62\x01\x06\xce\x10\xe6\xb1\xf4\x2d\x57\xb2\x8f\xd4\x75\x00\xf7\x39\x95\x1a\x9d\xdf\x62\xd4\xeb\x2b\x14\xe8\x5b\xa5\xcf\x84\xe8\xf7\x5c\xd2\x61\x6f\xe0\xce\x95\x7e\xcd\xfc\xb0\x5f\x11\x11\x75\x0a\x00\x28\x5c\x2e\x25\xea\x9f\xae\xdf\xbd\x05\x0f\xb2\x05\x38\xd7\x4a\xba\x56\x5d\x59\xcd\xe5\xa2\xdf\x77\x9c\x27\xf5\x6e\x03\xd7\x6a\xbe\xec\x0f\x86\x56\xc7\x38\x80\xf1\x18\xbe\x1d\xcd\x39\x8a\x00\xf0\x2e\xd2\x68\x0c\x57\xd2\x94\x2c\x92\x41\x5e\x4c\x06\x47\x79\xa9\x10\x06\x4c\xa8\x6e\xfb\xa4\xec\xba\x4c\x7c\x0e\xfd\x90\x1b\xab\xf4\xda\xd5\x18\x09\xe6\xe3\x95\x65\xb6\xd1\x87\x7e\xba\xfa\xf4\x65\x2c\xc4\x10\xb2\xdf\xbd\xe3\xde\x93\x94\x78\x39\x2c\x29\x24\x00\x58\x31\x0d\xdc\xe2\xd2\x80\x57\xe9\x71\x81\xf6\xb5\x40\x2a\x9a\x57\xeb\x73\xc1\x8c\xa1\x58\xd5\xef\x59\x15\x8d\x24\x5b\xf5\x8a\xa9\x00\xcc\x95\x86\x7e\x4a\xc3\x9b\xbc\x04\xfe\xff\x29\x29\x57\xa0\x5c\xd8\xf0\x25\xf0\x27\x4f\x9a\xd2\x16\xdc\xc0\xcb\x98\x7e\xe4\xbf\xd6\x5a\x69\xc6\x54\xed\x5a\xb6\x20\x86\xe0\x79\x1e\x38\x6f\xdf\x38\xed\x29\x8f\xc7\x20\xd9\x8a\x2f\x58\xaa\x3d\xcb\x66\x95\x9a\x1b\x74\x7c\x12\x9d\x8c\xca\x25\xcb\x65\x5c\x9a\x4c\xcb\x6d\x7a\x00\xad\xee\x2c\x08\xfa\x3d\x6e\x46\xcc\xb7\x7c\x85\xb5\xf9\xd2\x4f\x02\x28\x0c\xee\x23\xa1\x71\xa9\x56\xb8\x83\xca\xd1\x1e\x8a\xe3\x31\x18\xf4\x6d\xc3\x88\x1a\xb3\xe3\x41\xaa\xa0\xb6\xdd\xec\x93\x26\xe4\x41\x80\xf2\xab\xe6\x54\xa8\xa5\x9b\xc4\x51\x57\xb9\x28\xd1\xdf\x99\x0a\xd6\xe9\x67\x3e\x2f\x37\x44\xad\x5c\x6e\x46\x91\xe6\x4b\xa6\xd7\x54\x34\x4b\x26\x44\x3e\x26\x6d\x1f\x95\xa3\xe8\xa7\x58\x48\xd4\x65\x15\x40\x78\xe2\xee\xda\x5c\xb3\xff\x91\x6b\xe2\x59\xd6\xed\x83\x12\xdc\x5f\x0f\xe1\x83\x56\x3e\x06\xb1\xc6\x21\x30\x19\xc0\x59\x1c\x70\x0b\xe4\x63\x71\x5d\xe3\x51\xad\x0c\x70\x7f\xcf\xe7\xe0\xbe\x65\xc6\x5e\xad\xa5\x8f\x41\x83\x07\xc0\x67\xb8\xe6\xfe\x0d\x5a\x03\x82\x19\x0b\x26\xed\x43\x9b\x7e\x35\xc2\xfd\x51\xe9\x25\xb3\xe0\x9c\x4e\x26\xdf\x8e\x26\x27\xa3\xc9\x29\x9c\x3c\x9f\x4e\x9e\xc1\xbb\xab\x6b\xa7\x45\xef\xfe\x9e\x6c\x6d\x2b\x93\x90\xad\x10\xa4\xb2\x30\x43\x94\x39\xb7\x97\xa0\x63\x09\xbe\x5a\x46\x62\x9d\x56\xb5\x09\xca\x4a\xe8\x4c\xc3\x73\xa5\x8a\x90\x0c\xe4\x58\x2e\x79\x14\x2d\xc6\x4c\xdd\x61\x40\x85\x79\x2c\x44\x1a\xe6\xcb\x6e\x5b\x96\x02\x20\x16\x34\xc0\xf0\xdf\x71\xf4\xac\xd1\x00\x20\xb8\x9b\x47\x10\x57\xad\x50\xd3\xbe\xd2\xea\x01\x60\xac\x56\x72\xb1\x51\x0d\xc0\x40\x49\x5f\x70\xff\xc6\x73\xaa\x8d\x64\x9a\x46\xce\x5e\x41\xad\x37\x70\xe0\xb2\x9b\x72\x8d\xb7\x64\x5a\x33\xf2\x6b\x73\x18\xee\x15\x3d\xe2\xff\x7e\x1b\xf5\x9a\x04\x11\x19\x20\x3f\x14\xff\x82\x1a\x71\xff\xd0\x4d\xb9\xce\xbb\x30\xfa\x43\x71\x2f\xe9\xa5\xfc\xb7\x51\xaf\x49\x60\x2c\x93\x01\xd3\xc1\x81\x04\x28\xc9\x11\xff\xab\x2d\xb4\xc7\x75\x01\x70\xc5\x03\x94\x3e\x6e\xf4\xd9\xcd\xa8\x18\x46\x7c\x5e\xe7\x65\xf8\x85\xc5\x22\x73\x9e\xe3\xc2\x0a\xdd\x22\xbc\x15\xfc\x4a\x47\x71\xf3\x04\x2a\x67\x3c\x13\xca\xbf\xf9\x2d\x56\xb6\x92\x24\x7c\x0a\xd7\x21\x37\x60\xb8\x45\x4a\xb7\x8c\x12\x3c\x60\x16\x0d\x30\x21\xca\x0d\xda\xd0\x59\x81\x59\x0c\xc0\x2a\xb0\xe1\xf6\xc0\x17\x16\xbe\xe9\xfa\x4a\xc4\x4b\x69\xc8\x37\x57\x3e\x4a\x8b\x1a\x83\xbc\xad\x6c\xa5\x46\x25\x71\x64\x43\xae\xab\x46\x80\x80\xaf\x6a\x5f\xf5\x50\x4a\x23\x9e\xba\x21\x33\x23\xca\x46\x47\x05\x61\xa0\xdc\x4d\x2b\x01\xd7\x9a\xf9\x37\x5c\x2e\x36\x38\x6d\x0c\xd9\xc9\x8e\x8e\x56\x5c\x2e\xe0\x8a\x59\x6e\xe6\xbc\x62\xd0\x5c\xe6\x28\xdb\x06\x1a\x75\x14\xb1\x5d\x8a\xe9\xc6\x2d\xc6\x94\x54\x92\xe4\x40\x72\x5d\x2b\xcb\xc4\x83\x64\x4a\x29\x94\xf2\xfc\xc1\xab\x55\xee\x83\x87\x5e\xaf\xb3\x34\xf1\x29\x76\xab\x2f\xd0\x0b\x03\xcb\xf4\x02\xad\xf7\xcf\x99\x60\xf2\x26\x3f\x9b\xd2\xf6\xc9\xe5\x8d\x71\x4b\x41\x2f\x23\x94\x49\xe2\xb4\x46\xd7\xf4\xda\xea\x79\xa0\xf9\x5c\x8a\x00\x8d\xcd\xe7\xf3\x45\xd3\xe9\x10\x28\xa5\x71\xc1\xd6\x26\x49\x20\x60\x6b\x73\x28\xd9\x56\xa8\x83\x18\xbf\x56\xaa\x6c\xf4\xc1\x34\x75\x2e\x94\xc1\x00\xde\xc8\x34\x0f\x41\xfb\xb5\x72\x55\x04\x4a\xd1\x1e\xea\x1c\x3b\x67\xb4\xe1\x2e\x79\x56\x78\x60\xc7\x20\xfb\x85\xbf\xe2\x6f\x31\x9a\x43\xf8\x45\x2a\xe3\x5e\x9f\xa8\xf5\x3a\xd0\x34\xd2\xa8\x75\xe8\x79\x9c\x09\xb1\x7f\x1a\x07\x8b\x97\xb5\x46\x7b\xab\xb2\x46\xb3\x53\x1d\x63\x88\xb4\x5a\xd0\xf9\xde\x2d\x0b\xd5\x19\x06\x56\x4c\xc4\xe8\x35\xa5\xcd\xbc\x21\x49\x60\xc9\xee\xbc\x5d\x13\xc9\x4e\x17\x3f\x65\x27\xfa\xff\xf8\x2e\x7e\xad\x51\x06\xa6\x8b\x7e\xa5\xaa\x1a\x49\x9f\xc9\x15\x33\xc7\x36\x1d\x05\x21\xf2\x45\x68\xbd\x93\xd3\x49\xde\xa5\x03\x00\x3a\x1c\x04\x94\x41\x0b\x39\x14\x02\x1e\x45\x92\xb6\x16\x8b\x4e\x11\x6a\x9a\x21\x78\x15\x39\xd3\x3e\x3f\x6b\xb4\xb1\x96\xd0\xda\x9f\xe1\x7b\x98\xc0\x0f\xf0\x8e\xd9\xd0\xd5\x2a\x96\x41\xff\x64\x32\x81\xff\x85\x8e\xd4\x02\xc6\xed\xc1\x03\x98\x42\xa1\x8a\xfa\xc1\x98\xfe\x4b\xbc\x85\x14\x82\xeb\x77\x40\x30\xaf\xd6\x6f\x82\x7e\x2f\xd3\x6a\x6f\x30\x6c\x49\x4a\x58\xe0\x14\x7a\x82\x4b\xec\x0d\x1b\x2d\x01\xb3\x6c\xda\xea\x0d\x20\xd8\x0c\x85\x99\x96\xa8\xd1\x92\x45\x15\x28\x46\x7a\xa8\xe6\x7e\xc1\x2c\x92\x8d\x98\x0c\xfe\x9a\x0c\xe1\x64\x32\x80\x64\xd0\x64\x93\x31\x32\x68\xcd\x14\x3e\xb6\x5a\x00\xee\x33\x86\x53\xe8\x15\xca\xa8\x25\x71\xfd\x6f\x06\xbd\x61\x3a\xbc\x29\x4f\xbe\x42\x83\x21\xcc\x94\x0e\x50\x9f\x2b\xa1\xf4\x14\x7a\xc7\x4f\x4f\x9f\x07\x2f\x5e\xf4\x86\x30\xe7\x42\x4c\x61\xce\x84\xc1\x21\xac\xcf\xee\xb8\x79\x73\x31\x85\x5e\x3e\xb0\x07\xc9\x70\x87\x24\x14\x28\xab\x83\x4a\x91\x9d\x74\x4a\xb2\x45\x33\xe5\xe0\x94\x54\xb2\x29\xe8\xfc\xd9\x77\xfe\x53\x7f\xbb\xa0\xbe\x8a\xbf\x4c\xcc\x34\x46\x94\x11\xf6\xdf\x91\xb1\x8c\xf6\x5d\xf2\xfd\xdf\x53\xff\xf9\xb3\xd9\x7e\xf9\x5a\xe2\xd5\xc1\x3a\x68\x4b\xaf\x22\xd2\x94\xd9\x34\xb9\x14\x10\xee\xa8\x07\x5a\x39\xec\x34\x1b\x5a\x2e\x1e\xd4\x56\x74\x08\x91\x32\x9c\x18\x90\xb1\xe3\xdc\xf6\x86\x60\xb9\x7f\x43\x64\x61\xc9\xe5\x14\x26\x43\x8a\xae\x53\x20\x97\x4c\xba\x34\x5b\xd2\xcc\x26\xd7\xa0\xa8\x29\x56\x75\x91\x8c\x34\xfa\x9c\x80\xdc\x29\xa4\x64\x37\xa8\x36\x55\xd2\x74\xeb\xe6\x57\x32\xd8\xc4\x82\xeb\x68\xcc\x71\x85\x23\x3c\xec\x04\xb9\x15\xbb\xca\x8e\xd7\xdb\x10\x8a\xcf\xb4\xaf\xd1\xb1\x16\x98\x84\xe2\x2c\x0b\x6a\x9e\x1e\x30\x95\x5e\x30\xc9\x7f\xcf\x00\x57\x02\xcb\xa8\x32\x4d\xcd\x38\xa3\x63\x30\xca\x15\xd7\x4a\xa6\xa1\x2b\xa7\x6a\xd9\x4c\x20\x41\x49\x02\x3b\x10\x21\x5b\x5e\x97\xe5\xdf\xc5\x1e\x57\x34\x03\x21\xc0\xed\xba\x33\x82\xf3\xd7\xcb\x76\xf5\x87\x8b\x1f\xe1\x42\xdd\x4a\xa1\x58\x6d\x47\xb2\x0d\xe4\x90\x94\xad\x99\x5c\x20\xb8\x7f\xd1\x2a\x8e\x30\xa8\xf4\x00\x8d\x4d\xa2\x2d\x4a\x40\xbb\xc9\x06\x9e\x58\x34\xe4\x22\x6d\xb4\x35\x3e\x6b\xcc\x7f\x41\x4d\xf6\x64\x5a\x03\xaa\xdd\xff\xca\x32\xd1\x66\x95\x67\x4f\xee\x2c\xb6\x56\xc9\x12\x27\xa5\xc2\x2d\xd3\x92\xf2\xff\x34\x95\xba\xbf\x77\x2f\x63\x1b\xc5\xf6\x47\x2e\x90\x80\xe1\x24\x69\x65\x5c\xe9\xfd\xa2\xe7\x2c\x99\x5e\x70\x39\x4a\xed\x7e\x0a\xcf\xa3\xbb\x97\x0e\xa4\xb9\x80\xe7\x5c\x87\x08\x46\xc5\xda\x47\xba\xc4\x94\x0b\x0c\xc0\x70\x5a\x63\x4b\xc0\x84\xd5\x4c\x1a\x91\xd9\xc1\x2d\x33\x64\x33\x41\xec\x63\xb0\x99\xb0\xe5\x49\xdb\x5b\x26\x17\x31\x5b\x60\x92\x40\x5f\xc5\x96\xc0\x8c\x60\x70\xd4\xea\x47\xb0\x24\xe1\xcf\x34\xff\x6a\x40\x07\xc9\x4e\x15\x70\x39\x57\x0f\x9f\xff\xfe\x09\x6c\xf4\xe8\x44\x67\x77\x08\x5a\xe4\x88\x8f\x23\xeb\x67\x78\xfd\x7e\xa3\xa1\x89\xf7\x6e\xaf\xad\xd7\x1c\x17\x88\xe2\xe3\xc6\xa1\x4e\xac\xf2\x33\x2c\x28\xf6\xc8\x34\xea\xcc\x30\x64\x2b\xae\x34\x45\xa1\xd2\x07\x01\x97\x91\x50\x6b\x24\x4c\x4c\x06\x40\x90\x9a\x66\x74\xe1\x67\xfe\x6b\x22\x4f\x31\xf3\x3f\xe3\xce\x9f\x71\xe7\xcf\xb8\x53\x8b\x3b\x45\x5e\xfd\xd8\x91\xa7\xe4\xd3\x68\xa5\x0c\x08\xe9\x80\x3a\x43\x30\x11\xfa\x7c\xce\x7d\x30\x16\x23\x03\x36\x64\x16\x98\x46\xb0\xec\x06\x25\x70\x09\x1a\x4d\xa4\xa4\x41\x82\xe0\x6f\x70\x0d\xe9\xab\x84\x47\x0d\x41\x6f\x2e\xda\x35\x57\x7e\x88\x41\x2c\x10\xfa\x14\x9d\xe8\x32\x7e\xc9\x6c\xd3\xc8\x6d\x08\x6f\x99\xdd\x82\x5a\xda\xb0\xbc\xc8\xd8\x1f\xbb\x2a\xa5\x3d\x24\x6c\xbd\xb9\x68\x55\x67\x49\x0f\xbd\xb4\xd8\xe8\x9f\x3e\xde\xa0\x41\x1d\xad\x9d\x2e\x60\x03\xb8\x94\x10\xe0\x92\xc9\xe0\x68\xb7\xdd\x75\x86\xca\x5b\x6e\x43\xe0\x32\xc0\x3b\xf8\x1f\x37\x53\x5b\x7e\x3a\x84\x4d\xc1\x09\xcd\x2a\x1c\x8a\x60\xb8\xb6\x1b\xb5\x3a\x93\x9f\xe4\xf3\x87\x7e\x8e\x01\x61\x92\x0c\x36\x84\x48\xd5\x11\xa2\x7f\x23\xe8\x19\x41\x13\x1f\x2a\xfe\xa5\xa4\xaa\x4e\x1f\xb2\x53\x52\x92\x7c\x03\x81\x92\x4d\xc3\xe9\x9e\xfc\x43\x54\x52\xde\x7d\xed\xd6\xc9\x17\xe8\x83\x69\x3f\xe4\xab\xfd\xf2\x36\x6b\x32\x8b\xa1\x7b\xde\x20\xc6\x7c\x7d\xca\xc6\xf0\x59\x81\x84\x17\x07\xfb\x47\xf4\xc8\x33\x63\xf8\x42\xe2\x46\xfd\x45\x0b\x87\xb7\x61\xdb\xfb\xb6\x7a\x59\x73\x5a\x0f\x4a\x10\x72\xe1\x3a\x1b\x2f\x62\x3c\xb3\x1d\x2f\x11\x9c\x7d\x16\xf1\xb5\x36\xbf\x63\x35\x37\xd7\xb6\x42\xfd\x73\x3d\x94\xed\xe1\xb3\xe2\x6a\xe1\xef\xdc\x86\xf0\x37\xe9\x93\x13\xd0\x4d\x03\x3d\x27\x7a\xc4\xa5\xce\xb8\x6e\xd4\x16\x2e\xf8\xb5\xcb\xbd\x31\xd3\x07\xac\x78\x1a\xbd\xdc\x4c\xd0\x33\x9b\x24\xf7\xf7\xdd\x0b\xdc\xd4\x78\x9d\x72\x15\x51\x2e\x94\xa4\x54\x5b\xcd\x9b\xd5\x79\x34\x82\x7e\xa3\xb6\x0a\x3f\xad\xbd\xe7\x0f\x35\x9e\xe3\xf2\x21\xc0\xe3\x26\x0f\xdd\x4f\x0c\x3e\xe7\x19\xc3\x3a\x47\x46\x72\xb0\xd3\x94\x60\xe7\x6c\xdd\xc6\x4c\xd2\xdb\x03\xb6\xdc\x99\x32\x74\x3f\xbe\xd9\x6f\xc2\x39\xda\x0a\x3f\xe3\xfa\x4b\xac\xbb\xc4\x64\x7f\xd8\xda\x02\xaf\xd6\xfb\xcd\xb9\x04\x79\x93\x64\x87\x78\xa9\x15\xe7\x5d\x7f\xc6\xf5\xbe\x90\x93\xeb\xbd\xdb\xf2\x01\xca\x6b\x0c\xa2\x7a\x91\xe6\x6f\x29\x12\xd9\xea\x98\x1f\x72\x8a\xe9\xb4\x5a\x6d\xfa\xcc\xc9\xc4\xbe\x8f\xc6\xc0\x3f\xd0\x7c\x51\xb2\xf1\x5e\xb5\xbb\xc9\x60\xa3\x57\xe3\xb3\xa6\xab\x52\xb3\xaf\xda\x1a\xa0\xe3\x46\x61\x01\xdf\x95\x2e\xb3\xd7\x5d\xd2\x3e\xad\xda\x4d\x91\x8a\x9a\xb4\x23\xbd\xfc\x42\xed\x66\x7f\xf2\x4e\x95\xb7\x94\xa3\x0a\xb7\xd9\x79\x2f\x16\xd5\x5f\xa1\x5c\xd6\xf0\xc2\xfc\xd8\x7e\xae\xe4\x9c\x12\x07\x7a\x24\x0c\xa7\x93\x93\x17\x47\x1d\x77\x42\xf4\xb8\xf1\x96\xcb\x40\xdd\xba\x42\xf9\xe9\x70\x62\x1a\x7a\x9e\x53\x7b\x05\xda\x7e\xf5\x75\xd4\xf1\x84\x91\xae\x7a\x68\xe4\xb9\x5a\x46\x4a\x52\x6e\x0e\x1e\x74\x91\x76\x4d\x24\xb8\xed\xf7\x8e\xcb\xf7\x8c\x24\x44\x73\x68\xfe\xa2\xf5\xfb\x93\xfa\x45\x11\x71\xa0\xbb\x7c\x2e\x53\x62\xe0\xb5\xf8\x7d\x3c\xa9\xc0\x61\x22\xf9\xd1\x29\x24\x76\x86\x4e\x05\xf6\x3a\x43\xa7\xc0\x5b\xa8\x58\x66\xd9\xce\xd0\x29\x23\x9a\xf3\xab\x9b\xe6\x60\x97\xf3\x7e\x8d\xe3\x00\xbe\xf7\x60\x52\x17\x29\x57\x4d\xbd\x4f\xd9\x56\x18\x41\x72\x04\x00\x90\xfc\x6b\x00\x59\x72\xe6\xd9\xcd\x30\x00\x00")
 
 func complySoc2TemplatesIndexAceBytes() ([]byte, error) {
 	return bindataRead(
@@ -1261,7 +1282,27 @@ func complySoc2TemplatesIndexAce() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "comply-soc2/templates/index.ace", size: 8288, mode: os.FileMode(420), modTime: time.Unix(1757470844, 0)}
+	info := bindataFileInfo{name: "comply-soc2/templates/index.ace", size: 12493, mode: os.FileMode(420), modTime: time.Unix(1792336769, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _complySoc2TemplatesTrustCenterIndexAce = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x56\x4b\x6f\xe3\x36\x10\xbe\xfb\x57\x0c\x94\x1e\xd7\x74\xb7\x8b\x16\x45\x00\xa1\x08\x92\xe6\xd2\x22\x49\x37\x29\x7a\x2c\x46\xe4\xd8\x62\x42\x91\x5a\x72\x94\xac\x57\xd5\x7f\x2f\x28\xd1\xb6\x64\x7b\xf3\xe8\x03\x16\x24\x73\x86\xdf\x7c\xf3\xe2\x23\x07\xe5\x24\xaf\x6b\x82\x92\x2b\x33\x8b\x2f\x30\x68\x57\x39\xd9\x19\x40\x49\xa8\x66\x00\x00\x15\x31\x82\x2c\xd1\x07\xe2\xbc\xe1\xe5\xfc\xc7\x5e\xcc\x9a\x0d\x41\xdb\x8a\x1b\xef\xee\x49\xb2\xb8\xf6\x2b\xb4\xfa\x0b\xb2\x76\xf6\x0a\x2b\xea\x3a\xb8\xf3\x4d\x60\x38\x27\xcb\xe4\x7b\x90\xd1\xf6\x01\x3c\x99\x3c\x0b\xbc\x36\x14\x4a\x22\xce\xa0\xf4\xb4\xcc\xb3\x92\xb9\x0e\xa7\x8b\x85\x54\xf6\x3e\x08\x69\x5c\xa3\x96\x06\x3d\x09\xe9\xaa\x05\xde\xe3\xe7\x85\xd1\x45\x58\x14\x8d\xa9\x70\xf1\xad\xf8\x41\x7c\xb7\x90\x21\x8d\x45\xa5\xad\x90\x21\x64\xff\x29\x4b\x78\x42\x96\x65\xe2\x0a\x68\x55\x60\x67\x69\xac\x9b\xf2\xf6\x99\xb2\x58\x51\x9e\x3d\x6a\x7a\xaa\x9d\xe7\x0c\xa4\xb3\x4c\x96\xf3\xec\x49\x2b\x2e\x73\x45\x8f\x5a\xd2\xbc\x1f\xbc\x03\x6d\x35\x6b\x34\xf3\x20\xd1\x50\xfe\x3e\x9a\x29\x9c\x5a\xf7\x51\x04\x92\x31\x95\xa2\x24\xef\x84\x0e\xf3\xda\xeb\x0a\xfd\x3a\xfe\x0d\x15\x1a\xd3\x4f\x02\xe8\xf5\xf3\x2d\x2a\x3e\x22\x72\xa2\xb6\x29\xe9\xc3\x53\xbe\x17\xff\xa8\x64\xc3\x53\x8b\xd0\x14\x03\xfe\x96\x64\xe3\x35\xaf\x01\xad\x82\x73\x57\xd5\x46\xa3\x95\x04\xd7\x8f\xe4\x63\xd8\x3d\xea\xc4\xa5\x91\xd8\x84\xb1\xf5\x49\xa4\x8c\x24\xeb\x6d\xab\x97\x20\x2e\x28\x48\xaf\xeb\x18\x70\xd7\x25\x4d\x61\x9c\x7c\xf8\xd4\x38\xa6\x24\x00\xa8\x63\xc3\x1d\x9b\xdb\xb6\x64\xd5\x76\x54\x7e\x80\x4b\x8f\x15\x3d\x39\xff\x10\x92\x4c\x30\xae\x82\x40\x4f\xf3\x8a\x94\x6e\xaa\x24\x8e\x50\x8f\x76\x45\x20\x76\x90\xad\x21\x80\x50\xa3\x8d\xd0\x98\x76\x6d\x97\x2e\x3a\x30\x52\x8f\x79\x4f\x62\x60\xde\x99\xf0\x62\xcc\xe5\x07\x38\x1f\xe6\xc2\x79\x4c\x14\xae\x36\x21\x32\x16\x86\xc4\xf0\x8e\x85\xd6\x5f\x68\xfe\x7d\xe4\x5e\x36\xc6\xf4\x4d\x93\x26\x02\xf0\x76\x85\xa6\xf1\xb8\x5e\x51\xbd\xcb\xc1\x81\x02\x2b\x6d\xd6\xfb\xd2\xde\x15\x52\x1b\xd7\x36\x89\x03\xe0\x49\x77\x8d\x32\x76\xcb\x68\x15\x7a\x35\x4e\x58\x54\x7f\x13\x92\x02\x4e\x73\x10\xc3\x66\x70\x0c\xdf\xfb\xa1\x69\x0a\xdf\x8f\x43\x8d\x0d\x76\xdd\xa1\xf2\x90\x60\xa3\xd8\x44\x72\x8b\xac\xc3\x52\x93\xea\x3a\x58\x8c\x15\x77\x8e\xd1\x4c\xa0\xd3\x46\x3a\xea\xcf\x64\x08\x10\xd8\x3b\xbb\xfa\xaa\x1f\xc7\x67\xf7\xc4\xaf\x9a\xf9\xef\xc3\x38\x51\x4e\x36\x15\x59\x7e\x55\x63\xde\x34\x85\xd1\x12\x2e\x36\x98\xe9\x32\xdd\x48\xbb\xee\xff\x68\xd8\x98\xc0\x7d\xd9\xdd\xba\x3e\x90\xdd\x5c\x5c\xbe\xdc\x9c\x87\xbe\x1e\xad\xe6\xb3\x0d\xf4\x8b\xb6\xea\xa5\x8a\xe2\x70\xb2\xb4\xad\xb8\x6e\xb8\x6e\xf8\x52\x1b\x8a\x87\x40\xd7\x01\xa3\x5f\x11\xe7\x7f\x16\x06\xed\x74\x11\xc6\xdf\x11\xc4\x57\x2a\x18\xe7\x92\x09\xbb\x09\x35\x5c\x39\xd8\x96\x15\x4a\x7c\x24\x28\x88\x2c\xd4\xb1\x7a\xa1\x24\x05\x6b\x62\x31\x3b\x34\x75\xe2\xe9\x53\x43\x81\xe7\x28\x25\x85\x57\x75\xc4\xc7\x01\x01\x67\x3d\x22\xc9\x6b\x38\x53\x4a\xc7\x5d\x0e\xcd\xd6\x93\xfe\x20\x89\xa7\x9a\x34\x8d\xd2\x76\x05\xd8\x28\xcd\xe0\x29\x1e\x85\xe1\x1d\xe8\x00\xf8\x88\xda\xc4\x6e\x81\xc6\x2a\xf2\x70\x75\x71\xb6\x71\x73\xe9\x7c\x15\x6f\x1b\xa5\x53\x79\xed\x02\x03\xf6\xae\xe5\x59\xdb\x8a\xe4\xc3\xe0\xc2\xef\x1f\x7f\xed\xba\x2c\xa1\x00\xc4\x52\x93\x19\x57\xc5\x60\x41\x46\xf4\xef\xfd\x96\x12\x69\x8f\x1e\x89\x00\xb4\xad\x1b\x16\xfd\x1b\xe2\x7d\x28\x67\xfa\xcc\x10\x6b\x98\xdb\x31\xfc\x59\xa2\x3f\x9c\x7f\x80\x9f\x2b\xd4\xe6\xcd\x74\x14\x51\x03\x1f\x4d\x0c\x3c\x4b\x18\x8f\x5e\xb4\xeb\x37\xb3\xed\x82\x93\x7b\x16\x9e\xa5\xdb\x2e\x27\x48\xb5\x20\xf5\x12\x75\x64\x42\x4f\x28\x36\x7f\x06\xda\x8a\x42\xd8\x9d\x78\x47\x68\x8f\x1a\x2b\x1a\x66\x67\x45\xfa\xec\xee\x43\x43\x50\xa1\x29\x2a\xcd\xc7\x5b\x75\xb8\x61\xc4\xbd\x12\x25\xf7\x15\xda\xad\xa3\xf4\x05\xf8\x0b\x7e\x8b\x48\xed\x6c\xf8\x09\xd2\xdc\xd9\xde\x12\xcf\x22\x96\xdd\x69\xdb\xee\x59\xcb\xe0\x40\x74\x64\xe9\x2d\x9d\x63\xf2\x62\xf8\x24\xfd\x6e\xdd\xcd\x26\xd1\x93\x65\x51\x62\x98\xc7\xcc\xcd\x65\x7f\x81\x9e\xe4\xbb\x7e\xcb\x35\xee\xef\x01\x00\xc5\x76\x87\xcf\xe9\x0b\x00\x00")
+
+func complySoc2TemplatesTrustCenterIndexAceBytes() ([]byte, error) {
+	return bindataRead(
+		_complySoc2TemplatesTrustCenterIndexAce,
+		"comply-soc2/templates/trust-center/index.ace",
+	)
+}
+
+func complySoc2TemplatesTrustCenterIndexAce() (*asset, error) {
+	bytes, err := complySoc2TemplatesTrustCenterIndexAceBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "comply-soc2/templates/trust-center/index.ace", size: 3049, mode: os.FileMode(420), modTime: time.Unix(1792328676, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -1318,63 +1359,65 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"comply-blank/README.md":                   complyBlankReadmeMd,
-	"comply-blank/TODO.md":                     complyBlankTodoMd,
-	"comply-blank/narratives/.gitkeep":         complyBlankNarrativesGitkeep,
-	"comply-blank/policies/.gitkeep":           complyBlankPoliciesGitkeep,
-	"comply-blank/procedures/.gitkeep":         complyBlankProceduresGitkeep,
-	"comply-blank/standards/.gitkeep":          complyBlankStandardsGitkeep,
-	"comply-blank/templates/.gitkeep":          complyBlankTemplatesGitkeep,
-	"comply-blank/templates/default.latex":     complyBlankTemplatesDefaultLatex,
-	"comply-blank/templates/index.ace":         complyBlankTemplatesIndexAce,
-	"comply-soc2/README.md":                    complySoc2ReadmeMd,
-	"comply-soc2/TODO.md":                      complySoc2TodoMd,
-	"comply-soc2/narratives/README.md":         complySoc2NarrativesReadmeMd,
-	"comply-soc2/narratives/control.md":        complySoc2NarrativesControlMd,
-	"comply-soc2/narratives/organizational.md": complySoc2NarrativesOrganizationalMd,
-	"comply-soc2/narratives/products.md":       complySoc2NarrativesProductsMd,
-	"comply-soc2/narratives/security.md":       complySoc2NarrativesSecurityMd,
-	"comply-soc2/narratives/system.md":         complySoc2NarrativesSystemMd,
-	"comply-soc2/policies/README.md":           complySoc2PoliciesReadmeMd,
-	"comply-soc2/policies/access.md":           complySoc2PoliciesAccessMd,
-	"comply-soc2/policies/application.md":      complySoc2PoliciesApplicationMd,
-	"comply-soc2/policies/asset.md":            complySoc2PoliciesAssetMd,
-	"comply-soc2/policies/availability.md":     complySoc2PoliciesAvailabilityMd,
-	"comply-soc2/policies/change.md":           complySoc2PoliciesChangeMd,
-	"comply-soc2/policies/classification.md":   complySoc2PoliciesClassificationMd,
-	"comply-soc2/policies/conduct.md":          complySoc2PoliciesConductMd,
-	"comply-soc2/policies/confidentiality.md":  complySoc2PoliciesConfidentialityMd,
-	"comply-soc2/policies/continuity.md":       complySoc2PoliciesContinuityMd,
-	"comply-soc2/policies/cyber.md":            complySoc2PoliciesCyberMd,
-	"comply-soc2/policies/datacenter.md":       complySoc2PoliciesDatacenterMd,
-	"comply-soc2/policies/development.md":      complySoc2PoliciesDevelopmentMd,
-	"comply-soc2/policies/disaster.md":         complySoc2PoliciesDisasterMd,
-	"comply-soc2/policies/encryption.md":       complySoc2PoliciesEncryptionMd,
-	"comply-soc2/policies/incident.md":         complySoc2PoliciesIncidentMd,
-	"comply-soc2/policies/information.md":      complySoc2PoliciesInformationMd,
-	"comply-soc2/policies/log.md":              complySoc2PoliciesLogMd,
-	"comply-soc2/policies/media.md":            complySoc2PoliciesMediaMd,
-	"comply-soc2/policies/office.md":           complySoc2PoliciesOfficeMd,
-	"comply-soc2/policies/password.md":         complySoc2PoliciesPasswordMd,
-	"comply-soc2/policies/patch.md":            complySoc2PoliciesPatchMd,
-	"comply-soc2/policies/policy.md":           complySoc2PoliciesPolicyMd,
-	"comply-soc2/policies/privacy.md":          complySoc2PoliciesPrivacyMd,
-	"comply-soc2/policies/processing.md":       complySoc2PoliciesProcessingMd,
-	"comply-soc2/policies/remote.md":           complySoc2PoliciesRemoteMd,
-	"comply-soc2/policies/retention.md":        complySoc2PoliciesRetentionMd,
-	"comply-soc2/policies/risk.md":             complySoc2PoliciesRiskMd,
-	"comply-soc2/policies/vendor.md":           complySoc2PoliciesVendorMd,
-	"comply-soc2/policies/workstation.md":      complySoc2PoliciesWorkstationMd,
-	"comply-soc2/procedures/README.md":         complySoc2ProceduresReadmeMd,
-	"comply-soc2/procedures/offboarding.md":    complySoc2ProceduresOffboardingMd,
-	"comply-soc2/procedures/onboarding.md":     complySoc2ProceduresOnboardingMd,
-	"comply-soc2/procedures/patch.md":          complySoc2ProceduresPatchMd,
-	"comply-soc2/procedures/workstation.md":    complySoc2ProceduresWorkstationMd,
-	"comply-soc2/standards/README.md":          complySoc2StandardsReadmeMd,
-	"comply-soc2/standards/TSC-2017.yml":       complySoc2StandardsTsc2017Yml,
-	"comply-soc2/standards/TSC-2022.yml":       complySoc2StandardsTsc2022Yml,
-	"comply-soc2/templates/default.latex":      complySoc2TemplatesDefaultLatex,
-	"comply-soc2/templates/index.ace":          complySoc2TemplatesIndexAce,
+	"comply-blank/README.md":                        complyBlankReadmeMd,
+	"comply-blank/TODO.md":                          complyBlankTodoMd,
+	"comply-blank/narratives/.gitkeep":              complyBlankNarrativesGitkeep,
+	"comply-blank/policies/.gitkeep":                complyBlankPoliciesGitkeep,
+	"comply-blank/procedures/.gitkeep":              complyBlankProceduresGitkeep,
+	"comply-blank/standards/.gitkeep":               complyBlankStandardsGitkeep,
+	"comply-blank/templates/.gitkeep":               complyBlankTemplatesGitkeep,
+	"comply-blank/templates/default.latex":          complyBlankTemplatesDefaultLatex,
+	"comply-blank/templates/index.ace":              complyBlankTemplatesIndexAce,
+	"comply-blank/templates/trust-center/index.ace": complyBlankTemplatesTrustCenterIndexAce,
+	"comply-soc2/README.md":                         complySoc2ReadmeMd,
+	"comply-soc2/TODO.md":                           complySoc2TodoMd,
+	"comply-soc2/narratives/README.md":              complySoc2NarrativesReadmeMd,
+	"comply-soc2/narratives/control.md":             complySoc2NarrativesControlMd,
+	"comply-soc2/narratives/organizational.md":      complySoc2NarrativesOrganizationalMd,
+	"comply-soc2/narratives/products.md":            complySoc2NarrativesProductsMd,
+	"comply-soc2/narratives/security.md":            complySoc2NarrativesSecurityMd,
+	"comply-soc2/narratives/system.md":              complySoc2NarrativesSystemMd,
+	"comply-soc2/policies/README.md":                complySoc2PoliciesReadmeMd,
+	"comply-soc2/policies/access.md":                complySoc2PoliciesAccessMd,
+	"comply-soc2/policies/application.md":           complySoc2PoliciesApplicationMd,
+	"comply-soc2/policies/asset.md":                 complySoc2PoliciesAssetMd,
+	"comply-soc2/policies/availability.md":          complySoc2PoliciesAvailabilityMd,
+	"comply-soc2/policies/change.md":                complySoc2PoliciesChangeMd,
+	"comply-soc2/policies/classification.md":        complySoc2PoliciesClassificationMd,
+	"comply-soc2/policies/conduct.md":               complySoc2PoliciesConductMd,
+	"comply-soc2/policies/confidentiality.md":       complySoc2PoliciesConfidentialityMd,
+	"comply-soc2/policies/continuity.md":            complySoc2PoliciesContinuityMd,
+	"comply-soc2/policies/cyber.md":                 complySoc2PoliciesCyberMd,
+	"comply-soc2/policies/datacenter.md":            complySoc2PoliciesDatacenterMd,
+	"comply-soc2/policies/development.md":           complySoc2PoliciesDevelopmentMd,
+	"comply-soc2/policies/disaster.md":              complySoc2PoliciesDisasterMd,
+	"comply-soc2/policies/encryption.md":            complySoc2PoliciesEncryptionMd,
+	"comply-soc2/policies/incident.md":              complySoc2PoliciesIncidentMd,
+	"comply-soc2/policies/information.md":           complySoc2PoliciesInformationMd,
+	"comply-soc2/policies/log.md":                   complySoc2PoliciesLogMd,
+	"comply-soc2/policies/media.md":                 complySoc2PoliciesMediaMd,
+	"comply-soc2/policies/office.md":                complySoc2PoliciesOfficeMd,
+	"comply-soc2/policies/password.md":              complySoc2PoliciesPasswordMd,
+	"comply-soc2/policies/patch.md":                 complySoc2PoliciesPatchMd,
+	"comply-soc2/policies/policy.md":                complySoc2PoliciesPolicyMd,
+	"comply-soc2/policies/privacy.md":               complySoc2PoliciesPrivacyMd,
+	"comply-soc2/policies/processing.md":            complySoc2PoliciesProcessingMd,
+	"comply-soc2/policies/remote.md":                complySoc2PoliciesRemoteMd,
+	"comply-soc2/policies/retention.md":             complySoc2PoliciesRetentionMd,
+	"comply-soc2/policies/risk.md":                  complySoc2PoliciesRiskMd,
+	"comply-soc2/policies/vendor.md":                complySoc2PoliciesVendorMd,
+	"comply-soc2/policies/workstation.md":           complySoc2PoliciesWorkstationMd,
+	"comply-soc2/procedures/README.md":              complySoc2ProceduresReadmeMd,
+	"comply-soc2/procedures/offboarding.md":         complySoc2ProceduresOffboardingMd,
+	"comply-soc2/procedures/onboarding.md":          complySoc2ProceduresOnboardingMd,
+	"comply-soc2/procedures/patch.md":               complySoc2ProceduresPatchMd,
+	"comply-soc2/procedures/workstation.md":         complySoc2ProceduresWorkstationMd,
+	"comply-soc2/standards/README.md":               complySoc2StandardsReadmeMd,
+	"comply-soc2/standards/TSC-2017.yml":            complySoc2StandardsTsc2017Yml,
+	"comply-soc2/standards/TSC-2022.yml":            complySoc2StandardsTsc2022Yml,
+	"comply-soc2/templates/default.latex":           complySoc2TemplatesDefaultLatex,
+	"comply-soc2/templates/index.ace":               complySoc2TemplatesIndexAce,
+	"comply-soc2/templates/trust-center/index.ace":  complySoc2TemplatesTrustCenterIndexAce,
 }
 
 // AssetDir returns the file names below a certain
@@ -1439,6 +1482,9 @@ var _bintree = &bintree{nil, map[string]*bintree{
 			".gitkeep":      &bintree{complyBlankTemplatesGitkeep, map[string]*bintree{}},
 			"default.latex": &bintree{complyBlankTemplatesDefaultLatex, map[string]*bintree{}},
 			"index.ace":     &bintree{complyBlankTemplatesIndexAce, map[string]*bintree{}},
+			"trust-center": &bintree{nil, map[string]*bintree{
+				"index.ace": &bintree{complyBlankTemplatesTrustCenterIndexAce, map[string]*bintree{}},
+			}},
 		}},
 	}},
 	"comply-soc2": &bintree{nil, map[string]*bintree{
@@ -1499,6 +1545,9 @@ var _bintree = &bintree{nil, map[string]*bintree{
 		"templates": &bintree{nil, map[string]*bintree{
 			"default.latex": &bintree{complySoc2TemplatesDefaultLatex, map[string]*bintree{}},
 			"index.ace":     &bintree{complySoc2TemplatesIndexAce, map[string]*bintree{}},
+			"trust-center": &bintree{nil, map[string]*bintree{
+				"index.ace": &bintree{complySoc2TemplatesTrustCenterIndexAce, map[string]*bintree{}},
+			}},
 		}},
 	}},
 }}
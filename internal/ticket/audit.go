@@ -0,0 +1,85 @@
+package ticket
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+// AuditImport records how an auditor request was imported.
+type AuditImport struct {
+	Request *model.AuditRequest
+	Ticket  *model.Ticket
+	// Existing is set when the request already had a ticket, which is left untouched
+	Existing bool
+}
+
+// ImportAudit opens a ticket labelled comply-audit in the given ticket system,
+// or the default one, for every auditor request that does not have one yet.
+// Importing a revised list therefore only adds the new requests. With dryRun
+// set, nothing is created.
+func ImportAudit(requests []*model.AuditRequest, system string, dryRun bool) ([]*AuditImport, error) {
+	ts, err := config.Config().TicketSystemFor(system)
+	if err != nil {
+		return nil, err
+	}
+	tp := model.GetPlugin(model.TicketSystem(ts))
+
+	var imports []*AuditImport
+	for _, req := range requests {
+		// the cache may lag the ticket system; ask it directly before opening a ticket
+		existing, err := tp.FindByTag(model.AuditRequestTag, req.ID)
+		if err != nil {
+			return imports, errors.Wrapf(err, "unable to look up audit request %s", req.ID)
+		}
+		if len(existing) > 0 {
+			t := existing[0]
+			t.Source = ts
+			imports = append(imports, &AuditImport{Request: req, Ticket: t, Existing: true})
+			continue
+		}
+
+		t := auditTicket(req)
+		if !dryRun {
+			labels := []string{"comply", model.AuditTag, model.TagFor(model.AuditRequestTag, req.ID)}
+			err = tp.Create(t, labels)
+			if err != nil {
+				return imports, errors.Wrapf(err, "unable to create a ticket for audit request %s", req.ID)
+			}
+			t.Source = ts
+			if t.Attributes == nil {
+				t.Attributes = make(map[string]interface{})
+			}
+			// label the cached ticket as the next sync will
+			for _, l := range labels {
+				t.SetLabel(l)
+			}
+			err = cache(t)
+			if err != nil {
+				return imports, err
+			}
+		}
+		imports = append(imports, &AuditImport{Request: req, Ticket: t})
+	}
+	return imports, nil
+}
+
+func auditTicket(req *model.AuditRequest) *model.Ticket {
+	body := []string{req.Description, "", "", "---", "Audit-Request: " + req.ID}
+	if len(req.Controls) > 0 {
+		body = append(body, "Controls: "+strings.Join(req.Controls, ", "))
+	}
+	if req.DueAt != nil {
+		body = append(body, "Due: "+req.DueAt.Format("2006-01-02"))
+	}
+	return &model.Ticket{
+		Name:       fmt.Sprintf("Audit request %s: %s", req.ID, req.Title),
+		Body:       strings.TrimLeft(strings.Join(body, "\n"), "\n"),
+		Assignee:   req.Assignee,
+		DueAt:      req.DueAt,
+		Attributes: make(map[string]interface{}),
+	}
+}
@@ -0,0 +1,59 @@
+package ticket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestImportAudit(t *testing.T) {
+	_, cleanup := testProject(t, "id: patch\nname: Apply Patches\n")
+	defer cleanup()
+
+	due := time.Now().Add(-time.Hour)
+	requests := []*model.AuditRequest{
+		{ID: "1", Title: "Access reviews", Controls: []string{"CC6.1"}, DueAt: &due},
+		{ID: "2", Title: "Terminations"},
+	}
+
+	imports, err := ImportAudit(requests, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tickets, _ := model.ReadTickets(); len(imports) != 2 || len(tickets) != 0 {
+		t.Fatalf("expected a dry run to plan 2 tickets and create none, got %d and %d", len(imports), len(tickets))
+	}
+
+	for i := 0; i < 2; i++ {
+		imports, err = ImportAudit(requests, "", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, i := range imports {
+		if !i.Existing {
+			t.Errorf("request %s was imported twice", i.Request.ID)
+		}
+	}
+
+	err = Sync(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tickets, err := model.ReadTickets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 2 {
+		t.Fatalf("expected 2 audit tickets, got %d", len(tickets))
+	}
+	for _, ticket := range tickets {
+		if !ticket.Audit() || ticket.AuditRequest() == "" {
+			t.Errorf("ticket %s is not labelled as an audit request", ticket.Key())
+		}
+		if ticket.AuditRequest() == "1" && (!ticket.Overdue(time.Now()) || len(ticket.AuditControls()) != 1) {
+			t.Errorf("expected request 1 to be overdue and list its control, got %+v", ticket)
+		}
+	}
+}
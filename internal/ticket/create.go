@@ -0,0 +1,50 @@
+package ticket
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+// Create opens a ticket for a procedure in the procedure's ticket system,
+// returning the created ticket and the plugin that holds it.
+func Create(procedure *model.Procedure) (*model.Ticket, model.TicketPlugin, error) {
+	return create(procedure, "")
+}
+
+// create opens a procedure ticket, recording the scheduled occurrence it belongs to, if any.
+func create(procedure *model.Procedure, occurrence string) (*model.Ticket, model.TicketPlugin, error) {
+	ts, err := config.Config().TicketSystemFor(procedure.TicketSystem)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "procedure %s", procedure.ID)
+	}
+
+	dueAt, err := procedure.DueAt(time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tp := model.GetPlugin(model.TicketSystem(ts))
+	t := &model.Ticket{
+		Name:      procedure.Name,
+		Body:      fmt.Sprintf("%s\n\n\n---\nProcedure-ID: %s\n\n%s", procedure.Body, procedure.ID, model.ProcedureTrailer(procedure.ID)),
+		Procedure: procedure.ID,
+		Assignee:  procedure.Assignee,
+		Priority:  procedure.Priority,
+		DueAt:     dueAt,
+	}
+	if occurrence != "" {
+		t.Body += "\n" + model.OccurrenceTrailer(occurrence)
+	}
+	labels := append([]string{"comply", model.ProcedureTag, model.TagFor(model.ProcedureTag, procedure.ID)}, procedure.Labels...)
+	err = tp.Create(t, labels)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.Source = ts
+	t.Procedure = procedure.ID
+	return t, tp, nil
+}
@@ -0,0 +1,238 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gohugoio/hugo/watcher"
+	"github.com/robfig/cron"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+// DaemonOptions configures RunDaemon.
+type DaemonOptions struct {
+	// Listen is the address serving /healthz, /status and /hooks/
+	Listen string
+	// SyncInterval is how often tickets are re-synced between scheduled runs
+	SyncInterval time.Duration
+	// Sync refreshes the ticket cache
+	Sync func() error
+}
+
+// DaemonStatus is served by the daemon's /status endpoint.
+type DaemonStatus struct {
+	StartedAt     time.Time  `json:"startedAt"`
+	LoadedAt      time.Time  `json:"proceduresLoadedAt"`
+	Scheduled     int        `json:"scheduledProcedures"`
+	LastSync      *time.Time `json:"lastSync,omitempty"`
+	LastSyncError string     `json:"lastSyncError,omitempty"`
+	LastRun       *time.Time `json:"lastRun,omitempty"`
+	LastRunError  string     `json:"lastRunError,omitempty"`
+	Plans         []*Plan    `json:"plans"`
+}
+
+type daemon struct {
+	opts DaemonOptions
+
+	// work serializes syncs, runs and reloads
+	work sync.Mutex
+	cron *cron.Cron
+
+	mu     sync.Mutex
+	status DaemonStatus
+}
+
+// RunDaemon keeps running until interrupted, triggering procedures as their
+// schedules come due, re-syncing tickets every SyncInterval and reloading
+// procedures when they change.
+func RunDaemon(opts DaemonOptions) error {
+	lock, err := AcquireLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	d := &daemon{opts: opts}
+	d.status.StartedAt = time.Now().UTC()
+
+	d.runScheduled()
+	err = d.reload()
+	if err != nil {
+		return err
+	}
+	defer func() { d.cron.Stop() }()
+
+	w, err := watcher.New(500*time.Millisecond, time.Second, false)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	w.Add(filepath.Join(config.ProjectRoot(), "procedures"))
+	w.Add(filepath.Join(config.ProjectRoot(), "comply.yml"))
+
+	srv := &http.Server{Addr: opts.Listen, Handler: d.handler()}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	defer srv.Shutdown(context.Background())
+
+	log.Printf("scheduler running; status at http://%s/status (ctrl-c to quit)", opts.Listen)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	syncTicker := time.NewTicker(opts.SyncInterval)
+	defer syncTicker.Stop()
+
+	for {
+		select {
+		case <-sigCh:
+			log.Printf("scheduler stopping")
+			return nil
+		case err := <-errCh:
+			return err
+		case <-lock.Lost():
+			// wait for a run in progress, which stops at its next ticket
+			d.work.Lock()
+			defer d.work.Unlock()
+			return lock.Held()
+		case err := <-w.Errors():
+			log.Printf("watching procedures: %v", err)
+		case <-w.Events:
+			err := d.reload()
+			if err != nil {
+				log.Printf("reloading procedures: %v", err)
+			}
+		case <-syncTicker.C:
+			d.work.Lock()
+			d.sync()
+			d.work.Unlock()
+		}
+	}
+}
+
+// reload rebuilds the in-process cron from the procedures on disk.
+func (d *daemon) reload() error {
+	d.work.Lock()
+	defer d.work.Unlock()
+
+	procedures, err := model.ReadProcedures()
+	if err != nil {
+		return err
+	}
+
+	c := cron.New()
+	scheduled := 0
+	for _, procedure := range procedures {
+		if procedure.Cron == "" {
+			continue
+		}
+		schedule, err := procedure.Schedule()
+		if err != nil {
+			log.Printf("skipping %v", err)
+			continue
+		}
+		// every firing evaluates all procedures; TriggerScheduled only acts on due occurrences
+		c.Schedule(schedule, cron.FuncJob(d.runScheduled))
+		scheduled++
+	}
+
+	if d.cron != nil {
+		d.cron.Stop()
+	}
+	d.cron = c
+	d.cron.Start()
+
+	d.mu.Lock()
+	d.status.LoadedAt = time.Now().UTC()
+	d.status.Scheduled = scheduled
+	d.mu.Unlock()
+	log.Printf("loaded %d scheduled procedures", scheduled)
+	return nil
+}
+
+func (d *daemon) runScheduled() {
+	d.work.Lock()
+	defer d.work.Unlock()
+
+	if !d.sync() {
+		// deciding on a stale cache risks duplicate tickets; wait for the next firing
+		return
+	}
+	err := TriggerScheduled()
+	now := time.Now().UTC()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status.LastRun = &now
+	d.status.LastRunError = ""
+	if err != nil {
+		d.status.LastRunError = err.Error()
+		log.Printf("scheduler run failed: %v", err)
+	}
+}
+
+// sync refreshes the ticket cache, reporting success; callers hold d.work.
+func (d *daemon) sync() bool {
+	err := d.opts.Sync()
+	now := time.Now().UTC()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status.LastSync = &now
+	d.status.LastSyncError = ""
+	if err != nil {
+		d.status.LastSyncError = err.Error()
+		log.Printf("sync failed: %v", err)
+		return false
+	}
+	return true
+}
+
+func (d *daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		msg := d.status.LastSyncError
+		if msg == "" {
+			msg = d.status.LastRunError
+		}
+		d.mu.Unlock()
+
+		if msg != "" {
+			http.Error(w, msg, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+	mux.Handle("/hooks/", WebhookHandler())
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		plans, err := PlanScheduled(time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		d.mu.Lock()
+		status := d.status
+		d.mu.Unlock()
+		status.Plans = plans
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(status)
+	})
+	return mux
+}
@@ -0,0 +1,165 @@
+package ticket
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/model"
+)
+
+// Harvest archives a closed procedure ticket's body, comments, attachments and
+// closing user under .comply/evidence/<procedure-id>/<ticket>/, so the evidence
+// outlives the ticket system. Tickets are archived once; Harvest reports
+// whether it wrote an archive. Only tickets of the project's procedures are
+// archived.
+func Harvest(t *model.Ticket, tp model.TicketPlugin) (bool, error) {
+	procedureID := t.ProcedureID()
+	if t.State != model.Closed || procedureID == "" {
+		return false, nil
+	}
+	evidencePath, err := model.EvidencePath(procedureID, t)
+	if err != nil {
+		return false, err
+	}
+	if model.HasEvidence(procedureID, t) {
+		return false, nil
+	}
+	ef, ok := tp.(model.EvidenceFetcher)
+	if !ok {
+		return false, nil
+	}
+	known, err := knownProcedure(procedureID)
+	if err != nil || !known {
+		return false, err
+	}
+
+	ev, err := ef.Evidence(t.ID)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to fetch evidence for ticket %s", t.ID)
+	}
+
+	// assemble the archive beside its final location so a failed harvest leaves no manifest behind
+	dir := filepath.Join(model.EvidenceRoot(), evidencePath)
+	tmp := dir + ".partial"
+	err = os.RemoveAll(tmp)
+	if err == nil {
+		err = os.MkdirAll(tmp, os.FileMode(0755))
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "could not create evidence directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	body := fmt.Sprintf("# %s\n\n%s\n", t.Name, strings.TrimSpace(t.Body))
+	err = ioutil.WriteFile(filepath.Join(tmp, "ticket.md"), []byte(body), os.FileMode(0644))
+	if err != nil {
+		return false, errors.Wrap(err, "unable to write ticket body")
+	}
+
+	m := &model.EvidenceManifest{
+		Procedure:   procedureID,
+		TicketID:    t.ID,
+		Source:      t.Source,
+		Name:        t.Name,
+		Link:        t.Link,
+		CreatedAt:   t.CreatedAt,
+		ClosedAt:    t.ClosedAt,
+		ClosedBy:    ev.ClosedBy,
+		HarvestedAt: time.Now().UTC(),
+		// archives list no comments or attachments as [] rather than null
+		Comments:    append([]*model.Comment{}, ev.Comments...),
+		Attachments: []*model.ArchivedAttachment{},
+	}
+
+	used := make(map[string]bool)
+	for _, a := range ev.Attachments {
+		archived, err := archiveAttachment(ef, a, tmp, used)
+		if err != nil {
+			return false, errors.Wrapf(err, "unable to archive attachment %s of ticket %s", a.Name, t.ID)
+		}
+		m.Attachments = append(m.Attachments, archived)
+	}
+
+	err = model.SaveEvidenceManifest(tmp, m)
+	if err != nil {
+		return false, err
+	}
+	err = os.RemoveAll(dir)
+	if err == nil {
+		err = os.Rename(tmp, dir)
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "unable to finalize evidence archive")
+	}
+	return true, nil
+}
+
+// knownProcedure reports whether procedures/ declares a procedure.
+func knownProcedure(procedureID string) (bool, error) {
+	procedures, err := model.ReadProcedures()
+	if err != nil {
+		return false, err
+	}
+	for _, p := range procedures {
+		if p.ID == procedureID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func archiveAttachment(ef model.EvidenceFetcher, a *model.Attachment, dir string, used map[string]bool) (*model.ArchivedAttachment, error) {
+	name := attachmentFilename(a.Name)
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%d-%s", i, attachmentFilename(a.Name))
+	}
+	used[name] = true
+	file := path.Join("attachments", name)
+
+	err := os.MkdirAll(filepath.Join(dir, "attachments"), os.FileMode(0755))
+	if err != nil {
+		return nil, err
+	}
+	rc, err := ef.Download(a)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(filepath.Join(dir, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, h), rc)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ArchivedAttachment{
+		Name:   a.Name,
+		File:   file,
+		URL:    a.URL,
+		Size:   size,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// attachmentFilename keeps attachment names from escaping the archive.
+func attachmentFilename(name string) string {
+	name = path.Base(strings.Replace(name, "\\", "/", -1))
+	if name == "." || name == "/" || name == ".." || name == "" {
+		return "attachment"
+	}
+	return name
+}
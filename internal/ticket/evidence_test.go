@@ -0,0 +1,119 @@
+package ticket
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestHarvest(t *testing.T) {
+	dir, cleanup := testProject(t, "id: patch\nname: Apply Patches\n")
+	defer cleanup()
+
+	procedures, err := model.ReadProcedures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, tp, err := Create(procedures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tu := tp.(model.TicketUpdater)
+	err = tu.Comment("1", "Patched all hosts.")
+	if err == nil {
+		err = os.MkdirAll(filepath.Join(dir, "tickets", "1"), 0755)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "tickets", "1", "hosts.txt"), []byte("web-1\n"), 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticket, err := tp.Get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archived, err := Harvest(ticket, tp)
+	if err != nil || archived {
+		t.Fatalf("open tickets must not be archived (archived=%v, err=%v)", archived, err)
+	}
+
+	err = tu.Close("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticket, err = tp.Get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archived, err = Harvest(ticket, tp)
+	if err != nil || !archived {
+		t.Fatalf("expected closed ticket to be archived (archived=%v, err=%v)", archived, err)
+	}
+	archived, err = Harvest(ticket, tp)
+	if err != nil || archived {
+		t.Fatalf("expected ticket to be archived once (archived=%v, err=%v)", archived, err)
+	}
+
+	manifests, err := model.ReadEvidence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := manifests["patch/"+ticket.Key()]
+	if m == nil {
+		t.Fatalf("missing manifest, found %v", manifests)
+	}
+	if len(m.Comments) != 1 || m.Comments[0].Body != "Patched all hosts." || m.Comments[0].CreatedAt == nil {
+		t.Fatalf("unexpected comments %+v", m.Comments)
+	}
+	if len(m.Attachments) != 1 || m.Attachments[0].File != "attachments/hosts.txt" || m.Attachments[0].Size != 6 {
+		t.Fatalf("unexpected attachments %+v", m.Attachments)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(model.EvidenceRoot(), "patch", ticket.Key(), "attachments", "hosts.txt"))
+	if err != nil || string(b) != "web-1\n" {
+		t.Fatalf("attachment not archived: %q, %v", b, err)
+	}
+}
+
+func TestAttachmentFilename(t *testing.T) {
+	for name, want := range map[string]string{
+		"report.pdf":        "report.pdf",
+		"../../etc/passwd":  "passwd",
+		`C:\Users\scan.png`: "scan.png",
+		"..":                "attachment",
+		"":                  "attachment",
+	} {
+		if got := attachmentFilename(name); got != want {
+			t.Errorf("attachmentFilename(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestHarvestUntrustedProcedure(t *testing.T) {
+	dir, cleanup := testProject(t, "id: patch\nname: Apply Patches\n")
+	defer cleanup()
+	tp := model.GetPlugin(model.Local)
+
+	// anyone able to edit a ticket controls the procedure recorded in its body
+	for _, procedureID := range []string{"../../..", "..", `patch\..\..`} {
+		ticket := &model.Ticket{ID: "1", Source: "local", State: model.Closed, Body: "<!-- comply-procedure: " + procedureID + " -->"}
+		archived, err := Harvest(ticket, tp)
+		if err == nil || archived {
+			t.Errorf("%s: expected the ticket to be rejected (archived=%v, err=%v)", procedureID, archived, err)
+		}
+	}
+
+	ticket := &model.Ticket{ID: "1", Source: "local", State: model.Closed, Body: "<!-- comply-procedure: unknown -->"}
+	archived, err := Harvest(ticket, tp)
+	if err != nil || archived {
+		t.Errorf("expected tickets of unknown procedures to be skipped (archived=%v, err=%v)", archived, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".comply", "evidence", "unknown")); !os.IsNotExist(err) {
+		t.Errorf("expected no evidence for an unknown procedure, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package ticket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+)
+
+const (
+	// lockHeartbeat is how often a lock holder refreshes the lock file
+	lockHeartbeat = 30 * time.Second
+	// lockStaleAfter is how long an unrefreshed lock is honored; process IDs
+	// are meaningless across containers, so liveness is judged by the heartbeat
+	lockStaleAfter = 4 * lockHeartbeat
+)
+
+// Lock keeps two comply processes sharing a project from creating tickets at once.
+type Lock struct {
+	path  string
+	token string
+	stop  chan struct{}
+	done  chan struct{}
+	lost  chan struct{}
+}
+
+var (
+	heldMu sync.Mutex
+	held   *Lock
+)
+
+func lockPath() string {
+	return filepath.Join(config.ProjectRoot(), ".comply", "scheduler.lock")
+}
+
+// AcquireLock takes the scheduler lock, failing if another live process holds it.
+func AcquireLock() (*Lock, error) {
+	path := lockPath()
+	err := os.MkdirAll(filepath.Dir(path), os.FileMode(0755))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create directory .comply")
+	}
+
+	l := &Lock{
+		path:  path,
+		token: newLockToken(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+		lost:  make(chan struct{}),
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.FileMode(0644))
+	if os.IsExist(err) {
+		info, statErr := os.Stat(path)
+		if statErr == nil && time.Since(info.ModTime()) < lockStaleAfter {
+			return nil, fmt.Errorf("another comply scheduler holds %s (%s)", path, lockHolder(path))
+		}
+		// the previous holder stopped refreshing the lock; take it over
+		_ = os.Remove(path)
+		f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.FileMode(0644))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to acquire %s", path)
+	}
+	host, _ := os.Hostname()
+	fmt.Fprintf(f, "%s\npid %d on %s since %s", l.token, os.Getpid(), host, time.Now().UTC().Format(time.RFC3339))
+	f.Close()
+
+	// two processes taking over the same stale lock can each remove the
+	// other's file; only the one whose token is on disk holds the lock
+	if err = l.Held(); err != nil {
+		return nil, err
+	}
+
+	heldMu.Lock()
+	held = l
+	heldMu.Unlock()
+	go l.heartbeat()
+	return l, nil
+}
+
+func newLockToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// lockHolder describes the process holding the lock at path.
+func lockHolder(path string) string {
+	b, _ := ioutil.ReadFile(path)
+	content := string(b)
+	if i := strings.Index(content, "\n"); i >= 0 {
+		content = content[i+1:]
+	}
+	return content
+}
+
+// Held reports an error unless the lock file still carries this lock's token.
+func (l *Lock) Held() error {
+	b, err := ioutil.ReadFile(l.path)
+	if err == nil && strings.SplitN(string(b), "\n", 2)[0] == l.token {
+		return nil
+	}
+	return fmt.Errorf("lost the scheduler lock %s to another process (%s)", l.path, lockHolder(l.path))
+}
+
+// Lost is closed when the heartbeat finds that another process took the lock
+// over; the holder must stop acting on tickets.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *Lock) heartbeat() {
+	defer close(l.done)
+	t := time.NewTicker(lockHeartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-t.C:
+			if err := l.Held(); err != nil {
+				log.Print(err)
+				close(l.lost)
+				return
+			}
+			_ = os.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+// checkLock reports an error if this process acquired the scheduler lock and
+// has since lost it.
+func checkLock() error {
+	heldMu.Lock()
+	l := held
+	heldMu.Unlock()
+	if l == nil {
+		return nil
+	}
+	return l.Held()
+}
+
+// Release gives up the lock, leaving it alone if another process has taken it over.
+func (l *Lock) Release() error {
+	close(l.stop)
+	<-l.done
+
+	heldMu.Lock()
+	if held == l {
+		held = nil
+	}
+	heldMu.Unlock()
+
+	if err := l.Held(); err != nil {
+		return err
+	}
+	err := os.Remove(l.path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to release %s", l.path)
+	}
+	return nil
+}
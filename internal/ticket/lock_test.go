@@ -0,0 +1,58 @@
+package ticket
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/strongdm/comply/internal/config"
+)
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetProjectRoot(dir)
+	defer config.SetProjectRoot("")
+
+	lock, err := AcquireLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = AcquireLock(); err == nil {
+		t.Fatal("expected a second scheduler to be refused the lock")
+	}
+	err = lock.Release()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err = AcquireLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a holder that stopped refreshing the lock no longer blocks others
+	stale := time.Now().Add(-2 * lockStaleAfter)
+	err = os.Chtimes(lockPath(), stale, stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taken, err := AcquireLock()
+	if err != nil {
+		t.Fatalf("expected a stale lock to be taken over: %v", err)
+	}
+	defer taken.Release()
+	if lock.Held() == nil {
+		t.Error("expected the previous holder to have lost the lock")
+	}
+	if lock.Release() == nil {
+		t.Error("expected releasing a lost lock to fail")
+	}
+	if err = taken.Held(); err != nil {
+		t.Errorf("releasing a lost lock must leave the new holder's alone: %v", err)
+	}
+}
@@ -3,120 +3,390 @@ package ticket
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/robfig/cron"
 	"github.com/strongdm/comply/internal/config"
 	"github.com/strongdm/comply/internal/model"
 )
 
-func byProcedureByTime(tickets []*model.Ticket) map[string][]*model.Ticket {
-	result := make(map[string][]*model.Ticket)
-	for _, t := range tickets {
-		procedureID := t.ProcedureID()
-		if procedureID == "" {
-			// missing procedure metadata; skip
-			continue
+// byTime orders tickets by creation time, oldest first.
+func byTime(tickets []*model.Ticket) []*model.Ticket {
+	sort.SliceStable(tickets, func(i, j int) bool {
+		if tickets[i].CreatedAt == nil || tickets[j].CreatedAt == nil {
+			return false
 		}
-		list, ok := result[procedureID]
-		if !ok {
-			list = make([]*model.Ticket, 0)
+		return tickets[i].CreatedAt.Before(*tickets[j].CreatedAt)
+	})
+	return tickets
+}
+
+// occurrenceRecord is cached once the scheduler has acted on an occurrence, so
+// later runs neither comment again nor open a ticket for it.
+type occurrenceRecord struct {
+	Procedure  string
+	Occurrence string
+	Action     string
+	Ticket     string
+	At         time.Time
+}
+
+// Scheduler actions reported by Plan.
+const (
+	ActionNone     = "none"
+	ActionCreate   = "create"
+	ActionComment  = config.OpenTicketComment
+	ActionEscalate = config.OpenTicketEscalate
+	ActionSkip     = config.OpenTicketSkip
+)
+
+// Plan describes what the scheduler does for a procedure and why.
+type Plan struct {
+	Procedure  string     `json:"procedure"`
+	Name       string     `json:"name"`
+	Cron       string     `json:"cron"`
+	Timezone   string     `json:"timezone,omitempty"`
+	LastTicket string     `json:"lastTicket,omitempty"`
+	LastState  string     `json:"lastState,omitempty"`
+	Occurrence string     `json:"occurrence,omitempty"`
+	DueAt      *time.Time `json:"dueAt,omitempty"`
+	NextDueAt  *time.Time `json:"nextDueAt,omitempty"`
+	Action     string     `json:"action"`
+	Reason     string     `json:"reason"`
+
+	procedure *model.Procedure
+	last      *model.Ticket
+}
+
+// PlanScheduled decides, without changing anything, what the scheduler would do
+// for every procedure at now, using the ticket cache.
+func PlanScheduled(now time.Time) ([]*Plan, error) {
+	return plan(now, model.ReadTicketsForProcedure)
+}
+
+// PlanScheduledFor is PlanScheduled for the given tickets rather than the
+// local cache, e.g. tickets returned by Fetch.
+func PlanScheduledFor(tickets []*model.Ticket, now time.Time) ([]*Plan, error) {
+	byProcedure := make(map[string][]*model.Ticket)
+	for _, t := range tickets {
+		if id := t.ProcedureID(); id != "" {
+			byProcedure[id] = append(byProcedure[id], t)
 		}
-		list = append(list, t)
-		sort.Slice(list, func(i, j int) bool {
-			if list[i].CreatedAt == nil || list[j].CreatedAt == nil {
-				return false
-			}
-			return list[i].CreatedAt.Before(*list[j].CreatedAt)
-		})
-		result[procedureID] = list
 	}
-	return result
+	return plan(now, func(procedureID string) ([]*model.Ticket, error) {
+		return byProcedure[procedureID], nil
+	})
 }
 
-func TriggerScheduled() error {
-	rawTickets, err := model.ReadTickets()
+func plan(now time.Time, ticketsFor func(procedureID string) ([]*model.Ticket, error)) ([]*Plan, error) {
+	procedures, err := model.ReadProcedures()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	tickets := byProcedureByTime(rawTickets)
-	procedures, err := model.ReadProcedures()
+
+	last := lastRun()
+	var plans []*Plan
+	for _, procedure := range procedures {
+		previous, err := ticketsFor(procedure.ID)
+		if err != nil {
+			return nil, err
+		}
+		procedurePlans, err := planFor(procedure, byTime(previous), now.UTC(), last)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, procedurePlans...)
+	}
+	// keep each procedure's occurrences in chronological order
+	sort.SliceStable(plans, func(i, j int) bool {
+		return plans[i].Procedure < plans[j].Procedure
+	})
+	return plans, nil
+}
+
+// TriggerScheduled opens a ticket for every procedure occurrence that has come
+// due and has not been handled yet. Each occurrence is handled at most once.
+func TriggerScheduled() error {
+	now := time.Now()
+	plans, err := PlanScheduled(now)
 	if err != nil {
 		return err
 	}
 
-	for _, procedure := range procedures {
-		if procedure.Cron == "" {
+	for _, plan := range plans {
+		if plan.Action == ActionNone {
 			continue
 		}
-
-		procedureID := procedure.ID
-		schedule, err := cron.Parse(procedure.Cron)
+		if err = checkLock(); err != nil {
+			return err
+		}
+		switch plan.Action {
+		case ActionCreate:
+			err = trigger(plan.procedure, plan.Occurrence)
+		default:
+			err = handleOpen(plan)
+		}
 		if err != nil {
-			continue
+			return err
 		}
-		ticketsForProc, ok := tickets[procedureID]
-		if ok {
-			// find most recent one
-			mostRecent := ticketsForProc[len(ticketsForProc)-1]
-			if mostRecent.CreatedAt == nil {
-				continue
-			}
+	}
+	return model.DB().Write("scheduler", "state", schedulerState{LastRun: now.UTC()})
+}
 
-			// would another have triggered since?
-			nextTrigger := schedule.Next(*mostRecent.CreatedAt).UTC()
-			if nextTrigger.After(time.Now().UTC()) {
-				// in the future, nothing to do
-				continue
-			}
-			err = trigger(procedure)
-			if err != nil {
-				return err
-			}
-		} else {
-			// don't go back further than 13 months
-			tooOld := time.Now().Add(-1 * time.Hour * 24 * (365 + 30))
-			// search back one day until triggers
-			triggeredAt := time.Now().Add(-24 * time.Hour).UTC()
-		SEARCH:
-			for {
-				if triggeredAt.Before(tooOld) {
-					break SEARCH
-				}
+// lookback bounds how far back missed occurrences are considered.
+const lookback = 24 * (365 + 30) * time.Hour
 
-				candidate := schedule.Next(triggeredAt)
-				// in the future? not far eonugh back yet.
-				if candidate.After(time.Now().UTC()) {
-					triggeredAt = triggeredAt.Add(-24 * time.Hour)
-					continue
-				}
+// maxCatchUp bounds the tickets opened for missed occurrences in one run.
+const maxCatchUp = 50
+
+// schedulerState is cached after every scheduler run.
+type schedulerState struct {
+	LastRun time.Time
+}
+
+func lastRun() *time.Time {
+	var state schedulerState
+	if model.DB().Read("scheduler", "state", &state) != nil || state.LastRun.IsZero() {
+		return nil
+	}
+	return &state.LastRun
+}
+
+func planFor(procedure *model.Procedure, previous []*model.Ticket, now time.Time, lastRun *time.Time) ([]*Plan, error) {
+	plan := &Plan{
+		Procedure: procedure.ID,
+		Name:      procedure.Name,
+		Cron:      procedure.Cron,
+		Action:    ActionNone,
+		procedure: procedure,
+	}
+	if len(previous) > 0 {
+		plan.last = previous[len(previous)-1]
+		plan.LastTicket = plan.last.ID
+		plan.LastState = string(plan.last.State)
+	}
+
+	if procedure.Cron == "" {
+		plan.Reason = "no schedule; tickets are created on demand"
+		return []*Plan{plan}, nil
+	}
+	schedule, err := procedure.Schedule()
+	if err != nil {
+		plan.Reason = err.Error()
+		return []*Plan{plan}, nil
+	}
+	plan.Timezone = schedule.Location().String()
+	if next := schedule.Next(now); !next.IsZero() {
+		plan.NextDueAt = &next
+	}
+	catchUp, err := config.Config().CatchUpPolicy(procedure.CatchUp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "procedure %s", procedure.ID)
+	}
+
+	since := now.Add(-lookback)
+	at, ok := schedule.Prev(now, since)
+	if !ok {
+		plan.Reason = "not due within the last 13 months"
+		return []*Plan{plan}, nil
+	}
 
-				// is in the past? then trigger.
-				err = trigger(procedure)
-				if err != nil {
-					return err
+	var plans []*Plan
+	if catchUp == config.CatchUpAll {
+		from := since
+		if plan.last != nil && plan.last.CreatedAt != nil && plan.last.CreatedAt.After(from) {
+			from = *plan.last.CreatedAt
+		}
+		for _, missed := range schedule.Between(from, at.Add(-time.Second), maxCatchUp) {
+			key := occurrenceKey(procedure.ID, schedule, missed)
+			if _, done := handled(key, previous); done {
+				continue
+			}
+			p := *plan
+			p.Occurrence = key
+			p.DueAt = &missed
+			p.Action = ActionCreate
+			p.Reason = fmt.Sprintf("%s was missed (catchUp: all)", key)
+			if plan.last != nil && plan.last.State == model.Open {
+				if err := applyOpenPolicy(&p); err != nil {
+					return nil, err
 				}
-				break SEARCH
 			}
+			plans = append(plans, &p)
 		}
+	}
+
+	key := occurrenceKey(procedure.ID, schedule, at)
+	plan.Occurrence = key
+	plan.DueAt = &at
+	plans = append(plans, plan)
+
+	if reason, done := handled(key, previous); done {
+		plan.Reason = reason
+		return plans, nil
+	}
+	if catchUp == config.CatchUpNone && lastRun == nil {
+		plan.Reason = "scheduler has not run yet (catchUp: none)"
+		return plans, nil
+	}
+	if catchUp == config.CatchUpNone && !at.After(*lastRun) {
+		plan.Reason = fmt.Sprintf("%s came due before the scheduler last ran (catchUp: none)", key)
+		return plans, nil
+	}
+
+	last := plan.last
+	if last == nil {
+		plan.Action = ActionCreate
+		plan.Reason = fmt.Sprintf("%s came due and no ticket exists yet", key)
+		return plans, nil
+	}
+	if last.CreatedAt == nil {
+		plan.Reason = fmt.Sprintf("ticket %s has no creation time", last.ID)
+		return plans, nil
+	}
+	if !last.CreatedAt.Before(at) {
+		// opened on demand, or before tickets carried their occurrence, since this one came due
+		plan.Reason = fmt.Sprintf("ticket %s was opened after %s came due", last.ID, key)
+		return plans, nil
+	}
+
+	if last.State == model.Open {
+		return plans, applyOpenPolicy(plan)
+	}
 
+	plan.Action = ActionCreate
+	plan.Reason = fmt.Sprintf("%s came due after ticket %s", key, last.ID)
+	return plans, nil
+}
+
+// applyOpenPolicy sets the plan's action for an occurrence that came due while
+// the procedure's last ticket is still open.
+func applyOpenPolicy(plan *Plan) error {
+	key, last := plan.Occurrence, plan.last
+	policy, err := config.Config().OpenTicketPolicy(plan.procedure.OpenTickets)
+	if err != nil {
+		return errors.Wrapf(err, "procedure %s", plan.Procedure)
+	}
+	if policy == config.OpenTicketCreate {
+		plan.Action = ActionCreate
+		plan.Reason = fmt.Sprintf("%s came due while ticket %s is still open (policy: create)", key, last.ID)
+		return nil
+	}
+	plan.Action = policy
+	plan.Reason = fmt.Sprintf("%s came due while ticket %s is still open", key, last.ID)
+	if policy != config.OpenTicketSkip {
+		tp, err := model.GetPluginFor(last)
+		if err != nil {
+			return err
+		}
+		if _, ok := tp.(model.TicketUpdater); !ok {
+			plan.Action = ActionSkip
+			plan.Reason += "; its ticket system does not support comments"
+		}
 	}
 	return nil
 }
 
-func trigger(procedure *model.Procedure) error {
-	fmt.Printf("triggering procedure %s (cron expression: %s)\n", procedure.Name, procedure.Cron)
+// handled reports whether an occurrence already has a ticket or was acted on, and why.
+func handled(key string, previous []*model.Ticket) (string, bool) {
+	for _, t := range previous {
+		if t.OccurrenceID() == key {
+			return fmt.Sprintf("ticket %s already covers %s", t.ID, key), true
+		}
+	}
+	var rec occurrenceRecord
+	if model.DB().Read("occurrences", key, &rec) == nil {
+		return fmt.Sprintf("%s already handled (%s)", key, rec.Action), true
+	}
+	return "", false
+}
+
+// occurrenceKey identifies a scheduled occurrence by procedure and period, e.g.
+// patch@2026-10 for a monthly procedure. The period's granularity follows the
+// schedule, and it is formatted in the schedule's time zone.
+func occurrenceKey(procedureID string, schedule *model.Schedule, at time.Time) string {
+	layout := "2006-01-02T15:04"
+	switch interval := schedule.Next(at).Sub(at); {
+	case interval >= 28*24*time.Hour:
+		layout = "2006-01"
+	case interval >= 24*time.Hour:
+		layout = "2006-01-02"
+	}
+	return procedureID + "@" + at.In(schedule.Location()).Format(layout)
+}
 
-	ts, err := config.Config().TicketSystem()
+// handleOpen applies the open ticket policy when an occurrence comes due while
+// the procedure's previous ticket is still open.
+func handleOpen(plan *Plan) error {
+	key, open := plan.Occurrence, plan.last
+	if plan.Action == ActionSkip {
+		fmt.Printf("skipping %s: %s\n", key, plan.Reason)
+		return record(plan.procedure, key, plan.Action, open)
+	}
+
+	tp, err := model.GetPluginFor(open)
+	if err != nil {
+		return err
+	}
+	comment := fmt.Sprintf("Procedure %s came due again (%s) while this ticket is still open.", plan.Name, key)
+	if plan.Action == ActionEscalate {
+		comment = "Escalation: " + comment
+		if sc := config.Config().Scheduler; sc != nil && sc.EscalateTo != "" {
+			comment = "@" + strings.TrimPrefix(sc.EscalateTo, "@") + " " + comment
+		}
+	}
+	// planFor only chooses comment or escalate for plugins that can update tickets
+	err = tp.(model.TicketUpdater).Comment(open.ID, comment)
 	if err != nil {
-		return errors.Wrap(err, "error in ticket system configuration")
+		return errors.Wrapf(err, "unable to %s on ticket %s", plan.Action, open.ID)
 	}
+	fmt.Printf("%s: ticket %s is still open (%s)\n", key, open.ID, plan.Action)
+	return record(plan.procedure, key, plan.Action, open)
+}
+
+func trigger(procedure *model.Procedure, key string) error {
+	ts, err := config.Config().TicketSystemFor(procedure.TicketSystem)
+	if err != nil {
+		return errors.Wrapf(err, "procedure %s", procedure.ID)
+	}
+
+	// the cache may lag the ticket system; ask it directly before opening a ticket
+	tickets, err := model.GetPlugin(model.TicketSystem(ts)).FindByTag(model.ProcedureTag, procedure.ID)
+	if err != nil {
+		return errors.Wrapf(err, "unable to look up %s", key)
+	}
+	for _, t := range tickets {
+		if t.OccurrenceID() != key {
+			continue
+		}
+		t.Source = ts
+		err = cache(t)
+		if err != nil {
+			return err
+		}
+		return record(procedure, key, "existing", t)
+	}
+
+	fmt.Printf("triggering procedure %s (%s, cron expression: %s)\n", procedure.Name, key, procedure.Cron)
+
+	t, _, err := create(procedure, key)
+	if err != nil {
+		return err
+	}
+	err = cache(t)
+	if err != nil {
+		return err
+	}
+	return record(procedure, key, "created", t)
+}
 
-	tp := model.GetPlugin(model.TicketSystem(ts))
-	err = tp.Create(&model.Ticket{
-		Name: procedure.Name,
-		Body: fmt.Sprintf("%s\n\n\n---\nProcedure-ID: %s", procedure.Body, procedure.ID),
-	}, []string{"comply", "comply-procedure"})
-	return err
+func record(procedure *model.Procedure, key, action string, t *model.Ticket) error {
+	return model.DB().Write("occurrences", key, occurrenceRecord{
+		Procedure:  procedure.ID,
+		Occurrence: key,
+		Action:     action,
+		Ticket:     t.Key(),
+		At:         time.Now().UTC(),
+	})
 }
@@ -0,0 +1,280 @@
+package ticket
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+	"github.com/strongdm/comply/internal/plugin/local"
+)
+
+func TestOccurrenceKey(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		expr, timezone, want string
+	}{
+		{"0 0 0 1 * *", "", "patch@2026-10"},
+		{"0 0 6 * * *", "", "patch@2026-10-18"},
+		{"0 0 * * * *", "", "patch@2026-10-18T09:00"},
+		{"0 0 * * MON", "", "patch@2026-10-12"},
+		{"@quarterly", "", "patch@2026-10"},
+		// the period is named in the procedure's time zone
+		{"0 0 1 * *", "Australia/Sydney", "patch@2026-10"},
+	} {
+		schedule, err := model.ParseSchedule(tc.expr, tc.timezone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at, ok := schedule.Prev(now, now.Add(-lookback))
+		if !ok {
+			t.Fatalf("%s: no occurrence found", tc.expr)
+		}
+		if got := occurrenceKey("patch", schedule, at); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+}
+
+var registerOnce sync.Once
+
+// testProject creates a project using local tickets with a single procedure.
+func testProject(t *testing.T, procedure string) (string, func()) {
+	dir, err := ioutil.TempDir("", "comply-scheduler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// procedures are enumerated relative to the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup := func() {
+		os.Chdir(wd)
+		config.SetProjectRoot("")
+		os.RemoveAll(dir)
+	}
+	config.SetProjectRoot(dir)
+	err = os.Chdir(dir)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+
+	err = os.Mkdir(filepath.Join(dir, "procedures"), 0755)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "procedures", "patch.md"), []byte(procedure+"---\nPatch everything.\n"), 0644)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "comply.yml"), []byte("name: test\ntickets:\n  local: {}\n"), 0644)
+	}
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	registerOnce.Do(local.Register)
+	return dir, cleanup
+}
+
+func TestTriggerScheduledIdempotent(t *testing.T) {
+	dir, cleanup := testProject(t, "id: patch\nname: Apply Patches\ncron: \"0 0 0 1 * *\"\n")
+	defer cleanup()
+
+	countTickets := func() int {
+		files, err := ioutil.ReadDir(filepath.Join(dir, "tickets"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(files)
+	}
+
+	plans, err := PlanScheduled(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || plans[0].Action != ActionCreate {
+		t.Fatalf("expected the dry run to plan a ticket, got %+v", plans)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "tickets")); !os.IsNotExist(err) {
+		t.Fatal("the dry run must not create tickets")
+	}
+
+	for i := 0; i < 2; i++ {
+		err = TriggerScheduled()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := countTickets(); n != 1 {
+		t.Fatalf("expected a single ticket after repeated runs, found %d", n)
+	}
+	plans, err = PlanScheduled(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plans[0].Action != ActionNone || plans[0].LastTicket == "" {
+		t.Errorf("expected nothing left to do, got %+v", plans[0])
+	}
+
+	// a stale cache must not produce a duplicate either
+	err = os.Remove(filepath.Join(dir, ".comply", "comply.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = TriggerScheduled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countTickets(); n != 1 {
+		t.Fatalf("expected a single ticket with an empty cache, found %d", n)
+	}
+
+	tickets, err := model.ReadTickets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 1 || tickets[0].OccurrenceID() == "" || tickets[0].ProcedureID() != "patch" {
+		t.Errorf("expected the scheduled ticket to be cached with its occurrence, got %+v", tickets)
+	}
+}
+
+func TestPlanScheduledForFetched(t *testing.T) {
+	dir, cleanup := testProject(t, "id: patch\nname: Apply Patches\ncron: \"0 0 0 1 * *\"\n")
+	defer cleanup()
+
+	err := TriggerScheduled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Remove(filepath.Join(dir, ".comply", "comply.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tickets, err := Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plans, err := PlanScheduledFor(tickets, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || plans[0].Action != ActionNone || plans[0].LastTicket == "" {
+		t.Errorf("expected the fetched ticket to be planned for, got %+v", plans)
+	}
+
+	cached, err := model.ReadTickets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cached) != 0 {
+		t.Errorf("planning from fetched tickets must not cache them, found %d", len(cached))
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	_, cleanup := testProject(t, "id: patch\nname: Apply Patches\ncron: \"@monthly\"\ncatchUp: all\n")
+	defer cleanup()
+
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	created := now.AddDate(0, -3, 0)
+	last := &model.Ticket{ID: "1", State: model.Closed, Procedure: "patch", CreatedAt: &created}
+
+	procedures, err := model.ReadProcedures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := procedures[0]
+
+	plans, err := planFor(p, []*model.Ticket{last}, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, plan := range plans {
+		if plan.Action == ActionCreate {
+			keys = append(keys, plan.Occurrence)
+		}
+	}
+	if len(keys) != 3 || keys[0] != "patch@2026-08" || keys[2] != "patch@2026-10" {
+		t.Errorf("expected every missed month to be planned, got %v", keys)
+	}
+
+	p.CatchUp = config.CatchUpLatest
+	plans, err = planFor(p, []*model.Ticket{last}, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || plans[0].Action != ActionCreate || plans[0].Occurrence != "patch@2026-10" {
+		t.Errorf("expected only the latest occurrence, got %+v", plans)
+	}
+
+	p.CatchUp = config.CatchUpNone
+	lastRun := now.Add(-24 * time.Hour)
+	plans, err = planFor(p, []*model.Ticket{last}, now, &lastRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || plans[0].Action != ActionNone {
+		t.Errorf("expected an occurrence missed before the last run to be skipped, got %+v", plans)
+	}
+	lastRun = time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	plans, err = planFor(p, []*model.Ticket{last}, now, &lastRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || plans[0].Action != ActionCreate {
+		t.Errorf("expected an occurrence due since the last run to be created, got %+v", plans)
+	}
+	plans, err = planFor(p, []*model.Ticket{last}, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || plans[0].Action != ActionNone || plans[0].Reason != "scheduler has not run yet (catchUp: none)" {
+		t.Errorf("expected nothing to be created before the scheduler first runs, got %+v", plans)
+	}
+}
+
+func TestCatchUpOpenTicket(t *testing.T) {
+	_, cleanup := testProject(t, "id: patch\nname: Apply Patches\ncron: \"@monthly\"\ncatchUp: all\nopenTickets: skip\n")
+	defer cleanup()
+
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	created := now.AddDate(0, -3, 0)
+	last := &model.Ticket{ID: "1", State: model.Open, Procedure: "patch", CreatedAt: &created}
+
+	procedures, err := model.ReadProcedures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := procedures[0]
+
+	plans, err := planFor(p, []*model.Ticket{last}, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 3 {
+		t.Fatalf("expected every missed month to be planned, got %+v", plans)
+	}
+	for _, plan := range plans {
+		if plan.Action != ActionSkip {
+			t.Errorf("expected %s to follow the open ticket policy, got %s", plan.Occurrence, plan.Action)
+		}
+	}
+
+	p.OpenTickets = config.OpenTicketCreate
+	plans, err = planFor(p, []*model.Ticket{last}, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, plan := range plans {
+		if plan.Action != ActionCreate {
+			t.Errorf("expected %s to be created (policy: create), got %s", plan.Occurrence, plan.Action)
+		}
+	}
+}
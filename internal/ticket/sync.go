@@ -0,0 +1,147 @@
+package ticket
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+// fullSyncInterval bounds how long deleted or relabeled tickets linger in the
+// cache: incremental syncs only see tickets that still carry the comply label.
+const fullSyncInterval = 24 * time.Hour
+
+// syncOverlap re-fetches tickets updated shortly before the previous sync,
+// covering clock skew between comply and the ticket system.
+const syncOverlap = 5 * time.Minute
+
+// cache writes tickets to the local cache in one transaction, along with the
+// progress of their checklists.
+func cache(tickets ...*model.Ticket) error {
+	return model.DB().Update(func(tx *model.Tx) error {
+		for _, t := range tickets {
+			t.Checklist = model.ParseChecklist(t.Body)
+			err := tx.Write("tickets", t.Key(), t)
+			if err != nil {
+				return err
+			}
+			if t.Source != "" {
+				// drop the entry cached under the bare ID before tickets carried their source
+				_ = tx.Delete("tickets", t.ID)
+			}
+		}
+		return nil
+	})
+}
+
+// Sync fetches comply tickets from every configured ticket system into the
+// local cache. Ticket systems last fully synced within a day only send tickets
+// updated since their previous sync; otherwise, or when full is set, every
+// ticket is fetched and cached tickets that are gone are removed.
+func Sync(full bool) error {
+	systems, err := config.Config().TicketSystems()
+	if err != nil {
+		return errors.Wrap(err, "error in ticket system configuration")
+	}
+
+	if _, err := model.ReadTickets(); err != nil {
+		// rebuild rather than patch a cache that can't be read
+		fmt.Fprintf(os.Stderr, "warning: %v; fetching all tickets\n", err)
+		full = true
+	}
+
+	for _, ts := range systems {
+		err = syncSystem(ts, full)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fetch returns the comply tickets of every configured ticket system, leaving
+// the local cache and the evidence archive untouched.
+func Fetch() ([]*model.Ticket, error) {
+	systems, err := config.Config().TicketSystems()
+	if err != nil {
+		return nil, errors.Wrap(err, "error in ticket system configuration")
+	}
+
+	var all []*model.Ticket
+	for _, ts := range systems {
+		tickets, err := model.GetPlugin(model.TicketSystem(ts)).FindByTagName("comply")
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to fetch %s tickets", ts)
+		}
+		for _, t := range tickets {
+			t.Source = ts
+		}
+		all = append(all, tickets...)
+	}
+	return all, nil
+}
+
+func syncSystem(ts string, full bool) error {
+	tp := model.GetPlugin(model.TicketSystem(ts))
+	state, err := model.ReadSyncState(ts)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		state = &model.SyncState{Source: ts}
+	}
+
+	start := time.Now().UTC()
+	inc, ok := tp.(model.IncrementalFinder)
+	incremental := ok && !full && !state.LastFullSync.IsZero() && start.Sub(state.LastFullSync) < fullSyncInterval
+
+	var tickets []*model.Ticket
+	if incremental {
+		tickets, err = inc.FindByTagNameSince("comply", state.LastSync.Add(-syncOverlap))
+	} else {
+		tickets, err = tp.FindByTagName("comply")
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unable to sync %s tickets", ts)
+	}
+
+	keep := make(map[string]bool)
+	for _, t := range tickets {
+		t.Source = ts
+		keep[t.Key()] = true
+	}
+	err = cache(tickets...)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tickets {
+		archived, err := Harvest(t, tp)
+		if err != nil {
+			// evidence can be harvested on a later sync; don't hold up the rest
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		if archived {
+			fmt.Printf("archived evidence for %s ticket %s\n", ts, t.ID)
+		}
+	}
+
+	state.LastSync = start
+	state.Full = !incremental
+	state.Fetched = len(tickets)
+	state.Pruned = 0
+	if !incremental {
+		state.LastFullSync = start
+		state.Pruned, err = model.PruneTickets(ts, keep)
+		if err != nil {
+			return err
+		}
+		if state.Pruned > 0 {
+			fmt.Printf("removed %d cached %s tickets that were deleted or no longer labeled comply\n", state.Pruned, ts)
+		}
+	}
+	return model.SaveSyncState(state)
+}
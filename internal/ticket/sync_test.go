@@ -0,0 +1,84 @@
+package ticket
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestSync(t *testing.T) {
+	dir, cleanup := testProject(t, "id: patch\nname: Apply Patches\n")
+	defer cleanup()
+
+	procedures, err := model.ReadProcedures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		_, _, err = Create(procedures[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cached := func() int {
+		tickets, err := model.ReadTickets()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(tickets)
+	}
+
+	err = Sync(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := model.ReadSyncState(config.Local)
+	if err != nil || state == nil {
+		t.Fatalf("expected sync state, got %v (%v)", state, err)
+	}
+	if !state.Full || state.Fetched != 2 || cached() != 2 {
+		t.Fatalf("expected the first sync to fetch every ticket, got %+v", state)
+	}
+
+	err = os.Remove(filepath.Join(dir, "tickets", "2.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Sync(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _ = model.ReadSyncState(config.Local)
+	if state.Full || cached() != 2 {
+		t.Fatalf("expected an incremental sync to leave the cache alone, got %+v", state)
+	}
+
+	err = Sync(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _ = model.ReadSyncState(config.Local)
+	if !state.Full || state.Pruned != 1 || cached() != 1 {
+		t.Fatalf("expected a full sync to remove the deleted ticket, got %+v", state)
+	}
+
+	// a corrupt cache is reported, then rebuilt by the next sync
+	err = model.DB().Write("tickets", "local-9", map[string]interface{}{"ID": 9, "Source": "local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = model.ReadTickets(); err == nil {
+		t.Fatal("expected a corrupt cache to be reported")
+	}
+	err = Sync(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached() != 1 {
+		t.Fatal("expected the corrupt entry to be removed")
+	}
+}
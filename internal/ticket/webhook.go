@@ -0,0 +1,134 @@
+package ticket
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+// maxWebhookBody bounds the size of a change notification.
+const maxWebhookBody = 10 << 20
+
+// webhookMu serializes cache updates from concurrent notifications.
+var webhookMu sync.Mutex
+
+// WebhookHandler serves /hooks/<ticket system>, updating the cached copy of a
+// ticket as soon as its ticket system reports a change. Notifications only
+// identify the ticket; its current state is fetched from the ticket system,
+// so it is cached exactly as comply sync would cache it.
+func WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "webhooks must be POSTed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ts := strings.Trim(strings.TrimPrefix(r.URL.Path, "/hooks/"), "/")
+		wr, tp, err := webhookReceiver(ts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "unable to read request body", http.StatusBadRequest)
+			return
+		}
+
+		ID, err := wr.Webhook(r, body)
+		if errors.Cause(err) == model.ErrWebhookRejected {
+			log.Printf("rejected %s webhook from %s: %v", ts, r.RemoteAddr, err)
+			http.Error(w, model.ErrWebhookRejected.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ID == "" {
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprintln(w, "ignored")
+			return
+		}
+
+		result, err := applyWebhook(ts, tp, ID)
+		if err != nil {
+			log.Printf("%s webhook for ticket %s: %v", ts, ID, err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintln(w, result)
+	})
+}
+
+// webhookReceiver returns the configured ticket system named by a webhook path.
+func webhookReceiver(ts string) (model.WebhookReceiver, model.TicketPlugin, error) {
+	systems, err := config.Config().TicketSystems()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, configured := range systems {
+		if configured != ts {
+			continue
+		}
+		if err := model.ConfigurationError(model.TicketSystem(ts)); err != nil {
+			return nil, nil, err
+		}
+		tp := model.GetPlugin(model.TicketSystem(ts))
+		wr, ok := tp.(model.WebhookReceiver)
+		if !ok {
+			return nil, nil, fmt.Errorf("the %s ticket system does not support webhooks", ts)
+		}
+		return wr, tp, nil
+	}
+	return nil, nil, fmt.Errorf("no %q ticket system is configured", ts)
+}
+
+// applyWebhook refreshes the cached copy of a ticket, removing it if it was
+// deleted or is no longer labeled comply.
+func applyWebhook(ts string, tp model.TicketPlugin, ID string) (string, error) {
+	t, err := tp.Get(ID)
+	if err != nil {
+		return "", err
+	}
+
+	webhookMu.Lock()
+	defer webhookMu.Unlock()
+
+	if t == nil || !t.Bool("comply") {
+		key := (&model.Ticket{ID: ID, Source: ts}).Key()
+		err = model.DB().Delete("tickets", key)
+		if os.IsNotExist(err) {
+			return "ignored", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return "removed", nil
+	}
+
+	t.Source = ts
+	err = cache(t)
+	if err != nil {
+		return "", err
+	}
+	archived, err := Harvest(t, tp)
+	if err != nil {
+		// comply sync harvests whatever is missed here
+		log.Printf("warning: %v", err)
+	}
+	if archived {
+		log.Printf("archived evidence for %s ticket %s", ts, t.ID)
+	}
+	return "updated", nil
+}
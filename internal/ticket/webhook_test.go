@@ -0,0 +1,45 @@
+package ticket
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/strongdm/comply/internal/config"
+	"github.com/strongdm/comply/internal/model"
+)
+
+func TestApplyWebhook(t *testing.T) {
+	dir, cleanup := testProject(t, "id: patch\nname: Apply Patches\n")
+	defer cleanup()
+
+	procedures, err := model.ReadProcedures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, tp, err := Create(procedures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := applyWebhook(config.Local, tp, created.ID)
+	if err != nil || result != "updated" {
+		t.Fatalf("expected the ticket to be cached, got %q (%v)", result, err)
+	}
+	tickets, err := model.ReadTicketsForProcedure("patch")
+	if err != nil || len(tickets) != 1 || tickets[0].Source != config.Local {
+		t.Fatalf("expected the cached ticket, got %+v (%v)", tickets, err)
+	}
+
+	err = os.Remove(filepath.Join(dir, "tickets", created.ID+".md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = applyWebhook(config.Local, tp, created.ID)
+	if err != nil || result != "removed" {
+		t.Fatalf("expected the deleted ticket to be removed, got %q (%v)", result, err)
+	}
+	if result, _ = applyWebhook(config.Local, tp, created.ID); result != "ignored" {
+		t.Errorf("expected a repeated notification to be ignored, got %q", result)
+	}
+}
@@ -0,0 +1,135 @@
+package translate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/model"
+)
+
+// memoryCollection holds translated segments in the local cache.
+const memoryCollection = "translations"
+
+type memoryEntry struct {
+	Source       string    `json:"source"`
+	Model        string    `json:"model,omitempty"`
+	Language     string    `json:"language"`
+	Translation  string    `json:"translation"`
+	TranslatedAt time.Time `json:"translatedAt"`
+}
+
+// Memory is a translation memory: a Provider that remembers every segment it
+// translates, keyed by the model, target language and the segment's hash, so
+// only new or edited segments are sent to the underlying provider. Switching
+// models translates everything again.
+type Memory struct {
+	Provider Provider
+	// Model identifies the provider and model, e.g. anthropic/claude-3-5-sonnet-20241022
+	Model string
+	// Refresh translates every segment again, replacing what was remembered
+	Refresh bool
+
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+// NewMemory wraps a new provider in a translation memory.
+func NewMemory(providerType, model string) (*Memory, error) {
+	apiKey, err := getAPIKey(providerType)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := NewProvider(providerType, apiKey, model)
+	if err != nil {
+		return nil, err
+	}
+	return &Memory{Provider: provider, Model: providerModel(providerType, provider)}, nil
+}
+
+// providerModel names the model a provider translates with, after defaults are applied.
+func providerModel(providerType string, provider Provider) string {
+	var model string
+	switch p := provider.(type) {
+	case *OpenAIProvider:
+		model = p.Model
+	case *AnthropicProvider:
+		model = p.Model
+	case *OllamaProvider:
+		model = p.Model
+	}
+	return strings.ToLower(providerType) + "/" + model
+}
+
+func segmentKey(model, segment, targetLang string) string {
+	sum := sha256.Sum256([]byte(segment))
+	return model + ":" + targetLang + ":" + hex.EncodeToString(sum[:])
+}
+
+// Translate returns the remembered translation of a segment, asking the
+// provider only for segments it has not seen.
+func (m *Memory) Translate(segment, sourceLang, targetLang string) (string, error) {
+	key := segmentKey(m.Model, segment, targetLang)
+	if !m.Refresh {
+		var entry memoryEntry
+		err := model.DB().Read(memoryCollection, key, &entry)
+		if err == nil && entry.Source == segment {
+			m.count(true)
+			return entry.Translation, nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", errors.Wrap(err, "unable to read translation memory")
+		}
+	}
+
+	translated, err := m.Provider.Translate(segment, sourceLang, targetLang)
+	if err != nil {
+		return "", err
+	}
+	m.count(false)
+	// remember each segment as soon as it is paid for, so an interrupted run loses nothing
+	err = model.DB().Write(memoryCollection, key, memoryEntry{
+		Source:       segment,
+		Model:        m.Model,
+		Language:     targetLang,
+		Translation:  translated,
+		TranslatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to update translation memory")
+	}
+	return translated, nil
+}
+
+func (m *Memory) count(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+// Stats returns how many segments were reused from memory and how many were sent to the provider.
+func (m *Memory) Stats() (hits, misses int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hits, m.misses
+}
+
+// Report summarizes the memory's hit rate.
+func (m *Memory) Report() string {
+	hits, misses := m.Stats()
+	total := hits + misses
+	if total == 0 {
+		return "Translation memory: no segments translated"
+	}
+	return fmt.Sprintf("Translation memory: reused %d of %d segments (%d%% hit rate), sent %d to the provider", hits, total, hits*100/total, misses)
+}
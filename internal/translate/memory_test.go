@@ -0,0 +1,118 @@
+package translate
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/comply/internal/config"
+)
+
+// upperProvider "translates" by upper-casing, recording what it was sent.
+type upperProvider struct {
+	sent []string
+}
+
+func (p *upperProvider) Translate(text, sourceLang, targetLang string) (string, error) {
+	p.sent = append(p.sent, text)
+	return strings.ToUpper(text), nil
+}
+
+const policy = `name: Access Policy
+acronym: AP
+majorRevisions:
+  - date: Jun 1 2018
+    comment: "Initial document: first draft"
+---
+# Purpose
+
+Access is granted on request.
+
+` + "```" + `
+keep this
+
+as is
+` + "```" + `
+
+Access is revoked on termination.
+`
+
+func TestMemory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comply-translate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetProjectRoot(dir)
+	defer config.SetProjectRoot("")
+
+	provider := &upperProvider{}
+	memory := &Memory{Provider: provider}
+	translated, err := TranslateTemplate(policy, "en", "de", memory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"name: ACCESS POLICY\nacronym: AP\n",
+		"    comment: 'INITIAL DOCUMENT: FIRST DRAFT'\n---\n",
+		"# PURPOSE\n\nACCESS IS GRANTED ON REQUEST.\n",
+		"```\nkeep this\n\nas is\n```",
+		"ACCESS IS REVOKED ON TERMINATION.\n",
+	} {
+		if !strings.Contains(translated, want) {
+			t.Errorf("expected %q in\n%s", want, translated)
+		}
+	}
+	if hits, misses := memory.Stats(); hits != 0 || misses != 5 {
+		t.Fatalf("expected 5 segments translated, got %d hits and %d misses", hits, misses)
+	}
+
+	// editing one paragraph only sends that paragraph
+	provider.sent = nil
+	memory = &Memory{Provider: provider}
+	edited := strings.Replace(policy, "granted on request", "granted on approved request", 1)
+	_, err = TranslateTemplate(edited, "en", "de", memory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.sent) != 1 || provider.sent[0] != "Access is granted on approved request." {
+		t.Errorf("expected only the edited paragraph to be sent, got %q", provider.sent)
+	}
+	if hits, misses := memory.Stats(); hits != 4 || misses != 1 {
+		t.Errorf("expected 4 hits and 1 miss, got %d and %d", hits, misses)
+	}
+	if r := memory.Report(); !strings.Contains(r, "reused 4 of 5 segments (80% hit rate)") {
+		t.Errorf("unexpected report %q", r)
+	}
+
+	// other languages are translated separately
+	provider.sent = nil
+	_, err = TranslateTemplate(edited, "en", "fr", &Memory{Provider: provider})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.sent) != 5 {
+		t.Errorf("expected every segment to be sent for a new language, got %d", len(provider.sent))
+	}
+
+	// as are other models
+	provider.sent = nil
+	_, err = TranslateTemplate(edited, "en", "fr", &Memory{Provider: provider, Model: "ollama/llama3:8b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.sent) != 5 {
+		t.Errorf("expected every segment to be sent for a new model, got %d", len(provider.sent))
+	}
+}
+
+func TestProviderModel(t *testing.T) {
+	provider, err := NewProvider("Anthropic", "key", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model := providerModel("Anthropic", provider); model != "anthropic/claude-3-5-sonnet-20241022" {
+		t.Errorf("expected the default model to be named, got %q", model)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/strongdm/comply/internal/config"
+	"gopkg.in/yaml.v2"
 )
 
 // Provider represents a translation service provider
@@ -69,92 +71,75 @@ func NewProvider(providerType, apiKey, model string) (Provider, error) {
 	}
 }
 
-// TranslateTemplate translates a template file while preserving all structure
-func TranslateTemplate(content, sourceLang, targetLang, providerType, model string) (string, error) {
-	provider, err := NewProvider(providerType, getAPIKey(providerType), model)
+// TranslateTemplate translates a policy, procedure or narrative template: the
+// name and revision comments in its YAML metadata and each paragraph of its
+// markdown body, leaving the rest of its structure untouched.
+func TranslateTemplate(content, sourceLang, targetLang string, provider Provider) (string, error) {
+	meta, body, ok := splitTemplate(content)
+	if !ok {
+		return TranslateDocument(content, sourceLang, targetLang, provider)
+	}
+
+	meta, err := translateMetadata(meta, sourceLang, targetLang, provider)
 	if err != nil {
 		return "", err
 	}
-
-	// Use direct translation approach with structured prompt
-	return TranslateTemplateDirect(content, sourceLang, targetLang, provider)
-}
-
-// TranslateTemplateDirect uses a single LLM call with a structured prompt to translate the entire document
-func TranslateTemplateDirect(content, sourceLang, targetLang string, provider Provider) (string, error) {
-	// Create structured prompt based on translate-prompt.md
-	prompt := fmt.Sprintf(`You are a professional translator. Your task is to translate a markdown template document while preserving its exact structure and format.
-
-**Instructions:**
-1. Translate the YAML metadata fields: `+"`name`"+` and `+"`comment`"+` values to %s
-2. Translate all content after the `+"`---`"+` separator to %s
-3. Keep all other YAML fields unchanged (acronym, satisfies, dates, etc.)
-4. Preserve all markdown formatting, template variables (like {{.Name}}), and document structure
-5. Return only the translated content with no additional comments or explanations
-6. Maintain the exact same line breaks and spacing as the original
-
-**Input document:**
-%s
-
-**Target language:** %s`, targetLang, targetLang, content, targetLang)
-
-	// Call the provider with the structured prompt
-	result, err := provider.Translate(prompt, sourceLang, targetLang)
+	body, err = TranslateDocument(body, sourceLang, targetLang, provider)
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to translate template using direct approach")
+		return "", err
 	}
-
-	// Light cleanup - only remove obvious artifacts while preserving structure
-	cleaned := cleanDirectTranslationResponse(result, content)
-
-	return cleaned, nil
+	return meta + body, nil
 }
 
-// cleanDirectTranslationResponse performs minimal cleaning while preserving document structure
-func cleanDirectTranslationResponse(response, original string) string {
-	// Remove common prefixes that might appear before the document
-	prefixPatterns := []string{
-		`(?i)^\s*here\s+is\s+the\s+translated\s+document:?\s*\n?`,
-		`(?i)^\s*translated\s+document:?\s*\n?`,
-		`(?i)^\s*result:?\s*\n?`,
-		`(?i)^\s*translation:?\s*\n?`,
-	}
-
-	result := response
-	for _, pattern := range prefixPatterns {
-		result = regexp.MustCompile(pattern).ReplaceAllString(result, "")
+// splitTemplate separates a template's YAML metadata, up to and including the
+// --- line that ends it, from its markdown body.
+func splitTemplate(content string) (string, string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "---" {
+			continue
+		}
+		if i == 0 {
+			// metadata opened with its own --- line
+			continue
+		}
+		return strings.Join(lines[:i+1], ""), strings.Join(lines[i+1:], ""), true
 	}
+	return "", content, false
+}
 
-	// If result doesn't start with expected YAML frontmatter or content, try to extract it
-	lines := strings.Split(result, "\n")
-	startIndex := -1
+// translatableField matches the metadata values shown to readers.
+var translatableField = regexp.MustCompile(`^(\s*(?:-\s+)?(?:name|comment):[ \t]*)(\S.*?)[ \t]*$`)
 
-	// Look for the start of meaningful content
+// translateMetadata translates the name and revision comments of template metadata.
+func translateMetadata(meta, sourceLang, targetLang string, provider Provider) (string, error) {
+	lines := strings.Split(meta, "\n")
 	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		// Check if line looks like YAML field or --- delimiter or markdown header
-		if trimmed == "---" ||
-			regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*:\s*`).MatchString(trimmed) ||
-			strings.HasPrefix(trimmed, "#") {
-			startIndex = i
-			break
+		m := translatableField.FindStringSubmatch(line)
+		if m == nil {
+			continue
 		}
+		var value string
+		if yaml.Unmarshal([]byte(m[2]), &value) != nil || value == "" {
+			continue
+		}
+		translated, err := provider.Translate(value, sourceLang, targetLang)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to translate %q", value)
+		}
+		// quote the translation if YAML needs it
+		quoted, err := yaml.Marshal(strings.Join(strings.Fields(translated), " "))
+		if err != nil {
+			return "", err
+		}
+		lines[i] = m[1] + strings.TrimSuffix(string(quoted), "\n")
 	}
-
-	if startIndex > 0 {
-		result = strings.Join(lines[startIndex:], "\n")
-	}
-
-	return strings.TrimSpace(result)
+	return strings.Join(lines, "\n"), nil
 }
 
-// TranslateDocument translates a compliance document while preserving structure
-func TranslateDocument(content, sourceLang, targetLang, providerType, model string) (string, error) {
-	provider, err := NewProvider(providerType, getAPIKey(providerType), model)
-	if err != nil {
-		return "", err
-	}
-
+// TranslateDocument translates a markdown document paragraph by paragraph,
+// keeping metadata, tables and code blocks as they are.
+func TranslateDocument(content, sourceLang, targetLang string, provider Provider) (string, error) {
 	// Extract metadata and content sections
 	sections := extractSections(content)
 
@@ -382,8 +367,13 @@ func extractSections(content string) []string {
 	var sections []string
 	var currentSection []string
 
+	inFence := false
 	for _, line := range lines {
-		if line == "" {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		// blank lines inside a code block don't end it
+		if line == "" && !inFence {
 			if len(currentSection) > 0 {
 				sections = append(sections, strings.Join(currentSection, "\n"))
 				currentSection = []string{}
@@ -438,15 +428,25 @@ func shouldTranslate(section string) bool {
 	return true
 }
 
-// getAPIKey retrieves API key from environment
-func getAPIKey(provider string) string {
+// getAPIKey resolves translation.apiKey from comply.yml, falling back to the provider's environment variable
+func getAPIKey(provider string) (string, error) {
+	if config.Exists() {
+		if t := config.Config().Translation; t != nil && t.APIKey != "" {
+			key, err := config.ResolveSecret(t.APIKey)
+			if err != nil {
+				return "", errors.Wrap(err, "unable to resolve translation.apiKey")
+			}
+			return key, nil
+		}
+	}
+
 	switch strings.ToLower(provider) {
 	case "openai":
-		return os.Getenv("OPENAI_API_KEY")
+		return os.Getenv("OPENAI_API_KEY"), nil
 	case "anthropic":
-		return os.Getenv("ANTHROPIC_API_KEY")
+		return os.Getenv("ANTHROPIC_API_KEY"), nil
 	default:
-		return ""
+		return "", nil
 	}
 }
 